@@ -13,6 +13,7 @@ import (
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -31,6 +32,7 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/redisqueue"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/safemode"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/selfupdate"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/store"
 	_ "github.com/router-for-me/CLIProxyAPI/v7/internal/translator"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/tui"
@@ -84,6 +86,23 @@ func shouldEnableExampleAPIKeySafeMode(cfg *config.Config, commandMode, tuiMode,
 func main() {
 	fmt.Printf("CLIProxyAPI Version: %s, Commit: %s, BuiltAt: %s\n", buildinfo.Version, buildinfo.Commit, buildinfo.BuildDate)
 
+	// "service install|uninstall|start|stop" manages the OS service registration and exits.
+	if len(os.Args) > 1 && os.Args[1] == "service" {
+		if errService := cmd.RunServiceCommand(os.Args[2:], DefaultConfigPath, os.Stdout); errService != nil {
+			log.Errorf("service command failed: %v", errService)
+			os.Exit(1)
+		}
+		return
+	}
+	// "update [-check] [-channel beta]" installs the newest release and exits.
+	if len(os.Args) > 1 && os.Args[1] == "update" {
+		if errUpdate := cmd.RunUpdateCommand(os.Args[2:], DefaultConfigPath, os.Stdout); errUpdate != nil {
+			log.Errorf("update failed: %v", errUpdate)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Command-line flags to control the application's behavior.
 	var codexLogin bool
 	var copilotLogin bool
@@ -115,6 +134,9 @@ func main() {
 	var authHealthAuthDir string
 	var authHealthOutput string
 	var authHealthTimeout int
+	var configCheck bool
+	var readOnly bool
+	var writablePath string
 
 	// Define command-line flags for different operation modes.
 	flag.BoolVar(&codexLogin, "codex-login", false, "Login to Codex using OAuth")
@@ -147,6 +169,9 @@ func main() {
 	flag.StringVar(&authHealthAuthDir, "auth-health-auth-dir", "", "Auth directory to validate for -auth-health-check")
 	flag.StringVar(&authHealthOutput, "auth-health-output", "", "TSV report path for -auth-health-check")
 	flag.IntVar(&authHealthTimeout, "auth-health-timeout", 90, "Per-auth validation timeout in seconds")
+	flag.BoolVar(&configCheck, "config-check", false, "Validate the config file and exit (non-zero on errors)")
+	flag.BoolVar(&readOnly, "read-only", false, "Run on a read-only root filesystem, writing only under the writable path (env READ_ONLY_FS)")
+	flag.StringVar(&writablePath, "writable-path", "", "Directory for logs, management assets and store workspaces (env WRITABLE_PATH)")
 
 	flag.CommandLine.Usage = func() {
 		out := flag.CommandLine.Output()
@@ -210,6 +235,8 @@ func main() {
 		objectStoreAccess    string
 		objectStoreSecret    string
 		objectStoreBucket    string
+		objectStoreRegion    string
+		objectStorePrefix    string
 		objectStoreLocalPath string
 		objectStoreInst      *store.ObjectTokenStore
 	)
@@ -237,6 +264,33 @@ func main() {
 		}
 		return "", false
 	}
+	if configCheck {
+		checkPath := configPath
+		if strings.TrimSpace(checkPath) == "" {
+			checkPath = filepath.Join(wd, "config.yaml")
+		}
+		if errCheck := cmd.DoConfigCheck(checkPath); errCheck != nil {
+			fmt.Fprintln(os.Stderr, errCheck)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if !readOnly {
+		if value, ok := lookupEnv("READ_ONLY_FS", "read_only_fs"); ok {
+			readOnly, _ = strconv.ParseBool(value)
+		}
+	}
+	if readOnly {
+		base, errReadOnly := cmd.PrepareReadOnlyRuntime(writablePath)
+		if errReadOnly != nil {
+			log.Errorf("%v", errReadOnly)
+			return
+		}
+		log.Infof("read-only filesystem mode: writing runtime state under %s", base)
+	} else if strings.TrimSpace(writablePath) != "" {
+		_ = os.Setenv("WRITABLE_PATH", strings.TrimSpace(writablePath))
+	}
 	writableBase := util.WritablePath()
 
 	if strings.TrimSpace(homeJWT) == "" {
@@ -294,6 +348,12 @@ func main() {
 	if value, ok := lookupEnv("OBJECTSTORE_BUCKET", "objectstore_bucket"); ok {
 		objectStoreBucket = value
 	}
+	if value, ok := lookupEnv("OBJECTSTORE_REGION", "objectstore_region"); ok {
+		objectStoreRegion = value
+	}
+	if value, ok := lookupEnv("OBJECTSTORE_PREFIX", "objectstore_prefix"); ok {
+		objectStorePrefix = value
+	}
 	if value, ok := lookupEnv("OBJECTSTORE_LOCAL_PATH", "objectstore_local_path"); ok {
 		objectStoreLocalPath = value
 	}
@@ -441,6 +501,8 @@ func main() {
 			Bucket:    objectStoreBucket,
 			AccessKey: objectStoreAccess,
 			SecretKey: objectStoreSecret,
+			Region:    objectStoreRegion,
+			Prefix:    objectStorePrefix,
 			LocalRoot: objectStoreRoot,
 			UseSSL:    useSSL,
 			PathStyle: true,
@@ -564,13 +626,19 @@ func main() {
 		log.Errorf("failed to configure log output: %v", err)
 		return
 	}
+	logging.ConfigureSessionTranscripts(cfg)
+	logging.ConfigureRequestRetention(cfg)
 
 	log.Infof("CLIProxyAPI Version: %s, Commit: %s, BuiltAt: %s", buildinfo.Version, buildinfo.Commit, buildinfo.BuildDate)
+	cmd.LogGOMAXPROCS()
 
 	// Set the log level based on the configuration.
 	util.SetLogLevel(cfg)
 
-	if authHealthCheck && strings.TrimSpace(authHealthAuthDir) != "" {
+	// An auth health check pointed at an explicit directory validates the files there instead of
+	// the configured token store.
+	authHealthLocalDir := authHealthCheck && strings.TrimSpace(authHealthAuthDir) != ""
+	if authHealthLocalDir {
 		cfg.AuthDir = strings.TrimSpace(authHealthAuthDir)
 	}
 	if resolvedAuthDir, errResolveAuthDir := util.ResolveAuthDir(cfg.AuthDir); errResolveAuthDir != nil {
@@ -579,7 +647,34 @@ func main() {
 	} else {
 		cfg.AuthDir = resolvedAuthDir
 	}
+
+	// Secret manager token stores are selected in config; environment-selected stores take precedence.
+	var secretStoreInst *store.SecretTokenStore
+	if cfg.TokenStore.Type != "" && cfg.TokenStore.Type != config.TokenStoreFile {
+		if usePostgresStore || useObjectStore || useGitStore {
+			log.Warnf("token-store type %q ignored: an environment-configured token store is active", cfg.TokenStore.Type)
+		} else if !authHealthLocalDir {
+			secretStoreInst, err = newSecretTokenStore(cfg.TokenStore)
+			if err != nil {
+				log.Errorf("failed to initialize %s token store: %v", cfg.TokenStore.Type, err)
+				return
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			errBootstrap := secretStoreInst.Bootstrap(ctx)
+			cancel()
+			if errBootstrap != nil {
+				log.Errorf("failed to bootstrap %s token store: %v", cfg.TokenStore.Type, errBootstrap)
+				return
+			}
+			cfg.AuthDir = secretStoreInst.AuthDir()
+			log.Infof("%s token store enabled, workspace path: %s", cfg.TokenStore.Type, cfg.AuthDir)
+		}
+	}
+	if readOnly {
+		cmd.WarnIfNotWritable("auth dir", cfg.AuthDir)
+	}
 	managementasset.SetCurrentConfig(cfg)
+	selfupdate.SetCurrentConfig(cfg)
 
 	// Create login options to be used in authentication flows.
 	options := &cmd.LoginOptions{
@@ -619,6 +714,8 @@ func main() {
 		sdkAuth.RegisterTokenStore(objectStoreInst)
 	} else if useGitStore {
 		sdkAuth.RegisterTokenStore(gitStoreInst)
+	} else if secretStoreInst != nil {
+		sdkAuth.RegisterTokenStore(secretStoreInst)
 	} else {
 		sdkAuth.RegisterTokenStore(sdkAuth.NewFileTokenStore())
 	}
@@ -775,6 +872,7 @@ func main() {
 		} else {
 			// Start the main proxy service
 			managementasset.StartAutoUpdater(context.Background(), configFilePath)
+			selfupdate.StartScheduler(context.Background())
 			misc.StartAntigravityVersionUpdater(context.Background())
 			if !localModel && !cfg.Home.Enabled {
 				registry.StartModelsUpdater(context.Background())
@@ -841,3 +939,31 @@ func loadPluginBootstrapConfig(path string) *config.Config {
 	}
 	return cfg
 }
+
+// newSecretTokenStore builds the secret manager token store selected by the token-store config.
+func newSecretTokenStore(cfg config.TokenStoreConfig) (*store.SecretTokenStore, error) {
+	switch cfg.Type {
+	case config.TokenStoreVault:
+		return store.NewVaultTokenStore(store.VaultStoreConfig{
+			Address:   cfg.Vault.Address,
+			Token:     cfg.Vault.Token,
+			Namespace: cfg.Vault.Namespace,
+			Mount:     cfg.Vault.Mount,
+			Prefix:    cfg.Vault.Prefix,
+			LocalRoot: cfg.LocalRoot,
+		})
+	case config.TokenStoreAWSSecretsManager:
+		return store.NewAWSSecretsTokenStore(store.AWSSecretsStoreConfig{
+			Region:          cfg.AWS.Region,
+			AccessKeyID:     cfg.AWS.AccessKeyID,
+			SecretAccessKey: cfg.AWS.SecretAccessKey,
+			SessionToken:    cfg.AWS.SessionToken,
+			Endpoint:        cfg.AWS.Endpoint,
+			Prefix:          cfg.AWS.Prefix,
+			KMSKeyID:        cfg.AWS.KMSKeyID,
+			LocalRoot:       cfg.LocalRoot,
+		})
+	default:
+		return nil, fmt.Errorf("unsupported token store type %q", cfg.Type)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"strings"
+	"time"
 
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
 	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
@@ -24,16 +25,18 @@ func Register(cfg *sdkconfig.SDKConfig) {
 
 	sdkaccess.RegisterProvider(
 		sdkaccess.AccessProviderTypeConfigAPIKey,
-		newProvider(sdkaccess.DefaultAccessProviderName, keys),
+		newProvider(sdkaccess.DefaultAccessProviderName, keys, keyExpiries(cfg, keys)),
 	)
 }
 
 type provider struct {
 	name string
 	keys map[string]struct{}
+	// expiries holds when keys with an api-key-metadata expiry stop being accepted.
+	expiries map[string]time.Time
 }
 
-func newProvider(name string, keys []string) *provider {
+func newProvider(name string, keys []string, expiries map[string]time.Time) *provider {
 	providerName := strings.TrimSpace(name)
 	if providerName == "" {
 		providerName = sdkaccess.DefaultAccessProviderName
@@ -42,7 +45,25 @@ func newProvider(name string, keys []string) *provider {
 	for _, key := range keys {
 		keySet[key] = struct{}{}
 	}
-	return &provider{name: providerName, keys: keySet}
+	return &provider{name: providerName, keys: keySet, expiries: expiries}
+}
+
+// keyExpiries returns the expiry of each of keys that has one.
+func keyExpiries(cfg *sdkconfig.SDKConfig, keys []string) map[string]time.Time {
+	var expiries map[string]time.Time
+	for _, key := range keys {
+		meta, ok := cfg.APIKeyMetadataFor(key)
+		if !ok {
+			continue
+		}
+		if expiry, expires := meta.Expiry(); expires {
+			if expiries == nil {
+				expiries = make(map[string]time.Time)
+			}
+			expiries[key] = expiry
+		}
+	}
+	return expiries
 }
 
 func (p *provider) Identifier() string {
@@ -90,6 +111,9 @@ func (p *provider) Authenticate(_ context.Context, r *http.Request) (*sdkaccess.
 			continue
 		}
 		if _, ok := p.keys[candidate.value]; ok {
+			if expiry, expires := p.expiries[candidate.value]; expires && !time.Now().Before(expiry) {
+				return nil, sdkaccess.NewInvalidCredentialError()
+			}
 			return &sdkaccess.Result{
 				Provider:  p.Identifier(),
 				Principal: candidate.value,
@@ -0,0 +1,39 @@
+package configaccess
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+func TestAuthenticateRejectsExpiredKeys(t *testing.T) {
+	cfg := &sdkconfig.SDKConfig{
+		APIKeys: []string{"current", "expired", "open"},
+		APIKeyMetadata: []sdkconfig.APIKeyMetadata{
+			{APIKey: "current", ExpiresAt: time.Now().Add(time.Hour).UTC().Format(time.RFC3339)},
+			{APIKey: "expired", ExpiresAt: time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)},
+			{APIKey: "open", Owner: "alice"},
+		},
+	}
+	keys := normalizeKeys(cfg.APIKeys)
+	p := newProvider(sdkaccess.DefaultAccessProviderName, keys, keyExpiries(cfg, keys))
+
+	for key, wantOK := range map[string]bool{"current": true, "expired": false, "open": true} {
+		req := httptest.NewRequest("GET", "/v1/models", nil)
+		req.Header.Set("Authorization", "Bearer "+key)
+		res, authErr := p.Authenticate(context.Background(), req)
+		if wantOK {
+			if authErr != nil || res == nil || res.Principal != key {
+				t.Errorf("key %q: result %+v, error %v; want accepted", key, res, authErr)
+			}
+			continue
+		}
+		if !sdkaccess.IsAuthErrorCode(authErr, sdkaccess.AuthErrorCodeInvalidCredential) {
+			t.Errorf("key %q: error %v, want invalid credential", key, authErr)
+		}
+	}
+}
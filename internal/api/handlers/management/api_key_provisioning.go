@@ -0,0 +1,235 @@
+package management
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+// clientAPIKeyPrefix starts every client API key issued through management.
+const clientAPIKeyPrefix = "sk-"
+
+// apiKeyMetadataView is an api-key-metadata entry as returned by management.
+type apiKeyMetadataView struct {
+	config.APIKeyMetadata
+	Expired bool `json:"expired"`
+}
+
+func newAPIKeyMetadataView(meta config.APIKeyMetadata, now time.Time) apiKeyMetadataView {
+	return apiKeyMetadataView{APIKeyMetadata: meta, Expired: meta.Expired(now)}
+}
+
+// apiKeyExpiryRequest sets the expiry of an issued key, as an RFC 3339 time or a duration from
+// now such as "720h".
+type apiKeyExpiryRequest struct {
+	ExpiresAt string `json:"expires-at"`
+	ExpiresIn string `json:"expires-in"`
+}
+
+// resolve returns the RFC 3339 expiry requested, "" when none is, and whether one is.
+func (r apiKeyExpiryRequest) resolve(now time.Time) (string, bool, error) {
+	expiresAt, expiresIn := strings.TrimSpace(r.ExpiresAt), strings.TrimSpace(r.ExpiresIn)
+	switch {
+	case expiresAt != "" && expiresIn != "":
+		return "", false, errors.New("expires-at and expires-in are mutually exclusive")
+	case expiresAt != "":
+		parsed, errParse := time.Parse(time.RFC3339, expiresAt)
+		if errParse != nil {
+			return "", false, fmt.Errorf("invalid expires-at: %w", errParse)
+		}
+		if !parsed.After(now) {
+			return "", false, errors.New("expires-at must be in the future")
+		}
+		return parsed.UTC().Format(time.RFC3339), true, nil
+	case expiresIn != "":
+		duration, errParse := time.ParseDuration(expiresIn)
+		if errParse != nil || duration <= 0 {
+			return "", false, fmt.Errorf("invalid expires-in %q", expiresIn)
+		}
+		return now.Add(duration).UTC().Format(time.RFC3339), true, nil
+	}
+	return "", false, nil
+}
+
+// GetAPIKeyMetadata lists every client API key with its owner and expiry.
+func (h *Handler) GetAPIKeyMetadata(c *gin.Context) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	now := time.Now()
+	views := make([]apiKeyMetadataView, 0, len(h.cfg.APIKeys))
+	for _, key := range h.cfg.APIKeys {
+		meta, ok := h.cfg.APIKeyMetadataFor(key)
+		if !ok {
+			meta = config.APIKeyMetadata{APIKey: strings.TrimSpace(key)}
+		}
+		views = append(views, newAPIKeyMetadataView(meta, now))
+	}
+	c.JSON(http.StatusOK, gin.H{"api-key-metadata": views})
+}
+
+// CreateAPIKey issues a new client API key, records its owner and expiry in api-key-metadata
+// and returns it. The key is accepted once the saved config is reloaded.
+func (h *Handler) CreateAPIKey(c *gin.Context) {
+	var body struct {
+		Owner       string `json:"owner"`
+		Description string `json:"description"`
+		apiKeyExpiryRequest
+	}
+	if errBind := c.ShouldBindJSON(&body); errBind != nil && !errors.Is(errBind, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	now := time.Now()
+	expiresAt, _, errExpiry := body.resolve(now)
+	if errExpiry != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errExpiry.Error()})
+		return
+	}
+	key, errKey := generateClientAPIKey()
+	if errKey != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to generate api key: %v", errKey)})
+		return
+	}
+	meta := config.APIKeyMetadata{
+		APIKey:      key,
+		Owner:       strings.TrimSpace(body.Owner),
+		Description: strings.TrimSpace(body.Description),
+		CreatedAt:   now.UTC().Format(time.RFC3339),
+		ExpiresAt:   expiresAt,
+	}
+
+	h.mu.Lock()
+	h.cfg.APIKeys = append(h.cfg.APIKeys, key)
+	h.cfg.SetAPIKeyMetadata(meta)
+	cfgSnapshot, okSnapshot := h.saveConfigAndSnapshotLocked(c)
+	h.mu.Unlock()
+	if !okSnapshot {
+		return
+	}
+	h.reloadConfigAfterManagementSaveAsync(c.Request.Context(), cfgSnapshot)
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "api-key": key, "metadata": newAPIKeyMetadataView(meta, now)})
+}
+
+// RotateAPIKey replaces a client API key with a new one that keeps its owner, description and
+// every per-key setting, such as its models, priority and rate limit. The old key is removed, or with "grace-period" stays accepted
+// for that long. The new key keeps the old expiry unless expires-at or expires-in is given.
+func (h *Handler) RotateAPIKey(c *gin.Context) {
+	var body struct {
+		APIKey      string `json:"api-key"`
+		GracePeriod string `json:"grace-period"`
+		apiKeyExpiryRequest
+	}
+	if errBind := c.ShouldBindJSON(&body); errBind != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	oldKey := strings.TrimSpace(body.APIKey)
+	if oldKey == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "api-key is required"})
+		return
+	}
+	var grace time.Duration
+	if raw := strings.TrimSpace(body.GracePeriod); raw != "" {
+		parsed, errParse := time.ParseDuration(raw)
+		if errParse != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid grace-period %q", raw)})
+			return
+		}
+		grace = parsed
+	}
+	now := time.Now()
+	expiresAt, expirySet, errExpiry := body.resolve(now)
+	if errExpiry != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errExpiry.Error()})
+		return
+	}
+	newKey, errKey := generateClientAPIKey()
+	if errKey != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to generate api key: %v", errKey)})
+		return
+	}
+
+	h.mu.Lock()
+	if !slices.ContainsFunc(h.cfg.APIKeys, func(key string) bool { return strings.TrimSpace(key) == oldKey }) {
+		h.mu.Unlock()
+		c.JSON(http.StatusNotFound, gin.H{"error": "api key not found"})
+		return
+	}
+	oldMeta, _ := h.cfg.APIKeyMetadataFor(oldKey)
+	oldMeta.APIKey = oldKey
+	meta := oldMeta
+	meta.APIKey = newKey
+	meta.CreatedAt = now.UTC().Format(time.RFC3339)
+	if expirySet {
+		meta.ExpiresAt = expiresAt
+	}
+	h.cfg.APIKeys = append(h.cfg.APIKeys, newKey)
+	h.cfg.CopyAPIKeySettings(oldKey, newKey)
+	h.cfg.SetAPIKeyMetadata(meta)
+	response := gin.H{"status": "ok", "api-key": newKey, "metadata": newAPIKeyMetadataView(meta, now)}
+	if grace > 0 {
+		graceEnd := now.Add(grace)
+		if expiry, expires := oldMeta.Expiry(); !expires || graceEnd.Before(expiry) {
+			oldMeta.ExpiresAt = graceEnd.UTC().Format(time.RFC3339)
+		}
+		h.cfg.SetAPIKeyMetadata(oldMeta)
+		response["previous-key-expires-at"] = oldMeta.ExpiresAt
+	} else {
+		h.cfg.RemoveAPIKey(oldKey)
+	}
+	cfgSnapshot, okSnapshot := h.saveConfigAndSnapshotLocked(c)
+	h.mu.Unlock()
+	if !okSnapshot {
+		return
+	}
+	h.reloadConfigAfterManagementSaveAsync(c.Request.Context(), cfgSnapshot)
+	c.JSON(http.StatusOK, response)
+}
+
+// RevokeAPIKey removes a client API key from api-keys, every per-key setting and
+// api-key-metadata.
+func (h *Handler) RevokeAPIKey(c *gin.Context) {
+	var body struct {
+		APIKey string `json:"api-key"`
+	}
+	if errBind := c.ShouldBindJSON(&body); errBind != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	key := strings.TrimSpace(body.APIKey)
+	if key == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "api-key is required"})
+		return
+	}
+
+	h.mu.Lock()
+	if !h.cfg.RemoveAPIKey(key) {
+		h.mu.Unlock()
+		c.JSON(http.StatusNotFound, gin.H{"error": "api key not found"})
+		return
+	}
+	cfgSnapshot, okSnapshot := h.saveConfigAndSnapshotLocked(c)
+	h.mu.Unlock()
+	if !okSnapshot {
+		return
+	}
+	h.reloadConfigAfterManagementSaveAsync(c.Request.Context(), cfgSnapshot)
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+func generateClientAPIKey() (string, error) {
+	buf := make([]byte, 24)
+	if _, errRead := rand.Read(buf); errRead != nil {
+		return "", errRead
+	}
+	return clientAPIKeyPrefix + hex.EncodeToString(buf), nil
+}
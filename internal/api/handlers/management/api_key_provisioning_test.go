@@ -0,0 +1,102 @@
+package management
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func postAPIKeyAction(t *testing.T, handle gin.HandlerFunc, body string) (*httptest.ResponseRecorder, map[string]any) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v0/management/api-keys/action", strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	handle(c)
+	var decoded map[string]any
+	_ = json.Unmarshal(rec.Body.Bytes(), &decoded)
+	return rec, decoded
+}
+
+func TestCreateRotateRevokeAPIKey(t *testing.T) {
+	h := &Handler{
+		cfg: &config.Config{
+			SDKConfig: config.SDKConfig{
+				APIKeys:      []string{"existing"},
+				APIKeyModels: []config.APIKeyModelScope{{APIKeys: []string{"existing"}, Models: []string{"gpt-5-mini"}}},
+			},
+			InboundRateLimit: config.InboundRateLimitConfig{
+				Enabled: true,
+				Keys:    map[string]config.InboundRateLimitRule{"existing": {RequestsPerMinute: 10, Burst: 2}},
+			},
+		},
+		configFilePath: writeTestConfigFile(t),
+	}
+
+	rec, created := postAPIKeyAction(t, h.CreateAPIKey, `{"owner":"alice","expires-in":"720h"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("create status = %d; body=%s", rec.Code, rec.Body.String())
+	}
+	key, _ := created["api-key"].(string)
+	if !strings.HasPrefix(key, clientAPIKeyPrefix) || !slices.Contains(h.cfg.APIKeys, key) {
+		t.Fatalf("created key %q not added to api-keys %v", key, h.cfg.APIKeys)
+	}
+	meta, ok := h.cfg.APIKeyMetadataFor(key)
+	if !ok || meta.Owner != "alice" || meta.ExpiresAt == "" || meta.Expired(time.Now()) {
+		t.Fatalf("created key metadata = %+v", meta)
+	}
+
+	rec, rotated := postAPIKeyAction(t, h.RotateAPIKey, `{"api-key":"existing","grace-period":"1h"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("rotate status = %d; body=%s", rec.Code, rec.Body.String())
+	}
+	newKey, _ := rotated["api-key"].(string)
+	if !h.cfg.APIKeyAllowsModel(newKey, "gpt-5-mini") || h.cfg.APIKeyAllowsModel(newKey, "gpt-5") {
+		t.Fatalf("rotated key did not keep the model scope: %+v", h.cfg.APIKeyModels)
+	}
+	if got := h.cfg.InboundRateLimit.Keys[newKey]; got.RequestsPerMinute != 10 || got.Burst != 2 {
+		t.Fatalf("rotated key rate limit = %+v, want the old key's limit", got)
+	}
+	oldMeta, ok := h.cfg.APIKeyMetadataFor("existing")
+	if !ok || oldMeta.Expired(time.Now()) || !oldMeta.Expired(time.Now().Add(2*time.Hour)) {
+		t.Fatalf("old key metadata = %+v, want it to expire after the grace period", oldMeta)
+	}
+
+	rec, _ = postAPIKeyAction(t, h.RevokeAPIKey, `{"api-key":"existing"}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("revoke status = %d; body=%s", rec.Code, rec.Body.String())
+	}
+	if slices.Contains(h.cfg.APIKeys, "existing") {
+		t.Fatalf("revoked key still in api-keys %v", h.cfg.APIKeys)
+	}
+	if _, ok := h.cfg.APIKeyMetadataFor("existing"); ok {
+		t.Fatal("revoked key metadata was kept")
+	}
+	if _, ok := h.cfg.InboundRateLimit.Keys["existing"]; ok {
+		t.Fatal("revoked key rate limit was kept")
+	}
+
+	rec, _ = postAPIKeyAction(t, h.RevokeAPIKey, `{"api-key":"existing"}`)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("second revoke status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestCreateAPIKeyRejectsPastExpiry(t *testing.T) {
+	h := &Handler{cfg: &config.Config{}, configFilePath: writeTestConfigFile(t)}
+
+	rec, _ := postAPIKeyAction(t, h.CreateAPIKey, `{"expires-at":"2020-01-01T00:00:00Z"}`)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d; body=%s", rec.Code, http.StatusBadRequest, rec.Body.String())
+	}
+	if len(h.cfg.APIKeys) != 0 {
+		t.Fatalf("api-keys = %v, want none", h.cfg.APIKeys)
+	}
+}
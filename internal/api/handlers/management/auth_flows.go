@@ -0,0 +1,427 @@
+package management
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/auth/copilot"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/auth/kimi"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/auth/qwen"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/misc"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	authFlowStatusPending   = "pending"
+	authFlowStatusCompleted = "completed"
+	authFlowStatusError     = "error"
+	authFlowStatusCanceled  = "canceled"
+
+	// authFlowRetention keeps finished flows visible long enough for a UI to poll the result.
+	authFlowRetention = 10 * time.Minute
+	// authFlowDefaultExpiry bounds flows whose provider does not report expires_in.
+	authFlowDefaultExpiry = 15 * time.Minute
+)
+
+var errAuthFlowCanceled = errors.New("auth flow canceled")
+
+// authFlow is a device-code onboarding session started through the management API.
+type authFlow struct {
+	ID                      string    `json:"id"`
+	Provider                string    `json:"provider"`
+	Status                  string    `json:"status"`
+	Error                   string    `json:"error,omitempty"`
+	UserCode                string    `json:"user_code"`
+	VerificationURI         string    `json:"verification_uri"`
+	VerificationURIComplete string    `json:"verification_uri_complete,omitempty"`
+	Interval                int       `json:"interval,omitempty"`
+	AuthFile                string    `json:"auth_file,omitempty"`
+	CreatedAt               time.Time `json:"created_at"`
+	ExpiresAt               time.Time `json:"expires_at"`
+	FinishedAt              time.Time `json:"finished_at,omitzero"`
+
+	cancel context.CancelFunc
+}
+
+type authFlowStore struct {
+	mu    sync.Mutex
+	flows map[string]*authFlow
+}
+
+var authFlows = &authFlowStore{flows: make(map[string]*authFlow)}
+
+func (s *authFlowStore) purgeLocked(now time.Time) {
+	for id, flow := range s.flows {
+		if flow.Status == authFlowStatusPending {
+			continue
+		}
+		if now.Sub(flow.FinishedAt) > authFlowRetention {
+			delete(s.flows, id)
+		}
+	}
+}
+
+func (s *authFlowStore) add(flow *authFlow) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.purgeLocked(time.Now())
+	s.flows[flow.ID] = flow
+}
+
+func (s *authFlowStore) get(id string) (authFlow, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.purgeLocked(time.Now())
+	flow, ok := s.flows[id]
+	if !ok {
+		return authFlow{}, false
+	}
+	return *flow, true
+}
+
+func (s *authFlowStore) list() []authFlow {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.purgeLocked(time.Now())
+	out := make([]authFlow, 0, len(s.flows))
+	for _, flow := range s.flows {
+		out = append(out, *flow)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out
+}
+
+// finish records the outcome of a pending flow. Flows that were already canceled keep
+// their canceled status, so a late provider result is discarded.
+func (s *authFlowStore) finish(id, status, message, authFile string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	flow, ok := s.flows[id]
+	if !ok || flow.Status != authFlowStatusPending {
+		return false
+	}
+	flow.Status = status
+	flow.Error = message
+	flow.AuthFile = authFile
+	flow.FinishedAt = time.Now()
+	if flow.cancel != nil {
+		flow.cancel()
+		flow.cancel = nil
+	}
+	return true
+}
+
+// isPending reports whether the flow still accepts a result.
+func (s *authFlowStore) isPending(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	flow, ok := s.flows[id]
+	return ok && flow.Status == authFlowStatusPending
+}
+
+// authFlowRequest is the body accepted by POST /auth-flows.
+type authFlowRequest struct {
+	Provider string `json:"provider"`
+	// AccountType selects the GitHub Copilot plan (individual, business, enterprise).
+	AccountType string `json:"account_type"`
+	// Email labels the Qwen account; Qwen tokens do not carry an identity.
+	Email string `json:"email"`
+}
+
+// authFlowDevice carries the user-facing device-code details for a started flow.
+type authFlowDevice struct {
+	UserCode                string
+	VerificationURI         string
+	VerificationURIComplete string
+	ExpiresIn               int
+	Interval                int
+}
+
+// authFlowWaitFunc blocks until the user approves the device code and returns the auth record to persist.
+type authFlowWaitFunc func(ctx context.Context) (*coreauth.Auth, error)
+
+// normalizeAuthFlowProvider maps accepted provider aliases to their canonical names.
+func normalizeAuthFlowProvider(provider string) string {
+	switch strings.ToLower(strings.TrimSpace(provider)) {
+	case "copilot", "github-copilot", "github":
+		return "copilot"
+	case "qwen":
+		return "qwen"
+	case "kimi":
+		return "kimi"
+	case "gemini", "gemini-cli":
+		return "gemini"
+	default:
+		return ""
+	}
+}
+
+// StartAuthFlow starts an asynchronous device-code login and returns the code the user must enter.
+// Poll GetAuthFlow with the returned id until status is completed or error.
+func (h *Handler) StartAuthFlow(c *gin.Context) {
+	var req authFlowRequest
+	if errBind := c.ShouldBindJSON(&req); errBind != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	if h.cfg == nil || strings.TrimSpace(h.cfg.AuthDir) == "" {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "auth directory not configured"})
+		return
+	}
+
+	provider := normalizeAuthFlowProvider(req.Provider)
+	switch provider {
+	case "":
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error":            "unsupported provider",
+			"supported_values": []string{"copilot", "qwen", "kimi"},
+		})
+		return
+	case "gemini":
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "gemini login requires a browser redirect and has no device-code flow; use /gemini-cli-auth-url",
+		})
+		return
+	}
+
+	email := strings.TrimSpace(req.Email)
+	if provider == "qwen" && email == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "email is required for qwen"})
+		return
+	}
+
+	id, errState := misc.GenerateRandomState()
+	if errState != nil {
+		log.Errorf("Failed to generate auth flow id: %v", errState)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate auth flow id"})
+		return
+	}
+
+	ctx := PopulateAuthContext(context.Background(), c)
+	var (
+		device    *authFlowDevice
+		wait      authFlowWaitFunc
+		errDevice error
+	)
+	switch provider {
+	case "copilot":
+		validation := copilot.ValidateAccountType(strings.TrimSpace(req.AccountType))
+		if strings.TrimSpace(req.AccountType) == "" {
+			validation = copilot.ValidateAccountType("individual")
+		}
+		if !validation.Valid {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":        validation.ErrorMessage,
+				"valid_values": validation.ValidValues,
+				"default":      validation.DefaultValue,
+			})
+			return
+		}
+		device, wait, errDevice = h.startCopilotDeviceFlow(ctx, validation.AccountType)
+	case "qwen":
+		device, wait, errDevice = h.startQwenDeviceFlow(ctx, email)
+	case "kimi":
+		device, wait, errDevice = h.startKimiDeviceFlow(ctx)
+	}
+	if errDevice != nil {
+		log.Errorf("Failed to start %s device flow: %v", provider, errDevice)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "failed to obtain device code"})
+		return
+	}
+
+	now := time.Now()
+	expiry := authFlowDefaultExpiry
+	if device.ExpiresIn > 0 {
+		expiry = time.Duration(device.ExpiresIn) * time.Second
+	}
+	flowCtx, cancel := context.WithDeadline(ctx, now.Add(expiry))
+	flow := &authFlow{
+		ID:                      id,
+		Provider:                provider,
+		Status:                  authFlowStatusPending,
+		UserCode:                device.UserCode,
+		VerificationURI:         device.VerificationURI,
+		VerificationURIComplete: device.VerificationURIComplete,
+		Interval:                device.Interval,
+		CreatedAt:               now,
+		ExpiresAt:               now.Add(expiry),
+		cancel:                  cancel,
+	}
+	authFlows.add(flow)
+	// Keep /get-auth-status working for UIs that already poll by state.
+	RegisterOAuthSession(id, provider)
+
+	go h.runAuthFlow(flowCtx, id, provider, wait)
+
+	c.JSON(http.StatusAccepted, *flow)
+}
+
+// runAuthFlow waits for the device code approval and persists the resulting auth record.
+func (h *Handler) runAuthFlow(ctx context.Context, id, provider string, wait authFlowWaitFunc) {
+	record, errWait := wait(ctx)
+	if errWait != nil {
+		message := fmt.Sprintf("Authentication failed: %v", errWait)
+		if errors.Is(errWait, context.DeadlineExceeded) {
+			message = "Authentication failed: device code expired"
+		}
+		if authFlows.finish(id, authFlowStatusError, message, "") {
+			SetOAuthSessionError(id, message)
+		}
+		log.Warnf("auth flow %s (%s) failed: %v", id, provider, errWait)
+		return
+	}
+	if record == nil {
+		if authFlows.finish(id, authFlowStatusError, "Authentication failed: no result returned", "") {
+			SetOAuthSessionError(id, "Authentication failed: no result returned")
+		}
+		return
+	}
+	if !authFlows.isPending(id) {
+		log.Infof("auth flow %s (%s) finished after cancellation; discarding tokens", id, provider)
+		return
+	}
+
+	savedPath, errSave := h.saveTokenRecord(ctx, record)
+	if errSave != nil {
+		log.Errorf("auth flow %s (%s): failed to save tokens: %v", id, provider, errSave)
+		if authFlows.finish(id, authFlowStatusError, "Failed to save authentication tokens", "") {
+			SetOAuthSessionError(id, "Failed to save authentication tokens")
+		}
+		return
+	}
+	authFlows.finish(id, authFlowStatusCompleted, "", record.FileName)
+	CompleteOAuthSession(id)
+	log.Infof("auth flow %s (%s) completed; token saved to %s", id, provider, savedPath)
+}
+
+func (h *Handler) startCopilotDeviceFlow(ctx context.Context, accountType copilot.AccountType) (*authFlowDevice, authFlowWaitFunc, error) {
+	copilotAuth := copilot.NewCopilotAuth(h.cfg)
+	deviceCode, err := copilotAuth.GetDeviceCode(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	device := &authFlowDevice{
+		UserCode:        deviceCode.UserCode,
+		VerificationURI: deviceCode.VerificationURI,
+		ExpiresIn:       deviceCode.ExpiresIn,
+		Interval:        deviceCode.Interval,
+	}
+	wait := func(ctx context.Context) (*coreauth.Auth, error) {
+		result, errComplete := copilotAuth.CompleteAuthWithDeviceCode(ctx, deviceCode, accountType)
+		if errComplete != nil {
+			return nil, errComplete
+		}
+		if result == nil || result.Storage == nil {
+			return nil, nil
+		}
+		label := result.Storage.Username
+		if label == "" {
+			label = result.Storage.Email
+		}
+		return &coreauth.Auth{
+			ID:       result.SuggestedFilename,
+			Provider: "copilot",
+			FileName: result.SuggestedFilename,
+			Label:    label,
+			Storage:  result.Storage,
+		}, nil
+	}
+	return device, wait, nil
+}
+
+func (h *Handler) startQwenDeviceFlow(ctx context.Context, email string) (*authFlowDevice, authFlowWaitFunc, error) {
+	qwenAuth := qwen.NewQwenAuth(h.cfg)
+	deviceFlow, err := qwenAuth.InitiateDeviceFlow(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	device := &authFlowDevice{
+		UserCode:                deviceFlow.UserCode,
+		VerificationURI:         deviceFlow.VerificationURI,
+		VerificationURIComplete: deviceFlow.VerificationURIComplete,
+		ExpiresIn:               deviceFlow.ExpiresIn,
+		Interval:                deviceFlow.Interval,
+	}
+	wait := func(ctx context.Context) (*coreauth.Auth, error) {
+		// PollForToken has no context parameter; cancellation is honored once it returns.
+		tokenData, errPoll := qwenAuth.PollForToken(deviceFlow.DeviceCode, deviceFlow.CodeVerifier)
+		if errPoll != nil {
+			return nil, errPoll
+		}
+		if errCtx := ctx.Err(); errCtx != nil {
+			return nil, errCtx
+		}
+		tokenStorage := qwenAuth.CreateTokenStorage(tokenData)
+		tokenStorage.Email = email
+		fileName := fmt.Sprintf("qwen-%s.json", email)
+		return &coreauth.Auth{
+			ID:       fileName,
+			Provider: "qwen",
+			FileName: fileName,
+			Storage:  tokenStorage,
+			Metadata: map[string]any{"email": email},
+		}, nil
+	}
+	return device, wait, nil
+}
+
+func (h *Handler) startKimiDeviceFlow(ctx context.Context) (*authFlowDevice, authFlowWaitFunc, error) {
+	kimiAuth := kimi.NewKimiAuth(h.cfg)
+	deviceFlow, err := kimiAuth.StartDeviceFlow(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	device := &authFlowDevice{
+		UserCode:                deviceFlow.UserCode,
+		VerificationURI:         deviceFlow.VerificationURI,
+		VerificationURIComplete: deviceFlow.VerificationURIComplete,
+		ExpiresIn:               deviceFlow.ExpiresIn,
+		Interval:                deviceFlow.Interval,
+	}
+	wait := func(ctx context.Context) (*coreauth.Auth, error) {
+		authBundle, errWait := kimiAuth.WaitForAuthorization(ctx, deviceFlow)
+		if errWait != nil {
+			return nil, errWait
+		}
+		return kimiAuthRecord(kimiAuth, authBundle), nil
+	}
+	return device, wait, nil
+}
+
+// ListAuthFlows returns pending flows and recently finished ones.
+func (h *Handler) ListAuthFlows(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"auth-flows": authFlows.list()})
+}
+
+// GetAuthFlow returns the current state of a device-code flow.
+func (h *Handler) GetAuthFlow(c *gin.Context) {
+	flow, ok := authFlows.get(strings.TrimSpace(c.Param("id")))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "auth flow not found"})
+		return
+	}
+	c.JSON(http.StatusOK, flow)
+}
+
+// CancelAuthFlow aborts a pending device-code flow.
+func (h *Handler) CancelAuthFlow(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+	if _, ok := authFlows.get(id); !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "auth flow not found"})
+		return
+	}
+	if !authFlows.finish(id, authFlowStatusCanceled, errAuthFlowCanceled.Error(), "") {
+		c.JSON(http.StatusConflict, gin.H{"error": "auth flow is not pending"})
+		return
+	}
+	SetOAuthSessionError(id, errAuthFlowCanceled.Error())
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
@@ -0,0 +1,84 @@
+package management
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func newAuthFlowTestContext(method, target, body string) (*gin.Context, *httptest.ResponseRecorder) {
+	gin.SetMode(gin.TestMode)
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(method, target, strings.NewReader(body))
+	c.Request.Header.Set("Content-Type", "application/json")
+	return c, rec
+}
+
+func TestStartAuthFlow_RejectsUnsupportedProviders(t *testing.T) {
+	h := NewHandlerWithoutConfigFilePath(&config.Config{AuthDir: t.TempDir()}, nil)
+
+	cases := map[string]string{
+		"unknown": `{"provider":"nope"}`,
+		"gemini":  `{"provider":"gemini"}`,
+		"qwen":    `{"provider":"qwen"}`,
+		"invalid": `not json`,
+	}
+	for name, body := range cases {
+		c, rec := newAuthFlowTestContext(http.MethodPost, "/v0/management/auth-flows", body)
+		h.StartAuthFlow(c)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: status = %d, want %d; body=%s", name, rec.Code, http.StatusBadRequest, rec.Body.String())
+		}
+	}
+}
+
+func TestAuthFlowStore_FinishOnlyOnce(t *testing.T) {
+	store := &authFlowStore{flows: make(map[string]*authFlow)}
+	_, cancel := context.WithCancel(context.Background())
+	store.add(&authFlow{ID: "flow-1", Provider: "kimi", Status: authFlowStatusPending, CreatedAt: time.Now(), cancel: cancel})
+
+	if !store.finish("flow-1", authFlowStatusCanceled, errAuthFlowCanceled.Error(), "") {
+		t.Fatal("expected pending flow to be finished")
+	}
+	if store.finish("flow-1", authFlowStatusCompleted, "", "kimi.json") {
+		t.Fatal("expected canceled flow to reject a late result")
+	}
+	flow, ok := store.get("flow-1")
+	if !ok {
+		t.Fatal("expected finished flow to be retained")
+	}
+	if flow.Status != authFlowStatusCanceled || flow.AuthFile != "" {
+		t.Fatalf("unexpected flow state: %+v", flow)
+	}
+	if store.isPending("flow-1") {
+		t.Fatal("expected finished flow to not be pending")
+	}
+}
+
+func TestAuthFlowStore_PurgesExpiredFinishedFlows(t *testing.T) {
+	store := &authFlowStore{flows: make(map[string]*authFlow)}
+	store.add(&authFlow{ID: "old", Status: authFlowStatusCompleted, FinishedAt: time.Now().Add(-2 * authFlowRetention)})
+	store.add(&authFlow{ID: "pending", Status: authFlowStatusPending})
+
+	flows := store.list()
+	if len(flows) != 1 || flows[0].ID != "pending" {
+		t.Fatalf("expected only pending flow to remain, got %+v", flows)
+	}
+}
+
+func TestGetAuthFlow_NotFound(t *testing.T) {
+	h := NewHandlerWithoutConfigFilePath(&config.Config{AuthDir: t.TempDir()}, nil)
+	c, rec := newAuthFlowTestContext(http.MethodGet, "/v0/management/auth-flows/missing", "")
+	c.Params = gin.Params{{Key: "id", Value: "missing"}}
+	h.GetAuthFlow(c)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d", rec.Code, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,23 @@
+package management
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetAuthHealth reports per-credential health: last success and failure, the rolling error
+// rate, cooldowns and retry hints, quota state and token expiry, plus a per billing tier
+// summary. The provider query parameter narrows the result.
+func (h *Handler) GetAuthHealth(c *gin.Context) {
+	if h == nil || h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	provider := strings.TrimSpace(c.Query("provider"))
+	c.JSON(http.StatusOK, gin.H{
+		"auths": h.authManager.AuthHealth(provider),
+		"tiers": h.authManager.TierUsage(provider),
+	})
+}
@@ -0,0 +1,32 @@
+package management
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetAuthPreflight returns the most recent credential preflight report.
+func (h *Handler) GetAuthPreflight(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	report := h.authManager.LatestPreflight()
+	if report == nil {
+		c.JSON(http.StatusOK, gin.H{"status": "pending"})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// RunAuthPreflight validates every credential now and returns the fresh report.
+func (h *Handler) RunAuthPreflight(c *gin.Context) {
+	if h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	report := h.authManager.Preflight(c.Request.Context())
+	report.LogSummary()
+	c.JSON(http.StatusOK, report)
+}
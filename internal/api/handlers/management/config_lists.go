@@ -482,6 +482,7 @@ func (h *Handler) PatchClaudeKey(c *gin.Context) {
 		Headers                 *map[string]string    `json:"headers"`
 		ExcludedModels          *[]string             `json:"excluded-models"`
 		RebuildMidSystemMessage *bool                 `json:"rebuild-mid-system-message"`
+		AllowedBetas            *[]string             `json:"allowed-betas"`
 	}
 	var body struct {
 		Index *int            `json:"index"`
@@ -538,6 +539,9 @@ func (h *Handler) PatchClaudeKey(c *gin.Context) {
 	if body.Value.RebuildMidSystemMessage != nil {
 		entry.RebuildMidSystemMessage = *body.Value.RebuildMidSystemMessage
 	}
+	if body.Value.AllowedBetas != nil {
+		entry.AllowedBetas = config.NormalizeAllowedBetas(*body.Value.AllowedBetas)
+	}
 	normalizeClaudeKey(&entry)
 	h.cfg.ClaudeKey[targetIndex] = entry
 	h.cfg.SanitizeClaudeKeys()
@@ -1298,6 +1302,7 @@ func normalizeClaudeKey(entry *config.ClaudeKey) {
 	entry.ProxyURL = strings.TrimSpace(entry.ProxyURL)
 	entry.Headers = config.NormalizeHeaders(entry.Headers)
 	entry.ExcludedModels = config.NormalizeExcludedModels(entry.ExcludedModels)
+	entry.AllowedBetas = config.NormalizeAllowedBetas(entry.AllowedBetas)
 	if len(entry.Models) == 0 {
 		return
 	}
@@ -1573,6 +1578,7 @@ func (h *Handler) PutCopilotKeys(c *gin.Context) {
 		entry.HeaderProfile = strings.TrimSpace(entry.HeaderProfile)
 		entry.CLIHeaderModels = config.NormalizeExcludedModels(entry.CLIHeaderModels)
 		entry.VSCodeChatHeaderModels = config.NormalizeExcludedModels(entry.VSCodeChatHeaderModels)
+		entry.ResponsesModels = config.NormalizeExcludedModels(entry.ResponsesModels)
 		filtered = append(filtered, entry)
 	}
 	h.cfg.CopilotKey = filtered
@@ -1597,6 +1603,7 @@ func (h *Handler) PatchCopilotKey(c *gin.Context) {
 	value.HeaderProfile = strings.TrimSpace(value.HeaderProfile)
 	value.CLIHeaderModels = config.NormalizeExcludedModels(value.CLIHeaderModels)
 	value.VSCodeChatHeaderModels = config.NormalizeExcludedModels(value.VSCodeChatHeaderModels)
+	value.ResponsesModels = config.NormalizeExcludedModels(value.ResponsesModels)
 
 	h.mu.Lock()
 	defer h.mu.Unlock()
@@ -0,0 +1,147 @@
+package management
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+// GetActiveProfile returns the profile applied to requests without an X-CPA-Profile header.
+func (h *Handler) GetActiveProfile(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"active-profile": h.cfg.ActiveProfile})
+}
+
+// PutActiveProfile switches the active profile. An empty value disables the default profile.
+func (h *Handler) PutActiveProfile(c *gin.Context) {
+	var body struct {
+		Value *string `json:"value"`
+	}
+	if errBindJSON := c.ShouldBindJSON(&body); errBindJSON != nil || body.Value == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	name := strings.TrimSpace(*body.Value)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if name != "" {
+		profile, ok := h.cfg.FindProfile(name)
+		if !ok {
+			c.JSON(http.StatusNotFound, gin.H{"error": "profile not found"})
+			return
+		}
+		name = profile.Name
+	}
+	h.cfg.ActiveProfile = name
+	h.persistLocked(c)
+}
+
+// GetProfiles returns all configured profiles.
+func (h *Handler) GetProfiles(c *gin.Context) {
+	profiles := h.cfg.Profiles
+	if profiles == nil {
+		profiles = []config.ProfileConfig{}
+	}
+	c.JSON(http.StatusOK, gin.H{"profiles": profiles, "active-profile": h.cfg.ActiveProfile})
+}
+
+// PutProfiles replaces all profiles.
+func (h *Handler) PutProfiles(c *gin.Context) {
+	var body struct {
+		Value []config.ProfileConfig `json:"value"`
+	}
+	if errBindJSON := c.ShouldBindJSON(&body); errBindJSON != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	profiles, ok := normalizeProfiles(body.Value)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "profiles require unique, non-empty names"})
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.cfg.Profiles = profiles
+	h.persistLocked(c)
+}
+
+// PatchProfiles adds profiles or replaces existing ones with the same name.
+func (h *Handler) PatchProfiles(c *gin.Context) {
+	var body struct {
+		Value []config.ProfileConfig `json:"value"`
+	}
+	if errBindJSON := c.ShouldBindJSON(&body); errBindJSON != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	incoming, ok := normalizeProfiles(body.Value)
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "profiles require unique, non-empty names"})
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, profile := range incoming {
+		if existing, found := h.cfg.FindProfile(profile.Name); found {
+			*existing = profile
+			continue
+		}
+		h.cfg.Profiles = append(h.cfg.Profiles, profile)
+	}
+	h.persistLocked(c)
+}
+
+// DeleteProfiles removes profiles by name, or all profiles when no names are given.
+// Deleting the active profile also clears active-profile.
+func (h *Handler) DeleteProfiles(c *gin.Context) {
+	var body struct {
+		Value []string `json:"value"`
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if errBindJSON := c.ShouldBindJSON(&body); errBindJSON != nil || len(body.Value) == 0 {
+		h.cfg.Profiles = nil
+		h.cfg.ActiveProfile = ""
+		h.persistLocked(c)
+		return
+	}
+	toRemove := make(map[string]bool, len(body.Value))
+	for _, name := range body.Value {
+		toRemove[strings.ToLower(strings.TrimSpace(name))] = true
+	}
+	kept := make([]config.ProfileConfig, 0, len(h.cfg.Profiles))
+	for _, profile := range h.cfg.Profiles {
+		if !toRemove[strings.ToLower(strings.TrimSpace(profile.Name))] {
+			kept = append(kept, profile)
+		}
+	}
+	h.cfg.Profiles = kept
+	if toRemove[strings.ToLower(strings.TrimSpace(h.cfg.ActiveProfile))] {
+		h.cfg.ActiveProfile = ""
+	}
+	h.persistLocked(c)
+}
+
+// normalizeProfiles trims names and provider lists and rejects empty or duplicate names.
+func normalizeProfiles(in []config.ProfileConfig) ([]config.ProfileConfig, bool) {
+	out := make([]config.ProfileConfig, 0, len(in))
+	seen := make(map[string]bool, len(in))
+	for _, profile := range in {
+		profile.Name = strings.TrimSpace(profile.Name)
+		key := strings.ToLower(profile.Name)
+		if key == "" || seen[key] {
+			return nil, false
+		}
+		seen[key] = true
+		providers := make([]string, 0, len(profile.Providers))
+		for _, provider := range profile.Providers {
+			if trimmed := strings.ToLower(strings.TrimSpace(provider)); trimmed != "" {
+				providers = append(providers, trimmed)
+			}
+		}
+		profile.Providers = providers
+		out = append(out, profile)
+	}
+	return out, true
+}
@@ -0,0 +1,96 @@
+package management
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	runtimepprof "runtime/pprof"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+var processStartedAt = time.Now()
+
+// DebugPprof serves net/http/pprof under /v0/management/debug/pprof/. The index page links to
+// the named profiles relative to this path.
+func (h *Handler) DebugPprof(c *gin.Context) {
+	switch name := strings.Trim(c.Param("profile"), "/"); name {
+	case "":
+		pprof.Index(c.Writer, c.Request)
+	case "cmdline":
+		pprof.Cmdline(c.Writer, c.Request)
+	case "profile":
+		pprof.Profile(c.Writer, c.Request)
+	case "symbol":
+		pprof.Symbol(c.Writer, c.Request)
+	case "trace":
+		pprof.Trace(c.Writer, c.Request)
+	default:
+		if runtimepprof.Lookup(name) == nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("unknown profile %q", name)})
+			return
+		}
+		pprof.Handler(name).ServeHTTP(c.Writer, c.Request)
+	}
+}
+
+// DebugGoroutines writes a text dump of every goroutine's stack. The debug query parameter
+// selects the format: 2 (default) prints full stacks, 1 groups identical stacks with counts.
+func (h *Handler) DebugGoroutines(c *gin.Context) {
+	debug := 2
+	if raw := strings.TrimSpace(c.Query("debug")); raw != "" {
+		parsed, errParse := strconv.Atoi(raw)
+		if errParse != nil || parsed < 1 || parsed > 2 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "debug must be 1 or 2"})
+			return
+		}
+		debug = parsed
+	}
+	c.Header("Content-Type", "text/plain; charset=utf-8")
+	c.Status(http.StatusOK)
+	_ = runtimepprof.Lookup("goroutine").WriteTo(c.Writer, debug)
+}
+
+// DebugHeap returns a heap profile for `go tool pprof`. With gc=true a garbage collection runs
+// first so the profile reflects live objects only.
+func (h *Handler) DebugHeap(c *gin.Context) {
+	if gc, _ := strconv.ParseBool(c.Query("gc")); gc {
+		runtime.GC()
+	}
+	c.Header("Content-Type", "application/octet-stream")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="heap-%s.pb.gz"`, time.Now().UTC().Format("20060102T150405Z")))
+	c.Status(http.StatusOK)
+	_ = runtimepprof.Lookup("heap").WriteTo(c.Writer, 0)
+}
+
+// DebugRuntime returns a summary of the Go runtime: goroutines, scheduler and memory statistics.
+func (h *Handler) DebugRuntime(c *gin.Context) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	c.JSON(http.StatusOK, gin.H{
+		"go_version":     runtime.Version(),
+		"goos":           runtime.GOOS,
+		"goarch":         runtime.GOARCH,
+		"num_cpu":        runtime.NumCPU(),
+		"gomaxprocs":     runtime.GOMAXPROCS(0),
+		"goroutines":     runtime.NumGoroutine(),
+		"uptime_seconds": int64(time.Since(processStartedAt).Seconds()),
+		"memory": gin.H{
+			"heap_alloc_bytes":    mem.HeapAlloc,
+			"heap_inuse_bytes":    mem.HeapInuse,
+			"heap_idle_bytes":     mem.HeapIdle,
+			"heap_released_bytes": mem.HeapReleased,
+			"heap_objects":        mem.HeapObjects,
+			"stack_inuse_bytes":   mem.StackInuse,
+			"sys_bytes":           mem.Sys,
+			"total_alloc_bytes":   mem.TotalAlloc,
+			"num_gc":              mem.NumGC,
+			"gc_pause_total_ns":   mem.PauseTotalNs,
+			"next_gc_bytes":       mem.NextGC,
+		},
+	})
+}
@@ -0,0 +1,47 @@
+package management
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func newDebugTestRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{}
+	router := gin.New()
+	router.GET("/v0/management/debug/pprof/*profile", h.DebugPprof)
+	router.GET("/v0/management/debug/goroutines", h.DebugGoroutines)
+	router.GET("/v0/management/debug/runtime", h.DebugRuntime)
+	return router
+}
+
+func TestDebugPprofServesIndexAndNamedProfiles(t *testing.T) {
+	router := newDebugTestRouter()
+
+	cases := []struct {
+		path       string
+		wantStatus int
+		wantBody   string
+	}{
+		{path: "/v0/management/debug/pprof/", wantStatus: http.StatusOK, wantBody: "goroutine"},
+		{path: "/v0/management/debug/pprof/goroutine?debug=1", wantStatus: http.StatusOK, wantBody: "goroutine profile"},
+		{path: "/v0/management/debug/pprof/not-a-profile", wantStatus: http.StatusNotFound, wantBody: "unknown profile"},
+		{path: "/v0/management/debug/goroutines", wantStatus: http.StatusOK, wantBody: "goroutine "},
+		{path: "/v0/management/debug/goroutines?debug=3", wantStatus: http.StatusBadRequest, wantBody: "debug must be"},
+		{path: "/v0/management/debug/runtime", wantStatus: http.StatusOK, wantBody: `"goroutines"`},
+	}
+	for _, tc := range cases {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if rec.Code != tc.wantStatus {
+			t.Fatalf("GET %s status = %d, want %d; body=%s", tc.path, rec.Code, tc.wantStatus, rec.Body.String())
+		}
+		if !strings.Contains(rec.Body.String(), tc.wantBody) {
+			t.Fatalf("GET %s body does not contain %q: %s", tc.path, tc.wantBody, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package management
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/evals"
+)
+
+// evalBackend executes evaluation requests through the handler's current auth manager.
+type evalBackend struct {
+	h *Handler
+}
+
+func (b evalBackend) Execute(ctx context.Context, model string, payload []byte, headers http.Header) ([]byte, error) {
+	b.h.mu.Lock()
+	manager := b.h.authManager
+	b.h.mu.Unlock()
+	return evals.ManagerBackend{Manager: manager}.Execute(ctx, model, payload, headers)
+}
+
+func (h *Handler) evals() *evals.Runner {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.evalRunner == nil {
+		h.evalRunner = evals.NewRunner(evalBackend{h: h})
+	}
+	return h.evalRunner
+}
+
+// StartEval starts an evaluation run: every dataset prompt is sent to target_a and target_b and
+// judge_model picks the better output. The run proceeds in the background; poll GetEval for the report.
+func (h *Handler) StartEval(c *gin.Context) {
+	var spec evals.Spec
+	if errBind := c.ShouldBindJSON(&spec); errBind != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid body"})
+		return
+	}
+	var pricing *config.SDKConfig
+	h.mu.Lock()
+	if h.cfg != nil {
+		pricing = &h.cfg.SDKConfig
+	}
+	h.mu.Unlock()
+	id, errStart := h.evals().Start(spec, pricing)
+	if errStart != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errStart.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"id": id, "status": evals.StatusRunning})
+}
+
+// ListEvals returns the summary reports of retained evaluation runs, newest first.
+func (h *Handler) ListEvals(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"evals": h.evals().List()})
+}
+
+// GetEval returns the report of one evaluation run; cases=false omits the per-case results.
+func (h *Handler) GetEval(c *gin.Context) {
+	report, ok := h.evals().Get(strings.TrimSpace(c.Param("id")), c.Query("cases") != "false")
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "eval not found"})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// CancelEval stops a running evaluation; results gathered so far stay in its report.
+func (h *Handler) CancelEval(c *gin.Context) {
+	if !h.evals().Cancel(strings.TrimSpace(c.Param("id"))) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "eval not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
@@ -259,19 +259,56 @@ func (h *Handler) GetRequestErrorLogs(c *gin.Context) {
 // GetRequestLogByID finds and downloads a request log file by its request ID.
 // The ID is matched against the suffix of log file names (format: *-{requestID}.log).
 func (h *Handler) GetRequestLogByID(c *gin.Context) {
+	fullPath, matchedFile, ok := h.resolveRequestLogByID(c)
+	if !ok {
+		return
+	}
+	c.FileAttachment(fullPath, matchedFile)
+}
+
+// GetRequestLogHAR exports the request log for a request ID as a HAR file containing the
+// inbound request, each translated upstream request and their responses.
+func (h *Handler) GetRequestLogHAR(c *gin.Context) {
+	fullPath, matchedFile, ok := h.resolveRequestLogByID(c)
+	if !ok {
+		return
+	}
+	data, errRead := os.ReadFile(fullPath)
+	if errRead != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to read log file: %v", errRead)})
+		return
+	}
+	har, errConvert := logging.RequestLogToHAR(data)
+	if errConvert != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": fmt.Sprintf("failed to convert log file: %v", errConvert)})
+		return
+	}
+	payload, errMarshal := logging.MarshalHAR(har)
+	if errMarshal != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to encode har: %v", errMarshal)})
+		return
+	}
+	name := strings.TrimSuffix(matchedFile, ".log") + ".har"
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	c.Data(http.StatusOK, "application/json", payload)
+}
+
+// resolveRequestLogByID locates the request log file for the ":id" route parameter (or "id" query).
+// It writes an error response and returns false when the file cannot be resolved.
+func (h *Handler) resolveRequestLogByID(c *gin.Context) (string, string, bool) {
 	if h == nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "handler unavailable"})
-		return
+		return "", "", false
 	}
 	if h.cfg == nil {
 		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "configuration unavailable"})
-		return
+		return "", "", false
 	}
 
 	dir := h.logDirectory()
 	if strings.TrimSpace(dir) == "" {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "log directory not configured"})
-		return
+		return "", "", false
 	}
 
 	requestID := strings.TrimSpace(c.Param("id"))
@@ -280,21 +317,21 @@ func (h *Handler) GetRequestLogByID(c *gin.Context) {
 	}
 	if requestID == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "missing request ID"})
-		return
+		return "", "", false
 	}
 	if strings.ContainsAny(requestID, "/\\") {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid request ID"})
-		return
+		return "", "", false
 	}
 
 	entries, err := os.ReadDir(dir)
 	if err != nil {
 		if os.IsNotExist(err) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "log directory not found"})
-			return
+			return "", "", false
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to list log directory: %v", err)})
-		return
+		return "", "", false
 	}
 
 	suffix := "-" + requestID + ".log"
@@ -312,36 +349,36 @@ func (h *Handler) GetRequestLogByID(c *gin.Context) {
 
 	if matchedFile == "" {
 		c.JSON(http.StatusNotFound, gin.H{"error": "log file not found for the given request ID"})
-		return
+		return "", "", false
 	}
 
 	dirAbs, errAbs := filepath.Abs(dir)
 	if errAbs != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to resolve log directory: %v", errAbs)})
-		return
+		return "", "", false
 	}
 	fullPath := filepath.Clean(filepath.Join(dirAbs, matchedFile))
 	prefix := dirAbs + string(os.PathSeparator)
 	if !strings.HasPrefix(fullPath, prefix) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid log file path"})
-		return
+		return "", "", false
 	}
 
 	info, errStat := os.Stat(fullPath)
 	if errStat != nil {
 		if os.IsNotExist(errStat) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "log file not found"})
-			return
+			return "", "", false
 		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to read log file: %v", errStat)})
-		return
+		return "", "", false
 	}
 	if info.IsDir() {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid log file"})
-		return
+		return "", "", false
 	}
 
-	c.FileAttachment(fullPath, matchedFile)
+	return fullPath, matchedFile, true
 }
 
 // DownloadRequestErrorLog downloads a specific error request log file by name.
@@ -0,0 +1,82 @@
+package management
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+	log "github.com/sirupsen/logrus"
+)
+
+const logStreamKeepAliveInterval = 15 * time.Second
+
+// StreamLogs tails application logs over Server-Sent Events.
+// Query parameters:
+//   - level: least severe level to deliver (default "info")
+//   - provider: only entries whose provider field (or message) mentions this provider
+func (h *Handler) StreamLogs(c *gin.Context) {
+	if h == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "handler unavailable"})
+		return
+	}
+	filter := logging.LogStreamFilter{Level: log.InfoLevel, Provider: strings.TrimSpace(c.Query("provider"))}
+	if rawLevel := strings.TrimSpace(c.Query("level")); rawLevel != "" {
+		level, errLevel := log.ParseLevel(rawLevel)
+		if errLevel != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid level: %s", rawLevel)})
+			return
+		}
+		filter.Level = level
+	}
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "streaming unsupported"})
+		return
+	}
+
+	entries, cancel := logging.SubscribeLogs(filter, 0)
+	defer func() {
+		if dropped := cancel(); dropped > 0 {
+			log.Debugf("management log stream dropped %d entries for a slow client", dropped)
+		}
+	}()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	_, _ = fmt.Fprint(c.Writer, ": connected\n\n")
+	flusher.Flush()
+
+	ticker := time.NewTicker(logStreamKeepAliveInterval)
+	defer ticker.Stop()
+	ctx := c.Request.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, errWrite := fmt.Fprint(c.Writer, ": keep-alive\n\n"); errWrite != nil {
+				return
+			}
+			flusher.Flush()
+		case entry, open := <-entries:
+			if !open {
+				return
+			}
+			payload, errMarshal := json.Marshal(entry)
+			if errMarshal != nil {
+				continue
+			}
+			if _, errWrite := fmt.Fprintf(c.Writer, "event: log\ndata: %s\n\n", payload); errWrite != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
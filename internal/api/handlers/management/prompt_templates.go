@@ -0,0 +1,21 @@
+package management
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/prompttemplate"
+)
+
+// ListPromptTemplates returns the prompt templates clients can render, from the config and the
+// templates directory.
+func (h *Handler) ListPromptTemplates(c *gin.Context) {
+	var cfg *config.SDKConfig
+	h.mu.Lock()
+	if h.cfg != nil {
+		cfg = &h.cfg.SDKConfig
+	}
+	h.mu.Unlock()
+	c.JSON(http.StatusOK, gin.H{"templates": prompttemplate.List(cfg)})
+}
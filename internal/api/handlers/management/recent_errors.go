@@ -0,0 +1,26 @@
+package management
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetRecentErrors lists the latest upstream failures kept in memory, newest first.
+// Query parameters: provider, auth_id and limit narrow the result. Works without request logging.
+func (h *Handler) GetRecentErrors(c *gin.Context) {
+	if h == nil || h.authManager == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "core auth manager unavailable"})
+		return
+	}
+	limit, errLimit := parseLimit(c.Query("limit"))
+	if errLimit != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid limit: %v", errLimit)})
+		return
+	}
+	provider := strings.TrimSpace(c.Query("provider"))
+	authID := strings.TrimSpace(c.Query("auth_id"))
+	c.JSON(http.StatusOK, gin.H{"errors": h.authManager.RecentErrors(provider, authID, limit)})
+}
@@ -0,0 +1,69 @@
+package management
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/redisqueue"
+)
+
+// DeleteRequests purges the request logs and queued usage records of requests received before
+// the "before" query parameter and made with the client API key in the "key" query parameter.
+// At least one of them is required; given both, a request must match both.
+func (h *Handler) DeleteRequests(c *gin.Context) {
+	if h == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "handler unavailable"})
+		return
+	}
+	before, errBefore := parseRequestsBefore(c.Query("before"))
+	if errBefore != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": errBefore.Error()})
+		return
+	}
+	apiKey := strings.TrimSpace(c.Query("key"))
+	if before.IsZero() && apiKey == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "before or key is required"})
+		return
+	}
+
+	filter := logging.RequestLogFilter{Before: before, ClientKeyHash: logging.ClientKeyHash(apiKey)}
+	logsRemoved, errPurge := logging.PurgeRequestLogs(h.logDirectory(), filter)
+	usageRemoved := redisqueue.PurgeUsageRecords(before, apiKey)
+	if errPurge != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":                 fmt.Sprintf("failed to purge request logs: %v", errPurge),
+			"request_logs_removed":  logsRemoved,
+			"usage_records_removed": usageRemoved,
+		})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"request_logs_removed":  logsRemoved,
+		"usage_records_removed": usageRemoved,
+	})
+}
+
+// parseRequestsBefore accepts an RFC 3339 time or unix seconds. Empty values yield the zero time.
+func parseRequestsBefore(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if seconds, errInt := strconv.ParseInt(value, 10, 64); errInt == nil {
+		if seconds <= 0 {
+			return time.Time{}, errors.New("before must be a positive unix time")
+		}
+		return time.Unix(seconds, 0), nil
+	}
+	parsed, errParse := time.Parse(time.RFC3339, value)
+	if errParse != nil {
+		return time.Time{}, errors.New("before must be an RFC 3339 time or unix seconds")
+	}
+	return parsed, nil
+}
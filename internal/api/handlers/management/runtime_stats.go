@@ -0,0 +1,140 @@
+package management
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/baseurlpin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/cache"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/claudeusage"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/latencystats"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/memguard"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/payloadstats"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/streamwatch"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/telemetry"
+)
+
+// GetCacheStats returns the hit, miss and eviction counters of every named cache.
+func (h *Handler) GetCacheStats(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"caches": cache.Snapshot()})
+}
+
+// GetMemoryGuard returns process and in-flight memory as seen by the memory guard.
+func (h *Handler) GetMemoryGuard(c *gin.Context) {
+	c.JSON(http.StatusOK, memguard.Snapshot())
+}
+
+// ListStreams returns the live response streams, oldest first, with the watchdog's alarm counters.
+func (h *Handler) ListStreams(c *gin.Context) {
+	c.JSON(http.StatusOK, streamwatch.Snapshot())
+}
+
+// CancelStream force-cancels one live stream so its upstream request and goroutines stop.
+func (h *Handler) CancelStream(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+	if id == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing stream id"})
+		return
+	}
+	if !streamwatch.Cancel(id) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "stream not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
+
+// GetPayloadStats returns the per-model histograms of payload sizes and output tokens, as JSON or,
+// with ?format=prometheus, in the Prometheus text exposition format.
+func (h *Handler) GetPayloadStats(c *gin.Context) {
+	if strings.EqualFold(c.Query("format"), "prometheus") {
+		c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		c.Status(http.StatusOK)
+		if err := payloadstats.WritePrometheus(c.Writer); err != nil {
+			_ = c.Error(err)
+		}
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"models": payloadstats.Snapshot()})
+}
+
+// GetLatencyHeatmap returns hourly TTFT and completion latency aggregates per provider.
+// Query parameters: provider filters to one provider, hours sets the window (default and maximum
+// 168), tz names the IANA time zone hours are reported in (default UTC), and group=hour-of-day
+// merges the days into one cell per hour of the day.
+func (h *Handler) GetLatencyHeatmap(c *gin.Context) {
+	hours := int(latencystats.Retention / time.Hour)
+	if raw := strings.TrimSpace(c.Query("hours")); raw != "" {
+		parsed, errParse := strconv.Atoi(raw)
+		if errParse != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "hours must be a positive integer"})
+			return
+		}
+		hours = min(parsed, hours)
+	}
+	loc := time.UTC
+	if tz := strings.TrimSpace(c.Query("tz")); tz != "" {
+		parsed, errLoad := time.LoadLocation(tz)
+		if errLoad != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown time zone " + tz})
+			return
+		}
+		loc = parsed
+	}
+	byHourOfDay := false
+	switch group := strings.TrimSpace(c.Query("group")); group {
+	case "", "hour":
+	case "hour-of-day":
+		byHourOfDay = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "group must be hour or hour-of-day"})
+		return
+	}
+	providers := latencystats.Snapshot(c.Query("provider"), time.Duration(hours)*time.Hour, loc, byHourOfDay)
+	c.JSON(http.StatusOK, gin.H{"hours": hours, "tz": loc.String(), "providers": providers})
+}
+
+// GetClaudeUsageLimits returns the last subscription usage windows Anthropic reported for each
+// Claude OAuth credential.
+func (h *Handler) GetClaudeUsageLimits(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"credentials": claudeusage.Snapshot()})
+}
+
+// GetBaseURLPins returns the regional endpoint groups with their last probe results and the
+// endpoint each is pinned to.
+func (h *Handler) GetBaseURLPins(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"groups": baseurlpin.Default.Snapshot()})
+}
+
+// GetTelemetryPreview returns the anonymous telemetry report that would be sent for the
+// current period, noise included, whether or not telemetry is enabled.
+func (h *Handler) GetTelemetryPreview(c *gin.Context) {
+	c.JSON(http.StatusOK, telemetry.Preview())
+}
+
+// GetModelCollisions returns every registered model ID with the providers claiming it and which
+// one requests resolve to. With ?conflicts=true only shared and shadowed models are listed.
+func (h *Handler) GetModelCollisions(c *gin.Context) {
+	var sdkCfg *config.SDKConfig
+	if h.cfg != nil {
+		sdkCfg = &h.cfg.SDKConfig
+	}
+	report := registry.GetGlobalRegistry().CollisionReport(config.ModelRoutingPrefixes(sdkCfg))
+	if strings.EqualFold(c.Query("conflicts"), "true") {
+		conflicts := make([]registry.ModelClaims, 0, report.Shared+report.Shadowed)
+		for _, model := range report.Models {
+			if model.Resolution == registry.ResolutionShared || model.Resolution == registry.ResolutionShadowed {
+				conflicts = append(conflicts, model)
+			}
+		}
+		report.Models = conflicts
+	}
+	if report.Models == nil {
+		report.Models = []registry.ModelClaims{}
+	}
+	c.JSON(http.StatusOK, report)
+}
@@ -0,0 +1,88 @@
+package management
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+)
+
+// ListSessionTranscripts lists stored execution session transcripts, most recently updated first.
+func (h *Handler) ListSessionTranscripts(c *gin.Context) {
+	if h == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "handler unavailable"})
+		return
+	}
+	transcripts, errList := logging.ListSessionTranscripts(h.logDirectory())
+	if errList != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to list session transcripts: %v", errList)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"enabled":     logging.SessionTranscriptsEnabled(),
+		"transcripts": transcripts,
+	})
+}
+
+// GetSessionTranscript returns every recorded turn of one execution session.
+// The optional limit query parameter keeps only the most recent turns.
+func (h *Handler) GetSessionTranscript(c *gin.Context) {
+	if h == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "handler unavailable"})
+		return
+	}
+	sessionID := strings.TrimSpace(c.Param("id"))
+	if sessionID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing session id"})
+		return
+	}
+	limit, errLimit := parseLimit(c.Query("limit"))
+	if errLimit != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid limit: %v", errLimit)})
+		return
+	}
+	entries, errRead := logging.ReadSessionTranscript(h.logDirectory(), sessionID)
+	if errRead != nil {
+		if errors.Is(errRead, os.ErrNotExist) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "session transcript not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to read session transcript: %v", errRead)})
+		return
+	}
+	total := len(entries)
+	if limit > 0 && total > limit {
+		entries = entries[total-limit:]
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"session_id": sessionID,
+		"total":      total,
+		"entries":    entries,
+	})
+}
+
+// DeleteSessionTranscript removes the transcript of one execution session.
+func (h *Handler) DeleteSessionTranscript(c *gin.Context) {
+	if h == nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "handler unavailable"})
+		return
+	}
+	sessionID := strings.TrimSpace(c.Param("id"))
+	if sessionID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "missing session id"})
+		return
+	}
+	if errDelete := logging.DeleteSessionTranscript(h.logDirectory(), sessionID); errDelete != nil {
+		if errors.Is(errDelete, os.ErrNotExist) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "session transcript not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("failed to delete session transcript: %v", errDelete)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok"})
+}
@@ -5,6 +5,7 @@ package middleware
 
 import (
 	"bytes"
+	"maps"
 	"net/http"
 	"strings"
 	"time"
@@ -82,6 +83,23 @@ func NewResponseWriterWrapper(w gin.ResponseWriter, logger logging.RequestLogger
 	}
 }
 
+// requestLogHeaders returns the request headers to log, tagged with the hash of the client API
+// key so the logs of one key can be found for deletion.
+func (w *ResponseWriterWrapper) requestLogHeaders() map[string][]string {
+	headers := w.requestInfo.Headers
+	if w.ginCtx == nil {
+		return headers
+	}
+	keyHash := logging.ClientKeyHash(w.ginCtx.GetString("userApiKey"))
+	if keyHash == "" {
+		return headers
+	}
+	tagged := make(map[string][]string, len(headers)+1)
+	maps.Copy(tagged, headers)
+	tagged[logging.RequestLogClientKeyHeader] = []string{keyHash}
+	return tagged
+}
+
 func (w *ResponseWriterWrapper) transformResponseLogChunk(data []byte) []byte {
 	if len(data) == 0 {
 		return data
@@ -200,7 +218,7 @@ func (w *ResponseWriterWrapper) WriteHeader(statusCode int) {
 		streamWriter, err := w.logger.LogStreamingRequest(
 			w.requestInfo.URL,
 			w.requestInfo.Method,
-			w.requestInfo.Headers,
+			w.requestLogHeaders(),
 			w.requestInfo.Body,
 			w.requestInfo.RequestID,
 		)
@@ -548,7 +566,7 @@ func (w *ResponseWriterWrapper) logRequest(requestBody []byte, statusCode int, h
 		return loggerWithAllSources.LogRequestWithOptionsAndAllSources(
 			w.requestInfo.URL,
 			w.requestInfo.Method,
-			w.requestInfo.Headers,
+			w.requestLogHeaders(),
 			requestBody,
 			statusCode,
 			headers,
@@ -586,7 +604,7 @@ func (w *ResponseWriterWrapper) logRequest(requestBody []byte, statusCode int, h
 		return loggerWithSources.LogRequestWithOptionsAndSources(
 			w.requestInfo.URL,
 			w.requestInfo.Method,
-			w.requestInfo.Headers,
+			w.requestLogHeaders(),
 			requestBody,
 			statusCode,
 			headers,
@@ -632,7 +650,7 @@ func (w *ResponseWriterWrapper) logRequest(requestBody []byte, statusCode int, h
 		return loggerWithOptions.LogRequestWithOptions(
 			w.requestInfo.URL,
 			w.requestInfo.Method,
-			w.requestInfo.Headers,
+			w.requestLogHeaders(),
 			requestBody,
 			statusCode,
 			headers,
@@ -652,7 +670,7 @@ func (w *ResponseWriterWrapper) logRequest(requestBody []byte, statusCode int, h
 	return w.logger.LogRequest(
 		w.requestInfo.URL,
 		w.requestInfo.Method,
-		w.requestInfo.Headers,
+		w.requestLogHeaders(),
 		requestBody,
 		statusCode,
 		headers,
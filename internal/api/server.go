@@ -8,14 +8,17 @@ import (
 	"context"
 	"crypto/subtle"
 	"crypto/tls"
+	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math"
 	"net"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
 	"sort"
 	"strconv"
 	"strings"
@@ -27,17 +30,27 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/access"
 	managementHandlers "github.com/router-for-me/CLIProxyAPI/v7/internal/api/handlers/management"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/api/middleware"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/audit"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/cache"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/durablestate"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/home"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/managementasset"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/memguard"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/metrics"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/payloadstats"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/pluginhost"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/provenance"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/providerstatus"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/ratelimit"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/redisqueue"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/safemode"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/secretdlp"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/selfupdate"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/streamwatch"
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/common"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
 	sdkaccess "github.com/router-for-me/CLIProxyAPI/v7/sdk/access"
 	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
@@ -331,6 +344,21 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 	}
 
 	engine.Use(corsMiddleware())
+	isProxyRequest := func(req *http.Request) bool {
+		return isExampleAPIKeySafeModeProxyPath(req.URL.Path)
+	}
+	servingAuth := func(c *gin.Context) (string, string) {
+		authID := c.GetString(handlers.SelectedAuthIDGinKey)
+		if authID == "" || authManager == nil {
+			return "", authID
+		}
+		if selected, ok := authManager.GetByID(authID); ok {
+			return selected.Provider, authID
+		}
+		return "", authID
+	}
+	engine.Use(audit.Middleware(isProxyRequest, servingAuth))
+	engine.Use(provenance.Middleware(isProxyRequest, servingAuth))
 	wd, err := os.Getwd()
 	if err != nil {
 		wd = configFilePath
@@ -371,9 +399,25 @@ func NewServer(cfg *config.Config, authManager *auth.Manager, accessManager *sdk
 		authManager.SetRetryConfig(cfg.RequestRetry, time.Duration(cfg.MaxRetryInterval)*time.Second, cfg.MaxRetryCredentials)
 	}
 	managementasset.SetCurrentConfig(cfg)
+	selfupdate.SetCurrentConfig(cfg)
 	auth.SetQuotaCooldownDisabled(cfg.DisableCooling)
 	auth.SetTransientErrorCooldownSeconds(cfg.TransientErrorCooldownSeconds)
 	applySignatureCacheConfig(nil, cfg)
+	applySystemMessagePolicy(nil, cfg)
+	if errRateLimit := ratelimit.Configure(cfg); errRateLimit != nil {
+		log.Errorf("failed to configure inbound rate limit: %v", errRateLimit)
+	}
+	if errSharedCache := cache.Configure(cfg); errSharedCache != nil {
+		log.Errorf("failed to configure shared cache: %v", errSharedCache)
+	}
+	memguard.Configure(cfg)
+	streamwatch.Configure(cfg)
+	if errSigning := provenance.Configure(cfg); errSigning != nil {
+		log.Errorf("response signing disabled: %v", errSigning)
+	}
+	if errAudit := audit.Configure(cfg); errAudit != nil {
+		log.Errorf("audit log disabled: %v", errAudit)
+	}
 	// Initialize management handler
 	s.mgmt = managementHandlers.NewHandler(cfg, configFilePath, authManager)
 	s.mgmt.SetPluginHost(optionState.pluginHost)
@@ -506,6 +550,8 @@ func isExampleAPIKeySafeModeProxyPath(path string) bool {
 		return true
 	case path == "/v1beta" || strings.HasPrefix(path, "/v1beta/"):
 		return true
+	case strings.HasPrefix(path, "/upload/v1beta/"):
+		return true
 	case path == "/openai/v1" || strings.HasPrefix(path, "/openai/v1/"):
 		return true
 	case path == "/backend-api/codex" || strings.HasPrefix(path, "/backend-api/codex/"):
@@ -579,11 +625,18 @@ func (s *Server) setupRoutes() {
 				"cursor-composer": composer,
 			}
 		}
+		if component == "" {
+			if statuses := providerstatus.Default.Snapshot(); len(statuses) > 0 {
+				body["provider_status"] = statuses
+			}
+		}
 		if component != "" {
 			body["component"] = component
 		}
 		c.JSON(http.StatusOK, body)
 	}
+	s.engine.GET("/metrics", s.serveMetrics)
+	s.engine.GET("/response-signing/public-key", serveResponseSigningKey)
 	s.engine.GET("/readyz", readyzHandler)
 	s.engine.GET("/ready", readyzHandler)
 	s.engine.HEAD("/readyz", readyzHandler)
@@ -602,7 +655,11 @@ func (s *Server) setupRoutes() {
 	{
 		v1.GET("/models", s.unifiedModelsHandler(openaiHandlers, claudeCodeHandlers))
 		v1.POST("/chat/completions", openaiHandlers.ChatCompletions)
+		v1.GET("/chat/completions/ws", openaiHandlers.ChatCompletionsWebsocket)
 		v1.POST("/completions", openaiHandlers.Completions)
+		v1.POST("/estimate", openaiHandlers.Estimate)
+		v1.POST("/embeddings", openaiHandlers.Embeddings)
+		v1.POST("/ensemble/chat/completions", openaiHandlers.EnsembleChatCompletions)
 		v1.POST("/images/generations", openaiHandlers.ImagesGenerations)
 		v1.POST("/images/edits", openaiHandlers.ImagesEdits)
 		v1.POST("/videos", openaiHandlers.XAIVideosGenerations)
@@ -612,6 +669,10 @@ func (s *Server) setupRoutes() {
 		v1.GET("/videos/:request_id", openaiHandlers.XAIVideosRetrieve)
 		v1.POST("/messages", claudeCodeHandlers.ClaudeMessages)
 		v1.POST("/messages/count_tokens", claudeCodeHandlers.ClaudeCountTokens)
+		v1.POST("/messages/batches", claudeCodeHandlers.CreateMessageBatch)
+		v1.GET("/messages/batches/:id", claudeCodeHandlers.GetMessageBatch)
+		v1.POST("/messages/batches/:id/cancel", claudeCodeHandlers.CancelMessageBatch)
+		v1.GET("/messages/batches/:id/results", claudeCodeHandlers.MessageBatchResults)
 		v1.GET("/responses", openaiResponsesHandlers.ResponsesWebsocket)
 		v1.POST("/responses", openaiResponsesHandlers.Responses)
 		v1.POST("/responses/compact", openaiResponsesHandlers.Compact)
@@ -642,6 +703,16 @@ func (s *Server) setupRoutes() {
 		v1beta.POST("/interactions", geminiHandlers.Interactions)
 		v1beta.POST("/models/*action", geminiHandlers.GeminiHandler)
 		v1beta.GET("/models/*action", s.geminiGetHandler(geminiHandlers))
+		v1beta.GET("/files/:name", geminiHandlers.GetFile)
+		v1beta.DELETE("/files/:name", geminiHandlers.DeleteFile)
+	}
+
+	// Gemini Files API uploads
+	uploadV1beta := s.engine.Group("/upload/v1beta")
+	uploadV1beta.Use(AuthMiddleware(s.accessManager))
+	{
+		uploadV1beta.POST("/files", geminiHandlers.UploadFile)
+		uploadV1beta.PUT("/files", geminiHandlers.UploadFile)
 	}
 
 	// Root endpoint
@@ -808,6 +879,11 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.GET("/debug", s.mgmt.GetDebug)
 		mgmt.PUT("/debug", s.mgmt.PutDebug)
 		mgmt.PATCH("/debug", s.mgmt.PutDebug)
+		mgmt.GET("/debug/pprof/*profile", s.mgmt.DebugPprof)
+		mgmt.POST("/debug/pprof/*profile", s.mgmt.DebugPprof)
+		mgmt.GET("/debug/goroutines", s.mgmt.DebugGoroutines)
+		mgmt.GET("/debug/heap", s.mgmt.DebugHeap)
+		mgmt.GET("/debug/runtime", s.mgmt.DebugRuntime)
 
 		mgmt.GET("/logging-to-file", s.mgmt.GetLoggingToFile)
 		mgmt.PUT("/logging-to-file", s.mgmt.PutLoggingToFile)
@@ -845,6 +921,10 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.PUT("/api-keys", s.mgmt.PutAPIKeys)
 		mgmt.PATCH("/api-keys", s.mgmt.PatchAPIKeys)
 		mgmt.DELETE("/api-keys", s.mgmt.DeleteAPIKeys)
+		mgmt.POST("/api-keys/create", s.mgmt.CreateAPIKey)
+		mgmt.POST("/api-keys/rotate", s.mgmt.RotateAPIKey)
+		mgmt.POST("/api-keys/revoke", s.mgmt.RevokeAPIKey)
+		mgmt.GET("/api-key-metadata", s.mgmt.GetAPIKeyMetadata)
 		mgmt.GET("/api-key-usage", s.mgmt.GetAPIKeyUsage)
 		mgmt.GET("/usage-queue", s.mgmt.GetUsageQueue)
 
@@ -859,10 +939,36 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.DELETE("/interactions-api-key", s.mgmt.DeleteInteractionsKey)
 
 		mgmt.GET("/logs", s.mgmt.GetLogs)
+		mgmt.GET("/logs/stream", s.mgmt.StreamLogs)
 		mgmt.DELETE("/logs", s.mgmt.DeleteLogs)
+		mgmt.GET("/recent-errors", s.mgmt.GetRecentErrors)
+		mgmt.GET("/auth-health", s.mgmt.GetAuthHealth)
+
+		mgmt.GET("/cache-stats", s.mgmt.GetCacheStats)
+		mgmt.GET("/memory-guard", s.mgmt.GetMemoryGuard)
+		mgmt.GET("/streams", s.mgmt.ListStreams)
+		mgmt.DELETE("/streams/:id", s.mgmt.CancelStream)
+		mgmt.GET("/payload-stats", s.mgmt.GetPayloadStats)
+		mgmt.GET("/latency-heatmap", s.mgmt.GetLatencyHeatmap)
+		mgmt.GET("/claude-usage-limits", s.mgmt.GetClaudeUsageLimits)
+		mgmt.GET("/base-url-pins", s.mgmt.GetBaseURLPins)
+		mgmt.GET("/telemetry/preview", s.mgmt.GetTelemetryPreview)
+		mgmt.GET("/model-collisions", s.mgmt.GetModelCollisions)
+
+		mgmt.GET("/evals", s.mgmt.ListEvals)
+		mgmt.POST("/evals", s.mgmt.StartEval)
+		mgmt.GET("/evals/:id", s.mgmt.GetEval)
+		mgmt.DELETE("/evals/:id", s.mgmt.CancelEval)
+		mgmt.GET("/prompt-templates", s.mgmt.ListPromptTemplates)
+
+		mgmt.GET("/session-transcripts", s.mgmt.ListSessionTranscripts)
+		mgmt.GET("/session-transcripts/:id", s.mgmt.GetSessionTranscript)
+		mgmt.DELETE("/session-transcripts/:id", s.mgmt.DeleteSessionTranscript)
+		mgmt.DELETE("/requests", s.mgmt.DeleteRequests)
 		mgmt.GET("/request-error-logs", s.mgmt.GetRequestErrorLogs)
 		mgmt.GET("/request-error-logs/:name", s.mgmt.DownloadRequestErrorLog)
 		mgmt.GET("/request-log-by-id/:id", s.mgmt.GetRequestLogByID)
+		mgmt.GET("/request-log-by-id/:id/har", s.mgmt.GetRequestLogHAR)
 		mgmt.GET("/request-log", s.mgmt.GetRequestLog)
 		mgmt.PUT("/request-log", s.mgmt.PutRequestLog)
 		mgmt.PATCH("/request-log", s.mgmt.PutRequestLog)
@@ -884,6 +990,13 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.GET("/routing/strategy", s.mgmt.GetRoutingStrategy)
 		mgmt.PUT("/routing/strategy", s.mgmt.PutRoutingStrategy)
 		mgmt.PATCH("/routing/strategy", s.mgmt.PutRoutingStrategy)
+		mgmt.GET("/active-profile", s.mgmt.GetActiveProfile)
+		mgmt.PUT("/active-profile", s.mgmt.PutActiveProfile)
+		mgmt.PATCH("/active-profile", s.mgmt.PutActiveProfile)
+		mgmt.GET("/profiles", s.mgmt.GetProfiles)
+		mgmt.PUT("/profiles", s.mgmt.PutProfiles)
+		mgmt.PATCH("/profiles", s.mgmt.PatchProfiles)
+		mgmt.DELETE("/profiles", s.mgmt.DeleteProfiles)
 
 		mgmt.GET("/claude-api-key", s.mgmt.GetClaudeKeys)
 		mgmt.PUT("/claude-api-key", s.mgmt.PutClaudeKeys)
@@ -938,6 +1051,12 @@ func (s *Server) registerManagementRoutes() {
 		mgmt.GET("/kimi-auth-url", s.mgmt.RequestKimiToken)
 		mgmt.GET("/xai-auth-url", s.mgmt.RequestXAIToken)
 		mgmt.GET("/get-auth-status", s.mgmt.GetAuthStatus)
+		mgmt.GET("/auth-flows", s.mgmt.ListAuthFlows)
+		mgmt.POST("/auth-flows", s.mgmt.StartAuthFlow)
+		mgmt.GET("/auth-flows/:id", s.mgmt.GetAuthFlow)
+		mgmt.DELETE("/auth-flows/:id", s.mgmt.CancelAuthFlow)
+		mgmt.GET("/auth-preflight", s.mgmt.GetAuthPreflight)
+		mgmt.POST("/auth-preflight", s.mgmt.RunAuthPreflight)
 	}
 }
 
@@ -1047,6 +1166,46 @@ func (s *Server) pluginResourceNoRoute(c *gin.Context) {
 	c.AbortWithStatus(http.StatusNotFound)
 }
 
+// serveResponseSigningKey publishes the key verifying response signatures when
+// response-signing.enable is set.
+func serveResponseSigningKey(c *gin.Context) {
+	signer := provenance.Active()
+	if signer == nil {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"algorithm":  "ed25519",
+		"key_id":     signer.KeyID(),
+		"public_key": base64.StdEncoding.EncodeToString(signer.PublicKey()),
+	})
+}
+
+// serveMetrics writes the Prometheus metrics when metrics.enable is set.
+func (s *Server) serveMetrics(c *gin.Context) {
+	cfg := s.cfg
+	if cfg == nil || !cfg.Metrics.Enable {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+	if token := cfg.Metrics.BearerToken; token != "" {
+		provided, _ := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			c.AbortWithStatus(http.StatusUnauthorized)
+			return
+		}
+	}
+	c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.Status(http.StatusOK)
+	if errWrite := metrics.WritePrometheus(c.Writer); errWrite != nil {
+		log.Debugf("metrics: write response: %v", errWrite)
+		return
+	}
+	if errWrite := payloadstats.WritePrometheus(c.Writer); errWrite != nil {
+		log.Debugf("metrics: write response: %v", errWrite)
+	}
+}
+
 func (s *Server) serveManagementControlPanel(c *gin.Context) {
 	cfg := s.cfg
 	if cfg == nil || cfg.Home.Enabled || cfg.RemoteManagement.DisableControlPanel {
@@ -1855,6 +2014,46 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 		}
 	}
 
+	if oldCfg == nil || !reflect.DeepEqual(oldCfg.InboundRateLimit, cfg.InboundRateLimit) {
+		if err := ratelimit.Configure(cfg); err != nil {
+			log.Errorf("failed to configure inbound rate limit: %v", err)
+		}
+	}
+
+	if oldCfg == nil || oldCfg.MemoryGuard != cfg.MemoryGuard {
+		memguard.Configure(cfg)
+	}
+
+	if oldCfg == nil || oldCfg.StreamWatchdog != cfg.StreamWatchdog {
+		streamwatch.Configure(cfg)
+	}
+
+	if oldCfg == nil || oldCfg.ResponseSigning != cfg.ResponseSigning {
+		if errSigning := provenance.Configure(cfg); errSigning != nil {
+			log.Errorf("response signing disabled: %v", errSigning)
+		}
+	}
+
+	if oldCfg == nil || oldCfg.AuditLog != cfg.AuditLog {
+		if errAudit := audit.Configure(cfg); errAudit != nil {
+			log.Errorf("audit log disabled: %v", errAudit)
+		}
+	}
+
+	if oldCfg == nil || !reflect.DeepEqual(oldCfg.SharedCache, cfg.SharedCache) {
+		if err := cache.Configure(cfg); err != nil {
+			log.Errorf("failed to configure shared cache: %v", err)
+		}
+	}
+
+	if oldCfg == nil || oldCfg.SessionTranscripts != cfg.SessionTranscripts {
+		logging.ConfigureSessionTranscripts(cfg)
+	}
+
+	if oldCfg == nil || oldCfg.RequestRetention != cfg.RequestRetention {
+		logging.ConfigureRequestRetention(cfg)
+	}
+
 	if oldCfg == nil || oldCfg.UsageStatisticsEnabled != cfg.UsageStatisticsEnabled {
 		redisqueue.SetUsageStatisticsEnabled(cfg.UsageStatisticsEnabled)
 	}
@@ -1881,6 +2080,7 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 	}
 
 	applySignatureCacheConfig(oldCfg, cfg)
+	applySystemMessagePolicy(oldCfg, cfg)
 
 	if s.handlers != nil && s.handlers.AuthManager != nil {
 		s.handlers.AuthManager.SetRetryConfig(cfg.RequestRetry, time.Duration(cfg.MaxRetryInterval)*time.Second, cfg.MaxRetryCredentials)
@@ -1938,6 +2138,7 @@ func (s *Server) UpdateClients(cfg *config.Config) {
 		s.wsAuthChanged(oldCfg.WebsocketAuth, cfg.WebsocketAuth)
 	}
 	managementasset.SetCurrentConfig(cfg)
+	selfupdate.SetCurrentConfig(cfg)
 	// Save YAML snapshot for next comparison
 	s.oldConfigYaml, _ = yaml.Marshal(cfg)
 
@@ -2018,6 +2219,21 @@ func AuthMiddleware(manager *sdkaccess.Manager) gin.HandlerFunc {
 				if len(result.Metadata) > 0 {
 					c.Set("accessMetadata", result.Metadata)
 				}
+				if decision := ratelimit.AllowInbound(c.Request.Context(), result.Principal); !decision.Allowed {
+					writeRateLimited(c, decision)
+					return
+				}
+			}
+			reservation, retryAfter, admitted := memguard.Admit(c.Request.ContentLength)
+			if !admitted {
+				writeMemoryPressure(c, retryAfter)
+				return
+			}
+			if reservation != nil {
+				defer reservation.Release()
+				if c.Request.ContentLength < 0 {
+					c.Request.Body = memguard.TrackBody(c.Request.Body, reservation)
+				}
 			}
 			c.Next()
 			return
@@ -2031,6 +2247,28 @@ func AuthMiddleware(manager *sdkaccess.Manager) gin.HandlerFunc {
 	}
 }
 
+// writeRateLimited rejects a request whose API key exceeded its inbound rate limit.
+func writeRateLimited(c *gin.Context, decision ratelimit.Decision) {
+	retryAfter := int(math.Ceil(decision.RetryAfter.Seconds()))
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	c.Header("X-RateLimit-Limit", strconv.Itoa(decision.Limit))
+	c.Header("X-RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+	c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "rate limit exceeded"})
+}
+
+// writeMemoryPressure rejects a request while the process is above its memory watermark.
+func writeMemoryPressure(c *gin.Context, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "server is under memory pressure, retry later"})
+}
+
 func configuredSignatureCacheEnabled(cfg *config.Config) bool {
 	if cfg != nil && cfg.AntigravitySignatureCacheEnabled != nil {
 		return *cfg.AntigravitySignatureCacheEnabled
@@ -2058,6 +2296,17 @@ func applySignatureCacheConfig(oldCfg, cfg *config.Config) {
 	}
 }
 
+func applySystemMessagePolicy(oldCfg, cfg *config.Config) {
+	if cfg == nil || (oldCfg != nil && oldCfg.SystemMessagePolicy == cfg.SystemMessagePolicy) {
+		return
+	}
+	policy, ok := translatorcommon.ParseSystemMessagePolicy(cfg.SystemMessagePolicy)
+	if !ok {
+		log.Warnf("unknown system-message-policy %q, using %q", cfg.SystemMessagePolicy, policy)
+	}
+	translatorcommon.SetSystemMessagePolicy(policy)
+}
+
 func configuredSignatureBypassStrict(cfg *config.Config) bool {
 	if cfg != nil && cfg.AntigravitySignatureBypassStrict != nil {
 		return *cfg.AntigravitySignatureBypassStrict
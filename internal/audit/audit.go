@@ -0,0 +1,175 @@
+// Package audit writes one structured JSON line per proxied request to a dedicated audit log,
+// separate from the debug and request logs.
+//
+// A line records the request's trace ID, a hash of the client API key, the requested model, the
+// provider and auth that served it, a latency breakdown, token usage and the response status.
+// Provider, auth, latency and token details are collected from the usage records the executors
+// publish for the request.
+package audit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
+)
+
+const (
+	// usagePluginName registers the audit log among the named usage plugins.
+	usagePluginName  = "audit-log"
+	defaultFileName  = "audit.log"
+	defaultMaxSizeMB = 100
+)
+
+// Entry is one audit log line.
+type Entry struct {
+	Time          time.Time `json:"time"`
+	TraceID       string    `json:"trace_id"`
+	Method        string    `json:"method"`
+	Path          string    `json:"path"`
+	Status        int       `json:"status"`
+	ClientKeyHash string    `json:"client_key_hash,omitempty"`
+	Model         string    `json:"model,omitempty"`
+	Provider      string    `json:"provider,omitempty"`
+	AuthID        string    `json:"auth_id,omitempty"`
+	// Attempts counts the upstream attempts, including retries on other credentials.
+	Attempts int     `json:"attempts"`
+	Latency  Latency `json:"latency_ms"`
+	Tokens   Tokens  `json:"tokens"`
+}
+
+// Latency breaks the time spent on a request down in milliseconds. Overhead is the time spent in
+// the proxy outside upstream calls, including admission queueing.
+type Latency struct {
+	Total    int64 `json:"total"`
+	Upstream int64 `json:"upstream"`
+	TTFT     int64 `json:"ttft,omitempty"`
+	Overhead int64 `json:"overhead"`
+}
+
+// Tokens is the token usage reported for a request.
+type Tokens struct {
+	Input     int64 `json:"input"`
+	Output    int64 `json:"output"`
+	Reasoning int64 `json:"reasoning,omitempty"`
+	Cached    int64 `json:"cached,omitempty"`
+	Total     int64 `json:"total"`
+}
+
+// logger serializes audit lines to one output.
+type logger struct {
+	mu  sync.Mutex
+	out io.Writer
+	// closer is nil for stdout.
+	closer io.Closer
+}
+
+var active atomic.Pointer[logger]
+
+// Configure applies the audit-log configuration. A nil cfg or a disabled section turns the audit
+// log off.
+func Configure(cfg *config.Config) error {
+	var next *logger
+	if cfg != nil && cfg.AuditLog.Enable {
+		created, errCreate := newLogger(cfg)
+		if errCreate != nil {
+			swap(nil)
+			return errCreate
+		}
+		next = created
+	}
+	swap(next)
+	return nil
+}
+
+func swap(next *logger) {
+	previous := active.Swap(next)
+	if next != nil {
+		coreusage.RegisterNamedPlugin(usagePluginName, usagePlugin{})
+	} else {
+		coreusage.UnregisterNamedPlugin(usagePluginName)
+	}
+	if previous != nil && previous.closer != nil {
+		previous.mu.Lock()
+		errClose := previous.closer.Close()
+		previous.mu.Unlock()
+		if errClose != nil {
+			log.Debugf("audit log: close previous output: %v", errClose)
+		}
+	}
+}
+
+// Enabled reports whether the audit log is on.
+func Enabled() bool {
+	return active.Load() != nil
+}
+
+func newLogger(cfg *config.Config) (*logger, error) {
+	settings := cfg.AuditLog
+	switch output := strings.ToLower(strings.TrimSpace(settings.Output)); output {
+	case "", "stdout":
+		return &logger{out: os.Stdout}, nil
+	case "file":
+	default:
+		return nil, fmt.Errorf("audit log: unknown output %q", settings.Output)
+	}
+	path := strings.TrimSpace(settings.File)
+	if path == "" {
+		path = defaultFileName
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(logging.ResolveLogDirectory(cfg), path)
+	}
+	if errMkdir := os.MkdirAll(filepath.Dir(path), 0o755); errMkdir != nil {
+		return nil, fmt.Errorf("audit log: create directory: %w", errMkdir)
+	}
+	maxSize := settings.MaxSizeMB
+	if maxSize <= 0 {
+		maxSize = defaultMaxSizeMB
+	}
+	writer := &lumberjack.Logger{
+		Filename:   path,
+		MaxSize:    maxSize,
+		MaxBackups: max(settings.MaxBackups, 0),
+	}
+	log.Infof("audit log writing to %s", path)
+	return &logger{out: writer, closer: writer}, nil
+}
+
+// write appends entry to the audit log, if it is on.
+func write(entry Entry) {
+	l := active.Load()
+	if l == nil {
+		return
+	}
+	line, errMarshal := json.Marshal(entry)
+	if errMarshal != nil {
+		log.Warnf("audit log: encode entry: %v", errMarshal)
+		return
+	}
+	line = append(line, '\n')
+	l.mu.Lock()
+	_, errWrite := l.out.Write(line)
+	l.mu.Unlock()
+	if errWrite != nil && !errors.Is(errWrite, os.ErrClosed) {
+		log.Warnf("audit log: write entry: %v", errWrite)
+	}
+}
+
+// KeyHash hashes a client API key so audit lines identify the caller without naming the key. It
+// matches the hash request logs are tagged with.
+func KeyHash(apiKey string) string {
+	return logging.ClientKeyHash(apiKey)
+}
@@ -0,0 +1,135 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+func enableAuditFile(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "audit.log")
+	cfg := &config.Config{AuditLog: config.AuditLogConfig{Enable: true, Output: "file", File: path}}
+	if errConfigure := Configure(cfg); errConfigure != nil {
+		t.Fatalf("Configure() error = %v", errConfigure)
+	}
+	t.Cleanup(func() { _ = Configure(nil) })
+	return path
+}
+
+func readEntries(t *testing.T, path string) []Entry {
+	t.Helper()
+	file, errOpen := os.Open(path)
+	if errors.Is(errOpen, os.ErrNotExist) {
+		return nil
+	}
+	if errOpen != nil {
+		t.Fatalf("open audit log: %v", errOpen)
+	}
+	defer func() { _ = file.Close() }()
+	var entries []Entry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry Entry
+		if errDecode := json.Unmarshal(scanner.Bytes(), &entry); errDecode != nil {
+			t.Fatalf("decode line %q: %v", scanner.Text(), errDecode)
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+func newAuditEngine(handler gin.HandlerFunc) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(Middleware(nil, func(*gin.Context) (string, string) { return "fallback", "fallback-auth" }))
+	engine.POST("/v1/chat/completions", func(c *gin.Context) {
+		c.Set("userApiKey", "client-key")
+		handler(c)
+	})
+	return engine
+}
+
+func TestMiddlewareWritesEntryWithUsage(t *testing.T) {
+	path := enableAuditFile(t)
+	engine := newAuditEngine(func(c *gin.Context) {
+		ctx := c.Request.Context()
+		usagePlugin{}.HandleUsage(ctx, coreusage.Record{
+			Provider: "codex", AuthID: "codex-a", Model: "gpt-5", Alias: "my-model",
+			RequestID: logging.GetRequestID(ctx), Failed: true, Latency: 10 * time.Millisecond,
+		})
+		usagePlugin{}.HandleUsage(ctx, coreusage.Record{
+			Provider: "codex", AuthID: "codex-b", Model: "gpt-5", Alias: "my-model",
+			RequestID: logging.GetRequestID(ctx), Latency: 40 * time.Millisecond, TTFT: 5 * time.Millisecond,
+			Detail: coreusage.Detail{InputTokens: 12, OutputTokens: 3, TotalTokens: 15},
+		})
+		c.String(http.StatusOK, "ok")
+	})
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil))
+	traceID := rec.Header().Get(HeaderTraceID)
+	if traceID == "" {
+		t.Fatal("missing trace ID header")
+	}
+
+	entries := readEntries(t, path)
+	if len(entries) != 1 {
+		t.Fatalf("entries = %+v, want one", entries)
+	}
+	got := entries[0]
+	if got.TraceID != traceID || got.Status != http.StatusOK || got.Model != "my-model" {
+		t.Fatalf("entry = %+v", got)
+	}
+	if got.Provider != "codex" || got.AuthID != "codex-b" || got.Attempts != 2 {
+		t.Fatalf("serving = %s/%s after %d attempts, want codex/codex-b after 2", got.Provider, got.AuthID, got.Attempts)
+	}
+	if got.ClientKeyHash != KeyHash("client-key") || got.ClientKeyHash == "client-key" {
+		t.Fatalf("client key hash = %q", got.ClientKeyHash)
+	}
+	if got.Tokens.Input != 12 || got.Tokens.Output != 3 || got.Tokens.Total != 15 {
+		t.Fatalf("tokens = %+v", got.Tokens)
+	}
+	if got.Latency.Upstream != 50 || got.Latency.TTFT != 5 {
+		t.Fatalf("latency = %+v", got.Latency)
+	}
+}
+
+func TestMiddlewareWritesEntryWithoutUsageAfterGrace(t *testing.T) {
+	previousGrace := usageGrace
+	usageGrace = 10 * time.Millisecond
+	t.Cleanup(func() { usageGrace = previousGrace })
+	path := enableAuditFile(t)
+	engine := newAuditEngine(func(c *gin.Context) {
+		c.String(http.StatusForbidden, "denied")
+	})
+
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil))
+
+	deadline := time.Now().Add(2 * time.Second)
+	var entries []Entry
+	for time.Now().Before(deadline) {
+		if entries = readEntries(t, path); len(entries) > 0 {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("entries = %+v, want one", entries)
+	}
+	if got := entries[0]; got.Status != http.StatusForbidden || got.Provider != "fallback" || got.AuthID != "fallback-auth" || got.Attempts != 0 {
+		t.Fatalf("entry = %+v", got)
+	}
+}
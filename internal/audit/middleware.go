@@ -0,0 +1,164 @@
+package audit
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+// HeaderTraceID returns the trace ID of a request to the client, for correlation with its
+// audit line.
+const HeaderTraceID = "X-CLIProxy-Trace-ID"
+
+// usageGrace is how long a finished request waits for usage records still queued for delivery
+// before its line is written without them.
+var usageGrace = 2 * time.Second
+
+// ServingFunc reports the provider and auth ID that served the request behind c.
+type ServingFunc func(c *gin.Context) (provider string, authID string)
+
+// pending holds the entries of requests in flight, by trace ID, for the usage plugin.
+var pending sync.Map
+
+// Middleware writes an audit line for each request accepted by include while the audit log is
+// on. serving is consulted for requests that published no successful usage record.
+func Middleware(include func(*http.Request) bool, serving ServingFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !Enabled() || (include != nil && !include(c.Request)) {
+			c.Next()
+			return
+		}
+		traceID := logging.GetGinRequestID(c)
+		if traceID == "" {
+			traceID = logging.GenerateRequestID()
+			logging.SetGinRequestID(c, traceID)
+			c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), traceID))
+		}
+		c.Header(HeaderTraceID, traceID)
+		start := time.Now()
+		entry := &pendingEntry{entry: Entry{Time: start, TraceID: traceID, Method: c.Request.Method, Path: c.Request.URL.Path}}
+		pending.Store(traceID, entry)
+		defer func() {
+			var provider, authID string
+			if serving != nil {
+				provider, authID = serving(c)
+			}
+			entry.finish(c.Writer.Status(), time.Since(start), KeyHash(c.GetString("userApiKey")), provider, authID)
+		}()
+		c.Next()
+	}
+}
+
+// pendingEntry collects the audit line of one request until it is written.
+type pendingEntry struct {
+	mu       sync.Mutex
+	entry    Entry
+	total    time.Duration
+	upstream time.Duration
+	ttft     time.Duration
+	// served is set by the first successful usage record.
+	served   bool
+	finished bool
+	written  bool
+	timer    *time.Timer
+}
+
+// finish records the response of the request. The line is written now when a successful usage
+// record already arrived, and otherwise once one arrives or usageGrace passes.
+func (p *pendingEntry) finish(status int, total time.Duration, keyHash, provider, authID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.finished = true
+	p.total = total
+	p.entry.Status = status
+	p.entry.ClientKeyHash = keyHash
+	if p.entry.Provider == "" {
+		p.entry.Provider = provider
+	}
+	if p.entry.AuthID == "" {
+		p.entry.AuthID = authID
+	}
+	if p.served {
+		p.writeLocked()
+		return
+	}
+	p.timer = time.AfterFunc(usageGrace, func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.writeLocked()
+	})
+}
+
+// addUsage folds one upstream attempt into the entry.
+func (p *pendingEntry) addUsage(record coreusage.Record) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.written {
+		return
+	}
+	p.entry.Attempts++
+	p.upstream += record.Latency
+	if p.entry.Model == "" {
+		p.entry.Model = strings.TrimSpace(record.Alias)
+		if p.entry.Model == "" {
+			p.entry.Model = strings.TrimSpace(record.Model)
+		}
+	}
+	if record.Failed {
+		return
+	}
+	p.served = true
+	p.entry.Provider = record.Provider
+	p.entry.AuthID = record.AuthID
+	if record.TTFT > 0 && p.ttft == 0 {
+		p.ttft = record.TTFT
+	}
+	p.entry.Tokens.Input += record.Detail.InputTokens
+	p.entry.Tokens.Output += record.Detail.OutputTokens
+	p.entry.Tokens.Reasoning += record.Detail.ReasoningTokens
+	p.entry.Tokens.Cached += record.Detail.CachedTokens
+	p.entry.Tokens.Total += record.Detail.TotalTokens
+	if p.finished {
+		p.writeLocked()
+	}
+}
+
+func (p *pendingEntry) writeLocked() {
+	if p.written {
+		return
+	}
+	p.written = true
+	if p.timer != nil {
+		p.timer.Stop()
+	}
+	pending.CompareAndDelete(p.entry.TraceID, p)
+	p.entry.Latency = Latency{
+		Total:    p.total.Milliseconds(),
+		Upstream: p.upstream.Milliseconds(),
+		TTFT:     p.ttft.Milliseconds(),
+		Overhead: max(p.total-p.upstream, 0).Milliseconds(),
+	}
+	write(p.entry)
+}
+
+// usagePlugin attaches usage records to the audit entries of their requests.
+type usagePlugin struct{}
+
+func (usagePlugin) HandleUsage(ctx context.Context, record coreusage.Record) {
+	traceID := strings.TrimSpace(record.RequestID)
+	if traceID == "" {
+		traceID = logging.GetRequestID(ctx)
+	}
+	if traceID == "" {
+		return
+	}
+	if value, ok := pending.Load(traceID); ok {
+		value.(*pendingEntry).addUsage(record)
+	}
+}
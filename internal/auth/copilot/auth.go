@@ -57,6 +57,23 @@ type GitHubUserResponse struct {
 	Name  string `json:"name"`
 }
 
+// CopilotQuotaSnapshot is one quota bucket of a Copilot account, e.g. premium_interactions.
+type CopilotQuotaSnapshot struct {
+	Entitlement      float64 `json:"entitlement"`
+	Remaining        float64 `json:"remaining"`
+	PercentRemaining float64 `json:"percent_remaining"`
+	Unlimited        bool    `json:"unlimited"`
+	OverageCount     float64 `json:"overage_count"`
+	OveragePermitted bool    `json:"overage_permitted"`
+}
+
+// CopilotUsageResponse represents the quota part of GitHub's Copilot user endpoint.
+type CopilotUsageResponse struct {
+	Plan           string                          `json:"copilot_plan,omitempty"`
+	QuotaResetDate string                          `json:"quota_reset_date,omitempty"`
+	QuotaSnapshots map[string]CopilotQuotaSnapshot `json:"quota_snapshots,omitempty"`
+}
+
 // CopilotAuth handles the GitHub Copilot OAuth2 device code authentication flow.
 type CopilotAuth struct {
 	httpClient    *http.Client
@@ -403,6 +420,42 @@ func (a *CopilotAuth) GetGitHubUser(ctx context.Context, githubToken string) (*G
 	return &user, nil
 }
 
+// GetCopilotUsage fetches the plan and quota snapshots (chat, completions, premium
+// interactions) of the Copilot account behind githubToken.
+func (a *CopilotAuth) GetCopilotUsage(ctx context.Context, githubToken string) (*CopilotUsageResponse, error) {
+	if githubToken == "" {
+		return nil, ErrNoGitHubToken
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", GitHubAPIBaseURL+CopilotUserPath, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range GitHubHeaders(githubToken, a.vsCodeVersion) {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to get copilot usage: status %d, body: %s", resp.StatusCode, string(body))
+	}
+
+	var usage CopilotUsageResponse
+	if err = json.Unmarshal(body, &usage); err != nil {
+		return nil, err
+	}
+	return &usage, nil
+}
+
 // CopilotModel represents a model available through the Copilot API.
 type CopilotModel struct {
 	ID                 string              `json:"id"`
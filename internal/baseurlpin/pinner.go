@@ -0,0 +1,270 @@
+// Package baseurlpin probes the regional base URLs of credentials and pins each credential to
+// its fastest healthy endpoint.
+package baseurlpin
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/httpfetch"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultInterval = 5 * time.Minute
+	minInterval     = 30 * time.Second
+	defaultTimeout  = 5 * time.Second
+	// idleTTL drops endpoint groups no credential has used for this long.
+	idleTTL = 24 * time.Hour
+	// switchRatio is how much faster another healthy endpoint must be before a pinned, healthy
+	// endpoint is replaced, so similar latencies do not flap the pin.
+	switchRatio = 0.8
+	maxAuthIDs  = 64
+)
+
+// Endpoint is the last probe result of one base URL.
+type Endpoint struct {
+	URL       string    `json:"url"`
+	Healthy   bool      `json:"healthy"`
+	LatencyMs int64     `json:"latency_ms,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at,omitzero"`
+}
+
+// Group is a set of interchangeable base URLs and the endpoint currently pinned.
+type Group struct {
+	Pinned    string     `json:"pinned"`
+	AuthIDs   []string   `json:"auth_ids"`
+	Endpoints []Endpoint `json:"endpoints"`
+}
+
+type group struct {
+	candidates []string
+	endpoints  map[string]*Endpoint
+	pinned     string
+	authIDs    map[string]struct{}
+	lastUsed   time.Time
+	probing    bool
+}
+
+// Pinner tracks endpoint groups. The zero value pins every group to its first candidate until
+// Apply starts probing.
+type Pinner struct {
+	mu     sync.Mutex
+	groups map[string]*group
+	ctx    context.Context
+
+	runMu   sync.Mutex
+	cfg     config.BaseURLPinningConfig
+	cancel  context.CancelFunc
+	timeout time.Duration
+	client  httpfetch.Doer
+}
+
+// Default is the pinner configured from base-url-pinning.
+var Default = &Pinner{}
+
+// Apply starts or restarts probing for cfg. Known groups and their pins are kept.
+func (p *Pinner) Apply(cfg config.BaseURLPinningConfig) {
+	p.runMu.Lock()
+	defer p.runMu.Unlock()
+	if p.cancel != nil && p.cfg == cfg {
+		return
+	}
+	if p.cancel != nil {
+		p.cancel()
+	}
+	p.cfg = cfg
+	interval := defaultInterval
+	if cfg.ProbeIntervalSeconds > 0 {
+		interval = max(time.Duration(cfg.ProbeIntervalSeconds)*time.Second, minInterval)
+	}
+	timeout := defaultTimeout
+	if cfg.ProbeTimeoutSeconds > 0 {
+		timeout = time.Duration(cfg.ProbeTimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	p.mu.Lock()
+	p.ctx = ctx
+	p.timeout = timeout
+	p.mu.Unlock()
+	go p.run(ctx, interval)
+}
+
+// Stop stops probing. Pins stay at their last value.
+func (p *Pinner) Stop() {
+	p.runMu.Lock()
+	defer p.runMu.Unlock()
+	if p.cancel != nil {
+		p.cancel()
+		p.cancel = nil
+	}
+	p.cfg = config.BaseURLPinningConfig{}
+	p.mu.Lock()
+	p.ctx = nil
+	p.mu.Unlock()
+}
+
+// PinBaseURL returns the endpoint authID should use among candidates. A group seen for the
+// first time is probed in the background and served from its first candidate meanwhile.
+func (p *Pinner) PinBaseURL(authID string, candidates []string) string {
+	if len(candidates) == 0 {
+		return ""
+	}
+	key := strings.Join(candidates, "\n")
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.groups == nil {
+		p.groups = make(map[string]*group)
+	}
+	g, ok := p.groups[key]
+	if !ok {
+		g = &group{
+			candidates: append([]string(nil), candidates...),
+			endpoints:  make(map[string]*Endpoint, len(candidates)),
+			pinned:     candidates[0],
+			authIDs:    make(map[string]struct{}),
+		}
+		for _, url := range candidates {
+			g.endpoints[url] = &Endpoint{URL: url}
+		}
+		p.groups[key] = g
+		if p.ctx != nil {
+			g.probing = true
+			go p.probe(p.ctx, g, p.timeout)
+		}
+	}
+	g.lastUsed = time.Now()
+	if _, known := g.authIDs[authID]; !known && authID != "" && len(g.authIDs) < maxAuthIDs {
+		g.authIDs[authID] = struct{}{}
+	}
+	return g.pinned
+}
+
+func (p *Pinner) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		p.mu.Lock()
+		cutoff := time.Now().Add(-idleTTL)
+		var due []*group
+		for key, g := range p.groups {
+			if g.lastUsed.Before(cutoff) {
+				delete(p.groups, key)
+				continue
+			}
+			if !g.probing {
+				g.probing = true
+				due = append(due, g)
+			}
+		}
+		timeout := p.timeout
+		p.mu.Unlock()
+		for _, g := range due {
+			p.probe(ctx, g, timeout)
+		}
+	}
+}
+
+// probe measures every candidate of g and moves the pin when the pinned endpoint is unhealthy
+// or clearly slower than the fastest healthy one.
+func (p *Pinner) probe(ctx context.Context, g *group, timeout time.Duration) {
+	results := make([]Endpoint, len(g.candidates))
+	var wg sync.WaitGroup
+	for i, url := range g.candidates {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = p.measure(ctx, url, timeout)
+		}()
+	}
+	wg.Wait()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	g.probing = false
+	if ctx.Err() != nil {
+		return
+	}
+	var fastest *Endpoint
+	for i := range results {
+		result := results[i]
+		*g.endpoints[result.URL] = result
+		if result.Healthy && (fastest == nil || result.LatencyMs < fastest.LatencyMs) {
+			fastest = g.endpoints[result.URL]
+		}
+	}
+	if fastest == nil || fastest.URL == g.pinned {
+		return
+	}
+	current := g.endpoints[g.pinned]
+	if current.Healthy && float64(fastest.LatencyMs) > float64(current.LatencyMs)*switchRatio {
+		return
+	}
+	log.Infof("base url pinning: %s -> %s (%d ms)", g.pinned, fastest.URL, fastest.LatencyMs)
+	g.pinned = fastest.URL
+}
+
+// measure times a GET of url. Any response below 500 counts as healthy: the probe checks that the
+// region answers, not that the unauthenticated request succeeds.
+func (p *Pinner) measure(ctx context.Context, url string, timeout time.Duration) Endpoint {
+	result := Endpoint{URL: url, CheckedAt: time.Now()}
+	client := p.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, errRequest := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if errRequest != nil {
+		result.Error = errRequest.Error()
+		return result
+	}
+	start := time.Now()
+	resp, errDo := client.Do(req)
+	if errDo != nil {
+		result.Error = errDo.Error()
+		return result
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	_ = resp.Body.Close()
+	result.LatencyMs = max(time.Since(start).Milliseconds(), 1)
+	if resp.StatusCode >= http.StatusInternalServerError {
+		result.Error = http.StatusText(resp.StatusCode)
+		return result
+	}
+	result.Healthy = true
+	return result
+}
+
+// Snapshot returns the known groups ordered by pinned endpoint.
+func (p *Pinner) Snapshot() []Group {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	out := make([]Group, 0, len(p.groups))
+	for _, g := range p.groups {
+		entry := Group{Pinned: g.pinned, AuthIDs: make([]string, 0, len(g.authIDs)), Endpoints: make([]Endpoint, 0, len(g.candidates))}
+		for id := range g.authIDs {
+			entry.AuthIDs = append(entry.AuthIDs, id)
+		}
+		sort.Strings(entry.AuthIDs)
+		for _, url := range g.candidates {
+			entry.Endpoints = append(entry.Endpoints, *g.endpoints[url])
+		}
+		out = append(out, entry)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Pinned < out[j].Pinned })
+	return out
+}
@@ -0,0 +1,72 @@
+package baseurlpin
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestProbePinsFastestHealthyEndpoint(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer slow.Close()
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer fast.Close()
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer broken.Close()
+
+	p := &Pinner{}
+	candidates := []string{slow.URL, broken.URL, fast.URL}
+	if got := p.PinBaseURL("auth-1", candidates); got != slow.URL {
+		t.Fatalf("before probing got %q, want the first candidate", got)
+	}
+	g := p.groups[slow.URL+"\n"+broken.URL+"\n"+fast.URL]
+	p.probe(context.Background(), g, time.Second)
+
+	if got := p.PinBaseURL("auth-1", candidates); got != fast.URL {
+		t.Fatalf("after probing got %q, want %q", got, fast.URL)
+	}
+	groups := p.Snapshot()
+	if len(groups) != 1 || groups[0].Endpoints[1].Healthy || !groups[0].Endpoints[2].Healthy {
+		t.Fatalf("unexpected snapshot: %+v", groups)
+	}
+}
+
+func TestProbeKeepsPinWhenLatenciesAreClose(t *testing.T) {
+	p := &Pinner{}
+	candidates := []string{"https://a.example.com", "https://b.example.com"}
+	p.PinBaseURL("auth-1", candidates)
+	g := p.groups["https://a.example.com\nhttps://b.example.com"]
+	*g.endpoints["https://a.example.com"] = Endpoint{URL: "https://a.example.com", Healthy: true, LatencyMs: 100}
+
+	p.client = doerFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Host == "a.example.com" {
+			time.Sleep(20 * time.Millisecond)
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	})
+	p.probe(context.Background(), g, time.Second)
+	if g.pinned != "https://b.example.com" {
+		t.Fatalf("pinned %q, want the clearly faster endpoint", g.pinned)
+	}
+
+	p.client = doerFunc(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody}, nil
+	})
+	p.probe(context.Background(), g, time.Second)
+	if g.pinned != "https://b.example.com" {
+		t.Fatalf("pin moved to %q on similar latency", g.pinned)
+	}
+}
+
+type doerFunc func(*http.Request) (*http.Response, error)
+
+func (f doerFunc) Do(req *http.Request) (*http.Response, error) { return f(req) }
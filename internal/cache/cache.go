@@ -0,0 +1,116 @@
+package cache
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// BackendMemory keeps entries in process memory.
+	BackendMemory = "memory"
+	// BackendRedis keeps entries in Redis, shared by every replica.
+	BackendRedis = "redis"
+)
+
+// Cache is a bounded key/value store whose entries expire. Implementations must be safe for
+// concurrent use.
+type Cache[V any] interface {
+	// Get returns the live value stored under key.
+	Get(key string) (V, bool)
+	// Set stores value under key with the cache's default TTL.
+	Set(key string, value V)
+	// SetWithTTL stores value under key for ttl; ttl <= 0 uses the cache's default TTL.
+	SetWithTTL(key string, value V, ttl time.Duration)
+	// Delete removes key.
+	Delete(key string)
+	// Clear removes every entry.
+	Clear()
+	// Len returns the number of entries held in process memory.
+	Len() int
+	// Stats returns a snapshot of the cache's counters.
+	Stats() Stats
+}
+
+// Options configures a cache built by New.
+type Options struct {
+	// Name identifies the cache in Stats. Named caches are listed by Snapshot; a later cache
+	// registered under the same name replaces the earlier one.
+	Name string
+	// TTL is the default entry lifetime. 0 keeps entries until they are evicted.
+	TTL time.Duration
+	// MaxEntries bounds the in-memory entry count; the least recently used entry is evicted
+	// first. 0 leaves the cache unbounded.
+	MaxEntries int
+	// Shared stores entries in the shared-cache backend when one is configured, so every replica
+	// sees them. Values must round-trip through encoding/json.
+	Shared bool
+}
+
+// Stats is a point-in-time view of a cache's counters.
+type Stats struct {
+	Name       string `json:"name"`
+	Backend    string `json:"backend"`
+	Entries    int    `json:"entries"`
+	MaxEntries int    `json:"max_entries,omitempty"`
+	TTLSeconds int64  `json:"ttl_seconds,omitempty"`
+	Hits       uint64 `json:"hits"`
+	Misses     uint64 `json:"misses"`
+	Sets       uint64 `json:"sets"`
+	// Evictions counts entries dropped to stay within MaxEntries.
+	Evictions uint64 `json:"evictions"`
+	// Expirations counts entries dropped because their TTL passed.
+	Expirations uint64 `json:"expirations"`
+	// Errors counts failed shared-backend operations; those fall back to process memory.
+	Errors uint64 `json:"errors,omitempty"`
+}
+
+// New builds a cache described by opts. Shared caches use the configured shared-cache backend
+// and fall back to process memory while none is configured or it is unreachable.
+func New[V any](opts Options) Cache[V] {
+	var c Cache[V]
+	if opts.Shared {
+		c = newSharedCache[V](opts)
+	} else {
+		c = NewMemory[V](opts)
+	}
+	register(opts.Name, c)
+	return c
+}
+
+type statsProvider interface {
+	Stats() Stats
+}
+
+var (
+	namedCachesMu sync.RWMutex
+	namedCaches   = make(map[string]statsProvider)
+)
+
+func register(name string, c statsProvider) {
+	if name == "" {
+		return
+	}
+	namedCachesMu.Lock()
+	namedCaches[name] = c
+	namedCachesMu.Unlock()
+}
+
+// Unregister removes the named cache from Snapshot.
+func Unregister(name string) {
+	namedCachesMu.Lock()
+	delete(namedCaches, name)
+	namedCachesMu.Unlock()
+}
+
+// Snapshot returns the stats of every named cache, sorted by name.
+func Snapshot() []Stats {
+	namedCachesMu.RLock()
+	out := make([]Stats, 0, len(namedCaches))
+	for _, c := range namedCaches {
+		out = append(out, c.Stats())
+	}
+	namedCachesMu.RUnlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
@@ -0,0 +1,93 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestMemoryCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewMemory[int](Options{Name: "lru", MaxEntries: 2})
+	c.Set("a", 1)
+	c.Set("b", 2)
+	if _, ok := c.Get("a"); !ok {
+		t.Fatal("expected a to be cached")
+	}
+	c.Set("c", 3)
+
+	if _, ok := c.Get("b"); ok {
+		t.Fatal("expected b to be evicted as least recently used")
+	}
+	if v, ok := c.Get("a"); !ok || v != 1 {
+		t.Fatalf("Get(a) = %d, %v; want 1, true", v, ok)
+	}
+	stats := c.Stats()
+	if stats.Entries != 2 || stats.Evictions != 1 || stats.Hits != 2 || stats.Misses != 1 || stats.Sets != 3 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestMemoryCacheExpiresEntries(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	c := NewMemory[string](Options{TTL: time.Minute})
+	c.now = func() time.Time { return now }
+
+	c.Set("default", "x")
+	c.SetWithTTL("short", "y", time.Second)
+	now = now.Add(2 * time.Second)
+	if _, ok := c.Get("short"); ok {
+		t.Fatal("expected short-lived entry to expire")
+	}
+	if _, ok := c.Get("default"); !ok {
+		t.Fatal("expected default-TTL entry to be live")
+	}
+	now = now.Add(time.Minute)
+	if _, ok := c.Get("default"); ok {
+		t.Fatal("expected default-TTL entry to expire")
+	}
+	if stats := c.Stats(); stats.Expirations != 2 || stats.Entries != 0 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestSharedCacheFallsBackToMemoryWithoutBackend(t *testing.T) {
+	if errConfigure := Configure(nil); errConfigure != nil {
+		t.Fatalf("Configure(nil) error = %v", errConfigure)
+	}
+	c := New[[]string](Options{Name: "test-shared", TTL: time.Minute, Shared: true})
+	defer Unregister("test-shared")
+
+	c.Set("k", []string{"v"})
+	if v, ok := c.Get("k"); !ok || len(v) != 1 || v[0] != "v" {
+		t.Fatalf("Get(k) = %v, %v", v, ok)
+	}
+	c.Delete("k")
+	if _, ok := c.Get("k"); ok {
+		t.Fatal("expected k to be deleted")
+	}
+
+	var found bool
+	for _, stats := range Snapshot() {
+		if stats.Name == "test-shared" {
+			found = true
+			if stats.Backend != BackendMemory || stats.Hits != 1 || stats.Misses != 1 {
+				t.Fatalf("unexpected stats: %+v", stats)
+			}
+		}
+	}
+	if !found {
+		t.Fatal("expected named cache in Snapshot")
+	}
+}
+
+func TestConfigureRejectsInvalidBackends(t *testing.T) {
+	for _, cfg := range []config.SharedCacheConfig{
+		{Backend: "memcached"},
+		{Backend: BackendRedis},
+	} {
+		if errConfigure := Configure(&config.Config{SharedCache: cfg}); errConfigure == nil {
+			t.Fatalf("Configure(%+v) expected error", cfg)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package cache
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// MemoryCache is an in-process LRU cache with per-entry expiry. Expired entries are dropped
+// lazily when they are read or when the cache needs room.
+type MemoryCache[V any] struct {
+	mu         sync.Mutex
+	name       string
+	ttl        time.Duration
+	maxEntries int
+	order      *list.List
+	entries    map[string]*list.Element
+	stats      Stats
+	now        func() time.Time
+}
+
+type memoryEntry[V any] struct {
+	key       string
+	value     V
+	expiresAt time.Time
+}
+
+// NewMemory builds an unregistered in-memory cache; opts.Shared is ignored.
+func NewMemory[V any](opts Options) *MemoryCache[V] {
+	return &MemoryCache[V]{
+		name:       opts.Name,
+		ttl:        opts.TTL,
+		maxEntries: opts.MaxEntries,
+		order:      list.New(),
+		entries:    make(map[string]*list.Element),
+		now:        time.Now,
+	}
+}
+
+// Get implements Cache.
+func (c *MemoryCache[V]) Get(key string) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var zero V
+	elem, ok := c.entries[key]
+	if !ok {
+		c.stats.Misses++
+		return zero, false
+	}
+	entry := elem.Value.(*memoryEntry[V])
+	if c.expired(entry, c.now()) {
+		c.removeElement(elem)
+		c.stats.Expirations++
+		c.stats.Misses++
+		return zero, false
+	}
+	c.order.MoveToFront(elem)
+	c.stats.Hits++
+	return entry.value, true
+}
+
+// Set implements Cache.
+func (c *MemoryCache[V]) Set(key string, value V) {
+	c.SetWithTTL(key, value, 0)
+}
+
+// SetWithTTL implements Cache.
+func (c *MemoryCache[V]) SetWithTTL(key string, value V, ttl time.Duration) {
+	if ttl <= 0 {
+		ttl = c.ttl
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	var expiresAt time.Time
+	if ttl > 0 {
+		expiresAt = now.Add(ttl)
+	}
+	c.stats.Sets++
+	if elem, ok := c.entries[key]; ok {
+		entry := elem.Value.(*memoryEntry[V])
+		entry.value, entry.expiresAt = value, expiresAt
+		c.order.MoveToFront(elem)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&memoryEntry[V]{key: key, value: value, expiresAt: expiresAt})
+	if c.maxEntries > 0 && c.order.Len() > c.maxEntries {
+		c.makeRoom(now)
+	}
+}
+
+// Delete implements Cache.
+func (c *MemoryCache[V]) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.removeElement(elem)
+	}
+}
+
+// Clear implements Cache.
+func (c *MemoryCache[V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	clear(c.entries)
+}
+
+// Len implements Cache. Expired entries that have not been dropped yet are counted.
+func (c *MemoryCache[V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Stats implements Cache.
+func (c *MemoryCache[V]) Stats() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	stats := c.stats
+	stats.Name = c.name
+	stats.Backend = BackendMemory
+	stats.Entries = c.order.Len()
+	stats.MaxEntries = c.maxEntries
+	stats.TTLSeconds = int64(c.ttl / time.Second)
+	return stats
+}
+
+// makeRoom drops least recently used entries until the cache is within maxEntries. Callers hold
+// c.mu.
+func (c *MemoryCache[V]) makeRoom(now time.Time) {
+	for c.order.Len() > c.maxEntries {
+		elem := c.order.Back()
+		if c.expired(elem.Value.(*memoryEntry[V]), now) {
+			c.stats.Expirations++
+		} else {
+			c.stats.Evictions++
+		}
+		c.removeElement(elem)
+	}
+}
+
+func (c *MemoryCache[V]) expired(entry *memoryEntry[V], now time.Time) bool {
+	return !entry.expiresAt.IsZero() && !now.Before(entry.expiresAt)
+}
+
+func (c *MemoryCache[V]) removeElement(elem *list.Element) {
+	c.order.Remove(elem)
+	delete(c.entries, elem.Value.(*memoryEntry[V]).key)
+}
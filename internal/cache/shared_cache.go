@@ -0,0 +1,208 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/redisclient"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultSharedCacheKeyPrefix = "cliproxy:cache:"
+	sharedCacheOperationTimeout = 500 * time.Millisecond
+)
+
+type redisBackend struct {
+	client *redis.Client
+	prefix string
+}
+
+var activeSharedBackend atomic.Pointer[redisBackend]
+
+// Configure applies the shared-cache configuration. With the redis backend, caches built with
+// Options.Shared keep their entries in Redis; otherwise they stay in process memory.
+func Configure(cfg *config.Config) error {
+	var next *redisBackend
+	if cfg != nil {
+		switch strings.ToLower(strings.TrimSpace(cfg.SharedCache.Backend)) {
+		case "", BackendMemory:
+		case BackendRedis:
+			backend, errBackend := newRedisBackend(cfg.SharedCache.Redis)
+			if errBackend != nil {
+				return errBackend
+			}
+			next = backend
+		default:
+			return fmt.Errorf("cache: unknown shared-cache backend %q", cfg.SharedCache.Backend)
+		}
+	}
+	if previous := activeSharedBackend.Swap(next); previous != nil {
+		if errClose := previous.client.Close(); errClose != nil {
+			log.Debugf("cache: failed to close previous redis client: %v", errClose)
+		}
+	}
+	return nil
+}
+
+func newRedisBackend(cfg config.RedisConfig) (*redisBackend, error) {
+	client, errClient := redisclient.New(cfg, sharedCacheOperationTimeout)
+	if errClient != nil {
+		return nil, fmt.Errorf("cache: %w", errClient)
+	}
+	prefix := cfg.KeyPrefix
+	if strings.TrimSpace(prefix) == "" {
+		prefix = defaultSharedCacheKeyPrefix
+	}
+	return &redisBackend{client: client, prefix: prefix}, nil
+}
+
+// sharedCache stores JSON-encoded entries in the shared Redis backend while one is configured
+// and in process memory otherwise. A failed Redis operation falls back to process memory.
+type sharedCache[V any] struct {
+	name  string
+	ttl   time.Duration
+	local *MemoryCache[V]
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+	sets   atomic.Uint64
+	errors atomic.Uint64
+}
+
+func newSharedCache[V any](opts Options) *sharedCache[V] {
+	name := opts.Name
+	if name == "" {
+		name = "default"
+	}
+	return &sharedCache[V]{name: name, ttl: opts.TTL, local: NewMemory[V](opts)}
+}
+
+func (c *sharedCache[V]) key(backend *redisBackend, key string) string {
+	return backend.prefix + c.name + ":" + key
+}
+
+func (c *sharedCache[V]) failed(op string, err error) {
+	c.errors.Add(1)
+	log.Debugf("cache %s: redis %s failed, using process memory: %v", c.name, op, err)
+}
+
+// Get implements Cache.
+func (c *sharedCache[V]) Get(key string) (V, bool) {
+	backend := activeSharedBackend.Load()
+	if backend == nil {
+		return c.local.Get(key)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sharedCacheOperationTimeout)
+	defer cancel()
+	raw, errGet := backend.client.Get(ctx, c.key(backend, key)).Bytes()
+	var value V
+	switch {
+	case errors.Is(errGet, redis.Nil):
+		c.misses.Add(1)
+		return value, false
+	case errGet != nil:
+		c.failed("get", errGet)
+		return c.local.Get(key)
+	}
+	if errUnmarshal := json.Unmarshal(raw, &value); errUnmarshal != nil {
+		c.failed("decode", errUnmarshal)
+		c.misses.Add(1)
+		return value, false
+	}
+	c.hits.Add(1)
+	return value, true
+}
+
+// Set implements Cache.
+func (c *sharedCache[V]) Set(key string, value V) {
+	c.SetWithTTL(key, value, 0)
+}
+
+// SetWithTTL implements Cache.
+func (c *sharedCache[V]) SetWithTTL(key string, value V, ttl time.Duration) {
+	backend := activeSharedBackend.Load()
+	if backend == nil {
+		c.local.SetWithTTL(key, value, ttl)
+		return
+	}
+	if ttl <= 0 {
+		ttl = c.ttl
+	}
+	raw, errMarshal := json.Marshal(value)
+	if errMarshal != nil {
+		c.failed("encode", errMarshal)
+		c.local.SetWithTTL(key, value, ttl)
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sharedCacheOperationTimeout)
+	defer cancel()
+	if errSet := backend.client.Set(ctx, c.key(backend, key), raw, ttl).Err(); errSet != nil {
+		c.failed("set", errSet)
+		c.local.SetWithTTL(key, value, ttl)
+		return
+	}
+	c.sets.Add(1)
+}
+
+// Delete implements Cache.
+func (c *sharedCache[V]) Delete(key string) {
+	c.local.Delete(key)
+	backend := activeSharedBackend.Load()
+	if backend == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), sharedCacheOperationTimeout)
+	defer cancel()
+	if errDel := backend.client.Del(ctx, c.key(backend, key)).Err(); errDel != nil {
+		c.failed("delete", errDel)
+	}
+}
+
+// Clear implements Cache.
+func (c *sharedCache[V]) Clear() {
+	c.local.Clear()
+	backend := activeSharedBackend.Load()
+	if backend == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*sharedCacheOperationTimeout)
+	defer cancel()
+	iter := backend.client.Scan(ctx, 0, c.key(backend, "*"), 256).Iterator()
+	for iter.Next(ctx) {
+		if errDel := backend.client.Del(ctx, iter.Val()).Err(); errDel != nil {
+			c.failed("clear", errDel)
+			return
+		}
+	}
+	if errScan := iter.Err(); errScan != nil {
+		c.failed("clear", errScan)
+	}
+}
+
+// Len implements Cache. Entries held in Redis are not counted.
+func (c *sharedCache[V]) Len() int {
+	return c.local.Len()
+}
+
+// Stats implements Cache. Redis enforces TTLs itself, so evictions and expirations only cover
+// the process-memory store.
+func (c *sharedCache[V]) Stats() Stats {
+	stats := c.local.Stats()
+	stats.Name = c.name
+	if activeSharedBackend.Load() != nil {
+		stats.Backend = BackendRedis
+	}
+	stats.Hits += c.hits.Load()
+	stats.Misses += c.misses.Load()
+	stats.Sets += c.sets.Load()
+	stats.Errors = c.errors.Load()
+	return stats
+}
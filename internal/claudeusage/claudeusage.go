@@ -0,0 +1,163 @@
+// Package claudeusage parses the unified rate-limit headers Anthropic returns for Claude
+// subscription (Pro/Max) OAuth credentials and keeps the last reported limits per credential.
+package claudeusage
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const headerPrefix = "Anthropic-Ratelimit-Unified-"
+
+// maxEntries bounds the tracked credentials; the least recently updated entry is dropped first.
+const maxEntries = 1024
+
+// Window is the state of one subscription usage window.
+type Window struct {
+	// Status is allowed, allowed_warning or rejected.
+	Status string `json:"status,omitempty"`
+	// Utilization is the consumed fraction of the window, from 0 to 1.
+	Utilization float64   `json:"utilization"`
+	ResetAt     time.Time `json:"reset_at,omitzero"`
+}
+
+// Limits is the usage state reported by one response.
+type Limits struct {
+	// Status is the overall status: allowed, allowed_warning or rejected.
+	Status  string    `json:"status,omitempty"`
+	ResetAt time.Time `json:"reset_at,omitzero"`
+	// Claim names the window that decided Status, such as five_hour or seven_day.
+	Claim    string  `json:"representative_claim,omitempty"`
+	FiveHour *Window `json:"five_hour,omitempty"`
+	SevenDay *Window `json:"seven_day,omitempty"`
+}
+
+// Parse reads the unified rate-limit headers. ok is false when h carries none.
+func Parse(h http.Header) (limits Limits, ok bool) {
+	if h == nil {
+		return Limits{}, false
+	}
+	limits.Status = strings.ToLower(strings.TrimSpace(h.Get(headerPrefix + "Status")))
+	limits.ResetAt = parseUnix(h.Get(headerPrefix + "Reset"))
+	limits.Claim = strings.TrimSpace(h.Get(headerPrefix + "Representative-Claim"))
+	limits.FiveHour = parseWindow(h, "5h")
+	limits.SevenDay = parseWindow(h, "7d")
+	ok = limits.Status != "" || !limits.ResetAt.IsZero() || limits.FiveHour != nil || limits.SevenDay != nil
+	return limits, ok
+}
+
+func parseWindow(h http.Header, name string) *Window {
+	prefix := headerPrefix + name + "-"
+	status := strings.ToLower(strings.TrimSpace(h.Get(prefix + "Status")))
+	rawUtilization := strings.TrimSpace(h.Get(prefix + "Utilization"))
+	resetAt := parseUnix(h.Get(prefix + "Reset"))
+	if status == "" && rawUtilization == "" && resetAt.IsZero() {
+		return nil
+	}
+	window := &Window{Status: status, ResetAt: resetAt}
+	if utilization, errParse := strconv.ParseFloat(rawUtilization, 64); errParse == nil && utilization >= 0 {
+		window.Utilization = utilization
+	}
+	return window
+}
+
+func parseUnix(raw string) time.Time {
+	seconds, errParse := strconv.ParseInt(strings.TrimSpace(raw), 10, 64)
+	if errParse != nil || seconds <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0)
+}
+
+// RetryAfter returns how long a rate-limited credential should cool down. A Retry-After header
+// in seconds wins; otherwise the reset of the rejected subscription window is used. It returns
+// nil when neither is present.
+func RetryAfter(h http.Header, now time.Time) *time.Duration {
+	if h == nil {
+		return nil
+	}
+	if seconds, errParse := strconv.Atoi(strings.TrimSpace(h.Get("Retry-After"))); errParse == nil {
+		d := time.Duration(max(seconds, 0)) * time.Second
+		return &d
+	}
+	limits, ok := Parse(h)
+	if !ok {
+		return nil
+	}
+	resetAt := limits.rejectedReset()
+	if resetAt.IsZero() || !resetAt.After(now) {
+		return nil
+	}
+	d := resetAt.Sub(now)
+	return &d
+}
+
+// rejectedReset returns the latest reset among the rejected windows, falling back to the
+// overall reset when only the overall status is rejected.
+func (l Limits) rejectedReset() time.Time {
+	var resetAt time.Time
+	for _, window := range []*Window{l.FiveHour, l.SevenDay} {
+		if window != nil && window.Status == "rejected" && window.ResetAt.After(resetAt) {
+			resetAt = window.ResetAt
+		}
+	}
+	if resetAt.IsZero() && l.Status == "rejected" {
+		resetAt = l.ResetAt
+	}
+	return resetAt
+}
+
+// Entry is the last reported limits of one credential.
+type Entry struct {
+	AuthID    string    `json:"auth_id"`
+	Label     string    `json:"label,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Limits
+}
+
+var (
+	mu      sync.Mutex
+	entries = make(map[string]*Entry)
+)
+
+// Observe records the limits reported in h for the credential authID. Responses without unified
+// rate-limit headers, such as those for API keys, are ignored.
+func Observe(authID, label string, h http.Header) {
+	authID = strings.TrimSpace(authID)
+	if authID == "" {
+		return
+	}
+	limits, ok := Parse(h)
+	if !ok {
+		return
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if _, exists := entries[authID]; !exists && len(entries) >= maxEntries {
+		var oldestID string
+		var oldest time.Time
+		for id, entry := range entries {
+			if oldestID == "" || entry.UpdatedAt.Before(oldest) {
+				oldestID, oldest = id, entry.UpdatedAt
+			}
+		}
+		delete(entries, oldestID)
+	}
+	entries[authID] = &Entry{AuthID: authID, Label: label, UpdatedAt: time.Now(), Limits: limits}
+}
+
+// Snapshot returns the tracked credentials ordered by auth ID.
+func Snapshot() []Entry {
+	mu.Lock()
+	defer mu.Unlock()
+	out := make([]Entry, 0, len(entries))
+	for _, entry := range entries {
+		out = append(out, *entry)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].AuthID < out[j].AuthID })
+	return out
+}
@@ -0,0 +1,82 @@
+package claudeusage
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestParseReadsSubscriptionWindows(t *testing.T) {
+	h := http.Header{}
+	h.Set("anthropic-ratelimit-unified-status", "allowed_warning")
+	h.Set("anthropic-ratelimit-unified-representative-claim", "five_hour")
+	h.Set("anthropic-ratelimit-unified-5h-status", "allowed_warning")
+	h.Set("anthropic-ratelimit-unified-5h-utilization", "0.92")
+	h.Set("anthropic-ratelimit-unified-5h-reset", "1760000000")
+	h.Set("anthropic-ratelimit-unified-7d-utilization", "0.4")
+
+	limits, ok := Parse(h)
+	if !ok {
+		t.Fatal("expected unified limits")
+	}
+	if limits.Status != "allowed_warning" || limits.Claim != "five_hour" {
+		t.Fatalf("unexpected limits: %+v", limits)
+	}
+	if limits.FiveHour == nil || limits.FiveHour.Utilization != 0.92 || !limits.FiveHour.ResetAt.Equal(time.Unix(1760000000, 0)) {
+		t.Fatalf("unexpected five hour window: %+v", limits.FiveHour)
+	}
+	if limits.SevenDay == nil || limits.SevenDay.Utilization != 0.4 {
+		t.Fatalf("unexpected seven day window: %+v", limits.SevenDay)
+	}
+	if _, ok := Parse(http.Header{"Content-Type": {"application/json"}}); ok {
+		t.Fatal("expected no limits without unified headers")
+	}
+}
+
+func TestRetryAfterUsesRejectedWindowReset(t *testing.T) {
+	now := time.Unix(1760000000, 0)
+	h := http.Header{}
+	h.Set("anthropic-ratelimit-unified-status", "rejected")
+	h.Set("anthropic-ratelimit-unified-reset", "1760000600")
+	h.Set("anthropic-ratelimit-unified-5h-status", "allowed")
+	h.Set("anthropic-ratelimit-unified-5h-reset", "1760000300")
+	h.Set("anthropic-ratelimit-unified-7d-status", "rejected")
+	h.Set("anthropic-ratelimit-unified-7d-reset", "1760003600")
+
+	got := RetryAfter(h, now)
+	if got == nil || *got != time.Hour {
+		t.Fatalf("RetryAfter = %v, want 1h", got)
+	}
+
+	h.Set("Retry-After", "30")
+	if got = RetryAfter(h, now); got == nil || *got != 30*time.Second {
+		t.Fatalf("RetryAfter with header = %v, want 30s", got)
+	}
+
+	allowed := http.Header{}
+	allowed.Set("anthropic-ratelimit-unified-status", "allowed")
+	allowed.Set("anthropic-ratelimit-unified-reset", "1760000600")
+	if got = RetryAfter(allowed, now); got != nil {
+		t.Fatalf("RetryAfter for allowed = %v, want nil", got)
+	}
+}
+
+func TestObserveTracksOnlyUnifiedResponses(t *testing.T) {
+	h := http.Header{}
+	h.Set("anthropic-ratelimit-unified-status", "allowed")
+	Observe("claude-max.json", "max@example.com", h)
+	Observe("claude-key", "", http.Header{"Request-Id": {"req_1"}})
+
+	found := false
+	for _, entry := range Snapshot() {
+		if entry.AuthID == "claude-key" {
+			t.Fatal("API key response without unified headers was tracked")
+		}
+		if entry.AuthID == "claude-max.json" {
+			found = entry.Status == "allowed" && entry.Label == "max@example.com"
+		}
+	}
+	if !found {
+		t.Fatal("expected subscription credential to be tracked")
+	}
+}
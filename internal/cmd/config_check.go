@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+)
+
+// DoConfigCheck loads the config file at configFilePath, rejects keys unknown to the config
+// schema in it and its config.d overlays, and validates the settings the server would
+// otherwise only reject or ignore at runtime. It is meant for init containers and
+// deploy pipelines, so it never contacts upstream providers or token stores.
+func DoConfigCheck(configFilePath string) error {
+	configFilePath = strings.TrimSpace(configFilePath)
+	if configFilePath == "" {
+		return fmt.Errorf("config check: config path is empty")
+	}
+	cfg, err := config.LoadConfig(configFilePath)
+	if err != nil {
+		return fmt.Errorf("config check: %w", err)
+	}
+
+	var problems []error
+	if errKeys := config.CheckConfigKeys(configFilePath); errKeys != nil {
+		problems = append(problems, errKeys)
+	}
+	if errValidate := cfg.Validate(); errValidate != nil {
+		problems = append(problems, errValidate)
+	}
+	if cfg.Port < 0 || cfg.Port > 65535 {
+		problems = append(problems, fmt.Errorf("port %d is out of range", cfg.Port))
+	}
+	if cfg.TLS.Enable {
+		for _, file := range []struct{ name, path string }{{"tls.cert", cfg.TLS.Cert}, {"tls.key", cfg.TLS.Key}} {
+			if strings.TrimSpace(file.path) == "" {
+				problems = append(problems, fmt.Errorf("%s is required when tls.enable is set", file.name))
+				continue
+			}
+			if _, errStat := os.Stat(file.path); errStat != nil {
+				problems = append(problems, fmt.Errorf("%s: %w", file.name, errStat))
+			}
+		}
+	}
+	if _, errAuthDir := util.ResolveAuthDir(cfg.AuthDir); errAuthDir != nil {
+		problems = append(problems, fmt.Errorf("auth-dir: %w", errAuthDir))
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("config check: %s: %w", configFilePath, errors.Join(problems...))
+	}
+	fmt.Printf("config OK: %s\n", configFilePath)
+	return nil
+}
@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	log "github.com/sirupsen/logrus"
+)
+
+// PrepareReadOnlyRuntime readies the process for a read-only root filesystem. Everything the
+// server writes besides the auth dir and config file (logs, management assets, store
+// workspaces) goes under the writable path: writablePath when set, otherwise WRITABLE_PATH,
+// otherwise a directory in os.TempDir(). The chosen path is exported as WRITABLE_PATH and
+// must accept writes.
+func PrepareReadOnlyRuntime(writablePath string) (string, error) {
+	base := strings.TrimSpace(writablePath)
+	if base == "" {
+		base = util.WritablePath()
+	}
+	if base == "" {
+		base = filepath.Join(os.TempDir(), "cli-proxy-api")
+	}
+	base = filepath.Clean(base)
+	if errSet := os.Setenv("WRITABLE_PATH", base); errSet != nil {
+		return "", fmt.Errorf("read-only runtime: set WRITABLE_PATH: %w", errSet)
+	}
+	if errProbe := probeWritableDir(base); errProbe != nil {
+		return "", fmt.Errorf("read-only runtime: writable path %s: %w", base, errProbe)
+	}
+	return base, nil
+}
+
+// WarnIfNotWritable logs when dir cannot be written, which under a read-only root filesystem
+// means refreshed tokens or management edits are kept in memory only.
+func WarnIfNotWritable(what, dir string) {
+	if strings.TrimSpace(dir) == "" {
+		return
+	}
+	if errProbe := probeWritableDir(dir); errProbe != nil {
+		log.Warnf("%s %s is not writable; changes will not be persisted: %v", what, dir, errProbe)
+	}
+}
+
+func probeWritableDir(dir string) error {
+	if errMkdir := os.MkdirAll(dir, 0o755); errMkdir != nil {
+		return errMkdir
+	}
+	probe, errCreate := os.CreateTemp(dir, ".write-probe-*")
+	if errCreate != nil {
+		return errCreate
+	}
+	name := probe.Name()
+	_ = probe.Close()
+	return os.Remove(name)
+}
+
+// LogGOMAXPROCS reports the scheduler parallelism. Unless GOMAXPROCS is set explicitly, the Go
+// runtime derives it from the container's cgroup CPU limit and follows later limit changes.
+func LogGOMAXPROCS() {
+	source := "runtime default, capped by the cgroup CPU limit"
+	if value := strings.TrimSpace(os.Getenv("GOMAXPROCS")); value != "" {
+		source = "GOMAXPROCS environment variable"
+	}
+	log.Infof("GOMAXPROCS=%d (from %s, %d CPUs visible)", runtime.GOMAXPROCS(0), source, runtime.NumCPU())
+}
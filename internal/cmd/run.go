@@ -13,6 +13,8 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/api"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/pluginhost"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/selfupdate"
+	osservice "github.com/router-for-me/CLIProxyAPI/v7/internal/service"
 	"github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy"
 	log "github.com/sirupsen/logrus"
 )
@@ -31,7 +33,9 @@ func StartService(cfg *config.Config, configPath string, localPassword string) {
 
 // StartServiceWithPluginHost builds and runs the proxy service with a shared plugin host.
 func StartServiceWithPluginHost(cfg *config.Config, configPath string, localPassword string, host *pluginhost.Host, serverOptions ...api.ServerOption) {
-	ctxSignal, cancel := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	ctxService, serviceStopped := osservice.StopContext(context.Background())
+	defer serviceStopped()
+	ctxSignal, cancel := signal.NotifyContext(ctxService, syscall.SIGINT, syscall.SIGTERM)
 	defer cancel()
 
 	builder := cliproxy.NewBuilder().
@@ -55,19 +59,34 @@ func StartServiceWithPluginHost(cfg *config.Config, configPath string, localPass
 		}))
 	}
 
+	// An installed self-update shuts the service down gracefully before restarting.
+	runCtx, updateCancel := context.WithCancel(runCtx)
+	defer updateCancel()
+	go func() {
+		select {
+		case <-selfupdate.RestartRequested():
+			log.Info("self-update installed, restarting")
+			updateCancel()
+		case <-runCtx.Done():
+		}
+	}()
+
 	service, err := builder.Build()
 	if err != nil {
 		log.Errorf("failed to build proxy service: %v", err)
 		return
 	}
 
-	// Background opt-in job (disabled unless COPILOT_HOT_TAKES_INTERVAL_MINS is set).
-	StartCopilotHotTakesLoop(runCtx, cfg, localPassword)
-
 	err = service.Run(runCtx)
 	if err != nil && !errors.Is(err, context.Canceled) {
 		log.Errorf("proxy service exited with error: %v", err)
 	}
+	if selfupdate.RestartPending() {
+		cancel()
+		if errRestart := selfupdate.Restart(); errRestart != nil {
+			log.Errorf("self-update restart failed: %v", errRestart)
+		}
+	}
 }
 
 // StartServiceBackground starts the proxy service in a background goroutine
@@ -0,0 +1,71 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/service"
+)
+
+// RunServiceCommand handles "service install|uninstall|start|stop", registering the
+// current binary with the OS service manager (Windows service or launchd agent).
+//
+// Parameters:
+//   - args: The arguments following "service" on the command line
+//   - defaultConfigPath: The config path used when -config is not provided
+//   - output: Destination for usage and status messages
+func RunServiceCommand(args []string, defaultConfigPath string, output io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: service install|uninstall|start|stop [-config path] [-name name]")
+	}
+	action := args[0]
+	fs := flag.NewFlagSet("service "+action, flag.ContinueOnError)
+	fs.SetOutput(output)
+	configPath := fs.String("config", defaultConfigPath, "Configure File Path")
+	name := fs.String("name", service.DefaultName, "Service name")
+	logDir := fs.String("log-dir", "", "Directory for service stdout/stderr logs (macOS)")
+	if errParse := fs.Parse(args[1:]); errParse != nil {
+		return errParse
+	}
+
+	opts := service.Options{
+		Name:   strings.TrimSpace(*name),
+		LogDir: strings.TrimSpace(*logDir),
+	}
+	if strings.EqualFold(action, "install") {
+		exe, errExe := os.Executable()
+		if errExe != nil {
+			return fmt.Errorf("resolve executable path: %w", errExe)
+		}
+		if resolved, errEval := filepath.EvalSymlinks(exe); errEval == nil {
+			exe = resolved
+		}
+		wd, errWd := os.Getwd()
+		if errWd != nil {
+			return fmt.Errorf("get working directory: %w", errWd)
+		}
+		// Services do not inherit the caller's working directory, so the config path is pinned.
+		cfgPath := strings.TrimSpace(*configPath)
+		if cfgPath == "" {
+			cfgPath = filepath.Join(wd, "config.yaml")
+		} else if !filepath.IsAbs(cfgPath) {
+			cfgPath = filepath.Join(wd, cfgPath)
+		}
+		opts.Executable = exe
+		opts.Args = []string{"-config", cfgPath}
+		opts.WorkingDir = wd
+	}
+	if opts.Name != "" && opts.Name != service.DefaultName {
+		opts.Label = service.DefaultLabel + "." + opts.Name
+	}
+
+	if errRun := service.Run(action, opts); errRun != nil {
+		return errRun
+	}
+	_, _ = fmt.Fprintf(output, "service %s: %s completed\n", opts.Name, strings.ToLower(action))
+	return nil
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/buildinfo"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/selfupdate"
+)
+
+// RunUpdateCommand handles "update", checking GitHub releases and installing a newer
+// binary over the current executable. Settings default to the self-update config section.
+//
+// Parameters:
+//   - args: The arguments following "update" on the command line
+//   - defaultConfigPath: The config path used when -config is not provided
+//   - output: Destination for status messages
+func RunUpdateCommand(args []string, defaultConfigPath string, output io.Writer) error {
+	fs := flag.NewFlagSet("update", flag.ContinueOnError)
+	fs.SetOutput(output)
+	configPath := fs.String("config", defaultConfigPath, "Configure File Path")
+	channel := fs.String("channel", "", "Release channel: stable or beta (defaults to self-update.channel)")
+	checkOnly := fs.Bool("check", false, "Only report whether an update is available")
+	force := fs.Bool("force", false, "Reinstall the selected release even if it is not newer")
+	if errParse := fs.Parse(args); errParse != nil {
+		return errParse
+	}
+
+	cfgPath := strings.TrimSpace(*configPath)
+	if cfgPath == "" {
+		wd, errWd := os.Getwd()
+		if errWd != nil {
+			return fmt.Errorf("get working directory: %w", errWd)
+		}
+		cfgPath = filepath.Join(wd, "config.yaml")
+	}
+	cfg, errLoad := config.LoadConfigOptional(cfgPath, true)
+	if errLoad != nil {
+		return fmt.Errorf("load config: %w", errLoad)
+	}
+	opts := selfupdate.OptionsFromConfig(cfg)
+	if strings.TrimSpace(*channel) != "" {
+		opts.Channel = *channel
+	}
+	opts.Force = *force
+
+	ctx := context.Background()
+	release, errCheck := selfupdate.Check(ctx, opts)
+	if errors.Is(errCheck, selfupdate.ErrNoUpdate) {
+		_, _ = fmt.Fprintf(output, "Already up to date (current %s, latest %s)\n", buildinfo.Version, release.Version)
+		return nil
+	}
+	if errCheck != nil {
+		return errCheck
+	}
+	if *checkOnly {
+		_, _ = fmt.Fprintf(output, "Update available: %s (current %s)\n", release.Version, buildinfo.Version)
+		return nil
+	}
+	if errApply := selfupdate.Apply(ctx, release, opts); errApply != nil {
+		return errApply
+	}
+	_, _ = fmt.Fprintf(output, "Installed %s; restart the proxy to use it\n", release.Version)
+	return nil
+}
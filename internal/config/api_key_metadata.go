@@ -0,0 +1,163 @@
+package config
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+// APIKeyMetadataFor returns the api-key-metadata entry of apiKey, and whether there is one. When
+// several entries name a key the first one wins.
+func (c *SDKConfig) APIKeyMetadataFor(apiKey string) (APIKeyMetadata, bool) {
+	if c == nil {
+		return APIKeyMetadata{}, false
+	}
+	apiKey = strings.TrimSpace(apiKey)
+	if apiKey == "" {
+		return APIKeyMetadata{}, false
+	}
+	for _, entry := range c.APIKeyMetadata {
+		if strings.TrimSpace(entry.APIKey) == apiKey {
+			return entry, true
+		}
+	}
+	return APIKeyMetadata{}, false
+}
+
+// Expiry returns when the key stops being accepted, and whether it expires at all. An expires-at
+// that does not parse expires the key at the zero time, so a typo never grants unlimited access.
+func (m APIKeyMetadata) Expiry() (time.Time, bool) {
+	raw := strings.TrimSpace(m.ExpiresAt)
+	if raw == "" {
+		return time.Time{}, false
+	}
+	expiry, errParse := time.Parse(time.RFC3339, raw)
+	if errParse != nil {
+		return time.Time{}, true
+	}
+	return expiry, true
+}
+
+// Expired reports whether the key is no longer accepted at now.
+func (m APIKeyMetadata) Expired(now time.Time) bool {
+	expiry, expires := m.Expiry()
+	return expires && !now.Before(expiry)
+}
+
+// SetAPIKeyMetadata replaces the api-key-metadata entries of meta.APIKey with meta.
+func (c *SDKConfig) SetAPIKeyMetadata(meta APIKeyMetadata) {
+	if c == nil {
+		return
+	}
+	meta.APIKey = strings.TrimSpace(meta.APIKey)
+	c.APIKeyMetadata = slices.DeleteFunc(c.APIKeyMetadata, func(entry APIKeyMetadata) bool {
+		return strings.TrimSpace(entry.APIKey) == meta.APIKey
+	})
+	c.APIKeyMetadata = append(c.APIKeyMetadata, meta)
+}
+
+// clientKeyLists returns every per-key setting that names client API keys in a list: the
+// api-key-models, api-key-priorities, api-key-residency and output-processors entries, the Amp
+// upstream-api-keys mapping and finetune-capture. inbound-rate-limit.keys is keyed by API key
+// and handled separately.
+func (c *Config) clientKeyLists() []*[]string {
+	var lists []*[]string
+	for i := range c.APIKeyModels {
+		lists = append(lists, &c.APIKeyModels[i].APIKeys)
+	}
+	for i := range c.APIKeyPriorities {
+		lists = append(lists, &c.APIKeyPriorities[i].APIKeys)
+	}
+	for i := range c.APIKeyResidency {
+		lists = append(lists, &c.APIKeyResidency[i].APIKeys)
+	}
+	for i := range c.OutputProcessors {
+		lists = append(lists, &c.OutputProcessors[i].APIKeys)
+	}
+	for i := range c.AmpCode.UpstreamAPIKeys {
+		lists = append(lists, &c.AmpCode.UpstreamAPIKeys[i].APIKeys)
+	}
+	return append(lists, &c.FinetuneCapture.APIKeys)
+}
+
+// CopyAPIKeySettings gives to every per-key setting of from, so a rotated key keeps the models,
+// priority, region, rate limit, output processors, Amp upstream key and finetune capture of the
+// key it replaces.
+func (c *Config) CopyAPIKeySettings(from, to string) {
+	if c == nil {
+		return
+	}
+	from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+	if from == "" || to == "" || from == to {
+		return
+	}
+	for _, keys := range c.clientKeyLists() {
+		if namesKey(*keys, from) && !namesKey(*keys, to) {
+			*keys = append(*keys, to)
+		}
+	}
+	if rule, ok := c.InboundRateLimit.Keys[from]; ok {
+		keys := maps.Clone(c.InboundRateLimit.Keys)
+		keys[to] = rule
+		c.InboundRateLimit.Keys = keys
+	}
+}
+
+// RemoveAPIKey drops apiKey from api-keys, from every per-key setting and from
+// api-key-metadata. Entries left without keys are removed, since an empty key list would apply
+// them to every key; for the same reason finetune capture limited to apiKey alone is disabled.
+// It reports whether api-keys named the key.
+func (c *Config) RemoveAPIKey(apiKey string) bool {
+	if c == nil {
+		return false
+	}
+	apiKey = strings.TrimSpace(apiKey)
+	if apiKey == "" {
+		return false
+	}
+	isKey := func(key string) bool { return strings.TrimSpace(key) == apiKey }
+	found := slices.ContainsFunc(c.APIKeys, isKey)
+	c.APIKeys = slices.DeleteFunc(c.APIKeys, isKey)
+
+	c.APIKeyModels = removeKeyFromEntries(c.APIKeyModels, func(entry *APIKeyModelScope) *[]string { return &entry.APIKeys }, isKey)
+	c.APIKeyPriorities = removeKeyFromEntries(c.APIKeyPriorities, func(entry *APIKeyPriority) *[]string { return &entry.APIKeys }, isKey)
+	c.APIKeyResidency = removeKeyFromEntries(c.APIKeyResidency, func(entry *APIKeyResidency) *[]string { return &entry.APIKeys }, isKey)
+	c.OutputProcessors = removeKeyFromEntries(c.OutputProcessors, func(entry *OutputProcessorRule) *[]string { return &entry.APIKeys }, isKey)
+	c.AmpCode.UpstreamAPIKeys = removeKeyFromEntries(c.AmpCode.UpstreamAPIKeys, func(entry *AmpUpstreamAPIKeyEntry) *[]string { return &entry.APIKeys }, isKey)
+	if captured := len(c.FinetuneCapture.APIKeys); captured > 0 {
+		c.FinetuneCapture.APIKeys = slices.DeleteFunc(c.FinetuneCapture.APIKeys, isKey)
+		if len(c.FinetuneCapture.APIKeys) == 0 {
+			c.FinetuneCapture.Enabled = false
+		}
+	}
+	if _, ok := c.InboundRateLimit.Keys[apiKey]; ok {
+		keys := maps.Clone(c.InboundRateLimit.Keys)
+		delete(keys, apiKey)
+		c.InboundRateLimit.Keys = keys
+	}
+	c.APIKeyMetadata = slices.DeleteFunc(c.APIKeyMetadata, func(entry APIKeyMetadata) bool {
+		return isKey(entry.APIKey)
+	})
+	return found
+}
+
+func namesKey(keys []string, apiKey string) bool {
+	return slices.ContainsFunc(keys, func(key string) bool { return strings.TrimSpace(key) == apiKey })
+}
+
+// removeKeyFromEntries drops the keys matching isKey from each entry, and the entries that named
+// no other key.
+func removeKeyFromEntries[T any](entries []T, keysOf func(*T) *[]string, isKey func(string) bool) []T {
+	kept := entries[:0]
+	for i := range entries {
+		keys := keysOf(&entries[i])
+		before := len(*keys)
+		*keys = slices.DeleteFunc(*keys, isKey)
+		if len(*keys) == 0 && before > 0 {
+			continue
+		}
+		kept = append(kept, entries[i])
+	}
+	return kept
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestAPIKeyMetadataExpired(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	cases := []struct {
+		expiresAt string
+		want      bool
+	}{
+		{"", false},
+		{"2026-03-02T00:00:00Z", false},
+		{"2026-03-01T12:00:00Z", true},
+		{"2026-02-01T00:00:00+01:00", true},
+		{"next tuesday", true},
+	}
+	for _, tc := range cases {
+		meta := APIKeyMetadata{APIKey: "k", ExpiresAt: tc.expiresAt}
+		if got := meta.Expired(now); got != tc.want {
+			t.Errorf("Expired() with expires-at %q = %v, want %v", tc.expiresAt, got, tc.want)
+		}
+	}
+}
+
+func TestCopyAndRemoveAPIKey(t *testing.T) {
+	cfg := &Config{
+		SDKConfig: SDKConfig{
+			APIKeys:          []string{"old", "other"},
+			APIKeyModels:     []APIKeyModelScope{{APIKeys: []string{"old"}, Models: []string{"gpt-5-mini"}}},
+			APIKeyPriorities: []APIKeyPriority{{APIKeys: []string{"old", "other"}, Priority: PriorityBatch}},
+			APIKeyResidency:  []APIKeyResidency{{APIKeys: []string{"other"}, Region: "eu"}},
+			APIKeyMetadata:   []APIKeyMetadata{{APIKey: "old", Owner: "alice"}},
+			OutputProcessors: []OutputProcessorRule{{APIKeys: []string{"old"}}},
+		},
+		InboundRateLimit: InboundRateLimitConfig{Keys: map[string]InboundRateLimitRule{"old": {RequestsPerMinute: 5}}},
+		FinetuneCapture:  FinetuneCaptureConfig{Enabled: true, APIKeys: []string{"old"}},
+		AmpCode:          AmpCode{UpstreamAPIKeys: []AmpUpstreamAPIKeyEntry{{UpstreamAPIKey: "amp", APIKeys: []string{"old"}}}},
+	}
+
+	cfg.APIKeys = append(cfg.APIKeys, "new")
+	cfg.CopyAPIKeySettings("old", "new")
+	if !cfg.APIKeyAllowsModel("new", "gpt-5-mini") || cfg.APIKeyAllowsModel("new", "gpt-5") {
+		t.Fatalf("rotated key did not keep the model scope: %+v", cfg.APIKeyModels)
+	}
+	if got := cfg.APIKeyPriorityClass("new"); got != PriorityBatch {
+		t.Fatalf("rotated key priority = %q, want %q", got, PriorityBatch)
+	}
+	if got := cfg.APIKeyResidencyRegion("new"); got != "" {
+		t.Fatalf("rotated key residency = %q, want none", got)
+	}
+	if got := cfg.InboundRateLimit.Keys["new"]; got.RequestsPerMinute != 5 {
+		t.Fatalf("rotated key rate limit = %+v, want 5 requests per minute", got)
+	}
+	for name, keys := range map[string][]string{
+		"output-processors": cfg.OutputProcessors[0].APIKeys,
+		"finetune-capture":  cfg.FinetuneCapture.APIKeys,
+		"upstream-api-keys": cfg.AmpCode.UpstreamAPIKeys[0].APIKeys,
+	} {
+		if !slices.Equal(keys, []string{"old", "new"}) {
+			t.Fatalf("%s keys = %v, want [old new]", name, keys)
+		}
+	}
+
+	if !cfg.RemoveAPIKey("old") {
+		t.Fatal("RemoveAPIKey(old) = false, want true")
+	}
+	if slices.Contains(cfg.APIKeys, "old") {
+		t.Fatalf("api-keys still names the removed key: %v", cfg.APIKeys)
+	}
+	if len(cfg.APIKeyModels) != 1 || !slices.Equal(cfg.APIKeyModels[0].APIKeys, []string{"new"}) {
+		t.Fatalf("api-key-models = %+v, want the scope kept for the new key only", cfg.APIKeyModels)
+	}
+	if !slices.Equal(cfg.APIKeyPriorities[0].APIKeys, []string{"other", "new"}) {
+		t.Fatalf("api-key-priorities keys = %v", cfg.APIKeyPriorities[0].APIKeys)
+	}
+	if _, ok := cfg.InboundRateLimit.Keys["old"]; ok {
+		t.Fatal("rate limit of the removed key was kept")
+	}
+	if _, ok := cfg.APIKeyMetadataFor("old"); ok {
+		t.Fatal("metadata of the removed key was kept")
+	}
+
+	if !cfg.RemoveAPIKey("new") {
+		t.Fatal("RemoveAPIKey(new) = false, want true")
+	}
+	if len(cfg.APIKeyModels) != 0 || len(cfg.OutputProcessors) != 0 || len(cfg.AmpCode.UpstreamAPIKeys) != 0 {
+		t.Fatalf("entries emptied by the removal were kept: models %+v, output-processors %+v, upstream-api-keys %+v",
+			cfg.APIKeyModels, cfg.OutputProcessors, cfg.AmpCode.UpstreamAPIKeys)
+	}
+	if cfg.FinetuneCapture.Enabled {
+		t.Fatal("finetune capture limited to removed keys is still enabled")
+	}
+	if len(cfg.InboundRateLimit.Keys) != 0 {
+		t.Fatalf("rate limit keys = %v, want none", cfg.InboundRateLimit.Keys)
+	}
+	if cfg.RemoveAPIKey("missing") {
+		t.Fatal("RemoveAPIKey(missing) = true, want false")
+	}
+}
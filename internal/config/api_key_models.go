@@ -0,0 +1,42 @@
+package config
+
+import "strings"
+
+// APIKeyModelPatterns returns the model patterns apiKey is limited to, and whether any
+// api-key-models scope names the key. Unscoped keys may use every model.
+func (c *SDKConfig) APIKeyModelPatterns(apiKey string) ([]string, bool) {
+	if c == nil || len(c.APIKeyModels) == 0 {
+		return nil, false
+	}
+	apiKey = strings.TrimSpace(apiKey)
+	if apiKey == "" {
+		return nil, false
+	}
+	var patterns []string
+	scoped := false
+	for _, scope := range c.APIKeyModels {
+		for _, key := range scope.APIKeys {
+			if strings.TrimSpace(key) == apiKey {
+				scoped = true
+				patterns = append(patterns, scope.Models...)
+				break
+			}
+		}
+	}
+	return patterns, scoped
+}
+
+// APIKeyAllowsModel reports whether apiKey may list and call model.
+func (c *SDKConfig) APIKeyAllowsModel(apiKey, model string) bool {
+	patterns, scoped := c.APIKeyModelPatterns(apiKey)
+	if !scoped {
+		return true
+	}
+	model = strings.ToLower(strings.TrimSpace(model))
+	for _, pattern := range patterns {
+		if matchModelPattern(strings.ToLower(strings.TrimSpace(pattern)), model) {
+			return true
+		}
+	}
+	return false
+}
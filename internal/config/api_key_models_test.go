@@ -0,0 +1,62 @@
+package config
+
+import "testing"
+
+func TestAPIKeyAllowsModel(t *testing.T) {
+	cfg := &SDKConfig{APIKeyModels: []APIKeyModelScope{
+		{APIKeys: []string{"cheap"}, Models: []string{"gpt-5-mini", "gemini-*-flash*"}},
+		{APIKeys: []string{" cheap ", "haiku"}, Models: []string{"claude-haiku-*"}},
+	}}
+
+	cases := []struct {
+		key, model string
+		want       bool
+	}{
+		{"cheap", "gpt-5-mini", true},
+		{"cheap", "GEMINI-2.5-FLASH-LITE", true},
+		{"cheap", "claude-haiku-4-5", true},
+		{"cheap", "gpt-5", false},
+		{"haiku", "gpt-5-mini", false},
+		{"haiku", "claude-haiku-4-5", true},
+		{"unscoped", "gpt-5", true},
+		{"", "gpt-5", true},
+	}
+	for _, tc := range cases {
+		if got := cfg.APIKeyAllowsModel(tc.key, tc.model); got != tc.want {
+			t.Errorf("APIKeyAllowsModel(%q, %q) = %v, want %v", tc.key, tc.model, got, tc.want)
+		}
+	}
+}
+
+func TestAPIKeyModelPatternsEmptyScopeDeniesEverything(t *testing.T) {
+	cfg := &SDKConfig{APIKeyModels: []APIKeyModelScope{{APIKeys: []string{"locked"}}}}
+
+	if _, scoped := cfg.APIKeyModelPatterns("locked"); !scoped {
+		t.Fatal("expected key to be scoped")
+	}
+	if cfg.APIKeyAllowsModel("locked", "gpt-5") {
+		t.Fatal("expected a scope without models to deny every model")
+	}
+}
+
+func TestAPIKeyPriorityClass(t *testing.T) {
+	cfg := &SDKConfig{APIKeyPriorities: []APIKeyPriority{
+		{APIKeys: []string{" ops "}, Priority: "HIGH"},
+		{APIKeys: []string{"nightly"}, Priority: "batch"},
+		{APIKeys: []string{"typo"}, Priority: "urgent"},
+		{APIKeys: []string{"ops"}, Priority: "batch"},
+	}}
+
+	cases := map[string]string{
+		"ops":      PriorityHigh,
+		"nightly":  PriorityBatch,
+		"typo":     PriorityNormal,
+		"unlisted": PriorityNormal,
+		"":         PriorityNormal,
+	}
+	for key, want := range cases {
+		if got := cfg.APIKeyPriorityClass(key); got != want {
+			t.Errorf("APIKeyPriorityClass(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package config
+
+import "strings"
+
+// Admission priority classes of client API keys.
+const (
+	PriorityHigh   = "high"
+	PriorityNormal = "normal"
+	PriorityBatch  = "batch"
+)
+
+// APIKeyPriorityClass returns the admission priority class of apiKey. Keys not named by any
+// api-key-priorities entry, and entries with an unknown priority, are "normal". When several
+// entries name a key the first one wins.
+func (c *SDKConfig) APIKeyPriorityClass(apiKey string) string {
+	if c == nil || len(c.APIKeyPriorities) == 0 {
+		return PriorityNormal
+	}
+	apiKey = strings.TrimSpace(apiKey)
+	if apiKey == "" {
+		return PriorityNormal
+	}
+	for _, entry := range c.APIKeyPriorities {
+		for _, key := range entry.APIKeys {
+			if strings.TrimSpace(key) != apiKey {
+				continue
+			}
+			switch priority := strings.ToLower(strings.TrimSpace(entry.Priority)); priority {
+			case PriorityHigh, PriorityBatch:
+				return priority
+			}
+			return PriorityNormal
+		}
+	}
+	return PriorityNormal
+}
@@ -0,0 +1,24 @@
+package config
+
+import "strings"
+
+// APIKeyResidencyRegion returns the data-residency region required for the requests of apiKey,
+// lowercased, or "" when the key is unrestricted. When several entries name a key the first one
+// wins.
+func (c *SDKConfig) APIKeyResidencyRegion(apiKey string) string {
+	if c == nil || len(c.APIKeyResidency) == 0 {
+		return ""
+	}
+	apiKey = strings.TrimSpace(apiKey)
+	if apiKey == "" {
+		return ""
+	}
+	for _, entry := range c.APIKeyResidency {
+		for _, key := range entry.APIKeys {
+			if strings.TrimSpace(key) == apiKey {
+				return strings.ToLower(strings.TrimSpace(entry.Region))
+			}
+		}
+	}
+	return ""
+}
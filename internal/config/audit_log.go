@@ -0,0 +1,17 @@
+package config
+
+// AuditLogConfig writes one structured JSON line per proxied request, separate from the debug
+// and request logs.
+type AuditLogConfig struct {
+	// Enable turns the audit log on.
+	Enable bool `yaml:"enable" json:"enable"`
+	// Output is "stdout" (default) or "file".
+	Output string `yaml:"output,omitempty" json:"output,omitempty"`
+	// File is the audit log path for the file output. Defaults to audit.log in the logs
+	// directory; relative paths resolve against the logs directory.
+	File string `yaml:"file,omitempty" json:"file,omitempty"`
+	// MaxSizeMB rotates the file once it reaches this size. Defaults to 100.
+	MaxSizeMB int `yaml:"max-size-mb,omitempty" json:"max-size-mb,omitempty"`
+	// MaxBackups is how many rotated files are kept; 0 keeps them all.
+	MaxBackups int `yaml:"max-backups,omitempty" json:"max-backups,omitempty"`
+}
@@ -52,12 +52,50 @@ type Config struct {
 	// AuthDir is the directory where authentication token files are stored.
 	AuthDir string `yaml:"auth-dir" json:"-"`
 
+	// TokenStore selects the backend persisting credential tokens (file, Vault, AWS Secrets Manager).
+	TokenStore TokenStoreConfig `yaml:"token-store,omitempty" json:"token-store,omitempty"`
+
 	// Debug enables or disables debug-level logging and other debug features.
 	Debug bool `yaml:"debug" json:"debug"`
 
 	// Pprof config controls the optional pprof HTTP debug server.
 	Pprof PprofConfig `yaml:"pprof" json:"pprof"`
 
+	// Metrics exposes Prometheus metrics at /metrics.
+	Metrics MetricsConfig `yaml:"metrics" json:"metrics"`
+
+	// SelfUpdate configures the optional background binary updater.
+	SelfUpdate SelfUpdateConfig `yaml:"self-update" json:"self-update"`
+
+	// Warmup issues warm-up requests so cold-starting providers are ready before real traffic.
+	Warmup WarmupConfig `yaml:"warmup" json:"warmup"`
+
+	// ScheduledJobs sends templated prompts to models on a schedule, e.g. keep-alive pings,
+	// account warmers, or daily digests.
+	ScheduledJobs []ScheduledJob `yaml:"scheduled-jobs,omitempty" json:"scheduled-jobs,omitempty"`
+
+	// UsageSinks forward a structured usage event per upstream request to external systems.
+	UsageSinks []UsageSink `yaml:"usage-sinks,omitempty" json:"usage-sinks,omitempty"`
+
+	// UsageReport periodically summarizes usage per provider, model and client key.
+	UsageReport UsageReportConfig `yaml:"usage-report" json:"usage-report"`
+
+	// ProviderStatus polls provider status pages and routes around declared outages.
+	ProviderStatus ProviderStatusConfig `yaml:"provider-status" json:"provider-status"`
+
+	// BaseURLPinning tunes the probing of credentials with regional-base-urls.
+	BaseURLPinning BaseURLPinningConfig `yaml:"base-url-pinning" json:"base-url-pinning"`
+
+	// FinetuneCapture appends sampled (prompt, completion) pairs to fine-tuning JSONL files.
+	FinetuneCapture FinetuneCaptureConfig `yaml:"finetune-capture" json:"finetune-capture"`
+
+	// Telemetry opts in to sending anonymous, noised usage counts. Off by default.
+	Telemetry TelemetryConfig `yaml:"telemetry" json:"telemetry"`
+
+	// KeepAliveShaping paces scheduled background requests so subscription accounts see
+	// human-like traffic instead of machine-regular bursts.
+	KeepAliveShaping KeepAliveShapingConfig `yaml:"keep-alive-shaping" json:"keep-alive-shaping"`
+
 	// CommercialMode disables high-overhead request logging and HTTP middleware features to minimize per-request memory usage.
 	CommercialMode bool `yaml:"commercial-mode" json:"commercial-mode"`
 
@@ -80,9 +118,38 @@ type Config struct {
 	// Default: 60. Max: 3600.
 	RedisUsageQueueRetentionSeconds int `yaml:"redis-usage-queue-retention-seconds" json:"redis-usage-queue-retention-seconds"`
 
+	// InboundRateLimit limits the request rate of each client API key.
+	InboundRateLimit InboundRateLimitConfig `yaml:"inbound-rate-limit" json:"inbound-rate-limit"`
+
+	// SharedCache selects where shareable caches (e.g. upstream model lists) keep their entries.
+	SharedCache SharedCacheConfig `yaml:"shared-cache" json:"shared-cache"`
+
+	// MemoryGuard rejects new requests while the process is above a memory watermark.
+	MemoryGuard MemoryGuardConfig `yaml:"memory-guard" json:"memory-guard"`
+
+	// StreamWatchdog reports response streams that live too long or pile up.
+	StreamWatchdog StreamWatchdogConfig `yaml:"stream-watchdog" json:"stream-watchdog"`
+
+	// ResponseSigning signs response bodies and attaches provenance headers.
+	ResponseSigning ResponseSigningConfig `yaml:"response-signing" json:"response-signing"`
+
+	// AuditLog writes a structured JSON line per proxied request.
+	AuditLog AuditLogConfig `yaml:"audit-log" json:"audit-log"`
+
+	// SessionTranscripts persists the full request/response transcript of execution sessions
+	// (e.g. Responses websocket connections) for debugging agent behavior end-to-end.
+	SessionTranscripts SessionTranscriptsConfig `yaml:"session-transcripts" json:"session-transcripts"`
+
+	// RequestRetention redacts logged request data once it is older than a retention period.
+	RequestRetention RequestRetentionConfig `yaml:"request-retention" json:"request-retention"`
+
 	// DisableCooling disables quota cooldown scheduling when true.
 	DisableCooling bool `yaml:"disable-cooling" json:"disable-cooling"`
 
+	// ReadOnlyProviders lists providers whose credentials only serve token counting and model
+	// listing; generation requests routed to them are refused with 403.
+	ReadOnlyProviders []string `yaml:"read-only-providers,omitempty" json:"read-only-providers,omitempty"`
+
 	// SaveCooldownStatus persists runtime cooldown status next to auth files when true.
 	SaveCooldownStatus bool `yaml:"save-cooldown-status" json:"save-cooldown-status"`
 
@@ -172,6 +239,11 @@ type Config struct {
 	// the auth/OAuth token file). Default false preserves the per-client "auto" behavior.
 	DisableClaudeCloakMode bool `yaml:"disable-claude-cloak-mode" json:"disable-claude-cloak-mode"`
 
+	// SystemMessagePolicy controls how OpenAI system and developer messages are merged when
+	// translating to formats with a single system instruction: "concatenate" (default),
+	// "first-wins", or "role-downgrade".
+	SystemMessagePolicy string `yaml:"system-message-policy" json:"system-message-policy"`
+
 	// OpenAICompatibility defines OpenAI API compatibility configurations for external providers.
 	OpenAICompatibility []OpenAICompatibility `yaml:"openai-compatibility" json:"openai-compatibility"`
 
@@ -195,6 +267,16 @@ type Config struct {
 	// OAuthExcludedModels defines per-provider global model exclusions applied to OAuth/file-backed auth entries.
 	OAuthExcludedModels map[string][]string `yaml:"oauth-excluded-models,omitempty" json:"oauth-excluded-models,omitempty"`
 
+	// HeaderTemplates sets upstream request headers per provider key ("*" matches every provider).
+	// Values may reference {auth_id}, {auth_label}, {provider}, {version}, {session_uuid}
+	// (stable per execution session, or per auth without one), and {uuid} (fresh per request).
+	// Headers set through an auth's "header:" attributes take precedence.
+	HeaderTemplates map[string]map[string]string `yaml:"header-templates,omitempty" json:"header-templates,omitempty"`
+
+	// ProviderBetas enables upstream beta features per provider key ("*" matches every provider)
+	// and model. Executors attach an enabled beta's header when a request uses the feature it gates.
+	ProviderBetas map[string][]ProviderBetaRule `yaml:"provider-betas,omitempty" json:"provider-betas,omitempty"`
+
 	// OAuthProxyPool defines per-provider proxy pools for OAuth/file-backed auth entries.
 	// Values are CSV proxy URLs. Assignment is deterministic and alphabetical by auth ID.
 	// Example:
@@ -251,6 +333,46 @@ type PassthruRoute struct {
 	// route's upstream. Shorthand for a payload drop-tools rule scoped to this
 	// route; useful when a strict upstream rejects specific tool schemas.
 	DropTools []string `yaml:"drop-tools,omitempty" json:"drop-tools,omitempty"`
+	// StreamTransform rewrites each upstream SSE data event before translation,
+	// for vendors whose streams deviate slightly from the protocol.
+	StreamTransform *PassthruStreamTransform `yaml:"stream-transform,omitempty" json:"stream-transform,omitempty"`
+	// Priority orders routes that share a routing name: requests go to the
+	// highest priority route that is available and fall back to lower ones.
+	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
+	// HealthCheck probes the upstream periodically and takes the route out of
+	// rotation while the probe fails.
+	HealthCheck *PassthruHealthCheck `yaml:"health-check,omitempty" json:"health-check,omitempty"`
+}
+
+// PassthruHealthCheck configures the periodic probe of a passthru upstream.
+type PassthruHealthCheck struct {
+	// Path is requested with GET relative to base-url, e.g. "/models" or "/health".
+	// Any 2xx response counts as healthy.
+	Path string `yaml:"path" json:"path"`
+	// Interval between probes. Default: 30s.
+	Interval string `yaml:"interval,omitempty" json:"interval,omitempty"`
+	// Timeout for each probe. Default: 5s.
+	Timeout string `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+	// FailureThreshold is the number of consecutive failed probes before the
+	// route is taken out of rotation. Default: 1.
+	FailureThreshold int `yaml:"failure-threshold,omitempty" json:"failure-threshold,omitempty"`
+}
+
+// PassthruStreamTransform defines edits applied to the JSON payload of every
+// upstream SSE data event. Paths use gjson syntax; a "#" segment applies the
+// edit to every element of an array, e.g. "choices.#.delta.reasoning".
+type PassthruStreamTransform struct {
+	// Rename moves values from the key path to the value path.
+	Rename map[string]string `yaml:"rename,omitempty" json:"rename,omitempty"`
+	// Delete removes paths from the event.
+	Delete []string `yaml:"delete,omitempty" json:"delete,omitempty"`
+	// Set always writes values, overwriting any existing ones.
+	Set map[string]any `yaml:"set,omitempty" json:"set,omitempty"`
+}
+
+// Empty reports whether the transform has no edits.
+func (t *PassthruStreamTransform) Empty() bool {
+	return t == nil || (len(t.Rename) == 0 && len(t.Delete) == 0 && len(t.Set) == 0)
 }
 
 // PassthruPayload defines route-scoped payload parameter rules.
@@ -409,6 +531,220 @@ type PprofConfig struct {
 	Addr string `yaml:"addr" json:"addr"`
 }
 
+// MetricsConfig controls the Prometheus /metrics endpoint.
+type MetricsConfig struct {
+	// Enable serves request, token, latency, retry and error metrics per provider, model and
+	// auth ID at /metrics.
+	Enable bool `yaml:"enable" json:"enable"`
+	// BearerToken, when set, must be sent as "Authorization: Bearer <token>" to scrape.
+	BearerToken string `yaml:"bearer-token,omitempty" json:"bearer-token,omitempty"`
+}
+
+// SelfUpdateConfig controls the self-update scheduler.
+type SelfUpdateConfig struct {
+	// Enabled turns on periodic checks that install newer releases and restart gracefully.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Channel selects the release stream: "stable" (default) or "beta" (includes pre-releases).
+	Channel string `yaml:"channel,omitempty" json:"channel,omitempty"`
+	// CheckInterval is a duration string such as "6h". Empty, invalid or sub-hour values use 24h.
+	CheckInterval string `yaml:"check-interval,omitempty" json:"check-interval,omitempty"`
+	// Repository overrides the GitHub repository releases are fetched from.
+	Repository string `yaml:"repository,omitempty" json:"repository,omitempty"`
+	// PublicKey is a base64 Ed25519 public key used to verify the release checksums.txt.sig.
+	// Scheduled updates are refused without it.
+	PublicKey string `yaml:"public-key,omitempty" json:"public-key,omitempty"`
+}
+
+// WarmupConfig controls warm-up requests for providers with cold starts
+// (e.g. Chutes serverless deployments or local Ollama models).
+type WarmupConfig struct {
+	// Enabled sends a minimal one-token request for each model shortly after startup.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Models lists model names as clients request them.
+	Models []string `yaml:"models,omitempty" json:"models,omitempty"`
+	// Interval repeats the warm-up on a schedule, as a duration string such as "10m".
+	// Empty or invalid values only warm up at startup; values below one minute use one minute.
+	Interval string `yaml:"interval,omitempty" json:"interval,omitempty"`
+}
+
+// SessionTranscriptsConfig controls persistence of execution session transcripts.
+type SessionTranscriptsConfig struct {
+	// Enabled records every turn of an execution session under the logs directory.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// RetentionHours deletes transcripts not written to for this long.
+	// 0 uses the default of 72 hours. Negative values keep transcripts forever.
+	RetentionHours int `yaml:"retention-hours,omitempty" json:"retention-hours,omitempty"`
+	// MaxEntryBytes truncates the stored request and response of each turn. Default: 1 MiB.
+	MaxEntryBytes int `yaml:"max-entry-bytes,omitempty" json:"max-entry-bytes,omitempty"`
+}
+
+// RequestRetentionConfig controls how long request logs keep their headers and bodies.
+type RequestRetentionConfig struct {
+	// RedactAfterHours strips headers and bodies from request logs older than this many hours,
+	// keeping the request line, timestamp and client key hash. 0 disables redaction.
+	RedactAfterHours int `yaml:"redact-after-hours,omitempty" json:"redact-after-hours,omitempty"`
+}
+
+// ScheduledJob describes one prompt sent to a model on a schedule.
+type ScheduledJob struct {
+	// Name identifies the job in logs. Defaults to the job's position in the list.
+	Name string `yaml:"name" json:"name"`
+	// Disabled keeps the job configured without running it.
+	Disabled bool `yaml:"disabled,omitempty" json:"disabled,omitempty"`
+	// Schedule is a five-field cron expression ("0 9 * * 1-5"), a descriptor such as
+	// "@hourly" or "@daily", or "@every <duration>" with a minimum of one minute.
+	Schedule string `yaml:"schedule" json:"schedule"`
+	// RunOnStart also runs the job once shortly after startup.
+	RunOnStart bool `yaml:"run-on-start,omitempty" json:"run-on-start,omitempty"`
+	// Model is the model name as clients request it.
+	Model string `yaml:"model" json:"model"`
+	// Prompt is a Go text/template rendered with the fetched sources, keyed by source name.
+	Prompt string `yaml:"prompt" json:"prompt"`
+	// Sources fetch data for the prompt template before each run.
+	Sources []ScheduledJobSource `yaml:"sources,omitempty" json:"sources,omitempty"`
+	// MaxTokens caps the completion length. Zero leaves it to the provider.
+	MaxTokens int `yaml:"max-tokens,omitempty" json:"max-tokens,omitempty"`
+	// Headers are forwarded to the provider request builder, e.g. force-copilot-initiator.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+	// AuthPinning selects which credentials receive the prompt:
+	// "none" (default) lets the router pick, "each" sends it once per matching auth,
+	// and "round-robin" pins one matching auth per run in turn.
+	AuthPinning string `yaml:"auth-pinning,omitempty" json:"auth-pinning,omitempty"`
+	// Provider restricts pinned auths to one provider. Defaults to the providers serving Model.
+	Provider string `yaml:"provider,omitempty" json:"provider,omitempty"`
+}
+
+// ProviderStatusConfig configures status page polling.
+type ProviderStatusConfig struct {
+	// Enabled turns on polling of the built-in and configured status pages.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// IntervalSeconds is the polling interval. Defaults to 120; the minimum is 30.
+	IntervalSeconds int `yaml:"interval-seconds,omitempty" json:"interval-seconds,omitempty"`
+	// AvoidIndicator is the lowest Statuspage indicator ("minor", "major" or "critical") at which
+	// routing avoids the provider when another candidate exists. Defaults to "major".
+	AvoidIndicator string `yaml:"avoid-indicator,omitempty" json:"avoid-indicator,omitempty"`
+	// Pages add or replace status pages per provider. Built in are claude (Anthropic), codex
+	// (OpenAI) and copilot (GitHub).
+	Pages []ProviderStatusPage `yaml:"pages,omitempty" json:"pages,omitempty"`
+}
+
+// ProviderStatusPage maps a provider to a Statuspage-compatible status.json URL.
+type ProviderStatusPage struct {
+	// Provider is the provider key, e.g. "claude".
+	Provider string `yaml:"provider" json:"provider"`
+	// URL returns {"status":{"indicator":"...","description":"..."}}; empty disables the page.
+	URL string `yaml:"url" json:"url"`
+}
+
+// BaseURLPinningConfig configures how regional base URLs are probed. Credentials without
+// regional-base-urls are never probed.
+type BaseURLPinningConfig struct {
+	// ProbeIntervalSeconds is how often every endpoint is re-probed. Defaults to 300; the
+	// minimum is 30.
+	ProbeIntervalSeconds int `yaml:"probe-interval-seconds,omitempty" json:"probe-interval-seconds,omitempty"`
+	// ProbeTimeoutSeconds bounds one probe; slower endpoints count as unhealthy. Defaults to 5.
+	ProbeTimeoutSeconds int `yaml:"probe-timeout-seconds,omitempty" json:"probe-timeout-seconds,omitempty"`
+}
+
+// FinetuneCaptureConfig configures capturing of exchanges in the OpenAI chat fine-tuning
+// JSONL format.
+type FinetuneCaptureConfig struct {
+	// Enabled turns on capturing.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Dir receives one <model>-<date>.jsonl file per model and day. Defaults to "./finetune".
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+	// Models limits capturing to requested models matching these glob patterns; empty captures
+	// every model.
+	Models []string `yaml:"models,omitempty" json:"models,omitempty"`
+	// APIKeys limits capturing to requests authenticated with these client API keys; empty
+	// captures every key.
+	APIKeys []string `yaml:"api-keys,omitempty" json:"api-keys,omitempty"`
+	// SampleRate is the captured fraction of matching requests, from 0 to 1. Defaults to 1.
+	SampleRate float64 `yaml:"sample-rate,omitempty" json:"sample-rate,omitempty"`
+	// RedactDefaults replaces e-mail addresses, common API keys and bearer tokens with
+	// "[REDACTED]".
+	RedactDefaults bool `yaml:"redact-defaults,omitempty" json:"redact-defaults,omitempty"`
+	// RedactPatterns are additional regular expressions whose matches are redacted.
+	RedactPatterns []string `yaml:"redact-patterns,omitempty" json:"redact-patterns,omitempty"`
+}
+
+// TelemetryConfig configures the opt-in anonymous telemetry described in docs/telemetry.md.
+type TelemetryConfig struct {
+	// Enabled opts in to sending reports. Off by default.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Endpoint receives each report as a JSON POST. Nothing is sent when empty.
+	Endpoint string `yaml:"endpoint,omitempty" json:"endpoint,omitempty"`
+	// IntervalHours is the reporting period. Defaults to 24.
+	IntervalHours int `yaml:"interval-hours,omitempty" json:"interval-hours,omitempty"`
+	// Epsilon is the differential privacy parameter of the Laplace noise added to every count;
+	// smaller values add more noise. Defaults to 1.
+	Epsilon float64 `yaml:"epsilon,omitempty" json:"epsilon,omitempty"`
+}
+
+// UsageReportConfig configures the periodic usage summary report.
+type UsageReportConfig struct {
+	// Enabled turns on usage collection and report generation.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Schedule is a scheduled-jobs style cron expression or descriptor. Defaults to "@daily".
+	Schedule string `yaml:"schedule,omitempty" json:"schedule,omitempty"`
+	// Format is "markdown" (default) or "html".
+	Format string `yaml:"format,omitempty" json:"format,omitempty"`
+	// Dir receives one report file per run. Empty skips writing to disk.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+	// WebhookURL receives each report as the body of a POST. Empty skips posting.
+	WebhookURL string `yaml:"webhook-url,omitempty" json:"webhook-url,omitempty"`
+}
+
+// UsageSink forwards usage events to an external system.
+type UsageSink struct {
+	// Name identifies the sink in logs. Defaults to the sink's position in the list.
+	Name string `yaml:"name" json:"name"`
+	// Type is "webhook" (JSON POST per event) or "statsd" (DogStatsD-tagged UDP metrics).
+	Type string `yaml:"type" json:"type"`
+	// URL is the webhook endpoint.
+	URL string `yaml:"url,omitempty" json:"url,omitempty"`
+	// Headers are added to every webhook request, e.g. an Authorization header.
+	Headers map[string]string `yaml:"headers,omitempty" json:"headers,omitempty"`
+	// Address is the statsd host:port.
+	Address string `yaml:"address,omitempty" json:"address,omitempty"`
+	// Prefix is prepended to statsd metric names. Defaults to "cliproxy".
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+}
+
+// ScheduledJobSource fetches data exposed to a scheduled job's prompt template.
+type ScheduledJobSource struct {
+	// Name is the template key holding the fetched items, e.g. {{range .headlines}}.
+	Name string `yaml:"name" json:"name"`
+	// Type is "hacker-news" (random top story titles) or "http" (GET URL).
+	Type string `yaml:"type" json:"type"`
+	// URL is fetched by "http" sources.
+	URL string `yaml:"url,omitempty" json:"url,omitempty"`
+	// Path is an optional gjson path selecting items from an "http" JSON response.
+	// Without it the trimmed body is exposed as a single item.
+	Path string `yaml:"path,omitempty" json:"path,omitempty"`
+	// Count limits the number of items. Defaults to 7 for "hacker-news"; zero keeps all items otherwise.
+	Count int `yaml:"count,omitempty" json:"count,omitempty"`
+}
+
+// KeepAliveShapingConfig paces background requests sent by scheduled jobs.
+// When disabled, consecutive background requests are still spaced 27-33 seconds apart.
+type KeepAliveShapingConfig struct {
+	// Enabled applies the settings below instead of the default spacing.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Providers limits shaping to jobs pinned to these providers, e.g. ["grok", "copilot"].
+	// Empty shapes every scheduled job.
+	Providers []string `yaml:"providers,omitempty" json:"providers,omitempty"`
+	// MinGap and MaxGap bound the randomized pause between consecutive background requests,
+	// as duration strings. Gaps cluster around the midpoint.
+	MinGap string `yaml:"min-gap,omitempty" json:"min-gap,omitempty"`
+	MaxGap string `yaml:"max-gap,omitempty" json:"max-gap,omitempty"`
+	// DailyCap limits background requests per auth per local calendar day. Zero means unlimited.
+	DailyCap int `yaml:"daily-cap,omitempty" json:"daily-cap,omitempty"`
+	// ActiveHours restricts background requests to a local time window such as "08:00-23:30".
+	// Windows that wrap midnight ("22:00-06:00") are allowed. Empty means all day.
+	ActiveHours string `yaml:"active-hours,omitempty" json:"active-hours,omitempty"`
+}
+
 // RemoteManagement holds management API configuration under 'remote-management'.
 type RemoteManagement struct {
 	// AllowRemote toggles remote (non-localhost) access to management API.
@@ -456,6 +792,47 @@ type RoutingConfig struct {
 	// SessionAffinityTTL specifies how long session-to-auth bindings are retained.
 	// Default: 1h. Accepts duration strings like "30m", "1h", "2h30m".
 	SessionAffinityTTL string `yaml:"session-affinity-ttl,omitempty" json:"session-affinity-ttl,omitempty"`
+
+	// ProviderAffinity keeps a session on the provider that last served it when a model is
+	// available from several providers, so fallbacks do not move it to a provider with a cold
+	// prompt cache. The session falls back to the other providers while that provider has no
+	// available credential. Sessions are identified like SessionAffinity and retained for
+	// SessionAffinityTTL.
+	ProviderAffinity bool `yaml:"provider-affinity,omitempty" json:"provider-affinity,omitempty"`
+
+	// ConcurrencyWait is how long a request waits for a free slot when every candidate
+	// credential is at its max-concurrent-requests cap. Empty fails over to other credentials
+	// and then returns 429 without waiting. Accepts duration strings like "500ms", "10s".
+	ConcurrencyWait string `yaml:"concurrency-wait,omitempty" json:"concurrency-wait,omitempty"`
+
+	// StandbyThreshold is the number of available active credentials a provider must keep for
+	// its standby credentials to stay out of rotation. Standby credentials join the pool while
+	// fewer are available and return to standby once it recovers. 0 defaults to 1.
+	StandbyThreshold int `yaml:"standby-threshold,omitempty" json:"standby-threshold,omitempty"`
+
+	// PreferCheaperTiers routes each request to the cheapest billing tier of a provider that
+	// still has an available credential (free, then subscription, then paid), so free quotas
+	// are used up before paid ones. Credentials name their tier with "tier"; without it API
+	// keys count as paid, free Codex plans as free and other logins as subscriptions.
+	PreferCheaperTiers bool `yaml:"prefer-cheaper-tiers,omitempty" json:"prefer-cheaper-tiers,omitempty"`
+
+	// RegionFailover lists ordered groups of credential regions. Requests use the credentials of
+	// the first region of a group that has any for the model, and move to the next region only
+	// after a 5xx or timeout there. Credentials name their region with "region".
+	RegionFailover []RegionFailoverGroup `yaml:"region-failover,omitempty" json:"region-failover,omitempty"`
+
+	// RegionFailoverCooldown is how long a region is skipped after a 5xx or timeout.
+	// Default: 1m. Accepts duration strings like "30s", "5m".
+	RegionFailoverCooldown string `yaml:"region-failover-cooldown,omitempty" json:"region-failover-cooldown,omitempty"`
+}
+
+// RegionFailoverGroup is an ordered list of regions, primary first.
+type RegionFailoverGroup struct {
+	// Name identifies the group in logs and metrics. Defaults to the primary region.
+	Name string `yaml:"name,omitempty" json:"name,omitempty"`
+
+	// Regions lists the regions in failover order, such as "us-central1" or "europe-west4".
+	Regions []string `yaml:"regions" json:"regions"`
 }
 
 // ChutesConfig holds Chutes API configuration.
@@ -664,6 +1041,26 @@ type ClaudeKey struct {
 	// If empty, the default Claude API URL will be used.
 	BaseURL string `yaml:"base-url" json:"base-url"`
 
+	// RegionalBaseURLs lists alternative regional endpoints for this credential. Together with
+	// BaseURL they are probed and the fastest healthy one is used (see base-url-pinning).
+	RegionalBaseURLs []string `yaml:"regional-base-urls,omitempty" json:"regional-base-urls,omitempty"`
+
+	// MaxConcurrentRequests caps the in-flight requests of this credential; requests beyond it
+	// are routed to another credential (see routing.concurrency-wait). 0 means unlimited.
+	MaxConcurrentRequests int `yaml:"max-concurrent-requests,omitempty" json:"max-concurrent-requests,omitempty"`
+
+	// Standby keeps this credential out of rotation until the provider has fewer available
+	// active credentials than routing.standby-threshold.
+	Standby bool `yaml:"standby,omitempty" json:"standby,omitempty"`
+
+	// Tier names the billing tier of this credential for routing.prefer-cheaper-tiers:
+	// "free", "subscription" or "paid". Empty treats API keys as paid.
+	Tier string `yaml:"tier,omitempty" json:"tier,omitempty"`
+
+	// Residency is the data-residency region of this credential, such as "eu" or "us",
+	// matched against api-key-residency.
+	Residency string `yaml:"residency,omitempty" json:"residency,omitempty"`
+
 	// ProxyURL overrides the global proxy setting for this API key if provided.
 	ProxyURL string `yaml:"proxy-url" json:"proxy-url"`
 
@@ -682,6 +1079,10 @@ type ClaudeKey struct {
 	// DisableCooling disables auth/model cooldown scheduling for this credential when true.
 	DisableCooling bool `yaml:"disable-cooling,omitempty" json:"disable-cooling,omitempty"`
 
+	// AllowedBetas lists gated Anthropic beta features (e.g. "computer-use") this credential may use.
+	// An entry matches the exact beta or every dated version of a beta family.
+	AllowedBetas []string `yaml:"allowed-betas,omitempty" json:"allowed-betas,omitempty"`
+
 	// Cloak configures request cloaking for non-Claude-Code clients.
 	Cloak *CloakConfig `yaml:"cloak,omitempty" json:"cloak,omitempty"`
 
@@ -727,7 +1128,28 @@ type CodexKey struct {
 	// If empty, the default Codex API URL will be used.
 	BaseURL string `yaml:"base-url" json:"base-url"`
 
-	// Websockets enables the Responses API websocket transport for this credential.
+	// RegionalBaseURLs lists alternative regional endpoints for this credential. Together with
+	// BaseURL they are probed and the fastest healthy one is used (see base-url-pinning).
+	RegionalBaseURLs []string `yaml:"regional-base-urls,omitempty" json:"regional-base-urls,omitempty"`
+
+	// MaxConcurrentRequests caps the in-flight requests of this credential; requests beyond it
+	// are routed to another credential (see routing.concurrency-wait). 0 means unlimited.
+	MaxConcurrentRequests int `yaml:"max-concurrent-requests,omitempty" json:"max-concurrent-requests,omitempty"`
+
+	// Standby keeps this credential out of rotation until the provider has fewer available
+	// active credentials than routing.standby-threshold.
+	Standby bool `yaml:"standby,omitempty" json:"standby,omitempty"`
+
+	// Tier names the billing tier of this credential for routing.prefer-cheaper-tiers:
+	// "free", "subscription" or "paid". Empty treats API keys as paid.
+	Tier string `yaml:"tier,omitempty" json:"tier,omitempty"`
+
+	// Residency is the data-residency region of this credential, such as "eu" or "us",
+	// matched against api-key-residency.
+	Residency string `yaml:"residency,omitempty" json:"residency,omitempty"`
+
+	// Websockets enables the Responses API websocket transport for this credential. Requests fall
+	// back to HTTP when the websocket upgrade fails, is throttled or is refused with 426.
 	Websockets bool `yaml:"websockets,omitempty" json:"websockets,omitempty"`
 
 	// ProxyURL overrides the global proxy setting for this API key if provided.
@@ -785,6 +1207,10 @@ type CopilotKey struct {
 	// VSCodeChatHeaderModels lists model IDs that should always use the "vscode-chat" header profile.
 	VSCodeChatHeaderModels []string `yaml:"vscode-chat-header-models,omitempty" json:"vscode-chat-header-models,omitempty"`
 
+	// ResponsesModels lists model IDs served through Copilot's native /responses endpoint instead
+	// of /chat/completions, which keeps encrypted reasoning across turns.
+	ResponsesModels []string `yaml:"responses-models,omitempty" json:"responses-models,omitempty"`
+
 	// AgentInitiatorPersist, when true, forces subsequent Copilot requests sharing the
 	// same prompt_cache_key to send X-Initiator=agent after the first call. Default false.
 	AgentInitiatorPersist bool `yaml:"agent-initiator-persist" json:"agent-initiator-persist"`
@@ -855,6 +1281,10 @@ type GrokConfig struct {
 
 	// RequestTimeoutSeconds sets the HTTP client timeout for Grok requests (defaults to 120s when unset/zero).
 	RequestTimeoutSeconds int `yaml:"request-timeout,omitempty" json:"request-timeout,omitempty"`
+
+	// LegacyToolPrompt describes tools in an injected system prompt and parses <tool_call> tags
+	// from the answer instead of sending structured tool definitions.
+	LegacyToolPrompt bool `yaml:"legacy-tool-prompt,omitempty" json:"legacy-tool-prompt,omitempty"`
 }
 
 // CursorKey represents the configuration for a Cursor Composer API key.
@@ -935,6 +1365,30 @@ type GeminiKey struct {
 	// BaseURL optionally overrides the Gemini API endpoint.
 	BaseURL string `yaml:"base-url,omitempty" json:"base-url,omitempty"`
 
+	// RegionalBaseURLs lists alternative regional endpoints for this credential. Together with
+	// BaseURL they are probed and the fastest healthy one is used (see base-url-pinning).
+	RegionalBaseURLs []string `yaml:"regional-base-urls,omitempty" json:"regional-base-urls,omitempty"`
+
+	// MaxConcurrentRequests caps the in-flight requests of this credential; requests beyond it
+	// are routed to another credential (see routing.concurrency-wait). 0 means unlimited.
+	MaxConcurrentRequests int `yaml:"max-concurrent-requests,omitempty" json:"max-concurrent-requests,omitempty"`
+
+	// Standby keeps this credential out of rotation until the provider has fewer available
+	// active credentials than routing.standby-threshold.
+	Standby bool `yaml:"standby,omitempty" json:"standby,omitempty"`
+
+	// Tier names the billing tier of this credential for routing.prefer-cheaper-tiers:
+	// "free", "subscription" or "paid". Empty treats API keys as paid.
+	Tier string `yaml:"tier,omitempty" json:"tier,omitempty"`
+
+	// Residency is the data-residency region of this credential, such as "eu" or "us",
+	// matched against api-key-residency.
+	Residency string `yaml:"residency,omitempty" json:"residency,omitempty"`
+
+	// Region is the region this credential is served from, matched against
+	// routing.region-failover.
+	Region string `yaml:"region,omitempty" json:"region,omitempty"`
+
 	// ProxyURL optionally overrides the global proxy for this API key.
 	ProxyURL string `yaml:"proxy-url,omitempty" json:"proxy-url,omitempty"`
 
@@ -1009,6 +1463,10 @@ type OpenAICompatibility struct {
 	// Higher values are preferred; defaults to 0.
 	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
 
+	// Residency is the data-residency region of this provider's credentials, matched against
+	// api-key-residency.
+	Residency string `yaml:"residency,omitempty" json:"residency,omitempty"`
+
 	// Disabled prevents this provider from being used for routing.
 	Disabled bool `yaml:"disabled,omitempty" json:"disabled,omitempty"`
 
@@ -1033,6 +1491,88 @@ type OpenAICompatibility struct {
 	// SupportsDeveloperRole controls whether OpenAI-compatible requests may include the
 	// OpenAI "developer" role for this compatibility entry.
 	SupportsDeveloperRole *bool `yaml:"supports-developer-role,omitempty" json:"supports-developer-role,omitempty"`
+
+	// Signing optionally signs every upstream request, for gateways that require HMAC,
+	// AWS SigV4, or Google OAuth authenticated requests.
+	Signing *RequestSigning `yaml:"signing,omitempty" json:"signing,omitempty"`
+
+	// Quirks adapts Chat Completions requests and responses to upstreams that deviate
+	// from the OpenAI API.
+	Quirks *OpenAICompatibilityQuirks `yaml:"quirks,omitempty" json:"quirks,omitempty"`
+
+	// DetectCapabilities probes the provider on first use, with a /models request and a tiny
+	// chat request, to detect streaming, tool and stream usage support. Results are cached
+	// and merged into the registered models' supported parameters.
+	DetectCapabilities bool `yaml:"detect-capabilities,omitempty" json:"detect-capabilities,omitempty"`
+}
+
+// OpenAI compatibility tool formats accepted in OpenAICompatibilityQuirks.ToolFormat.
+const (
+	OpenAICompatToolFormatTools           = "tools"
+	OpenAICompatToolFormatLegacyFunctions = "legacy-functions"
+)
+
+// OpenAICompatibilityQuirks lists the deviations of an OpenAI-compatible upstream.
+type OpenAICompatibilityQuirks struct {
+	// NoSystemRole folds system and developer messages into the first user message.
+	NoSystemRole bool `yaml:"no-system-role,omitempty" json:"no-system-role,omitempty"`
+
+	// NoStreamUsage stops requesting stream_options.include_usage on streaming requests.
+	NoStreamUsage bool `yaml:"no-stream-usage,omitempty" json:"no-stream-usage,omitempty"`
+
+	// RequiresMaxTokens makes every request carry max_tokens. max_completion_tokens is
+	// renamed when present; otherwise this value is used.
+	RequiresMaxTokens int `yaml:"requires-max-tokens,omitempty" json:"requires-max-tokens,omitempty"`
+
+	// ToolFormat is "tools" (default) or "legacy-functions", which sends the deprecated
+	// functions/function_call fields and maps function_call responses back to tool_calls.
+	ToolFormat string `yaml:"tool-format,omitempty" json:"tool-format,omitempty"`
+}
+
+// LegacyFunctions reports whether the upstream only understands the deprecated functions API.
+func (q *OpenAICompatibilityQuirks) LegacyFunctions() bool {
+	if q == nil {
+		return false
+	}
+	format := strings.ToLower(strings.TrimSpace(q.ToolFormat))
+	return format == OpenAICompatToolFormatLegacyFunctions || format == "legacy_functions"
+}
+
+// Request signing types accepted in RequestSigning.Type.
+const (
+	RequestSigningHMAC  = "hmac"
+	RequestSigningSigV4 = "aws-sigv4"
+	RequestSigningGCP   = "gcp-oauth"
+)
+
+// RequestSigning configures how upstream requests are signed.
+type RequestSigning struct {
+	// Type is "hmac", "aws-sigv4", or "gcp-oauth".
+	Type string `yaml:"type" json:"type"`
+
+	// Secret is the HMAC-SHA256 key. The signature covers
+	// "<METHOD>\n<path?query>\n<timestamp>\n<hex sha256 of body>".
+	Secret string `yaml:"secret,omitempty" json:"secret,omitempty"`
+	// SignatureHeader receives the hex HMAC signature. Defaults to "X-Signature".
+	SignatureHeader string `yaml:"signature-header,omitempty" json:"signature-header,omitempty"`
+	// TimestampHeader receives the Unix timestamp used in the signature. Defaults to "X-Timestamp".
+	TimestampHeader string `yaml:"timestamp-header,omitempty" json:"timestamp-header,omitempty"`
+
+	// Region and Service scope AWS SigV4 signatures, e.g. "us-east-1" and "bedrock".
+	Region  string `yaml:"region,omitempty" json:"region,omitempty"`
+	Service string `yaml:"service,omitempty" json:"service,omitempty"`
+	// AccessKeyID, SecretAccessKey, and SessionToken are the AWS credentials. When empty, the
+	// standard AWS chain is used: AWS_* environment variables, web identity (EKS IRSA), the
+	// container endpoint (ECS, EKS Pod Identity) and the EC2 instance role.
+	AccessKeyID     string `yaml:"access-key-id,omitempty" json:"access-key-id,omitempty"`
+	SecretAccessKey string `yaml:"secret-access-key,omitempty" json:"secret-access-key,omitempty"`
+	SessionToken    string `yaml:"session-token,omitempty" json:"session-token,omitempty"`
+
+	// CredentialsFile is a Google service account JSON file. When empty, Application Default
+	// Credentials are used.
+	CredentialsFile string `yaml:"credentials-file,omitempty" json:"credentials-file,omitempty"`
+	// Scopes override the Google OAuth scopes. Defaults to cloud-platform.
+	Scopes []string `yaml:"scopes,omitempty" json:"scopes,omitempty"`
 }
 
 // OpenAICompatibilityAPIKey represents an API key configuration with optional proxy setting.
@@ -1115,6 +1655,17 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 		return cfg, nil
 	}
 
+	// Merge config.d overlays on top of the main file before decoding.
+	mergedData, errOverlay := applyConfigOverlays(configFile, data)
+	if errOverlay != nil {
+		if !optional {
+			return nil, errOverlay
+		}
+		log.WithError(errOverlay).Warn("ignoring invalid config overlays")
+	} else {
+		data = mergedData
+	}
+
 	// Unmarshal the YAML data into the Config struct.
 	var cfg Config
 	// Set defaults before unmarshal so that absent keys keep defaults.
@@ -1239,6 +1790,8 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 
 	cfg.NormalizePluginsConfig()
 
+	cfg.SanitizeTokenStore()
+
 	// Sanitize Gemini API key configuration and migrate legacy entries.
 	cfg.SanitizeGeminiKeys()
 
@@ -1267,6 +1820,9 @@ func LoadConfigOptional(configFile string, optional bool) (*Config, error) {
 	// Sanitize Claude key headers
 	cfg.SanitizeClaudeKeys()
 
+	// Sanitize provider beta rules
+	cfg.SanitizeProviderBetas()
+
 	// Sanitize Kiro keys: trim whitespace from credential fields
 	cfg.SanitizeKiroKeys()
 
@@ -1844,6 +2400,9 @@ func (cfg *Config) SanitizeCopilotKeys() {
 		for j := range entry.VSCodeChatHeaderModels {
 			entry.VSCodeChatHeaderModels[j] = strings.TrimSpace(entry.VSCodeChatHeaderModels[j])
 		}
+		for j := range entry.ResponsesModels {
+			entry.ResponsesModels[j] = strings.TrimSpace(entry.ResponsesModels[j])
+		}
 	}
 }
 
@@ -1956,6 +2515,7 @@ func (cfg *Config) SanitizeClaudeKeys() {
 		entry.Prefix = normalizeModelPrefix(entry.Prefix)
 		entry.Headers = NormalizeHeaders(entry.Headers)
 		entry.ExcludedModels = NormalizeExcludedModels(entry.ExcludedModels)
+		entry.AllowedBetas = NormalizeAllowedBetas(entry.AllowedBetas)
 	}
 }
 
@@ -2080,6 +2640,11 @@ func NormalizeExcludedModels(models []string) []string {
 	return out
 }
 
+// NormalizeAllowedBetas trims, lowercases, and deduplicates beta feature names.
+func NormalizeAllowedBetas(betas []string) []string {
+	return NormalizeExcludedModels(betas)
+}
+
 func splitAndTrim(csv string) []string {
 	csv = strings.TrimSpace(csv)
 	if csv == "" {
@@ -2322,6 +2887,13 @@ func SaveConfigPreserveComments(configFile string, cfg *Config) error {
 		return fmt.Errorf("expected generated root mapping node")
 	}
 
+	// Values loaded from config.d overlays belong to those files; never copy them into the main file.
+	overlay, errOverlay := loadOverlayRoot(configFile)
+	if errOverlay != nil {
+		return errOverlay
+	}
+	excludeOverlayContent(original.Content[0], generated.Content[0], overlay)
+
 	// Remove deprecated sections before merging back the sanitized config.
 	removeLegacyAuthBlock(original.Content[0])
 	removeLegacyOpenAICompatAPIKeys(original.Content[0])
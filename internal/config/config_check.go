@@ -0,0 +1,129 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CheckConfigKeys decodes configFile and each of its overlay files with unknown keys rejected,
+// so misspelled settings that LoadConfig silently ignores are reported with the file naming them.
+func CheckConfigKeys(configFile string) error {
+	files, err := OverlayFiles(configFile)
+	if err != nil {
+		return err
+	}
+	var problems []error
+	for _, path := range append([]string{configFile}, files...) {
+		data, errRead := os.ReadFile(path)
+		if errRead != nil {
+			problems = append(problems, errRead)
+			continue
+		}
+		if len(bytes.TrimSpace(data)) == 0 {
+			continue
+		}
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		var cfg Config
+		if errDecode := decoder.Decode(&cfg); errDecode != nil && !errors.Is(errDecode, io.EOF) {
+			problems = append(problems, fmt.Errorf("%s: %w", path, errDecode))
+		}
+	}
+	return errors.Join(problems...)
+}
+
+// Validate reports settings of the inbound rate limit, shared cache, region failover and
+// virtual model sections that would be rejected or ignored at runtime.
+func (c *Config) Validate() error {
+	if c == nil {
+		return nil
+	}
+	var problems []error
+
+	limit := c.InboundRateLimit
+	problems = append(problems, validateBackend("inbound-rate-limit", limit.Backend, limit.Redis)...)
+	if limit.RequestsPerMinute < 0 || limit.Burst < 0 {
+		problems = append(problems, errors.New("inbound-rate-limit: requests-per-minute and burst must not be negative"))
+	}
+	for _, rule := range limit.Keys {
+		if rule.RequestsPerMinute < 0 || rule.Burst < 0 {
+			// The key itself is a secret, so it is not named.
+			problems = append(problems, errors.New("inbound-rate-limit.keys: a key has a negative requests-per-minute or burst"))
+			break
+		}
+	}
+	problems = append(problems, validateBackend("shared-cache", c.SharedCache.Backend, c.SharedCache.Redis)...)
+
+	for i, group := range c.Routing.RegionFailover {
+		if len(group.Regions) == 0 {
+			problems = append(problems, fmt.Errorf("routing.region-failover[%d]: regions is empty", i))
+		}
+		seen := make(map[string]struct{}, len(group.Regions))
+		for _, region := range group.Regions {
+			region = strings.ToLower(strings.TrimSpace(region))
+			if region == "" {
+				problems = append(problems, fmt.Errorf("routing.region-failover[%d]: region name is empty", i))
+				continue
+			}
+			if _, dup := seen[region]; dup {
+				problems = append(problems, fmt.Errorf("routing.region-failover[%d]: region %s is listed twice", i, region))
+			}
+			seen[region] = struct{}{}
+		}
+	}
+	if raw := strings.TrimSpace(c.Routing.RegionFailoverCooldown); raw != "" {
+		if cooldown, errParse := time.ParseDuration(raw); errParse != nil || cooldown <= 0 {
+			problems = append(problems, fmt.Errorf("routing.region-failover-cooldown: %q is not a positive duration", raw))
+		}
+	}
+
+	names := make(map[string]struct{}, len(c.VirtualModels))
+	for i, virtual := range c.VirtualModels {
+		name := strings.ToLower(strings.TrimSpace(virtual.Name))
+		if name == "" {
+			problems = append(problems, fmt.Errorf("virtual-models[%d]: name is empty", i))
+		} else if _, dup := names[name]; dup {
+			problems = append(problems, fmt.Errorf("virtual-models[%d]: name %s is used twice", i, virtual.Name))
+		}
+		names[name] = struct{}{}
+		if len(virtual.Candidates) == 0 {
+			problems = append(problems, fmt.Errorf("virtual-models[%d]: candidates is empty", i))
+		}
+		for j, candidate := range virtual.Candidates {
+			if strings.TrimSpace(candidate.Model) == "" {
+				problems = append(problems, fmt.Errorf("virtual-models[%d].candidates[%d]: model is empty", i, j))
+			}
+			switch strings.ToLower(strings.TrimSpace(candidate.When)) {
+			case "", "else", "always", "tools", "vision":
+			default:
+				problems = append(problems, fmt.Errorf("virtual-models[%d].candidates[%d]: unknown condition %q", i, j, candidate.When))
+			}
+		}
+	}
+	return errors.Join(problems...)
+}
+
+// validateBackend checks a memory/redis backend selection and the redis settings it needs.
+func validateBackend(section, backend string, redis RedisConfig) []error {
+	switch strings.ToLower(strings.TrimSpace(backend)) {
+	case "", "memory":
+		return nil
+	case "redis":
+		if strings.TrimSpace(redis.Addr) == "" {
+			return []error{fmt.Errorf("%s: the redis backend requires redis.addr", section)}
+		}
+		if redis.DB < 0 {
+			return []error{fmt.Errorf("%s: redis.db must not be negative", section)}
+		}
+		return nil
+	default:
+		return []error{fmt.Errorf("%s: unknown backend %q", section, backend)}
+	}
+}
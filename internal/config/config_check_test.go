@@ -0,0 +1,79 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCheckConfigKeysReportsUnknownKeysInOverlays(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.yaml")
+	writeOverlayTestFile(t, configPath, "port: 8317\ninbound-rate-limit:\n  enabled: true\n  requests-per-minute: 60\n")
+	writeOverlayTestFile(t, filepath.Join(dir, OverlayDirName, "10-keys.yaml"), "api-keys:\n  - k1\n")
+	if errCheck := CheckConfigKeys(configPath); errCheck != nil {
+		t.Fatalf("CheckConfigKeys() error = %v", errCheck)
+	}
+
+	overlay := filepath.Join(dir, OverlayDirName, "20-routing.yaml")
+	writeOverlayTestFile(t, overlay, "routing:\n  region-failovr:\n    - regions: [us-east1]\n")
+	errCheck := CheckConfigKeys(configPath)
+	if errCheck == nil || !strings.Contains(errCheck.Error(), overlay) || !strings.Contains(errCheck.Error(), "region-failovr") {
+		t.Fatalf("CheckConfigKeys() error = %v, want the misspelled key in %s", errCheck, overlay)
+	}
+}
+
+func TestCheckConfigKeysAcceptsExampleConfig(t *testing.T) {
+	if errCheck := CheckConfigKeys(filepath.Join("..", "..", "config.example.yaml")); errCheck != nil {
+		t.Fatalf("CheckConfigKeys(config.example.yaml) error = %v", errCheck)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	valid := &Config{
+		InboundRateLimit: InboundRateLimitConfig{Backend: "redis", RequestsPerMinute: 60, Redis: RedisConfig{Addr: "redis:6379"}},
+		Routing: RoutingConfig{
+			RegionFailover:         []RegionFailoverGroup{{Regions: []string{"us-east1", "europe-west4"}}},
+			RegionFailoverCooldown: "30s",
+		},
+		SDKConfig: SDKConfig{VirtualModels: []VirtualModel{{
+			Name:       "auto",
+			Candidates: []VirtualModelCandidate{{Model: "gpt-5", When: "vision"}, {Model: "gpt-5-mini"}},
+		}}},
+	}
+	if errValidate := valid.Validate(); errValidate != nil {
+		t.Fatalf("Validate() error = %v", errValidate)
+	}
+
+	invalid := &Config{
+		InboundRateLimit: InboundRateLimitConfig{Backend: "redis", Burst: -1},
+		SharedCache:      SharedCacheConfig{Backend: "memcached"},
+		Routing: RoutingConfig{
+			RegionFailover:         []RegionFailoverGroup{{Regions: []string{"us-east1", "US-EAST1"}}, {}},
+			RegionFailoverCooldown: "soon",
+		},
+		SDKConfig: SDKConfig{VirtualModels: []VirtualModel{
+			{Name: "auto", Candidates: []VirtualModelCandidate{{Model: "gpt-5", When: "images"}}},
+			{Name: "Auto"},
+		}},
+	}
+	errValidate := invalid.Validate()
+	if errValidate == nil {
+		t.Fatal("Validate() = nil, want problems")
+	}
+	for _, want := range []string{
+		"inbound-rate-limit: the redis backend requires redis.addr",
+		"inbound-rate-limit: requests-per-minute and burst must not be negative",
+		`shared-cache: unknown backend "memcached"`,
+		"routing.region-failover[0]: region us-east1 is listed twice",
+		"routing.region-failover[1]: regions is empty",
+		`routing.region-failover-cooldown: "soon"`,
+		`virtual-models[0].candidates[0]: unknown condition "images"`,
+		"virtual-models[1]: name Auto is used twice",
+		"virtual-models[1]: candidates is empty",
+	} {
+		if !strings.Contains(errValidate.Error(), want) {
+			t.Errorf("Validate() error lacks %q:\n%v", want, errValidate)
+		}
+	}
+}
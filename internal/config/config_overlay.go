@@ -0,0 +1,247 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// OverlayDirName is the directory next to the main config file whose YAML files are
+// merged on top of it, so credentials, routing rules and API keys can live in separately
+// managed files.
+const OverlayDirName = "config.d"
+
+// OverlayDir returns the overlay directory for configFile.
+func OverlayDir(configFile string) string {
+	return filepath.Join(filepath.Dir(configFile), OverlayDirName)
+}
+
+// IsOverlayFile reports whether name has an extension that is loaded from the overlay directory.
+func IsOverlayFile(name string) bool {
+	base := filepath.Base(name)
+	if strings.HasPrefix(base, ".") {
+		return false
+	}
+	switch strings.ToLower(filepath.Ext(base)) {
+	case ".yaml", ".yml":
+		return true
+	default:
+		return false
+	}
+}
+
+// OverlayFiles lists the overlay files for configFile in merge order. Files are applied in
+// lexical order of their names, so "10-keys.yaml" overrides the main config and is in turn
+// overridden by "20-routing.yaml". A missing overlay directory yields no files.
+func OverlayFiles(configFile string) ([]string, error) {
+	dir := OverlayDir(configFile)
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read config overlay directory: %w", err)
+	}
+	files := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || !IsOverlayFile(entry.Name()) {
+			continue
+		}
+		files = append(files, filepath.Join(dir, entry.Name()))
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// loadOverlayRoot parses every overlay file and merges them into a single mapping node.
+// It returns nil when no overlay contributes any keys.
+func loadOverlayRoot(configFile string) (*yaml.Node, error) {
+	files, err := OverlayFiles(configFile)
+	if err != nil {
+		return nil, err
+	}
+	var merged *yaml.Node
+	for _, path := range files {
+		data, errRead := os.ReadFile(path)
+		if errRead != nil {
+			return nil, fmt.Errorf("read config overlay %s: %w", path, errRead)
+		}
+		if len(bytes.TrimSpace(data)) == 0 {
+			continue
+		}
+		var doc yaml.Node
+		if errParse := yaml.Unmarshal(data, &doc); errParse != nil {
+			return nil, fmt.Errorf("parse config overlay %s: %w", path, errParse)
+		}
+		if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0] == nil {
+			continue
+		}
+		root := doc.Content[0]
+		if root.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("config overlay %s: expected a mapping at the top level", path)
+		}
+		if merged == nil {
+			merged = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		}
+		mergeOverlayNode(merged, root)
+	}
+	return merged, nil
+}
+
+// applyConfigOverlays merges the overlay files for configFile onto the main config YAML.
+// Mappings merge recursively, sequences are concatenated (skipping entries already present),
+// and any other value in a later file replaces the earlier one.
+func applyConfigOverlays(configFile string, data []byte) ([]byte, error) {
+	overlay, err := loadOverlayRoot(configFile)
+	if err != nil || overlay == nil {
+		return data, err
+	}
+
+	root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	if len(bytes.TrimSpace(data)) > 0 {
+		var doc yaml.Node
+		if errParse := yaml.Unmarshal(data, &doc); errParse != nil {
+			return nil, fmt.Errorf("failed to parse config file: %w", errParse)
+		}
+		if doc.Kind == yaml.DocumentNode && len(doc.Content) > 0 && doc.Content[0] != nil {
+			if doc.Content[0].Kind != yaml.MappingNode {
+				return nil, fmt.Errorf("failed to parse config file: expected root mapping node")
+			}
+			root = doc.Content[0]
+		}
+	}
+	mergeOverlayNode(root, overlay)
+
+	merged, err := yaml.Marshal(root)
+	if err != nil {
+		return nil, fmt.Errorf("render merged config: %w", err)
+	}
+	log.Debugf("merged config overlays from %s", OverlayDir(configFile))
+	return merged, nil
+}
+
+// mergeOverlayNode merges the src mapping into dst in place.
+func mergeOverlayNode(dst, src *yaml.Node) {
+	for i := 0; i+1 < len(src.Content); i += 2 {
+		key, value := src.Content[i], src.Content[i+1]
+		idx := findMapKeyIndex(dst, key.Value)
+		if idx < 0 {
+			dst.Content = append(dst.Content, deepCopyNode(key), deepCopyNode(value))
+			continue
+		}
+		existing := dst.Content[idx+1]
+		switch {
+		case existing.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode:
+			mergeOverlayNode(existing, value)
+		case existing.Kind == yaml.SequenceNode && value.Kind == yaml.SequenceNode:
+			for _, item := range value.Content {
+				if overlaySequenceContains(existing, item) {
+					continue
+				}
+				existing.Content = append(existing.Content, deepCopyNode(item))
+			}
+		default:
+			dst.Content[idx+1] = deepCopyNode(value)
+		}
+	}
+}
+
+// excludeOverlayContent removes values contributed by overlays from a rendered config before
+// it is written back to the main file. Keys that only exist in overlays are dropped, and
+// sequence entries that come from an overlay are filtered out, so saving never copies
+// overlay content into the main config.
+func excludeOverlayContent(original, generated, overlay *yaml.Node) {
+	if original == nil || generated == nil || overlay == nil {
+		return
+	}
+	for i := 0; i+1 < len(overlay.Content); i += 2 {
+		key, value := overlay.Content[i].Value, overlay.Content[i+1]
+		genIdx := findMapKeyIndex(generated, key)
+		if genIdx < 0 {
+			continue
+		}
+		origIdx := findMapKeyIndex(original, key)
+		if origIdx < 0 {
+			removeMapKey(generated, key)
+			continue
+		}
+		genValue := generated.Content[genIdx+1]
+		switch {
+		case genValue.Kind == yaml.MappingNode && value.Kind == yaml.MappingNode:
+			excludeOverlayContent(original.Content[origIdx+1], genValue, value)
+		case genValue.Kind == yaml.SequenceNode && value.Kind == yaml.SequenceNode:
+			kept := genValue.Content[:0]
+			for _, item := range genValue.Content {
+				if overlaySequenceContains(value, item) {
+					continue
+				}
+				kept = append(kept, item)
+			}
+			genValue.Content = kept
+		}
+	}
+}
+
+func overlaySequenceContains(seq, item *yaml.Node) bool {
+	for _, existing := range seq.Content {
+		if overlayNodesEqual(existing, item) {
+			return true
+		}
+	}
+	return false
+}
+
+// overlayNodesEqual compares two nodes while ignoring zero-valued mapping entries, so a
+// hand-written overlay entry matches the same entry rendered from Config.
+func overlayNodesEqual(a, b *yaml.Node) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.Kind == yaml.AliasNode {
+		return overlayNodesEqual(a.Alias, b)
+	}
+	if b.Kind == yaml.AliasNode {
+		return overlayNodesEqual(a, b.Alias)
+	}
+	if a.Kind != b.Kind {
+		return false
+	}
+	switch a.Kind {
+	case yaml.MappingNode:
+		count := 0
+		for i := 0; i+1 < len(a.Content); i += 2 {
+			if isZeroValueNode(a.Content[i+1]) {
+				continue
+			}
+			count++
+			idx := findMapKeyIndex(b, a.Content[i].Value)
+			if idx < 0 || !overlayNodesEqual(a.Content[i+1], b.Content[idx+1]) {
+				return false
+			}
+		}
+		for i := 0; i+1 < len(b.Content); i += 2 {
+			if !isZeroValueNode(b.Content[i+1]) {
+				count--
+			}
+		}
+		return count == 0
+	case yaml.SequenceNode:
+		if len(a.Content) != len(b.Content) {
+			return false
+		}
+		for i := range a.Content {
+			if !overlayNodesEqual(a.Content[i], b.Content[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return strings.TrimSpace(a.Value) == strings.TrimSpace(b.Value)
+	}
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeOverlayTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if errMkdir := os.MkdirAll(filepath.Dir(path), 0o755); errMkdir != nil {
+		t.Fatalf("failed to create dir: %v", errMkdir)
+	}
+	if errWrite := os.WriteFile(path, []byte(content), 0o600); errWrite != nil {
+		t.Fatalf("failed to write %s: %v", path, errWrite)
+	}
+}
+
+func TestLoadConfigMergesOverlaysInOrder(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yaml")
+	writeOverlayTestFile(t, configFile, "port: 8317\napi-keys:\n  - main-key\nrouting:\n  strategy: round-robin\n")
+	writeOverlayTestFile(t, filepath.Join(dir, OverlayDirName, "10-keys.yaml"), "api-keys:\n  - team-key\n  - main-key\n")
+	writeOverlayTestFile(t, filepath.Join(dir, OverlayDirName, "20-port.yml"), "port: 9000\n")
+	writeOverlayTestFile(t, filepath.Join(dir, OverlayDirName, "30-port.yaml"), "port: 9100\n")
+	writeOverlayTestFile(t, filepath.Join(dir, OverlayDirName, "notes.txt"), "port: 1\n")
+
+	cfg, err := LoadConfig(configFile)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if cfg.Port != 9100 {
+		t.Fatalf("port = %d, want 9100 from the last overlay", cfg.Port)
+	}
+	if got := strings.Join(cfg.APIKeys, ","); got != "main-key,team-key" {
+		t.Fatalf("api-keys = %q, want main-key,team-key", got)
+	}
+	if cfg.Routing.Strategy != "round-robin" {
+		t.Fatalf("routing.strategy = %q, want round-robin from the main file", cfg.Routing.Strategy)
+	}
+}
+
+func TestLoadConfigRejectsInvalidOverlay(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yaml")
+	writeOverlayTestFile(t, configFile, "port: 8317\n")
+	writeOverlayTestFile(t, filepath.Join(dir, OverlayDirName, "bad.yaml"), "- not a mapping\n")
+
+	if _, err := LoadConfig(configFile); err == nil {
+		t.Fatal("expected error for non-mapping overlay")
+	}
+}
+
+func TestSaveConfigPreserveCommentsKeepsOverlayContentOut(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config.yaml")
+	writeOverlayTestFile(t, configFile, "port: 8317\napi-keys:\n  - main-key\n")
+	writeOverlayTestFile(t, filepath.Join(dir, OverlayDirName, "keys.yaml"), "api-keys:\n  - team-key\nproxy-url: socks5://127.0.0.1:1080\n")
+
+	cfg, err := LoadConfig(configFile)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	cfg.Port = 8400
+	if errSave := SaveConfigPreserveComments(configFile, cfg); errSave != nil {
+		t.Fatalf("SaveConfigPreserveComments() error = %v", errSave)
+	}
+
+	saved, errRead := os.ReadFile(configFile)
+	if errRead != nil {
+		t.Fatalf("failed to read saved config: %v", errRead)
+	}
+	text := string(saved)
+	if strings.Contains(text, "team-key") || strings.Contains(text, "proxy-url") {
+		t.Fatalf("overlay content leaked into main config:\n%s", text)
+	}
+	if !strings.Contains(text, "port: 8400") || !strings.Contains(text, "main-key") {
+		t.Fatalf("main config lost its own values:\n%s", text)
+	}
+}
@@ -3,6 +3,8 @@ package config
 import (
 	"strings"
 	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
 )
 
 const (
@@ -137,6 +139,33 @@ func FindManagedProviderByPrefix(cfg *SDKConfig, model string) (ManagedProviderC
 	return ManagedProviderConfig{}, model, false
 }
 
+// ModelRoutingPrefixes returns every model-name prefix that forces a provider, built-in and
+// managed, in the order request routing checks them.
+func ModelRoutingPrefixes(cfg *SDKConfig) []registry.RoutingPrefix {
+	prefixes := registry.BuiltinRoutingPrefixes()
+	if cfg == nil {
+		return prefixes
+	}
+	for _, protocol := range []string{
+		ManagedProviderProtocolOpenAIResponses,
+		ManagedProviderProtocolOpenAICompletions,
+		ManagedProviderProtocolAnthropic,
+		ManagedProviderProtocolOpenAI,
+	} {
+		for _, provider := range cfg.ManagedProviders {
+			if prefix := ManagedProviderPrefix(provider); prefix != "" {
+				prefixes = append(prefixes, registry.RoutingPrefix{Prefix: protocol + "-" + prefix, Provider: ManagedProviderName(provider)})
+			}
+		}
+	}
+	for _, provider := range cfg.ManagedProviders {
+		if prefix := ManagedProviderPrefix(provider); prefix != "" {
+			prefixes = append(prefixes, registry.RoutingPrefix{Prefix: prefix, Provider: ManagedProviderName(provider)})
+		}
+	}
+	return prefixes
+}
+
 // FindManagedProviderByProtocolPrefix returns a managed provider when model uses
 // "<protocol>-<provider-prefix><model>", such as "anthropic-vsllm-qwen3.7-max".
 func FindManagedProviderByProtocolPrefix(cfg *SDKConfig, model string) (ManagedProviderConfig, string, string, bool) {
@@ -0,0 +1,91 @@
+package config
+
+import "strings"
+
+// ProviderBetaRule enables upstream beta features for the models it matches.
+type ProviderBetaRule struct {
+	// Models lists model names or wildcard patterns (e.g. "claude-sonnet-4*"). Empty matches every model.
+	Models []string `yaml:"models,omitempty" json:"models,omitempty"`
+	// Betas lists beta names (e.g. "context-1m-2025-08-07") or beta families (e.g. "context-1m").
+	Betas []string `yaml:"betas" json:"betas"`
+}
+
+// EnabledBetas returns the betas enabled for model on provider by the provider-betas rules.
+// Rules under "*" apply to every provider.
+func (cfg *Config) EnabledBetas(provider, model string) []string {
+	if cfg == nil || len(cfg.ProviderBetas) == 0 {
+		return nil
+	}
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	model = strings.ToLower(strings.TrimSpace(model))
+	var out []string
+	for key, rules := range cfg.ProviderBetas {
+		key = strings.ToLower(strings.TrimSpace(key))
+		if key != "*" && key != provider {
+			continue
+		}
+		for _, rule := range rules {
+			if rule.matchesModel(model) {
+				out = append(out, rule.Betas...)
+			}
+		}
+	}
+	return out
+}
+
+func (r ProviderBetaRule) matchesModel(model string) bool {
+	if len(r.Models) == 0 {
+		return true
+	}
+	for _, pattern := range r.Models {
+		if matchModelPattern(strings.ToLower(strings.TrimSpace(pattern)), model) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchModelPattern matches model against pattern, where '*' matches any run of characters.
+func matchModelPattern(pattern, model string) bool {
+	if pattern == "" {
+		return false
+	}
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == model
+	}
+	if !strings.HasPrefix(model, parts[0]) {
+		return false
+	}
+	model = model[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		idx := strings.Index(model, part)
+		if idx < 0 {
+			return false
+		}
+		model = model[idx+len(part):]
+	}
+	return strings.HasSuffix(model, parts[len(parts)-1])
+}
+
+// SanitizeProviderBetas trims and deduplicates provider-betas entries and drops rules without betas.
+func (cfg *Config) SanitizeProviderBetas() {
+	if cfg == nil || len(cfg.ProviderBetas) == 0 {
+		return
+	}
+	for provider, rules := range cfg.ProviderBetas {
+		kept := rules[:0]
+		for _, rule := range rules {
+			rule.Betas = NormalizeAllowedBetas(rule.Betas)
+			if len(rule.Betas) == 0 {
+				continue
+			}
+			kept = append(kept, rule)
+		}
+		if len(kept) == 0 {
+			delete(cfg.ProviderBetas, provider)
+			continue
+		}
+		cfg.ProviderBetas[provider] = kept
+	}
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEnabledBetas_MatchesProviderAndModel(t *testing.T) {
+	cfg := &Config{ProviderBetas: map[string][]ProviderBetaRule{
+		"claude": {
+			{Models: []string{"claude-sonnet-4*"}, Betas: []string{"context-1m-2025-08-07"}},
+			{Betas: []string{"extended-cache-ttl"}},
+		},
+		"*":     {{Models: []string{"*-opus-*"}, Betas: []string{"mcp-client"}}},
+		"codex": {{Betas: []string{"unused"}}},
+	}}
+
+	got := cfg.EnabledBetas("claude", "claude-sonnet-4-5")
+	want := []string{"context-1m-2025-08-07", "extended-cache-ttl"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("EnabledBetas(claude, sonnet) = %v, want %v", got, want)
+	}
+
+	got = cfg.EnabledBetas("Claude", "claude-opus-4-1")
+	if len(got) != 2 || !containsString(got, "mcp-client") || containsString(got, "context-1m-2025-08-07") {
+		t.Fatalf("EnabledBetas(claude, opus) = %v, want extended-cache-ttl and mcp-client", got)
+	}
+}
+
+func TestSanitizeProviderBetas_DropsEmptyRules(t *testing.T) {
+	cfg := &Config{ProviderBetas: map[string][]ProviderBetaRule{
+		"claude": {{Betas: []string{" Context-1M ", "context-1m", ""}}, {Models: []string{"x"}}},
+		"empty":  {{Betas: []string{" "}}},
+	}}
+	cfg.SanitizeProviderBetas()
+
+	if _, ok := cfg.ProviderBetas["empty"]; ok {
+		t.Fatal("expected provider without betas to be removed")
+	}
+	rules := cfg.ProviderBetas["claude"]
+	if len(rules) != 1 || !reflect.DeepEqual(rules[0].Betas, []string{"context-1m"}) {
+		t.Fatalf("claude rules = %+v, want one rule with [context-1m]", rules)
+	}
+}
+
+func containsString(values []string, want string) bool {
+	for _, v := range values {
+		if v == want {
+			return true
+		}
+	}
+	return false
+}
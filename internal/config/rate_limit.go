@@ -0,0 +1,60 @@
+package config
+
+// InboundRateLimitConfig limits how fast each client API key may send requests to the proxy.
+type InboundRateLimitConfig struct {
+	// Enabled turns the limiter on.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// Backend selects where buckets are kept: "memory" (default, per process) or "redis"
+	// (shared by every replica pointing at the same Redis).
+	Backend string `yaml:"backend,omitempty" json:"backend,omitempty"`
+	// RequestsPerMinute is the sustained rate allowed for each key. 0 disables the default limit.
+	RequestsPerMinute int `yaml:"requests-per-minute" json:"requests-per-minute"`
+	// Burst is how many requests a key may send at once. Defaults to RequestsPerMinute.
+	Burst int `yaml:"burst,omitempty" json:"burst,omitempty"`
+	// Keys overrides the limit for specific API keys.
+	Keys map[string]InboundRateLimitRule `yaml:"keys,omitempty" json:"keys,omitempty"`
+	// Redis configures the redis backend.
+	Redis RedisConfig `yaml:"redis,omitempty" json:"redis,omitempty"`
+}
+
+// InboundRateLimitRule is the limit of one API key. RequestsPerMinute 0 exempts the key.
+type InboundRateLimitRule struct {
+	RequestsPerMinute int `yaml:"requests-per-minute" json:"requests-per-minute"`
+	Burst             int `yaml:"burst,omitempty" json:"burst,omitempty"`
+}
+
+// SharedCacheConfig selects the backend of caches that can be shared between replicas.
+type SharedCacheConfig struct {
+	// Backend is "memory" (default, per process) or "redis".
+	Backend string `yaml:"backend,omitempty" json:"backend,omitempty"`
+	// Redis configures the redis backend.
+	Redis RedisConfig `yaml:"redis,omitempty" json:"redis,omitempty"`
+}
+
+// RedisConfig locates a Redis server holding state shared by every replica.
+type RedisConfig struct {
+	Addr     string `yaml:"addr" json:"addr"`
+	Username string `yaml:"username,omitempty" json:"username,omitempty"`
+	Password string `yaml:"password,omitempty" json:"-"`
+	DB       int    `yaml:"db,omitempty" json:"db,omitempty"`
+	// TLS connects to Redis over TLS.
+	TLS bool `yaml:"tls,omitempty" json:"tls,omitempty"`
+	// KeyPrefix namespaces keys. Default: "cliproxy:ratelimit:" for rate limit buckets and
+	// "cliproxy:cache:" for shared caches.
+	KeyPrefix string `yaml:"key-prefix,omitempty" json:"key-prefix,omitempty"`
+}
+
+// RuleForKey returns the limit that applies to apiKey and whether it is limited at all.
+func (c InboundRateLimitConfig) RuleForKey(apiKey string) (InboundRateLimitRule, bool) {
+	rule := InboundRateLimitRule{RequestsPerMinute: c.RequestsPerMinute, Burst: c.Burst}
+	if override, ok := c.Keys[apiKey]; ok {
+		rule = override
+	}
+	if rule.RequestsPerMinute <= 0 {
+		return InboundRateLimitRule{}, false
+	}
+	if rule.Burst <= 0 {
+		rule.Burst = rule.RequestsPerMinute
+	}
+	return rule, true
+}
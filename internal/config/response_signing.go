@@ -0,0 +1,13 @@
+package config
+
+// ResponseSigningConfig signs proxy response bodies so downstream consumers can verify which
+// proxy and provider produced an output.
+type ResponseSigningConfig struct {
+	// Enable attaches an Ed25519 signature and the serving provider and auth hash to responses.
+	Enable bool `yaml:"enable" json:"enable"`
+	// PrivateKeyFile is a PEM (PKCS#8) Ed25519 private key. A key is generated there when the
+	// file does not exist; when empty, an ephemeral key is generated at startup.
+	PrivateKeyFile string `yaml:"private-key-file,omitempty" json:"private-key-file,omitempty"`
+	// KeyID is sent with each signature. Defaults to a fingerprint of the public key.
+	KeyID string `yaml:"key-id,omitempty" json:"key-id,omitempty"`
+}
@@ -0,0 +1,27 @@
+package config
+
+// MemoryGuardConfig rejects new requests with 503 while the process is above a memory
+// watermark, instead of letting a burst of large requests or streams exhaust memory.
+type MemoryGuardConfig struct {
+	// Enabled turns admission control on.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+	// HighWatermarkMB is the process memory (Go runtime, excluding memory returned to the OS)
+	// above which new requests are rejected. 0 disables the check.
+	HighWatermarkMB int `yaml:"high-watermark-mb" json:"high-watermark-mb"`
+	// MaxInflightMB caps the approximate memory held by in-flight request payloads and stream
+	// buffers. 0 disables the check.
+	MaxInflightMB int `yaml:"max-inflight-mb,omitempty" json:"max-inflight-mb,omitempty"`
+	// RetryAfterSeconds is sent as Retry-After on rejected requests. Default: 5.
+	RetryAfterSeconds int `yaml:"retry-after-seconds,omitempty" json:"retry-after-seconds,omitempty"`
+}
+
+// StreamWatchdogConfig raises alarms for response streams that live too long or pile up, which
+// usually means a stream goroutine is stuck.
+type StreamWatchdogConfig struct {
+	// MaxAgeMinutes is the age above which a live stream is reported. 0 uses the default (60);
+	// negative disables the age alarm.
+	MaxAgeMinutes int `yaml:"max-age-minutes,omitempty" json:"max-age-minutes,omitempty"`
+	// MaxCount is the number of concurrent live streams above which an alarm is raised. 0 disables
+	// the count alarm.
+	MaxCount int `yaml:"max-count,omitempty" json:"max-count,omitempty"`
+}
@@ -56,6 +56,24 @@ type SDKConfig struct {
 	// APIKeys is a list of keys for authenticating clients to this proxy server.
 	APIKeys []string `yaml:"api-keys" json:"api-keys"`
 
+	// APIKeyModels restricts which models client API keys can list and call. Keys not named by
+	// any entry are unrestricted.
+	APIKeyModels []APIKeyModelScope `yaml:"api-key-models,omitempty" json:"api-key-models,omitempty"`
+
+	// APIKeyPriorities assigns client API keys to admission priority classes. Keys not named by
+	// any entry are "normal".
+	APIKeyPriorities []APIKeyPriority `yaml:"api-key-priorities,omitempty" json:"api-key-priorities,omitempty"`
+
+	// APIKeyResidency pins client API keys to a data-residency region. Requests of a pinned key
+	// are only routed to credentials tagged with that region.
+	APIKeyResidency []APIKeyResidency `yaml:"api-key-residency,omitempty" json:"api-key-residency,omitempty"`
+
+	// APIKeyMetadata records the owner and expiry of client API keys. Expired keys are rejected.
+	APIKeyMetadata []APIKeyMetadata `yaml:"api-key-metadata,omitempty" json:"api-key-metadata,omitempty"`
+
+	// Admission bounds concurrent upstream requests and queues the rest by API key priority.
+	Admission AdmissionConfig `yaml:"admission,omitempty" json:"admission,omitempty"`
+
 	// EnableGeminiCLIEndpoint enables the localhost-only Gemini CLI compatibility endpoint.
 	EnableGeminiCLIEndpoint bool `yaml:"enable-gemini-cli-endpoint" json:"enable-gemini-cli-endpoint"`
 
@@ -63,6 +81,12 @@ type SDKConfig struct {
 	// Default is false (disabled).
 	PassthroughHeaders bool `yaml:"passthrough-headers" json:"passthrough-headers"`
 
+	// ResponseHeaders refines which upstream response headers reach downstream clients.
+	ResponseHeaders ResponseHeadersConfig `yaml:"response-headers,omitempty" json:"response-headers,omitempty"`
+
+	// ModelPricing lists per-model token prices used by the /v1/estimate preview endpoint.
+	ModelPricing []ModelPricing `yaml:"model-pricing,omitempty" json:"model-pricing,omitempty"`
+
 	// Streaming configures server-side streaming behavior (keep-alives and safe bootstrap retries).
 	Streaming StreamingConfig `yaml:"streaming" json:"streaming"`
 
@@ -72,6 +96,425 @@ type SDKConfig struct {
 	// NonStreamKeepAliveInterval controls how often blank lines are emitted for non-streaming responses.
 	// <= 0 disables keep-alives. Value is in seconds.
 	NonStreamKeepAliveInterval int `yaml:"nonstream-keepalive-interval,omitempty" json:"nonstream-keepalive-interval,omitempty"`
+
+	// RequestDedup collapses identical in-flight client requests onto a single upstream call.
+	RequestDedup RequestDedupConfig `yaml:"request-dedup,omitempty" json:"request-dedup,omitempty"`
+
+	// StreamReplay lets clients resume streams after a dropped connection.
+	StreamReplay StreamReplayConfig `yaml:"stream-replay,omitempty" json:"stream-replay,omitempty"`
+
+	// ActiveProfile names the profile applied to requests that do not select one with the
+	// X-CPA-Profile header. Empty means such requests are not restricted by any profile.
+	ActiveProfile string `yaml:"active-profile,omitempty" json:"active-profile,omitempty"`
+
+	// Profiles are named routing configurations (e.g. "work", "personal", "offline") that can be
+	// switched without running separate proxy instances.
+	Profiles []ProfileConfig `yaml:"profiles,omitempty" json:"profiles,omitempty"`
+
+	// VirtualModels are model names listed like any other model that expand, per request, to the
+	// first matching candidate of an ordered list.
+	VirtualModels []VirtualModel `yaml:"virtual-models,omitempty" json:"virtual-models,omitempty"`
+
+	// StrictParameters rejects chat completions carrying parameters the model does not list in its
+	// supported parameters with a 400, instead of silently dropping them during translation. It
+	// also rejects Responses requests declaring built-in tools a Claude or Gemini provider would drop.
+	StrictParameters bool `yaml:"strict-parameters,omitempty" json:"strict-parameters,omitempty"`
+
+	// JSONMode validates chat completions requested with response_format json_object.
+	JSONMode JSONModeConfig `yaml:"json-mode,omitempty" json:"json-mode,omitempty"`
+
+	// AutoContinue re-issues chat completions cut off with finish_reason "length".
+	AutoContinue AutoContinueConfig `yaml:"auto-continue,omitempty" json:"auto-continue,omitempty"`
+
+	// OutputProcessors post-process assistant text in chat completion responses. Processors of
+	// every matching rule are applied in rule order.
+	OutputProcessors []OutputProcessorRule `yaml:"output-processors,omitempty" json:"output-processors,omitempty"`
+
+	// Ensemble sets the defaults of /v1/ensemble/chat/completions.
+	Ensemble EnsembleConfig `yaml:"ensemble,omitempty" json:"ensemble,omitempty"`
+
+	// PromptTemplates are named prompts that chat completion requests render server-side with
+	// {"template": "<name>", "variables": {...}}.
+	PromptTemplates PromptTemplatesConfig `yaml:"prompt-templates,omitempty" json:"prompt-templates,omitempty"`
+
+	// LongContext enables the "-longctx" model suffix, which answers chat completions too long for
+	// the target model by summarizing chunks on a cheaper model first.
+	LongContext LongContextConfig `yaml:"long-context,omitempty" json:"long-context,omitempty"`
+
+	// ConversationCompression summarizes earlier turns of long conversations before translation.
+	ConversationCompression ConversationCompressionConfig `yaml:"conversation-compression,omitempty" json:"conversation-compression,omitempty"`
+
+	// MessageBatches serves the Anthropic Message Batches API under /v1/messages/batches.
+	MessageBatches MessageBatchesConfig `yaml:"message-batches,omitempty" json:"message-batches,omitempty"`
+
+	// Cache serves repeated non-streaming requests from a local response cache.
+	Cache ResponseCacheConfig `yaml:"cache,omitempty" json:"cache,omitempty"`
+}
+
+// ResponseCacheConfig configures the in-memory cache of non-streaming completions. Entries are
+// keyed on the client API key, endpoint, model and normalized payload, so only byte-for-byte
+// equivalent requests share a response.
+type ResponseCacheConfig struct {
+	// Enabled turns on the cache.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// TTL is how long a response is served from the cache, as a Go duration. Default is "1h".
+	TTL string `yaml:"ttl,omitempty" json:"ttl,omitempty"`
+
+	// MaxSizeMB bounds the cached response bodies; least recently used entries are evicted
+	// first. Default is 64.
+	MaxSizeMB int `yaml:"max-size-mb,omitempty" json:"max-size-mb,omitempty"`
+
+	// Models overrides TTL for matching models. The first matching entry wins.
+	Models []ResponseCacheModelTTL `yaml:"models,omitempty" json:"models,omitempty"`
+}
+
+// ResponseCacheModelTTL overrides the cache TTL for models matching a glob pattern.
+type ResponseCacheModelTTL struct {
+	// Model is a glob pattern such as "gpt-5*".
+	Model string `yaml:"model" json:"model"`
+
+	// TTL replaces the default TTL; "0" disables caching for the model.
+	TTL string `yaml:"ttl" json:"ttl"`
+}
+
+// MessageBatchesConfig configures the Anthropic Message Batches API. Batched requests run in the
+// background through the regular execution pipeline and their results are kept on disk.
+type MessageBatchesConfig struct {
+	// Enabled turns on /v1/messages/batches.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// Dir holds one directory per batch with its state and results. Default is "./batches".
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+
+	// Parallelism is the number of requests of one batch executed at once. Default is 4.
+	Parallelism int `yaml:"parallelism,omitempty" json:"parallelism,omitempty"`
+
+	// RetentionHours is how long ended batches and their results are kept. Default is 696
+	// (29 days, as upstream).
+	RetentionHours int `yaml:"retention-hours,omitempty" json:"retention-hours,omitempty"`
+}
+
+// ConversationCompressionConfig controls summarization of long chat histories. Once a request's
+// history exceeds ThresholdTokens, the turns before the last KeepRecent messages are replaced by a
+// summary written by SummaryModel. Summaries are reused and extended as the conversation grows.
+type ConversationCompressionConfig struct {
+	// Enabled turns on compression for /v1/chat/completions and /v1/messages.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// SummaryModel writes the summaries. Defaults to the requested model.
+	SummaryModel string `yaml:"summary-model,omitempty" json:"summary-model,omitempty"`
+
+	// ThresholdTokens is the estimated history size (four characters per token) above which
+	// earlier turns are compressed. Default is 64000.
+	ThresholdTokens int `yaml:"threshold-tokens,omitempty" json:"threshold-tokens,omitempty"`
+
+	// KeepRecent is the minimum number of trailing messages sent verbatim. Default is 8.
+	KeepRecent int `yaml:"keep-recent,omitempty" json:"keep-recent,omitempty"`
+}
+
+// LongContextConfig configures the map-reduce pipeline behind the "-longctx" model suffix.
+type LongContextConfig struct {
+	// Enabled turns on the "-longctx" suffix for /v1/chat/completions.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// SummaryModel condenses each chunk. Defaults to the target model.
+	SummaryModel string `yaml:"summary-model,omitempty" json:"summary-model,omitempty"`
+
+	// ChunkTokens is the approximate size of one chunk, estimated at four characters per token.
+	// Default is 8000.
+	ChunkTokens int `yaml:"chunk-tokens,omitempty" json:"chunk-tokens,omitempty"`
+
+	// MaxChunks rejects documents that would split into more chunks. Default is 64.
+	MaxChunks int `yaml:"max-chunks,omitempty" json:"max-chunks,omitempty"`
+
+	// Concurrency is the number of chunks summarized in parallel. Default is 4.
+	Concurrency int `yaml:"concurrency,omitempty" json:"concurrency,omitempty"`
+}
+
+// PromptTemplatesConfig holds the prompt template registry.
+type PromptTemplatesConfig struct {
+	// Dir holds one template per .yaml, .yml or .json file, named after the file unless the
+	// template sets a name. Changes are picked up without a restart.
+	Dir string `yaml:"dir,omitempty" json:"dir,omitempty"`
+
+	// Templates are defined inline; they take precedence over templates in Dir with the same name.
+	Templates []PromptTemplate `yaml:"templates,omitempty" json:"templates,omitempty"`
+}
+
+// PromptTemplate renders into the messages of a chat completion.
+type PromptTemplate struct {
+	// Name identifies the template in requests (case-insensitive).
+	Name string `yaml:"name" json:"name"`
+
+	// Description documents the template for the teams using it.
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+
+	// Model is used when the request does not name one.
+	Model string `yaml:"model,omitempty" json:"model,omitempty"`
+
+	// Defaults are variable values used when the request does not provide them.
+	Defaults map[string]string `yaml:"defaults,omitempty" json:"defaults,omitempty"`
+
+	// Messages are rendered in order as Go text/templates over the request variables, e.g.
+	// "Review this {{.language}} code:\n{{.code}}". Referencing a missing variable is an error.
+	Messages []PromptTemplateMessage `yaml:"messages" json:"messages"`
+}
+
+// PromptTemplateMessage is one templated chat message.
+type PromptTemplateMessage struct {
+	Role    string `yaml:"role" json:"role"`
+	Content string `yaml:"content" json:"content"`
+}
+
+// EnsembleConfig configures the multi-model ensemble endpoint. Requests may override every field
+// with their "ensemble" object.
+type EnsembleConfig struct {
+	// Models are queried in parallel with the same prompt.
+	Models []string `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// Mode selects the response: "all" (default) returns every completion, "fastest" the first
+	// successful one and "judge" the one picked by JudgeModel.
+	Mode string `yaml:"mode,omitempty" json:"mode,omitempty"`
+
+	// JudgeModel compares the candidate completions in "judge" mode.
+	JudgeModel string `yaml:"judge-model,omitempty" json:"judge-model,omitempty"`
+}
+
+// APIKeyModelScope limits client API keys to the models matching its patterns.
+type APIKeyModelScope struct {
+	// APIKeys lists the client API keys the scope applies to.
+	APIKeys []string `yaml:"api-keys" json:"api-keys"`
+
+	// Models lists the model names or "*" wildcard patterns the keys may use. A key named by
+	// several scopes may use the models of all of them.
+	Models []string `yaml:"models" json:"models"`
+}
+
+// APIKeyPriority assigns client API keys to an admission priority class.
+type APIKeyPriority struct {
+	// APIKeys lists the client API keys the priority applies to.
+	APIKeys []string `yaml:"api-keys" json:"api-keys"`
+
+	// Priority is "high", "normal" or "batch". Unknown values count as "normal".
+	Priority string `yaml:"priority" json:"priority"`
+}
+
+// APIKeyResidency requires the requests of client API keys to stay in a data-residency region.
+type APIKeyResidency struct {
+	// APIKeys lists the client API keys the requirement applies to.
+	APIKeys []string `yaml:"api-keys" json:"api-keys"`
+
+	// Region is the region credentials must be tagged with, such as "eu" or "us". A region also
+	// admits its sub-regions, so "eu" admits credentials tagged "eu-west".
+	Region string `yaml:"region" json:"region"`
+}
+
+// APIKeyMetadata describes one client API key.
+type APIKeyMetadata struct {
+	// APIKey is the client API key described.
+	APIKey string `yaml:"api-key" json:"api-key"`
+
+	// Owner names the person or team the key was issued to.
+	Owner string `yaml:"owner,omitempty" json:"owner,omitempty"`
+
+	// Description says what the key is used for.
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+
+	// CreatedAt is when the key was issued, in RFC 3339 format.
+	CreatedAt string `yaml:"created-at,omitempty" json:"created-at,omitempty"`
+
+	// ExpiresAt is when the key stops being accepted, in RFC 3339 format. Empty means never; a
+	// value that does not parse counts as already expired.
+	ExpiresAt string `yaml:"expires-at,omitempty" json:"expires-at,omitempty"`
+}
+
+// AdmissionConfig controls the admission queue in front of upstream execution. Requests are
+// admitted high priority first, and batch requests are held back while credentials are cooling
+// down after quota or rate-limit errors so they do not compete with interactive traffic.
+type AdmissionConfig struct {
+	// MaxConcurrent caps the requests executing upstream at once; the rest wait in the queue.
+	// <= 0 leaves concurrency unbounded, so only batch requests queue during cooldowns.
+	MaxConcurrent int `yaml:"max-concurrent,omitempty" json:"max-concurrent,omitempty"`
+
+	// MaxQueue bounds the waiting requests. When full, the newest lowest-priority waiter is shed
+	// to make room for a higher-priority request. Default is 256.
+	MaxQueue int `yaml:"max-queue,omitempty" json:"max-queue,omitempty"`
+
+	// QueueTimeoutSeconds is how long a request may wait before it is shed with a 429.
+	// Default is 30.
+	QueueTimeoutSeconds int `yaml:"queue-timeout-seconds,omitempty" json:"queue-timeout-seconds,omitempty"`
+}
+
+// OutputProcessorRule selects post-processors for requests by client API key and model.
+type OutputProcessorRule struct {
+	// APIKeys restricts the rule to these client API keys. Empty matches every key.
+	APIKeys []string `yaml:"api-keys,omitempty" json:"api-keys,omitempty"`
+
+	// Models restricts the rule to these requested model names or aliases; "*" wildcards are
+	// supported. Empty matches every model.
+	Models []string `yaml:"models,omitempty" json:"models,omitempty"`
+
+	// Processors lists the post-processors to apply: "strip-code-fences",
+	// "normalize-line-endings" and "trailing-newline".
+	Processors []string `yaml:"processors" json:"processors"`
+}
+
+// AutoContinueConfig controls automatic continuation of truncated chat completions.
+type AutoContinueConfig struct {
+	// MaxContinuations is the number of follow-up requests issued when a response stops with
+	// finish_reason "length"; the parts are stitched into one response or stream.
+	// <= 0 disables auto-continue. Default is 0.
+	MaxContinuations int `yaml:"max-continuations,omitempty" json:"max-continuations,omitempty"`
+}
+
+// JSONModeConfig controls output validation for OpenAI JSON mode requests.
+type JSONModeConfig struct {
+	// Validate checks that the assembled assistant message is valid JSON and reports the
+	// outcome in the X-CPA-JSON-Mode response header (a trailer for streaming responses).
+	Validate bool `yaml:"validate" json:"validate"`
+
+	// Repair re-asks the model once with the parse error when a non-streaming response is not
+	// valid JSON. Implies Validate.
+	Repair bool `yaml:"repair" json:"repair"`
+}
+
+// ProfileConfig is a named routing configuration.
+type ProfileConfig struct {
+	// Name identifies the profile in active-profile and the X-CPA-Profile header (case-insensitive).
+	Name string `yaml:"name" json:"name"`
+
+	// Providers lists the providers requests may be routed to (e.g. "claude", "codex", "gemini").
+	// Empty allows every provider.
+	Providers []string `yaml:"providers,omitempty" json:"providers,omitempty"`
+
+	// ModelMappings rewrite requested model names before provider resolution.
+	ModelMappings []ProfileModelMapping `yaml:"model-mappings,omitempty" json:"model-mappings,omitempty"`
+}
+
+// ProfileModelMapping routes a requested model to another model within a profile.
+type ProfileModelMapping struct {
+	// From is the model name requested by the client (case-insensitive exact match).
+	From string `yaml:"from" json:"from"`
+
+	// To is the model name used instead.
+	To string `yaml:"to" json:"to"`
+}
+
+// FindProfile returns the profile with the given name (case-insensitive).
+func (c *SDKConfig) FindProfile(name string) (*ProfileConfig, bool) {
+	if c == nil {
+		return nil, false
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, false
+	}
+	for i := range c.Profiles {
+		if strings.EqualFold(strings.TrimSpace(c.Profiles[i].Name), name) {
+			return &c.Profiles[i], true
+		}
+	}
+	return nil, false
+}
+
+// AllowsProvider reports whether requests under this profile may use provider.
+func (p *ProfileConfig) AllowsProvider(provider string) bool {
+	if p == nil || len(p.Providers) == 0 {
+		return true
+	}
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	for _, allowed := range p.Providers {
+		if strings.ToLower(strings.TrimSpace(allowed)) == provider {
+			return true
+		}
+	}
+	return false
+}
+
+// MapModel applies the profile's model mappings to modelName.
+func (p *ProfileConfig) MapModel(modelName string) string {
+	if p == nil {
+		return modelName
+	}
+	trimmed := strings.TrimSpace(modelName)
+	for _, mapping := range p.ModelMappings {
+		to := strings.TrimSpace(mapping.To)
+		if to != "" && strings.EqualFold(strings.TrimSpace(mapping.From), trimmed) {
+			return to
+		}
+	}
+	return modelName
+}
+
+// VirtualModel routes requests for its name to the first candidate whose condition the request
+// meets and whose model is currently served by some provider.
+type VirtualModel struct {
+	// Name is the model name clients request (case-insensitive).
+	Name string `yaml:"name" json:"name"`
+
+	// Description is shown in model listings.
+	Description string `yaml:"description,omitempty" json:"description,omitempty"`
+
+	// Candidates are tried in order.
+	Candidates []VirtualModelCandidate `yaml:"candidates" json:"candidates"`
+}
+
+// VirtualModelCandidate is one model a virtual model may resolve to.
+type VirtualModelCandidate struct {
+	// Model is the model requests are routed to, e.g. "claude-sonnet-4" or "copilot-gpt-5.1".
+	Model string `yaml:"model" json:"model"`
+
+	// When is the condition under which the candidate is used: "tools" when the request declares
+	// tools, "vision" when it carries images, and empty or "else" for any request.
+	When string `yaml:"when,omitempty" json:"when,omitempty"`
+}
+
+// FindVirtualModel returns the virtual model with the given name (case-insensitive).
+func (c *SDKConfig) FindVirtualModel(name string) (*VirtualModel, bool) {
+	if c == nil {
+		return nil, false
+	}
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, false
+	}
+	for i := range c.VirtualModels {
+		if strings.EqualFold(strings.TrimSpace(c.VirtualModels[i].Name), name) {
+			return &c.VirtualModels[i], true
+		}
+	}
+	return nil, false
+}
+
+// RequestDedupConfig controls deduplication of identical client requests.
+// Two requests are identical when they share the inbound API key, endpoint, and raw payload bytes.
+type RequestDedupConfig struct {
+	// Enabled attaches duplicate requests to the response of the first in-flight request
+	// instead of issuing a second upstream call. Default is false.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// WindowSeconds keeps a completed response joinable for this many seconds so that a
+	// retry arriving shortly after the original finished is still served from it.
+	// <= 0 only deduplicates requests that are still in flight.
+	WindowSeconds int `yaml:"window-seconds,omitempty" json:"window-seconds,omitempty"`
+}
+
+// StreamReplayConfig controls resumable streams. A streaming request carrying an Idempotency-Key
+// keeps consuming its upstream stream after the client disconnects, and the client can reconnect
+// with the same key and a Last-Event-ID header to continue after the last event it received.
+type StreamReplayConfig struct {
+	// Enabled turns on resumable streams. Default is false.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// MaxBufferMB bounds the buffered events of one stream; older events are dropped and can no
+	// longer be resumed from. Default is 4.
+	MaxBufferMB int `yaml:"max-buffer-mb,omitempty" json:"max-buffer-mb,omitempty"`
+
+	// WindowSeconds is how long a stream stays resumable without a connected client, both while
+	// it is running and after it finished. A running stream nobody resumes within the window is
+	// canceled upstream. Default is 300.
+	WindowSeconds int `yaml:"window-seconds,omitempty" json:"window-seconds,omitempty"`
 }
 
 // ProxyEnabledFor reports whether the global ProxyURL should be applied for the given service name.
@@ -99,6 +542,62 @@ func (c *SDKConfig) ProxyEnabledFor(service string) bool {
 	return false
 }
 
+// ResponseHeaderRules lists upstream response header patterns to allow or block.
+// Patterns are case-insensitive; a trailing "*" matches any suffix (e.g. "anthropic-ratelimit-*").
+type ResponseHeaderRules struct {
+	// Allow lists headers forwarded even when passthrough-headers is disabled. Allowed headers
+	// also bypass the built-in AI gateway header stripping, but never the hop-by-hop filter.
+	Allow []string `yaml:"allow,omitempty" json:"allow,omitempty"`
+
+	// Block lists headers that are never forwarded. Block wins over Allow.
+	Block []string `yaml:"block,omitempty" json:"block,omitempty"`
+}
+
+// ResponseHeadersConfig configures the upstream response header passthrough policy.
+type ResponseHeadersConfig struct {
+	ResponseHeaderRules `yaml:",inline"`
+
+	// Providers adds rules for responses served by a specific provider (e.g. "claude", "codex").
+	// They are combined with the global rules.
+	Providers map[string]ResponseHeaderRules `yaml:"providers,omitempty" json:"providers,omitempty"`
+}
+
+// ModelPricing is the token price of the models matching Model.
+type ModelPricing struct {
+	// Model is a case-insensitive model name; a trailing "*" matches any suffix (e.g. "claude-opus-*").
+	Model string `yaml:"model" json:"model"`
+
+	// InputPerMillion is the price of one million prompt tokens.
+	InputPerMillion float64 `yaml:"input-per-million" json:"input-per-million"`
+
+	// OutputPerMillion is the price of one million completion tokens.
+	OutputPerMillion float64 `yaml:"output-per-million" json:"output-per-million"`
+}
+
+// PricingForModel returns the first pricing entry matching model.
+func (c *SDKConfig) PricingForModel(model string) (ModelPricing, bool) {
+	model = strings.ToLower(strings.TrimSpace(model))
+	if c == nil || model == "" {
+		return ModelPricing{}, false
+	}
+	for _, entry := range c.ModelPricing {
+		pattern := strings.ToLower(strings.TrimSpace(entry.Model))
+		if pattern == "" {
+			continue
+		}
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(model, prefix) {
+				return entry, true
+			}
+			continue
+		}
+		if pattern == model {
+			return entry, true
+		}
+	}
+	return ModelPricing{}, false
+}
+
 // StreamingConfig holds server streaming behavior configuration.
 type StreamingConfig struct {
 	// KeepAliveSeconds controls how often the server emits SSE heartbeats (": keep-alive\n\n").
@@ -0,0 +1,78 @@
+package config
+
+import "strings"
+
+// Token store backends accepted by TokenStoreConfig.Type.
+const (
+	TokenStoreFile              = "file"
+	TokenStoreVault             = "vault"
+	TokenStoreAWSSecretsManager = "aws-secrets-manager"
+)
+
+// TokenStoreConfig selects where credential token files are persisted. The default keeps them
+// as JSON files under auth-dir; the secret backends keep the authoritative copy remotely and
+// mirror it into a private spool directory so the file watcher keeps working.
+type TokenStoreConfig struct {
+	// Type is "file" (default), "vault" or "aws-secrets-manager".
+	Type string `yaml:"type,omitempty" json:"type,omitempty"`
+	// LocalRoot is the spool directory used by secret backends. Defaults to a directory under
+	// the system temp dir, so nothing has to be mounted persistently.
+	LocalRoot string `yaml:"local-root,omitempty" json:"local-root,omitempty"`
+	// Vault configures the HashiCorp Vault KV v2 backend.
+	Vault VaultTokenStoreConfig `yaml:"vault,omitempty" json:"vault,omitempty"`
+	// AWS configures the AWS Secrets Manager backend.
+	AWS AWSSecretsManagerConfig `yaml:"aws,omitempty" json:"aws,omitempty"`
+}
+
+// VaultTokenStoreConfig locates the Vault KV v2 engine holding the tokens.
+type VaultTokenStoreConfig struct {
+	// Address is the Vault server URL. Falls back to VAULT_ADDR.
+	Address string `yaml:"address,omitempty" json:"address,omitempty"`
+	// Token authenticates requests. Falls back to VAULT_TOKEN.
+	Token string `yaml:"token,omitempty" json:"-"`
+	// Namespace is sent as X-Vault-Namespace (Vault Enterprise). Falls back to VAULT_NAMESPACE.
+	Namespace string `yaml:"namespace,omitempty" json:"namespace,omitempty"`
+	// Mount is the KV v2 mount path. Default: "secret".
+	Mount string `yaml:"mount,omitempty" json:"mount,omitempty"`
+	// Prefix is the path under the mount where tokens are written. Default: "cliproxy/auths".
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+}
+
+// AWSSecretsManagerConfig locates the AWS Secrets Manager secrets holding the tokens.
+// Without static credentials the standard AWS chain is used: AWS_* environment variables, web
+// identity (EKS IRSA), the container endpoint (ECS, EKS Pod Identity) and the EC2 instance role.
+type AWSSecretsManagerConfig struct {
+	Region          string `yaml:"region,omitempty" json:"region,omitempty"`
+	AccessKeyID     string `yaml:"access-key-id,omitempty" json:"-"`
+	SecretAccessKey string `yaml:"secret-access-key,omitempty" json:"-"`
+	SessionToken    string `yaml:"session-token,omitempty" json:"-"`
+	// Endpoint overrides the regional endpoint, e.g. for VPC endpoints or LocalStack.
+	Endpoint string `yaml:"endpoint,omitempty" json:"endpoint,omitempty"`
+	// Prefix is prepended to every secret name. Default: "cliproxy/auths".
+	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
+	// KMSKeyID encrypts newly created secrets with a customer managed key.
+	KMSKeyID string `yaml:"kms-key-id,omitempty" json:"kms-key-id,omitempty"`
+}
+
+// SanitizeTokenStore normalizes the token store backend name, falling back to the file store
+// for unknown values.
+func (cfg *Config) SanitizeTokenStore() {
+	if cfg == nil {
+		return
+	}
+	switch strings.ToLower(strings.TrimSpace(cfg.TokenStore.Type)) {
+	case TokenStoreVault:
+		cfg.TokenStore.Type = TokenStoreVault
+	case TokenStoreAWSSecretsManager, "aws", "secretsmanager", "aws-secretsmanager":
+		cfg.TokenStore.Type = TokenStoreAWSSecretsManager
+	default:
+		cfg.TokenStore.Type = TokenStoreFile
+	}
+	cfg.TokenStore.LocalRoot = strings.TrimSpace(cfg.TokenStore.LocalRoot)
+	cfg.TokenStore.Vault.Address = strings.TrimRight(strings.TrimSpace(cfg.TokenStore.Vault.Address), "/")
+	cfg.TokenStore.Vault.Mount = strings.Trim(strings.TrimSpace(cfg.TokenStore.Vault.Mount), "/")
+	cfg.TokenStore.Vault.Prefix = strings.Trim(strings.TrimSpace(cfg.TokenStore.Vault.Prefix), "/")
+	cfg.TokenStore.AWS.Region = strings.TrimSpace(cfg.TokenStore.AWS.Region)
+	cfg.TokenStore.AWS.Endpoint = strings.TrimRight(strings.TrimSpace(cfg.TokenStore.AWS.Endpoint), "/")
+	cfg.TokenStore.AWS.Prefix = strings.Trim(strings.TrimSpace(cfg.TokenStore.AWS.Prefix), "/")
+}
@@ -17,6 +17,14 @@ type VertexCompatKey struct {
 	// Higher values are preferred; defaults to 0.
 	Priority int `yaml:"priority,omitempty" json:"priority,omitempty"`
 
+	// Residency is the data-residency region of this credential, matched against
+	// api-key-residency.
+	Residency string `yaml:"residency,omitempty" json:"residency,omitempty"`
+
+	// Region is the region this credential is served from, matched against
+	// routing.region-failover.
+	Region string `yaml:"region,omitempty" json:"region,omitempty"`
+
 	// Prefix optionally namespaces model aliases for this credential (e.g., "teamA/vertex-pro").
 	Prefix string `yaml:"prefix,omitempty" json:"prefix,omitempty"`
 
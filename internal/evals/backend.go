@@ -0,0 +1,44 @@
+package evals
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+)
+
+// ManagerBackend routes evaluation requests through the core auth manager.
+type ManagerBackend struct {
+	Manager *coreauth.Manager
+}
+
+// Execute sends an OpenAI chat completions payload for model and returns the response body.
+func (b ManagerBackend) Execute(ctx context.Context, model string, payload []byte, headers http.Header) ([]byte, error) {
+	if b.Manager == nil {
+		return nil, errors.New("auth manager unavailable")
+	}
+	providers := util.GetProviderName(model)
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("model %s has no registered provider", model)
+	}
+	source := sdktranslator.FromString("openai")
+	resp, errExec := b.Manager.Execute(ctx, providers, cliproxyexecutor.Request{
+		Model:   model,
+		Payload: payload,
+	}, cliproxyexecutor.Options{
+		Headers:         headers.Clone(),
+		OriginalRequest: payload,
+		SourceFormat:    source,
+		ResponseFormat:  source,
+		Metadata:        map[string]any{cliproxyexecutor.RequestedModelMetadataKey: model},
+	})
+	if errExec != nil {
+		return nil, errExec
+	}
+	return resp.Payload, nil
+}
@@ -0,0 +1,470 @@
+// Package evals runs management-triggered evaluations: every prompt of a dataset is sent to two
+// routing targets, a judge model decides which output is better, and the run is summarised in a
+// report with win rates, latency and cost.
+package evals
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Run statuses.
+const (
+	StatusRunning   = "running"
+	StatusCompleted = "completed"
+	StatusCancelled = "cancelled"
+)
+
+// Verdicts of one case.
+const (
+	WinnerA   = "a"
+	WinnerB   = "b"
+	WinnerTie = "tie"
+)
+
+const (
+	defaultConcurrency = 4
+	maxConcurrency     = 16
+	maxDatasetSize     = 1000
+	// maxRetainedRuns bounds how many finished runs are kept for reporting.
+	maxRetainedRuns = 20
+	runTimeout      = 2 * time.Hour
+)
+
+// Backend executes OpenAI chat completion payloads through the proxy's routing.
+type Backend interface {
+	Execute(ctx context.Context, model string, payload []byte, headers http.Header) ([]byte, error)
+}
+
+// Target is one side of the comparison.
+type Target struct {
+	// Name labels the target in the report; it defaults to the model.
+	Name  string `json:"name,omitempty"`
+	Model string `json:"model"`
+	// Headers are passed to the executor with every request of the target.
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// Case is one dataset entry. Either Prompt or Messages must be set.
+type Case struct {
+	ID       string          `json:"id,omitempty"`
+	Prompt   string          `json:"prompt,omitempty"`
+	Messages json.RawMessage `json:"messages,omitempty"`
+	// Reference is an optional expected answer shown to the judge.
+	Reference string `json:"reference,omitempty"`
+}
+
+// Spec describes an evaluation run.
+type Spec struct {
+	Name        string `json:"name,omitempty"`
+	Dataset     []Case `json:"dataset"`
+	TargetA     Target `json:"target_a"`
+	TargetB     Target `json:"target_b"`
+	JudgeModel  string `json:"judge_model"`
+	Concurrency int    `json:"concurrency,omitempty"`
+	MaxTokens   int    `json:"max_tokens,omitempty"`
+}
+
+// Output is what one target produced for a case.
+type Output struct {
+	Content      string `json:"content,omitempty"`
+	LatencyMs    int64  `json:"latency_ms"`
+	InputTokens  int64  `json:"input_tokens,omitempty"`
+	OutputTokens int64  `json:"output_tokens,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// CaseResult is the outcome of one case.
+type CaseResult struct {
+	ID         string `json:"id"`
+	A          Output `json:"a"`
+	B          Output `json:"b"`
+	Winner     string `json:"winner,omitempty"`
+	Reason     string `json:"reason,omitempty"`
+	JudgeError string `json:"judge_error,omitempty"`
+}
+
+// TargetReport aggregates the results of one target.
+type TargetReport struct {
+	Name         string  `json:"name"`
+	Model        string  `json:"model"`
+	Wins         int     `json:"wins"`
+	Losses       int     `json:"losses"`
+	Ties         int     `json:"ties"`
+	Errors       int     `json:"errors"`
+	WinRate      float64 `json:"win_rate"`
+	AvgLatencyMs int64   `json:"avg_latency_ms"`
+	P50LatencyMs int64   `json:"p50_latency_ms"`
+	P95LatencyMs int64   `json:"p95_latency_ms"`
+	InputTokens  int64   `json:"input_tokens"`
+	OutputTokens int64   `json:"output_tokens"`
+	// Cost is computed from model-pricing; it is omitted when the model has no pricing entry.
+	Cost *float64 `json:"cost,omitempty"`
+}
+
+// Report is the state of a run. Cases are only included when requested.
+type Report struct {
+	ID         string       `json:"id"`
+	Name       string       `json:"name,omitempty"`
+	Status     string       `json:"status"`
+	JudgeModel string       `json:"judge_model"`
+	CreatedAt  time.Time    `json:"created_at"`
+	FinishedAt time.Time    `json:"finished_at,omitzero"`
+	Total      int          `json:"total"`
+	Completed  int          `json:"completed"`
+	Judged     int          `json:"judged"`
+	TargetA    TargetReport `json:"target_a"`
+	TargetB    TargetReport `json:"target_b"`
+	Cases      []CaseResult `json:"cases,omitempty"`
+}
+
+type run struct {
+	mu         sync.Mutex
+	id         string
+	spec       Spec
+	pricing    *config.SDKConfig
+	status     string
+	createdAt  time.Time
+	finishedAt time.Time
+	results    []CaseResult
+	done       []bool
+	cancel     context.CancelFunc
+}
+
+// Runner starts evaluation runs and keeps their reports in memory.
+type Runner struct {
+	backend Backend
+
+	mu    sync.Mutex
+	runs  map[string]*run
+	order []string
+}
+
+// NewRunner returns a runner executing requests through backend.
+func NewRunner(backend Backend) *Runner {
+	return &Runner{backend: backend, runs: make(map[string]*run)}
+}
+
+// Validate normalises spec and reports the first problem found.
+func (s *Spec) Validate() error {
+	s.TargetA.Model = strings.TrimSpace(s.TargetA.Model)
+	s.TargetB.Model = strings.TrimSpace(s.TargetB.Model)
+	s.JudgeModel = strings.TrimSpace(s.JudgeModel)
+	switch {
+	case len(s.Dataset) == 0:
+		return errors.New("dataset is empty")
+	case len(s.Dataset) > maxDatasetSize:
+		return fmt.Errorf("dataset has %d cases, the maximum is %d", len(s.Dataset), maxDatasetSize)
+	case s.TargetA.Model == "" || s.TargetB.Model == "":
+		return errors.New("target_a.model and target_b.model are required")
+	case s.JudgeModel == "":
+		return errors.New("judge_model is required")
+	}
+	if s.TargetA.Name = strings.TrimSpace(s.TargetA.Name); s.TargetA.Name == "" {
+		s.TargetA.Name = s.TargetA.Model
+	}
+	if s.TargetB.Name = strings.TrimSpace(s.TargetB.Name); s.TargetB.Name == "" {
+		s.TargetB.Name = s.TargetB.Model
+	}
+	if s.Concurrency <= 0 {
+		s.Concurrency = defaultConcurrency
+	}
+	s.Concurrency = min(s.Concurrency, maxConcurrency)
+	for i := range s.Dataset {
+		entry := &s.Dataset[i]
+		if entry.ID = strings.TrimSpace(entry.ID); entry.ID == "" {
+			entry.ID = fmt.Sprintf("case-%d", i+1)
+		}
+		if strings.TrimSpace(entry.Prompt) == "" && !gjson.ParseBytes(entry.Messages).IsArray() {
+			return fmt.Errorf("dataset case %s needs a prompt or a messages array", entry.ID)
+		}
+	}
+	return nil
+}
+
+// Start validates spec and runs it in the background. cfg supplies model-pricing for costs.
+func (r *Runner) Start(spec Spec, cfg *config.SDKConfig) (string, error) {
+	if r == nil || r.backend == nil {
+		return "", errors.New("evaluation backend unavailable")
+	}
+	if errValidate := spec.Validate(); errValidate != nil {
+		return "", errValidate
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), runTimeout)
+	current := &run{
+		id:        uuid.NewString(),
+		spec:      spec,
+		pricing:   cfg,
+		status:    StatusRunning,
+		createdAt: time.Now(),
+		results:   make([]CaseResult, len(spec.Dataset)),
+		done:      make([]bool, len(spec.Dataset)),
+		cancel:    cancel,
+	}
+	r.mu.Lock()
+	r.runs[current.id] = current
+	r.order = append(r.order, current.id)
+	r.pruneLocked()
+	r.mu.Unlock()
+
+	go r.execute(ctx, current)
+	return current.id, nil
+}
+
+// Get returns the report of run id.
+func (r *Runner) Get(id string, withCases bool) (Report, bool) {
+	r.mu.Lock()
+	current, ok := r.runs[id]
+	r.mu.Unlock()
+	if !ok {
+		return Report{}, false
+	}
+	return current.report(withCases), true
+}
+
+// List returns the reports of every retained run, newest first, without cases.
+func (r *Runner) List() []Report {
+	r.mu.Lock()
+	runs := make([]*run, 0, len(r.order))
+	for i := len(r.order) - 1; i >= 0; i-- {
+		runs = append(runs, r.runs[r.order[i]])
+	}
+	r.mu.Unlock()
+	reports := make([]Report, 0, len(runs))
+	for _, current := range runs {
+		reports = append(reports, current.report(false))
+	}
+	return reports
+}
+
+// Cancel stops run id. It reports whether the run was found.
+func (r *Runner) Cancel(id string) bool {
+	r.mu.Lock()
+	current, ok := r.runs[id]
+	r.mu.Unlock()
+	if ok {
+		current.cancel()
+	}
+	return ok
+}
+
+// pruneLocked drops the oldest finished runs beyond maxRetainedRuns.
+func (r *Runner) pruneLocked() {
+	for i := 0; len(r.order) > maxRetainedRuns && i < len(r.order); {
+		current := r.runs[r.order[i]]
+		current.mu.Lock()
+		running := current.status == StatusRunning
+		current.mu.Unlock()
+		if running {
+			i++
+			continue
+		}
+		delete(r.runs, current.id)
+		r.order = append(r.order[:i], r.order[i+1:]...)
+	}
+}
+
+func (r *Runner) execute(ctx context.Context, current *run) {
+	defer current.cancel()
+	spec := current.spec
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range spec.Concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				result := r.evaluate(ctx, spec, i)
+				current.mu.Lock()
+				current.results[i] = result
+				current.done[i] = true
+				current.mu.Unlock()
+			}
+		}()
+	}
+feed:
+	for i := range spec.Dataset {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	current.mu.Lock()
+	current.status = StatusCompleted
+	if ctx.Err() != nil && !current.allDoneLocked() {
+		current.status = StatusCancelled
+	}
+	current.finishedAt = time.Now()
+	current.mu.Unlock()
+	log.Infof("evals: run %s (%s) %s", current.id, spec.Name, current.status)
+}
+
+// evaluate runs case i against both targets in parallel and asks the judge for a verdict.
+func (r *Runner) evaluate(ctx context.Context, spec Spec, i int) CaseResult {
+	entry := spec.Dataset[i]
+	result := CaseResult{ID: entry.ID}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() { defer wg.Done(); result.A = r.generate(ctx, spec, spec.TargetA, entry) }()
+	go func() { defer wg.Done(); result.B = r.generate(ctx, spec, spec.TargetB, entry) }()
+	wg.Wait()
+
+	switch {
+	case result.A.Error != "" && result.B.Error != "":
+		result.JudgeError = "both targets failed"
+	case result.A.Error != "":
+		result.Winner, result.Reason = WinnerB, "target a failed"
+	case result.B.Error != "":
+		result.Winner, result.Reason = WinnerA, "target b failed"
+	default:
+		winner, reason, errJudge := r.judge(ctx, spec.JudgeModel, entry, result.A.Content, result.B.Content, i%2 == 1)
+		if errJudge != nil {
+			result.JudgeError = errJudge.Error()
+		} else {
+			result.Winner, result.Reason = winner, reason
+		}
+	}
+	return result
+}
+
+// generate sends entry to target and measures the response.
+func (r *Runner) generate(ctx context.Context, spec Spec, target Target, entry Case) Output {
+	payload := []byte(`{}`)
+	payload, _ = sjson.SetBytes(payload, "model", target.Model)
+	if gjson.ParseBytes(entry.Messages).IsArray() {
+		payload, _ = sjson.SetRawBytes(payload, "messages", entry.Messages)
+	} else {
+		payload, _ = sjson.SetBytes(payload, "messages", []map[string]string{{"role": "user", "content": entry.Prompt}})
+	}
+	if spec.MaxTokens > 0 {
+		payload, _ = sjson.SetBytes(payload, "max_tokens", spec.MaxTokens)
+	}
+	var headers http.Header
+	if len(target.Headers) > 0 {
+		headers = make(http.Header, len(target.Headers))
+		for key, value := range target.Headers {
+			headers.Set(key, value)
+		}
+	}
+
+	started := time.Now()
+	resp, errExec := r.backend.Execute(ctx, target.Model, payload, headers)
+	output := Output{LatencyMs: time.Since(started).Milliseconds()}
+	if errExec != nil {
+		output.Error = errExec.Error()
+		return output
+	}
+	output.Content = gjson.GetBytes(resp, "choices.0.message.content").String()
+	output.InputTokens = gjson.GetBytes(resp, "usage.prompt_tokens").Int()
+	output.OutputTokens = gjson.GetBytes(resp, "usage.completion_tokens").Int()
+	return output
+}
+
+func (current *run) allDoneLocked() bool {
+	for _, done := range current.done {
+		if !done {
+			return false
+		}
+	}
+	return true
+}
+
+func (current *run) report(withCases bool) Report {
+	current.mu.Lock()
+	defer current.mu.Unlock()
+	spec := current.spec
+	report := Report{
+		ID:         current.id,
+		Name:       spec.Name,
+		Status:     current.status,
+		JudgeModel: spec.JudgeModel,
+		CreatedAt:  current.createdAt,
+		FinishedAt: current.finishedAt,
+		Total:      len(spec.Dataset),
+		TargetA:    TargetReport{Name: spec.TargetA.Name, Model: spec.TargetA.Model},
+		TargetB:    TargetReport{Name: spec.TargetB.Name, Model: spec.TargetB.Model},
+	}
+	var latenciesA, latenciesB []int64
+	for i, result := range current.results {
+		if !current.done[i] {
+			continue
+		}
+		report.Completed++
+		if withCases {
+			report.Cases = append(report.Cases, result)
+		}
+		latenciesA = accumulate(&report.TargetA, result.A, latenciesA)
+		latenciesB = accumulate(&report.TargetB, result.B, latenciesB)
+		switch result.Winner {
+		case WinnerA:
+			report.TargetA.Wins++
+			report.TargetB.Losses++
+		case WinnerB:
+			report.TargetB.Wins++
+			report.TargetA.Losses++
+		case WinnerTie:
+			report.TargetA.Ties++
+			report.TargetB.Ties++
+		default:
+			continue
+		}
+		report.Judged++
+	}
+	finishTarget(&report.TargetA, latenciesA, report.Judged, current.pricing)
+	finishTarget(&report.TargetB, latenciesB, report.Judged, current.pricing)
+	return report
+}
+
+func accumulate(target *TargetReport, output Output, latencies []int64) []int64 {
+	if output.Error != "" {
+		target.Errors++
+		return latencies
+	}
+	target.InputTokens += output.InputTokens
+	target.OutputTokens += output.OutputTokens
+	return append(latencies, output.LatencyMs)
+}
+
+// finishTarget derives win rate, latency percentiles and cost. Ties count as half a win.
+func finishTarget(target *TargetReport, latencies []int64, judged int, pricing *config.SDKConfig) {
+	if judged > 0 {
+		target.WinRate = (float64(target.Wins) + float64(target.Ties)/2) / float64(judged)
+	}
+	if len(latencies) > 0 {
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		var sum int64
+		for _, latency := range latencies {
+			sum += latency
+		}
+		target.AvgLatencyMs = sum / int64(len(latencies))
+		target.P50LatencyMs = percentile(latencies, 50)
+		target.P95LatencyMs = percentile(latencies, 95)
+	}
+	if price, ok := pricing.PricingForModel(target.Model); ok {
+		cost := (float64(target.InputTokens)*price.InputPerMillion + float64(target.OutputTokens)*price.OutputPerMillion) / 1e6
+		target.Cost = &cost
+	}
+}
+
+// percentile returns the nearest-rank percentile of sorted values.
+func percentile(sorted []int64, p int) int64 {
+	rank := (p*len(sorted) + 99) / 100
+	return sorted[max(rank, 1)-1]
+}
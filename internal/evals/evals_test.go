@@ -0,0 +1,159 @@
+package evals
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+// fakeBackend answers targets with their model name and has the judge prefer the answer from
+// "model-a" unless the prompt asks for a tie.
+type fakeBackend struct {
+	mu     sync.Mutex
+	judged int
+}
+
+func (b *fakeBackend) Execute(_ context.Context, model string, payload []byte, _ http.Header) ([]byte, error) {
+	switch model {
+	case "judge":
+		b.mu.Lock()
+		b.judged++
+		b.mu.Unlock()
+		prompt := gjson.GetBytes(payload, "messages.1.content").String()
+		if strings.Contains(prompt, "tie please") {
+			return []byte(`{"choices":[{"message":{"content":"{\"winner\":\"tie\",\"reason\":\"same\"}"}}]}`), nil
+		}
+		position := "1"
+		if strings.Index(prompt, "from model-a") > strings.Index(prompt, "from model-b") {
+			position = "2"
+		}
+		return []byte(`{"choices":[{"message":{"content":"{\"winner\":\"` + position + `\",\"reason\":\"a is better\"}"}}]}`), nil
+	case "broken":
+		return nil, errors.New("upstream unavailable")
+	}
+	return []byte(`{"choices":[{"message":{"content":"from ` + model + `"}}],"usage":{"prompt_tokens":1000,"completion_tokens":500}}`), nil
+}
+
+func waitForRun(t *testing.T, runner *Runner, id string) Report {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if report, ok := runner.Get(id, true); ok && report.Status != StatusRunning {
+			return report
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("run did not finish")
+	return Report{}
+}
+
+func TestRunnerProducesReport(t *testing.T) {
+	backend := &fakeBackend{}
+	runner := NewRunner(backend)
+	pricing := &config.SDKConfig{ModelPricing: []config.ModelPricing{{Model: "model-a", InputPerMillion: 2, OutputPerMillion: 10}}}
+	id, err := runner.Start(Spec{
+		Name: "smoke",
+		Dataset: []Case{
+			{Prompt: "one"},
+			{Prompt: "two"},
+			{Messages: []byte(`[{"role":"user","content":"tie please"}]`)},
+			{Prompt: "four"},
+		},
+		TargetA:    Target{Model: "model-a"},
+		TargetB:    Target{Name: "candidate", Model: "model-b"},
+		JudgeModel: "judge",
+	}, pricing)
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	report := waitForRun(t, runner, id)
+	if report.Status != StatusCompleted || report.Completed != 4 || report.Judged != 4 || len(report.Cases) != 4 {
+		t.Fatalf("report = %+v, want 4 completed and judged cases", report)
+	}
+	// Swapped presentation order must not change the verdict.
+	if report.TargetA.Wins != 3 || report.TargetA.Ties != 1 || report.TargetB.Losses != 3 {
+		t.Fatalf("target a = %+v, target b = %+v", report.TargetA, report.TargetB)
+	}
+	if report.TargetA.WinRate != 0.875 || report.TargetB.WinRate != 0.125 {
+		t.Fatalf("win rates = %v, %v", report.TargetA.WinRate, report.TargetB.WinRate)
+	}
+	if report.TargetA.Cost == nil || *report.TargetA.Cost != 0.028 || report.TargetB.Cost != nil {
+		t.Fatalf("costs = %v, %v; want 0.028 for priced model a only", report.TargetA.Cost, report.TargetB.Cost)
+	}
+	if report.TargetB.Name != "candidate" || report.Cases[0].ID != "case-1" {
+		t.Fatalf("names not applied: %+v", report)
+	}
+	if summaries := runner.List(); len(summaries) != 1 || summaries[0].Cases != nil {
+		t.Fatalf("List() = %+v, want one summary without cases", summaries)
+	}
+}
+
+func TestRunnerSkipsJudgeWhenTargetFails(t *testing.T) {
+	backend := &fakeBackend{}
+	runner := NewRunner(backend)
+	id, err := runner.Start(Spec{
+		Dataset:    []Case{{Prompt: "one"}},
+		TargetA:    Target{Model: "model-a"},
+		TargetB:    Target{Model: "broken"},
+		JudgeModel: "judge",
+	}, nil)
+	if err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	report := waitForRun(t, runner, id)
+	if report.TargetA.Wins != 1 || report.TargetB.Errors != 1 || backend.judged != 0 {
+		t.Fatalf("report = %+v, judged = %d; want a default win without judging", report, backend.judged)
+	}
+}
+
+func TestSpecValidate(t *testing.T) {
+	valid := func() Spec {
+		return Spec{Dataset: []Case{{Prompt: "x"}}, TargetA: Target{Model: "a"}, TargetB: Target{Model: "b"}, JudgeModel: "j"}
+	}
+	for name, mutate := range map[string]func(*Spec){
+		"empty dataset": func(s *Spec) { s.Dataset = nil },
+		"missing model": func(s *Spec) { s.TargetB.Model = " " },
+		"missing judge": func(s *Spec) { s.JudgeModel = "" },
+		"empty case":    func(s *Spec) { s.Dataset = []Case{{ID: "blank"}} },
+	} {
+		spec := valid()
+		mutate(&spec)
+		if errValidate := spec.Validate(); errValidate == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+	spec := valid()
+	if errValidate := spec.Validate(); errValidate != nil || spec.Concurrency != defaultConcurrency || spec.TargetA.Name != "a" {
+		t.Fatalf("Validate() = %v, spec = %+v", errValidate, spec)
+	}
+}
+
+func TestParseVerdict(t *testing.T) {
+	cases := map[string]int{
+		"```json\n{\"winner\": \"2\", \"reason\": \"x\"}\n```": 2,
+		`{"winner": 1}`:      1,
+		`{"winner": "TIE"}`:  0,
+		`Answer 1 is better`: -1,
+		`{"winner": "3"}`:    -1,
+	}
+	for content, want := range cases {
+		got, _, err := parseVerdict(content)
+		if want < 0 {
+			if err == nil {
+				t.Fatalf("parseVerdict(%q) expected error", content)
+			}
+			continue
+		}
+		if err != nil || got != want {
+			t.Fatalf("parseVerdict(%q) = %d, %v; want %d", content, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package evals
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+const judgeSystemPrompt = "You compare two answers to the same conversation and decide which is better for " +
+	"correctness, completeness and clarity. When a reference answer is given, prefer the answer that agrees " +
+	`with it. Reply with only a JSON object {"winner": "1" | "2" | "tie", "reason": "<one sentence>"}.`
+
+// judge asks judgeModel which of the two outputs is better. With swap the outputs are presented in
+// reverse order, so alternating cases counter the judge's position bias.
+func (r *Runner) judge(ctx context.Context, judgeModel string, entry Case, outputA, outputB string, swap bool) (string, string, error) {
+	first, second := outputA, outputB
+	if swap {
+		first, second = outputB, outputA
+	}
+	payload, errBuild := buildJudgeRequest(judgeModel, entry, first, second)
+	if errBuild != nil {
+		return "", "", errBuild
+	}
+	resp, errExec := r.backend.Execute(ctx, judgeModel, payload, nil)
+	if errExec != nil {
+		return "", "", fmt.Errorf("judge request: %w", errExec)
+	}
+	position, reason, errParse := parseVerdict(gjson.GetBytes(resp, "choices.0.message.content").String())
+	if errParse != nil {
+		return "", "", errParse
+	}
+	switch {
+	case position == 0:
+		return WinnerTie, reason, nil
+	case (position == 1) != swap:
+		return WinnerA, reason, nil
+	default:
+		return WinnerB, reason, nil
+	}
+}
+
+// buildJudgeRequest builds the chat completion asking the judge to compare first and second.
+func buildJudgeRequest(judgeModel string, entry Case, first, second string) ([]byte, error) {
+	var prompt strings.Builder
+	prompt.WriteString("Conversation:\n")
+	if messages := gjson.ParseBytes(entry.Messages); messages.IsArray() {
+		for _, message := range messages.Array() {
+			content := message.Get("content")
+			text := content.Raw
+			if content.Type == gjson.String {
+				text = content.String()
+			}
+			fmt.Fprintf(&prompt, "[%s]\n%s\n\n", message.Get("role").String(), text)
+		}
+	} else {
+		fmt.Fprintf(&prompt, "[user]\n%s\n\n", entry.Prompt)
+	}
+	if entry.Reference != "" {
+		fmt.Fprintf(&prompt, "Reference answer:\n%s\n\n", entry.Reference)
+	}
+	fmt.Fprintf(&prompt, "Answer 1:\n%s\n\nAnswer 2:\n%s\n", first, second)
+
+	return json.Marshal(map[string]any{
+		"model": judgeModel,
+		"messages": []map[string]string{
+			{"role": "system", "content": judgeSystemPrompt},
+			{"role": "user", "content": prompt.String()},
+		},
+	})
+}
+
+// parseVerdict returns the winning position (1 or 2, 0 for a tie) and reason from the judge's reply.
+func parseVerdict(content string) (int, string, error) {
+	content = strings.TrimSpace(content)
+	start, end := strings.Index(content, "{"), strings.LastIndex(content, "}")
+	if start < 0 || end <= start {
+		return 0, "", fmt.Errorf("judge reply is not a JSON verdict: %q", truncate(content, 200))
+	}
+	verdict := gjson.Parse(content[start : end+1])
+	reason := verdict.Get("reason").String()
+	switch strings.ToLower(strings.TrimSpace(verdict.Get("winner").String())) {
+	case "1":
+		return 1, reason, nil
+	case "2":
+		return 2, reason, nil
+	case "tie":
+		return 0, reason, nil
+	}
+	return 0, "", fmt.Errorf("judge reply has no valid winner: %q", truncate(content, 200))
+}
+
+func truncate(value string, limit int) string {
+	if len(value) <= limit {
+		return value
+	}
+	return value[:limit] + "..."
+}
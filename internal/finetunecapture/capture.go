@@ -0,0 +1,237 @@
+// Package finetunecapture appends sampled (prompt, completion) pairs to JSONL files in the
+// OpenAI chat fine-tuning format.
+package finetunecapture
+
+import (
+	"encoding/json"
+	"math/rand/v2"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/streamrelay"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultDir = "./finetune"
+	// queueSize bounds the examples waiting to be written; further examples are dropped.
+	queueSize = 256
+	// maxCompletionBytes bounds the completion collected from one stream.
+	maxCompletionBytes = 1 << 20
+	redacted           = "[REDACTED]"
+)
+
+// defaultRedactions match e-mail addresses and common API key and bearer token shapes.
+var defaultRedactions = []*regexp.Regexp{
+	regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`),
+	regexp.MustCompile(`\b(?:sk|pk|rk)-[A-Za-z0-9_-]{16,}`),
+	regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`),
+	regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`),
+	regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/=-]{16,}`),
+}
+
+type settings struct {
+	dir        string
+	models     []string
+	apiKeys    map[string]struct{}
+	sampleRate float64
+	redactions []*regexp.Regexp
+}
+
+// Message is one chat message of a fine-tuning example.
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type example struct {
+	model    string
+	messages []Message
+}
+
+var (
+	active  atomic.Pointer[settings]
+	startMu sync.Mutex
+	queue   chan example
+)
+
+// Apply enables, reconfigures or disables capturing for cfg.
+func Apply(cfg config.FinetuneCaptureConfig) {
+	if !cfg.Enabled {
+		active.Store(nil)
+		return
+	}
+	next := &settings{dir: strings.TrimSpace(cfg.Dir), sampleRate: cfg.SampleRate}
+	if next.dir == "" {
+		next.dir = defaultDir
+	}
+	if next.sampleRate <= 0 || next.sampleRate > 1 {
+		next.sampleRate = 1
+	}
+	for _, model := range cfg.Models {
+		if model = strings.ToLower(strings.TrimSpace(model)); model != "" {
+			next.models = append(next.models, model)
+		}
+	}
+	if len(cfg.APIKeys) > 0 {
+		next.apiKeys = make(map[string]struct{}, len(cfg.APIKeys))
+		for _, key := range cfg.APIKeys {
+			if key = strings.TrimSpace(key); key != "" {
+				next.apiKeys[key] = struct{}{}
+			}
+		}
+	}
+	if cfg.RedactDefaults {
+		next.redactions = append(next.redactions, defaultRedactions...)
+	}
+	for _, pattern := range cfg.RedactPatterns {
+		re, errCompile := regexp.Compile(pattern)
+		if errCompile != nil {
+			log.Warnf("finetune capture: invalid redact pattern %q: %v", pattern, errCompile)
+			continue
+		}
+		next.redactions = append(next.redactions, re)
+	}
+
+	startMu.Lock()
+	if queue == nil {
+		queue = make(chan example, queueSize)
+		go writeLoop(queue)
+	}
+	startMu.Unlock()
+	active.Store(next)
+}
+
+// selected reports the settings to capture a request with, or nil when it is not captured.
+func selected(model, apiKey string) *settings {
+	s := active.Load()
+	if s == nil {
+		return nil
+	}
+	if s.apiKeys != nil {
+		if _, ok := s.apiKeys[apiKey]; !ok {
+			return nil
+		}
+	}
+	if len(s.models) > 0 {
+		model = strings.ToLower(model)
+		matched := false
+		for _, pattern := range s.models {
+			if ok, _ := path.Match(pattern, model); ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return nil
+		}
+	}
+	if s.sampleRate < 1 && rand.Float64() >= s.sampleRate {
+		return nil
+	}
+	return s
+}
+
+// Capture records a non-streaming exchange whose request is in requestFormat and response in
+// responseFormat, both handler types such as "openai" or "claude".
+func Capture(requestFormat, responseFormat, model, apiKey string, request, response []byte) {
+	s := selected(model, apiKey)
+	if s == nil {
+		return
+	}
+	enqueue(s, model, PromptMessages(requestFormat, request), Completion(responseFormat, response))
+}
+
+// StreamObserver returns an observer that records a streamed exchange once the stream ends. It
+// is inactive when the request is not captured.
+func StreamObserver(requestFormat, responseFormat, model, apiKey string, request []byte) streamrelay.Observer {
+	s := selected(model, apiKey)
+	if s == nil {
+		return streamrelay.Observer{}
+	}
+	var completion strings.Builder
+	return streamrelay.Observer{
+		OnChunk: func(chunk []byte) {
+			if completion.Len() < maxCompletionBytes {
+				completion.WriteString(StreamDelta(responseFormat, chunk))
+			}
+		},
+		OnClose: func() {
+			enqueue(s, model, PromptMessages(requestFormat, request), completion.String())
+		},
+	}
+}
+
+func enqueue(s *settings, model string, prompt []Message, completion string) {
+	if len(prompt) == 0 || strings.TrimSpace(completion) == "" {
+		return
+	}
+	messages := append(prompt, Message{Role: "assistant", Content: completion})
+	for i := range messages {
+		for _, re := range s.redactions {
+			messages[i].Content = re.ReplaceAllString(messages[i].Content, redacted)
+		}
+	}
+	select {
+	case queue <- example{model: model, messages: messages}:
+	default:
+		log.Debug("finetune capture: queue full, dropping example")
+	}
+}
+
+func writeLoop(examples <-chan example) {
+	for ex := range examples {
+		s := active.Load()
+		if s == nil {
+			continue
+		}
+		if errWrite := write(s.dir, ex, time.Now()); errWrite != nil {
+			log.Warnf("finetune capture: %v", errWrite)
+		}
+	}
+}
+
+// write appends ex to <dir>/<model>-<date>.jsonl.
+func write(dir string, ex example, now time.Time) error {
+	line, errMarshal := json.Marshal(struct {
+		Messages []Message `json:"messages"`
+	}{ex.messages})
+	if errMarshal != nil {
+		return errMarshal
+	}
+	if errDir := os.MkdirAll(dir, 0o700); errDir != nil {
+		return errDir
+	}
+	name := fileSafe(ex.model) + "-" + now.Format("2006-01-02") + ".jsonl"
+	file, errOpen := os.OpenFile(filepath.Join(dir, name), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if errOpen != nil {
+		return errOpen
+	}
+	_, errWrite := file.Write(append(line, '\n'))
+	if errClose := file.Close(); errWrite == nil {
+		errWrite = errClose
+	}
+	return errWrite
+}
+
+// fileSafe maps a model name to a file name component.
+func fileSafe(model string) string {
+	safe := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '-', r == '_', r == '.':
+			return r
+		}
+		return '_'
+	}, strings.TrimSpace(model))
+	if strings.Trim(safe, "._") == "" {
+		return "unknown"
+	}
+	return safe
+}
@@ -0,0 +1,87 @@
+package finetunecapture
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestPromptMessagesAndCompletionFormats(t *testing.T) {
+	claudeReq := []byte(`{"system":[{"type":"text","text":"Be brief."}],"messages":[{"role":"user","content":[{"type":"text","text":"Hi"},{"type":"image"}]},{"role":"assistant","content":"Hello"},{"role":"user","content":"Bye"}]}`)
+	got := PromptMessages("claude", claudeReq)
+	want := []Message{{"system", "Be brief."}, {"user", "Hi"}, {"assistant", "Hello"}, {"user", "Bye"}}
+	if len(got) != len(want) {
+		t.Fatalf("claude prompt = %+v", got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("claude prompt[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	responsesReq := []byte(`{"instructions":"Sys","input":[{"type":"message","role":"developer","content":"Dev"},{"type":"function_call_output","output":"x"},{"role":"user","content":[{"type":"input_text","text":"Q"}]}]}`)
+	if got := PromptMessages("openai-response", responsesReq); len(got) != 3 || got[1].Role != "system" || got[2].Content != "Q" {
+		t.Fatalf("responses prompt = %+v", got)
+	}
+
+	geminiCLIReq := []byte(`{"request":{"contents":[{"role":"user","parts":[{"text":"A"}]},{"role":"model","parts":[{"text":"B"}]}]}}`)
+	if got := PromptMessages("gemini-cli", geminiCLIReq); len(got) != 2 || got[1].Role != "assistant" {
+		t.Fatalf("gemini-cli prompt = %+v", got)
+	}
+
+	if got := Completion("openai", []byte(`{"choices":[{"message":{"content":"done"}}]}`)); got != "done" {
+		t.Fatalf("openai completion = %q", got)
+	}
+	if got := Completion("gemini", []byte(`{"candidates":[{"content":{"parts":[{"text":"hm","thought":true},{"text":"ok"}]}}]}`)); got != "ok" {
+		t.Fatalf("gemini completion = %q", got)
+	}
+}
+
+func TestStreamDelta(t *testing.T) {
+	chunk := []byte("event: content_block_delta\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"Hel\"}}\n\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"lo\"}}\n")
+	if got := StreamDelta("claude", chunk); got != "Hello" {
+		t.Fatalf("claude delta = %q", got)
+	}
+	if got := StreamDelta("openai", []byte(`data: {"choices":[{"delta":{"content":"x"}}]}`)); got != "x" {
+		t.Fatalf("openai delta = %q", got)
+	}
+	if got := StreamDelta("openai", []byte("data: [DONE]")); got != "" {
+		t.Fatalf("done delta = %q", got)
+	}
+	if got := StreamDelta("gemini", []byte(`{"candidates":[{"content":{"parts":[{"text":"g"}]}}]}`)); got != "g" {
+		t.Fatalf("bare gemini delta = %q", got)
+	}
+}
+
+func TestCaptureWritesRedactedJSONL(t *testing.T) {
+	dir := t.TempDir()
+	Apply(config.FinetuneCaptureConfig{Enabled: true, Dir: dir, Models: []string{"gpt-*"}, RedactDefaults: true, RedactPatterns: []string{`secret-\d+`}})
+	defer Apply(config.FinetuneCaptureConfig{})
+
+	Capture("openai", "openai", "client-key", "claude-sonnet-4", []byte(`{"messages":[{"role":"user","content":"skip"}]}`), []byte(`{"choices":[{"message":{"content":"no"}}]}`))
+	observer := StreamObserver("openai", "openai", "gpt-5", "client-key", []byte(`{"messages":[{"role":"user","content":"mail me at a@example.com, code secret-42"}]}`))
+	observer.OnChunk([]byte(`data: {"choices":[{"delta":{"content":"Sure"}}]}`))
+	observer.OnClose()
+
+	path := filepath.Join(dir, "gpt-5-"+time.Now().Format("2006-01-02")+".jsonl")
+	var data []byte
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(10 * time.Millisecond) {
+		if data, _ = os.ReadFile(path); len(data) > 0 {
+			break
+		}
+	}
+	want := `{"messages":[{"role":"user","content":"mail me at [REDACTED], code [REDACTED]"},{"role":"assistant","content":"Sure"}]}` + "\n"
+	if string(data) != want {
+		t.Fatalf("captured %q, want %q", data, want)
+	}
+	entries, _ := os.ReadDir(dir)
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), "claude") {
+			t.Fatalf("unselected model was captured: %s", entry.Name())
+		}
+	}
+}
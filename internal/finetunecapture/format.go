@@ -0,0 +1,173 @@
+package finetunecapture
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/constant"
+	"github.com/tidwall/gjson"
+)
+
+// PromptMessages converts a request body of handlerType ("openai", "openai-response", "claude",
+// "gemini" or "gemini-cli") into system, user and assistant messages. Non-text content is
+// dropped.
+func PromptMessages(handlerType string, request []byte) []Message {
+	root := gjson.ParseBytes(request)
+	var out []Message
+	add := func(role, content string) {
+		if content = strings.TrimSpace(content); content != "" {
+			out = append(out, Message{Role: role, Content: content})
+		}
+	}
+	switch handlerType {
+	case constant.OpenAI:
+		for _, msg := range root.Get("messages").Array() {
+			if role := chatRole(msg.Get("role").String()); role != "" {
+				add(role, partsText(msg.Get("content")))
+			}
+		}
+	case constant.OpenaiResponse:
+		add("system", root.Get("instructions").String())
+		input := root.Get("input")
+		if input.Type == gjson.String {
+			add("user", input.String())
+			break
+		}
+		for _, item := range input.Array() {
+			if itemType := item.Get("type").String(); itemType != "" && itemType != "message" {
+				continue
+			}
+			if role := chatRole(item.Get("role").String()); role != "" {
+				add(role, partsText(item.Get("content")))
+			}
+		}
+	case constant.Claude:
+		add("system", partsText(root.Get("system")))
+		for _, msg := range root.Get("messages").Array() {
+			if role := chatRole(msg.Get("role").String()); role != "" {
+				add(role, partsText(msg.Get("content")))
+			}
+		}
+	case constant.Gemini, constant.GeminiCLI:
+		if handlerType == constant.GeminiCLI {
+			root = root.Get("request")
+		}
+		add("system", geminiText(root.Get("systemInstruction.parts")))
+		for _, content := range root.Get("contents").Array() {
+			role := "user"
+			if content.Get("role").String() == "model" {
+				role = "assistant"
+			}
+			add(role, geminiText(content.Get("parts")))
+		}
+	}
+	return out
+}
+
+// Completion extracts the assistant text of a non-streaming response of handlerType.
+func Completion(handlerType string, response []byte) string {
+	root := gjson.ParseBytes(response)
+	switch handlerType {
+	case constant.OpenAI:
+		return root.Get("choices.0.message.content").String()
+	case constant.OpenaiResponse:
+		var b strings.Builder
+		for _, item := range root.Get("output").Array() {
+			for _, part := range item.Get("content").Array() {
+				if part.Get("type").String() == "output_text" {
+					b.WriteString(part.Get("text").String())
+				}
+			}
+		}
+		return b.String()
+	case constant.Claude:
+		return partsText(root.Get("content"))
+	case constant.Gemini, constant.GeminiCLI:
+		if handlerType == constant.GeminiCLI {
+			root = root.Get("response")
+		}
+		return geminiText(root.Get("candidates.0.content.parts"))
+	}
+	return ""
+}
+
+// StreamDelta extracts the assistant text carried by one stream chunk of handlerType. A chunk
+// may hold several SSE data lines or, for Gemini without alt=sse, a bare JSON object.
+func StreamDelta(handlerType string, chunk []byte) string {
+	var b strings.Builder
+	found := false
+	for line := range bytes.SplitSeq(chunk, []byte("\n")) {
+		payload, ok := bytes.CutPrefix(bytes.TrimSpace(line), []byte("data:"))
+		if !ok {
+			continue
+		}
+		found = true
+		b.WriteString(eventDelta(handlerType, bytes.TrimSpace(payload)))
+	}
+	if !found {
+		b.WriteString(eventDelta(handlerType, bytes.TrimSpace(chunk)))
+	}
+	return b.String()
+}
+
+func eventDelta(handlerType string, payload []byte) string {
+	if len(payload) == 0 || !gjson.ValidBytes(payload) {
+		return ""
+	}
+	root := gjson.ParseBytes(payload)
+	switch handlerType {
+	case constant.OpenAI:
+		return root.Get("choices.0.delta.content").String()
+	case constant.OpenaiResponse:
+		if root.Get("type").String() == "response.output_text.delta" {
+			return root.Get("delta").String()
+		}
+	case constant.Claude:
+		if root.Get("type").String() == "content_block_delta" && root.Get("delta.type").String() == "text_delta" {
+			return root.Get("delta.text").String()
+		}
+	case constant.Gemini, constant.GeminiCLI:
+		if handlerType == constant.GeminiCLI {
+			root = root.Get("response")
+		}
+		return geminiText(root.Get("candidates.0.content.parts"))
+	}
+	return ""
+}
+
+// chatRole maps a request role to a fine-tuning role; tool and function messages are skipped.
+func chatRole(role string) string {
+	switch role {
+	case "system", "developer":
+		return "system"
+	case "user", "assistant":
+		return role
+	}
+	return ""
+}
+
+// partsText joins a string content or the text of text, input_text and output_text parts.
+func partsText(content gjson.Result) string {
+	if content.Type == gjson.String {
+		return content.String()
+	}
+	var texts []string
+	for _, part := range content.Array() {
+		switch part.Get("type").String() {
+		case "text", "input_text", "output_text":
+			texts = append(texts, part.Get("text").String())
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// geminiText joins the text parts, skipping thoughts.
+func geminiText(parts gjson.Result) string {
+	var b strings.Builder
+	for _, part := range parts.Array() {
+		if !part.Get("thought").Bool() {
+			b.WriteString(part.Get("text").String())
+		}
+	}
+	return b.String()
+}
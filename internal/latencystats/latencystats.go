@@ -0,0 +1,185 @@
+// Package latencystats keeps hourly time-to-first-token and completion latency aggregates per
+// provider so degradation windows can be drawn as a heat map.
+package latencystats
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+// Retention is how long hourly cells are kept.
+const Retention = 7 * 24 * time.Hour
+
+// Cell is the latency aggregate of one provider over one hour, or over one hour of the day when
+// grouped.
+type Cell struct {
+	// Hour is the start of the hour for hourly cells.
+	Hour time.Time `json:"hour,omitzero"`
+	// HourOfDay is the hour of the day (0-23) for grouped cells.
+	HourOfDay    *int  `json:"hour_of_day,omitempty"`
+	Requests     int64 `json:"requests"`
+	Failed       int64 `json:"failed"`
+	TTFTAvgMs    int64 `json:"ttft_avg_ms"`
+	TTFTMaxMs    int64 `json:"ttft_max_ms"`
+	LatencyAvgMs int64 `json:"latency_avg_ms"`
+	LatencyMaxMs int64 `json:"latency_max_ms"`
+}
+
+type cell struct {
+	requests, failed       int64
+	ttftCount, ttftSum     int64
+	ttftMax                int64
+	latencySum, latencyMax int64
+}
+
+func (c *cell) merge(other *cell) {
+	c.requests += other.requests
+	c.failed += other.failed
+	c.ttftCount += other.ttftCount
+	c.ttftSum += other.ttftSum
+	c.ttftMax = max(c.ttftMax, other.ttftMax)
+	c.latencySum += other.latencySum
+	c.latencyMax = max(c.latencyMax, other.latencyMax)
+}
+
+func (c *cell) export() Cell {
+	out := Cell{Requests: c.requests, Failed: c.failed, TTFTMaxMs: c.ttftMax, LatencyMaxMs: c.latencyMax}
+	if c.ttftCount > 0 {
+		out.TTFTAvgMs = c.ttftSum / c.ttftCount
+	}
+	if c.requests > 0 {
+		out.LatencyAvgMs = c.latencySum / c.requests
+	}
+	return out
+}
+
+type registry struct {
+	mu        sync.Mutex
+	providers map[string]map[int64]*cell
+}
+
+var active = &registry{providers: make(map[string]map[int64]*cell)}
+
+var now = time.Now
+
+func init() {
+	coreusage.RegisterPlugin(usagePlugin{})
+}
+
+type usagePlugin struct{}
+
+func (usagePlugin) HandleUsage(_ context.Context, record coreusage.Record) {
+	at := record.RequestedAt
+	if at.IsZero() {
+		at = now()
+	}
+	active.observe(record.Provider, at, record.TTFT, record.Latency, record.Failed)
+}
+
+func (r *registry) observe(provider string, at time.Time, ttft, latency time.Duration, failed bool) {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	if provider == "" {
+		provider = "unknown"
+	}
+	hour := at.Truncate(time.Hour).Unix()
+	cutoff := now().Add(-Retention).Unix()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if hour < cutoff {
+		return
+	}
+	cells, ok := r.providers[provider]
+	if !ok {
+		cells = make(map[int64]*cell)
+		r.providers[provider] = cells
+	}
+	c, ok := cells[hour]
+	if !ok {
+		for start := range cells {
+			if start < cutoff {
+				delete(cells, start)
+			}
+		}
+		c = &cell{}
+		cells[hour] = c
+	}
+	c.requests++
+	if failed {
+		c.failed++
+	}
+	if ms := ttft.Milliseconds(); ms > 0 {
+		c.ttftCount++
+		c.ttftSum += ms
+		c.ttftMax = max(c.ttftMax, ms)
+	}
+	ms := latency.Milliseconds()
+	c.latencySum += ms
+	c.latencyMax = max(c.latencyMax, ms)
+}
+
+// Snapshot returns the cells of the last window per provider, oldest first. Hours are reported
+// in loc. With byHourOfDay the cells of every day are merged into one cell per hour of the day.
+// An empty provider selects every provider.
+func Snapshot(provider string, window time.Duration, loc *time.Location, byHourOfDay bool) map[string][]Cell {
+	if loc == nil {
+		loc = time.UTC
+	}
+	window = min(max(window, time.Hour), Retention)
+	cutoff := now().Add(-window).Truncate(time.Hour).Unix()
+	provider = strings.ToLower(strings.TrimSpace(provider))
+
+	r := active
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make(map[string][]Cell, len(r.providers))
+	for name, cells := range r.providers {
+		if provider != "" && name != provider {
+			continue
+		}
+		var exported []Cell
+		if byHourOfDay {
+			var hours [24]cell
+			var seen [24]bool
+			for start, c := range cells {
+				if start < cutoff {
+					continue
+				}
+				h := time.Unix(start, 0).In(loc).Hour()
+				hours[h].merge(c)
+				seen[h] = true
+			}
+			for h := range hours {
+				if !seen[h] {
+					continue
+				}
+				entry := hours[h].export()
+				hourOfDay := h
+				entry.HourOfDay = &hourOfDay
+				exported = append(exported, entry)
+			}
+		} else {
+			starts := make([]int64, 0, len(cells))
+			for start := range cells {
+				if start >= cutoff {
+					starts = append(starts, start)
+				}
+			}
+			sort.Slice(starts, func(i, j int) bool { return starts[i] < starts[j] })
+			for _, start := range starts {
+				entry := cells[start].export()
+				entry.Hour = time.Unix(start, 0).In(loc)
+				exported = append(exported, entry)
+			}
+		}
+		if len(exported) > 0 {
+			out[name] = exported
+		}
+	}
+	return out
+}
@@ -0,0 +1,43 @@
+package latencystats
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSnapshotHourlyAndHourOfDay(t *testing.T) {
+	previousNow, previous := now, active
+	defer func() { now, active = previousNow, previous }()
+	current := time.Date(2026, 3, 4, 12, 30, 0, 0, time.UTC)
+	now = func() time.Time { return current }
+	active = &registry{providers: make(map[string]map[int64]*cell)}
+
+	active.observe("Copilot", current.Add(-time.Hour), 200*time.Millisecond, time.Second, false)
+	active.observe("copilot", current.Add(-time.Hour), 400*time.Millisecond, 3*time.Second, true)
+	active.observe("copilot", current.Add(-25*time.Hour), 0, 2*time.Second, false)
+	active.observe("copilot", current.Add(-8*24*time.Hour), 0, time.Second, false)
+	active.observe("claude", current, 0, time.Second, false)
+
+	hourly := Snapshot("copilot", Retention, time.UTC, false)["copilot"]
+	if len(hourly) != 2 {
+		t.Fatalf("hourly cells = %+v, want 2", hourly)
+	}
+	last := hourly[1]
+	if !last.Hour.Equal(time.Date(2026, 3, 4, 11, 0, 0, 0, time.UTC)) {
+		t.Fatalf("hour = %s", last.Hour)
+	}
+	if last.Requests != 2 || last.Failed != 1 || last.TTFTAvgMs != 300 || last.TTFTMaxMs != 400 || last.LatencyAvgMs != 2000 || last.LatencyMaxMs != 3000 {
+		t.Fatalf("unexpected cell: %+v", last)
+	}
+
+	grouped := Snapshot("", Retention, time.UTC, true)
+	if cells := grouped["copilot"]; len(cells) != 1 || *cells[0].HourOfDay != 11 || cells[0].Requests != 3 {
+		t.Fatalf("unexpected hour-of-day cells: %+v", cells)
+	}
+	if _, ok := grouped["claude"]; !ok {
+		t.Fatal("expected every provider without a filter")
+	}
+	if recent := Snapshot("copilot", 2*time.Hour, time.UTC, false)["copilot"]; len(recent) != 1 {
+		t.Fatalf("window cells = %+v, want 1", recent)
+	}
+}
@@ -0,0 +1,160 @@
+package logging
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+)
+
+type connectionTraceKey struct{}
+
+// ConnectionStats describes how the latest upstream round trip obtained its connection.
+type ConnectionStats struct {
+	// Reused reports that an existing connection was taken from the pool.
+	Reused   bool
+	WasIdle  bool
+	IdleTime time.Duration
+	// RemoteAddr is the address dialed, which is the proxy when one is configured.
+	RemoteAddr string
+	// DNS, Connect and TLS are the setup phases of a new connection; they are zero for reused ones.
+	DNS     time.Duration
+	Connect time.Duration
+	TLS     time.Duration
+	// TTFB is the time from requesting a connection to the first response byte.
+	TTFB time.Duration
+}
+
+// String formats the stats as space-separated key=value pairs for request logs.
+func (s ConnectionStats) String() string {
+	parts := []string{fmt.Sprintf("reused=%t", s.Reused)}
+	if s.WasIdle {
+		parts = append(parts, "idle="+formatTraceDuration(s.IdleTime))
+	}
+	if s.RemoteAddr != "" {
+		parts = append(parts, "remote="+s.RemoteAddr)
+	}
+	for _, phase := range []struct {
+		name string
+		d    time.Duration
+	}{{"dns", s.DNS}, {"connect", s.Connect}, {"tls", s.TLS}, {"ttfb", s.TTFB}} {
+		if phase.d > 0 {
+			parts = append(parts, phase.name+"="+formatTraceDuration(phase.d))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func formatTraceDuration(d time.Duration) string {
+	return d.Round(time.Millisecond / 10).String()
+}
+
+type connectionTraceHolder struct {
+	mu       sync.Mutex
+	observed bool
+	stats    ConnectionStats
+
+	getConn, dnsStart, connectStart, tlsStart time.Time
+}
+
+// WithConnectionTrace attaches an httptrace.ClientTrace recording connection reuse, remote
+// address and DNS, connect, TLS and first-byte timings of upstream requests made with ctx. Each
+// round trip replaces the stats of the previous one, so they describe the latest attempt.
+func WithConnectionTrace(ctx context.Context) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if holder, ok := ctx.Value(connectionTraceKey{}).(*connectionTraceHolder); ok && holder != nil {
+		return ctx
+	}
+	holder := &connectionTraceHolder{}
+	ctx = context.WithValue(ctx, connectionTraceKey{}, holder)
+	return httptrace.WithClientTrace(ctx, holder.clientTrace())
+}
+
+// GetConnectionStats returns the connection stats of the latest round trip made with ctx.
+func GetConnectionStats(ctx context.Context) (ConnectionStats, bool) {
+	if ctx == nil {
+		return ConnectionStats{}, false
+	}
+	holder, ok := ctx.Value(connectionTraceKey{}).(*connectionTraceHolder)
+	if !ok || holder == nil {
+		return ConnectionStats{}, false
+	}
+	holder.mu.Lock()
+	defer holder.mu.Unlock()
+	return holder.stats, holder.observed
+}
+
+// ResetConnectionStats forgets the stats of the previous round trip made with ctx, so an attempt
+// that fails before reaching the network is not reported with its predecessor's connection.
+func ResetConnectionStats(ctx context.Context) {
+	if ctx == nil {
+		return
+	}
+	holder, ok := ctx.Value(connectionTraceKey{}).(*connectionTraceHolder)
+	if !ok || holder == nil {
+		return
+	}
+	holder.mu.Lock()
+	holder.observed = false
+	holder.stats = ConnectionStats{}
+	holder.mu.Unlock()
+}
+
+func (h *connectionTraceHolder) clientTrace() *httptrace.ClientTrace {
+	update := func(fn func(now time.Time)) {
+		now := time.Now()
+		h.mu.Lock()
+		fn(now)
+		h.mu.Unlock()
+	}
+	return &httptrace.ClientTrace{
+		GetConn: func(string) {
+			update(func(now time.Time) {
+				h.observed = true
+				h.stats = ConnectionStats{}
+				h.getConn = now
+			})
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			update(func(now time.Time) { h.dnsStart = now })
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			update(func(now time.Time) { h.stats.DNS = now.Sub(h.dnsStart) })
+		},
+		ConnectStart: func(string, string) {
+			// Dialers racing IPv4 and IPv6 start several connects; time from the first.
+			update(func(now time.Time) {
+				if h.stats.Connect == 0 && h.connectStart.Before(h.getConn) {
+					h.connectStart = now
+				}
+			})
+		},
+		ConnectDone: func(string, string, error) {
+			update(func(now time.Time) { h.stats.Connect = now.Sub(h.connectStart) })
+		},
+		TLSHandshakeStart: func() {
+			update(func(now time.Time) { h.tlsStart = now })
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			update(func(now time.Time) { h.stats.TLS = now.Sub(h.tlsStart) })
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			update(func(time.Time) {
+				h.stats.Reused = info.Reused
+				h.stats.WasIdle = info.WasIdle
+				h.stats.IdleTime = info.IdleTime
+				if info.Conn != nil && info.Conn.RemoteAddr() != nil {
+					h.stats.RemoteAddr = info.Conn.RemoteAddr().String()
+				}
+			})
+		},
+		GotFirstResponseByte: func() {
+			update(func(now time.Time) { h.stats.TTFB = now.Sub(h.getConn) })
+		},
+	}
+}
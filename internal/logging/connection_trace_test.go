@@ -0,0 +1,62 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWithConnectionTraceRecordsReuse(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer server.Close()
+
+	ctx := WithConnectionTrace(context.Background())
+	if _, ok := GetConnectionStats(ctx); ok {
+		t.Fatal("stats reported before any round trip")
+	}
+	client := &http.Client{Transport: &http.Transport{}}
+	do := func() ConnectionStats {
+		t.Helper()
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+		if err != nil {
+			t.Fatalf("NewRequest: %v", err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("Do: %v", err)
+		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+		stats, ok := GetConnectionStats(ctx)
+		if !ok {
+			t.Fatal("no stats after round trip")
+		}
+		return stats
+	}
+
+	first := do()
+	if first.Reused || first.Connect <= 0 || first.TTFB <= 0 {
+		t.Fatalf("first round trip = %+v, want a new connection with connect and ttfb timings", first)
+	}
+	if first.RemoteAddr != strings.TrimPrefix(server.URL, "http://") {
+		t.Fatalf("remote = %q, want %q", first.RemoteAddr, strings.TrimPrefix(server.URL, "http://"))
+	}
+
+	second := do()
+	if !second.Reused || second.Connect != 0 {
+		t.Fatalf("second round trip = %+v, want a reused connection without connect timing", second)
+	}
+	if !strings.Contains(second.String(), "reused=true") {
+		t.Fatalf("String() = %q, want reused=true", second.String())
+	}
+
+	ResetConnectionStats(ctx)
+	if _, ok := GetConnectionStats(ctx); ok {
+		t.Fatal("stats reported after reset")
+	}
+}
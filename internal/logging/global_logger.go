@@ -118,6 +118,7 @@ func SetupBaseLogger() {
 		log.SetLevel(level)
 		log.SetReportCaller(true)
 		log.SetFormatter(&LogFormatter{})
+		log.AddHook(defaultLogBroadcaster)
 
 		ginInfoWriter = log.StandardLogger().Writer()
 		gin.DefaultWriter = ginInfoWriter
@@ -0,0 +1,406 @@
+package logging
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/buildinfo"
+)
+
+// HAR is the root of an HTTP Archive 1.2 document.
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+// HARLog holds the creator and the recorded entries of a HAR document.
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+// HARCreator identifies the application that produced the archive.
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// HAREntry is one request/response exchange.
+type HAREntry struct {
+	StartedDateTime string      `json:"startedDateTime"`
+	Time            float64     `json:"time"`
+	Request         HARRequest  `json:"request"`
+	Response        HARResponse `json:"response"`
+	Cache           struct{}    `json:"cache"`
+	Timings         HARTimings  `json:"timings"`
+	Comment         string      `json:"comment,omitempty"`
+}
+
+// HARRequest describes a recorded request.
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+// HARResponse describes a recorded response.
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+	Comment     string         `json:"comment,omitempty"`
+}
+
+// HARNameValue is a header, cookie or query string pair.
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// HARPostData holds a request body.
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// HARContent holds a response body.
+type HARContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+// HARTimings holds entry timings in milliseconds.
+type HARTimings struct {
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+}
+
+// logSectionPattern matches section banners such as "=== API REQUEST 2 ===".
+var logSectionPattern = regexp.MustCompile(`^=== ([A-Z ]+?)(?: (\d+))? ===$`)
+
+type logSection struct {
+	name  string
+	index int
+	body  string
+}
+
+// RequestLogToHAR converts a request log file written by FileRequestLogger into a HAR document.
+// The first entry is the inbound client exchange; each upstream attempt follows as its own entry,
+// so the translated request sent to the provider sits next to what the client sent.
+func RequestLogToHAR(data []byte) (*HAR, error) {
+	sections := splitLogSections(data)
+	if len(sections) == 0 {
+		return nil, fmt.Errorf("request log has no sections")
+	}
+
+	var info, headers, body, response string
+	var hasInfo bool
+	upstreamRequests := make(map[int]string)
+	upstreamResponses := make(map[int]string)
+	var order []int
+	for _, section := range sections {
+		switch section.name {
+		case "REQUEST INFO":
+			info, hasInfo = section.body, true
+		case "HEADERS":
+			headers = section.body
+		case "REQUEST BODY":
+			body = section.body
+		case "RESPONSE":
+			response = section.body
+		case "API REQUEST", "API RESPONSE":
+			if _, seen := upstreamRequests[section.index]; !seen {
+				if _, seenResponse := upstreamResponses[section.index]; !seenResponse {
+					order = append(order, section.index)
+				}
+			}
+			if section.name == "API REQUEST" {
+				upstreamRequests[section.index] = section.body
+			} else {
+				upstreamResponses[section.index] = section.body
+			}
+		}
+	}
+	if !hasInfo {
+		return nil, fmt.Errorf("request log has no request info section")
+	}
+
+	infoFields := parseLogFields(info)
+	requestHeaders := parseLogHeaderLines(headers)
+	inbound := HAREntry{
+		StartedDateTime: harTimestamp(infoFields["Timestamp"]),
+		Request:         harRequest(infoFields["Method"], inboundURL(infoFields["URL"], requestHeaders), requestHeaders, trimLogBody(body)),
+		Response:        parseDownstreamResponse(response),
+		Comment:         "client request",
+	}
+
+	entries := []HAREntry{inbound}
+	for _, index := range order {
+		entries = append(entries, upstreamEntry(index, upstreamRequests[index], upstreamResponses[index]))
+	}
+	return &HAR{Log: HARLog{
+		Version: "1.2",
+		Creator: HARCreator{Name: "CLIProxyAPI", Version: buildinfo.Version},
+		Entries: entries,
+	}}, nil
+}
+
+// MarshalHAR renders a HAR document as indented JSON.
+func MarshalHAR(har *HAR) ([]byte, error) {
+	return json.MarshalIndent(har, "", "  ")
+}
+
+func splitLogSections(data []byte) []logSection {
+	var sections []logSection
+	var current *logSection
+	var builder strings.Builder
+	flush := func() {
+		if current == nil {
+			return
+		}
+		current.body = builder.String()
+		sections = append(sections, *current)
+		builder.Reset()
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if match := logSectionPattern.FindStringSubmatch(strings.TrimRight(line, "\r")); match != nil {
+			flush()
+			index, _ := strconv.Atoi(match[2])
+			current = &logSection{name: match[1], index: index}
+			continue
+		}
+		if current != nil {
+			builder.WriteString(line)
+			builder.WriteByte('\n')
+		}
+	}
+	flush()
+	return sections
+}
+
+// parseLogFields reads "Key: value" lines up to the first blank line.
+func parseLogFields(text string) map[string]string {
+	fields := make(map[string]string)
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			break
+		}
+		if key, value, ok := strings.Cut(line, ": "); ok {
+			fields[strings.TrimSpace(key)] = strings.TrimSpace(value)
+		}
+	}
+	return fields
+}
+
+func parseLogHeaderLines(text string) []HARNameValue {
+	headers := make([]HARNameValue, 0)
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" || line == "<none>" {
+			continue
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		headers = append(headers, HARNameValue{Name: strings.TrimSpace(key), Value: strings.TrimSpace(value)})
+	}
+	return headers
+}
+
+// cutLogBlock splits text at the first line equal to marker and returns the parts before and after it.
+func cutLogBlock(text, marker string) (string, string, bool) {
+	if strings.HasPrefix(text, marker+"\n") {
+		return "", text[len(marker)+1:], true
+	}
+	before, after, ok := strings.Cut(text, "\n"+marker+"\n")
+	return before, after, ok
+}
+
+func trimLogBody(body string) string {
+	body = strings.TrimRight(body, "\n")
+	if body == "<empty>" || body == "<missing>" {
+		return ""
+	}
+	return body
+}
+
+func harTimestamp(raw string) string {
+	if parsed, errParse := time.Parse(time.RFC3339Nano, strings.TrimSpace(raw)); errParse == nil {
+		return parsed.Format(time.RFC3339Nano)
+	}
+	return time.Unix(0, 0).UTC().Format(time.RFC3339Nano)
+}
+
+func headerValue(headers []HARNameValue, name string) string {
+	for _, header := range headers {
+		if strings.EqualFold(header.Name, name) {
+			return header.Value
+		}
+	}
+	return ""
+}
+
+func inboundURL(path string, headers []HARNameValue) string {
+	if strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") {
+		return path
+	}
+	host := headerValue(headers, "Host")
+	if host == "" {
+		host = "localhost"
+	}
+	scheme := "http"
+	if proto := headerValue(headers, "X-Forwarded-Proto"); proto != "" {
+		scheme = proto
+	}
+	return scheme + "://" + host + path
+}
+
+func harRequest(method, rawURL string, headers []HARNameValue, body string) HARRequest {
+	if method == "" {
+		method = http.MethodGet
+	}
+	request := HARRequest{
+		Method:      method,
+		URL:         rawURL,
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []HARNameValue{},
+		Headers:     headers,
+		QueryString: []HARNameValue{},
+		HeadersSize: -1,
+		BodySize:    len(body),
+	}
+	if parsed, errParse := url.Parse(rawURL); errParse == nil {
+		for key, values := range parsed.Query() {
+			for _, value := range values {
+				request.QueryString = append(request.QueryString, HARNameValue{Name: key, Value: value})
+			}
+		}
+	}
+	if body != "" {
+		mimeType := headerValue(headers, "Content-Type")
+		if mimeType == "" {
+			mimeType = "application/json"
+		}
+		request.PostData = &HARPostData{MimeType: mimeType, Text: body}
+	}
+	return request
+}
+
+func harResponse(status int, headers []HARNameValue, body, comment string) HARResponse {
+	mimeType := headerValue(headers, "Content-Type")
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	return HARResponse{
+		Status:      status,
+		StatusText:  http.StatusText(status),
+		HTTPVersion: "HTTP/1.1",
+		Cookies:     []HARNameValue{},
+		Headers:     headers,
+		Content:     HARContent{Size: len(body), MimeType: mimeType, Text: body},
+		RedirectURL: "",
+		HeadersSize: -1,
+		BodySize:    len(body),
+		Comment:     comment,
+	}
+}
+
+// parseDownstreamResponse parses the "=== RESPONSE ===" section: a Status line, header lines,
+// a blank line and the body.
+func parseDownstreamResponse(text string) HARResponse {
+	head, body, _ := strings.Cut(text, "\n\n")
+	status := 0
+	var headerLines strings.Builder
+	for _, line := range strings.Split(head, "\n") {
+		if value, ok := strings.CutPrefix(line, "Status: "); ok {
+			status, _ = strconv.Atoi(strings.TrimSpace(value))
+			continue
+		}
+		headerLines.WriteString(line)
+		headerLines.WriteByte('\n')
+	}
+	return harResponse(status, parseLogHeaderLines(headerLines.String()), strings.TrimRight(body, "\n"), "")
+}
+
+func upstreamEntry(index int, requestText, responseText string) HAREntry {
+	requestFields := parseLogFields(requestText)
+	_, requestRest, _ := cutLogBlock(requestText, "Headers:")
+	requestHeaderText, requestBody, _ := cutLogBlock(requestRest, "Body:")
+	requestHeaders := parseLogHeaderLines(requestHeaderText)
+
+	entry := HAREntry{
+		StartedDateTime: harTimestamp(requestFields["Timestamp"]),
+		Request:         harRequest(requestFields["HTTP Method"], requestFields["Upstream URL"], requestHeaders, trimLogBody(requestBody)),
+		Comment:         fmt.Sprintf("upstream attempt %d", index),
+	}
+	if auth := requestFields["Auth"]; auth != "" {
+		entry.Comment += " (" + auth + ")"
+	}
+
+	responseFields := parseLogFields(responseText)
+	status := 0
+	var comment string
+	var responseHeaders []HARNameValue
+	var responseBody string
+	if strings.TrimSpace(responseText) == "" {
+		comment = "no upstream response recorded"
+		responseHeaders = []HARNameValue{}
+	} else {
+		// The response section starts with its own Timestamp field, then Status/Headers/Body.
+		_, afterIntro, _ := strings.Cut(responseText, "\n\n")
+		statusText, headerRest, hasHeaders := cutLogBlock(afterIntro, "Headers:")
+		if !hasHeaders {
+			statusText, headerRest = afterIntro, ""
+		}
+		for _, line := range strings.Split(statusText, "\n") {
+			if value, ok := strings.CutPrefix(line, "Status: "); ok {
+				status, _ = strconv.Atoi(strings.TrimSpace(value))
+			}
+			if value, ok := strings.CutPrefix(line, "Error: "); ok {
+				comment = strings.TrimSpace(value)
+			}
+		}
+		headerText, bodyText, hasBody := cutLogBlock(headerRest, "Body:")
+		if !hasBody {
+			headerText = headerRest
+		}
+		responseHeaders = parseLogHeaderLines(headerText)
+		responseBody = trimLogBody(bodyText)
+	}
+	entry.Response = harResponse(status, responseHeaders, responseBody, comment)
+
+	started, errStart := time.Parse(time.RFC3339Nano, requestFields["Timestamp"])
+	answered, errAnswer := time.Parse(time.RFC3339Nano, responseFields["Timestamp"])
+	if errStart == nil && errAnswer == nil && !answered.Before(started) {
+		wait := float64(answered.Sub(started).Microseconds()) / 1000
+		entry.Time = wait
+		entry.Timings = HARTimings{Send: 0, Wait: wait, Receive: 0}
+	}
+	return entry
+}
@@ -0,0 +1,119 @@
+package logging
+
+import (
+	"strings"
+	"testing"
+)
+
+const sampleRequestLog = `=== REQUEST INFO ===
+Version: dev
+URL: /v1/chat/completions?debug=1
+Method: POST
+Timestamp: 2026-03-06T12:00:00Z
+
+=== HEADERS ===
+Host: proxy.local:8317
+Content-Type: application/json
+Authorization: Bearer sk-1...abcd
+
+=== REQUEST BODY ===
+{"model":"claude-sonnet","messages":[]}
+
+=== API REQUEST 1 ===
+Timestamp: 2026-03-06T12:00:00.100Z
+Upstream URL: https://api.anthropic.com/v1/messages
+HTTP Method: POST
+Auth: provider=claude, auth_id=work
+
+Headers:
+Content-Type: application/json
+X-Api-Key: sk-a...wxyz
+
+Body:
+{"model":"claude-sonnet","messages":[],"max_tokens":1024}
+
+=== API RESPONSE 1 ===
+Timestamp: 2026-03-06T12:00:00.600Z
+
+Status: 429
+Headers:
+Content-Type: application/json
+Anthropic-Ratelimit-Requests-Remaining: 0
+
+Body:
+{"type":"error"}
+
+=== API REQUEST 2 ===
+Timestamp: 2026-03-06T12:00:01Z
+Upstream URL: https://api.anthropic.com/v1/messages
+HTTP Method: POST
+
+Headers:
+<none>
+
+Body:
+<empty>
+
+=== API RESPONSE 2 ===
+Timestamp: 2026-03-06T12:00:01.5Z
+
+Error: connection reset by peer
+
+=== RESPONSE ===
+Status: 200
+Content-Type: application/json
+
+{"id":"chatcmpl-1"}
+`
+
+func TestRequestLogToHAR(t *testing.T) {
+	har, err := RequestLogToHAR([]byte(sampleRequestLog))
+	if err != nil {
+		t.Fatalf("RequestLogToHAR() error = %v", err)
+	}
+	if har.Log.Version != "1.2" || len(har.Log.Entries) != 3 {
+		t.Fatalf("har = %+v, want 3 entries", har.Log)
+	}
+
+	inbound := har.Log.Entries[0]
+	if inbound.Request.URL != "http://proxy.local:8317/v1/chat/completions?debug=1" || inbound.Request.Method != "POST" {
+		t.Fatalf("inbound request = %+v", inbound.Request)
+	}
+	if inbound.Request.PostData == nil || !strings.Contains(inbound.Request.PostData.Text, `"claude-sonnet"`) {
+		t.Fatalf("inbound post data = %+v", inbound.Request.PostData)
+	}
+	if len(inbound.Request.QueryString) != 1 || inbound.Request.QueryString[0].Name != "debug" {
+		t.Fatalf("inbound query = %+v", inbound.Request.QueryString)
+	}
+	if inbound.Response.Status != 200 || inbound.Response.Content.Text != `{"id":"chatcmpl-1"}` {
+		t.Fatalf("inbound response = %+v", inbound.Response)
+	}
+
+	first := har.Log.Entries[1]
+	if first.Request.URL != "https://api.anthropic.com/v1/messages" || !strings.Contains(first.Request.PostData.Text, "max_tokens") {
+		t.Fatalf("upstream request = %+v", first.Request)
+	}
+	if first.Response.Status != 429 || first.Response.Content.Text != `{"type":"error"}` {
+		t.Fatalf("upstream response = %+v", first.Response)
+	}
+	if len(first.Response.Headers) != 2 || first.Response.Headers[1].Name != "Anthropic-Ratelimit-Requests-Remaining" {
+		t.Fatalf("upstream response headers = %+v", first.Response.Headers)
+	}
+	if first.Timings.Wait != 500 || !strings.Contains(first.Comment, "auth_id=work") {
+		t.Fatalf("upstream entry timings/comment = %+v / %q", first.Timings, first.Comment)
+	}
+
+	second := har.Log.Entries[2]
+	if second.Request.PostData != nil || len(second.Request.Headers) != 0 {
+		t.Fatalf("second request = %+v", second.Request)
+	}
+	if second.Response.Status != 0 || second.Response.Comment != "connection reset by peer" {
+		t.Fatalf("second response = %+v", second.Response)
+	}
+}
+
+func TestRequestLogToHARRejectsUnknownFormat(t *testing.T) {
+	if _, err := RequestLogToHAR([]byte("plain text")); err == nil {
+		t.Fatal("expected error for non request log input")
+	}
+}
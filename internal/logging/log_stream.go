@@ -0,0 +1,138 @@
+package logging
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultLogStreamBuffer = 256
+
+// LogStreamEntry is a structured application log entry delivered to live log subscribers.
+type LogStreamEntry struct {
+	Timestamp string            `json:"timestamp"`
+	Level     string            `json:"level"`
+	Message   string            `json:"message"`
+	RequestID string            `json:"request_id,omitempty"`
+	Provider  string            `json:"provider,omitempty"`
+	Fields    map[string]string `json:"fields,omitempty"`
+}
+
+// LogStreamFilter selects which entries a subscriber receives.
+type LogStreamFilter struct {
+	// Level is the least severe level delivered; entries below it are dropped.
+	Level log.Level
+	// Provider matches the entry's "provider" field, or the message when the field is absent.
+	Provider string
+}
+
+// Match reports whether entry passes the filter.
+func (f LogStreamFilter) Match(entry LogStreamEntry, level log.Level) bool {
+	if level > f.Level {
+		return false
+	}
+	provider := strings.ToLower(strings.TrimSpace(f.Provider))
+	if provider == "" {
+		return true
+	}
+	if entry.Provider != "" {
+		return strings.EqualFold(entry.Provider, provider)
+	}
+	return strings.Contains(strings.ToLower(entry.Message), provider)
+}
+
+type logSubscriber struct {
+	filter  LogStreamFilter
+	ch      chan LogStreamEntry
+	dropped atomic.Int64
+}
+
+// logBroadcaster is a logrus hook fanning entries out to live log subscribers.
+// Entries are dropped for subscribers that cannot keep up rather than blocking the logger.
+type logBroadcaster struct {
+	mu          sync.RWMutex
+	subscribers map[*logSubscriber]struct{}
+	active      atomic.Int32
+}
+
+var defaultLogBroadcaster = &logBroadcaster{subscribers: make(map[*logSubscriber]struct{})}
+
+// Levels implements logrus.Hook.
+func (b *logBroadcaster) Levels() []log.Level {
+	return log.AllLevels
+}
+
+// Fire implements logrus.Hook.
+func (b *logBroadcaster) Fire(entry *log.Entry) error {
+	if entry == nil || b.active.Load() == 0 {
+		return nil
+	}
+	streamEntry := newLogStreamEntry(entry)
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for sub := range b.subscribers {
+		if !sub.filter.Match(streamEntry, entry.Level) {
+			continue
+		}
+		select {
+		case sub.ch <- streamEntry:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+	return nil
+}
+
+func newLogStreamEntry(entry *log.Entry) LogStreamEntry {
+	streamEntry := LogStreamEntry{
+		Timestamp: entry.Time.Format(time.RFC3339Nano),
+		Level:     entry.Level.String(),
+		Message:   strings.TrimRight(entry.Message, "\r\n"),
+		RequestID: appLogRequestID(entry),
+	}
+	for key, value := range entry.Data {
+		if key == "request_id" {
+			continue
+		}
+		text := fmt.Sprint(value)
+		if key == "provider" {
+			streamEntry.Provider = text
+			continue
+		}
+		if streamEntry.Fields == nil {
+			streamEntry.Fields = make(map[string]string, len(entry.Data))
+		}
+		streamEntry.Fields[key] = text
+	}
+	return streamEntry
+}
+
+// SubscribeLogs registers a live log subscriber. The returned cancel function must be called to
+// release the subscription; it closes the channel and reports how many entries were dropped.
+func SubscribeLogs(filter LogStreamFilter, buffer int) (<-chan LogStreamEntry, func() int64) {
+	if buffer <= 0 {
+		buffer = defaultLogStreamBuffer
+	}
+	sub := &logSubscriber{filter: filter, ch: make(chan LogStreamEntry, buffer)}
+	b := defaultLogBroadcaster
+	b.mu.Lock()
+	b.subscribers[sub] = struct{}{}
+	b.active.Add(1)
+	b.mu.Unlock()
+
+	var once sync.Once
+	return sub.ch, func() int64 {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, sub)
+			b.active.Add(-1)
+			b.mu.Unlock()
+			close(sub.ch)
+		})
+		return sub.dropped.Load()
+	}
+}
@@ -0,0 +1,58 @@
+package logging
+
+import (
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func fireTestEntry(level log.Level, message string, fields log.Fields) {
+	entry := log.NewEntry(log.StandardLogger()).WithFields(fields)
+	entry.Level = level
+	entry.Message = message
+	entry.Time = time.Now()
+	_ = defaultLogBroadcaster.Fire(entry)
+}
+
+func TestSubscribeLogsFiltersByLevelAndProvider(t *testing.T) {
+	entries, cancel := SubscribeLogs(LogStreamFilter{Level: log.WarnLevel, Provider: "Grok"}, 8)
+	defer cancel()
+
+	fireTestEntry(log.InfoLevel, "grok info", nil)
+	fireTestEntry(log.WarnLevel, "other provider", log.Fields{"provider": "copilot"})
+	fireTestEntry(log.ErrorLevel, "upstream failed", log.Fields{"provider": "grok", "request_id": "req-1", "status": 502})
+	fireTestEntry(log.WarnLevel, "grok executor: retrying", nil)
+
+	first := <-entries
+	if first.Message != "upstream failed" || first.Provider != "grok" || first.RequestID != "req-1" || first.Fields["status"] != "502" {
+		t.Fatalf("first entry = %+v", first)
+	}
+	second := <-entries
+	if second.Message != "grok executor: retrying" || second.Level != "warning" {
+		t.Fatalf("second entry = %+v", second)
+	}
+	select {
+	case extra := <-entries:
+		t.Fatalf("unexpected entry %+v", extra)
+	default:
+	}
+}
+
+func TestSubscribeLogsDropsWhenFullAndCloses(t *testing.T) {
+	entries, cancel := SubscribeLogs(LogStreamFilter{Level: log.TraceLevel}, 1)
+	fireTestEntry(log.InfoLevel, "one", nil)
+	fireTestEntry(log.InfoLevel, "two", nil)
+	if dropped := cancel(); dropped != 1 {
+		t.Fatalf("dropped = %d, want 1", dropped)
+	}
+	if entry := <-entries; entry.Message != "one" {
+		t.Fatalf("entry = %+v", entry)
+	}
+	if _, open := <-entries; open {
+		t.Fatal("expected channel to be closed after cancel")
+	}
+	if defaultLogBroadcaster.active.Load() != 0 {
+		t.Fatal("expected no active subscribers")
+	}
+}
@@ -0,0 +1,273 @@
+package logging
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// RequestLogClientKeyHeader tags a request log with the hash of the client API key that made the
+// request, so the logs of one key can be found for deletion. Incoming headers are canonicalized,
+// so a client cannot send a header spelled this way.
+const RequestLogClientKeyHeader = "X-CLIProxy-Client-Key-Hash"
+
+const (
+	requestLogInfoSection     = "=== REQUEST INFO ==="
+	requestLogHeadersSection  = "=== HEADERS ==="
+	requestLogRedactedSection = "=== REDACTED ==="
+	requestRedactionInterval  = 10 * time.Minute
+	requestLogMaxHeadLine     = 1 << 20
+)
+
+var (
+	requestRetentionMu     sync.Mutex
+	requestRetentionCancel context.CancelFunc
+)
+
+// ClientKeyHash hashes a client API key so logs identify the caller without naming the key.
+func ClientKeyHash(apiKey string) string {
+	apiKey = strings.TrimSpace(apiKey)
+	if apiKey == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(apiKey))
+	return hex.EncodeToString(sum[:8])
+}
+
+// ConfigureRequestRetention applies the request-retention configuration, redacting request logs
+// older than the retention period in the background.
+func ConfigureRequestRetention(cfg *config.Config) {
+	requestRetentionMu.Lock()
+	defer requestRetentionMu.Unlock()
+	if requestRetentionCancel != nil {
+		requestRetentionCancel()
+		requestRetentionCancel = nil
+	}
+	if cfg == nil || cfg.RequestRetention.RedactAfterHours <= 0 {
+		return
+	}
+	retention := time.Duration(cfg.RequestRetention.RedactAfterHours) * time.Hour
+	ctx, cancel := context.WithCancel(context.Background())
+	requestRetentionCancel = cancel
+	go runRequestRedaction(ctx, ResolveLogDirectory(cfg), retention)
+}
+
+func runRequestRedaction(ctx context.Context, dir string, retention time.Duration) {
+	ticker := time.NewTicker(requestRedactionInterval)
+	defer ticker.Stop()
+
+	redactOnce := func() {
+		redacted, errRedact := RedactRequestLogs(dir, time.Now().Add(-retention))
+		if errRedact != nil {
+			log.WithError(errRedact).Warn("logging: failed to redact expired request logs")
+			return
+		}
+		if redacted > 0 {
+			log.Debugf("logging: redacted %d request log(s) past the retention period", redacted)
+		}
+	}
+
+	redactOnce()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			redactOnce()
+		}
+	}
+}
+
+// RequestLogFilter selects request logs. Zero fields match every log.
+type RequestLogFilter struct {
+	// Before matches logs of requests received before this time.
+	Before time.Time
+	// ClientKeyHash matches logs of requests made with the key of this ClientKeyHash.
+	ClientKeyHash string
+}
+
+func (f RequestLogFilter) matches(head requestLogHead) bool {
+	if !f.Before.IsZero() && !head.timestamp.Before(f.Before) {
+		return false
+	}
+	return f.ClientKeyHash == "" || head.clientKeyHash == f.ClientKeyHash
+}
+
+// PurgeRequestLogs deletes the request logs in dir matching filter, including redacted and error
+// request logs, and returns how many were removed.
+func PurgeRequestLogs(dir string, filter RequestLogFilter) (int, error) {
+	removed := 0
+	errWalk := walkRequestLogs(dir, func(path string, head requestLogHead) error {
+		if !filter.matches(head) {
+			return nil
+		}
+		if errRemove := os.Remove(path); errRemove != nil && !os.IsNotExist(errRemove) {
+			return fmt.Errorf("remove %s: %w", filepath.Base(path), errRemove)
+		}
+		removed++
+		return nil
+	})
+	return removed, errWalk
+}
+
+// RedactRequestLogs strips the headers and bodies from the request logs in dir of requests
+// received before cutoff. The request line without its query, the timestamp and the client key
+// hash are kept, so redacted logs can still be found and deleted by key.
+func RedactRequestLogs(dir string, cutoff time.Time) (int, error) {
+	redacted := 0
+	errWalk := walkRequestLogs(dir, func(path string, head requestLogHead) error {
+		if head.redacted || !head.timestamp.Before(cutoff) {
+			return nil
+		}
+		if errRedact := redactRequestLog(path, head); errRedact != nil {
+			return fmt.Errorf("redact %s: %w", filepath.Base(path), errRedact)
+		}
+		redacted++
+		return nil
+	})
+	return redacted, errWalk
+}
+
+// requestLogHead is what a request log records about its request ahead of the bodies.
+type requestLogHead struct {
+	info          []string
+	timestamp     time.Time
+	clientKeyHash string
+	redacted      bool
+}
+
+// walkRequestLogs calls fn for each request log in dir. Other files, such as the main log, are
+// skipped. A missing directory holds no logs.
+func walkRequestLogs(dir string, fn func(path string, head requestLogHead) error) error {
+	entries, errRead := os.ReadDir(dir)
+	if errRead != nil {
+		if os.IsNotExist(errRead) {
+			return nil
+		}
+		return errRead
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".log") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		head, ok, errHead := readRequestLogHead(path)
+		if errHead != nil {
+			if os.IsNotExist(errHead) {
+				continue
+			}
+			return errHead
+		}
+		if !ok {
+			continue
+		}
+		if head.timestamp.IsZero() {
+			if info, errInfo := entry.Info(); errInfo == nil {
+				head.timestamp = info.ModTime()
+			}
+		}
+		if errFn := fn(path, head); errFn != nil {
+			return errFn
+		}
+	}
+	return nil
+}
+
+// readRequestLogHead reads the request info and header sections of the log at path. ok is false
+// when the file is not a request log.
+func readRequestLogHead(path string) (head requestLogHead, ok bool, err error) {
+	file, errOpen := os.Open(path)
+	if errOpen != nil {
+		return head, false, errOpen
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), requestLogMaxHeadLine)
+	if !scanner.Scan() || scanner.Text() != requestLogInfoSection {
+		return head, false, nil
+	}
+	section := requestLogInfoSection
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			if section == requestLogInfoSection {
+				continue
+			}
+			break
+		}
+		if strings.HasPrefix(line, "=== ") && strings.HasSuffix(line, " ===") {
+			section = line
+			if section == requestLogRedactedSection {
+				head.redacted = true
+			} else if section != requestLogHeadersSection {
+				break
+			}
+			continue
+		}
+		switch section {
+		case requestLogInfoSection:
+			head.info = append(head.info, line)
+			if value, found := strings.CutPrefix(line, "Timestamp: "); found {
+				if parsed, errParse := time.Parse(time.RFC3339Nano, value); errParse == nil {
+					head.timestamp = parsed
+				}
+			}
+		default:
+			if value, found := strings.CutPrefix(line, RequestLogClientKeyHeader+": "); found {
+				head.clientKeyHash = value
+			}
+		}
+	}
+	// Overlong lines are bodies or headers nobody filters on; the head read so far stands.
+	if errScan := scanner.Err(); errScan != nil && errScan != bufio.ErrTooLong {
+		return head, false, errScan
+	}
+	return head, true, nil
+}
+
+func redactRequestLog(path string, head requestLogHead) error {
+	var builder strings.Builder
+	builder.WriteString(requestLogInfoSection + "\n")
+	for _, line := range head.info {
+		if url, found := strings.CutPrefix(line, "URL: "); found {
+			url, _, _ = strings.Cut(url, "?")
+			line = "URL: " + url
+		}
+		builder.WriteString(line + "\n")
+	}
+	builder.WriteString("\n" + requestLogRedactedSection + "\n")
+	builder.WriteString("Redacted At: " + time.Now().Format(time.RFC3339) + "\n")
+	if head.clientKeyHash != "" {
+		builder.WriteString(RequestLogClientKeyHeader + ": " + head.clientKeyHash + "\n")
+	}
+
+	temp, errCreate := os.CreateTemp(filepath.Dir(path), ".redact-*")
+	if errCreate != nil {
+		return errCreate
+	}
+	tempPath := temp.Name()
+	_, errWrite := temp.WriteString(builder.String())
+	if errClose := temp.Close(); errWrite == nil {
+		errWrite = errClose
+	}
+	if errWrite == nil {
+		errWrite = os.Rename(tempPath, path)
+	}
+	if errWrite != nil {
+		_ = os.Remove(tempPath)
+	}
+	return errWrite
+}
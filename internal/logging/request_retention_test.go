@@ -0,0 +1,93 @@
+package logging
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeTestRequestLog(t *testing.T, dir, name string, timestamp time.Time, keyHash string) string {
+	t.Helper()
+	var builder strings.Builder
+	builder.WriteString("=== REQUEST INFO ===\nVersion: dev\nURL: /v1beta/models/gemini:generateContent?key=client-key\nMethod: POST\n")
+	builder.WriteString("Timestamp: " + timestamp.Format(time.RFC3339Nano) + "\n\n")
+	builder.WriteString("=== HEADERS ===\nContent-Type: application/json\n")
+	if keyHash != "" {
+		builder.WriteString(RequestLogClientKeyHeader + ": " + keyHash + "\n")
+	}
+	builder.WriteString("\n=== REQUEST BODY ===\n{\"prompt\":\"personal data\"}\n\n=== RESPONSE ===\nStatus: 200\n")
+	path := filepath.Join(dir, name)
+	if errWrite := os.WriteFile(path, []byte(builder.String()), 0o644); errWrite != nil {
+		t.Fatalf("write %s: %v", name, errWrite)
+	}
+	return path
+}
+
+func TestPurgeRequestLogsMatchesKeyAndTime(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	keyHash := ClientKeyHash("client-key")
+	oldMatch := writeTestRequestLog(t, dir, "v1-chat-completions-2026-01-01T000000-aaaa.log", now.Add(-48*time.Hour), keyHash)
+	newMatch := writeTestRequestLog(t, dir, "v1-chat-completions-2026-01-03T000000-bbbb.log", now, keyHash)
+	otherKey := writeTestRequestLog(t, dir, "error-v1-chat-completions-2026-01-01T000000-cccc.log", now.Add(-48*time.Hour), ClientKeyHash("other-key"))
+	mainLog := filepath.Join(dir, "main.log")
+	if errWrite := os.WriteFile(mainLog, []byte("[2026-01-01 00:00:00] info\n"), 0o644); errWrite != nil {
+		t.Fatalf("write main.log: %v", errWrite)
+	}
+
+	removed, errPurge := PurgeRequestLogs(dir, RequestLogFilter{Before: now.Add(-time.Hour), ClientKeyHash: keyHash})
+	if errPurge != nil || removed != 1 {
+		t.Fatalf("PurgeRequestLogs() = (%d, %v), want 1 removed", removed, errPurge)
+	}
+	if _, errStat := os.Stat(oldMatch); !os.IsNotExist(errStat) {
+		t.Fatalf("old log of the key was kept")
+	}
+	for _, path := range []string{newMatch, otherKey, mainLog} {
+		if _, errStat := os.Stat(path); errStat != nil {
+			t.Fatalf("%s was removed: %v", filepath.Base(path), errStat)
+		}
+	}
+
+	removed, errPurge = PurgeRequestLogs(dir, RequestLogFilter{ClientKeyHash: keyHash})
+	if errPurge != nil || removed != 1 {
+		t.Fatalf("PurgeRequestLogs(key only) = (%d, %v), want 1 removed", removed, errPurge)
+	}
+}
+
+func TestRedactRequestLogsKeepsKeyHash(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	keyHash := ClientKeyHash("client-key")
+	expired := writeTestRequestLog(t, dir, "v1-chat-completions-2026-01-01T000000-aaaa.log", now.Add(-48*time.Hour), keyHash)
+	recent := writeTestRequestLog(t, dir, "v1-chat-completions-2026-01-03T000000-bbbb.log", now, keyHash)
+
+	redacted, errRedact := RedactRequestLogs(dir, now.Add(-24*time.Hour))
+	if errRedact != nil || redacted != 1 {
+		t.Fatalf("RedactRequestLogs() = (%d, %v), want 1 redacted", redacted, errRedact)
+	}
+	data, errRead := os.ReadFile(expired)
+	if errRead != nil {
+		t.Fatalf("read redacted log: %v", errRead)
+	}
+	content := string(data)
+	if strings.Contains(content, "personal data") || strings.Contains(content, "client-key") {
+		t.Fatalf("redacted log still holds request data:\n%s", content)
+	}
+	if !strings.Contains(content, requestLogRedactedSection) || !strings.Contains(content, "URL: /v1beta/models/gemini:generateContent\n") {
+		t.Fatalf("redacted log lost its request line:\n%s", content)
+	}
+	if recentData, _ := os.ReadFile(recent); !strings.Contains(string(recentData), "personal data") {
+		t.Fatal("log within the retention period was redacted")
+	}
+
+	redacted, errRedact = RedactRequestLogs(dir, now.Add(-24*time.Hour))
+	if errRedact != nil || redacted != 0 {
+		t.Fatalf("second RedactRequestLogs() = (%d, %v), want nothing left to redact", redacted, errRedact)
+	}
+	removed, errPurge := PurgeRequestLogs(dir, RequestLogFilter{ClientKeyHash: keyHash})
+	if errPurge != nil || removed != 2 {
+		t.Fatalf("PurgeRequestLogs() = (%d, %v), want the redacted and recent logs removed", removed, errPurge)
+	}
+}
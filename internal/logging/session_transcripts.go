@@ -0,0 +1,273 @@
+package logging
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// SessionTranscriptsDirName is the logs subdirectory holding execution session transcripts.
+	SessionTranscriptsDirName = "transcripts"
+
+	defaultTranscriptRetention     = 72 * time.Hour
+	defaultTranscriptMaxEntryBytes = 1 << 20
+	transcriptPruneInterval        = 10 * time.Minute
+	transcriptFileSuffix           = ".jsonl"
+)
+
+// TranscriptEntry is one turn of an execution session: what the client sent and what it got back.
+type TranscriptEntry struct {
+	Timestamp time.Time       `json:"timestamp"`
+	SessionID string          `json:"session_id"`
+	RequestID string          `json:"request_id,omitempty"`
+	Model     string          `json:"model,omitempty"`
+	AuthID    string          `json:"auth_id,omitempty"`
+	Request   json.RawMessage `json:"request,omitempty"`
+	Response  json.RawMessage `json:"response,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	// Truncated reports that Request or Response exceeded max-entry-bytes and was stored as a
+	// truncated string.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// TranscriptSummary describes one stored session transcript.
+type TranscriptSummary struct {
+	SessionID string    `json:"session_id"`
+	Size      int64     `json:"size"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type sessionTranscriptStore struct {
+	mu            sync.Mutex
+	enabled       bool
+	dir           string
+	retention     time.Duration
+	maxEntryBytes int
+	lastPrune     time.Time
+}
+
+var defaultTranscriptStore = &sessionTranscriptStore{}
+
+// ConfigureSessionTranscripts applies the session-transcripts configuration.
+func ConfigureSessionTranscripts(cfg *config.Config) {
+	store := defaultTranscriptStore
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if cfg == nil || !cfg.SessionTranscripts.Enabled {
+		store.enabled = false
+		return
+	}
+	store.enabled = true
+	store.dir = filepath.Join(ResolveLogDirectory(cfg), SessionTranscriptsDirName)
+	store.retention = defaultTranscriptRetention
+	switch hours := cfg.SessionTranscripts.RetentionHours; {
+	case hours > 0:
+		store.retention = time.Duration(hours) * time.Hour
+	case hours < 0:
+		store.retention = 0
+	}
+	store.maxEntryBytes = cfg.SessionTranscripts.MaxEntryBytes
+	if store.maxEntryBytes <= 0 {
+		store.maxEntryBytes = defaultTranscriptMaxEntryBytes
+	}
+	store.lastPrune = time.Time{}
+}
+
+// SessionTranscriptsEnabled reports whether execution session turns are being recorded.
+func SessionTranscriptsEnabled() bool {
+	store := defaultTranscriptStore
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	return store.enabled
+}
+
+// AppendSessionTranscript records one turn of an execution session. It is a no-op when
+// session transcripts are disabled.
+func AppendSessionTranscript(entry TranscriptEntry) error {
+	entry.SessionID = strings.TrimSpace(entry.SessionID)
+	if entry.SessionID == "" {
+		return nil
+	}
+	store := defaultTranscriptStore
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	if !store.enabled {
+		return nil
+	}
+	if entry.Timestamp.IsZero() {
+		entry.Timestamp = time.Now()
+	}
+	var truncatedRequest, truncatedResponse bool
+	entry.Request, truncatedRequest = boundTranscriptPayload(entry.Request, store.maxEntryBytes)
+	entry.Response, truncatedResponse = boundTranscriptPayload(entry.Response, store.maxEntryBytes)
+	entry.Truncated = entry.Truncated || truncatedRequest || truncatedResponse
+
+	line, errMarshal := json.Marshal(entry)
+	if errMarshal != nil {
+		return fmt.Errorf("session transcripts: marshal entry: %w", errMarshal)
+	}
+	if errMkdir := os.MkdirAll(store.dir, 0o755); errMkdir != nil {
+		return fmt.Errorf("session transcripts: create directory: %w", errMkdir)
+	}
+	file, errOpen := os.OpenFile(filepath.Join(store.dir, transcriptFileName(entry.SessionID)), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if errOpen != nil {
+		return fmt.Errorf("session transcripts: open file: %w", errOpen)
+	}
+	_, errWrite := file.Write(append(line, '\n'))
+	if errClose := file.Close(); errWrite == nil {
+		errWrite = errClose
+	}
+	if errWrite != nil {
+		return fmt.Errorf("session transcripts: write entry: %w", errWrite)
+	}
+
+	if store.retention > 0 && time.Since(store.lastPrune) >= transcriptPruneInterval {
+		store.lastPrune = time.Now()
+		if removed := pruneSessionTranscripts(store.dir, store.retention, store.lastPrune); removed > 0 {
+			log.Debugf("session transcripts: removed %d expired transcript(s)", removed)
+		}
+	}
+	return nil
+}
+
+// ListSessionTranscripts returns the transcripts stored under logDir, most recently updated first.
+func ListSessionTranscripts(logDir string) ([]TranscriptSummary, error) {
+	entries, errRead := os.ReadDir(filepath.Join(logDir, SessionTranscriptsDirName))
+	if errRead != nil {
+		if errors.Is(errRead, os.ErrNotExist) {
+			return []TranscriptSummary{}, nil
+		}
+		return nil, errRead
+	}
+	out := make([]TranscriptSummary, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		sessionID, ok := sessionIDFromTranscriptFileName(entry.Name())
+		if !ok {
+			continue
+		}
+		info, errInfo := entry.Info()
+		if errInfo != nil {
+			continue
+		}
+		out = append(out, TranscriptSummary{SessionID: sessionID, Size: info.Size(), UpdatedAt: info.ModTime()})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].UpdatedAt.After(out[j].UpdatedAt) })
+	return out, nil
+}
+
+// ReadSessionTranscript returns every recorded turn of sessionID in order. It returns an error
+// wrapping os.ErrNotExist when no transcript exists.
+func ReadSessionTranscript(logDir, sessionID string) ([]TranscriptEntry, error) {
+	file, errOpen := os.Open(transcriptPath(logDir, sessionID))
+	if errOpen != nil {
+		return nil, errOpen
+	}
+	defer func() { _ = file.Close() }()
+
+	out := make([]TranscriptEntry, 0)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*defaultTranscriptMaxEntryBytes)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+		var entry TranscriptEntry
+		if errUnmarshal := json.Unmarshal(line, &entry); errUnmarshal != nil {
+			continue
+		}
+		out = append(out, entry)
+	}
+	if errScan := scanner.Err(); errScan != nil {
+		return nil, errScan
+	}
+	return out, nil
+}
+
+// DeleteSessionTranscript removes the transcript of sessionID.
+func DeleteSessionTranscript(logDir, sessionID string) error {
+	store := defaultTranscriptStore
+	store.mu.Lock()
+	defer store.mu.Unlock()
+	return os.Remove(transcriptPath(logDir, sessionID))
+}
+
+func transcriptPath(logDir, sessionID string) string {
+	return filepath.Join(logDir, SessionTranscriptsDirName, transcriptFileName(strings.TrimSpace(sessionID)))
+}
+
+// transcriptFileName encodes the session ID so arbitrary client-supplied IDs are safe file names.
+func transcriptFileName(sessionID string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(sessionID)) + transcriptFileSuffix
+}
+
+func sessionIDFromTranscriptFileName(name string) (string, bool) {
+	encoded, ok := strings.CutSuffix(name, transcriptFileSuffix)
+	if !ok {
+		return "", false
+	}
+	decoded, errDecode := base64.RawURLEncoding.DecodeString(encoded)
+	if errDecode != nil || len(decoded) == 0 {
+		return "", false
+	}
+	return string(decoded), true
+}
+
+// boundTranscriptPayload keeps payload as raw JSON when it is valid and within limit; otherwise it
+// is stored as a (possibly truncated) JSON string.
+func boundTranscriptPayload(payload json.RawMessage, limit int) (json.RawMessage, bool) {
+	if len(payload) == 0 {
+		return nil, false
+	}
+	if len(payload) <= limit && json.Valid(payload) {
+		return payload, false
+	}
+	truncated := false
+	text := string(payload)
+	if len(text) > limit {
+		text = strings.ToValidUTF8(text[:limit], "")
+		truncated = true
+	}
+	encoded, errMarshal := json.Marshal(text)
+	if errMarshal != nil {
+		return nil, truncated
+	}
+	return encoded, truncated
+}
+
+func pruneSessionTranscripts(dir string, retention time.Duration, now time.Time) int {
+	entries, errRead := os.ReadDir(dir)
+	if errRead != nil {
+		return 0
+	}
+	cutoff := now.Add(-retention)
+	removed := 0
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), transcriptFileSuffix) {
+			continue
+		}
+		info, errInfo := entry.Info()
+		if errInfo != nil || info.ModTime().After(cutoff) {
+			continue
+		}
+		if errRemove := os.Remove(filepath.Join(dir, entry.Name())); errRemove == nil {
+			removed++
+		}
+	}
+	return removed
+}
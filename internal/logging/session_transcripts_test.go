@@ -0,0 +1,105 @@
+package logging
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func enableTestTranscripts(t *testing.T, logDir string, maxEntryBytes int) {
+	t.Helper()
+	store := defaultTranscriptStore
+	store.mu.Lock()
+	enabled, dir, retention, maxBytes, lastPrune := store.enabled, store.dir, store.retention, store.maxEntryBytes, store.lastPrune
+	store.enabled = true
+	store.dir = filepath.Join(logDir, SessionTranscriptsDirName)
+	store.retention = time.Hour
+	store.maxEntryBytes = maxEntryBytes
+	store.lastPrune = time.Now()
+	store.mu.Unlock()
+	t.Cleanup(func() {
+		store.mu.Lock()
+		store.enabled, store.dir, store.retention, store.maxEntryBytes, store.lastPrune = enabled, dir, retention, maxBytes, lastPrune
+		store.mu.Unlock()
+	})
+}
+
+func TestSessionTranscriptRoundTrip(t *testing.T) {
+	logDir := t.TempDir()
+	enableTestTranscripts(t, logDir, 64)
+	sessionID := "ws/session:1"
+
+	if err := AppendSessionTranscript(TranscriptEntry{SessionID: sessionID, Model: "gpt-5", Request: json.RawMessage(`{"type":"response.create"}`), Response: json.RawMessage(`{"id":"resp_1","output":[]}`)}); err != nil {
+		t.Fatalf("AppendSessionTranscript() error = %v", err)
+	}
+	large := json.RawMessage(`{"input":"` + string(make([]byte, 100)) + `"}`)
+	if err := AppendSessionTranscript(TranscriptEntry{SessionID: sessionID, Request: large, Error: "upstream closed"}); err != nil {
+		t.Fatalf("AppendSessionTranscript() error = %v", err)
+	}
+
+	summaries, err := ListSessionTranscripts(logDir)
+	if err != nil || len(summaries) != 1 || summaries[0].SessionID != sessionID {
+		t.Fatalf("ListSessionTranscripts() = %+v, %v", summaries, err)
+	}
+
+	entries, err := ReadSessionTranscript(logDir, sessionID)
+	if err != nil {
+		t.Fatalf("ReadSessionTranscript() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("entries = %d, want 2", len(entries))
+	}
+	if entries[0].Model != "gpt-5" || string(entries[0].Response) != `{"id":"resp_1","output":[]}` || entries[0].Truncated {
+		t.Fatalf("first entry = %+v", entries[0])
+	}
+	var truncated string
+	if !entries[1].Truncated || json.Unmarshal(entries[1].Request, &truncated) != nil || len(truncated) > 64 {
+		t.Fatalf("second entry = %+v", entries[1])
+	}
+
+	if err := DeleteSessionTranscript(logDir, sessionID); err != nil {
+		t.Fatalf("DeleteSessionTranscript() error = %v", err)
+	}
+	if _, err := ReadSessionTranscript(logDir, sessionID); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("ReadSessionTranscript() after delete error = %v, want not exist", err)
+	}
+}
+
+func TestAppendSessionTranscriptDisabled(t *testing.T) {
+	logDir := t.TempDir()
+	enableTestTranscripts(t, logDir, 1024)
+	defaultTranscriptStore.mu.Lock()
+	defaultTranscriptStore.enabled = false
+	defaultTranscriptStore.mu.Unlock()
+
+	if err := AppendSessionTranscript(TranscriptEntry{SessionID: "s1", Request: json.RawMessage(`{}`)}); err != nil {
+		t.Fatalf("AppendSessionTranscript() error = %v", err)
+	}
+	if summaries, _ := ListSessionTranscripts(logDir); len(summaries) != 0 {
+		t.Fatalf("summaries = %+v, want none while disabled", summaries)
+	}
+}
+
+func TestPruneSessionTranscripts(t *testing.T) {
+	dir := t.TempDir()
+	stale := filepath.Join(dir, transcriptFileName("old"))
+	fresh := filepath.Join(dir, transcriptFileName("new"))
+	for _, path := range []string{stale, fresh} {
+		if err := os.WriteFile(path, []byte("{}\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now()
+	if err := os.Chtimes(stale, now.Add(-2*time.Hour), now.Add(-2*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if removed := pruneSessionTranscripts(dir, time.Hour, now); removed != 1 {
+		t.Fatalf("removed = %d, want 1", removed)
+	}
+	if _, err := os.Stat(fresh); err != nil {
+		t.Fatalf("fresh transcript removed: %v", err)
+	}
+}
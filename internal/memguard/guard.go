@@ -0,0 +1,193 @@
+// Package memguard tracks the approximate memory held by in-flight requests and rejects new
+// requests while the process is above a configured memory watermark.
+package memguard
+
+import (
+	"runtime/metrics"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultRetryAfter = 5 * time.Second
+	// sampleInterval bounds how often process memory is read from the runtime.
+	sampleInterval = 250 * time.Millisecond
+	// payloadCopies approximates how many copies of a request body are alive while it is served:
+	// the raw body, the translated request and the upstream payload.
+	payloadCopies     = 3
+	rejectLogInterval = 10 * time.Second
+)
+
+// Usage is a snapshot of the guard's view of memory.
+type Usage struct {
+	Enabled            bool  `json:"enabled"`
+	ProcessBytes       int64 `json:"process_bytes"`
+	InflightBytes      int64 `json:"inflight_bytes"`
+	HighWatermarkBytes int64 `json:"high_watermark_bytes,omitempty"`
+	MaxInflightBytes   int64 `json:"max_inflight_bytes,omitempty"`
+	Rejected           int64 `json:"rejected"`
+}
+
+type guard struct {
+	mu            sync.RWMutex
+	enabled       bool
+	highWatermark int64
+	maxInflight   int64
+	retryAfter    time.Duration
+
+	inflight atomic.Int64
+	rejected atomic.Int64
+
+	sampleMu      sync.Mutex
+	sampledAt     time.Time
+	sampledBytes  int64
+	lastRejectLog time.Time
+}
+
+var active = &guard{}
+
+// readProcessBytes returns the memory the Go runtime holds from the OS. Tests replace it.
+var readProcessBytes = func() int64 {
+	samples := []metrics.Sample{
+		{Name: "/memory/classes/total:bytes"},
+		{Name: "/memory/classes/heap/released:bytes"},
+	}
+	metrics.Read(samples)
+	var total, released uint64
+	if samples[0].Value.Kind() == metrics.KindUint64 {
+		total = samples[0].Value.Uint64()
+	}
+	if samples[1].Value.Kind() == metrics.KindUint64 {
+		released = samples[1].Value.Uint64()
+	}
+	if released > total {
+		return 0
+	}
+	return int64(total - released)
+}
+
+var now = time.Now
+
+// Configure applies the memory-guard configuration. A nil cfg turns the guard off.
+func Configure(cfg *config.Config) {
+	g := active
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.enabled = cfg != nil && cfg.MemoryGuard.Enabled
+	if !g.enabled {
+		return
+	}
+	g.highWatermark = int64(max(cfg.MemoryGuard.HighWatermarkMB, 0)) << 20
+	g.maxInflight = int64(max(cfg.MemoryGuard.MaxInflightMB, 0)) << 20
+	g.retryAfter = defaultRetryAfter
+	if cfg.MemoryGuard.RetryAfterSeconds > 0 {
+		g.retryAfter = time.Duration(cfg.MemoryGuard.RetryAfterSeconds) * time.Second
+	}
+}
+
+// Enabled reports whether admission control is on.
+func Enabled() bool {
+	g := active
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.enabled
+}
+
+// Reservation is memory accounted to one request. Methods on a nil Reservation are no-ops.
+type Reservation struct {
+	bytes atomic.Int64
+}
+
+// Add accounts n more bytes to the reservation.
+func (r *Reservation) Add(n int64) {
+	if r == nil || n <= 0 {
+		return
+	}
+	r.bytes.Add(n)
+	active.inflight.Add(n)
+}
+
+// Release returns every byte held by the reservation. It is safe to call more than once.
+func (r *Reservation) Release() {
+	if r == nil {
+		return
+	}
+	if n := r.bytes.Swap(0); n != 0 {
+		active.inflight.Add(-n)
+	}
+}
+
+// Admit decides whether a new request carrying bodyBytes (negative when unknown) may start.
+// An admitted request receives a Reservation that must be released when it finishes; a rejected
+// one receives the Retry-After delay to report.
+func Admit(bodyBytes int64) (*Reservation, time.Duration, bool) {
+	g := active
+	g.mu.RLock()
+	enabled, highWatermark, maxInflight, retryAfter := g.enabled, g.highWatermark, g.maxInflight, g.retryAfter
+	g.mu.RUnlock()
+	if !enabled {
+		return nil, 0, true
+	}
+	expected := max(bodyBytes, 0) * payloadCopies
+	if highWatermark > 0 {
+		if processBytes := g.processBytes(); processBytes >= highWatermark {
+			g.reject("process memory %d MiB is above the %d MiB watermark", processBytes>>20, highWatermark>>20)
+			return nil, retryAfter, false
+		}
+	}
+	if maxInflight > 0 {
+		// A request is always admitted when nothing else is in flight, however large it is.
+		if inflight := g.inflight.Load(); inflight > 0 && inflight+expected > maxInflight {
+			g.reject("in-flight requests hold ~%d MiB, limit is %d MiB", inflight>>20, maxInflight>>20)
+			return nil, retryAfter, false
+		}
+	}
+	reservation := &Reservation{}
+	reservation.Add(expected)
+	return reservation, 0, true
+}
+
+// Snapshot returns the current memory usage as seen by the guard.
+func Snapshot() Usage {
+	g := active
+	g.mu.RLock()
+	usage := Usage{
+		Enabled:            g.enabled,
+		HighWatermarkBytes: g.highWatermark,
+		MaxInflightBytes:   g.maxInflight,
+	}
+	g.mu.RUnlock()
+	usage.ProcessBytes = g.processBytes()
+	usage.InflightBytes = g.inflight.Load()
+	usage.Rejected = g.rejected.Load()
+	return usage
+}
+
+func (g *guard) processBytes() int64 {
+	g.sampleMu.Lock()
+	defer g.sampleMu.Unlock()
+	current := now()
+	if g.sampledAt.IsZero() || current.Sub(g.sampledAt) >= sampleInterval {
+		g.sampledBytes = readProcessBytes()
+		g.sampledAt = current
+	}
+	return g.sampledBytes
+}
+
+func (g *guard) reject(format string, args ...any) {
+	g.rejected.Add(1)
+	g.sampleMu.Lock()
+	current := now()
+	shouldLog := current.Sub(g.lastRejectLog) >= rejectLogInterval
+	if shouldLog {
+		g.lastRejectLog = current
+	}
+	g.sampleMu.Unlock()
+	if shouldLog {
+		log.Warnf("memory guard: rejecting new requests: "+format, args...)
+	}
+}
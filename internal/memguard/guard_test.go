@@ -0,0 +1,99 @@
+package memguard
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/streamrelay"
+)
+
+func configureForTest(t *testing.T, guardCfg config.MemoryGuardConfig, processBytes int64) {
+	t.Helper()
+	previousRead, previousNow := readProcessBytes, now
+	readProcessBytes = func() int64 { return processBytes }
+	current := time.Unix(1_700_000_000, 0)
+	now = func() time.Time {
+		current = current.Add(time.Second)
+		return current
+	}
+	Configure(&config.Config{MemoryGuard: guardCfg})
+	t.Cleanup(func() {
+		readProcessBytes, now = previousRead, previousNow
+		Configure(nil)
+		active.inflight.Store(0)
+		active.rejected.Store(0)
+	})
+}
+
+func TestAdmitRejectsAboveHighWatermark(t *testing.T) {
+	configureForTest(t, config.MemoryGuardConfig{Enabled: true, HighWatermarkMB: 100, RetryAfterSeconds: 7}, 200<<20)
+
+	reservation, retryAfter, admitted := Admit(1024)
+	if admitted || reservation != nil {
+		t.Fatal("expected request to be rejected above the watermark")
+	}
+	if retryAfter != 7*time.Second {
+		t.Fatalf("retryAfter = %v, want 7s", retryAfter)
+	}
+	if usage := Snapshot(); usage.Rejected != 1 || usage.ProcessBytes != 200<<20 {
+		t.Fatalf("unexpected usage: %+v", usage)
+	}
+}
+
+func TestAdmitTracksInflightPayloads(t *testing.T) {
+	configureForTest(t, config.MemoryGuardConfig{Enabled: true, MaxInflightMB: 1}, 0)
+
+	first, _, admitted := Admit(400 << 10)
+	if !admitted {
+		t.Fatal("expected the first request to be admitted")
+	}
+	if got := Snapshot().InflightBytes; got != 400<<10*payloadCopies {
+		t.Fatalf("InflightBytes = %d, want %d", got, 400<<10*payloadCopies)
+	}
+	if _, _, admitted := Admit(1); admitted {
+		t.Fatal("expected a request to be rejected above max-inflight-mb")
+	}
+	first.Release()
+	first.Release()
+	if got := Snapshot().InflightBytes; got != 0 {
+		t.Fatalf("InflightBytes after release = %d, want 0", got)
+	}
+	if _, _, admitted := Admit(1); !admitted {
+		t.Fatal("expected a request to be admitted after release")
+	}
+}
+
+func TestAdmitDisabledAllowsEverything(t *testing.T) {
+	configureForTest(t, config.MemoryGuardConfig{HighWatermarkMB: 1}, 1<<30)
+
+	if reservation, _, admitted := Admit(1 << 30); !admitted || reservation != nil {
+		t.Fatal("expected a disabled guard to admit without a reservation")
+	}
+}
+
+func TestTrackStreamAccountsLargestChunkUntilClosed(t *testing.T) {
+	configureForTest(t, config.MemoryGuardConfig{Enabled: true}, 0)
+
+	in := make(chan []byte)
+	out := streamrelay.Relay(context.Background(), in, StreamObserver())
+	go func() {
+		in <- make([]byte, 10)
+		in <- make([]byte, 100)
+		in <- make([]byte, 50)
+		close(in)
+	}()
+	for i := 0; i < 3; i++ {
+		<-out
+	}
+	if got := Snapshot().InflightBytes; got != 100*streamBufferFactor {
+		t.Fatalf("InflightBytes = %d, want %d", got, 100*streamBufferFactor)
+	}
+	if _, ok := <-out; ok {
+		t.Fatal("expected output to close")
+	}
+	if got := Snapshot().InflightBytes; got != 0 {
+		t.Fatalf("InflightBytes after close = %d, want 0", got)
+	}
+}
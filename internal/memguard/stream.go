@@ -0,0 +1,50 @@
+package memguard
+
+import (
+	"io"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/streamrelay"
+)
+
+// streamBufferFactor approximates the buffers behind a stream from its largest chunk: line
+// scanners grow by doubling until the longest line fits.
+const streamBufferFactor = 2
+
+// StreamObserver accounts the buffers of a streaming response to the in-flight total until the
+// stream ends. It observes nothing while the guard is disabled.
+func StreamObserver() streamrelay.Observer {
+	if !Enabled() {
+		return streamrelay.Observer{}
+	}
+	reservation := &Reservation{}
+	var largest int64
+	return streamrelay.Observer{
+		OnChunk: func(chunk []byte) {
+			if size := int64(len(chunk)) * streamBufferFactor; size > largest {
+				reservation.Add(size - largest)
+				largest = size
+			}
+		},
+		OnClose: reservation.Release,
+	}
+}
+
+// TrackBody accounts bytes read from body to reservation. It is used for bodies of unknown
+// length, which Admit could not account up front.
+func TrackBody(body io.ReadCloser, reservation *Reservation) io.ReadCloser {
+	if body == nil || reservation == nil {
+		return body
+	}
+	return &trackedBody{ReadCloser: body, reservation: reservation}
+}
+
+type trackedBody struct {
+	io.ReadCloser
+	reservation *Reservation
+}
+
+func (b *trackedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.reservation.Add(int64(n) * payloadCopies)
+	return n, err
+}
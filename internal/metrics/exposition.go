@@ -0,0 +1,19 @@
+package metrics
+
+import (
+	"strconv"
+	"strings"
+)
+
+// labelEscaper escapes label values as the exposition format requires.
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// Label renders one name="value" label pair of the Prometheus text exposition format.
+func Label(name, value string) string {
+	return name + `="` + labelEscaper.Replace(value) + `"`
+}
+
+// FormatFloat renders a sample value or bucket bound of the Prometheus text exposition format.
+func FormatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
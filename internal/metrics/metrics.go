@@ -0,0 +1,281 @@
+// Package metrics aggregates usage records into Prometheus counters and histograms per
+// provider, model and auth ID and renders them in the Prometheus text exposition format.
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+const (
+	// maxSeries bounds the provider/model/auth combinations; further ones share an "other" series.
+	maxSeries = 5000
+	// recentRequests is how many request IDs are remembered to count retried attempts.
+	recentRequests = 8192
+)
+
+// buckets are the histogram upper bounds in seconds.
+var buckets = []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120, 300}
+
+type seriesKey struct {
+	provider, model, authID string
+}
+
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+func (h *histogram) observe(d time.Duration) {
+	if h.counts == nil {
+		h.counts = make([]uint64, len(buckets))
+	}
+	seconds := d.Seconds()
+	for i, bound := range buckets {
+		if seconds <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += seconds
+	h.count++
+}
+
+type series struct {
+	requests uint64
+	failures uint64
+	retries  uint64
+	errors   map[int]uint64
+	tokens   map[string]int64
+	latency  histogram
+	ttft     histogram
+}
+
+type failoverKey struct {
+	group, from, to string
+}
+
+type registry struct {
+	mu     sync.Mutex
+	series map[seriesKey]*series
+	// failovers counts region failovers by group and the regions involved.
+	failovers map[failoverKey]uint64
+	// seen counts attempts per request ID; ring evicts the oldest IDs.
+	seen     map[string]struct{}
+	ring     []string
+	ringNext int
+}
+
+var active = &registry{series: make(map[seriesKey]*series), failovers: make(map[failoverKey]uint64), seen: make(map[string]struct{})}
+
+func init() {
+	coreusage.RegisterPlugin(usagePlugin{})
+}
+
+type usagePlugin struct{}
+
+func (usagePlugin) HandleUsage(_ context.Context, record coreusage.Record) {
+	active.observe(record)
+}
+
+func (r *registry) observe(record coreusage.Record) {
+	key := seriesKey{
+		provider: valueOr(strings.TrimSpace(record.Provider), "unknown"),
+		model:    valueOr(strings.TrimSpace(record.Model), "unknown"),
+		authID:   valueOr(strings.TrimSpace(record.AuthID), "unknown"),
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.series[key]
+	if !ok {
+		if len(r.series) >= maxSeries {
+			key = seriesKey{provider: "other", model: "other", authID: "other"}
+			s = r.series[key]
+		}
+		if s == nil {
+			s = &series{errors: make(map[int]uint64), tokens: make(map[string]int64)}
+			r.series[key] = s
+		}
+	}
+	s.requests++
+	if r.retried(record.RequestID) {
+		s.retries++
+	}
+	if record.Failed {
+		s.failures++
+		s.errors[record.Fail.StatusCode]++
+	}
+	s.tokens["input"] += record.Detail.InputTokens
+	s.tokens["output"] += record.Detail.OutputTokens
+	s.tokens["reasoning"] += record.Detail.ReasoningTokens
+	s.tokens["cached"] += record.Detail.CachedTokens
+	s.latency.observe(record.Latency)
+	if record.TTFT > 0 {
+		s.ttft.observe(record.TTFT)
+	}
+}
+
+// RecordRegionFailover counts a move of a region failover group from one region to another.
+func RecordRegionFailover(group, from, to string) {
+	r := active
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failovers[failoverKey{group: group, from: from, to: to}]++
+}
+
+// retried reports whether requestID already produced an upstream attempt and remembers it.
+func (r *registry) retried(requestID string) bool {
+	if requestID == "" {
+		return false
+	}
+	if _, ok := r.seen[requestID]; ok {
+		return true
+	}
+	if len(r.ring) < recentRequests {
+		r.ring = append(r.ring, requestID)
+	} else {
+		delete(r.seen, r.ring[r.ringNext])
+		r.ring[r.ringNext] = requestID
+		r.ringNext = (r.ringNext + 1) % recentRequests
+	}
+	r.seen[requestID] = struct{}{}
+	return false
+}
+
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// WritePrometheus writes every metric in the Prometheus text exposition format.
+func WritePrometheus(w io.Writer) error {
+	r := active
+	r.mu.Lock()
+	keys := make([]seriesKey, 0, len(r.series))
+	snapshot := make(map[seriesKey]series, len(r.series))
+	for key, s := range r.series {
+		keys = append(keys, key)
+		copied := *s
+		copied.errors = make(map[int]uint64, len(s.errors))
+		for code, n := range s.errors {
+			copied.errors[code] = n
+		}
+		copied.tokens = make(map[string]int64, len(s.tokens))
+		for kind, n := range s.tokens {
+			copied.tokens[kind] = n
+		}
+		copied.latency.counts = append([]uint64(nil), s.latency.counts...)
+		copied.ttft.counts = append([]uint64(nil), s.ttft.counts...)
+		snapshot[key] = copied
+	}
+	failovers := make(map[failoverKey]uint64, len(r.failovers))
+	for key, n := range r.failovers {
+		failovers[key] = n
+	}
+	r.mu.Unlock()
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.provider != b.provider {
+			return a.provider < b.provider
+		}
+		if a.model != b.model {
+			return a.model < b.model
+		}
+		return a.authID < b.authID
+	})
+
+	var b strings.Builder
+	header := func(name, kind, help string) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+	}
+	header("cliproxy_requests_total", "counter", "Upstream request attempts.")
+	for _, key := range keys {
+		fmt.Fprintf(&b, "cliproxy_requests_total{%s} %d\n", labels(key), snapshot[key].requests)
+	}
+	header("cliproxy_request_failures_total", "counter", "Failed upstream request attempts.")
+	for _, key := range keys {
+		fmt.Fprintf(&b, "cliproxy_request_failures_total{%s} %d\n", labels(key), snapshot[key].failures)
+	}
+	header("cliproxy_request_errors_total", "counter", "Failed upstream request attempts by HTTP status (0 when none was received).")
+	for _, key := range keys {
+		s := snapshot[key]
+		codes := make([]int, 0, len(s.errors))
+		for code := range s.errors {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		for _, code := range codes {
+			fmt.Fprintf(&b, "cliproxy_request_errors_total{%s,status=\"%d\"} %d\n", labels(key), code, s.errors[code])
+		}
+	}
+	header("cliproxy_request_retries_total", "counter", "Upstream attempts after the first for the same proxy request.")
+	for _, key := range keys {
+		fmt.Fprintf(&b, "cliproxy_request_retries_total{%s} %d\n", labels(key), snapshot[key].retries)
+	}
+	header("cliproxy_tokens_total", "counter", "Tokens reported by upstream responses.")
+	for _, key := range keys {
+		s := snapshot[key]
+		for _, kind := range []string{"input", "output", "reasoning", "cached"} {
+			fmt.Fprintf(&b, "cliproxy_tokens_total{%s,type=\"%s\"} %d\n", labels(key), kind, s.tokens[kind])
+		}
+	}
+	header("cliproxy_request_duration_seconds", "histogram", "Upstream request latency.")
+	for _, key := range keys {
+		writeHistogram(&b, "cliproxy_request_duration_seconds", labels(key), snapshot[key].latency)
+	}
+	header("cliproxy_time_to_first_token_seconds", "histogram", "Time to the first streamed token.")
+	for _, key := range keys {
+		if s := snapshot[key]; s.ttft.count > 0 {
+			writeHistogram(&b, "cliproxy_time_to_first_token_seconds", labels(key), s.ttft)
+		}
+	}
+	if len(failovers) > 0 {
+		failoverKeys := make([]failoverKey, 0, len(failovers))
+		for key := range failovers {
+			failoverKeys = append(failoverKeys, key)
+		}
+		sort.Slice(failoverKeys, func(i, j int) bool {
+			a, b := failoverKeys[i], failoverKeys[j]
+			if a.group != b.group {
+				return a.group < b.group
+			}
+			if a.from != b.from {
+				return a.from < b.from
+			}
+			return a.to < b.to
+		})
+		header("cliproxy_region_failovers_total", "counter", "Moves of a region failover group to another region.")
+		for _, key := range failoverKeys {
+			fmt.Fprintf(&b, "cliproxy_region_failovers_total{%s,%s,%s} %d\n", Label("group", key.group), Label("from", key.from), Label("to", key.to), failovers[key])
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func writeHistogram(b *strings.Builder, name, labels string, h histogram) {
+	for i, bound := range buckets {
+		var n uint64
+		if i < len(h.counts) {
+			n = h.counts[i]
+		}
+		fmt.Fprintf(b, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, FormatFloat(bound), n)
+	}
+	fmt.Fprintf(b, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, h.count)
+	fmt.Fprintf(b, "%s_sum{%s} %s\n", name, labels, FormatFloat(h.sum))
+	fmt.Fprintf(b, "%s_count{%s} %d\n", name, labels, h.count)
+}
+
+func labels(key seriesKey) string {
+	return Label("provider", key.provider) + "," + Label("model", key.model) + "," + Label("auth_id", key.authID)
+}
@@ -0,0 +1,52 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+func TestWriteTextRendersSeries(t *testing.T) {
+	active.observe(coreusage.Record{
+		Provider: "claude", Model: "claude-sonnet-4", AuthID: "team\"a", RequestID: "req-1",
+		Latency: 1500 * time.Millisecond, TTFT: 200 * time.Millisecond,
+		Detail: coreusage.Detail{InputTokens: 10, OutputTokens: 5},
+	})
+	active.observe(coreusage.Record{
+		Provider: "claude", Model: "claude-sonnet-4", AuthID: "team\"a", RequestID: "req-1",
+		Latency: 3 * time.Second, Failed: true, Fail: coreusage.Failure{StatusCode: 429},
+	})
+	RecordRegionFailover("vertex", "us-central1", "us-east4")
+
+	var b strings.Builder
+	if err := WritePrometheus(&b); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	labels := `provider="claude",model="claude-sonnet-4",auth_id="team\"a"`
+	for _, want := range []string{
+		"# TYPE cliproxy_requests_total counter",
+		"cliproxy_requests_total{" + labels + "} 2\n",
+		"cliproxy_request_failures_total{" + labels + "} 1\n",
+		"cliproxy_request_errors_total{" + labels + `,status="429"} 1` + "\n",
+		"cliproxy_request_retries_total{" + labels + "} 1\n",
+		"cliproxy_tokens_total{" + labels + `,type="input"} 10` + "\n",
+		"cliproxy_request_duration_seconds_bucket{" + labels + `,le="2.5"} 1` + "\n",
+		"cliproxy_request_duration_seconds_bucket{" + labels + `,le="+Inf"} 2` + "\n",
+		"cliproxy_request_duration_seconds_sum{" + labels + "} 4.5\n",
+		"cliproxy_time_to_first_token_seconds_count{" + labels + "} 1\n",
+		`cliproxy_region_failovers_total{group="vertex",from="us-central1",to="us-east4"} 1` + "\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q in:\n%s", want, out)
+		}
+	}
+}
+
+func TestLabelEscapesValue(t *testing.T) {
+	if got, want := Label("model", "a\"b\\c\nd"), `model="a\"b\\c\nd"`; got != want {
+		t.Fatalf("Label() = %s, want %s", got, want)
+	}
+}
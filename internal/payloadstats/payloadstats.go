@@ -0,0 +1,225 @@
+// Package payloadstats keeps per-model histograms of request and response payload sizes and output
+// token counts, so buffer sizes and memory watermarks can be chosen from observed traffic.
+package payloadstats
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/metrics"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/streamrelay"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+// maxModels bounds the number of tracked models; further models are folded into otherModel so
+// clients sending arbitrary model names cannot grow the registry without limit.
+const maxModels = 256
+
+const otherModel = "other"
+
+// Metric names, also used as the Prometheus metric suffixes.
+const (
+	MetricRequestBytes     = "request_bytes"
+	MetricResponseBytes    = "response_bytes"
+	MetricStreamChunkBytes = "stream_chunk_bytes"
+	MetricOutputTokens     = "output_tokens"
+)
+
+var (
+	// byteBuckets are the upper bounds of the size histograms, 1 KiB to 64 MiB.
+	byteBuckets = []float64{1 << 10, 4 << 10, 16 << 10, 64 << 10, 256 << 10, 1 << 20, 4 << 20, 16 << 20, 64 << 20}
+	// tokenBuckets are the upper bounds of the output-token histogram.
+	tokenBuckets = []float64{64, 256, 1024, 4096, 16384, 65536, 262144}
+)
+
+// metricBuckets returns the bucket bounds of a metric.
+func metricBuckets(metric string) []float64 {
+	if metric == MetricOutputTokens {
+		return tokenBuckets
+	}
+	return byteBuckets
+}
+
+// Bucket is one cumulative histogram bucket: Count observations were at most LE.
+type Bucket struct {
+	LE    float64 `json:"le"`
+	Count uint64  `json:"count"`
+}
+
+// Histogram is a snapshot of one metric for one model.
+type Histogram struct {
+	Count   uint64   `json:"count"`
+	Sum     float64  `json:"sum"`
+	Max     float64  `json:"max"`
+	Buckets []Bucket `json:"buckets"`
+}
+
+// ModelStats holds the histograms of one model, keyed by metric name.
+type ModelStats struct {
+	Model   string               `json:"model"`
+	Metrics map[string]Histogram `json:"metrics"`
+}
+
+type histogram struct {
+	bounds []float64
+	counts []uint64 // per bucket, not cumulative; the last entry counts values above every bound
+	count  uint64
+	sum    float64
+	max    float64
+}
+
+func (h *histogram) observe(value float64) {
+	idx := sort.SearchFloat64s(h.bounds, value)
+	h.counts[idx]++
+	h.count++
+	h.sum += value
+	h.max = math.Max(h.max, value)
+}
+
+func (h *histogram) snapshot() Histogram {
+	out := Histogram{Count: h.count, Sum: h.sum, Max: h.max, Buckets: make([]Bucket, len(h.bounds))}
+	var cumulative uint64
+	for i, bound := range h.bounds {
+		cumulative += h.counts[i]
+		out.Buckets[i] = Bucket{LE: bound, Count: cumulative}
+	}
+	return out
+}
+
+type registry struct {
+	mu     sync.Mutex
+	models map[string]map[string]*histogram
+}
+
+var active = &registry{models: make(map[string]map[string]*histogram)}
+
+func init() {
+	coreusage.RegisterPlugin(usagePlugin{})
+}
+
+// usagePlugin records the output tokens of every usage record.
+type usagePlugin struct{}
+
+func (usagePlugin) HandleUsage(_ context.Context, record coreusage.Record) {
+	if record.Failed || record.Detail.OutputTokens <= 0 {
+		return
+	}
+	model := record.Alias
+	if model == "" {
+		model = record.Model
+	}
+	Observe(model, MetricOutputTokens, float64(record.Detail.OutputTokens))
+}
+
+// Observe records value in the metric histogram of model.
+func Observe(model, metric string, value float64) {
+	model = strings.TrimSpace(model)
+	if model == "" {
+		model = "unknown"
+	}
+	r := active
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	metrics, ok := r.models[model]
+	if !ok {
+		if len(r.models) >= maxModels {
+			model = otherModel
+			metrics = r.models[model]
+		}
+		if metrics == nil {
+			metrics = make(map[string]*histogram)
+			r.models[model] = metrics
+		}
+	}
+	h, ok := metrics[metric]
+	if !ok {
+		bounds := metricBuckets(metric)
+		h = &histogram{bounds: bounds, counts: make([]uint64, len(bounds)+1)}
+		metrics[metric] = h
+	}
+	h.observe(value)
+}
+
+// ObserveRequest records the size of a request payload sent to model.
+func ObserveRequest(model string, payload []byte) {
+	Observe(model, MetricRequestBytes, float64(len(payload)))
+}
+
+// ObserveResponse records the size of a non-streaming response from model.
+func ObserveResponse(model string, payload []byte) {
+	Observe(model, MetricResponseBytes, float64(len(payload)))
+}
+
+// StreamObserver records the total size of a stream and its largest chunk, which bounds the line
+// buffer a scanner needs, once the stream ends.
+func StreamObserver(model string) streamrelay.Observer {
+	var total, largest int
+	return streamrelay.Observer{
+		OnChunk: func(chunk []byte) {
+			total += len(chunk)
+			largest = max(largest, len(chunk))
+		},
+		OnClose: func() {
+			Observe(model, MetricResponseBytes, float64(total))
+			Observe(model, MetricStreamChunkBytes, float64(largest))
+		},
+	}
+}
+
+// Snapshot returns the histograms of every model, sorted by model name.
+func Snapshot() []ModelStats {
+	r := active
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]ModelStats, 0, len(r.models))
+	for model, histograms := range r.models {
+		stats := ModelStats{Model: model, Metrics: make(map[string]Histogram, len(histograms))}
+		for name, h := range histograms {
+			stats.Metrics[name] = h.snapshot()
+		}
+		out = append(out, stats)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Model < out[j].Model })
+	return out
+}
+
+// WritePrometheus writes the histograms in the Prometheus text exposition format, as
+// cliproxy_<metric> histograms labelled by model.
+func WritePrometheus(w io.Writer) error {
+	snapshot := Snapshot()
+	for _, metric := range []string{MetricRequestBytes, MetricResponseBytes, MetricStreamChunkBytes, MetricOutputTokens} {
+		name := "cliproxy_" + metric
+		if _, err := fmt.Fprintf(w, "# TYPE %s histogram\n", name); err != nil {
+			return err
+		}
+		for _, stats := range snapshot {
+			h, ok := stats.Metrics[metric]
+			if !ok {
+				continue
+			}
+			label := metrics.Label("model", stats.Model)
+			for _, bucket := range h.Buckets {
+				if _, err := fmt.Fprintf(w, "%s_bucket{%s,le=\"%s\"} %d\n", name, label, metrics.FormatFloat(bucket.LE), bucket.Count); err != nil {
+					return err
+				}
+			}
+			if _, err := fmt.Fprintf(w, "%s_bucket{%s,le=\"+Inf\"} %d\n%s_sum{%s} %s\n%s_count{%s} %d\n",
+				name, label, h.Count, name, label, metrics.FormatFloat(h.Sum), name, label, h.Count); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// reset clears every histogram; it is used by tests.
+func reset() {
+	active.mu.Lock()
+	active.models = make(map[string]map[string]*histogram)
+	active.mu.Unlock()
+}
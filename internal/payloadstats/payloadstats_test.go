@@ -0,0 +1,118 @@
+package payloadstats
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/streamrelay"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+func findModel(t *testing.T, model string) ModelStats {
+	t.Helper()
+	for _, stats := range Snapshot() {
+		if stats.Model == model {
+			return stats
+		}
+	}
+	t.Fatalf("model %q not in snapshot", model)
+	return ModelStats{}
+}
+
+func TestObserveBucketsAreCumulative(t *testing.T) {
+	reset()
+	t.Cleanup(reset)
+
+	ObserveRequest("gpt-5", make([]byte, 100))
+	ObserveRequest("gpt-5", make([]byte, 5000))
+	ObserveRequest("gpt-5", make([]byte, 100<<20))
+
+	h := findModel(t, "gpt-5").Metrics[MetricRequestBytes]
+	if h.Count != 3 || h.Max != 100<<20 {
+		t.Fatalf("count=%d max=%v, want 3 and %d", h.Count, h.Max, 100<<20)
+	}
+	if h.Buckets[0].LE != 1<<10 || h.Buckets[0].Count != 1 {
+		t.Fatalf("first bucket = %+v, want le=1024 count=1", h.Buckets[0])
+	}
+	if h.Buckets[2].Count != 2 {
+		t.Fatalf("16KiB bucket count = %d, want 2", h.Buckets[2].Count)
+	}
+	if last := h.Buckets[len(h.Buckets)-1]; last.Count != 2 {
+		t.Fatalf("64MiB bucket count = %d, want 2 (the 100MiB payload is only in +Inf)", last.Count)
+	}
+}
+
+func TestTrackStreamRecordsTotalAndLargestChunk(t *testing.T) {
+	reset()
+	t.Cleanup(reset)
+
+	in := make(chan []byte, 3)
+	in <- make([]byte, 10)
+	in <- make([]byte, 3000)
+	in <- make([]byte, 20)
+	close(in)
+	for range streamrelay.Relay(context.Background(), in, StreamObserver("claude-sonnet")) {
+	}
+
+	stats := findModel(t, "claude-sonnet")
+	if got := stats.Metrics[MetricResponseBytes].Sum; got != 3030 {
+		t.Fatalf("response bytes = %v, want 3030", got)
+	}
+	if got := stats.Metrics[MetricStreamChunkBytes].Max; got != 3000 {
+		t.Fatalf("largest chunk = %v, want 3000", got)
+	}
+}
+
+func TestUsagePluginRecordsOutputTokens(t *testing.T) {
+	reset()
+	t.Cleanup(reset)
+
+	plugin := usagePlugin{}
+	plugin.HandleUsage(context.Background(), coreusage.Record{Model: "upstream", Alias: "alias", Detail: coreusage.Detail{OutputTokens: 500}})
+	plugin.HandleUsage(context.Background(), coreusage.Record{Model: "upstream", Failed: true, Detail: coreusage.Detail{OutputTokens: 500}})
+
+	h := findModel(t, "alias").Metrics[MetricOutputTokens]
+	if h.Count != 1 || h.Sum != 500 {
+		t.Fatalf("output tokens count=%d sum=%v, want 1 and 500", h.Count, h.Sum)
+	}
+}
+
+func TestObserveFoldsExcessModels(t *testing.T) {
+	reset()
+	t.Cleanup(reset)
+
+	for i := 0; i < maxModels+10; i++ {
+		ObserveRequest(fmt.Sprintf("model-%d", i), nil)
+	}
+	if got := len(Snapshot()); got != maxModels+1 {
+		t.Fatalf("tracked models = %d, want %d", got, maxModels+1)
+	}
+	if got := findModel(t, otherModel).Metrics[MetricRequestBytes].Count; got != 10 {
+		t.Fatalf("other count = %d, want 10", got)
+	}
+}
+
+func TestWritePrometheus(t *testing.T) {
+	reset()
+	t.Cleanup(reset)
+
+	ObserveResponse(`we"ird`, make([]byte, 2048))
+	var out strings.Builder
+	if err := WritePrometheus(&out); err != nil {
+		t.Fatalf("WritePrometheus: %v", err)
+	}
+	text := out.String()
+	for _, want := range []string{
+		"# TYPE cliproxy_response_bytes histogram\n",
+		`cliproxy_response_bytes_bucket{model="we\"ird",le="1024"} 0`,
+		`cliproxy_response_bytes_bucket{model="we\"ird",le="4096"} 1`,
+		`cliproxy_response_bytes_bucket{model="we\"ird",le="+Inf"} 1`,
+		`cliproxy_response_bytes_sum{model="we\"ird"} 2048`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Fatalf("output missing %q:\n%s", want, text)
+		}
+	}
+}
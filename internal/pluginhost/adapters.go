@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
 	"net/url"
 	"reflect"
@@ -1829,6 +1830,7 @@ func (a *usageAdapter) HandleUsage(ctx context.Context, record coreusage.Record)
 		Source:          record.Source,
 		ReasoningEffort: record.ReasoningEffort,
 		ServiceTier:     record.ServiceTier,
+		Tags:            maps.Clone(record.Tags),
 		RequestedAt:     record.RequestedAt,
 		Latency:         record.Latency,
 		TTFT:            record.TTFT,
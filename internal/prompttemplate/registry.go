@@ -0,0 +1,226 @@
+// Package prompttemplate renders named prompt templates, defined inline in the config or as files
+// in a templates directory, into chat completion messages.
+package prompttemplate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// rescanInterval bounds how often the templates directory is checked for changes.
+const rescanInterval = 2 * time.Second
+
+// ErrNotFound is returned for a template name that is not defined.
+var ErrNotFound = errors.New("prompt template not found")
+
+// Message is one rendered chat message.
+type Message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// Rendered is a template rendered with request variables.
+type Rendered struct {
+	// Model is the template's default model; empty when the template does not set one.
+	Model    string
+	Messages []Message
+}
+
+// Summary describes an available template.
+type Summary struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Model       string `json:"model,omitempty"`
+	Source      string `json:"source"`
+}
+
+type fileEntry struct {
+	modTime  time.Time
+	size     int64
+	template config.PromptTemplate
+	err      error
+}
+
+// directory caches the templates of one directory and reloads changed files.
+type directory struct {
+	mu        sync.Mutex
+	path      string
+	scannedAt time.Time
+	files     map[string]fileEntry
+	byName    map[string]config.PromptTemplate
+}
+
+var templatesDir = &directory{}
+
+var now = time.Now
+
+// Render renders template name from cfg with variables layered over the template defaults.
+func Render(cfg *config.SDKConfig, name string, variables map[string]any) (Rendered, error) {
+	tmpl, ok := lookup(cfg, name)
+	if !ok {
+		return Rendered{}, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	data := make(map[string]any, len(tmpl.Defaults)+len(variables))
+	for key, value := range tmpl.Defaults {
+		data[key] = value
+	}
+	for key, value := range variables {
+		data[key] = value
+	}
+
+	rendered := Rendered{Model: strings.TrimSpace(tmpl.Model), Messages: make([]Message, 0, len(tmpl.Messages))}
+	for i, message := range tmpl.Messages {
+		parsed, errParse := template.New(fmt.Sprintf("%s#%d", tmpl.Name, i)).Option("missingkey=error").Parse(message.Content)
+		if errParse != nil {
+			return Rendered{}, fmt.Errorf("prompt template %s: message %d: %w", tmpl.Name, i+1, errParse)
+		}
+		var content strings.Builder
+		if errExecute := parsed.Execute(&content, data); errExecute != nil {
+			return Rendered{}, fmt.Errorf("prompt template %s: message %d: %w", tmpl.Name, i+1, errExecute)
+		}
+		role := strings.TrimSpace(message.Role)
+		if role == "" {
+			role = "user"
+		}
+		rendered.Messages = append(rendered.Messages, Message{Role: role, Content: content.String()})
+	}
+	if len(rendered.Messages) == 0 {
+		return Rendered{}, fmt.Errorf("prompt template %s has no messages", tmpl.Name)
+	}
+	return rendered, nil
+}
+
+// List returns the available templates sorted by name. Inline templates hide directory templates
+// with the same name.
+func List(cfg *config.SDKConfig) []Summary {
+	seen := make(map[string]bool)
+	var summaries []Summary
+	if cfg != nil {
+		for _, tmpl := range cfg.PromptTemplates.Templates {
+			key := strings.ToLower(strings.TrimSpace(tmpl.Name))
+			if key == "" || seen[key] {
+				continue
+			}
+			seen[key] = true
+			summaries = append(summaries, Summary{Name: tmpl.Name, Description: tmpl.Description, Model: tmpl.Model, Source: "config"})
+		}
+		for key, tmpl := range templatesDir.templates(cfg.PromptTemplates.Dir) {
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			summaries = append(summaries, Summary{Name: tmpl.Name, Description: tmpl.Description, Model: tmpl.Model, Source: "dir"})
+		}
+	}
+	sort.Slice(summaries, func(i, j int) bool { return summaries[i].Name < summaries[j].Name })
+	return summaries
+}
+
+func lookup(cfg *config.SDKConfig, name string) (config.PromptTemplate, bool) {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if cfg == nil || key == "" {
+		return config.PromptTemplate{}, false
+	}
+	for _, tmpl := range cfg.PromptTemplates.Templates {
+		if strings.ToLower(strings.TrimSpace(tmpl.Name)) == key {
+			return tmpl, true
+		}
+	}
+	tmpl, ok := templatesDir.templates(cfg.PromptTemplates.Dir)[key]
+	return tmpl, ok
+}
+
+// templates returns the templates of dir keyed by lower-cased name, rescanning the directory
+// when rescanInterval has passed or dir changed.
+func (d *directory) templates(dir string) map[string]config.PromptTemplate {
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		return nil
+	}
+	if strings.HasPrefix(dir, "~") {
+		if home, errHome := os.UserHomeDir(); errHome == nil {
+			dir = filepath.Join(home, strings.TrimLeft(strings.TrimPrefix(dir, "~"), "/\\"))
+		}
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	current := now()
+	if dir == d.path && !d.scannedAt.IsZero() && current.Sub(d.scannedAt) < rescanInterval {
+		return d.byName
+	}
+	if dir != d.path {
+		d.path = dir
+		d.files = nil
+	}
+	d.scannedAt = current
+	d.scan()
+	return d.byName
+}
+
+func (d *directory) scan() {
+	entries, errRead := os.ReadDir(d.path)
+	if errRead != nil {
+		if d.files != nil || !errors.Is(errRead, os.ErrNotExist) {
+			log.Warnf("prompt templates: read %s: %v", d.path, errRead)
+		}
+		d.files, d.byName = nil, nil
+		return
+	}
+	files := make(map[string]fileEntry, len(entries))
+	byName := make(map[string]config.PromptTemplate, len(entries))
+	for _, entry := range entries {
+		ext := strings.ToLower(filepath.Ext(entry.Name()))
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			continue
+		}
+		path := filepath.Join(d.path, entry.Name())
+		info, errInfo := entry.Info()
+		if errInfo != nil {
+			continue
+		}
+		cached, ok := d.files[path]
+		if !ok || !cached.modTime.Equal(info.ModTime()) || cached.size != info.Size() {
+			cached = loadFile(path, strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name())))
+			cached.modTime, cached.size = info.ModTime(), info.Size()
+			if cached.err != nil {
+				log.Warnf("prompt templates: %v", cached.err)
+			} else {
+				log.Debugf("prompt templates: loaded %s from %s", cached.template.Name, path)
+			}
+		}
+		files[path] = cached
+		if cached.err == nil {
+			byName[strings.ToLower(cached.template.Name)] = cached.template
+		}
+	}
+	d.files, d.byName = files, byName
+}
+
+func loadFile(path, defaultName string) fileEntry {
+	data, errRead := os.ReadFile(path)
+	if errRead != nil {
+		return fileEntry{err: fmt.Errorf("read %s: %w", path, errRead)}
+	}
+	var tmpl config.PromptTemplate
+	if errUnmarshal := yaml.Unmarshal(data, &tmpl); errUnmarshal != nil {
+		return fileEntry{err: fmt.Errorf("parse %s: %w", path, errUnmarshal)}
+	}
+	if tmpl.Name = strings.TrimSpace(tmpl.Name); tmpl.Name == "" {
+		tmpl.Name = defaultName
+	}
+	if len(tmpl.Messages) == 0 {
+		return fileEntry{err: fmt.Errorf("%s defines no messages", path)}
+	}
+	return fileEntry{template: tmpl}
+}
@@ -0,0 +1,80 @@
+package prompttemplate
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestRenderInlineTemplate(t *testing.T) {
+	cfg := &config.SDKConfig{PromptTemplates: config.PromptTemplatesConfig{Templates: []config.PromptTemplate{{
+		Name:     "Code-Review",
+		Model:    "gpt-5",
+		Defaults: map[string]string{"focus": "correctness"},
+		Messages: []config.PromptTemplateMessage{
+			{Role: "system", Content: "Review {{.language}} for {{.focus}}."},
+			{Content: "{{.code}}"},
+		},
+	}}}}
+
+	rendered, err := Render(cfg, "code-review", map[string]any{"language": "Go", "code": "x := 1"})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if rendered.Model != "gpt-5" || len(rendered.Messages) != 2 {
+		t.Fatalf("rendered = %+v", rendered)
+	}
+	if got := rendered.Messages[0]; got.Role != "system" || got.Content != "Review Go for correctness." {
+		t.Fatalf("system message = %+v", got)
+	}
+	if got := rendered.Messages[1]; got.Role != "user" || got.Content != "x := 1" {
+		t.Fatalf("user message = %+v", got)
+	}
+
+	if _, err = Render(cfg, "code-review", map[string]any{"language": "Go"}); err == nil {
+		t.Fatal("expected error for a missing variable")
+	}
+	if _, err = Render(cfg, "unknown", nil); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Render(unknown) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestDirectoryTemplatesReload(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "greet.yaml")
+	if err := os.WriteFile(path, []byte("messages:\n  - content: \"Hello {{.name}}\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "broken.json"), []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	current := time.Unix(1_700_000_000, 0)
+	now = func() time.Time { return current }
+	defer func() { now = time.Now }()
+	cfg := &config.SDKConfig{PromptTemplates: config.PromptTemplatesConfig{Dir: dir}}
+
+	rendered, err := Render(cfg, "greet", map[string]any{"name": "team"})
+	if err != nil || rendered.Messages[0].Content != "Hello team" {
+		t.Fatalf("Render() = %+v, %v", rendered, err)
+	}
+
+	if err = os.WriteFile(path, []byte("name: greet\nmessages:\n  - content: \"Hi again {{.name}}\"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Chtimes(path, current.Add(time.Minute), current.Add(time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	current = current.Add(rescanInterval)
+	if rendered, err = Render(cfg, "greet", map[string]any{"name": "team"}); err != nil || rendered.Messages[0].Content != "Hi again team" {
+		t.Fatalf("Render() after change = %+v, %v", rendered, err)
+	}
+
+	summaries := List(cfg)
+	if len(summaries) != 1 || summaries[0].Name != "greet" || summaries[0].Source != "dir" {
+		t.Fatalf("List() = %+v, want only the valid directory template", summaries)
+	}
+}
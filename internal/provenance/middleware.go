@@ -0,0 +1,169 @@
+package provenance
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"hash"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxBufferedBody is the largest response signed in headers; larger responses, streams and
+// flushed responses are passed through and signed in trailers.
+const maxBufferedBody = 8 << 20
+
+// ServingFunc reports the provider and auth ID that served the request behind c.
+type ServingFunc func(c *gin.Context) (provider string, authID string)
+
+// Middleware signs the responses of requests accepted by include while signing is configured.
+func Middleware(include func(*http.Request) bool, serving ServingFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		signer := Active()
+		if signer == nil || (include != nil && !include(c.Request)) || isUpgrade(c.Request) {
+			c.Next()
+			return
+		}
+		writer := &signingWriter{ResponseWriter: c.Writer, digest: sha256.New(), status: http.StatusOK}
+		c.Writer = writer
+		c.Next()
+		c.Writer = writer.ResponseWriter
+
+		var provider, authID string
+		if serving != nil {
+			provider, authID = serving(c)
+		}
+		fields := Fields{
+			Timestamp:     time.Now().Unix(),
+			Method:        c.Request.Method,
+			Path:          c.Request.URL.Path,
+			Status:        writer.status,
+			Provider:      provider,
+			AuthHash:      AuthHash(authID),
+			ContentSHA256: hex.EncodeToString(writer.digest.Sum(nil)),
+		}
+		signature := signer.Sign(fields)
+		values := map[string]string{
+			HeaderSignature:     base64.StdEncoding.EncodeToString(signature),
+			HeaderKeyID:         signer.KeyID(),
+			HeaderTimestamp:     strconv.FormatInt(fields.Timestamp, 10),
+			HeaderProvider:      fields.Provider,
+			HeaderAuthHash:      fields.AuthHash,
+			HeaderContentSHA256: fields.ContentSHA256,
+		}
+		header := writer.ResponseWriter.Header()
+		for name, value := range values {
+			if value != "" {
+				header.Set(name, value)
+			}
+		}
+		if writer.streaming {
+			// Values set after the body are sent as the trailers declared when streaming began.
+			return
+		}
+		header.Del("Content-Length")
+		writer.ResponseWriter.WriteHeader(writer.status)
+		_, _ = writer.ResponseWriter.Write(writer.body.Bytes())
+	}
+}
+
+func isUpgrade(req *http.Request) bool {
+	return req != nil && strings.Contains(strings.ToLower(req.Header.Get("Connection")), "upgrade")
+}
+
+// signingWriter hashes the response body. Bodies are held back so the signature can go in the
+// headers, until the handler flushes, starts an event stream or exceeds maxBufferedBody.
+type signingWriter struct {
+	gin.ResponseWriter
+	digest    hash.Hash
+	body      bytes.Buffer
+	status    int
+	written   bool
+	size      int
+	streaming bool
+}
+
+func (w *signingWriter) WriteHeader(code int) {
+	if code > 0 && !w.written {
+		w.status = code
+	}
+}
+
+func (w *signingWriter) WriteHeaderNow() {
+	w.written = true
+	if w.streaming {
+		w.ResponseWriter.WriteHeaderNow()
+	}
+}
+
+func (w *signingWriter) Write(data []byte) (int, error) {
+	w.written = true
+	if !w.streaming && (isEventStream(w.Header()) || w.body.Len()+len(data) > maxBufferedBody) {
+		w.startStreaming()
+	}
+	w.digest.Write(data)
+	w.size += len(data)
+	if w.streaming {
+		return w.ResponseWriter.Write(data)
+	}
+	return w.body.Write(data)
+}
+
+func (w *signingWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *signingWriter) Flush() {
+	w.startStreaming()
+	w.ResponseWriter.Flush()
+}
+
+func (w *signingWriter) Status() int {
+	return w.status
+}
+
+func (w *signingWriter) Size() int {
+	if !w.written {
+		return -1
+	}
+	return w.size
+}
+
+func (w *signingWriter) Written() bool {
+	return w.written
+}
+
+func (w *signingWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.startStreaming()
+	return w.ResponseWriter.Hijack()
+}
+
+// startStreaming declares the provenance trailers and sends the held-back response.
+func (w *signingWriter) startStreaming() {
+	if w.streaming {
+		return
+	}
+	w.streaming = true
+	header := w.ResponseWriter.Header()
+	header.Del("Content-Length")
+	for _, name := range headerNames {
+		header.Add("Trailer", name)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	w.ResponseWriter.WriteHeaderNow()
+	if w.body.Len() > 0 {
+		_, _ = w.ResponseWriter.Write(w.body.Bytes())
+		w.body.Reset()
+	}
+}
+
+func isEventStream(header http.Header) bool {
+	return strings.HasPrefix(strings.ToLower(header.Get("Content-Type")), "text/event-stream")
+}
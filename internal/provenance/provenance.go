@@ -0,0 +1,203 @@
+// Package provenance signs proxy response bodies with Ed25519 so downstream consumers can verify
+// which proxy and provider produced an output.
+//
+// A signed response carries these headers (trailers for streamed responses):
+//
+//	X-CLIProxy-Signature            base64 Ed25519 signature
+//	X-CLIProxy-Signature-Key-ID     identifies the signing key
+//	X-CLIProxy-Signature-Timestamp  unix seconds
+//	X-CLIProxy-Provider             provider of the auth that served the request
+//	X-CLIProxy-Auth-Hash            truncated SHA-256 of the serving auth ID
+//	X-CLIProxy-Content-SHA256       hex SHA-256 of the response body
+//
+// The signature covers the newline-joined fields of Message.
+package provenance
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// Response headers carrying the provenance of a response.
+const (
+	HeaderSignature      = "X-CLIProxy-Signature"
+	HeaderKeyID          = "X-CLIProxy-Signature-Key-ID"
+	HeaderTimestamp      = "X-CLIProxy-Signature-Timestamp"
+	HeaderProvider       = "X-CLIProxy-Provider"
+	HeaderAuthHash       = "X-CLIProxy-Auth-Hash"
+	HeaderContentSHA256  = "X-CLIProxy-Content-SHA256"
+	messageFormatVersion = "cliproxy-response-signature-v1"
+)
+
+var headerNames = []string{HeaderSignature, HeaderKeyID, HeaderTimestamp, HeaderProvider, HeaderAuthHash, HeaderContentSHA256}
+
+// Signer signs responses with one Ed25519 key.
+type Signer struct {
+	key   ed25519.PrivateKey
+	keyID string
+}
+
+var active atomic.Pointer[Signer]
+
+// Configure applies the response-signing configuration. A nil cfg or a disabled section turns
+// signing off; when the key cannot be loaded signing stays off and the error is returned.
+func Configure(cfg *config.Config) error {
+	if cfg == nil || !cfg.ResponseSigning.Enable {
+		active.Store(nil)
+		return nil
+	}
+	signer, errSigner := NewSigner(cfg.ResponseSigning)
+	if errSigner != nil {
+		active.Store(nil)
+		return errSigner
+	}
+	active.Store(signer)
+	log.Infof("response signing enabled with key %s", signer.KeyID())
+	return nil
+}
+
+// Active returns the configured signer, or nil when signing is off.
+func Active() *Signer {
+	return active.Load()
+}
+
+// NewSigner loads the key named by settings, generating it when the file does not exist.
+func NewSigner(settings config.ResponseSigningConfig) (*Signer, error) {
+	path := strings.TrimSpace(settings.PrivateKeyFile)
+	var key ed25519.PrivateKey
+	var errKey error
+	if path == "" {
+		_, key, errKey = ed25519.GenerateKey(rand.Reader)
+		if errKey == nil {
+			log.Warn("response-signing.private-key-file is not set; signing with an ephemeral key")
+		}
+	} else {
+		key, errKey = loadOrCreateKey(path)
+	}
+	if errKey != nil {
+		return nil, errKey
+	}
+	keyID := strings.TrimSpace(settings.KeyID)
+	if keyID == "" {
+		keyID = Fingerprint(key.Public().(ed25519.PublicKey))
+	}
+	return &Signer{key: key, keyID: keyID}, nil
+}
+
+// KeyID identifies the signing key.
+func (s *Signer) KeyID() string {
+	return s.keyID
+}
+
+// PublicKey returns the key verifying this signer's signatures.
+func (s *Signer) PublicKey() ed25519.PublicKey {
+	return s.key.Public().(ed25519.PublicKey)
+}
+
+// Sign signs the message built from fields.
+func (s *Signer) Sign(fields Fields) []byte {
+	return ed25519.Sign(s.key, Message(s.keyID, fields))
+}
+
+// Fields are the signed attributes of one response.
+type Fields struct {
+	Timestamp     int64
+	Method        string
+	Path          string
+	Status        int
+	Provider      string
+	AuthHash      string
+	ContentSHA256 string
+}
+
+// Message returns the bytes signed for a response.
+func Message(keyID string, fields Fields) []byte {
+	return []byte(strings.Join([]string{
+		messageFormatVersion,
+		keyID,
+		strconv.FormatInt(fields.Timestamp, 10),
+		fields.Method,
+		fields.Path,
+		strconv.Itoa(fields.Status),
+		fields.Provider,
+		fields.AuthHash,
+		fields.ContentSHA256,
+	}, "\n"))
+}
+
+// Verify reports whether signature is valid for fields under publicKey.
+func Verify(publicKey ed25519.PublicKey, keyID string, fields Fields, signature []byte) bool {
+	return len(publicKey) == ed25519.PublicKeySize && ed25519.Verify(publicKey, Message(keyID, fields), signature)
+}
+
+// AuthHash hashes an auth ID so responses identify the serving credential without naming it.
+func AuthHash(authID string) string {
+	if authID == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(authID))
+	return hex.EncodeToString(sum[:16])
+}
+
+// Fingerprint is the default key ID of publicKey.
+func Fingerprint(publicKey ed25519.PublicKey) string {
+	sum := sha256.Sum256(publicKey)
+	return "ed25519:" + hex.EncodeToString(sum[:8])
+}
+
+func loadOrCreateKey(path string) (ed25519.PrivateKey, error) {
+	raw, errRead := os.ReadFile(path)
+	if errors.Is(errRead, os.ErrNotExist) {
+		return createKey(path)
+	}
+	if errRead != nil {
+		return nil, fmt.Errorf("read response signing key: %w", errRead)
+	}
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, fmt.Errorf("response signing key %s is not PEM encoded", path)
+	}
+	parsed, errParse := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if errParse != nil {
+		return nil, fmt.Errorf("parse response signing key: %w", errParse)
+	}
+	key, ok := parsed.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("response signing key %s is not an Ed25519 key", path)
+	}
+	return key, nil
+}
+
+func createKey(path string) (ed25519.PrivateKey, error) {
+	_, key, errGenerate := ed25519.GenerateKey(rand.Reader)
+	if errGenerate != nil {
+		return nil, fmt.Errorf("generate response signing key: %w", errGenerate)
+	}
+	der, errMarshal := x509.MarshalPKCS8PrivateKey(key)
+	if errMarshal != nil {
+		return nil, fmt.Errorf("encode response signing key: %w", errMarshal)
+	}
+	if errMkdir := os.MkdirAll(filepath.Dir(path), 0o700); errMkdir != nil {
+		return nil, fmt.Errorf("create response signing key directory: %w", errMkdir)
+	}
+	encoded := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if errWrite := os.WriteFile(path, encoded, 0o600); errWrite != nil {
+		return nil, fmt.Errorf("write response signing key: %w", errWrite)
+	}
+	log.Infof("generated response signing key at %s", path)
+	return key, nil
+}
@@ -0,0 +1,114 @@
+package provenance
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func enableSigning(t *testing.T) *Signer {
+	t.Helper()
+	if errConfigure := Configure(&config.Config{ResponseSigning: config.ResponseSigningConfig{Enable: true}}); errConfigure != nil {
+		t.Fatalf("Configure(): %v", errConfigure)
+	}
+	t.Cleanup(func() { _ = Configure(nil) })
+	return Active()
+}
+
+func serve(handler gin.HandlerFunc, req *http.Request) *http.Response {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(Middleware(nil, func(*gin.Context) (string, string) { return "gemini", "auth-1" }))
+	engine.POST("/v1/test", handler)
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	return rec.Result()
+}
+
+func verifyResponse(t *testing.T, signer *Signer, values http.Header, status int, body string) {
+	t.Helper()
+	timestamp, _ := strconv.ParseInt(values.Get(HeaderTimestamp), 10, 64)
+	signature, _ := base64.StdEncoding.DecodeString(values.Get(HeaderSignature))
+	fields := Fields{
+		Timestamp:     timestamp,
+		Method:        http.MethodPost,
+		Path:          "/v1/test",
+		Status:        status,
+		Provider:      values.Get(HeaderProvider),
+		AuthHash:      values.Get(HeaderAuthHash),
+		ContentSHA256: values.Get(HeaderContentSHA256),
+	}
+	if fields.Provider != "gemini" || fields.AuthHash != AuthHash("auth-1") {
+		t.Fatalf("provenance = %q/%q, want gemini and the auth hash", fields.Provider, fields.AuthHash)
+	}
+	sum := sha256.Sum256([]byte(body))
+	if fields.ContentSHA256 != hex.EncodeToString(sum[:]) {
+		t.Fatalf("content digest = %q, want the digest of %q", fields.ContentSHA256, body)
+	}
+	if !Verify(signer.PublicKey(), values.Get(HeaderKeyID), fields, signature) {
+		t.Fatal("signature does not verify")
+	}
+	fields.Status++
+	if Verify(signer.PublicKey(), values.Get(HeaderKeyID), fields, signature) {
+		t.Fatal("signature verifies for a different status")
+	}
+}
+
+func TestMiddlewareSignsBufferedResponseInHeaders(t *testing.T) {
+	signer := enableSigning(t)
+	resp := serve(func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{"ok": true})
+	}, httptest.NewRequest(http.MethodPost, "/v1/test", nil))
+
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusCreated)
+	}
+	if resp.Header.Get(HeaderSignature) == "" {
+		t.Fatal("buffered response is missing the signature header")
+	}
+	verifyResponse(t, signer, resp.Header, http.StatusCreated, `{"ok":true}`)
+}
+
+func TestMiddlewareSignsStreamInTrailers(t *testing.T) {
+	signer := enableSigning(t)
+	resp := serve(func(c *gin.Context) {
+		c.Header("Content-Type", "text/event-stream")
+		_, _ = c.Writer.Write([]byte("data: one\n\n"))
+		c.Writer.Flush()
+		_, _ = c.Writer.Write([]byte("data: two\n\n"))
+	}, httptest.NewRequest(http.MethodPost, "/v1/test", nil))
+
+	if resp.Header.Get(HeaderSignature) != "" {
+		t.Fatal("stream signature was sent before the body")
+	}
+	if resp.Trailer.Get(HeaderSignature) == "" {
+		t.Fatalf("stream is missing the signature trailer; trailers = %v", resp.Trailer)
+	}
+	verifyResponse(t, signer, resp.Trailer, http.StatusOK, "data: one\n\ndata: two\n\n")
+}
+
+func TestNewSignerPersistsGeneratedKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys", "signing.pem")
+	first, errFirst := NewSigner(config.ResponseSigningConfig{Enable: true, PrivateKeyFile: path})
+	if errFirst != nil {
+		t.Fatalf("NewSigner(): %v", errFirst)
+	}
+	second, errSecond := NewSigner(config.ResponseSigningConfig{Enable: true, PrivateKeyFile: path, KeyID: "proxy-1"})
+	if errSecond != nil {
+		t.Fatalf("NewSigner() reload: %v", errSecond)
+	}
+	if !first.PublicKey().Equal(second.PublicKey()) {
+		t.Fatal("reloaded key differs from the generated one")
+	}
+	if first.KeyID() != Fingerprint(first.PublicKey()) || second.KeyID() != "proxy-1" {
+		t.Fatalf("key ids = %q, %q", first.KeyID(), second.KeyID())
+	}
+}
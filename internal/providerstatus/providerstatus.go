@@ -0,0 +1,213 @@
+// Package providerstatus polls provider status pages so routing can avoid providers with
+// declared incidents and /readyz can report them.
+package providerstatus
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/httpfetch"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	defaultInterval  = 120 * time.Second
+	minInterval      = 30 * time.Second
+	fetchTimeout     = 10 * time.Second
+	maxStatusBody    = 1 << 20
+	defaultIndicator = "major"
+)
+
+// defaultPages are the Statuspage status.json endpoints polled for built-in providers.
+var defaultPages = map[string]string{
+	"claude":  "https://status.anthropic.com/api/v2/status.json",
+	"codex":   "https://status.openai.com/api/v2/status.json",
+	"copilot": "https://www.githubstatus.com/api/v2/status.json",
+}
+
+// indicatorRank orders Statuspage indicators by severity.
+var indicatorRank = map[string]int{"none": 0, "minor": 1, "major": 2, "critical": 3}
+
+// Status is the last known state of one provider's status page.
+type Status struct {
+	Provider    string    `json:"provider"`
+	URL         string    `json:"url"`
+	Indicator   string    `json:"indicator"`
+	Description string    `json:"description,omitempty"`
+	Error       string    `json:"error,omitempty"`
+	CheckedAt   time.Time `json:"checked_at,omitzero"`
+	// Avoided reports that routing currently avoids the provider.
+	Avoided bool `json:"avoided"`
+}
+
+// Monitor holds the polled statuses. The zero value is idle until Apply enables it.
+type Monitor struct {
+	mu       sync.RWMutex
+	statuses map[string]*Status
+	minRank  int
+
+	runMu  sync.Mutex
+	cfg    config.ProviderStatusConfig
+	cancel context.CancelFunc
+	client httpfetch.Doer
+}
+
+// Default is the monitor configured from provider-status.
+var Default = &Monitor{}
+
+// Apply starts, restarts or stops polling for cfg.
+func (m *Monitor) Apply(cfg config.ProviderStatusConfig) {
+	m.runMu.Lock()
+	defer m.runMu.Unlock()
+	if m.cancel != nil && reflect.DeepEqual(m.cfg, cfg) {
+		return
+	}
+	if m.cancel != nil {
+		m.cancel()
+		m.cancel = nil
+	}
+	m.cfg = cfg
+
+	pages := make(map[string]string, len(defaultPages)+len(cfg.Pages))
+	if cfg.Enabled {
+		for provider, url := range defaultPages {
+			pages[provider] = url
+		}
+		for _, page := range cfg.Pages {
+			provider := strings.ToLower(strings.TrimSpace(page.Provider))
+			if provider == "" {
+				continue
+			}
+			if url := strings.TrimSpace(page.URL); url != "" {
+				pages[provider] = url
+			} else {
+				delete(pages, provider)
+			}
+		}
+	}
+	indicator := strings.ToLower(strings.TrimSpace(cfg.AvoidIndicator))
+	minRank, ok := indicatorRank[indicator]
+	if !ok || minRank == 0 {
+		if indicator != "" {
+			log.Warnf("provider status: unknown avoid-indicator %q, using %q", cfg.AvoidIndicator, defaultIndicator)
+		}
+		minRank = indicatorRank[defaultIndicator]
+	}
+
+	statuses := make(map[string]*Status, len(pages))
+	for provider, url := range pages {
+		statuses[provider] = &Status{Provider: provider, URL: url, Indicator: "unknown"}
+	}
+	m.mu.Lock()
+	m.statuses = statuses
+	m.minRank = minRank
+	m.mu.Unlock()
+	if len(pages) == 0 {
+		return
+	}
+
+	interval := defaultInterval
+	if cfg.IntervalSeconds > 0 {
+		interval = max(time.Duration(cfg.IntervalSeconds)*time.Second, minInterval)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	go m.run(ctx, pages, interval)
+}
+
+// Stop stops polling and forgets the statuses.
+func (m *Monitor) Stop() {
+	m.Apply(config.ProviderStatusConfig{})
+}
+
+func (m *Monitor) run(ctx context.Context, pages map[string]string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		m.poll(ctx, pages)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *Monitor) poll(ctx context.Context, pages map[string]string) {
+	client := m.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	var wg sync.WaitGroup
+	for provider, url := range pages {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			fetchCtx, cancel := context.WithTimeout(ctx, fetchTimeout)
+			defer cancel()
+			body, errFetch := httpfetch.GetBytes(fetchCtx, client, url, nil, maxStatusBody)
+			if ctx.Err() != nil {
+				return
+			}
+			m.record(provider, body, errFetch)
+		}()
+	}
+	wg.Wait()
+}
+
+// record stores the outcome of one poll. A failed poll keeps the previous indicator, so an
+// unreachable status page neither declares nor clears an outage.
+func (m *Monitor) record(provider string, body []byte, errFetch error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	status, ok := m.statuses[provider]
+	if !ok {
+		return
+	}
+	status.CheckedAt = time.Now()
+	if errFetch != nil {
+		status.Error = errFetch.Error()
+		return
+	}
+	indicator := strings.ToLower(gjson.GetBytes(body, "status.indicator").String())
+	if _, known := indicatorRank[indicator]; !known {
+		status.Error = "status page returned no known indicator"
+		return
+	}
+	status.Error = ""
+	status.Description = gjson.GetBytes(body, "status.description").String()
+	if status.Indicator != indicator && (indicatorRank[indicator] >= m.minRank || indicatorRank[status.Indicator] >= m.minRank) {
+		log.Warnf("provider status: %s is now %q (%s)", provider, indicator, status.Description)
+	}
+	status.Indicator = indicator
+}
+
+// ProviderOutage reports whether provider's status page declares an incident at or above the
+// configured indicator.
+func (m *Monitor) ProviderOutage(provider string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	status, ok := m.statuses[strings.ToLower(strings.TrimSpace(provider))]
+	return ok && indicatorRank[status.Indicator] >= m.minRank
+}
+
+// Snapshot returns the polled statuses ordered by provider.
+func (m *Monitor) Snapshot() []Status {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	out := make([]Status, 0, len(m.statuses))
+	for _, status := range m.statuses {
+		entry := *status
+		entry.Avoided = indicatorRank[status.Indicator] >= m.minRank
+		out = append(out, entry)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Provider < out[j].Provider })
+	return out
+}
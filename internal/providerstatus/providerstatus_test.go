@@ -0,0 +1,55 @@
+package providerstatus
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestMonitorPollsAndReportsOutages(t *testing.T) {
+	indicator := "major"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(`{"status":{"indicator":"` + indicator + `","description":"Partial outage"}}`))
+	}))
+	defer server.Close()
+
+	m := &Monitor{}
+	m.Apply(config.ProviderStatusConfig{
+		Enabled: true,
+		Pages: []config.ProviderStatusPage{
+			{Provider: "Claude", URL: server.URL + "/claude"},
+			{Provider: "codex", URL: server.URL + "/broken"},
+			{Provider: "copilot", URL: ""},
+		},
+	})
+	defer m.Stop()
+	pages := map[string]string{"claude": server.URL + "/claude", "codex": server.URL + "/broken"}
+	m.poll(context.Background(), pages)
+
+	if !m.ProviderOutage("claude") {
+		t.Fatal("expected major incident to be avoided")
+	}
+	if m.ProviderOutage("codex") || m.ProviderOutage("copilot") {
+		t.Fatal("unreachable or disabled status pages must not declare outages")
+	}
+	statuses := m.Snapshot()
+	if len(statuses) != 2 || statuses[0].Provider != "claude" || !statuses[0].Avoided || statuses[0].Description != "Partial outage" {
+		t.Fatalf("unexpected statuses: %+v", statuses)
+	}
+	if statuses[1].Error == "" {
+		t.Fatalf("expected fetch error for codex: %+v", statuses[1])
+	}
+
+	indicator = "minor"
+	m.poll(context.Background(), pages)
+	if m.ProviderOutage("claude") {
+		t.Fatal("minor incident is below the default avoid indicator")
+	}
+}
@@ -0,0 +1,78 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestMemoryLimiterTokenBucket(t *testing.T) {
+	now := time.Unix(1_700_000_000, 0)
+	limiter := NewMemoryLimiter()
+	limiter.now = func() time.Time { return now }
+	rule := Rule{RequestsPerMinute: 60, Burst: 2}
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if decision, _ := limiter.Allow(ctx, "key", rule); !decision.Allowed {
+			t.Fatalf("request %d rejected within burst", i)
+		}
+	}
+	decision, _ := limiter.Allow(ctx, "key", rule)
+	if decision.Allowed || decision.RetryAfter != time.Second {
+		t.Fatalf("third request = %+v, want rejection with 1s retry", decision)
+	}
+	if other, _ := limiter.Allow(ctx, "other", rule); !other.Allowed {
+		t.Fatal("buckets must be per key")
+	}
+
+	now = now.Add(time.Second)
+	if decision, _ := limiter.Allow(ctx, "key", rule); !decision.Allowed {
+		t.Fatal("request rejected after a token refilled")
+	}
+}
+
+func TestAllowInboundUsesKeyOverrides(t *testing.T) {
+	cfg := &config.Config{InboundRateLimit: config.InboundRateLimitConfig{
+		Enabled:           true,
+		RequestsPerMinute: 1,
+		Keys: map[string]config.InboundRateLimitRule{
+			"exempt": {RequestsPerMinute: 0},
+		},
+	}}
+	if err := Configure(cfg); err != nil {
+		t.Fatalf("Configure() error = %v", err)
+	}
+	t.Cleanup(func() { _ = Configure(nil) })
+	ctx := context.Background()
+
+	if !AllowInbound(ctx, "limited").Allowed {
+		t.Fatal("first request of limited key rejected")
+	}
+	if AllowInbound(ctx, "limited").Allowed {
+		t.Fatal("second request of limited key allowed")
+	}
+	for i := 0; i < 3; i++ {
+		if !AllowInbound(ctx, "exempt").Allowed {
+			t.Fatal("exempt key rejected")
+		}
+	}
+
+	if err := Configure(nil); err != nil {
+		t.Fatalf("Configure(nil) error = %v", err)
+	}
+	if !AllowInbound(ctx, "limited").Allowed {
+		t.Fatal("request rejected after limiting was disabled")
+	}
+}
+
+func TestNewRejectsUnknownBackend(t *testing.T) {
+	if _, err := New(config.InboundRateLimitConfig{Backend: "memcached"}); err == nil {
+		t.Fatal("expected error for unsupported backend")
+	}
+	if _, err := New(config.InboundRateLimitConfig{Backend: BackendRedis}); err == nil {
+		t.Fatal("expected error for redis backend without addr")
+	}
+}
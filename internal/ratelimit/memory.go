@@ -0,0 +1,79 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// memoryIdleTTL drops buckets that have been full and unused for this long.
+const memoryIdleTTL = 10 * time.Minute
+
+type memoryBucket struct {
+	tokens   float64
+	updated  time.Time
+	lastSeen time.Time
+}
+
+// MemoryLimiter keeps token buckets in process memory.
+type MemoryLimiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryLimiter returns an empty in-memory limiter.
+func NewMemoryLimiter() *MemoryLimiter {
+	return &MemoryLimiter{buckets: make(map[string]*memoryBucket), now: time.Now}
+}
+
+// Allow implements Limiter.
+func (l *MemoryLimiter) Allow(_ context.Context, key string, rule Rule) (Decision, error) {
+	interval := rule.refillInterval()
+	if interval <= 0 || rule.Burst <= 0 {
+		return Decision{Allowed: true}, nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := l.now()
+	l.sweepLocked(now)
+
+	bucket := l.buckets[key]
+	if bucket == nil {
+		bucket = &memoryBucket{tokens: float64(rule.Burst), updated: now}
+		l.buckets[key] = bucket
+	}
+	elapsed := now.Sub(bucket.updated)
+	if elapsed > 0 {
+		bucket.tokens = math.Min(float64(rule.Burst), bucket.tokens+float64(elapsed)/float64(interval))
+		bucket.updated = now
+	}
+	bucket.lastSeen = now
+
+	decision := Decision{Limit: rule.Burst}
+	if bucket.tokens >= 1 {
+		bucket.tokens--
+		decision.Allowed = true
+	} else {
+		decision.RetryAfter = time.Duration((1 - bucket.tokens) * float64(interval))
+	}
+	decision.Remaining = int(bucket.tokens)
+	return decision, nil
+}
+
+func (l *MemoryLimiter) sweepLocked(now time.Time) {
+	if now.Sub(l.lastSweep) < memoryIdleTTL {
+		return
+	}
+	l.lastSweep = now
+	for key, bucket := range l.buckets {
+		if now.Sub(bucket.lastSeen) >= memoryIdleTTL {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// Close implements Limiter.
+func (l *MemoryLimiter) Close() error { return nil }
@@ -0,0 +1,108 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/redisclient"
+)
+
+const (
+	defaultRedisKeyPrefix = "cliproxy:ratelimit:"
+	redisOperationTimeout = 500 * time.Millisecond
+)
+
+// tokenBucketScript refills and takes one token atomically using the Redis server clock, so
+// replicas with skewed clocks share one consistent bucket.
+// KEYS[1] bucket key; ARGV[1] burst; ARGV[2] refill interval in microseconds.
+// Returns {allowed, remaining, retry_after_us}.
+var tokenBucketScript = redis.NewScript(`
+local burst = tonumber(ARGV[1])
+local interval = tonumber(ARGV[2])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) * 1000000 + tonumber(t[2])
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+  tokens = burst
+  ts = now
+end
+if now > ts then
+  tokens = math.min(burst, tokens + (now - ts) / interval)
+  ts = now
+end
+local allowed = 0
+local retry = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  retry = math.ceil((1 - tokens) * interval)
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(ts))
+redis.call('PEXPIRE', KEYS[1], math.ceil(burst * interval / 1000) + 1000)
+return {allowed, math.floor(tokens), retry}
+`)
+
+// RedisLimiter keeps token buckets in Redis so every replica enforces the same limit.
+type RedisLimiter struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisLimiter connects to the Redis server described by cfg.
+func NewRedisLimiter(cfg config.RedisConfig) (*RedisLimiter, error) {
+	client, errClient := redisclient.New(cfg, redisOperationTimeout)
+	if errClient != nil {
+		return nil, fmt.Errorf("ratelimit: %w", errClient)
+	}
+	prefix := cfg.KeyPrefix
+	if strings.TrimSpace(prefix) == "" {
+		prefix = defaultRedisKeyPrefix
+	}
+	return &RedisLimiter{client: client, prefix: prefix}, nil
+}
+
+// Allow implements Limiter.
+func (l *RedisLimiter) Allow(ctx context.Context, key string, rule Rule) (Decision, error) {
+	interval := rule.refillInterval()
+	if interval <= 0 || rule.Burst <= 0 {
+		return Decision{Allowed: true}, nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	ctx, cancel := context.WithTimeout(ctx, redisOperationTimeout)
+	defer cancel()
+	result, errRun := tokenBucketScript.Run(ctx, l.client, []string{l.bucketKey(key)}, rule.Burst, interval.Microseconds()).Int64Slice()
+	if errRun != nil {
+		return Decision{}, fmt.Errorf("ratelimit: redis token bucket: %w", errRun)
+	}
+	if len(result) != 3 {
+		return Decision{}, fmt.Errorf("ratelimit: redis token bucket returned %d values", len(result))
+	}
+	return Decision{
+		Allowed:    result[0] == 1,
+		Limit:      rule.Burst,
+		Remaining:  int(result[1]),
+		RetryAfter: time.Duration(result[2]) * time.Microsecond,
+	}, nil
+}
+
+// bucketKey hashes the API key so secrets are never written to Redis in clear text.
+func (l *RedisLimiter) bucketKey(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return l.prefix + hex.EncodeToString(sum[:16])
+}
+
+// Close implements Limiter.
+func (l *RedisLimiter) Close() error {
+	return l.client.Close()
+}
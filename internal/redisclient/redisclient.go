@@ -0,0 +1,36 @@
+// Package redisclient connects to the Redis server that replicas use to share state such as
+// rate limit buckets and cache entries.
+package redisclient
+
+import (
+	"crypto/tls"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+// New returns a client for the Redis server described by cfg. Dialing and every command give
+// up after timeout, so an unreachable Redis cannot stall the requests that depend on it.
+func New(cfg config.RedisConfig, timeout time.Duration) (*redis.Client, error) {
+	addr := strings.TrimSpace(cfg.Addr)
+	if addr == "" {
+		return nil, errors.New("redis backend requires redis.addr")
+	}
+	options := &redis.Options{
+		Addr:                  addr,
+		Username:              cfg.Username,
+		Password:              cfg.Password,
+		DB:                    cfg.DB,
+		DialTimeout:           timeout,
+		ReadTimeout:           timeout,
+		WriteTimeout:          timeout,
+		ContextTimeoutEnabled: true,
+	}
+	if cfg.TLS {
+		options.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	return redis.NewClient(options), nil
+}
@@ -60,6 +60,10 @@ func (p *usageQueuePlugin) HandleUsage(ctx context.Context, record coreusage.Rec
 	if serviceTier == "" {
 		serviceTier = coreusage.ServiceTierFromContext(ctx)
 	}
+	tags := record.Tags
+	if len(tags) == 0 {
+		tags = coreusage.RequestTagsFromContext(ctx)
+	}
 
 	tokens := tokenStats{
 		InputTokens:         record.Detail.InputTokens,
@@ -107,6 +111,7 @@ func (p *usageQueuePlugin) HandleUsage(ctx context.Context, record coreusage.Rec
 		RequestID:       requestID,
 		ReasoningEffort: reasoningEffort,
 		ServiceTier:     serviceTier,
+		Tags:            tags,
 	})
 	if err != nil {
 		return
@@ -116,16 +121,17 @@ func (p *usageQueuePlugin) HandleUsage(ctx context.Context, record coreusage.Rec
 
 type queuedUsageDetail struct {
 	requestDetail
-	Provider        string `json:"provider"`
-	ExecutorType    string `json:"executor_type"`
-	Model           string `json:"model"`
-	Alias           string `json:"alias"`
-	Endpoint        string `json:"endpoint"`
-	AuthType        string `json:"auth_type"`
-	APIKey          string `json:"api_key"`
-	RequestID       string `json:"request_id"`
-	ReasoningEffort string `json:"reasoning_effort"`
-	ServiceTier     string `json:"service_tier"`
+	Provider        string            `json:"provider"`
+	ExecutorType    string            `json:"executor_type"`
+	Model           string            `json:"model"`
+	Alias           string            `json:"alias"`
+	Endpoint        string            `json:"endpoint"`
+	AuthType        string            `json:"auth_type"`
+	APIKey          string            `json:"api_key"`
+	RequestID       string            `json:"request_id"`
+	ReasoningEffort string            `json:"reasoning_effort"`
+	ServiceTier     string            `json:"service_tier"`
+	Tags            map[string]string `json:"tags,omitempty"`
 }
 
 type requestDetail struct {
@@ -64,6 +64,23 @@ func TestUsageQueuePluginPayloadIncludesStableFieldsAndSuccess(t *testing.T) {
 	})
 }
 
+func TestUsageQueuePluginPayloadIncludesRequestTags(t *testing.T) {
+	withEnabledQueue(t, func() {
+		ctx := coreusage.WithRequestTags(context.Background(), map[string]string{"team": "infra", "job": "nightly"})
+		plugin := &usageQueuePlugin{}
+		plugin.HandleUsage(ctx, coreusage.Record{Provider: "openai", Model: "gpt-5"})
+
+		payload := popSinglePayload(t)
+		var tags map[string]string
+		if err := json.Unmarshal(payload["tags"], &tags); err != nil {
+			t.Fatalf("unmarshal tags: %v", err)
+		}
+		if len(tags) != 2 || tags["team"] != "infra" || tags["job"] != "nightly" {
+			t.Fatalf("tags = %v, want team and job", tags)
+		}
+	})
+}
+
 func TestUsageQueuePluginAsyncUsesRecordResponseHeaders(t *testing.T) {
 	withEnabledQueue(t, func() {
 		ctx := internallogging.WithRequestID(context.Background(), "ctx-request-id")
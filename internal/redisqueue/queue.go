@@ -1,6 +1,7 @@
 package redisqueue
 
 import (
+	"encoding/json"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -95,6 +96,21 @@ func PopOldest(count int) [][]byte {
 	return global.popOldest(count)
 }
 
+// PurgeUsageRecords removes the queued usage records of requests made with apiKey before the
+// given time and returns how many were removed. Zero arguments match every record.
+func PurgeUsageRecords(before time.Time, apiKey string) int {
+	return global.purge(func(payload []byte) bool {
+		var record queuedUsageDetail
+		if errUnmarshal := json.Unmarshal(payload, &record); errUnmarshal != nil {
+			return false
+		}
+		if !before.IsZero() && !record.Timestamp.Before(before) {
+			return false
+		}
+		return apiKey == "" || record.APIKey == apiKey
+	})
+}
+
 func SubscribeUsage() (<-chan []byte, func()) {
 	return global.subscribe(usageSubscriberBuffer, []byte(usageSupportRefreshPayload))
 }
@@ -223,6 +239,26 @@ func (q *queue) popOldest(count int) [][]byte {
 	return out
 }
 
+func (q *queue) purge(match func([]byte) bool) int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	removed := 0
+	kept := make([]queueItem, 0, len(q.items)-q.head)
+	for _, item := range q.items[q.head:] {
+		if match(item.payload) {
+			removed++
+			continue
+		}
+		kept = append(kept, item)
+	}
+	if removed > 0 {
+		q.items = kept
+		q.head = 0
+	}
+	return removed
+}
+
 func (q *queue) pruneLocked(now time.Time) {
 	if q.head >= len(q.items) {
 		q.items = nil
@@ -133,3 +133,20 @@ func requireErrorQueueEmpty(t *testing.T) {
 		t.Fatalf("error queue retained %d item(s), want none", len(errorGlobal.items)-errorGlobal.head)
 	}
 }
+
+func TestPurgeUsageRecordsMatchesKeyAndTime(t *testing.T) {
+	withEnabledQueue(t, func() {
+		now := time.Now()
+		Enqueue([]byte(`{"timestamp":"` + now.Add(-time.Hour).Format(time.RFC3339Nano) + `","api_key":"client-key"}`))
+		Enqueue([]byte(`{"timestamp":"` + now.Format(time.RFC3339Nano) + `","api_key":"client-key"}`))
+		Enqueue([]byte(`{"timestamp":"` + now.Add(-time.Hour).Format(time.RFC3339Nano) + `","api_key":"other-key"}`))
+
+		if removed := PurgeUsageRecords(now.Add(-time.Minute), "client-key"); removed != 1 {
+			t.Fatalf("PurgeUsageRecords() = %d, want 1", removed)
+		}
+		items := PopOldest(10)
+		if len(items) != 2 {
+			t.Fatalf("PopOldest() items = %q, want the two unmatched records", items)
+		}
+	})
+}
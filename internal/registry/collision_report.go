@@ -0,0 +1,142 @@
+package registry
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// RoutingPrefix is a model-name prefix that forces requests to one provider, such as "copilot-".
+type RoutingPrefix struct {
+	Prefix   string `json:"prefix"`
+	Provider string `json:"provider"`
+}
+
+// BuiltinRoutingPrefixes returns the built-in forcing prefixes in the order request routing
+// checks them.
+func BuiltinRoutingPrefixes() []RoutingPrefix {
+	return []RoutingPrefix{
+		{Prefix: CopilotModelPrefix, Provider: "copilot"},
+		{Prefix: CodexModelPrefix, Provider: "codex"},
+		{Prefix: ChutesModelPrefix, Provider: "chutes"},
+		{Prefix: KimiModelPrefix, Provider: "kimi"},
+		{Prefix: IFlowModelPrefix, Provider: "iflow"},
+		{Prefix: CursorModelPrefix, Provider: "cursor"},
+	}
+}
+
+// Model resolutions reported by CollisionReport.
+const (
+	// ResolutionSingle means one provider claims the model.
+	ResolutionSingle = "single"
+	// ResolutionShared means several providers claim the model; requests rotate among them.
+	ResolutionShared = "shared"
+	// ResolutionPrefix means a routing prefix sends requests to a provider that claims the model.
+	ResolutionPrefix = "prefix"
+	// ResolutionShadowed means a routing prefix sends requests to a provider that does not claim
+	// the model, so the claiming providers are unreachable under this ID.
+	ResolutionShadowed = "shadowed"
+)
+
+// ProviderClaim is one provider registering a model ID.
+type ProviderClaim struct {
+	Provider string `json:"provider"`
+	Clients  int    `json:"clients"`
+}
+
+// ModelClaims lists the providers registering one model ID and how requests for it resolve.
+type ModelClaims struct {
+	ID         string          `json:"id"`
+	Providers  []ProviderClaim `json:"providers"`
+	Resolution string          `json:"resolution"`
+	// Winner is the provider requests are routed to; it is empty for shared models.
+	Winner  string `json:"winner,omitempty"`
+	Warning string `json:"warning,omitempty"`
+}
+
+// CollisionReport describes every registered model ID and the name collisions among them.
+type CollisionReport struct {
+	Models   []ModelClaims `json:"models"`
+	Shared   int           `json:"shared"`
+	Shadowed int           `json:"shadowed"`
+}
+
+// Warnings returns the warning of every shadowed model.
+func (r CollisionReport) Warnings() []string {
+	var out []string
+	for _, model := range r.Models {
+		if model.Warning != "" {
+			out = append(out, model.Warning)
+		}
+	}
+	return out
+}
+
+// CollisionReport lists every registered model ID with the providers claiming it, sorted by ID.
+// prefixes are the routing prefixes in the order request routing checks them; a model ID that
+// starts with one is routed to its provider regardless of who registered it.
+func (r *ModelRegistry) CollisionReport(prefixes []RoutingPrefix) CollisionReport {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	var report CollisionReport
+	for id, registration := range r.models {
+		if registration == nil {
+			continue
+		}
+		claims := ModelClaims{ID: id}
+		for provider, clients := range registration.Providers {
+			if clients > 0 {
+				claims.Providers = append(claims.Providers, ProviderClaim{Provider: provider, Clients: clients})
+			}
+		}
+		if len(claims.Providers) == 0 {
+			continue
+		}
+		sort.Slice(claims.Providers, func(i, j int) bool { return claims.Providers[i].Provider < claims.Providers[j].Provider })
+		resolveModelClaims(&claims, prefixes)
+		switch claims.Resolution {
+		case ResolutionShared:
+			report.Shared++
+		case ResolutionShadowed:
+			report.Shadowed++
+		}
+		report.Models = append(report.Models, claims)
+	}
+	sort.Slice(report.Models, func(i, j int) bool { return report.Models[i].ID < report.Models[j].ID })
+	return report
+}
+
+func resolveModelClaims(claims *ModelClaims, prefixes []RoutingPrefix) {
+	lower := strings.ToLower(claims.ID)
+	for _, prefix := range prefixes {
+		if prefix.Prefix == "" || !strings.HasPrefix(lower, strings.ToLower(prefix.Prefix)) {
+			continue
+		}
+		claims.Winner = prefix.Provider
+		for _, claim := range claims.Providers {
+			if strings.EqualFold(claim.Provider, prefix.Provider) {
+				claims.Resolution = ResolutionPrefix
+				return
+			}
+		}
+		claims.Resolution = ResolutionShadowed
+		claims.Warning = fmt.Sprintf("model %q registered by %s is shadowed: the %q prefix routes it to %s",
+			claims.ID, claimProviderNames(claims.Providers), prefix.Prefix, prefix.Provider)
+		return
+	}
+	if len(claims.Providers) == 1 {
+		claims.Resolution = ResolutionSingle
+		claims.Winner = claims.Providers[0].Provider
+		return
+	}
+	claims.Resolution = ResolutionShared
+}
+
+func claimProviderNames(claims []ProviderClaim) string {
+	names := make([]string, 0, len(claims))
+	for _, claim := range claims {
+		names = append(names, claim.Provider)
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,56 @@
+package registry
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCollisionReportResolutions(t *testing.T) {
+	r := newTestModelRegistry()
+	r.RegisterClient("openai-1", "openai", []*ModelInfo{{ID: "gpt-5"}, {ID: "copilot-x"}})
+	r.RegisterClient("openai-2", "openai", []*ModelInfo{{ID: "gpt-5"}})
+	r.RegisterClient("codex-1", "codex", []*ModelInfo{{ID: "gpt-5"}, {ID: "codex-mini"}})
+	r.RegisterClient("claude-1", "claude", []*ModelInfo{{ID: "claude-sonnet"}})
+
+	report := r.CollisionReport(BuiltinRoutingPrefixes())
+	if report.Shared != 1 || report.Shadowed != 1 {
+		t.Fatalf("shared = %d, shadowed = %d, want 1 and 1", report.Shared, report.Shadowed)
+	}
+
+	byID := make(map[string]ModelClaims, len(report.Models))
+	ids := make([]string, 0, len(report.Models))
+	for _, model := range report.Models {
+		byID[model.ID] = model
+		ids = append(ids, model.ID)
+	}
+	if got := strings.Join(ids, ","); got != "claude-sonnet,codex-mini,copilot-x,gpt-5" {
+		t.Fatalf("ids = %s, want sorted model IDs", got)
+	}
+
+	tests := []struct {
+		id         string
+		resolution string
+		winner     string
+	}{
+		{id: "claude-sonnet", resolution: ResolutionSingle, winner: "claude"},
+		{id: "codex-mini", resolution: ResolutionPrefix, winner: "codex"},
+		{id: "copilot-x", resolution: ResolutionShadowed, winner: "copilot"},
+		{id: "gpt-5", resolution: ResolutionShared},
+	}
+	for _, tt := range tests {
+		got := byID[tt.id]
+		if got.Resolution != tt.resolution || got.Winner != tt.winner {
+			t.Errorf("%s: resolution = %q, winner = %q, want %q, %q", tt.id, got.Resolution, got.Winner, tt.resolution, tt.winner)
+		}
+	}
+
+	shared := byID["gpt-5"].Providers
+	if len(shared) != 2 || shared[0] != (ProviderClaim{Provider: "codex", Clients: 1}) || shared[1] != (ProviderClaim{Provider: "openai", Clients: 2}) {
+		t.Fatalf("gpt-5 providers = %+v, want codex x1 and openai x2", shared)
+	}
+
+	warnings := report.Warnings()
+	if len(warnings) != 1 || !strings.Contains(warnings[0], `"copilot-x"`) || !strings.Contains(warnings[0], "openai") {
+		t.Fatalf("warnings = %v, want one warning about copilot-x from openai", warnings)
+	}
+}
@@ -13,6 +13,7 @@ const (
 	xaiBuiltinImageQualityModelID   = "grok-imagine-image-quality"
 	xaiBuiltinVideoModelID          = "grok-imagine-video"
 	xaiBuiltinVideo15PreviewModelID = "grok-imagine-video-1.5-preview"
+	geminiBuiltinEmbeddingModelID   = "gemini-embedding-001"
 )
 
 // staticModelsJSON mirrors the top-level structure of models.json.
@@ -201,6 +202,12 @@ func WithCodexBuiltins(models []*ModelInfo) []*ModelInfo {
 	return upsertModelInfos(models, codexBuiltinImage15ModelInfo(), codexBuiltinImageModelInfo())
 }
 
+// WithGeminiBuiltins injects hard-coded Gemini API model definitions that should
+// not depend on remote models.json updates, such as the embedding model.
+func WithGeminiBuiltins(models []*ModelInfo) []*ModelInfo {
+	return upsertModelInfos(models, geminiBuiltinEmbeddingModelInfo())
+}
+
 // WithXAIBuiltins injects hard-coded xAI image/video model definitions that should
 // not depend on remote models.json updates.
 func WithXAIBuiltins(models []*ModelInfo) []*ModelInfo {
@@ -264,6 +271,22 @@ func codexBuiltinImageModelInfo() *ModelInfo {
 	}
 }
 
+func geminiBuiltinEmbeddingModelInfo() *ModelInfo {
+	return &ModelInfo{
+		ID:                         geminiBuiltinEmbeddingModelID,
+		Object:                     "model",
+		Created:                    1752019200, // 2025-07-09
+		OwnedBy:                    "google",
+		Type:                       "gemini",
+		DisplayName:                "Gemini Embedding 001",
+		Name:                       "models/" + geminiBuiltinEmbeddingModelID,
+		Version:                    "001",
+		Description:                "Gemini text embedding model, served through /v1/embeddings.",
+		InputTokenLimit:            2048,
+		SupportedGenerationMethods: []string{EmbeddingGenerationMethod},
+	}
+}
+
 func xaiBuiltinImageModelInfo() *ModelInfo {
 	return &ModelInfo{
 		ID:          xaiBuiltinImageModelID,
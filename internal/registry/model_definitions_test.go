@@ -126,3 +126,16 @@ func TestAntigravityWebSearchModelForRequiresRequestedModelCapability(t *testing
 		t.Fatalf("unknown model should not get Antigravity web search model, got %q", got)
 	}
 }
+
+func TestWithGeminiBuiltinsIncludesEmbeddingModel(t *testing.T) {
+	for _, model := range WithGeminiBuiltins(nil) {
+		if model != nil && model.ID == geminiBuiltinEmbeddingModelID {
+			if !SupportsEmbeddings(model) {
+				t.Fatalf("%s does not support embeddings", model.ID)
+			}
+			return
+		}
+	}
+
+	t.Fatalf("expected Gemini builtin model %s", geminiBuiltinEmbeddingModelID)
+}
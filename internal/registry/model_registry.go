@@ -5,6 +5,9 @@ package registry
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"sort"
 	"strings"
@@ -18,6 +21,23 @@ import (
 // OpenAIImageModelType marks models that are callable through OpenAI-compatible image endpoints.
 const OpenAIImageModelType = "openai-image"
 
+// EmbeddingGenerationMethod marks models that serve /v1/embeddings in SupportedGenerationMethods,
+// following the Gemini API naming.
+const EmbeddingGenerationMethod = "embedContent"
+
+// SupportsEmbeddings reports whether info describes an embedding model.
+func SupportsEmbeddings(info *ModelInfo) bool {
+	if info == nil {
+		return false
+	}
+	for _, method := range info.SupportedGenerationMethods {
+		if method == EmbeddingGenerationMethod || method == "batchEmbedContents" {
+			return true
+		}
+	}
+	return false
+}
+
 const (
 	DefaultClaudeMaxInputTokens  = 200000
 	DefaultClaudeMaxOutputTokens = 64000
@@ -83,6 +103,9 @@ type ModelInfo struct {
 type availableModelsCacheEntry struct {
 	models    []map[string]any
 	expiresAt time.Time
+	// json and etag are the serialized models, computed on first use by GetAvailableModelsJSON.
+	json []byte
+	etag string
 }
 
 // ThinkingSupport describes a model family's supported internal reasoning budget range.
@@ -801,6 +824,52 @@ func (r *ModelRegistry) GetAvailableModels(handlerType string) []map[string]any
 	return models
 }
 
+// GetAvailableModelsJSON returns the models of GetAvailableModels serialized as a JSON array
+// sorted by model id, and a hex digest of it for use in ETags. The serialization is cached with the models and
+// invalidated with them, so unchanged listings are not re-encoded. Callers must not modify the
+// returned bytes.
+func (r *ModelRegistry) GetAvailableModelsJSON(handlerType string) ([]byte, string, error) {
+	now := time.Now()
+
+	r.mutex.RLock()
+	if cache, ok := r.availableModelsCache[handlerType]; ok && cache.json != nil && (cache.expiresAt.IsZero() || now.Before(cache.expiresAt)) {
+		r.mutex.RUnlock()
+		return cache.json, cache.etag, nil
+	}
+	r.mutex.RUnlock()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.ensureAvailableModelsCacheLocked()
+
+	cache, ok := r.availableModelsCache[handlerType]
+	if !ok || !(cache.expiresAt.IsZero() || now.Before(cache.expiresAt)) {
+		models, expiresAt := r.buildAvailableModelsLocked(handlerType, now)
+		cache = availableModelsCacheEntry{models: models, expiresAt: expiresAt}
+	}
+	if cache.json == nil {
+		sorted := append([]map[string]any(nil), cache.models...)
+		sort.SliceStable(sorted, func(i, j int) bool { return modelSortKey(sorted[i]) < modelSortKey(sorted[j]) })
+		data, errMarshal := json.Marshal(sorted)
+		if errMarshal != nil {
+			return nil, "", errMarshal
+		}
+		sum := sha256.Sum256(data)
+		cache.json = data
+		cache.etag = hex.EncodeToString(sum[:16])
+	}
+	r.availableModelsCache[handlerType] = cache
+	return cache.json, cache.etag, nil
+}
+
+func modelSortKey(model map[string]any) string {
+	if id, ok := model["id"].(string); ok && id != "" {
+		return id
+	}
+	name, _ := model["name"].(string)
+	return name
+}
+
 func (r *ModelRegistry) buildAvailableModelsLocked(handlerType string, now time.Time) ([]map[string]any, time.Time) {
 	models := make([]map[string]any, 0, len(r.models))
 	var expiresAt time.Time
@@ -1,6 +1,9 @@
 package registry
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestGetAvailableModelsReturnsClonedSnapshots(t *testing.T) {
 	r := newTestModelRegistry()
@@ -98,3 +101,26 @@ func TestGetAvailableModelsInvalidatesCacheOnRegistryChanges(t *testing.T) {
 		t.Fatalf("expected model to reappear after resume, got %d", len(models))
 	}
 }
+
+func TestGetAvailableModelsJSONCachesUntilRegistryChanges(t *testing.T) {
+	r := newTestModelRegistry()
+	r.RegisterClient("client-1", "OpenAI", []*ModelInfo{{ID: "m2", OwnedBy: "team-a"}, {ID: "m1", OwnedBy: "team-a"}})
+
+	first, firstTag, err := r.GetAvailableModelsJSON("openai")
+	if err != nil {
+		t.Fatalf("GetAvailableModelsJSON: %v", err)
+	}
+	if !strings.HasPrefix(string(first), `[{"created"`) || strings.Index(string(first), `"m1"`) > strings.Index(string(first), `"m2"`) {
+		t.Fatalf("expected models sorted by id, got %s", first)
+	}
+	again, againTag, _ := r.GetAvailableModelsJSON("openai")
+	if &again[0] != &first[0] || againTag != firstTag {
+		t.Fatal("expected the cached serialization to be reused")
+	}
+
+	r.RegisterClient("client-2", "OpenAI", []*ModelInfo{{ID: "m3", OwnedBy: "team-b"}})
+	updated, updatedTag, _ := r.GetAvailableModelsJSON("openai")
+	if updatedTag == firstTag || !strings.Contains(string(updated), `"m3"`) {
+		t.Fatalf("expected a new serialization after registration, got tag %s body %s", updatedTag, updated)
+	}
+}
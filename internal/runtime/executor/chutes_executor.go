@@ -16,6 +16,7 @@ import (
 	"sync"
 	"time"
 
+	internalcache "github.com/router-for-me/CLIProxyAPI/v7/internal/cache"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/runtime/executor/helps"
@@ -33,6 +34,8 @@ const (
 	chutesModelsEndpoint = "/models"
 	chutesChatEndpoint   = "/chat/completions"
 
+	chutesEmbeddingsEndpoint = "/embeddings"
+
 	// Default retry configuration for Chutes 429 errors.
 	chutesDefaultMaxRetries = 4
 )
@@ -70,8 +73,11 @@ var chutesRetryableStatusCodes = map[int]bool{
 
 // Shared caches (survive executor recreation)
 var (
-	chutesModelCacheMu sync.Mutex
-	chutesModelCache   *chutesModelCacheEntry
+	// chutesModelCache stays in process memory: ModelInfo.UpstreamID does not survive JSON.
+	chutesModelCache = internalcache.New[chutesModelCacheEntry](internalcache.Options{
+		Name: "chutes-models",
+		TTL:  chutesModelCacheTTL,
+	})
 
 	// Maps registry model IDs to actual Chutes API model IDs
 	chutesAliasMapMu sync.RWMutex
@@ -83,13 +89,15 @@ var (
 )
 
 type chutesModelCacheEntry struct {
-	models    []*registry.ModelInfo
-	aliases   map[string]string
-	fetchedAt time.Time
+	models  []*registry.ModelInfo
+	aliases map[string]string
 }
 
 const chutesModelCacheTTL = 30 * time.Minute
 
+// chutesModelCacheKey is the only key of chutesModelCache; the model list is global.
+const chutesModelCacheKey = "models"
+
 // ChutesModel represents a model from the Chutes /v1/models response.
 type ChutesModel struct {
 	ID                  string   `json:"id"`
@@ -239,6 +247,64 @@ func (e *ChutesExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth, r
 	return cliproxyexecutor.Response{Payload: []byte(out)}, nil
 }
 
+// ExecuteEmbeddings forwards an OpenAI embeddings request to Chutes. Models that Chutes lists
+// without embedding output are refused.
+func (e *ChutesExecutor) ExecuteEmbeddings(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (resp cliproxyexecutor.Response, err error) {
+	apiKey, baseURL := chutesCreds(auth, e.cfg)
+	if apiKey == "" {
+		return resp, fmt.Errorf("chutes executor: missing api key")
+	}
+	if info := registry.LookupModelInfo(strings.TrimPrefix(req.Model, registry.ChutesModelPrefix), "chutes"); info != nil && !registry.SupportsEmbeddings(info) {
+		return resp, statusErr{code: http.StatusBadRequest, msg: fmt.Sprintf("chutes model %s does not support embeddings", req.Model)}
+	}
+
+	reporter := helps.NewUsageReporter(ctx, e.Identifier(), req.Model, auth)
+	defer reporter.TrackFailure(ctx, &err)
+
+	apiModel := resolveChutesModel(req.Model)
+	body, _ := sjson.SetBytes(bytes.Clone(req.Payload), "model", apiModel)
+	endpoint := strings.TrimSuffix(baseURL, "/") + chutesEmbeddingsEndpoint
+
+	start := time.Now()
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return resp, err
+	}
+	applyChutesHeaders(httpReq, apiKey, false)
+	logChutesRequestHeaders(httpReq)
+
+	httpClient := helps.NewProxyAwareHTTPClient(ctx, e.cfg, auth, 0, "chutes")
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		return resp, err
+	}
+	defer httpResp.Body.Close()
+	helps.RecordAPIResponseMetadata(ctx, e.cfg, httpResp.StatusCode, httpResp.Header.Clone())
+	data, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return resp, err
+	}
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		log.WithFields(log.Fields{
+			"status":           httpResp.StatusCode,
+			"duration":         time.Since(start).String(),
+			"endpoint":         endpoint,
+			"response_headers": formatChutesResponseHeaders(httpResp.Header),
+			"body":             sanitizeResponseBody(data),
+		}).Info("chutes: upstream non-2xx")
+		helps.AppendAPIResponseChunk(ctx, e.cfg, data)
+		se := statusErr{code: httpResp.StatusCode, msg: string(data)}
+		if httpResp.StatusCode == http.StatusTooManyRequests {
+			se.retryAfter = chutesShortRetryAfter()
+		}
+		return resp, se
+	}
+
+	reporter.Publish(ctx, helps.ParseOpenAIUsage(data))
+	reporter.EnsurePublished(ctx)
+	return cliproxyexecutor.Response{Payload: data, Headers: httpResp.Header.Clone()}, nil
+}
+
 // ExecuteStream performs a streaming chat completion request.
 func (e *ChutesExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (result *cliproxyexecutor.StreamResult, err error) {
 	apiKey, baseURL := chutesCreds(auth, e.cfg)
@@ -436,14 +502,10 @@ func (e *ChutesExecutor) HttpRequest(ctx context.Context, auth *cliproxyauth.Aut
 // NOT part of ProviderExecutor interface, but follows Copilot/Antigravity convention.
 func (e *ChutesExecutor) FetchModels(ctx context.Context, auth *cliproxyauth.Auth, cfg *config.Config) []*registry.ModelInfo {
 	// 1. Check cache
-	chutesModelCacheMu.Lock()
-	if chutesModelCache != nil && time.Since(chutesModelCache.fetchedAt) < chutesModelCacheTTL {
-		models := chutesModelCache.models
-		restoreChutesAliasMap(chutesModelCache.aliases)
-		chutesModelCacheMu.Unlock()
-		return models
+	if cached, ok := chutesModelCache.Get(chutesModelCacheKey); ok {
+		restoreChutesAliasMap(cached.aliases)
+		return cached.models
 	}
-	chutesModelCacheMu.Unlock()
 
 	apiKey, baseURL := chutesCreds(auth, cfg)
 	if apiKey == "" {
@@ -493,13 +555,10 @@ func (e *ChutesExecutor) FetchModels(ctx context.Context, auth *cliproxyauth.Aut
 	models = registry.GenerateChutesAliases(models)
 
 	// 5. Cache
-	chutesModelCacheMu.Lock()
-	chutesModelCache = &chutesModelCacheEntry{
-		models:    models,
-		aliases:   snapshotChutesAliasMap(),
-		fetchedAt: time.Now(),
-	}
-	chutesModelCacheMu.Unlock()
+	chutesModelCache.Set(chutesModelCacheKey, chutesModelCacheEntry{
+		models:  models,
+		aliases: snapshotChutesAliasMap(),
+	})
 
 	return models
 }
@@ -587,6 +646,9 @@ func processChutesModels(raw []ChutesModel, teePref string, whitelist, blocklist
 				SupportedParameters: mapChutesFeatures(sel.SupportedFeatures),
 				UpstreamID:          sel.ID,
 			}
+			if chutesSupportsEmbeddings(sel) {
+				info.SupportedGenerationMethods = []string{registry.EmbeddingGenerationMethod}
+			}
 			models = append(models, info)
 		}
 	}
@@ -691,6 +753,19 @@ func mapChutesFeatures(features []string) []string {
 	return params
 }
 
+// chutesSupportsEmbeddings reports whether Chutes lists m as producing embeddings.
+func chutesSupportsEmbeddings(m ChutesModel) bool {
+	for _, values := range [][]string{m.OutputModalities, m.SupportedFeatures} {
+		for _, value := range values {
+			switch strings.ToLower(strings.TrimSpace(value)) {
+			case "embedding", "embeddings":
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func resolveChutesModel(modelID string) string {
 	stripped := strings.TrimPrefix(modelID, registry.ChutesModelPrefix)
 
@@ -822,9 +897,7 @@ func chutesCreds(auth *cliproxyauth.Auth, cfg *config.Config) (apiKey, baseURL s
 
 // EvictChutesModelCache clears the model cache (for testing or auth removal).
 func EvictChutesModelCache() {
-	chutesModelCacheMu.Lock()
-	chutesModelCache = nil
-	chutesModelCacheMu.Unlock()
+	chutesModelCache.Clear()
 }
 
 // chutesMaxRetries returns the configured max retries or the default.
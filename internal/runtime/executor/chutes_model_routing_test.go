@@ -2,7 +2,6 @@ package executor
 
 import (
 	"testing"
-	"time"
 
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
 )
@@ -55,9 +54,8 @@ func TestChutesModelCacheRestoresAliasMap(t *testing.T) {
 		upstreamID = "google/gemma-4-31B-turbo-TEE"
 	)
 
-	chutesModelCacheMu.Lock()
-	previousCache := chutesModelCache
-	chutesModelCache = &chutesModelCacheEntry{
+	previousCache, hadPreviousCache := chutesModelCache.Get(chutesModelCacheKey)
+	chutesModelCache.Set(chutesModelCacheKey, chutesModelCacheEntry{
 		models: []*registry.ModelInfo{
 			{
 				ID:         publicID,
@@ -70,13 +68,13 @@ func TestChutesModelCacheRestoresAliasMap(t *testing.T) {
 		aliases: map[string]string{
 			publicID: upstreamID,
 		},
-		fetchedAt: time.Now(),
-	}
-	chutesModelCacheMu.Unlock()
+	})
 	defer func() {
-		chutesModelCacheMu.Lock()
-		chutesModelCache = previousCache
-		chutesModelCacheMu.Unlock()
+		if hadPreviousCache {
+			chutesModelCache.Set(chutesModelCacheKey, previousCache)
+			return
+		}
+		chutesModelCache.Delete(chutesModelCacheKey)
 	}()
 
 	restoreAliases := clearChutesAliasesForTest(t)
@@ -0,0 +1,213 @@
+package executor
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	"github.com/tidwall/gjson"
+)
+
+// claudeStandardContextTokens is the context window available without the 1M context beta.
+const claudeStandardContextTokens = 200000
+
+// claudeComputerUseToolBetas maps Claude computer-use tool versions to the beta that enables them.
+var claudeComputerUseToolBetas = map[string]string{
+	"computer_20241022":    "computer-use-2024-10-22",
+	"bash_20241022":        "computer-use-2024-10-22",
+	"text_editor_20241022": "computer-use-2024-10-22",
+	"computer_20250124":    "computer-use-2025-01-24",
+	"computer_20251124":    "computer-use-2025-11-24",
+}
+
+// claudeBetaFeature describes a request feature that Anthropic gates behind a beta header.
+type claudeBetaFeature struct {
+	// family is the beta family name, used in error messages and to identify gated betas.
+	family string
+	// gated features are rejected when their beta is not enabled, and their betas are stripped
+	// from client-supplied headers. Other features are simply sent without the beta.
+	gated bool
+	// detect returns the betas a request body needs for this feature.
+	detect func(body []byte) []string
+}
+
+// claudeBetaFeatures lists the gated features the Claude executor recognizes.
+var claudeBetaFeatures = []claudeBetaFeature{
+	{family: "computer-use", gated: true, detect: detectClaudeComputerUse},
+	{family: "context-1m", detect: detectClaudeLongContext},
+	{family: "prompt-caching", detect: detectClaudePromptCaching},
+	{family: "extended-cache-ttl", detect: detectClaudeExtendedCacheTTL},
+	{family: "mcp-client", detect: detectClaudeMCPServers},
+	{family: "files-api", detect: detectClaudeFileSources},
+}
+
+// claudeEnabledBetas returns the betas enabled for a request: the credential's allowed-betas
+// (from the synthesized auth attribute or the matching claude-api-key entry) plus the
+// provider-betas rules matching the auth provider and model.
+func claudeEnabledBetas(cfg *config.Config, auth *cliproxyauth.Auth, model string) []string {
+	var enabled []string
+	if auth != nil && auth.Attributes != nil && strings.TrimSpace(auth.Attributes["allowed_betas"]) != "" {
+		enabled = append(enabled, strings.Split(auth.Attributes["allowed_betas"], ",")...)
+	} else if entry := resolveClaudeKeyConfig(cfg, auth); entry != nil {
+		enabled = append(enabled, entry.AllowedBetas...)
+	}
+	provider := "claude"
+	if auth != nil && strings.TrimSpace(auth.Provider) != "" {
+		provider = auth.Provider
+	}
+	return append(enabled, cfg.EnabledBetas(provider, model)...)
+}
+
+// claudeBetaAllowed reports whether beta is enabled by the allowed list. An entry matches the
+// exact beta, or every dated version of a beta family (e.g. "computer-use" matches
+// "computer-use-2025-01-24"); "*" allows everything.
+func claudeBetaAllowed(allowed []string, beta string) bool {
+	for _, entry := range allowed {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if entry == "*" || strings.EqualFold(entry, beta) || strings.HasPrefix(strings.ToLower(beta), strings.ToLower(entry)+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// isClaudeGatedBeta reports whether beta belongs to a gated feature and must be enabled to be forwarded.
+func isClaudeGatedBeta(beta string) bool {
+	beta = strings.ToLower(strings.TrimSpace(beta))
+	for _, feature := range claudeBetaFeatures {
+		if feature.gated && strings.HasPrefix(beta, feature.family) {
+			return true
+		}
+	}
+	return false
+}
+
+// claudeFeatureBetas returns the enabled betas required by the features a Claude request uses.
+// It fails with a 400 when a request uses a gated feature whose beta is not enabled.
+func claudeFeatureBetas(body []byte, enabled []string) ([]string, error) {
+	var out []string
+	seen := map[string]bool{}
+	for _, feature := range claudeBetaFeatures {
+		for _, beta := range feature.detect(body) {
+			if seen[beta] {
+				continue
+			}
+			seen[beta] = true
+			if claudeBetaAllowed(enabled, beta) {
+				out = append(out, beta)
+				continue
+			}
+			if feature.gated {
+				return nil, statusErr{code: http.StatusBadRequest, msg: fmt.Sprintf("%s requires beta %s, which is not enabled for this credential", feature.family, beta)}
+			}
+		}
+	}
+	return out, nil
+}
+
+// filterClaudeBetaHeader drops gated betas that are not enabled from the Anthropic-Beta header,
+// including those supplied by the client.
+func filterClaudeBetaHeader(h http.Header, enabled []string) {
+	raw := h.Get("Anthropic-Beta")
+	if raw == "" {
+		return
+	}
+	betas := strings.Split(raw, ",")
+	kept := betas[:0]
+	for _, beta := range betas {
+		if isClaudeGatedBeta(beta) && !claudeBetaAllowed(enabled, strings.TrimSpace(beta)) {
+			continue
+		}
+		kept = append(kept, beta)
+	}
+	h.Set("Anthropic-Beta", strings.Join(kept, ","))
+}
+
+func detectClaudeComputerUse(body []byte) []string {
+	var betas []string
+	gjson.GetBytes(body, "tools").ForEach(func(_, tool gjson.Result) bool {
+		if beta, ok := claudeComputerUseToolBetas[tool.Get("type").String()]; ok {
+			betas = append(betas, beta)
+		}
+		return true
+	})
+	return betas
+}
+
+// detectClaudeLongContext estimates input tokens at four bytes each; it is only a trigger for
+// attaching the beta, which Anthropic bills at long-context rates only when actually exceeded.
+func detectClaudeLongContext(body []byte) []string {
+	if len(body)/4 <= claudeStandardContextTokens {
+		return nil
+	}
+	return []string{"context-1m-2025-08-07"}
+}
+
+func detectClaudePromptCaching(body []byte) []string {
+	if len(claudeCacheControls(body)) == 0 {
+		return nil
+	}
+	return []string{"prompt-caching-2024-07-31"}
+}
+
+func detectClaudeExtendedCacheTTL(body []byte) []string {
+	for _, cc := range claudeCacheControls(body) {
+		if cc.Get("ttl").String() == "1h" {
+			return []string{"extended-cache-ttl-2025-04-11"}
+		}
+	}
+	return nil
+}
+
+func detectClaudeMCPServers(body []byte) []string {
+	if len(gjson.GetBytes(body, "mcp_servers").Array()) == 0 {
+		return nil
+	}
+	return []string{"mcp-client-2025-04-04"}
+}
+
+func detectClaudeFileSources(body []byte) []string {
+	found := false
+	gjson.GetBytes(body, "messages").ForEach(func(_, msg gjson.Result) bool {
+		msg.Get("content").ForEach(func(_, part gjson.Result) bool {
+			found = part.Get("source.type").String() == "file"
+			return !found
+		})
+		return !found
+	})
+	if !found {
+		return nil
+	}
+	return []string{"files-api-2025-04-14"}
+}
+
+// claudeCacheControls collects the cache_control objects set on tools, system blocks and message content.
+func claudeCacheControls(body []byte) []gjson.Result {
+	var out []gjson.Result
+	collect := func(block gjson.Result) {
+		if cc := block.Get("cache_control"); cc.IsObject() {
+			out = append(out, cc)
+		}
+	}
+	gjson.GetBytes(body, "tools").ForEach(func(_, tool gjson.Result) bool {
+		collect(tool)
+		return true
+	})
+	gjson.GetBytes(body, "system").ForEach(func(_, block gjson.Result) bool {
+		collect(block)
+		return true
+	})
+	gjson.GetBytes(body, "messages").ForEach(func(_, msg gjson.Result) bool {
+		msg.Get("content").ForEach(func(_, part gjson.Result) bool {
+			collect(part)
+			return true
+		})
+		return true
+	})
+	return out
+}
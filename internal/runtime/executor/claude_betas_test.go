@@ -0,0 +1,104 @@
+package executor
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+func TestClaudeFeatureBetas_RejectsComputerUseWhenNotEnabled(t *testing.T) {
+	body := []byte(`{"tools":[{"type":"computer_20250124","name":"computer","display_width_px":1024,"display_height_px":768}]}`)
+
+	_, err := claudeFeatureBetas(body, nil)
+	if err == nil {
+		t.Fatal("expected computer use to be rejected without an enabled beta")
+	}
+	if se, ok := err.(statusErr); !ok || se.StatusCode() != http.StatusBadRequest {
+		t.Fatalf("err = %v, want 400 statusErr", err)
+	}
+}
+
+func TestClaudeFeatureBetas_AttachesEnabledBetas(t *testing.T) {
+	body := []byte(`{
+		"tools":[{"name":"Read","input_schema":{}},{"type":"computer_20250124","name":"computer"}],
+		"system":[{"type":"text","text":"sys","cache_control":{"type":"ephemeral","ttl":"1h"}}],
+		"messages":[{"role":"user","content":[{"type":"document","source":{"type":"file","file_id":"file_1"}}]}],
+		"mcp_servers":[{"type":"url","url":"https://mcp.example.com","name":"example"}]
+	}`)
+
+	got, err := claudeFeatureBetas(body, []string{"computer-use", "extended-cache-ttl", "files-api-2025-04-14"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []string{"computer-use-2025-01-24", "extended-cache-ttl-2025-04-11", "files-api-2025-04-14"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("betas = %v, want %v", got, want)
+	}
+}
+
+func TestClaudeFeatureBetas_LongContext(t *testing.T) {
+	body := []byte(`{"messages":[{"role":"user","content":"` + strings.Repeat("a", 4*claudeStandardContextTokens+4) + `"}]}`)
+
+	got, err := claudeFeatureBetas(body, []string{"context-1m"})
+	if err != nil || !reflect.DeepEqual(got, []string{"context-1m-2025-08-07"}) {
+		t.Fatalf("betas = %v, err = %v, want context-1m-2025-08-07", got, err)
+	}
+	if got, _ = claudeFeatureBetas(body, nil); len(got) != 0 {
+		t.Fatalf("betas = %v, want none when context-1m is not enabled", got)
+	}
+}
+
+func TestClaudeEnabledBetas_MergesKeyAndProviderRules(t *testing.T) {
+	cfg := &config.Config{
+		ClaudeKey: []config.ClaudeKey{{APIKey: "key-betas", AllowedBetas: []string{"computer-use"}}},
+		ProviderBetas: map[string][]config.ProviderBetaRule{
+			"claude": {{Models: []string{"claude-sonnet-4*"}, Betas: []string{"context-1m"}}},
+		},
+	}
+	auth := &cliproxyauth.Auth{Provider: "claude", Attributes: map[string]string{"api_key": "key-betas"}}
+
+	got := claudeEnabledBetas(cfg, auth, "claude-sonnet-4-5")
+	if !reflect.DeepEqual(got, []string{"computer-use", "context-1m"}) {
+		t.Fatalf("enabled = %v, want [computer-use context-1m]", got)
+	}
+	if got = claudeEnabledBetas(cfg, auth, "claude-haiku-4-5"); !reflect.DeepEqual(got, []string{"computer-use"}) {
+		t.Fatalf("enabled = %v, want [computer-use]", got)
+	}
+}
+
+func TestClaudeBetaAllowed(t *testing.T) {
+	cases := []struct {
+		allowed []string
+		beta    string
+		want    bool
+	}{
+		{nil, "computer-use-2025-01-24", false},
+		{[]string{"computer-use"}, "computer-use-2025-01-24", true},
+		{[]string{"computer-use-2024-10-22"}, "computer-use-2025-01-24", false},
+		{[]string{"computer-use-2025-01-24"}, "computer-use-2025-01-24", true},
+		{[]string{"*"}, "computer-use-2025-11-24", true},
+	}
+	for _, tc := range cases {
+		if got := claudeBetaAllowed(tc.allowed, tc.beta); got != tc.want {
+			t.Errorf("claudeBetaAllowed(%v, %q) = %v, want %v", tc.allowed, tc.beta, got, tc.want)
+		}
+	}
+}
+
+func TestFilterClaudeBetaHeader_StripsGatedBetasUnlessEnabled(t *testing.T) {
+	h := http.Header{"Anthropic-Beta": []string{"oauth-2025-04-20,computer-use-2025-01-24,context-1m-2025-08-07"}}
+	filterClaudeBetaHeader(h, nil)
+	if got := h.Get("Anthropic-Beta"); got != "oauth-2025-04-20,context-1m-2025-08-07" {
+		t.Fatalf("Anthropic-Beta = %q, want computer-use beta stripped", got)
+	}
+
+	h = http.Header{"Anthropic-Beta": []string{"oauth-2025-04-20,computer-use-2025-01-24"}}
+	filterClaudeBetaHeader(h, []string{"computer-use"})
+	if got := h.Get("Anthropic-Beta"); got != "oauth-2025-04-20,computer-use-2025-01-24" {
+		t.Fatalf("Anthropic-Beta = %q, want computer-use beta kept", got)
+	}
+}
@@ -0,0 +1,123 @@
+package executor
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	codexSessionConversationTTL         = 3 * time.Hour
+	codexSessionConversationMaxSessions = 4096
+)
+
+// codexSessionConversationStore remembers the conversation ID sent upstream for each downstream
+// execution session.
+type codexSessionConversationStore struct {
+	mu       sync.Mutex
+	sessions map[string]*codexSessionConversation
+}
+
+type codexSessionConversation struct {
+	id         string
+	lastAccess time.Time
+}
+
+var globalCodexSessionConversations = &codexSessionConversationStore{
+	sessions: make(map[string]*codexSessionConversation),
+}
+
+// attachCodexSessionConversation pins Responses requests of one downstream execution session to a
+// single upstream conversation. The prompt_cache_key a client sends is remembered for its session;
+// requests that omit it get the remembered key, or a generated one on the first turn, so the Codex
+// backend caches and truncates the session as one conversation instead of starting a new one per call.
+func attachCodexSessionConversation(from sdktranslator.Format, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) cliproxyexecutor.Request {
+	return globalCodexSessionConversations.attach(from, req, opts)
+}
+
+func (s *codexSessionConversationStore) attach(from sdktranslator.Format, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) cliproxyexecutor.Request {
+	if s == nil || !sourceFormatEqual(from, sdktranslator.FormatOpenAIResponse) || len(req.Payload) == 0 {
+		return req
+	}
+	sessionID := executionSessionIDFromOptions(opts)
+	if sessionID == "" {
+		sessionID = metadataString(req.Metadata, cliproxyexecutor.ExecutionSessionMetadataKey)
+	}
+	if sessionID == "" {
+		return req
+	}
+	if promptCacheKey := strings.TrimSpace(gjson.GetBytes(req.Payload, "prompt_cache_key").String()); promptCacheKey != "" {
+		s.remember(sessionID, promptCacheKey)
+		return req
+	}
+	payload, errSet := sjson.SetBytes(req.Payload, "prompt_cache_key", s.conversationID(sessionID))
+	if errSet != nil {
+		return req
+	}
+	req.Payload = payload
+	return req
+}
+
+// conversationID returns the conversation ID of sessionID, generating one on first use.
+func (s *codexSessionConversationStore) conversationID(sessionID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	if entry := s.sessions[sessionID]; entry != nil && now.Sub(entry.lastAccess) <= codexSessionConversationTTL {
+		entry.lastAccess = now
+		return entry.id
+	}
+	id := uuid.NewString()
+	s.storeLocked(sessionID, id, now)
+	return id
+}
+
+func (s *codexSessionConversationStore) remember(sessionID, id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.storeLocked(sessionID, id, time.Now())
+}
+
+func (s *codexSessionConversationStore) storeLocked(sessionID, id string, now time.Time) {
+	if s.sessions == nil {
+		s.sessions = make(map[string]*codexSessionConversation)
+	}
+	if entry := s.sessions[sessionID]; entry != nil {
+		entry.id = id
+		entry.lastAccess = now
+		return
+	}
+	for key, entry := range s.sessions {
+		if now.Sub(entry.lastAccess) > codexSessionConversationTTL {
+			delete(s.sessions, key)
+		}
+	}
+	for len(s.sessions) >= codexSessionConversationMaxSessions {
+		oldestKey := ""
+		var oldest time.Time
+		for key, entry := range s.sessions {
+			if oldestKey == "" || entry.lastAccess.Before(oldest) {
+				oldestKey, oldest = key, entry.lastAccess
+			}
+		}
+		delete(s.sessions, oldestKey)
+	}
+	s.sessions[sessionID] = &codexSessionConversation{id: id, lastAccess: now}
+}
+
+// forget drops the conversation of a closed execution session.
+func (s *codexSessionConversationStore) forget(sessionID string) {
+	sessionID = strings.TrimSpace(sessionID)
+	if s == nil || sessionID == "" {
+		return
+	}
+	s.mu.Lock()
+	delete(s.sessions, sessionID)
+	s.mu.Unlock()
+}
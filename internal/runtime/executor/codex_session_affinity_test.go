@@ -0,0 +1,63 @@
+package executor
+
+import (
+	"testing"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+	"github.com/tidwall/gjson"
+)
+
+func TestCodexSessionConversationReattachesAcrossTurns(t *testing.T) {
+	store := &codexSessionConversationStore{}
+	from := sdktranslator.FromString("openai-response")
+	opts := cliproxyexecutor.Options{Metadata: map[string]any{cliproxyexecutor.ExecutionSessionMetadataKey: "sess-1"}}
+
+	first := store.attach(from, cliproxyexecutor.Request{Payload: []byte(`{"input":"hi"}`)}, opts)
+	id := gjson.GetBytes(first.Payload, "prompt_cache_key").String()
+	if id == "" {
+		t.Fatalf("first payload = %s, want a generated prompt_cache_key", first.Payload)
+	}
+	second := store.attach(from, cliproxyexecutor.Request{Payload: []byte(`{"input":"again"}`)}, opts)
+	if got := gjson.GetBytes(second.Payload, "prompt_cache_key").String(); got != id {
+		t.Fatalf("second prompt_cache_key = %q, want %q", got, id)
+	}
+
+	other := store.attach(from, cliproxyexecutor.Request{Payload: []byte(`{"input":"hi"}`)},
+		cliproxyexecutor.Options{Metadata: map[string]any{cliproxyexecutor.ExecutionSessionMetadataKey: "sess-2"}})
+	if got := gjson.GetBytes(other.Payload, "prompt_cache_key").String(); got == "" || got == id {
+		t.Fatalf("other session prompt_cache_key = %q, want a distinct key", got)
+	}
+
+	store.forget("sess-1")
+	if got := store.conversationID("sess-1"); got == id {
+		t.Fatal("conversation kept after the session was forgotten")
+	}
+}
+
+func TestCodexSessionConversationRemembersClientKey(t *testing.T) {
+	store := &codexSessionConversationStore{}
+	from := sdktranslator.FromString("openai-response")
+	opts := cliproxyexecutor.Options{Metadata: map[string]any{cliproxyexecutor.ExecutionSessionMetadataKey: "sess-1"}}
+
+	store.attach(from, cliproxyexecutor.Request{Payload: []byte(`{"prompt_cache_key":"client-key","input":"hi"}`)}, opts)
+	next := store.attach(from, cliproxyexecutor.Request{Payload: []byte(`{"input":"again"}`)}, opts)
+	if got := gjson.GetBytes(next.Payload, "prompt_cache_key").String(); got != "client-key" {
+		t.Fatalf("prompt_cache_key = %q, want the client's key", got)
+	}
+}
+
+func TestCodexSessionConversationIgnoresRequestsOutsideSessions(t *testing.T) {
+	store := &codexSessionConversationStore{}
+	payload := []byte(`{"input":"hi"}`)
+
+	req := store.attach(sdktranslator.FromString("openai-response"), cliproxyexecutor.Request{Payload: payload}, cliproxyexecutor.Options{})
+	if string(req.Payload) != string(payload) {
+		t.Fatalf("payload = %s, want unchanged without an execution session", req.Payload)
+	}
+	opts := cliproxyexecutor.Options{Metadata: map[string]any{cliproxyexecutor.ExecutionSessionMetadataKey: "sess-1"}}
+	req = store.attach(sdktranslator.FromString("claude"), cliproxyexecutor.Request{Payload: payload}, opts)
+	if string(req.Payload) != string(payload) {
+		t.Fatalf("payload = %s, want unchanged for non-Responses sources", req.Payload)
+	}
+}
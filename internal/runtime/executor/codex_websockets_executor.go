@@ -38,6 +38,8 @@ const (
 	codexResponsesWebsocketBetaHeaderValue = "responses_websockets=2026-02-06"
 	codexResponsesWebsocketIdleTimeout     = 5 * time.Minute
 	codexResponsesWebsocketHandshakeTO     = 30 * time.Second
+	codexResponsesWebsocketDialAttempts    = 2
+	codexResponsesWebsocketRedialDelay     = 250 * time.Millisecond
 )
 
 // CodexWebsocketsExecutor executes Codex Responses requests using a WebSocket transport.
@@ -189,6 +191,7 @@ func (e *CodexWebsocketsExecutor) Execute(ctx context.Context, auth *cliproxyaut
 	defer reporter.TrackFailure(ctx, &err)
 
 	from := opts.SourceFormat
+	req = attachCodexSessionConversation(from, req, opts)
 	responseFormat := cliproxyexecutor.ResponseFormatOrSource(opts)
 	to := sdktranslator.FromString("codex")
 	originalPayloadSource := req.Payload
@@ -262,19 +265,21 @@ func (e *CodexWebsocketsExecutor) Execute(ctx context.Context, auth *cliproxyaut
 	}
 	helps.RecordAPIWebsocketRequest(ctx, e.cfg, wsReqLog)
 
-	conn, respHS, errDial := e.ensureUpstreamConn(ctx, auth, sess, authID, wsURL, wsHeaders)
+	conn, respHS, errDial := e.dialUpstreamConnWithReconnect(ctx, auth, sess, authID, wsURL, wsHeaders)
 	if errDial != nil {
 		bodyErr := websocketHandshakeBody(respHS)
 		if respHS != nil {
 			helps.RecordAPIWebsocketUpgradeRejection(ctx, e.cfg, websocketUpgradeRequestLog(wsReqLog), respHS.StatusCode, respHS.Header.Clone(), bodyErr)
+		} else {
+			helps.RecordAPIWebsocketError(ctx, e.cfg, "dial", errDial)
 		}
-		if respHS != nil && respHS.StatusCode == http.StatusUpgradeRequired {
+		if ctx.Err() == nil && codexWebsocketFallbackToHTTP(respHS) {
+			log.Warnf("codex websockets executor: websocket upgrade for auth %s failed, falling back to HTTP: %v", authID, errDial)
 			return e.CodexExecutor.Execute(ctx, auth, req, opts)
 		}
 		if respHS != nil && respHS.StatusCode > 0 {
 			return resp, statusErr{code: respHS.StatusCode, msg: string(bodyErr)}
 		}
-		helps.RecordAPIWebsocketError(ctx, e.cfg, "dial", errDial)
 		return resp, errDial
 	}
 	recordAPIWebsocketHandshake(ctx, e.cfg, respHS)
@@ -414,6 +419,7 @@ func (e *CodexWebsocketsExecutor) ExecuteStream(ctx context.Context, auth *clipr
 	defer reporter.TrackFailure(ctx, &err)
 
 	from := opts.SourceFormat
+	req = attachCodexSessionConversation(from, req, opts)
 	responseFormat := cliproxyexecutor.ResponseFormatOrSource(opts)
 	to := sdktranslator.FromString("codex")
 	body := req.Payload
@@ -482,26 +488,28 @@ func (e *CodexWebsocketsExecutor) ExecuteStream(ctx context.Context, auth *clipr
 	}
 	helps.RecordAPIWebsocketRequest(ctx, e.cfg, wsReqLog)
 
-	conn, respHS, errDial := e.ensureUpstreamConn(ctx, auth, sess, authID, wsURL, wsHeaders)
+	conn, respHS, errDial := e.dialUpstreamConnWithReconnect(ctx, auth, sess, authID, wsURL, wsHeaders)
 	var upstreamHeaders http.Header
 	if respHS != nil {
 		upstreamHeaders = respHS.Header.Clone()
 	}
 	if errDial != nil {
+		if sess != nil {
+			sess.reqMu.Unlock()
+		}
 		bodyErr := websocketHandshakeBody(respHS)
 		if respHS != nil {
 			helps.RecordAPIWebsocketUpgradeRejection(ctx, e.cfg, websocketUpgradeRequestLog(wsReqLog), respHS.StatusCode, respHS.Header.Clone(), bodyErr)
+		} else {
+			helps.RecordAPIWebsocketError(ctx, e.cfg, "dial", errDial)
 		}
-		if respHS != nil && respHS.StatusCode == http.StatusUpgradeRequired {
+		if ctx.Err() == nil && codexWebsocketFallbackToHTTP(respHS) {
+			log.Warnf("codex websockets executor: websocket upgrade for auth %s failed, falling back to HTTP: %v", authID, errDial)
 			return e.CodexExecutor.ExecuteStream(ctx, auth, req, opts)
 		}
 		if respHS != nil && respHS.StatusCode > 0 {
 			return nil, statusErr{code: respHS.StatusCode, msg: string(bodyErr)}
 		}
-		helps.RecordAPIWebsocketError(ctx, e.cfg, "dial", errDial)
-		if sess != nil {
-			sess.reqMu.Unlock()
-		}
 		return nil, errDial
 	}
 	recordAPIWebsocketHandshake(ctx, e.cfg, respHS)
@@ -717,6 +725,49 @@ func (e *CodexWebsocketsExecutor) dialCodexWebsocket(ctx context.Context, auth *
 	return conn, resp, err
 }
 
+// dialUpstreamConnWithReconnect opens the upstream websocket, dialing again after a short delay
+// when the first attempt fails before any handshake response was received.
+func (e *CodexWebsocketsExecutor) dialUpstreamConnWithReconnect(ctx context.Context, auth *cliproxyauth.Auth, sess *codexWebsocketSession, authID string, wsURL string, headers http.Header) (*websocket.Conn, *http.Response, error) {
+	var (
+		conn    *websocket.Conn
+		resp    *http.Response
+		errDial error
+	)
+	for attempt := 1; attempt <= codexResponsesWebsocketDialAttempts; attempt++ {
+		conn, resp, errDial = e.ensureUpstreamConn(ctx, auth, sess, authID, wsURL, headers)
+		if errDial == nil || resp != nil || attempt == codexResponsesWebsocketDialAttempts {
+			break
+		}
+		log.Debugf("codex websockets executor: dial %s failed (attempt %d): %v", wsURL, attempt, errDial)
+		timer := time.NewTimer(codexResponsesWebsocketRedialDelay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, nil, errDial
+		case <-timer.C:
+		}
+	}
+	return conn, resp, errDial
+}
+
+// codexWebsocketFallbackToHTTP reports whether a failed websocket upgrade should be retried over
+// the HTTP transport. Connection failures, 426, 429 and 5xx fall back: the HTTP endpoint is
+// throttled separately from the websocket one. Auth and request errors are returned as is so the
+// caller can refresh or cool down the credential.
+func codexWebsocketFallbackToHTTP(resp *http.Response) bool {
+	if resp == nil || resp.StatusCode <= 0 {
+		return true
+	}
+	switch {
+	case resp.StatusCode == http.StatusUpgradeRequired, resp.StatusCode == http.StatusTooManyRequests:
+		return true
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return true
+	default:
+		return false
+	}
+}
+
 func writeCodexWebsocketMessage(sess *codexWebsocketSession, conn *websocket.Conn, payload []byte) error {
 	if sess != nil {
 		return sess.writeMessage(conn, websocket.TextMessage, payload)
@@ -1535,6 +1586,7 @@ func (e *CodexWebsocketsExecutor) CloseExecutionSession(sessionID string) {
 		// downstream websocket requests get interrupted.
 		return
 	}
+	globalCodexSessionConversations.forget(sessionID)
 
 	store := e.store
 	if store == nil {
@@ -1032,3 +1032,60 @@ func TestNewProxyAwareWebsocketDialerDirectDisablesProxy(t *testing.T) {
 		t.Fatal("expected websocket proxy function to be nil for direct mode")
 	}
 }
+
+func TestCodexWebsocketFallbackToHTTP(t *testing.T) {
+	cases := []struct {
+		resp *http.Response
+		want bool
+	}{
+		{resp: nil, want: true},
+		{resp: &http.Response{StatusCode: http.StatusUpgradeRequired}, want: true},
+		{resp: &http.Response{StatusCode: http.StatusTooManyRequests}, want: true},
+		{resp: &http.Response{StatusCode: http.StatusBadGateway}, want: true},
+		{resp: &http.Response{StatusCode: http.StatusUnauthorized}, want: false},
+		{resp: &http.Response{StatusCode: http.StatusBadRequest}, want: false},
+	}
+	for _, tc := range cases {
+		status := 0
+		if tc.resp != nil {
+			status = tc.resp.StatusCode
+		}
+		if got := codexWebsocketFallbackToHTTP(tc.resp); got != tc.want {
+			t.Fatalf("codexWebsocketFallbackToHTTP(status %d) = %t, want %t", status, got, tc.want)
+		}
+	}
+}
+
+func TestCodexWebsocketsExecuteFallsBackToHTTPWhenUpgradeThrottled(t *testing.T) {
+	var upgrades, posts int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if websocket.IsWebSocketUpgrade(r) {
+			upgrades++
+			http.Error(w, `{"error":{"message":"slow down"}}`, http.StatusTooManyRequests)
+			return
+		}
+		posts++
+		w.Header().Set("Content-Type", "text/event-stream")
+		_, _ = w.Write([]byte("data: {\"type\":\"response.completed\",\"response\":{\"id\":\"resp-http\",\"output\":[],\"usage\":{\"input_tokens\":0,\"output_tokens\":0,\"total_tokens\":0}}}\n\n"))
+	}))
+	defer server.Close()
+
+	exec := NewCodexWebsocketsExecutor(&config.Config{SDKConfig: config.SDKConfig{DisableImageGeneration: config.DisableImageGenerationAll}})
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{"api_key": "sk-test", "base_url": server.URL}}
+	req := cliproxyexecutor.Request{
+		Model:   "gpt-5-codex",
+		Payload: []byte(`{"model":"gpt-5-codex","input":[{"type":"message","id":"msg-1"}]}`),
+	}
+	opts := cliproxyexecutor.Options{SourceFormat: sdktranslator.FromString("codex")}
+
+	resp, err := exec.Execute(context.Background(), auth, req, opts)
+	if err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+	if upgrades != 1 || posts != 1 {
+		t.Fatalf("upgrades = %d, posts = %d, want one of each", upgrades, posts)
+	}
+	if got := gjson.GetBytes(resp.Payload, "response.id").String(); got != "resp-http" {
+		t.Fatalf("response id = %q, want resp-http; payload=%s", got, resp.Payload)
+	}
+}
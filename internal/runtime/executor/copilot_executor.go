@@ -15,6 +15,7 @@ import (
 	"time"
 
 	copilotauth "github.com/router-for-me/CLIProxyAPI/v7/internal/auth/copilot"
+	internalcache "github.com/router-for-me/CLIProxyAPI/v7/internal/cache"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/runtime/executor/helps"
@@ -31,9 +32,8 @@ import (
 // It manages token refresh and proper header injection for Copilot requests.
 type CopilotExecutor struct {
 	cfg            *config.Config
-	tokenMu        sync.RWMutex
 	mu             sync.Mutex
-	tokenCache     map[string]*cachedToken
+	tokenCache     internalcache.Cache[cachedToken]
 	modelMu        sync.Mutex
 	initiatorCount map[string]uint64
 }
@@ -44,22 +44,23 @@ type cachedToken struct {
 	expiresAt time.Time
 }
 
-// modelCacheEntry stores cached models.
-// Shared model cache across executor instances (survives executor recreation).
+// sharedModelCache holds fetched models per auth ID across executor instances (survives
+// executor recreation) and, with a shared-cache backend, across replicas.
 var (
-	sharedModelCacheMu sync.Mutex
-	sharedModelCache   = make(map[string]*sharedModelCacheEntry)
+	sharedModelCache = internalcache.New[[]*registry.ModelInfo](internalcache.Options{
+		Name:       "copilot-models",
+		TTL:        sharedModelCacheTTL,
+		MaxEntries: sharedModelCacheMaxEntries,
+		Shared:     true,
+	})
 
 	copilotAuthLockMapMu sync.Mutex
 	copilotAuthLocks     = make(map[string]*sync.Mutex)
 )
 
-type sharedModelCacheEntry struct {
-	models    []*registry.ModelInfo
-	fetchedAt time.Time
-}
-
 const sharedModelCacheTTL = 30 * time.Minute
+const sharedModelCacheMaxEntries = 1024
+const copilotTokenCacheMaxEntries = 1024
 const defaultCopilotStreamReadBufferSize = 64 * 1024
 const defaultCopilotStreamMaxAttempts = 2
 const defaultCopilotStreamIdleBudget = 0
@@ -68,8 +69,11 @@ const defaultCopilotStreamIdleBudget = 0
 
 func NewCopilotExecutor(cfg *config.Config) *CopilotExecutor {
 	return &CopilotExecutor{
-		cfg:            cfg,
-		tokenCache:     make(map[string]*cachedToken),
+		cfg: cfg,
+		tokenCache: internalcache.New[cachedToken](internalcache.Options{
+			Name:       "copilot-tokens",
+			MaxEntries: copilotTokenCacheMaxEntries,
+		}),
 		initiatorCount: make(map[string]uint64),
 	}
 }
@@ -271,7 +275,7 @@ func (e *CopilotExecutor) HttpRequest(ctx context.Context, auth *cliproxyauth.Au
 // cache when auth is nil/unknown. This keeps Gemini reasoning warm across reauths.
 func (e *CopilotExecutor) reasoningCache(auth *cliproxyauth.Auth) *geminiReasoningCache {
 	if auth == nil || strings.TrimSpace(auth.ID) == "" {
-		return newGeminiReasoningCache()
+		return newGeminiReasoningCache("")
 	}
 	return getSharedGeminiReasoningCache(strings.TrimSpace(auth.ID))
 }
@@ -505,6 +509,51 @@ func setCopilotReasoningEffort(payload []byte, effort string) []byte {
 	return payload
 }
 
+// copilotUsesResponsesEndpoint reports whether model is configured to be served through
+// Copilot's native /responses endpoint. Comparisons ignore case and the copilot- prefix.
+func copilotUsesResponsesEndpoint(entry *config.CopilotKey, model string) bool {
+	if entry == nil || len(entry.ResponsesModels) == 0 {
+		return false
+	}
+	m := strings.TrimPrefix(normalizeModelID(model), "copilot-")
+	for _, v := range entry.ResponsesModels {
+		if strings.TrimPrefix(normalizeModelID(v), "copilot-") == m {
+			return true
+		}
+	}
+	return false
+}
+
+// prepareCopilotResponsesPayload adjusts a Responses API payload for Copilot's /responses
+// endpoint. Copilot keeps no server-side state, so reasoning is requested as encrypted content
+// and replayed by the client on the next turn instead of referenced by response id.
+func prepareCopilotResponsesPayload(body []byte) []byte {
+	body, _ = sjson.SetBytes(body, "store", false)
+	body, _ = sjson.DeleteBytes(body, "previous_response_id")
+	body, _ = sjson.DeleteBytes(body, "stream_options")
+	for _, item := range gjson.GetBytes(body, "include").Array() {
+		if item.String() == "reasoning.encrypted_content" {
+			return body
+		}
+	}
+	if !gjson.GetBytes(body, "include").IsArray() {
+		body, _ = sjson.SetRawBytes(body, "include", []byte(`[]`))
+	}
+	body, _ = sjson.SetBytes(body, "include.-1", "reasoning.encrypted_content")
+	return body
+}
+
+// copilotResponsesCompletedEvent wraps a non-streaming Responses reply in the
+// response.completed event the Responses translators expect.
+func copilotResponsesCompletedEvent(data []byte) []byte {
+	if gjson.GetBytes(data, "type").String() == "response.completed" {
+		return data
+	}
+	event := []byte(`{"type":"response.completed"}`)
+	event, _ = sjson.SetRawBytes(event, "response", data)
+	return event
+}
+
 // sanitizeCopilotPayload removes fields that Copilot's Chat Completions endpoint
 // rejects (strip max_tokens and parallel_tool_calls).
 func sanitizeCopilotPayload(body []byte, model string) []byte {
@@ -547,13 +596,21 @@ func (e *CopilotExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth,
 	reporter := helps.NewUsageReporter(ctx, e.Identifier(), apiModel, auth)
 	defer reporter.TrackFailure(ctx, &err)
 
+	useResponses := copilotUsesResponsesEndpoint(e.copilotKeyConfig(), apiModel)
 	from := opts.SourceFormat
 	to := sdktranslator.FromString("openai")
+	if useResponses {
+		to = sdktranslator.FromString("codex")
+	}
 
 	requestedModel := helps.PayloadRequestedModel(opts, req.Model)
 	body := sdktranslator.TranslateRequest(from, to, apiModel, bytes.Clone(req.Payload), false)
 	body = helps.ApplyPayloadConfigWithRoot(e.cfg, apiModel, to.String(), "", body, nil, requestedModel, "")
-	body = sanitizeCopilotPayload(body, apiModel)
+	if useResponses {
+		body = prepareCopilotResponsesPayload(body)
+	} else {
+		body = sanitizeCopilotPayload(body, apiModel)
+	}
 	body, _ = sjson.SetBytes(body, "stream", false)
 
 	// Apply reasoning effort from alias if resolved
@@ -570,6 +627,9 @@ func (e *CopilotExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth,
 
 	baseURL := copilotauth.CopilotBaseURL(accountType)
 	url := baseURL + "/chat/completions"
+	if useResponses {
+		url = baseURL + "/responses"
+	}
 
 	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
 	if err != nil {
@@ -625,7 +685,15 @@ func (e *CopilotExecutor) Execute(ctx context.Context, auth *cliproxyauth.Auth,
 	helps.AppendAPIResponseChunk(ctx, e.cfg, data)
 
 	// Parse usage from response
-	reporter.Publish(ctx, helps.ParseOpenAIUsage(data))
+	if useResponses {
+		// The translators consume a non-streaming Responses reply as its response.completed event.
+		data = copilotResponsesCompletedEvent(data)
+		if detail, ok := helps.ParseCodexUsage(data); ok {
+			reporter.Publish(ctx, detail)
+		}
+	} else {
+		reporter.Publish(ctx, helps.ParseOpenAIUsage(data))
+	}
 
 	var param any
 	out := sdktranslator.TranslateNonStream(ctx, to, from, translatorModel, bytes.Clone(opts.OriginalRequest), body, data, &param)
@@ -656,13 +724,21 @@ func (e *CopilotExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.
 	reporter := helps.NewUsageReporter(ctx, e.Identifier(), apiModel, auth)
 	defer reporter.TrackFailure(ctx, &err)
 
+	useResponses := copilotUsesResponsesEndpoint(e.copilotKeyConfig(), apiModel)
 	from := opts.SourceFormat
 	to := sdktranslator.FromString("openai")
+	if useResponses {
+		to = sdktranslator.FromString("codex")
+	}
 
 	requestedModel := helps.PayloadRequestedModel(opts, req.Model)
 	body := sdktranslator.TranslateRequest(from, to, apiModel, bytes.Clone(req.Payload), true)
 	body = helps.ApplyPayloadConfigWithRoot(e.cfg, apiModel, to.String(), "", body, nil, requestedModel, "")
-	body = sanitizeCopilotPayload(body, apiModel)
+	if useResponses {
+		body = prepareCopilotResponsesPayload(body)
+	} else {
+		body = sanitizeCopilotPayload(body, apiModel)
+	}
 	body, _ = sjson.SetBytes(body, "stream", true)
 
 	// Apply reasoning effort from alias if resolved
@@ -679,6 +755,9 @@ func (e *CopilotExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.
 
 	baseURL := copilotauth.CopilotBaseURL(accountType)
 	url := baseURL + "/chat/completions"
+	if useResponses {
+		url = baseURL + "/responses"
+	}
 
 	var authID, authLabel, authType, authValue string
 	if auth != nil {
@@ -765,7 +844,11 @@ func (e *CopilotExecutor) ExecuteStream(ctx context.Context, auth *cliproxyauth.
 				// Parse usage from final chunk if present
 				if bytes.HasPrefix(line, dataTag) {
 					data := bytes.TrimSpace(line[5:])
-					if gjson.GetBytes(data, "usage").Exists() {
+					if useResponses {
+						if detail, ok := helps.ParseCodexUsage(data); ok {
+							reporter.Publish(ctx, detail)
+						}
+					} else if gjson.GetBytes(data, "usage").Exists() {
 						reporter.Publish(ctx, helps.ParseOpenAIUsage(data))
 					}
 
@@ -1098,12 +1181,7 @@ func (e *CopilotExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Auth)
 	}
 
 	expiresAt := time.Unix(tokenResp.ExpiresAt, 0)
-	e.tokenMu.Lock()
-	e.tokenCache[githubToken] = &cachedToken{
-		token:     tokenResp.Token,
-		expiresAt: expiresAt,
-	}
-	e.tokenMu.Unlock()
+	e.setCachedToken(githubToken, tokenResp.Token, expiresAt)
 
 	if auth.Metadata == nil {
 		auth.Metadata = make(map[string]any)
@@ -1111,6 +1189,11 @@ func (e *CopilotExecutor) Refresh(ctx context.Context, auth *cliproxyauth.Auth)
 	auth.Metadata["copilot_token"] = tokenResp.Token
 	auth.Metadata["copilot_token_expiry"] = expiresAt.Format(time.RFC3339)
 	auth.Metadata["type"] = "copilot"
+	if usage, errUsage := authSvc.GetCopilotUsage(ctx, githubToken); errUsage == nil {
+		auth.Metadata[cliproxyauth.QuotaSnapshotMetadataKey] = usage
+	} else {
+		log.Debugf("copilot executor: usage fetch failed auth_id=%s: %v", auth.ID, errUsage)
+	}
 
 	log.Debug("Copilot token refreshed successfully")
 	return auth, nil
@@ -1168,9 +1251,7 @@ func (e *CopilotExecutor) getCopilotToken(ctx context.Context, auth *cliproxyaut
 }
 
 func (e *CopilotExecutor) getValidCachedToken(githubToken string) (string, bool) {
-	e.tokenMu.RLock()
-	defer e.tokenMu.RUnlock()
-	if cached, ok := e.tokenCache[githubToken]; ok {
+	if cached, ok := e.tokenCache.Get(githubToken); ok {
 		if time.Now().Add(60 * time.Second).Before(cached.expiresAt) {
 			return cached.token, true
 		}
@@ -1179,12 +1260,15 @@ func (e *CopilotExecutor) getValidCachedToken(githubToken string) (string, bool)
 }
 
 func (e *CopilotExecutor) setCachedToken(githubToken, token string, expiresAt time.Time) {
-	e.tokenMu.Lock()
-	defer e.tokenMu.Unlock()
-	e.tokenCache[githubToken] = &cachedToken{
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		e.tokenCache.Delete(githubToken)
+		return
+	}
+	e.tokenCache.SetWithTTL(githubToken, cachedToken{
 		token:     token,
 		expiresAt: expiresAt,
-	}
+	}, ttl)
 }
 
 // CountTokens provides a token count estimate for Copilot models.
@@ -1241,23 +1325,12 @@ func (e *CopilotExecutor) CountTokens(ctx context.Context, auth *cliproxyauth.Au
 }
 
 func getCachedCopilotModels(authID string) []*registry.ModelInfo {
-	sharedModelCacheMu.Lock()
-	defer sharedModelCacheMu.Unlock()
-	if entry, ok := sharedModelCache[authID]; ok {
-		if time.Since(entry.fetchedAt) < sharedModelCacheTTL {
-			return entry.models
-		}
-	}
-	return nil
+	models, _ := sharedModelCache.Get(authID)
+	return models
 }
 
 func setCachedCopilotModels(authID string, models []*registry.ModelInfo) {
-	sharedModelCacheMu.Lock()
-	defer sharedModelCacheMu.Unlock()
-	sharedModelCache[authID] = &sharedModelCacheEntry{
-		fetchedAt: time.Now(),
-		models:    models,
-	}
+	sharedModelCache.Set(authID, models)
 }
 
 func copilotAuthLockKey(auth *cliproxyauth.Auth) string {
@@ -1304,9 +1377,7 @@ func EvictCopilotModelCache(authID string) {
 	if authID == "" {
 		return
 	}
-	sharedModelCacheMu.Lock()
-	delete(sharedModelCache, authID)
-	sharedModelCacheMu.Unlock()
+	sharedModelCache.Delete(authID)
 }
 
 func (e *CopilotExecutor) FetchModels(ctx context.Context, auth *cliproxyauth.Auth, cfg *config.Config) ([]*registry.ModelInfo, error) {
@@ -1348,9 +1419,7 @@ func (e *CopilotExecutor) FetchModels(ctx context.Context, auth *cliproxyauth.Au
 		}
 
 		expiresAt := time.Unix(tokenResp.ExpiresAt, 0)
-		e.tokenMu.Lock()
-		e.tokenCache[githubToken] = &cachedToken{token: tokenResp.Token, expiresAt: expiresAt}
-		e.tokenMu.Unlock()
+		e.setCachedToken(githubToken, tokenResp.Token, expiresAt)
 
 		if auth.Metadata == nil {
 			auth.Metadata = make(map[string]any)
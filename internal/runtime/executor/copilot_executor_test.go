@@ -11,6 +11,8 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
 	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+	"github.com/tidwall/gjson"
 )
 
 // TestStripCopilotPrefix verifies that the copilot- prefix is correctly stripped from model names.
@@ -715,3 +717,46 @@ func TestMaskToken(t *testing.T) {
 		})
 	}
 }
+
+// TestCopilotUsesResponsesEndpoint verifies per-model routing to the native /responses endpoint.
+func TestCopilotUsesResponsesEndpoint(t *testing.T) {
+	entry := &config.CopilotKey{ResponsesModels: []string{"GPT-5-Codex", "copilot-gpt-5.1-codex"}}
+
+	for model, want := range map[string]bool{
+		"gpt-5-codex":         true,
+		"copilot-gpt-5-codex": true,
+		"gpt-5.1-codex":       true,
+		"gpt-5":               false,
+	} {
+		if got := copilotUsesResponsesEndpoint(entry, model); got != want {
+			t.Errorf("copilotUsesResponsesEndpoint(%q) = %v, want %v", model, got, want)
+		}
+	}
+	if copilotUsesResponsesEndpoint(nil, "gpt-5-codex") {
+		t.Error("copilotUsesResponsesEndpoint without config = true, want false")
+	}
+}
+
+// TestPrepareCopilotResponsesPayloadKeepsEncryptedReasoning verifies that encrypted reasoning
+// from a previous turn is forwarded and requested again for the next one.
+func TestPrepareCopilotResponsesPayloadKeepsEncryptedReasoning(t *testing.T) {
+	payload := []byte(`{"model":"gpt-5-codex","previous_response_id":"resp_1","input":[{"type":"message","role":"user","content":[{"type":"input_text","text":"hi"}]},{"type":"reasoning","summary":[],"encrypted_content":"gAAAA-opaque"},{"type":"message","role":"user","content":[{"type":"input_text","text":"next"}]}]}`)
+	body := sdktranslator.TranslateRequest(sdktranslator.FromString("openai-response"), sdktranslator.FromString("codex"), "gpt-5-codex", payload, true)
+	body = prepareCopilotResponsesPayload(body)
+
+	if got := gjson.GetBytes(body, `input.#(type=="reasoning").encrypted_content`).String(); got != "gAAAA-opaque" {
+		t.Fatalf("reasoning encrypted_content = %q, want it preserved. body=%s", got, body)
+	}
+	includes := gjson.GetBytes(body, "include").Array()
+	if len(includes) != 1 || includes[0].String() != "reasoning.encrypted_content" {
+		t.Fatalf("include = %s, want [reasoning.encrypted_content]", gjson.GetBytes(body, "include").Raw)
+	}
+	if gjson.GetBytes(body, "store").Bool() || gjson.GetBytes(body, "previous_response_id").Exists() {
+		t.Fatalf("stateless fields not applied: %s", body)
+	}
+
+	event := copilotResponsesCompletedEvent([]byte(`{"id":"resp_2","output":[]}`))
+	if gjson.GetBytes(event, "type").String() != "response.completed" || gjson.GetBytes(event, "response.id").String() != "resp_2" {
+		t.Fatalf("completed event = %s", event)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"sync"
 	"time"
 
+	internalcache "github.com/router-for-me/CLIProxyAPI/v7/internal/cache"
 	log "github.com/sirupsen/logrus"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
@@ -12,7 +13,7 @@ import (
 
 type geminiReasoningCache struct {
 	mu    sync.RWMutex
-	cache map[string]*geminiReasoning
+	cache internalcache.Cache[*geminiReasoning]
 }
 
 type geminiReasoning struct {
@@ -22,30 +23,40 @@ type geminiReasoning struct {
 }
 
 const geminiReasoningTTL = 30 * time.Minute
+const geminiReasoningMaxEntries = 1000
 
 var (
 	sharedGeminiReasoningMu sync.Mutex
 	sharedGeminiReasoning   = make(map[string]*geminiReasoningCache)
 )
 
-func newGeminiReasoningCache() *geminiReasoningCache {
+// newGeminiReasoningCache builds a reasoning cache; a non-empty name lists it in cache stats.
+func newGeminiReasoningCache(name string) *geminiReasoningCache {
 	return &geminiReasoningCache{
-		cache: make(map[string]*geminiReasoning),
+		cache: internalcache.New[*geminiReasoning](internalcache.Options{
+			Name:       name,
+			TTL:        geminiReasoningTTL,
+			MaxEntries: geminiReasoningMaxEntries,
+		}),
 	}
 }
 
+func geminiReasoningCacheName(authID string) string {
+	return "copilot-gemini-reasoning:" + authID
+}
+
 // getSharedGeminiReasoningCache returns a cache keyed by authID to preserve
 // reasoning data across executor re-creations (e.g., after reauth).
 func getSharedGeminiReasoningCache(authID string) *geminiReasoningCache {
 	if authID == "" {
-		return newGeminiReasoningCache()
+		return newGeminiReasoningCache("")
 	}
 	sharedGeminiReasoningMu.Lock()
 	defer sharedGeminiReasoningMu.Unlock()
 	if cache, ok := sharedGeminiReasoning[authID]; ok && cache != nil {
 		return cache
 	}
-	cache := newGeminiReasoningCache()
+	cache := newGeminiReasoningCache(geminiReasoningCacheName(authID))
 	sharedGeminiReasoning[authID] = cache
 	return cache
 }
@@ -58,6 +69,7 @@ func EvictCopilotGeminiReasoningCache(authID string) {
 	sharedGeminiReasoningMu.Lock()
 	delete(sharedGeminiReasoning, authID)
 	sharedGeminiReasoningMu.Unlock()
+	internalcache.Unregister(geminiReasoningCacheName(authID))
 }
 
 // InjectReasoning inserts cached reasoning fields back into assistant messages
@@ -72,7 +84,7 @@ func (c *geminiReasoningCache) InjectReasoning(body []byte) []byte {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	if len(c.cache) == 0 {
+	if c.cache.Len() == 0 {
 		log.Debug("copilot executor: no cached Gemini reasoning available")
 		return body
 	}
@@ -107,7 +119,7 @@ func (c *geminiReasoningCache) InjectReasoning(body []byte) []byte {
 			return true
 		}
 
-		reasoning := c.cache[callID]
+		reasoning, _ := c.cache.Get(callID)
 		if reasoning == nil || (reasoning.Opaque == "" && reasoning.Text == "") {
 			log.Debugf("copilot executor: no cached reasoning for call_id %s", callID)
 			return true
@@ -159,35 +171,25 @@ func (c *geminiReasoningCache) CacheReasoning(data []byte) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	// Lazy eviction: simple random cleanup if cache gets too big
-	if len(c.cache) > 1000 {
-		now := time.Now()
-		for k, v := range c.cache {
-			if now.Sub(v.createdAt) > geminiReasoningTTL {
-				delete(c.cache, k)
-			}
-		}
-	}
-
 	if callID == "" {
 		return
 	}
 
 	log.Debugf("copilot executor: caching Gemini reasoning for call_id %s (opaque=%d chars, text=%d chars)", callID, len(opaque), len(text))
 
-	if c.cache[callID] == nil {
-		c.cache[callID] = &geminiReasoning{
-			createdAt: time.Now(),
-		}
+	reasoning, ok := c.cache.Get(callID)
+	if !ok || reasoning == nil {
+		reasoning = &geminiReasoning{}
 	}
 
 	// Only update if we got new values
 	if opaque != "" {
-		c.cache[callID].Opaque = opaque
+		reasoning.Opaque = opaque
 	}
 	if text != "" {
 		// Append text since it comes in chunks
-		c.cache[callID].Text += text
+		reasoning.Text += text
 	}
-	c.cache[callID].createdAt = time.Now()
+	reasoning.createdAt = time.Now()
+	c.cache.Set(callID, reasoning)
 }
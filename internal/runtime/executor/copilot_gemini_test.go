@@ -8,21 +8,22 @@ import (
 )
 
 func TestGeminiReasoningCache_CacheAndInject(t *testing.T) {
-	cache := newGeminiReasoningCache()
+	cache := newGeminiReasoningCache("")
 
 	// Cache reasoning from a streaming delta
 	delta := `{"choices":[{"delta":{"tool_calls":[{"id":"call_123"}],"reasoning_opaque":"opaque_data","reasoning_text":"thinking..."}}]}`
 	cache.CacheReasoning([]byte(delta))
 
 	// Verify it was cached
-	if cache.cache["call_123"] == nil {
+	cached, ok := cache.cache.Get("call_123")
+	if !ok || cached == nil {
 		t.Fatal("reasoning was not cached")
 	}
-	if cache.cache["call_123"].Opaque != "opaque_data" {
-		t.Errorf("Opaque = %q, want %q", cache.cache["call_123"].Opaque, "opaque_data")
+	if cached.Opaque != "opaque_data" {
+		t.Errorf("Opaque = %q, want %q", cached.Opaque, "opaque_data")
 	}
-	if cache.cache["call_123"].Text != "thinking..." {
-		t.Errorf("Text = %q, want %q", cache.cache["call_123"].Text, "thinking...")
+	if cached.Text != "thinking..." {
+		t.Errorf("Text = %q, want %q", cached.Text, "thinking...")
 	}
 
 	// Inject into a request body
@@ -39,7 +40,7 @@ func TestGeminiReasoningCache_CacheAndInject(t *testing.T) {
 }
 
 func TestGeminiReasoningCache_TextAppends(t *testing.T) {
-	cache := newGeminiReasoningCache()
+	cache := newGeminiReasoningCache("")
 
 	// Simulate streaming chunks
 	chunk1 := `{"choices":[{"delta":{"tool_calls":[{"id":"call_456"}],"reasoning_text":"Hello "}}]}`
@@ -48,13 +49,14 @@ func TestGeminiReasoningCache_TextAppends(t *testing.T) {
 	cache.CacheReasoning([]byte(chunk1))
 	cache.CacheReasoning([]byte(chunk2))
 
-	if cache.cache["call_456"].Text != "Hello World" {
-		t.Errorf("Text = %q, want %q", cache.cache["call_456"].Text, "Hello World")
+	cached, _ := cache.cache.Get("call_456")
+	if cached == nil || cached.Text != "Hello World" {
+		t.Errorf("cached reasoning = %+v, want Text %q", cached, "Hello World")
 	}
 }
 
 func TestGeminiReasoningCache_NoInjectWhenAlreadyPresent(t *testing.T) {
-	cache := newGeminiReasoningCache()
+	cache := newGeminiReasoningCache("")
 
 	delta := `{"choices":[{"delta":{"tool_calls":[{"id":"call_789"}],"reasoning_opaque":"cached"}}]}`
 	cache.CacheReasoning([]byte(delta))
@@ -70,13 +72,13 @@ func TestGeminiReasoningCache_NoInjectWhenAlreadyPresent(t *testing.T) {
 }
 
 func TestGeminiReasoningCache_TTLExpiry(t *testing.T) {
-	cache := newGeminiReasoningCache()
+	cache := newGeminiReasoningCache("")
 
 	// Manually insert expired entry
-	cache.cache["expired_call"] = &geminiReasoning{
+	cache.cache.Set("expired_call", &geminiReasoning{
 		Opaque:    "old_data",
 		createdAt: time.Now().Add(-31 * time.Minute), // expired
-	}
+	})
 
 	body := `{"messages":[{"role":"assistant","tool_calls":[{"id":"expired_call"}]}]}`
 	result := cache.InjectReasoning([]byte(body))
@@ -91,14 +93,14 @@ func TestEvictCopilotGeminiReasoningCache(t *testing.T) {
 	// Setup shared cache
 	testAuthID := "test-auth-evict"
 	cache := getSharedGeminiReasoningCache(testAuthID)
-	cache.cache["test"] = &geminiReasoning{Opaque: "data", createdAt: time.Now()}
+	cache.cache.Set("test", &geminiReasoning{Opaque: "data", createdAt: time.Now()})
 
 	// Evict
 	EvictCopilotGeminiReasoningCache(testAuthID)
 
 	// Get again - should be fresh
 	newCache := getSharedGeminiReasoningCache(testAuthID)
-	if len(newCache.cache) != 0 {
+	if newCache.cache.Len() != 0 {
 		t.Error("cache was not evicted")
 	}
 }
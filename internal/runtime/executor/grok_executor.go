@@ -405,6 +405,9 @@ func (e *GrokExecutor) buildGrokPayload(ctx context.Context, req cliproxyexecuto
 	from := opts.SourceFormat
 	to := sdktranslator.FromString("grok")
 	body := sdktranslator.TranslateRequest(from, to, req.Model, bytes.Clone(req.Payload), stream)
+	if e.cfg != nil && e.cfg.Grok.LegacyToolPrompt {
+		body = groktranslator.ApplyLegacyToolPrompt(body, req.Payload)
+	}
 	apiModel := stripGrokPrefix(req.Model)
 	if apiModel == "" {
 		apiModel = req.Model
@@ -47,6 +47,7 @@ type upstreamAttempt struct {
 	responseSource       *logging.FileBodySource
 	responseIntroWritten bool
 	statusWritten        bool
+	connectionWritten    bool
 	headersWritten       bool
 	bodyStarted          bool
 	bodyHasContent       bool
@@ -68,6 +69,7 @@ func RecordAPIRequest(ctx context.Context, cfg *config.Config, info UpstreamRequ
 		return
 	}
 
+	logging.ResetConnectionStats(ctx)
 	attempts := getAttempts(ginCtx)
 	index := len(attempts) + 1
 
@@ -152,6 +154,7 @@ func RecordAPIResponseMetadata(ctx context.Context, cfg *config.Config, status i
 		writeAttemptResponse(ginCtx, attempt, []byte(fmt.Sprintf("Status: %d\n", status)))
 		attempt.statusWritten = true
 	}
+	writeAttemptConnection(ctx, ginCtx, attempt)
 	if !attempt.headersWritten {
 		builder := &strings.Builder{}
 		builder.WriteString("Headers:\n")
@@ -183,6 +186,7 @@ func RecordAPIResponseError(ctx context.Context, cfg *config.Config, err error)
 	if attempt.errorWritten {
 		writeAttemptResponse(ginCtx, attempt, []byte("\n"))
 	}
+	writeAttemptConnection(ctx, ginCtx, attempt)
 	writeAttemptResponse(ginCtx, attempt, []byte(fmt.Sprintf("Error: %s\n", err.Error())))
 	attempt.errorWritten = true
 
@@ -423,6 +427,20 @@ func ensureResponseIntro(ginCtx *gin.Context, attempt *upstreamAttempt) {
 	attempt.responseIntroWritten = true
 }
 
+// writeAttemptConnection logs how the attempt's upstream connection was set up, so slow responses
+// can be attributed to connection setup rather than model latency.
+func writeAttemptConnection(ctx context.Context, ginCtx *gin.Context, attempt *upstreamAttempt) {
+	if attempt == nil || attempt.connectionWritten {
+		return
+	}
+	stats, ok := logging.GetConnectionStats(ctx)
+	if !ok {
+		return
+	}
+	writeAttemptResponse(ginCtx, attempt, []byte(fmt.Sprintf("Connection: %s\n", stats)))
+	attempt.connectionWritten = true
+}
+
 func writeAttemptResponse(ginCtx *gin.Context, attempt *upstreamAttempt, payload []byte) {
 	if attempt == nil || len(payload) == 0 {
 		return
@@ -0,0 +1,213 @@
+package helps
+
+import (
+	"bytes"
+	"encoding/json"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// StreamTransform rewrites the JSON payload of upstream SSE data lines for
+// passthru routes that configure stream-transform.
+type StreamTransform struct {
+	renames [][2]string
+	deletes []string
+	sets    []streamTransformSet
+}
+
+type streamTransformSet struct {
+	path  string
+	value any
+}
+
+// streamTransformPath is a concrete path produced by expanding the "#"
+// segments of a configured path, with the array indices it expanded to.
+type streamTransformPath struct {
+	path    string
+	indices []int
+}
+
+// NewStreamTransform returns the stream transform configured for auth, or nil
+// when the route has none. A nil *StreamTransform leaves lines unchanged.
+func NewStreamTransform(auth *cliproxyauth.Auth) *StreamTransform {
+	if auth == nil || auth.Attributes == nil {
+		return nil
+	}
+	raw := strings.TrimSpace(auth.Attributes["stream_transform"])
+	if raw == "" {
+		return nil
+	}
+	var cfg config.PassthruStreamTransform
+	if err := json.Unmarshal([]byte(raw), &cfg); err != nil {
+		log.Warnf("stream transform: invalid configuration for auth %s: %v", auth.ID, err)
+		return nil
+	}
+	if cfg.Empty() {
+		return nil
+	}
+
+	t := &StreamTransform{}
+	for _, from := range sortedKeys(cfg.Rename) {
+		to := strings.TrimSpace(cfg.Rename[from])
+		from = strings.TrimSpace(from)
+		if from == "" || to == "" || from == to {
+			continue
+		}
+		if wildcardSegments(to) > wildcardSegments(from) {
+			log.Warnf("stream transform: rename %q -> %q uses more \"#\" segments in the target than the source; skipped", from, to)
+			continue
+		}
+		t.renames = append(t.renames, [2]string{from, to})
+	}
+	for _, path := range cfg.Delete {
+		if path = strings.TrimSpace(path); path != "" {
+			t.deletes = append(t.deletes, path)
+		}
+	}
+	for _, path := range sortedKeys(cfg.Set) {
+		if trimmed := strings.TrimSpace(path); trimmed != "" {
+			t.sets = append(t.sets, streamTransformSet{path: trimmed, value: cfg.Set[path]})
+		}
+	}
+	if len(t.renames) == 0 && len(t.deletes) == 0 && len(t.sets) == 0 {
+		return nil
+	}
+	return t
+}
+
+// Apply rewrites an SSE "data:" line whose payload is a JSON object. Other
+// lines, including "data: [DONE]", are returned unchanged.
+func (t *StreamTransform) Apply(line []byte) []byte {
+	if t == nil {
+		return line
+	}
+	trimmed := bytes.TrimSpace(line)
+	if !bytes.HasPrefix(trimmed, []byte("data:")) {
+		return line
+	}
+	data := bytes.TrimSpace(trimmed[len("data:"):])
+	if len(data) == 0 || data[0] != '{' || !gjson.ValidBytes(data) {
+		return line
+	}
+	out := make([]byte, 0, len(data)+len("data: "))
+	out = append(out, "data: "...)
+	return append(out, t.applyJSON(data)...)
+}
+
+func (t *StreamTransform) applyJSON(data []byte) []byte {
+	out := bytes.Clone(data)
+	for _, rename := range t.renames {
+		for _, source := range expandStreamTransformPath(out, rename[0]) {
+			value := gjson.GetBytes(out, source.path)
+			if !value.Exists() {
+				continue
+			}
+			target := substituteWildcards(rename[1], source.indices)
+			updated, errSet := sjson.SetRawBytes(out, target, []byte(value.Raw))
+			if errSet != nil {
+				continue
+			}
+			if updated, errSet = sjson.DeleteBytes(updated, source.path); errSet != nil {
+				continue
+			}
+			out = updated
+		}
+	}
+	for _, path := range t.deletes {
+		matches := expandStreamTransformPath(out, path)
+		// Delete from the end so removing array elements does not shift later matches.
+		for i := len(matches) - 1; i >= 0; i-- {
+			if updated, errDelete := sjson.DeleteBytes(out, matches[i].path); errDelete == nil {
+				out = updated
+			}
+		}
+	}
+	for _, set := range t.sets {
+		for _, target := range expandStreamTransformPath(out, set.path) {
+			if updated, errSet := sjson.SetBytes(out, target.path, set.value); errSet == nil {
+				out = updated
+			}
+		}
+	}
+	return out
+}
+
+// expandStreamTransformPath replaces every "#" segment of path with the
+// indices of the array present at that point in data. Paths without "#" are
+// returned as-is, whether or not they exist.
+func expandStreamTransformPath(data []byte, path string) []streamTransformPath {
+	paths := []streamTransformPath{{}}
+	for _, segment := range strings.Split(path, ".") {
+		if segment != "#" {
+			for i := range paths {
+				paths[i].path = joinStreamTransformPath(paths[i].path, segment)
+			}
+			continue
+		}
+		next := make([]streamTransformPath, 0, len(paths))
+		for _, p := range paths {
+			array := gjson.GetBytes(data, p.path)
+			if p.path == "" {
+				array = gjson.ParseBytes(data)
+			}
+			if !array.IsArray() {
+				continue
+			}
+			for i := range array.Array() {
+				indices := append(append([]int(nil), p.indices...), i)
+				next = append(next, streamTransformPath{
+					path:    joinStreamTransformPath(p.path, strconv.Itoa(i)),
+					indices: indices,
+				})
+			}
+		}
+		paths = next
+	}
+	return paths
+}
+
+// substituteWildcards replaces the "#" segments of path, in order, with indices.
+func substituteWildcards(path string, indices []int) string {
+	segments := strings.Split(path, ".")
+	next := 0
+	for i, segment := range segments {
+		if segment == "#" && next < len(indices) {
+			segments[i] = strconv.Itoa(indices[next])
+			next++
+		}
+	}
+	return strings.Join(segments, ".")
+}
+
+func wildcardSegments(path string) int {
+	count := 0
+	for _, segment := range strings.Split(path, ".") {
+		if segment == "#" {
+			count++
+		}
+	}
+	return count
+}
+
+func joinStreamTransformPath(prefix, segment string) string {
+	if prefix == "" {
+		return segment
+	}
+	return prefix + "." + segment
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
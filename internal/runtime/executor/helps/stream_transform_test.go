@@ -0,0 +1,44 @@
+package helps
+
+import (
+	"testing"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+func TestStreamTransformApply(t *testing.T) {
+	auth := &cliproxyauth.Auth{
+		ID: "auth-transform",
+		Attributes: map[string]string{
+			"stream_transform": `{"rename":{"choices.#.delta.reasoning":"choices.#.delta.reasoning_content"},"delete":["choices.#.delta.vendor_meta","x_trace"],"set":{"object":"chat.completion.chunk"}}`,
+		},
+	}
+	transform := NewStreamTransform(auth)
+	if transform == nil {
+		t.Fatal("NewStreamTransform() = nil, want a transform")
+	}
+
+	line := []byte(`data: {"x_trace":"abc","choices":[{"delta":{"reasoning":"think","vendor_meta":1}},{"delta":{"content":"hi","reasoning":"more"}}]}`)
+	got := string(transform.Apply(line))
+	want := `data: {"choices":[{"delta":{"reasoning_content":"think"}},{"delta":{"content":"hi","reasoning_content":"more"}}],"object":"chat.completion.chunk"}`
+	if got != want {
+		t.Fatalf("Apply() = %s\nwant %s", got, want)
+	}
+
+	for _, unchanged := range []string{"data: [DONE]", "event: message_start", ""} {
+		if got := string(transform.Apply([]byte(unchanged))); got != unchanged {
+			t.Errorf("Apply(%q) = %q, want unchanged", unchanged, got)
+		}
+	}
+}
+
+func TestNewStreamTransformWithoutConfig(t *testing.T) {
+	if transform := NewStreamTransform(&cliproxyauth.Auth{Attributes: map[string]string{}}); transform != nil {
+		t.Fatalf("NewStreamTransform() = %+v, want nil", transform)
+	}
+	line := []byte(`data: {"a":1}`)
+	var transform *StreamTransform
+	if got := transform.Apply(line); string(got) != string(line) {
+		t.Fatalf("nil Apply() = %s, want unchanged", got)
+	}
+}
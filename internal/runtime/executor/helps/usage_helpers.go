@@ -33,6 +33,7 @@ type UsageReporter struct {
 	source       string
 	reasoning    string
 	serviceTier  string
+	tags         map[string]string
 	requestedAt  time.Time
 	ttftMu       sync.RWMutex
 	ttft         time.Duration
@@ -71,6 +72,7 @@ func NewUsageReporter(ctx context.Context, provider, model string, auth *cliprox
 		authType:    resolveUsageAuthType(auth),
 		reasoning:   usage.ReasoningEffortFromContext(ctx),
 		serviceTier: usage.ServiceTierFromContext(ctx),
+		tags:        usage.RequestTagsFromContext(ctx),
 	}
 	if auth != nil {
 		reporter.authID = auth.ID
@@ -241,6 +243,9 @@ func (r *UsageReporter) EnsurePublished(ctx context.Context) {
 
 func (r *UsageReporter) publishRecord(ctx context.Context, record usage.Record) {
 	record.ResponseHeaders = internallogging.GetResponseHeaders(ctx)
+	if record.RequestID == "" {
+		record.RequestID = internallogging.GetRequestID(ctx)
+	}
 	usage.PublishRecord(ctx, record)
 }
 
@@ -271,6 +276,7 @@ func (r *UsageReporter) buildRecordForModel(model string, detail usage.Detail, f
 		AuthType:        r.authType,
 		ReasoningEffort: r.reasoning,
 		ServiceTier:     r.serviceTier,
+		Tags:            r.tags,
 		RequestedAt:     r.requestedAt,
 		Latency:         r.latency(),
 		TTFT:            r.ttftDuration(),
@@ -0,0 +1,255 @@
+package executor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/runtime/executor/helps"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	openAICompatCapabilitiesTTL          = 24 * time.Hour
+	openAICompatCapabilitiesProbeTimeout = 30 * time.Second
+	openAICompatCapabilitiesBodyLimit    = 1 << 20
+)
+
+// OpenAICompatCapabilities is what capability detection learned about an OpenAI-compatible
+// provider.
+type OpenAICompatCapabilities struct {
+	Provider    string    `json:"provider"`
+	Model       string    `json:"model,omitempty"`
+	Models      []string  `json:"models,omitempty"`
+	Streaming   bool      `json:"streaming"`
+	Tools       bool      `json:"tools"`
+	StreamUsage bool      `json:"stream_usage"`
+	Error       string    `json:"error,omitempty"`
+	DetectedAt  time.Time `json:"detected_at"`
+}
+
+// SupportedParameters returns the Chat Completions parameters the provider was seen to accept.
+func (c OpenAICompatCapabilities) SupportedParameters() []string {
+	var params []string
+	if c.Streaming {
+		params = append(params, "stream")
+	}
+	if c.StreamUsage {
+		params = append(params, "stream_options")
+	}
+	if c.Tools {
+		params = append(params, "tools", "tool_choice")
+	}
+	return params
+}
+
+type openAICompatCapabilityCache struct {
+	mu       sync.Mutex
+	entries  map[string]OpenAICompatCapabilities
+	inflight map[string]bool
+	hook     func(provider string)
+}
+
+var openAICompatCapabilities = &openAICompatCapabilityCache{
+	entries:  make(map[string]OpenAICompatCapabilities),
+	inflight: make(map[string]bool),
+}
+
+// OpenAICompatCapabilitiesFor returns the detected capabilities of the named compatibility
+// provider, if detection has completed and is still fresh.
+func OpenAICompatCapabilitiesFor(provider string) (OpenAICompatCapabilities, bool) {
+	key := strings.ToLower(strings.TrimSpace(provider))
+	openAICompatCapabilities.mu.Lock()
+	defer openAICompatCapabilities.mu.Unlock()
+	caps, ok := openAICompatCapabilities.entries[key]
+	if !ok || time.Since(caps.DetectedAt) > openAICompatCapabilitiesTTL {
+		return OpenAICompatCapabilities{}, false
+	}
+	return caps, true
+}
+
+// SetOpenAICompatCapabilitiesHook installs a callback run after capability detection for a
+// provider completes, so its models can be registered again with the detected capabilities.
+func SetOpenAICompatCapabilitiesHook(hook func(provider string)) {
+	openAICompatCapabilities.mu.Lock()
+	openAICompatCapabilities.hook = hook
+	openAICompatCapabilities.mu.Unlock()
+}
+
+// maybeDetectCapabilities starts capability detection in the background on the first request
+// to a provider with detect-capabilities enabled, and again once the cached result expires.
+func (e *OpenAICompatExecutor) maybeDetectCapabilities(auth *cliproxyauth.Auth) {
+	compat := e.resolveCompatConfig(auth)
+	if compat == nil || !compat.DetectCapabilities {
+		return
+	}
+	key := strings.ToLower(strings.TrimSpace(compat.Name))
+	if key == "" {
+		return
+	}
+	cache := openAICompatCapabilities
+	cache.mu.Lock()
+	caps, cached := cache.entries[key]
+	if cache.inflight[key] || (cached && time.Since(caps.DetectedAt) <= openAICompatCapabilitiesTTL) {
+		cache.mu.Unlock()
+		return
+	}
+	cache.inflight[key] = true
+	cache.mu.Unlock()
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), openAICompatCapabilitiesProbeTimeout)
+		defer cancel()
+		detected := e.detectCapabilities(ctx, auth, compat)
+
+		cache.mu.Lock()
+		delete(cache.inflight, key)
+		cache.entries[key] = detected
+		hook := cache.hook
+		cache.mu.Unlock()
+
+		if detected.Error != "" {
+			log.Warnf("openai compat executor: capability detection for %s incomplete: %s", compat.Name, detected.Error)
+		} else {
+			log.Infof("openai compat executor: %s capabilities: streaming=%t tools=%t stream_usage=%t", compat.Name, detected.Streaming, detected.Tools, detected.StreamUsage)
+		}
+		if hook != nil {
+			hook(compat.Name)
+		}
+	}()
+}
+
+// detectCapabilities lists the provider's models, then sends a one-token streaming chat request
+// with a tool and stream usage. A rejected request is retried without the tool, then without
+// streaming, to tell which feature the provider refused.
+func (e *OpenAICompatExecutor) detectCapabilities(ctx context.Context, auth *cliproxyauth.Auth, compat *config.OpenAICompatibility) OpenAICompatCapabilities {
+	caps := OpenAICompatCapabilities{Provider: compat.Name, DetectedAt: time.Now()}
+	baseURL, apiKey := e.resolveCredentials(auth)
+	if baseURL == "" {
+		caps.Error = "missing provider baseURL"
+		return caps
+	}
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	client := helps.NewProxyAwareHTTPClient(ctx, e.cfg, auth, openAICompatCapabilitiesProbeTimeout)
+
+	if status, body, errModels := e.capabilityRequest(ctx, client, auth, apiKey, http.MethodGet, baseURL+"/models", nil); errModels == nil && status == http.StatusOK {
+		for _, model := range gjson.GetBytes(body, "data").Array() {
+			if id := model.Get("id").String(); id != "" {
+				caps.Models = append(caps.Models, id)
+			}
+		}
+	}
+	for _, model := range compat.Models {
+		if name := strings.TrimSpace(model.Name); name != "" && !model.Image {
+			caps.Model = name
+			break
+		}
+	}
+	if caps.Model == "" && len(caps.Models) > 0 {
+		caps.Model = caps.Models[0]
+	}
+	if caps.Model == "" {
+		caps.Error = "no model to probe"
+		return caps
+	}
+
+	tool := `,"tools":[{"type":"function","function":{"name":"ping","description":"Reply to a ping.","parameters":{"type":"object","properties":{}}}}]`
+	streamOptions := `,"stream":true,"stream_options":{"include_usage":true}`
+	attempts := []struct {
+		extra            string
+		tools, streaming bool
+	}{
+		{extra: tool + streamOptions, tools: true, streaming: true},
+		{extra: streamOptions, streaming: true},
+		{},
+	}
+	for _, attempt := range attempts {
+		payload := fmt.Sprintf(`{"model":%q,"messages":[{"role":"user","content":"ping"}],"max_tokens":1%s}`, caps.Model, attempt.extra)
+		status, body, errProbe := e.capabilityRequest(ctx, client, auth, apiKey, http.MethodPost, baseURL+"/chat/completions", []byte(payload))
+		if errProbe != nil {
+			caps.Error = errProbe.Error()
+			return caps
+		}
+		if status < 200 || status > 299 {
+			caps.Error = fmt.Sprintf("chat request returned status %d", status)
+			continue
+		}
+		caps.Error = ""
+		caps.Tools = attempt.tools
+		if attempt.streaming {
+			caps.Streaming, caps.StreamUsage = scanCapabilityStream(body)
+		}
+		return caps
+	}
+	return caps
+}
+
+func (e *OpenAICompatExecutor) capabilityRequest(ctx context.Context, client *http.Client, auth *cliproxyauth.Auth, apiKey, method, url string, payload []byte) (int, []byte, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, errReq := http.NewRequestWithContext(ctx, method, url, body)
+	if errReq != nil {
+		return 0, nil, errReq
+	}
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+	req.Header.Set("User-Agent", "cli-proxy-openai-compat")
+	if auth != nil {
+		util.ApplyCustomHeadersFromAttrs(req, auth.Attributes)
+	}
+	if errSign := signCompatRequest(ctx, req, e.signingFor(auth)); errSign != nil {
+		return 0, nil, errSign
+	}
+	resp, errDo := client.Do(req)
+	if errDo != nil {
+		return 0, nil, errDo
+	}
+	defer func() {
+		if errClose := resp.Body.Close(); errClose != nil {
+			log.Errorf("openai compat executor: close capability probe body error: %v", errClose)
+		}
+	}()
+	data, errRead := io.ReadAll(io.LimitReader(resp.Body, openAICompatCapabilitiesBodyLimit))
+	if errRead != nil {
+		return resp.StatusCode, nil, errRead
+	}
+	return resp.StatusCode, data, nil
+}
+
+// scanCapabilityStream reports whether body is an SSE stream of chat chunks and whether any chunk
+// reported usage.
+func scanCapabilityStream(body []byte) (streaming, usage bool) {
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(nil, openAICompatCapabilitiesBodyLimit)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if !bytes.HasPrefix(line, []byte("data:")) {
+			continue
+		}
+		data := bytes.TrimSpace(line[len("data:"):])
+		if !gjson.ValidBytes(data) {
+			continue
+		}
+		streaming = true
+		if gjson.GetBytes(data, "usage.total_tokens").Exists() || gjson.GetBytes(data, "usage.prompt_tokens").Exists() {
+			usage = true
+		}
+	}
+	return streaming, usage
+}
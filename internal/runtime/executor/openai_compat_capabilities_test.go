@@ -0,0 +1,81 @@
+package executor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	"github.com/tidwall/gjson"
+)
+
+func TestOpenAICompatDetectCapabilitiesFallsBackWithoutTools(t *testing.T) {
+	var chatRequests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/models":
+			_, _ = io.WriteString(w, `{"data":[{"id":"upstream-a"},{"id":"upstream-b"}]}`)
+		case "/v1/chat/completions":
+			chatRequests++
+			body, _ := io.ReadAll(r.Body)
+			if gjson.GetBytes(body, "tools").Exists() {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = io.WriteString(w, `{"error":{"message":"tools are not supported"}}`)
+				return
+			}
+			if got := gjson.GetBytes(body, "model").String(); got != "configured-model" {
+				t.Errorf("probe model = %q, want configured-model", got)
+			}
+			w.Header().Set("Content-Type", "text/event-stream")
+			_, _ = io.WriteString(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"p\"}}]}\n\n")
+			_, _ = io.WriteString(w, "data: [DONE]\n\n")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	compat := config.OpenAICompatibility{
+		Name:               "vendor",
+		DetectCapabilities: true,
+		Models:             []config.OpenAICompatibilityModel{{Name: "configured-model", Alias: "m"}},
+	}
+	executor := NewOpenAICompatExecutor("openai-compatibility", &config.Config{OpenAICompatibility: []config.OpenAICompatibility{compat}})
+	auth := &cliproxyauth.Auth{Attributes: map[string]string{
+		"base_url":    server.URL + "/v1",
+		"api_key":     "test",
+		"compat_name": "vendor",
+	}}
+
+	caps := executor.detectCapabilities(context.Background(), auth, &compat)
+	if caps.Error != "" {
+		t.Fatalf("detection error = %q", caps.Error)
+	}
+	if !caps.Streaming || caps.Tools || caps.StreamUsage {
+		t.Fatalf("caps = %+v, want streaming without tools or stream usage", caps)
+	}
+	if chatRequests != 2 {
+		t.Fatalf("chat requests = %d, want a retry without tools", chatRequests)
+	}
+	if strings.Join(caps.Models, ",") != "upstream-a,upstream-b" {
+		t.Fatalf("models = %v, want listed models", caps.Models)
+	}
+	if got := strings.Join(caps.SupportedParameters(), ","); got != "stream" {
+		t.Fatalf("SupportedParameters() = %s, want stream", got)
+	}
+}
+
+func TestScanCapabilityStreamDetectsUsage(t *testing.T) {
+	body := []byte("data: {\"choices\":[]}\n\ndata: {\"choices\":[],\"usage\":{\"prompt_tokens\":3,\"completion_tokens\":1,\"total_tokens\":4}}\n\ndata: [DONE]\n")
+	streaming, usage := scanCapabilityStream(body)
+	if !streaming || !usage {
+		t.Fatalf("streaming = %t, usage = %t, want both", streaming, usage)
+	}
+	if streaming, _ := scanCapabilityStream([]byte(`{"choices":[]}`)); streaming {
+		t.Fatal("plain JSON body reported as a stream")
+	}
+}
@@ -0,0 +1,299 @@
+package executor
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// quirksFor returns the quirks of the compatibility entry behind auth, or nil when it has none.
+func (e *OpenAICompatExecutor) quirksFor(auth *cliproxyauth.Auth) *config.OpenAICompatibilityQuirks {
+	if compat := e.resolveCompatConfig(auth); compat != nil {
+		return compat.Quirks
+	}
+	return nil
+}
+
+// applyOpenAICompatRequestQuirks rewrites a Chat Completions request for an upstream's quirks.
+func applyOpenAICompatRequestQuirks(payload []byte, quirks *config.OpenAICompatibilityQuirks) []byte {
+	if quirks == nil || len(payload) == 0 {
+		return payload
+	}
+	if quirks.NoSystemRole {
+		payload = foldSystemMessagesIntoUser(payload)
+	}
+	if quirks.RequiresMaxTokens > 0 {
+		payload = requireMaxTokens(payload, quirks.RequiresMaxTokens)
+	}
+	if quirks.NoStreamUsage {
+		if updated, errDelete := sjson.DeleteBytes(payload, "stream_options"); errDelete == nil {
+			payload = updated
+		}
+	}
+	if quirks.LegacyFunctions() {
+		payload = toolsToLegacyFunctions(payload)
+	}
+	return payload
+}
+
+// foldSystemMessagesIntoUser removes system and developer messages and prepends their text to
+// the first user message, or to a new user message when there is none.
+func foldSystemMessagesIntoUser(payload []byte) []byte {
+	messages := gjson.GetBytes(payload, "messages")
+	if !messages.IsArray() {
+		return payload
+	}
+	var system []string
+	kept := make([]string, 0, len(messages.Array()))
+	firstUser := -1
+	removed := false
+	for _, msg := range messages.Array() {
+		switch msg.Get("role").String() {
+		case "system", "developer":
+			removed = true
+			if text := openAICompatMessageText(msg.Get("content")); text != "" {
+				system = append(system, text)
+			}
+			continue
+		case "user":
+			if firstUser < 0 {
+				firstUser = len(kept)
+			}
+		}
+		kept = append(kept, msg.Raw)
+	}
+	if !removed {
+		return payload
+	}
+	prefix := strings.Join(system, "\n\n")
+	if prefix != "" {
+		if firstUser < 0 {
+			user, _ := sjson.Set(`{"role":"user"}`, "content", prefix)
+			kept = append([]string{user}, kept...)
+		} else {
+			kept[firstUser] = prependMessageText(kept[firstUser], prefix)
+		}
+	}
+	out, errSet := sjson.SetRawBytes(payload, "messages", []byte("["+strings.Join(kept, ",")+"]"))
+	if errSet != nil {
+		return payload
+	}
+	return out
+}
+
+// openAICompatMessageText returns the text of a string content or the joined text parts of an
+// array content.
+func openAICompatMessageText(content gjson.Result) string {
+	if !content.IsArray() {
+		return content.String()
+	}
+	var parts []string
+	for _, part := range content.Array() {
+		if part.Get("type").String() == "text" {
+			if text := part.Get("text").String(); text != "" {
+				parts = append(parts, text)
+			}
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+func prependMessageText(message, prefix string) string {
+	content := gjson.Get(message, "content")
+	if content.IsArray() {
+		textPart, _ := sjson.Set(`{"type":"text"}`, "text", prefix)
+		parts := []string{textPart}
+		for _, part := range content.Array() {
+			parts = append(parts, part.Raw)
+		}
+		updated, errSet := sjson.SetRaw(message, "content", "["+strings.Join(parts, ",")+"]")
+		if errSet != nil {
+			return message
+		}
+		return updated
+	}
+	text := prefix
+	if existing := content.String(); existing != "" {
+		text += "\n\n" + existing
+	}
+	updated, errSet := sjson.Set(message, "content", text)
+	if errSet != nil {
+		return message
+	}
+	return updated
+}
+
+// requireMaxTokens ensures the request carries max_tokens, renaming max_completion_tokens or
+// falling back to fallback.
+func requireMaxTokens(payload []byte, fallback int) []byte {
+	if gjson.GetBytes(payload, "max_tokens").Exists() {
+		return payload
+	}
+	var (
+		out    []byte
+		errSet error
+	)
+	if limit := gjson.GetBytes(payload, "max_completion_tokens"); limit.Exists() {
+		out, errSet = sjson.SetRawBytes(payload, "max_tokens", []byte(limit.Raw))
+		if errSet == nil {
+			out, errSet = sjson.DeleteBytes(out, "max_completion_tokens")
+		}
+	} else {
+		out, errSet = sjson.SetBytes(payload, "max_tokens", fallback)
+	}
+	if errSet != nil {
+		return payload
+	}
+	return out
+}
+
+// toolsToLegacyFunctions rewrites tools, tool_choice, assistant tool_calls and tool results into
+// the deprecated functions API. The functions API has one call per assistant turn, so only the
+// first call of an assistant message is kept.
+func toolsToLegacyFunctions(payload []byte) []byte {
+	out := payload
+	if tools := gjson.GetBytes(out, "tools"); tools.Exists() {
+		functions := make([]string, 0, len(tools.Array()))
+		for _, tool := range tools.Array() {
+			if fn := tool.Get("function"); fn.Exists() && (tool.Get("type").String() == "function" || !tool.Get("type").Exists()) {
+				functions = append(functions, fn.Raw)
+			}
+		}
+		out, _ = sjson.DeleteBytes(out, "tools")
+		if len(functions) > 0 {
+			out, _ = sjson.SetRawBytes(out, "functions", []byte("["+strings.Join(functions, ",")+"]"))
+		}
+	}
+	if choice := gjson.GetBytes(out, "tool_choice"); choice.Exists() {
+		out, _ = sjson.DeleteBytes(out, "tool_choice")
+		switch {
+		case choice.Type == gjson.String && choice.String() == "none":
+			out, _ = sjson.SetBytes(out, "function_call", "none")
+		case choice.Type == gjson.String:
+			out, _ = sjson.SetBytes(out, "function_call", "auto")
+		case choice.Get("function.name").String() != "":
+			out, _ = sjson.SetBytes(out, "function_call.name", choice.Get("function.name").String())
+		}
+	}
+	out, _ = sjson.DeleteBytes(out, "parallel_tool_calls")
+
+	messages := gjson.GetBytes(out, "messages")
+	if !messages.IsArray() {
+		return out
+	}
+	names := make(map[string]string)
+	rewritten := make([]string, 0, len(messages.Array()))
+	for _, msg := range messages.Array() {
+		raw := msg.Raw
+		switch msg.Get("role").String() {
+		case "assistant":
+			calls := msg.Get("tool_calls")
+			if !calls.IsArray() {
+				break
+			}
+			for _, call := range calls.Array() {
+				names[call.Get("id").String()] = call.Get("function.name").String()
+			}
+			raw, _ = sjson.Delete(raw, "tool_calls")
+			if first := calls.Get("0.function"); first.Exists() {
+				raw, _ = sjson.Set(raw, "function_call.name", first.Get("name").String())
+				raw, _ = sjson.Set(raw, "function_call.arguments", first.Get("arguments").String())
+			}
+		case "tool":
+			fn := `{"role":"function"}`
+			fn, _ = sjson.Set(fn, "name", names[msg.Get("tool_call_id").String()])
+			fn, _ = sjson.Set(fn, "content", openAICompatMessageText(msg.Get("content")))
+			raw = fn
+		}
+		rewritten = append(rewritten, raw)
+	}
+	if updated, errSet := sjson.SetRawBytes(out, "messages", []byte("["+strings.Join(rewritten, ",")+"]")); errSet == nil {
+		out = updated
+	}
+	return out
+}
+
+// applyOpenAICompatResponseQuirks rewrites a non-streaming Chat Completions response.
+func applyOpenAICompatResponseQuirks(body []byte, quirks *config.OpenAICompatibilityQuirks) []byte {
+	if !quirks.LegacyFunctions() {
+		return body
+	}
+	out := body
+	for i, choice := range gjson.GetBytes(body, "choices").Array() {
+		prefix := fmt.Sprintf("choices.%d", i)
+		if fn := choice.Get("message.function_call"); fn.Exists() {
+			call := `{"type":"function"}`
+			call, _ = sjson.Set(call, "id", newLegacyFunctionCallID())
+			call, _ = sjson.SetRaw(call, "function", fn.Raw)
+			out, _ = sjson.SetRawBytes(out, prefix+".message.tool_calls", []byte("["+call+"]"))
+			out, _ = sjson.DeleteBytes(out, prefix+".message.function_call")
+		}
+		if choice.Get("finish_reason").String() == "function_call" {
+			out, _ = sjson.SetBytes(out, prefix+".finish_reason", "tool_calls")
+		}
+	}
+	return out
+}
+
+// legacyFunctionsStream maps function_call stream deltas back to tool_calls deltas.
+// A nil *legacyFunctionsStream leaves lines unchanged.
+type legacyFunctionsStream struct{}
+
+func newLegacyFunctionsStream(quirks *config.OpenAICompatibilityQuirks) *legacyFunctionsStream {
+	if !quirks.LegacyFunctions() {
+		return nil
+	}
+	return &legacyFunctionsStream{}
+}
+
+// Apply rewrites one SSE line.
+func (s *legacyFunctionsStream) Apply(line []byte) []byte {
+	if s == nil {
+		return line
+	}
+	trimmed := bytes.TrimSpace(line)
+	if !bytes.HasPrefix(trimmed, []byte("data:")) {
+		return line
+	}
+	data := bytes.TrimSpace(trimmed[len("data:"):])
+	if len(data) == 0 || data[0] != '{' || !gjson.ValidBytes(data) {
+		return line
+	}
+	out := data
+	changed := false
+	for i, choice := range gjson.GetBytes(data, "choices").Array() {
+		prefix := fmt.Sprintf("choices.%d", i)
+		if fn := choice.Get("delta.function_call"); fn.Exists() {
+			call := `{"index":0,"function":{}}`
+			if name := fn.Get("name").String(); name != "" {
+				call, _ = sjson.Set(call, "id", newLegacyFunctionCallID())
+				call, _ = sjson.Set(call, "type", "function")
+				call, _ = sjson.Set(call, "function.name", name)
+			}
+			if args := fn.Get("arguments"); args.Exists() {
+				call, _ = sjson.Set(call, "function.arguments", args.String())
+			}
+			out, _ = sjson.SetRawBytes(out, prefix+".delta.tool_calls", []byte("["+call+"]"))
+			out, _ = sjson.DeleteBytes(out, prefix+".delta.function_call")
+			changed = true
+		}
+		if choice.Get("finish_reason").String() == "function_call" {
+			out, _ = sjson.SetBytes(out, prefix+".finish_reason", "tool_calls")
+			changed = true
+		}
+	}
+	if !changed {
+		return line
+	}
+	return append([]byte("data: "), out...)
+}
+
+func newLegacyFunctionCallID() string {
+	return "call_" + strings.ReplaceAll(uuid.New().String(), "-", "")[:24]
+}
@@ -0,0 +1,87 @@
+package executor
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestApplyOpenAICompatRequestQuirksFoldsSystemAndMaxTokens(t *testing.T) {
+	payload := []byte(`{"model":"m","max_completion_tokens":256,"stream_options":{"include_usage":true},"messages":[{"role":"system","content":"be brief"},{"role":"developer","content":[{"type":"text","text":"use json"}]},{"role":"user","content":"hi"}]}`)
+	quirks := &config.OpenAICompatibilityQuirks{NoSystemRole: true, NoStreamUsage: true, RequiresMaxTokens: 1024}
+
+	got := applyOpenAICompatRequestQuirks(payload, quirks)
+
+	messages := gjson.GetBytes(got, "messages").Array()
+	if len(messages) != 1 || messages[0].Get("role").String() != "user" {
+		t.Fatalf("messages = %s, want a single user message", gjson.GetBytes(got, "messages").Raw)
+	}
+	if content := messages[0].Get("content").String(); content != "be brief\n\nuse json\n\nhi" {
+		t.Fatalf("content = %q, want system text folded before the user text", content)
+	}
+	if gjson.GetBytes(got, "max_tokens").Int() != 256 || gjson.GetBytes(got, "max_completion_tokens").Exists() {
+		t.Fatalf("payload = %s, want max_completion_tokens renamed to max_tokens", got)
+	}
+	if gjson.GetBytes(got, "stream_options").Exists() {
+		t.Fatalf("payload = %s, want stream_options removed", got)
+	}
+
+	got = applyOpenAICompatRequestQuirks([]byte(`{"messages":[{"role":"user","content":"hi"}]}`), quirks)
+	if gjson.GetBytes(got, "max_tokens").Int() != 1024 {
+		t.Fatalf("payload = %s, want fallback max_tokens", got)
+	}
+}
+
+func TestApplyOpenAICompatRequestQuirksLegacyFunctions(t *testing.T) {
+	payload := []byte(`{"tools":[{"type":"function","function":{"name":"lookup","parameters":{"type":"object"}}}],"tool_choice":{"type":"function","function":{"name":"lookup"}},"parallel_tool_calls":false,"messages":[{"role":"user","content":"find x"},{"role":"assistant","content":null,"tool_calls":[{"id":"call_1","type":"function","function":{"name":"lookup","arguments":"{\"q\":\"x\"}"}}]},{"role":"tool","tool_call_id":"call_1","content":"found"}]}`)
+	quirks := &config.OpenAICompatibilityQuirks{ToolFormat: "legacy-functions"}
+
+	got := applyOpenAICompatRequestQuirks(payload, quirks)
+
+	if gjson.GetBytes(got, "tools").Exists() || gjson.GetBytes(got, "parallel_tool_calls").Exists() {
+		t.Fatalf("payload = %s, want tools and parallel_tool_calls removed", got)
+	}
+	if name := gjson.GetBytes(got, "functions.0.name").String(); name != "lookup" {
+		t.Fatalf("functions = %s, want lookup", gjson.GetBytes(got, "functions").Raw)
+	}
+	if name := gjson.GetBytes(got, "function_call.name").String(); name != "lookup" {
+		t.Fatalf("function_call = %s, want forced lookup", gjson.GetBytes(got, "function_call").Raw)
+	}
+	if args := gjson.GetBytes(got, "messages.1.function_call.arguments").String(); args != `{"q":"x"}` {
+		t.Fatalf("assistant function_call arguments = %q", args)
+	}
+	result := gjson.GetBytes(got, "messages.2")
+	if result.Get("role").String() != "function" || result.Get("name").String() != "lookup" || result.Get("content").String() != "found" {
+		t.Fatalf("tool result = %s, want a function message named lookup", result.Raw)
+	}
+}
+
+func TestLegacyFunctionsResponsesMapToToolCalls(t *testing.T) {
+	quirks := &config.OpenAICompatibilityQuirks{ToolFormat: "legacy-functions"}
+
+	body := applyOpenAICompatResponseQuirks([]byte(`{"choices":[{"index":0,"message":{"role":"assistant","function_call":{"name":"lookup","arguments":"{}"}},"finish_reason":"function_call"}]}`), quirks)
+	if gjson.GetBytes(body, "choices.0.message.function_call").Exists() ||
+		gjson.GetBytes(body, "choices.0.message.tool_calls.0.function.name").String() != "lookup" ||
+		!strings.HasPrefix(gjson.GetBytes(body, "choices.0.message.tool_calls.0.id").String(), "call_") ||
+		gjson.GetBytes(body, "choices.0.finish_reason").String() != "tool_calls" {
+		t.Fatalf("response = %s, want function_call mapped to tool_calls", body)
+	}
+
+	stream := newLegacyFunctionsStream(quirks)
+	first := stream.Apply([]byte(`data: {"choices":[{"index":0,"delta":{"function_call":{"name":"lookup","arguments":""}}}]}`))
+	call := gjson.GetBytes(first[len("data: "):], "choices.0.delta.tool_calls.0")
+	if call.Get("function.name").String() != "lookup" || call.Get("id").String() == "" || call.Get("index").Int() != 0 {
+		t.Fatalf("first chunk = %s, want a tool call start", first)
+	}
+	next := stream.Apply([]byte(`data: {"choices":[{"index":0,"delta":{"function_call":{"arguments":"{}"}},"finish_reason":"function_call"}]}`))
+	chunk := gjson.GetBytes(next[len("data: "):], "choices.0")
+	if chunk.Get("delta.tool_calls.0.id").Exists() || chunk.Get("delta.tool_calls.0.function.arguments").String() != "{}" ||
+		chunk.Get("finish_reason").String() != "tool_calls" {
+		t.Fatalf("next chunk = %s, want an argument delta and tool_calls finish", next)
+	}
+	if got := string(stream.Apply([]byte("data: [DONE]"))); got != "data: [DONE]" {
+		t.Fatalf("done line = %q, want unchanged", got)
+	}
+}
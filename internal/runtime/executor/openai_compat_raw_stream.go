@@ -0,0 +1,115 @@
+package executor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/runtime/executor/helps"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+)
+
+const (
+	openAICompatRawStreamReadSize = 32 << 10
+	openAICompatRawStreamMaxLine  = 52_428_800 // 50MB, as the scanner path
+)
+
+// openAICompatRawStreamEligible reports whether a Chat Completions stream can skip the per-line
+// translation pipeline. That holds when the client speaks the upstream's format, where the
+// translator only strips the "data:" prefix, and nothing else rewrites upstream lines.
+func openAICompatRawStreamEligible(from, responseFormat sdktranslator.Format, lineRewriters ...bool) bool {
+	openAI := sdktranslator.FromString("openai")
+	if !sourceFormatEqual(from, openAI) || !sourceFormatEqual(responseFormat, openAI) {
+		return false
+	}
+	for _, rewrites := range lineRewriters {
+		if rewrites {
+			return false
+		}
+	}
+	return !sdktranslator.HasPluginHooks()
+}
+
+// streamOpenAICompatRaw forwards the JSON payload of each upstream SSE data line as it arrives.
+// Lines are split with IndexByte on the read buffer and payloads are passed on unchanged, but this
+// is not a byte-for-byte copy of the upstream stream: the handler writes its own SSE framing, and
+// comments and event, id and retry fields are dropped as on the translated path. Request logging
+// and usage parsing run after a payload was handed on, so they stay off the path to the client.
+func (e *OpenAICompatExecutor) streamOpenAICompatRaw(ctx context.Context, body io.Reader, out chan<- cliproxyexecutor.StreamChunk, reporter *helps.UsageReporter) {
+	var streamUsage helps.StreamUsageBuffer
+	defer streamUsage.Publish(ctx, reporter)
+
+	send := func(chunk cliproxyexecutor.StreamChunk) bool {
+		select {
+		case out <- chunk:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	// handleLine returns false when streaming must stop.
+	handleLine := func(line []byte) bool {
+		trimmed := bytes.TrimSpace(line)
+		data, isData := bytes.CutPrefix(trimmed, []byte("data:"))
+		if !isData {
+			helps.AppendAPIResponseChunk(ctx, e.cfg, line)
+			if len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[') {
+				streamErr := statusErr{code: http.StatusBadGateway, msg: string(trimmed)}
+				helps.RecordAPIResponseError(ctx, e.cfg, streamErr)
+				reporter.PublishFailure(ctx, streamErr)
+				send(cliproxyexecutor.StreamChunk{Err: streamErr})
+				return false
+			}
+			// Comments, event, id and retry fields carry nothing for Chat Completions clients.
+			return true
+		}
+		forwarded := true
+		if data = bytes.TrimSpace(data); len(data) > 0 && !bytes.Equal(data, []byte("[DONE]")) {
+			forwarded = send(cliproxyexecutor.StreamChunk{Payload: bytes.Clone(data)})
+		}
+		helps.AppendAPIResponseChunk(ctx, e.cfg, line)
+		streamUsage.Observe(helps.ParseOpenAIStreamUsage(line))
+		return forwarded
+	}
+
+	buf := make([]byte, 0, openAICompatRawStreamReadSize)
+	read := make([]byte, openAICompatRawStreamReadSize)
+	for {
+		n, errRead := body.Read(read)
+		if n > 0 {
+			buf = append(buf, read[:n]...)
+			start := 0
+			for {
+				idx := bytes.IndexByte(buf[start:], '\n')
+				if idx < 0 {
+					break
+				}
+				if !handleLine(buf[start : start+idx]) {
+					return
+				}
+				start += idx + 1
+			}
+			buf = append(buf[:0], buf[start:]...)
+			if len(buf) > openAICompatRawStreamMaxLine {
+				errRead = bufio.ErrTooLong
+			}
+		}
+		if errRead == io.EOF {
+			if len(buf) > 0 && !handleLine(buf) {
+				return
+			}
+			break
+		}
+		if errRead != nil {
+			helps.RecordAPIResponseError(ctx, e.cfg, errRead)
+			reporter.PublishFailure(ctx, errRead)
+			send(cliproxyexecutor.StreamChunk{Err: errRead})
+			return
+		}
+	}
+	streamUsage.Publish(ctx, reporter)
+	reporter.EnsurePublished(ctx)
+}
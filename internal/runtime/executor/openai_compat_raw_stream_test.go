@@ -0,0 +1,83 @@
+package executor
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/runtime/executor/helps"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+)
+
+func TestOpenAICompatRawStreamEligible(t *testing.T) {
+	openAI := sdktranslator.FromString("openai")
+	if !openAICompatRawStreamEligible(openAI, openAI, false, false, false) {
+		t.Fatal("openai -> openai without line rewriters should use the raw stream")
+	}
+	if openAICompatRawStreamEligible(sdktranslator.FromString("claude"), openAI) {
+		t.Fatal("claude source should use the translated stream")
+	}
+	if openAICompatRawStreamEligible(openAI, sdktranslator.FromString("openai-response")) {
+		t.Fatal("Responses output should use the translated stream")
+	}
+	if openAICompatRawStreamEligible(openAI, openAI, false, true) {
+		t.Fatal("line rewriters should force the translated stream")
+	}
+}
+
+func TestOpenAICompatRawStreamForwardsDataPayloads(t *testing.T) {
+	upstream := ": keep-alive\n" +
+		"event: chunk\n" +
+		"data: {\"id\":\"c1\",\"choices\":[{\"delta\":{\"content\":\"hi\"}}]}\r\n" +
+		"\n" +
+		"data:{\"id\":\"c1\",\"choices\":[],\"usage\":{\"prompt_tokens\":3,\"completion_tokens\":1,\"total_tokens\":4}}\n" +
+		"data: [DONE]\n" +
+		"data: {\"id\":\"tail\"}"
+	executor := NewOpenAICompatExecutor("openai-compatibility", &config.Config{})
+	out := make(chan cliproxyexecutor.StreamChunk, 8)
+	reporter := helps.NewExecutorUsageReporter(context.Background(), executor, "model", nil)
+
+	// OneByteReader splits every line across reads.
+	executor.streamOpenAICompatRaw(context.Background(), iotest.OneByteReader(strings.NewReader(upstream)), out, reporter)
+	close(out)
+
+	var got []string
+	for chunk := range out {
+		if chunk.Err != nil {
+			t.Fatalf("stream chunk error: %v", chunk.Err)
+		}
+		got = append(got, string(chunk.Payload))
+	}
+	want := []string{
+		`{"id":"c1","choices":[{"delta":{"content":"hi"}}]}`,
+		`{"id":"c1","choices":[],"usage":{"prompt_tokens":3,"completion_tokens":1,"total_tokens":4}}`,
+		`{"id":"tail"}`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Fatalf("payloads = %q, want %q", got, want)
+	}
+}
+
+func TestOpenAICompatRawStreamRejectsBareJSONError(t *testing.T) {
+	executor := NewOpenAICompatExecutor("openai-compatibility", &config.Config{})
+	out := make(chan cliproxyexecutor.StreamChunk, 4)
+	reporter := helps.NewExecutorUsageReporter(context.Background(), executor, "model", nil)
+
+	executor.streamOpenAICompatRaw(context.Background(), strings.NewReader("{\"error\":{\"message\":\"boom\"}}\ndata: {\"id\":\"late\"}\n"), out, reporter)
+	close(out)
+
+	chunk, ok := <-out
+	if !ok || chunk.Err == nil {
+		t.Fatalf("first chunk = %+v, want an error", chunk)
+	}
+	if status, okStatus := chunk.Err.(statusErr); !okStatus || status.StatusCode() != http.StatusBadGateway {
+		t.Fatalf("error = %v, want a 502 statusErr", chunk.Err)
+	}
+	if extra, more := <-out; more {
+		t.Fatalf("unexpected chunk after error: %+v", extra)
+	}
+}
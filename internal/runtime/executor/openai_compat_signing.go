@@ -0,0 +1,195 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/sigv4"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	defaultSignatureHeader = "X-Signature"
+	defaultTimestampHeader = "X-Timestamp"
+	gcpCloudPlatformScope  = "https://www.googleapis.com/auth/cloud-platform"
+)
+
+// signingNow is replaced in tests for deterministic signatures.
+var signingNow = time.Now
+
+// gcpTokenSources caches Google token sources per credentials file and scope set so access
+// tokens are reused until they expire.
+var gcpTokenSources sync.Map
+
+// signCompatRequest applies the provider's signing configuration to req. It runs after every
+// other header is set because SigV4 and HMAC signatures cover the final request.
+func signCompatRequest(ctx context.Context, req *http.Request, signing *config.RequestSigning) error {
+	if req == nil || signing == nil {
+		return nil
+	}
+	switch strings.ToLower(strings.TrimSpace(signing.Type)) {
+	case "":
+		return nil
+	case config.RequestSigningHMAC:
+		return signHMAC(req, signing)
+	case config.RequestSigningSigV4:
+		return signSigV4(ctx, req, signing)
+	case config.RequestSigningGCP:
+		return signGCP(ctx, req, signing)
+	default:
+		return fmt.Errorf("request signing: unknown type %q", signing.Type)
+	}
+}
+
+// requestBodyBytes returns the request body and restores it so the request can still be sent.
+func requestBodyBytes(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	if req.GetBody != nil {
+		body, errGetBody := req.GetBody()
+		if errGetBody != nil {
+			return nil, errGetBody
+		}
+		defer func() { _ = body.Close() }()
+		return io.ReadAll(body)
+	}
+	data, errRead := io.ReadAll(req.Body)
+	_ = req.Body.Close()
+	if errRead != nil {
+		return nil, errRead
+	}
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	req.GetBody = func() (io.ReadCloser, error) { return io.NopCloser(bytes.NewReader(data)), nil }
+	return data, nil
+}
+
+func sha256HexBytes(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func signHMAC(req *http.Request, signing *config.RequestSigning) error {
+	if signing.Secret == "" {
+		return fmt.Errorf("request signing: hmac secret is empty")
+	}
+	body, errBody := requestBodyBytes(req)
+	if errBody != nil {
+		return fmt.Errorf("request signing: read body: %w", errBody)
+	}
+	timestamp := strconv.FormatInt(signingNow().Unix(), 10)
+	stringToSign := strings.Join([]string{req.Method, req.URL.RequestURI(), timestamp, sha256HexBytes(body)}, "\n")
+	signatureHeader := strings.TrimSpace(signing.SignatureHeader)
+	if signatureHeader == "" {
+		signatureHeader = defaultSignatureHeader
+	}
+	timestampHeader := strings.TrimSpace(signing.TimestampHeader)
+	if timestampHeader == "" {
+		timestampHeader = defaultTimestampHeader
+	}
+	req.Header.Set(timestampHeader, timestamp)
+	req.Header.Set(signatureHeader, hex.EncodeToString(hmacSHA256([]byte(signing.Secret), stringToSign)))
+	return nil
+}
+
+// ambientAWSCredentials resolves AWS credentials from the environment, web identity, container
+// or instance role for sigv4 signing configured without static keys.
+var ambientAWSCredentials = sync.OnceValue(func() *sigv4.CredentialSource {
+	return sigv4.NewCredentialSource(sigv4.Credentials{})
+})
+
+// signSigV4 signs req with AWS Signature Version 4, replacing any bearer Authorization header.
+func signSigV4(ctx context.Context, req *http.Request, signing *config.RequestSigning) error {
+	region := strings.TrimSpace(signing.Region)
+	service := strings.TrimSpace(signing.Service)
+	if region == "" || service == "" {
+		return fmt.Errorf("request signing: aws-sigv4 requires region and service")
+	}
+	creds := sigv4.Credentials{
+		AccessKeyID:     strings.TrimSpace(signing.AccessKeyID),
+		SecretAccessKey: strings.TrimSpace(signing.SecretAccessKey),
+		SessionToken:    strings.TrimSpace(signing.SessionToken),
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		resolved, errCreds := ambientAWSCredentials().Retrieve(ctx)
+		if errCreds != nil {
+			return fmt.Errorf("request signing: %w", errCreds)
+		}
+		creds = resolved
+	}
+	body, errBody := requestBodyBytes(req)
+	if errBody != nil {
+		return fmt.Errorf("request signing: read body: %w", errBody)
+	}
+	sigv4.Sign(req, body, creds, region, service, signingNow())
+	return nil
+}
+
+// signGCP replaces the Authorization header with a Google OAuth access token.
+func signGCP(ctx context.Context, req *http.Request, signing *config.RequestSigning) error {
+	scopes := signing.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{gcpCloudPlatformScope}
+	}
+	file := strings.TrimSpace(signing.CredentialsFile)
+	cacheKey := file + "|" + strings.Join(scopes, " ")
+	var source oauth2.TokenSource
+	if cached, ok := gcpTokenSources.Load(cacheKey); ok {
+		source = cached.(oauth2.TokenSource)
+	} else {
+		var creds *google.Credentials
+		if file != "" {
+			data, errRead := os.ReadFile(file)
+			if errRead != nil {
+				return fmt.Errorf("request signing: read gcp credentials: %w", errRead)
+			}
+			parsed, errParse := google.CredentialsFromJSON(context.Background(), data, scopes...)
+			if errParse != nil {
+				return fmt.Errorf("request signing: parse gcp credentials: %w", errParse)
+			}
+			creds = parsed
+		} else {
+			found, errFind := google.FindDefaultCredentials(ctx, scopes...)
+			if errFind != nil {
+				return fmt.Errorf("request signing: find gcp default credentials: %w", errFind)
+			}
+			creds = found
+		}
+		source = creds.TokenSource
+		gcpTokenSources.Store(cacheKey, source)
+	}
+	token, errToken := source.Token()
+	if errToken != nil {
+		return fmt.Errorf("request signing: gcp access token: %w", errToken)
+	}
+	req.Header.Set("Authorization", "Bearer "+token.AccessToken)
+	return nil
+}
+
+// signingFor returns the signing configuration of the compat provider serving auth.
+func (e *OpenAICompatExecutor) signingFor(auth *cliproxyauth.Auth) *config.RequestSigning {
+	if compat := e.resolveCompatConfig(auth); compat != nil {
+		return compat.Signing
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package executor
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+func withSigningTime(t *testing.T, at time.Time) {
+	t.Helper()
+	previous := signingNow
+	signingNow = func() time.Time { return at }
+	t.Cleanup(func() { signingNow = previous })
+}
+
+func TestSignSigV4MatchesAWSTestVector(t *testing.T) {
+	withSigningTime(t, time.Date(2015, time.August, 30, 12, 36, 0, 0, time.UTC))
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	req.Header.Set("Authorization", "Bearer drop-me")
+
+	err := signCompatRequest(context.Background(), req, &config.RequestSigning{
+		Type:            config.RequestSigningSigV4,
+		Region:          "us-east-1",
+		Service:         "service",
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	})
+	if err != nil {
+		t.Fatalf("signCompatRequest() error = %v", err)
+	}
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Fatalf("Authorization = %q\nwant %q", got, want)
+	}
+}
+
+func TestSignHMACKeepsBody(t *testing.T) {
+	withSigningTime(t, time.Unix(1700000000, 0))
+	body := []byte(`{"a":1}`)
+	req, _ := http.NewRequest(http.MethodPost, "https://gateway.example.com/v1/chat/completions?x=1", bytes.NewReader(body))
+
+	if err := signCompatRequest(context.Background(), req, &config.RequestSigning{Type: "HMAC", Secret: "secret"}); err != nil {
+		t.Fatalf("signCompatRequest() error = %v", err)
+	}
+	if got := req.Header.Get("X-Timestamp"); got != "1700000000" {
+		t.Fatalf("X-Timestamp = %q", got)
+	}
+	if got := req.Header.Get("X-Signature"); got != "ee0d64010372da4792ca44632652c39a379f02fca8593036d3d939dde4743f2c" {
+		t.Fatalf("X-Signature = %q", got)
+	}
+	sent, _ := io.ReadAll(req.Body)
+	if !bytes.Equal(sent, body) {
+		t.Fatalf("body = %s, want unchanged", sent)
+	}
+}
+
+func TestSignCompatRequestErrors(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, "https://gateway.example.com/", strings.NewReader("{}"))
+	for name, signing := range map[string]*config.RequestSigning{
+		"unknown type":     {Type: "kerberos"},
+		"missing secret":   {Type: config.RequestSigningHMAC},
+		"missing sigv4 id": {Type: config.RequestSigningSigV4, Region: "us-east-1", Service: "bedrock"},
+	} {
+		t.Setenv("AWS_ACCESS_KEY_ID", "")
+		t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+		t.Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", "")
+		t.Setenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "")
+		t.Setenv("AWS_CONTAINER_CREDENTIALS_FULL_URI", "")
+		t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+		if err := signCompatRequest(context.Background(), req, signing); err == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+	if err := signCompatRequest(context.Background(), req, nil); err != nil {
+		t.Fatalf("nil signing error = %v", err)
+	}
+}
+
+func TestOpenAICompatPrepareRequestSigns(t *testing.T) {
+	cfg := &config.Config{OpenAICompatibility: []config.OpenAICompatibility{{
+		Name:    "gateway",
+		Signing: &config.RequestSigning{Type: config.RequestSigningHMAC, Secret: "s"},
+	}}}
+	exec := NewOpenAICompatExecutor("gateway", cfg)
+	auth := &cliproxyauth.Auth{Provider: "gateway", Attributes: map[string]string{"compat_name": "gateway", "api_key": "k"}}
+	req, _ := http.NewRequest(http.MethodPost, "https://gateway.example.com/v1/models", strings.NewReader("{}"))
+	if err := exec.PrepareRequest(req, auth); err != nil {
+		t.Fatalf("PrepareRequest() error = %v", err)
+	}
+	if req.Header.Get("X-Signature") == "" || req.Header.Get("Authorization") != "Bearer k" {
+		t.Fatalf("headers = %v", req.Header)
+	}
+}
@@ -0,0 +1,176 @@
+package scheduledjobs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// minEveryInterval keeps "@every" schedules from hammering providers.
+const minEveryInterval = time.Minute
+
+// Schedule computes the next activation time after a given instant.
+type Schedule interface {
+	Next(after time.Time) time.Time
+}
+
+// everySchedule fires at a fixed interval.
+type everySchedule struct {
+	interval time.Duration
+}
+
+func (s everySchedule) Next(after time.Time) time.Time {
+	return after.Add(s.interval)
+}
+
+// cronSchedule is a parsed five-field cron expression evaluated in local time.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// domStar and dowStar record unrestricted day fields; cron matches either day
+	// field when both are restricted.
+	domStar, dowStar bool
+}
+
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseSchedule parses a five-field cron expression, a descriptor such as "@daily",
+// or "@every <duration>".
+func ParseSchedule(spec string) (Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, fmt.Errorf("empty schedule")
+	}
+	lower := strings.ToLower(spec)
+	if rest, ok := strings.CutPrefix(lower, "@every"); ok {
+		interval, errParse := time.ParseDuration(strings.TrimSpace(rest))
+		if errParse != nil {
+			return nil, fmt.Errorf("invalid @every interval: %w", errParse)
+		}
+		return everySchedule{interval: max(interval, minEveryInterval)}, nil
+	}
+	if expanded, ok := cronDescriptors[lower]; ok {
+		spec = expanded
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields, got %d", spec, len(fields))
+	}
+	var s cronSchedule
+	var err error
+	if s.minute, err = parseCronField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("minute: %w", err)
+	}
+	if s.hour, err = parseCronField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("hour: %w", err)
+	}
+	if s.dom, err = parseCronField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("day of month: %w", err)
+	}
+	if s.month, err = parseCronField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("month: %w", err)
+	}
+	if s.dow, err = parseCronField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("day of week: %w", err)
+	}
+	// Both 0 and 7 mean Sunday.
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domStar = fields[2] == "*" || fields[2] == "?"
+	s.dowStar = fields[4] == "*" || fields[4] == "?"
+	return s, nil
+}
+
+// parseCronField parses comma-separated values, ranges, and steps into a bit set.
+func parseCronField(field string, lo, hi int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if base, rawStep, ok := strings.Cut(part, "/"); ok {
+			n, errStep := strconv.Atoi(rawStep)
+			if errStep != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q", rawStep)
+			}
+			part, step = base, n
+		}
+		start, end := lo, hi
+		switch {
+		case part == "*" || part == "?":
+		case strings.Contains(part, "-"):
+			rawStart, rawEnd, _ := strings.Cut(part, "-")
+			var errStart, errEnd error
+			start, errStart = strconv.Atoi(rawStart)
+			end, errEnd = strconv.Atoi(rawEnd)
+			if errStart != nil || errEnd != nil {
+				return 0, fmt.Errorf("invalid range %q", part)
+			}
+		default:
+			n, errValue := strconv.Atoi(part)
+			if errValue != nil {
+				return 0, fmt.Errorf("invalid value %q", part)
+			}
+			start = n
+			if step == 1 {
+				end = n
+			}
+		}
+		if start < lo || end > hi || start > end {
+			return 0, fmt.Errorf("%q out of range %d-%d", part, lo, hi)
+		}
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// Next returns the first minute after the given instant matching the expression.
+// It gives up after five years so impossible dates like "0 0 30 2 *" cannot spin forever.
+func (s cronSchedule) Next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case s.domStar && s.dowStar:
+		return true
+	case s.domStar:
+		return dowMatch
+	case s.dowStar:
+		return domMatch
+	default:
+		return domMatch || dowMatch
+	}
+}
@@ -0,0 +1,50 @@
+package scheduledjobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseScheduleNext(t *testing.T) {
+	base := time.Date(2026, time.March, 6, 10, 17, 30, 0, time.Local) // Friday
+	cases := []struct {
+		spec string
+		want time.Time
+	}{
+		{"*/15 * * * *", time.Date(2026, time.March, 6, 10, 30, 0, 0, time.Local)},
+		{"0 9 * * 1-5", time.Date(2026, time.March, 9, 9, 0, 0, 0, time.Local)},
+		{"@daily", time.Date(2026, time.March, 7, 0, 0, 0, 0, time.Local)},
+		{"@hourly", time.Date(2026, time.March, 6, 11, 0, 0, 0, time.Local)},
+		{"30 8 1,15 * *", time.Date(2026, time.March, 15, 8, 30, 0, 0, time.Local)},
+		{"0 0 * * 7", time.Date(2026, time.March, 8, 0, 0, 0, 0, time.Local)},
+		{"@every 45m", base.Add(45 * time.Minute)},
+		{"@every 10s", base.Add(minEveryInterval)},
+	}
+	for _, tc := range cases {
+		schedule, err := ParseSchedule(tc.spec)
+		if err != nil {
+			t.Fatalf("ParseSchedule(%q) error = %v", tc.spec, err)
+		}
+		if got := schedule.Next(base); !got.Equal(tc.want) {
+			t.Fatalf("%q: next = %s, want %s", tc.spec, got, tc.want)
+		}
+	}
+}
+
+func TestParseScheduleRejectsInvalid(t *testing.T) {
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "*/0 * * * *", "5-1 * * * *", "@every soon"} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Fatalf("ParseSchedule(%q) succeeded, want error", spec)
+		}
+	}
+}
+
+func TestCronScheduleImpossibleDate(t *testing.T) {
+	schedule, err := ParseSchedule("0 0 30 2 *")
+	if err != nil {
+		t.Fatalf("ParseSchedule error = %v", err)
+	}
+	if got := schedule.Next(time.Now()); !got.IsZero() {
+		t.Fatalf("next = %s, want zero time", got)
+	}
+}
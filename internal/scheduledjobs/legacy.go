@@ -0,0 +1,51 @@
+package scheduledjobs
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const legacyHotTakesPrompt = `What do you think about these headlines?
+{{range .headlines}}- {{.}}
+{{end}}`
+
+// legacyCopilotHotTakesJob expresses the COPILOT_HOT_TAKES_* environment variables as a
+// scheduled job so existing deployments keep working without a scheduled-jobs section.
+func legacyCopilotHotTakesJob() (config.ScheduledJob, bool) {
+	raw := strings.TrimSpace(os.Getenv("COPILOT_HOT_TAKES_INTERVAL_MINS"))
+	if raw == "" {
+		return config.ScheduledJob{}, false
+	}
+	minutes, errAtoi := strconv.Atoi(raw)
+	if errAtoi != nil || minutes <= 0 {
+		log.Warnf("scheduled jobs: invalid COPILOT_HOT_TAKES_INTERVAL_MINS=%q; copilot hot takes disabled", raw)
+		return config.ScheduledJob{}, false
+	}
+	model := strings.TrimSpace(os.Getenv("COPILOT_HOT_TAKES_MODEL"))
+	if model == "" {
+		// Accept the historical misspelling.
+		model = strings.TrimSpace(os.Getenv("COPILOT_HOT_TAKES_MOEL"))
+	}
+	if model == "" {
+		model = "claude-haiku-4.5"
+	}
+	if !strings.HasPrefix(strings.ToLower(model), "copilot-") {
+		model = "copilot-" + model
+	}
+	return config.ScheduledJob{
+		Name:        "copilot-hot-takes",
+		Schedule:    fmt.Sprintf("@every %dm", minutes),
+		RunOnStart:  true,
+		Model:       model,
+		Prompt:      legacyHotTakesPrompt,
+		Sources:     []config.ScheduledJobSource{{Name: "headlines", Type: sourceTypeHackerNews, Count: defaultHackerNewsCount}},
+		Headers:     map[string]string{"force-copilot-initiator": "user"},
+		AuthPinning: PinningEach,
+		Provider:    "copilot",
+	}, true
+}
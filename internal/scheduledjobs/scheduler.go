@@ -0,0 +1,324 @@
+// Package scheduledjobs runs the scheduled-jobs config section: templated prompts sent to a
+// model on a cron schedule, optionally pinned to each or one rotating credential.
+package scheduledjobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/httpfetch"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/trafficshaper"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+// Auth pinning strategies accepted in scheduled-jobs entries.
+const (
+	PinningNone       = "none"
+	PinningEach       = "each"
+	PinningRoundRobin = "round-robin"
+)
+
+const (
+	// startupDelay gives auth loading and model registration time to settle before run-on-start jobs.
+	startupDelay = 15 * time.Second
+	// runTimeout bounds one run, including source fetches and every pinned call.
+	runTimeout = 30 * time.Minute
+)
+
+// Backend executes scheduled prompts through the proxy's routing.
+type Backend interface {
+	// Execute sends an OpenAI chat completions payload for model and returns the response body.
+	// A non-empty authID pins execution to that credential.
+	Execute(ctx context.Context, model string, payload []byte, headers http.Header, authID string) ([]byte, error)
+	// AuthIDs lists usable credentials of provider, or of the providers serving model when
+	// provider is empty, in a stable order.
+	AuthIDs(provider, model string) []string
+}
+
+// Job is a validated scheduled-jobs entry.
+type Job struct {
+	Name       string
+	Schedule   Schedule
+	RunOnStart bool
+	Model      string
+	Prompt     *template.Template
+	Sources    []config.ScheduledJobSource
+	MaxTokens  int
+	Headers    http.Header
+	Pinning    string
+	Provider   string
+}
+
+// JobsFromConfig returns the configured jobs plus the legacy Copilot hot-takes job when its
+// environment variables are set.
+func JobsFromConfig(cfg *config.Config) []config.ScheduledJob {
+	var jobs []config.ScheduledJob
+	if cfg != nil {
+		jobs = append(jobs, cfg.ScheduledJobs...)
+	}
+	if legacy, ok := legacyCopilotHotTakesJob(); ok {
+		jobs = append(jobs, legacy)
+	}
+	return jobs
+}
+
+// ParseJob validates one scheduled-jobs entry. index names unnamed jobs.
+func ParseJob(index int, entry config.ScheduledJob) (Job, error) {
+	job := Job{
+		Name:       strings.TrimSpace(entry.Name),
+		RunOnStart: entry.RunOnStart,
+		Model:      strings.TrimSpace(entry.Model),
+		Sources:    entry.Sources,
+		MaxTokens:  entry.MaxTokens,
+		Provider:   strings.ToLower(strings.TrimSpace(entry.Provider)),
+	}
+	if job.Name == "" {
+		job.Name = fmt.Sprintf("job-%d", index+1)
+	}
+	if job.Model == "" {
+		return Job{}, fmt.Errorf("model is required")
+	}
+	schedule, errSchedule := ParseSchedule(entry.Schedule)
+	if errSchedule != nil {
+		return Job{}, fmt.Errorf("schedule: %w", errSchedule)
+	}
+	job.Schedule = schedule
+	if strings.TrimSpace(entry.Prompt) == "" {
+		return Job{}, fmt.Errorf("prompt is required")
+	}
+	prompt, errTemplate := template.New(job.Name).Option("missingkey=error").Parse(entry.Prompt)
+	if errTemplate != nil {
+		return Job{}, fmt.Errorf("prompt: %w", errTemplate)
+	}
+	job.Prompt = prompt
+	switch pinning := strings.ToLower(strings.TrimSpace(entry.AuthPinning)); pinning {
+	case "", PinningNone:
+		job.Pinning = PinningNone
+	case PinningEach, PinningRoundRobin:
+		job.Pinning = pinning
+	default:
+		return Job{}, fmt.Errorf("unknown auth-pinning %q", entry.AuthPinning)
+	}
+	if len(entry.Headers) > 0 {
+		job.Headers = make(http.Header, len(entry.Headers))
+		for key, value := range entry.Headers {
+			if key = strings.TrimSpace(key); key != "" {
+				job.Headers.Set(key, value)
+			}
+		}
+	}
+	return job, nil
+}
+
+// Scheduler runs one goroutine per enabled job and restarts them when the job list changes.
+type Scheduler struct {
+	mu      sync.Mutex
+	cancel  context.CancelFunc
+	entries []config.ScheduledJob
+
+	// client fetches prompt sources; nil uses a default client.
+	client httpfetch.Doer
+	// shaper paces background requests across every job.
+	shaper *trafficshaper.Shaper
+}
+
+// ApplyShaping updates keep-alive shaping for current and future runs. Daily counters survive
+// the update.
+func (s *Scheduler) ApplyShaping(settings trafficshaper.Settings) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.shaper == nil {
+		s.shaper = trafficshaper.New(settings)
+		return
+	}
+	s.shaper.Update(settings)
+}
+
+// Apply replaces the running jobs with entries. Unchanged entries keep their schedule;
+// a nil backend or empty list stops every job.
+func (s *Scheduler) Apply(entries []config.ScheduledJob, backend Backend) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancel != nil && reflect.DeepEqual(s.entries, entries) {
+		return
+	}
+	if s.cancel != nil {
+		s.cancel()
+		s.cancel = nil
+	}
+	s.entries = entries
+	if backend == nil || len(entries) == 0 {
+		return
+	}
+	client := s.client
+	if client == nil {
+		client = defaultSourceClient
+	}
+	if s.shaper == nil {
+		s.shaper = trafficshaper.New(trafficshaper.DefaultSettings())
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	for i, entry := range entries {
+		if entry.Disabled {
+			continue
+		}
+		job, errParse := ParseJob(i, entry)
+		if errParse != nil {
+			log.Warnf("scheduled jobs: skipping %q: %v", entry.Name, errParse)
+			continue
+		}
+		r := &runner{job: job, backend: backend, client: client, shaper: s.shaper}
+		go r.loop(ctx)
+	}
+}
+
+// Stop cancels every running job.
+func (s *Scheduler) Stop() {
+	s.Apply(nil, nil)
+}
+
+// runner executes one job on its schedule.
+type runner struct {
+	job     Job
+	backend Backend
+	client  httpfetch.Doer
+	// shaper paces calls when it applies to the job's provider; nil disables pacing.
+	shaper *trafficshaper.Shaper
+	// next is the round-robin position among pinned auths.
+	next int
+}
+
+func (r *runner) loop(ctx context.Context) {
+	if r.job.RunOnStart {
+		if !sleepContext(ctx, startupDelay) {
+			return
+		}
+		r.runLogged(ctx)
+	}
+	for {
+		now := time.Now()
+		at := r.job.Schedule.Next(now)
+		if at.IsZero() {
+			log.Warnf("scheduled jobs: %s has no upcoming run; stopping", r.job.Name)
+			return
+		}
+		log.Debugf("scheduled jobs: %s next run at %s", r.job.Name, at.Format(time.RFC3339))
+		if !sleepContext(ctx, at.Sub(now)) {
+			return
+		}
+		r.runLogged(ctx)
+	}
+}
+
+func (r *runner) runLogged(ctx context.Context) {
+	runCtx, cancel := context.WithTimeout(ctx, runTimeout)
+	defer cancel()
+	if errRun := r.run(runCtx); errRun != nil && ctx.Err() == nil {
+		log.Warnf("scheduled jobs: %s run failed: %v", r.job.Name, errRun)
+	}
+}
+
+// run fetches sources, renders the prompt, and sends it to every pinned target.
+func (r *runner) run(ctx context.Context) error {
+	data, errSources := fetchSources(ctx, r.client, r.job.Sources)
+	if errSources != nil {
+		return errSources
+	}
+	var prompt strings.Builder
+	if errRender := r.job.Prompt.Execute(&prompt, data); errRender != nil {
+		return fmt.Errorf("render prompt: %w", errRender)
+	}
+	payload, errPayload := buildPayload(r.job, prompt.String())
+	if errPayload != nil {
+		return errPayload
+	}
+	targets, errTargets := r.targets()
+	if errTargets != nil {
+		return errTargets
+	}
+
+	shaped := r.shaper != nil && r.shaper.Applies(r.job.Provider)
+	for i, authID := range targets {
+		if shaped {
+			if errWait := r.shaper.Wait(ctx, authID); errWait != nil {
+				if errors.Is(errWait, trafficshaper.ErrDailyCap) || errors.Is(errWait, trafficshaper.ErrOutsideActiveHours) {
+					log.Debugf("scheduled jobs: %s call %d/%d auth=%q skipped: %v", r.job.Name, i+1, len(targets), authID, errWait)
+					continue
+				}
+				return errWait
+			}
+		}
+		resp, errExec := r.backend.Execute(ctx, r.job.Model, payload, r.job.Headers, authID)
+		if errExec != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			log.Warnf("scheduled jobs: %s call %d/%d auth=%q failed: %v", r.job.Name, i+1, len(targets), authID, errExec)
+		} else {
+			log.Infof("[scheduled job %s] call=%d/%d auth=%q model=%s\n%s", r.job.Name, i+1, len(targets), authID, r.job.Model, extractAssistantText(resp))
+		}
+	}
+	return nil
+}
+
+// targets returns the auth IDs to pin for this run; a single empty ID lets the router choose.
+func (r *runner) targets() ([]string, error) {
+	if r.job.Pinning == PinningNone {
+		return []string{""}, nil
+	}
+	ids := r.backend.AuthIDs(r.job.Provider, r.job.Model)
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no auths available for pinning")
+	}
+	if r.job.Pinning == PinningEach {
+		return ids, nil
+	}
+	id := ids[r.next%len(ids)]
+	r.next++
+	return []string{id}, nil
+}
+
+func buildPayload(job Job, prompt string) ([]byte, error) {
+	payload := map[string]any{
+		"model":    job.Model,
+		"messages": []map[string]string{{"role": "user", "content": prompt}},
+		"stream":   false,
+	}
+	if job.MaxTokens > 0 {
+		payload["max_tokens"] = job.MaxTokens
+	}
+	return json.Marshal(payload)
+}
+
+func extractAssistantText(resp []byte) string {
+	if v := gjson.GetBytes(resp, "choices.0.message.content"); v.Type == gjson.String {
+		return v.String()
+	}
+	if v := gjson.GetBytes(resp, "choices.0.message.content.0.text"); v.Type == gjson.String {
+		return v.String()
+	}
+	return strings.TrimSpace(string(resp))
+}
+
+// sleepContext waits for d and reports false when ctx is cancelled first.
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(max(d, 0))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
@@ -0,0 +1,152 @@
+package scheduledjobs
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+type fakeBackend struct {
+	ids     []string
+	prompts []string
+	pinned  []string
+	headers []http.Header
+}
+
+func (b *fakeBackend) Execute(_ context.Context, _ string, payload []byte, headers http.Header, authID string) ([]byte, error) {
+	b.prompts = append(b.prompts, gjson.GetBytes(payload, "messages.0.content").String())
+	b.pinned = append(b.pinned, authID)
+	b.headers = append(b.headers, headers)
+	return []byte(`{"choices":[{"message":{"content":"ok"}}]}`), nil
+}
+
+func (b *fakeBackend) AuthIDs(string, string) []string { return b.ids }
+
+func TestParseJobValidates(t *testing.T) {
+	valid := config.ScheduledJob{Schedule: "@daily", Model: "gpt-5", Prompt: "ping"}
+	job, err := ParseJob(2, valid)
+	if err != nil {
+		t.Fatalf("ParseJob error = %v", err)
+	}
+	if job.Name != "job-3" || job.Pinning != PinningNone {
+		t.Fatalf("job = %+v, want default name and pinning", job)
+	}
+
+	for name, mutate := range map[string]func(*config.ScheduledJob){
+		"missing model":  func(j *config.ScheduledJob) { j.Model = "" },
+		"bad schedule":   func(j *config.ScheduledJob) { j.Schedule = "often" },
+		"empty prompt":   func(j *config.ScheduledJob) { j.Prompt = " " },
+		"bad template":   func(j *config.ScheduledJob) { j.Prompt = "{{.x" },
+		"unknown pinned": func(j *config.ScheduledJob) { j.AuthPinning = "random" },
+	} {
+		entry := valid
+		mutate(&entry)
+		if _, errParse := ParseJob(0, entry); errParse == nil {
+			t.Fatalf("%s: expected error", name)
+		}
+	}
+}
+
+func TestRunnerRendersSourcesAndPinsEachAuth(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"items":[{"t":"one"},{"t":"two"},{"t":"three"}]}`))
+	}))
+	defer server.Close()
+
+	job, err := ParseJob(0, config.ScheduledJob{
+		Schedule:    "@daily",
+		Model:       "gpt-5",
+		Prompt:      "{{range .news}}[{{.}}]{{end}}",
+		Sources:     []config.ScheduledJobSource{{Name: "news", Type: "http", URL: server.URL, Path: "items.#.t", Count: 2}},
+		Headers:     map[string]string{"force-copilot-initiator": "user"},
+		AuthPinning: PinningEach,
+	})
+	if err != nil {
+		t.Fatalf("ParseJob error = %v", err)
+	}
+	backend := &fakeBackend{ids: []string{"a"}}
+	r := &runner{job: job, backend: backend, client: server.Client()}
+	if errRun := r.run(context.Background()); errRun != nil {
+		t.Fatalf("run error = %v", errRun)
+	}
+	if len(backend.prompts) != 1 || backend.prompts[0] != "[one][two]" {
+		t.Fatalf("prompts = %v, want [one][two]", backend.prompts)
+	}
+	if backend.pinned[0] != "a" || backend.headers[0].Get("Force-Copilot-Initiator") != "user" {
+		t.Fatalf("pinned = %v headers = %v", backend.pinned, backend.headers[0])
+	}
+}
+
+func TestRunnerRoundRobinRotatesAuths(t *testing.T) {
+	job, err := ParseJob(0, config.ScheduledJob{Schedule: "@hourly", Model: "m", Prompt: "ping", AuthPinning: PinningRoundRobin})
+	if err != nil {
+		t.Fatalf("ParseJob error = %v", err)
+	}
+	backend := &fakeBackend{ids: []string{"a", "b"}}
+	r := &runner{job: job, backend: backend}
+	for i := 0; i < 3; i++ {
+		if errRun := r.run(context.Background()); errRun != nil {
+			t.Fatalf("run error = %v", errRun)
+		}
+	}
+	if got := strings.Join(backend.pinned, ","); got != "a,b,a" {
+		t.Fatalf("pinned = %s, want a,b,a", got)
+	}
+
+	backend.ids = nil
+	if errRun := r.run(context.Background()); errRun == nil {
+		t.Fatal("expected error without auths")
+	}
+}
+
+func TestRunnerFailingSourceSkipsCall(t *testing.T) {
+	job, err := ParseJob(0, config.ScheduledJob{
+		Schedule: "@hourly",
+		Model:    "m",
+		Prompt:   "{{.x}}",
+		Sources:  []config.ScheduledJobSource{{Name: "x", Type: "ftp"}},
+	})
+	if err != nil {
+		t.Fatalf("ParseJob error = %v", err)
+	}
+	backend := &fakeBackend{}
+	if errRun := (&runner{job: job, backend: backend}).run(context.Background()); errRun == nil {
+		t.Fatal("expected source error")
+	}
+	if len(backend.prompts) != 0 {
+		t.Fatalf("prompts = %v, want none", backend.prompts)
+	}
+}
+
+func TestLegacyCopilotHotTakesJob(t *testing.T) {
+	t.Setenv("COPILOT_HOT_TAKES_INTERVAL_MINS", "45")
+	t.Setenv("COPILOT_HOT_TAKES_MODEL", "gpt-5-mini")
+	jobs := JobsFromConfig(&config.Config{})
+	if len(jobs) != 1 {
+		t.Fatalf("jobs = %d, want legacy job", len(jobs))
+	}
+	job, err := ParseJob(0, jobs[0])
+	if err != nil {
+		t.Fatalf("legacy job invalid: %v", err)
+	}
+	if job.Model != "copilot-gpt-5-mini" || job.Pinning != PinningEach || job.Provider != "copilot" {
+		t.Fatalf("job = %+v", job)
+	}
+	var prompt strings.Builder
+	if errRender := job.Prompt.Execute(&prompt, map[string]any{"headlines": []string{"a", "b"}}); errRender != nil {
+		t.Fatalf("render error = %v", errRender)
+	}
+	if want := "What do you think about these headlines?\n- a\n- b\n"; prompt.String() != want {
+		t.Fatalf("prompt = %q, want %q", prompt.String(), want)
+	}
+
+	t.Setenv("COPILOT_HOT_TAKES_INTERVAL_MINS", "")
+	if jobs := JobsFromConfig(nil); len(jobs) != 0 {
+		t.Fatalf("jobs = %v, want none", jobs)
+	}
+}
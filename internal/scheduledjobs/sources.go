@@ -0,0 +1,137 @@
+package scheduledjobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/httpfetch"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+const (
+	sourceTypeHackerNews = "hacker-news"
+	sourceTypeHTTP       = "http"
+
+	defaultHackerNewsCount = 7
+	maxSourceBodySize      = 4 << 20
+)
+
+var (
+	hnTopStoriesURL = "https://hacker-news.firebaseio.com/v0/topstories.json"
+	hnItemURLFmt    = "https://hacker-news.firebaseio.com/v0/item/%d.json"
+)
+
+// fetchSources resolves every source of a job into template data keyed by source name.
+// A failing source aborts the run so the model is never prompted with missing data.
+func fetchSources(ctx context.Context, client httpfetch.Doer, sources []config.ScheduledJobSource) (map[string]any, error) {
+	data := make(map[string]any, len(sources))
+	for _, source := range sources {
+		name := strings.TrimSpace(source.Name)
+		if name == "" {
+			continue
+		}
+		var (
+			items []string
+			err   error
+		)
+		switch strings.ToLower(strings.TrimSpace(source.Type)) {
+		case sourceTypeHackerNews:
+			count := source.Count
+			if count <= 0 {
+				count = defaultHackerNewsCount
+			}
+			items, err = fetchHackerNewsTitles(ctx, client, count)
+		case sourceTypeHTTP:
+			items, err = fetchHTTPItems(ctx, client, source)
+		default:
+			err = fmt.Errorf("unknown type %q", source.Type)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("source %s: %w", name, err)
+		}
+		data[name] = items
+	}
+	return data, nil
+}
+
+// fetchHackerNewsTitles returns up to count titles of random top stories. Items that fail to
+// load are skipped so a single bad item does not shrink the result.
+func fetchHackerNewsTitles(ctx context.Context, client httpfetch.Doer, count int) ([]string, error) {
+	body, errFetch := httpfetch.GetBytes(ctx, client, hnTopStoriesURL, nil, maxSourceBodySize)
+	if errFetch != nil {
+		return nil, fmt.Errorf("top stories: %w", errFetch)
+	}
+	var ids []int64
+	if errDecode := json.Unmarshal(body, &ids); errDecode != nil {
+		return nil, fmt.Errorf("top stories: %w", errDecode)
+	}
+	r := rand.New(rand.NewSource(time.Now().UnixNano()))
+	r.Shuffle(len(ids), func(i, j int) { ids[i], ids[j] = ids[j], ids[i] })
+
+	titles := make([]string, 0, count)
+	for _, id := range ids {
+		if len(titles) >= count {
+			break
+		}
+		item, errItem := httpfetch.GetBytes(ctx, client, fmt.Sprintf(hnItemURLFmt, id), nil, 1<<20)
+		if errItem != nil {
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			log.Debugf("scheduled jobs: skip HN item %d: %v", id, errItem)
+			continue
+		}
+		if title := strings.TrimSpace(gjson.GetBytes(item, "title").String()); title != "" {
+			titles = append(titles, title)
+		}
+	}
+	if len(titles) == 0 {
+		return nil, fmt.Errorf("no titles fetched")
+	}
+	if len(titles) < count {
+		log.Warnf("scheduled jobs: only fetched %d/%d HN titles; continuing anyway", len(titles), count)
+	}
+	return titles, nil
+}
+
+// fetchHTTPItems GETs source.URL and selects items with source.Path, or returns the whole body.
+func fetchHTTPItems(ctx context.Context, client httpfetch.Doer, source config.ScheduledJobSource) ([]string, error) {
+	rawURL := strings.TrimSpace(source.URL)
+	if rawURL == "" {
+		return nil, fmt.Errorf("url is required")
+	}
+	body, errFetch := httpfetch.GetBytes(ctx, client, rawURL, nil, maxSourceBodySize)
+	if errFetch != nil {
+		return nil, errFetch
+	}
+	path := strings.TrimSpace(source.Path)
+	if path == "" {
+		return []string{strings.TrimSpace(string(body))}, nil
+	}
+	result := gjson.GetBytes(body, path)
+	if !result.Exists() {
+		return nil, fmt.Errorf("path %q not found", path)
+	}
+	var items []string
+	if result.IsArray() {
+		for _, item := range result.Array() {
+			items = append(items, item.String())
+		}
+	} else {
+		items = []string{result.String()}
+	}
+	if source.Count > 0 && len(items) > source.Count {
+		items = items[:source.Count]
+	}
+	return items, nil
+}
+
+// defaultSourceClient is used when the scheduler is not given a client.
+var defaultSourceClient = &http.Client{Timeout: 15 * time.Second}
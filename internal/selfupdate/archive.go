@@ -0,0 +1,98 @@
+package selfupdate
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// binaryNamePrefix matches the release binaries (cli-proxy-api, cli-proxy-api-plus).
+const binaryNamePrefix = "cli-proxy-api"
+
+// extractBinary returns the proxy executable from a release archive. A file with the same
+// name as the running executable wins; otherwise the first cli-proxy-api* binary is used.
+func extractBinary(archiveName string, archive []byte, preferredName string) ([]byte, error) {
+	files := make(map[string][]byte)
+	var order []string
+	collect := func(name string, r io.Reader) error {
+		base := path.Base(strings.ReplaceAll(name, `\`, "/"))
+		if !strings.HasPrefix(strings.ToLower(base), binaryNamePrefix) {
+			return nil
+		}
+		data, err := io.ReadAll(io.LimitReader(r, maxArchiveSize+1))
+		if err != nil {
+			return fmt.Errorf("read %s from archive: %w", name, err)
+		}
+		if len(data) > maxArchiveSize {
+			return fmt.Errorf("%s in archive exceeds %d bytes", name, maxArchiveSize)
+		}
+		if _, exists := files[base]; !exists {
+			order = append(order, base)
+		}
+		files[base] = data
+		return nil
+	}
+
+	lowerName := strings.ToLower(archiveName)
+	switch {
+	case strings.HasSuffix(lowerName, ".zip"):
+		reader, err := zip.NewReader(bytes.NewReader(archive), int64(len(archive)))
+		if err != nil {
+			return nil, fmt.Errorf("open zip archive: %w", err)
+		}
+		for _, file := range reader.File {
+			if file.FileInfo().IsDir() {
+				continue
+			}
+			rc, errOpen := file.Open()
+			if errOpen != nil {
+				return nil, fmt.Errorf("open %s in archive: %w", file.Name, errOpen)
+			}
+			errCollect := collect(file.Name, rc)
+			_ = rc.Close()
+			if errCollect != nil {
+				return nil, errCollect
+			}
+		}
+	case strings.HasSuffix(lowerName, ".tar.gz"), strings.HasSuffix(lowerName, ".tgz"):
+		gz, err := gzip.NewReader(bytes.NewReader(archive))
+		if err != nil {
+			return nil, fmt.Errorf("open gzip archive: %w", err)
+		}
+		defer func() {
+			_ = gz.Close()
+		}()
+		tr := tar.NewReader(gz)
+		for {
+			header, errNext := tr.Next()
+			if errors.Is(errNext, io.EOF) {
+				break
+			}
+			if errNext != nil {
+				return nil, fmt.Errorf("read tar archive: %w", errNext)
+			}
+			if header.Typeflag != tar.TypeReg {
+				continue
+			}
+			if errCollect := collect(header.Name, tr); errCollect != nil {
+				return nil, errCollect
+			}
+		}
+	default:
+		return nil, fmt.Errorf("unsupported archive format: %s", archiveName)
+	}
+
+	if data, ok := files[preferredName]; ok {
+		return data, nil
+	}
+	if len(order) > 0 {
+		return files[order[0]], nil
+	}
+	return nil, fmt.Errorf("no %s binary found in %s", binaryNamePrefix, archiveName)
+}
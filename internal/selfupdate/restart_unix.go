@@ -0,0 +1,22 @@
+//go:build !windows
+
+package selfupdate
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// Restart replaces the current process with the freshly installed binary, keeping the
+// same PID so service managers (launchd, systemd) keep tracking it.
+func Restart() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("resolve executable path: %w", err)
+	}
+	if errExec := syscall.Exec(exe, os.Args, os.Environ()); errExec != nil {
+		return fmt.Errorf("exec new binary: %w", errExec)
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+//go:build windows
+
+package selfupdate
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+
+	"golang.org/x/sys/windows/svc"
+)
+
+// Restart starts the freshly installed binary. When running as a Windows service the
+// process exits with a failure code instead, so the service recovery actions restart it.
+func Restart() error {
+	if isService, err := svc.IsWindowsService(); err == nil && isService {
+		os.Exit(1)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("resolve executable path: %w", err)
+	}
+	cmd := exec.Command(exe, os.Args[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if errStart := cmd.Start(); errStart != nil {
+		return fmt.Errorf("start new binary: %w", errStart)
+	}
+	return nil
+}
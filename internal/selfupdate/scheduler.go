@@ -0,0 +1,117 @@
+package selfupdate
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultCheckInterval = 24 * time.Hour
+
+var (
+	currentConfigPtr atomic.Pointer[config.Config]
+	schedulerOnce    sync.Once
+	restartOnce      sync.Once
+	restartCh        = make(chan struct{})
+)
+
+// SetCurrentConfig stores the latest configuration snapshot for the scheduler.
+func SetCurrentConfig(cfg *config.Config) {
+	currentConfigPtr.Store(cfg)
+}
+
+// RestartRequested is closed once an update has been installed and the process should
+// shut down gracefully and Restart.
+func RestartRequested() <-chan struct{} {
+	return restartCh
+}
+
+// RestartPending reports whether an installed update is waiting for a restart.
+func RestartPending() bool {
+	select {
+	case <-restartCh:
+		return true
+	default:
+		return false
+	}
+}
+
+func requestRestart() {
+	restartOnce.Do(func() {
+		close(restartCh)
+	})
+}
+
+// StartScheduler launches the background update loop. It re-reads the configuration on
+// every tick, so enabling self-update or switching channels takes effect on hot reload.
+func StartScheduler(ctx context.Context) {
+	schedulerOnce.Do(func() {
+		go runScheduler(ctx)
+	})
+}
+
+func checkInterval(cfg *config.Config) time.Duration {
+	if cfg == nil {
+		return defaultCheckInterval
+	}
+	raw := strings.TrimSpace(cfg.SelfUpdate.CheckInterval)
+	if raw == "" {
+		return defaultCheckInterval
+	}
+	interval, err := time.ParseDuration(raw)
+	if err != nil || interval < time.Hour {
+		log.Warnf("self-update: invalid check-interval %q, using %s", raw, defaultCheckInterval)
+		return defaultCheckInterval
+	}
+	return interval
+}
+
+func runScheduler(ctx context.Context) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	// Delay the first check so startup is not slowed by network calls.
+	timer := time.NewTimer(time.Minute)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-restartCh:
+			return
+		case <-timer.C:
+		}
+		cfg := currentConfigPtr.Load()
+		if cfg != nil && cfg.SelfUpdate.Enabled {
+			runScheduledUpdate(ctx, cfg)
+		}
+		timer.Reset(checkInterval(cfg))
+	}
+}
+
+func runScheduledUpdate(ctx context.Context, cfg *config.Config) {
+	opts := OptionsFromConfig(cfg)
+	// Unattended installs must be signature-verified.
+	opts.RequireSignature = true
+	release, err := Check(ctx, opts)
+	if errors.Is(err, ErrNoUpdate) {
+		log.Debugf("self-update: up to date on %s channel", normalizeChannel(opts.Channel))
+		return
+	}
+	if err != nil {
+		log.Warnf("self-update: check failed: %v", err)
+		return
+	}
+	log.Infof("self-update: installing %s from %s channel", release.Version, normalizeChannel(opts.Channel))
+	if errApply := Apply(ctx, release, opts); errApply != nil {
+		log.Errorf("self-update: install failed: %v", errApply)
+		return
+	}
+	requestRestart()
+}
@@ -0,0 +1,401 @@
+// Package selfupdate checks GitHub releases for newer builds of the proxy, verifies the
+// downloaded archive against the release checksums (and an optional Ed25519 signature),
+// and swaps the running executable in place.
+package selfupdate
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/buildinfo"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/httpfetch"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultRepository is the GitHub repository release builds are published to.
+	DefaultRepository = "https://github.com/router-for-me/CLIProxyAPIPlus"
+	// ChannelStable only considers full releases.
+	ChannelStable = "stable"
+	// ChannelBeta also considers pre-releases.
+	ChannelBeta = "beta"
+
+	checksumsAssetName   = "checksums.txt"
+	signatureAssetSuffix = ".sig"
+	httpUserAgent        = "CLIProxyAPI-self-updater"
+	maxMetadataSize      = 4 << 20
+	maxArchiveSize       = 256 << 20
+	downloadTimeout      = 10 * time.Minute
+)
+
+// ErrNoUpdate is returned when the running build is already the newest on the channel.
+var ErrNoUpdate = errors.New("already running the latest version")
+
+// Release describes the release selected for an update.
+type Release struct {
+	Version    string
+	Prerelease bool
+	Archive    Asset
+	Checksums  Asset
+	Signature  *Asset
+}
+
+// Asset is a downloadable file attached to a release.
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+type githubRelease struct {
+	TagName    string  `json:"tag_name"`
+	Draft      bool    `json:"draft"`
+	Prerelease bool    `json:"prerelease"`
+	Assets     []Asset `json:"assets"`
+}
+
+// Options controls a single check or update run.
+type Options struct {
+	// Channel is ChannelStable or ChannelBeta.
+	Channel string
+	// Repository is a GitHub repository URL or API releases endpoint.
+	Repository string
+	// PublicKey is a base64 Ed25519 public key used to verify checksums.txt.sig, which holds
+	// the base64 signature of checksums.txt.
+	PublicKey string
+	// RequireSignature rejects releases that cannot be signature-verified.
+	RequireSignature bool
+	// ProxyURL routes GitHub requests through the configured outbound proxy.
+	ProxyURL string
+	// CurrentVersion defaults to buildinfo.Version.
+	CurrentVersion string
+	// Force installs the selected release even when it is not newer.
+	Force bool
+}
+
+// OptionsFromConfig builds update options from the self-update config section.
+func OptionsFromConfig(cfg *config.Config) Options {
+	if cfg == nil {
+		return Options{Channel: ChannelStable}
+	}
+	return Options{
+		Channel:    cfg.SelfUpdate.Channel,
+		Repository: cfg.SelfUpdate.Repository,
+		PublicKey:  cfg.SelfUpdate.PublicKey,
+		ProxyURL:   cfg.ProxyURL,
+	}
+}
+
+func normalizeChannel(channel string) string {
+	if strings.EqualFold(strings.TrimSpace(channel), ChannelBeta) {
+		return ChannelBeta
+	}
+	return ChannelStable
+}
+
+func newHTTPClient(proxyURL string, timeout time.Duration) *http.Client {
+	client := &http.Client{Timeout: timeout}
+	sdkCfg := &sdkconfig.SDKConfig{ProxyURL: strings.TrimSpace(proxyURL)}
+	util.SetProxy(sdkCfg, client)
+	return client
+}
+
+// resolveReleasesURL converts a repository URL into the GitHub API releases list endpoint.
+func resolveReleasesURL(repo string) (string, error) {
+	repo = strings.TrimSpace(repo)
+	if repo == "" {
+		repo = DefaultRepository
+	}
+	parsed, err := url.Parse(repo)
+	if err != nil || parsed.Host == "" {
+		return "", fmt.Errorf("invalid self-update repository %q", repo)
+	}
+	parts := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	switch strings.ToLower(parsed.Host) {
+	case "github.com":
+		if len(parts) >= 2 && parts[0] != "" && parts[1] != "" {
+			return fmt.Sprintf("https://api.github.com/repos/%s/%s/releases", parts[0], strings.TrimSuffix(parts[1], ".git")), nil
+		}
+	case "api.github.com":
+		if len(parts) >= 3 && parts[0] == "repos" {
+			return fmt.Sprintf("https://api.github.com/repos/%s/%s/releases", parts[1], parts[2]), nil
+		}
+	}
+	return "", fmt.Errorf("unsupported self-update repository %q", repo)
+}
+
+// Check returns the newest release on the configured channel, or ErrNoUpdate when the
+// running build is already current.
+func Check(ctx context.Context, opts Options) (*Release, error) {
+	releasesURL, err := resolveReleasesURL(opts.Repository)
+	if err != nil {
+		return nil, err
+	}
+	client := newHTTPClient(opts.ProxyURL, 30*time.Second)
+	data, err := httpfetch.GetBytes(ctx, client, releasesURL, map[string]string{
+		"Accept":     "application/vnd.github+json",
+		"User-Agent": httpUserAgent,
+	}, maxMetadataSize)
+	if err != nil {
+		return nil, fmt.Errorf("fetch releases: %w", err)
+	}
+	var releases []githubRelease
+	if errDecode := json.Unmarshal(data, &releases); errDecode != nil {
+		return nil, fmt.Errorf("decode releases: %w", errDecode)
+	}
+	release, err := selectRelease(releases, normalizeChannel(opts.Channel), runtime.GOOS, runtime.GOARCH)
+	if err != nil {
+		return nil, err
+	}
+	current := opts.CurrentVersion
+	if current == "" {
+		current = buildinfo.Version
+	}
+	if !opts.Force && !isNewerVersion(release.Version, current) {
+		return release, ErrNoUpdate
+	}
+	return release, nil
+}
+
+// selectRelease picks the first (newest) non-draft release on the channel that ships an
+// archive for goos/goarch together with a checksums file.
+func selectRelease(releases []githubRelease, channel, goos, goarch string) (*Release, error) {
+	for _, candidate := range releases {
+		if candidate.Draft || (candidate.Prerelease && channel != ChannelBeta) {
+			continue
+		}
+		archive, ok := archiveAsset(candidate.Assets, goos, goarch)
+		if !ok {
+			continue
+		}
+		release := &Release{
+			Version:    strings.TrimSpace(candidate.TagName),
+			Prerelease: candidate.Prerelease,
+			Archive:    archive,
+		}
+		hasChecksums := false
+		for i := range candidate.Assets {
+			asset := candidate.Assets[i]
+			switch asset.Name {
+			case checksumsAssetName:
+				release.Checksums = asset
+				hasChecksums = true
+			case checksumsAssetName + signatureAssetSuffix:
+				release.Signature = &asset
+			}
+		}
+		if !hasChecksums {
+			continue
+		}
+		return release, nil
+	}
+	return nil, fmt.Errorf("no %s release found for %s/%s", channel, goos, goarch)
+}
+
+// archiveAsset finds the release archive for the platform, following the goreleaser
+// naming used by release builds (aarch64 for arm64, zip on Windows).
+func archiveAsset(assets []Asset, goos, goarch string) (Asset, bool) {
+	arch := goarch
+	if goarch == "arm64" {
+		arch = "aarch64"
+	}
+	ext := ".tar.gz"
+	if goos == "windows" {
+		ext = ".zip"
+	}
+	suffix := "_" + goos + "_" + arch + ext
+	for _, asset := range assets {
+		if strings.HasSuffix(strings.ToLower(asset.Name), suffix) {
+			return asset, true
+		}
+	}
+	return Asset{}, false
+}
+
+// isNewerVersion reports whether candidate is a higher numeric version than current.
+// Development builds never auto-update unless forced.
+func isNewerVersion(candidate, current string) bool {
+	candidateParts, okCandidate := parseVersion(candidate)
+	currentParts, okCurrent := parseVersion(current)
+	if !okCandidate || !okCurrent {
+		return false
+	}
+	for i := 0; i < len(candidateParts) || i < len(currentParts); i++ {
+		var a, b int
+		if i < len(candidateParts) {
+			a = candidateParts[i]
+		}
+		if i < len(currentParts) {
+			b = currentParts[i]
+		}
+		if a != b {
+			return a > b
+		}
+	}
+	return false
+}
+
+func parseVersion(version string) ([]int, bool) {
+	version = strings.TrimPrefix(strings.TrimSpace(version), "v")
+	if idx := strings.IndexAny(version, "-+"); idx >= 0 {
+		version = version[:idx]
+	}
+	if version == "" {
+		return nil, false
+	}
+	fields := strings.Split(version, ".")
+	parts := make([]int, 0, len(fields))
+	for _, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return nil, false
+		}
+		parts = append(parts, n)
+	}
+	return parts, true
+}
+
+// Apply downloads, verifies and installs release over the running executable.
+// The new binary takes effect after the process restarts.
+func Apply(ctx context.Context, release *Release, opts Options) error {
+	if release == nil {
+		return fmt.Errorf("no release to apply")
+	}
+	client := newHTTPClient(opts.ProxyURL, downloadTimeout)
+	headers := map[string]string{"User-Agent": httpUserAgent}
+
+	checksums, err := httpfetch.GetBytes(ctx, client, release.Checksums.URL, headers, maxMetadataSize)
+	if err != nil {
+		return fmt.Errorf("download checksums: %w", err)
+	}
+	if errVerify := verifyChecksumsSignature(ctx, client, release, checksums, opts); errVerify != nil {
+		return errVerify
+	}
+	expected, ok := checksumFor(checksums, release.Archive.Name)
+	if !ok {
+		return fmt.Errorf("checksum for %s not found in %s", release.Archive.Name, checksumsAssetName)
+	}
+
+	archive, err := httpfetch.GetBytes(ctx, client, release.Archive.URL, headers, maxArchiveSize)
+	if err != nil {
+		return fmt.Errorf("download archive: %w", err)
+	}
+	sum := sha256.Sum256(archive)
+	if actual := hex.EncodeToString(sum[:]); actual != expected {
+		return fmt.Errorf("checksum mismatch for %s: expected %s, got %s", release.Archive.Name, expected, actual)
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("resolve executable path: %w", err)
+	}
+	if resolved, errEval := filepath.EvalSymlinks(exe); errEval == nil {
+		exe = resolved
+	}
+	binary, err := extractBinary(release.Archive.Name, archive, filepath.Base(exe))
+	if err != nil {
+		return err
+	}
+	if errReplace := replaceExecutable(exe, binary); errReplace != nil {
+		return errReplace
+	}
+	log.Infof("self-update: installed %s to %s", release.Version, exe)
+	return nil
+}
+
+func verifyChecksumsSignature(ctx context.Context, client *http.Client, release *Release, checksums []byte, opts Options) error {
+	publicKey := strings.TrimSpace(opts.PublicKey)
+	if publicKey == "" {
+		if opts.RequireSignature {
+			return fmt.Errorf("self-update requires self-update.public-key to verify release signatures")
+		}
+		log.Warn("self-update: no public key configured, verifying checksums only")
+		return nil
+	}
+	if release.Signature == nil {
+		return fmt.Errorf("release %s has no %s", release.Version, checksumsAssetName+signatureAssetSuffix)
+	}
+	signature, err := httpfetch.GetBytes(ctx, client, release.Signature.URL, map[string]string{"User-Agent": httpUserAgent}, 4096)
+	if err != nil {
+		return fmt.Errorf("download signature: %w", err)
+	}
+	return verifySignature(publicKey, checksums, signature)
+}
+
+// verifySignature checks an Ed25519 signature over data. The key and the signature are
+// both standard base64 text; surrounding whitespace, such as a trailing newline in
+// checksums.txt.sig, is ignored.
+func verifySignature(publicKey string, data, signature []byte) error {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(publicKey))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return fmt.Errorf("invalid self-update public key")
+	}
+	sig, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(signature)))
+	if err != nil || len(sig) != ed25519.SignatureSize {
+		return fmt.Errorf("invalid release signature encoding: want base64 Ed25519 signature")
+	}
+	if !ed25519.Verify(ed25519.PublicKey(key), data, sig) {
+		return fmt.Errorf("release signature verification failed")
+	}
+	return nil
+}
+
+// checksumFor looks up name in a sha256sum-formatted checksums file.
+func checksumFor(checksums []byte, name string) (string, bool) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		if strings.TrimPrefix(fields[1], "*") == name {
+			return strings.ToLower(fields[0]), true
+		}
+	}
+	return "", false
+}
+
+// replaceExecutable swaps the binary at path for data. The previous binary is kept as
+// path+".old" so a failed start can be rolled back by hand; renaming (rather than
+// overwriting) works while the old executable is still running, including on Windows.
+func replaceExecutable(path string, data []byte) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fmt.Errorf("stat executable: %w", err)
+	}
+	newPath := path + ".new"
+	oldPath := path + ".old"
+	if errWrite := os.WriteFile(newPath, data, info.Mode().Perm()|0o100); errWrite != nil {
+		return fmt.Errorf("write new executable: %w", errWrite)
+	}
+	_ = os.Remove(oldPath)
+	if errRename := os.Rename(path, oldPath); errRename != nil {
+		_ = os.Remove(newPath)
+		return fmt.Errorf("move current executable aside: %w", errRename)
+	}
+	if errRename := os.Rename(newPath, path); errRename != nil {
+		if errRestore := os.Rename(oldPath, path); errRestore != nil {
+			return fmt.Errorf("install new executable: %w (restore failed: %v)", errRename, errRestore)
+		}
+		return fmt.Errorf("install new executable: %w", errRename)
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package selfupdate
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"testing"
+)
+
+func TestIsNewerVersion(t *testing.T) {
+	cases := []struct {
+		candidate string
+		current   string
+		want      bool
+	}{
+		{"v6.6.1", "6.6.0", true},
+		{"v6.6.0-plus", "6.6.0-plus", false},
+		{"v6.10.0", "6.9.9", true},
+		{"v6.5.0", "6.6.0", false},
+		{"v7.0.0", "dev", false},
+	}
+	for _, tc := range cases {
+		if got := isNewerVersion(tc.candidate, tc.current); got != tc.want {
+			t.Fatalf("isNewerVersion(%q, %q) = %t, want %t", tc.candidate, tc.current, got, tc.want)
+		}
+	}
+}
+
+func TestSelectReleaseHonorsChannel(t *testing.T) {
+	assets := []Asset{
+		{Name: "CLIProxyAPIPlus_6.7.0_linux_amd64.tar.gz", URL: "https://example.com/a"},
+		{Name: "checksums.txt", URL: "https://example.com/c"},
+	}
+	releases := []githubRelease{
+		{TagName: "v6.8.0-beta.1", Prerelease: true, Assets: assets},
+		{TagName: "v6.7.0", Assets: assets},
+	}
+
+	stable, err := selectRelease(releases, ChannelStable, "linux", "amd64")
+	if err != nil {
+		t.Fatalf("select stable: %v", err)
+	}
+	if stable.Version != "v6.7.0" {
+		t.Fatalf("stable version = %s, want v6.7.0", stable.Version)
+	}
+	beta, err := selectRelease(releases, ChannelBeta, "linux", "amd64")
+	if err != nil {
+		t.Fatalf("select beta: %v", err)
+	}
+	if beta.Version != "v6.8.0-beta.1" {
+		t.Fatalf("beta version = %s, want v6.8.0-beta.1", beta.Version)
+	}
+	if _, err = selectRelease(releases, ChannelStable, "windows", "arm64"); err == nil {
+		t.Fatal("expected error when no archive matches the platform")
+	}
+}
+
+func TestResolveReleasesURL(t *testing.T) {
+	got, err := resolveReleasesURL("https://github.com/acme/proxy.git")
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if got != "https://api.github.com/repos/acme/proxy/releases" {
+		t.Fatalf("releases url = %s", got)
+	}
+	if _, err = resolveReleasesURL("https://example.com/acme/proxy"); err == nil {
+		t.Fatal("expected error for non-GitHub repository")
+	}
+}
+
+func TestChecksumFor(t *testing.T) {
+	checksums := []byte("abc123  other.tar.gz\nDEADBEEF *CLIProxyAPIPlus_6.7.0_linux_amd64.tar.gz\n")
+	sum, ok := checksumFor(checksums, "CLIProxyAPIPlus_6.7.0_linux_amd64.tar.gz")
+	if !ok || sum != "deadbeef" {
+		t.Fatalf("checksumFor = %q, %t", sum, ok)
+	}
+	if _, ok = checksumFor(checksums, "missing.zip"); ok {
+		t.Fatal("expected missing entry")
+	}
+}
+
+func TestVerifySignature(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	data := []byte("checksums")
+	sig := ed25519.Sign(priv, data)
+	key := base64.StdEncoding.EncodeToString(pub)
+
+	encoded := []byte(base64.StdEncoding.EncodeToString(sig) + "\n")
+	if errVerify := verifySignature(key, data, encoded); errVerify != nil {
+		t.Fatalf("base64 signature: %v", errVerify)
+	}
+	if errVerify := verifySignature(key, data, sig); errVerify == nil {
+		t.Fatal("expected raw signature bytes to be rejected")
+	}
+	if errVerify := verifySignature(key, []byte("tampered"), encoded); errVerify == nil {
+		t.Fatal("expected verification failure for tampered data")
+	}
+}
+
+func TestExtractBinaryFromTarGz(t *testing.T) {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+	for name, body := range map[string]string{"LICENSE": "license", "cli-proxy-api-plus": "binary"} {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o755, Size: int64(len(body)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatalf("write header: %v", err)
+		}
+		if _, err := tw.Write([]byte(body)); err != nil {
+			t.Fatalf("write body: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("close tar: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("close gzip: %v", err)
+	}
+
+	data, err := extractBinary("release_linux_amd64.tar.gz", buf.Bytes(), "cli-proxy-api-plus")
+	if err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	if string(data) != "binary" {
+		t.Fatalf("extracted %q, want binary", data)
+	}
+}
+
+func TestExtractBinaryFromZip(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	w, err := zw.Create("cli-proxy-api-plus.exe")
+	if err != nil {
+		t.Fatalf("create entry: %v", err)
+	}
+	if _, err = w.Write([]byte("exe")); err != nil {
+		t.Fatalf("write entry: %v", err)
+	}
+	if err = zw.Close(); err != nil {
+		t.Fatalf("close zip: %v", err)
+	}
+
+	data, err := extractBinary("release_windows_amd64.zip", buf.Bytes(), "cli-proxy-api.exe")
+	if err != nil {
+		t.Fatalf("extract: %v", err)
+	}
+	if string(data) != "exe" {
+		t.Fatalf("extracted %q, want exe", data)
+	}
+}
@@ -0,0 +1,113 @@
+// Package service registers the proxy as a background OS service so it starts at login
+// and restarts automatically: a Windows service on Windows and a launchd agent on macOS.
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+const (
+	// DefaultName is the service name used on Windows and the log file stem on macOS.
+	DefaultName = "cli-proxy-api"
+	// DefaultLabel is the launchd job label used on macOS.
+	DefaultLabel = "com.router-for-me.cli-proxy-api"
+	// DefaultDisplayName is the human-readable Windows service name.
+	DefaultDisplayName = "CLI Proxy API"
+)
+
+// ErrUnsupported is returned on platforms without a supported service manager.
+var ErrUnsupported = errors.New("service management is only supported on Windows and macOS")
+
+// Options describes how the proxy is registered with the OS service manager.
+type Options struct {
+	// Name is the Windows service name; on macOS it names the log file.
+	Name string
+	// Label is the launchd job label.
+	Label string
+	// Executable is the absolute path of the proxy binary.
+	Executable string
+	// Args are passed to Executable on every start (typically "-config <path>").
+	Args []string
+	// WorkingDir is the directory the service runs in.
+	WorkingDir string
+	// LogDir receives the service's stdout/stderr.
+	LogDir string
+}
+
+// Run performs a service action: install, uninstall, start or stop.
+func Run(action string, opts Options) error {
+	opts = normalizeOptions(opts)
+	switch strings.ToLower(strings.TrimSpace(action)) {
+	case "install":
+		if opts.Executable == "" {
+			return fmt.Errorf("service install: executable path is required")
+		}
+		return install(opts)
+	case "uninstall":
+		return uninstall(opts)
+	case "start":
+		return start(opts)
+	case "stop":
+		return stop(opts)
+	default:
+		return fmt.Errorf("unknown service action %q (expected install, uninstall, start or stop)", action)
+	}
+}
+
+// StopContext returns a context that is canceled when the OS service manager asks the
+// process to stop, and a function the caller must invoke once shutdown has finished.
+// Outside a managed service it returns parent unchanged.
+func StopContext(parent context.Context) (context.Context, func()) {
+	return stopContext(parent)
+}
+
+func normalizeOptions(opts Options) Options {
+	if strings.TrimSpace(opts.Name) == "" {
+		opts.Name = DefaultName
+	}
+	if strings.TrimSpace(opts.Label) == "" {
+		opts.Label = DefaultLabel
+	}
+	return opts
+}
+
+// launchdPlist renders a launchd agent definition that keeps the proxy running and
+// redirects its output into LogDir.
+func launchdPlist(opts Options, stdoutPath, stderrPath string) []byte {
+	var buf bytes.Buffer
+	writeString := func(value string) {
+		buf.WriteString("<string>")
+		_ = xml.EscapeText(&buf, []byte(value))
+		buf.WriteString("</string>")
+	}
+	buf.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	buf.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	buf.WriteString(`<plist version="1.0">` + "\n<dict>\n")
+	buf.WriteString("\t<key>Label</key>")
+	writeString(opts.Label)
+	buf.WriteString("\n\t<key>ProgramArguments</key>\n\t<array>\n")
+	for _, arg := range append([]string{opts.Executable}, opts.Args...) {
+		buf.WriteString("\t\t")
+		writeString(arg)
+		buf.WriteString("\n")
+	}
+	buf.WriteString("\t</array>\n")
+	if opts.WorkingDir != "" {
+		buf.WriteString("\t<key>WorkingDirectory</key>")
+		writeString(opts.WorkingDir)
+		buf.WriteString("\n")
+	}
+	buf.WriteString("\t<key>RunAtLoad</key>\n\t<true/>\n")
+	buf.WriteString("\t<key>KeepAlive</key>\n\t<true/>\n")
+	buf.WriteString("\t<key>StandardOutPath</key>")
+	writeString(stdoutPath)
+	buf.WriteString("\n\t<key>StandardErrorPath</key>")
+	writeString(stderrPath)
+	buf.WriteString("\n</dict>\n</plist>\n")
+	return buf.Bytes()
+}
@@ -0,0 +1,102 @@
+//go:build darwin
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+func launchdPlistPath(opts Options) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("resolve home directory: %w", err)
+	}
+	return filepath.Join(home, "Library", "LaunchAgents", opts.Label+".plist"), nil
+}
+
+func launchdLogDir(opts Options) (string, error) {
+	if opts.LogDir != "" {
+		return opts.LogDir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("resolve home directory: %w", err)
+	}
+	return filepath.Join(home, "Library", "Logs", opts.Name), nil
+}
+
+func launchdDomain() string {
+	return fmt.Sprintf("gui/%d", os.Getuid())
+}
+
+func launchctl(args ...string) error {
+	output, err := exec.Command("launchctl", args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("launchctl %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+func install(opts Options) error {
+	plistPath, err := launchdPlistPath(opts)
+	if err != nil {
+		return err
+	}
+	if _, errStat := os.Stat(plistPath); errStat == nil {
+		return fmt.Errorf("launchd agent already installed at %s", plistPath)
+	}
+	logDir, err := launchdLogDir(opts)
+	if err != nil {
+		return err
+	}
+	if errMkdir := os.MkdirAll(logDir, 0o755); errMkdir != nil {
+		return fmt.Errorf("create log directory: %w", errMkdir)
+	}
+	if errMkdir := os.MkdirAll(filepath.Dir(plistPath), 0o755); errMkdir != nil {
+		return fmt.Errorf("create LaunchAgents directory: %w", errMkdir)
+	}
+	stdoutPath := filepath.Join(logDir, opts.Name+".log")
+	stderrPath := filepath.Join(logDir, opts.Name+".err.log")
+	if errWrite := os.WriteFile(plistPath, launchdPlist(opts, stdoutPath, stderrPath), 0o644); errWrite != nil {
+		return fmt.Errorf("write launchd agent: %w", errWrite)
+	}
+	return nil
+}
+
+func uninstall(opts Options) error {
+	plistPath, err := launchdPlistPath(opts)
+	if err != nil {
+		return err
+	}
+	// The agent may already be stopped; bootout failing here is expected.
+	_ = launchctl("bootout", launchdDomain()+"/"+opts.Label)
+	if errRemove := os.Remove(plistPath); errRemove != nil && !os.IsNotExist(errRemove) {
+		return fmt.Errorf("remove launchd agent: %w", errRemove)
+	}
+	return nil
+}
+
+func start(opts Options) error {
+	plistPath, err := launchdPlistPath(opts)
+	if err != nil {
+		return err
+	}
+	if _, errStat := os.Stat(plistPath); errStat != nil {
+		return fmt.Errorf("launchd agent is not installed; run \"service install\" first")
+	}
+	return launchctl("bootstrap", launchdDomain(), plistPath)
+}
+
+func stop(opts Options) error {
+	return launchctl("bootout", launchdDomain()+"/"+opts.Label)
+}
+
+func stopContext(parent context.Context) (context.Context, func()) {
+	// launchd stops agents with SIGTERM, which the regular signal handling already covers.
+	return parent, func() {}
+}
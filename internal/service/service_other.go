@@ -0,0 +1,14 @@
+//go:build !darwin && !windows
+
+package service
+
+import "context"
+
+func install(Options) error   { return ErrUnsupported }
+func uninstall(Options) error { return ErrUnsupported }
+func start(Options) error     { return ErrUnsupported }
+func stop(Options) error      { return ErrUnsupported }
+
+func stopContext(parent context.Context) (context.Context, func()) {
+	return parent, func() {}
+}
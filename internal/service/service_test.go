@@ -0,0 +1,43 @@
+package service
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLaunchdPlistIncludesArgumentsAndLogs(t *testing.T) {
+	opts := normalizeOptions(Options{
+		Executable: "/usr/local/bin/cli-proxy-api",
+		Args:       []string{"-config", "/Users/me/proxy & co/config.yaml"},
+		WorkingDir: "/Users/me",
+	})
+	plist := string(launchdPlist(opts, "/tmp/out.log", "/tmp/err.log"))
+
+	for _, want := range []string{
+		"<string>" + DefaultLabel + "</string>",
+		"<string>/usr/local/bin/cli-proxy-api</string>",
+		"<string>/Users/me/proxy &amp; co/config.yaml</string>",
+		"<key>KeepAlive</key>\n\t<true/>",
+		"<key>RunAtLoad</key>\n\t<true/>",
+		"<key>StandardOutPath</key><string>/tmp/out.log</string>",
+		"<key>StandardErrorPath</key><string>/tmp/err.log</string>",
+		"<key>WorkingDirectory</key><string>/Users/me</string>",
+	} {
+		if !strings.Contains(plist, want) {
+			t.Fatalf("plist missing %q:\n%s", want, plist)
+		}
+	}
+}
+
+func TestRunRejectsUnknownAction(t *testing.T) {
+	err := Run("restart-everything", Options{})
+	if err == nil || !strings.Contains(err.Error(), "unknown service action") {
+		t.Fatalf("expected unknown action error, got %v", err)
+	}
+}
+
+func TestRunInstallRequiresExecutable(t *testing.T) {
+	if err := Run("install", Options{}); err == nil {
+		t.Fatal("expected error when executable is missing")
+	}
+}
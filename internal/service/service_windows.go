@@ -0,0 +1,169 @@
+//go:build windows
+
+package service
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/windows"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+)
+
+// recoveryResetPeriod is how long (seconds) the service must run cleanly before the
+// failure counter used for restart actions is reset.
+const recoveryResetPeriod = 24 * 60 * 60
+
+func install(opts Options) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("connect to service manager: %w", err)
+	}
+	defer func() {
+		if errDisconnect := m.Disconnect(); errDisconnect != nil {
+			log.Warnf("service: disconnect from service manager: %v", errDisconnect)
+		}
+	}()
+	if existing, errOpen := m.OpenService(opts.Name); errOpen == nil {
+		_ = existing.Close()
+		return fmt.Errorf("service %s is already installed", opts.Name)
+	}
+	s, err := m.CreateService(opts.Name, opts.Executable, mgr.Config{
+		DisplayName: DefaultDisplayName,
+		Description: "OpenAI/Gemini/Claude compatible API proxy",
+		StartType:   mgr.StartAutomatic,
+	}, opts.Args...)
+	if err != nil {
+		return fmt.Errorf("create service: %w", err)
+	}
+	defer func() {
+		if errClose := s.Close(); errClose != nil {
+			log.Warnf("service: close handle: %v", errClose)
+		}
+	}()
+	actions := []mgr.RecoveryAction{
+		{Type: mgr.ServiceRestart, Delay: 5 * time.Second},
+		{Type: mgr.ServiceRestart, Delay: 30 * time.Second},
+		{Type: mgr.ServiceRestart, Delay: time.Minute},
+	}
+	if errRecovery := s.SetRecoveryActions(actions, recoveryResetPeriod); errRecovery != nil {
+		return fmt.Errorf("configure service restart: %w", errRecovery)
+	}
+	return nil
+}
+
+func withService(name string, fn func(*mgr.Service) error) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return fmt.Errorf("connect to service manager: %w", err)
+	}
+	defer func() {
+		if errDisconnect := m.Disconnect(); errDisconnect != nil {
+			log.Warnf("service: disconnect from service manager: %v", errDisconnect)
+		}
+	}()
+	s, err := m.OpenService(name)
+	if err != nil {
+		return fmt.Errorf("open service %s: %w", name, err)
+	}
+	defer func() {
+		if errClose := s.Close(); errClose != nil {
+			log.Warnf("service: close handle: %v", errClose)
+		}
+	}()
+	return fn(s)
+}
+
+func uninstall(opts Options) error {
+	return withService(opts.Name, func(s *mgr.Service) error {
+		// The service may already be stopped; a failed stop request is expected.
+		_, _ = s.Control(svc.Stop)
+		return s.Delete()
+	})
+}
+
+func start(opts Options) error {
+	return withService(opts.Name, func(s *mgr.Service) error {
+		return s.Start()
+	})
+}
+
+func stop(opts Options) error {
+	return withService(opts.Name, func(s *mgr.Service) error {
+		_, errControl := s.Control(svc.Stop)
+		return errControl
+	})
+}
+
+// windowsHandler bridges service control requests to context cancellation and keeps the
+// service in StopPending until the proxy reports that shutdown has finished.
+type windowsHandler struct {
+	cancel  context.CancelFunc
+	stopped <-chan struct{}
+}
+
+func (h *windowsHandler) Execute(_ []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+	for {
+		select {
+		case <-h.stopped:
+			return false, 0
+		case req := <-requests:
+			switch req.Cmd {
+			case svc.Interrogate:
+				status <- req.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending}
+				h.cancel()
+				<-h.stopped
+				return false, 0
+			}
+		}
+	}
+}
+
+func stopContext(parent context.Context) (context.Context, func()) {
+	isService, err := svc.IsWindowsService()
+	if err != nil || !isService {
+		return parent, func() {}
+	}
+	redirectServiceOutput()
+	ctx, cancel := context.WithCancel(parent)
+	stopped := make(chan struct{})
+	go func() {
+		if errRun := svc.Run(DefaultName, &windowsHandler{cancel: cancel, stopped: stopped}); errRun != nil {
+			log.Errorf("service: run failed: %v", errRun)
+		}
+	}()
+	return ctx, func() {
+		cancel()
+		close(stopped)
+	}
+}
+
+// redirectServiceOutput sends stdout/stderr to a log file next to the executable, since
+// Windows services have no console attached.
+func redirectServiceOutput() {
+	exe, err := os.Executable()
+	if err != nil {
+		return
+	}
+	logDir := filepath.Join(filepath.Dir(exe), "logs")
+	if errMkdir := os.MkdirAll(logDir, 0o755); errMkdir != nil {
+		return
+	}
+	file, err := os.OpenFile(filepath.Join(logDir, DefaultName+"-service.log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return
+	}
+	os.Stdout = file
+	os.Stderr = file
+	_ = windows.SetStdHandle(windows.STD_OUTPUT_HANDLE, windows.Handle(file.Fd()))
+	_ = windows.SetStdHandle(windows.STD_ERROR_HANDLE, windows.Handle(file.Fd()))
+	log.SetOutput(file)
+}
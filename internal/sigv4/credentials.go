@@ -0,0 +1,288 @@
+package sigv4
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultIMDSEndpoint = "http://169.254.169.254"
+	// containerCredentialsHost serves the credentials of AWS_CONTAINER_CREDENTIALS_RELATIVE_URI.
+	containerCredentialsHost = "http://169.254.170.2"
+	// refreshWindow renews temporary credentials this long before they expire.
+	refreshWindow = 5 * time.Minute
+)
+
+// Credentials sign AWS requests. Expires is zero for long-term credentials.
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Expires         time.Time
+}
+
+func (c Credentials) valid(now time.Time) bool {
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return false
+	}
+	return c.Expires.IsZero() || now.Add(refreshWindow).Before(c.Expires)
+}
+
+// CredentialSource resolves the credentials to sign with, in the order of the AWS SDKs:
+//
+//  1. the static credentials it was created with
+//  2. AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and AWS_SESSION_TOKEN
+//  3. web identity (EKS IRSA): AWS_WEB_IDENTITY_TOKEN_FILE and AWS_ROLE_ARN
+//  4. the container endpoint (ECS task roles, EKS Pod Identity):
+//     AWS_CONTAINER_CREDENTIALS_RELATIVE_URI or AWS_CONTAINER_CREDENTIALS_FULL_URI
+//  5. the EC2 instance role through IMDSv2, unless AWS_EC2_METADATA_DISABLED is true
+//
+// Temporary credentials are cached until shortly before they expire.
+type CredentialSource struct {
+	static Credentials
+	client *http.Client
+	// stsEndpoint and imdsEndpoint are replaced in tests.
+	stsEndpoint  string
+	imdsEndpoint string
+
+	mu     sync.Mutex
+	cached Credentials
+}
+
+// NewCredentialSource returns a source preferring static, when it holds an access key.
+func NewCredentialSource(static Credentials) *CredentialSource {
+	return &CredentialSource{
+		static: Credentials{
+			AccessKeyID:     strings.TrimSpace(static.AccessKeyID),
+			SecretAccessKey: strings.TrimSpace(static.SecretAccessKey),
+			SessionToken:    strings.TrimSpace(static.SessionToken),
+		},
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Retrieve returns the credentials to sign the next request with.
+func (s *CredentialSource) Retrieve(ctx context.Context) (Credentials, error) {
+	if s.static.AccessKeyID != "" && s.static.SecretAccessKey != "" {
+		return s.static, nil
+	}
+	if env := (Credentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}); env.AccessKeyID != "" && env.SecretAccessKey != "" {
+		return env, nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cached.valid(time.Now()) {
+		return s.cached, nil
+	}
+	creds, errFetch := s.fetch(ctx)
+	if errFetch != nil {
+		return Credentials{}, errFetch
+	}
+	s.cached = creds
+	return creds, nil
+}
+
+func (s *CredentialSource) fetch(ctx context.Context) (Credentials, error) {
+	if tokenFile, roleARN := os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE"), os.Getenv("AWS_ROLE_ARN"); tokenFile != "" && roleARN != "" {
+		return s.webIdentity(ctx, tokenFile, roleARN)
+	}
+	if relative := os.Getenv("AWS_CONTAINER_CREDENTIALS_RELATIVE_URI"); relative != "" {
+		return s.container(ctx, containerCredentialsHost+relative)
+	}
+	if full := os.Getenv("AWS_CONTAINER_CREDENTIALS_FULL_URI"); full != "" {
+		return s.container(ctx, full)
+	}
+	if !strings.EqualFold(strings.TrimSpace(os.Getenv("AWS_EC2_METADATA_DISABLED")), "true") {
+		return s.instanceRole(ctx)
+	}
+	return Credentials{}, errors.New("aws credentials are not configured")
+}
+
+// webIdentity exchanges the projected service account token for role credentials.
+func (s *CredentialSource) webIdentity(ctx context.Context, tokenFile, roleARN string) (Credentials, error) {
+	token, errRead := os.ReadFile(tokenFile)
+	if errRead != nil {
+		return Credentials{}, fmt.Errorf("aws web identity: read token: %w", errRead)
+	}
+	sessionName := os.Getenv("AWS_ROLE_SESSION_NAME")
+	if sessionName == "" {
+		sessionName = "cliproxy-" + strconv.FormatInt(time.Now().Unix(), 10)
+	}
+	endpoint := s.stsEndpoint
+	if endpoint == "" {
+		endpoint = "https://sts.amazonaws.com"
+		if region := firstNonEmpty(os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION")); region != "" {
+			endpoint = "https://sts." + region + ".amazonaws.com"
+		}
+	}
+	form := url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"Version":          {"2011-06-15"},
+		"RoleArn":          {roleARN},
+		"RoleSessionName":  {sessionName},
+		"WebIdentityToken": {strings.TrimSpace(string(token))},
+	}
+	req, errReq := http.NewRequestWithContext(ctx, http.MethodPost, endpoint+"/", strings.NewReader(form.Encode()))
+	if errReq != nil {
+		return Credentials{}, errReq
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	body, errDo := s.do(req)
+	if errDo != nil {
+		return Credentials{}, fmt.Errorf("aws web identity: %w", errDo)
+	}
+	var parsed struct {
+		Result struct {
+			Credentials struct {
+				AccessKeyID     string    `xml:"AccessKeyId"`
+				SecretAccessKey string    `xml:"SecretAccessKey"`
+				SessionToken    string    `xml:"SessionToken"`
+				Expiration      time.Time `xml:"Expiration"`
+			} `xml:"Credentials"`
+		} `xml:"AssumeRoleWithWebIdentityResult"`
+	}
+	if errDecode := xml.Unmarshal(body, &parsed); errDecode != nil {
+		return Credentials{}, fmt.Errorf("aws web identity: decode response: %w", errDecode)
+	}
+	creds := parsed.Result.Credentials
+	return checked("aws web identity", Credentials{
+		AccessKeyID:     creds.AccessKeyID,
+		SecretAccessKey: creds.SecretAccessKey,
+		SessionToken:    creds.SessionToken,
+		Expires:         creds.Expiration,
+	})
+}
+
+// container reads the credentials of an ECS task role or EKS Pod Identity association.
+func (s *CredentialSource) container(ctx context.Context, endpoint string) (Credentials, error) {
+	req, errReq := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if errReq != nil {
+		return Credentials{}, errReq
+	}
+	authorization := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN")
+	if tokenFile := os.Getenv("AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE"); tokenFile != "" {
+		token, errRead := os.ReadFile(tokenFile)
+		if errRead != nil {
+			return Credentials{}, fmt.Errorf("aws container credentials: read token: %w", errRead)
+		}
+		authorization = strings.TrimSpace(string(token))
+	}
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	body, errDo := s.do(req)
+	if errDo != nil {
+		return Credentials{}, fmt.Errorf("aws container credentials: %w", errDo)
+	}
+	return decodeJSONCredentials("aws container credentials", body)
+}
+
+// instanceRole reads the credentials of the EC2 instance profile through IMDSv2.
+func (s *CredentialSource) instanceRole(ctx context.Context) (Credentials, error) {
+	endpoint := s.imdsEndpoint
+	if endpoint == "" {
+		endpoint = firstNonEmpty(os.Getenv("AWS_EC2_METADATA_SERVICE_ENDPOINT"), defaultIMDSEndpoint)
+	}
+	endpoint = strings.TrimRight(endpoint, "/")
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	tokenReq, errReq := http.NewRequestWithContext(ctx, http.MethodPut, endpoint+"/latest/api/token", nil)
+	if errReq != nil {
+		return Credentials{}, errReq
+	}
+	tokenReq.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "21600")
+	token, errToken := s.do(tokenReq)
+	if errToken != nil {
+		return Credentials{}, fmt.Errorf("aws instance role: metadata token: %w", errToken)
+	}
+	get := func(path string) ([]byte, error) {
+		req, errGet := http.NewRequestWithContext(ctx, http.MethodGet, endpoint+path, nil)
+		if errGet != nil {
+			return nil, errGet
+		}
+		req.Header.Set("X-aws-ec2-metadata-token", string(token))
+		return s.do(req)
+	}
+	roles, errRoles := get("/latest/meta-data/iam/security-credentials/")
+	if errRoles != nil {
+		return Credentials{}, fmt.Errorf("aws instance role: list roles: %w", errRoles)
+	}
+	role, _, _ := strings.Cut(strings.TrimSpace(string(roles)), "\n")
+	if role == "" {
+		return Credentials{}, errors.New("aws instance role: no role attached to the instance")
+	}
+	body, errCreds := get("/latest/meta-data/iam/security-credentials/" + url.PathEscape(role))
+	if errCreds != nil {
+		return Credentials{}, fmt.Errorf("aws instance role: %w", errCreds)
+	}
+	return decodeJSONCredentials("aws instance role", body)
+}
+
+func (s *CredentialSource) do(req *http.Request) ([]byte, error) {
+	resp, errDo := s.client.Do(req)
+	if errDo != nil {
+		return nil, errDo
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, errRead := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if errRead != nil {
+		return nil, errRead
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("%s %s: status %d", req.Method, req.URL.Path, resp.StatusCode)
+	}
+	return body, nil
+}
+
+// decodeJSONCredentials decodes the credential document served by the container endpoint and
+// the instance metadata service.
+func decodeJSONCredentials(source string, body []byte) (Credentials, error) {
+	var parsed struct {
+		AccessKeyID     string    `json:"AccessKeyId"`
+		SecretAccessKey string    `json:"SecretAccessKey"`
+		Token           string    `json:"Token"`
+		Expiration      time.Time `json:"Expiration"`
+	}
+	if errDecode := json.Unmarshal(body, &parsed); errDecode != nil {
+		return Credentials{}, fmt.Errorf("%s: decode response: %w", source, errDecode)
+	}
+	return checked(source, Credentials{
+		AccessKeyID:     parsed.AccessKeyID,
+		SecretAccessKey: parsed.SecretAccessKey,
+		SessionToken:    parsed.Token,
+		Expires:         parsed.Expiration,
+	})
+}
+
+func checked(source string, creds Credentials) (Credentials, error) {
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return Credentials{}, fmt.Errorf("%s: response holds no credentials", source)
+	}
+	return creds, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,132 @@
+package sigv4
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var exampleCredentials = Credentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+
+func TestSignMatchesReferenceSignatures(t *testing.T) {
+	at := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+	// Example request from the AWS Signature Version 4 documentation.
+	req, _ := http.NewRequest(http.MethodGet, "https://iam.amazonaws.com/?Action=ListUsers&Version=2010-05-08", nil)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	Sign(req, nil, exampleCredentials, "us-east-1", "iam", at)
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/iam/aws4_request, " +
+		"SignedHeaders=content-type;host;x-amz-date, " +
+		"Signature=5d672d79c15b13162d9279b0855cfba6789a8edb4c82c400e06b5924a6f2b5d7"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Fatalf("iam Authorization = %q\nwant %q", got, want)
+	}
+
+	// get-vanilla from the AWS Signature Version 4 test suite.
+	req, _ = http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	req.Header.Set("Authorization", "Bearer drop-me")
+	Sign(req, nil, exampleCredentials, "us-east-1", "service", at)
+	want = "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Fatalf("get-vanilla Authorization = %q\nwant %q", got, want)
+	}
+}
+
+func clearAWSEnv(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{
+		"AWS_ACCESS_KEY_ID", "AWS_SECRET_ACCESS_KEY", "AWS_SESSION_TOKEN",
+		"AWS_WEB_IDENTITY_TOKEN_FILE", "AWS_ROLE_ARN", "AWS_ROLE_SESSION_NAME",
+		"AWS_CONTAINER_CREDENTIALS_RELATIVE_URI", "AWS_CONTAINER_CREDENTIALS_FULL_URI",
+		"AWS_CONTAINER_AUTHORIZATION_TOKEN", "AWS_CONTAINER_AUTHORIZATION_TOKEN_FILE",
+		"AWS_EC2_METADATA_DISABLED",
+	} {
+		t.Setenv(name, "")
+	}
+}
+
+func TestCredentialSourceWebIdentity(t *testing.T) {
+	clearAWSEnv(t)
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	if errWrite := os.WriteFile(tokenFile, []byte("jwt-token\n"), 0o600); errWrite != nil {
+		t.Fatal(errWrite)
+	}
+	t.Setenv("AWS_WEB_IDENTITY_TOKEN_FILE", tokenFile)
+	t.Setenv("AWS_ROLE_ARN", "arn:aws:iam::123456789012:role/cliproxy")
+	calls := 0
+	sts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if errParse := r.ParseForm(); errParse != nil || r.Form.Get("WebIdentityToken") != "jwt-token" || r.Form.Get("RoleArn") != "arn:aws:iam::123456789012:role/cliproxy" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`<AssumeRoleWithWebIdentityResponse><AssumeRoleWithWebIdentityResult><Credentials>
+<AccessKeyId>ASIAIRSA</AccessKeyId><SecretAccessKey>secret</SecretAccessKey><SessionToken>session</SessionToken>
+<Expiration>` + time.Now().Add(time.Hour).UTC().Format(time.RFC3339) + `</Expiration>
+</Credentials></AssumeRoleWithWebIdentityResult></AssumeRoleWithWebIdentityResponse>`))
+	}))
+	defer sts.Close()
+
+	source := NewCredentialSource(Credentials{})
+	source.stsEndpoint = sts.URL
+	for range 2 {
+		creds, errRetrieve := source.Retrieve(context.Background())
+		if errRetrieve != nil {
+			t.Fatalf("Retrieve() error = %v", errRetrieve)
+		}
+		if creds.AccessKeyID != "ASIAIRSA" || creds.SessionToken != "session" {
+			t.Fatalf("Retrieve() = %+v", creds)
+		}
+	}
+	if calls != 1 {
+		t.Fatalf("STS calls = %d, want the credentials cached", calls)
+	}
+}
+
+func TestCredentialSourceInstanceRole(t *testing.T) {
+	clearAWSEnv(t)
+	imds := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/latest/api/token" {
+			if r.Method != http.MethodPut {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			_, _ = w.Write([]byte("imds-token"))
+			return
+		}
+		if r.Header.Get("X-aws-ec2-metadata-token") != "imds-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/latest/meta-data/iam/security-credentials/":
+			_, _ = w.Write([]byte("cliproxy-role"))
+		case "/latest/meta-data/iam/security-credentials/cliproxy-role":
+			_, _ = w.Write([]byte(`{"AccessKeyId":"ASIAEC2","SecretAccessKey":"secret","Token":"session","Expiration":"` +
+				time.Now().Add(time.Hour).UTC().Format(time.RFC3339) + `"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer imds.Close()
+
+	source := NewCredentialSource(Credentials{})
+	source.imdsEndpoint = imds.URL
+	creds, errRetrieve := source.Retrieve(context.Background())
+	if errRetrieve != nil || creds.AccessKeyID != "ASIAEC2" || creds.SessionToken != "session" {
+		t.Fatalf("Retrieve() = %+v, %v", creds, errRetrieve)
+	}
+
+	t.Setenv("AWS_EC2_METADATA_DISABLED", "true")
+	if _, errRetrieve = NewCredentialSource(Credentials{}).Retrieve(context.Background()); errRetrieve == nil {
+		t.Fatal("Retrieve() without any credentials succeeded")
+	}
+	static := Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}
+	if creds, _ = NewCredentialSource(static).Retrieve(context.Background()); creds != static {
+		t.Fatalf("Retrieve() = %+v, want the static credentials", creds)
+	}
+}
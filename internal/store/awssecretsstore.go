@@ -0,0 +1,222 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/sigv4"
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+const (
+	awsSecretsDefaultPrefix = "cliproxy/auths"
+	awsSecretsService       = "secretsmanager"
+)
+
+// AWSSecretsStoreConfig captures configuration for the AWS Secrets Manager backed token store.
+// Without static credentials the standard AWS chain is used: environment variables, web
+// identity (EKS IRSA), the container endpoint (ECS, EKS Pod Identity) and the EC2 instance role.
+// The region falls back to AWS_REGION / AWS_DEFAULT_REGION.
+type AWSSecretsStoreConfig struct {
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Endpoint        string
+	Prefix          string
+	KMSKeyID        string
+	LocalRoot       string
+	// HTTPClient overrides the client used to reach Secrets Manager.
+	HTTPClient *http.Client
+}
+
+// NewAWSSecretsTokenStore initializes a token store persisting each auth as a Secrets Manager secret.
+func NewAWSSecretsTokenStore(cfg AWSSecretsStoreConfig) (*SecretTokenStore, error) {
+	backend := &awsSecretsBackend{
+		region: firstNonEmpty(cfg.Region, os.Getenv("AWS_REGION"), os.Getenv("AWS_DEFAULT_REGION")),
+		credentials: sigv4.NewCredentialSource(sigv4.Credentials{
+			AccessKeyID:     cfg.AccessKeyID,
+			SecretAccessKey: cfg.SecretAccessKey,
+			SessionToken:    cfg.SessionToken,
+		}),
+		prefix:   strings.Trim(firstNonEmpty(cfg.Prefix, awsSecretsDefaultPrefix), "/"),
+		kmsKeyID: strings.TrimSpace(cfg.KMSKeyID),
+		client:   cfg.HTTPClient,
+		now:      time.Now,
+	}
+	if backend.region == "" {
+		return nil, fmt.Errorf("aws secrets store: region is required")
+	}
+	backend.endpoint = strings.TrimRight(firstNonEmpty(cfg.Endpoint, "https://secretsmanager."+backend.region+".amazonaws.com"), "/")
+	if backend.client == nil {
+		backend.client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return newSecretTokenStore("aws secrets store", cliproxyauth.AuthSourceAWSSecrets, backend, cfg.LocalRoot)
+}
+
+// awsSecretsBackend talks to the Secrets Manager JSON API, signing requests with SigV4.
+type awsSecretsBackend struct {
+	client      *http.Client
+	endpoint    string
+	region      string
+	credentials *sigv4.CredentialSource
+	prefix      string
+	kmsKeyID    string
+	now         func() time.Time
+}
+
+// awsAPIError is an error response of the Secrets Manager API.
+type awsAPIError struct {
+	Status  int
+	Type    string
+	Message string
+}
+
+func (e *awsAPIError) Error() string {
+	return fmt.Sprintf("secrets manager: status %d: %s: %s", e.Status, e.Type, e.Message)
+}
+
+func isAWSResourceNotFound(err error) bool {
+	apiErr, ok := err.(*awsAPIError)
+	return ok && apiErr.Type == "ResourceNotFoundException"
+}
+
+func (b *awsSecretsBackend) List(ctx context.Context) ([]string, error) {
+	namePrefix := b.prefix + "/"
+	var names []string
+	nextToken := ""
+	for {
+		in := map[string]any{
+			"MaxResults": 100,
+			"Filters":    []map[string]any{{"Key": "name", "Values": []string{namePrefix}}},
+		}
+		if nextToken != "" {
+			in["NextToken"] = nextToken
+		}
+		var out struct {
+			SecretList []struct {
+				Name string `json:"Name"`
+			} `json:"SecretList"`
+			NextToken string `json:"NextToken"`
+		}
+		if err := b.call(ctx, "ListSecrets", in, &out); err != nil {
+			return nil, err
+		}
+		for _, secret := range out.SecretList {
+			// The name filter matches prefixes loosely, so re-check the exact prefix.
+			if name, ok := strings.CutPrefix(secret.Name, namePrefix); ok && name != "" {
+				names = append(names, name)
+			}
+		}
+		if out.NextToken == "" {
+			return names, nil
+		}
+		nextToken = out.NextToken
+	}
+}
+
+func (b *awsSecretsBackend) Get(ctx context.Context, name string) ([]byte, error) {
+	var out struct {
+		SecretString string `json:"SecretString"`
+	}
+	err := b.call(ctx, "GetSecretValue", map[string]any{"SecretId": b.secretID(name)}, &out)
+	if isAWSResourceNotFound(err) {
+		return nil, errSecretNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if out.SecretString == "" {
+		return nil, errSecretNotFound
+	}
+	return []byte(out.SecretString), nil
+}
+
+func (b *awsSecretsBackend) Put(ctx context.Context, name string, data []byte) error {
+	err := b.call(ctx, "PutSecretValue", map[string]any{
+		"SecretId":     b.secretID(name),
+		"SecretString": string(data),
+	}, nil)
+	if !isAWSResourceNotFound(err) {
+		return err
+	}
+	in := map[string]any{
+		"Name":         b.secretID(name),
+		"SecretString": string(data),
+		"Description":  "CLIProxyAPI credential",
+	}
+	if b.kmsKeyID != "" {
+		in["KmsKeyId"] = b.kmsKeyID
+	}
+	return b.call(ctx, "CreateSecret", in, nil)
+}
+
+func (b *awsSecretsBackend) Delete(ctx context.Context, name string) error {
+	// Skip the recovery window so a re-login can recreate the secret under the same name.
+	err := b.call(ctx, "DeleteSecret", map[string]any{
+		"SecretId":                   b.secretID(name),
+		"ForceDeleteWithoutRecovery": true,
+	}, nil)
+	if isAWSResourceNotFound(err) {
+		return errSecretNotFound
+	}
+	return err
+}
+
+func (b *awsSecretsBackend) secretID(name string) string {
+	return b.prefix + "/" + strings.TrimLeft(name, "/")
+}
+
+func (b *awsSecretsBackend) call(ctx context.Context, action string, in any, out any) error {
+	body, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("encode %s request: %w", action, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.endpoint+"/", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "secretsmanager."+action)
+	creds, err := b.credentials.Retrieve(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: %w", action, err)
+	}
+	sigv4.Sign(req, body, creds, b.region, awsSecretsService, b.now())
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	payload, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("read %s response: %w", action, err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := &awsAPIError{Status: resp.StatusCode}
+		var parsed struct {
+			Type    string `json:"__type"`
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(payload, &parsed) == nil {
+			// __type may be namespaced, e.g. "com.amazonaws...#ResourceNotFoundException".
+			apiErr.Type = parsed.Type[strings.LastIndex(parsed.Type, "#")+1:]
+			apiErr.Message = parsed.Message
+		}
+		return apiErr
+	}
+	if out != nil && len(payload) > 0 {
+		if err = json.Unmarshal(payload, out); err != nil {
+			return fmt.Errorf("decode %s response: %w", action, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/sigv4"
+)
+
+func TestAWSSecretsBackendCreatesMissingSecret(t *testing.T) {
+	var mu sync.Mutex
+	secrets := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		var in map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&in)
+		mu.Lock()
+		defer mu.Unlock()
+		notFound := func() {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"__type":"ResourceNotFoundException","message":"not found"}`))
+		}
+		switch r.Header.Get("X-Amz-Target") {
+		case "secretsmanager.PutSecretValue":
+			id, _ := in["SecretId"].(string)
+			if _, ok := secrets[id]; !ok {
+				notFound()
+				return
+			}
+			secrets[id], _ = in["SecretString"].(string)
+		case "secretsmanager.CreateSecret":
+			id, _ := in["Name"].(string)
+			secrets[id], _ = in["SecretString"].(string)
+		case "secretsmanager.GetSecretValue":
+			id, _ := in["SecretId"].(string)
+			value, ok := secrets[id]
+			if !ok {
+				notFound()
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"SecretString": value})
+			return
+		case "secretsmanager.ListSecrets":
+			list := make([]map[string]string, 0, len(secrets))
+			for id := range secrets {
+				list = append(list, map[string]string{"Name": id})
+			}
+			list = append(list, map[string]string{"Name": "cliproxy/authsx/other"})
+			_ = json.NewEncoder(w).Encode(map[string]any{"SecretList": list})
+			return
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	backend := &awsSecretsBackend{
+		client:      server.Client(),
+		endpoint:    server.URL,
+		region:      "us-east-1",
+		credentials: sigv4.NewCredentialSource(sigv4.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"}),
+		prefix:      awsSecretsDefaultPrefix,
+		now:         time.Now,
+	}
+	ctx := context.Background()
+	if _, err := backend.Get(ctx, "codex-a.json"); err != errSecretNotFound {
+		t.Fatalf("Get() error = %v, want errSecretNotFound", err)
+	}
+	if err := backend.Put(ctx, "codex-a.json", []byte(`{"type":"codex"}`)); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if err := backend.Put(ctx, "codex-a.json", []byte(`{"type":"codex","v":2}`)); err != nil {
+		t.Fatalf("second Put() error = %v", err)
+	}
+	got, err := backend.Get(ctx, "codex-a.json")
+	if err != nil || string(got) != `{"type":"codex","v":2}` {
+		t.Fatalf("Get() = %q, %v", got, err)
+	}
+	names, err := backend.List(ctx)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(names) != 1 || names[0] != "codex-a.json" {
+		t.Fatalf("List() = %v, want [codex-a.json]", names)
+	}
+}
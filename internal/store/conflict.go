@@ -0,0 +1,26 @@
+package store
+
+import (
+	"encoding/json"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+// ErrAuthConflict reports that a shared backend already holds a fresher copy of an auth record
+// than the one being written. The stored copy is mirrored locally instead, so the file watcher
+// reloads it and the caller keeps using the newer token.
+var ErrAuthConflict = cliproxyauth.ErrStoreConflict
+
+// preferStoredAuth reports whether the stored payload must win over the incoming one after a
+// concurrent modification was detected.
+func preferStoredAuth(stored, incoming []byte) bool {
+	storedMeta := make(map[string]any)
+	if errUnmarshal := json.Unmarshal(stored, &storedMeta); errUnmarshal != nil {
+		return false
+	}
+	incomingMeta := make(map[string]any)
+	if errUnmarshal := json.Unmarshal(incoming, &incomingMeta); errUnmarshal != nil {
+		return true
+	}
+	return cliproxyauth.PreferStoredMetadata(storedMeta, incomingMeta)
+}
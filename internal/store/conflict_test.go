@@ -0,0 +1,28 @@
+package store
+
+import "testing"
+
+func TestPreferStoredAuth(t *testing.T) {
+	older := []byte(`{"type":"claude","expired":"2026-01-01T00:00:00Z"}`)
+	newer := []byte(`{"type":"claude","expired":"2026-01-01T08:00:00Z"}`)
+	noExpiry := []byte(`{"type":"claude","api_key":"x"}`)
+
+	cases := []struct {
+		name     string
+		stored   []byte
+		incoming []byte
+		want     bool
+	}{
+		{name: "stored fresher", stored: newer, incoming: older, want: true},
+		{name: "incoming fresher", stored: older, incoming: newer, want: false},
+		{name: "same expiry", stored: older, incoming: older, want: false},
+		{name: "stored without expiry", stored: noExpiry, incoming: older, want: false},
+		{name: "incoming without expiry", stored: newer, incoming: noExpiry, want: true},
+		{name: "invalid stored json", stored: []byte(`{`), incoming: older, want: false},
+	}
+	for _, tc := range cases {
+		if got := preferStoredAuth(tc.stored, tc.incoming); got != tc.want {
+			t.Fatalf("%s: preferStoredAuth() = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
@@ -42,6 +42,7 @@ type ObjectStoreConfig struct {
 
 // ObjectTokenStore persists configuration and authentication metadata using an S3-compatible object storage backend.
 // Files are mirrored to a local workspace so existing file-based flows continue to operate.
+// Google Cloud Storage is supported through its S3 interoperability endpoint with HMAC keys.
 type ObjectTokenStore struct {
 	client     *minio.Client
 	cfg        ObjectStoreConfig
@@ -49,6 +50,11 @@ type ObjectTokenStore struct {
 	configPath string
 	authDir    string
 	mu         sync.Mutex
+
+	// etags remembers the ETag of each auth object as last downloaded or uploaded so writes can
+	// detect that another replica replaced the object in the meantime.
+	etagMu sync.Mutex
+	etags  map[string]string
 }
 
 // NewObjectTokenStore initializes an object storage backed token store.
@@ -115,6 +121,7 @@ func NewObjectTokenStore(cfg ObjectStoreConfig) (*ObjectTokenStore, error) {
 		spoolRoot:  absRoot,
 		configPath: filepath.Join(configDir, "config.yaml"),
 		authDir:    authDir,
+		etags:      make(map[string]string),
 	}, nil
 }
 
@@ -444,6 +451,7 @@ func (s *ObjectTokenStore) syncAuthFromBucket(ctx context.Context) error {
 		if errWrite := os.WriteFile(local, data, 0o600); errWrite != nil {
 			return fmt.Errorf("object store: write auth %s: %w", local, errWrite)
 		}
+		s.setETag(object.Key, object.ETag)
 	}
 	if err := removeAuthFilesAbsentFromBucket(s.authDir, seen); err != nil {
 		return err
@@ -493,9 +501,68 @@ func (s *ObjectTokenStore) uploadAuth(ctx context.Context, path string) error {
 		return s.deleteAuthObject(ctx, path)
 	}
 	key := objectStoreAuthPrefix + "/" + filepath.ToSlash(rel)
+	if errCheck := s.checkAuthConflict(ctx, key, path, data); errCheck != nil {
+		return errCheck
+	}
 	return s.putObject(ctx, key, data, "application/json")
 }
 
+// checkAuthConflict compares the remote ETag with the one last seen before an auth upload.
+// S3-compatible backends offer no portable conditional PUT, so this narrows rather than
+// eliminates the race window. When the remote object changed and holds a fresher token, it is
+// mirrored locally and ErrAuthConflict is returned instead of overwriting it.
+func (s *ObjectTokenStore) checkAuthConflict(ctx context.Context, key, path string, data []byte) error {
+	fullKey := s.prefixedKey(key)
+	info, err := s.client.StatObject(ctx, s.cfg.Bucket, fullKey, minio.StatObjectOptions{})
+	if err != nil {
+		if isObjectNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("object store: stat auth %s: %w", fullKey, err)
+	}
+	if known, ok := s.knownETag(fullKey); ok && known == info.ETag {
+		return nil
+	}
+	object, err := s.client.GetObject(ctx, s.cfg.Bucket, fullKey, minio.GetObjectOptions{})
+	if err != nil {
+		return fmt.Errorf("object store: fetch auth %s: %w", fullKey, err)
+	}
+	stored, errRead := io.ReadAll(object)
+	_ = object.Close()
+	if errRead != nil {
+		return fmt.Errorf("object store: read auth %s: %w", fullKey, errRead)
+	}
+	s.setETag(fullKey, info.ETag)
+	if !preferStoredAuth(stored, data) {
+		return nil
+	}
+	if errWrite := os.WriteFile(path, stored, 0o600); errWrite != nil {
+		return fmt.Errorf("object store: mirror newer auth %s: %w", fullKey, errWrite)
+	}
+	log.Warnf("object store: auth %s was refreshed by another writer; keeping the newer stored token", key)
+	return fmt.Errorf("object store: %s: %w", key, ErrAuthConflict)
+}
+
+func (s *ObjectTokenStore) knownETag(fullKey string) (string, bool) {
+	s.etagMu.Lock()
+	defer s.etagMu.Unlock()
+	etag, ok := s.etags[fullKey]
+	return etag, ok
+}
+
+func (s *ObjectTokenStore) setETag(fullKey, etag string) {
+	s.etagMu.Lock()
+	defer s.etagMu.Unlock()
+	if s.etags == nil {
+		s.etags = make(map[string]string)
+	}
+	if etag == "" {
+		delete(s.etags, fullKey)
+		return
+	}
+	s.etags[fullKey] = etag
+}
+
 func (s *ObjectTokenStore) deleteAuthObject(ctx context.Context, path string) error {
 	if path == "" {
 		return nil
@@ -514,12 +581,13 @@ func (s *ObjectTokenStore) putObject(ctx context.Context, key string, data []byt
 	}
 	fullKey := s.prefixedKey(key)
 	reader := bytes.NewReader(data)
-	_, err := s.client.PutObject(ctx, s.cfg.Bucket, fullKey, reader, int64(len(data)), minio.PutObjectOptions{
+	info, err := s.client.PutObject(ctx, s.cfg.Bucket, fullKey, reader, int64(len(data)), minio.PutObjectOptions{
 		ContentType: contentType,
 	})
 	if err != nil {
 		return fmt.Errorf("object store: put object %s: %w", fullKey, err)
 	}
+	s.setETag(fullKey, info.ETag)
 	return nil
 }
 
@@ -532,6 +600,7 @@ func (s *ObjectTokenStore) deleteObject(ctx context.Context, key string) error {
 		}
 		return fmt.Errorf("object store: delete object %s: %w", fullKey, err)
 	}
+	s.setETag(fullKey, "")
 	return nil
 }
 
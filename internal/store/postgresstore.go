@@ -43,6 +43,11 @@ type PostgresStore struct {
 	configPath string
 	authDir    string
 	mu         sync.Mutex
+
+	// versions tracks the row version last read or written per auth id so concurrent
+	// writers (other replicas refreshing the same credential) are detected on save.
+	versionMu sync.Mutex
+	versions  map[string]int64
 }
 
 // NewPostgresStore establishes a connection to PostgreSQL and prepares the local workspace.
@@ -95,6 +100,7 @@ func NewPostgresStore(ctx context.Context, cfg PostgresStoreConfig) (*PostgresSt
 		spoolRoot:  absSpool,
 		configPath: filepath.Join(configDir, "config.yaml"),
 		authDir:    authDir,
+		versions:   make(map[string]int64),
 	}
 	return store, nil
 }
@@ -140,6 +146,11 @@ func (s *PostgresStore) EnsureSchema(ctx context.Context) error {
 	`, authTable)); err != nil {
 		return fmt.Errorf("postgres store: create auth table: %w", err)
 	}
+	if _, err := s.db.ExecContext(ctx, fmt.Sprintf(
+		"ALTER TABLE %s ADD COLUMN IF NOT EXISTS version BIGINT NOT NULL DEFAULT 0", authTable,
+	)); err != nil {
+		return fmt.Errorf("postgres store: add auth version column: %w", err)
+	}
 	return nil
 }
 
@@ -270,7 +281,7 @@ func (s *PostgresStore) Save(ctx context.Context, auth *cliproxyauth.Auth) (stri
 
 // List enumerates all auth records stored in PostgreSQL.
 func (s *PostgresStore) List(ctx context.Context) ([]*cliproxyauth.Auth, error) {
-	query := fmt.Sprintf("SELECT id, content, created_at, updated_at FROM %s ORDER BY id", s.fullTableName(s.cfg.AuthTable))
+	query := fmt.Sprintf("SELECT id, content, version, created_at, updated_at FROM %s ORDER BY id", s.fullTableName(s.cfg.AuthTable))
 	rows, err := s.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, fmt.Errorf("postgres store: list auth: %w", err)
@@ -282,12 +293,14 @@ func (s *PostgresStore) List(ctx context.Context) ([]*cliproxyauth.Auth, error)
 		var (
 			id        string
 			payload   string
+			version   int64
 			createdAt time.Time
 			updatedAt time.Time
 		)
-		if err = rows.Scan(&id, &payload, &createdAt, &updatedAt); err != nil {
+		if err = rows.Scan(&id, &payload, &version, &createdAt, &updatedAt); err != nil {
 			return nil, fmt.Errorf("postgres store: scan auth row: %w", err)
 		}
+		s.setVersion(id, version)
 		path, errPath := s.absoluteAuthPath(id)
 		if errPath != nil {
 			log.WithError(errPath).Warnf("postgres store: skipping auth %s outside spool", id)
@@ -452,7 +465,7 @@ func (s *PostgresStore) syncConfigFromDatabase(ctx context.Context, exampleConfi
 
 // syncAuthFromDatabase populates the local auth directory from PostgreSQL data.
 func (s *PostgresStore) syncAuthFromDatabase(ctx context.Context) error {
-	query := fmt.Sprintf("SELECT id, content FROM %s", s.fullTableName(s.cfg.AuthTable))
+	query := fmt.Sprintf("SELECT id, content, version FROM %s", s.fullTableName(s.cfg.AuthTable))
 	rows, err := s.db.QueryContext(ctx, query)
 	if err != nil {
 		return fmt.Errorf("postgres store: load auth from database: %w", err)
@@ -470,10 +483,12 @@ func (s *PostgresStore) syncAuthFromDatabase(ctx context.Context) error {
 		var (
 			id      string
 			payload string
+			version int64
 		)
-		if err = rows.Scan(&id, &payload); err != nil {
+		if err = rows.Scan(&id, &payload, &version); err != nil {
 			return fmt.Errorf("postgres store: scan auth row: %w", err)
 		}
+		s.setVersion(id, version)
 		path, errPath := s.absoluteAuthPath(id)
 		if errPath != nil {
 			log.WithError(errPath).Warnf("postgres store: skipping auth %s outside spool", id)
@@ -517,16 +532,97 @@ func (s *PostgresStore) upsertAuthRecord(ctx context.Context, relID, path string
 	return s.persistAuth(ctx, relID, data)
 }
 
+// persistAuth writes an auth record with optimistic locking: the update only applies when the
+// row still has the version this process last saw. On a mismatch the stored and incoming
+// payloads are compared and the one with the later token expiry wins.
 func (s *PostgresStore) persistAuth(ctx context.Context, relID string, data []byte) error {
 	jsonPayload := json.RawMessage(data)
+	table := s.fullTableName(s.cfg.AuthTable)
+	expected, known := s.knownVersion(relID)
+	if !known {
+		query := fmt.Sprintf(`
+			INSERT INTO %s (id, content, version, created_at, updated_at)
+			VALUES ($1, $2, 1, NOW(), NOW())
+			ON CONFLICT (id) DO NOTHING
+		`, table)
+		result, err := s.db.ExecContext(ctx, query, relID, jsonPayload)
+		if err != nil {
+			return fmt.Errorf("postgres store: insert auth record: %w", err)
+		}
+		if affected, errAffected := result.RowsAffected(); errAffected == nil && affected == 1 {
+			s.setVersion(relID, 1)
+			return nil
+		}
+		return s.resolveAuthConflict(ctx, relID, data)
+	}
+	updated, err := s.updateAuthVersioned(ctx, relID, jsonPayload, expected)
+	if err != nil {
+		return err
+	}
+	if updated {
+		return nil
+	}
+	return s.resolveAuthConflict(ctx, relID, data)
+}
+
+// updateAuthVersioned updates the row when its version still equals expected.
+func (s *PostgresStore) updateAuthVersioned(ctx context.Context, relID string, payload json.RawMessage, expected int64) (bool, error) {
 	query := fmt.Sprintf(`
-		INSERT INTO %s (id, content, created_at, updated_at)
-		VALUES ($1, $2, NOW(), NOW())
-		ON CONFLICT (id)
-		DO UPDATE SET content = EXCLUDED.content, updated_at = NOW()
+		UPDATE %s SET content = $2, version = version + 1, updated_at = NOW()
+		WHERE id = $1 AND version = $3
+		RETURNING version
 	`, s.fullTableName(s.cfg.AuthTable))
-	if _, err := s.db.ExecContext(ctx, query, relID, jsonPayload); err != nil {
-		return fmt.Errorf("postgres store: upsert auth record: %w", err)
+	var version int64
+	err := s.db.QueryRowContext(ctx, query, relID, payload, expected).Scan(&version)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return false, nil
+	case err != nil:
+		return false, fmt.Errorf("postgres store: update auth record: %w", err)
+	}
+	s.setVersion(relID, version)
+	return true, nil
+}
+
+// resolveAuthConflict handles a version mismatch. When the stored row holds a fresher token it is
+// mirrored to the spool directory and ErrAuthConflict is returned; otherwise the incoming payload
+// overwrites the row guarded by the version just read.
+func (s *PostgresStore) resolveAuthConflict(ctx context.Context, relID string, data []byte) error {
+	query := fmt.Sprintf("SELECT content, version FROM %s WHERE id = $1", s.fullTableName(s.cfg.AuthTable))
+	var (
+		stored  string
+		version int64
+	)
+	err := s.db.QueryRowContext(ctx, query, relID).Scan(&stored, &version)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		s.forgetVersion(relID)
+		return s.persistAuth(ctx, relID, data)
+	case err != nil:
+		return fmt.Errorf("postgres store: load conflicting auth record: %w", err)
+	}
+	if jsonEqual([]byte(stored), data) {
+		s.setVersion(relID, version)
+		return nil
+	}
+	if preferStoredAuth([]byte(stored), data) {
+		s.setVersion(relID, version)
+		path, errPath := s.absoluteAuthPath(relID)
+		if errPath != nil {
+			return errPath
+		}
+		if errWrite := os.WriteFile(path, []byte(stored), 0o600); errWrite != nil {
+			return fmt.Errorf("postgres store: mirror newer auth record: %w", errWrite)
+		}
+		log.Warnf("postgres store: auth %s was refreshed by another writer; keeping the newer stored token", relID)
+		return fmt.Errorf("postgres store: %s: %w", relID, ErrAuthConflict)
+	}
+	updated, err := s.updateAuthVersioned(ctx, relID, json.RawMessage(data), version)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return fmt.Errorf("postgres store: %s: %w", relID, ErrAuthConflict)
 	}
 	return nil
 }
@@ -536,9 +632,32 @@ func (s *PostgresStore) deleteAuthRecord(ctx context.Context, relID string) erro
 	if _, err := s.db.ExecContext(ctx, query, relID); err != nil {
 		return fmt.Errorf("postgres store: delete auth record: %w", err)
 	}
+	s.forgetVersion(relID)
 	return nil
 }
 
+func (s *PostgresStore) knownVersion(relID string) (int64, bool) {
+	s.versionMu.Lock()
+	defer s.versionMu.Unlock()
+	version, ok := s.versions[relID]
+	return version, ok
+}
+
+func (s *PostgresStore) setVersion(relID string, version int64) {
+	s.versionMu.Lock()
+	defer s.versionMu.Unlock()
+	if s.versions == nil {
+		s.versions = make(map[string]int64)
+	}
+	s.versions[relID] = version
+}
+
+func (s *PostgresStore) forgetVersion(relID string) {
+	s.versionMu.Lock()
+	defer s.versionMu.Unlock()
+	delete(s.versions, relID)
+}
+
 func (s *PostgresStore) persistConfig(ctx context.Context, data []byte) error {
 	query := fmt.Sprintf(`
 		INSERT INTO %s (id, content, created_at, updated_at)
@@ -0,0 +1,447 @@
+package store
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// errSecretNotFound is returned by secretBackend.Get when no secret exists under a name.
+var errSecretNotFound = errors.New("secret not found")
+
+// secretBackend is a remote secret manager holding one auth JSON document per name. Names are
+// slash separated paths relative to the backend's configured prefix, e.g. "codex-user.json".
+type secretBackend interface {
+	List(ctx context.Context) ([]string, error)
+	Get(ctx context.Context, name string) ([]byte, error)
+	Put(ctx context.Context, name string, data []byte) error
+	Delete(ctx context.Context, name string) error
+}
+
+// SecretTokenStore persists authentication metadata in a secret manager such as HashiCorp Vault
+// or AWS Secrets Manager. The secret backend holds the authoritative copy; auth files are
+// mirrored to a private spool directory so the file watcher and file-based flows keep working.
+type SecretTokenStore struct {
+	backend secretBackend
+	name    string
+	source  string
+	authDir string
+	mu      sync.Mutex
+
+	// digests remembers the hash of each secret as last downloaded or uploaded so writes can
+	// detect that another replica replaced it in the meantime.
+	digestMu sync.Mutex
+	digests  map[string]string
+}
+
+func newSecretTokenStore(name, source string, backend secretBackend, localRoot string) (*SecretTokenStore, error) {
+	root := strings.TrimSpace(localRoot)
+	if root == "" {
+		root = filepath.Join(os.TempDir(), "cliproxy-"+source)
+	}
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("%s: resolve spool directory: %w", name, err)
+	}
+	authDir := filepath.Join(absRoot, "auths")
+	if err = os.MkdirAll(authDir, 0o700); err != nil {
+		return nil, fmt.Errorf("%s: create auth directory: %w", name, err)
+	}
+	return &SecretTokenStore{
+		backend: backend,
+		name:    name,
+		source:  source,
+		authDir: authDir,
+		digests: make(map[string]string),
+	}, nil
+}
+
+// SetBaseDir implements the optional interface used by authenticators; it is a no-op because
+// the secret store controls its own workspace.
+func (s *SecretTokenStore) SetBaseDir(string) {}
+
+// AuthDir returns the local directory containing mirrored auth files.
+func (s *SecretTokenStore) AuthDir() string {
+	if s == nil {
+		return ""
+	}
+	return s.authDir
+}
+
+// Bootstrap mirrors every auth stored in the secret backend into the spool directory.
+func (s *SecretTokenStore) Bootstrap(ctx context.Context) error {
+	if s == nil {
+		return fmt.Errorf("secret store: not initialized")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	names, err := s.backend.List(ctx)
+	if err != nil {
+		return fmt.Errorf("%s: list auths: %w", s.name, err)
+	}
+	seen := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		local, rel, errPath := s.localPath(name)
+		if errPath != nil {
+			log.WithField("secret", name).Warnf("%s: skip auth outside mirror", s.name)
+			continue
+		}
+		data, errGet := s.backend.Get(ctx, name)
+		if errors.Is(errGet, errSecretNotFound) {
+			continue
+		}
+		if errGet != nil {
+			return fmt.Errorf("%s: download auth %s: %w", s.name, name, errGet)
+		}
+		seen[rel] = struct{}{}
+		if errMkdir := os.MkdirAll(filepath.Dir(local), 0o700); errMkdir != nil {
+			return fmt.Errorf("%s: prepare auth subdir: %w", s.name, errMkdir)
+		}
+		if errWrite := os.WriteFile(local, data, 0o600); errWrite != nil {
+			return fmt.Errorf("%s: write auth %s: %w", s.name, local, errWrite)
+		}
+		s.setDigest(name, data)
+	}
+	return removeAuthFilesAbsentFromBucket(s.authDir, seen)
+}
+
+// Save persists authentication metadata to the spool directory and the secret backend.
+func (s *SecretTokenStore) Save(ctx context.Context, auth *cliproxyauth.Auth) (string, error) {
+	if auth == nil {
+		return "", fmt.Errorf("%s: auth is nil", s.name)
+	}
+	path, err := s.resolveAuthPath(auth)
+	if err != nil {
+		return "", err
+	}
+	if auth.Disabled {
+		if _, statErr := os.Stat(path); errors.Is(statErr, fs.ErrNotExist) {
+			return "", nil
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err = os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return "", fmt.Errorf("%s: create auth directory: %w", s.name, err)
+	}
+
+	switch {
+	case auth.Storage != nil:
+		if auth.Metadata == nil {
+			auth.Metadata = make(map[string]any)
+		}
+		auth.Metadata["disabled"] = auth.Disabled
+		if setter, ok := auth.Storage.(interface{ SetMetadata(map[string]any) }); ok {
+			setter.SetMetadata(auth.Metadata)
+		}
+		if err = auth.Storage.SaveTokenToFile(path); err != nil {
+			return "", err
+		}
+	case auth.Metadata != nil:
+		auth.Metadata["disabled"] = auth.Disabled
+		raw, errMarshal := json.Marshal(auth.Metadata)
+		if errMarshal != nil {
+			return "", fmt.Errorf("%s: marshal metadata: %w", s.name, errMarshal)
+		}
+		if existing, errRead := os.ReadFile(path); errRead == nil && jsonEqual(existing, raw) {
+			return path, nil
+		} else if errRead != nil && !errors.Is(errRead, fs.ErrNotExist) {
+			return "", fmt.Errorf("%s: read existing metadata: %w", s.name, errRead)
+		}
+		tmp := path + ".tmp"
+		if errWrite := os.WriteFile(tmp, raw, 0o600); errWrite != nil {
+			return "", fmt.Errorf("%s: write temp auth file: %w", s.name, errWrite)
+		}
+		if errRename := os.Rename(tmp, path); errRename != nil {
+			return "", fmt.Errorf("%s: rename auth file: %w", s.name, errRename)
+		}
+	default:
+		return "", fmt.Errorf("%s: nothing to persist for %s", s.name, auth.ID)
+	}
+
+	if auth.Attributes == nil {
+		auth.Attributes = make(map[string]string)
+	}
+	auth.Attributes[cliproxyauth.AttributePath] = path
+	auth.Attributes[cliproxyauth.AttributeSourceBackend] = s.source
+	if strings.TrimSpace(auth.FileName) == "" {
+		auth.FileName = auth.ID
+	}
+
+	if err = s.uploadAuth(ctx, path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// List enumerates auth JSON files from the mirrored workspace.
+func (s *SecretTokenStore) List(_ context.Context) ([]*cliproxyauth.Auth, error) {
+	entries := make([]*cliproxyauth.Auth, 0, 32)
+	err := filepath.WalkDir(s.authDir, func(path string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		if d.IsDir() || !strings.HasSuffix(strings.ToLower(d.Name()), ".json") {
+			return nil
+		}
+		auth, err := s.readAuthFile(path)
+		if err != nil {
+			log.WithError(err).Warnf("%s: skip auth %s", s.name, path)
+			return nil
+		}
+		if auth != nil {
+			entries = append(entries, auth)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%s: walk auth directory: %w", s.name, err)
+	}
+	return entries, nil
+}
+
+// Delete removes an auth file locally and from the secret backend.
+func (s *SecretTokenStore) Delete(ctx context.Context, id string) error {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return fmt.Errorf("%s: id is empty", s.name)
+	}
+	path := id
+	if !filepath.IsAbs(path) {
+		clean := filepath.Clean(filepath.FromSlash(id))
+		if clean == "." || clean == ".." || strings.HasPrefix(clean, ".."+string(os.PathSeparator)) {
+			return fmt.Errorf("%s: invalid auth identifier %s", s.name, id)
+		}
+		if !strings.HasSuffix(strings.ToLower(clean), ".json") {
+			clean += ".json"
+		}
+		path = filepath.Join(s.authDir, clean)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("%s: delete auth file: %w", s.name, err)
+	}
+	return s.deleteSecret(ctx, path)
+}
+
+// PersistAuthFiles uploads the provided auth files to the secret backend.
+func (s *SecretTokenStore) PersistAuthFiles(ctx context.Context, _ string, paths ...string) error {
+	if len(paths) == 0 {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range paths {
+		trimmed := strings.TrimSpace(p)
+		if trimmed == "" {
+			continue
+		}
+		abs := trimmed
+		if !filepath.IsAbs(abs) {
+			abs = filepath.Join(s.authDir, trimmed)
+		}
+		if err := s.uploadAuth(ctx, abs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PersistConfig is a no-op: secret backends only hold credentials, the configuration file stays
+// wherever it was loaded from.
+func (s *SecretTokenStore) PersistConfig(context.Context) error { return nil }
+
+func (s *SecretTokenStore) uploadAuth(ctx context.Context, path string) error {
+	name, err := s.secretName(path)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return s.deleteSecret(ctx, path)
+		}
+		return fmt.Errorf("%s: read auth file: %w", s.name, err)
+	}
+	if len(data) == 0 {
+		return s.deleteSecret(ctx, path)
+	}
+	if errCheck := s.checkAuthConflict(ctx, name, path, data); errCheck != nil {
+		return errCheck
+	}
+	if err = s.backend.Put(ctx, name, data); err != nil {
+		return fmt.Errorf("%s: put auth %s: %w", s.name, name, err)
+	}
+	s.setDigest(name, data)
+	return nil
+}
+
+// checkAuthConflict compares the stored secret with the copy last seen before an upload. When
+// another replica replaced it with a fresher token, the stored copy is mirrored locally and
+// ErrAuthConflict is returned instead of overwriting it.
+func (s *SecretTokenStore) checkAuthConflict(ctx context.Context, name, path string, data []byte) error {
+	stored, err := s.backend.Get(ctx, name)
+	if errors.Is(err, errSecretNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("%s: fetch auth %s: %w", s.name, name, err)
+	}
+	if known, ok := s.knownDigest(name); ok && known == secretDigest(stored) {
+		return nil
+	}
+	s.setDigest(name, stored)
+	if !preferStoredAuth(stored, data) {
+		return nil
+	}
+	if errWrite := os.WriteFile(path, stored, 0o600); errWrite != nil {
+		return fmt.Errorf("%s: mirror newer auth %s: %w", s.name, name, errWrite)
+	}
+	log.Warnf("%s: auth %s was refreshed by another writer; keeping the newer stored token", s.name, name)
+	return fmt.Errorf("%s: %s: %w", s.name, name, ErrAuthConflict)
+}
+
+func (s *SecretTokenStore) deleteSecret(ctx context.Context, path string) error {
+	name, err := s.secretName(path)
+	if err != nil {
+		return err
+	}
+	if err = s.backend.Delete(ctx, name); err != nil && !errors.Is(err, errSecretNotFound) {
+		return fmt.Errorf("%s: delete auth %s: %w", s.name, name, err)
+	}
+	s.digestMu.Lock()
+	delete(s.digests, name)
+	s.digestMu.Unlock()
+	return nil
+}
+
+// secretName maps a mirrored auth file to its slash separated secret name.
+func (s *SecretTokenStore) secretName(path string) (string, error) {
+	rel, err := filepath.Rel(s.authDir, path)
+	if err != nil {
+		return "", fmt.Errorf("%s: resolve auth relative path: %w", s.name, err)
+	}
+	rel = filepath.Clean(rel)
+	if rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(os.PathSeparator)) {
+		return "", fmt.Errorf("%s: auth %s is outside the mirror", s.name, path)
+	}
+	return filepath.ToSlash(rel), nil
+}
+
+// localPath maps a secret name to its mirrored auth file and the relative path inside the mirror.
+func (s *SecretTokenStore) localPath(name string) (string, string, error) {
+	rel := filepath.Clean(filepath.FromSlash(strings.TrimLeft(name, "/")))
+	if rel == "." || rel == ".." || filepath.IsAbs(rel) || strings.HasPrefix(rel, ".."+string(os.PathSeparator)) {
+		return "", "", fmt.Errorf("%s: invalid secret name %s", s.name, name)
+	}
+	return filepath.Join(s.authDir, rel), rel, nil
+}
+
+func (s *SecretTokenStore) resolveAuthPath(auth *cliproxyauth.Auth) (string, error) {
+	if auth.Attributes != nil {
+		if path := strings.TrimSpace(auth.Attributes[cliproxyauth.AttributePath]); path != "" {
+			if filepath.IsAbs(path) {
+				return path, nil
+			}
+			return filepath.Join(s.authDir, path), nil
+		}
+	}
+	fileName := strings.TrimSpace(auth.FileName)
+	if fileName == "" {
+		fileName = strings.TrimSpace(auth.ID)
+	}
+	if fileName == "" {
+		return "", fmt.Errorf("%s: auth %s missing filename", s.name, auth.ID)
+	}
+	if !strings.HasSuffix(strings.ToLower(fileName), ".json") {
+		fileName += ".json"
+	}
+	return filepath.Join(s.authDir, fileName), nil
+}
+
+func (s *SecretTokenStore) readAuthFile(path string) (*cliproxyauth.Auth, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, nil
+	}
+	metadata := make(map[string]any)
+	if err = json.Unmarshal(data, &metadata); err != nil {
+		return nil, fmt.Errorf("unmarshal auth json: %w", err)
+	}
+	provider := strings.TrimSpace(valueAsString(metadata["type"]))
+	if provider == "" {
+		provider = "unknown"
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("stat auth file: %w", err)
+	}
+	rel, errRel := filepath.Rel(s.authDir, path)
+	if errRel != nil {
+		rel = filepath.Base(path)
+	}
+	rel = normalizeAuthID(rel)
+	attr := map[string]string{
+		cliproxyauth.AttributePath:          path,
+		cliproxyauth.AttributeSourceBackend: s.source,
+	}
+	if email := strings.TrimSpace(valueAsString(metadata["email"])); email != "" {
+		attr["email"] = email
+	}
+	auth := &cliproxyauth.Auth{
+		ID:         rel,
+		Provider:   provider,
+		FileName:   rel,
+		Label:      labelFor(metadata),
+		Status:     cliproxyauth.StatusActive,
+		Attributes: attr,
+		Metadata:   metadata,
+		CreatedAt:  info.ModTime(),
+		UpdatedAt:  info.ModTime(),
+	}
+	cliproxyauth.ApplyCustomHeadersFromMetadata(auth)
+	if disabled, ok := metadata["disabled"].(bool); ok && disabled {
+		auth.Disabled = true
+		auth.Status = cliproxyauth.StatusDisabled
+	}
+	return auth, nil
+}
+
+func (s *SecretTokenStore) knownDigest(name string) (string, bool) {
+	s.digestMu.Lock()
+	defer s.digestMu.Unlock()
+	digest, ok := s.digests[name]
+	return digest, ok
+}
+
+func (s *SecretTokenStore) setDigest(name string, data []byte) {
+	s.digestMu.Lock()
+	defer s.digestMu.Unlock()
+	s.digests[name] = secretDigest(data)
+}
+
+func secretDigest(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
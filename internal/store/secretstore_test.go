@@ -0,0 +1,196 @@
+package store
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+// fakeVault implements the subset of the Vault KV v2 API used by vaultBackend.
+type fakeVault struct {
+	mu      sync.Mutex
+	secrets map[string]string
+}
+
+func (f *fakeVault) secret(key string) (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	value, ok := f.secrets[key]
+	return value, ok
+}
+
+func (f *fakeVault) setSecret(key, value string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.secrets[key] = value
+}
+
+func (f *fakeVault) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("X-Vault-Token") != "test-token" {
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = w.Write([]byte(`{"errors":["permission denied"]}`))
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	path := r.URL.Path
+	switch {
+	case r.Method == "LIST" && strings.HasPrefix(path, "/v1/secret/metadata/"):
+		dir := strings.TrimPrefix(path, "/v1/secret/metadata/")
+		seen := map[string]bool{}
+		for key := range f.secrets {
+			rest, ok := strings.CutPrefix(key, dir)
+			if !ok {
+				continue
+			}
+			if idx := strings.Index(rest, "/"); idx >= 0 {
+				rest = rest[:idx+1]
+			}
+			seen[rest] = true
+		}
+		if len(seen) == 0 {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		keys := make([]string, 0, len(seen))
+		for key := range seen {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"keys": keys}})
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "/v1/secret/data/"):
+		value, ok := f.secrets[strings.TrimPrefix(path, "/v1/secret/data/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": map[string]any{"data": map[string]any{"auth": value}}})
+	case r.Method == http.MethodPost && strings.HasPrefix(path, "/v1/secret/data/"):
+		var body struct {
+			Data map[string]string `json:"data"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.secrets[strings.TrimPrefix(path, "/v1/secret/data/")] = body.Data["auth"]
+		_, _ = w.Write([]byte(`{"data":{"version":1}}`))
+	case r.Method == http.MethodDelete && strings.HasPrefix(path, "/v1/secret/metadata/"):
+		delete(f.secrets, strings.TrimPrefix(path, "/v1/secret/metadata/"))
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func newTestVaultStore(t *testing.T, vault *fakeVault) *SecretTokenStore {
+	t.Helper()
+	server := httptest.NewServer(vault)
+	t.Cleanup(server.Close)
+	store, err := NewVaultTokenStore(VaultStoreConfig{
+		Address:   server.URL,
+		Token:     "test-token",
+		LocalRoot: t.TempDir(),
+	})
+	if err != nil {
+		t.Fatalf("NewVaultTokenStore() error = %v", err)
+	}
+	return store
+}
+
+func TestVaultTokenStoreRoundTrip(t *testing.T) {
+	vault := &fakeVault{secrets: map[string]string{
+		"cliproxy/auths/team/codex-a.json": `{"type":"codex","email":"a@example.com"}`,
+	}}
+	store := newTestVaultStore(t, vault)
+	ctx := context.Background()
+
+	stale := filepath.Join(store.AuthDir(), "stale.json")
+	if err := os.WriteFile(stale, []byte(`{"type":"codex"}`), 0o600); err != nil {
+		t.Fatalf("write stale auth: %v", err)
+	}
+	if err := store.Bootstrap(ctx); err != nil {
+		t.Fatalf("Bootstrap() error = %v", err)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Fatalf("stale auth stat error = %v, want not exist", err)
+	}
+	auths, err := store.List(ctx)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(auths) != 1 || auths[0].ID != "team/codex-a.json" || auths[0].Provider != "codex" {
+		t.Fatalf("List() = %+v, want team/codex-a.json", auths)
+	}
+	if got := auths[0].AuthSourceKind(); got != cliproxyauth.AuthSourceVault {
+		t.Fatalf("AuthSourceKind() = %q, want %q", got, cliproxyauth.AuthSourceVault)
+	}
+
+	auth := &cliproxyauth.Auth{
+		ID:       "copilot-b.json",
+		Provider: "copilot",
+		Metadata: map[string]any{"type": "copilot", "access_token": "fresh"},
+	}
+	if _, err = store.Save(ctx, auth); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	stored, _ := vault.secret("cliproxy/auths/copilot-b.json")
+	if !strings.Contains(stored, `"access_token":"fresh"`) {
+		t.Fatalf("vault secret = %q, want saved token", stored)
+	}
+
+	if err = store.Delete(ctx, "copilot-b.json"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, ok := vault.secret("cliproxy/auths/copilot-b.json"); ok {
+		t.Fatal("vault secret still present after Delete()")
+	}
+	if _, err = os.Stat(filepath.Join(store.AuthDir(), "copilot-b.json")); !os.IsNotExist(err) {
+		t.Fatalf("mirrored auth stat error = %v, want not exist", err)
+	}
+}
+
+func TestSecretTokenStoreKeepsNewerStoredToken(t *testing.T) {
+	vault := &fakeVault{secrets: map[string]string{
+		"cliproxy/auths/codex-a.json": `{"type":"codex","access_token":"old","expired":"2026-01-01T00:00:00Z"}`,
+	}}
+	store := newTestVaultStore(t, vault)
+	ctx := context.Background()
+	if err := store.Bootstrap(ctx); err != nil {
+		t.Fatalf("Bootstrap() error = %v", err)
+	}
+
+	// Another replica refreshes the token after this one mirrored it.
+	newer := `{"type":"codex","access_token":"newer","expired":"2026-01-01T02:00:00Z"}`
+	vault.setSecret("cliproxy/auths/codex-a.json", newer)
+
+	auth := &cliproxyauth.Auth{
+		ID:       "codex-a.json",
+		Provider: "codex",
+		Metadata: map[string]any{"type": "codex", "access_token": "stale", "expired": "2026-01-01T01:00:00Z"},
+	}
+	_, err := store.Save(ctx, auth)
+	if !errors.Is(err, cliproxyauth.ErrStoreConflict) {
+		t.Fatalf("Save() error = %v, want ErrStoreConflict", err)
+	}
+	if got, _ := vault.secret("cliproxy/auths/codex-a.json"); got != newer {
+		t.Fatalf("vault secret = %q, want the newer stored token", got)
+	}
+	mirrored, errRead := os.ReadFile(filepath.Join(store.AuthDir(), "codex-a.json"))
+	if errRead != nil {
+		t.Fatalf("read mirrored auth: %v", errRead)
+	}
+	if string(mirrored) != newer {
+		t.Fatalf("mirrored auth = %q, want the newer stored token", mirrored)
+	}
+}
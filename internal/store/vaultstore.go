@@ -0,0 +1,203 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+const (
+	vaultDefaultMount  = "secret"
+	vaultDefaultPrefix = "cliproxy/auths"
+	// vaultAuthField is the KV field holding the raw auth JSON, kept verbatim so token storages
+	// read back exactly what they wrote.
+	vaultAuthField = "auth"
+)
+
+// VaultStoreConfig captures configuration for the HashiCorp Vault backed token store.
+type VaultStoreConfig struct {
+	Address   string
+	Token     string
+	Namespace string
+	Mount     string
+	Prefix    string
+	LocalRoot string
+	// HTTPClient overrides the client used to reach Vault.
+	HTTPClient *http.Client
+}
+
+// NewVaultTokenStore initializes a token store persisting auths in a Vault KV v2 engine.
+// Address, token and namespace fall back to VAULT_ADDR, VAULT_TOKEN and VAULT_NAMESPACE.
+func NewVaultTokenStore(cfg VaultStoreConfig) (*SecretTokenStore, error) {
+	backend := &vaultBackend{
+		address:   strings.TrimRight(firstNonEmpty(cfg.Address, os.Getenv("VAULT_ADDR")), "/"),
+		token:     firstNonEmpty(cfg.Token, os.Getenv("VAULT_TOKEN")),
+		namespace: firstNonEmpty(cfg.Namespace, os.Getenv("VAULT_NAMESPACE")),
+		mount:     strings.Trim(firstNonEmpty(cfg.Mount, vaultDefaultMount), "/"),
+		prefix:    strings.Trim(firstNonEmpty(cfg.Prefix, vaultDefaultPrefix), "/"),
+		client:    cfg.HTTPClient,
+	}
+	if backend.address == "" {
+		return nil, fmt.Errorf("vault store: address is required")
+	}
+	if backend.token == "" {
+		return nil, fmt.Errorf("vault store: token is required")
+	}
+	if backend.client == nil {
+		backend.client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return newSecretTokenStore("vault store", cliproxyauth.AuthSourceVault, backend, cfg.LocalRoot)
+}
+
+// vaultBackend talks to the Vault KV v2 HTTP API.
+type vaultBackend struct {
+	client    *http.Client
+	address   string
+	token     string
+	namespace string
+	mount     string
+	prefix    string
+}
+
+func (b *vaultBackend) List(ctx context.Context) ([]string, error) {
+	var names []string
+	pending := []string{""}
+	for len(pending) > 0 {
+		dir := pending[0]
+		pending = pending[1:]
+		var resp struct {
+			Data struct {
+				Keys []string `json:"keys"`
+			} `json:"data"`
+		}
+		found, err := b.do(ctx, "LIST", b.apiPath("metadata", dir), nil, &resp)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			continue
+		}
+		for _, key := range resp.Data.Keys {
+			if strings.HasSuffix(key, "/") {
+				pending = append(pending, dir+key)
+				continue
+			}
+			names = append(names, dir+key)
+		}
+	}
+	return names, nil
+}
+
+func (b *vaultBackend) Get(ctx context.Context, name string) ([]byte, error) {
+	var resp struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	found, err := b.do(ctx, http.MethodGet, b.apiPath("data", name), nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	// Deleted versions keep their metadata but return no data.
+	raw, _ := resp.Data.Data[vaultAuthField].(string)
+	if !found || raw == "" {
+		return nil, errSecretNotFound
+	}
+	return []byte(raw), nil
+}
+
+func (b *vaultBackend) Put(ctx context.Context, name string, data []byte) error {
+	body := map[string]any{"data": map[string]string{vaultAuthField: string(data)}}
+	_, err := b.do(ctx, http.MethodPost, b.apiPath("data", name), body, nil)
+	return err
+}
+
+func (b *vaultBackend) Delete(ctx context.Context, name string) error {
+	// Deleting the metadata removes every version, so revoked tokens do not linger in history.
+	_, err := b.do(ctx, http.MethodDelete, b.apiPath("metadata", name), nil, nil)
+	return err
+}
+
+func (b *vaultBackend) apiPath(kind, name string) string {
+	segments := []string{"v1", b.mount, kind}
+	for _, part := range strings.Split(b.prefix+"/"+name, "/") {
+		if part != "" {
+			segments = append(segments, url.PathEscape(part))
+		}
+	}
+	path := strings.Join(segments, "/")
+	if strings.HasSuffix(name, "/") || name == "" {
+		path += "/"
+	}
+	return path
+}
+
+// do performs a Vault API call and decodes the response into out. It reports false without an
+// error when Vault answers 404.
+func (b *vaultBackend) do(ctx context.Context, method, path string, body any, out any) (bool, error) {
+	var reader io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return false, fmt.Errorf("encode request: %w", err)
+		}
+		reader = bytes.NewReader(raw)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, b.address+"/"+path, reader)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("X-Vault-Token", b.token)
+	req.Header.Set("X-Vault-Request", "true")
+	if b.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", b.namespace)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	payload, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return false, fmt.Errorf("read response: %w", err)
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		var vaultErr struct {
+			Errors []string `json:"errors"`
+		}
+		if json.Unmarshal(payload, &vaultErr) == nil && len(vaultErr.Errors) > 0 {
+			return false, fmt.Errorf("vault %s %s: status %d: %s", method, path, resp.StatusCode, strings.Join(vaultErr.Errors, "; "))
+		}
+		return false, fmt.Errorf("vault %s %s: status %d", method, path, resp.StatusCode)
+	}
+	if out != nil && len(payload) > 0 {
+		if err = json.Unmarshal(payload, out); err != nil {
+			return false, fmt.Errorf("decode response: %w", err)
+		}
+	}
+	return true, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if trimmed := strings.TrimSpace(v); trimmed != "" {
+			return trimmed
+		}
+	}
+	return ""
+}
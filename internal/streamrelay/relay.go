@@ -0,0 +1,62 @@
+// Package streamrelay forwards response stream chunks through a single goroutine that lets
+// several features observe the stream without adding a channel hop each.
+package streamrelay
+
+import "context"
+
+// Observer is notified of every chunk a relayed stream carries and of its end. Nil callbacks
+// are skipped.
+type Observer struct {
+	// OnChunk is called for each chunk before it is forwarded.
+	OnChunk func(chunk []byte)
+	// OnClose is called once when the stream ends, before the output channel closes.
+	OnClose func()
+}
+
+// Relay forwards in until it closes or ctx is done, calling the observers on the way. When ctx
+// is done the remaining chunks are drained without being forwarded. It returns in unchanged
+// when there is nothing to observe.
+func Relay(ctx context.Context, in <-chan []byte, observers ...Observer) <-chan []byte {
+	active := observers[:0:0]
+	for _, observer := range observers {
+		if observer.OnChunk != nil || observer.OnClose != nil {
+			active = append(active, observer)
+		}
+	}
+	if in == nil || len(active) == 0 {
+		closeObservers(active)
+		return in
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	out := make(chan []byte)
+	go func() {
+		defer close(out)
+		defer closeObservers(active)
+		for chunk := range in {
+			for _, observer := range active {
+				if observer.OnChunk != nil {
+					observer.OnChunk(chunk)
+				}
+			}
+			select {
+			case out <- chunk:
+			case <-ctx.Done():
+				// Keep draining so the producer can finish and release its resources.
+				for range in {
+				}
+				return
+			}
+		}
+	}()
+	return out
+}
+
+func closeObservers(observers []Observer) {
+	for _, observer := range observers {
+		if observer.OnClose != nil {
+			observer.OnClose()
+		}
+	}
+}
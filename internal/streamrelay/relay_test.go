@@ -0,0 +1,41 @@
+package streamrelay
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRelayNotifiesObserversInOrder(t *testing.T) {
+	in := make(chan []byte, 2)
+	in <- []byte("ab")
+	in <- []byte("c")
+	close(in)
+
+	var events []string
+	observer := func(name string) Observer {
+		return Observer{
+			OnChunk: func(chunk []byte) { events = append(events, name+":"+string(chunk)) },
+			OnClose: func() { events = append(events, name+":close") },
+		}
+	}
+	out := Relay(context.Background(), in, observer("a"), Observer{}, observer("b"))
+	for range out {
+	}
+
+	want := []string{"a:ab", "b:ab", "a:c", "b:c", "a:close", "b:close"}
+	if len(events) != len(want) {
+		t.Fatalf("events = %v, want %v", events, want)
+	}
+	for i := range want {
+		if events[i] != want[i] {
+			t.Fatalf("events = %v, want %v", events, want)
+		}
+	}
+}
+
+func TestRelayWithoutObserversReturnsInput(t *testing.T) {
+	in := make(chan []byte)
+	if out := Relay(context.Background(), in, Observer{}); out != (<-chan []byte)(in) {
+		t.Fatal("expected input channel to be returned unchanged")
+	}
+}
@@ -0,0 +1,319 @@
+// Package streamwatch keeps a registry of live response streams so streams whose goroutines never
+// finish can be spotted, alarmed on and cancelled.
+package streamwatch
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/streamrelay"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultMaxAge = time.Hour
+	checkInterval = 30 * time.Second
+)
+
+var errForceCancelled = errors.New("stream cancelled by the stream watchdog")
+
+// Info describes a stream when it is registered.
+type Info struct {
+	// Handler is the entry protocol serving the stream, e.g. "openai" or "claude".
+	Handler string
+	Model   string
+}
+
+// StreamInfo is a snapshot of one live stream.
+type StreamInfo struct {
+	ID          string    `json:"id"`
+	RequestID   string    `json:"request_id,omitempty"`
+	Handler     string    `json:"handler,omitempty"`
+	Model       string    `json:"model,omitempty"`
+	StartedAt   time.Time `json:"started_at"`
+	AgeSeconds  int64     `json:"age_seconds"`
+	Chunks      int64     `json:"chunks"`
+	LastChunkAt time.Time `json:"last_chunk_at,omitzero"`
+	// OverAge reports that the stream is older than the configured maximum age.
+	OverAge bool `json:"over_age,omitempty"`
+}
+
+// Stats are the registry's alarm counters since process start.
+type Stats struct {
+	Live           int          `json:"live"`
+	MaxAgeSeconds  int64        `json:"max_age_seconds,omitempty"`
+	MaxCount       int          `json:"max_count,omitempty"`
+	AgeAlarms      uint64       `json:"age_alarms"`
+	CountAlarms    uint64       `json:"count_alarms"`
+	ForceCancelled uint64       `json:"force_cancelled"`
+	Streams        []StreamInfo `json:"streams"`
+	CheckedAt      time.Time    `json:"checked_at,omitzero"`
+}
+
+// Stream is the handle of a registered stream.
+type Stream struct {
+	id        string
+	info      Info
+	requestID string
+	startedAt time.Time
+	ctx       context.Context
+	cancel    context.CancelFunc
+
+	chunks      atomic.Int64
+	lastChunkAt atomic.Int64
+	alarmed     atomic.Bool
+	doneOnce    sync.Once
+	// forced reports that the stream was cancelled through Cancel.
+	forced atomic.Bool
+	// errsDone is closed when the goroutine started by Errors has stopped forwarding.
+	errsDone chan struct{}
+}
+
+type registry struct {
+	mu       sync.RWMutex
+	streams  map[string]*Stream
+	maxAge   time.Duration
+	maxCount int
+
+	ageAlarms      atomic.Uint64
+	countAlarms    atomic.Uint64
+	forceCancelled atomic.Uint64
+	checkedAt      atomic.Int64
+	countAlarmed   atomic.Bool
+
+	startOnce sync.Once
+}
+
+var active = &registry{streams: make(map[string]*Stream), maxAge: defaultMaxAge}
+
+var now = time.Now
+
+// Configure applies the stream-watchdog configuration and starts the periodic check.
+func Configure(cfg *config.Config) {
+	maxAge, maxCount := defaultMaxAge, 0
+	if cfg != nil {
+		switch minutes := cfg.StreamWatchdog.MaxAgeMinutes; {
+		case minutes > 0:
+			maxAge = time.Duration(minutes) * time.Minute
+		case minutes < 0:
+			maxAge = 0
+		}
+		maxCount = max(cfg.StreamWatchdog.MaxCount, 0)
+	}
+	r := active
+	r.mu.Lock()
+	r.maxAge, r.maxCount = maxAge, maxCount
+	r.mu.Unlock()
+	// New limits re-arm the count alarm.
+	r.countAlarmed.Store(false)
+	r.startOnce.Do(func() { go r.run() })
+}
+
+// Register adds a stream to the registry. The returned context is cancelled by Cancel, with ctx
+// and when the stream ends; the stream leaves the registry when its Observer closes or Done is
+// called.
+func Register(ctx context.Context, info Info) (context.Context, *Stream) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	streamCtx, cancel := context.WithCancel(ctx)
+	stream := &Stream{
+		id:        uuid.NewString(),
+		info:      info,
+		requestID: logging.GetRequestID(ctx),
+		startedAt: now(),
+		ctx:       streamCtx,
+		cancel:    cancel,
+	}
+	active.mu.Lock()
+	active.streams[stream.id] = stream
+	active.mu.Unlock()
+	return streamCtx, stream
+}
+
+// Observer counts the chunks of the stream and removes it from the registry when the stream
+// ends. A stream force-cancelled through Cancel first hands its terminal error to the channel
+// returned by Errors, so the client sees a failed stream rather than a normal end.
+func (s *Stream) Observer() streamrelay.Observer {
+	return streamrelay.Observer{
+		OnChunk: func([]byte) {
+			s.chunks.Add(1)
+			s.lastChunkAt.Store(now().UnixNano())
+		},
+		OnClose: func() {
+			s.Done()
+			if s.errsDone != nil {
+				<-s.errsDone
+			}
+		},
+	}
+}
+
+// Errors forwards errs and adds a terminal error when the stream is force-cancelled. It must be
+// called before the stream is relayed, and at most once.
+func (s *Stream) Errors(errs <-chan *interfaces.ErrorMessage) <-chan *interfaces.ErrorMessage {
+	out := make(chan *interfaces.ErrorMessage, 1)
+	s.errsDone = make(chan struct{})
+	go func() {
+		defer close(s.errsDone)
+		defer close(out)
+		for {
+			select {
+			case msg, ok := <-errs:
+				if !ok {
+					return
+				}
+				select {
+				case out <- msg:
+				case <-s.ctx.Done():
+					return
+				}
+			case <-s.ctx.Done():
+				// An upstream error sent before the stream ended still wins over the cancellation.
+				var msg *interfaces.ErrorMessage
+				select {
+				case pending, ok := <-errs:
+					if ok {
+						msg = pending
+					}
+				default:
+				}
+				if msg == nil && s.forced.Load() {
+					msg = &interfaces.ErrorMessage{StatusCode: http.StatusServiceUnavailable, Error: errForceCancelled}
+				}
+				if msg != nil {
+					select {
+					case out <- msg:
+					default:
+					}
+				}
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Done removes the stream from the registry and releases its context. It is safe to call more
+// than once.
+func (s *Stream) Done() {
+	s.doneOnce.Do(func() {
+		active.mu.Lock()
+		delete(active.streams, s.id)
+		active.mu.Unlock()
+		s.cancel()
+	})
+}
+
+func (s *Stream) snapshot(current time.Time, maxAge time.Duration) StreamInfo {
+	info := StreamInfo{
+		ID:         s.id,
+		RequestID:  s.requestID,
+		Handler:    s.info.Handler,
+		Model:      s.info.Model,
+		StartedAt:  s.startedAt,
+		AgeSeconds: int64(current.Sub(s.startedAt).Seconds()),
+		Chunks:     s.chunks.Load(),
+		OverAge:    maxAge > 0 && current.Sub(s.startedAt) > maxAge,
+	}
+	if last := s.lastChunkAt.Load(); last > 0 {
+		info.LastChunkAt = time.Unix(0, last)
+	}
+	return info
+}
+
+// Cancel cancels the context of the live stream id so its upstream request and goroutines stop.
+// It reports whether the stream was found.
+func Cancel(id string) bool {
+	active.mu.RLock()
+	stream, ok := active.streams[id]
+	active.mu.RUnlock()
+	if !ok {
+		return false
+	}
+	active.forceCancelled.Add(1)
+	stream.forced.Store(true)
+	log.Warnf("stream watchdog: force-cancelling stream %s (request %s, model %s, age %s)", id, stream.requestID, stream.info.Model, now().Sub(stream.startedAt).Round(time.Second))
+	stream.cancel()
+	return true
+}
+
+// Snapshot returns the live streams, oldest first, and the alarm counters.
+func Snapshot() Stats {
+	r := active
+	current := now()
+	r.mu.RLock()
+	stats := Stats{
+		Live:          len(r.streams),
+		MaxAgeSeconds: int64(r.maxAge / time.Second),
+		MaxCount:      r.maxCount,
+		Streams:       make([]StreamInfo, 0, len(r.streams)),
+	}
+	for _, stream := range r.streams {
+		stats.Streams = append(stats.Streams, stream.snapshot(current, r.maxAge))
+	}
+	r.mu.RUnlock()
+	sort.Slice(stats.Streams, func(i, j int) bool { return stats.Streams[i].StartedAt.Before(stats.Streams[j].StartedAt) })
+	stats.AgeAlarms = r.ageAlarms.Load()
+	stats.CountAlarms = r.countAlarms.Load()
+	stats.ForceCancelled = r.forceCancelled.Load()
+	if checked := r.checkedAt.Load(); checked > 0 {
+		stats.CheckedAt = time.Unix(0, checked)
+	}
+	return stats
+}
+
+func (r *registry) run() {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		r.check()
+	}
+}
+
+// check raises an alarm for every stream that crossed the maximum age and when the number of
+// live streams exceeds the maximum count. Each stream alarms once; the count alarm re-arms once
+// the count drops back under the maximum.
+func (r *registry) check() {
+	current := now()
+	r.checkedAt.Store(current.UnixNano())
+	r.mu.RLock()
+	maxAge, maxCount, live := r.maxAge, r.maxCount, len(r.streams)
+	var overAge []*Stream
+	if maxAge > 0 {
+		for _, stream := range r.streams {
+			if current.Sub(stream.startedAt) > maxAge && !stream.alarmed.Load() {
+				overAge = append(overAge, stream)
+			}
+		}
+	}
+	r.mu.RUnlock()
+
+	for _, stream := range overAge {
+		if stream.alarmed.Swap(true) {
+			continue
+		}
+		r.ageAlarms.Add(1)
+		log.Warnf("stream watchdog: stream %s (request %s, handler %s, model %s) is %s old, above the %s maximum; %d chunks so far",
+			stream.id, stream.requestID, stream.info.Handler, stream.info.Model, current.Sub(stream.startedAt).Round(time.Second), maxAge, stream.chunks.Load())
+	}
+	if maxCount > 0 {
+		if live > maxCount {
+			if !r.countAlarmed.Swap(true) {
+				r.countAlarms.Add(1)
+				log.Warnf("stream watchdog: %d live streams, above the maximum of %d", live, maxCount)
+			}
+		} else {
+			r.countAlarmed.Store(false)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package streamwatch
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/streamrelay"
+)
+
+func findStream(id string) (StreamInfo, bool) {
+	for _, info := range Snapshot().Streams {
+		if info.ID == id {
+			return info, true
+		}
+	}
+	return StreamInfo{}, false
+}
+
+func TestObserverRemovesStreamWhenChannelCloses(t *testing.T) {
+	ctx, stream := Register(context.Background(), Info{Handler: "openai", Model: "gpt-test"})
+	in := make(chan []byte)
+	out := streamrelay.Relay(ctx, in, stream.Observer())
+
+	go func() {
+		in <- []byte("a")
+		in <- []byte("b")
+		close(in)
+	}()
+	<-out
+	info, ok := findStream(stream.id)
+	if !ok {
+		t.Fatal("expected live stream in snapshot")
+	}
+	if info.Model != "gpt-test" || info.Chunks < 1 {
+		t.Fatalf("unexpected stream info: %+v", info)
+	}
+	<-out
+	if _, open := <-out; open {
+		t.Fatal("expected output to close")
+	}
+	if _, ok := findStream(stream.id); ok {
+		t.Fatal("expected stream to leave the registry after close")
+	}
+	select {
+	case <-ctx.Done():
+	default:
+		t.Fatal("expected stream context to be released when the stream ends")
+	}
+}
+
+func TestCancelReportsTerminalError(t *testing.T) {
+	ctx, stream := Register(context.Background(), Info{Model: "m"})
+	upstreamErrs := make(chan *interfaces.ErrorMessage)
+	errs := stream.Errors(upstreamErrs)
+	in := make(chan []byte)
+	out := streamrelay.Relay(ctx, in, stream.Observer())
+	go func() {
+		defer close(in)
+		for {
+			select {
+			case in <- []byte("chunk"):
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	<-out
+	if !Cancel(stream.id) {
+		t.Fatal("expected live stream to be cancelled")
+	}
+	for range out {
+	}
+	// The error must be pending once the data channel closes, as the stream forwarder only
+	// polls errs at that point.
+	select {
+	case msg := <-errs:
+		if msg == nil || !errors.Is(msg.Error, errForceCancelled) {
+			t.Fatalf("terminal error = %+v, want force-cancel error", msg)
+		}
+	default:
+		t.Fatal("expected a terminal error after a forced cancel")
+	}
+}
+
+func TestCancelStopsStreamContext(t *testing.T) {
+	ctx, stream := Register(context.Background(), Info{Model: "m"})
+	defer stream.Done()
+
+	if Cancel("missing") {
+		t.Fatal("expected unknown stream to be reported as not found")
+	}
+	if !Cancel(stream.id) {
+		t.Fatal("expected live stream to be cancelled")
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("expected stream context to be cancelled")
+	}
+}
+
+func TestCheckAlarmsOnceForOverAgeStreams(t *testing.T) {
+	previousNow := now
+	defer func() { now = previousNow }()
+	current := time.Unix(1_700_000_000, 0)
+	now = func() time.Time { return current }
+
+	Configure(&config.Config{StreamWatchdog: config.StreamWatchdogConfig{MaxAgeMinutes: 1, MaxCount: 1}})
+	defer Configure(nil)
+	_, first := Register(context.Background(), Info{Model: "a"})
+	defer first.Done()
+	_, second := Register(context.Background(), Info{Model: "b"})
+	defer second.Done()
+
+	before := Snapshot()
+	current = current.Add(2 * time.Minute)
+	active.check()
+	active.check()
+
+	after := Snapshot()
+	if got := after.AgeAlarms - before.AgeAlarms; got != 2 {
+		t.Fatalf("age alarms = %d, want 2", got)
+	}
+	if got := after.CountAlarms - before.CountAlarms; got != 1 {
+		t.Fatalf("count alarms = %d, want 1", got)
+	}
+	if info, ok := findStream(first.id); !ok || !info.OverAge {
+		t.Fatalf("expected first stream to be over age: %+v", info)
+	}
+}
@@ -0,0 +1,258 @@
+// Package telemetry builds the opt-in anonymous telemetry report: per-provider request counts,
+// error classes and the binary version, with Laplace noise added to every count. Counts are
+// collected in memory only; nothing leaves the process unless telemetry is enabled with an
+// endpoint. The schema is documented in docs/telemetry.md.
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/buildinfo"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+)
+
+// SchemaVersion is bumped whenever a Report field is added, removed or changes meaning.
+const SchemaVersion = 1
+
+const (
+	defaultInterval = 24 * time.Hour
+	defaultEpsilon  = 1.0
+	sendTimeout     = 30 * time.Second
+)
+
+// knownProviders are reported by name; every other provider, e.g. a named OpenAI-compatible
+// upstream, is reported as "other" so user-chosen names never leave the process.
+var knownProviders = map[string]bool{
+	"aistudio": true, "antigravity": true, "chutes": true, "claude": true, "codex": true,
+	"copilot": true, "cursor": true, "gemini": true, "gemini-cli": true, "gemini-interactions": true,
+	"grok": true, "iflow": true, "kimi": true, "kiro": true, "qwen": true, "vertex": true, "xai": true,
+}
+
+// ErrorClasses are the error buckets of a report.
+var ErrorClasses = []string{"rate_limit", "auth", "client", "server", "network"}
+
+// ProviderCounts are the upstream attempts of one provider.
+type ProviderCounts struct {
+	Requests int64 `json:"requests"`
+	Failed   int64 `json:"failed"`
+}
+
+// Report is the payload posted to the telemetry endpoint.
+type Report struct {
+	SchemaVersion int                       `json:"schema_version"`
+	Version       string                    `json:"version"`
+	OS            string                    `json:"os"`
+	Arch          string                    `json:"arch"`
+	PeriodHours   int                       `json:"period_hours"`
+	Providers     map[string]ProviderCounts `json:"providers"`
+	Errors        map[string]int64          `json:"errors"`
+}
+
+type collector struct {
+	mu        sync.Mutex
+	start     time.Time
+	providers map[string]*ProviderCounts
+	errors    map[string]int64
+}
+
+var active = newCollector(time.Now())
+
+func newCollector(now time.Time) *collector {
+	return &collector{start: now, providers: make(map[string]*ProviderCounts), errors: make(map[string]int64)}
+}
+
+func init() {
+	coreusage.RegisterPlugin(usagePlugin{})
+}
+
+type usagePlugin struct{}
+
+func (usagePlugin) HandleUsage(_ context.Context, record coreusage.Record) {
+	active.add(record)
+}
+
+func (c *collector) add(record coreusage.Record) {
+	provider := strings.ToLower(strings.TrimSpace(record.Provider))
+	if !knownProviders[provider] {
+		provider = "other"
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	counts, ok := c.providers[provider]
+	if !ok {
+		counts = &ProviderCounts{}
+		c.providers[provider] = counts
+	}
+	counts.Requests++
+	if record.Failed {
+		counts.Failed++
+		c.errors[errorClass(record.Fail.StatusCode)]++
+	}
+}
+
+func errorClass(status int) string {
+	switch {
+	case status == http.StatusTooManyRequests:
+		return "rate_limit"
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return "auth"
+	case status >= 400 && status < 500:
+		return "client"
+	case status >= 500:
+		return "server"
+	}
+	return "network"
+}
+
+// report builds the noised report of the counts since the last reset and, with reset, starts
+// a new period.
+func (c *collector) report(now time.Time, epsilon float64, reset bool) Report {
+	c.mu.Lock()
+	start := c.start
+	providers := make(map[string]ProviderCounts, len(c.providers))
+	for name, counts := range c.providers {
+		providers[name] = *counts
+	}
+	errors := make(map[string]int64, len(ErrorClasses))
+	for _, class := range ErrorClasses {
+		errors[class] = c.errors[class]
+	}
+	if reset {
+		c.start = now
+		c.providers = make(map[string]*ProviderCounts)
+		c.errors = make(map[string]int64)
+	}
+	c.mu.Unlock()
+
+	out := Report{
+		SchemaVersion: SchemaVersion,
+		Version:       buildinfo.Version,
+		OS:            runtime.GOOS,
+		Arch:          runtime.GOARCH,
+		PeriodHours:   max(int(math.Round(now.Sub(start).Hours())), 1),
+		Providers:     make(map[string]ProviderCounts, len(providers)),
+		Errors:        make(map[string]int64, len(errors)),
+	}
+	for name, counts := range providers {
+		out.Providers[name] = ProviderCounts{Requests: noisy(counts.Requests, epsilon), Failed: noisy(counts.Failed, epsilon)}
+	}
+	for class, count := range errors {
+		out.Errors[class] = noisy(count, epsilon)
+	}
+	return out
+}
+
+// noisy adds Laplace noise of scale 1/epsilon to count, rounded and clamped at zero.
+func noisy(count int64, epsilon float64) int64 {
+	u := rand.Float64() - 0.5
+	if u == -0.5 {
+		u = 0
+	}
+	noise := -math.Copysign(1/epsilon, u) * math.Log(1-2*math.Abs(u))
+	return max(int64(math.Round(float64(count)+noise)), 0)
+}
+
+var (
+	runMu     sync.Mutex
+	runCancel context.CancelFunc
+	runCfg    config.TelemetryConfig
+)
+
+func epsilonOf(cfg config.TelemetryConfig) float64 {
+	if cfg.Epsilon > 0 {
+		return cfg.Epsilon
+	}
+	return defaultEpsilon
+}
+
+// Preview returns the report that would be sent now, without starting a new period.
+func Preview() Report {
+	runMu.Lock()
+	epsilon := epsilonOf(runCfg)
+	runMu.Unlock()
+	return active.report(time.Now(), epsilon, false)
+}
+
+// Apply starts, reschedules or stops sending reports for cfg. Reports are sent only when
+// enabled is set and an endpoint is configured.
+func Apply(cfg config.TelemetryConfig) {
+	runMu.Lock()
+	defer runMu.Unlock()
+	if runCancel != nil && cfg == runCfg {
+		return
+	}
+	if runCancel != nil {
+		runCancel()
+		runCancel = nil
+	}
+	runCfg = cfg
+	endpoint := strings.TrimSpace(cfg.Endpoint)
+	if !cfg.Enabled || endpoint == "" {
+		if cfg.Enabled {
+			log.Warnf("telemetry: enabled without endpoint; nothing is sent")
+		}
+		return
+	}
+	interval := defaultInterval
+	if cfg.IntervalHours > 0 {
+		interval = time.Duration(cfg.IntervalHours) * time.Hour
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	runCancel = cancel
+	go loop(ctx, endpoint, interval, epsilonOf(cfg))
+}
+
+// Stop stops sending reports.
+func Stop() {
+	Apply(config.TelemetryConfig{})
+}
+
+func loop(ctx context.Context, endpoint string, interval time.Duration, epsilon float64) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if errSend := send(ctx, endpoint, active.report(time.Now(), epsilon, true)); errSend != nil {
+			log.Debugf("telemetry: %v", errSend)
+		}
+	}
+}
+
+func send(ctx context.Context, endpoint string, report Report) error {
+	body, errMarshal := json.Marshal(report)
+	if errMarshal != nil {
+		return errMarshal
+	}
+	ctx, cancel := context.WithTimeout(ctx, sendTimeout)
+	defer cancel()
+	req, errRequest := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if errRequest != nil {
+		return errRequest
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, errDo := http.DefaultClient.Do(req)
+	if errDo != nil {
+		return errDo
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package telemetry
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+func TestReportAnonymizesProvidersAndClassifiesErrors(t *testing.T) {
+	start := time.Now().Add(-3 * time.Hour)
+	c := newCollector(start)
+	c.add(coreusage.Record{Provider: "claude"})
+	c.add(coreusage.Record{Provider: "claude", Failed: true, Fail: coreusage.Failure{StatusCode: 429}})
+	c.add(coreusage.Record{Provider: "my-private-upstream", Failed: true, Fail: coreusage.Failure{StatusCode: 503}})
+	c.add(coreusage.Record{Provider: "codex", Failed: true})
+
+	// A huge epsilon makes the noise negligible.
+	report := c.report(start.Add(3*time.Hour), 1e9, true)
+	if report.SchemaVersion != SchemaVersion || report.PeriodHours != 3 {
+		t.Fatalf("unexpected header: %+v", report)
+	}
+	if got := report.Providers["claude"]; got.Requests != 2 || got.Failed != 1 {
+		t.Fatalf("claude counts = %+v", got)
+	}
+	if _, leaked := report.Providers["my-private-upstream"]; leaked || report.Providers["other"].Requests != 1 {
+		t.Fatalf("custom provider must be reported as other: %+v", report.Providers)
+	}
+	want := map[string]int64{"rate_limit": 1, "auth": 0, "client": 0, "server": 1, "network": 1}
+	for class, count := range want {
+		if report.Errors[class] != count {
+			t.Fatalf("errors[%s] = %d, want %d", class, report.Errors[class], count)
+		}
+	}
+	if next := c.report(time.Now(), 1e9, false); len(next.Providers) != 0 {
+		t.Fatalf("report with reset must start a new period, got %+v", next.Providers)
+	}
+}
+
+func TestNoisyIsCenteredAndNonNegative(t *testing.T) {
+	var sum float64
+	const n = 20000
+	for range n {
+		v := noisy(100, 1)
+		if v < 0 {
+			t.Fatalf("negative count %d", v)
+		}
+		sum += float64(v)
+	}
+	if mean := sum / n; math.Abs(mean-100) > 0.5 {
+		t.Fatalf("mean = %.2f, want about 100", mean)
+	}
+}
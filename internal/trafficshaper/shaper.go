@@ -0,0 +1,223 @@
+// Package trafficshaper paces background requests (keep-alive pings, account warmers) so
+// subscription-based accounts see human-like traffic: randomized gaps between requests,
+// a per-auth daily cap, and an optional active-hours window.
+package trafficshaper
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultMinGap = 27 * time.Second
+	defaultMaxGap = 33 * time.Second
+)
+
+var (
+	// ErrDailyCap reports that an auth already sent its daily share of background requests.
+	ErrDailyCap = errors.New("daily cap reached")
+	// ErrOutsideActiveHours reports that background requests are paused at this time of day.
+	ErrOutsideActiveHours = errors.New("outside active hours")
+)
+
+// Settings are normalized shaping parameters.
+type Settings struct {
+	// Providers limits shaping to these providers; empty shapes everything.
+	Providers []string
+	MinGap    time.Duration
+	MaxGap    time.Duration
+	DailyCap  int
+	// ActiveFrom and ActiveTo are minutes after local midnight; equal values mean all day.
+	ActiveFrom int
+	ActiveTo   int
+}
+
+// DefaultSettings spaces consecutive requests 27-33 seconds apart without caps.
+func DefaultSettings() Settings {
+	return Settings{MinGap: defaultMinGap, MaxGap: defaultMaxGap}
+}
+
+// SettingsFromConfig normalizes cfg, falling back to DefaultSettings for disabled or invalid parts.
+func SettingsFromConfig(cfg config.KeepAliveShapingConfig) Settings {
+	settings := DefaultSettings()
+	if !cfg.Enabled {
+		return settings
+	}
+	for _, provider := range cfg.Providers {
+		if provider = strings.ToLower(strings.TrimSpace(provider)); provider != "" {
+			settings.Providers = append(settings.Providers, provider)
+		}
+	}
+	if gap, ok := parseGap("min-gap", cfg.MinGap); ok {
+		settings.MinGap = gap
+	}
+	if gap, ok := parseGap("max-gap", cfg.MaxGap); ok {
+		settings.MaxGap = gap
+	}
+	if settings.MaxGap < settings.MinGap {
+		settings.MaxGap = settings.MinGap
+	}
+	settings.DailyCap = max(cfg.DailyCap, 0)
+	if raw := strings.TrimSpace(cfg.ActiveHours); raw != "" {
+		from, to, errHours := parseActiveHours(raw)
+		if errHours != nil {
+			log.Warnf("keep-alive shaping: invalid active-hours %q: %v; running all day", raw, errHours)
+		} else {
+			settings.ActiveFrom, settings.ActiveTo = from, to
+		}
+	}
+	return settings
+}
+
+func parseGap(field, raw string) (time.Duration, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0, false
+	}
+	gap, errParse := time.ParseDuration(raw)
+	if errParse != nil || gap < 0 {
+		log.Warnf("keep-alive shaping: invalid %s %q; using default", field, raw)
+		return 0, false
+	}
+	return gap, true
+}
+
+// parseActiveHours parses "HH:MM-HH:MM" into minutes after midnight.
+func parseActiveHours(raw string) (int, int, error) {
+	rawFrom, rawTo, ok := strings.Cut(raw, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("expected HH:MM-HH:MM")
+	}
+	from, errFrom := time.Parse("15:04", strings.TrimSpace(rawFrom))
+	if errFrom != nil {
+		return 0, 0, errFrom
+	}
+	to, errTo := time.Parse("15:04", strings.TrimSpace(rawTo))
+	if errTo != nil {
+		return 0, 0, errTo
+	}
+	return from.Hour()*60 + from.Minute(), to.Hour()*60 + to.Minute(), nil
+}
+
+// Shaper serializes background requests across all auths. It is safe for concurrent use.
+type Shaper struct {
+	mu       sync.Mutex
+	settings Settings
+	rng      *rand.Rand
+	// next is the earliest time the following background request may start.
+	next time.Time
+	day  string
+	sent map[string]int
+
+	now func() time.Time
+}
+
+// New returns a Shaper using settings.
+func New(settings Settings) *Shaper {
+	return &Shaper{
+		settings: settings,
+		rng:      rand.New(rand.NewSource(time.Now().UnixNano())),
+		sent:     make(map[string]int),
+		now:      time.Now,
+	}
+}
+
+// Update swaps the settings while keeping today's counters and the pending gap.
+func (s *Shaper) Update(settings Settings) {
+	s.mu.Lock()
+	s.settings = settings
+	s.mu.Unlock()
+}
+
+// Applies reports whether requests for provider are shaped.
+func (s *Shaper) Applies(provider string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.settings.Providers) == 0 {
+		return true
+	}
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	for _, candidate := range s.settings.Providers {
+		if candidate == provider {
+			return true
+		}
+	}
+	return false
+}
+
+// Wait reserves a slot for one background request on authID and blocks until it starts.
+// It returns ErrDailyCap or ErrOutsideActiveHours without waiting when the request should be
+// skipped, and ctx.Err() when cancelled. An empty authID counts against a shared bucket.
+func (s *Shaper) Wait(ctx context.Context, authID string) error {
+	delay, errReserve := s.reserve(authID)
+	if errReserve != nil {
+		return errReserve
+	}
+	if delay <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+func (s *Shaper) reserve(authID string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	if !s.activeAt(now) {
+		return 0, ErrOutsideActiveHours
+	}
+	if day := now.Format(time.DateOnly); day != s.day {
+		s.day = day
+		clear(s.sent)
+	}
+	if s.settings.DailyCap > 0 && s.sent[authID] >= s.settings.DailyCap {
+		return 0, ErrDailyCap
+	}
+	s.sent[authID]++
+
+	start := now
+	if s.next.After(start) {
+		start = s.next
+	}
+	s.next = start.Add(s.gap())
+	return start.Sub(now), nil
+}
+
+// gap samples a pause between MinGap and MaxGap. Averaging two uniform draws gives a
+// triangular distribution, so pauses cluster around the midpoint rather than looking
+// evenly random.
+func (s *Shaper) gap() time.Duration {
+	spread := s.settings.MaxGap - s.settings.MinGap
+	if spread <= 0 {
+		return s.settings.MinGap
+	}
+	sample := (s.rng.Float64() + s.rng.Float64()) / 2
+	return s.settings.MinGap + time.Duration(sample*float64(spread))
+}
+
+func (s *Shaper) activeAt(t time.Time) bool {
+	from, to := s.settings.ActiveFrom, s.settings.ActiveTo
+	if from == to {
+		return true
+	}
+	minute := t.Hour()*60 + t.Minute()
+	if from < to {
+		return minute >= from && minute < to
+	}
+	return minute >= from || minute < to
+}
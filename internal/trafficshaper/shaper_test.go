@@ -0,0 +1,98 @@
+package trafficshaper
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestSettingsFromConfig(t *testing.T) {
+	if got := SettingsFromConfig(config.KeepAliveShapingConfig{MinGap: "1h"}); got.MinGap != defaultMinGap {
+		t.Fatalf("disabled config min gap = %s, want default", got.MinGap)
+	}
+	got := SettingsFromConfig(config.KeepAliveShapingConfig{
+		Enabled:     true,
+		Providers:   []string{" Grok ", ""},
+		MinGap:      "2m",
+		MaxGap:      "1m",
+		DailyCap:    -3,
+		ActiveHours: "22:00-06:30",
+	})
+	if len(got.Providers) != 1 || got.Providers[0] != "grok" {
+		t.Fatalf("providers = %v", got.Providers)
+	}
+	if got.MinGap != 2*time.Minute || got.MaxGap != 2*time.Minute || got.DailyCap != 0 {
+		t.Fatalf("settings = %+v", got)
+	}
+	if got.ActiveFrom != 22*60 || got.ActiveTo != 6*60+30 {
+		t.Fatalf("active hours = %d-%d", got.ActiveFrom, got.ActiveTo)
+	}
+	if bad := SettingsFromConfig(config.KeepAliveShapingConfig{Enabled: true, ActiveHours: "morning"}); bad.ActiveFrom != bad.ActiveTo {
+		t.Fatalf("invalid active hours should mean all day, got %d-%d", bad.ActiveFrom, bad.ActiveTo)
+	}
+}
+
+func TestShaperSpacesRequestsAndCapsPerAuth(t *testing.T) {
+	now := time.Date(2026, time.March, 6, 12, 0, 0, 0, time.Local)
+	s := New(Settings{MinGap: 10 * time.Second, MaxGap: 20 * time.Second, DailyCap: 2})
+	s.now = func() time.Time { return now }
+
+	first, err := s.reserve("a")
+	if err != nil || first != 0 {
+		t.Fatalf("first reserve = %s, %v; want immediate", first, err)
+	}
+	second, err := s.reserve("b")
+	if err != nil || second < 10*time.Second || second > 20*time.Second {
+		t.Fatalf("second reserve = %s, %v; want gap within bounds", second, err)
+	}
+	if _, err = s.reserve("a"); err != nil {
+		t.Fatalf("third reserve error = %v", err)
+	}
+	if _, err = s.reserve("a"); !errors.Is(err, ErrDailyCap) {
+		t.Fatalf("reserve over cap error = %v, want ErrDailyCap", err)
+	}
+
+	now = now.Add(24 * time.Hour)
+	if _, err = s.reserve("a"); err != nil {
+		t.Fatalf("reserve on next day error = %v", err)
+	}
+}
+
+func TestShaperActiveHours(t *testing.T) {
+	s := New(Settings{ActiveFrom: 22 * 60, ActiveTo: 6 * 60})
+	for hour, want := range map[int]bool{23: true, 3: true, 6: false, 12: false} {
+		at := time.Date(2026, time.March, 6, hour, 0, 0, 0, time.Local)
+		if got := s.activeAt(at); got != want {
+			t.Fatalf("activeAt(%02d:00) = %t, want %t", hour, got, want)
+		}
+	}
+	s.now = func() time.Time { return time.Date(2026, time.March, 6, 12, 0, 0, 0, time.Local) }
+	if err := s.Wait(context.Background(), "a"); !errors.Is(err, ErrOutsideActiveHours) {
+		t.Fatalf("Wait error = %v, want ErrOutsideActiveHours", err)
+	}
+}
+
+func TestShaperApplies(t *testing.T) {
+	if !New(DefaultSettings()).Applies("anything") {
+		t.Fatal("default settings should shape every provider")
+	}
+	s := New(Settings{Providers: []string{"copilot"}})
+	if !s.Applies("Copilot") || s.Applies("gemini") {
+		t.Fatal("provider filter not honored")
+	}
+}
+
+func TestShaperWaitHonorsCancellation(t *testing.T) {
+	s := New(Settings{MinGap: time.Hour, MaxGap: time.Hour})
+	if err := s.Wait(context.Background(), "a"); err != nil {
+		t.Fatalf("first Wait error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := s.Wait(ctx, "a"); !errors.Is(err, context.Canceled) {
+		t.Fatalf("Wait error = %v, want context.Canceled", err)
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/translator/gemini/common"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
@@ -363,6 +364,10 @@ func ConvertGeminiRequestToClaude(modelName string, inputRawJSON []byte, stream
 		var anthropicTools []interface{}
 
 		tools.ForEach(func(_, tool gjson.Result) bool {
+			// Google Search grounding maps to Claude's server-side web search tool.
+			if common.IsGoogleSearchTool(tool) {
+				anthropicTools = append(anthropicTools, map[string]interface{}{"type": "web_search_20250305", "name": "web_search"})
+			}
 			if funcDecls := tool.Get("functionDeclarations"); funcDecls.Exists() && funcDecls.IsArray() {
 				funcDecls.ForEach(func(_, funcDecl gjson.Result) bool {
 					anthropicTool := []byte(`{"name":"","description":"","input_schema":{}}`)
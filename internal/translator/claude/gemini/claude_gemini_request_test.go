@@ -112,3 +112,12 @@ func TestConvertGeminiRequestToClaude_SplitsNonImageInlineDataByMIME(t *testing.
 		t.Fatalf("non-image inlineData must not be converted to image. Output: %s", string(out))
 	}
 }
+
+func TestConvertGeminiRequestToClaude_MapsGoogleSearchToWebSearch(t *testing.T) {
+	out := ConvertGeminiRequestToClaude("claude-sonnet-4", []byte(`{"contents":[{"role":"user","parts":[{"text":"news?"}]}],"tools":[{"googleSearch":{}}]}`), false)
+
+	tool := gjson.GetBytes(out, "tools.0")
+	if tool.Get("type").String() != "web_search_20250305" || tool.Get("name").String() != "web_search" {
+		t.Fatalf("tools = %s, want web_search server tool", gjson.GetBytes(out, "tools").Raw)
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/google/uuid"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/common"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
@@ -115,19 +116,6 @@ func ConvertOpenAIRequestToClaude(modelName string, inputRawJSON []byte, stream
 		}
 	}
 
-	// Helper for generating tool call IDs in the form: toolu_<alphanum>
-	// This ensures unique identifiers for tool calls in the Claude Code format
-	genToolCallID := func() string {
-		const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
-		var b strings.Builder
-		// 24 chars random suffix for uniqueness
-		for i := 0; i < 24; i++ {
-			n, _ := rand.Int(rand.Reader, big.NewInt(int64(len(letters))))
-			b.WriteByte(letters[n.Int64()])
-		}
-		return "toolu_" + b.String()
-	}
-
 	// Model mapping to specify which Claude Code model to use
 	out, _ = sjson.SetBytes(out, "model", modelName)
 
@@ -162,116 +150,25 @@ func ConvertOpenAIRequestToClaude(modelName string, inputRawJSON []byte, stream
 
 	// Process messages and transform them to Claude Code format
 	if messages := root.Get("messages"); messages.Exists() && messages.IsArray() {
-		messageIndex := 0
-		messages.ForEach(func(_, message gjson.Result) bool {
-			role := message.Get("role").String()
-			contentResult := message.Get("content")
-
-			switch role {
-			case "system":
-				if contentResult.Exists() && contentResult.Type == gjson.String && contentResult.String() != "" {
-					textPart := []byte(`{"type":"text","text":""}`)
-					textPart, _ = sjson.SetBytes(textPart, "text", contentResult.String())
-					out, _ = sjson.SetRawBytes(out, "system.-1", textPart)
-				} else if contentResult.Exists() && contentResult.IsArray() {
-					contentResult.ForEach(func(_, part gjson.Result) bool {
-						if part.Get("type").String() == "text" {
-							textPart := []byte(`{"type":"text","text":""}`)
-							textPart, _ = sjson.SetBytes(textPart, "text", part.Get("text").String())
-							out, _ = sjson.SetRawBytes(out, "system.-1", textPart)
-						}
-						return true
-					})
-				}
-			case "user", "assistant":
-				msg := []byte(`{"role":"","content":[]}`)
-				msg, _ = sjson.SetBytes(msg, "role", role)
-
-				// Handle content based on its type (string or array)
-				if contentResult.Exists() && contentResult.Type == gjson.String && contentResult.String() != "" {
-					part := []byte(`{"type":"text","text":""}`)
-					part, _ = sjson.SetBytes(part, "text", contentResult.String())
-					msg, _ = sjson.SetRawBytes(msg, "content.-1", part)
-				} else if contentResult.Exists() && contentResult.IsArray() {
-					contentResult.ForEach(func(_, part gjson.Result) bool {
-						claudePart := convertOpenAIContentPartToClaudePart(part)
-						if claudePart != "" {
-							msg, _ = sjson.SetRawBytes(msg, "content.-1", []byte(claudePart))
-						}
-						return true
-					})
-				}
-
-				// Handle tool calls (for assistant messages)
-				if toolCalls := message.Get("tool_calls"); toolCalls.Exists() && toolCalls.IsArray() && role == "assistant" {
-					toolCalls.ForEach(func(_, toolCall gjson.Result) bool {
-						if toolCall.Get("type").String() == "function" {
-							toolCallID := toolCall.Get("id").String()
-							if toolCallID == "" {
-								toolCallID = genToolCallID()
-							}
-							toolCallID = util.SanitizeClaudeToolID(toolCallID)
-
-							function := toolCall.Get("function")
-							toolUse := []byte(`{"type":"tool_use","id":"","name":"","input":{}}`)
-							toolUse, _ = sjson.SetBytes(toolUse, "id", toolCallID)
-							toolUse, _ = sjson.SetBytes(toolUse, "name", function.Get("name").String())
-
-							// Parse arguments for the tool call
-							if args := function.Get("arguments"); args.Exists() {
-								argsStr := args.String()
-								if argsStr != "" && gjson.Valid(argsStr) {
-									argsJSON := gjson.Parse(argsStr)
-									if argsJSON.IsObject() {
-										toolUse, _ = sjson.SetRawBytes(toolUse, "input", []byte(argsJSON.Raw))
-									} else {
-										toolUse, _ = sjson.SetRawBytes(toolUse, "input", []byte("{}"))
-									}
-								} else {
-									toolUse, _ = sjson.SetRawBytes(toolUse, "input", []byte("{}"))
-								}
-							} else {
-								toolUse, _ = sjson.SetRawBytes(toolUse, "input", []byte("{}"))
-							}
-
-							msg, _ = sjson.SetRawBytes(msg, "content.-1", toolUse)
-						}
-						return true
-					})
-				}
-
-				out, _ = sjson.SetRawBytes(out, "messages.-1", msg)
-				messageIndex++
-
-			case "tool":
-				// Handle tool result messages conversion
-				toolCallID := message.Get("tool_call_id").String()
-				toolCallID = util.SanitizeClaudeToolID(toolCallID)
-				toolContentResult := message.Get("content")
-
-				msg := []byte(`{"role":"user","content":[{"type":"tool_result","tool_use_id":"","content":""}]}`)
-				msg, _ = sjson.SetBytes(msg, "content.0.tool_use_id", toolCallID)
-				toolResultContent, toolResultContentRaw := convertOpenAIToolResultContent(toolContentResult)
-				if toolResultContentRaw {
-					msg, _ = sjson.SetRawBytes(msg, "content.0.content", []byte(toolResultContent))
-				} else {
-					msg, _ = sjson.SetBytes(msg, "content.0.content", toolResultContent)
-				}
-				out, _ = sjson.SetRawBytes(out, "messages.-1", msg)
-				messageIndex++
+		// Messages convert independently, so long histories are converted in parallel.
+		// Developer and repeated system messages follow the configured system message policy.
+		var systemParts, claudeMessages [][]byte
+		for _, converted := range translatorcommon.MapMessages(translatorcommon.NormalizeSystemMessages(messages.Array()), convertOpenAIMessageToClaude) {
+			systemParts = append(systemParts, converted.system...)
+			if converted.message != nil {
+				claudeMessages = append(claudeMessages, converted.message)
 			}
-			return true
-		})
+		}
+		if len(systemParts) > 0 {
+			out, _ = sjson.SetRawBytes(out, "system", translatorcommon.JoinRawArray(systemParts))
+		}
 
 		// Preserve a minimal conversational turn for system-only inputs.
 		// Claude payloads with top-level system instructions but no messages are risky for downstream validation.
-		if messageIndex == 0 {
-			system := gjson.GetBytes(out, "system")
-			if system.Exists() && system.IsArray() && len(system.Array()) > 0 {
-				fallbackMsg := []byte(`{"role":"user","content":[{"type":"text","text":""}]}`)
-				out, _ = sjson.SetRawBytes(out, "messages.-1", fallbackMsg)
-			}
+		if len(claudeMessages) == 0 && len(systemParts) > 0 {
+			claudeMessages = append(claudeMessages, []byte(`{"role":"user","content":[{"type":"text","text":""}]}`))
 		}
+		out, _ = sjson.SetRawBytes(out, "messages", translatorcommon.JoinRawArray(claudeMessages))
 	}
 
 	// Tools mapping: OpenAI tools -> Claude Code tools
@@ -330,6 +227,126 @@ func ConvertOpenAIRequestToClaude(modelName string, inputRawJSON []byte, stream
 	return out
 }
 
+// claudeConvertedMessage is what a single OpenAI message contributes to a Claude request.
+type claudeConvertedMessage struct {
+	// system holds text parts for the top-level system array.
+	system [][]byte
+	// message is the converted Claude message, or nil when the message maps to none.
+	message []byte
+}
+
+// convertOpenAIMessageToClaude converts one OpenAI Chat Completions message.
+func convertOpenAIMessageToClaude(message gjson.Result) claudeConvertedMessage {
+	var converted claudeConvertedMessage
+	role := message.Get("role").String()
+	contentResult := message.Get("content")
+
+	switch role {
+	case "system":
+		if contentResult.Exists() && contentResult.Type == gjson.String && contentResult.String() != "" {
+			textPart := []byte(`{"type":"text","text":""}`)
+			textPart, _ = sjson.SetBytes(textPart, "text", contentResult.String())
+			converted.system = append(converted.system, textPart)
+		} else if contentResult.Exists() && contentResult.IsArray() {
+			contentResult.ForEach(func(_, part gjson.Result) bool {
+				if part.Get("type").String() == "text" {
+					textPart := []byte(`{"type":"text","text":""}`)
+					textPart, _ = sjson.SetBytes(textPart, "text", part.Get("text").String())
+					converted.system = append(converted.system, textPart)
+				}
+				return true
+			})
+		}
+	case "user", "assistant":
+		msg := []byte(`{"role":"","content":[]}`)
+		msg, _ = sjson.SetBytes(msg, "role", role)
+
+		// Handle content based on its type (string or array)
+		if contentResult.Exists() && contentResult.Type == gjson.String && contentResult.String() != "" {
+			part := []byte(`{"type":"text","text":""}`)
+			part, _ = sjson.SetBytes(part, "text", contentResult.String())
+			msg, _ = sjson.SetRawBytes(msg, "content.-1", part)
+		} else if contentResult.Exists() && contentResult.IsArray() {
+			contentResult.ForEach(func(_, part gjson.Result) bool {
+				claudePart := convertOpenAIContentPartToClaudePart(part)
+				if claudePart != "" {
+					msg, _ = sjson.SetRawBytes(msg, "content.-1", []byte(claudePart))
+				}
+				return true
+			})
+		}
+
+		// Handle tool calls (for assistant messages)
+		if toolCalls := message.Get("tool_calls"); toolCalls.Exists() && toolCalls.IsArray() && role == "assistant" {
+			toolCalls.ForEach(func(_, toolCall gjson.Result) bool {
+				if toolCall.Get("type").String() == "function" {
+					toolCallID := toolCall.Get("id").String()
+					if toolCallID == "" {
+						toolCallID = genClaudeToolCallID()
+					}
+					toolCallID = util.SanitizeClaudeToolID(toolCallID)
+
+					function := toolCall.Get("function")
+					toolUse := []byte(`{"type":"tool_use","id":"","name":"","input":{}}`)
+					toolUse, _ = sjson.SetBytes(toolUse, "id", toolCallID)
+					toolUse, _ = sjson.SetBytes(toolUse, "name", function.Get("name").String())
+
+					// Parse arguments for the tool call
+					if args := function.Get("arguments"); args.Exists() {
+						argsStr := args.String()
+						if argsStr != "" && gjson.Valid(argsStr) {
+							argsJSON := gjson.Parse(argsStr)
+							if argsJSON.IsObject() {
+								toolUse, _ = sjson.SetRawBytes(toolUse, "input", []byte(argsJSON.Raw))
+							} else {
+								toolUse, _ = sjson.SetRawBytes(toolUse, "input", []byte("{}"))
+							}
+						} else {
+							toolUse, _ = sjson.SetRawBytes(toolUse, "input", []byte("{}"))
+						}
+					} else {
+						toolUse, _ = sjson.SetRawBytes(toolUse, "input", []byte("{}"))
+					}
+
+					msg, _ = sjson.SetRawBytes(msg, "content.-1", toolUse)
+				}
+				return true
+			})
+		}
+
+		converted.message = msg
+
+	case "tool":
+		// Handle tool result messages conversion
+		toolCallID := message.Get("tool_call_id").String()
+		toolCallID = util.SanitizeClaudeToolID(toolCallID)
+		toolContentResult := message.Get("content")
+
+		msg := []byte(`{"role":"user","content":[{"type":"tool_result","tool_use_id":"","content":""}]}`)
+		msg, _ = sjson.SetBytes(msg, "content.0.tool_use_id", toolCallID)
+		toolResultContent, toolResultContentRaw := convertOpenAIToolResultContent(toolContentResult)
+		if toolResultContentRaw {
+			msg, _ = sjson.SetRawBytes(msg, "content.0.content", []byte(toolResultContent))
+		} else {
+			msg, _ = sjson.SetBytes(msg, "content.0.content", toolResultContent)
+		}
+		converted.message = msg
+	}
+	return converted
+}
+
+// genClaudeToolCallID generates a tool call ID in the form toolu_<alphanum>, as used by Claude Code.
+func genClaudeToolCallID() string {
+	const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	var b strings.Builder
+	// 24 chars random suffix for uniqueness
+	for i := 0; i < 24; i++ {
+		n, _ := rand.Int(rand.Reader, big.NewInt(int64(len(letters))))
+		b.WriteByte(letters[n.Int64()])
+	}
+	return "toolu_" + b.String()
+}
+
 func convertOpenAIContentPartToClaudePart(part gjson.Result) string {
 	switch part.Get("type").String() {
 	case "text":
@@ -411,7 +428,7 @@ func convertOpenAIToolResultContent(content gjson.Result) (string, bool) {
 
 			claudePart := convertOpenAIContentPartToClaudePart(part)
 			if claudePart != "" {
-				claudeContent, _ = sjson.SetRawBytes(claudeContent, "-1", []byte(claudePart))
+				claudeContent, _ = sjson.SetRawBytes(claudeContent, "-1", util.LimitClaudeToolResultImage([]byte(claudePart)))
 				partCount++
 			}
 			return true
@@ -428,7 +445,7 @@ func convertOpenAIToolResultContent(content gjson.Result) (string, bool) {
 		claudePart := convertOpenAIContentPartToClaudePart(content)
 		if claudePart != "" {
 			claudeContent := []byte("[]")
-			claudeContent, _ = sjson.SetRawBytes(claudeContent, "-1", []byte(claudePart))
+			claudeContent, _ = sjson.SetRawBytes(claudeContent, "-1", util.LimitClaudeToolResultImage([]byte(claudePart)))
 			return string(claudeContent), true
 		}
 		return content.Raw, false
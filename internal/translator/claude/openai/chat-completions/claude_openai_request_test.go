@@ -3,6 +3,7 @@ package chat_completions
 import (
 	"testing"
 
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/common"
 	"github.com/tidwall/gjson"
 )
 
@@ -269,6 +270,38 @@ func TestConvertOpenAIRequestToClaude_MultipleSystemMessagesMergedIntoTopLevelSy
 	}
 }
 
+func TestConvertOpenAIRequestToClaude_DeveloperAndLateSystemFollowPolicy(t *testing.T) {
+	inputJSON := `{
+		"model": "gpt-4.1",
+		"messages": [
+			{"role": "developer", "content": "Rule 1"},
+			{"role": "user", "content": "Hello"},
+			{"role": "system", "content": "Rule 2"}
+		]
+	}`
+
+	translatorcommon.SetSystemMessagePolicy(translatorcommon.SystemMessagePolicyRoleDowngrade)
+	defer translatorcommon.SetSystemMessagePolicy(translatorcommon.SystemMessagePolicyConcatenate)
+
+	result := ConvertOpenAIRequestToClaude("claude-sonnet-4-5", []byte(inputJSON), false)
+	resultJSON := gjson.ParseBytes(result)
+
+	system := resultJSON.Get("system").Array()
+	if len(system) != 1 || system[0].Get("text").String() != "Rule 1" {
+		t.Fatalf("Expected developer message as the only system block, got %s", resultJSON.Get("system").Raw)
+	}
+	messages := resultJSON.Get("messages").Array()
+	if len(messages) != 2 {
+		t.Fatalf("Expected 2 messages, got %d. Messages: %s", len(messages), resultJSON.Get("messages").Raw)
+	}
+	if got := messages[1].Get("role").String(); got != "user" {
+		t.Fatalf("Expected downgraded system message role %q, got %q", "user", got)
+	}
+	if got := messages[1].Get("content.0.text").String(); got != "Rule 2" {
+		t.Fatalf("Expected downgraded system text %q, got %q", "Rule 2", got)
+	}
+}
+
 func TestConvertOpenAIRequestToClaude_SystemOnlyInputKeepsFallbackUserMessage(t *testing.T) {
 	inputJSON := `{
 		"model": "gpt-4.1",
@@ -12,6 +12,7 @@ import (
 	"strings"
 	"time"
 
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/common"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -30,6 +31,8 @@ type ConvertAnthropicResponseToOpenAIParams struct {
 	ToolCallsAccumulator map[int]*ToolCallAccumulator
 	ToolCallOrdinals     map[int]int
 	NextToolCallOrdinal  int
+	// Citations positions text block citations, reported as annotations with the finish reason
+	Citations translatorcommon.CitationTracker
 }
 
 type claudeUsageTokens struct {
@@ -150,6 +153,14 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 		if contentBlock := root.Get("content_block"); contentBlock.Exists() {
 			blockType := contentBlock.Get("type").String()
 
+			if blockType == "text" {
+				(*param).(*ConvertAnthropicResponseToOpenAIParams).Citations.StartBlock()
+				for _, citation := range contentBlock.Get("citations").Array() {
+					(*param).(*ConvertAnthropicResponseToOpenAIParams).Citations.AddClaudeCitation(citation)
+				}
+				return [][]byte{}
+			}
+
 			if blockType == "tool_use" {
 				// Start of tool call - initialize accumulator to track arguments
 				toolCallID := contentBlock.Get("id").String()
@@ -189,8 +200,11 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 				// Text content delta - send incremental text updates
 				if text := delta.Get("text"); text.Exists() {
 					template, _ = sjson.SetBytes(template, "choices.0.delta.content", text.String())
+					(*param).(*ConvertAnthropicResponseToOpenAIParams).Citations.AddText(text.String())
 					hasContent = true
 				}
+			case "citations_delta":
+				(*param).(*ConvertAnthropicResponseToOpenAIParams).Citations.AddClaudeCitation(delta.Get("citation"))
 			case "thinking_delta":
 				// Accumulate reasoning/thinking content
 				if thinking := delta.Get("thinking"); thinking.Exists() {
@@ -220,6 +234,7 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 	case "content_block_stop":
 		// End of content block - output complete tool call if it's a tool_use block
 		index := int(root.Get("index").Int())
+		(*param).(*ConvertAnthropicResponseToOpenAIParams).Citations.EndBlock()
 		if (*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator != nil {
 			if accumulator, exists := (*param).(*ConvertAnthropicResponseToOpenAIParams).ToolCallsAccumulator[index]; exists {
 				// Build complete tool call with accumulated arguments
@@ -252,6 +267,9 @@ func ConvertClaudeResponseToOpenAI(_ context.Context, modelName string, original
 			if stopReason := delta.Get("stop_reason"); stopReason.Exists() {
 				(*param).(*ConvertAnthropicResponseToOpenAIParams).FinishReason = mapAnthropicStopReasonToOpenAI(stopReason.String())
 				template, _ = sjson.SetBytes(template, "choices.0.finish_reason", (*param).(*ConvertAnthropicResponseToOpenAIParams).FinishReason)
+				if annotations := (*param).(*ConvertAnthropicResponseToOpenAIParams).Citations.Annotations(); annotations != nil {
+					template, _ = sjson.SetRawBytes(template, "choices.0.delta.annotations", annotations)
+				}
 			}
 		}
 
@@ -341,6 +359,7 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 	var reasoningParts []string
 	usageTokens := claudeUsageTokens{}
 	toolCallsAccumulator := make(map[int]*ToolCallAccumulator)
+	var citations translatorcommon.CitationTracker
 
 	for _, chunk := range chunks {
 		root := gjson.ParseBytes(chunk)
@@ -360,7 +379,12 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 			// Handle different content block types at the beginning
 			if contentBlock := root.Get("content_block"); contentBlock.Exists() {
 				blockType := contentBlock.Get("type").String()
-				if blockType == "thinking" {
+				if blockType == "text" {
+					citations.StartBlock()
+					for _, citation := range contentBlock.Get("citations").Array() {
+						citations.AddClaudeCitation(citation)
+					}
+				} else if blockType == "thinking" {
 					// Start of thinking/reasoning content - skip for now as it's handled in delta
 					continue
 				} else if blockType == "tool_use" {
@@ -382,7 +406,10 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 					// Accumulate text content
 					if text := delta.Get("text"); text.Exists() {
 						contentParts = append(contentParts, text.String())
+						citations.AddText(text.String())
 					}
+				case "citations_delta":
+					citations.AddClaudeCitation(delta.Get("citation"))
 				case "thinking_delta":
 					// Accumulate reasoning/thinking content
 					if thinking := delta.Get("thinking"); thinking.Exists() {
@@ -402,6 +429,7 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 		case "content_block_stop":
 			// Finalize tool call arguments for this index when content block ends
 			index := int(root.Get("index").Int())
+			citations.EndBlock()
 			if accumulator, exists := toolCallsAccumulator[index]; exists {
 				if accumulator.Arguments.Len() == 0 {
 					accumulator.Arguments.WriteString("{}")
@@ -437,6 +465,9 @@ func ConvertClaudeResponseToOpenAINonStream(_ context.Context, _ string, origina
 	// Set message content by combining all text parts
 	messageContent := strings.Join(contentParts, "")
 	out, _ = sjson.SetBytes(out, "choices.0.message.content", messageContent)
+	if annotations := citations.Annotations(); annotations != nil {
+		out, _ = sjson.SetRawBytes(out, "choices.0.message.annotations", annotations)
+	}
 
 	// Add reasoning content if available (following OpenAI reasoning format)
 	if len(reasoningParts) > 0 {
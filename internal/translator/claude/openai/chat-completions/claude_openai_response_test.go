@@ -2,6 +2,7 @@ package chat_completions
 
 import (
 	"context"
+	"strings"
 	"testing"
 
 	"github.com/tidwall/gjson"
@@ -139,3 +140,39 @@ func TestConvertClaudeResponseToOpenAINonStream_UsageMergesMessageStartUsage(t *
 		t.Fatalf("expected cached_tokens %d, got %d", 22000, gotCachedTokens)
 	}
 }
+
+func TestConvertClaudeResponseToOpenAI_CitationsBecomeURLAnnotations(t *testing.T) {
+	events := []string{
+		`data: {"type":"message_start","message":{"id":"msg_123","model":"claude-opus-4-6"}}`,
+		`data: {"type":"content_block_start","index":0,"content_block":{"type":"text","text":""}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"text_delta","text":"Intro. "}}`,
+		`data: {"type":"content_block_stop","index":0}`,
+		`data: {"type":"content_block_start","index":1,"content_block":{"type":"text","text":"","citations":[]}}`,
+		`data: {"type":"content_block_delta","index":1,"delta":{"type":"citations_delta","citation":{"type":"web_search_result_location","url":"https://go.dev","title":"Go","cited_text":"Go 1.26"}}}`,
+		`data: {"type":"content_block_delta","index":1,"delta":{"type":"citations_delta","citation":{"type":"char_location","document_index":0,"cited_text":"x"}}}`,
+		`data: {"type":"content_block_delta","index":1,"delta":{"type":"text_delta","text":"Go 1.26 is out."}}`,
+		`data: {"type":"content_block_stop","index":1}`,
+		`data: {"type":"message_delta","delta":{"stop_reason":"end_turn"},"usage":{"output_tokens":4}}`,
+	}
+
+	var param any
+	var final []byte
+	for _, event := range events {
+		for _, chunk := range ConvertClaudeResponseToOpenAI(context.Background(), "claude-opus-4-6", nil, nil, []byte(event), &param) {
+			final = chunk
+		}
+	}
+	annotations := gjson.GetBytes(final, "choices.0.delta.annotations").Array()
+	if len(annotations) != 1 {
+		t.Fatalf("streamed annotations = %s, want 1 url citation", gjson.GetBytes(final, "choices.0.delta.annotations").Raw)
+	}
+	citation := annotations[0].Get("url_citation")
+	if citation.Get("url").String() != "https://go.dev" || citation.Get("start_index").Int() != 7 || citation.Get("end_index").Int() != 22 {
+		t.Fatalf("streamed annotation = %s", annotations[0].Raw)
+	}
+
+	out := ConvertClaudeResponseToOpenAINonStream(context.Background(), "", nil, nil, []byte(strings.Join(events, "\n")), nil)
+	if got := gjson.GetBytes(out, "choices.0.message.annotations.0.url_citation.end_index").Int(); got != 22 {
+		t.Fatalf("non-stream annotations = %s", gjson.GetBytes(out, "choices.0.message.annotations").Raw)
+	}
+}
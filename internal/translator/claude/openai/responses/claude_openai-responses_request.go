@@ -12,7 +12,9 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
 	sigcompat "github.com/router-for-me/CLIProxyAPI/v7/internal/signature"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/common"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	log "github.com/sirupsen/logrus"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -468,6 +470,14 @@ func ConvertOpenAIResponsesRequestToClaude(modelName string, inputRawJSON []byte
 					toolChoiceJSON, _ = sjson.SetBytes(toolChoiceJSON, "name", fn)
 					out, _ = sjson.SetRawBytes(out, "tool_choice", toolChoiceJSON)
 				}
+			} else if translatorcommon.IsResponsesWebSearchTool(toolChoice.Get("type").String()) {
+				if _, ok := includedToolNames[claudeWebSearchToolName]; ok {
+					out, _ = sjson.SetRawBytes(out, "tool_choice", []byte(`{"name":"web_search","type":"tool"}`))
+				}
+			} else if toolChoice.Get("type").String() == "computer_use_preview" {
+				if _, ok := includedToolNames["computer"]; ok {
+					out, _ = sjson.SetRawBytes(out, "tool_choice", []byte(`{"name":"computer","type":"tool"}`))
+				}
 			}
 		default:
 
@@ -512,6 +522,7 @@ func applyResponsesToolResultContent(toolResult []byte, output gjson.Result) []b
 		hasFile := false
 		output.ForEach(func(_, part gjson.Result) bool {
 			if partJSON := convertResponsesContentPartToClaude(part); len(partJSON) > 0 {
+				partJSON = util.LimitClaudeToolResultImage(partJSON)
 				partsJSON = append(partsJSON, string(partJSON))
 				partType := gjson.ParseBytes(partJSON).Get("type").String()
 				if partType == "image" {
@@ -619,18 +630,21 @@ func convertResponsesToolToClaudeTools(tool gjson.Result, toolNameMap map[string
 		}
 	case "namespace":
 		return convertResponsesNamespaceToolToClaude(tool, toolNameMap)
-	case "web_search":
+	case "web_search", "web_search_2025_08_26", "web_search_preview", "web_search_preview_2025_03_11":
 		if tJSON, ok := convertResponsesWebSearchToolToClaude(tool); ok {
 			if name := gjson.GetBytes(tJSON, "name").String(); name != "" {
 				toolNameMap[name] = name
 			}
 			return [][]byte{tJSON}
 		}
+	case "computer_use_preview":
+		return [][]byte{convertResponsesComputerToolToClaude(tool)}
 	default:
 		if isOpenAIResponsesApplyPatchCustomTool(toolType, tool) {
 			return nil
 		}
-		if isUnsupportedOpenAIBuiltinToolType(toolType) {
+		if translatorcommon.IsUnsupportedResponsesBuiltinTool(toolType) {
+			log.Warnf("claude responses translator: dropping built-in tool %s, which Claude does not support", toolType)
 			return nil
 		}
 		if tool.Get("name").String() != "" {
@@ -708,6 +722,20 @@ func convertResponsesWebSearchToolToClaude(tool gjson.Result) ([]byte, bool) {
 	return tJSON, true
 }
 
+// convertResponsesComputerToolToClaude maps the OpenAI computer_use_preview tool to Claude's
+// computer tool. Claude's action schema differs from OpenAI's, so calls surface as ordinary
+// "computer" function calls; the executor attaches the computer-use beta if the key allows it.
+func convertResponsesComputerToolToClaude(tool gjson.Result) []byte {
+	tJSON := []byte(`{"type":"computer_20250124","name":"computer","display_width_px":1024,"display_height_px":768}`)
+	if width := tool.Get("display_width"); width.Exists() && width.Int() > 0 {
+		tJSON, _ = sjson.SetBytes(tJSON, "display_width_px", width.Int())
+	}
+	if height := tool.Get("display_height"); height.Exists() && height.Int() > 0 {
+		tJSON, _ = sjson.SetBytes(tJSON, "display_height_px", height.Int())
+	}
+	return tJSON
+}
+
 func responsesToolName(tool gjson.Result) string {
 	if name := strings.TrimSpace(tool.Get("name").String()); name != "" {
 		return name
@@ -816,12 +844,3 @@ func splitResponsesQualifiedFunctionCallFromRequest(requestRawJSON []byte, quali
 	}
 	return bestChild, bestNamespace
 }
-
-func isUnsupportedOpenAIBuiltinToolType(toolType string) bool {
-	switch toolType {
-	case "image_generation", "file_search", "code_interpreter", "computer_use_preview":
-		return true
-	default:
-		return false
-	}
-}
@@ -321,3 +321,25 @@ func testGPTResponsesReasoningSignature() string {
 	}
 	return base64.URLEncoding.EncodeToString(payload)
 }
+
+func TestConvertOpenAIResponsesRequestToClaude_MapsComputerUsePreviewTool(t *testing.T) {
+	inputJSON := `{
+		"model": "gpt-4.1",
+		"input": "open the settings page",
+		"tools": [{"type": "computer_use_preview", "display_width": 1280, "display_height": 800, "environment": "browser"}],
+		"tool_choice": {"type": "computer_use_preview"}
+	}`
+
+	result := gjson.ParseBytes(ConvertOpenAIResponsesRequestToClaude("claude-sonnet-4-5", []byte(inputJSON), false))
+
+	tool := result.Get("tools.0")
+	if tool.Get("type").String() != "computer_20250124" || tool.Get("name").String() != "computer" {
+		t.Fatalf("tool = %s, want computer_20250124 named computer", tool.Raw)
+	}
+	if tool.Get("display_width_px").Int() != 1280 || tool.Get("display_height_px").Int() != 800 {
+		t.Fatalf("tool display = %s, want 1280x800", tool.Raw)
+	}
+	if result.Get("tool_choice.name").String() != "computer" {
+		t.Fatalf("tool_choice = %s, want computer", result.Get("tool_choice").Raw)
+	}
+}
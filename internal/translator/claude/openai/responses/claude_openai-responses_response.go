@@ -28,9 +28,9 @@ type claudeToResponsesState struct {
 	FuncNames   map[int]string // index -> function name
 	FuncCallIDs map[int]string // index -> call id
 	// message text aggregation
-	TextBuf            strings.Builder
-	CurrentTextBuf     strings.Builder
-	MessageAnnotations []any
+	TextBuf        strings.Builder
+	CurrentTextBuf strings.Builder
+	Citations      translatorcommon.CitationTracker
 	// reasoning state
 	ReasoningActive    bool
 	ReasoningItemID    string
@@ -38,6 +38,14 @@ type claudeToResponsesState struct {
 	ReasoningSignature string
 	ReasoningPartAdded bool
 	ReasoningIndex     int
+	// ReasoningItems holds every finished reasoning output item in block order.
+	ReasoningItems [][]byte
+	ReasoningChars int
+	// server-side web search state
+	WebSearchID    string                      // server_tool_use block whose input is streaming
+	WebSearchInput map[string]*strings.Builder // tool_use_id -> input JSON
+	WebSearchIndex map[string]int              // tool_use_id -> output_index of its web_search_call item
+	WebSearchItems [][]byte
 	// usage aggregation
 	Usage claudeResponsesUsageTokens
 }
@@ -117,11 +125,14 @@ func noSSEOutput(out [][]byte) [][]byte {
 	return out
 }
 
-func (st *claudeToResponsesState) appendMessageAnnotation(annotation any) {
-	if annotation == nil {
-		return
+// messageAnnotations returns the citations of the assistant message as Responses annotations,
+// or nil when it cites nothing.
+func (st *claudeToResponsesState) messageAnnotations() []byte {
+	annotations := st.Citations.Annotations()
+	if annotations == nil {
+		return nil
 	}
-	st.MessageAnnotations = append(st.MessageAnnotations, annotation)
+	return translatorcommon.ResponsesAnnotations(annotations)
 }
 
 func (st *claudeToResponsesState) finalizeAssistantMessage(nextSeq func() int) [][]byte {
@@ -140,8 +151,8 @@ func (st *claudeToResponsesState) finalizeAssistantMessage(nextSeq func() int) [
 	partDone, _ = sjson.SetBytes(partDone, "sequence_number", nextSeq())
 	partDone, _ = sjson.SetBytes(partDone, "item_id", st.CurrentMsgID)
 	partDone, _ = sjson.SetBytes(partDone, "part.text", fullText)
-	if len(st.MessageAnnotations) > 0 {
-		partDone, _ = sjson.SetBytes(partDone, "part.annotations", st.MessageAnnotations)
+	if annotations := st.messageAnnotations(); annotations != nil {
+		partDone, _ = sjson.SetRawBytes(partDone, "part.annotations", annotations)
 	}
 	out = append(out, emitEvent("response.content_part.done", partDone))
 
@@ -149,8 +160,8 @@ func (st *claudeToResponsesState) finalizeAssistantMessage(nextSeq func() int) [
 	final, _ = sjson.SetBytes(final, "sequence_number", nextSeq())
 	final, _ = sjson.SetBytes(final, "item.id", st.CurrentMsgID)
 	final, _ = sjson.SetBytes(final, "item.content.0.text", fullText)
-	if len(st.MessageAnnotations) > 0 {
-		final, _ = sjson.SetBytes(final, "item.content.0.annotations", st.MessageAnnotations)
+	if annotations := st.messageAnnotations(); annotations != nil {
+		final, _ = sjson.SetRawBytes(final, "item.content.0.annotations", annotations)
 	}
 	out = append(out, emitEvent("response.output_item.done", final))
 
@@ -187,7 +198,7 @@ func ConvertClaudeResponseToOpenAIResponses(ctx context.Context, modelName strin
 			// Reset per-message aggregation state
 			st.TextBuf.Reset()
 			st.CurrentTextBuf.Reset()
-			st.MessageAnnotations = nil
+			st.Citations.Reset()
 			st.ReasoningBuf.Reset()
 			st.ReasoningActive = false
 			st.InTextBlock = false
@@ -200,6 +211,12 @@ func ConvertClaudeResponseToOpenAIResponses(ctx context.Context, modelName strin
 			st.ReasoningSignature = ""
 			st.ReasoningIndex = 0
 			st.ReasoningPartAdded = false
+			st.ReasoningItems = nil
+			st.ReasoningChars = 0
+			st.WebSearchID = ""
+			st.WebSearchInput = make(map[string]*strings.Builder)
+			st.WebSearchIndex = make(map[string]int)
+			st.WebSearchItems = nil
 			st.FuncArgsBuf = make(map[int]*strings.Builder)
 			st.FuncNames = make(map[int]string)
 			st.FuncCallIDs = make(map[int]string)
@@ -227,6 +244,10 @@ func ConvertClaudeResponseToOpenAIResponses(ctx context.Context, modelName strin
 		typ := cb.Get("type").String()
 		if typ == "text" {
 			st.InTextBlock = true
+			st.Citations.StartBlock()
+			for _, citation := range cb.Get("citations").Array() {
+				st.Citations.AddClaudeCitation(citation)
+			}
 			if st.CurrentMsgID == "" {
 				st.CurrentMsgID = fmt.Sprintf("msg_%s_0", st.ResponseID)
 			}
@@ -284,6 +305,73 @@ func ConvertClaudeResponseToOpenAIResponses(ctx context.Context, modelName strin
 			part, _ = sjson.SetBytes(part, "output_index", idx)
 			out = append(out, emitEvent("response.reasoning_summary_part.added", part))
 			st.ReasoningPartAdded = true
+		} else if typ == "redacted_thinking" {
+			// Redacted thinking arrives whole; surface it as a reasoning item whose encrypted
+			// content carries the opaque data and whose summary stays empty.
+			itemID := fmt.Sprintf("rs_%s_%d", st.ResponseID, idx)
+			reasoningItem := claudeResponsesReasoningItem(itemID, cb.Get("data").String(), "")
+			item := []byte(`{"type":"response.output_item.added","sequence_number":0,"output_index":0,"item":{}}`)
+			item, _ = sjson.SetBytes(item, "sequence_number", nextSeq())
+			item, _ = sjson.SetBytes(item, "output_index", idx)
+			item, _ = sjson.SetRawBytes(item, "item", reasoningItem)
+			item, _ = sjson.SetBytes(item, "item.status", "in_progress")
+			out = append(out, emitEvent("response.output_item.added", item))
+			itemDone := []byte(`{"type":"response.output_item.done","sequence_number":0,"output_index":0,"item":{}}`)
+			itemDone, _ = sjson.SetBytes(itemDone, "sequence_number", nextSeq())
+			itemDone, _ = sjson.SetBytes(itemDone, "output_index", idx)
+			itemDone, _ = sjson.SetRawBytes(itemDone, "item", reasoningItem)
+			out = append(out, emitEvent("response.output_item.done", itemDone))
+			st.ReasoningItems = append(st.ReasoningItems, reasoningItem)
+		} else if typ == "server_tool_use" && cb.Get("name").String() == claudeWebSearchToolName {
+			st.WebSearchID = cb.Get("id").String()
+			if st.WebSearchInput == nil {
+				st.WebSearchInput = make(map[string]*strings.Builder)
+			}
+			st.WebSearchInput[st.WebSearchID] = &strings.Builder{}
+			if st.WebSearchIndex == nil {
+				st.WebSearchIndex = make(map[string]int)
+			}
+			st.WebSearchIndex[st.WebSearchID] = idx
+			if input := cb.Get("input"); input.Get("query").Exists() {
+				st.WebSearchInput[st.WebSearchID].WriteString(input.Raw)
+			}
+			item := []byte(`{"type":"response.output_item.added","sequence_number":0,"output_index":0,"item":{"id":"","type":"web_search_call","status":"in_progress"}}`)
+			item, _ = sjson.SetBytes(item, "sequence_number", nextSeq())
+			item, _ = sjson.SetBytes(item, "output_index", idx)
+			item, _ = sjson.SetBytes(item, "item.id", claudeWebSearchCallID(st.WebSearchID))
+			out = append(out, emitEvent("response.output_item.added", item))
+			for _, event := range []string{"response.web_search_call.in_progress", "response.web_search_call.searching"} {
+				progress := []byte(`{"type":"","sequence_number":0,"output_index":0,"item_id":""}`)
+				progress, _ = sjson.SetBytes(progress, "type", event)
+				progress, _ = sjson.SetBytes(progress, "sequence_number", nextSeq())
+				progress, _ = sjson.SetBytes(progress, "output_index", idx)
+				progress, _ = sjson.SetBytes(progress, "item_id", claudeWebSearchCallID(st.WebSearchID))
+				out = append(out, emitEvent(event, progress))
+			}
+		} else if typ == "web_search_tool_result" {
+			toolUseID := cb.Get("tool_use_id").String()
+			input := ""
+			if buf := st.WebSearchInput[toolUseID]; buf != nil {
+				input = buf.String()
+			}
+			searchItem := claudeWebSearchCallItem(toolUseID, input, cb.Get("content"))
+			// The call item keeps the output_index of the server_tool_use block that opened it.
+			if searchIdx, ok := st.WebSearchIndex[toolUseID]; ok {
+				idx = searchIdx
+			}
+			if gjson.GetBytes(searchItem, "status").String() == "completed" {
+				searchDone := []byte(`{"type":"response.web_search_call.completed","sequence_number":0,"output_index":0,"item_id":""}`)
+				searchDone, _ = sjson.SetBytes(searchDone, "sequence_number", nextSeq())
+				searchDone, _ = sjson.SetBytes(searchDone, "output_index", idx)
+				searchDone, _ = sjson.SetBytes(searchDone, "item_id", claudeWebSearchCallID(toolUseID))
+				out = append(out, emitEvent("response.web_search_call.completed", searchDone))
+			}
+			itemDone := []byte(`{"type":"response.output_item.done","sequence_number":0,"output_index":0,"item":{}}`)
+			itemDone, _ = sjson.SetBytes(itemDone, "sequence_number", nextSeq())
+			itemDone, _ = sjson.SetBytes(itemDone, "output_index", idx)
+			itemDone, _ = sjson.SetRawBytes(itemDone, "item", searchItem)
+			out = append(out, emitEvent("response.output_item.done", itemDone))
+			st.WebSearchItems = append(st.WebSearchItems, searchItem)
 		}
 	case "content_block_delta":
 		d := root.Get("delta")
@@ -301,8 +389,15 @@ func ConvertClaudeResponseToOpenAIResponses(ctx context.Context, modelName strin
 				// aggregate text for response.output
 				st.TextBuf.WriteString(t.String())
 				st.CurrentTextBuf.WriteString(t.String())
+				st.Citations.AddText(t.String())
 			}
 		} else if dt == "input_json_delta" {
+			if st.WebSearchID != "" {
+				if pj := d.Get("partial_json"); pj.Exists() {
+					st.WebSearchInput[st.WebSearchID].WriteString(pj.String())
+				}
+				return [][]byte{}
+			}
 			if !st.InFuncBlock || st.CurrentFCID == "" {
 				return [][]byte{}
 			}
@@ -339,15 +434,17 @@ func ConvertClaudeResponseToOpenAIResponses(ctx context.Context, modelName strin
 			}
 			return [][]byte{}
 		} else if dt == "citations_delta" {
-			if citation := d.Get("citation"); citation.Exists() {
-				st.appendMessageAnnotation(citation.Value())
-			}
+			st.Citations.AddClaudeCitation(d.Get("citation"))
 			return [][]byte{}
 		}
 	case "content_block_stop":
 		idx := int(root.Get("index").Int())
-		if st.InTextBlock {
+		if st.WebSearchID != "" {
+			// The web search call completes when its web_search_tool_result block arrives.
+			st.WebSearchID = ""
+		} else if st.InTextBlock {
 			st.InTextBlock = false
+			st.Citations.EndBlock()
 		} else if st.InFuncBlock {
 			args := "{}"
 			if buf := st.FuncArgsBuf[idx]; buf != nil {
@@ -384,17 +481,14 @@ func ConvertClaudeResponseToOpenAIResponses(ctx context.Context, modelName strin
 			partDone, _ = sjson.SetBytes(partDone, "output_index", st.ReasoningIndex)
 			partDone, _ = sjson.SetBytes(partDone, "part.text", full)
 			out = append(out, emitEvent("response.reasoning_summary_part.done", partDone))
-			itemDone := []byte(`{"type":"response.output_item.done","sequence_number":0,"output_index":0,"item":{"id":"","type":"reasoning","encrypted_content":"","summary":[]}}`)
+			reasoningItem := claudeResponsesReasoningItem(st.ReasoningItemID, st.ReasoningSignature, full)
+			itemDone := []byte(`{"type":"response.output_item.done","sequence_number":0,"output_index":0,"item":{}}`)
 			itemDone, _ = sjson.SetBytes(itemDone, "sequence_number", nextSeq())
-			itemDone, _ = sjson.SetBytes(itemDone, "item.id", st.ReasoningItemID)
 			itemDone, _ = sjson.SetBytes(itemDone, "output_index", st.ReasoningIndex)
-			itemDone, _ = sjson.SetBytes(itemDone, "item.encrypted_content", st.ReasoningSignature)
-			if full != "" {
-				summary := []byte(`{"type":"summary_text","text":""}`)
-				summary, _ = sjson.SetBytes(summary, "text", full)
-				itemDone, _ = sjson.SetRawBytes(itemDone, "item.summary.-1", summary)
-			}
+			itemDone, _ = sjson.SetRawBytes(itemDone, "item", reasoningItem)
 			out = append(out, emitEvent("response.output_item.done", itemDone))
+			st.ReasoningItems = append(st.ReasoningItems, reasoningItem)
+			st.ReasoningChars += len(full)
 			st.ReasoningActive = false
 			st.ReasoningPartAdded = false
 		}
@@ -478,16 +572,16 @@ func ConvertClaudeResponseToOpenAIResponses(ctx context.Context, modelName strin
 
 		// Build response.output from aggregated state
 		outputsWrapper := []byte(`{"arr":[]}`)
-		// reasoning item (if any)
-		if st.ReasoningBuf.Len() > 0 || st.ReasoningPartAdded || st.ReasoningSignature != "" {
-			item := []byte(`{"id":"","type":"reasoning","encrypted_content":"","summary":[]}`)
-			item, _ = sjson.SetBytes(item, "id", st.ReasoningItemID)
-			item, _ = sjson.SetBytes(item, "encrypted_content", st.ReasoningSignature)
-			if st.ReasoningBuf.Len() > 0 {
-				summary := []byte(`{"type":"summary_text","text":""}`)
-				summary, _ = sjson.SetBytes(summary, "text", st.ReasoningBuf.String())
-				item, _ = sjson.SetRawBytes(item, "summary.-1", summary)
-			}
+		// reasoning items (if any), including a block the stream never closed
+		for _, item := range st.ReasoningItems {
+			outputsWrapper, _ = sjson.SetRawBytes(outputsWrapper, "arr.-1", item)
+		}
+		if st.ReasoningActive && (st.ReasoningBuf.Len() > 0 || st.ReasoningPartAdded || st.ReasoningSignature != "") {
+			item := claudeResponsesReasoningItem(st.ReasoningItemID, st.ReasoningSignature, st.ReasoningBuf.String())
+			outputsWrapper, _ = sjson.SetRawBytes(outputsWrapper, "arr.-1", item)
+			st.ReasoningChars += st.ReasoningBuf.Len()
+		}
+		for _, item := range st.WebSearchItems {
 			outputsWrapper, _ = sjson.SetRawBytes(outputsWrapper, "arr.-1", item)
 		}
 		// assistant message item (if any text)
@@ -495,8 +589,8 @@ func ConvertClaudeResponseToOpenAIResponses(ctx context.Context, modelName strin
 			item := []byte(`{"id":"","type":"message","status":"completed","content":[{"type":"output_text","annotations":[],"logprobs":[],"text":""}],"role":"assistant"}`)
 			item, _ = sjson.SetBytes(item, "id", st.CurrentMsgID)
 			item, _ = sjson.SetBytes(item, "content.0.text", st.TextBuf.String())
-			if len(st.MessageAnnotations) > 0 {
-				item, _ = sjson.SetBytes(item, "content.0.annotations", st.MessageAnnotations)
+			if annotations := st.messageAnnotations(); annotations != nil {
+				item, _ = sjson.SetRawBytes(item, "content.0.annotations", annotations)
 			}
 			outputsWrapper, _ = sjson.SetRawBytes(outputsWrapper, "arr.-1", item)
 		}
@@ -537,10 +631,7 @@ func ConvertClaudeResponseToOpenAIResponses(ctx context.Context, modelName strin
 			completed, _ = sjson.SetRawBytes(completed, "response.output", []byte(gjson.GetBytes(outputsWrapper, "arr").Raw))
 		}
 
-		reasoningTokens := int64(0)
-		if st.ReasoningBuf.Len() > 0 {
-			reasoningTokens = int64(st.ReasoningBuf.Len() / 4)
-		}
+		reasoningTokens := int64(st.ReasoningChars / 4)
 		usagePresent := st.Usage.HasUsage || reasoningTokens > 0
 		if usagePresent {
 			inputTokens, outputTokens, totalTokens, cachedTokens := st.Usage.OpenAIResponsesUsage()
@@ -588,17 +679,15 @@ func ConvertClaudeResponseToOpenAIResponsesNonStream(_ context.Context, _ string
 
 	// Aggregation state
 	var (
-		responseID      string
-		createdAt       int64
-		currentMsgID    string
-		currentFCID     string
-		textBuf         strings.Builder
-		reasoningBuf    strings.Builder
-		reasoningActive bool
-		reasoningItemID string
-		reasoningSig    string
-		annotations     []any
-		usageTokens     claudeResponsesUsageTokens
+		responseID     string
+		createdAt      int64
+		currentMsgID   string
+		currentFCID    string
+		textBuf        strings.Builder
+		reasoningItems []*reasoningState
+		reasoning      *reasoningState
+		citations      translatorcommon.CitationTracker
+		usageTokens    claudeResponsesUsageTokens
 	)
 
 	// Per-index tool call aggregation
@@ -608,6 +697,10 @@ func ConvertClaudeResponseToOpenAIResponsesNonStream(_ context.Context, _ string
 		args strings.Builder
 	}
 	toolCalls := make(map[int]*toolState)
+	// Server-side web searches: block index -> tool_use_id, and tool_use_id -> input JSON
+	webSearchBlocks := make(map[int]string)
+	webSearchInputs := make(map[string]*strings.Builder)
+	var webSearchItems [][]byte
 
 	// Walk through SSE chunks to fill state
 	for _, ch := range chunks {
@@ -632,6 +725,10 @@ func ConvertClaudeResponseToOpenAIResponsesNonStream(_ context.Context, _ string
 			switch typ {
 			case "text":
 				currentMsgID = "msg_" + responseID + "_0"
+				citations.StartBlock()
+				for _, citation := range cb.Get("citations").Array() {
+					citations.AddClaudeCitation(citation)
+				}
 			case "tool_use":
 				currentFCID = cb.Get("id").String()
 				name := cb.Get("name").String()
@@ -642,12 +739,26 @@ func ConvertClaudeResponseToOpenAIResponsesNonStream(_ context.Context, _ string
 					toolCalls[idx].name = name
 				}
 			case "thinking":
-				reasoningActive = true
-				reasoningItemID = fmt.Sprintf("rs_%s_%d", responseID, idx)
-				reasoningSig = ""
-				if signature := cb.Get("signature"); signature.Exists() && signature.String() != "" {
-					reasoningSig = signature.String()
+				reasoning = &reasoningState{id: fmt.Sprintf("rs_%s_%d", responseID, idx), signature: cb.Get("signature").String()}
+				reasoningItems = append(reasoningItems, reasoning)
+			case "redacted_thinking":
+				reasoningItems = append(reasoningItems, &reasoningState{id: fmt.Sprintf("rs_%s_%d", responseID, idx), signature: cb.Get("data").String()})
+			case "server_tool_use":
+				if cb.Get("name").String() == claudeWebSearchToolName {
+					toolUseID := cb.Get("id").String()
+					webSearchBlocks[idx] = toolUseID
+					webSearchInputs[toolUseID] = &strings.Builder{}
+					if input := cb.Get("input"); input.Get("query").Exists() {
+						webSearchInputs[toolUseID].WriteString(input.Raw)
+					}
+				}
+			case "web_search_tool_result":
+				toolUseID := cb.Get("tool_use_id").String()
+				input := ""
+				if buf := webSearchInputs[toolUseID]; buf != nil {
+					input = buf.String()
 				}
+				webSearchItems = append(webSearchItems, claudeWebSearchCallItem(toolUseID, input, cb.Get("content")))
 			}
 
 		case "content_block_delta":
@@ -660,36 +771,39 @@ func ConvertClaudeResponseToOpenAIResponsesNonStream(_ context.Context, _ string
 			case "text_delta":
 				if t := d.Get("text"); t.Exists() {
 					textBuf.WriteString(t.String())
+					citations.AddText(t.String())
 				}
 			case "input_json_delta":
 				if pj := d.Get("partial_json"); pj.Exists() {
 					idx := int(root.Get("index").Int())
+					if toolUseID, ok := webSearchBlocks[idx]; ok {
+						webSearchInputs[toolUseID].WriteString(pj.String())
+						continue
+					}
 					if toolCalls[idx] == nil {
 						toolCalls[idx] = &toolState{}
 					}
 					toolCalls[idx].args.WriteString(pj.String())
 				}
 			case "thinking_delta":
-				if reasoningActive {
+				if reasoning != nil {
 					if t := d.Get("thinking"); t.Exists() {
-						reasoningBuf.WriteString(t.String())
+						reasoning.text.WriteString(t.String())
 					}
 				}
 			case "signature_delta":
-				if reasoningActive {
+				if reasoning != nil {
 					if signature := d.Get("signature"); signature.Exists() && signature.String() != "" {
-						reasoningSig = signature.String()
+						reasoning.signature = signature.String()
 					}
 				}
 			case "citations_delta":
-				if citation := d.Get("citation"); citation.Exists() {
-					annotations = append(annotations, citation.Value())
-				}
+				citations.AddClaudeCitation(d.Get("citation"))
 			}
 
 		case "content_block_stop":
-			// Nothing special to finalize for non-stream aggregation
-			_ = root
+			reasoning = nil
+			citations.EndBlock()
 
 		case "message_delta":
 			usageTokens.Merge(root.Get("usage"))
@@ -768,23 +882,23 @@ func ConvertClaudeResponseToOpenAIResponsesNonStream(_ context.Context, _ string
 
 	// Build output array
 	outputsWrapper := []byte(`{"arr":[]}`)
-	if reasoningBuf.Len() > 0 || reasoningSig != "" {
-		item := []byte(`{"id":"","type":"reasoning","encrypted_content":"","summary":[]}`)
-		item, _ = sjson.SetBytes(item, "id", reasoningItemID)
-		item, _ = sjson.SetBytes(item, "encrypted_content", reasoningSig)
-		if reasoningBuf.Len() > 0 {
-			summary := []byte(`{"type":"summary_text","text":""}`)
-			summary, _ = sjson.SetBytes(summary, "text", reasoningBuf.String())
-			item, _ = sjson.SetRawBytes(item, "summary.-1", summary)
+	reasoningChars := 0
+	for _, rs := range reasoningItems {
+		if rs.text.Len() == 0 && rs.signature == "" {
+			continue
 		}
+		outputsWrapper, _ = sjson.SetRawBytes(outputsWrapper, "arr.-1", claudeResponsesReasoningItem(rs.id, rs.signature, rs.text.String()))
+		reasoningChars += rs.text.Len()
+	}
+	for _, item := range webSearchItems {
 		outputsWrapper, _ = sjson.SetRawBytes(outputsWrapper, "arr.-1", item)
 	}
 	if currentMsgID != "" || textBuf.Len() > 0 {
 		item := []byte(`{"id":"","type":"message","status":"completed","content":[{"type":"output_text","annotations":[],"logprobs":[],"text":""}],"role":"assistant"}`)
 		item, _ = sjson.SetBytes(item, "id", currentMsgID)
 		item, _ = sjson.SetBytes(item, "content.0.text", textBuf.String())
-		if len(annotations) > 0 {
-			item, _ = sjson.SetBytes(item, "content.0.annotations", annotations)
+		if annotations := citations.Annotations(); annotations != nil {
+			item, _ = sjson.SetRawBytes(item, "content.0.annotations", translatorcommon.ResponsesAnnotations(annotations))
 		}
 		outputsWrapper, _ = sjson.SetRawBytes(outputsWrapper, "arr.-1", item)
 	}
@@ -825,13 +939,31 @@ func ConvertClaudeResponseToOpenAIResponsesNonStream(_ context.Context, _ string
 	out, _ = sjson.SetBytes(out, "usage.input_tokens_details.cached_tokens", cachedTokens)
 	out, _ = sjson.SetBytes(out, "usage.output_tokens", outputTokens)
 	out, _ = sjson.SetBytes(out, "usage.total_tokens", totalTokens)
-	if reasoningBuf.Len() > 0 {
-		// Rough estimate similar to chat completions
-		reasoningTokens := int64(len(reasoningBuf.String()) / 4)
-		if reasoningTokens > 0 {
-			out, _ = sjson.SetBytes(out, "usage.output_tokens_details.reasoning_tokens", reasoningTokens)
-		}
+	// Rough estimate similar to chat completions
+	if reasoningTokens := int64(reasoningChars / 4); reasoningTokens > 0 {
+		out, _ = sjson.SetBytes(out, "usage.output_tokens_details.reasoning_tokens", reasoningTokens)
 	}
 
 	return out
 }
+
+// reasoningState aggregates one thinking or redacted_thinking block for the non-stream response.
+type reasoningState struct {
+	id        string
+	signature string
+	text      strings.Builder
+}
+
+// claudeResponsesReasoningItem builds a completed Responses reasoning item. signature is the
+// thinking signature, or the opaque data of a redacted_thinking block.
+func claudeResponsesReasoningItem(id, signature, text string) []byte {
+	item := []byte(`{"id":"","type":"reasoning","encrypted_content":"","summary":[]}`)
+	item, _ = sjson.SetBytes(item, "id", id)
+	item, _ = sjson.SetBytes(item, "encrypted_content", signature)
+	if text != "" {
+		summary := []byte(`{"type":"summary_text","text":""}`)
+		summary, _ = sjson.SetBytes(summary, "text", text)
+		item, _ = sjson.SetRawBytes(item, "summary.-1", summary)
+	}
+	return item
+}
@@ -88,6 +88,70 @@ func TestConvertClaudeResponseToOpenAIResponses_ThinkingIncludesSignature(t *tes
 	}
 }
 
+func TestConvertClaudeResponseToOpenAIResponses_KeepsEveryReasoningBlock(t *testing.T) {
+	chunks := [][]byte{
+		[]byte(`data: {"type":"message_start","message":{"id":"msg_multi","usage":{"input_tokens":1,"output_tokens":0}}}`),
+		[]byte(`data: {"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}`),
+		[]byte(`data: {"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"first"}}`),
+		[]byte(`data: {"type":"content_block_delta","index":0,"delta":{"type":"signature_delta","signature":"sig_one"}}`),
+		[]byte(`data: {"type":"content_block_stop","index":0}`),
+		[]byte(`data: {"type":"content_block_start","index":1,"content_block":{"type":"redacted_thinking","data":"opaque_blob"}}`),
+		[]byte(`data: {"type":"content_block_stop","index":1}`),
+		[]byte(`data: {"type":"content_block_start","index":2,"content_block":{"type":"thinking","thinking":""}}`),
+		[]byte(`data: {"type":"content_block_delta","index":2,"delta":{"type":"thinking_delta","thinking":"second"}}`),
+		[]byte(`data: {"type":"content_block_delta","index":2,"delta":{"type":"signature_delta","signature":"sig_two"}}`),
+		[]byte(`data: {"type":"content_block_stop","index":2}`),
+		[]byte(`data: {"type":"message_stop"}`),
+	}
+
+	var param any
+	var outputs [][]byte
+	for _, chunk := range chunks {
+		outputs = append(outputs, ConvertClaudeResponseToOpenAIResponses(context.Background(), "claude-test", nil, nil, chunk, &param)...)
+	}
+
+	var added, done []gjson.Result
+	var completed gjson.Result
+	for _, output := range outputs {
+		event, data := parseClaudeResponsesSSEEvent(t, output)
+		switch event {
+		case "response.output_item.added":
+			added = append(added, data)
+		case "response.output_item.done":
+			done = append(done, data)
+		case "response.completed":
+			completed = data
+		}
+	}
+
+	if len(added) != 3 || len(done) != 3 {
+		t.Fatalf("got %d added and %d done reasoning items, want 3 each", len(added), len(done))
+	}
+	redacted := done[1].Get("item")
+	if redacted.Get("encrypted_content").String() != "opaque_blob" || len(redacted.Get("summary").Array()) != 0 {
+		t.Fatalf("redacted reasoning item = %s", redacted.Raw)
+	}
+	if got := added[1].Get("item.status").String(); got != "in_progress" {
+		t.Fatalf("redacted added status = %q, want in_progress", got)
+	}
+
+	output := completed.Get("response.output").Array()
+	if len(output) != 3 {
+		t.Fatalf("completed output = %s, want three reasoning items", completed.Get("response.output").Raw)
+	}
+	for i, want := range []string{"sig_one", "opaque_blob", "sig_two"} {
+		if got := output[i].Get("encrypted_content").String(); got != want {
+			t.Fatalf("output[%d].encrypted_content = %q, want %q", i, got, want)
+		}
+	}
+	if got := output[2].Get("summary.0.text").String(); got != "second" {
+		t.Fatalf("output[2] summary = %q, want second", got)
+	}
+	if output[0].Get("id").String() == output[2].Get("id").String() {
+		t.Fatal("reasoning items share an id")
+	}
+}
+
 func TestConvertClaudeResponseToOpenAIResponses_SuppressesSignatureDeltaPassthrough(t *testing.T) {
 	chunk := []byte(`data: {"type":"content_block_delta","index":0,"delta":{"type":"signature_delta","signature":"claude_sig_123"}}`)
 
@@ -119,11 +183,17 @@ func TestConvertClaudeResponseToOpenAIResponses_AggregatesTextBlocksUntilMessage
 	outputs := translateClaudeResponsesStreamThroughRegistry(chunks)
 
 	counts := map[string]int{}
+	itemIndexes := map[string][]int64{}
 	var outputTextDone gjson.Result
 	var completed gjson.Result
 	for _, output := range outputs {
 		event, data := parseClaudeResponsesSSEEvent(t, output)
 		counts[event]++
+		if itemID := data.Get("item.id").String(); itemID != "" {
+			itemIndexes[itemID] = append(itemIndexes[itemID], data.Get("output_index").Int())
+		} else if itemID := data.Get("item_id").String(); itemID != "" {
+			itemIndexes[itemID] = append(itemIndexes[itemID], data.Get("output_index").Int())
+		}
 		if event == "response.output_text.done" {
 			outputTextDone = data
 		}
@@ -135,8 +205,26 @@ func TestConvertClaudeResponseToOpenAIResponses_AggregatesTextBlocksUntilMessage
 		}
 	}
 
-	if counts["response.output_item.added"] != 1 {
-		t.Fatalf("response.output_item.added count = %d, want 1", counts["response.output_item.added"])
+	// One message item aggregates both text blocks; the web search is its own item.
+	if counts["response.output_item.added"] != 2 {
+		t.Fatalf("response.output_item.added count = %d, want 2", counts["response.output_item.added"])
+	}
+	if counts["response.output_item.done"] != 2 {
+		t.Fatalf("response.output_item.done count = %d, want 2", counts["response.output_item.done"])
+	}
+	for itemID, indexes := range itemIndexes {
+		for _, index := range indexes {
+			if index != indexes[0] {
+				t.Fatalf("item %s output_index changed: %v", itemID, indexes)
+			}
+		}
+	}
+	messageIndexes := itemIndexes["msg_msg_123_0"]
+	if len(messageIndexes) == 0 || messageIndexes[0] != 0 {
+		t.Fatalf("message item output_index = %v, want 0", messageIndexes)
+	}
+	if searchIndexes := itemIndexes["ws_srv_123"]; len(searchIndexes) == 0 || searchIndexes[0] == messageIndexes[0] {
+		t.Fatalf("web_search_call output_index = %v, want distinct from message", searchIndexes)
 	}
 	if counts["response.content_part.added"] != 1 {
 		t.Fatalf("response.content_part.added count = %d, want 1", counts["response.content_part.added"])
@@ -147,9 +235,6 @@ func TestConvertClaudeResponseToOpenAIResponses_AggregatesTextBlocksUntilMessage
 	if counts["response.content_part.done"] != 1 {
 		t.Fatalf("response.content_part.done count = %d, want 1", counts["response.content_part.done"])
 	}
-	if counts["response.output_item.done"] != 1 {
-		t.Fatalf("response.output_item.done count = %d, want 1", counts["response.output_item.done"])
-	}
 	if counts["response.function_call_arguments.delta"] != 0 {
 		t.Fatalf("response.function_call_arguments.delta count = %d, want 0", counts["response.function_call_arguments.delta"])
 	}
@@ -158,11 +243,19 @@ func TestConvertClaudeResponseToOpenAIResponses_AggregatesTextBlocksUntilMessage
 	if got := outputTextDone.Get("text").String(); got != wantText {
 		t.Fatalf("output_text.done text = %q, want %q", got, wantText)
 	}
-	if got := completed.Get("response.output.0.content.0.text").String(); got != wantText {
+	message := completed.Get(`response.output.#(type=="message")`)
+	if got := message.Get("content.0.text").String(); got != wantText {
 		t.Fatalf("completed message text = %q, want %q", got, wantText)
 	}
-	if got := completed.Get("response.output.0.content.0.annotations.0.type").String(); got != "web_search_result_location" {
-		t.Fatalf("completed annotation type = %q", got)
+	if got := completed.Get(`response.output.#(type=="web_search_call").status`).String(); got != "completed" {
+		t.Fatalf("completed web_search_call status = %q, want completed", got)
+	}
+	annotation := message.Get("content.0.annotations.0")
+	if annotation.Get("type").String() != "url_citation" || annotation.Get("url").String() != "https://example.com" || annotation.Get("title").String() != "Example" {
+		t.Fatalf("completed annotation = %s", annotation.Raw)
+	}
+	if annotation.Get("start_index").Int() != 26 || annotation.Get("end_index").Int() != 45 {
+		t.Fatalf("completed annotation span = [%d, %d), want [26, 45)", annotation.Get("start_index").Int(), annotation.Get("end_index").Int())
 	}
 }
 
@@ -223,6 +316,44 @@ func TestConvertClaudeResponseToOpenAIResponsesNonStream_ThinkingIncludesSignatu
 	}
 }
 
+func TestConvertClaudeResponseToOpenAIResponsesNonStream_KeepsEveryReasoningBlock(t *testing.T) {
+	raw := []byte(strings.Join([]string{
+		`data: {"type":"message_start","message":{"id":"msg_multi","usage":{"input_tokens":1,"output_tokens":0}}}`,
+		`data: {"type":"content_block_start","index":0,"content_block":{"type":"thinking","thinking":""}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"thinking_delta","thinking":"first thought"}}`,
+		`data: {"type":"content_block_delta","index":0,"delta":{"type":"signature_delta","signature":"sig_one"}}`,
+		`data: {"type":"content_block_stop","index":0}`,
+		`data: {"type":"content_block_start","index":1,"content_block":{"type":"redacted_thinking","data":"opaque_blob"}}`,
+		`data: {"type":"content_block_stop","index":1}`,
+		`data: {"type":"content_block_start","index":2,"content_block":{"type":"text","text":""}}`,
+		`data: {"type":"content_block_delta","index":2,"delta":{"type":"text_delta","text":"answer"}}`,
+		`data: {"type":"content_block_stop","index":2}`,
+		`data: {"type":"message_stop"}`,
+	}, "\n"))
+
+	out := ConvertClaudeResponseToOpenAIResponsesNonStream(context.Background(), "claude-test", nil, nil, raw, nil)
+	root := gjson.ParseBytes(out)
+
+	if got := root.Get("output.#").Int(); got != 3 {
+		t.Fatalf("output = %s, want two reasoning items and a message", root.Get("output").Raw)
+	}
+	if got := root.Get("output.0.summary.0.text").String(); got != "first thought" {
+		t.Fatalf("first reasoning summary = %q", got)
+	}
+	if got := root.Get("output.1.encrypted_content").String(); got != "opaque_blob" {
+		t.Fatalf("redacted reasoning encrypted_content = %q, want opaque_blob", got)
+	}
+	if got := root.Get("output.1.summary.#").Int(); got != 0 {
+		t.Fatalf("redacted reasoning summary count = %d, want 0", got)
+	}
+	if got := root.Get("output.2.type").String(); got != "message" {
+		t.Fatalf("output[2].type = %q, want message", got)
+	}
+	if got := root.Get("usage.output_tokens_details.reasoning_tokens").Int(); got != 3 {
+		t.Fatalf("reasoning_tokens = %d, want 3", got)
+	}
+}
+
 func TestConvertClaudeResponseToOpenAIResponsesNonStream_ReportsCacheTokens(t *testing.T) {
 	raw := []byte(strings.Join([]string{
 		`data: {"type":"message_start","message":{"id":"msg_nonstream","usage":{"input_tokens":13,"output_tokens":1,"cache_read_input_tokens":22000,"cache_creation_input_tokens":31}}}`,
@@ -0,0 +1,34 @@
+package responses
+
+import (
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/common"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// claudeWebSearchToolName is the name of Claude's server-side web search tool.
+const claudeWebSearchToolName = "web_search"
+
+// claudeWebSearchCallID maps a Claude server_tool_use id to a web_search_call item id.
+func claudeWebSearchCallID(toolUseID string) string {
+	return "ws_" + toolUseID
+}
+
+// claudeWebSearchCallItem builds the web_search_call output item for a Claude web search. input is
+// the server_tool_use input JSON and result the web_search_tool_result content; the item is failed
+// when the result is an error and in progress when no result has arrived.
+func claudeWebSearchCallItem(toolUseID, input string, result gjson.Result) []byte {
+	item := translatorcommon.ResponsesWebSearchCallItem(claudeWebSearchCallID(toolUseID), gjson.Get(input, "query").String())
+	switch {
+	case !result.Exists():
+		item, _ = sjson.SetBytes(item, "status", "in_progress")
+	case result.IsArray():
+		result.ForEach(func(_, entry gjson.Result) bool {
+			item = translatorcommon.AppendResponsesWebSearchSource(item, entry.Get("url").String())
+			return true
+		})
+	default:
+		item, _ = sjson.SetBytes(item, "status", "failed")
+	}
+	return item
+}
@@ -0,0 +1,93 @@
+package responses
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+var claudeWebSearchChunks = []string{
+	`data: {"type":"message_start","message":{"id":"msg_ws","usage":{"input_tokens":1,"output_tokens":0}}}`,
+	`data: {"type":"content_block_start","index":0,"content_block":{"type":"server_tool_use","id":"srvtoolu_1","name":"web_search","input":{}}}`,
+	`data: {"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"{\"query\":"}}`,
+	`data: {"type":"content_block_delta","index":0,"delta":{"type":"input_json_delta","partial_json":"\"weather paris\"}"}}`,
+	`data: {"type":"content_block_stop","index":0}`,
+	`data: {"type":"content_block_start","index":1,"content_block":{"type":"web_search_tool_result","tool_use_id":"srvtoolu_1","content":[{"type":"web_search_result","url":"https://example.com/a","title":"a"}]}}`,
+	`data: {"type":"content_block_stop","index":1}`,
+	`data: {"type":"content_block_start","index":2,"content_block":{"type":"text","text":""}}`,
+	`data: {"type":"content_block_delta","index":2,"delta":{"type":"text_delta","text":"It is sunny."}}`,
+	`data: {"type":"content_block_stop","index":2}`,
+	`data: {"type":"message_stop"}`,
+}
+
+func TestConvertOpenAIResponsesRequestToClaude_MapsWebSearchPreview(t *testing.T) {
+	input := []byte(`{"model":"claude-sonnet-4","input":"weather?","tools":[{"type":"web_search_preview"},{"type":"file_search","vector_store_ids":["vs_1"]}],"tool_choice":{"type":"web_search_preview"}}`)
+
+	out := ConvertOpenAIResponsesRequestToClaude("claude-sonnet-4", input, false)
+	if got := gjson.GetBytes(out, "tools.#").Int(); got != 1 {
+		t.Fatalf("tools = %s, want only the web search tool", gjson.GetBytes(out, "tools").Raw)
+	}
+	if got := gjson.GetBytes(out, "tools.0.type").String(); got != "web_search_20250305" {
+		t.Fatalf("tool type = %q, want web_search_20250305", got)
+	}
+	if got := gjson.GetBytes(out, "tool_choice.name").String(); got != "web_search" {
+		t.Fatalf("tool_choice = %s, want the web search tool", gjson.GetBytes(out, "tool_choice").Raw)
+	}
+}
+
+func TestConvertClaudeResponseToOpenAIResponses_WebSearchCall(t *testing.T) {
+	var param any
+	var events []string
+	var done, completed gjson.Result
+	for _, chunk := range claudeWebSearchChunks {
+		for _, output := range ConvertClaudeResponseToOpenAIResponses(context.Background(), "claude-test", nil, nil, []byte(chunk), &param) {
+			event, data := parseClaudeResponsesSSEEvent(t, output)
+			events = append(events, event)
+			switch {
+			case event == "response.output_item.done" && data.Get("item.type").String() == "web_search_call":
+				done = data
+			case event == "response.completed":
+				completed = data
+			}
+		}
+	}
+
+	joined := strings.Join(events, ",")
+	for _, want := range []string{"response.web_search_call.in_progress", "response.web_search_call.searching", "response.web_search_call.completed"} {
+		if !strings.Contains(joined, want) {
+			t.Fatalf("events %s missing %s", joined, want)
+		}
+	}
+	if strings.Contains(joined, "response.function_call_arguments.delta") {
+		t.Fatalf("web search input leaked as function call arguments: %s", joined)
+	}
+	if got := done.Get("item.action.query").String(); got != "weather paris" {
+		t.Fatalf("query = %q, want weather paris", got)
+	}
+	if got := done.Get("item.action.sources.0.url").String(); got != "https://example.com/a" {
+		t.Fatalf("source = %q", got)
+	}
+	output := completed.Get("response.output").Array()
+	if len(output) != 2 || output[0].Get("type").String() != "web_search_call" || output[1].Get("type").String() != "message" {
+		t.Fatalf("completed output = %s", completed.Get("response.output").Raw)
+	}
+}
+
+func TestConvertClaudeResponseToOpenAIResponsesNonStream_WebSearchCall(t *testing.T) {
+	out := ConvertClaudeResponseToOpenAIResponsesNonStream(context.Background(), "claude-test", nil, nil, []byte(strings.Join(claudeWebSearchChunks, "\n")), nil)
+	root := gjson.ParseBytes(out)
+
+	if got := root.Get("output.#").Int(); got != 2 {
+		t.Fatalf("output = %s, want a web search call and a message", root.Get("output").Raw)
+	}
+	if root.Get("output.0.status").String() != "completed" || root.Get("output.0.action.query").String() != "weather paris" {
+		t.Fatalf("web search call = %s", root.Get("output.0").Raw)
+	}
+
+	failed := claudeWebSearchCallItem("srvtoolu_2", `{"query":"x"}`, gjson.Parse(`{"type":"web_search_tool_result_error","error_code":"max_uses_exceeded"}`))
+	if got := gjson.GetBytes(failed, "status").String(); got != "failed" {
+		t.Fatalf("status = %q, want failed", got)
+	}
+}
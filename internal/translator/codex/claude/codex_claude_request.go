@@ -40,7 +40,7 @@ import (
 // Returns:
 //   - []byte: The transformed request data in internal client format
 func ConvertClaudeRequestToCodex(modelName string, inputRawJSON []byte, _ bool) []byte {
-	rawJSON := inputRawJSON
+	rawJSON := translatorcommon.DegradeClaudeServerToolBlocks(inputRawJSON)
 
 	template := []byte(`{"model":"","instructions":"","input":[]}`)
 
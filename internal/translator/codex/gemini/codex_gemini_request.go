@@ -16,6 +16,7 @@ import (
 	"strings"
 
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/translator/gemini/common"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
@@ -274,6 +275,10 @@ func ConvertGeminiRequestToCodex(modelName string, inputRawJSON []byte, _ bool)
 		tarr := tools.Array()
 		for i := 0; i < len(tarr); i++ {
 			td := tarr[i]
+			// Google Search grounding maps to the Responses API web_search tool.
+			if common.IsGoogleSearchTool(td) {
+				out, _ = sjson.SetRawBytes(out, "tools.-1", []byte(`{"type":"web_search"}`))
+			}
 			fns := td.Get("functionDeclarations")
 			if !fns.IsArray() {
 				continue
@@ -0,0 +1,114 @@
+package common
+
+import (
+	"unicode/utf8"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// URLCitationAnnotation builds an OpenAI Chat Completions url_citation annotation citing url over
+// the characters [start, end) of the message text.
+func URLCitationAnnotation(url, title string, start, end int) []byte {
+	annotation := []byte(`{"type":"url_citation","url_citation":{"url":"","title":"","start_index":0,"end_index":0}}`)
+	annotation, _ = sjson.SetBytes(annotation, "url_citation.url", url)
+	annotation, _ = sjson.SetBytes(annotation, "url_citation.title", title)
+	annotation, _ = sjson.SetBytes(annotation, "url_citation.start_index", start)
+	annotation, _ = sjson.SetBytes(annotation, "url_citation.end_index", end)
+	return annotation
+}
+
+// ResponsesAnnotations converts Chat Completions annotations into the flat form used by the
+// output_text parts of the OpenAI Responses API.
+func ResponsesAnnotations(annotations []byte) []byte {
+	out := []byte(`[]`)
+	for _, annotation := range gjson.ParseBytes(annotations).Array() {
+		citation := annotation.Get("url_citation")
+		if annotation.Get("type").String() != "url_citation" || !citation.Exists() {
+			continue
+		}
+		item := []byte(`{"type":"url_citation","url":"","title":"","start_index":0,"end_index":0}`)
+		item, _ = sjson.SetBytes(item, "url", citation.Get("url").String())
+		item, _ = sjson.SetBytes(item, "title", citation.Get("title").String())
+		item, _ = sjson.SetBytes(item, "start_index", citation.Get("start_index").Int())
+		item, _ = sjson.SetBytes(item, "end_index", citation.Get("end_index").Int())
+		out, _ = sjson.SetRawBytes(out, "-1", item)
+	}
+	return out
+}
+
+// ClaudeCitationURL returns the URL and title a Claude text citation points at. Document
+// citations (char_location, page_location, content_block_location) have no URL and report false.
+func ClaudeCitationURL(citation gjson.Result) (url, title string, ok bool) {
+	switch citation.Get("type").String() {
+	case "web_search_result_location":
+		url = citation.Get("url").String()
+	case "search_result_location":
+		url = citation.Get("source").String()
+	}
+	if url == "" {
+		return "", "", false
+	}
+	return url, citation.Get("title").String(), true
+}
+
+// CitationTracker positions the citations of a Claude message over the text they cite. Claude
+// attaches citations to a whole text block, so each citation spans the block it arrived in.
+type CitationTracker struct {
+	textLength  int
+	blockStart  int
+	pending     []gjson.Result
+	annotations [][]byte
+}
+
+// StartBlock marks the beginning of a text block. Citations received since the previous block
+// ended belong to this block.
+func (t *CitationTracker) StartBlock() {
+	t.blockStart = t.textLength
+}
+
+// AddText records text appended to the current block.
+func (t *CitationTracker) AddText(text string) {
+	t.textLength += utf8.RuneCountInString(text)
+}
+
+// AddClaudeCitation records a citation of the current block.
+func (t *CitationTracker) AddClaudeCitation(citation gjson.Result) {
+	if citation.Exists() {
+		t.pending = append(t.pending, citation)
+	}
+}
+
+// EndBlock closes the current text block, anchoring its citations.
+func (t *CitationTracker) EndBlock() {
+	t.flush()
+	t.blockStart = t.textLength
+}
+
+// Annotations returns the collected citations as Chat Completions annotations, or nil when the
+// message cites nothing.
+func (t *CitationTracker) Annotations() []byte {
+	t.flush()
+	if len(t.annotations) == 0 {
+		return nil
+	}
+	out := []byte(`[]`)
+	for _, annotation := range t.annotations {
+		out, _ = sjson.SetRawBytes(out, "-1", annotation)
+	}
+	return out
+}
+
+// Reset clears all recorded text and citations.
+func (t *CitationTracker) Reset() {
+	*t = CitationTracker{}
+}
+
+func (t *CitationTracker) flush() {
+	for _, citation := range t.pending {
+		if url, title, ok := ClaudeCitationURL(citation); ok {
+			t.annotations = append(t.annotations, URLCitationAnnotation(url, title, t.blockStart, t.textLength))
+		}
+	}
+	t.pending = nil
+}
@@ -0,0 +1,41 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestCitationTrackerSpansCitedBlock(t *testing.T) {
+	var tracker CitationTracker
+	tracker.StartBlock()
+	tracker.AddText("héllo ")
+	tracker.EndBlock()
+	tracker.AddClaudeCitation(gjson.Parse(`{"type":"search_result_location","source":"https://example.com/a","title":"A"}`))
+	tracker.StartBlock()
+	tracker.AddText("world")
+	tracker.EndBlock()
+
+	annotations := gjson.ParseBytes(tracker.Annotations()).Array()
+	if len(annotations) != 1 {
+		t.Fatalf("annotations = %v, want 1", annotations)
+	}
+	citation := annotations[0].Get("url_citation")
+	if citation.Get("url").String() != "https://example.com/a" || citation.Get("start_index").Int() != 6 || citation.Get("end_index").Int() != 11 {
+		t.Fatalf("annotation = %s", annotations[0].Raw)
+	}
+
+	tracker.Reset()
+	tracker.AddClaudeCitation(gjson.Parse(`{"type":"page_location","document_index":0}`))
+	if got := tracker.Annotations(); got != nil {
+		t.Fatalf("document citation produced annotations %s", got)
+	}
+}
+
+func TestResponsesAnnotationsFlattensURLCitations(t *testing.T) {
+	chat := []byte(`[` + string(URLCitationAnnotation("https://go.dev", "Go", 1, 4)) + `]`)
+	got := gjson.ParseBytes(ResponsesAnnotations(chat)).Get("0")
+	if got.Get("type").String() != "url_citation" || got.Get("url").String() != "https://go.dev" || got.Get("title").String() != "Go" || got.Get("start_index").Int() != 1 || got.Get("end_index").Int() != 4 {
+		t.Fatalf("responses annotation = %s", got.Raw)
+	}
+}
@@ -0,0 +1,188 @@
+package common
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// DegradeClaudeServerToolBlocks rewrites the server-side tool blocks of a Claude Messages request
+// (server_tool_use, mcp_tool_use and their *_tool_result blocks) into plain tool_use and
+// tool_result blocks for upstreams that only understand client tools. Result blocks live in the
+// assistant turn upstream, so they are moved into a user turn following their call.
+func DegradeClaudeServerToolBlocks(rawJSON []byte) []byte {
+	if !bytes.Contains(rawJSON, []byte(`_tool_use"`)) && !bytes.Contains(rawJSON, []byte(`_tool_result"`)) {
+		return rawJSON
+	}
+	messages := gjson.GetBytes(rawJSON, "messages")
+	if !messages.IsArray() {
+		return rawJSON
+	}
+
+	type outMessage struct {
+		raw   string
+		role  string
+		parts []string
+	}
+	var out []outMessage
+	modified := false
+	// pendingResults marks that the last output message is a synthesized user turn holding
+	// degraded results, which the next user message is merged into.
+	pendingResults := false
+
+	for _, message := range messages.Array() {
+		role := message.Get("role").String()
+		content := message.Get("content")
+		if pendingResults && role == "user" {
+			last := &out[len(out)-1]
+			if content.IsArray() {
+				for _, part := range content.Array() {
+					last.parts = append(last.parts, degradeClaudeServerToolPart(part))
+				}
+			} else if text := content.String(); text != "" {
+				block, _ := sjson.Set(`{"type":"text","text":""}`, "text", text)
+				last.parts = append(last.parts, block)
+			}
+			pendingResults = false
+			continue
+		}
+		pendingResults = false
+		if !content.IsArray() || !hasClaudeServerToolBlock(content) {
+			out = append(out, outMessage{raw: message.Raw})
+			continue
+		}
+		modified = true
+		if role != "assistant" {
+			parts := make([]string, 0, len(content.Array()))
+			for _, part := range content.Array() {
+				parts = append(parts, degradeClaudeServerToolPart(part))
+			}
+			out = append(out, outMessage{role: role, parts: parts})
+			continue
+		}
+		var current *outMessage
+		for _, part := range content.Array() {
+			partRole := "assistant"
+			if isClaudeServerToolResult(part.Get("type").String()) {
+				partRole = "user"
+			}
+			if current == nil || current.role != partRole {
+				out = append(out, outMessage{role: partRole})
+				current = &out[len(out)-1]
+			}
+			current.parts = append(current.parts, degradeClaudeServerToolPart(part))
+		}
+		pendingResults = current != nil && current.role == "user"
+	}
+	if !modified {
+		return rawJSON
+	}
+
+	rendered := make([]string, 0, len(out))
+	for _, message := range out {
+		if message.raw != "" {
+			rendered = append(rendered, message.raw)
+			continue
+		}
+		item, _ := sjson.Set(`{"role":""}`, "role", message.role)
+		item, _ = sjson.SetRaw(item, "content", "["+strings.Join(message.parts, ",")+"]")
+		rendered = append(rendered, item)
+	}
+	updated, errSet := sjson.SetRawBytes(rawJSON, "messages", []byte("["+strings.Join(rendered, ",")+"]"))
+	if errSet != nil {
+		return rawJSON
+	}
+	return updated
+}
+
+func hasClaudeServerToolBlock(content gjson.Result) bool {
+	for _, part := range content.Array() {
+		partType := part.Get("type").String()
+		if partType == "server_tool_use" || partType == "mcp_tool_use" || isClaudeServerToolResult(partType) {
+			return true
+		}
+	}
+	return false
+}
+
+// isClaudeServerToolResult reports whether blockType is the result of a server-side tool, such as
+// web_search_tool_result or mcp_tool_result.
+func isClaudeServerToolResult(blockType string) bool {
+	return blockType != "tool_result" && strings.HasSuffix(blockType, "_tool_result")
+}
+
+func degradeClaudeServerToolPart(part gjson.Result) string {
+	partType := part.Get("type").String()
+	switch {
+	case partType == "server_tool_use" || partType == "mcp_tool_use":
+		name := part.Get("name").String()
+		if server := part.Get("server_name").String(); server != "" {
+			name = "mcp__" + server + "__" + name
+		}
+		block, _ := sjson.Set(`{"type":"tool_use","id":"","name":""}`, "id", part.Get("id").String())
+		block, _ = sjson.Set(block, "name", name)
+		input := part.Get("input")
+		if !input.IsObject() {
+			input = gjson.Parse(`{}`)
+		}
+		block, _ = sjson.SetRaw(block, "input", input.Raw)
+		return block
+	case isClaudeServerToolResult(partType):
+		text, isError := claudeServerToolResultText(part.Get("content"))
+		block, _ := sjson.Set(`{"type":"tool_result","tool_use_id":"","content":""}`, "tool_use_id", part.Get("tool_use_id").String())
+		block, _ = sjson.Set(block, "content", text)
+		if isError || part.Get("is_error").Bool() {
+			block, _ = sjson.Set(block, "is_error", true)
+		}
+		return block
+	default:
+		return part.Raw
+	}
+}
+
+// claudeServerToolResultText flattens the content of a server tool result into text and reports
+// whether it is an error result.
+func claudeServerToolResultText(content gjson.Result) (string, bool) {
+	switch {
+	case content.Type == gjson.String:
+		return content.String(), false
+	case content.IsArray():
+		lines := make([]string, 0, len(content.Array()))
+		isError := false
+		for _, item := range content.Array() {
+			text, itemError := claudeServerToolResultItemText(item)
+			isError = isError || itemError
+			if text != "" {
+				lines = append(lines, text)
+			}
+		}
+		return strings.Join(lines, "\n\n"), isError
+	case content.IsObject():
+		return claudeServerToolResultItemText(content)
+	default:
+		return "", false
+	}
+}
+
+func claudeServerToolResultItemText(item gjson.Result) (string, bool) {
+	itemType := item.Get("type").String()
+	if errorCode := item.Get("error_code").String(); errorCode != "" || strings.HasSuffix(itemType, "_error") {
+		return strings.TrimSpace(itemType + ": " + errorCode), true
+	}
+	switch itemType {
+	case "text":
+		return item.Get("text").String(), false
+	case "web_search_result":
+		return strings.TrimSpace(item.Get("title").String() + "\n" + item.Get("url").String()), false
+	case "web_fetch_result":
+		text := item.Get("url").String()
+		if data := item.Get("content.source.data"); data.Type == gjson.String {
+			text += "\n" + data.String()
+		}
+		return text, false
+	default:
+		return item.Raw, false
+	}
+}
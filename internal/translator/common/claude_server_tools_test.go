@@ -0,0 +1,75 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestDegradeClaudeServerToolBlocks(t *testing.T) {
+	input := []byte(`{"model":"m","messages":[
+		{"role":"user","content":"find it"},
+		{"role":"assistant","content":[
+			{"type":"text","text":"Searching."},
+			{"type":"server_tool_use","id":"srvtoolu_1","name":"web_search","input":{"query":"go"}},
+			{"type":"web_search_tool_result","tool_use_id":"srvtoolu_1","content":[{"type":"web_search_result","title":"Go","url":"https://go.dev","encrypted_content":"x"}]},
+			{"type":"mcp_tool_use","id":"mcptoolu_1","name":"lookup","server_name":"docs","input":{"q":"go"}},
+			{"type":"mcp_tool_result","tool_use_id":"mcptoolu_1","is_error":true,"content":[{"type":"text","text":"denied"}]},
+			{"type":"text","text":"Found it."}
+		]},
+		{"role":"user","content":"thanks"}
+	]}`)
+
+	messages := gjson.GetBytes(DegradeClaudeServerToolBlocks(input), "messages").Array()
+	wantRoles := []string{"user", "assistant", "user", "assistant", "user", "assistant", "user"}
+	if len(messages) != len(wantRoles) {
+		t.Fatalf("got %d messages, want %d: %v", len(messages), len(wantRoles), messages)
+	}
+	for i, role := range wantRoles {
+		if got := messages[i].Get("role").String(); got != role {
+			t.Fatalf("messages[%d].role = %q, want %q", i, got, role)
+		}
+	}
+	if call := messages[1].Get("content.1"); call.Get("type").String() != "tool_use" || call.Get("name").String() != "web_search" || call.Get("input.query").String() != "go" {
+		t.Fatalf("server_tool_use degraded to %s", call.Raw)
+	}
+	if result := messages[2].Get("content.0"); result.Get("type").String() != "tool_result" || result.Get("tool_use_id").String() != "srvtoolu_1" || result.Get("content").String() != "Go\nhttps://go.dev" {
+		t.Fatalf("web_search_tool_result degraded to %s", result.Raw)
+	}
+	if call := messages[3].Get("content.0"); call.Get("name").String() != "mcp__docs__lookup" {
+		t.Fatalf("mcp_tool_use degraded to %s", call.Raw)
+	}
+	// The trailing user turn is merged behind the last degraded result.
+	if result := messages[4].Get("content.0"); !result.Get("is_error").Bool() || result.Get("content").String() != "denied" {
+		t.Fatalf("mcp_tool_result degraded to %s", result.Raw)
+	}
+	if got := messages[6].Get("content").String(); got != "thanks" {
+		t.Fatalf("final user message = %q", got)
+	}
+}
+
+func TestDegradeClaudeServerToolBlocksMergesFollowingUserTurn(t *testing.T) {
+	input := []byte(`{"messages":[
+		{"role":"assistant","content":[
+			{"type":"server_tool_use","id":"srvtoolu_1","name":"web_search","input":{}},
+			{"type":"web_search_tool_result","tool_use_id":"srvtoolu_1","content":{"type":"web_search_tool_result_error","error_code":"max_uses_exceeded"}}
+		]},
+		{"role":"user","content":"go on"}
+	]}`)
+
+	messages := gjson.GetBytes(DegradeClaudeServerToolBlocks(input), "messages").Array()
+	if len(messages) != 2 {
+		t.Fatalf("got %d messages, want 2: %v", len(messages), messages)
+	}
+	content := messages[1].Get("content").Array()
+	if len(content) != 2 || content[0].Get("type").String() != "tool_result" || !content[0].Get("is_error").Bool() || content[1].Get("text").String() != "go on" {
+		t.Fatalf("merged user turn = %s", messages[1].Raw)
+	}
+}
+
+func TestDegradeClaudeServerToolBlocksLeavesPlainRequests(t *testing.T) {
+	input := []byte(`{"messages":[{"role":"assistant","content":[{"type":"tool_use","id":"t","name":"n","input":{}}]},{"role":"user","content":[{"type":"tool_result","tool_use_id":"t","content":"ok"}]}]}`)
+	if got := DegradeClaudeServerToolBlocks(input); string(got) != string(input) {
+		t.Fatalf("plain request rewritten to %s", got)
+	}
+}
@@ -0,0 +1,81 @@
+package common
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/tidwall/gjson"
+)
+
+// ParallelMessageThreshold is the message count from which MapMessages converts messages on a
+// worker pool. Below it, handing messages to goroutines costs more than it saves.
+const ParallelMessageThreshold = 256
+
+// parallelMessageBatch is how many consecutive messages a worker claims at a time.
+const parallelMessageBatch = 32
+
+// MapMessages applies convert to every message and returns the results in input order.
+// Arrays of at least ParallelMessageThreshold messages are converted concurrently, so convert
+// must depend only on the message it is given and must not mutate shared state.
+func MapMessages[T any](messages []gjson.Result, convert func(gjson.Result) T) []T {
+	out := make([]T, len(messages))
+	workers := min(runtime.GOMAXPROCS(0), (len(messages)+parallelMessageBatch-1)/parallelMessageBatch)
+	if len(messages) < ParallelMessageThreshold || workers < 2 {
+		for i, message := range messages {
+			out[i] = convert(message)
+		}
+		return out
+	}
+
+	var (
+		next      atomic.Int64
+		wg        sync.WaitGroup
+		panicOnce sync.Once
+		panicVal  any
+	)
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				// Re-raised on the caller's goroutine so handler recovery still applies.
+				if r := recover(); r != nil {
+					panicOnce.Do(func() { panicVal = r })
+				}
+			}()
+			for {
+				end := int(next.Add(parallelMessageBatch))
+				start := end - parallelMessageBatch
+				if start >= len(messages) {
+					return
+				}
+				for i := start; i < min(end, len(messages)); i++ {
+					out[i] = convert(messages[i])
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if panicVal != nil {
+		panic(panicVal)
+	}
+	return out
+}
+
+// JoinRawArray returns a JSON array holding the raw JSON values of items in order.
+func JoinRawArray(items [][]byte) []byte {
+	size := 2
+	for _, item := range items {
+		size += len(item) + 1
+	}
+	out := make([]byte, 0, size)
+	out = append(out, '[')
+	for i, item := range items {
+		if i > 0 {
+			out = append(out, ',')
+		}
+		out = append(out, item...)
+	}
+	return append(out, ']')
+}
@@ -0,0 +1,57 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func parallelTestMessages(n int) []gjson.Result {
+	items := make([]string, n)
+	for i := range items {
+		items[i] = fmt.Sprintf(`{"index":%d}`, i)
+	}
+	return gjson.Parse("[" + strings.Join(items, ",") + "]").Array()
+}
+
+func TestMapMessagesKeepsOrder(t *testing.T) {
+	for _, n := range []int{0, 1, ParallelMessageThreshold - 1, ParallelMessageThreshold, 1000} {
+		got := MapMessages(parallelTestMessages(n), func(message gjson.Result) int64 {
+			return message.Get("index").Int()
+		})
+		if len(got) != n {
+			t.Fatalf("MapMessages(%d) returned %d results", n, len(got))
+		}
+		for i, index := range got {
+			if index != int64(i) {
+				t.Fatalf("MapMessages(%d)[%d] = %d, want %d", n, i, index, i)
+			}
+		}
+	}
+}
+
+func TestMapMessagesRepanicsOnCaller(t *testing.T) {
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Fatalf("recover() = %v, want boom", r)
+		}
+	}()
+	MapMessages(parallelTestMessages(1000), func(message gjson.Result) int64 {
+		if message.Get("index").Int() == 700 {
+			panic("boom")
+		}
+		return 0
+	})
+	t.Fatal("MapMessages did not panic")
+}
+
+func TestJoinRawArray(t *testing.T) {
+	if got := string(JoinRawArray(nil)); got != "[]" {
+		t.Fatalf("JoinRawArray(nil) = %s, want []", got)
+	}
+	if got := string(JoinRawArray([][]byte{[]byte(`{"a":1}`), []byte(`"b"`)})); got != `[{"a":1},"b"]` {
+		t.Fatalf("JoinRawArray() = %s", got)
+	}
+}
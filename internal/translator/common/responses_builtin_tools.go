@@ -0,0 +1,35 @@
+package common
+
+import "github.com/tidwall/gjson"
+
+// IsUnsupportedResponsesBuiltinTool reports whether an OpenAI Responses built-in tool type has no
+// equivalent in the Claude and Gemini APIs. There is no vector store or sandbox in the proxy
+// either, so their translators drop these tools.
+func IsUnsupportedResponsesBuiltinTool(toolType string) bool {
+	switch toolType {
+	case "file_search", "code_interpreter", "image_generation":
+		return true
+	default:
+		return false
+	}
+}
+
+// UnsupportedResponsesBuiltinTools returns the tool types of an OpenAI Responses request that
+// IsUnsupportedResponsesBuiltinTool reports, each once and in request order.
+func UnsupportedResponsesBuiltinTools(rawJSON []byte) []string {
+	var types []string
+	gjson.GetBytes(rawJSON, "tools").ForEach(func(_, tool gjson.Result) bool {
+		toolType := tool.Get("type").String()
+		if !IsUnsupportedResponsesBuiltinTool(toolType) {
+			return true
+		}
+		for _, seen := range types {
+			if seen == toolType {
+				return true
+			}
+		}
+		types = append(types, toolType)
+		return true
+	})
+	return types
+}
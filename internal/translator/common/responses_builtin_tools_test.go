@@ -0,0 +1,16 @@
+package common
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestUnsupportedResponsesBuiltinTools(t *testing.T) {
+	raw := []byte(`{"tools":[{"type":"function","name":"f"},{"type":"file_search"},{"type":"web_search"},{"type":"code_interpreter"},{"type":"file_search"}]}`)
+	if got := UnsupportedResponsesBuiltinTools(raw); !slices.Equal(got, []string{"file_search", "code_interpreter"}) {
+		t.Fatalf("UnsupportedResponsesBuiltinTools() = %v", got)
+	}
+	if got := UnsupportedResponsesBuiltinTools([]byte(`{"input":"hi"}`)); len(got) != 0 {
+		t.Fatalf("UnsupportedResponsesBuiltinTools() without tools = %v", got)
+	}
+}
@@ -0,0 +1,33 @@
+package common
+
+import "github.com/tidwall/sjson"
+
+// IsResponsesWebSearchTool reports whether an OpenAI Responses tool type is the built-in web
+// search tool.
+func IsResponsesWebSearchTool(toolType string) bool {
+	switch toolType {
+	case "web_search", "web_search_2025_08_26", "web_search_preview", "web_search_preview_2025_03_11":
+		return true
+	default:
+		return false
+	}
+}
+
+// ResponsesWebSearchCallItem builds a completed web_search_call output item for a search query.
+func ResponsesWebSearchCallItem(id, query string) []byte {
+	item := []byte(`{"id":"","type":"web_search_call","status":"completed","action":{"type":"search","query":""}}`)
+	item, _ = sjson.SetBytes(item, "id", id)
+	item, _ = sjson.SetBytes(item, "action.query", query)
+	return item
+}
+
+// AppendResponsesWebSearchSource adds a URL source to a web_search_call item. Empty URLs are skipped.
+func AppendResponsesWebSearchSource(item []byte, url string) []byte {
+	if url == "" {
+		return item
+	}
+	source := []byte(`{"type":"url","url":""}`)
+	source, _ = sjson.SetBytes(source, "url", url)
+	item, _ = sjson.SetRawBytes(item, "action.sources.-1", source)
+	return item
+}
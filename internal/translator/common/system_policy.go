@@ -0,0 +1,117 @@
+package common
+
+import (
+	"strings"
+	"sync/atomic"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// SystemMessagePolicy decides how OpenAI system and developer messages are folded into
+// upstream formats that carry a single system instruction (Claude "system", Gemini
+// "systemInstruction").
+//
+// Every policy treats "developer" exactly like "system".
+type SystemMessagePolicy string
+
+const (
+	// SystemMessagePolicyConcatenate merges every system message, in order, into the
+	// system instruction. This is the default.
+	SystemMessagePolicyConcatenate SystemMessagePolicy = "concatenate"
+	// SystemMessagePolicyFirstWins keeps only the first system message as the system
+	// instruction and drops the rest.
+	SystemMessagePolicyFirstWins SystemMessagePolicy = "first-wins"
+	// SystemMessagePolicyRoleDowngrade keeps the leading system messages as the system
+	// instruction and turns system messages that appear later in the conversation into
+	// user messages at their original position.
+	SystemMessagePolicyRoleDowngrade SystemMessagePolicy = "role-downgrade"
+)
+
+var systemMessagePolicy atomic.Value
+
+// ParseSystemMessagePolicy resolves a configured policy name. An empty name resolves to
+// SystemMessagePolicyConcatenate; ok is false for unknown names.
+func ParseSystemMessagePolicy(name string) (policy SystemMessagePolicy, ok bool) {
+	switch SystemMessagePolicy(strings.ToLower(strings.TrimSpace(name))) {
+	case "", SystemMessagePolicyConcatenate:
+		return SystemMessagePolicyConcatenate, true
+	case SystemMessagePolicyFirstWins:
+		return SystemMessagePolicyFirstWins, true
+	case SystemMessagePolicyRoleDowngrade:
+		return SystemMessagePolicyRoleDowngrade, true
+	default:
+		return SystemMessagePolicyConcatenate, false
+	}
+}
+
+// SetSystemMessagePolicy sets the policy used by NormalizeSystemMessages.
+func SetSystemMessagePolicy(policy SystemMessagePolicy) {
+	systemMessagePolicy.Store(policy)
+}
+
+// CurrentSystemMessagePolicy returns the policy used by NormalizeSystemMessages.
+func CurrentSystemMessagePolicy() SystemMessagePolicy {
+	if policy, ok := systemMessagePolicy.Load().(SystemMessagePolicy); ok {
+		return policy
+	}
+	return SystemMessagePolicyConcatenate
+}
+
+// NormalizeSystemMessages applies the current SystemMessagePolicy to OpenAI messages or
+// Responses input items. In the result every message whose role is "system" belongs to
+// the system instruction; developer messages are renamed to "system", messages the policy
+// downgrades are renamed to "user", and messages it discards are removed. Other items
+// are returned unchanged and in order.
+func NormalizeSystemMessages(messages []gjson.Result) []gjson.Result {
+	return normalizeSystemMessages(messages, CurrentSystemMessagePolicy())
+}
+
+func normalizeSystemMessages(messages []gjson.Result, policy SystemMessagePolicy) []gjson.Result {
+	out := make([]gjson.Result, 0, len(messages))
+	seenSystem, leading := false, true
+	for _, message := range messages {
+		role := strings.ToLower(message.Get("role").String())
+		if !isSystemRole(role) || !isMessageItem(message) {
+			leading = false
+			out = append(out, message)
+			continue
+		}
+
+		target := "system"
+		switch policy {
+		case SystemMessagePolicyFirstWins:
+			if seenSystem {
+				continue
+			}
+		case SystemMessagePolicyRoleDowngrade:
+			if !leading {
+				target = "user"
+			}
+		}
+		seenSystem = true
+		if role != target {
+			message = withRole(message, target)
+		}
+		out = append(out, message)
+	}
+	return out
+}
+
+func isSystemRole(role string) bool {
+	return role == "system" || role == "developer"
+}
+
+// isMessageItem reports whether a Responses input item is a message; chat messages have no type.
+func isMessageItem(item gjson.Result) bool {
+	itemType := item.Get("type").String()
+	return itemType == "" || itemType == "message"
+}
+
+func withRole(message gjson.Result, role string) gjson.Result {
+	raw, errSet := sjson.SetBytes([]byte(message.Raw), "role", role)
+	if errSet != nil {
+		return message
+	}
+	return gjson.ParseBytes(raw)
+}
@@ -0,0 +1,49 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestNormalizeSystemMessages(t *testing.T) {
+	messages := gjson.Parse(`[
+		{"role":"system","content":"a"},
+		{"role":"developer","content":"b"},
+		{"role":"user","content":"hi"},
+		{"role":"developer","content":"c"},
+		{"type":"function_call","role":"system","name":"f"}
+	]`).Array()
+
+	tests := []struct {
+		policy SystemMessagePolicy
+		want   []string
+	}{
+		{SystemMessagePolicyConcatenate, []string{"system:a", "system:b", "user:hi", "system:c", "system:"}},
+		{SystemMessagePolicyFirstWins, []string{"system:a", "user:hi", "system:"}},
+		{SystemMessagePolicyRoleDowngrade, []string{"system:a", "system:b", "user:hi", "user:c", "system:"}},
+	}
+	for _, tt := range tests {
+		got := normalizeSystemMessages(messages, tt.policy)
+		if len(got) != len(tt.want) {
+			t.Fatalf("%s: got %d messages, want %d", tt.policy, len(got), len(tt.want))
+		}
+		for i, message := range got {
+			if desc := message.Get("role").String() + ":" + message.Get("content").String(); desc != tt.want[i] {
+				t.Errorf("%s: message %d = %q, want %q", tt.policy, i, desc, tt.want[i])
+			}
+		}
+	}
+}
+
+func TestParseSystemMessagePolicy(t *testing.T) {
+	if policy, ok := ParseSystemMessagePolicy(""); !ok || policy != SystemMessagePolicyConcatenate {
+		t.Fatalf("empty policy = %q, %v", policy, ok)
+	}
+	if policy, ok := ParseSystemMessagePolicy(" First-Wins "); !ok || policy != SystemMessagePolicyFirstWins {
+		t.Fatalf("first-wins policy = %q, %v", policy, ok)
+	}
+	if _, ok := ParseSystemMessagePolicy("merge"); ok {
+		t.Fatal("unknown policy accepted")
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/translator/gemini/common"
 	. "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/gemini/openai/chat-completions"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
 	log "github.com/sirupsen/logrus"
@@ -27,6 +28,10 @@ type convertCliResponseToOpenAIChatParams struct {
 	SawToolCall          bool
 	UpstreamFinishReason string
 	SanitizedNameMap     map[string]string
+	// Text and Grounding collect the answer text and the latest grounding metadata, so
+	// citations can be reported with the final chunk.
+	Text      string
+	Grounding string
 }
 
 // functionCallIDCounter provides a process-wide unique counter for function call identifiers.
@@ -92,6 +97,9 @@ func ConvertCliResponseToOpenAI(_ context.Context, _ string, originalRequestRawJ
 	if stopReasonResult := gjson.GetBytes(rawJSON, "response.stop_reason"); stopReasonResult.Exists() && stopReasonResult.String() != "" {
 		(*param).(*convertCliResponseToOpenAIChatParams).UpstreamFinishReason = strings.ToUpper(stopReasonResult.String())
 	}
+	if groundingResult := gjson.GetBytes(rawJSON, "response.candidates.0.groundingMetadata"); groundingResult.Exists() {
+		(*param).(*convertCliResponseToOpenAIChatParams).Grounding = groundingResult.Raw
+	}
 
 	// Extract and set usage metadata (token counts).
 	if usageResult := gjson.GetBytes(rawJSON, "response.usageMetadata"); usageResult.Exists() {
@@ -151,6 +159,7 @@ func ConvertCliResponseToOpenAI(_ context.Context, _ string, originalRequestRawJ
 					template, _ = sjson.SetBytes(template, "choices.0.delta.reasoning_content", textContent)
 				} else {
 					template, _ = sjson.SetBytes(template, "choices.0.delta.content", textContent)
+					(*param).(*convertCliResponseToOpenAIChatParams).Text += textContent
 				}
 				template, _ = sjson.SetBytes(template, "choices.0.delta.role", "assistant")
 			} else if functionCallResult.Exists() {
@@ -219,6 +228,9 @@ func ConvertCliResponseToOpenAI(_ context.Context, _ string, originalRequestRawJ
 		}
 		template, _ = sjson.SetBytes(template, "choices.0.finish_reason", finishReason)
 		template, _ = sjson.SetBytes(template, "choices.0.native_finish_reason", strings.ToLower(upstreamFinishReason))
+		if annotations := common.GroundingURLCitations(gjson.Parse(params.Grounding), params.Text); annotations != nil {
+			template, _ = sjson.SetRawBytes(template, "choices.0.delta.annotations", annotations)
+		}
 	}
 
 	return [][]byte{template}
@@ -0,0 +1,100 @@
+package common
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/common"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// IsOpenAIWebSearchTool reports whether an OpenAI tool entry requests the built-in web search,
+// which Gemini serves with Google Search grounding.
+func IsOpenAIWebSearchTool(tool gjson.Result) bool {
+	toolType := tool.Get("type").String()
+	return toolType == "web_search" || strings.HasPrefix(toolType, "web_search_preview")
+}
+
+// OpenAIWebSearchRequested reports whether an OpenAI Chat Completions request asks for web
+// search through a web_search tool or the web_search_options parameter.
+func OpenAIWebSearchRequested(root gjson.Result) bool {
+	if root.Get("web_search_options").Exists() {
+		return true
+	}
+	for _, tool := range root.Get("tools").Array() {
+		if IsOpenAIWebSearchTool(tool) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsGoogleSearchTool reports whether a Gemini tool entry enables Google Search grounding.
+func IsGoogleSearchTool(tool gjson.Result) bool {
+	return tool.Get("googleSearch").Exists() || tool.Get("google_search").Exists() || tool.Get("googleSearchRetrieval").Exists()
+}
+
+// EnsureGoogleSearchTool appends a googleSearch tool to the Gemini tools array at path unless
+// one is already declared.
+func EnsureGoogleSearchTool(out []byte, path string) []byte {
+	tools := gjson.GetBytes(out, path)
+	for _, tool := range tools.Array() {
+		if IsGoogleSearchTool(tool) {
+			return out
+		}
+	}
+	if !tools.IsArray() {
+		out, _ = sjson.SetRawBytes(out, path, []byte(`[]`))
+	}
+	out, _ = sjson.SetRawBytes(out, path+".-1", []byte(`{"googleSearch":{}}`))
+	return out
+}
+
+// GroundingURLCitations converts the grounding metadata of a candidate into OpenAI Chat
+// Completions url_citation annotations. Each grounding support cites its chunks over the
+// supported span of text; Gemini reports byte offsets, which are converted to the character
+// offsets OpenAI uses. Sources not tied to a span are cited over the whole text. It returns nil
+// when the candidate was not grounded.
+func GroundingURLCitations(grounding gjson.Result, text string) []byte {
+	chunks := grounding.Get("groundingChunks").Array()
+	if len(chunks) == 0 {
+		return nil
+	}
+	annotations := []byte(`[]`)
+	cited := make(map[int]bool, len(chunks))
+	appendCitation := func(chunkIndex int, start, end int) {
+		if chunkIndex < 0 || chunkIndex >= len(chunks) {
+			return
+		}
+		web := chunks[chunkIndex].Get("web")
+		url := web.Get("uri").String()
+		if url == "" {
+			return
+		}
+		cited[chunkIndex] = true
+		annotations, _ = sjson.SetRawBytes(annotations, "-1", translatorcommon.URLCitationAnnotation(url, web.Get("title").String(), start, end))
+	}
+	for _, support := range grounding.Get("groundingSupports").Array() {
+		start := byteOffsetToCharOffset(text, int(support.Get("segment.startIndex").Int()))
+		end := byteOffsetToCharOffset(text, int(support.Get("segment.endIndex").Int()))
+		for _, index := range support.Get("groundingChunkIndices").Array() {
+			appendCitation(int(index.Int()), start, end)
+		}
+	}
+	textLength := utf8.RuneCountInString(text)
+	for i := range chunks {
+		if !cited[i] {
+			appendCitation(i, 0, textLength)
+		}
+	}
+	if len(gjson.ParseBytes(annotations).Array()) == 0 {
+		return nil
+	}
+	return annotations
+}
+
+func byteOffsetToCharOffset(text string, offset int) int {
+	offset = min(max(offset, 0), len(text))
+	return utf8.RuneCountInString(text[:offset])
+}
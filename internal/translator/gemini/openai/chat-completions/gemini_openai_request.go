@@ -8,6 +8,7 @@ import (
 
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/misc"
 	sigcompat "github.com/router-for-me/CLIProxyAPI/v7/internal/signature"
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/common"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/translator/gemini/common"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
 	log "github.com/sirupsen/logrus"
@@ -29,6 +30,7 @@ const geminiFunctionThoughtSignature = "skip_thought_signature_validator"
 //   - []byte: The transformed request data in Gemini API format
 func ConvertOpenAIRequestToGemini(modelName string, inputRawJSON []byte, _ bool) []byte {
 	rawJSON := inputRawJSON
+	toolNames := util.SanitizedToolNames(rawJSON)
 	// Base envelope (no default thinkingConfig)
 	out := []byte(`{"contents":[]}`)
 
@@ -140,7 +142,8 @@ func ConvertOpenAIRequestToGemini(modelName string, inputRawJSON []byte, _ bool)
 	// messages -> systemInstruction + contents
 	messages := gjson.GetBytes(rawJSON, "messages")
 	if messages.IsArray() {
-		arr := messages.Array()
+		// Developer and repeated system messages follow the configured system message policy.
+		arr := translatorcommon.NormalizeSystemMessages(messages.Array())
 		// First pass: assistant tool_calls id->name map
 		tcID2Name := map[string]string{}
 		for i := 0; i < len(arr); i++ {
@@ -162,7 +165,8 @@ func ConvertOpenAIRequestToGemini(modelName string, inputRawJSON []byte, _ bool)
 		}
 
 		// Second pass build systemInstruction/tool responses cache
-		toolResponses := map[string]string{} // tool_call_id -> response text
+		toolResponses := map[string]string{}                    // tool_call_id -> response text
+		toolImages := map[string][]util.ClaudeToolResultImage{} // tool_call_id -> images sent as inline data
 		for i := 0; i < len(arr); i++ {
 			m := arr[i]
 			role := m.Get("role").String()
@@ -170,7 +174,7 @@ func ConvertOpenAIRequestToGemini(modelName string, inputRawJSON []byte, _ bool)
 				toolCallID := m.Get("tool_call_id").String()
 				if toolCallID != "" {
 					c := m.Get("content")
-					toolResponses[toolCallID] = c.Raw
+					toolResponses[toolCallID], toolImages[toolCallID] = util.ExtractOpenAIToolResultImages(c)
 				}
 			}
 		}
@@ -181,7 +185,7 @@ func ConvertOpenAIRequestToGemini(modelName string, inputRawJSON []byte, _ bool)
 			role := m.Get("role").String()
 			content := m.Get("content")
 
-			if (role == "system" || role == "developer") && len(arr) > 1 {
+			if role == "system" && len(arr) > 1 {
 				// system -> systemInstruction as a user message style
 				if content.Type == gjson.String {
 					out, _ = sjson.SetBytes(out, "systemInstruction.role", "user")
@@ -201,7 +205,7 @@ func ConvertOpenAIRequestToGemini(modelName string, inputRawJSON []byte, _ bool)
 						}
 					}
 				}
-			} else if role == "user" || ((role == "system" || role == "developer") && len(arr) == 1) {
+			} else if role == "user" || (role == "system" && len(arr) == 1) {
 				// Build single user content node to avoid splitting into multiple contents
 				node := []byte(`{"role":"user","parts":[]}`)
 				if content.Type == gjson.String {
@@ -312,7 +316,7 @@ func ConvertOpenAIRequestToGemini(modelName string, inputRawJSON []byte, _ bool)
 							continue
 						}
 						fid := tc.Get("id").String()
-						fname := util.SanitizeFunctionName(tc.Get("function.name").String())
+						fname := util.SanitizeToolName(toolNames, tc.Get("function.name").String())
 						fargs := tc.Get("function.arguments").String()
 						node, _ = sjson.SetBytes(node, "parts."+itoa(p)+".functionCall.name", fname)
 						node, _ = sjson.SetRawBytes(node, "parts."+itoa(p)+".functionCall.args", []byte(fargs))
@@ -329,13 +333,18 @@ func ConvertOpenAIRequestToGemini(modelName string, inputRawJSON []byte, _ bool)
 					pp := 0
 					for _, fid := range fIDs {
 						if name, ok := tcID2Name[fid]; ok {
-							toolNode, _ = sjson.SetBytes(toolNode, "parts."+itoa(pp)+".functionResponse.name", util.SanitizeFunctionName(name))
+							toolNode, _ = sjson.SetBytes(toolNode, "parts."+itoa(pp)+".functionResponse.name", util.SanitizeToolName(toolNames, name))
 							resp := toolResponses[fid]
 							if resp == "" {
 								resp = "{}"
 							}
 							toolNode, _ = sjson.SetBytes(toolNode, "parts."+itoa(pp)+".functionResponse.response.result", []byte(resp))
 							pp++
+							for _, img := range toolImages[fid] {
+								toolNode, _ = sjson.SetBytes(toolNode, "parts."+itoa(pp)+".inlineData.mime_type", img.MimeType)
+								toolNode, _ = sjson.SetBytes(toolNode, "parts."+itoa(pp)+".inlineData.data", img.Data)
+								pp++
+							}
 						}
 					}
 					if pp > 0 {
@@ -407,7 +416,7 @@ func ConvertOpenAIRequestToGemini(modelName string, inputRawJSON []byte, _ bool)
 						fnRaw = string(fnRawBytes)
 					}
 					fnRawBytes := []byte(fnRaw)
-					fnRawBytes, _ = sjson.SetBytes(fnRawBytes, "name", util.SanitizeFunctionName(fn.Get("name").String()))
+					fnRawBytes, _ = sjson.SetBytes(fnRawBytes, "name", util.SanitizeToolName(toolNames, fn.Get("name").String()))
 					fnRaw = string(fnRawBytes)
 					if parameters := gjson.Get(fnRaw, "parametersJsonSchema"); parameters.Exists() {
 						fnRaw, _ = sjson.SetRaw(fnRaw, "parametersJsonSchema", util.CleanJSONSchemaForGemini(parameters.Raw))
@@ -474,6 +483,11 @@ func ConvertOpenAIRequestToGemini(modelName string, inputRawJSON []byte, _ bool)
 		}
 	}
 
+	// The OpenAI built-in web search is served by Google Search grounding
+	if common.OpenAIWebSearchRequested(gjson.ParseBytes(rawJSON)) {
+		out = common.EnsureGoogleSearchTool(out, "tools")
+	}
+
 	out = common.AttachDefaultSafetySettings(out, "safetySettings")
 
 	return out
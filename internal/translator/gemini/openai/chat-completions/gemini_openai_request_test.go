@@ -182,3 +182,65 @@ func TestConvertOpenAIRequestToGeminiCleansToolSchemaRequiredFields(t *testing.T
 		t.Fatalf("required[1] = %q, want industry. Schema: %s", got, schema.Raw)
 	}
 }
+
+func TestConvertOpenAIRequestToGeminiSendsToolResultImagesAsInlineData(t *testing.T) {
+	inputJSON := `{
+		"model": "gemini-3-flash",
+		"messages": [
+			{"role": "user", "content": "take a screenshot"},
+			{
+				"role": "assistant",
+				"tool_calls": [{
+					"id": "call_1",
+					"type": "function",
+					"function": {"name": "screenshot", "arguments": "{}"}
+				}]
+			},
+			{"role": "tool", "tool_call_id": "call_1", "content": [
+				{"type": "text", "text": "captured"},
+				{"type": "image_url", "image_url": {"url": "data:image/png;base64,iVBORw0KGgo="}}
+			]}
+		]
+	}`
+
+	result := ConvertOpenAIRequestToGemini("gemini-3-flash", []byte(inputJSON), false)
+	toolParts := gjson.GetBytes(result, "contents.2.parts").Array()
+	if len(toolParts) != 2 {
+		t.Fatalf("tool parts length = %d, want 2. Output: %s", len(toolParts), result)
+	}
+	if got := toolParts[0].Get("functionResponse.response.result").String(); got != "captured" {
+		t.Fatalf("functionResponse result = %q, want captured. Output: %s", got, result)
+	}
+	if got := toolParts[1].Get("inlineData.mime_type").String(); got != "image/png" {
+		t.Fatalf("inlineData mime_type = %q, want image/png. Output: %s", got, result)
+	}
+	if got := toolParts[1].Get("inlineData.data").String(); got != "iVBORw0KGgo=" {
+		t.Fatalf("inlineData data = %q. Output: %s", got, result)
+	}
+}
+
+func TestConvertOpenAIRequestToGeminiMapsWebSearchToGoogleSearch(t *testing.T) {
+	inputJSON := `{
+		"model": "gemini-2.5-flash",
+		"messages": [{"role": "user", "content": "latest go release?"}],
+		"tools": [
+			{"type": "web_search"},
+			{"type": "function", "function": {"name": "lookup", "parameters": {"type": "object"}}}
+		]
+	}`
+
+	result := ConvertOpenAIRequestToGemini("gemini-2.5-flash", []byte(inputJSON), false)
+	tools := gjson.GetBytes(result, "tools").Array()
+	searchTools := 0
+	for _, tool := range tools {
+		if tool.Get("googleSearch").Exists() {
+			searchTools++
+		}
+	}
+	if searchTools != 1 {
+		t.Fatalf("googleSearch tools = %d, want 1. tools=%s", searchTools, gjson.GetBytes(result, "tools").Raw)
+	}
+	if got := gjson.GetBytes(result, "tools.#.functionDeclarations.0.name").Array(); len(got) != 1 || got[0].String() != "lookup" {
+		t.Fatalf("function declarations = %v, want lookup", got)
+	}
+}
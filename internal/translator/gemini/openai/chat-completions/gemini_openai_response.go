@@ -13,6 +13,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/translator/gemini/common"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
 	log "github.com/sirupsen/logrus"
 	"github.com/tidwall/gjson"
@@ -27,6 +28,10 @@ type convertGeminiResponseToOpenAIChatParams struct {
 	SawToolCall          map[int]bool
 	UpstreamFinishReason map[int]string
 	SanitizedNameMap     map[string]string
+	// Text and Grounding collect the answer text and the latest grounding metadata per
+	// candidate, so citations can be reported with the final chunk.
+	Text      map[int]string
+	Grounding map[int]string
 }
 
 // functionCallIDCounter provides a process-wide unique counter for function call identifiers.
@@ -55,6 +60,8 @@ func ConvertGeminiResponseToOpenAI(_ context.Context, _ string, originalRequestR
 			SawToolCall:          make(map[int]bool),
 			UpstreamFinishReason: make(map[int]string),
 			SanitizedNameMap:     util.SanitizedToolNameMap(originalRequestRawJSON),
+			Text:                 make(map[int]string),
+			Grounding:            make(map[int]string),
 		}
 	}
 
@@ -72,6 +79,12 @@ func ConvertGeminiResponseToOpenAI(_ context.Context, _ string, originalRequestR
 	if p.SanitizedNameMap == nil {
 		p.SanitizedNameMap = util.SanitizedToolNameMap(originalRequestRawJSON)
 	}
+	if p.Text == nil {
+		p.Text = make(map[int]string)
+	}
+	if p.Grounding == nil {
+		p.Grounding = make(map[int]string)
+	}
 
 	if bytes.HasPrefix(rawJSON, []byte("data:")) {
 		rawJSON = bytes.TrimSpace(rawJSON[5:])
@@ -148,6 +161,9 @@ func ConvertGeminiResponseToOpenAI(_ context.Context, _ string, originalRequestR
 			if finishReasonResult := candidate.Get("finishReason"); finishReasonResult.Exists() {
 				p.UpstreamFinishReason[candidateIndex] = strings.ToUpper(finishReasonResult.String())
 			}
+			if grounding := candidate.Get("groundingMetadata"); grounding.Exists() {
+				p.Grounding[candidateIndex] = grounding.Raw
+			}
 
 			partsResult := candidate.Get("content.parts")
 
@@ -181,6 +197,7 @@ func ConvertGeminiResponseToOpenAI(_ context.Context, _ string, originalRequestR
 							template, _ = sjson.SetBytes(template, "choices.0.delta.reasoning_content", text)
 						} else {
 							template, _ = sjson.SetBytes(template, "choices.0.delta.content", text)
+							p.Text[candidateIndex] += text
 						}
 						template, _ = sjson.SetBytes(template, "choices.0.delta.role", "assistant")
 					} else if functionCallResult.Exists() {
@@ -251,6 +268,9 @@ func ConvertGeminiResponseToOpenAI(_ context.Context, _ string, originalRequestR
 				}
 				template, _ = sjson.SetBytes(template, "choices.0.finish_reason", finishReason)
 				template, _ = sjson.SetBytes(template, "choices.0.native_finish_reason", strings.ToLower(upstreamFinishReason))
+				if annotations := common.GroundingURLCitations(gjson.Parse(p.Grounding[candidateIndex]), p.Text[candidateIndex]); annotations != nil {
+					template, _ = sjson.SetRawBytes(template, "choices.0.delta.annotations", annotations)
+				}
 			}
 
 			responseStrings = append(responseStrings, template)
@@ -408,6 +428,10 @@ func ConvertGeminiResponseToOpenAINonStream(_ context.Context, _ string, origina
 				}
 			}
 
+			if annotations := common.GroundingURLCitations(candidate.Get("groundingMetadata"), gjson.GetBytes(choiceTemplate, "message.content").String()); annotations != nil {
+				choiceTemplate, _ = sjson.SetRawBytes(choiceTemplate, "message.annotations", annotations)
+			}
+
 			if hasFunctionCall {
 				choiceTemplate, _ = sjson.SetBytes(choiceTemplate, "finish_reason", "tool_calls")
 				choiceTemplate, _ = sjson.SetBytes(choiceTemplate, "native_finish_reason", "tool_calls")
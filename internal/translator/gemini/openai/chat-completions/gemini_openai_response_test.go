@@ -38,3 +38,49 @@ func TestGeminiFinishReasonOnlyOnFinalChunk(t *testing.T) {
 		t.Fatalf("expected native_finish_reason stop, got %s", nfr3)
 	}
 }
+
+func TestGeminiToolNamesRoundTripForOpenAIChatRequests(t *testing.T) {
+	original := []byte(`{"model":"m","messages":[{"role":"user","content":"hi"}],"tools":[
+		{"type":"function","function":{"name":"read/file","parameters":{"type":"object"}}},
+		{"type":"function","function":{"name":"read@file","parameters":{"type":"object"}}}
+	]}`)
+	request := ConvertOpenAIRequestToGemini("gemini-2.0-flash", original, false)
+	declared := gjson.GetBytes(request, "tools.0.functionDeclarations.#.name").Array()
+	if len(declared) != 2 || declared[0].String() == declared[1].String() {
+		t.Fatalf("declared names = %v, want two distinct names", declared)
+	}
+
+	response := []byte(`{"candidates":[{"content":{"parts":[{"functionCall":{"name":"` + declared[1].String() + `","args":{}}}]},"finishReason":"STOP"}]}`)
+	out := ConvertGeminiResponseToOpenAINonStream(context.Background(), "m", original, request, response, nil)
+	if got := gjson.GetBytes(out, "choices.0.message.tool_calls.0.function.name").String(); got != "read@file" {
+		t.Fatalf("tool call name = %q, want read@file; out=%s", got, out)
+	}
+}
+
+func TestGeminiGroundingMetadataBecomesURLCitations(t *testing.T) {
+	grounding := `"groundingMetadata":{"groundingChunks":[{"web":{"uri":"https://go.dev/doc","title":"go.dev"}},{"web":{"uri":"https://example.com","title":"example"}}],"groundingSupports":[{"segment":{"startIndex":7,"endIndex":15},"groundingChunkIndices":[0]}]}`
+	raw := []byte(`{"candidates":[{"content":{"parts":[{"text":"Answer: Go 1.26."}]},"finishReason":"STOP",` + grounding + `}],"usageMetadata":{"promptTokenCount":1,"candidatesTokenCount":2,"totalTokenCount":3}}`)
+
+	out := ConvertGeminiResponseToOpenAINonStream(context.Background(), "model", nil, nil, raw, nil)
+	annotations := gjson.GetBytes(out, "choices.0.message.annotations").Array()
+	if len(annotations) != 2 {
+		t.Fatalf("annotations = %s, want 2", gjson.GetBytes(out, "choices.0.message.annotations").Raw)
+	}
+	first := annotations[0].Get("url_citation")
+	if annotations[0].Get("type").String() != "url_citation" || first.Get("url").String() != "https://go.dev/doc" || first.Get("start_index").Int() != 7 || first.Get("end_index").Int() != 15 {
+		t.Fatalf("first annotation = %s", annotations[0].Raw)
+	}
+	if second := annotations[1].Get("url_citation"); second.Get("start_index").Int() != 0 || second.Get("end_index").Int() != 16 {
+		t.Fatalf("uncited source annotation = %s", annotations[1].Raw)
+	}
+
+	var param any
+	ConvertGeminiResponseToOpenAI(context.Background(), "model", nil, nil, []byte(`{"candidates":[{"content":{"parts":[{"text":"Answer: "}]}}]}`), &param)
+	final := ConvertGeminiResponseToOpenAI(context.Background(), "model", nil, nil, raw, &param)
+	if len(final) != 1 {
+		t.Fatalf("expected 1 final chunk, got %d", len(final))
+	}
+	if got := gjson.GetBytes(final[0], "choices.0.delta.annotations.#").Int(); got != 2 {
+		t.Fatalf("streamed annotations = %s", gjson.GetBytes(final[0], "choices.0.delta.annotations").Raw)
+	}
+}
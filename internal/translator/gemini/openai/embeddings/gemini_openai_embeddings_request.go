@@ -0,0 +1,49 @@
+// Package embeddings translates OpenAI embeddings requests into Gemini batchEmbedContents
+// requests and Gemini embeddings back into OpenAI embeddings responses.
+package embeddings
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ConvertOpenAIRequestToGemini converts an OpenAI embeddings request into a Gemini
+// batchEmbedContents request with one entry per input. Gemini embeds text only, so inputs given
+// as token arrays are rejected.
+func ConvertOpenAIRequestToGemini(modelName string, rawJSON []byte) ([]byte, error) {
+	input := gjson.GetBytes(rawJSON, "input")
+	var texts []string
+	switch {
+	case input.Type == gjson.String:
+		texts = append(texts, input.String())
+	case input.IsArray():
+		for _, item := range input.Array() {
+			if item.Type != gjson.String {
+				return nil, errors.New("embeddings input must be a string or an array of strings")
+			}
+			texts = append(texts, item.String())
+		}
+	default:
+		return nil, errors.New("embeddings input must be a string or an array of strings")
+	}
+	if len(texts) == 0 {
+		return nil, errors.New("embeddings input must not be empty")
+	}
+
+	model := "models/" + strings.TrimPrefix(modelName, "models/")
+	dimensions := gjson.GetBytes(rawJSON, "dimensions").Int()
+	out := []byte(`{"requests":[]}`)
+	for _, text := range texts {
+		request := []byte(`{}`)
+		request, _ = sjson.SetBytes(request, "model", model)
+		request, _ = sjson.SetBytes(request, "content.parts.0.text", text)
+		if dimensions > 0 {
+			request, _ = sjson.SetBytes(request, "outputDimensionality", dimensions)
+		}
+		out, _ = sjson.SetRawBytes(out, "requests.-1", request)
+	}
+	return out, nil
+}
@@ -0,0 +1,45 @@
+package embeddings
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"math"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ConvertGeminiResponseToOpenAI converts a Gemini batchEmbedContents response into an OpenAI
+// embeddings response. With encoding_format "base64" in originalRequest each embedding is
+// returned as base64 of its little-endian float32 values, as OpenAI does. Gemini reports no
+// token usage for embeddings, so usage is zero.
+func ConvertGeminiResponseToOpenAI(modelName string, originalRequest, rawJSON []byte) []byte {
+	encodeBase64 := strings.EqualFold(gjson.GetBytes(originalRequest, "encoding_format").String(), "base64")
+	out := []byte(`{"object":"list","data":[],"model":"","usage":{"prompt_tokens":0,"total_tokens":0}}`)
+	out, _ = sjson.SetBytes(out, "model", modelName)
+	for index, embedding := range gjson.GetBytes(rawJSON, "embeddings").Array() {
+		item := []byte(`{"object":"embedding","index":0}`)
+		item, _ = sjson.SetBytes(item, "index", index)
+		values := embedding.Get("values")
+		switch {
+		case encodeBase64:
+			item, _ = sjson.SetBytes(item, "embedding", encodeEmbedding(values))
+		case values.IsArray():
+			item, _ = sjson.SetRawBytes(item, "embedding", []byte(values.Raw))
+		default:
+			item, _ = sjson.SetRawBytes(item, "embedding", []byte(`[]`))
+		}
+		out, _ = sjson.SetRawBytes(out, "data.-1", item)
+	}
+	return out
+}
+
+func encodeEmbedding(values gjson.Result) string {
+	floats := values.Array()
+	buf := make([]byte, 4*len(floats))
+	for i, value := range floats {
+		binary.LittleEndian.PutUint32(buf[4*i:], math.Float32bits(float32(value.Float())))
+	}
+	return base64.StdEncoding.EncodeToString(buf)
+}
@@ -0,0 +1,89 @@
+package embeddings
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestConvertOpenAIRequestToGeminiBatchesInputs(t *testing.T) {
+	out, err := ConvertOpenAIRequestToGemini("gemini-embedding-001", []byte(`{"model":"gemini-embedding-001","input":["first","second"],"dimensions":256}`))
+	if err != nil {
+		t.Fatalf("convert: %v", err)
+	}
+	requests := gjson.GetBytes(out, "requests").Array()
+	if len(requests) != 2 {
+		t.Fatalf("requests = %d, want 2: %s", len(requests), out)
+	}
+	for i, want := range []string{"first", "second"} {
+		if got := requests[i].Get("content.parts.0.text").String(); got != want {
+			t.Fatalf("request %d text = %q, want %q", i, got, want)
+		}
+		if got := requests[i].Get("model").String(); got != "models/gemini-embedding-001" {
+			t.Fatalf("request %d model = %q", i, got)
+		}
+		if got := requests[i].Get("outputDimensionality").Int(); got != 256 {
+			t.Fatalf("request %d outputDimensionality = %d, want 256", i, got)
+		}
+	}
+
+	single, err := ConvertOpenAIRequestToGemini("gemini-embedding-001", []byte(`{"input":"only"}`))
+	if err != nil {
+		t.Fatalf("convert string input: %v", err)
+	}
+	if got := gjson.GetBytes(single, "requests.#").Int(); got != 1 {
+		t.Fatalf("requests = %d, want 1", got)
+	}
+	if gjson.GetBytes(single, "requests.0.outputDimensionality").Exists() {
+		t.Fatalf("outputDimensionality set without dimensions: %s", single)
+	}
+}
+
+func TestConvertOpenAIRequestToGeminiRejectsUnsupportedInput(t *testing.T) {
+	for _, body := range []string{`{"input":[1,2,3]}`, `{"input":[]}`, `{}`} {
+		if _, err := ConvertOpenAIRequestToGemini("gemini-embedding-001", []byte(body)); err == nil {
+			t.Fatalf("expected error for %s", body)
+		}
+	}
+}
+
+func TestConvertGeminiResponseToOpenAI(t *testing.T) {
+	response := []byte(`{"embeddings":[{"values":[0.5,-1]},{"values":[0.25]}]}`)
+	out := ConvertGeminiResponseToOpenAI("gemini-embedding-001", []byte(`{"input":["a","b"]}`), response)
+	if got := gjson.GetBytes(out, "object").String(); got != "list" {
+		t.Fatalf("object = %q, want list", got)
+	}
+	if got := gjson.GetBytes(out, "model").String(); got != "gemini-embedding-001" {
+		t.Fatalf("model = %q", got)
+	}
+	data := gjson.GetBytes(out, "data").Array()
+	if len(data) != 2 {
+		t.Fatalf("data = %d, want 2: %s", len(data), out)
+	}
+	if data[1].Get("index").Int() != 1 || data[1].Get("object").String() != "embedding" {
+		t.Fatalf("unexpected second item: %s", data[1].Raw)
+	}
+	if got := data[0].Get("embedding").Raw; got != `[0.5,-1]` {
+		t.Fatalf("embedding = %s, want [0.5,-1]", got)
+	}
+}
+
+func TestConvertGeminiResponseToOpenAIBase64(t *testing.T) {
+	response := []byte(`{"embeddings":[{"values":[0.5,-1]}]}`)
+	out := ConvertGeminiResponseToOpenAI("gemini-embedding-001", []byte(`{"input":"a","encoding_format":"base64"}`), response)
+	raw, err := base64.StdEncoding.DecodeString(gjson.GetBytes(out, "data.0.embedding").String())
+	if err != nil {
+		t.Fatalf("decode embedding: %v", err)
+	}
+	if len(raw) != 8 {
+		t.Fatalf("decoded %d bytes, want 8", len(raw))
+	}
+	for i, want := range []float32{0.5, -1} {
+		if got := math.Float32frombits(binary.LittleEndian.Uint32(raw[4*i:])); got != want {
+			t.Fatalf("value %d = %v, want %v", i, got, want)
+		}
+	}
+}
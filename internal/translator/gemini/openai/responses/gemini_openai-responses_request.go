@@ -6,8 +6,10 @@ import (
 	"strings"
 
 	sigcompat "github.com/router-for-me/CLIProxyAPI/v7/internal/signature"
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/common"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/translator/gemini/common"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	log "github.com/sirupsen/logrus"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -16,6 +18,7 @@ const geminiResponsesThoughtSignature = "skip_thought_signature_validator"
 
 func ConvertOpenAIResponsesRequestToGemini(modelName string, inputRawJSON []byte, stream bool) []byte {
 	rawJSON := inputRawJSON
+	toolNames := util.SanitizedToolNames(rawJSON)
 
 	// Note: stream parameter is part of the fixed method signature
 	useGeminiNativeReasoningLayout := sigcompat.SignatureProviderFromModelName(modelName) == sigcompat.SignatureProviderGemini
@@ -35,7 +38,9 @@ func ConvertOpenAIResponsesRequestToGemini(modelName string, inputRawJSON []byte
 
 	// Convert input messages to Gemini contents format
 	if input := root.Get("input"); input.Exists() && input.IsArray() {
-		items := input.Array()
+		// Developer and repeated system messages follow the configured system message policy;
+		// the "instructions" field above always stays in the system instruction.
+		items := translatorcommon.NormalizeSystemMessages(input.Array())
 
 		// Normalize consecutive function calls and outputs so each call is immediately followed by its response
 		normalized := make([]gjson.Result, 0, len(items))
@@ -295,7 +300,7 @@ func ConvertOpenAIResponsesRequestToGemini(modelName string, inputRawJSON []byte
 
 			case "function_call":
 				// Handle function calls - convert to model message with functionCall
-				name := util.SanitizeFunctionName(item.Get("name").String())
+				name := util.SanitizeToolName(toolNames, item.Get("name").String())
 				arguments := item.Get("arguments").String()
 
 				modelContent := []byte(`{"role":"model","parts":[]}`)
@@ -337,7 +342,7 @@ func ConvertOpenAIResponsesRequestToGemini(modelName string, inputRawJSON []byte
 						return true
 					})
 				}
-				functionName = util.SanitizeFunctionName(functionName)
+				functionName = util.SanitizeToolName(toolNames, functionName)
 
 				functionResponse, _ = sjson.SetBytes(functionResponse, "functionResponse.name", functionName)
 				functionResponse, _ = sjson.SetBytes(functionResponse, "functionResponse.id", callID)
@@ -392,13 +397,22 @@ func ConvertOpenAIResponsesRequestToGemini(modelName string, inputRawJSON []byte
 	// Convert tools to Gemini functionDeclarations format
 	if tools := root.Get("tools"); tools.Exists() && tools.IsArray() {
 		geminiTools := []byte(`[{"functionDeclarations":[]}]`)
+		webSearch := false
 
 		tools.ForEach(func(_, tool gjson.Result) bool {
+			if translatorcommon.IsResponsesWebSearchTool(tool.Get("type").String()) {
+				webSearch = true
+				return true
+			}
+			if toolType := tool.Get("type").String(); translatorcommon.IsUnsupportedResponsesBuiltinTool(toolType) {
+				log.Warnf("gemini responses translator: dropping built-in tool %s, which Gemini does not support", toolType)
+				return true
+			}
 			if tool.Get("type").String() == "function" {
 				funcDecl := []byte(`{"name":"","description":"","parametersJsonSchema":{}}`)
 
 				if name := tool.Get("name"); name.Exists() {
-					funcDecl, _ = sjson.SetBytes(funcDecl, "name", util.SanitizeFunctionName(name.String()))
+					funcDecl, _ = sjson.SetBytes(funcDecl, "name", util.SanitizeToolName(toolNames, name.String()))
 				}
 				if desc := tool.Get("description"); desc.Exists() {
 					funcDecl, _ = sjson.SetBytes(funcDecl, "description", desc.String())
@@ -412,8 +426,15 @@ func ConvertOpenAIResponsesRequestToGemini(modelName string, inputRawJSON []byte
 			return true
 		})
 
-		// Only add tools if there are function declarations
-		if funcDecls := gjson.GetBytes(geminiTools, "0.functionDeclarations"); funcDecls.Exists() && len(funcDecls.Array()) > 0 {
+		// Only keep the function declarations tool if there are declarations
+		if funcDecls := gjson.GetBytes(geminiTools, "0.functionDeclarations"); !funcDecls.Exists() || len(funcDecls.Array()) == 0 {
+			geminiTools = []byte(`[]`)
+		}
+		// The built-in web search tool is served by Google Search grounding
+		if webSearch {
+			geminiTools, _ = sjson.SetRawBytes(geminiTools, "-1", []byte(`{"googleSearch":{}}`))
+		}
+		if gjson.GetBytes(geminiTools, "#").Int() > 0 {
 			out, _ = sjson.SetRawBytes(out, "tools", geminiTools)
 		}
 	}
@@ -43,6 +43,11 @@ type geminiToResponsesState struct {
 	FuncCallIDs      map[int]string
 	FuncDone         map[int]bool
 	SanitizedNameMap map[string]string
+
+	// web search (Google Search grounding) aggregation
+	WebSearchGrounding string
+	WebSearchIndex     int
+	WebSearchItem      []byte
 }
 
 // responseIDCounter provides a process-wide unique counter for synthesized response identifiers.
@@ -186,12 +191,19 @@ func ConvertGeminiResponseToOpenAIResponses(_ context.Context, modelName string,
 		partDone, _ = sjson.SetBytes(partDone, "item_id", st.CurrentMsgID)
 		partDone, _ = sjson.SetBytes(partDone, "output_index", st.MsgIndex)
 		partDone, _ = sjson.SetBytes(partDone, "part.text", fullText)
+		annotations := geminiGroundingAnnotations(gjson.Parse(st.WebSearchGrounding), fullText)
+		if annotations != nil {
+			partDone, _ = sjson.SetRawBytes(partDone, "part.annotations", annotations)
+		}
 		out = append(out, emitEvent("response.content_part.done", partDone))
 		final := []byte(`{"type":"response.output_item.done","sequence_number":0,"output_index":0,"item":{"id":"","type":"message","status":"completed","content":[{"type":"output_text","text":""}],"role":"assistant"}}`)
 		final, _ = sjson.SetBytes(final, "sequence_number", nextSeq())
 		final, _ = sjson.SetBytes(final, "output_index", st.MsgIndex)
 		final, _ = sjson.SetBytes(final, "item.id", st.CurrentMsgID)
 		final, _ = sjson.SetBytes(final, "item.content.0.text", fullText)
+		if annotations != nil {
+			final, _ = sjson.SetRawBytes(final, "item.content.0.annotations", annotations)
+		}
 		out = append(out, emitEvent("response.output_item.done", final))
 
 		st.MsgClosed = true
@@ -380,12 +392,39 @@ func ConvertGeminiResponseToOpenAIResponses(_ context.Context, modelName string,
 		})
 	}
 
+	if grounding := geminiGroundingMetadata(root); grounding.Exists() {
+		st.WebSearchGrounding = grounding.Raw
+	}
+
 	// Finalization on finishReason
 	if fr := root.Get("candidates.0.finishReason"); fr.Exists() && fr.String() != "" {
 		// Finalize reasoning first to keep ordering tight with last delta
 		finalizeReasoning()
 		finalizeMessage()
 
+		// Grounding arrives with the answer, so the web search call is reported once it is known.
+		if st.WebSearchGrounding != "" && st.WebSearchItem == nil {
+			st.WebSearchIndex = st.NextIndex
+			st.NextIndex++
+			itemID := fmt.Sprintf("ws_%s_%d", st.ResponseID, st.WebSearchIndex)
+			st.WebSearchItem = geminiWebSearchCallItem(itemID, gjson.Parse(st.WebSearchGrounding))
+			added := []byte(`{"type":"response.output_item.added","sequence_number":0,"output_index":0,"item":{"id":"","type":"web_search_call","status":"in_progress"}}`)
+			added, _ = sjson.SetBytes(added, "sequence_number", nextSeq())
+			added, _ = sjson.SetBytes(added, "output_index", st.WebSearchIndex)
+			added, _ = sjson.SetBytes(added, "item.id", itemID)
+			out = append(out, emitEvent("response.output_item.added", added))
+			searchDone := []byte(`{"type":"response.web_search_call.completed","sequence_number":0,"output_index":0,"item_id":""}`)
+			searchDone, _ = sjson.SetBytes(searchDone, "sequence_number", nextSeq())
+			searchDone, _ = sjson.SetBytes(searchDone, "output_index", st.WebSearchIndex)
+			searchDone, _ = sjson.SetBytes(searchDone, "item_id", itemID)
+			out = append(out, emitEvent("response.web_search_call.completed", searchDone))
+			itemDone := []byte(`{"type":"response.output_item.done","sequence_number":0,"output_index":0,"item":{}}`)
+			itemDone, _ = sjson.SetBytes(itemDone, "sequence_number", nextSeq())
+			itemDone, _ = sjson.SetBytes(itemDone, "output_index", st.WebSearchIndex)
+			itemDone, _ = sjson.SetRawBytes(itemDone, "item", st.WebSearchItem)
+			out = append(out, emitEvent("response.output_item.done", itemDone))
+		}
+
 		// Close function calls
 		if len(st.FuncArgsBuf) > 0 {
 			// sort indices (small N); avoid extra imports
@@ -515,9 +554,16 @@ func ConvertGeminiResponseToOpenAIResponses(_ context.Context, modelName string,
 				item := []byte(`{"id":"","type":"message","status":"completed","content":[{"type":"output_text","annotations":[],"logprobs":[],"text":""}],"role":"assistant"}`)
 				item, _ = sjson.SetBytes(item, "id", st.CurrentMsgID)
 				item, _ = sjson.SetBytes(item, "content.0.text", st.TextBuf.String())
+				if annotations := geminiGroundingAnnotations(gjson.Parse(st.WebSearchGrounding), st.TextBuf.String()); annotations != nil {
+					item, _ = sjson.SetRawBytes(item, "content.0.annotations", annotations)
+				}
 				outputsWrapper, _ = sjson.SetRawBytes(outputsWrapper, "arr.-1", item)
 				continue
 			}
+			if st.WebSearchItem != nil && idx == st.WebSearchIndex {
+				outputsWrapper, _ = sjson.SetRawBytes(outputsWrapper, "arr.-1", st.WebSearchItem)
+				continue
+			}
 
 			if callID, ok := st.FuncCallIDs[idx]; ok && callID != "" {
 				args := "{}"
@@ -720,6 +766,11 @@ func ConvertGeminiResponseToOpenAIResponsesNonStream(_ context.Context, _ string
 		})
 	}
 
+	// Web search call reported by Google Search grounding
+	if grounding := geminiGroundingMetadata(root); grounding.Exists() {
+		appendOutput(geminiWebSearchCallItem(fmt.Sprintf("ws_%s", strings.TrimPrefix(id, "resp_")), grounding))
+	}
+
 	// Reasoning output item
 	if reasoningText.Len() > 0 || reasoningEncrypted != "" {
 		rid := strings.TrimPrefix(id, "resp_")
@@ -740,6 +791,9 @@ func ConvertGeminiResponseToOpenAIResponsesNonStream(_ context.Context, _ string
 		itemJSON := []byte(`{"id":"","type":"message","status":"completed","content":[{"type":"output_text","annotations":[],"logprobs":[],"text":""}],"role":"assistant"}`)
 		itemJSON, _ = sjson.SetBytes(itemJSON, "id", fmt.Sprintf("msg_%s_0", strings.TrimPrefix(id, "resp_")))
 		itemJSON, _ = sjson.SetBytes(itemJSON, "content.0.text", messageText.String())
+		if annotations := geminiGroundingAnnotations(geminiGroundingMetadata(root), messageText.String()); annotations != nil {
+			itemJSON, _ = sjson.SetRawBytes(itemJSON, "content.0.annotations", annotations)
+		}
 		appendOutput(itemJSON)
 	}
 
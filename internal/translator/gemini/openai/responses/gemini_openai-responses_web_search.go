@@ -0,0 +1,47 @@
+package responses
+
+import (
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/common"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/translator/gemini/common"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// geminiGroundingMetadata returns the grounding metadata of the first candidate, if Google Search
+// was used to answer.
+func geminiGroundingMetadata(root gjson.Result) gjson.Result {
+	grounding := root.Get("candidates.0.groundingMetadata")
+	if !grounding.Exists() || (!grounding.Get("webSearchQueries").Exists() && !grounding.Get("groundingChunks").Exists()) {
+		return gjson.Result{}
+	}
+	return grounding
+}
+
+// geminiWebSearchCallItem builds a completed web_search_call output item from Gemini grounding
+// metadata: the first search query becomes the action query and grounding chunks its sources.
+func geminiWebSearchCallItem(id string, grounding gjson.Result) []byte {
+	queries := grounding.Get("webSearchQueries").Array()
+	query := ""
+	if len(queries) > 0 {
+		query = queries[0].String()
+	}
+	item := translatorcommon.ResponsesWebSearchCallItem(id, query)
+	if len(queries) > 1 {
+		item, _ = sjson.SetRawBytes(item, "action.queries", []byte(grounding.Get("webSearchQueries").Raw))
+	}
+	grounding.Get("groundingChunks").ForEach(func(_, chunk gjson.Result) bool {
+		item = translatorcommon.AppendResponsesWebSearchSource(item, chunk.Get("web.uri").String())
+		return true
+	})
+	return item
+}
+
+// geminiGroundingAnnotations converts grounding metadata into url_citation annotations over the
+// message text, or nil when the answer was not grounded.
+func geminiGroundingAnnotations(grounding gjson.Result, text string) []byte {
+	annotations := common.GroundingURLCitations(grounding, text)
+	if annotations == nil {
+		return nil
+	}
+	return translatorcommon.ResponsesAnnotations(annotations)
+}
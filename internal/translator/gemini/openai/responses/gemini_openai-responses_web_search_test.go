@@ -0,0 +1,77 @@
+package responses
+
+import (
+	"context"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+const geminiGroundedChunk = `{"candidates":[{"content":{"role":"model","parts":[{"text":"It is sunny."}]},"finishReason":"STOP","groundingMetadata":{"webSearchQueries":["weather paris"],"groundingChunks":[{"web":{"uri":"https://example.com/a","title":"a"}},{"web":{"uri":"https://example.com/b","title":"b"}}]}}],"responseId":"grounded"}`
+
+func TestConvertOpenAIResponsesRequestToGemini_MapsWebSearchToGoogleSearch(t *testing.T) {
+	input := []byte(`{"model":"gemini-2.5-pro","input":"weather?","tools":[{"type":"web_search_preview"},{"type":"function","name":"lookup","parameters":{"type":"object"}},{"type":"file_search","vector_store_ids":["vs_1"]}]}`)
+
+	out := ConvertOpenAIResponsesRequestToGemini("gemini-2.5-pro", input, false)
+	tools := gjson.GetBytes(out, "tools").Array()
+	if len(tools) != 2 {
+		t.Fatalf("tools = %s, want function declarations and googleSearch", gjson.GetBytes(out, "tools").Raw)
+	}
+	if tools[0].Get("functionDeclarations.0.name").String() != "lookup" || !tools[1].Get("googleSearch").Exists() {
+		t.Fatalf("tools = %s", gjson.GetBytes(out, "tools").Raw)
+	}
+
+	out = ConvertOpenAIResponsesRequestToGemini("gemini-2.5-pro", []byte(`{"input":"weather?","tools":[{"type":"web_search"}]}`), false)
+	if got := gjson.GetBytes(out, "tools").Raw; got != `[{"googleSearch":{}}]` {
+		t.Fatalf("tools = %s, want only googleSearch", got)
+	}
+}
+
+func TestConvertGeminiResponseToOpenAIResponses_GroundingEmitsWebSearchCall(t *testing.T) {
+	var param any
+	var done gjson.Result
+	var completed gjson.Result
+	sawSearchCompleted := false
+	for _, chunk := range ConvertGeminiResponseToOpenAIResponses(context.Background(), "gemini-2.5-pro", nil, nil, []byte("data: "+geminiGroundedChunk), &param) {
+		event, data := parseSSEEvent(t, chunk)
+		switch event {
+		case "response.web_search_call.completed":
+			sawSearchCompleted = true
+		case "response.output_item.done":
+			if data.Get("item.type").String() == "web_search_call" {
+				done = data
+			}
+		case "response.completed":
+			completed = data
+		}
+	}
+
+	if !sawSearchCompleted || !done.Exists() {
+		t.Fatal("expected web_search_call completed and output_item.done events")
+	}
+	if got := done.Get("item.action.query").String(); got != "weather paris" {
+		t.Fatalf("query = %q, want weather paris", got)
+	}
+	if got := done.Get("item.action.sources.#").Int(); got != 2 {
+		t.Fatalf("sources = %d, want 2", got)
+	}
+	output := completed.Get("response.output").Array()
+	if len(output) != 2 || output[0].Get("type").String() != "message" || output[1].Get("type").String() != "web_search_call" {
+		t.Fatalf("completed output = %s", completed.Get("response.output").Raw)
+	}
+}
+
+func TestConvertGeminiResponseToOpenAIResponsesNonStream_GroundingEmitsWebSearchCall(t *testing.T) {
+	out := ConvertGeminiResponseToOpenAIResponsesNonStream(context.Background(), "gemini-2.5-pro", nil, nil, []byte(geminiGroundedChunk), nil)
+	root := gjson.ParseBytes(out)
+
+	if got := root.Get("output.0.type").String(); got != "web_search_call" {
+		t.Fatalf("output = %s, want web_search_call first", root.Get("output").Raw)
+	}
+	if got := root.Get("output.0.action.sources.1.url").String(); got != "https://example.com/b" {
+		t.Fatalf("second source = %q", got)
+	}
+	if got := root.Get("output.1.content.0.text").String(); got != "It is sunny." {
+		t.Fatalf("message text = %q", got)
+	}
+}
@@ -1,7 +1,9 @@
 // Package chat_completions provides response translation functionality for Grok to OpenAI API compatibility.
 // This package handles the conversion of Grok API responses (JSON-lines format) into OpenAI Chat Completions-compatible
 // JSON format. Grok responses contain incremental tokens at result.response.token and final messages at
-// result.response.modelResponse.message. The translator supports both streaming (SSE chunks) and non-streaming modes.
+// result.response.modelResponse.message. Structured tool calls arrive at result.response.toolCalls and
+// result.response.modelResponse.toolCalls; with grok.legacy-tool-prompt they are parsed from <tool_call>
+// tags in the text instead. The translator supports both streaming (SSE chunks) and non-streaming modes.
 package chat_completions
 
 import (
@@ -9,9 +11,11 @@ import (
 	"fmt"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/common"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -21,11 +25,23 @@ type convertGrokResponseToOpenAIParams struct {
 	CreatedAt            int64
 	HasEmittedFirstChunk bool
 	InThinking           bool
-	// Tool call buffering (Option A: buffer everything when tools present)
+	// Tool call buffering for the legacy tool prompt (buffer everything when tools present)
 	ContentBuffer   strings.Builder // Buffer ALL streaming content
 	HasTools        bool            // Whether the original request had tools
 	IsBuffering     bool            // Whether we're in buffering mode
 	StreamCompleted bool            // Whether we've seen the final message
+	// Structured tool calls emitted so far, by ID, and their count
+	SeenToolCalls map[string]struct{}
+	ToolCallCount int
+	// Web search sources, reported as url_citation annotations with the finish reason
+	WebSources []grokWebSource
+	TextLength int // characters of content emitted so far
+}
+
+// grokWebSource is a web search result Grok consulted while answering.
+type grokWebSource struct {
+	URL   string
+	Title string
 }
 
 type grokConfigCtxKey struct{}
@@ -75,8 +91,8 @@ func ConvertGrokResponseToOpenAI(ctx context.Context, modelName string, original
 		filtered = cfg.Grok.FilteredTags
 	}
 
-	// Check if original request has tools - if so, we need to buffer everything
-	if !state.IsBuffering && originalRequestRawJSON != nil {
+	// With the legacy tool prompt, tool calls are tags in the text, so buffer everything
+	if !state.IsBuffering && legacyToolPrompt(cfg) && originalRequestRawJSON != nil {
 		tools := gjson.GetBytes(originalRequestRawJSON, "tools")
 		if tools.Exists() && tools.IsArray() && len(tools.Array()) > 0 {
 			state.HasTools = true
@@ -116,24 +132,33 @@ func ConvertGrokResponseToOpenAI(ctx context.Context, modelName string, original
 		}
 	}
 
-	if web := resp.Get("webSearchResults"); web.Exists() && showThinking {
-		if formatted := formatWebSearch(web); formatted != "" {
-			contentParts = append(contentParts, formatted)
+	if web := resp.Get("webSearchResults"); web.Exists() {
+		state.WebSources = appendGrokWebSources(state.WebSources, web)
+		if showThinking {
+			if formatted := formatWebSearch(web); formatted != "" {
+				contentParts = append(contentParts, formatted)
+			}
 		}
 	}
 
+	toolCalls := state.takeToolCalls(resp)
+
 	// Check for final message (stream completion signal)
-	if finalMessage := strings.TrimSpace(resp.Get("modelResponse.message").String()); finalMessage != "" {
+	finalMessage := strings.TrimSpace(resp.Get("modelResponse.message").String())
+	if finalMessage != "" || len(resp.Get("modelResponse.toolCalls").Array()) > 0 {
 		finishReason = "stop"
 		state.StreamCompleted = true
 	}
+	if finishReason != "" && state.ToolCallCount > 0 {
+		finishReason = "tool_calls"
+	}
 
 	content := strings.Join(contentParts, "")
 
 	// Set metadata
 	setGrokResponseMetadata(parsed, state)
 
-	// Option A: If we have tools, buffer everything and emit at the end
+	// Legacy tool prompt: buffer everything and emit at the end
 	if state.IsBuffering {
 		// Accumulate content
 		state.ContentBuffer.WriteString(content)
@@ -148,19 +173,75 @@ func ConvertGrokResponseToOpenAI(ctx context.Context, modelName string, original
 		return []string{}
 	}
 
-	// Normal streaming (no tools)
-	if len(contentParts) == 0 && finishReason == "" {
+	if len(contentParts) == 0 && finishReason == "" && len(toolCalls) == 0 {
 		return []string{}
 	}
 
-	chunk := buildOpenAIStreamChunk(modelName, state.ResponseID, state.CreatedAt, content, finishReason)
-	if !state.HasEmittedFirstChunk {
-		chunk, _ = sjson.Set(chunk, "choices.0.delta.role", "assistant")
+	var chunks []string
+	if len(contentParts) > 0 || len(toolCalls) == 0 {
+		textFinish := finishReason
+		if len(toolCalls) > 0 {
+			textFinish = ""
+		}
+		chunk := buildOpenAIStreamChunk(modelName, state.ResponseID, state.CreatedAt, content, textFinish)
+		if !state.HasEmittedFirstChunk {
+			chunk, _ = sjson.Set(chunk, "choices.0.delta.role", "assistant")
+		}
+		state.TextLength += utf8.RuneCountInString(content)
+		if textFinish != "" {
+			chunk = setGrokAnnotations(chunk, "choices.0.delta.annotations", state.WebSources, state.TextLength)
+		}
+		state.HasEmittedFirstChunk = true
+		chunks = append(chunks, chunk)
 	}
+	if len(toolCalls) > 0 {
+		chunk := buildOpenAIStreamChunkWithToolCalls(modelName, state.ResponseID, state.CreatedAt, toolCalls, state.ToolCallCount-len(toolCalls), finishReason)
+		if !state.HasEmittedFirstChunk {
+			chunk, _ = sjson.Set(chunk, "choices.0.delta.role", "assistant")
+		}
+		if finishReason != "" {
+			chunk = setGrokAnnotations(chunk, "choices.0.delta.annotations", state.WebSources, state.TextLength)
+		}
+		state.HasEmittedFirstChunk = true
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
 
-	state.HasEmittedFirstChunk = true
+// legacyToolPrompt reports whether tools use the prompt-injected <tool_call> protocol.
+func legacyToolPrompt(cfg *config.Config) bool {
+	return cfg != nil && cfg.Grok.LegacyToolPrompt
+}
 
-	return []string{chunk}
+// takeToolCalls returns the structured tool calls in resp that were not emitted yet, in OpenAI
+// format. Grok may repeat streamed calls in the final modelResponse, so calls are deduplicated.
+func (s *convertGrokResponseToOpenAIParams) takeToolCalls(resp gjson.Result) []map[string]interface{} {
+	var calls []map[string]interface{}
+	for _, path := range []string{"toolCalls", "modelResponse.toolCalls"} {
+		for _, raw := range resp.Get(path).Array() {
+			key := raw.Get("id").String()
+			if key == "" {
+				key = raw.Get("call_id").String()
+			}
+			if key == "" {
+				key = raw.Raw
+			}
+			if _, seen := s.SeenToolCalls[key]; seen {
+				continue
+			}
+			call := parseToolCallJSON(raw.Raw, s.ToolCallCount)
+			if call == nil {
+				continue
+			}
+			if s.SeenToolCalls == nil {
+				s.SeenToolCalls = make(map[string]struct{})
+			}
+			s.SeenToolCalls[key] = struct{}{}
+			s.ToolCallCount++
+			calls = append(calls, call)
+		}
+	}
+	return calls
 }
 
 // emitBufferedContent parses buffered content for tool calls and emits appropriate chunks
@@ -179,17 +260,21 @@ func emitBufferedContent(modelName string, state *convertGrokResponseToOpenAIPar
 		results = append(results, chunk)
 	}
 
+	state.TextLength += utf8.RuneCountInString(cleanedContent)
+
 	// Emit tool calls if present
 	if len(toolCalls) > 0 {
-		chunk := buildOpenAIStreamChunkWithToolCalls(modelName, state.ResponseID, state.CreatedAt, toolCalls, "tool_calls")
+		chunk := buildOpenAIStreamChunkWithToolCalls(modelName, state.ResponseID, state.CreatedAt, toolCalls, 0, "tool_calls")
 		if !state.HasEmittedFirstChunk {
 			chunk, _ = sjson.Set(chunk, "choices.0.delta.role", "assistant")
 			state.HasEmittedFirstChunk = true
 		}
+		chunk = setGrokAnnotations(chunk, "choices.0.delta.annotations", state.WebSources, state.TextLength)
 		results = append(results, chunk)
 	} else {
 		// No tool calls, emit finish
 		finishChunk := buildOpenAIStreamChunk(modelName, state.ResponseID, state.CreatedAt, "", "stop")
+		finishChunk = setGrokAnnotations(finishChunk, "choices.0.delta.annotations", state.WebSources, state.TextLength)
 		results = append(results, finishChunk)
 	}
 
@@ -204,6 +289,8 @@ func ConvertGrokResponseToOpenAINonStream(ctx context.Context, modelName string,
 		createdAt  int64
 		content    []string
 		finish     string
+		sources    []grokWebSource
+		toolCalls  []map[string]interface{}
 	)
 
 	cfg := grokConfigFromContext(ctx)
@@ -263,12 +350,17 @@ func ConvertGrokResponseToOpenAINonStream(ctx context.Context, modelName string,
 			}
 		}
 
-		if web := resp.Get("webSearchResults"); web.Exists() && showThinking {
-			if formatted := formatWebSearch(web); formatted != "" {
-				content = append(content, formatted)
+		if web := resp.Get("webSearchResults"); web.Exists() {
+			sources = appendGrokWebSources(sources, web)
+			if showThinking {
+				if formatted := formatWebSearch(web); formatted != "" {
+					content = append(content, formatted)
+				}
 			}
 		}
 
+		toolCalls = append(toolCalls, state.takeToolCalls(resp)...)
+
 		if finalMessage := strings.TrimSpace(resp.Get("modelResponse.message").String()); finalMessage != "" {
 			content = append(content, finalMessage)
 			finish = "stop"
@@ -283,7 +375,15 @@ func ConvertGrokResponseToOpenAINonStream(ctx context.Context, modelName string,
 		createdAt = time.Now().Unix()
 	}
 
-	return buildOpenAINonStreamResponse(modelName, responseID, createdAt, strings.Join(content, ""), finish)
+	joined := strings.Join(content, "")
+	if legacyToolPrompt(cfg) {
+		var tagCalls []map[string]interface{}
+		tagCalls, joined = extractToolCalls(joined)
+		toolCalls = append(toolCalls, tagCalls...)
+	}
+	out := buildOpenAINonStreamResponse(modelName, responseID, createdAt, joined, finish, toolCalls)
+	textLength := utf8.RuneCountInString(gjson.Get(out, "choices.0.message.content").String())
+	return setGrokAnnotations(out, "choices.0.message.annotations", sources, textLength)
 }
 
 func buildOpenAIStreamChunk(modelName, responseID string, createdAt int64, content string, finishReason string) string {
@@ -301,7 +401,8 @@ func buildOpenAIStreamChunk(modelName, responseID string, createdAt int64, conte
 	return json
 }
 
-func buildOpenAIStreamChunkWithToolCalls(modelName, responseID string, createdAt int64, toolCalls []map[string]interface{}, finishReason string) string {
+// buildOpenAIStreamChunkWithToolCalls builds a chunk carrying toolCalls, indexed from firstIndex.
+func buildOpenAIStreamChunkWithToolCalls(modelName, responseID string, createdAt int64, toolCalls []map[string]interface{}, firstIndex int, finishReason string) string {
 	json := `{"id":"","object":"chat.completion.chunk","created":0,"model":"","choices":[{"index":0,"delta":{"tool_calls":[]},"finish_reason":null}]}`
 
 	json, _ = sjson.Set(json, "id", responseID)
@@ -313,7 +414,7 @@ func buildOpenAIStreamChunkWithToolCalls(modelName, responseID string, createdAt
 	for i, tc := range toolCalls {
 		fn := tc["function"].(map[string]string)
 		streamTC := map[string]interface{}{
-			"index": i,
+			"index": firstIndex + i,
 			"id":    tc["id"],
 			"type":  "function",
 			"function": map[string]string{
@@ -333,13 +434,11 @@ func buildOpenAIStreamChunkWithToolCalls(modelName, responseID string, createdAt
 	return json
 }
 
-func buildOpenAINonStreamResponse(modelName, responseID string, createdAt int64, content string, finishReason string) string {
+func buildOpenAINonStreamResponse(modelName, responseID string, createdAt int64, content string, finishReason string, toolCalls []map[string]interface{}) string {
 	if finishReason == "" {
 		finishReason = "stop"
 	}
-
-	// Check for tool calls in the content
-	toolCalls, cleanedContent := extractToolCalls(content)
+	cleanedContent := strings.TrimSpace(content)
 
 	json := `{"id":"","object":"chat.completion","created":0,"model":"","choices":[{"index":0,"message":{"role":"assistant","content":""},"finish_reason":"stop"}],"usage":{"prompt_tokens":0,"completion_tokens":0,"total_tokens":0}}`
 
@@ -486,6 +585,9 @@ func parseToolCallJSON(jsonStr string, index int) map[string]interface{} {
 	if toolName == "" {
 		toolName = parsed.Get("name").String()
 	}
+	if toolName == "" {
+		toolName = parsed.Get("function.name").String()
+	}
 	if toolName == "" {
 		return nil
 	}
@@ -501,6 +603,9 @@ func parseToolCallJSON(jsonStr string, index int) map[string]interface{} {
 
 	// Get arguments - handle both object and string formats
 	args := parsed.Get("arguments")
+	if !args.Exists() {
+		args = parsed.Get("function.arguments")
+	}
 	argsStr := "{}"
 	if args.Exists() {
 		if args.IsObject() || args.IsArray() {
@@ -677,3 +782,39 @@ func formatWebSearch(web gjson.Result) string {
 	}
 	return builder.String()
 }
+
+// appendGrokWebSources adds the results of a webSearchResults payload to sources, skipping URLs
+// already present.
+func appendGrokWebSources(sources []grokWebSource, web gjson.Result) []grokWebSource {
+	for _, res := range web.Get("results").Array() {
+		url := strings.TrimSpace(res.Get("url").String())
+		if url == "" {
+			continue
+		}
+		seen := false
+		for _, source := range sources {
+			if source.URL == url {
+				seen = true
+				break
+			}
+		}
+		if !seen {
+			sources = append(sources, grokWebSource{URL: url, Title: strings.TrimSpace(res.Get("title").String())})
+		}
+	}
+	return sources
+}
+
+// setGrokAnnotations sets url_citation annotations for sources at path. Grok does not report
+// which span each source supports, so every citation covers the whole answer.
+func setGrokAnnotations(json string, path string, sources []grokWebSource, textLength int) string {
+	if len(sources) == 0 {
+		return json
+	}
+	annotations := []byte(`[]`)
+	for _, source := range sources {
+		annotations, _ = sjson.SetRawBytes(annotations, "-1", translatorcommon.URLCitationAnnotation(source.URL, source.Title, 0, textLength))
+	}
+	json, _ = sjson.SetRaw(json, path, string(annotations))
+	return json
+}
@@ -0,0 +1,74 @@
+package chat_completions
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/tidwall/gjson"
+)
+
+const grokToolRequest = `{"model":"grok-3","messages":[{"role":"user","content":"weather?"}],"tools":[{"type":"function","function":{"name":"get_weather"}}]}`
+
+func TestConvertGrokResponseToOpenAI_NativeToolCalls(t *testing.T) {
+	lines := []string{
+		`{"result":{"response":{"token":"Checking. ","isThinking":false}}}`,
+		`{"result":{"response":{"toolCalls":[{"id":"call_1","function":{"name":"get_weather","arguments":"{\"city\":\"Paris\"}"}}]}}}`,
+		`{"result":{"response":{"modelResponse":{"message":"Checking.","toolCalls":[{"id":"call_1","function":{"name":"get_weather","arguments":"{\"city\":\"Paris\"}"}}]}}}}`,
+	}
+	var param any
+	var chunks []string
+	for _, line := range lines {
+		chunks = append(chunks, ConvertGrokResponseToOpenAI(context.Background(), "grok-3", []byte(grokToolRequest), nil, []byte(line), &param)...)
+	}
+	if len(chunks) != 3 {
+		t.Fatalf("chunks = %v, want text, tool call and finish", chunks)
+	}
+	if got := gjson.Get(chunks[0], "choices.0.delta.content").String(); got != "Checking. " {
+		t.Errorf("text chunk content = %q, want it streamed without buffering", got)
+	}
+	call := gjson.Get(chunks[1], "choices.0.delta.tool_calls.0")
+	if call.Get("id").String() != "call_1" || call.Get("function.name").String() != "get_weather" || call.Get("function.arguments").String() != `{"city":"Paris"}` {
+		t.Errorf("tool call chunk = %s", chunks[1])
+	}
+	if got := gjson.Get(chunks[2], "choices.0.finish_reason").String(); got != "tool_calls" {
+		t.Errorf("finish reason = %q, want tool_calls; chunk=%s", got, chunks[2])
+	}
+	if gjson.Get(chunks[2], "choices.0.delta.tool_calls").Exists() {
+		t.Errorf("final chunk repeats the streamed tool call: %s", chunks[2])
+	}
+}
+
+func TestConvertGrokResponseToOpenAINonStream_NativeToolCalls(t *testing.T) {
+	raw := `{"result":{"response":{"modelResponse":{"message":"On it.","toolCalls":[{"id":"call_9","name":"get_weather","arguments":{"city":"Oslo"}}]}}}}`
+	var param any
+	out := ConvertGrokResponseToOpenAINonStream(context.Background(), "grok-3", []byte(grokToolRequest), nil, []byte(raw), &param)
+	if got := gjson.Get(out, "choices.0.finish_reason").String(); got != "tool_calls" {
+		t.Fatalf("finish reason = %q; out=%s", got, out)
+	}
+	if got := gjson.Get(out, "choices.0.message.tool_calls.0.function.arguments").String(); got != `{"city":"Oslo"}` {
+		t.Errorf("tool call arguments = %q", got)
+	}
+	if got := gjson.Get(out, "choices.0.message.content").String(); got != "On it." {
+		t.Errorf("content = %q", got)
+	}
+}
+
+func TestConvertGrokResponseToOpenAI_LegacyToolPrompt(t *testing.T) {
+	ctx := WithGrokConfig(context.Background(), &config.Config{Grok: config.GrokConfig{LegacyToolPrompt: true}})
+	lines := []string{
+		`{"result":{"response":{"token":"<tool_call>{\"tool_name\":\"get_weather\",\"arguments\":{\"city\":\"Rome\"}}"}}}`,
+		`{"result":{"response":{"token":"</tool_call>"}}}`,
+		`{"result":{"response":{"modelResponse":{"message":"done"}}}}`,
+	}
+	var param any
+	var chunks []string
+	for _, line := range lines {
+		chunks = append(chunks, ConvertGrokResponseToOpenAI(ctx, "grok-3", []byte(grokToolRequest), nil, []byte(line), &param)...)
+	}
+	joined := strings.Join(chunks, "\n")
+	if !strings.Contains(joined, `"name":"get_weather"`) || strings.Contains(joined, "<tool_call>") {
+		t.Fatalf("legacy chunks = %v, want the tag parsed into a tool call", chunks)
+	}
+}
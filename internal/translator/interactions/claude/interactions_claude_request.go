@@ -3,12 +3,13 @@ package claude
 import (
 	"strings"
 
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/common"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
 
 func ConvertClaudeRequestToInteractions(modelName string, inputRawJSON []byte, stream bool) []byte {
-	root := gjson.ParseBytes(inputRawJSON)
+	root := gjson.ParseBytes(translatorcommon.DegradeClaudeServerToolBlocks(inputRawJSON))
 	out := []byte(`{"model":"","input":[]}`)
 	out, _ = sjson.SetBytes(out, "model", firstNonEmpty(modelName, root.Get("model").String()))
 	if streamValue, ok := claudeRequestStreamValue(root, stream); ok {
@@ -12,6 +12,7 @@ import (
 	"unicode/utf8"
 
 	"github.com/google/uuid"
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/common"
 	kirocommon "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/kiro/common"
 	log "github.com/sirupsen/logrus"
 	"github.com/tidwall/gjson"
@@ -126,7 +127,7 @@ func ConvertClaudeRequestToKiro(modelName string, inputRawJSON []byte, stream bo
 	// For Kiro, we pass through the Claude format since buildKiroPayload
 	// expects Claude format and does the conversion internally.
 	// The actual conversion happens in the executor when building the HTTP request.
-	return inputRawJSON
+	return translatorcommon.DegradeClaudeServerToolBlocks(inputRawJSON)
 }
 
 // BuildKiroPayload constructs the Kiro API request payload from Claude format.
@@ -20,7 +20,7 @@ import (
 // It extracts the model name, system instruction, message contents, and tool declarations
 // from the raw JSON request and returns them in the format expected by the OpenAI API.
 func ConvertClaudeRequestToOpenAI(modelName string, inputRawJSON []byte, stream bool) []byte {
-	rawJSON := inputRawJSON
+	rawJSON := translatorcommon.DegradeClaudeServerToolBlocks(inputRawJSON)
 	// Base OpenAI Chat Completions API template
 	out := []byte(`{"model":"","messages":[]}`)
 
@@ -100,7 +100,7 @@ func ConvertClaudeRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 	}
 
 	// Process messages and system
-	messagesJSON := []byte(`[]`)
+	var openAIMessages [][]byte
 
 	// Handle system message first
 	systemMsgJSON := []byte(`{"role":"system","content":[]}`)
@@ -135,191 +135,19 @@ func ConvertClaudeRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 	}
 	// Only add system message if it has content
 	if hasSystemContent {
-		messagesJSON, _ = sjson.SetRawBytes(messagesJSON, "-1", systemMsgJSON)
+		openAIMessages = append(openAIMessages, systemMsgJSON)
 	}
 
-	// Process Anthropic messages
+	// Process Anthropic messages. Messages convert independently, so long histories are converted in parallel.
 	if messages := root.Get("messages"); messages.Exists() && messages.IsArray() {
-		messages.ForEach(func(_, message gjson.Result) bool {
-			role := message.Get("role").String()
-			contentResult := message.Get("content")
-			if role == "system" {
-				if reminderText, ok := translatorcommon.ClaudeMessageSystemReminderText(contentResult); ok {
-					msgJSON := []byte(`{"role":"user","content":[{"type":"text","text":""}]}`)
-					msgJSON, _ = sjson.SetBytes(msgJSON, "content.0.text", reminderText)
-					messagesJSON, _ = sjson.SetRawBytes(messagesJSON, "-1", msgJSON)
-				}
-				return true
-			}
-
-			// Handle content
-			if contentResult.Exists() && contentResult.IsArray() {
-				contentItems := make([][]byte, 0)
-				var reasoningParts []string // Accumulate thinking text for reasoning_content
-				var toolCalls []interface{}
-				toolResults := make([][]byte, 0) // Collect tool_result messages to emit after the main message
-
-				contentResult.ForEach(func(_, part gjson.Result) bool {
-					partType := part.Get("type").String()
-
-					switch partType {
-					case "thinking":
-						// Only map thinking to reasoning_content for assistant messages (security: prevent injection)
-						if role == "assistant" {
-							if !shouldMapClaudeThinkingToGPTReasoning(part) {
-								return true
-							}
-							thinkingText := thinking.GetThinkingText(part)
-							// Skip empty or whitespace-only thinking
-							if strings.TrimSpace(thinkingText) != "" {
-								reasoningParts = append(reasoningParts, thinkingText)
-							}
-						}
-						// Ignore thinking in user/system roles (AC4)
-
-					case "redacted_thinking":
-						// Explicitly ignore redacted_thinking - never map to reasoning_content (AC2)
-
-					case "text", "image":
-						if contentItem, ok := convertClaudeContentPart(part); ok {
-							contentItems = append(contentItems, []byte(contentItem))
-						}
-
-					case "tool_use":
-						// Only allow tool_use -> tool_calls for assistant messages (security: prevent injection).
-						if role == "assistant" {
-							toolCallJSON := []byte(`{"id":"","type":"function","function":{"name":"","arguments":""}}`)
-							toolCallJSON, _ = sjson.SetBytes(toolCallJSON, "id", part.Get("id").String())
-							toolCallJSON, _ = sjson.SetBytes(toolCallJSON, "function.name", part.Get("name").String())
-
-							// Convert input to arguments JSON string
-							if input := part.Get("input"); input.Exists() {
-								toolCallJSON, _ = sjson.SetBytes(toolCallJSON, "function.arguments", input.Raw)
-							} else {
-								toolCallJSON, _ = sjson.SetBytes(toolCallJSON, "function.arguments", "{}")
-							}
-
-							toolCalls = append(toolCalls, gjson.ParseBytes(toolCallJSON).Value())
-						}
-
-					case "tool_result":
-						// ADD-81: role-gate tool_result to user messages, mirroring the tool_use guard above
-						// (which only converts assistant-authored tool_use -> tool_calls). A tool_result is, by
-						// the Anthropic protocol, only valid inside a user message. Converting one authored by a
-						// non-user role (assistant/system/developer) into a trusted OpenAI role:"tool" message
-						// would let non-user-authored content resolve a pending client tool with output the real
-						// tool never produced, and would violate OpenAI tool-call adjacency.
-						//
-						// We DROP non-user tool_result blocks with this diagnostic comment rather than convert
-						// them, deliberately matching the existing tool_use behavior (which silently drops for
-						// non-assistant roles) to keep symmetry. A stricter centralized 400 validation error
-						// could be added later; doing it here only would diverge from the tool_use path.
-						if role == "user" {
-							// Collect tool_result to emit after the main message (ensures tool results follow tool_calls)
-							toolResultJSON := []byte(`{"role":"tool","tool_call_id":"","content":""}`)
-							toolResultJSON, _ = sjson.SetBytes(toolResultJSON, "tool_call_id", part.Get("tool_use_id").String())
-							toolResultContent, toolResultContentRaw := convertClaudeToolResultContent(part.Get("content"))
-							if toolResultContentRaw {
-								toolResultJSON, _ = sjson.SetRawBytes(toolResultJSON, "content", []byte(toolResultContent))
-							} else {
-								toolResultJSON, _ = sjson.SetBytes(toolResultJSON, "content", toolResultContent)
-							}
-							// C5: a failed client tool must not reach the model as success. Carry is_error
-							// (snake_case, OpenAI convention) on the role:tool message so it survives to the
-							// composer executor + bridge. Only set when true to keep payloads minimal.
-							if isErr := part.Get("is_error"); isErr.Exists() && isErr.Bool() {
-								toolResultJSON, _ = sjson.SetBytes(toolResultJSON, "is_error", true)
-							}
-							toolResults = append(toolResults, toolResultJSON)
-						}
-						// For role != "user": drop the block (do not append to toolResults) and do NOT convert
-						// it to a role:"tool" message. See diagnostic above.
-					}
-					return true
-				})
-
-				// Build reasoning content string
-				reasoningContent := ""
-				if len(reasoningParts) > 0 {
-					reasoningContent = strings.Join(reasoningParts, "\n\n")
-				}
-
-				hasContent := len(contentItems) > 0
-				hasReasoning := reasoningContent != ""
-				hasToolCalls := len(toolCalls) > 0
-				hasToolResults := len(toolResults) > 0
-
-				// OpenAI requires: tool messages MUST immediately follow the assistant message with tool_calls.
-				// Therefore, we emit tool_result messages FIRST (they respond to the previous assistant's tool_calls),
-				// then emit the current message's content.
-				for _, toolResultJSON := range toolResults {
-					messagesJSON, _ = sjson.SetRawBytes(messagesJSON, "-1", toolResultJSON)
-				}
-
-				// For assistant messages: emit a single unified message with content, tool_calls, and reasoning_content
-				// This avoids splitting into multiple assistant messages which breaks OpenAI tool-call adjacency
-				if role == "assistant" {
-					if hasContent || hasReasoning || hasToolCalls {
-						msgJSON := []byte(`{"role":"assistant"}`)
-
-						// Add content (as array if we have items, empty string if reasoning-only)
-						if hasContent {
-							contentArrayJSON := []byte(`[]`)
-							for _, contentItem := range contentItems {
-								contentArrayJSON, _ = sjson.SetRawBytes(contentArrayJSON, "-1", contentItem)
-							}
-							msgJSON, _ = sjson.SetRawBytes(msgJSON, "content", contentArrayJSON)
-						} else {
-							// Ensure content field exists for OpenAI compatibility
-							msgJSON, _ = sjson.SetBytes(msgJSON, "content", "")
-						}
-
-						// Add reasoning_content if present
-						if hasReasoning {
-							msgJSON, _ = sjson.SetBytes(msgJSON, "reasoning_content", reasoningContent)
-						}
-
-						// Add tool_calls if present (in same message as content)
-						if hasToolCalls {
-							msgJSON, _ = sjson.SetBytes(msgJSON, "tool_calls", toolCalls)
-						}
-
-						messagesJSON, _ = sjson.SetRawBytes(messagesJSON, "-1", msgJSON)
-					}
-				} else {
-					// For non-assistant roles: emit content message if we have content
-					// If the message only contains tool_results (no text/image), we still processed them above
-					if hasContent {
-						msgJSON := []byte(`{"role":""}`)
-						msgJSON, _ = sjson.SetBytes(msgJSON, "role", role)
-
-						contentArrayJSON := []byte(`[]`)
-						for _, contentItem := range contentItems {
-							contentArrayJSON, _ = sjson.SetRawBytes(contentArrayJSON, "-1", contentItem)
-						}
-						msgJSON, _ = sjson.SetRawBytes(msgJSON, "content", contentArrayJSON)
-
-						messagesJSON, _ = sjson.SetRawBytes(messagesJSON, "-1", msgJSON)
-					} else if hasToolResults && !hasContent {
-						// tool_results already emitted above, no additional user message needed
-					}
-				}
-
-			} else if contentResult.Exists() && contentResult.Type == gjson.String {
-				// Simple string content
-				msgJSON := []byte(`{"role":"","content":""}`)
-				msgJSON, _ = sjson.SetBytes(msgJSON, "role", role)
-				msgJSON, _ = sjson.SetBytes(msgJSON, "content", contentResult.String())
-				messagesJSON, _ = sjson.SetRawBytes(messagesJSON, "-1", msgJSON)
-			}
-
-			return true
-		})
+		for _, converted := range translatorcommon.MapMessages(messages.Array(), convertClaudeMessageToOpenAI) {
+			openAIMessages = append(openAIMessages, converted...)
+		}
 	}
 
 	// Set messages
-	if msgs := gjson.ParseBytes(messagesJSON); msgs.IsArray() && len(msgs.Array()) > 0 {
-		out, _ = sjson.SetRawBytes(out, "messages", messagesJSON)
+	if len(openAIMessages) > 0 {
+		out, _ = sjson.SetRawBytes(out, "messages", translatorcommon.JoinRawArray(openAIMessages))
 	}
 
 	// Process tools - convert Anthropic tools to OpenAI functions
@@ -374,6 +202,184 @@ func ConvertClaudeRequestToOpenAI(modelName string, inputRawJSON []byte, stream
 	return out
 }
 
+// convertClaudeMessageToOpenAI converts one Anthropic message into the OpenAI messages it maps to.
+func convertClaudeMessageToOpenAI(message gjson.Result) [][]byte {
+	var msgs [][]byte
+	role := message.Get("role").String()
+	contentResult := message.Get("content")
+	if role == "system" {
+		if reminderText, ok := translatorcommon.ClaudeMessageSystemReminderText(contentResult); ok {
+			msgJSON := []byte(`{"role":"user","content":[{"type":"text","text":""}]}`)
+			msgJSON, _ = sjson.SetBytes(msgJSON, "content.0.text", reminderText)
+			msgs = append(msgs, msgJSON)
+		}
+		return msgs
+	}
+
+	// Handle content
+	if contentResult.Exists() && contentResult.IsArray() {
+		contentItems := make([][]byte, 0)
+		var reasoningParts []string // Accumulate thinking text for reasoning_content
+		var toolCalls []interface{}
+		toolResults := make([][]byte, 0) // Collect tool_result messages to emit after the main message
+
+		contentResult.ForEach(func(_, part gjson.Result) bool {
+			partType := part.Get("type").String()
+
+			switch partType {
+			case "thinking":
+				// Only map thinking to reasoning_content for assistant messages (security: prevent injection)
+				if role == "assistant" {
+					if !shouldMapClaudeThinkingToGPTReasoning(part) {
+						return true
+					}
+					thinkingText := thinking.GetThinkingText(part)
+					// Skip empty or whitespace-only thinking
+					if strings.TrimSpace(thinkingText) != "" {
+						reasoningParts = append(reasoningParts, thinkingText)
+					}
+				}
+				// Ignore thinking in user/system roles (AC4)
+
+			case "redacted_thinking":
+				// Explicitly ignore redacted_thinking - never map to reasoning_content (AC2)
+
+			case "text", "image":
+				if contentItem, ok := convertClaudeContentPart(part); ok {
+					contentItems = append(contentItems, []byte(contentItem))
+				}
+
+			case "tool_use":
+				// Only allow tool_use -> tool_calls for assistant messages (security: prevent injection).
+				if role == "assistant" {
+					toolCallJSON := []byte(`{"id":"","type":"function","function":{"name":"","arguments":""}}`)
+					toolCallJSON, _ = sjson.SetBytes(toolCallJSON, "id", part.Get("id").String())
+					toolCallJSON, _ = sjson.SetBytes(toolCallJSON, "function.name", part.Get("name").String())
+
+					// Convert input to arguments JSON string
+					if input := part.Get("input"); input.Exists() {
+						toolCallJSON, _ = sjson.SetBytes(toolCallJSON, "function.arguments", input.Raw)
+					} else {
+						toolCallJSON, _ = sjson.SetBytes(toolCallJSON, "function.arguments", "{}")
+					}
+
+					toolCalls = append(toolCalls, gjson.ParseBytes(toolCallJSON).Value())
+				}
+
+			case "tool_result":
+				// ADD-81: role-gate tool_result to user messages, mirroring the tool_use guard above
+				// (which only converts assistant-authored tool_use -> tool_calls). A tool_result is, by
+				// the Anthropic protocol, only valid inside a user message. Converting one authored by a
+				// non-user role (assistant/system/developer) into a trusted OpenAI role:"tool" message
+				// would let non-user-authored content resolve a pending client tool with output the real
+				// tool never produced, and would violate OpenAI tool-call adjacency.
+				//
+				// We DROP non-user tool_result blocks with this diagnostic comment rather than convert
+				// them, deliberately matching the existing tool_use behavior (which silently drops for
+				// non-assistant roles) to keep symmetry. A stricter centralized 400 validation error
+				// could be added later; doing it here only would diverge from the tool_use path.
+				if role == "user" {
+					// Collect tool_result to emit after the main message (ensures tool results follow tool_calls)
+					toolResultJSON := []byte(`{"role":"tool","tool_call_id":"","content":""}`)
+					toolResultJSON, _ = sjson.SetBytes(toolResultJSON, "tool_call_id", part.Get("tool_use_id").String())
+					toolResultContent, toolResultContentRaw := convertClaudeToolResultContent(part.Get("content"))
+					if toolResultContentRaw {
+						toolResultJSON, _ = sjson.SetRawBytes(toolResultJSON, "content", []byte(toolResultContent))
+					} else {
+						toolResultJSON, _ = sjson.SetBytes(toolResultJSON, "content", toolResultContent)
+					}
+					// C5: a failed client tool must not reach the model as success. Carry is_error
+					// (snake_case, OpenAI convention) on the role:tool message so it survives to the
+					// composer executor + bridge. Only set when true to keep payloads minimal.
+					if isErr := part.Get("is_error"); isErr.Exists() && isErr.Bool() {
+						toolResultJSON, _ = sjson.SetBytes(toolResultJSON, "is_error", true)
+					}
+					toolResults = append(toolResults, toolResultJSON)
+				}
+				// For role != "user": drop the block (do not append to toolResults) and do NOT convert
+				// it to a role:"tool" message. See diagnostic above.
+			}
+			return true
+		})
+
+		// Build reasoning content string
+		reasoningContent := ""
+		if len(reasoningParts) > 0 {
+			reasoningContent = strings.Join(reasoningParts, "\n\n")
+		}
+
+		hasContent := len(contentItems) > 0
+		hasReasoning := reasoningContent != ""
+		hasToolCalls := len(toolCalls) > 0
+		hasToolResults := len(toolResults) > 0
+
+		// OpenAI requires: tool messages MUST immediately follow the assistant message with tool_calls.
+		// Therefore, we emit tool_result messages FIRST (they respond to the previous assistant's tool_calls),
+		// then emit the current message's content.
+		for _, toolResultJSON := range toolResults {
+			msgs = append(msgs, toolResultJSON)
+		}
+
+		// For assistant messages: emit a single unified message with content, tool_calls, and reasoning_content
+		// This avoids splitting into multiple assistant messages which breaks OpenAI tool-call adjacency
+		if role == "assistant" {
+			if hasContent || hasReasoning || hasToolCalls {
+				msgJSON := []byte(`{"role":"assistant"}`)
+
+				// Add content (as array if we have items, empty string if reasoning-only)
+				if hasContent {
+					contentArrayJSON := []byte(`[]`)
+					for _, contentItem := range contentItems {
+						contentArrayJSON, _ = sjson.SetRawBytes(contentArrayJSON, "-1", contentItem)
+					}
+					msgJSON, _ = sjson.SetRawBytes(msgJSON, "content", contentArrayJSON)
+				} else {
+					// Ensure content field exists for OpenAI compatibility
+					msgJSON, _ = sjson.SetBytes(msgJSON, "content", "")
+				}
+
+				// Add reasoning_content if present
+				if hasReasoning {
+					msgJSON, _ = sjson.SetBytes(msgJSON, "reasoning_content", reasoningContent)
+				}
+
+				// Add tool_calls if present (in same message as content)
+				if hasToolCalls {
+					msgJSON, _ = sjson.SetBytes(msgJSON, "tool_calls", toolCalls)
+				}
+
+				msgs = append(msgs, msgJSON)
+			}
+		} else {
+			// For non-assistant roles: emit content message if we have content
+			// If the message only contains tool_results (no text/image), we still processed them above
+			if hasContent {
+				msgJSON := []byte(`{"role":""}`)
+				msgJSON, _ = sjson.SetBytes(msgJSON, "role", role)
+
+				contentArrayJSON := []byte(`[]`)
+				for _, contentItem := range contentItems {
+					contentArrayJSON, _ = sjson.SetRawBytes(contentArrayJSON, "-1", contentItem)
+				}
+				msgJSON, _ = sjson.SetRawBytes(msgJSON, "content", contentArrayJSON)
+
+				msgs = append(msgs, msgJSON)
+			} else if hasToolResults && !hasContent {
+				// tool_results already emitted above, no additional user message needed
+			}
+		}
+
+	} else if contentResult.Exists() && contentResult.Type == gjson.String {
+		// Simple string content
+		msgJSON := []byte(`{"role":"","content":""}`)
+		msgJSON, _ = sjson.SetBytes(msgJSON, "role", role)
+		msgJSON, _ = sjson.SetBytes(msgJSON, "content", contentResult.String())
+		msgs = append(msgs, msgJSON)
+	}
+
+	return msgs
+}
+
 func normalizeObjectSchemaProperties(schema any) any {
 	switch value := schema.(type) {
 	case map[string]any:
@@ -19,6 +19,9 @@ import (
 	"github.com/tidwall/sjson"
 )
 
+// ConvertOpenAIRequestToGrok converts an OpenAI chat request into a Grok conversation payload.
+// Function tools are passed as structured definitions; see ApplyLegacyToolPrompt for the
+// prompt-injected tool protocol.
 func ConvertOpenAIRequestToGrok(modelName string, inputRawJSON []byte, stream bool) []byte {
 	// Streaming is handled by the executor; Grok payload shape is the same for stream/non-stream.
 	rawJSON := bytes.Clone(inputRawJSON)
@@ -59,6 +62,7 @@ func ConvertOpenAIRequestToGrok(modelName string, inputRawJSON []byte, stream bo
 	out, _ = sjson.SetBytes(out, "forceSideBySide", toggles.forceSideBySide)
 	out, _ = sjson.SetBytes(out, "modelMode", cfg.ModelMode)
 	out, _ = sjson.SetBytes(out, "isAsyncChat", toggles.isAsyncChat)
+	out = setGrokTools(out, gjson.ParseBytes(rawJSON))
 
 	// OpenAI parameters (temperature, max_tokens, top_p) are intentionally not mapped here.
 	// Grok controls these via model configuration; executors should enforce or translate
@@ -74,16 +78,6 @@ func extractOpenAIContent(rawJSON []byte) (messageWithRoles string, plainText st
 	var plainBuilder strings.Builder
 	imageAttachments = make([]map[string]string, 0)
 
-	// Extract tools and build tool instruction if present
-	if tools := root.Get("tools"); tools.Exists() && tools.IsArray() && len(tools.Array()) > 0 {
-		toolInstruction := buildToolInstruction(tools)
-		if toolInstruction != "" {
-			contentBuilder.WriteString("system: ")
-			contentBuilder.WriteString(toolInstruction)
-			contentBuilder.WriteString("\n")
-		}
-	}
-
 	if messages := root.Get("messages"); messages.Exists() && messages.IsArray() {
 		messages.ForEach(func(_, msg gjson.Result) bool {
 			role := msg.Get("role").String()
@@ -156,19 +150,6 @@ func extractOpenAIContent(rawJSON []byte) (messageWithRoles string, plainText st
 		})
 	}
 
-	// Append end-of-context reminder if tools are present (combats recency bias)
-	if tools := root.Get("tools"); tools.Exists() && tools.IsArray() && len(tools.Array()) > 0 {
-		contentBuilder.WriteString("\nsystem: [REMINDER BEFORE YOU RESPOND]\n")
-		contentBuilder.WriteString("STOP. Check these before writing ANYTHING:\n")
-		contentBuilder.WriteString("• ONE tool call only, then STOP and wait for result. NO parallel calls!\n")
-		contentBuilder.WriteString("• Did a tool call FAIL? → RETRY with corrected parameters. NEVER give up.\n")
-		contentBuilder.WriteString("• Did an edit fail? → READ the file first, then retry with correct oldString.\n")
-		contentBuilder.WriteString("• Am I claiming success? → Where is my TOOL OUTPUT proof?\n")
-		contentBuilder.WriteString("• Did my last tool say PASS? → If NO, I'm still failing - FIX IT\n")
-		contentBuilder.WriteString("• Using 'done'/'fixed'/'all tests pass' without proof? → FORBIDDEN\n")
-		contentBuilder.WriteString("ONE TOOL CALL → WAIT → RESULT → NEXT CALL. FAILED = RETRY. NO GIVING UP.\n")
-	}
-
 	messageWithRoles = contentBuilder.String()
 	if messageWithRoles == "" {
 		messageWithRoles = "user: Hello\n"
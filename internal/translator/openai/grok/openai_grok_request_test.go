@@ -158,6 +158,50 @@ func TestConvertOpenAIRequestToGrok_ToolHandling(t *testing.T) {
 	}
 }
 
+func TestConvertOpenAIRequestToGrok_NativeTools(t *testing.T) {
+	payload := []byte(`{"model":"grok-3","messages":[{"role":"user","content":"weather?"}],` +
+		`"tools":[{"type":"function","function":{"name":"get_weather","description":"Look up weather","parameters":{"type":"object","properties":{"city":{"type":"string"}}}}},{"type":"web_search"}],` +
+		`"tool_choice":"auto","parallel_tool_calls":false}`)
+	got := ConvertOpenAIRequestToGrok("grok-3", payload, false)
+
+	if n := len(gjson.GetBytes(got, "tools").Array()); n != 1 {
+		t.Fatalf("tools = %s, want only the function tool", gjson.GetBytes(got, "tools").Raw)
+	}
+	if name := gjson.GetBytes(got, "tools.0.function.name").String(); name != "get_weather" {
+		t.Errorf("tool name = %q", name)
+	}
+	if typ := gjson.GetBytes(got, "tools.0.function.parameters.properties.city.type").String(); typ != "string" {
+		t.Errorf("tool parameters = %s", gjson.GetBytes(got, "tools.0.function.parameters").Raw)
+	}
+	if choice := gjson.GetBytes(got, "toolChoice").String(); choice != "auto" {
+		t.Errorf("toolChoice = %q", choice)
+	}
+	if gjson.GetBytes(got, "parallelToolCalls").Bool() {
+		t.Error("parallelToolCalls = true, want false")
+	}
+	if msg := gjson.GetBytes(got, "message").String(); strings.Contains(msg, "AVAILABLE TOOLS") || strings.Contains(msg, "REMINDER") {
+		t.Errorf("native message carries the legacy tool prompt: %q", msg)
+	}
+
+	legacy := ApplyLegacyToolPrompt(got, payload)
+	if gjson.GetBytes(legacy, "tools").Exists() || gjson.GetBytes(legacy, "toolChoice").Exists() {
+		t.Errorf("legacy payload keeps structured tools: %s", legacy)
+	}
+	msg := gjson.GetBytes(legacy, "message").String()
+	if !strings.HasPrefix(msg, "system: ") || !strings.Contains(msg, "- get_weather: Look up weather") || !strings.Contains(msg, "user: weather?") || !strings.Contains(msg, "[REMINDER BEFORE YOU RESPOND]") {
+		t.Errorf("legacy message = %q", msg)
+	}
+
+	plain := []byte(`{"model":"grok-3","messages":[{"role":"user","content":"hi"}]}`)
+	converted := ConvertOpenAIRequestToGrok("grok-3", plain, false)
+	if gjson.GetBytes(converted, "tools").Exists() {
+		t.Errorf("request without tools got tools: %s", converted)
+	}
+	if string(ApplyLegacyToolPrompt(converted, plain)) != string(converted) {
+		t.Error("ApplyLegacyToolPrompt changed a request without tools")
+	}
+}
+
 func TestExtractOpenAIContent(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -0,0 +1,82 @@
+package grok
+
+import (
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// setGrokTools copies the function tools of an OpenAI request into the Grok payload as structured
+// definitions, with the tool choice and parallel tool call setting when present.
+func setGrokTools(out []byte, root gjson.Result) []byte {
+	tools := []byte(`[]`)
+	count := 0
+	root.Get("tools").ForEach(func(_, tool gjson.Result) bool {
+		fn := tool.Get("function")
+		if tool.Get("type").String() != "function" || strings.TrimSpace(fn.Get("name").String()) == "" {
+			return true
+		}
+		def := []byte(`{"type":"function","function":{}}`)
+		def, _ = sjson.SetBytes(def, "function.name", fn.Get("name").String())
+		if desc := fn.Get("description").String(); desc != "" {
+			def, _ = sjson.SetBytes(def, "function.description", desc)
+		}
+		if params := fn.Get("parameters"); params.IsObject() {
+			def, _ = sjson.SetRawBytes(def, "function.parameters", []byte(params.Raw))
+		}
+		if strict := fn.Get("strict"); strict.Exists() {
+			def, _ = sjson.SetBytes(def, "function.strict", strict.Bool())
+		}
+		tools, _ = sjson.SetRawBytes(tools, "-1", def)
+		count++
+		return true
+	})
+	if count == 0 {
+		return out
+	}
+	out, _ = sjson.SetRawBytes(out, "tools", tools)
+	if choice := root.Get("tool_choice"); choice.Exists() {
+		out, _ = sjson.SetRawBytes(out, "toolChoice", []byte(choice.Raw))
+	}
+	if parallel := root.Get("parallel_tool_calls"); parallel.Exists() {
+		out, _ = sjson.SetBytes(out, "parallelToolCalls", parallel.Bool())
+	}
+	return out
+}
+
+// ApplyLegacyToolPrompt rewrites a payload built by ConvertOpenAIRequestToGrok to the
+// prompt-injected tool protocol used before Grok accepted structured tools: the tool definitions
+// move into a system instruction around the conversation and Grok answers with <tool_call> tags.
+// Payloads of requests without tools are returned unchanged.
+func ApplyLegacyToolPrompt(payload, inputRawJSON []byte) []byte {
+	tools := gjson.GetBytes(inputRawJSON, "tools")
+	if !tools.IsArray() || len(tools.Array()) == 0 {
+		return payload
+	}
+	message, _, _ := extractOpenAIContent(inputRawJSON)
+	var builder strings.Builder
+	if instruction := buildToolInstruction(tools); instruction != "" {
+		builder.WriteString("system: ")
+		builder.WriteString(instruction)
+		builder.WriteString("\n")
+	}
+	builder.WriteString(message)
+	builder.WriteString(legacyToolReminder)
+	payload, _ = sjson.SetBytes(payload, "message", builder.String())
+	for _, key := range []string{"tools", "toolChoice", "parallelToolCalls"} {
+		payload, _ = sjson.DeleteBytes(payload, key)
+	}
+	return payload
+}
+
+// legacyToolReminder closes the conversation of the legacy tool protocol to combat recency bias.
+const legacyToolReminder = "\nsystem: [REMINDER BEFORE YOU RESPOND]\n" +
+	"STOP. Check these before writing ANYTHING:\n" +
+	"• ONE tool call only, then STOP and wait for result. NO parallel calls!\n" +
+	"• Did a tool call FAIL? → RETRY with corrected parameters. NEVER give up.\n" +
+	"• Did an edit fail? → READ the file first, then retry with correct oldString.\n" +
+	"• Am I claiming success? → Where is my TOOL OUTPUT proof?\n" +
+	"• Did my last tool say PASS? → If NO, I'm still failing - FIX IT\n" +
+	"• Using 'done'/'fixed'/'all tests pass' without proof? → FORBIDDEN\n" +
+	"ONE TOOL CALL → WAIT → RESULT → NEXT CALL. FAILED = RETRY. NO GIVING UP.\n"
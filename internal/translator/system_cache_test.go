@@ -0,0 +1,65 @@
+package translator
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/constant"
+	"github.com/tidwall/sjson"
+)
+
+// largeSystemPromptPayload returns the benchmark payload of format with a system prompt big
+// enough for the translator system prompt cache.
+func largeSystemPromptPayload(tb testing.TB, format string, tag string) []byte {
+	tb.Helper()
+	prompt := tag + ": " + strings.Repeat("Follow the repository conventions and explain every change. ", 200)
+	payload := benchmarkPayload(tb, format)
+	var errSet error
+	switch format {
+	case constant.OpenAI:
+		payload, errSet = sjson.SetBytes(payload, "messages.0.content", prompt)
+	case constant.OpenaiResponse:
+		payload, errSet = sjson.SetBytes(payload, "input.0.content", prompt)
+	case constant.Claude:
+		payload, errSet = sjson.SetBytes(payload, "system", prompt)
+	case constant.Gemini:
+		payload, errSet = sjson.SetBytes(payload, "systemInstruction.parts.0.text", prompt)
+	}
+	if errSet != nil {
+		tb.Fatalf("set %s system prompt: %v", format, errSet)
+	}
+	return payload
+}
+
+func TestTranslateRequestSystemPromptCacheMatchesFullTranslation(t *testing.T) {
+	for _, tc := range translatorBenchmarkCases(t) {
+		t.Run(tc.name, func(t *testing.T) {
+			// Prompts below the cache threshold always take the full translation path.
+			if !bytes.Equal(benchmarkTranslateRequest(tc), benchmarkTranslateRequest(tc)) {
+				t.Skip("translator output is not deterministic")
+			}
+			cached := tc
+			cached.payload = largeSystemPromptPayload(t, tc.from.String(), t.Name())
+			// The first call translates the whole payload and fills the cache; the second one
+			// splices the cached system prompt into the translated remainder.
+			want := benchmarkTranslateRequest(cached)
+			if got := benchmarkTranslateRequest(cached); !bytes.Equal(got, want) {
+				t.Fatalf("cached translation differs from the full translation\ngot:  %s\nwant: %s", got, want)
+			}
+		})
+	}
+}
+
+func BenchmarkTranslateRequestLargeSystemPrompt(b *testing.B) {
+	for _, tc := range translatorBenchmarkCases(b) {
+		tc.payload = largeSystemPromptPayload(b, tc.from.String(), "benchmark")
+		b.Run(tc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(tc.payload)))
+			for i := 0; i < b.N; i++ {
+				benchmarkTranslateRequest(tc)
+			}
+		})
+	}
+}
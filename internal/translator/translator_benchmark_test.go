@@ -0,0 +1,282 @@
+package translator
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/constant"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	benchmarkHistoryMessages = 100
+	benchmarkTools           = 20
+	benchmarkModel           = "benchmark-model"
+
+	// translatorRequestBudget is the most a single request translation of a benchmark payload may
+	// take in the performance budget check. It leaves headroom for shared CI runners.
+	translatorRequestBudget = 50 * time.Millisecond
+	// translatorBudgetEnv enables the performance budget check, which is skipped by default
+	// because timings on a loaded developer machine are noisy.
+	translatorBudgetEnv = "TRANSLATOR_PERF_BUDGET"
+)
+
+var benchmarkSourceFormats = []string{
+	constant.OpenAI,
+	constant.OpenaiResponse,
+	constant.Claude,
+	constant.Gemini,
+}
+
+var benchmarkTargetFormats = []string{
+	constant.OpenAI,
+	constant.OpenaiResponse,
+	constant.Claude,
+	constant.Gemini,
+	constant.GeminiCLI,
+	constant.Codex,
+	constant.Antigravity,
+	constant.Interactions,
+	constant.Grok,
+	constant.Kiro,
+}
+
+func TestMain(m *testing.M) {
+	// Some translators warn about the unknown benchmark model on every call.
+	log.SetLevel(log.ErrorLevel)
+	os.Exit(m.Run())
+}
+
+type translatorBenchmarkCase struct {
+	name     string
+	from, to sdktranslator.Format
+	payload  []byte
+}
+
+// translatorBenchmarkCases returns every registered request translator out of the benchmark
+// source formats, each with a 100-message history that uses tools.
+func translatorBenchmarkCases(tb testing.TB) []translatorBenchmarkCase {
+	tb.Helper()
+	var cases []translatorBenchmarkCase
+	for _, from := range benchmarkSourceFormats {
+		payload := benchmarkPayload(tb, from)
+		for _, to := range benchmarkTargetFormats {
+			if from == to || !sdktranslator.HasRequestTransformer(sdktranslator.FromString(from), sdktranslator.FromString(to)) {
+				continue
+			}
+			cases = append(cases, translatorBenchmarkCase{
+				name:    from + "_to_" + to,
+				from:    sdktranslator.FromString(from),
+				to:      sdktranslator.FromString(to),
+				payload: payload,
+			})
+		}
+	}
+	if len(cases) == 0 {
+		tb.Fatal("no request translators registered")
+	}
+	return cases
+}
+
+func BenchmarkTranslateRequestLargeHistory(b *testing.B) {
+	for _, tc := range translatorBenchmarkCases(b) {
+		b.Run(tc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(tc.payload)))
+			for i := 0; i < b.N; i++ {
+				benchmarkTranslateRequest(tc)
+			}
+		})
+	}
+}
+
+func TestTranslateRequestPerformanceBudget(t *testing.T) {
+	if os.Getenv(translatorBudgetEnv) == "" {
+		t.Skipf("set %s=1 to check translator performance budgets", translatorBudgetEnv)
+	}
+	for _, tc := range translatorBenchmarkCases(t) {
+		result := testing.Benchmark(func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				benchmarkTranslateRequest(tc)
+			}
+		})
+		perOp := time.Duration(result.NsPerOp())
+		t.Logf("%s: %s/op, %d B/op, %d allocs/op", tc.name, perOp, result.AllocedBytesPerOp(), result.AllocsPerOp())
+		if perOp > translatorRequestBudget {
+			t.Errorf("%s: %s/op exceeds the %s budget", tc.name, perOp, translatorRequestBudget)
+		}
+	}
+}
+
+func benchmarkTranslateRequest(tc translatorBenchmarkCase) []byte {
+	// Translators may rewrite their input in place, so each run gets a fresh copy.
+	payload := append([]byte(nil), tc.payload...)
+	return sdktranslator.TranslateRequest(tc.from, tc.to, benchmarkModel, payload, true)
+}
+
+func benchmarkPayload(tb testing.TB, format string) []byte {
+	tb.Helper()
+	var body map[string]any
+	switch format {
+	case constant.OpenAI:
+		body = benchmarkOpenAIChatPayload()
+	case constant.OpenaiResponse:
+		body = benchmarkOpenAIResponsesPayload()
+	case constant.Claude:
+		body = benchmarkClaudePayload()
+	case constant.Gemini:
+		body = benchmarkGeminiPayload()
+	default:
+		tb.Fatalf("no benchmark payload for %s", format)
+	}
+	payload, errMarshal := json.Marshal(body)
+	if errMarshal != nil {
+		tb.Fatalf("marshal %s benchmark payload: %v", format, errMarshal)
+	}
+	return payload
+}
+
+func benchmarkToolSchema(index int) map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"path":    map[string]any{"type": "string", "description": fmt.Sprintf("Path for tool %d", index)},
+			"limit":   map[string]any{"type": "integer", "minimum": 1},
+			"options": map[string]any{"type": "array", "items": map[string]any{"type": "string", "enum": []string{"a", "b", "c"}}},
+		},
+		"required": []string{"path"},
+	}
+}
+
+func benchmarkText(turn int) string {
+	return fmt.Sprintf("Turn %d: please look at the repository layout and explain how the request flows through the handlers, executors and translators before changing anything.", turn)
+}
+
+func benchmarkToolResult(turn int) string {
+	return fmt.Sprintf(`{"turn":%d,"files":["cmd/server/main.go","internal/api/server.go","sdk/cliproxy/service.go"],"lines":%d}`, turn, turn*37)
+}
+
+// Every fourth exchange is a tool call and its result; the others are plain text turns.
+func benchmarkIsToolTurn(turn int) bool { return turn%4 == 3 }
+
+func benchmarkOpenAIChatPayload() map[string]any {
+	tools := make([]any, 0, benchmarkTools)
+	for i := 0; i < benchmarkTools; i++ {
+		tools = append(tools, map[string]any{"type": "function", "function": map[string]any{
+			"name": fmt.Sprintf("tool_%d", i), "description": "Benchmark tool", "parameters": benchmarkToolSchema(i),
+		}})
+	}
+	messages := []any{map[string]any{"role": "system", "content": "You are a careful coding agent."}}
+	for turn := 0; len(messages) < benchmarkHistoryMessages; turn++ {
+		if benchmarkIsToolTurn(turn) {
+			callID := fmt.Sprintf("call_%d", turn)
+			messages = append(messages,
+				map[string]any{"role": "assistant", "content": "", "tool_calls": []any{map[string]any{
+					"id": callID, "type": "function",
+					"function": map[string]any{"name": fmt.Sprintf("tool_%d", turn%benchmarkTools), "arguments": `{"path":"internal/api"}`},
+				}}},
+				map[string]any{"role": "tool", "tool_call_id": callID, "content": benchmarkToolResult(turn)},
+			)
+			continue
+		}
+		messages = append(messages,
+			map[string]any{"role": "user", "content": benchmarkText(turn)},
+			map[string]any{"role": "assistant", "content": benchmarkText(turn)},
+		)
+	}
+	return map[string]any{"model": benchmarkModel, "stream": true, "messages": messages, "tools": tools}
+}
+
+func benchmarkOpenAIResponsesPayload() map[string]any {
+	tools := make([]any, 0, benchmarkTools)
+	for i := 0; i < benchmarkTools; i++ {
+		tools = append(tools, map[string]any{
+			"type": "function", "name": fmt.Sprintf("tool_%d", i), "description": "Benchmark tool", "parameters": benchmarkToolSchema(i),
+		})
+	}
+	input := []any{map[string]any{"type": "message", "role": "system", "content": "You are a careful coding agent."}}
+	for turn := 0; len(input) < benchmarkHistoryMessages; turn++ {
+		if benchmarkIsToolTurn(turn) {
+			callID := fmt.Sprintf("call_%d", turn)
+			input = append(input,
+				map[string]any{"type": "function_call", "call_id": callID, "name": fmt.Sprintf("tool_%d", turn%benchmarkTools), "arguments": `{"path":"internal/api"}`},
+				map[string]any{"type": "function_call_output", "call_id": callID, "output": benchmarkToolResult(turn)},
+			)
+			continue
+		}
+		input = append(input,
+			map[string]any{"type": "message", "role": "user", "content": []any{map[string]any{"type": "input_text", "text": benchmarkText(turn)}}},
+			map[string]any{"type": "message", "role": "assistant", "content": []any{map[string]any{"type": "output_text", "text": benchmarkText(turn)}}},
+		)
+	}
+	return map[string]any{"model": benchmarkModel, "stream": true, "input": input, "tools": tools}
+}
+
+func benchmarkClaudePayload() map[string]any {
+	tools := make([]any, 0, benchmarkTools)
+	for i := 0; i < benchmarkTools; i++ {
+		tools = append(tools, map[string]any{
+			"name": fmt.Sprintf("tool_%d", i), "description": "Benchmark tool", "input_schema": benchmarkToolSchema(i),
+		})
+	}
+	var messages []any
+	for turn := 0; len(messages) < benchmarkHistoryMessages; turn++ {
+		if benchmarkIsToolTurn(turn) {
+			callID := fmt.Sprintf("toolu_%d", turn)
+			messages = append(messages,
+				map[string]any{"role": "assistant", "content": []any{map[string]any{
+					"type": "tool_use", "id": callID, "name": fmt.Sprintf("tool_%d", turn%benchmarkTools), "input": map[string]any{"path": "internal/api"},
+				}}},
+				map[string]any{"role": "user", "content": []any{map[string]any{
+					"type": "tool_result", "tool_use_id": callID, "content": benchmarkToolResult(turn),
+				}}},
+			)
+			continue
+		}
+		messages = append(messages,
+			map[string]any{"role": "user", "content": []any{map[string]any{"type": "text", "text": benchmarkText(turn)}}},
+			map[string]any{"role": "assistant", "content": []any{map[string]any{"type": "text", "text": benchmarkText(turn)}}},
+		)
+	}
+	return map[string]any{
+		"model": benchmarkModel, "stream": true, "max_tokens": 4096,
+		"system": "You are a careful coding agent.", "messages": messages, "tools": tools,
+	}
+}
+
+func benchmarkGeminiPayload() map[string]any {
+	declarations := make([]any, 0, benchmarkTools)
+	for i := 0; i < benchmarkTools; i++ {
+		declarations = append(declarations, map[string]any{
+			"name": fmt.Sprintf("tool_%d", i), "description": "Benchmark tool", "parameters": benchmarkToolSchema(i),
+		})
+	}
+	var contents []any
+	for turn := 0; len(contents) < benchmarkHistoryMessages; turn++ {
+		if benchmarkIsToolTurn(turn) {
+			name := fmt.Sprintf("tool_%d", turn%benchmarkTools)
+			contents = append(contents,
+				map[string]any{"role": "model", "parts": []any{map[string]any{
+					"functionCall": map[string]any{"name": name, "args": map[string]any{"path": "internal/api"}},
+				}}},
+				map[string]any{"role": "user", "parts": []any{map[string]any{
+					"functionResponse": map[string]any{"name": name, "response": map[string]any{"result": benchmarkToolResult(turn)}},
+				}}},
+			)
+			continue
+		}
+		contents = append(contents,
+			map[string]any{"role": "user", "parts": []any{map[string]any{"text": benchmarkText(turn)}}},
+			map[string]any{"role": "model", "parts": []any{map[string]any{"text": benchmarkText(turn)}}},
+		)
+	}
+	return map[string]any{
+		"systemInstruction": map[string]any{"parts": []any{map[string]any{"text": "You are a careful coding agent."}}},
+		"contents":          contents,
+		"tools":             []any{map[string]any{"functionDeclarations": declarations}},
+	}
+}
@@ -0,0 +1,190 @@
+// Package usagereport aggregates usage records into a periodic summary per provider, model and
+// client key, rendered as markdown or HTML.
+package usagereport
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+// maxRows bounds the rows collected between two reports; further combinations are folded
+// into a single "other" row.
+const maxRows = 5000
+
+// Row is the usage of one provider, model and client key combination.
+type Row struct {
+	Provider     string
+	Model        string
+	Key          string
+	Requests     int64
+	Failed       int64
+	InputTokens  int64
+	OutputTokens int64
+}
+
+// Report is the usage collected over one period.
+type Report struct {
+	Start time.Time
+	End   time.Time
+	Rows  []Row
+}
+
+type rowKey struct {
+	provider, model, key string
+}
+
+type collector struct {
+	enabled atomic.Bool
+	mu      sync.Mutex
+	start   time.Time
+	rows    map[rowKey]*Row
+}
+
+var active = &collector{start: time.Now(), rows: make(map[rowKey]*Row)}
+
+func init() {
+	coreusage.RegisterPlugin(usagePlugin{})
+}
+
+type usagePlugin struct{}
+
+func (usagePlugin) HandleUsage(_ context.Context, record coreusage.Record) {
+	if active.enabled.Load() {
+		active.add(record)
+	}
+}
+
+func (c *collector) add(record coreusage.Record) {
+	model := strings.TrimSpace(record.Alias)
+	if model == "" {
+		model = strings.TrimSpace(record.Model)
+	}
+	key := rowKey{provider: valueOr(record.Provider, "unknown"), model: valueOr(model, "unknown"), key: valueOr(util.HideAPIKey(strings.TrimSpace(record.APIKey)), "-")}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	row, ok := c.rows[key]
+	if !ok {
+		if len(c.rows) >= maxRows {
+			key = rowKey{provider: "other", model: "other", key: "other"}
+			row = c.rows[key]
+		}
+		if row == nil {
+			row = &Row{Provider: key.provider, Model: key.model, Key: key.key}
+			c.rows[key] = row
+		}
+	}
+	row.Requests++
+	if record.Failed {
+		row.Failed++
+	}
+	row.InputTokens += record.Detail.InputTokens
+	row.OutputTokens += record.Detail.OutputTokens
+}
+
+// take returns the usage collected since the previous call and starts a new period.
+func (c *collector) take(now time.Time) Report {
+	c.mu.Lock()
+	rows, start := c.rows, c.start
+	c.rows, c.start = make(map[rowKey]*Row), now
+	c.mu.Unlock()
+
+	report := Report{Start: start, End: now, Rows: make([]Row, 0, len(rows))}
+	for _, row := range rows {
+		report.Rows = append(report.Rows, *row)
+	}
+	sort.Slice(report.Rows, func(i, j int) bool {
+		a, b := report.Rows[i], report.Rows[j]
+		if a.Provider != b.Provider {
+			return a.Provider < b.Provider
+		}
+		if a.Model != b.Model {
+			return a.Model < b.Model
+		}
+		return a.Key < b.Key
+	})
+	return report
+}
+
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// Render formats report as "markdown" or "html". Estimated costs use the model-pricing entries
+// of pricing; models without a price show "-".
+func Render(report Report, format string, pricing *config.SDKConfig) string {
+	header := []string{"Provider", "Model", "Key", "Requests", "Input tokens", "Output tokens", "Est. cost", "Error rate"}
+	var total Row
+	var totalCost float64
+	rows := make([][]string, 0, len(report.Rows)+1)
+	for _, row := range report.Rows {
+		cost := "-"
+		if price, ok := pricing.PricingForModel(row.Model); ok {
+			value := (float64(row.InputTokens)*price.InputPerMillion + float64(row.OutputTokens)*price.OutputPerMillion) / 1e6
+			totalCost += value
+			cost = fmt.Sprintf("$%.4f", value)
+		}
+		rows = append(rows, rowCells(row, cost))
+		total.Requests += row.Requests
+		total.Failed += row.Failed
+		total.InputTokens += row.InputTokens
+		total.OutputTokens += row.OutputTokens
+	}
+	total.Provider = "Total"
+	rows = append(rows, rowCells(total, fmt.Sprintf("$%.4f", totalCost)))
+
+	title := fmt.Sprintf("Usage report %s - %s", report.Start.Format(time.RFC3339), report.End.Format(time.RFC3339))
+	var b strings.Builder
+	if strings.EqualFold(strings.TrimSpace(format), "html") {
+		b.WriteString("<!DOCTYPE html>\n<html><head><meta charset=\"utf-8\"><title>" + html.EscapeString(title) + "</title></head><body>\n")
+		b.WriteString("<h1>" + html.EscapeString(title) + "</h1>\n<table>\n<tr>")
+		for _, cell := range header {
+			b.WriteString("<th>" + html.EscapeString(cell) + "</th>")
+		}
+		b.WriteString("</tr>\n")
+		for _, cells := range rows {
+			b.WriteString("<tr>")
+			for _, cell := range cells {
+				b.WriteString("<td>" + html.EscapeString(cell) + "</td>")
+			}
+			b.WriteString("</tr>\n")
+		}
+		b.WriteString("</table>\n</body></html>\n")
+		return b.String()
+	}
+	b.WriteString("# " + title + "\n\n")
+	b.WriteString("| " + strings.Join(header, " | ") + " |\n")
+	b.WriteString(strings.Repeat("| --- ", len(header)) + "|\n")
+	for _, cells := range rows {
+		for i, cell := range cells {
+			cells[i] = strings.ReplaceAll(cell, "|", "\\|")
+		}
+		b.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+	return b.String()
+}
+
+func rowCells(row Row, cost string) []string {
+	errorRate := "-"
+	if row.Requests > 0 {
+		errorRate = fmt.Sprintf("%.1f%%", float64(row.Failed)*100/float64(row.Requests))
+	}
+	return []string{
+		row.Provider, row.Model, row.Key,
+		fmt.Sprint(row.Requests), fmt.Sprint(row.InputTokens), fmt.Sprint(row.OutputTokens),
+		cost, errorRate,
+	}
+}
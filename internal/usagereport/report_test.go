@@ -0,0 +1,58 @@
+package usagereport
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+func TestCollectorAggregatesAndResets(t *testing.T) {
+	c := &collector{rows: make(map[rowKey]*Row)}
+	c.add(coreusage.Record{Provider: "claude", Model: "claude-sonnet", APIKey: "sk-client-0001", Detail: coreusage.Detail{InputTokens: 100, OutputTokens: 10}})
+	c.add(coreusage.Record{Provider: "claude", Model: "claude-sonnet", APIKey: "sk-client-0001", Failed: true})
+	c.add(coreusage.Record{Provider: "codex", Alias: "gpt-5", Model: "gpt-5-codex"})
+
+	report := c.take(time.Now())
+	if len(report.Rows) != 2 {
+		t.Fatalf("rows = %+v, want 2", report.Rows)
+	}
+	claude := report.Rows[0]
+	if claude.Key != "sk-c...0001" || claude.Requests != 2 || claude.Failed != 1 || claude.InputTokens != 100 {
+		t.Fatalf("unexpected claude row: %+v", claude)
+	}
+	if codex := report.Rows[1]; codex.Model != "gpt-5" || codex.Key != "-" {
+		t.Fatalf("unexpected codex row: %+v", codex)
+	}
+	if next := c.take(time.Now()); len(next.Rows) != 0 {
+		t.Fatalf("expected a new period to start empty, got %+v", next.Rows)
+	}
+}
+
+func TestRenderMarkdownIncludesCostAndErrorRate(t *testing.T) {
+	report := Report{
+		Start: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		End:   time.Date(2026, 1, 2, 0, 0, 0, 0, time.UTC),
+		Rows: []Row{
+			{Provider: "claude", Model: "claude-sonnet", Key: "k", Requests: 4, Failed: 1, InputTokens: 1_000_000, OutputTokens: 500_000},
+			{Provider: "codex", Model: "gpt-5", Key: "k", Requests: 1},
+		},
+	}
+	pricing := &config.SDKConfig{ModelPricing: []config.ModelPricing{{Model: "claude-*", InputPerMillion: 3, OutputPerMillion: 15}}}
+
+	out := Render(report, "markdown", pricing)
+	for _, want := range []string{
+		"| claude | claude-sonnet | k | 4 | 1000000 | 500000 | $10.5000 | 25.0% |",
+		"| codex | gpt-5 | k | 1 | 0 | 0 | - | 0.0% |",
+		"| Total |  |  | 5 | 1000000 | 500000 | $10.5000 | 20.0% |",
+	} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("report is missing %q:\n%s", want, out)
+		}
+	}
+	if html := Render(report, "html", pricing); !strings.Contains(html, "<td>claude-sonnet</td>") {
+		t.Fatalf("html report is missing rows:\n%s", html)
+	}
+}
@@ -0,0 +1,154 @@
+package usagereport
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/scheduledjobs"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultSchedule = "@daily"
+	webhookTimeout  = 30 * time.Second
+)
+
+var (
+	runMu     sync.Mutex
+	runCancel context.CancelFunc
+	runCfg    config.UsageReportConfig
+	// pricing is the configuration whose model-pricing entries price the next report.
+	pricing *config.SDKConfig
+)
+
+// Apply starts, reschedules or stops report generation for cfg. Collected usage survives a
+// reschedule and is reported on the next run.
+func Apply(cfg *config.Config) {
+	runMu.Lock()
+	defer runMu.Unlock()
+	var next config.UsageReportConfig
+	if cfg != nil {
+		next = cfg.UsageReport
+		pricing = &cfg.SDKConfig
+	}
+	if runCancel != nil && next == runCfg {
+		return
+	}
+	if runCancel != nil {
+		runCancel()
+		runCancel = nil
+	}
+	wasEnabled := runCfg.Enabled
+	runCfg = next
+	active.enabled.Store(next.Enabled)
+	if !next.Enabled {
+		return
+	}
+	if !wasEnabled {
+		// Start the first period now rather than at a previous enablement.
+		active.take(time.Now())
+	}
+	spec := strings.TrimSpace(next.Schedule)
+	if spec == "" {
+		spec = defaultSchedule
+	}
+	schedule, errSchedule := scheduledjobs.ParseSchedule(spec)
+	if errSchedule != nil {
+		log.Warnf("usage report: invalid schedule %q: %v", spec, errSchedule)
+		return
+	}
+	if strings.TrimSpace(next.Dir) == "" && strings.TrimSpace(next.WebhookURL) == "" {
+		log.Warnf("usage report: enabled without dir or webhook-url; reports are discarded")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	runCancel = cancel
+	go loop(ctx, schedule, next)
+}
+
+// Stop stops report generation and usage collection.
+func Stop() {
+	Apply(nil)
+}
+
+func loop(ctx context.Context, schedule scheduledjobs.Schedule, cfg config.UsageReportConfig) {
+	for {
+		now := time.Now()
+		at := schedule.Next(now)
+		if at.IsZero() {
+			log.Warnf("usage report: schedule has no upcoming run; stopping")
+			return
+		}
+		timer := time.NewTimer(at.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		runMu.Lock()
+		prices := pricing
+		runMu.Unlock()
+		if errRun := publish(ctx, cfg, active.take(time.Now()), prices); errRun != nil {
+			log.Warnf("usage report: %v", errRun)
+		}
+	}
+}
+
+// publish renders report and writes it to the configured directory and webhook.
+func publish(ctx context.Context, cfg config.UsageReportConfig, report Report, prices *config.SDKConfig) error {
+	format, extension, contentType := "markdown", ".md", "text/markdown; charset=utf-8"
+	if strings.EqualFold(strings.TrimSpace(cfg.Format), "html") {
+		format, extension, contentType = "html", ".html", "text/html; charset=utf-8"
+	}
+	body := Render(report, format, prices)
+
+	var errs []string
+	if dir := strings.TrimSpace(cfg.Dir); dir != "" {
+		if errDir := os.MkdirAll(dir, 0o755); errDir != nil {
+			errs = append(errs, fmt.Sprintf("create %s: %v", dir, errDir))
+		} else {
+			path := filepath.Join(dir, "usage-report-"+report.End.Format("2006-01-02-1504")+extension)
+			if errWrite := os.WriteFile(path, []byte(body), 0o644); errWrite != nil {
+				errs = append(errs, fmt.Sprintf("write %s: %v", path, errWrite))
+			} else {
+				log.Infof("usage report: wrote %s", path)
+			}
+		}
+	}
+	if url := strings.TrimSpace(cfg.WebhookURL); url != "" {
+		if errPost := post(ctx, url, contentType, body); errPost != nil {
+			errs = append(errs, fmt.Sprintf("post %s: %v", url, errPost))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+func post(ctx context.Context, url, contentType, body string) error {
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader([]byte(body)))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
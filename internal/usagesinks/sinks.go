@@ -0,0 +1,75 @@
+// Package usagesinks implements the usage sinks that can be configured under usage-sinks.
+package usagesinks
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+)
+
+// pluginPrefix namespaces configured sinks among the named usage plugins.
+const pluginPrefix = "usage-sink:"
+
+type sink interface {
+	coreusage.Sink
+	io.Closer
+}
+
+var (
+	mu     sync.Mutex
+	active = make(map[string]sink)
+)
+
+// Apply replaces the configured sinks with sinks. Invalid entries are logged and skipped.
+func Apply(sinks []config.UsageSink) {
+	next := make(map[string]sink, len(sinks))
+	for i, cfg := range sinks {
+		name := strings.TrimSpace(cfg.Name)
+		if name == "" {
+			name = fmt.Sprintf("%d", i)
+		}
+		if _, duplicate := next[name]; duplicate {
+			log.Warnf("usage sinks: duplicate sink name %q, skipping", name)
+			continue
+		}
+		created, err := newSink(cfg)
+		if err != nil {
+			log.Warnf("usage sinks: sink %q: %v", name, err)
+			continue
+		}
+		next[name] = created
+	}
+
+	mu.Lock()
+	previous := active
+	active = next
+	mu.Unlock()
+
+	for name, created := range next {
+		coreusage.RegisterSink(pluginPrefix+name, created)
+	}
+	for name, old := range previous {
+		if _, kept := next[name]; !kept {
+			coreusage.UnregisterNamedPlugin(pluginPrefix + name)
+		}
+		if errClose := old.Close(); errClose != nil {
+			log.Debugf("usage sinks: close sink %q: %v", name, errClose)
+		}
+	}
+}
+
+func newSink(cfg config.UsageSink) (sink, error) {
+	switch strings.ToLower(strings.TrimSpace(cfg.Type)) {
+	case "webhook":
+		return newWebhookSink(cfg.URL, cfg.Headers)
+	case "statsd":
+		return newStatsdSink(cfg.Address, cfg.Prefix)
+	default:
+		return nil, fmt.Errorf("unknown type %q", cfg.Type)
+	}
+}
@@ -0,0 +1,75 @@
+package usagesinks
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+func TestStatsdPacket(t *testing.T) {
+	packet := string(statsdPacket("proxy", coreusage.Event{
+		Provider:  "claude",
+		Model:     "claude:sonnet",
+		AuthType:  "oauth",
+		LatencyMs: 120,
+		Tokens:    coreusage.EventTokens{Input: 10, Output: 4},
+	}))
+	tags := "|#provider:claude,model:claude_sonnet,auth_type:oauth,failed:false"
+	want := strings.Join([]string{
+		"proxy.requests:1|c" + tags,
+		"proxy.latency:120|ms" + tags,
+		"proxy.tokens.input:10|c" + tags,
+		"proxy.tokens.output:4|c" + tags,
+	}, "\n")
+	if packet != want {
+		t.Fatalf("packet =\n%s\nwant\n%s", packet, want)
+	}
+}
+
+func TestWebhookSinkPostsEvent(t *testing.T) {
+	received := make(chan coreusage.Event, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer t" {
+			t.Errorf("missing configured header: %v", r.Header)
+		}
+		body, _ := io.ReadAll(r.Body)
+		var event coreusage.Event
+		if err := json.Unmarshal(body, &event); err != nil {
+			t.Errorf("decode event: %v", err)
+		}
+		received <- event
+	}))
+	defer server.Close()
+
+	sink, err := newWebhookSink(server.URL, map[string]string{"Authorization": "Bearer t"})
+	if err != nil {
+		t.Fatalf("newWebhookSink: %v", err)
+	}
+	defer func() { _ = sink.Close() }()
+	sink.HandleEvent(context.Background(), coreusage.Event{Model: "m", RequestID: "req-1"})
+
+	select {
+	case event := <-received:
+		if event.Model != "m" || event.RequestID != "req-1" {
+			t.Fatalf("unexpected event: %+v", event)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook was not called")
+	}
+}
+
+func TestNewSinkRejectsInvalidConfig(t *testing.T) {
+	if _, err := newWebhookSink("ftp://example.com", nil); err == nil {
+		t.Fatal("expected non-http webhook url to be rejected")
+	}
+	if _, err := newStatsdSink("", ""); err == nil {
+		t.Fatal("expected missing statsd address to be rejected")
+	}
+}
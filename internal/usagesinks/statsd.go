@@ -0,0 +1,91 @@
+package usagesinks
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+const defaultStatsdPrefix = "cliproxy"
+
+// statsdSink writes DogStatsD-tagged counters and timers over UDP. Writes never block on the
+// collector; lost packets are accepted as with any statsd client.
+type statsdSink struct {
+	conn   net.Conn
+	prefix string
+}
+
+func newStatsdSink(address, prefix string) (*statsdSink, error) {
+	address = strings.TrimSpace(address)
+	if address == "" {
+		return nil, fmt.Errorf("statsd address is required")
+	}
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, fmt.Errorf("dial statsd %s: %w", address, err)
+	}
+	prefix = strings.Trim(strings.TrimSpace(prefix), ".")
+	if prefix == "" {
+		prefix = defaultStatsdPrefix
+	}
+	return &statsdSink{conn: conn, prefix: prefix}, nil
+}
+
+func (s *statsdSink) HandleEvent(_ context.Context, event coreusage.Event) {
+	_, _ = s.conn.Write(statsdPacket(s.prefix, event))
+}
+
+// Close closes the UDP socket.
+func (s *statsdSink) Close() error {
+	return s.conn.Close()
+}
+
+// statsdPacket renders the metrics of one event as newline-separated statsd lines.
+func statsdPacket(prefix string, event coreusage.Event) []byte {
+	tags := "|#provider:" + statsdTag(event.Provider) +
+		",model:" + statsdTag(event.Model) +
+		",auth_type:" + statsdTag(event.AuthType) +
+		",failed:" + strconv.FormatBool(event.Failed)
+
+	var b strings.Builder
+	line := func(name string, value int64, kind string) {
+		b.WriteString(prefix)
+		b.WriteByte('.')
+		b.WriteString(name)
+		b.WriteByte(':')
+		b.WriteString(strconv.FormatInt(value, 10))
+		b.WriteByte('|')
+		b.WriteString(kind)
+		b.WriteString(tags)
+		b.WriteByte('\n')
+	}
+	line("requests", 1, "c")
+	line("latency", event.LatencyMs, "ms")
+	if event.TTFTMs > 0 {
+		line("ttft", event.TTFTMs, "ms")
+	}
+	line("tokens.input", event.Tokens.Input, "c")
+	line("tokens.output", event.Tokens.Output, "c")
+	if event.Tokens.Reasoning > 0 {
+		line("tokens.reasoning", event.Tokens.Reasoning, "c")
+	}
+	if event.Tokens.Cached > 0 {
+		line("tokens.cached", event.Tokens.Cached, "c")
+	}
+	return []byte(strings.TrimSuffix(b.String(), "\n"))
+}
+
+// statsdTag strips the characters that delimit statsd fields and tags.
+func statsdTag(value string) string {
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case '|', ',', '#', ':', '\n', ' ':
+			return '_'
+		}
+		return r
+	}, value)
+}
@@ -0,0 +1,103 @@
+package usagesinks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	webhookQueueSize = 256
+	webhookTimeout   = 10 * time.Second
+)
+
+// webhookSink POSTs each event as JSON from its own goroutine so a slow endpoint does not hold
+// up the usage dispatcher. Events are dropped while the queue is full.
+type webhookSink struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+	queue   chan coreusage.Event
+	done    chan struct{}
+	once    sync.Once
+}
+
+func newWebhookSink(rawURL string, headers map[string]string) (*webhookSink, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("webhook url %q must be an absolute http(s) URL", rawURL)
+	}
+	s := &webhookSink{
+		url:     rawURL,
+		headers: headers,
+		client:  &http.Client{Timeout: webhookTimeout},
+		queue:   make(chan coreusage.Event, webhookQueueSize),
+		done:    make(chan struct{}),
+	}
+	go s.run()
+	return s, nil
+}
+
+func (s *webhookSink) HandleEvent(_ context.Context, event coreusage.Event) {
+	select {
+	case <-s.done:
+	case s.queue <- event:
+	default:
+		log.Warnf("usage sinks: webhook %s is falling behind, dropping usage event", s.url)
+	}
+}
+
+func (s *webhookSink) run() {
+	for {
+		select {
+		case <-s.done:
+			return
+		case event := <-s.queue:
+			if err := s.post(event); err != nil {
+				log.Warnf("usage sinks: webhook %s: %v", s.url, err)
+			}
+		}
+	}
+}
+
+func (s *webhookSink) post(event coreusage.Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range s.headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Close stops the sender; queued events are dropped.
+func (s *webhookSink) Close() error {
+	s.once.Do(func() { close(s.done) })
+	return nil
+}
@@ -46,6 +46,7 @@ type ClaudeToolResult struct {
 //
 // Unlike Antigravity, image blocks without base64 data are dropped rather than
 // emitted as empty inline data parts, matching the Gemini image part guards.
+// Images over MaxToolResultImageBytes are replaced by a text block noting the omission.
 func ConvertClaudeToolResultContent(content gjson.Result) ClaudeToolResult {
 	switch {
 	case content.Type == gjson.String:
@@ -56,6 +57,13 @@ func ConvertClaudeToolResultContent(content gjson.Result) ClaudeToolResult {
 		lastNonImageRaw := ""
 		filtered := []byte(`[]`)
 		content.ForEach(func(_, block gjson.Result) bool {
+			if isClaudeBase64Image(block) && ToolResultImageTooLarge(block.Get("source.data").String()) {
+				note, _ := sjson.SetBytes([]byte(`{"type":"text","text":""}`), "text", OmittedToolResultImageText(block.Get("source.data").String()))
+				nonImageCount++
+				lastNonImageRaw = string(note)
+				filtered, _ = sjson.SetRawBytes(filtered, "-1", note)
+				return true
+			}
 			if isClaudeBase64Image(block) {
 				if img, ok := claudeImageFromBlock(block); ok {
 					images = append(images, img)
@@ -77,6 +85,9 @@ func ConvertClaudeToolResultContent(content gjson.Result) ClaudeToolResult {
 		}
 	case content.IsObject():
 		if isClaudeBase64Image(content) {
+			if data := content.Get("source.data").String(); ToolResultImageTooLarge(data) {
+				return ClaudeToolResult{Result: OmittedToolResultImageText(data)}
+			}
 			if img, ok := claudeImageFromBlock(content); ok {
 				return ClaudeToolResult{Images: []ClaudeToolResultImage{img}}
 			}
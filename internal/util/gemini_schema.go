@@ -6,7 +6,9 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
+	log "github.com/sirupsen/logrus"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -28,6 +30,73 @@ func CleanJSONSchemaForGemini(jsonStr string) string {
 	return cleanJSONSchema(jsonStr, false)
 }
 
+// geminiLossySchemaKeywords are keywords Gemini rejects that the cleaner can only approximate:
+// references become description hints, unions collapse to their richest branch, const becomes a
+// single-value enum and pattern-based property rules are dropped.
+var geminiLossySchemaKeywords = []string{"$ref", "allOf", "anyOf", "oneOf", "const", "patternProperties", "propertyNames"}
+
+// maxGeminiSchemaWarnings bounds the set of reported tool schemas; the oldest entry is
+// forgotten when it is full.
+const maxGeminiSchemaWarnings = 1024
+
+var geminiSchemaWarnings = struct {
+	sync.Mutex
+	seen map[string]uint64 // key -> order in which it was first reported
+	next uint64
+}{}
+
+// GeminiSchemaRewrites returns the sorted keywords of a JSON schema that CleanJSONSchemaForGemini
+// rewrites in ways that may change what the schema accepts.
+func GeminiSchemaRewrites(jsonStr string) []string {
+	var found []string
+	pathsByField := findPathsByFields(jsonStr, geminiLossySchemaKeywords)
+	for _, key := range geminiLossySchemaKeywords {
+		for _, p := range pathsByField[key] {
+			if !isPropertyDefinition(trimSuffix(p, "."+key)) {
+				found = append(found, key)
+				break
+			}
+		}
+	}
+	sort.Strings(found)
+	return found
+}
+
+// WarnGeminiSchemaRewrites logs which keywords of a tool's input schema are rewritten for Gemini,
+// so a tool that misbehaves can be traced to its schema instead of an opaque upstream 400.
+// Clients resend the same tools every turn, so each tool and keyword set is reported once.
+func WarnGeminiSchemaRewrites(toolName, jsonStr string) {
+	rewrites := GeminiSchemaRewrites(jsonStr)
+	if len(rewrites) == 0 || !firstGeminiSchemaWarning(toolName+"\x00"+strings.Join(rewrites, ",")) {
+		return
+	}
+	log.Warnf("gemini schema: tool %q uses unsupported keywords %s; rewritten to a compatible approximation", toolName, strings.Join(rewrites, ", "))
+}
+
+func firstGeminiSchemaWarning(key string) bool {
+	geminiSchemaWarnings.Lock()
+	defer geminiSchemaWarnings.Unlock()
+	if _, ok := geminiSchemaWarnings.seen[key]; ok {
+		return false
+	}
+	if geminiSchemaWarnings.seen == nil {
+		geminiSchemaWarnings.seen = make(map[string]uint64)
+	}
+	for len(geminiSchemaWarnings.seen) >= maxGeminiSchemaWarnings {
+		oldestKey := ""
+		var oldest uint64
+		for seenKey, order := range geminiSchemaWarnings.seen {
+			if oldestKey == "" || order < oldest {
+				oldestKey, oldest = seenKey, order
+			}
+		}
+		delete(geminiSchemaWarnings.seen, oldestKey)
+	}
+	geminiSchemaWarnings.next++
+	geminiSchemaWarnings.seen[key] = geminiSchemaWarnings.next
+	return true
+}
+
 // cleanJSONSchema performs the core cleaning operations on the JSON schema.
 func cleanJSONSchema(jsonStr string, addPlaceholder bool) string {
 	// Phase 1: Convert and add hints
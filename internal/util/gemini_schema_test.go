@@ -3,6 +3,7 @@ package util
 import (
 	"encoding/json"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -1154,3 +1155,47 @@ func TestCleanJSONSchemaForAntigravity_UniqueItemsStripped(t *testing.T) {
 		t.Errorf("uniqueItems hint missing in description")
 	}
 }
+
+func TestGeminiSchemaRewrites(t *testing.T) {
+	input := `{
+		"type": "object",
+		"$defs": {"Mode": {"type": "string"}},
+		"properties": {
+			"mode": {"$ref": "#/$defs/Mode"},
+			"target": {"oneOf": [{"type": "string"}, {"type": "integer"}]},
+			"kind": {"const": "file"},
+			"const": {"type": "string"}
+		}
+	}`
+	if got, want := GeminiSchemaRewrites(input), []string{"$ref", "const", "oneOf"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("GeminiSchemaRewrites() = %v, want %v", got, want)
+	}
+
+	plain := `{"type":"object","properties":{"anyOf":{"type":"string"}},"additionalProperties":false}`
+	if got := GeminiSchemaRewrites(plain); len(got) != 0 {
+		t.Fatalf("GeminiSchemaRewrites(plain) = %v, want none", got)
+	}
+}
+
+func TestFirstGeminiSchemaWarningReportsOnce(t *testing.T) {
+	key := "TestFirstGeminiSchemaWarningReportsOnce\x00$ref"
+	if !firstGeminiSchemaWarning(key) {
+		t.Fatal("first report suppressed")
+	}
+	if firstGeminiSchemaWarning(key) {
+		t.Fatal("repeated report not suppressed")
+	}
+}
+
+func TestFirstGeminiSchemaWarningForgetsOldestWhenFull(t *testing.T) {
+	prefix := "TestFirstGeminiSchemaWarningForgetsOldestWhenFull\x00"
+	for i := 0; i <= maxGeminiSchemaWarnings; i++ {
+		firstGeminiSchemaWarning(prefix + strconv.Itoa(i))
+	}
+	if firstGeminiSchemaWarning(prefix + strconv.Itoa(maxGeminiSchemaWarnings)) {
+		t.Fatal("newest report repeated after the set filled up")
+	}
+	if !firstGeminiSchemaWarning(prefix + "0") {
+		t.Fatal("oldest report was not forgotten")
+	}
+}
@@ -1,6 +1,7 @@
 package util
 
 import (
+	"strings"
 	"testing"
 )
 
@@ -106,9 +107,51 @@ func TestSanitizedToolNameMap(t *testing.T) {
 		if m["read_file"] != "read/file" {
 			t.Errorf("expected first mapping read/file, got %q", m["read_file"])
 		}
+		if m["read_file_1"] != "read@file" {
+			t.Errorf("expected suffixed mapping read@file, got %q", m["read_file_1"])
+		}
+	})
+
+	t.Run("reads OpenAI chat function names", func(t *testing.T) {
+		raw := []byte(`{"tools":[{"type":"function","function":{"name":"mcp/server/read"}}]}`)
+		if m := SanitizedToolNameMap(raw); m["mcp_server_read"] != "mcp/server/read" {
+			t.Errorf("expected mcp_server_read → mcp/server/read, got %v", m)
+		}
 	})
 }
 
+func TestSanitizedToolNames(t *testing.T) {
+	raw := []byte(`{"tools":[
+		{"name":"read/file"},
+		{"name":"read_file"},
+		{"name":"read@file"}
+	]}`)
+	names := SanitizedToolNames(raw)
+	if _, renamed := names["read_file"]; renamed {
+		t.Errorf("valid name read_file was renamed to %q", names["read_file"])
+	}
+	if got := SanitizeToolName(names, "read/file"); got != "read_file_1" {
+		t.Errorf("SanitizeToolName(read/file) = %q, want read_file_1", got)
+	}
+	if got := SanitizeToolName(names, "read@file"); got != "read_file_2" {
+		t.Errorf("SanitizeToolName(read@file) = %q, want read_file_2", got)
+	}
+	if got := SanitizeToolName(names, "undeclared/tool"); got != "undeclared_tool" {
+		t.Errorf("SanitizeToolName(undeclared/tool) = %q, want undeclared_tool", got)
+	}
+
+	long := strings.Repeat("a", 70)
+	names = SanitizedToolNames([]byte(`{"tools":[{"name":"` + long + `/x"},{"name":"` + long + `/y"}]}`))
+	first, second := names[long+"/x"], names[long+"/y"]
+	if first == second || len(first) > 64 || len(second) > 64 {
+		t.Errorf("truncated names = %q, %q, want distinct names of at most 64 chars", first, second)
+	}
+	restore := SanitizedToolNameMap([]byte(`{"tools":[{"name":"` + long + `/x"},{"name":"` + long + `/y"}]}`))
+	if restore[second] != long+"/y" {
+		t.Errorf("restore[%q] = %q, want the second tool", second, restore[second])
+	}
+}
+
 func TestRestoreSanitizedToolName(t *testing.T) {
 	m := map[string]string{
 		"mcp_server_read": "mcp/server/read",
@@ -0,0 +1,104 @@
+package util
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// MaxToolResultImageBytes caps the decoded size of a single image carried in a tool result.
+// Larger images are replaced by a short text note instead of being forwarded upstream, where
+// they would be rejected or silently truncate the request.
+const MaxToolResultImageBytes = 5 << 20
+
+// ToolResultImageTooLarge reports whether base64 image data decodes to more than
+// MaxToolResultImageBytes.
+func ToolResultImageTooLarge(data string) bool {
+	return base64.StdEncoding.DecodedLen(len(data)) > MaxToolResultImageBytes
+}
+
+// OmittedToolResultImageText is the text that stands in for an image dropped by the size limit.
+func OmittedToolResultImageText(data string) string {
+	return fmt.Sprintf("[image omitted from tool result: %d bytes exceeds the %d byte limit]",
+		base64.StdEncoding.DecodedLen(len(data)), MaxToolResultImageBytes)
+}
+
+// LimitClaudeToolResultImage returns block unchanged unless it is a Claude base64 image block
+// over MaxToolResultImageBytes, in which case it returns a text block noting the omission.
+func LimitClaudeToolResultImage(block []byte) []byte {
+	parsed := gjson.ParseBytes(block)
+	if !isClaudeBase64Image(parsed) || !ToolResultImageTooLarge(parsed.Get("source.data").String()) {
+		return block
+	}
+	note, _ := sjson.SetBytes([]byte(`{"type":"text","text":""}`), "text", OmittedToolResultImageText(parsed.Get("source.data").String()))
+	return note
+}
+
+// ExtractOpenAIToolResultImages separates base64 data URL images out of an OpenAI tool message
+// `content` so they can be sent as provider-native inline data parts. It returns the remaining
+// content for the textual function response and the extracted images.
+//
+// Content without data URL images is returned unchanged as content.Raw. Otherwise the remaining
+// parts are joined into a plain string when they are all text, or kept as a raw JSON array.
+// Images over MaxToolResultImageBytes are replaced by a text note.
+func ExtractOpenAIToolResultImages(content gjson.Result) (string, []ClaudeToolResultImage) {
+	if !content.IsArray() {
+		return content.Raw, nil
+	}
+	var images []ClaudeToolResultImage
+	var texts []string
+	filtered := []byte(`[]`)
+	hasImage, allText := false, true
+	content.ForEach(func(_, part gjson.Result) bool {
+		if part.Get("type").String() == "image_url" {
+			if mimeType, data, ok := parseBase64DataURL(part.Get("image_url.url").String()); ok {
+				hasImage = true
+				if !ToolResultImageTooLarge(data) {
+					images = append(images, ClaudeToolResultImage{MimeType: mimeType, Data: data})
+					return true
+				}
+				note := OmittedToolResultImageText(data)
+				textPart, _ := sjson.SetBytes([]byte(`{"type":"text","text":""}`), "text", note)
+				filtered, _ = sjson.SetRawBytes(filtered, "-1", textPart)
+				texts = append(texts, note)
+				return true
+			}
+		}
+		switch {
+		case part.Type == gjson.String:
+			texts = append(texts, part.String())
+		case part.Get("type").String() == "text":
+			texts = append(texts, part.Get("text").String())
+		default:
+			allText = false
+		}
+		filtered, _ = sjson.SetRawBytes(filtered, "-1", []byte(part.Raw))
+		return true
+	})
+	if !hasImage {
+		return content.Raw, nil
+	}
+	if allText {
+		return strings.Join(texts, "\n"), images
+	}
+	return string(filtered), images
+}
+
+// parseBase64DataURL splits a `data:<mime>;base64,<data>` URL into its media type and data.
+func parseBase64DataURL(url string) (string, string, bool) {
+	trimmed, ok := strings.CutPrefix(url, "data:")
+	if !ok {
+		return "", "", false
+	}
+	mimeType, data, ok := strings.Cut(trimmed, ";base64,")
+	if !ok || data == "" {
+		return "", "", false
+	}
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+	return mimeType, data, true
+}
@@ -0,0 +1,86 @@
+package util
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestExtractOpenAIToolResultImages(t *testing.T) {
+	oversized := strings.Repeat("A", MaxToolResultImageBytes/3*4+8)
+	tests := []struct {
+		name       string
+		wrapper    string
+		wantResult string
+		wantImages int
+	}{
+		{
+			name:       "StringContentUnchanged",
+			wrapper:    `{"content":"alpha"}`,
+			wantResult: `"alpha"`,
+		},
+		{
+			name:       "ArrayWithoutImagesUnchanged",
+			wrapper:    `{"content":[{"type":"text","text":"alpha"}]}`,
+			wantResult: `[{"type":"text","text":"alpha"}]`,
+		},
+		{
+			name:       "URLImageUnchanged",
+			wrapper:    `{"content":[{"type":"image_url","image_url":{"url":"https://example.com/a.png"}}]}`,
+			wantResult: `[{"type":"image_url","image_url":{"url":"https://example.com/a.png"}}]`,
+		},
+		{
+			name:       "TextAndImage",
+			wrapper:    `{"content":[{"type":"text","text":"alpha"},{"type":"image_url","image_url":{"url":"data:image/png;base64,aGVsbG8="}}]}`,
+			wantResult: "alpha",
+			wantImages: 1,
+		},
+		{
+			name:       "ImageOnly",
+			wrapper:    `{"content":[{"type":"image_url","image_url":{"url":"data:image/jpeg;base64,aGVsbG8="}}]}`,
+			wantResult: "",
+			wantImages: 1,
+		},
+		{
+			name:       "ImageWithStructuredPart",
+			wrapper:    `{"content":[{"type":"file","file":{"file_id":"f1"}},{"type":"image_url","image_url":{"url":"data:image/png;base64,aGVsbG8="}}]}`,
+			wantResult: `[{"type":"file","file":{"file_id":"f1"}}]`,
+			wantImages: 1,
+		},
+		{
+			name:       "OversizedImageReplaced",
+			wrapper:    `{"content":[{"type":"image_url","image_url":{"url":"data:image/png;base64,` + oversized + `"}}]}`,
+			wantResult: OmittedToolResultImageText(oversized),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, images := ExtractOpenAIToolResultImages(gjson.Get(tt.wrapper, "content"))
+			if result != tt.wantResult {
+				t.Fatalf("result = %q, want %q", result, tt.wantResult)
+			}
+			if len(images) != tt.wantImages {
+				t.Fatalf("images = %d, want %d", len(images), tt.wantImages)
+			}
+		})
+	}
+}
+
+func TestConvertClaudeToolResultContentReplacesOversizedImage(t *testing.T) {
+	oversized := strings.Repeat("A", MaxToolResultImageBytes/3*4+8)
+	content := `[{"type":"image","source":{"type":"base64","media_type":"image/png","data":"` + oversized + `"}}]`
+
+	got := ConvertClaudeToolResultContent(gjson.Parse(content))
+	if len(got.Images) != 0 || !got.ResultIsRaw {
+		t.Fatalf("got %d images, raw = %v; want the image replaced by a text block", len(got.Images), got.ResultIsRaw)
+	}
+	if text := gjson.Get(got.Result, "text").String(); !strings.Contains(text, "image omitted") {
+		t.Fatalf("result = %s, want an omission note", got.Result)
+	}
+
+	small := []byte(`{"type":"image","source":{"type":"base64","media_type":"image/png","data":"aGVsbG8="}}`)
+	if limited := LimitClaudeToolResultImage(small); string(limited) != string(small) {
+		t.Fatalf("LimitClaudeToolResultImage changed a small image: %s", limited)
+	}
+}
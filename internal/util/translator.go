@@ -6,9 +6,9 @@ package util
 import (
 	"bytes"
 	"fmt"
+	"strconv"
 	"strings"
 
-	log "github.com/sirupsen/logrus"
 	"github.com/tidwall/gjson"
 	"github.com/tidwall/sjson"
 )
@@ -287,42 +287,97 @@ func MapToolName(toolNameMap map[string]string, name string) string {
 	return name
 }
 
-// SanitizedToolNameMap builds a sanitized-name → original-name map from Claude request tools.
+// SanitizedToolNames maps each tool name declared in a Claude, OpenAI Chat or OpenAI Responses
+// request to the name sent upstream after SanitizeFunctionName. A rewritten name that would clash
+// with another tool gets a numeric suffix, so every tool stays distinct and its calls can be
+// mapped back with SanitizedToolNameMap. Only renamed tools are included.
+func SanitizedToolNames(rawJSON []byte) map[string]string {
+	names := declaredToolNames(rawJSON)
+	if len(names) == 0 {
+		return nil
+	}
+
+	// Names that need no rewriting keep their own name and are never displaced by a rewritten one.
+	used := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		if SanitizeFunctionName(name) == name {
+			used[name] = struct{}{}
+		}
+	}
+
+	var out map[string]string
+	for _, name := range names {
+		sanitized := SanitizeFunctionName(name)
+		if sanitized == name {
+			continue
+		}
+		if _, done := out[name]; done {
+			continue
+		}
+		unique := sanitized
+		for i := 1; ; i++ {
+			if _, taken := used[unique]; !taken {
+				break
+			}
+			suffix := "_" + strconv.Itoa(i)
+			unique = sanitized[:min(len(sanitized), 64-len(suffix))] + suffix
+		}
+		used[unique] = struct{}{}
+		if out == nil {
+			out = make(map[string]string)
+		}
+		out[name] = unique
+	}
+	return out
+}
+
+// SanitizeToolName returns the upstream name for a tool using a SanitizedToolNames map, falling
+// back to SanitizeFunctionName for names the request does not declare.
+func SanitizeToolName(toolNames map[string]string, name string) string {
+	if sanitized, ok := toolNames[name]; ok {
+		return sanitized
+	}
+	return SanitizeFunctionName(name)
+}
+
+// SanitizedToolNameMap builds a sanitized-name → original-name map from request tools.
 // It is used to restore exact tool names for clients (e.g. Claude Code) after the proxy
-// sanitizes tool names for Gemini/Vertex API compatibility via SanitizeFunctionName.
+// sanitizes tool names for Gemini/Vertex API compatibility via SanitizedToolNames.
 // Only entries where sanitization actually changes the name are included.
 func SanitizedToolNameMap(rawJSON []byte) map[string]string {
-	if len(rawJSON) == 0 || !gjson.ValidBytes(rawJSON) {
+	toolNames := SanitizedToolNames(rawJSON)
+	if len(toolNames) == 0 {
 		return nil
 	}
+	out := make(map[string]string, len(toolNames))
+	for original, sanitized := range toolNames {
+		out[sanitized] = original
+	}
+	return out
+}
 
+// declaredToolNames returns the function tool names of a request in declaration order,
+// reading both the Claude/Responses "name" and the OpenAI Chat "function.name" shapes.
+func declaredToolNames(rawJSON []byte) []string {
+	if len(rawJSON) == 0 || !gjson.ValidBytes(rawJSON) {
+		return nil
+	}
 	tools := gjson.GetBytes(rawJSON, "tools")
-	if !tools.Exists() || !tools.IsArray() {
+	if !tools.IsArray() {
 		return nil
 	}
-
-	out := make(map[string]string)
+	var names []string
 	tools.ForEach(func(_, tool gjson.Result) bool {
-		name := strings.TrimSpace(tool.Get("name").String())
+		name := tool.Get("name").String()
 		if name == "" {
-			return true
-		}
-		sanitized := SanitizeFunctionName(name)
-		if sanitized == name {
-			return true
+			name = tool.Get("function.name").String()
 		}
-		if _, exists := out[sanitized]; !exists {
-			out[sanitized] = name
-		} else {
-			log.Warnf("sanitized tool name collision: %q and %q both map to %q, keeping first", out[sanitized], name, sanitized)
+		if name != "" {
+			names = append(names, name)
 		}
 		return true
 	})
-
-	if len(out) == 0 {
-		return nil
-	}
-	return out
+	return names
 }
 
 // RestoreSanitizedToolName looks up a sanitized function name in the provided map
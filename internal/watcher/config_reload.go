@@ -6,6 +6,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"os"
+	"path/filepath"
 	"reflect"
 	"time"
 
@@ -58,8 +59,7 @@ func (w *Watcher) reloadConfigIfChanged() {
 		log.Debugf("ignoring empty config file write event")
 		return
 	}
-	sum := sha256.Sum256(data)
-	newHash := hex.EncodeToString(sum[:])
+	newHash := w.configSourcesHash(data)
 
 	w.clientsMutex.RLock()
 	currentHash := w.lastConfigHash
@@ -73,8 +73,7 @@ func (w *Watcher) reloadConfigIfChanged() {
 	if w.reloadConfig() {
 		finalHash := newHash
 		if updatedData, errRead := os.ReadFile(w.configPath); errRead == nil && len(updatedData) > 0 {
-			sumUpdated := sha256.Sum256(updatedData)
-			finalHash = hex.EncodeToString(sumUpdated[:])
+			finalHash = w.configSourcesHash(updatedData)
 		} else if errRead != nil {
 			log.WithError(errRead).Debug("failed to compute updated config hash after reload")
 		}
@@ -85,6 +84,26 @@ func (w *Watcher) reloadConfigIfChanged() {
 	}
 }
 
+// configSourcesHash fingerprints the main config together with its config.d overlays,
+// so an overlay edit is detected even when the main file is unchanged.
+func (w *Watcher) configSourcesHash(mainData []byte) string {
+	hasher := sha256.New()
+	hasher.Write(mainData)
+	files, errList := config.OverlayFiles(w.configPath)
+	if errList != nil {
+		log.WithError(errList).Debug("failed to list config overlays for hash check")
+	}
+	for _, path := range files {
+		data, errRead := os.ReadFile(path)
+		if errRead != nil {
+			continue
+		}
+		hasher.Write([]byte("\x00" + filepath.Base(path) + "\x00"))
+		hasher.Write(data)
+	}
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
 func (w *Watcher) reloadConfig() bool {
 	log.Debug("=========================== CONFIG RELOAD ============================")
 	log.Debugf("starting config reload from: %s", w.configPath)
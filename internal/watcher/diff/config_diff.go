@@ -24,6 +24,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.AuthDir != newCfg.AuthDir {
 		changes = append(changes, fmt.Sprintf("auth-dir: %s -> %s", oldCfg.AuthDir, newCfg.AuthDir))
 	}
+	if oldCfg.TokenStore.Type != newCfg.TokenStore.Type {
+		changes = append(changes, fmt.Sprintf("token-store.type: %s -> %s (restart required)", oldCfg.TokenStore.Type, newCfg.TokenStore.Type))
+	}
 	if oldCfg.Debug != newCfg.Debug {
 		changes = append(changes, fmt.Sprintf("debug: %t -> %t", oldCfg.Debug, newCfg.Debug))
 	}
@@ -33,6 +36,93 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if strings.TrimSpace(oldCfg.Pprof.Addr) != strings.TrimSpace(newCfg.Pprof.Addr) {
 		changes = append(changes, fmt.Sprintf("pprof.addr: %s -> %s", strings.TrimSpace(oldCfg.Pprof.Addr), strings.TrimSpace(newCfg.Pprof.Addr)))
 	}
+	if oldCfg.SelfUpdate.Enabled != newCfg.SelfUpdate.Enabled {
+		changes = append(changes, fmt.Sprintf("self-update.enabled: %t -> %t", oldCfg.SelfUpdate.Enabled, newCfg.SelfUpdate.Enabled))
+	}
+	if strings.TrimSpace(oldCfg.SelfUpdate.Channel) != strings.TrimSpace(newCfg.SelfUpdate.Channel) {
+		changes = append(changes, fmt.Sprintf("self-update.channel: %s -> %s", strings.TrimSpace(oldCfg.SelfUpdate.Channel), strings.TrimSpace(newCfg.SelfUpdate.Channel)))
+	}
+	if strings.TrimSpace(oldCfg.SelfUpdate.CheckInterval) != strings.TrimSpace(newCfg.SelfUpdate.CheckInterval) {
+		changes = append(changes, fmt.Sprintf("self-update.check-interval: %s -> %s", strings.TrimSpace(oldCfg.SelfUpdate.CheckInterval), strings.TrimSpace(newCfg.SelfUpdate.CheckInterval)))
+	}
+	if !reflect.DeepEqual(oldCfg.InboundRateLimit, newCfg.InboundRateLimit) {
+		changes = append(changes, fmt.Sprintf("inbound-rate-limit: enabled %t -> %t, backend %s -> %s", oldCfg.InboundRateLimit.Enabled, newCfg.InboundRateLimit.Enabled, oldCfg.InboundRateLimit.Backend, newCfg.InboundRateLimit.Backend))
+	}
+	if !reflect.DeepEqual(oldCfg.SharedCache, newCfg.SharedCache) {
+		changes = append(changes, fmt.Sprintf("shared-cache.backend: %s -> %s", oldCfg.SharedCache.Backend, newCfg.SharedCache.Backend))
+	}
+	if oldCfg.MemoryGuard != newCfg.MemoryGuard {
+		changes = append(changes, fmt.Sprintf("memory-guard: enabled %t -> %t, high-watermark-mb %d -> %d, max-inflight-mb %d -> %d", oldCfg.MemoryGuard.Enabled, newCfg.MemoryGuard.Enabled, oldCfg.MemoryGuard.HighWatermarkMB, newCfg.MemoryGuard.HighWatermarkMB, oldCfg.MemoryGuard.MaxInflightMB, newCfg.MemoryGuard.MaxInflightMB))
+	}
+	if oldCfg.StreamWatchdog != newCfg.StreamWatchdog {
+		changes = append(changes, fmt.Sprintf("stream-watchdog: max-age-minutes %d -> %d, max-count %d -> %d", oldCfg.StreamWatchdog.MaxAgeMinutes, newCfg.StreamWatchdog.MaxAgeMinutes, oldCfg.StreamWatchdog.MaxCount, newCfg.StreamWatchdog.MaxCount))
+	}
+	if oldCfg.ResponseSigning != newCfg.ResponseSigning {
+		changes = append(changes, fmt.Sprintf("response-signing: enable %t -> %t, private-key-file %q -> %q, key-id %q -> %q", oldCfg.ResponseSigning.Enable, newCfg.ResponseSigning.Enable, oldCfg.ResponseSigning.PrivateKeyFile, newCfg.ResponseSigning.PrivateKeyFile, oldCfg.ResponseSigning.KeyID, newCfg.ResponseSigning.KeyID))
+	}
+	if oldCfg.AuditLog != newCfg.AuditLog {
+		changes = append(changes, fmt.Sprintf("audit-log: enable %t -> %t, output %q -> %q, file %q -> %q", oldCfg.AuditLog.Enable, newCfg.AuditLog.Enable, oldCfg.AuditLog.Output, newCfg.AuditLog.Output, oldCfg.AuditLog.File, newCfg.AuditLog.File))
+	}
+	if !reflect.DeepEqual(oldCfg.SessionTranscripts, newCfg.SessionTranscripts) {
+		changes = append(changes, fmt.Sprintf("session-transcripts.enabled: %t -> %t", oldCfg.SessionTranscripts.Enabled, newCfg.SessionTranscripts.Enabled))
+	}
+	if oldCfg.RequestRetention.RedactAfterHours != newCfg.RequestRetention.RedactAfterHours {
+		changes = append(changes, fmt.Sprintf("request-retention.redact-after-hours: %d -> %d", oldCfg.RequestRetention.RedactAfterHours, newCfg.RequestRetention.RedactAfterHours))
+	}
+	if oldCfg.Warmup.Enabled != newCfg.Warmup.Enabled {
+		changes = append(changes, fmt.Sprintf("warmup.enabled: %t -> %t", oldCfg.Warmup.Enabled, newCfg.Warmup.Enabled))
+	}
+	if strings.TrimSpace(oldCfg.Warmup.Interval) != strings.TrimSpace(newCfg.Warmup.Interval) {
+		changes = append(changes, fmt.Sprintf("warmup.interval: %s -> %s", strings.TrimSpace(oldCfg.Warmup.Interval), strings.TrimSpace(newCfg.Warmup.Interval)))
+	}
+	if !reflect.DeepEqual(trimStrings(oldCfg.Warmup.Models), trimStrings(newCfg.Warmup.Models)) {
+		changes = append(changes, fmt.Sprintf("warmup.models: %v -> %v", trimStrings(oldCfg.Warmup.Models), trimStrings(newCfg.Warmup.Models)))
+	}
+	if !reflect.DeepEqual(oldCfg.ScheduledJobs, newCfg.ScheduledJobs) {
+		changes = append(changes, fmt.Sprintf("scheduled-jobs: updated (%d -> %d jobs)", len(oldCfg.ScheduledJobs), len(newCfg.ScheduledJobs)))
+	}
+	if !reflect.DeepEqual(oldCfg.ProviderStatus, newCfg.ProviderStatus) {
+		changes = append(changes, fmt.Sprintf("provider-status: enabled %t -> %t", oldCfg.ProviderStatus.Enabled, newCfg.ProviderStatus.Enabled))
+	}
+	if oldCfg.Metrics.Enable != newCfg.Metrics.Enable {
+		changes = append(changes, fmt.Sprintf("metrics.enable: %t -> %t", oldCfg.Metrics.Enable, newCfg.Metrics.Enable))
+	}
+	if oldCfg.Metrics.BearerToken != newCfg.Metrics.BearerToken {
+		changes = append(changes, "metrics.bearer-token: updated")
+	}
+	if !reflect.DeepEqual(oldCfg.FinetuneCapture, newCfg.FinetuneCapture) {
+		changes = append(changes, fmt.Sprintf("finetune-capture: enabled %t -> %t", oldCfg.FinetuneCapture.Enabled, newCfg.FinetuneCapture.Enabled))
+	}
+	if oldCfg.Telemetry != newCfg.Telemetry {
+		changes = append(changes, fmt.Sprintf("telemetry: enabled %t -> %t", oldCfg.Telemetry.Enabled, newCfg.Telemetry.Enabled))
+	}
+	if oldCfg.BaseURLPinning != newCfg.BaseURLPinning {
+		changes = append(changes, fmt.Sprintf("base-url-pinning: probe-interval-seconds %d -> %d", oldCfg.BaseURLPinning.ProbeIntervalSeconds, newCfg.BaseURLPinning.ProbeIntervalSeconds))
+	}
+	if oldCfg.UsageReport != newCfg.UsageReport {
+		changes = append(changes, fmt.Sprintf("usage-report: enabled %t -> %t", oldCfg.UsageReport.Enabled, newCfg.UsageReport.Enabled))
+	}
+	if !reflect.DeepEqual(oldCfg.UsageSinks, newCfg.UsageSinks) {
+		changes = append(changes, fmt.Sprintf("usage-sinks: updated (%d -> %d sinks)", len(oldCfg.UsageSinks), len(newCfg.UsageSinks)))
+	}
+	if oldCfg.KeepAliveShaping.Enabled != newCfg.KeepAliveShaping.Enabled {
+		changes = append(changes, fmt.Sprintf("keep-alive-shaping.enabled: %t -> %t", oldCfg.KeepAliveShaping.Enabled, newCfg.KeepAliveShaping.Enabled))
+	}
+	if !reflect.DeepEqual(trimStrings(oldCfg.KeepAliveShaping.Providers), trimStrings(newCfg.KeepAliveShaping.Providers)) {
+		changes = append(changes, fmt.Sprintf("keep-alive-shaping.providers: %v -> %v", trimStrings(oldCfg.KeepAliveShaping.Providers), trimStrings(newCfg.KeepAliveShaping.Providers)))
+	}
+	if strings.TrimSpace(oldCfg.KeepAliveShaping.MinGap) != strings.TrimSpace(newCfg.KeepAliveShaping.MinGap) {
+		changes = append(changes, fmt.Sprintf("keep-alive-shaping.min-gap: %s -> %s", strings.TrimSpace(oldCfg.KeepAliveShaping.MinGap), strings.TrimSpace(newCfg.KeepAliveShaping.MinGap)))
+	}
+	if strings.TrimSpace(oldCfg.KeepAliveShaping.MaxGap) != strings.TrimSpace(newCfg.KeepAliveShaping.MaxGap) {
+		changes = append(changes, fmt.Sprintf("keep-alive-shaping.max-gap: %s -> %s", strings.TrimSpace(oldCfg.KeepAliveShaping.MaxGap), strings.TrimSpace(newCfg.KeepAliveShaping.MaxGap)))
+	}
+	if oldCfg.KeepAliveShaping.DailyCap != newCfg.KeepAliveShaping.DailyCap {
+		changes = append(changes, fmt.Sprintf("keep-alive-shaping.daily-cap: %d -> %d", oldCfg.KeepAliveShaping.DailyCap, newCfg.KeepAliveShaping.DailyCap))
+	}
+	if strings.TrimSpace(oldCfg.KeepAliveShaping.ActiveHours) != strings.TrimSpace(newCfg.KeepAliveShaping.ActiveHours) {
+		changes = append(changes, fmt.Sprintf("keep-alive-shaping.active-hours: %s -> %s", strings.TrimSpace(oldCfg.KeepAliveShaping.ActiveHours), strings.TrimSpace(newCfg.KeepAliveShaping.ActiveHours)))
+	}
 	if oldCfg.LoggingToFile != newCfg.LoggingToFile {
 		changes = append(changes, fmt.Sprintf("logging-to-file: %t -> %t", oldCfg.LoggingToFile, newCfg.LoggingToFile))
 	}
@@ -45,6 +135,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.DisableCooling != newCfg.DisableCooling {
 		changes = append(changes, fmt.Sprintf("disable-cooling: %t -> %t", oldCfg.DisableCooling, newCfg.DisableCooling))
 	}
+	if !reflect.DeepEqual(trimStrings(oldCfg.ReadOnlyProviders), trimStrings(newCfg.ReadOnlyProviders)) {
+		changes = append(changes, fmt.Sprintf("read-only-providers: %v -> %v", trimStrings(oldCfg.ReadOnlyProviders), trimStrings(newCfg.ReadOnlyProviders)))
+	}
 	if oldCfg.SaveCooldownStatus != newCfg.SaveCooldownStatus {
 		changes = append(changes, fmt.Sprintf("save-cooldown-status: %t -> %t", oldCfg.SaveCooldownStatus, newCfg.SaveCooldownStatus))
 	}
@@ -90,6 +183,103 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.NonStreamKeepAliveInterval != newCfg.NonStreamKeepAliveInterval {
 		changes = append(changes, fmt.Sprintf("nonstream-keepalive-interval: %d -> %d", oldCfg.NonStreamKeepAliveInterval, newCfg.NonStreamKeepAliveInterval))
 	}
+	if !reflect.DeepEqual(oldCfg.ResponseHeaders, newCfg.ResponseHeaders) {
+		changes = append(changes, fmt.Sprintf("response-headers: updated (%d -> %d provider rules)", len(oldCfg.ResponseHeaders.Providers), len(newCfg.ResponseHeaders.Providers)))
+	}
+	if !reflect.DeepEqual(oldCfg.ModelPricing, newCfg.ModelPricing) {
+		changes = append(changes, fmt.Sprintf("model-pricing: %d -> %d entries", len(oldCfg.ModelPricing), len(newCfg.ModelPricing)))
+	}
+	if oldCfg.RequestDedup.Enabled != newCfg.RequestDedup.Enabled {
+		changes = append(changes, fmt.Sprintf("request-dedup.enabled: %t -> %t", oldCfg.RequestDedup.Enabled, newCfg.RequestDedup.Enabled))
+	}
+	if oldCfg.RequestDedup.WindowSeconds != newCfg.RequestDedup.WindowSeconds {
+		changes = append(changes, fmt.Sprintf("request-dedup.window-seconds: %d -> %d", oldCfg.RequestDedup.WindowSeconds, newCfg.RequestDedup.WindowSeconds))
+	}
+	if oldCfg.StreamReplay != newCfg.StreamReplay {
+		changes = append(changes, fmt.Sprintf("stream-replay: %+v -> %+v", oldCfg.StreamReplay, newCfg.StreamReplay))
+	}
+	if oldCfg.ActiveProfile != newCfg.ActiveProfile {
+		changes = append(changes, fmt.Sprintf("active-profile: %s -> %s", oldCfg.ActiveProfile, newCfg.ActiveProfile))
+	}
+	if len(oldCfg.Profiles) != len(newCfg.Profiles) {
+		changes = append(changes, fmt.Sprintf("profiles count: %d -> %d", len(oldCfg.Profiles), len(newCfg.Profiles)))
+	} else if !reflect.DeepEqual(oldCfg.Profiles, newCfg.Profiles) {
+		changes = append(changes, "profiles: updated")
+	}
+	if len(oldCfg.VirtualModels) != len(newCfg.VirtualModels) {
+		changes = append(changes, fmt.Sprintf("virtual-models count: %d -> %d", len(oldCfg.VirtualModels), len(newCfg.VirtualModels)))
+	} else if !reflect.DeepEqual(oldCfg.VirtualModels, newCfg.VirtualModels) {
+		changes = append(changes, "virtual-models: updated")
+	}
+	if oldCfg.StrictParameters != newCfg.StrictParameters {
+		changes = append(changes, fmt.Sprintf("strict-parameters: %t -> %t", oldCfg.StrictParameters, newCfg.StrictParameters))
+	}
+	if oldCfg.JSONMode.Validate != newCfg.JSONMode.Validate {
+		changes = append(changes, fmt.Sprintf("json-mode.validate: %t -> %t", oldCfg.JSONMode.Validate, newCfg.JSONMode.Validate))
+	}
+	if oldCfg.JSONMode.Repair != newCfg.JSONMode.Repair {
+		changes = append(changes, fmt.Sprintf("json-mode.repair: %t -> %t", oldCfg.JSONMode.Repair, newCfg.JSONMode.Repair))
+	}
+	if oldCfg.AutoContinue.MaxContinuations != newCfg.AutoContinue.MaxContinuations {
+		changes = append(changes, fmt.Sprintf("auto-continue.max-continuations: %d -> %d", oldCfg.AutoContinue.MaxContinuations, newCfg.AutoContinue.MaxContinuations))
+	}
+	if len(oldCfg.APIKeyModels) != len(newCfg.APIKeyModels) {
+		changes = append(changes, fmt.Sprintf("api-key-models count: %d -> %d", len(oldCfg.APIKeyModels), len(newCfg.APIKeyModels)))
+	} else if !reflect.DeepEqual(oldCfg.APIKeyModels, newCfg.APIKeyModels) {
+		changes = append(changes, "api-key-models: updated")
+	}
+	if len(oldCfg.APIKeyPriorities) != len(newCfg.APIKeyPriorities) {
+		changes = append(changes, fmt.Sprintf("api-key-priorities count: %d -> %d", len(oldCfg.APIKeyPriorities), len(newCfg.APIKeyPriorities)))
+	} else if !reflect.DeepEqual(oldCfg.APIKeyPriorities, newCfg.APIKeyPriorities) {
+		changes = append(changes, "api-key-priorities: updated")
+	}
+	if len(oldCfg.APIKeyResidency) != len(newCfg.APIKeyResidency) {
+		changes = append(changes, fmt.Sprintf("api-key-residency count: %d -> %d", len(oldCfg.APIKeyResidency), len(newCfg.APIKeyResidency)))
+	} else if !reflect.DeepEqual(oldCfg.APIKeyResidency, newCfg.APIKeyResidency) {
+		changes = append(changes, "api-key-residency: updated")
+	}
+	if len(oldCfg.APIKeyMetadata) != len(newCfg.APIKeyMetadata) {
+		changes = append(changes, fmt.Sprintf("api-key-metadata count: %d -> %d", len(oldCfg.APIKeyMetadata), len(newCfg.APIKeyMetadata)))
+	} else if !reflect.DeepEqual(oldCfg.APIKeyMetadata, newCfg.APIKeyMetadata) {
+		changes = append(changes, "api-key-metadata: updated")
+	}
+	if oldCfg.Admission != newCfg.Admission {
+		changes = append(changes, fmt.Sprintf("admission: %+v -> %+v", oldCfg.Admission, newCfg.Admission))
+	}
+	if len(oldCfg.OutputProcessors) != len(newCfg.OutputProcessors) {
+		changes = append(changes, fmt.Sprintf("output-processors count: %d -> %d", len(oldCfg.OutputProcessors), len(newCfg.OutputProcessors)))
+	} else if !reflect.DeepEqual(oldCfg.OutputProcessors, newCfg.OutputProcessors) {
+		changes = append(changes, "output-processors: updated")
+	}
+	if !reflect.DeepEqual(oldCfg.Ensemble.Models, newCfg.Ensemble.Models) {
+		changes = append(changes, fmt.Sprintf("ensemble.models: %v -> %v", oldCfg.Ensemble.Models, newCfg.Ensemble.Models))
+	}
+	if oldCfg.Ensemble.Mode != newCfg.Ensemble.Mode {
+		changes = append(changes, fmt.Sprintf("ensemble.mode: %s -> %s", oldCfg.Ensemble.Mode, newCfg.Ensemble.Mode))
+	}
+	if oldCfg.Ensemble.JudgeModel != newCfg.Ensemble.JudgeModel {
+		changes = append(changes, fmt.Sprintf("ensemble.judge-model: %s -> %s", oldCfg.Ensemble.JudgeModel, newCfg.Ensemble.JudgeModel))
+	}
+	if oldCfg.PromptTemplates.Dir != newCfg.PromptTemplates.Dir {
+		changes = append(changes, fmt.Sprintf("prompt-templates.dir: %s -> %s", oldCfg.PromptTemplates.Dir, newCfg.PromptTemplates.Dir))
+	}
+	if len(oldCfg.PromptTemplates.Templates) != len(newCfg.PromptTemplates.Templates) {
+		changes = append(changes, fmt.Sprintf("prompt-templates.templates count: %d -> %d", len(oldCfg.PromptTemplates.Templates), len(newCfg.PromptTemplates.Templates)))
+	} else if !reflect.DeepEqual(oldCfg.PromptTemplates.Templates, newCfg.PromptTemplates.Templates) {
+		changes = append(changes, "prompt-templates.templates: updated")
+	}
+	if oldCfg.LongContext != newCfg.LongContext {
+		changes = append(changes, fmt.Sprintf("long-context: %+v -> %+v", oldCfg.LongContext, newCfg.LongContext))
+	}
+	if oldCfg.ConversationCompression != newCfg.ConversationCompression {
+		changes = append(changes, fmt.Sprintf("conversation-compression: %+v -> %+v", oldCfg.ConversationCompression, newCfg.ConversationCompression))
+	}
+	if oldCfg.MessageBatches != newCfg.MessageBatches {
+		changes = append(changes, fmt.Sprintf("message-batches: %+v -> %+v", oldCfg.MessageBatches, newCfg.MessageBatches))
+	}
+	if !reflect.DeepEqual(oldCfg.Cache, newCfg.Cache) {
+		changes = append(changes, fmt.Sprintf("cache: %+v -> %+v", oldCfg.Cache, newCfg.Cache))
+	}
 
 	// Quota-exceeded behavior
 	if oldCfg.QuotaExceeded.SwitchProject != newCfg.QuotaExceeded.SwitchProject {
@@ -109,6 +299,24 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 	if oldCfg.Routing.Strategy != newCfg.Routing.Strategy {
 		changes = append(changes, fmt.Sprintf("routing.strategy: %s -> %s", oldCfg.Routing.Strategy, newCfg.Routing.Strategy))
 	}
+	if oldCfg.Routing.ProviderAffinity != newCfg.Routing.ProviderAffinity {
+		changes = append(changes, fmt.Sprintf("routing.provider-affinity: %t -> %t", oldCfg.Routing.ProviderAffinity, newCfg.Routing.ProviderAffinity))
+	}
+	if oldCfg.Routing.ConcurrencyWait != newCfg.Routing.ConcurrencyWait {
+		changes = append(changes, fmt.Sprintf("routing.concurrency-wait: %s -> %s", oldCfg.Routing.ConcurrencyWait, newCfg.Routing.ConcurrencyWait))
+	}
+	if oldCfg.Routing.StandbyThreshold != newCfg.Routing.StandbyThreshold {
+		changes = append(changes, fmt.Sprintf("routing.standby-threshold: %d -> %d", oldCfg.Routing.StandbyThreshold, newCfg.Routing.StandbyThreshold))
+	}
+	if oldCfg.Routing.PreferCheaperTiers != newCfg.Routing.PreferCheaperTiers {
+		changes = append(changes, fmt.Sprintf("routing.prefer-cheaper-tiers: %t -> %t", oldCfg.Routing.PreferCheaperTiers, newCfg.Routing.PreferCheaperTiers))
+	}
+	if !reflect.DeepEqual(oldCfg.Routing.RegionFailover, newCfg.Routing.RegionFailover) {
+		changes = append(changes, fmt.Sprintf("routing.region-failover: %d -> %d groups", len(oldCfg.Routing.RegionFailover), len(newCfg.Routing.RegionFailover)))
+	}
+	if oldCfg.Routing.RegionFailoverCooldown != newCfg.Routing.RegionFailoverCooldown {
+		changes = append(changes, fmt.Sprintf("routing.region-failover-cooldown: %s -> %s", oldCfg.Routing.RegionFailoverCooldown, newCfg.Routing.RegionFailoverCooldown))
+	}
 	if !reflect.DeepEqual(oldCfg.Payload, newCfg.Payload) {
 		changes = appendPayloadConfigChanges(changes, oldCfg.Payload, newCfg.Payload)
 	}
@@ -128,6 +336,18 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if strings.TrimSpace(o.BaseURL) != strings.TrimSpace(n.BaseURL) {
 				changes = append(changes, fmt.Sprintf("gemini[%d].base-url: %s -> %s", i, strings.TrimSpace(o.BaseURL), strings.TrimSpace(n.BaseURL)))
 			}
+			if !reflect.DeepEqual(trimStrings(o.RegionalBaseURLs), trimStrings(n.RegionalBaseURLs)) {
+				changes = append(changes, fmt.Sprintf("gemini[%d].regional-base-urls: %v -> %v", i, trimStrings(o.RegionalBaseURLs), trimStrings(n.RegionalBaseURLs)))
+			}
+			if o.MaxConcurrentRequests != n.MaxConcurrentRequests {
+				changes = append(changes, fmt.Sprintf("gemini[%d].max-concurrent-requests: %d -> %d", i, o.MaxConcurrentRequests, n.MaxConcurrentRequests))
+			}
+			if o.Standby != n.Standby {
+				changes = append(changes, fmt.Sprintf("gemini[%d].standby: %t -> %t", i, o.Standby, n.Standby))
+			}
+			if strings.TrimSpace(o.Tier) != strings.TrimSpace(n.Tier) {
+				changes = append(changes, fmt.Sprintf("gemini[%d].tier: %s -> %s", i, strings.TrimSpace(o.Tier), strings.TrimSpace(n.Tier)))
+			}
 			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
 				changes = append(changes, fmt.Sprintf("gemini[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
 			}
@@ -196,6 +416,18 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if strings.TrimSpace(o.BaseURL) != strings.TrimSpace(n.BaseURL) {
 				changes = append(changes, fmt.Sprintf("claude[%d].base-url: %s -> %s", i, strings.TrimSpace(o.BaseURL), strings.TrimSpace(n.BaseURL)))
 			}
+			if !reflect.DeepEqual(trimStrings(o.RegionalBaseURLs), trimStrings(n.RegionalBaseURLs)) {
+				changes = append(changes, fmt.Sprintf("claude[%d].regional-base-urls: %v -> %v", i, trimStrings(o.RegionalBaseURLs), trimStrings(n.RegionalBaseURLs)))
+			}
+			if o.MaxConcurrentRequests != n.MaxConcurrentRequests {
+				changes = append(changes, fmt.Sprintf("claude[%d].max-concurrent-requests: %d -> %d", i, o.MaxConcurrentRequests, n.MaxConcurrentRequests))
+			}
+			if o.Standby != n.Standby {
+				changes = append(changes, fmt.Sprintf("claude[%d].standby: %t -> %t", i, o.Standby, n.Standby))
+			}
+			if strings.TrimSpace(o.Tier) != strings.TrimSpace(n.Tier) {
+				changes = append(changes, fmt.Sprintf("claude[%d].tier: %s -> %s", i, strings.TrimSpace(o.Tier), strings.TrimSpace(n.Tier)))
+			}
 			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
 				changes = append(changes, fmt.Sprintf("claude[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
 			}
@@ -221,6 +453,9 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if o.RebuildMidSystemMessage != n.RebuildMidSystemMessage {
 				changes = append(changes, fmt.Sprintf("claude[%d].rebuild-mid-system-message: %t -> %t", i, o.RebuildMidSystemMessage, n.RebuildMidSystemMessage))
 			}
+			if strings.Join(o.AllowedBetas, ",") != strings.Join(n.AllowedBetas, ",") {
+				changes = append(changes, fmt.Sprintf("claude[%d].allowed-betas: [%s] -> [%s]", i, strings.Join(o.AllowedBetas, ","), strings.Join(n.AllowedBetas, ",")))
+			}
 			if o.Cloak != nil && n.Cloak != nil {
 				if strings.TrimSpace(o.Cloak.Mode) != strings.TrimSpace(n.Cloak.Mode) {
 					changes = append(changes, fmt.Sprintf("claude[%d].cloak.mode: %s -> %s", i, o.Cloak.Mode, n.Cloak.Mode))
@@ -245,6 +480,18 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 			if strings.TrimSpace(o.BaseURL) != strings.TrimSpace(n.BaseURL) {
 				changes = append(changes, fmt.Sprintf("codex[%d].base-url: %s -> %s", i, strings.TrimSpace(o.BaseURL), strings.TrimSpace(n.BaseURL)))
 			}
+			if !reflect.DeepEqual(trimStrings(o.RegionalBaseURLs), trimStrings(n.RegionalBaseURLs)) {
+				changes = append(changes, fmt.Sprintf("codex[%d].regional-base-urls: %v -> %v", i, trimStrings(o.RegionalBaseURLs), trimStrings(n.RegionalBaseURLs)))
+			}
+			if o.MaxConcurrentRequests != n.MaxConcurrentRequests {
+				changes = append(changes, fmt.Sprintf("codex[%d].max-concurrent-requests: %d -> %d", i, o.MaxConcurrentRequests, n.MaxConcurrentRequests))
+			}
+			if o.Standby != n.Standby {
+				changes = append(changes, fmt.Sprintf("codex[%d].standby: %t -> %t", i, o.Standby, n.Standby))
+			}
+			if strings.TrimSpace(o.Tier) != strings.TrimSpace(n.Tier) {
+				changes = append(changes, fmt.Sprintf("codex[%d].tier: %s -> %s", i, strings.TrimSpace(o.Tier), strings.TrimSpace(n.Tier)))
+			}
 			if strings.TrimSpace(o.ProxyURL) != strings.TrimSpace(n.ProxyURL) {
 				changes = append(changes, fmt.Sprintf("codex[%d].proxy-url: %s -> %s", i, formatProxyURL(o.ProxyURL), formatProxyURL(n.ProxyURL)))
 			}
@@ -273,6 +520,12 @@ func BuildConfigChangeDetails(oldCfg, newCfg *config.Config) []string {
 		}
 	}
 
+	if !reflect.DeepEqual(oldCfg.HeaderTemplates, newCfg.HeaderTemplates) {
+		changes = append(changes, fmt.Sprintf("header-templates: updated (%d -> %d providers)", len(oldCfg.HeaderTemplates), len(newCfg.HeaderTemplates)))
+	}
+	if !reflect.DeepEqual(oldCfg.ProviderBetas, newCfg.ProviderBetas) {
+		changes = append(changes, fmt.Sprintf("provider-betas: updated (%d -> %d providers)", len(oldCfg.ProviderBetas), len(newCfg.ProviderBetas)))
+	}
 	if entries, _ := DiffOAuthExcludedModelChanges(oldCfg.OAuthExcludedModels, newCfg.OAuthExcludedModels); len(entries) > 0 {
 		changes = append(changes, entries...)
 	}
@@ -4,6 +4,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"reflect"
 	"sort"
 	"strings"
 
@@ -78,6 +79,15 @@ func describeOpenAICompatibilityUpdate(oldEntry, newEntry config.OpenAICompatibi
 	if !equalStringMap(oldEntry.Headers, newEntry.Headers) {
 		details = append(details, "headers updated")
 	}
+	if !reflect.DeepEqual(oldEntry.Signing, newEntry.Signing) {
+		details = append(details, "signing updated")
+	}
+	if !reflect.DeepEqual(oldEntry.Quirks, newEntry.Quirks) {
+		details = append(details, "quirks updated")
+	}
+	if oldEntry.DetectCapabilities != newEntry.DetectCapabilities {
+		details = append(details, fmt.Sprintf("detect-capabilities %t -> %t", oldEntry.DetectCapabilities, newEntry.DetectCapabilities))
+	}
 	if len(details) == 0 {
 		return ""
 	}
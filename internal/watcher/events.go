@@ -14,6 +14,7 @@ import (
 
 	"github.com/fsnotify/fsnotify"
 	kiroauth "github.com/router-for-me/CLIProxyAPI/v7/internal/auth/kiro"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -34,6 +35,15 @@ func (w *Watcher) start(ctx context.Context) error {
 	}
 	log.Debugf("watching config file: %s", w.configPath)
 
+	overlayDir := config.OverlayDir(w.configPath)
+	if info, errStat := os.Stat(overlayDir); errStat == nil && info.IsDir() {
+		if errAddOverlay := w.watcher.Add(overlayDir); errAddOverlay != nil {
+			log.Errorf("failed to watch config overlay directory %s: %v", overlayDir, errAddOverlay)
+			return errAddOverlay
+		}
+		log.Debugf("watching config overlay directory: %s", overlayDir)
+	}
+
 	if errAddAuthDir := w.watcher.Add(w.authDir); errAddAuthDir != nil {
 		log.Errorf("failed to watch auth directory %s: %v", w.authDir, errAddAuthDir)
 		return errAddAuthDir
@@ -95,6 +105,10 @@ func (w *Watcher) handleEvent(event fsnotify.Event) {
 	normalizedConfigPath := w.normalizeAuthPath(w.configPath)
 	normalizedAuthDir := w.normalizeAuthPath(w.authDir)
 	isConfigEvent := normalizedName == normalizedConfigPath && event.Op&configOps != 0
+	if !isConfigEvent && filepath.Dir(normalizedName) == w.normalizeAuthPath(config.OverlayDir(w.configPath)) {
+		// Overlay removals change the merged config too.
+		isConfigEvent = config.IsOverlayFile(normalizedName) && event.Op&(configOps|fsnotify.Remove) != 0
+	}
 	authOps := fsnotify.Create | fsnotify.Write | fsnotify.Remove | fsnotify.Rename
 	if w.isKiroIDETokenFile(event.Name) && event.Op&authOps != 0 {
 		w.handleKiroIDETokenChange(event)
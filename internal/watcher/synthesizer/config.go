@@ -152,6 +152,19 @@ func (s *ConfigSynthesizer) synthesizePassthru(ctx *SynthesisContext) []*coreaut
 			if r.SupportsDeveloperRole != nil {
 				attrs["supports_developer_role"] = strconv.FormatBool(*r.SupportsDeveloperRole)
 			}
+			if !r.StreamTransform.Empty() {
+				if transformJSON, err := json.Marshal(r.StreamTransform); err == nil {
+					attrs["stream_transform"] = string(transformJSON)
+				}
+			}
+			if r.Priority != 0 {
+				attrs["priority"] = strconv.Itoa(r.Priority)
+			}
+			if r.HealthCheck != nil && strings.TrimSpace(r.HealthCheck.Path) != "" {
+				if healthJSON, err := json.Marshal(r.HealthCheck); err == nil {
+					attrs["health_check"] = string(healthJSON)
+				}
+			}
 			addConfigHeadersToAttrs(r.Headers, attrs)
 
 			providerName := protocol
@@ -424,6 +437,22 @@ func (s *ConfigSynthesizer) synthesizeGeminiKeyEntries(ctx *SynthesisContext, en
 		if base != "" {
 			attrs["base_url"] = base
 		}
+		addRegionalBaseURLsToAttrs(entry.RegionalBaseURLs, attrs)
+		if entry.MaxConcurrentRequests > 0 {
+			attrs["max_concurrent_requests"] = strconv.Itoa(entry.MaxConcurrentRequests)
+		}
+		if entry.Standby {
+			attrs["standby"] = "true"
+		}
+		if tier := strings.TrimSpace(entry.Tier); tier != "" {
+			attrs["tier"] = tier
+		}
+		if residency := strings.TrimSpace(entry.Residency); residency != "" {
+			attrs["residency"] = residency
+		}
+		if region := strings.TrimSpace(entry.Region); region != "" {
+			attrs["region"] = region
+		}
 		if hash := diff.ComputeGeminiModelsHash(entry.Models); hash != "" {
 			attrs["models_hash"] = hash
 		}
@@ -479,9 +508,25 @@ func (s *ConfigSynthesizer) synthesizeClaudeKeys(ctx *SynthesisContext) []*corea
 		if base != "" {
 			attrs["base_url"] = base
 		}
+		addRegionalBaseURLsToAttrs(ck.RegionalBaseURLs, attrs)
+		if ck.MaxConcurrentRequests > 0 {
+			attrs["max_concurrent_requests"] = strconv.Itoa(ck.MaxConcurrentRequests)
+		}
+		if ck.Standby {
+			attrs["standby"] = "true"
+		}
+		if tier := strings.TrimSpace(ck.Tier); tier != "" {
+			attrs["tier"] = tier
+		}
+		if residency := strings.TrimSpace(ck.Residency); residency != "" {
+			attrs["residency"] = residency
+		}
 		if ck.RebuildMidSystemMessage {
 			attrs["rebuild_mid_system_message"] = "true"
 		}
+		if len(ck.AllowedBetas) > 0 {
+			attrs["allowed_betas"] = strings.Join(ck.AllowedBetas, ",")
+		}
 		if hash := diff.ComputeClaudeModelsHash(ck.Models); hash != "" {
 			attrs["models_hash"] = hash
 		}
@@ -537,6 +582,19 @@ func (s *ConfigSynthesizer) synthesizeCodexKeys(ctx *SynthesisContext) []*coreau
 		if ck.BaseURL != "" {
 			attrs["base_url"] = ck.BaseURL
 		}
+		addRegionalBaseURLsToAttrs(ck.RegionalBaseURLs, attrs)
+		if ck.MaxConcurrentRequests > 0 {
+			attrs["max_concurrent_requests"] = strconv.Itoa(ck.MaxConcurrentRequests)
+		}
+		if ck.Standby {
+			attrs["standby"] = "true"
+		}
+		if tier := strings.TrimSpace(ck.Tier); tier != "" {
+			attrs["tier"] = tier
+		}
+		if residency := strings.TrimSpace(ck.Residency); residency != "" {
+			attrs["residency"] = residency
+		}
 		if ck.Websockets {
 			attrs["websockets"] = "true"
 		}
@@ -608,6 +666,9 @@ func (s *ConfigSynthesizer) synthesizeOpenAICompat(ctx *SynthesisContext) []*cor
 			if compat.Priority != 0 {
 				attrs["priority"] = strconv.Itoa(compat.Priority)
 			}
+			if residency := strings.TrimSpace(compat.Residency); residency != "" {
+				attrs["residency"] = residency
+			}
 			if key != "" {
 				attrs["api_key"] = key
 			}
@@ -650,6 +711,9 @@ func (s *ConfigSynthesizer) synthesizeOpenAICompat(ctx *SynthesisContext) []*cor
 			if compat.Priority != 0 {
 				attrs["priority"] = strconv.Itoa(compat.Priority)
 			}
+			if residency := strings.TrimSpace(compat.Residency); residency != "" {
+				attrs["residency"] = residency
+			}
 			if hash := diff.ComputeOpenAICompatModelsHash(compat.Models); hash != "" {
 				attrs["models_hash"] = hash
 			}
@@ -699,6 +763,12 @@ func (s *ConfigSynthesizer) synthesizeVertexCompat(ctx *SynthesisContext) []*cor
 		if compat.Priority != 0 {
 			attrs["priority"] = strconv.Itoa(compat.Priority)
 		}
+		if residency := strings.TrimSpace(compat.Residency); residency != "" {
+			attrs["residency"] = residency
+		}
+		if region := strings.TrimSpace(compat.Region); region != "" {
+			attrs["region"] = region
+		}
 		if key != "" {
 			attrs["api_key"] = key
 		}
@@ -103,6 +103,22 @@ func ApplyAuthExcludedModelsMeta(auth *coreauth.Auth, cfg *config.Config, perKey
 	}
 }
 
+// addRegionalBaseURLsToAttrs stores the regional base URLs of a credential for base URL pinning.
+func addRegionalBaseURLsToAttrs(urls []string, attrs map[string]string) {
+	if attrs == nil {
+		return
+	}
+	cleaned := make([]string, 0, len(urls))
+	for _, url := range urls {
+		if url = strings.TrimRight(strings.TrimSpace(url), "/"); url != "" {
+			cleaned = append(cleaned, url)
+		}
+	}
+	if len(cleaned) > 0 {
+		attrs["regional_base_urls"] = strings.Join(cleaned, ",")
+	}
+}
+
 // addConfigHeadersToAttrs adds header configuration to auth attributes.
 // Headers are prefixed with "header:" in the attributes map.
 func addConfigHeadersToAttrs(headers map[string]string, attrs map[string]string) {
@@ -0,0 +1,314 @@
+package handlers
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+const (
+	defaultAdmissionMaxQueue     = 256
+	defaultAdmissionQueueTimeout = 30 * time.Second
+	// admissionRecheckInterval is how often waiters re-check conditions that change without a
+	// release, such as the end of a quota cooldown.
+	admissionRecheckInterval = time.Second
+)
+
+// Admission ranks, most urgent first.
+const (
+	admissionRankHigh = iota
+	admissionRankNormal
+	admissionRankBatch
+	admissionRanks
+)
+
+var (
+	errAdmissionShed    = errors.New("request shed by admission control: upstream capacity is constrained")
+	errAdmissionTimeout = errors.New("request timed out in the admission queue: upstream capacity is constrained")
+)
+
+// admissionSettings is the effective admission configuration of one request.
+type admissionSettings struct {
+	maxConcurrent int
+	maxQueue      int
+	timeout       time.Duration
+}
+
+// admissionQueue admits requests to upstream execution in priority order. Requests wait while
+// max-concurrent requests are in flight, or, for batch requests, while upstream capacity is
+// constrained; within a rank waiters are served first come first served.
+type admissionQueue struct {
+	mu       sync.Mutex
+	inFlight int
+	queued   int
+	waiting  [admissionRanks]*list.List
+}
+
+type admissionWaiter struct {
+	rank     int
+	elem     *list.Element
+	done     chan struct{}
+	admitted bool
+}
+
+func newAdmissionQueue() *admissionQueue {
+	q := &admissionQueue{}
+	for rank := range q.waiting {
+		q.waiting[rank] = list.New()
+	}
+	return q
+}
+
+func (h *BaseAPIHandler) admissionQueue() *admissionQueue {
+	h.admissionOnce.Do(func() {
+		h.admission = newAdmissionQueue()
+	})
+	return h.admission
+}
+
+// admissionRank maps an api-key-priorities class to its rank.
+func admissionRank(priority string) int {
+	switch priority {
+	case config.PriorityHigh:
+		return admissionRankHigh
+	case config.PriorityBatch:
+		return admissionRankBatch
+	}
+	return admissionRankNormal
+}
+
+// admissionSettingsFor reports the admission settings of cfg and whether admission control is
+// enabled at all; it is off unless max-concurrent or api-key-priorities is configured.
+func admissionSettingsFor(cfg *config.SDKConfig) (admissionSettings, bool) {
+	if cfg == nil || (cfg.Admission.MaxConcurrent <= 0 && len(cfg.APIKeyPriorities) == 0) {
+		return admissionSettings{}, false
+	}
+	settings := admissionSettings{
+		maxConcurrent: cfg.Admission.MaxConcurrent,
+		maxQueue:      cfg.Admission.MaxQueue,
+		timeout:       time.Duration(cfg.Admission.QueueTimeoutSeconds) * time.Second,
+	}
+	if settings.maxQueue <= 0 {
+		settings.maxQueue = defaultAdmissionMaxQueue
+	}
+	if settings.timeout <= 0 {
+		settings.timeout = defaultAdmissionQueueTimeout
+	}
+	return settings, true
+}
+
+// admitRequest waits until the request behind ctx may execute upstream and returns the func
+// that ends its admission. Both results are nil when admission control is disabled.
+func (h *BaseAPIHandler) admitRequest(ctx context.Context) (func(), *interfaces.ErrorMessage) {
+	cfg := h.CurrentConfig()
+	settings, enabled := admissionSettingsFor(cfg)
+	if !enabled {
+		return nil, nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	rank := admissionRank(cfg.APIKeyPriorityClass(requestAPIKey(ctx)))
+	return h.admissionQueue().acquire(ctx, rank, settings, h.upstreamConstrained)
+}
+
+// upstreamConstrained reports whether credentials are cooling down after quota or rate-limit
+// errors, in which case batch requests are held back.
+func (h *BaseAPIHandler) upstreamConstrained() bool {
+	return h.AuthManager != nil && h.AuthManager.QuotaCooldownActive()
+}
+
+func (q *admissionQueue) acquire(ctx context.Context, rank int, settings admissionSettings, constrained func() bool) (func(), *interfaces.ErrorMessage) {
+	q.mu.Lock()
+	if q.canAdmitLocked(rank, settings, constrained) {
+		q.inFlight++
+		q.mu.Unlock()
+		return q.releaseFunc(settings, constrained), nil
+	}
+	if q.queued >= settings.maxQueue && !q.shedBelowLocked(rank) {
+		q.mu.Unlock()
+		return nil, admissionError(errAdmissionShed, settings)
+	}
+	waiter := &admissionWaiter{rank: rank, done: make(chan struct{})}
+	waiter.elem = q.waiting[rank].PushBack(waiter)
+	q.queued++
+	q.mu.Unlock()
+
+	timeout := time.NewTimer(settings.timeout)
+	defer timeout.Stop()
+	recheck := time.NewTicker(admissionRecheckInterval)
+	defer recheck.Stop()
+	for {
+		var errWait error
+		select {
+		case <-waiter.done:
+		case <-recheck.C:
+			q.dispatch(settings, constrained)
+			continue
+		case <-ctx.Done():
+			errWait = ctx.Err()
+		case <-timeout.C:
+			errWait = errAdmissionTimeout
+		}
+
+		q.mu.Lock()
+		admitted := waiter.admitted
+		if !admitted && waiter.elem != nil {
+			q.waiting[waiter.rank].Remove(waiter.elem)
+			waiter.elem = nil
+			q.queued--
+		}
+		q.mu.Unlock()
+		if admitted {
+			release := q.releaseFunc(settings, constrained)
+			if errCtx := ctx.Err(); errCtx != nil {
+				release()
+				return nil, &interfaces.ErrorMessage{StatusCode: 499, Error: errCtx}
+			}
+			return release, nil
+		}
+		switch {
+		case errWait == nil:
+			return nil, admissionError(errAdmissionShed, settings)
+		case errors.Is(errWait, errAdmissionTimeout):
+			return nil, admissionError(errWait, settings)
+		default:
+			return nil, &interfaces.ErrorMessage{StatusCode: 499, Error: errWait}
+		}
+	}
+}
+
+// canAdmitLocked reports whether a new request of rank may start without queueing. Waiters of
+// the same or a more urgent rank go first.
+func (q *admissionQueue) canAdmitLocked(rank int, settings admissionSettings, constrained func() bool) bool {
+	if settings.maxConcurrent > 0 && q.inFlight >= settings.maxConcurrent {
+		return false
+	}
+	for r := admissionRankHigh; r <= rank; r++ {
+		if q.waiting[r].Len() > 0 {
+			return false
+		}
+	}
+	return rank != admissionRankBatch || constrained == nil || !constrained()
+}
+
+// shedBelowLocked drops the newest waiter less urgent than rank to make room in a full queue.
+func (q *admissionQueue) shedBelowLocked(rank int) bool {
+	for r := admissionRankBatch; r > rank; r-- {
+		back := q.waiting[r].Back()
+		if back == nil {
+			continue
+		}
+		waiter := back.Value.(*admissionWaiter)
+		q.waiting[r].Remove(back)
+		waiter.elem = nil
+		q.queued--
+		close(waiter.done)
+		return true
+	}
+	return false
+}
+
+// dispatch admits waiters in rank order while capacity allows.
+func (q *admissionQueue) dispatch(settings admissionSettings, constrained func() bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for rank := range q.waiting {
+		if rank == admissionRankBatch && q.waiting[rank].Len() > 0 && constrained != nil && constrained() {
+			return
+		}
+		for q.waiting[rank].Len() > 0 {
+			if settings.maxConcurrent > 0 && q.inFlight >= settings.maxConcurrent {
+				return
+			}
+			front := q.waiting[rank].Front()
+			waiter := front.Value.(*admissionWaiter)
+			q.waiting[rank].Remove(front)
+			waiter.elem = nil
+			waiter.admitted = true
+			q.queued--
+			q.inFlight++
+			close(waiter.done)
+		}
+	}
+}
+
+func (q *admissionQueue) releaseFunc(settings admissionSettings, constrained func() bool) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			q.mu.Lock()
+			q.inFlight--
+			q.mu.Unlock()
+			q.dispatch(settings, constrained)
+		})
+	}
+}
+
+func admissionError(err error, settings admissionSettings) *interfaces.ErrorMessage {
+	addon := make(http.Header)
+	addon.Set("Retry-After", strconv.Itoa(int(settings.timeout/time.Second)))
+	return &interfaces.ErrorMessage{StatusCode: http.StatusTooManyRequests, Error: err, Addon: addon}
+}
+
+// releaseAfterStream relays a handler stream and calls release once both of its channels are
+// closed, keeping the request admitted for the whole stream.
+func releaseAfterStream(ctx context.Context, dataChan <-chan []byte, errChan <-chan *interfaces.ErrorMessage, release func()) (<-chan []byte, <-chan *interfaces.ErrorMessage) {
+	if release == nil {
+		return dataChan, errChan
+	}
+	if dataChan == nil && errChan == nil {
+		release()
+		return dataChan, errChan
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var dataOut chan []byte
+	if dataChan != nil {
+		dataOut = make(chan []byte)
+	}
+	errOut := make(chan *interfaces.ErrorMessage, 1)
+	go func() {
+		defer release()
+		defer close(errOut)
+		if dataOut != nil {
+			defer close(dataOut)
+		}
+		data, errs := dataChan, errChan
+		for data != nil || errs != nil {
+			select {
+			case chunk, ok := <-data:
+				if !ok {
+					data = nil
+					continue
+				}
+				select {
+				case dataOut <- chunk:
+				case <-ctx.Done():
+					// The client is gone; keep draining so the producer can finish.
+				}
+			case errMsg, ok := <-errs:
+				if !ok {
+					errs = nil
+					continue
+				}
+				select {
+				case errOut <- errMsg:
+				case <-ctx.Done():
+				}
+			}
+		}
+	}()
+	if dataOut == nil {
+		return nil, errOut
+	}
+	return dataOut, errOut
+}
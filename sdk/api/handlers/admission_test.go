@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+)
+
+func testAdmissionSettings(maxConcurrent, maxQueue int) admissionSettings {
+	return admissionSettings{maxConcurrent: maxConcurrent, maxQueue: maxQueue, timeout: 5 * time.Second}
+}
+
+// acquireAsync starts an acquire and returns the channel receiving its outcome.
+func acquireAsync(q *admissionQueue, ctx context.Context, rank int, settings admissionSettings, constrained func() bool) <-chan *interfaces.ErrorMessage {
+	out := make(chan *interfaces.ErrorMessage, 1)
+	go func() {
+		release, errMsg := q.acquire(ctx, rank, settings, constrained)
+		if release != nil {
+			defer release()
+		}
+		out <- errMsg
+	}()
+	return out
+}
+
+func waitQueued(t *testing.T, q *admissionQueue, want int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		q.mu.Lock()
+		queued := q.queued
+		q.mu.Unlock()
+		if queued == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("queued = %d, want %d", queued, want)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestAdmissionQueueAdmitsHigherPriorityFirst(t *testing.T) {
+	q := newAdmissionQueue()
+	settings := testAdmissionSettings(1, 10)
+	ctx := context.Background()
+
+	release, errMsg := q.acquire(ctx, admissionRankNormal, settings, nil)
+	if errMsg != nil || release == nil {
+		t.Fatalf("first acquire = %v, want admitted", errMsg)
+	}
+	batch := acquireAsync(q, ctx, admissionRankBatch, settings, nil)
+	waitQueued(t, q, 1)
+	high := acquireAsync(q, ctx, admissionRankHigh, settings, nil)
+	waitQueued(t, q, 2)
+
+	release()
+	select {
+	case errMsg = <-high:
+		if errMsg != nil {
+			t.Fatalf("high priority waiter failed: %v", errMsg.Error)
+		}
+	case <-batch:
+		t.Fatal("batch waiter was admitted before the high priority one")
+	case <-time.After(2 * time.Second):
+		t.Fatal("no waiter admitted after release")
+	}
+	if errMsg = <-batch; errMsg != nil {
+		t.Fatalf("batch waiter failed: %v", errMsg.Error)
+	}
+}
+
+func TestAdmissionQueueHoldsBatchWhileConstrained(t *testing.T) {
+	q := newAdmissionQueue()
+	settings := testAdmissionSettings(0, 10)
+	// constrained is only read by the queue while it holds q.mu.
+	constrained := true
+	isConstrained := func() bool { return constrained }
+	ctx := context.Background()
+
+	if release, errMsg := q.acquire(ctx, admissionRankNormal, settings, isConstrained); errMsg != nil || release == nil {
+		t.Fatalf("normal acquire while constrained = %v, want admitted", errMsg)
+	} else {
+		release()
+	}
+	batch := acquireAsync(q, ctx, admissionRankBatch, settings, isConstrained)
+	waitQueued(t, q, 1)
+
+	q.mu.Lock()
+	constrained = false
+	q.mu.Unlock()
+	select {
+	case errMsg := <-batch:
+		if errMsg != nil {
+			t.Fatalf("batch waiter failed: %v", errMsg.Error)
+		}
+	case <-time.After(3 * admissionRecheckInterval):
+		t.Fatal("batch waiter not admitted after the cooldown ended")
+	}
+}
+
+func TestAdmissionQueueShedsBatchWhenFull(t *testing.T) {
+	q := newAdmissionQueue()
+	settings := testAdmissionSettings(1, 1)
+	ctx := context.Background()
+
+	release, _ := q.acquire(ctx, admissionRankNormal, settings, nil)
+	batch := acquireAsync(q, ctx, admissionRankBatch, settings, nil)
+	waitQueued(t, q, 1)
+
+	if _, errMsg := q.acquire(ctx, admissionRankBatch, settings, nil); errMsg == nil || errMsg.StatusCode != http.StatusTooManyRequests {
+		t.Fatalf("batch acquire on a full queue = %v, want 429", errMsg)
+	}
+	normal := acquireAsync(q, ctx, admissionRankNormal, settings, nil)
+	select {
+	case errMsg := <-batch:
+		if errMsg == nil || errMsg.StatusCode != http.StatusTooManyRequests {
+			t.Fatalf("shed batch waiter = %v, want 429", errMsg)
+		}
+		if errMsg.Addon.Get("Retry-After") == "" {
+			t.Fatal("shed response lacks Retry-After")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("batch waiter was not shed for the normal request")
+	}
+	release()
+	if errMsg := <-normal; errMsg != nil {
+		t.Fatalf("normal waiter failed: %v", errMsg.Error)
+	}
+}
+
+func TestReleaseAfterStreamWaitsForBothChannels(t *testing.T) {
+	data := make(chan []byte)
+	errs := make(chan *interfaces.ErrorMessage, 1)
+	released := make(chan struct{})
+	outData, outErrs := releaseAfterStream(context.Background(), data, errs, func() { close(released) })
+
+	go func() {
+		data <- []byte("chunk")
+		close(data)
+		errs <- &interfaces.ErrorMessage{StatusCode: http.StatusBadGateway}
+		close(errs)
+	}()
+	if chunk := <-outData; string(chunk) != "chunk" {
+		t.Fatalf("chunk = %q, want %q", chunk, "chunk")
+	}
+	if _, ok := <-outData; ok {
+		t.Fatal("data channel not closed")
+	}
+	if errMsg := <-outErrs; errMsg == nil || errMsg.StatusCode != http.StatusBadGateway {
+		t.Fatalf("error = %v, want the forwarded 502", errMsg)
+	}
+	select {
+	case <-released:
+	case <-time.After(2 * time.Second):
+		t.Fatal("release not called after the stream ended")
+	}
+}
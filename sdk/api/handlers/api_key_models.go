@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
+	"github.com/tidwall/gjson"
+)
+
+// requestAPIKey returns the client API key that authenticated the request behind ctx.
+func requestAPIKey(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil {
+		return ""
+	}
+	return ginCtx.GetString("userApiKey")
+}
+
+// apiKeyAllowsModel reports whether apiKey may use modelName, which may carry a thinking suffix
+// such as "(high)".
+func (h *BaseAPIHandler) apiKeyAllowsModel(apiKey, modelName string) bool {
+	cfg := h.CurrentConfig()
+	if cfg.APIKeyAllowsModel(apiKey, modelName) {
+		return true
+	}
+	base := thinking.ParseSuffix(modelName).ModelName
+	return base != modelName && cfg.APIKeyAllowsModel(apiKey, base)
+}
+
+// checkAPIKeyModel rejects models outside the api-key-models scope of the client API key.
+func (h *BaseAPIHandler) checkAPIKeyModel(ctx context.Context, modelName string) *interfaces.ErrorMessage {
+	if h.apiKeyAllowsModel(requestAPIKey(ctx), modelName) {
+		return nil
+	}
+	return &interfaces.ErrorMessage{
+		StatusCode: http.StatusForbidden,
+		Error:      fmt.Errorf("model %s is not available for this API key", modelName),
+	}
+}
+
+// APIKeyAllowsModel reports whether the API key that authenticated c may list and call modelName.
+func (h *BaseAPIHandler) APIKeyAllowsModel(c *gin.Context, modelName string) bool {
+	if c == nil {
+		return true
+	}
+	return h.apiKeyAllowsModel(c.GetString("userApiKey"), modelName)
+}
+
+// ScopeModelListing filters a JSON array of models, as returned by the registry, to those the
+// API key of c may use, and returns the array with the digest identifying it. Models are matched
+// by their "id", or their "name" without the "models/" prefix. Unscoped keys get data unchanged.
+func (h *BaseAPIHandler) ScopeModelListing(c *gin.Context, data []byte, digest string) ([]byte, string) {
+	if c == nil {
+		return data, digest
+	}
+	apiKey := c.GetString("userApiKey")
+	if _, scoped := h.CurrentConfig().APIKeyModelPatterns(apiKey); !scoped {
+		return data, digest
+	}
+	var out strings.Builder
+	out.WriteByte('[')
+	first := true
+	gjson.ParseBytes(data).ForEach(func(_, model gjson.Result) bool {
+		id := model.Get("id").String()
+		if id == "" {
+			id = strings.TrimPrefix(model.Get("name").String(), "models/")
+		}
+		if !h.apiKeyAllowsModel(apiKey, id) {
+			return true
+		}
+		if !first {
+			out.WriteByte(',')
+		}
+		first = false
+		out.WriteString(model.Raw)
+		return true
+	})
+	out.WriteByte(']')
+	sum := sha256.Sum256([]byte(out.String()))
+	return []byte(out.String()), hex.EncodeToString(sum[:16])
+}
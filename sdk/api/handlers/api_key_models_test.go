@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+func newScopedTestContext(apiKey string) *gin.Context {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+	c.Set("userApiKey", apiKey)
+	return c
+}
+
+func TestScopeModelListingFiltersByAPIKey(t *testing.T) {
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{APIKeyModels: []sdkconfig.APIKeyModelScope{
+		{APIKeys: []string{"cheap"}, Models: []string{"gpt-5-mini", "gemini-*-flash"}},
+	}}, nil)
+	data := []byte(`[{"id":"gpt-5"},{"id":"gpt-5-mini"},{"name":"models/gemini-2.5-flash"}]`)
+
+	scoped, digest := handler.ScopeModelListing(newScopedTestContext("cheap"), data, "full")
+	if got, want := string(scoped), `[{"id":"gpt-5-mini"},{"name":"models/gemini-2.5-flash"}]`; got != want {
+		t.Fatalf("scoped listing = %s, want %s", got, want)
+	}
+	if digest == "full" || digest == "" {
+		t.Fatalf("digest = %q, want a digest of the scoped listing", digest)
+	}
+
+	unscoped, unscopedDigest := handler.ScopeModelListing(newScopedTestContext("other"), data, "full")
+	if string(unscoped) != string(data) || unscopedDigest != "full" {
+		t.Fatalf("unscoped key got %s (%s), want the listing unchanged", unscoped, unscopedDigest)
+	}
+}
+
+func TestCheckAPIKeyModelRejectsOutOfScopeModels(t *testing.T) {
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{APIKeyModels: []sdkconfig.APIKeyModelScope{
+		{APIKeys: []string{"cheap"}, Models: []string{"gpt-5-mini"}},
+	}}, nil)
+	ctx := context.WithValue(context.Background(), "gin", newScopedTestContext("cheap"))
+
+	if errMsg := handler.checkAPIKeyModel(ctx, "gpt-5-mini(high)"); errMsg != nil {
+		t.Fatalf("in-scope model with thinking suffix rejected: %v", errMsg.Error)
+	}
+	errMsg := handler.checkAPIKeyModel(ctx, "gpt-5")
+	if errMsg == nil || errMsg.StatusCode != http.StatusForbidden {
+		t.Fatalf("out-of-scope model error = %+v, want 403", errMsg)
+	}
+	if errMsg := handler.checkAPIKeyModel(context.Background(), "gpt-5"); errMsg != nil {
+		t.Fatalf("request without API key rejected: %v", errMsg.Error)
+	}
+}
@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+)
+
+// chunkBroadcast buffers the chunks of one upstream stream for any number of subscribers, as
+// shared by request deduplication and resumable streams. Chunks are addressed by their absolute
+// index, starting at 0. Beyond maxBytes the oldest chunks every subscriber has been sent are
+// dropped, always keeping the newest one, so a slow subscriber is never cut off but a new one
+// can only start within the buffer.
+type chunkBroadcast struct {
+	mu       sync.Mutex
+	chunks   [][]byte
+	base     int
+	size     int
+	maxBytes int
+	done     bool
+	headers  http.Header
+	errMsg   *interfaces.ErrorMessage
+	updated  chan struct{}
+	// cursors maps each subscriber to the index of its next chunk.
+	cursors map[int]int
+	nextSub int
+	// lastDetachedLocked, when set, runs with mu held once the last subscriber is gone.
+	lastDetachedLocked func()
+}
+
+// updatedLocked returns a channel closed on the next change; callers must hold b.mu.
+func (b *chunkBroadcast) updatedLocked() <-chan struct{} {
+	if b.updated == nil {
+		b.updated = make(chan struct{})
+	}
+	return b.updated
+}
+
+// broadcastLocked wakes all waiters; callers must hold b.mu.
+func (b *chunkBroadcast) broadcastLocked() {
+	if b.updated != nil {
+		close(b.updated)
+		b.updated = nil
+	}
+}
+
+func (b *chunkBroadcast) setHeaders(headers http.Header) {
+	b.mu.Lock()
+	b.headers = cloneHeader(headers)
+	b.mu.Unlock()
+}
+
+// appendLocked buffers a copy of chunk and wakes the subscribers; callers must hold b.mu.
+func (b *chunkBroadcast) appendLocked(chunk []byte) {
+	b.chunks = append(b.chunks, cloneBytes(chunk))
+	b.size += len(chunk)
+	b.trimLocked()
+	b.broadcastLocked()
+}
+
+// finishLocked ends the stream with errMsg, which is nil on success; callers must hold b.mu.
+func (b *chunkBroadcast) finishLocked(errMsg *interfaces.ErrorMessage) {
+	b.done = true
+	b.errMsg = errMsg
+	b.broadcastLocked()
+}
+
+// trimLocked drops the oldest chunks beyond maxBytes that every subscriber has been sent;
+// callers must hold b.mu.
+func (b *chunkBroadcast) trimLocked() {
+	end := b.base + len(b.chunks) - 1
+	for _, cursor := range b.cursors {
+		end = min(end, cursor)
+	}
+	drop := 0
+	for b.size > b.maxBytes && b.base+drop < end {
+		b.size -= len(b.chunks[drop])
+		b.chunks[drop] = nil
+		drop++
+	}
+	b.chunks = b.chunks[drop:]
+	b.base += drop
+}
+
+// attachLocked registers a subscriber starting at chunk start. It reports false when start is
+// no longer, or not yet, buffered; callers must hold b.mu.
+func (b *chunkBroadcast) attachLocked(start int) (int, bool) {
+	if start < b.base || start > b.base+len(b.chunks) {
+		return 0, false
+	}
+	if b.cursors == nil {
+		b.cursors = make(map[int]int)
+	}
+	id := b.nextSub
+	b.nextSub++
+	b.cursors[id] = start
+	return id, true
+}
+
+func (b *chunkBroadcast) detach(id int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.cursors, id)
+	b.trimLocked()
+	if len(b.cursors) == 0 && b.lastDetachedLocked != nil {
+		b.lastDetachedLocked()
+	}
+}
+
+// follow sends subscriber id the buffered chunks from start and then the live stream, filling
+// headers from the upstream headers before the first chunk. delivered, when set, is called with
+// the index of each chunk once it was handed over, and with the index past the last chunk at the
+// end of the stream. Channel semantics match ExecuteStreamWithAuthManager: errors are queued
+// before both channels close.
+func (b *chunkBroadcast) follow(ctx context.Context, id, start int, headers http.Header, delivered func(next int)) (<-chan []byte, <-chan *interfaces.ErrorMessage) {
+	dataChan := make(chan []byte)
+	errChan := make(chan *interfaces.ErrorMessage, 1)
+	go func() {
+		defer close(dataChan)
+		defer close(errChan)
+		defer b.detach(id)
+		next := start
+		headersFilled := false
+		for {
+			b.mu.Lock()
+			// Chunks before next were sent, so they may be trimmed while pending is delivered.
+			b.cursors[id] = next
+			b.trimLocked()
+			pending := b.chunks[next-b.base:]
+			if len(pending) > 0 && !headersFilled {
+				// Callers read headers only after the first chunk, so filling them here is race-free.
+				WriteUpstreamHeaders(headers, b.headers)
+				headersFilled = true
+			}
+			done, errMsg, updated := b.done, b.errMsg, b.updatedLocked()
+			b.mu.Unlock()
+			for _, chunk := range pending {
+				select {
+				case <-ctx.Done():
+					return
+				case dataChan <- cloneBytes(chunk):
+				}
+				if delivered != nil {
+					delivered(next)
+				}
+				next++
+			}
+			if len(pending) > 0 {
+				continue
+			}
+			if done {
+				if delivered != nil {
+					delivered(next)
+				}
+				if errMsg != nil {
+					errChan <- errMsg
+				}
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-updated:
+			}
+		}
+	}()
+	return dataChan, errChan
+}
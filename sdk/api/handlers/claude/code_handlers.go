@@ -80,6 +80,8 @@ func (h *ClaudeCodeAPIHandler) ClaudeMessages(c *gin.Context) {
 		return
 	}
 
+	rawJSON = h.CompressConversation(c, handlers.CompressionFormatClaude, rawJSON)
+
 	// Check if the client requested a streaming response.
 	streamResult := gjson.GetBytes(rawJSON, "stream")
 	if !streamResult.Exists() || streamResult.Type == gjson.False {
@@ -133,7 +135,31 @@ func (h *ClaudeCodeAPIHandler) ClaudeCountTokens(c *gin.Context) {
 // Parameters:
 //   - c: The Gin context for the request.
 func (h *ClaudeCodeAPIHandler) ClaudeModels(c *gin.Context) {
+	if data, digest, errJSON := registry.GetGlobalRegistry().GetAvailableModelsJSON(h.HandlerType()); errJSON == nil {
+		data, digest = h.ScopeModelListing(c, data, digest)
+		models := gjson.ParseBytes(data)
+		firstID, _ := json.Marshal(models.Get("0.id").String())
+		lastID, _ := json.Marshal(models.Get(strconv.FormatInt(models.Get("#").Int()-1, 10) + ".id").String())
+		body := make([]byte, 0, len(data)+len(firstID)+len(lastID)+64)
+		body = append(body, `{"data":`...)
+		body = append(body, data...)
+		body = append(body, `,"has_more":false,"first_id":`...)
+		body = append(body, firstID...)
+		body = append(body, `,"last_id":`...)
+		body = append(body, lastID...)
+		body = append(body, '}')
+		handlers.WriteModelListing(c, body, "claude-"+digest)
+		return
+	}
+
 	models := h.Models()
+	scoped := models[:0]
+	for _, model := range models {
+		if id, _ := model["id"].(string); h.APIKeyAllowsModel(c, id) {
+			scoped = append(scoped, model)
+		}
+	}
+	models = scoped
 	firstID := ""
 	lastID := ""
 	if len(models) > 0 {
@@ -178,32 +204,38 @@ func (h *ClaudeCodeAPIHandler) handleNonStreamingResponse(c *gin.Context, rawJSO
 		return
 	}
 
-	// Decompress gzipped responses - Claude API sometimes returns gzip without Content-Encoding header
-	// This fixes title generation and other non-streaming responses that arrive compressed
-	if len(resp) >= 2 && resp[0] == 0x1f && resp[1] == 0x8b {
-		gzReader, errGzip := gzip.NewReader(bytes.NewReader(resp))
-		if errGzip != nil {
-			log.Warnf("failed to decompress gzipped Claude response: %v", errGzip)
-		} else {
-			defer func() {
-				if errClose := gzReader.Close(); errClose != nil {
-					log.Warnf("failed to close Claude gzip reader: %v", errClose)
-				}
-			}()
-			decompressed, errRead := io.ReadAll(gzReader)
-			if errRead != nil {
-				log.Warnf("failed to read decompressed Claude response: %v", errRead)
-			} else {
-				resp = decompressed
-			}
-		}
-	}
+	resp = decompressClaudeResponse(resp)
 
 	handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
 	_, _ = c.Writer.Write(resp)
 	cliCancel()
 }
 
+// decompressClaudeResponse decompresses gzipped responses - Claude API sometimes returns gzip
+// without Content-Encoding header. This fixes title generation and other non-streaming responses
+// that arrive compressed.
+func decompressClaudeResponse(resp []byte) []byte {
+	if len(resp) < 2 || resp[0] != 0x1f || resp[1] != 0x8b {
+		return resp
+	}
+	gzReader, errGzip := gzip.NewReader(bytes.NewReader(resp))
+	if errGzip != nil {
+		log.Warnf("failed to decompress gzipped Claude response: %v", errGzip)
+		return resp
+	}
+	defer func() {
+		if errClose := gzReader.Close(); errClose != nil {
+			log.Warnf("failed to close Claude gzip reader: %v", errClose)
+		}
+	}()
+	decompressed, errRead := io.ReadAll(gzReader)
+	if errRead != nil {
+		log.Warnf("failed to read decompressed Claude response: %v", errRead)
+		return resp
+	}
+	return decompressed
+}
+
 // handleStreamingResponse streams Claude-compatible responses backed by Gemini.
 // It sets up SSE, selects a backend client with rotation/quota logic,
 // forwards chunks, and translates them to Claude CLI format.
@@ -366,7 +398,7 @@ func (h *ClaudeCodeAPIHandler) toClaudeError(msg *interfaces.ErrorMessage) claud
 			}
 		}
 	}
-	errType, message := claudeErrorDetailFromText(status, errText)
+	errType, message := handlers.AnthropicErrorDetail(status, errText)
 	detail := claudeErrorDetail{Type: errType, Message: message}
 	if msg != nil && msg.Error != nil {
 		var structured interface{ APIErrorBody() []byte }
@@ -436,65 +468,6 @@ func (h *ClaudeCodeAPIHandler) WriteErrorResponse(c *gin.Context, msg *interface
 	_, _ = c.Writer.Write(body)
 }
 
-func claudeErrorDetailFromText(status int, errText string) (string, string) {
-	message := strings.TrimSpace(errText)
-	if message == "" {
-		message = http.StatusText(status)
-	}
-	errType := claudeErrorTypeFromStatus(status)
-
-	var payload map[string]any
-	if json.Valid([]byte(message)) {
-		if err := json.Unmarshal([]byte(message), &payload); err == nil {
-			if e, ok := payload["error"].(map[string]any); ok {
-				if t, ok := e["type"].(string); ok && strings.TrimSpace(t) != "" {
-					errType = strings.TrimSpace(t)
-				}
-				if m, ok := e["message"].(string); ok && strings.TrimSpace(m) != "" {
-					message = strings.TrimSpace(m)
-				} else if c, ok := e["code"].(string); ok && strings.TrimSpace(c) != "" {
-					message = strings.TrimSpace(c)
-				}
-			} else {
-				if t, ok := payload["type"].(string); ok && strings.TrimSpace(t) != "" && strings.TrimSpace(t) != "error" {
-					errType = strings.TrimSpace(t)
-				}
-				if m, ok := payload["message"].(string); ok && strings.TrimSpace(m) != "" {
-					message = strings.TrimSpace(m)
-				}
-			}
-		}
-	}
-
-	return errType, message
-}
-
-func claudeErrorTypeFromStatus(status int) string {
-	switch status {
-	case http.StatusUnauthorized:
-		return "authentication_error"
-	case http.StatusPaymentRequired:
-		return "billing_error"
-	case http.StatusForbidden:
-		return "permission_error"
-	case http.StatusNotFound:
-		return "not_found_error"
-	case http.StatusRequestEntityTooLarge:
-		return "request_too_large"
-	case http.StatusTooManyRequests:
-		return "rate_limit_error"
-	case http.StatusGatewayTimeout:
-		return "timeout_error"
-	case 529:
-		return "overloaded_error"
-	default:
-		if status >= http.StatusInternalServerError {
-			return "api_error"
-		}
-		return "invalid_request_error"
-	}
-}
-
 func appendClaudeAPIResponse(c *gin.Context, data []byte) {
 	if c == nil || len(data) == 0 {
 		return
@@ -4,11 +4,13 @@ import (
 	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
 	"github.com/tidwall/gjson"
 )
 
@@ -147,3 +149,38 @@ func TestPendingClaudeStreamErrorUsesBufferedError(t *testing.T) {
 		t.Fatalf("pending error = %p, want %p", gotErr, wantErr)
 	}
 }
+
+func TestForwardClaudeStreamWritesAnthropicErrorEvent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/messages", nil)
+	handler := &ClaudeCodeAPIHandler{BaseAPIHandler: &handlers.BaseAPIHandler{}}
+
+	data := make(chan []byte, 1)
+	errs := make(chan *interfaces.ErrorMessage, 1)
+	data <- []byte("event: message_start\ndata: {\"type\":\"message_start\"}\n\n")
+	close(data)
+	errs <- &interfaces.ErrorMessage{
+		StatusCode: http.StatusBadGateway,
+		Error:      errors.New(`{"error":{"message":"stream reset","type":"server_error"}}`),
+	}
+
+	handler.forwardClaudeStream(c, recorder, func(error) {}, data, errs)
+
+	body := recorder.Body.String()
+	idx := strings.Index(body, "event: error\ndata: ")
+	if idx < 0 {
+		t.Fatalf("missing error event; body=%s", body)
+	}
+	payload := strings.TrimSpace(strings.TrimPrefix(body[idx:], "event: error\ndata: "))
+	for path, want := range map[string]string{
+		"type":          "error",
+		"error.type":    "api_error",
+		"error.message": "stream reset",
+	} {
+		if got := gjson.Get(payload, path).String(); got != want {
+			t.Fatalf("%s = %q, want %q; payload=%s", path, got, want, payload)
+		}
+	}
+}
@@ -0,0 +1,550 @@
+package claude
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	defaultMessageBatchesDir         = "./batches"
+	defaultMessageBatchesParallelism = 4
+	defaultMessageBatchesRetention   = 29 * 24 * time.Hour
+	// messageBatchDeadline is how long a batch may process; unfinished requests then expire.
+	messageBatchDeadline = 24 * time.Hour
+	// maxMessageBatchRequests and maxBatchCustomIDLength mirror the upstream limits.
+	maxMessageBatchRequests = 100000
+	maxBatchCustomIDLength  = 64
+
+	batchStateFile   = "batch.json"
+	batchResultsFile = "results.jsonl"
+)
+
+// Message batch processing statuses.
+const (
+	batchStatusInProgress = "in_progress"
+	batchStatusCanceling  = "canceling"
+	batchStatusEnded      = "ended"
+)
+
+// messageBatchRequestCounts tallies the requests of a batch by state.
+type messageBatchRequestCounts struct {
+	Processing int `json:"processing"`
+	Succeeded  int `json:"succeeded"`
+	Errored    int `json:"errored"`
+	Canceled   int `json:"canceled"`
+	Expired    int `json:"expired"`
+}
+
+// messageBatch mirrors the upstream MessageBatch object.
+type messageBatch struct {
+	ID                string                    `json:"id"`
+	Type              string                    `json:"type"`
+	ProcessingStatus  string                    `json:"processing_status"`
+	RequestCounts     messageBatchRequestCounts `json:"request_counts"`
+	EndedAt           *time.Time                `json:"ended_at"`
+	CreatedAt         time.Time                 `json:"created_at"`
+	ExpiresAt         time.Time                 `json:"expires_at"`
+	ArchivedAt        *time.Time                `json:"archived_at"`
+	CancelInitiatedAt *time.Time                `json:"cancel_initiated_at"`
+	ResultsURL        *string                   `json:"results_url"`
+}
+
+// messageBatchRecord is the persisted state of one batch. Owner is the SHA-256 of the client
+// API key that created it, so batches stay private to that key.
+type messageBatchRecord struct {
+	Batch messageBatch `json:"batch"`
+	Owner string       `json:"owner"`
+}
+
+type messageBatchRequest struct {
+	CustomID string          `json:"custom_id"`
+	Params   json.RawMessage `json:"params"`
+}
+
+// messageBatchStore keeps the batches of one directory. State changes are written through to
+// <dir>/<id>/batch.json and results are appended to <dir>/<id>/results.jsonl.
+type messageBatchStore struct {
+	mu      sync.Mutex
+	dir     string
+	batches map[string]*messageBatchRecord
+	cancels map[string]context.CancelFunc
+}
+
+var messageBatches = &messageBatchStore{}
+
+// messageBatchSettings returns the message-batches config with defaults applied, and whether it
+// is enabled.
+func (h *ClaudeCodeAPIHandler) messageBatchSettings() (sdkconfig.MessageBatchesConfig, bool) {
+	cfg := h.CurrentConfig()
+	if cfg == nil || !cfg.MessageBatches.Enabled {
+		return sdkconfig.MessageBatchesConfig{}, false
+	}
+	settings := cfg.MessageBatches
+	if strings.TrimSpace(settings.Dir) == "" {
+		settings.Dir = defaultMessageBatchesDir
+	}
+	if settings.Parallelism <= 0 {
+		settings.Parallelism = defaultMessageBatchesParallelism
+	}
+	return settings, true
+}
+
+func messageBatchRetention(settings sdkconfig.MessageBatchesConfig) time.Duration {
+	if settings.RetentionHours > 0 {
+		return time.Duration(settings.RetentionHours) * time.Hour
+	}
+	return defaultMessageBatchesRetention
+}
+
+// CreateMessageBatch handles POST /v1/messages/batches.
+func (h *ClaudeCodeAPIHandler) CreateMessageBatch(c *gin.Context) {
+	settings, ok := h.messageBatchSettings()
+	if !ok {
+		h.writeBatchError(c, http.StatusNotFound, "message batches are not enabled")
+		return
+	}
+	rawJSON, err := c.GetRawData()
+	if err != nil {
+		h.writeBatchError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err))
+		return
+	}
+	var body struct {
+		Requests []messageBatchRequest `json:"requests"`
+	}
+	if errUnmarshal := json.Unmarshal(rawJSON, &body); errUnmarshal != nil {
+		h.writeBatchError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", errUnmarshal))
+		return
+	}
+	if errValidate := validateMessageBatchRequests(body.Requests); errValidate != nil {
+		h.writeBatchError(c, http.StatusBadRequest, errValidate.Error())
+		return
+	}
+
+	now := time.Now().UTC()
+	record := &messageBatchRecord{
+		Batch: messageBatch{
+			ID:               newMessageBatchID(),
+			Type:             "message_batch",
+			ProcessingStatus: batchStatusInProgress,
+			RequestCounts:    messageBatchRequestCounts{Processing: len(body.Requests)},
+			CreatedAt:        now,
+			ExpiresAt:        now.Add(messageBatchDeadline),
+		},
+		Owner: batchOwner(c),
+	}
+	ctx, cancel := context.WithDeadline(context.Background(), record.Batch.ExpiresAt)
+	if errCreate := messageBatches.create(settings, record, cancel); errCreate != nil {
+		cancel()
+		log.Errorf("message batches: %v", errCreate)
+		h.writeBatchError(c, http.StatusInternalServerError, "failed to store the batch")
+		return
+	}
+
+	// The batch outlives this request: run it on a copy of the gin context whose request
+	// context is never canceled, and bound it by the batch deadline instead.
+	background := c.Copy()
+	background.Request = c.Request.Clone(context.WithoutCancel(c.Request.Context()))
+	go h.runMessageBatch(ctx, cancel, background, settings.Parallelism, record.Batch.ID, body.Requests)
+
+	c.JSON(http.StatusOK, presentMessageBatch(c, record.Batch))
+}
+
+// GetMessageBatch handles GET /v1/messages/batches/:id.
+func (h *ClaudeCodeAPIHandler) GetMessageBatch(c *gin.Context) {
+	record, ok := h.lookupMessageBatch(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, presentMessageBatch(c, record.Batch))
+}
+
+// CancelMessageBatch handles POST /v1/messages/batches/:id/cancel. Requests not yet finished are
+// reported as canceled.
+func (h *ClaudeCodeAPIHandler) CancelMessageBatch(c *gin.Context) {
+	record, ok := h.lookupMessageBatch(c)
+	if !ok {
+		return
+	}
+	batch := messageBatches.cancel(record.Batch.ID)
+	c.JSON(http.StatusOK, presentMessageBatch(c, batch))
+}
+
+// MessageBatchResults handles GET /v1/messages/batches/:id/results, streaming the JSONL results
+// of an ended batch.
+func (h *ClaudeCodeAPIHandler) MessageBatchResults(c *gin.Context) {
+	record, ok := h.lookupMessageBatch(c)
+	if !ok {
+		return
+	}
+	if record.Batch.ProcessingStatus != batchStatusEnded {
+		h.writeBatchError(c, http.StatusBadRequest, fmt.Sprintf("batch %s has not finished processing", record.Batch.ID))
+		return
+	}
+	file, errOpen := os.Open(messageBatches.path(record.Batch.ID, batchResultsFile))
+	if errOpen != nil && !errors.Is(errOpen, os.ErrNotExist) {
+		log.Errorf("message batches: open results of %s: %v", record.Batch.ID, errOpen)
+		h.writeBatchError(c, http.StatusInternalServerError, "failed to read the batch results")
+		return
+	}
+	c.Header("Content-Type", "application/x-jsonl")
+	c.Status(http.StatusOK)
+	if file == nil {
+		return
+	}
+	defer func() {
+		if errClose := file.Close(); errClose != nil {
+			log.Warnf("message batches: close results of %s: %v", record.Batch.ID, errClose)
+		}
+	}()
+	_, _ = bufio.NewReader(file).WriteTo(c.Writer)
+}
+
+// lookupMessageBatch resolves the :id batch of the calling client key, writing a not found
+// error otherwise.
+func (h *ClaudeCodeAPIHandler) lookupMessageBatch(c *gin.Context) (messageBatchRecord, bool) {
+	settings, ok := h.messageBatchSettings()
+	if !ok {
+		h.writeBatchError(c, http.StatusNotFound, "message batches are not enabled")
+		return messageBatchRecord{}, false
+	}
+	id := c.Param("id")
+	record, found := messageBatches.get(settings, id)
+	if !found || record.Owner != batchOwner(c) {
+		h.writeBatchError(c, http.StatusNotFound, fmt.Sprintf("batch %s not found", id))
+		return messageBatchRecord{}, false
+	}
+	return record, true
+}
+
+func (h *ClaudeCodeAPIHandler) writeBatchError(c *gin.Context, status int, message string) {
+	h.WriteErrorResponse(c, &interfaces.ErrorMessage{StatusCode: status, Error: errors.New(message)})
+}
+
+// runMessageBatch executes the requests of a batch with the given parallelism and records each
+// outcome. Requests left when ctx ends are canceled or, past the deadline, expired.
+func (h *ClaudeCodeAPIHandler) runMessageBatch(ctx context.Context, cancel context.CancelFunc, c *gin.Context, parallelism int, id string, requests []messageBatchRequest) {
+	defer cancel()
+	jobs := make(chan messageBatchRequest)
+	var wg sync.WaitGroup
+	for range min(parallelism, len(requests)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for request := range jobs {
+				messageBatches.record(id, request.CustomID, h.executeBatchRequest(ctx, c.Copy(), request.Params))
+			}
+		}()
+	}
+	for _, request := range requests {
+		if ctx.Err() != nil {
+			messageBatches.record(id, request.CustomID, batchInterruptedResult(ctx))
+			continue
+		}
+		jobs <- request
+	}
+	close(jobs)
+	wg.Wait()
+	messageBatches.finish(id)
+}
+
+// executeBatchRequest runs one batched request and returns its upstream result object.
+func (h *ClaudeCodeAPIHandler) executeBatchRequest(ctx context.Context, c *gin.Context, params []byte) []byte {
+	if ctx.Err() != nil {
+		return batchInterruptedResult(ctx)
+	}
+	params, _ = sjson.DeleteBytes(params, "stream")
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, ctx)
+	defer cliCancel()
+	resp, _, errMsg := h.ExecuteWithAuthManager(cliCtx, h.HandlerType(), gjson.GetBytes(params, "model").String(), params, "")
+	if errMsg != nil {
+		if ctx.Err() != nil {
+			return batchInterruptedResult(ctx)
+		}
+		errorBody, errMarshal := json.Marshal(h.toClaudeError(errMsg))
+		if errMarshal != nil {
+			errorBody = []byte(`{"type":"error","error":{"type":"api_error","message":"Internal Server Error"}}`)
+		}
+		result, _ := sjson.SetRawBytes([]byte(`{"type":"errored"}`), "error", errorBody)
+		return result
+	}
+	resp = decompressClaudeResponse(resp)
+	if !json.Valid(resp) {
+		return []byte(`{"type":"errored","error":{"type":"error","error":{"type":"api_error","message":"upstream returned an invalid message"}}}`)
+	}
+	result, _ := sjson.SetRawBytes([]byte(`{"type":"succeeded"}`), "message", resp)
+	return result
+}
+
+func batchInterruptedResult(ctx context.Context) []byte {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return []byte(`{"type":"expired"}`)
+	}
+	return []byte(`{"type":"canceled"}`)
+}
+
+func validateMessageBatchRequests(requests []messageBatchRequest) error {
+	if len(requests) == 0 {
+		return errors.New("requests: at least one request is required")
+	}
+	if len(requests) > maxMessageBatchRequests {
+		return fmt.Errorf("requests: at most %d requests are allowed", maxMessageBatchRequests)
+	}
+	seen := make(map[string]struct{}, len(requests))
+	for i, request := range requests {
+		if request.CustomID == "" || len(request.CustomID) > maxBatchCustomIDLength {
+			return fmt.Errorf("requests.%d.custom_id: must be 1 to %d characters", i, maxBatchCustomIDLength)
+		}
+		if _, duplicate := seen[request.CustomID]; duplicate {
+			return fmt.Errorf("requests.%d.custom_id: %q is not unique", i, request.CustomID)
+		}
+		seen[request.CustomID] = struct{}{}
+		params := gjson.ParseBytes(request.Params)
+		if !params.IsObject() {
+			return fmt.Errorf("requests.%d.params: must be an object", i)
+		}
+		if strings.TrimSpace(params.Get("model").String()) == "" {
+			return fmt.Errorf("requests.%d.params.model: field required", i)
+		}
+	}
+	return nil
+}
+
+// presentMessageBatch returns batch with results_url set once results can be downloaded.
+func presentMessageBatch(c *gin.Context, batch messageBatch) messageBatch {
+	if batch.ProcessingStatus != batchStatusEnded {
+		return batch
+	}
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if forwarded := strings.TrimSpace(strings.Split(c.GetHeader("X-Forwarded-Proto"), ",")[0]); forwarded != "" {
+		scheme = forwarded
+	}
+	url := scheme + "://" + c.Request.Host + "/v1/messages/batches/" + batch.ID + "/results"
+	batch.ResultsURL = &url
+	return batch
+}
+
+func batchOwner(c *gin.Context) string {
+	sum := sha256.Sum256([]byte(c.GetString("userApiKey")))
+	return hex.EncodeToString(sum[:])
+}
+
+func newMessageBatchID() string {
+	var b [12]byte
+	_, _ = rand.Read(b[:])
+	return "msgbatch_" + hex.EncodeToString(b[:])
+}
+
+// use switches the store to dir, loading the batches kept there and dropping expired ones.
+// Batches interrupted by a restart are ended with their unfinished requests expired. Callers
+// hold s.mu.
+func (s *messageBatchStore) use(settings sdkconfig.MessageBatchesConfig) {
+	dir := filepath.Clean(settings.Dir)
+	if s.batches != nil && s.dir == dir {
+		return
+	}
+	for _, cancel := range s.cancels {
+		cancel()
+	}
+	s.dir = dir
+	s.batches = make(map[string]*messageBatchRecord)
+	s.cancels = make(map[string]context.CancelFunc)
+	entries, errRead := os.ReadDir(dir)
+	if errRead != nil {
+		if !errors.Is(errRead, os.ErrNotExist) {
+			log.Warnf("message batches: read %s: %v", dir, errRead)
+		}
+		return
+	}
+	cutoff := time.Now().Add(-messageBatchRetention(settings))
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		data, errState := os.ReadFile(filepath.Join(dir, entry.Name(), batchStateFile))
+		if errState != nil {
+			continue
+		}
+		var record messageBatchRecord
+		if errUnmarshal := json.Unmarshal(data, &record); errUnmarshal != nil || record.Batch.ID != entry.Name() {
+			continue
+		}
+		if record.Batch.ProcessingStatus != batchStatusEnded {
+			record.Batch.RequestCounts.Expired += record.Batch.RequestCounts.Processing
+			record.Batch.RequestCounts.Processing = 0
+			record.Batch.ProcessingStatus = batchStatusEnded
+			endedAt := time.Now().UTC()
+			record.Batch.EndedAt = &endedAt
+			s.save(&record)
+		}
+		if record.Batch.EndedAt != nil && record.Batch.EndedAt.Before(cutoff) {
+			if errRemove := os.RemoveAll(filepath.Join(dir, entry.Name())); errRemove != nil {
+				log.Warnf("message batches: remove expired batch %s: %v", entry.Name(), errRemove)
+			}
+			continue
+		}
+		s.batches[record.Batch.ID] = &record
+	}
+}
+
+func (s *messageBatchStore) create(settings sdkconfig.MessageBatchesConfig, record *messageBatchRecord, cancel context.CancelFunc) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.use(settings)
+	s.prune(time.Now().Add(-messageBatchRetention(settings)))
+	if errMkdir := os.MkdirAll(filepath.Join(s.dir, record.Batch.ID), 0o700); errMkdir != nil {
+		return errMkdir
+	}
+	if errSave := s.save(record); errSave != nil {
+		return errSave
+	}
+	s.batches[record.Batch.ID] = record
+	s.cancels[record.Batch.ID] = cancel
+	return nil
+}
+
+func (s *messageBatchStore) get(settings sdkconfig.MessageBatchesConfig, id string) (messageBatchRecord, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.use(settings)
+	record, ok := s.batches[id]
+	if !ok {
+		return messageBatchRecord{}, false
+	}
+	return *record, true
+}
+
+func (s *messageBatchStore) path(id, name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return filepath.Join(s.dir, id, name)
+}
+
+// cancel asks a running batch to stop and returns its state.
+func (s *messageBatchStore) cancel(id string) messageBatch {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.batches[id]
+	if !ok {
+		return messageBatch{}
+	}
+	if record.Batch.ProcessingStatus == batchStatusInProgress {
+		record.Batch.ProcessingStatus = batchStatusCanceling
+		now := time.Now().UTC()
+		record.Batch.CancelInitiatedAt = &now
+		s.save(record)
+		if cancel := s.cancels[id]; cancel != nil {
+			cancel()
+		}
+	}
+	return record.Batch
+}
+
+// record appends the result of one request and updates the counts.
+func (s *messageBatchStore) record(id, customID string, result []byte) {
+	line, _ := sjson.SetBytes([]byte(`{}`), "custom_id", customID)
+	line, _ = sjson.SetRawBytes(line, "result", result)
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	record, ok := s.batches[id]
+	if !ok {
+		return
+	}
+	if errAppend := appendFile(filepath.Join(s.dir, id, batchResultsFile), line); errAppend != nil {
+		log.Errorf("message batches: write result of %s/%s: %v", id, customID, errAppend)
+	}
+	counts := &record.Batch.RequestCounts
+	counts.Processing--
+	switch gjson.GetBytes(result, "type").String() {
+	case "succeeded":
+		counts.Succeeded++
+	case "errored":
+		counts.Errored++
+	case "expired":
+		counts.Expired++
+	default:
+		counts.Canceled++
+	}
+}
+
+// finish marks a batch ended once every request has a result.
+func (s *messageBatchStore) finish(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.cancels, id)
+	record, ok := s.batches[id]
+	if !ok {
+		return
+	}
+	record.Batch.ProcessingStatus = batchStatusEnded
+	now := time.Now().UTC()
+	record.Batch.EndedAt = &now
+	s.save(record)
+}
+
+// prune drops batches that ended before cutoff. Callers hold s.mu.
+func (s *messageBatchStore) prune(cutoff time.Time) {
+	for id, record := range s.batches {
+		if record.Batch.EndedAt == nil || !record.Batch.EndedAt.Before(cutoff) {
+			continue
+		}
+		delete(s.batches, id)
+		if errRemove := os.RemoveAll(filepath.Join(s.dir, id)); errRemove != nil {
+			log.Warnf("message batches: remove expired batch %s: %v", id, errRemove)
+		}
+	}
+}
+
+// save writes the state of record atomically. Callers hold s.mu.
+func (s *messageBatchStore) save(record *messageBatchRecord) error {
+	data, errMarshal := json.Marshal(record)
+	if errMarshal != nil {
+		return errMarshal
+	}
+	path := filepath.Join(s.dir, record.Batch.ID, batchStateFile)
+	tmp := path + ".tmp"
+	if errWrite := os.WriteFile(tmp, data, 0o600); errWrite != nil {
+		log.Errorf("message batches: save %s: %v", record.Batch.ID, errWrite)
+		return errWrite
+	}
+	if errRename := os.Rename(tmp, path); errRename != nil {
+		log.Errorf("message batches: save %s: %v", record.Batch.ID, errRename)
+		return errRename
+	}
+	return nil
+}
+
+func appendFile(path string, data []byte) error {
+	file, errOpen := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if errOpen != nil {
+		return errOpen
+	}
+	if _, errWrite := file.Write(data); errWrite != nil {
+		_ = file.Close()
+		return errWrite
+	}
+	return file.Close()
+}
@@ -0,0 +1,94 @@
+package claude
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestValidateMessageBatchRequests(t *testing.T) {
+	params := json.RawMessage(`{"model":"claude-sonnet-4-5","max_tokens":16,"messages":[]}`)
+	valid := []messageBatchRequest{{CustomID: "a", Params: params}, {CustomID: "b", Params: params}}
+	if err := validateMessageBatchRequests(valid); err != nil {
+		t.Fatalf("validateMessageBatchRequests(valid) = %v", err)
+	}
+	for name, requests := range map[string][]messageBatchRequest{
+		"empty":        nil,
+		"duplicate id": {{CustomID: "a", Params: params}, {CustomID: "a", Params: params}},
+		"long id":      {{CustomID: strings.Repeat("x", maxBatchCustomIDLength+1), Params: params}},
+		"no model":     {{CustomID: "a", Params: json.RawMessage(`{"messages":[]}`)}},
+		"not object":   {{CustomID: "a", Params: json.RawMessage(`[]`)}},
+	} {
+		if err := validateMessageBatchRequests(requests); err == nil {
+			t.Fatalf("validateMessageBatchRequests(%s) accepted", name)
+		}
+	}
+}
+
+func TestMessageBatchStoreLifecycle(t *testing.T) {
+	settings := sdkconfig.MessageBatchesConfig{Enabled: true, Dir: t.TempDir()}
+	store := &messageBatchStore{}
+	record := &messageBatchRecord{Batch: messageBatch{ID: "msgbatch_test", ProcessingStatus: batchStatusInProgress, RequestCounts: messageBatchRequestCounts{Processing: 3}}}
+	if err := store.create(settings, record, func() {}); err != nil {
+		t.Fatalf("create() error = %v", err)
+	}
+	store.record(record.Batch.ID, "a", []byte(`{"type":"succeeded","message":{"id":"msg_1"}}`))
+	store.record(record.Batch.ID, "b", []byte(`{"type":"errored","error":{"type":"error","error":{"type":"api_error","message":"boom"}}}`))
+
+	// A restart while a request is still processing ends the batch with it expired.
+	reloaded := &messageBatchStore{}
+	got, ok := reloaded.get(settings, record.Batch.ID)
+	if !ok {
+		t.Fatal("batch not reloaded from disk")
+	}
+	counts := got.Batch.RequestCounts
+	if got.Batch.ProcessingStatus != batchStatusEnded || got.Batch.EndedAt == nil || counts.Succeeded != 1 || counts.Errored != 1 || counts.Expired != 1 || counts.Processing != 0 {
+		t.Fatalf("reloaded batch = %+v, want ended with 1 succeeded, 1 errored, 1 expired", got.Batch)
+	}
+
+	results, err := os.ReadFile(filepath.Join(settings.Dir, record.Batch.ID, batchResultsFile))
+	if err != nil {
+		t.Fatalf("read results: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(results)), "\n")
+	if len(lines) != 2 || gjson.Get(lines[0], "custom_id").String() != "a" || gjson.Get(lines[0], "result.message.id").String() != "msg_1" {
+		t.Fatalf("results = %s", results)
+	}
+}
+
+func TestBatchInterruptedResult(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if got := string(batchInterruptedResult(ctx)); got != `{"type":"canceled"}` {
+		t.Fatalf("canceled result = %s", got)
+	}
+	ctx, cancel = context.WithTimeout(context.Background(), 0)
+	defer cancel()
+	if got := string(batchInterruptedResult(ctx)); got != `{"type":"expired"}` {
+		t.Fatalf("expired result = %s", got)
+	}
+}
+
+func TestMessageBatchesDisabled(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/messages/batches", strings.NewReader(`{"requests":[]}`))
+	h := NewClaudeCodeAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, nil))
+
+	h.CreateMessageBatch(c)
+
+	if recorder.Code != http.StatusNotFound || gjson.GetBytes(recorder.Body.Bytes(), "error.type").String() != "not_found_error" {
+		t.Fatalf("status = %d, body = %s; want a not_found_error", recorder.Code, recorder.Body.String())
+	}
+}
@@ -0,0 +1,279 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	internalcache "github.com/router-for-me/CLIProxyAPI/v7/internal/cache"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// CompressedMessagesHeader reports how many earlier messages were replaced by a summary.
+const CompressedMessagesHeader = "X-CPA-Compressed-Messages"
+
+// Conversation formats understood by CompressConversation.
+const (
+	CompressionFormatOpenAI = "openai"
+	CompressionFormatClaude = "claude"
+)
+
+const (
+	defaultCompressionThresholdTokens = 64000
+	defaultCompressionKeepRecent      = 8
+
+	// compressionPartChars bounds how much of a single non-text part (tool calls, tool results)
+	// is shown to the summarizer.
+	compressionPartChars = 2000
+)
+
+const compressionSystemPrompt = "You maintain a running summary of the earlier part of a conversation between a user and an " +
+	"AI assistant, so the assistant can continue without the original messages. Keep the user's goals and " +
+	"constraints, decisions made, facts learned, file names, identifiers, commands, tool results that still matter " +
+	"and open tasks. Drop pleasantries and superseded details. Reply with the updated summary only."
+
+// compressionSettings returns the conversation-compression config with defaults applied, and
+// whether it is enabled.
+func compressionSettings(cfg *config.SDKConfig) (config.ConversationCompressionConfig, bool) {
+	if cfg == nil || !cfg.ConversationCompression.Enabled {
+		return config.ConversationCompressionConfig{}, false
+	}
+	settings := cfg.ConversationCompression
+	if settings.ThresholdTokens <= 0 {
+		settings.ThresholdTokens = defaultCompressionThresholdTokens
+	}
+	if settings.KeepRecent <= 0 {
+		settings.KeepRecent = defaultCompressionKeepRecent
+	}
+	return settings, true
+}
+
+func (h *BaseAPIHandler) compressionCache() internalcache.Cache[string] {
+	h.compressionOnce.Do(func() {
+		h.compressionSummary = internalcache.New[string](internalcache.Options{
+			Name:       "conversation-compression",
+			TTL:        6 * time.Hour,
+			MaxEntries: 1024,
+			Shared:     true,
+		})
+	})
+	return h.compressionSummary
+}
+
+// CompressConversation replaces the earlier turns of a long chat completions ("openai") or Claude
+// messages ("claude") request with a summary when conversation compression is enabled and the
+// history exceeds the threshold. It returns rawJSON unchanged when nothing is compressed or the
+// summarizer fails, so compression never fails a request.
+func (h *BaseAPIHandler) CompressConversation(c *gin.Context, format string, rawJSON []byte) []byte {
+	settings, enabled := compressionSettings(h.CurrentConfig())
+	if !enabled {
+		return rawJSON
+	}
+	history := gjson.GetBytes(rawJSON, "messages")
+	if len(history.Raw)/4 <= settings.ThresholdTokens {
+		return rawJSON
+	}
+	messages := history.Array()
+	boundary := compressionBoundary(format, messages, settings.KeepRecent)
+	if boundary <= 0 {
+		return rawJSON
+	}
+	model := settings.SummaryModel
+	if model == "" {
+		model = gjson.GetBytes(rawJSON, "model").String()
+	}
+
+	// Summaries are keyed by a hash chain over the compressed messages, so a later turn finds the
+	// summary of the longest already-compressed prefix and only summarizes what came after it.
+	keys := compressionPrefixKeys(model, messages[:boundary])
+	summaryCache := h.compressionCache()
+	start, summary := 0, ""
+	for i := boundary; i > 0; i-- {
+		if cached, ok := summaryCache.Get(keys[i]); ok {
+			start, summary = i, cached
+			break
+		}
+	}
+	if start < boundary {
+		ctx := context.Background()
+		if c != nil && c.Request != nil {
+			ctx = c.Request.Context()
+		}
+		updated, err := h.summarizeConversation(ctx, model, summary, messages[start:boundary])
+		if err != nil {
+			log.Warnf("conversation compression: summarizing %d messages on %s failed, sending the full history: %v", boundary-start, model, err)
+			return rawJSON
+		}
+		summary = updated
+		summaryCache.Set(keys[boundary], summary)
+	}
+
+	out, err := applyCompressionSummary(format, rawJSON, messages, boundary, summary)
+	if err != nil {
+		log.Warnf("conversation compression: rewriting request failed, sending the full history: %v", err)
+		return rawJSON
+	}
+	if c != nil {
+		c.Header(CompressedMessagesHeader, strconv.Itoa(boundary))
+	}
+	return out
+}
+
+// compressionBoundary returns the index of the first message kept verbatim: the latest plain user
+// turn that leaves at least keepRecent messages after it, so tool calls and their results are never
+// separated. It returns 0 when no such turn exists.
+func compressionBoundary(format string, messages []gjson.Result, keepRecent int) int {
+	for i := len(messages) - keepRecent; i > 0; i-- {
+		if isPlainUserTurn(format, messages[i]) {
+			return i
+		}
+	}
+	return 0
+}
+
+func isPlainUserTurn(format string, message gjson.Result) bool {
+	if message.Get("role").String() != "user" {
+		return false
+	}
+	if format != CompressionFormatClaude {
+		return true
+	}
+	plain := true
+	message.Get("content").ForEach(func(_, part gjson.Result) bool {
+		plain = part.Get("type").String() != "tool_result"
+		return plain
+	})
+	return plain
+}
+
+// compressionPrefixKeys returns keys[i] identifying messages[:i] summarized by model.
+func compressionPrefixKeys(model string, messages []gjson.Result) []string {
+	keys := make([]string, len(messages)+1)
+	sum := sha256.Sum256([]byte(model))
+	for i, message := range messages {
+		next := sha256.New()
+		next.Write(sum[:])
+		next.Write([]byte(message.Raw))
+		copy(sum[:], next.Sum(nil))
+		keys[i+1] = hex.EncodeToString(sum[:])
+	}
+	return keys
+}
+
+// summarizeConversation extends summary with messages using model.
+func (h *BaseAPIHandler) summarizeConversation(ctx context.Context, model, summary string, messages []gjson.Result) (string, error) {
+	var prompt strings.Builder
+	if summary != "" {
+		fmt.Fprintf(&prompt, "Summary so far:\n%s\n\n", summary)
+	}
+	prompt.WriteString("Messages to add to the summary:\n")
+	prompt.WriteString(compressionTranscript(messages))
+	request, err := json.Marshal(map[string]any{
+		"model": model,
+		"messages": []map[string]string{
+			{"role": "system", "content": compressionSystemPrompt},
+			{"role": "user", "content": prompt.String()},
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+	resp, _, errMsg := h.ExecuteWithAuthManager(ctx, CompressionFormatOpenAI, model, request, "")
+	if errMsg != nil {
+		if errMsg.Error != nil {
+			return "", errMsg.Error
+		}
+		return "", fmt.Errorf("summarizer returned status %d", errMsg.StatusCode)
+	}
+	updated := strings.TrimSpace(gjson.GetBytes(resp, "choices.0.message.content").String())
+	if updated == "" {
+		return "", fmt.Errorf("summarizer returned an empty summary")
+	}
+	return updated, nil
+}
+
+// compressionTranscript renders messages as "[role]" sections. Text is kept in full; other parts
+// such as tool calls and tool results are shown as truncated JSON.
+func compressionTranscript(messages []gjson.Result) string {
+	var out strings.Builder
+	for _, message := range messages {
+		role := message.Get("role").String()
+		if role == "system" || role == "developer" {
+			continue
+		}
+		fmt.Fprintf(&out, "[%s]\n", role)
+		content := message.Get("content")
+		if content.Type == gjson.String {
+			out.WriteString(content.String())
+			out.WriteString("\n")
+		} else {
+			content.ForEach(func(_, part gjson.Result) bool {
+				if part.Get("type").String() == "text" {
+					out.WriteString(part.Get("text").String())
+				} else {
+					out.WriteString(truncateCompressionPart(part.Raw))
+				}
+				out.WriteString("\n")
+				return true
+			})
+		}
+		if toolCalls := message.Get("tool_calls"); toolCalls.Exists() {
+			out.WriteString(truncateCompressionPart(toolCalls.Raw))
+			out.WriteString("\n")
+		}
+		out.WriteString("\n")
+	}
+	return out.String()
+}
+
+func truncateCompressionPart(raw string) string {
+	if len(raw) <= compressionPartChars {
+		return raw
+	}
+	return raw[:compressionPartChars] + "...[truncated]"
+}
+
+// applyCompressionSummary replaces messages[:boundary] with summary. System and developer messages
+// of chat completions are kept ahead of the summary; Claude requests get the summary appended to
+// the top-level system prompt.
+func applyCompressionSummary(format string, rawJSON []byte, messages []gjson.Result, boundary int, summary string) ([]byte, error) {
+	summaryText := "Summary of the earlier conversation, which was compressed to save context:\n" + summary
+	kept := make([]json.RawMessage, 0, len(messages)-boundary+2)
+	out := rawJSON
+	var err error
+	if format == CompressionFormatClaude {
+		switch system := gjson.GetBytes(rawJSON, "system"); {
+		case system.IsArray():
+			block, _ := json.Marshal(map[string]string{"type": "text", "text": summaryText})
+			out, err = sjson.SetRawBytes(out, "system.-1", block)
+		case system.Type == gjson.String && system.String() != "":
+			out, err = sjson.SetBytes(out, "system", system.String()+"\n\n"+summaryText)
+		default:
+			out, err = sjson.SetBytes(out, "system", summaryText)
+		}
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		for _, message := range messages[:boundary] {
+			if role := message.Get("role").String(); role == "system" || role == "developer" {
+				kept = append(kept, json.RawMessage(message.Raw))
+			}
+		}
+		summaryMessage, _ := json.Marshal(map[string]string{"role": "system", "content": summaryText})
+		kept = append(kept, summaryMessage)
+	}
+	for _, message := range messages[boundary:] {
+		kept = append(kept, json.RawMessage(message.Raw))
+	}
+	return sjson.SetBytes(out, "messages", kept)
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestCompressionBoundarySkipsToolResultTurns(t *testing.T) {
+	messages := gjson.Parse(`[
+		{"role":"user","content":"start"},
+		{"role":"assistant","content":[{"type":"tool_use","id":"t1","name":"Read","input":{}}]},
+		{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"ok"}]},
+		{"role":"assistant","content":"done"},
+		{"role":"user","content":"next"},
+		{"role":"assistant","content":"sure"}
+	]`).Array()
+
+	if got := compressionBoundary(CompressionFormatClaude, messages, 3); got != 0 {
+		t.Fatalf("claude boundary = %d, want 0 when only a tool_result turn is eligible", got)
+	}
+	if got := compressionBoundary(CompressionFormatClaude, messages, 2); got != 4 {
+		t.Fatalf("claude boundary = %d, want 4", got)
+	}
+	if got := compressionBoundary(CompressionFormatOpenAI, messages, 4); got != 2 {
+		t.Fatalf("openai boundary = %d, want 2", got)
+	}
+}
+
+func TestCompressionPrefixKeysChainMessages(t *testing.T) {
+	first := gjson.Parse(`[{"role":"user","content":"a"},{"role":"assistant","content":"b"}]`).Array()
+	grown := gjson.Parse(`[{"role":"user","content":"a"},{"role":"assistant","content":"b"},{"role":"user","content":"c"}]`).Array()
+
+	a, b := compressionPrefixKeys("m", first), compressionPrefixKeys("m", grown)
+	if a[2] != b[2] || a[1] == a[2] {
+		t.Fatalf("prefix keys not stable across growth: %v vs %v", a, b)
+	}
+	if compressionPrefixKeys("other", first)[2] == a[2] {
+		t.Fatal("prefix keys should depend on the summary model")
+	}
+}
+
+func TestApplyCompressionSummary(t *testing.T) {
+	openaiBody := []byte(`{"messages":[{"role":"system","content":"sys"},{"role":"user","content":"old"},{"role":"assistant","content":"reply"},{"role":"user","content":"new"}]}`)
+	out, err := applyCompressionSummary(CompressionFormatOpenAI, openaiBody, gjson.GetBytes(openaiBody, "messages").Array(), 3, "S")
+	if err != nil {
+		t.Fatalf("applyCompressionSummary() error = %v", err)
+	}
+	msgs := gjson.GetBytes(out, "messages").Array()
+	if len(msgs) != 3 || msgs[0].Get("content").String() != "sys" || !strings.HasSuffix(msgs[1].Get("content").String(), "\nS") || msgs[2].Get("content").String() != "new" {
+		t.Fatalf("openai messages = %s", gjson.GetBytes(out, "messages").Raw)
+	}
+
+	claudeBody := []byte(`{"system":[{"type":"text","text":"sys"}],"messages":[{"role":"user","content":"old"},{"role":"assistant","content":"reply"},{"role":"user","content":"new"}]}`)
+	out, err = applyCompressionSummary(CompressionFormatClaude, claudeBody, gjson.GetBytes(claudeBody, "messages").Array(), 2, "S")
+	if err != nil {
+		t.Fatalf("applyCompressionSummary() error = %v", err)
+	}
+	if n := len(gjson.GetBytes(out, "system").Array()); n != 2 || !strings.HasSuffix(gjson.GetBytes(out, "system.1.text").String(), "\nS") {
+		t.Fatalf("claude system = %s", gjson.GetBytes(out, "system").Raw)
+	}
+	if gjson.GetBytes(out, "messages.#").Int() != 1 || gjson.GetBytes(out, "messages.0.content").String() != "new" {
+		t.Fatalf("claude messages = %s", gjson.GetBytes(out, "messages").Raw)
+	}
+}
+
+func TestCompressConversationReusesCachedSummary(t *testing.T) {
+	h := NewBaseAPIHandlers(&sdkconfig.SDKConfig{ConversationCompression: sdkconfig.ConversationCompressionConfig{
+		Enabled:         true,
+		SummaryModel:    "summarizer",
+		ThresholdTokens: 10,
+		KeepRecent:      1,
+	}}, nil)
+	body := []byte(`{"model":"gpt-5","messages":[
+		{"role":"user","content":"` + strings.Repeat("history ", 20) + `"},
+		{"role":"assistant","content":"ok"},
+		{"role":"user","content":"latest question"}
+	]}`)
+	messages := gjson.GetBytes(body, "messages").Array()
+	h.compressionCache().Set(compressionPrefixKeys("summarizer", messages[:2])[2], "cached summary")
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	out := h.CompressConversation(c, CompressionFormatOpenAI, body)
+
+	if gjson.GetBytes(out, "messages.#").Int() != 2 || !strings.HasSuffix(gjson.GetBytes(out, "messages.0.content").String(), "cached summary") {
+		t.Fatalf("messages = %s, want cached summary and latest question", gjson.GetBytes(out, "messages").Raw)
+	}
+	if got := c.Writer.Header().Get(CompressedMessagesHeader); got != "2" {
+		t.Fatalf("%s = %q, want 2", CompressedMessagesHeader, got)
+	}
+
+	disabled := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, nil)
+	if out = disabled.CompressConversation(c, CompressionFormatOpenAI, body); string(out) != string(body) {
+		t.Fatal("compression changed the request while disabled")
+	}
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+)
+
+// ExecuteEmbeddingsWithAuthManager executes an OpenAI embeddings request via the core auth
+// manager on the providers of modelName that serve embeddings. The response is an OpenAI
+// embeddings response whatever the provider.
+func (h *BaseAPIHandler) ExecuteEmbeddingsWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte) ([]byte, http.Header, *interfaces.ErrorMessage) {
+	if errScope := h.checkAPIKeyModel(ctx, modelName); errScope != nil {
+		return nil, nil, errScope
+	}
+	profile, modelName, errProfile := h.applyRequestProfile(ctx, modelName, rawJSON)
+	if errProfile != nil {
+		return nil, nil, errProfile
+	}
+	originalRequestedModel := modelName
+	providers, normalizedModel, extraMeta, errMsg := h.providersForExecution(modelName, originalRequestedModel, false, modelRouteDecision{}, modelExecutionOptions{})
+	if errMsg != nil {
+		return nil, nil, errMsg
+	}
+	if providers, errMsg = restrictProvidersToProfile(profile, providers, normalizedModel); errMsg != nil {
+		return nil, nil, errMsg
+	}
+	reqMeta := requestExecutionMetadata(ctx)
+	if len(extraMeta) > 0 {
+		if reqMeta == nil {
+			reqMeta = make(map[string]any, len(extraMeta))
+		}
+		for k, v := range extraMeta {
+			reqMeta[k] = v
+		}
+	}
+	h.setResidencyMetadata(ctx, reqMeta)
+	reqMeta[coreexecutor.RequestedModelMetadataKey] = originalRequestedModel
+	req := coreexecutor.Request{
+		Model:   normalizedModel,
+		Payload: rawJSON,
+	}
+	opts := coreexecutor.Options{
+		OriginalRequest: rawJSON,
+		SourceFormat:    sdktranslator.FromString(handlerType),
+		Headers:         modelExecutionHeaders(ctx, nil),
+		Query:           modelExecutionQuery(ctx, nil),
+		Metadata:        reqMeta,
+	}
+	resp, err := h.AuthManager.ExecuteEmbeddings(ctx, providers, req, opts)
+	if err != nil {
+		err = enrichAuthSelectionError(err, providers, normalizedModel)
+		status := http.StatusInternalServerError
+		if se, ok := err.(interface{ StatusCode() int }); ok && se != nil {
+			if code := se.StatusCode(); code > 0 {
+				status = code
+			}
+		}
+		var addon http.Header
+		if he, ok := err.(interface{ Headers() http.Header }); ok && he != nil {
+			if hdr := he.Headers(); hdr != nil {
+				addon = hdr.Clone()
+			}
+		}
+		return nil, nil, &interfaces.ErrorMessage{StatusCode: status, Error: err, Addon: addon}
+	}
+	responseHeaders := downstreamHeadersFromExecutor(cloneHeader(resp.Headers), h.responseHeaderPolicy(opts.Metadata))
+	return resp.Payload, responseHeaders, nil
+}
@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// Error surfaces select the error envelope written to clients of an API family.
+const (
+	// ErrorSurfaceOpenAI produces {"error":{"message","type","code"}} bodies.
+	ErrorSurfaceOpenAI = "openai"
+	// ErrorSurfaceClaude produces Anthropic {"type":"error","error":{"type","message"}} bodies.
+	ErrorSurfaceClaude = "claude"
+	// ErrorSurfaceGemini produces Google {"error":{"code","message","status"}} bodies.
+	ErrorSurfaceGemini = "gemini"
+)
+
+// ErrorSurfaceForHandlerType maps a handler type (e.g. "gemini-cli") to its error surface.
+func ErrorSurfaceForHandlerType(handlerType string) string {
+	switch strings.ToLower(strings.TrimSpace(handlerType)) {
+	case "claude":
+		return ErrorSurfaceClaude
+	case "gemini", "gemini-cli":
+		return ErrorSurfaceGemini
+	default:
+		return ErrorSurfaceOpenAI
+	}
+}
+
+// anthropicErrorResponse mirrors the Anthropic Messages API error schema.
+type anthropicErrorResponse struct {
+	Type  string               `json:"type"`
+	Error anthropicErrorDetail `json:"error"`
+}
+
+type anthropicErrorDetail struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+}
+
+// googleErrorResponse mirrors the google.rpc.Status envelope used by the Gemini API.
+type googleErrorResponse struct {
+	Error googleErrorDetail `json:"error"`
+}
+
+type googleErrorDetail struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+	Status  string `json:"status"`
+}
+
+// BuildErrorResponseBodyForSurface builds an error body in the envelope expected by clients of
+// surface. Unknown surfaces fall back to the OpenAI-compatible envelope of BuildErrorResponseBody.
+// Upstream JSON errors in another API's shape are rewritten into the surface's schema so native
+// SDKs can parse them, including inside "event: error" stream frames.
+func BuildErrorResponseBodyForSurface(surface string, status int, errText string) []byte {
+	switch strings.ToLower(strings.TrimSpace(surface)) {
+	case ErrorSurfaceClaude:
+		return buildAnthropicErrorBody(status, errText)
+	case ErrorSurfaceGemini:
+		return buildGoogleErrorBody(status, errText)
+	default:
+		return BuildErrorResponseBody(status, errText)
+	}
+}
+
+func buildAnthropicErrorBody(status int, errText string) []byte {
+	if status <= 0 {
+		status = http.StatusInternalServerError
+	}
+	trimmed := strings.TrimSpace(errText)
+	if trimmed != "" && json.Valid([]byte(trimmed)) {
+		var envelope struct {
+			Type  string          `json:"type"`
+			Error json.RawMessage `json:"error"`
+		}
+		if json.Unmarshal([]byte(trimmed), &envelope) == nil && envelope.Type == "error" && len(envelope.Error) > 0 {
+			return []byte(trimmed)
+		}
+	}
+	errType, message := AnthropicErrorDetail(status, errText)
+	payload, errMarshal := json.Marshal(anthropicErrorResponse{
+		Type:  "error",
+		Error: anthropicErrorDetail{Type: errType, Message: message},
+	})
+	if errMarshal != nil {
+		return []byte(`{"type":"error","error":{"type":"api_error","message":"Internal Server Error"}}`)
+	}
+	return payload
+}
+
+func buildGoogleErrorBody(status int, errText string) []byte {
+	if status <= 0 {
+		status = http.StatusInternalServerError
+	}
+	message := strings.TrimSpace(errText)
+	if message != "" && json.Valid([]byte(message)) {
+		raw := []byte(message)
+		// Gemini streaming errors may arrive as a single-element array.
+		if first := gjson.GetBytes(raw, "0"); strings.HasPrefix(message, "[") && first.IsObject() {
+			raw = []byte(first.Raw)
+		}
+		googleErr := gjson.GetBytes(raw, "error")
+		if googleErr.Get("code").Type == gjson.Number && googleErr.Get("status").String() != "" {
+			return raw
+		}
+		if extracted := firstNonEmptyJSONString(raw, "error.message", "message", "error.code", "error"); extracted != "" {
+			message = extracted
+		}
+	}
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	payload, errMarshal := json.Marshal(googleErrorResponse{Error: googleErrorDetail{
+		Code:    status,
+		Message: message,
+		Status:  GoogleStatusFromHTTP(status),
+	}})
+	if errMarshal != nil {
+		return []byte(`{"error":{"code":500,"message":"Internal Server Error","status":"INTERNAL"}}`)
+	}
+	return payload
+}
+
+func firstNonEmptyJSONString(raw []byte, paths ...string) string {
+	for _, path := range paths {
+		if value := gjson.GetBytes(raw, path); value.Type == gjson.String && strings.TrimSpace(value.String()) != "" {
+			return strings.TrimSpace(value.String())
+		}
+	}
+	return ""
+}
+
+// GoogleStatusFromHTTP returns the canonical google.rpc.Code name for an HTTP status.
+func GoogleStatusFromHTTP(status int) string {
+	switch status {
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge:
+		return "INVALID_ARGUMENT"
+	case http.StatusUnauthorized:
+		return "UNAUTHENTICATED"
+	case http.StatusForbidden:
+		return "PERMISSION_DENIED"
+	case http.StatusNotFound:
+		return "NOT_FOUND"
+	case http.StatusConflict:
+		return "ABORTED"
+	case http.StatusPreconditionFailed:
+		return "FAILED_PRECONDITION"
+	case http.StatusTooManyRequests:
+		return "RESOURCE_EXHAUSTED"
+	case 499:
+		return "CANCELLED"
+	case http.StatusNotImplemented:
+		return "UNIMPLEMENTED"
+	case http.StatusServiceUnavailable:
+		return "UNAVAILABLE"
+	case http.StatusGatewayTimeout:
+		return "DEADLINE_EXCEEDED"
+	default:
+		if status >= http.StatusInternalServerError {
+			return "INTERNAL"
+		}
+		if status >= http.StatusBadRequest {
+			return "FAILED_PRECONDITION"
+		}
+		return "UNKNOWN"
+	}
+}
+
+// AnthropicErrorDetail derives the Anthropic error type and message for status and errText.
+// JSON error payloads (Anthropic or OpenAI shaped) contribute their own type and message.
+func AnthropicErrorDetail(status int, errText string) (string, string) {
+	message := strings.TrimSpace(errText)
+	if message == "" {
+		message = http.StatusText(status)
+	}
+	errType := anthropicErrorTypeFromStatus(status)
+
+	var payload map[string]any
+	if json.Valid([]byte(message)) {
+		if err := json.Unmarshal([]byte(message), &payload); err == nil {
+			if e, ok := payload["error"].(map[string]any); ok {
+				// OpenAI-only types such as "server_error" keep the status-derived type.
+				if t, ok := e["type"].(string); ok && anthropicErrorTypes[strings.TrimSpace(t)] {
+					errType = strings.TrimSpace(t)
+				}
+				if m, ok := e["message"].(string); ok && strings.TrimSpace(m) != "" {
+					message = strings.TrimSpace(m)
+				} else if c, ok := e["code"].(string); ok && strings.TrimSpace(c) != "" {
+					message = strings.TrimSpace(c)
+				}
+			} else {
+				if t, ok := payload["type"].(string); ok && strings.TrimSpace(t) != "" && strings.TrimSpace(t) != "error" {
+					errType = strings.TrimSpace(t)
+				}
+				if m, ok := payload["message"].(string); ok && strings.TrimSpace(m) != "" {
+					message = strings.TrimSpace(m)
+				}
+			}
+		}
+	}
+
+	return errType, message
+}
+
+// anthropicErrorTypes lists the error types defined by the Anthropic Messages API.
+var anthropicErrorTypes = map[string]bool{
+	"invalid_request_error": true,
+	"authentication_error":  true,
+	"billing_error":         true,
+	"permission_error":      true,
+	"not_found_error":       true,
+	"request_too_large":     true,
+	"rate_limit_error":      true,
+	"api_error":             true,
+	"timeout_error":         true,
+	"overloaded_error":      true,
+}
+
+func anthropicErrorTypeFromStatus(status int) string {
+	switch status {
+	case http.StatusUnauthorized:
+		return "authentication_error"
+	case http.StatusPaymentRequired:
+		return "billing_error"
+	case http.StatusForbidden:
+		return "permission_error"
+	case http.StatusNotFound:
+		return "not_found_error"
+	case http.StatusRequestEntityTooLarge:
+		return "request_too_large"
+	case http.StatusTooManyRequests:
+		return "rate_limit_error"
+	case http.StatusGatewayTimeout:
+		return "timeout_error"
+	case 529:
+		return "overloaded_error"
+	default:
+		if status >= http.StatusInternalServerError {
+			return "api_error"
+		}
+		return "invalid_request_error"
+	}
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func TestBuildErrorResponseBodyForSurfaceClaude(t *testing.T) {
+	body := BuildErrorResponseBodyForSurface(ErrorSurfaceClaude, http.StatusInternalServerError, `{"error":{"message":"upstream exploded","type":"server_error","code":"internal_server_error"}}`)
+	if got := gjson.GetBytes(body, "type").String(); got != "error" {
+		t.Fatalf("type = %q; body=%s", got, body)
+	}
+	if got := gjson.GetBytes(body, "error.type").String(); got != "api_error" {
+		t.Fatalf("error.type = %q, want api_error; body=%s", got, body)
+	}
+	if got := gjson.GetBytes(body, "error.message").String(); got != "upstream exploded" {
+		t.Fatalf("error.message = %q; body=%s", got, body)
+	}
+
+	plain := BuildErrorResponseBodyForSurface("Claude", http.StatusTooManyRequests, "slow down")
+	if gjson.GetBytes(plain, "error.type").String() != "rate_limit_error" || gjson.GetBytes(plain, "error.message").String() != "slow down" {
+		t.Fatalf("plain body = %s", plain)
+	}
+
+	native := `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`
+	if got := BuildErrorResponseBodyForSurface(ErrorSurfaceClaude, 529, native); string(got) != native {
+		t.Fatalf("native body = %s, want unchanged", got)
+	}
+}
+
+func TestBuildErrorResponseBodyForSurfaceDefaultsToOpenAI(t *testing.T) {
+	want := BuildErrorResponseBody(http.StatusUnauthorized, "bad key")
+	for _, surface := range []string{"", ErrorSurfaceOpenAI, "responses"} {
+		if got := BuildErrorResponseBodyForSurface(surface, http.StatusUnauthorized, "bad key"); !bytes.Equal(got, want) {
+			t.Fatalf("surface %q body = %s, want %s", surface, got, want)
+		}
+	}
+}
+
+func TestBuildErrorResponseBodyForSurfaceGemini(t *testing.T) {
+	body := BuildErrorResponseBodyForSurface(ErrorSurfaceGemini, http.StatusTooManyRequests, `{"error":{"message":"quota exceeded","type":"rate_limit_error"}}`)
+	if got := gjson.GetBytes(body, "error.code").Int(); got != http.StatusTooManyRequests {
+		t.Fatalf("error.code = %d; body=%s", got, body)
+	}
+	if got := gjson.GetBytes(body, "error.status").String(); got != "RESOURCE_EXHAUSTED" {
+		t.Fatalf("error.status = %q; body=%s", got, body)
+	}
+	if got := gjson.GetBytes(body, "error.message").String(); got != "quota exceeded" {
+		t.Fatalf("error.message = %q; body=%s", got, body)
+	}
+
+	native := `{"error":{"code":400,"message":"bad field","status":"INVALID_ARGUMENT"}}`
+	if got := BuildErrorResponseBodyForSurface(ErrorSurfaceGemini, http.StatusBadRequest, native); string(got) != native {
+		t.Fatalf("native body = %s, want unchanged", got)
+	}
+	if got := BuildErrorResponseBodyForSurface(ErrorSurfaceGemini, http.StatusBadRequest, "["+native+"]"); string(got) != native {
+		t.Fatalf("array body = %s, want unwrapped", got)
+	}
+
+	plain := BuildErrorResponseBodyForSurface(ErrorSurfaceGemini, http.StatusServiceUnavailable, "")
+	if gjson.GetBytes(plain, "error.status").String() != "UNAVAILABLE" || gjson.GetBytes(plain, "error.message").String() != "Service Unavailable" {
+		t.Fatalf("plain body = %s", plain)
+	}
+}
+
+func TestErrorSurfaceForHandlerType(t *testing.T) {
+	cases := map[string]string{
+		"claude":     ErrorSurfaceClaude,
+		"gemini":     ErrorSurfaceGemini,
+		"gemini-cli": ErrorSurfaceGemini,
+		"openai":     ErrorSurfaceOpenAI,
+		"":           ErrorSurfaceOpenAI,
+	}
+	for handlerType, want := range cases {
+		if got := ErrorSurfaceForHandlerType(handlerType); got != want {
+			t.Fatalf("ErrorSurfaceForHandlerType(%q) = %q, want %q", handlerType, got, want)
+		}
+	}
+}
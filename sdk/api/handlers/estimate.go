@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/runtime/executor/helps"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	"github.com/tidwall/gjson"
+)
+
+// RequestEstimate is a pre-flight preview of a request: how large its prompt is, what it could
+// cost at most and where it would be routed. Nothing is sent upstream to produce it.
+type RequestEstimate struct {
+	Model         string `json:"model"`
+	ResolvedModel string `json:"resolved_model,omitempty"`
+	PromptTokens  int64  `json:"prompt_tokens"`
+	// MaxOutputTokens is the requested output limit, or the model's limit when none was requested.
+	MaxOutputTokens int64 `json:"max_output_tokens,omitempty"`
+	ContextWindow   int64 `json:"context_window,omitempty"`
+	// FitsContext is nil when the model's context window is unknown.
+	FitsContext *bool `json:"fits_context,omitempty"`
+	// MaxCost prices PromptTokens plus MaxOutputTokens with the matching model-pricing entry;
+	// it is nil when no entry matches.
+	MaxCost        *float64 `json:"max_cost,omitempty"`
+	Provider       string   `json:"provider,omitempty"`
+	AuthID         string   `json:"auth_id,omitempty"`
+	AuthLabel      string   `json:"auth_label,omitempty"`
+	ExecutorPlugin string   `json:"executor_plugin,omitempty"`
+	SelectionError string   `json:"selection_error,omitempty"`
+}
+
+// EstimateRequest previews rawJSON without executing it. The body may be in OpenAI chat/responses,
+// Claude messages or Gemini generateContent format; modelName overrides its "model" field.
+func (h *BaseAPIHandler) EstimateRequest(ctx context.Context, handlerType, modelName string, rawJSON []byte) (*RequestEstimate, *interfaces.ErrorMessage) {
+	if strings.TrimSpace(modelName) == "" {
+		modelName = strings.TrimSpace(gjson.GetBytes(rawJSON, "model").String())
+	}
+	if modelName == "" {
+		return nil, &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: fmt.Errorf("model is required")}
+	}
+	estimate := &RequestEstimate{Model: modelName, ResolvedModel: modelName}
+
+	profile, routedModel, errProfile := h.applyRequestProfile(ctx, modelName, rawJSON)
+	if errProfile != nil {
+		return nil, errProfile
+	}
+	routeDecision := h.applyModelRouter(ctx, handlerType, routedModel, rawJSON, false, modelExecutionOptions{})
+	if routeDecision.ExecutorPluginID != "" {
+		estimate.ExecutorPlugin = routeDecision.ExecutorPluginID
+	} else {
+		providers, normalizedModel, extraMeta, errMsg := h.providersForExecution(routedModel, modelName, false, routeDecision, modelExecutionOptions{})
+		if errMsg == nil {
+			providers, errMsg = restrictProvidersToProfile(profile, providers, normalizedModel)
+		}
+		if errMsg != nil {
+			return nil, errMsg
+		}
+		providers = adjustExecutionProvidersForEntryProtocol(handlerType, providers)
+		estimate.ResolvedModel = normalizedModel
+		if h.AuthManager != nil {
+			reqMeta := requestExecutionMetadata(ctx)
+			for k, v := range extraMeta {
+				reqMeta[k] = v
+			}
+			h.setResidencyMetadata(ctx, reqMeta)
+			auth, provider, errPreview := h.AuthManager.PreviewAuth(providers, normalizedModel, coreexecutor.Options{Metadata: reqMeta})
+			if errPreview != nil {
+				estimate.SelectionError = enrichAuthSelectionError(errPreview, providers, normalizedModel).Error()
+			} else {
+				estimate.Provider = provider
+				estimate.AuthID = auth.ID
+				estimate.AuthLabel = auth.Label
+			}
+		}
+	}
+
+	promptTokens, errCount := estimatePromptTokens(estimate.ResolvedModel, rawJSON)
+	if errCount != nil {
+		return nil, &interfaces.ErrorMessage{StatusCode: http.StatusInternalServerError, Error: fmt.Errorf("failed to count tokens: %w", errCount)}
+	}
+	estimate.PromptTokens = promptTokens
+
+	requestedOutput := requestedMaxOutputTokens(rawJSON)
+	estimate.MaxOutputTokens = requestedOutput
+	if info := registry.LookupModelInfo(estimate.ResolvedModel, estimate.Provider); info != nil {
+		if estimate.MaxOutputTokens <= 0 {
+			estimate.MaxOutputTokens = int64(max(info.MaxCompletionTokens, info.OutputTokenLimit))
+		}
+		estimate.ContextWindow = int64(info.ContextLength)
+		if estimate.ContextWindow <= 0 {
+			estimate.ContextWindow = int64(info.InputTokenLimit)
+		}
+	}
+	if estimate.ContextWindow > 0 {
+		// Only an explicitly requested output budget has to fit next to the prompt.
+		fits := promptTokens+max(requestedOutput, 0) <= estimate.ContextWindow
+		estimate.FitsContext = &fits
+	}
+	if pricing, ok := h.CurrentConfig().PricingForModel(estimate.ResolvedModel); ok {
+		cost := (float64(promptTokens)*pricing.InputPerMillion + float64(estimate.MaxOutputTokens)*pricing.OutputPerMillion) / 1_000_000
+		estimate.MaxCost = &cost
+	}
+	return estimate, nil
+}
+
+// requestedMaxOutputTokens returns the output limit set in any supported request format, or 0.
+func requestedMaxOutputTokens(rawJSON []byte) int64 {
+	for _, path := range []string{"max_completion_tokens", "max_tokens", "max_output_tokens", "generationConfig.maxOutputTokens"} {
+		if value := gjson.GetBytes(rawJSON, path); value.Type == gjson.Number && value.Int() > 0 {
+			return value.Int()
+		}
+	}
+	return 0
+}
+
+// estimatePromptTokens approximates the prompt size of rawJSON with the model's tokenizer.
+func estimatePromptTokens(model string, rawJSON []byte) (int64, error) {
+	enc, errEnc := helps.GetTokenizer(model)
+	if errEnc != nil {
+		return 0, errEnc
+	}
+	root := gjson.ParseBytes(rawJSON)
+	switch {
+	case root.Get("messages").IsArray() && root.Get("system").Exists():
+		return helps.CountClaudeChatTokens(enc, rawJSON)
+	case root.Get("messages").IsArray():
+		return helps.CountOpenAIChatTokens(enc, rawJSON)
+	}
+	// Gemini contents and Responses API input: count every string in the prompt-bearing fields.
+	var segments []string
+	for _, path := range []string{"systemInstruction", "system_instruction", "contents", "instructions", "input", "prompt", "tools"} {
+		collectJSONStrings(root.Get(path), &segments)
+	}
+	text := strings.TrimSpace(strings.Join(segments, "\n"))
+	if text == "" {
+		return 0, nil
+	}
+	count, errCount := enc.Count(text)
+	return int64(count), errCount
+}
+
+func collectJSONStrings(value gjson.Result, segments *[]string) {
+	switch {
+	case value.Type == gjson.String:
+		if text := strings.TrimSpace(value.String()); text != "" {
+			*segments = append(*segments, text)
+		}
+	case value.IsArray() || value.IsObject():
+		value.ForEach(func(_, child gjson.Result) bool {
+			collectJSONStrings(child, segments)
+			return true
+		})
+	}
+}
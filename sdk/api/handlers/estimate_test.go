@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+func TestEstimatePromptTokensAcrossFormats(t *testing.T) {
+	prompt := "Summarize the plot of a long novel in three sentences."
+	bodies := map[string]string{
+		"openai":    `{"model":"gpt-4o","messages":[{"role":"user","content":"` + prompt + `"}]}`,
+		"claude":    `{"model":"claude-sonnet-4","system":"Be brief.","messages":[{"role":"user","content":[{"type":"text","text":"` + prompt + `"}]}]}`,
+		"gemini":    `{"contents":[{"role":"user","parts":[{"text":"` + prompt + `"}]}]}`,
+		"responses": `{"model":"gpt-5","instructions":"Be brief.","input":[{"role":"user","content":[{"type":"input_text","text":"` + prompt + `"}]}]}`,
+	}
+	for name, body := range bodies {
+		tokens, err := estimatePromptTokens("gpt-4o", []byte(body))
+		if err != nil {
+			t.Fatalf("%s: estimatePromptTokens() error = %v", name, err)
+		}
+		if tokens < 8 {
+			t.Fatalf("%s: tokens = %d, want the prompt to be counted", name, tokens)
+		}
+	}
+}
+
+func TestRequestedMaxOutputTokens(t *testing.T) {
+	cases := map[string]int64{
+		`{"max_completion_tokens":300,"max_tokens":100}`: 300,
+		`{"max_tokens":1024}`:                            1024,
+		`{"max_output_tokens":2048}`:                     2048,
+		`{"generationConfig":{"maxOutputTokens":512}}`:   512,
+		`{"messages":[]}`:                                0,
+	}
+	for body, want := range cases {
+		if got := requestedMaxOutputTokens([]byte(body)); got != want {
+			t.Fatalf("requestedMaxOutputTokens(%s) = %d, want %d", body, got, want)
+		}
+	}
+}
+
+func TestPricingForModel(t *testing.T) {
+	cfg := &config.SDKConfig{ModelPricing: []config.ModelPricing{
+		{Model: "claude-opus-*", InputPerMillion: 15, OutputPerMillion: 75},
+		{Model: "gpt-5", InputPerMillion: 1.25, OutputPerMillion: 10},
+	}}
+	if pricing, ok := cfg.PricingForModel("Claude-Opus-4-1"); !ok || pricing.OutputPerMillion != 75 {
+		t.Fatalf("PricingForModel(claude-opus) = %+v, %v", pricing, ok)
+	}
+	if _, ok := cfg.PricingForModel("gpt-5-mini"); ok {
+		t.Fatal("exact entry must not match a longer model name")
+	}
+	if _, ok := (*config.SDKConfig)(nil).PricingForModel("gpt-5"); ok {
+		t.Fatal("nil config must not match")
+	}
+}
@@ -78,6 +78,11 @@ func (h *GeminiCLIAPIHandler) HandlerType() string {
 	return GeminiCLI
 }
 
+// WriteErrorResponse writes msg using Google's error envelope.
+func (h *GeminiCLIAPIHandler) WriteErrorResponse(c *gin.Context, msg *interfaces.ErrorMessage) {
+	h.WriteErrorResponseForSurface(c, msg, handlers.ErrorSurfaceForHandlerType(h.HandlerType()))
+}
+
 // Models returns a list of models supported by this handler.
 func (h *GeminiCLIAPIHandler) Models() []map[string]any {
 	return make([]map[string]any, 0)
@@ -87,12 +92,7 @@ func (h *GeminiCLIAPIHandler) Models() []map[string]any {
 // It restricts access to localhost only and routes requests to appropriate internal handlers.
 func (h *GeminiCLIAPIHandler) CLIHandler(c *gin.Context) {
 	if cfg := h.CurrentConfig(); cfg == nil || !cfg.EnableGeminiCLIEndpoint {
-		c.JSON(http.StatusForbidden, handlers.ErrorResponse{
-			Error: handlers.ErrorDetail{
-				Message: "Gemini CLI endpoint is disabled",
-				Type:    "forbidden",
-			},
-		})
+		writeGeminiError(c, http.StatusForbidden, "Gemini CLI endpoint is disabled")
 		return
 	}
 
@@ -103,12 +103,7 @@ func (h *GeminiCLIAPIHandler) CLIHandler(c *gin.Context) {
 	}
 
 	if !strings.HasPrefix(c.Request.RemoteAddr, "127.0.0.1:") || requestHostname != "127.0.0.1" {
-		c.JSON(http.StatusForbidden, handlers.ErrorResponse{
-			Error: handlers.ErrorDetail{
-				Message: "CLI reply only allow local access",
-				Type:    "forbidden",
-			},
-		})
+		writeGeminiError(c, http.StatusForbidden, "CLI reply only allow local access")
 		return
 	}
 
@@ -123,12 +118,7 @@ func (h *GeminiCLIAPIHandler) CLIHandler(c *gin.Context) {
 		reqBody := bytes.NewBuffer(rawJSON)
 		req, err := http.NewRequest("POST", fmt.Sprintf("https://cloudcode-pa.googleapis.com%s", c.Request.URL.RequestURI()), reqBody)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
-				Error: handlers.ErrorDetail{
-					Message: fmt.Sprintf("Invalid request: %v", err),
-					Type:    "invalid_request_error",
-				},
-			})
+			writeGeminiError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err))
 			return
 		}
 		for key, value := range c.Request.Header {
@@ -139,12 +129,7 @@ func (h *GeminiCLIAPIHandler) CLIHandler(c *gin.Context) {
 
 		resp, err := httpClient.Do(req)
 		if err != nil {
-			c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
-				Error: handlers.ErrorDetail{
-					Message: fmt.Sprintf("Invalid request: %v", err),
-					Type:    "invalid_request_error",
-				},
-			})
+			writeGeminiError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err))
 			return
 		}
 
@@ -156,12 +141,7 @@ func (h *GeminiCLIAPIHandler) CLIHandler(c *gin.Context) {
 			}()
 			bodyBytes, _ := io.ReadAll(resp.Body)
 
-			c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
-				Error: handlers.ErrorDetail{
-					Message: string(bodyBytes),
-					Type:    "invalid_request_error",
-				},
-			})
+			writeGeminiError(c, http.StatusBadRequest, string(bodyBytes))
 			return
 		}
 
@@ -202,12 +182,7 @@ func (h *GeminiCLIAPIHandler) handleInternalStreamGenerateContent(c *gin.Context
 	// Get the http.Flusher interface to manually flush the response.
 	flusher, ok := c.Writer.(http.Flusher)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, handlers.ErrorResponse{
-			Error: handlers.ErrorDetail{
-				Message: "Streaming not supported",
-				Type:    "server_error",
-			},
-		})
+		writeGeminiError(c, http.StatusInternalServerError, "Streaming not supported")
 		return
 	}
 
@@ -268,7 +243,7 @@ func (h *GeminiCLIAPIHandler) forwardCLIStream(c *gin.Context, flusher http.Flus
 			if errMsg.Error != nil && errMsg.Error.Error() != "" {
 				errText = errMsg.Error.Error()
 			}
-			body := handlers.BuildErrorResponseBody(status, errText)
+			body := handlers.BuildErrorResponseBodyForSurface(handlers.ErrorSurfaceForHandlerType(h.HandlerType()), status, errText)
 			if alt == "" {
 				_, _ = fmt.Fprintf(c.Writer, "event: error\ndata: %s\n\n", string(body))
 			} else {
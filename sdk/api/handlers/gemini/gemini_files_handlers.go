@@ -0,0 +1,415 @@
+package gemini
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/router-for-me/CLIProxyAPI/v7/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	geminiFilesEndpoint   = "https://generativelanguage.googleapis.com"
+	geminiFilesUploadPath = "/upload/v1beta/files"
+
+	// Gemini deletes uploaded files after 48 hours; resumable upload sessions live for a week,
+	// but clients finish them long before that.
+	geminiFileBindingTTL   = 48 * time.Hour
+	geminiUploadSessionTTL = 24 * time.Hour
+
+	geminiUploadBindingPrefix = "upload:"
+)
+
+var (
+	geminiFileBindings = newGeminiFileBindingStore()
+	geminiFilesCursor  atomic.Uint64
+)
+
+// geminiFileBinding records which auth owns an uploaded file or upload session and where it
+// lives upstream. Files belong to the project of the API key they were uploaded with, so every
+// later request touching them must run on the same auth.
+type geminiFileBinding struct {
+	authID    string
+	upstream  string
+	expiresAt time.Time
+}
+
+type geminiFileBindingStore struct {
+	mu      sync.RWMutex
+	entries map[string]geminiFileBinding
+}
+
+func newGeminiFileBindingStore() *geminiFileBindingStore {
+	return &geminiFileBindingStore{
+		entries: make(map[string]geminiFileBinding),
+	}
+}
+
+func (s *geminiFileBindingStore) set(key string, authID string, upstream string, ttl time.Duration) {
+	if s == nil {
+		return
+	}
+	key = strings.TrimSpace(key)
+	authID = strings.TrimSpace(authID)
+	if key == "" || authID == "" {
+		return
+	}
+	now := time.Now()
+	s.mu.Lock()
+	s.cleanupExpiredLocked(now)
+	s.entries[key] = geminiFileBinding{
+		authID:    authID,
+		upstream:  strings.TrimSpace(upstream),
+		expiresAt: now.Add(ttl),
+	}
+	s.mu.Unlock()
+}
+
+func (s *geminiFileBindingStore) get(key string) (geminiFileBinding, bool) {
+	if s == nil {
+		return geminiFileBinding{}, false
+	}
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return geminiFileBinding{}, false
+	}
+	now := time.Now()
+	s.mu.RLock()
+	entry, ok := s.entries[key]
+	s.mu.RUnlock()
+	if !ok {
+		return geminiFileBinding{}, false
+	}
+	if now.After(entry.expiresAt) {
+		s.mu.Lock()
+		if current, exists := s.entries[key]; exists && now.After(current.expiresAt) {
+			delete(s.entries, key)
+		}
+		s.mu.Unlock()
+		return geminiFileBinding{}, false
+	}
+	return entry, true
+}
+
+func (s *geminiFileBindingStore) delete(key string) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	delete(s.entries, strings.TrimSpace(key))
+	s.mu.Unlock()
+}
+
+func (s *geminiFileBindingStore) cleanupExpiredLocked(now time.Time) {
+	for key, entry := range s.entries {
+		if now.After(entry.expiresAt) {
+			delete(s.entries, key)
+		}
+	}
+}
+
+// UploadFile handles POST /upload/v1beta/files. Simple, multipart and resumable uploads are
+// forwarded with a Gemini API key auth; resumable sessions are continued on the auth that
+// started them through the proxy upload URL returned to the client.
+func (h *GeminiAPIHandler) UploadFile(c *gin.Context) {
+	var auth *coreauth.Auth
+	var target string
+	if uploadID := strings.TrimSpace(c.Query("upload_id")); uploadID != "" {
+		binding, ok := geminiFileBindings.get(geminiUploadBindingPrefix + uploadID)
+		if !ok {
+			writeGeminiError(c, http.StatusNotFound, "Upload session not found or expired.")
+			return
+		}
+		auth = h.geminiFilesAuth(binding.authID)
+		target = binding.upstream
+	} else {
+		auth = h.geminiFilesAuth("")
+		if auth != nil {
+			target = geminiFilesBaseURL(auth) + geminiFilesUploadPath + forwardedGeminiFilesQuery(c.Request.URL)
+		}
+	}
+	if auth == nil {
+		writeGeminiError(c, http.StatusServiceUnavailable, "No Gemini API key credential is available for the Files API.")
+		return
+	}
+
+	req, errReq := http.NewRequestWithContext(c.Request.Context(), c.Request.Method, target, c.Request.Body)
+	if errReq != nil {
+		writeGeminiError(c, http.StatusInternalServerError, fmt.Sprintf("Invalid upload request: %v", errReq))
+		return
+	}
+	req.ContentLength = c.Request.ContentLength
+	for key, values := range c.Request.Header {
+		if strings.EqualFold(key, "Content-Type") || isGoogleUploadHeader(key) {
+			req.Header[key] = append([]string(nil), values...)
+		}
+	}
+
+	resp, errDo := h.AuthManager.HttpRequest(c.Request.Context(), auth, req)
+	if errDo != nil {
+		writeGeminiError(c, http.StatusBadGateway, fmt.Sprintf("Upload failed: %v", errDo))
+		return
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	proxyBase := geminiFilesProxyBaseURL(c)
+	for key, values := range resp.Header {
+		if strings.EqualFold(key, "Content-Type") || isGoogleUploadHeader(key) {
+			c.Writer.Header()[key] = append([]string(nil), values...)
+		}
+	}
+	if uploadURL := resp.Header.Get("X-Goog-Upload-URL"); uploadURL != "" {
+		if proxyURL, ok := bindGeminiUploadSession(uploadURL, auth.ID, proxyBase); ok {
+			c.Writer.Header().Set("X-Goog-Upload-URL", proxyURL)
+		} else {
+			c.Writer.Header().Del("X-Goog-Upload-URL")
+		}
+	}
+	h.writeGeminiFilesResponse(c, resp, "file", auth.ID, proxyBase)
+}
+
+// GetFile handles GET /v1beta/files/:name on the auth the file was uploaded with.
+func (h *GeminiAPIHandler) GetFile(c *gin.Context) {
+	h.forwardGeminiFileRequest(c, http.MethodGet)
+}
+
+// DeleteFile handles DELETE /v1beta/files/:name on the auth the file was uploaded with.
+func (h *GeminiAPIHandler) DeleteFile(c *gin.Context) {
+	h.forwardGeminiFileRequest(c, http.MethodDelete)
+}
+
+func (h *GeminiAPIHandler) forwardGeminiFileRequest(c *gin.Context, method string) {
+	name := "files/" + strings.TrimSpace(c.Param("name"))
+	binding, _ := geminiFileBindings.get(name)
+	auth := h.geminiFilesAuth(binding.authID)
+	if auth == nil {
+		writeGeminiError(c, http.StatusServiceUnavailable, "No Gemini API key credential is available for the Files API.")
+		return
+	}
+	target := geminiFilesBaseURL(auth) + "/v1beta/" + name + forwardedGeminiFilesQuery(c.Request.URL)
+	req, errReq := http.NewRequestWithContext(c.Request.Context(), method, target, nil)
+	if errReq != nil {
+		writeGeminiError(c, http.StatusInternalServerError, fmt.Sprintf("Invalid file request: %v", errReq))
+		return
+	}
+	resp, errDo := h.AuthManager.HttpRequest(c.Request.Context(), auth, req)
+	if errDo != nil {
+		writeGeminiError(c, http.StatusBadGateway, fmt.Sprintf("File request failed: %v", errDo))
+		return
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if method == http.MethodDelete && resp.StatusCode < http.StatusMultipleChoices {
+		geminiFileBindings.delete(name)
+	}
+	if contentType := resp.Header.Get("Content-Type"); contentType != "" {
+		c.Header("Content-Type", contentType)
+	}
+	h.writeGeminiFilesResponse(c, resp, "", auth.ID, geminiFilesProxyBaseURL(c))
+}
+
+// writeGeminiFilesResponse relays resp, binding the file object at path (the whole body when
+// path is empty) to authID and pointing its uri at the proxy.
+func (h *GeminiAPIHandler) writeGeminiFilesResponse(c *gin.Context, resp *http.Response, path string, authID string, proxyBase string) {
+	body, errRead := io.ReadAll(resp.Body)
+	if errRead != nil {
+		writeGeminiError(c, http.StatusBadGateway, fmt.Sprintf("Failed to read upstream response: %v", errRead))
+		return
+	}
+	if resp.StatusCode < http.StatusMultipleChoices {
+		body = rewriteGeminiFileObject(body, path, authID, proxyBase)
+	}
+	c.Writer.Header().Del("Content-Length")
+	c.Status(resp.StatusCode)
+	_, _ = c.Writer.Write(body)
+}
+
+// geminiFilesAuth returns the auth bound to a file when authID is set and still usable,
+// otherwise the next available Gemini API key auth. The Files API only accepts API keys.
+func (h *GeminiAPIHandler) geminiFilesAuth(authID string) *coreauth.Auth {
+	if h == nil || h.BaseAPIHandler == nil || h.AuthManager == nil {
+		return nil
+	}
+	if authID != "" {
+		if auth, ok := h.AuthManager.GetByID(authID); ok && geminiFilesAuthUsable(auth) {
+			return auth
+		}
+		return nil
+	}
+	var candidates []*coreauth.Auth
+	for _, auth := range h.AuthManager.List() {
+		if geminiFilesAuthUsable(auth) {
+			candidates = append(candidates, auth)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].ID < candidates[j].ID })
+	return candidates[(geminiFilesCursor.Add(1)-1)%uint64(len(candidates))]
+}
+
+func geminiFilesAuthUsable(auth *coreauth.Auth) bool {
+	if auth == nil || auth.Disabled || auth.Unavailable || auth.Status == coreauth.StatusDisabled {
+		return false
+	}
+	if !strings.EqualFold(strings.TrimSpace(auth.Provider), Gemini) {
+		return false
+	}
+	return auth.Attributes != nil && strings.TrimSpace(auth.Attributes["api_key"]) != ""
+}
+
+func geminiFilesBaseURL(auth *coreauth.Auth) string {
+	if auth != nil && auth.Attributes != nil {
+		if custom := strings.TrimRight(strings.TrimSpace(auth.Attributes["base_url"]), "/"); custom != "" {
+			return custom
+		}
+	}
+	return geminiFilesEndpoint
+}
+
+// geminiFilesProxyBaseURL is the scheme and host clients use to reach this proxy.
+func geminiFilesProxyBaseURL(c *gin.Context) string {
+	scheme := "http"
+	if c.Request.TLS != nil {
+		scheme = "https"
+	}
+	if forwarded := strings.TrimSpace(strings.Split(c.GetHeader("X-Forwarded-Proto"), ",")[0]); forwarded != "" {
+		scheme = forwarded
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+// forwardedGeminiFilesQuery returns the client query without the proxy API key.
+func forwardedGeminiFilesQuery(u *url.URL) string {
+	if u == nil || u.RawQuery == "" {
+		return ""
+	}
+	query := u.Query()
+	query.Del("key")
+	if len(query) == 0 {
+		return ""
+	}
+	return "?" + query.Encode()
+}
+
+func isGoogleUploadHeader(key string) bool {
+	return strings.HasPrefix(strings.ToLower(key), "x-goog-upload-")
+}
+
+// bindGeminiUploadSession remembers the upstream URL of a resumable upload and returns the proxy
+// URL the client continues the upload with.
+func bindGeminiUploadSession(uploadURL string, authID string, proxyBase string) (string, bool) {
+	parsed, errParse := url.Parse(uploadURL)
+	if errParse != nil {
+		return "", false
+	}
+	uploadID := strings.TrimSpace(parsed.Query().Get("upload_id"))
+	if uploadID == "" {
+		return "", false
+	}
+	geminiFileBindings.set(geminiUploadBindingPrefix+uploadID, authID, uploadURL, geminiUploadSessionTTL)
+	query := url.Values{"upload_id": {uploadID}}
+	if protocol := parsed.Query().Get("upload_protocol"); protocol != "" {
+		query.Set("upload_protocol", protocol)
+	}
+	return proxyBase + geminiFilesUploadPath + "?" + query.Encode(), true
+}
+
+// rewriteGeminiFileObject binds the file object at path in body to authID and replaces its
+// upstream uri with the proxy one.
+func rewriteGeminiFileObject(body []byte, path string, authID string, proxyBase string) []byte {
+	prefix := ""
+	if path != "" {
+		prefix = path + "."
+	}
+	name := strings.TrimSpace(gjson.GetBytes(body, prefix+"name").String())
+	uri := strings.TrimSpace(gjson.GetBytes(body, prefix+"uri").String())
+	if !strings.HasPrefix(name, "files/") || uri == "" {
+		return body
+	}
+	geminiFileBindings.set(name, authID, uri, geminiFileBindingTTL)
+	updated, errSet := sjson.SetBytes(body, prefix+"uri", proxyBase+"/v1beta/"+name)
+	if errSet != nil {
+		return body
+	}
+	return updated
+}
+
+// geminiFileNameFromURI extracts the files/<id> resource name from a file uri.
+func geminiFileNameFromURI(uri string) string {
+	parsed, errParse := url.Parse(strings.TrimSpace(uri))
+	if errParse != nil {
+		return ""
+	}
+	idx := strings.LastIndex(parsed.Path, "/files/")
+	if idx < 0 {
+		return ""
+	}
+	id := parsed.Path[idx+len("/files/"):]
+	if colon := strings.Index(id, ":"); colon >= 0 {
+		id = id[:colon]
+	}
+	if id == "" || strings.Contains(id, "/") {
+		return ""
+	}
+	return "files/" + id
+}
+
+// resolveGeminiFileReferences points fileData references to files uploaded through the proxy
+// back at their upstream uris and returns the auth that owns them. Files uploaded with
+// different auths cannot be used in one request.
+func resolveGeminiFileReferences(rawJSON []byte) ([]byte, string, error) {
+	authID := ""
+	out := rawJSON
+	var errResolve error
+	gjson.GetBytes(rawJSON, "contents").ForEach(func(contentKey, content gjson.Result) bool {
+		content.Get("parts").ForEach(func(partKey, part gjson.Result) bool {
+			for _, field := range [][2]string{{"fileData", "fileUri"}, {"file_data", "file_uri"}} {
+				uri := part.Get(field[0] + "." + field[1])
+				if !uri.Exists() {
+					continue
+				}
+				binding, ok := geminiFileBindings.get(geminiFileNameFromURI(uri.String()))
+				if !ok {
+					continue
+				}
+				if authID != "" && authID != binding.authID {
+					errResolve = fmt.Errorf("files referenced by this request were uploaded with different credentials")
+					return false
+				}
+				authID = binding.authID
+				path := fmt.Sprintf("contents.%d.parts.%d.%s.%s", contentKey.Int(), partKey.Int(), field[0], field[1])
+				if updated, errSet := sjson.SetBytes(out, path, binding.upstream); errSet == nil {
+					out = updated
+				}
+			}
+			return true
+		})
+		return errResolve == nil
+	})
+	if errResolve != nil {
+		return rawJSON, "", errResolve
+	}
+	return out, authID, nil
+}
+
+// contextWithGeminiFileAuth pins execution to the auth owning the files a request references.
+func contextWithGeminiFileAuth(ctx context.Context, authID string) context.Context {
+	if authID == "" {
+		return ctx
+	}
+	return handlers.WithPinnedAuthID(ctx, authID)
+}
@@ -0,0 +1,140 @@
+package gemini
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/runtime/executor"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestUploadFileResumableRoutesThroughProxy(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var server *httptest.Server
+	var gotKeys []string
+	var gotBody string
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotKeys = append(gotKeys, r.Header.Get("x-goog-api-key"))
+		if r.URL.Query().Get("key") != "" {
+			t.Errorf("client key leaked upstream: %s", r.URL.RawQuery)
+		}
+		switch r.Header.Get("X-Goog-Upload-Command") {
+		case "start":
+			w.Header().Set("X-Goog-Upload-URL", server.URL+"/upload/v1beta/files?upload_id=up-1&upload_protocol=resumable")
+			w.WriteHeader(http.StatusOK)
+		case "upload, finalize":
+			if r.URL.Query().Get("upload_id") != "up-1" {
+				t.Errorf("upload continued at %s, want the upstream session", r.URL.String())
+			}
+			body, _ := io.ReadAll(r.Body)
+			gotBody = string(body)
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"file":{"name":"files/abc","uri":"` + server.URL + `/v1beta/files/abc","state":"ACTIVE"}}`))
+		default:
+			http.Error(w, "unexpected command", http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	manager := coreauth.NewManager(nil, nil, nil)
+	manager.RegisterExecutor(executor.NewGeminiExecutor(&config.Config{}))
+	auth := &coreauth.Auth{
+		ID:         "gemini-files-auth",
+		Provider:   "gemini",
+		Status:     coreauth.StatusActive,
+		Attributes: map[string]string{"api_key": "upstream-key", "base_url": server.URL},
+	}
+	if _, errRegister := manager.Register(context.Background(), auth); errRegister != nil {
+		t.Fatalf("manager.Register(): %v", errRegister)
+	}
+	h := NewGeminiAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, manager))
+
+	rec := httptest.NewRecorder()
+	ctx, _ := gin.CreateTestContext(rec)
+	ctx.Request = httptest.NewRequest(http.MethodPost, "http://proxy.local/upload/v1beta/files?key=client-key", strings.NewReader(`{"file":{"display_name":"a"}}`))
+	ctx.Request.Header.Set("X-Goog-Upload-Protocol", "resumable")
+	ctx.Request.Header.Set("X-Goog-Upload-Command", "start")
+	h.UploadFile(ctx)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("start status = %d; body=%s", rec.Code, rec.Body.String())
+	}
+	proxyUploadURL := rec.Header().Get("X-Goog-Upload-URL")
+	if !strings.HasPrefix(proxyUploadURL, "http://proxy.local/upload/v1beta/files?") {
+		t.Fatalf("upload url = %q, want a proxy url", proxyUploadURL)
+	}
+
+	rec = httptest.NewRecorder()
+	ctx, _ = gin.CreateTestContext(rec)
+	ctx.Request = httptest.NewRequest(http.MethodPost, proxyUploadURL, strings.NewReader("file-bytes"))
+	ctx.Request.Header.Set("X-Goog-Upload-Command", "upload, finalize")
+	ctx.Request.Header.Set("X-Goog-Upload-Offset", "0")
+	h.UploadFile(ctx)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("upload status = %d; body=%s", rec.Code, rec.Body.String())
+	}
+	if gotBody != "file-bytes" {
+		t.Fatalf("upstream body = %q, want file-bytes", gotBody)
+	}
+	for _, key := range gotKeys {
+		if key != "upstream-key" {
+			t.Fatalf("upstream api keys = %v, want the auth key on every call", gotKeys)
+		}
+	}
+	proxyURI := gjson.Get(rec.Body.String(), "file.uri").String()
+	if proxyURI != "http://proxy.local/v1beta/files/abc" {
+		t.Fatalf("file uri = %q, want the proxy uri", proxyURI)
+	}
+
+	request := `{"contents":[{"parts":[{"text":"describe"},{"fileData":{"mimeType":"text/plain","fileUri":"` + proxyURI + `"}}]}]}`
+	resolved, authID, errResolve := resolveGeminiFileReferences([]byte(request))
+	if errResolve != nil {
+		t.Fatalf("resolveGeminiFileReferences(): %v", errResolve)
+	}
+	if authID != auth.ID {
+		t.Fatalf("pinned auth = %q, want %q", authID, auth.ID)
+	}
+	if got := gjson.GetBytes(resolved, "contents.0.parts.1.fileData.fileUri").String(); got != server.URL+"/v1beta/files/abc" {
+		t.Fatalf("resolved uri = %q, want the upstream uri", got)
+	}
+}
+
+func TestResolveGeminiFileReferencesRejectsMixedAuths(t *testing.T) {
+	geminiFileBindings.set("files/mixed-a", "auth-a", "https://upstream/v1beta/files/mixed-a", time.Hour)
+	geminiFileBindings.set("files/mixed-b", "auth-b", "https://upstream/v1beta/files/mixed-b", time.Hour)
+	request := `{"contents":[{"parts":[{"file_data":{"file_uri":"http://proxy/v1beta/files/mixed-a"}},{"file_data":{"file_uri":"http://proxy/v1beta/files/mixed-b"}}]}]}`
+	if _, _, errResolve := resolveGeminiFileReferences([]byte(request)); errResolve == nil {
+		t.Fatal("expected an error for files bound to different auths")
+	}
+}
+
+func TestResolveGeminiFileReferencesLeavesUnknownFiles(t *testing.T) {
+	request := `{"contents":[{"parts":[{"fileData":{"fileUri":"https://generativelanguage.googleapis.com/v1beta/files/unknown"}}]}]}`
+	resolved, authID, errResolve := resolveGeminiFileReferences([]byte(request))
+	if errResolve != nil || authID != "" || string(resolved) != request {
+		t.Fatalf("resolve = (%s, %q, %v), want the request unchanged", resolved, authID, errResolve)
+	}
+}
+
+func TestGeminiFileNameFromURI(t *testing.T) {
+	tests := map[string]string{
+		"https://generativelanguage.googleapis.com/v1beta/files/abc": "files/abc",
+		"http://proxy:8317/v1beta/files/abc:download?alt=media":      "files/abc",
+		"gs://bucket/object":                "",
+		"https://example.com/v1beta/files/": "",
+	}
+	for uri, want := range tests {
+		if got := geminiFileNameFromURI(uri); got != want {
+			t.Errorf("geminiFileNameFromURI(%q) = %q, want %q", uri, got, want)
+		}
+	}
+}
@@ -68,6 +68,16 @@ func (h *GeminiAPIHandler) HandlerType() string {
 	return Gemini
 }
 
+// WriteErrorResponse writes msg using Google's error envelope.
+func (h *GeminiAPIHandler) WriteErrorResponse(c *gin.Context, msg *interfaces.ErrorMessage) {
+	h.WriteErrorResponseForSurface(c, msg, handlers.ErrorSurfaceForHandlerType(h.HandlerType()))
+}
+
+// writeGeminiError writes a locally generated error using Google's error envelope.
+func writeGeminiError(c *gin.Context, status int, message string) {
+	c.Data(status, "application/json", handlers.BuildErrorResponseBodyForSurface(handlers.ErrorSurfaceGemini, status, message))
+}
+
 // Models returns the Gemini-compatible model metadata supported by this handler.
 func (h *GeminiAPIHandler) Models() []map[string]any {
 	// Get dynamic models from the global registry
@@ -82,6 +92,9 @@ func (h *GeminiAPIHandler) GeminiModels(c *gin.Context) {
 	normalizedModels := make([]map[string]any, 0, len(rawModels))
 	defaultMethods := []string{"generateContent"}
 	for _, model := range rawModels {
+		if name, _ := model["name"].(string); !h.APIKeyAllowsModel(c, strings.TrimPrefix(name, "models/")) {
+			continue
+		}
 		normalizedModel := make(map[string]any, len(model))
 		for k, v := range model {
 			normalizedModel[k] = v
@@ -114,12 +127,7 @@ func (h *GeminiAPIHandler) GeminiGetHandler(c *gin.Context) {
 		Action string `uri:"action" binding:"required"`
 	}
 	if err := c.ShouldBindUri(&request); err != nil {
-		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
-			Error: handlers.ErrorDetail{
-				Message: fmt.Sprintf("Invalid request: %v", err),
-				Type:    "invalid_request_error",
-			},
-		})
+		writeGeminiError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err))
 		return
 	}
 	action := strings.TrimPrefix(request.Action, "/")
@@ -131,7 +139,7 @@ func (h *GeminiAPIHandler) GeminiGetHandler(c *gin.Context) {
 	for _, model := range availableModels {
 		name, _ := model["name"].(string)
 		// Match name with or without 'models/' prefix
-		if name == action || name == "models/"+action {
+		if (name == action || name == "models/"+action) && h.APIKeyAllowsModel(c, strings.TrimPrefix(name, "models/")) {
 			targetModel = model
 			break
 		}
@@ -146,12 +154,7 @@ func (h *GeminiAPIHandler) GeminiGetHandler(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusNotFound, handlers.ErrorResponse{
-		Error: handlers.ErrorDetail{
-			Message: "Not Found",
-			Type:    "not_found",
-		},
-	})
+	writeGeminiError(c, http.StatusNotFound, "Not Found")
 }
 
 // GeminiHandler handles POST requests for Gemini API operations.
@@ -161,35 +164,31 @@ func (h *GeminiAPIHandler) GeminiHandler(c *gin.Context) {
 		Action string `uri:"action" binding:"required"`
 	}
 	if err := c.ShouldBindUri(&request); err != nil {
-		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
-			Error: handlers.ErrorDetail{
-				Message: fmt.Sprintf("Invalid request: %v", err),
-				Type:    "invalid_request_error",
-			},
-		})
+		writeGeminiError(c, http.StatusBadRequest, fmt.Sprintf("Invalid request: %v", err))
 		return
 	}
 	action := strings.Split(strings.TrimPrefix(request.Action, "/"), ":")
 	if len(action) != 2 {
-		c.JSON(http.StatusNotFound, handlers.ErrorResponse{
-			Error: handlers.ErrorDetail{
-				Message: fmt.Sprintf("%s not found.", c.Request.URL.Path),
-				Type:    "invalid_request_error",
-			},
-		})
+		writeGeminiError(c, http.StatusNotFound, fmt.Sprintf("%s not found.", c.Request.URL.Path))
 		return
 	}
 
 	method := action[1]
 	rawJSON, _ := c.GetRawData()
+	rawJSON, fileAuthID, errFiles := resolveGeminiFileReferences(rawJSON)
+	if errFiles != nil {
+		writeGeminiError(c, http.StatusBadRequest, errFiles.Error())
+		return
+	}
+	parentCtx := contextWithGeminiFileAuth(context.Background(), fileAuthID)
 
 	switch method {
 	case "generateContent":
-		h.handleGenerateContent(c, action[0], rawJSON)
+		h.handleGenerateContent(c, parentCtx, action[0], rawJSON)
 	case "streamGenerateContent":
-		h.handleStreamGenerateContent(c, action[0], rawJSON)
+		h.handleStreamGenerateContent(c, parentCtx, action[0], rawJSON)
 	case "countTokens":
-		h.handleCountTokens(c, action[0], rawJSON)
+		h.handleCountTokens(c, parentCtx, action[0], rawJSON)
 	}
 }
 
@@ -200,24 +199,20 @@ func (h *GeminiAPIHandler) GeminiHandler(c *gin.Context) {
 //
 // Parameters:
 //   - c: The Gin context for the request
+//   - parentCtx: The parent context, pinned to an auth when the request references uploaded files
 //   - modelName: The name of the Gemini model to use for content generation
 //   - rawJSON: The raw JSON request body containing generation parameters
-func (h *GeminiAPIHandler) handleStreamGenerateContent(c *gin.Context, modelName string, rawJSON []byte) {
+func (h *GeminiAPIHandler) handleStreamGenerateContent(c *gin.Context, parentCtx context.Context, modelName string, rawJSON []byte) {
 	alt := h.GetAlt(c)
 
 	// Get the http.Flusher interface to manually flush the response.
 	flusher, ok := c.Writer.(http.Flusher)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, handlers.ErrorResponse{
-			Error: handlers.ErrorDetail{
-				Message: "Streaming not supported",
-				Type:    "server_error",
-			},
-		})
+		writeGeminiError(c, http.StatusInternalServerError, "Streaming not supported")
 		return
 	}
 
-	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, parentCtx)
 	dataChan, upstreamHeaders, errChan := h.ExecuteStreamWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, alt)
 
 	setSSEHeaders := func() {
@@ -300,12 +295,13 @@ func (h *GeminiAPIHandler) handleStreamGenerateContent(c *gin.Context, modelName
 //
 // Parameters:
 //   - c: The Gin context for the request
+//   - parentCtx: The parent context, pinned to an auth when the request references uploaded files
 //   - modelName: The name of the Gemini model to use for token counting
 //   - rawJSON: The raw JSON request body containing the content to count
-func (h *GeminiAPIHandler) handleCountTokens(c *gin.Context, modelName string, rawJSON []byte) {
+func (h *GeminiAPIHandler) handleCountTokens(c *gin.Context, parentCtx context.Context, modelName string, rawJSON []byte) {
 	c.Header("Content-Type", "application/json")
 	alt := h.GetAlt(c)
-	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, parentCtx)
 	resp, upstreamHeaders, errMsg := h.ExecuteCountWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, alt)
 	if errMsg != nil {
 		h.WriteErrorResponse(c, errMsg)
@@ -324,12 +320,13 @@ func (h *GeminiAPIHandler) handleCountTokens(c *gin.Context, modelName string, r
 //
 // Parameters:
 //   - c: The Gin context for the request
+//   - parentCtx: The parent context, pinned to an auth when the request references uploaded files
 //   - modelName: The name of the Gemini model to use for content generation
 //   - rawJSON: The raw JSON request body containing generation parameters and content
-func (h *GeminiAPIHandler) handleGenerateContent(c *gin.Context, modelName string, rawJSON []byte) {
+func (h *GeminiAPIHandler) handleGenerateContent(c *gin.Context, parentCtx context.Context, modelName string, rawJSON []byte) {
 	c.Header("Content-Type", "application/json")
 	alt := h.GetAlt(c)
-	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, parentCtx)
 	stopKeepAlive := h.StartNonStreamingKeepAlive(c, cliCtx)
 	resp, upstreamHeaders, errMsg := h.ExecuteWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, alt)
 	stopKeepAlive()
@@ -371,7 +368,7 @@ func (h *GeminiAPIHandler) forwardGeminiStream(c *gin.Context, flusher http.Flus
 			if errMsg.Error != nil && errMsg.Error.Error() != "" {
 				errText = errMsg.Error.Error()
 			}
-			body := handlers.BuildErrorResponseBody(status, errText)
+			body := handlers.BuildErrorResponseBodyForSurface(handlers.ErrorSurfaceForHandlerType(h.HandlerType()), status, errText)
 			if alt == "" {
 				_, _ = fmt.Fprintf(c.Writer, "event: error\ndata: %s\n\n", string(body))
 			} else {
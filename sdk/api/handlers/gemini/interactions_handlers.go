@@ -93,12 +93,12 @@ func buildInteractionsExecutionRequest(target interactionsRequestTarget, modelNa
 func (h *GeminiAPIHandler) Interactions(c *gin.Context) {
 	rawJSON, errRead := c.GetRawData()
 	if errRead != nil {
-		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{Error: handlers.ErrorDetail{Message: errRead.Error(), Type: "invalid_request_error"}})
+		writeGeminiError(c, http.StatusBadRequest, errRead.Error())
 		return
 	}
 	target, errParse := parseInteractionsRequestTarget(rawJSON)
 	if errParse != nil {
-		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{Error: handlers.ErrorDetail{Message: errParse.Error(), Type: "invalid_request_error"}})
+		writeGeminiError(c, http.StatusBadRequest, errParse.Error())
 		return
 	}
 
@@ -134,7 +134,7 @@ func (h *GeminiAPIHandler) handleInteractionsNonStream(c *gin.Context, cliCtx co
 func (h *GeminiAPIHandler) handleInteractionsStream(c *gin.Context, cliCtx context.Context, cliCancel handlers.APIHandlerCancelFunc, req handlers.ProtocolExecutionRequest) {
 	flusher, ok := c.Writer.(http.Flusher)
 	if !ok {
-		c.JSON(http.StatusInternalServerError, handlers.ErrorResponse{Error: handlers.ErrorDetail{Message: "Streaming not supported", Type: "server_error"}})
+		writeGeminiError(c, http.StatusInternalServerError, "Streaming not supported")
 		return
 	}
 	stream, errMsg := h.ExecuteProtocolStreamWithAuthManager(cliCtx, req)
@@ -195,7 +195,7 @@ func (h *GeminiAPIHandler) forwardInteractionsStream(c *gin.Context, flusher htt
 			if errMsg.Error != nil && errMsg.Error.Error() != "" {
 				errText = errMsg.Error.Error()
 			}
-			body := handlers.BuildErrorResponseBody(status, errText)
+			body := handlers.BuildErrorResponseBodyForSurface(handlers.ErrorSurfaceForHandlerType(h.HandlerType()), status, errText)
 			_, _ = fmt.Fprintf(c.Writer, "event: error\ndata: %s\n\n", string(body))
 		},
 	})
@@ -116,8 +116,8 @@ func TestInteractionsRejectsInvalidJSON(t *testing.T) {
 	if rec.Code != http.StatusBadRequest {
 		t.Fatalf("status = %d, want %d; body=%s", rec.Code, http.StatusBadRequest, rec.Body.String())
 	}
-	if !strings.Contains(rec.Body.String(), "invalid_request_error") {
-		t.Fatalf("body = %s, want invalid_request_error", rec.Body.String())
+	if !strings.Contains(rec.Body.String(), "INVALID_ARGUMENT") {
+		t.Fatalf("body = %s, want INVALID_ARGUMENT", rec.Body.String())
 	}
 }
 
@@ -167,8 +167,8 @@ func TestInteractionsRejectsNonBooleanStream(t *testing.T) {
 	if rec.Code != http.StatusBadRequest {
 		t.Fatalf("status = %d, want %d; body=%s", rec.Code, http.StatusBadRequest, rec.Body.String())
 	}
-	if !strings.Contains(rec.Body.String(), "invalid_request_error") {
-		t.Fatalf("body = %s, want invalid_request_error", rec.Body.String())
+	if !strings.Contains(rec.Body.String(), "INVALID_ARGUMENT") {
+		t.Fatalf("body = %s, want INVALID_ARGUMENT", rec.Body.String())
 	}
 }
 
@@ -19,11 +19,17 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	internalcache "github.com/router-for-me/CLIProxyAPI/v7/internal/cache"
 	. "github.com/router-for-me/CLIProxyAPI/v7/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/finetunecapture"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/logging"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/memguard"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/payloadstats"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/secretdlp"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/streamrelay"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/streamwatch"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/turnprovenance"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
@@ -60,6 +66,10 @@ type ErrorDetail struct {
 
 const idempotencyKeyMetadataKey = "idempotency_key"
 
+// RequestTagsHeader carries comma-separated key=value tags (e.g. "team=infra,job=nightly") that
+// are stored with the request's usage records.
+const RequestTagsHeader = "X-CLIProxy-Tags"
+
 const (
 	defaultStreamingKeepAliveSeconds = 0
 	defaultStreamingBootstrapRetries = 0
@@ -68,6 +78,10 @@ const (
 	maxStreamInterceptorHistoryBytes  = 1 << 20
 )
 
+// SelectedAuthIDGinKey is the gin context key holding the ID of the auth that served the
+// request, updated on every selection so retries report the auth that answered.
+const SelectedAuthIDGinKey = "selectedAuthID"
+
 type pinnedAuthContextKey struct{}
 type selectedAuthCallbackContextKey struct{}
 type executionSessionContextKey struct{}
@@ -262,13 +276,23 @@ func PassthroughHeadersEnabled(cfg *config.SDKConfig) bool {
 	return cfg != nil && cfg.PassthroughHeaders
 }
 
+// setResidencyMetadata requires auth selection to honor the data-residency region configured
+// for the client API key of ctx.
+func (h *BaseAPIHandler) setResidencyMetadata(ctx context.Context, meta map[string]any) {
+	if region := h.CurrentConfig().APIKeyResidencyRegion(requestAPIKey(ctx)); region != "" {
+		meta[coreexecutor.RequiredResidencyMetadataKey] = region
+	}
+}
+
 func requestExecutionMetadata(ctx context.Context) map[string]any {
 	// Idempotency-Key is an optional client-supplied header used to correlate retries.
 	// Only include it if the client explicitly provides it.
 	key := ""
 	requestPath := ""
+	var ginCtx *gin.Context
 	if ctx != nil {
-		if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil && ginCtx.Request != nil {
+		if c, ok := ctx.Value("gin").(*gin.Context); ok && c != nil && c.Request != nil {
+			ginCtx = c
 			key = strings.TrimSpace(ginCtx.GetHeader("Idempotency-Key"))
 			requestPath = strings.TrimSpace(ginCtx.FullPath())
 			if requestPath == "" && ginCtx.Request.URL != nil {
@@ -287,7 +311,17 @@ func requestExecutionMetadata(ctx context.Context) map[string]any {
 	if pinnedAuthID := pinnedAuthIDFromContext(ctx); pinnedAuthID != "" {
 		meta[coreexecutor.PinnedAuthMetadataKey] = pinnedAuthID
 	}
-	if selectedCallback := selectedAuthIDCallbackFromContext(ctx); selectedCallback != nil {
+	selectedCallback := selectedAuthIDCallbackFromContext(ctx)
+	if ginCtx != nil {
+		clientCallback := selectedCallback
+		selectedCallback = func(authID string) {
+			ginCtx.Set(SelectedAuthIDGinKey, authID)
+			if clientCallback != nil {
+				clientCallback(authID)
+			}
+		}
+	}
+	if selectedCallback != nil {
 		meta[coreexecutor.SelectedAuthCallbackMetadataKey] = selectedCallback
 	}
 	if executionSessionID := executionSessionIDFromContext(ctx); executionSessionID != "" {
@@ -336,6 +370,34 @@ func setServiceTierMetadata(meta map[string]any, rawJSON []byte) {
 	meta[coreexecutor.ServiceTierMetadataKey] = serviceTier
 }
 
+// setRequestTagsMetadata records the request's tags for usage logs: the string values of the
+// Responses API metadata object, overridden by the RequestTagsHeader pairs.
+func setRequestTagsMetadata(ctx context.Context, meta map[string]any, entryProtocol string, rawJSON []byte) {
+	if meta == nil {
+		return
+	}
+	var tags map[string]string
+	if entryProtocol == OpenaiResponse {
+		gjson.GetBytes(rawJSON, "metadata").ForEach(func(key, value gjson.Result) bool {
+			if value.Type == gjson.String {
+				tags = coreusage.AddTag(tags, key.String(), value.String())
+			}
+			return true
+		})
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil && ginCtx.Request != nil {
+		for key, value := range coreusage.ParseTags(ginCtx.GetHeader(RequestTagsHeader)) {
+			tags = coreusage.AddTag(tags, key, value)
+		}
+	}
+	if len(tags) > 0 {
+		meta[coreexecutor.RequestTagsMetadataKey] = tags
+	}
+}
+
 // headersFromContext extracts the original HTTP request headers from the gin context
 // embedded in the provided context. This allows session affinity selectors to read
 // client-provided session headers.
@@ -432,6 +494,21 @@ type BaseAPIHandler struct {
 
 	// SecretDLP restores hosted egress-token-vault placeholders before responses reach downstream clients.
 	SecretDLP *secretdlp.Service
+
+	dedupOnce sync.Once
+	dedup     *requestDeduplicator
+
+	admissionOnce sync.Once
+	admission     *admissionQueue
+
+	compressionOnce    sync.Once
+	compressionSummary internalcache.Cache[string]
+
+	cacheOnce sync.Once
+	respCache *responseCache
+
+	replayOnce sync.Once
+	replay     *streamReplayStore
 }
 
 // NewBaseAPIHandlers creates a new API handlers instance.
@@ -623,6 +700,9 @@ func (h *BaseAPIHandler) GetContextWithCancel(handler interfaces.APIHandler, c *
 	}
 	newCtx = logging.WithResponseStatusHolder(newCtx)
 	newCtx = logging.WithResponseHeadersHolder(newCtx)
+	if cfg := h.CurrentConfig(); cfg != nil && cfg.RequestLog {
+		newCtx = logging.WithConnectionTrace(newCtx)
+	}
 
 	cancelCtx := newCtx
 	if requestCtx != nil && requestCtx != parentCtx {
@@ -735,10 +815,37 @@ func (h *BaseAPIHandler) executeWithAuthManager(ctx context.Context, handlerType
 }
 
 func (h *BaseAPIHandler) executeWithAuthManagerFormats(ctx context.Context, entryProtocol, exitProtocol, modelName string, rawJSON []byte, alt string, allowImageModel bool, execOptions modelExecutionOptions) ([]byte, http.Header, *interfaces.ErrorMessage) {
+	key := requestDedupKey(ctx, entryProtocol, exitProtocol, modelName, alt, rawJSON, false)
+	payloadstats.ObserveRequest(modelName, rawJSON)
+	execute := func() ([]byte, http.Header, *interfaces.ErrorMessage) {
+		return h.executeDeduplicated(ctx, key, func() ([]byte, http.Header, *interfaces.ErrorMessage) {
+			return h.executeWithAuthManagerOnce(ctx, entryProtocol, exitProtocol, modelName, rawJSON, alt, allowImageModel, execOptions)
+		})
+	}
+	cacheKey := ""
+	if !allowImageModel {
+		cacheKey = requestDedupKey(ctx, entryProtocol, exitProtocol, modelName, alt, normalizeCachePayload(rawJSON), false)
+	}
+	body, headers, errMsg := h.executeCached(ctx, cacheKey, modelName, execute)
+	if errMsg == nil {
+		payloadstats.ObserveResponse(modelName, body)
+		finetunecapture.Capture(entryProtocol, exitProtocol, modelName, requestAPIKey(ctx), rawJSON, body)
+	}
+	return body, headers, errMsg
+}
+
+func (h *BaseAPIHandler) executeWithAuthManagerOnce(ctx context.Context, entryProtocol, exitProtocol, modelName string, rawJSON []byte, alt string, allowImageModel bool, execOptions modelExecutionOptions) ([]byte, http.Header, *interfaces.ErrorMessage) {
 	if handshakeCtx, body, headers, errMsg, handled := maybeInvocationHandshake(ctx, rawJSON, false); handled {
 		_ = handshakeCtx
 		return body, headers, errMsg
 	}
+	if errScope := h.checkAPIKeyModel(ctx, modelName); errScope != nil {
+		return nil, nil, errScope
+	}
+	profile, modelName, errProfile := h.applyRequestProfile(ctx, modelName, rawJSON)
+	if errProfile != nil {
+		return nil, nil, errProfile
+	}
 	originalRequestedModel := modelName
 	routeDecision := h.applyModelRouter(ctx, entryProtocol, modelName, rawJSON, false, execOptions)
 	responseProtocol := modelExecutionResponseProtocol(entryProtocol, exitProtocol)
@@ -752,7 +859,16 @@ func (h *BaseAPIHandler) executeWithAuthManagerFormats(ctx context.Context, entr
 	if errMsg != nil {
 		return nil, nil, errMsg
 	}
+	if providers, errMsg = restrictProvidersToProfile(profile, providers, normalizedModel); errMsg != nil {
+		return nil, nil, errMsg
+	}
 	providers = adjustExecutionProvidersForEntryProtocol(entryProtocol, providers)
+	if errMsg = h.validateStrictParameters(ctx, entryProtocol, providers, normalizedModel, rawJSON); errMsg != nil {
+		return nil, nil, errMsg
+	}
+	if errMsg = h.validateStrictBuiltinTools(ctx, entryProtocol, providers, rawJSON); errMsg != nil {
+		return nil, nil, errMsg
+	}
 	reqMeta := requestExecutionMetadata(ctx)
 	if len(extraMeta) > 0 {
 		if reqMeta == nil {
@@ -762,11 +878,13 @@ func (h *BaseAPIHandler) executeWithAuthManagerFormats(ctx context.Context, entr
 			reqMeta[k] = v
 		}
 	}
+	h.setResidencyMetadata(ctx, reqMeta)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = originalRequestedModel
 	addAuthSelectionModelMetadata(reqMeta, execOptions.AuthSelectionModel)
 	addModelExecutionSourceMetadata(reqMeta, execOptions.InternalSource)
 	setReasoningEffortMetadata(reqMeta, entryProtocol, normalizedModel, rawJSON)
 	setServiceTierMetadata(reqMeta, rawJSON)
+	setRequestTagsMetadata(ctx, reqMeta, entryProtocol, rawJSON)
 	payload := rawJSON
 	if len(payload) == 0 {
 		payload = nil
@@ -794,6 +912,13 @@ func (h *BaseAPIHandler) executeWithAuthManagerFormats(ctx context.Context, entr
 	}
 	opts.Metadata = reqMeta
 	req, opts = h.applyRequestInterceptorsBeforeAuth(ctx, entryProtocol, originalRequestedModel, req, opts, execOptions.SkipInterceptorPluginID)
+	releaseAdmission, errAdmission := h.admitRequest(ctx)
+	if errAdmission != nil {
+		return nil, nil, errAdmission
+	}
+	if releaseAdmission != nil {
+		defer releaseAdmission()
+	}
 	resp, err := h.AuthManager.Execute(ctx, providers, req, opts)
 	if err != nil {
 		err = enrichAuthSelectionError(err, providers, normalizedModel)
@@ -813,7 +938,7 @@ func (h *BaseAPIHandler) executeWithAuthManagerFormats(ctx context.Context, entr
 	}
 	executedReq, executedOpts := afterAuthCapture.apply(req, opts)
 	rawResponseHeaders := cloneHeader(resp.Headers)
-	responseHeaders := downstreamHeadersFromExecutor(rawResponseHeaders, PassthroughHeadersEnabled(h.CurrentConfig()))
+	responseHeaders := downstreamHeadersFromExecutor(rawResponseHeaders, h.responseHeaderPolicy(opts.Metadata))
 	body, responseHeaders := h.applyResponseInterceptors(ctx, responseProtocol, normalizedModel, originalRequestedModel, executedOpts, rawResponseHeaders, responseHeaders, executedOpts.OriginalRequest, executedReq.Payload, resp.Payload, http.StatusOK, execOptions.SkipInterceptorPluginID)
 	body = h.restoreSecretDLPResponse(ctx, body)
 	if shouldExposeInvocationIdentity(opts.Headers, identity) || responseHeaders != nil {
@@ -829,6 +954,13 @@ func (h *BaseAPIHandler) ExecuteCountWithAuthManager(ctx context.Context, handle
 }
 
 func (h *BaseAPIHandler) executeCountWithAuthManager(ctx context.Context, handlerType, modelName string, rawJSON []byte, alt string, execOptions modelExecutionOptions) ([]byte, http.Header, *interfaces.ErrorMessage) {
+	if errScope := h.checkAPIKeyModel(ctx, modelName); errScope != nil {
+		return nil, nil, errScope
+	}
+	profile, modelName, errProfile := h.applyRequestProfile(ctx, modelName, rawJSON)
+	if errProfile != nil {
+		return nil, nil, errProfile
+	}
 	originalRequestedModel := modelName
 	routeDecision := h.applyModelRouter(ctx, handlerType, modelName, rawJSON, false, execOptions)
 	if routeDecision.ExecutorPluginID != "" {
@@ -838,6 +970,9 @@ func (h *BaseAPIHandler) executeCountWithAuthManager(ctx context.Context, handle
 	if errMsg != nil {
 		return nil, nil, errMsg
 	}
+	if providers, errMsg = restrictProvidersToProfile(profile, providers, normalizedModel); errMsg != nil {
+		return nil, nil, errMsg
+	}
 	providers = adjustExecutionProvidersForEntryProtocol(handlerType, providers)
 	reqMeta := requestExecutionMetadata(ctx)
 	if len(extraMeta) > 0 {
@@ -848,10 +983,12 @@ func (h *BaseAPIHandler) executeCountWithAuthManager(ctx context.Context, handle
 			reqMeta[k] = v
 		}
 	}
+	h.setResidencyMetadata(ctx, reqMeta)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = originalRequestedModel
 	addAuthSelectionModelMetadata(reqMeta, execOptions.AuthSelectionModel)
 	setReasoningEffortMetadata(reqMeta, handlerType, normalizedModel, rawJSON)
 	setServiceTierMetadata(reqMeta, rawJSON)
+	setRequestTagsMetadata(ctx, reqMeta, handlerType, rawJSON)
 	payload := rawJSON
 	if len(payload) == 0 {
 		payload = nil
@@ -891,7 +1028,7 @@ func (h *BaseAPIHandler) executeCountWithAuthManager(ctx context.Context, handle
 	}
 	executedReq, executedOpts := afterAuthCapture.apply(req, opts)
 	rawResponseHeaders := cloneHeader(resp.Headers)
-	responseHeaders := downstreamHeadersFromExecutor(rawResponseHeaders, PassthroughHeadersEnabled(h.CurrentConfig()))
+	responseHeaders := downstreamHeadersFromExecutor(rawResponseHeaders, h.responseHeaderPolicy(opts.Metadata))
 	body, responseHeaders := h.applyResponseInterceptors(ctx, handlerType, normalizedModel, originalRequestedModel, executedOpts, rawResponseHeaders, responseHeaders, executedOpts.OriginalRequest, executedReq.Payload, resp.Payload, http.StatusOK, execOptions.SkipInterceptorPluginID)
 	body = h.restoreSecretDLPResponse(ctx, body)
 	return body, responseHeaders, nil
@@ -910,7 +1047,7 @@ func (h *BaseAPIHandler) executeWithPluginExecutor(ctx context.Context, entryPro
 		return nil, nil, executionErrorMessage(errExecute)
 	}
 	rawResponseHeaders := cloneHeader(resp.Headers)
-	responseHeaders := downstreamHeadersFromExecutor(rawResponseHeaders, PassthroughHeadersEnabled(h.CurrentConfig()))
+	responseHeaders := downstreamHeadersFromExecutor(rawResponseHeaders, h.responseHeaderPolicy(opts.Metadata))
 	body, responseHeaders := h.applyResponseInterceptors(ctx, responseProtocol, modelName, originalRequestedModel, opts, rawResponseHeaders, responseHeaders, opts.OriginalRequest, req.Payload, resp.Payload, http.StatusOK, execOptions.SkipInterceptorPluginID)
 	body = h.restoreSecretDLPResponse(ctx, body)
 	return body, responseHeaders, nil
@@ -929,7 +1066,7 @@ func (h *BaseAPIHandler) countWithPluginExecutor(ctx context.Context, handlerTyp
 		return nil, nil, executionErrorMessage(errCount)
 	}
 	rawResponseHeaders := cloneHeader(resp.Headers)
-	responseHeaders := downstreamHeadersFromExecutor(rawResponseHeaders, PassthroughHeadersEnabled(h.CurrentConfig()))
+	responseHeaders := downstreamHeadersFromExecutor(rawResponseHeaders, h.responseHeaderPolicy(opts.Metadata))
 	body, responseHeaders := h.applyResponseInterceptors(ctx, handlerType, modelName, originalRequestedModel, opts, rawResponseHeaders, responseHeaders, opts.OriginalRequest, req.Payload, resp.Payload, http.StatusOK, execOptions.SkipInterceptorPluginID)
 	body = h.restoreSecretDLPResponse(ctx, body)
 	return body, responseHeaders, nil
@@ -937,11 +1074,13 @@ func (h *BaseAPIHandler) countWithPluginExecutor(ctx context.Context, handlerTyp
 
 func (h *BaseAPIHandler) pluginExecutorRequest(ctx context.Context, entryProtocol, responseProtocol, modelName, originalRequestedModel string, rawJSON []byte, alt string, stream bool, execOptions modelExecutionOptions) (coreexecutor.Request, coreexecutor.Options) {
 	reqMeta := requestExecutionMetadata(ctx)
+	h.setResidencyMetadata(ctx, reqMeta)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = originalRequestedModel
 	addAuthSelectionModelMetadata(reqMeta, execOptions.AuthSelectionModel)
 	addModelExecutionSourceMetadata(reqMeta, execOptions.InternalSource)
 	setReasoningEffortMetadata(reqMeta, entryProtocol, modelName, rawJSON)
 	setServiceTierMetadata(reqMeta, rawJSON)
+	setRequestTagsMetadata(ctx, reqMeta, entryProtocol, rawJSON)
 	payload := rawJSON
 	if len(payload) == 0 {
 		payload = nil
@@ -1048,13 +1187,13 @@ func (h *BaseAPIHandler) streamWithPluginExecutor(ctx context.Context, entryProt
 		return nil, nil, errChan
 	}
 
-	passthroughHeadersEnabled := PassthroughHeadersEnabled(h.CurrentConfig())
+	headerPolicy := h.responseHeaderPolicy(opts.Metadata)
 	interceptorHost := h.interceptorHost()
 	streamInterceptorsActive := streamInterceptorsEnabled(interceptorHost)
 	rawStreamHeaders := cloneHeader(streamResult.Headers)
 	baseStreamHeaders := cloneHeader(streamResult.Headers)
-	upstreamHeaders := downstreamHeadersFromExecutor(rawStreamHeaders, passthroughHeadersEnabled)
-	if upstreamHeaders == nil && (passthroughHeadersEnabled || streamInterceptorsActive) {
+	upstreamHeaders := downstreamHeadersFromExecutor(rawStreamHeaders, headerPolicy)
+	if upstreamHeaders == nil && (headerPolicy.forwards() || streamInterceptorsActive) {
 		upstreamHeaders = make(http.Header)
 	}
 	streamHeadersCommitted := false
@@ -1063,7 +1202,7 @@ func (h *BaseAPIHandler) streamWithPluginExecutor(ctx context.Context, entryProt
 		if streamHeadersCommitted || upstreamHeaders == nil {
 			return
 		}
-		nextHeaders := downstreamHeadersAfterInterceptors(baseStreamHeaders, rawStreamHeaders, passthroughHeadersEnabled)
+		nextHeaders := downstreamHeadersAfterInterceptors(baseStreamHeaders, rawStreamHeaders, headerPolicy)
 		replaceHeader(upstreamHeaders, nextHeaders)
 	}
 	var streamInterceptorBase streamInterceptorRequestBase
@@ -1160,6 +1299,46 @@ func (h *BaseAPIHandler) executeStreamWithAuthManager(ctx context.Context, handl
 }
 
 func (h *BaseAPIHandler) executeStreamWithAuthManagerFormats(ctx context.Context, entryProtocol, exitProtocol, modelName string, rawJSON []byte, alt string, allowImageModel bool, execOptions modelExecutionOptions) (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage) {
+	ctx, stream := streamwatch.Register(ctx, streamwatch.Info{Handler: entryProtocol, Model: modelName})
+	if _, _, enabled := StreamReplaySettings(h.CurrentConfig()); enabled {
+		if replayKey, ginCtx := streamReplayKey(ctx, alt); replayKey != "" {
+			dataChan, headers, errChan, resumed := h.executeStreamReplayable(ctx, ginCtx, replayKey, func(runCtx context.Context) (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage) {
+				return h.executeStreamWithAuthManagerOnce(runCtx, entryProtocol, exitProtocol, modelName, rawJSON, alt, allowImageModel, execOptions)
+			})
+			errChan = stream.Errors(errChan)
+			if resumed {
+				// The original request already observed the payload and the stream from its start.
+				return streamrelay.Relay(ctx, dataChan, memguard.StreamObserver(), stream.Observer()), headers, errChan
+			}
+			payloadstats.ObserveRequest(modelName, rawJSON)
+			dataChan = streamrelay.Relay(ctx, dataChan, memguard.StreamObserver(), payloadstats.StreamObserver(modelName), finetunecapture.StreamObserver(entryProtocol, exitProtocol, modelName, requestAPIKey(ctx), rawJSON), stream.Observer())
+			return dataChan, headers, errChan
+		}
+	}
+	key := requestDedupKey(ctx, entryProtocol, exitProtocol, modelName, alt, rawJSON, true)
+	payloadstats.ObserveRequest(modelName, rawJSON)
+	dataChan, headers, errChan := h.executeStreamDeduplicated(ctx, key, func() (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage) {
+		return h.executeStreamWithAuthManagerOnce(ctx, entryProtocol, exitProtocol, modelName, rawJSON, alt, allowImageModel, execOptions)
+	})
+	errChan = stream.Errors(errChan)
+	dataChan = streamrelay.Relay(ctx, dataChan, memguard.StreamObserver(), payloadstats.StreamObserver(modelName), finetunecapture.StreamObserver(entryProtocol, exitProtocol, modelName, requestAPIKey(ctx), rawJSON), stream.Observer())
+	return dataChan, headers, errChan
+}
+
+func (h *BaseAPIHandler) executeStreamWithAuthManagerOnce(ctx context.Context, entryProtocol, exitProtocol, modelName string, rawJSON []byte, alt string, allowImageModel bool, execOptions modelExecutionOptions) (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage) {
+	if errScope := h.checkAPIKeyModel(ctx, modelName); errScope != nil {
+		errChan := make(chan *interfaces.ErrorMessage, 1)
+		errChan <- errScope
+		close(errChan)
+		return nil, nil, errChan
+	}
+	profile, modelName, errProfile := h.applyRequestProfile(ctx, modelName, rawJSON)
+	if errProfile != nil {
+		errChan := make(chan *interfaces.ErrorMessage, 1)
+		errChan <- errProfile
+		close(errChan)
+		return nil, nil, errChan
+	}
 	originalRequestedModel := modelName
 	routeDecision := h.applyModelRouter(ctx, entryProtocol, modelName, rawJSON, true, execOptions)
 	responseProtocol := modelExecutionResponseProtocol(entryProtocol, exitProtocol)
@@ -1179,7 +1358,22 @@ func (h *BaseAPIHandler) executeStreamWithAuthManagerFormats(ctx context.Context
 		close(errChan)
 		return nil, nil, errChan
 	}
+	if providers, errMsg = restrictProvidersToProfile(profile, providers, normalizedModel); errMsg != nil {
+		errChan := make(chan *interfaces.ErrorMessage, 1)
+		errChan <- errMsg
+		close(errChan)
+		return nil, nil, errChan
+	}
 	providers = adjustExecutionProvidersForEntryProtocol(entryProtocol, providers)
+	if errMsg = h.validateStrictParameters(ctx, entryProtocol, providers, normalizedModel, rawJSON); errMsg == nil {
+		errMsg = h.validateStrictBuiltinTools(ctx, entryProtocol, providers, rawJSON)
+	}
+	if errMsg != nil {
+		errChan := make(chan *interfaces.ErrorMessage, 1)
+		errChan <- errMsg
+		close(errChan)
+		return nil, nil, errChan
+	}
 	reqMeta := requestExecutionMetadata(ctx)
 	if len(extraMeta) > 0 {
 		if reqMeta == nil {
@@ -1189,11 +1383,13 @@ func (h *BaseAPIHandler) executeStreamWithAuthManagerFormats(ctx context.Context
 			reqMeta[k] = v
 		}
 	}
+	h.setResidencyMetadata(ctx, reqMeta)
 	reqMeta[coreexecutor.RequestedModelMetadataKey] = originalRequestedModel
 	addAuthSelectionModelMetadata(reqMeta, execOptions.AuthSelectionModel)
 	addModelExecutionSourceMetadata(reqMeta, execOptions.InternalSource)
 	setReasoningEffortMetadata(reqMeta, entryProtocol, normalizedModel, rawJSON)
 	setServiceTierMetadata(reqMeta, rawJSON)
+	setRequestTagsMetadata(ctx, reqMeta, entryProtocol, rawJSON)
 	payload := rawJSON
 	if len(payload) == 0 {
 		payload = nil
@@ -1228,8 +1424,18 @@ func (h *BaseAPIHandler) executeStreamWithAuthManagerFormats(ctx context.Context
 	// observe the ID even when upstream connect fails.
 	earlyInvocationHeaders := seedStreamInvocationHeaders(opts.Headers, identity)
 	req, opts = h.applyRequestInterceptorsBeforeAuth(ctx, entryProtocol, originalRequestedModel, req, opts, execOptions.SkipInterceptorPluginID)
+	releaseAdmission, errAdmission := h.admitRequest(ctx)
+	if errAdmission != nil {
+		errChan := make(chan *interfaces.ErrorMessage, 1)
+		errChan <- errAdmission
+		close(errChan)
+		return nil, cloneHeader(earlyInvocationHeaders), errChan
+	}
 	streamResult, err := h.AuthManager.ExecuteStream(ctx, providers, req, opts)
 	if err != nil {
+		if releaseAdmission != nil {
+			releaseAdmission()
+		}
 		err = enrichAuthSelectionError(err, providers, normalizedModel)
 		errChan := make(chan *interfaces.ErrorMessage, 1)
 		status := http.StatusInternalServerError
@@ -1257,20 +1463,20 @@ func (h *BaseAPIHandler) executeStreamWithAuthManagerFormats(ctx context.Context
 	executedRequest := func() (coreexecutor.Request, coreexecutor.Options) {
 		return afterAuthCapture.apply(req, opts)
 	}
-	passthroughHeadersEnabled := PassthroughHeadersEnabled(h.CurrentConfig())
+	headerPolicy := h.responseHeaderPolicy(opts.Metadata)
 	interceptorHost := h.interceptorHost()
 	streamInterceptorsActive := streamInterceptorsEnabled(interceptorHost)
 	// Capture upstream headers from the initial connection synchronously before the goroutine starts.
 	// Keep a mutable map so bootstrap retries can replace it before first payload is sent.
 	rawStreamHeaders := cloneHeader(streamResult.Headers)
 	baseStreamHeaders := cloneHeader(streamResult.Headers)
-	upstreamHeaders := downstreamHeadersFromExecutor(rawStreamHeaders, passthroughHeadersEnabled)
+	upstreamHeaders := downstreamHeadersFromExecutor(rawStreamHeaders, headerPolicy)
 	if earlyInvocationHeaders != nil {
 		upstreamHeaders = preserveInvocationHeaders(upstreamHeaders, opts.Headers, identity)
 	} else if shouldExposeInvocationIdentity(opts.Headers, identity) || upstreamHeaders != nil {
 		upstreamHeaders = mergeInvocationResponseHeaders(upstreamHeaders, identity)
 	}
-	if upstreamHeaders == nil && (passthroughHeadersEnabled || streamInterceptorsActive || earlyInvocationHeaders != nil) {
+	if upstreamHeaders == nil && (headerPolicy.forwards() || streamInterceptorsActive || earlyInvocationHeaders != nil) {
 		upstreamHeaders = make(http.Header)
 		upstreamHeaders = preserveInvocationHeaders(upstreamHeaders, opts.Headers, identity)
 	}
@@ -1284,7 +1490,7 @@ func (h *BaseAPIHandler) executeStreamWithAuthManagerFormats(ctx context.Context
 		if streamHeadersCommitted {
 			return
 		}
-		nextHeaders := downstreamHeadersAfterInterceptors(baseStreamHeaders, rawStreamHeaders, passthroughHeadersEnabled)
+		nextHeaders := downstreamHeadersAfterInterceptors(baseStreamHeaders, rawStreamHeaders, headerPolicy)
 		replaceHeader(upstreamHeaders, nextHeaders)
 		preserveInvocationHeaders(upstreamHeaders, opts.Headers, identity)
 	}
@@ -1427,7 +1633,8 @@ func (h *BaseAPIHandler) executeStreamWithAuthManagerFormats(ctx context.Context
 								if !streamHeadersCommitted {
 									rawStreamHeaders = cloneHeader(retryResult.Headers)
 									baseStreamHeaders = cloneHeader(retryResult.Headers)
-									replaceHeader(upstreamHeaders, downstreamHeadersFromExecutor(rawStreamHeaders, passthroughHeadersEnabled))
+									headerPolicy = h.responseHeaderPolicy(opts.Metadata)
+									replaceHeader(upstreamHeaders, downstreamHeadersFromExecutor(rawStreamHeaders, headerPolicy))
 									preserveInvocationHeaders(upstreamHeaders, opts.Headers, identity)
 									streamHeaderInitialized = false
 								}
@@ -1504,7 +1711,8 @@ func (h *BaseAPIHandler) executeStreamWithAuthManagerFormats(ctx context.Context
 			}
 		}
 	}()
-	return dataChan, upstreamHeaders, errChan
+	admittedData, admittedErrs := releaseAfterStream(ctx, dataChan, errChan, releaseAdmission)
+	return admittedData, upstreamHeaders, admittedErrs
 }
 
 func validateSSEDataJSON(chunk []byte) error {
@@ -1974,18 +2182,43 @@ func finalInterceptorHeaders(current, intercepted http.Header) http.Header {
 	return cloneHeader(intercepted)
 }
 
-func downstreamHeadersFromExecutor(headers http.Header, passthrough bool) http.Header {
-	if !passthrough {
+// responseHeaderPolicy resolves the response header policy for a request. Provider rules are
+// keyed on the provider of the auth the manager selected, published in meta after execution.
+func (h *BaseAPIHandler) responseHeaderPolicy(meta map[string]any) responseHeaderPolicy {
+	cfg := h.CurrentConfig()
+	if cfg == nil || len(cfg.ResponseHeaders.Providers) == 0 || h.AuthManager == nil {
+		return responseHeaderPolicyFor(cfg, "")
+	}
+	provider := ""
+	if authID, ok := meta[coreexecutor.SelectedAuthMetadataKey].(string); ok && authID != "" {
+		if auth, found := h.AuthManager.GetByID(authID); found && auth != nil {
+			provider = auth.Provider
+		}
+	}
+	return responseHeaderPolicyFor(cfg, provider)
+}
+
+func downstreamHeadersFromExecutor(headers http.Header, policy responseHeaderPolicy) http.Header {
+	if !policy.forwards() {
 		return nil
 	}
-	return FilterUpstreamHeaders(headers)
+	return filterUpstreamHeaders(headers, policy)
 }
 
-func downstreamHeadersAfterInterceptors(baseRaw, finalRaw http.Header, passthrough bool) http.Header {
-	if passthrough {
-		return FilterUpstreamHeaders(finalRaw)
+func downstreamHeadersAfterInterceptors(baseRaw, finalRaw http.Header, policy responseHeaderPolicy) http.Header {
+	if policy.passthrough {
+		return filterUpstreamHeaders(finalRaw, policy)
+	}
+	// Interceptor-added headers are always forwarded; upstream headers only when allowed.
+	added := filterUpstreamHeaders(diffHeaders(baseRaw, finalRaw), responseHeaderPolicy{passthrough: true, block: policy.block})
+	allowed := filterUpstreamHeaders(finalRaw, policy)
+	if allowed == nil {
+		return added
+	}
+	for key, values := range added {
+		allowed[key] = values
 	}
-	return FilterUpstreamHeaders(diffHeaders(baseRaw, finalRaw))
+	return allowed
 }
 
 func diffHeaders(base, next http.Header) http.Header {
@@ -2374,7 +2607,7 @@ func (h *BaseAPIHandler) applyResponseInterceptors(ctx context.Context, handlerT
 		StatusCode:      statusCode,
 		Metadata:        opts.Metadata,
 	}, skipPluginID)
-	responseHeaders = downstreamHeadersAfterInterceptors(rawResponseHeaders, finalInterceptorHeaders(rawResponseHeaders, resp.Headers), PassthroughHeadersEnabled(h.CurrentConfig()))
+	responseHeaders = downstreamHeadersAfterInterceptors(rawResponseHeaders, finalInterceptorHeaders(rawResponseHeaders, resp.Headers), h.responseHeaderPolicy(opts.Metadata))
 	if len(resp.Body) > 0 {
 		body = cloneBytes(resp.Body)
 	}
@@ -2431,6 +2664,12 @@ func enrichAuthSelectionError(err error, providers []string, model string) error
 
 // WriteErrorResponse writes an error message to the response writer using the HTTP status embedded in the message.
 func (h *BaseAPIHandler) WriteErrorResponse(c *gin.Context, msg *interfaces.ErrorMessage) {
+	h.WriteErrorResponseForSurface(c, msg, ErrorSurfaceOpenAI)
+}
+
+// WriteErrorResponseForSurface is WriteErrorResponse with the body rendered in the error envelope of
+// surface (see BuildErrorResponseBodyForSurface).
+func (h *BaseAPIHandler) WriteErrorResponseForSurface(c *gin.Context, msg *interfaces.ErrorMessage, surface string) {
 	status := http.StatusInternalServerError
 	if msg != nil && msg.StatusCode > 0 {
 		status = msg.StatusCode
@@ -2492,7 +2731,9 @@ func (h *BaseAPIHandler) WriteErrorResponse(c *gin.Context, msg *interfaces.Erro
 		}
 	}
 	if len(body) == 0 {
-		body = BuildErrorResponseBody(status, errText)
+		body = BuildErrorResponseBodyForSurface(surface, status, errText)
+	} else if !strings.EqualFold(surface, ErrorSurfaceOpenAI) {
+		body = BuildErrorResponseBodyForSurface(surface, status, string(body))
 	}
 	// Append first to preserve upstream response logs, then drop duplicate payloads if already recorded.
 	var previous []byte
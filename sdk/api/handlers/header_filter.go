@@ -3,6 +3,8 @@ package handlers
 import (
 	"net/http"
 	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
 )
 
 // gatewayHeaderPrefixes lists header name prefixes injected by known AI gateway
@@ -39,6 +41,75 @@ var hopByHopHeaders = map[string]struct{}{
 // FilterUpstreamHeaders returns a copy of src with hop-by-hop and security-sensitive
 // headers removed. Returns nil if src is nil or empty after filtering.
 func FilterUpstreamHeaders(src http.Header) http.Header {
+	return filterUpstreamHeaders(src, responseHeaderPolicy{passthrough: true})
+}
+
+// responseHeaderPolicy decides which upstream response headers are forwarded downstream.
+type responseHeaderPolicy struct {
+	// passthrough forwards every header that survives the fixed filters.
+	passthrough bool
+	// allow matches headers forwarded even without passthrough; they also skip gateway stripping.
+	allow []string
+	// block matches headers that are never forwarded.
+	block []string
+}
+
+// forwards reports whether any upstream header can reach the client under this policy.
+func (p responseHeaderPolicy) forwards() bool {
+	return p.passthrough || len(p.allow) > 0
+}
+
+// responseHeaderPolicyFor builds the policy for a response served by provider. Provider rules
+// are appended to the global response-headers rules.
+func responseHeaderPolicyFor(cfg *config.SDKConfig, provider string) responseHeaderPolicy {
+	if cfg == nil {
+		return responseHeaderPolicy{}
+	}
+	policy := responseHeaderPolicy{passthrough: cfg.PassthroughHeaders}
+	policy.allow = appendHeaderPatterns(policy.allow, cfg.ResponseHeaders.Allow)
+	policy.block = appendHeaderPatterns(policy.block, cfg.ResponseHeaders.Block)
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	if provider == "" {
+		return policy
+	}
+	for name, rules := range cfg.ResponseHeaders.Providers {
+		if strings.ToLower(strings.TrimSpace(name)) != provider {
+			continue
+		}
+		policy.allow = appendHeaderPatterns(policy.allow, rules.Allow)
+		policy.block = appendHeaderPatterns(policy.block, rules.Block)
+	}
+	return policy
+}
+
+func appendHeaderPatterns(dst, patterns []string) []string {
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "" {
+			continue
+		}
+		dst = append(dst, pattern)
+	}
+	return dst
+}
+
+// matchHeaderPattern reports whether the lower-cased header name matches any pattern.
+func matchHeaderPattern(patterns []string, lowerKey string) bool {
+	for _, pattern := range patterns {
+		if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+			if strings.HasPrefix(lowerKey, prefix) {
+				return true
+			}
+			continue
+		}
+		if lowerKey == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+func filterUpstreamHeaders(src http.Header, policy responseHeaderPolicy) http.Header {
 	if src == nil {
 		return nil
 	}
@@ -52,9 +123,16 @@ func FilterUpstreamHeaders(src http.Header) http.Header {
 		if _, scoped := connectionScoped[canonicalKey]; scoped {
 			continue
 		}
+		lowerKey := strings.ToLower(key)
+		if matchHeaderPattern(policy.block, lowerKey) {
+			continue
+		}
+		allowed := matchHeaderPattern(policy.allow, lowerKey)
+		if !allowed && !policy.passthrough {
+			continue
+		}
 		// Strip headers injected by known AI gateway proxies to avoid
 		// Claude Code client-side gateway detection.
-		lowerKey := strings.ToLower(key)
 		gatewayMatch := false
 		for _, prefix := range gatewayHeaderPrefixes {
 			if strings.HasPrefix(lowerKey, prefix) {
@@ -62,7 +140,7 @@ func FilterUpstreamHeaders(src http.Header) http.Header {
 				break
 			}
 		}
-		if gatewayMatch {
+		if gatewayMatch && !allowed {
 			continue
 		}
 		dst[key] = values
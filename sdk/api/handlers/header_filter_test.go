@@ -3,6 +3,8 @@ package handlers
 import (
 	"net/http"
 	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
 )
 
 func TestFilterUpstreamHeaders_RemovesConnectionScopedHeaders(t *testing.T) {
@@ -53,3 +55,59 @@ func TestFilterUpstreamHeaders_ReturnsNilWhenAllHeadersBlocked(t *testing.T) {
 		t.Fatalf("expected nil when all headers are filtered, got %#v", filtered)
 	}
 }
+
+func TestResponseHeaderPolicyAllowAndBlock(t *testing.T) {
+	cfg := &config.SDKConfig{ResponseHeaders: config.ResponseHeadersConfig{
+		ResponseHeaderRules: config.ResponseHeaderRules{Block: []string{"X-Internal-*"}},
+		Providers: map[string]config.ResponseHeaderRules{
+			"Claude": {Allow: []string{"anthropic-ratelimit-*", "x-litellm-model"}},
+		},
+	}}
+	src := http.Header{}
+	src.Set("Anthropic-Ratelimit-Requests-Remaining", "99")
+	src.Set("X-Litellm-Model", "claude")
+	src.Set("X-Request-Id", "req-1")
+	src.Set("X-Internal-Trace", "t")
+	src.Set("Transfer-Encoding", "chunked")
+
+	claude := responseHeaderPolicyFor(cfg, "claude")
+	filtered := downstreamHeadersFromExecutor(src, claude)
+	if filtered.Get("Anthropic-Ratelimit-Requests-Remaining") != "99" || filtered.Get("X-Litellm-Model") != "claude" {
+		t.Fatalf("expected allowed headers without passthrough, got %#v", filtered)
+	}
+	if filtered.Get("X-Request-Id") != "" {
+		t.Fatalf("expected unlisted header to be dropped without passthrough, got %#v", filtered)
+	}
+
+	if got := downstreamHeadersFromExecutor(src, responseHeaderPolicyFor(cfg, "codex")); got != nil {
+		t.Fatalf("expected no headers for provider without rules, got %#v", got)
+	}
+
+	cfg.PassthroughHeaders = true
+	passthrough := downstreamHeadersFromExecutor(src, responseHeaderPolicyFor(cfg, "codex"))
+	if passthrough.Get("X-Request-Id") != "req-1" {
+		t.Fatalf("expected passthrough header, got %#v", passthrough)
+	}
+	for _, key := range []string{"X-Internal-Trace", "X-Litellm-Model", "Transfer-Encoding"} {
+		if passthrough.Get(key) != "" {
+			t.Fatalf("expected %s to be removed, got %#v", key, passthrough)
+		}
+	}
+}
+
+func TestDownstreamHeadersAfterInterceptorsKeepsAllowedUpstreamHeaders(t *testing.T) {
+	policy := responseHeaderPolicy{allow: []string{"openai-processing-ms"}}
+	base := http.Header{}
+	base.Set("Openai-Processing-Ms", "12")
+	base.Set("X-Request-Id", "req-1")
+	final := base.Clone()
+	final.Set("X-Plugin", "on")
+
+	got := downstreamHeadersAfterInterceptors(base, final, policy)
+	if got.Get("Openai-Processing-Ms") != "12" || got.Get("X-Plugin") != "on" {
+		t.Fatalf("expected allowed and interceptor headers, got %#v", got)
+	}
+	if got.Get("X-Request-Id") != "" {
+		t.Fatalf("expected unlisted upstream header to be dropped, got %#v", got)
+	}
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestWriteModelListingHonoursIfNoneMatch(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	body := []byte(`{"object":"list","data":[]}`)
+
+	cases := []struct {
+		name        string
+		ifNoneMatch string
+		wantStatus  int
+	}{
+		{name: "no validator", wantStatus: http.StatusOK},
+		{name: "matching", ifNoneMatch: `"openai-abc"`, wantStatus: http.StatusNotModified},
+		{name: "weak in list", ifNoneMatch: `"other", W/"openai-abc"`, wantStatus: http.StatusNotModified},
+		{name: "wildcard", ifNoneMatch: `*`, wantStatus: http.StatusNotModified},
+		{name: "stale", ifNoneMatch: `"openai-old"`, wantStatus: http.StatusOK},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			c.Request = httptest.NewRequest(http.MethodGet, "/v1/models", nil)
+			if tc.ifNoneMatch != "" {
+				c.Request.Header.Set("If-None-Match", tc.ifNoneMatch)
+			}
+
+			WriteModelListing(c, body, "openai-abc")
+
+			if recorder.Code != tc.wantStatus {
+				t.Fatalf("status = %d, want %d", recorder.Code, tc.wantStatus)
+			}
+			if got := recorder.Header().Get("ETag"); got != `"openai-abc"` {
+				t.Fatalf("ETag = %q, want %q", got, `"openai-abc"`)
+			}
+			wantBody := string(body)
+			if tc.wantStatus == http.StatusNotModified {
+				wantBody = ""
+			}
+			if got := recorder.Body.String(); got != wantBody {
+				t.Fatalf("body = %q, want %q", got, wantBody)
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ContinuationsHeader reports how many continuation requests were stitched into a
+// non-streaming chat completion.
+const ContinuationsHeader = "X-CPA-Continuations"
+
+// maxAutoContinuations caps auto-continue so a misconfiguration cannot loop indefinitely.
+const maxAutoContinuations = 10
+
+const continuationPrompt = "Continue exactly where your previous reply stopped. Do not repeat any text already written and do not add commentary."
+
+// autoContinueLimit returns the number of continuations allowed for this handler's config.
+func (h *OpenAIAPIHandler) autoContinueLimit() int {
+	cfg := h.CurrentConfig()
+	if cfg == nil || cfg.AutoContinue.MaxContinuations <= 0 {
+		return 0
+	}
+	return min(cfg.AutoContinue.MaxContinuations, maxAutoContinuations)
+}
+
+// buildContinuationRequest appends the partial assistant output and a continue prompt to the
+// original chat completions request.
+func buildContinuationRequest(rawJSON []byte, partial string, stream bool) ([]byte, error) {
+	out, err := sjson.SetBytes(rawJSON, "messages.-1", map[string]string{"role": "assistant", "content": partial})
+	if err != nil {
+		return nil, err
+	}
+	if out, err = sjson.SetBytes(out, "messages.-1", map[string]string{"role": "user", "content": continuationPrompt}); err != nil {
+		return nil, err
+	}
+	return sjson.SetBytes(out, "stream", stream)
+}
+
+// continueNonStreaming re-issues a truncated non-streaming completion up to limit times and
+// returns the last response carrying the stitched content and summed usage.
+func (h *OpenAIAPIHandler) continueNonStreaming(c *gin.Context, ctx context.Context, modelName string, rawJSON, resp []byte, limit int) []byte {
+	if gjson.GetBytes(resp, "choices.0.finish_reason").String() != "length" {
+		return resp
+	}
+	var content strings.Builder
+	content.WriteString(gjson.GetBytes(resp, "choices.0.message.content").String())
+	usage := map[string]int64{}
+	addUsage := func(payload []byte) {
+		for _, key := range []string{"prompt_tokens", "completion_tokens", "total_tokens"} {
+			usage[key] += gjson.GetBytes(payload, "usage."+key).Int()
+		}
+	}
+	addUsage(resp)
+
+	continuations := 0
+	for continuations < limit && gjson.GetBytes(resp, "choices.0.finish_reason").String() == "length" {
+		request, errBuild := buildContinuationRequest(rawJSON, content.String(), false)
+		if errBuild != nil {
+			log.Debugf("auto-continue: build request: %v", errBuild)
+			break
+		}
+		next, _, errMsg := h.ExecuteWithAuthManager(ctx, h.HandlerType(), modelName, request, h.GetAlt(c))
+		if errMsg != nil {
+			log.Debugf("auto-continue: continuation for %s failed: %v", modelName, errMsg.Error)
+			break
+		}
+		content.WriteString(gjson.GetBytes(next, "choices.0.message.content").String())
+		addUsage(next)
+		resp = next
+		continuations++
+	}
+	if continuations == 0 {
+		return resp
+	}
+	stitched, errSet := sjson.SetBytes(resp, "choices.0.message.content", content.String())
+	if errSet != nil {
+		return resp
+	}
+	if gjson.GetBytes(stitched, "usage").Exists() {
+		for key, value := range usage {
+			stitched, _ = sjson.SetBytes(stitched, "usage."+key, value)
+		}
+	}
+	c.Header(ContinuationsHeader, strconv.Itoa(continuations))
+	return stitched
+}
+
+// sseChunkPayload strips an optional "data:" prefix from a stream chunk.
+func sseChunkPayload(chunk []byte) []byte {
+	payload := bytes.TrimSpace(chunk)
+	if after, ok := bytes.CutPrefix(payload, []byte("data:")); ok {
+		payload = bytes.TrimSpace(after)
+	}
+	return payload
+}
+
+// continueStreaming wraps an upstream stream so a segment ending with finish_reason "length"
+// is followed by up to limit continuation streams. The intermediate finish_reason is cleared
+// so clients see a single uninterrupted completion.
+func (h *OpenAIAPIHandler) continueStreaming(c *gin.Context, ctx context.Context, modelName string, rawJSON []byte, data <-chan []byte, errs <-chan *interfaces.ErrorMessage, limit int) (<-chan []byte, <-chan *interfaces.ErrorMessage) {
+	outData := make(chan []byte)
+	outErrs := make(chan *interfaces.ErrorMessage, 1)
+	go func() {
+		defer close(outData)
+		defer close(outErrs)
+		var content strings.Builder
+		for continuations := 0; ; continuations++ {
+			canContinue := continuations < limit
+			truncated := false
+			for data != nil || errs != nil {
+				select {
+				case <-ctx.Done():
+					return
+				case chunk, ok := <-data:
+					if !ok {
+						data = nil
+						continue
+					}
+					payload := sseChunkPayload(chunk)
+					if delta := gjson.GetBytes(payload, "choices.0.delta.content"); delta.Type == gjson.String {
+						content.WriteString(delta.String())
+					}
+					if gjson.GetBytes(payload, "choices.0.finish_reason").String() == "length" {
+						truncated = true
+						if canContinue {
+							if cleared, errSet := sjson.SetRawBytes(payload, "choices.0.finish_reason", []byte("null")); errSet == nil {
+								chunk = cleared
+							}
+						}
+					}
+					select {
+					case <-ctx.Done():
+						return
+					case outData <- chunk:
+					}
+				case errMsg, ok := <-errs:
+					if !ok {
+						errs = nil
+						continue
+					}
+					if errMsg != nil {
+						outErrs <- errMsg
+						return
+					}
+				}
+			}
+			if !truncated || !canContinue {
+				return
+			}
+			request, errBuild := buildContinuationRequest(rawJSON, content.String(), true)
+			if errBuild != nil {
+				log.Debugf("auto-continue: build request: %v", errBuild)
+				return
+			}
+			data, _, errs = h.ExecuteStreamWithAuthManager(ctx, h.HandlerType(), modelName, request, h.GetAlt(c))
+		}
+	}()
+	return outData, outErrs
+}
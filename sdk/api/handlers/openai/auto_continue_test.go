@@ -0,0 +1,86 @@
+package openai
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestAutoContinueLimit(t *testing.T) {
+	cases := map[int]int{0: 0, -1: 0, 3: 3, 50: maxAutoContinuations}
+	for configured, want := range cases {
+		base := handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{AutoContinue: sdkconfig.AutoContinueConfig{MaxContinuations: configured}}, nil)
+		if got := NewOpenAIAPIHandler(base).autoContinueLimit(); got != want {
+			t.Fatalf("max-continuations %d: limit = %d, want %d", configured, got, want)
+		}
+	}
+}
+
+func TestBuildContinuationRequest(t *testing.T) {
+	raw := []byte(`{"model":"gpt-5","messages":[{"role":"user","content":"write a long file"}]}`)
+	out, err := buildContinuationRequest(raw, "package main\n", true)
+	if err != nil {
+		t.Fatalf("buildContinuationRequest() error = %v", err)
+	}
+	messages := gjson.GetBytes(out, "messages").Array()
+	if len(messages) != 3 || messages[1].Get("content").String() != "package main\n" || messages[2].Get("content").String() != continuationPrompt {
+		t.Fatalf("messages = %s", gjson.GetBytes(out, "messages").Raw)
+	}
+	if !gjson.GetBytes(out, "stream").Bool() {
+		t.Fatal("expected continuation to keep streaming")
+	}
+}
+
+func TestContinueStreamingPassesThroughWithoutTruncation(t *testing.T) {
+	h := NewOpenAIAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, nil))
+	data := make(chan []byte, 2)
+	errs := make(chan *interfaces.ErrorMessage)
+	data <- []byte(`data: {"choices":[{"delta":{"content":"hi"}}]}`)
+	data <- []byte(`data: {"choices":[{"delta":{},"finish_reason":"stop"}]}`)
+	close(data)
+	close(errs)
+
+	outData, outErrs := h.continueStreaming(nil, context.Background(), "gpt-5", []byte(`{}`), data, errs, 2)
+	var chunks [][]byte
+	for chunk := range outData {
+		chunks = append(chunks, chunk)
+	}
+	if len(chunks) != 2 {
+		t.Fatalf("chunks = %d, want 2", len(chunks))
+	}
+	if errMsg, ok := <-outErrs; ok {
+		t.Fatalf("unexpected error: %v", errMsg)
+	}
+}
+
+func TestContinueStreamingKeepsFinalLengthWhenLimitReached(t *testing.T) {
+	h := NewOpenAIAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, nil))
+	data := make(chan []byte, 1)
+	data <- []byte(`{"choices":[{"delta":{"content":"x"},"finish_reason":"length"}]}`)
+	close(data)
+
+	outData, _ := h.continueStreaming(nil, context.Background(), "gpt-5", []byte(`{}`), data, nil, 0)
+	chunk := <-outData
+	if got := gjson.GetBytes(sseChunkPayload(chunk), "choices.0.finish_reason").String(); got != "length" {
+		t.Fatalf("finish_reason = %q, want length", got)
+	}
+}
+
+func TestContinueStreamingForwardsErrors(t *testing.T) {
+	h := NewOpenAIAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, nil))
+	errs := make(chan *interfaces.ErrorMessage, 1)
+	errs <- &interfaces.ErrorMessage{StatusCode: http.StatusBadGateway, Error: errors.New("upstream failed")}
+	close(errs)
+
+	_, outErrs := h.continueStreaming(nil, context.Background(), "gpt-5", []byte(`{}`), nil, errs, 1)
+	errMsg, ok := <-outErrs
+	if !ok || errMsg == nil || errMsg.StatusCode != http.StatusBadGateway {
+		t.Fatalf("error = %v, want forwarded 502", errMsg)
+	}
+}
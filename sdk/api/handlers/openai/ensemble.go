@@ -0,0 +1,351 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// EnsembleModelHeader names the model whose completion an ensemble request returned in the
+// fastest and judge modes.
+const EnsembleModelHeader = "X-CPA-Ensemble-Model"
+
+const (
+	ensembleModeAll     = "all"
+	ensembleModeFastest = "fastest"
+	ensembleModeJudge   = "judge"
+
+	maxEnsembleModels = 8
+
+	ensembleStatusOK        = "ok"
+	ensembleStatusError     = "error"
+	ensembleStatusCancelled = "cancelled"
+)
+
+var ensembleJudgeNumber = regexp.MustCompile(`\d+`)
+
+// ensembleRequest is the resolved ensemble of one request.
+type ensembleRequest struct {
+	models     []string
+	mode       string
+	judgeModel string
+}
+
+// ensembleResult is the outcome of one model in the ensemble.
+type ensembleResult struct {
+	Model      string          `json:"model"`
+	Status     string          `json:"status"`
+	LatencyMs  int64           `json:"latency_ms"`
+	StatusCode int             `json:"status_code,omitempty"`
+	Error      string          `json:"error,omitempty"`
+	Completion json.RawMessage `json:"completion,omitempty"`
+}
+
+// EnsembleChatCompletions handles POST /v1/ensemble/chat/completions. The chat completion is sent
+// to every ensemble model in parallel; depending on the mode the response carries all completions,
+// the fastest successful one, or the one a judge model considers best.
+func (h *OpenAIAPIHandler) EnsembleChatCompletions(c *gin.Context) {
+	rawJSON, err := handlers.ReadRequestBody(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: fmt.Sprintf("Invalid request: %v", err),
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+	ensemble, errResolve := h.resolveEnsembleRequest(rawJSON)
+	if errResolve != nil {
+		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: errResolve.Error(),
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+	body, errDelete := sjson.DeleteBytes(rawJSON, "ensemble")
+	if errDelete != nil {
+		body = rawJSON
+	}
+
+	c.Header("Content-Type", "application/json")
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	results := h.runEnsemble(cliCtx, c, ensemble.models, body, ensemble.mode == ensembleModeFastest)
+
+	if ensemble.mode == ensembleModeAll {
+		c.JSON(http.StatusOK, gin.H{
+			"object":    "ensemble.chat.completion",
+			"created":   time.Now().Unix(),
+			"mode":      ensemble.mode,
+			"responses": results,
+		})
+		cliCancel()
+		return
+	}
+
+	selected := fastestEnsembleResult(results)
+	if selected < 0 {
+		errMsg := ensembleFailure(results)
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	metadata := gin.H{"mode": ensemble.mode}
+	if ensemble.mode == ensembleModeJudge {
+		metadata["judge_model"] = ensemble.judgeModel
+		judged, reason, errJudge := h.judgeEnsemble(cliCtx, c, ensemble.judgeModel, body, results)
+		if errJudge != nil {
+			log.Debugf("ensemble: judge %s failed, returning the fastest completion: %v", ensemble.judgeModel, errJudge)
+			metadata["judge_error"] = errJudge.Error()
+		} else {
+			selected = judged
+			metadata["judge_reason"] = reason
+		}
+	}
+	metadata["selected_model"] = results[selected].Model
+	candidates := make([]ensembleResult, len(results))
+	for i, result := range results {
+		result.Completion = nil
+		candidates[i] = result
+	}
+	metadata["candidates"] = candidates
+
+	resp := []byte(results[selected].Completion)
+	if withMetadata, errSet := sjson.SetBytes(resp, "ensemble", metadata); errSet == nil {
+		resp = withMetadata
+	}
+	c.Header(EnsembleModelHeader, results[selected].Model)
+	_, _ = c.Writer.Write(resp)
+	cliCancel()
+}
+
+// resolveEnsembleRequest merges the request's "ensemble" object over the configured defaults.
+func (h *OpenAIAPIHandler) resolveEnsembleRequest(rawJSON []byte) (ensembleRequest, error) {
+	var resolved ensembleRequest
+	if cfg := h.CurrentConfig(); cfg != nil {
+		resolved.models = cfg.Ensemble.Models
+		resolved.mode = cfg.Ensemble.Mode
+		resolved.judgeModel = cfg.Ensemble.JudgeModel
+	}
+	override := gjson.GetBytes(rawJSON, "ensemble")
+	if models := override.Get("models"); models.IsArray() {
+		resolved.models = nil
+		for _, model := range models.Array() {
+			resolved.models = append(resolved.models, model.String())
+		}
+	}
+	if mode := override.Get("mode"); mode.Exists() {
+		resolved.mode = mode.String()
+	}
+	if judgeModel := override.Get("judge_model"); judgeModel.Exists() {
+		resolved.judgeModel = judgeModel.String()
+	}
+
+	models := make([]string, 0, len(resolved.models))
+	for _, model := range resolved.models {
+		if model = strings.TrimSpace(model); model != "" {
+			models = append(models, model)
+		}
+	}
+	resolved.models = models
+	resolved.mode = strings.ToLower(strings.TrimSpace(resolved.mode))
+	resolved.judgeModel = strings.TrimSpace(resolved.judgeModel)
+	if resolved.mode == "" {
+		resolved.mode = ensembleModeAll
+	}
+
+	switch {
+	case gjson.GetBytes(rawJSON, "stream").Bool():
+		return resolved, errors.New("streaming is not supported for ensemble requests")
+	case len(resolved.models) == 0:
+		return resolved, errors.New("no ensemble models: set ensemble.models in the request or the configuration")
+	case len(resolved.models) > maxEnsembleModels:
+		return resolved, fmt.Errorf("too many ensemble models: %d, the maximum is %d", len(resolved.models), maxEnsembleModels)
+	}
+	switch resolved.mode {
+	case ensembleModeAll, ensembleModeFastest:
+	case ensembleModeJudge:
+		if resolved.judgeModel == "" {
+			return resolved, errors.New("judge mode requires ensemble.judge_model")
+		}
+	default:
+		return resolved, fmt.Errorf("unknown ensemble mode %q: use all, fastest or judge", resolved.mode)
+	}
+	return resolved, nil
+}
+
+// runEnsemble executes body against every model in parallel. With stopOnFirst the remaining
+// requests are cancelled as soon as one succeeds.
+func (h *OpenAIAPIHandler) runEnsemble(ctx context.Context, c *gin.Context, models []string, body []byte, stopOnFirst bool) []ensembleResult {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	payloads := make([][]byte, len(models))
+	for i, model := range models {
+		payload, errSet := sjson.SetBytes(body, "model", model)
+		if errSet != nil {
+			payload = body
+		}
+		payloads[i] = payload
+	}
+
+	alt := h.GetAlt(c)
+	results := make([]ensembleResult, len(models))
+	done := make(chan int, len(models))
+	var settled atomic.Bool
+	for i, model := range models {
+		go func(i int, model string) {
+			started := time.Now()
+			resp, _, errMsg := h.ExecuteWithAuthManager(runCtx, h.HandlerType(), model, payloads[i], alt)
+			result := ensembleResult{Model: model, LatencyMs: time.Since(started).Milliseconds()}
+			switch {
+			case errMsg == nil:
+				result.Status = ensembleStatusOK
+				result.Completion = resp
+			case settled.Load():
+				result.Status = ensembleStatusCancelled
+			default:
+				result.Status = ensembleStatusError
+				result.StatusCode = errMsg.StatusCode
+				if errMsg.Error != nil {
+					result.Error = errMsg.Error.Error()
+				}
+			}
+			results[i] = result
+			done <- i
+		}(i, model)
+	}
+	for range models {
+		i := <-done
+		if stopOnFirst && results[i].Status == ensembleStatusOK && !settled.Swap(true) {
+			cancel()
+		}
+	}
+	return results
+}
+
+// fastestEnsembleResult returns the index of the successful result with the lowest latency, or -1.
+func fastestEnsembleResult(results []ensembleResult) int {
+	selected := -1
+	for i, result := range results {
+		if result.Status != ensembleStatusOK {
+			continue
+		}
+		if selected < 0 || result.LatencyMs < results[selected].LatencyMs {
+			selected = i
+		}
+	}
+	return selected
+}
+
+// ensembleFailure builds the error returned when no model in the ensemble succeeded.
+func ensembleFailure(results []ensembleResult) *interfaces.ErrorMessage {
+	status := http.StatusBadGateway
+	failures := make([]string, 0, len(results))
+	for _, result := range results {
+		failures = append(failures, fmt.Sprintf("%s: %s", result.Model, result.Error))
+		if result.StatusCode > 0 && len(failures) == 1 {
+			status = result.StatusCode
+		}
+	}
+	return &interfaces.ErrorMessage{
+		StatusCode: status,
+		Error:      fmt.Errorf("every ensemble model failed: %s", strings.Join(failures, "; ")),
+	}
+}
+
+// judgeEnsemble asks judgeModel to pick the best successful completion and returns its index in
+// results with the judge's reason.
+func (h *OpenAIAPIHandler) judgeEnsemble(ctx context.Context, c *gin.Context, judgeModel string, body []byte, results []ensembleResult) (int, string, error) {
+	candidates := make([]int, 0, len(results))
+	for i, result := range results {
+		if result.Status == ensembleStatusOK {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 1 {
+		return candidates[0], "only one model succeeded", nil
+	}
+	judgeJSON, errBuild := buildEnsembleJudgeRequest(judgeModel, body, results, candidates)
+	if errBuild != nil {
+		return 0, "", errBuild
+	}
+	resp, _, errMsg := h.ExecuteWithAuthManager(ctx, h.HandlerType(), judgeModel, judgeJSON, h.GetAlt(c))
+	if errMsg != nil {
+		if errMsg.Error != nil {
+			return 0, "", errMsg.Error
+		}
+		return 0, "", fmt.Errorf("judge request failed with status %d", errMsg.StatusCode)
+	}
+	choice, reason, errParse := parseEnsembleVerdict(gjson.GetBytes(resp, "choices.0.message.content").String(), len(candidates))
+	if errParse != nil {
+		return 0, "", errParse
+	}
+	return candidates[choice-1], reason, nil
+}
+
+// buildEnsembleJudgeRequest builds the chat completion asking judgeModel to compare the candidates.
+func buildEnsembleJudgeRequest(judgeModel string, body []byte, results []ensembleResult, candidates []int) ([]byte, error) {
+	var prompt strings.Builder
+	prompt.WriteString("Conversation:\n")
+	for _, message := range gjson.GetBytes(body, "messages").Array() {
+		content := message.Get("content")
+		text := content.Raw
+		if content.Type == gjson.String {
+			text = content.String()
+		}
+		fmt.Fprintf(&prompt, "[%s]\n%s\n\n", message.Get("role").String(), text)
+	}
+	for n, i := range candidates {
+		fmt.Fprintf(&prompt, "Candidate %d:\n%s\n\n", n+1, gjson.GetBytes(results[i].Completion, "choices.0.message.content").String())
+	}
+
+	request := map[string]any{
+		"model": judgeModel,
+		"messages": []map[string]string{
+			{
+				"role": "system",
+				"content": "You compare candidate answers to the last message of a conversation and pick the best one " +
+					"for correctness, completeness and clarity. Reply with only a JSON object " +
+					`{"best": <candidate number>, "reason": "<one sentence>"}.`,
+			},
+			{"role": "user", "content": prompt.String()},
+		},
+	}
+	return json.Marshal(request)
+}
+
+// parseEnsembleVerdict extracts the 1-based candidate number and reason from the judge's reply.
+func parseEnsembleVerdict(content string, candidates int) (int, string, error) {
+	content = strings.TrimSpace(content)
+	if start, end := strings.Index(content, "{"), strings.LastIndex(content, "}"); start >= 0 && end > start {
+		verdict := gjson.Parse(content[start : end+1])
+		if best := verdict.Get("best"); best.Exists() {
+			if choice := int(best.Int()); choice >= 1 && choice <= candidates {
+				return choice, verdict.Get("reason").String(), nil
+			}
+		}
+	}
+	if match := ensembleJudgeNumber.FindString(content); match != "" {
+		if choice, errAtoi := strconv.Atoi(match); errAtoi == nil && choice >= 1 && choice <= candidates {
+			return choice, content, nil
+		}
+	}
+	return 0, "", fmt.Errorf("judge reply does not name a candidate between 1 and %d", candidates)
+}
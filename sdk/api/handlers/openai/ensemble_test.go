@@ -0,0 +1,103 @@
+package openai
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestResolveEnsembleRequest(t *testing.T) {
+	base := handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{Ensemble: sdkconfig.EnsembleConfig{
+		Models:     []string{"gpt-5", "claude-sonnet-4"},
+		JudgeModel: "gpt-5",
+	}}, nil)
+	h := NewOpenAIAPIHandler(base)
+
+	resolved, err := h.resolveEnsembleRequest([]byte(`{"messages":[]}`))
+	if err != nil {
+		t.Fatalf("resolveEnsembleRequest() error = %v", err)
+	}
+	if resolved.mode != ensembleModeAll || len(resolved.models) != 2 {
+		t.Fatalf("resolved = %+v, want configured models in all mode", resolved)
+	}
+
+	resolved, err = h.resolveEnsembleRequest([]byte(`{"ensemble":{"models":["a"," b ",""],"mode":"Judge"}}`))
+	if err != nil {
+		t.Fatalf("resolveEnsembleRequest() error = %v", err)
+	}
+	if resolved.mode != ensembleModeJudge || resolved.judgeModel != "gpt-5" || len(resolved.models) != 2 || resolved.models[1] != "b" {
+		t.Fatalf("resolved = %+v, want request override with configured judge", resolved)
+	}
+
+	for _, body := range []string{
+		`{"stream":true}`,
+		`{"ensemble":{"models":[]}}`,
+		`{"ensemble":{"mode":"vote"}}`,
+		`{"ensemble":{"mode":"judge","judge_model":""}}`,
+		`{"ensemble":{"models":["1","2","3","4","5","6","7","8","9"]}}`,
+	} {
+		if _, err = h.resolveEnsembleRequest([]byte(body)); err == nil {
+			t.Fatalf("resolveEnsembleRequest(%s) expected error", body)
+		}
+	}
+}
+
+func TestFastestEnsembleResult(t *testing.T) {
+	results := []ensembleResult{
+		{Model: "a", Status: ensembleStatusError, LatencyMs: 5},
+		{Model: "b", Status: ensembleStatusOK, LatencyMs: 40},
+		{Model: "c", Status: ensembleStatusOK, LatencyMs: 20},
+	}
+	if got := fastestEnsembleResult(results); got != 2 {
+		t.Fatalf("fastestEnsembleResult() = %d, want 2", got)
+	}
+	if got := fastestEnsembleResult(results[:1]); got != -1 {
+		t.Fatalf("fastestEnsembleResult() = %d, want -1 without successes", got)
+	}
+	if errMsg := ensembleFailure(results[:1]); errMsg.StatusCode != 502 {
+		t.Fatalf("ensembleFailure() status = %d, want 502", errMsg.StatusCode)
+	}
+}
+
+func TestBuildEnsembleJudgeRequest(t *testing.T) {
+	results := []ensembleResult{
+		{Model: "a", Status: ensembleStatusOK, Completion: []byte(`{"choices":[{"message":{"content":"four"}}]}`)},
+		{Model: "b", Status: ensembleStatusError},
+		{Model: "c", Status: ensembleStatusOK, Completion: []byte(`{"choices":[{"message":{"content":"4"}}]}`)},
+	}
+	out, err := buildEnsembleJudgeRequest("judge", []byte(`{"messages":[{"role":"user","content":"2+2?"}]}`), results, []int{0, 2})
+	if err != nil {
+		t.Fatalf("buildEnsembleJudgeRequest() error = %v", err)
+	}
+	if gjson.GetBytes(out, "model").String() != "judge" {
+		t.Fatalf("model = %s, want judge", gjson.GetBytes(out, "model").String())
+	}
+	prompt := gjson.GetBytes(out, "messages.1.content").String()
+	for _, want := range []string{"2+2?", "Candidate 1:\nfour", "Candidate 2:\n4"} {
+		if !strings.Contains(prompt, want) {
+			t.Fatalf("judge prompt %q does not contain %q", prompt, want)
+		}
+	}
+}
+
+func TestParseEnsembleVerdict(t *testing.T) {
+	cases := []struct {
+		content string
+		want    int
+	}{
+		{"```json\n{\"best\": 2, \"reason\": \"more precise\"}\n```", 2},
+		{"Candidate 1 is best.", 1},
+	}
+	for _, tc := range cases {
+		got, _, err := parseEnsembleVerdict(tc.content, 2)
+		if err != nil || got != tc.want {
+			t.Fatalf("parseEnsembleVerdict(%q) = %d, %v; want %d", tc.content, got, err, tc.want)
+		}
+	}
+	if _, _, err := parseEnsembleVerdict(`{"best": 3}`, 2); err == nil {
+		t.Fatal("expected error for out-of-range candidate")
+	}
+}
@@ -0,0 +1,36 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+)
+
+// Estimate handles POST /v1/estimate. It previews a chat, responses, Claude messages or Gemini
+// request: estimated prompt tokens, maximum possible cost, the provider/auth that would be
+// selected and whether the request fits the model's context. Nothing is sent upstream.
+func (h *OpenAIAPIHandler) Estimate(c *gin.Context) {
+	rawJSON, err := handlers.ReadRequestBody(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: fmt.Sprintf("Invalid request: %v", err),
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	estimate, errMsg := h.EstimateRequest(cliCtx, h.HandlerType(), c.Query("model"), rawJSON)
+	if errMsg != nil {
+		h.WriteErrorResponse(c, errMsg)
+		cliCancel(errMsg.Error)
+		return
+	}
+	c.JSON(http.StatusOK, estimate)
+	cliCancel()
+}
@@ -0,0 +1,135 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// JSONModeHeader reports the outcome of JSON mode validation for chat completions that
+// requested response_format json_object.
+const JSONModeHeader = "X-CPA-JSON-Mode"
+
+const (
+	jsonModeValid        = "valid"
+	jsonModeInvalid      = "invalid"
+	jsonModeRepaired     = "repaired"
+	jsonModeRepairFailed = "repair-failed"
+)
+
+type jsonModeSettings struct {
+	validate bool
+	repair   bool
+}
+
+// jsonModeSettingsFor returns the JSON mode checks that apply to a chat completions request.
+func (h *OpenAIAPIHandler) jsonModeSettingsFor(rawJSON []byte) jsonModeSettings {
+	cfg := h.CurrentConfig()
+	if cfg == nil || gjson.GetBytes(rawJSON, "response_format.type").String() != "json_object" {
+		return jsonModeSettings{}
+	}
+	return jsonModeSettings{
+		validate: cfg.JSONMode.Validate || cfg.JSONMode.Repair,
+		repair:   cfg.JSONMode.Repair,
+	}
+}
+
+// validateJSONContent returns the parse error when content is not a JSON document.
+func validateJSONContent(content string) error {
+	var value any
+	return json.Unmarshal([]byte(strings.TrimSpace(content)), &value)
+}
+
+// enforceJSONMode validates a non-streaming chat completion and, when enabled, re-asks the model
+// once with the parse error. It sets JSONModeHeader and returns the response to send.
+func (h *OpenAIAPIHandler) enforceJSONMode(c *gin.Context, ctx context.Context, modelName string, rawJSON, resp []byte, repair bool) []byte {
+	content := gjson.GetBytes(resp, "choices.0.message.content").String()
+	errParse := validateJSONContent(content)
+	if errParse == nil {
+		c.Header(JSONModeHeader, jsonModeValid)
+		return resp
+	}
+	if !repair {
+		c.Header(JSONModeHeader, jsonModeInvalid)
+		return resp
+	}
+	repairJSON, errBuild := buildJSONRepairRequest(rawJSON, content, errParse)
+	if errBuild != nil {
+		log.Debugf("json mode: build repair request: %v", errBuild)
+		c.Header(JSONModeHeader, jsonModeInvalid)
+		return resp
+	}
+	repaired, _, errMsg := h.ExecuteWithAuthManager(ctx, h.HandlerType(), modelName, repairJSON, h.GetAlt(c))
+	if errMsg != nil {
+		log.Debugf("json mode: repair request for %s failed: %v", modelName, errMsg.Error)
+		c.Header(JSONModeHeader, jsonModeRepairFailed)
+		return resp
+	}
+	if errRepaired := validateJSONContent(gjson.GetBytes(repaired, "choices.0.message.content").String()); errRepaired != nil {
+		c.Header(JSONModeHeader, jsonModeRepairFailed)
+		return resp
+	}
+	c.Header(JSONModeHeader, jsonModeRepaired)
+	return repaired
+}
+
+// buildJSONRepairRequest appends the invalid reply and a correction prompt to the original request.
+func buildJSONRepairRequest(rawJSON []byte, content string, errParse error) ([]byte, error) {
+	out, err := sjson.SetBytes(rawJSON, "messages.-1", map[string]string{"role": "assistant", "content": content})
+	if err != nil {
+		return nil, err
+	}
+	prompt := fmt.Sprintf("Your previous reply was not valid JSON (%v). Reply again with only the corrected JSON object and no other text.", errParse)
+	if out, err = sjson.SetBytes(out, "messages.-1", map[string]string{"role": "user", "content": prompt}); err != nil {
+		return nil, err
+	}
+	return sjson.SetBytes(out, "stream", false)
+}
+
+// jsonModeStream assembles streamed assistant content and reports its validity as a trailer,
+// because headers are already sent by the time the output is complete. A nil receiver is a no-op.
+type jsonModeStream struct {
+	content strings.Builder
+}
+
+func (h *OpenAIAPIHandler) newJSONModeStream(rawJSON []byte) *jsonModeStream {
+	if !h.jsonModeSettingsFor(rawJSON).validate {
+		return nil
+	}
+	return &jsonModeStream{}
+}
+
+// declareTrailer announces the trailer; it must run before the first body write.
+func (s *jsonModeStream) declareTrailer(c *gin.Context) {
+	if s == nil {
+		return
+	}
+	c.Writer.Header().Add("Trailer", JSONModeHeader)
+}
+
+func (s *jsonModeStream) observe(chunk []byte) {
+	if s == nil {
+		return
+	}
+	if delta := gjson.GetBytes(sseChunkPayload(chunk), "choices.0.delta.content"); delta.Type == gjson.String {
+		s.content.WriteString(delta.String())
+	}
+}
+
+func (s *jsonModeStream) finish(c *gin.Context) {
+	if s == nil {
+		return
+	}
+	outcome := jsonModeValid
+	if errParse := validateJSONContent(s.content.String()); errParse != nil {
+		outcome = jsonModeInvalid
+		log.Debugf("json mode: streamed output is not valid JSON: %v", errParse)
+	}
+	c.Writer.Header().Set(JSONModeHeader, outcome)
+}
@@ -0,0 +1,72 @@
+package openai
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+func TestJSONModeSettingsFor(t *testing.T) {
+	base := handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{JSONMode: sdkconfig.JSONModeConfig{Repair: true}}, nil)
+	h := NewOpenAIAPIHandler(base)
+
+	settings := h.jsonModeSettingsFor([]byte(`{"response_format":{"type":"json_object"}}`))
+	if !settings.validate || !settings.repair {
+		t.Fatalf("settings = %+v, want validate and repair", settings)
+	}
+	if settings = h.jsonModeSettingsFor([]byte(`{"response_format":{"type":"text"}}`)); settings.validate {
+		t.Fatalf("settings = %+v, want disabled without json_object", settings)
+	}
+}
+
+func TestBuildJSONRepairRequest(t *testing.T) {
+	raw := []byte(`{"model":"gpt-5","stream":true,"messages":[{"role":"user","content":"list colors"}]}`)
+	out, err := buildJSONRepairRequest(raw, `{"colors": [red]}`, errors.New("invalid character 'r'"))
+	if err != nil {
+		t.Fatalf("buildJSONRepairRequest() error = %v", err)
+	}
+	messages := gjson.GetBytes(out, "messages").Array()
+	if len(messages) != 3 {
+		t.Fatalf("messages = %d, want 3", len(messages))
+	}
+	if messages[1].Get("role").String() != "assistant" || messages[1].Get("content").String() != `{"colors": [red]}` {
+		t.Fatalf("assistant message = %s", messages[1].Raw)
+	}
+	if messages[2].Get("role").String() != "user" {
+		t.Fatalf("repair prompt = %s", messages[2].Raw)
+	}
+	if gjson.GetBytes(out, "stream").Bool() {
+		t.Fatal("expected repair request to disable streaming")
+	}
+}
+
+func TestJSONModeStreamReportsTrailer(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cases := map[string]string{
+		jsonModeValid:   `{"a":1}`,
+		jsonModeInvalid: `{"a":`,
+	}
+	for want, content := range cases {
+		rec := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(rec)
+		s := &jsonModeStream{}
+		s.declareTrailer(c)
+		for _, r := range content {
+			payload, _ := sjson.SetBytes([]byte(`{"choices":[{"delta":{}}]}`), "choices.0.delta.content", string(r))
+			s.observe(append([]byte("data: "), payload...))
+		}
+		s.finish(c)
+		if got := c.Writer.Header().Get(JSONModeHeader); got != want {
+			t.Fatalf("content %q: %s = %q, want %q", content, JSONModeHeader, got, want)
+		}
+	}
+
+	var disabled *jsonModeStream
+	disabled.observe([]byte(`data: {}`))
+}
@@ -0,0 +1,257 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// LongContextChunksHeader reports how many chunks a "-longctx" request was summarized in.
+const LongContextChunksHeader = "X-CPA-Longctx-Chunks"
+
+// longContextSuffix selects the map-reduce pipeline when appended to a model name.
+const longContextSuffix = "-longctx"
+
+const (
+	defaultLongContextChunkTokens = 8000
+	defaultLongContextMaxChunks   = 64
+	defaultLongContextConcurrency = 4
+
+	// longContextQuestionChars bounds the question quoted in every chunk request when the last
+	// user message is itself part of the document.
+	longContextQuestionChars = 4000
+)
+
+const longContextMapPrompt = "You read one excerpt of a long document or conversation. Extract every fact, quote, number " +
+	"and detail from the excerpt that could help answer the question. Be concise but do not omit relevant " +
+	"information. If nothing in the excerpt is relevant, reply with exactly \"NONE\"."
+
+// longContextSettings returns the long-context config with defaults applied, and whether it is enabled.
+func (h *OpenAIAPIHandler) longContextSettings() (sdkconfig.LongContextConfig, bool) {
+	cfg := h.CurrentConfig()
+	if cfg == nil || !cfg.LongContext.Enabled {
+		return sdkconfig.LongContextConfig{}, false
+	}
+	settings := cfg.LongContext
+	if settings.ChunkTokens <= 0 {
+		settings.ChunkTokens = defaultLongContextChunkTokens
+	}
+	if settings.MaxChunks <= 0 {
+		settings.MaxChunks = defaultLongContextMaxChunks
+	}
+	if settings.Concurrency <= 0 {
+		settings.Concurrency = defaultLongContextConcurrency
+	}
+	return settings, true
+}
+
+// longContextTarget returns the target model of a "-longctx" request.
+func longContextTarget(rawJSON []byte) (string, bool) {
+	target, ok := strings.CutSuffix(gjson.GetBytes(rawJSON, "model").String(), longContextSuffix)
+	return target, ok && target != ""
+}
+
+// longContextDocument is a chat completion split into what gets summarized and what is asked.
+type longContextDocument struct {
+	system   []gjson.Result
+	text     string
+	question string
+}
+
+// splitLongContextDocument separates system messages, the text to summarize and the question,
+// which is the last user message. A last user message too long for one chunk is summarized with
+// the rest, and only its tail is used as the question.
+func splitLongContextDocument(rawJSON []byte, chunkChars int) longContextDocument {
+	var doc longContextDocument
+	messages := gjson.GetBytes(rawJSON, "messages").Array()
+	last := -1
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Get("role").String() == "user" {
+			last = i
+			break
+		}
+	}
+	var text strings.Builder
+	for i, message := range messages {
+		role := message.Get("role").String()
+		if role == "system" || role == "developer" {
+			doc.system = append(doc.system, message)
+			continue
+		}
+		content := chatMessageText(message.Get("content"))
+		if i == last {
+			doc.question = content
+			if len(content) <= chunkChars {
+				continue
+			}
+			if len(content) > longContextQuestionChars {
+				doc.question = content[runeStart(content, len(content)-longContextQuestionChars):]
+			}
+		}
+		fmt.Fprintf(&text, "[%s]\n%s\n\n", role, content)
+	}
+	doc.text = strings.TrimSpace(text.String())
+	return doc
+}
+
+// chatMessageText returns the text of a chat message content, joining text parts.
+func chatMessageText(content gjson.Result) string {
+	if content.Type == gjson.String {
+		return content.String()
+	}
+	var parts []string
+	content.ForEach(func(_, part gjson.Result) bool {
+		if part.Get("type").String() == "text" {
+			parts = append(parts, part.Get("text").String())
+		}
+		return true
+	})
+	return strings.Join(parts, "\n")
+}
+
+// splitLongContextChunks cuts text into chunks of at most chunkChars, preferring to break after a
+// newline in the second half of a chunk.
+func splitLongContextChunks(text string, chunkChars int) []string {
+	var chunks []string
+	for len(text) > chunkChars {
+		cut := chunkChars
+		if idx := strings.LastIndexByte(text[:chunkChars], '\n'); idx > chunkChars/2 {
+			cut = idx + 1
+		} else if start := runeStart(text, cut); start > 0 {
+			cut = start
+		}
+		chunks = append(chunks, text[:cut])
+		text = text[cut:]
+	}
+	if strings.TrimSpace(text) != "" {
+		chunks = append(chunks, text)
+	}
+	return chunks
+}
+
+// runeStart moves i back to the start of the UTF-8 sequence containing s[i].
+func runeStart(s string, i int) int {
+	for i > 0 && !utf8.RuneStart(s[i]) {
+		i--
+	}
+	return i
+}
+
+// reduceLongContext rewrites a "-longctx" chat completion for its target model. When the
+// conversation does not fit in one chunk, every chunk is condensed on the summary model and the
+// conversation is replaced by the notes and the question; otherwise only the suffix is removed.
+func (h *OpenAIAPIHandler) reduceLongContext(c *gin.Context, rawJSON []byte, target string, settings sdkconfig.LongContextConfig) ([]byte, *interfaces.ErrorMessage) {
+	out, errSet := sjson.SetBytes(rawJSON, "model", target)
+	if errSet != nil {
+		return nil, &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: errSet}
+	}
+	chunkChars := settings.ChunkTokens * 4
+	doc := splitLongContextDocument(rawJSON, chunkChars)
+	if len(doc.text) <= chunkChars {
+		return out, nil
+	}
+	chunks := splitLongContextChunks(doc.text, chunkChars)
+	if len(chunks) > settings.MaxChunks {
+		return nil, &interfaces.ErrorMessage{
+			StatusCode: http.StatusRequestEntityTooLarge,
+			Error:      fmt.Errorf("long-context: document splits into %d chunks, more than the limit of %d", len(chunks), settings.MaxChunks),
+		}
+	}
+	summaryModel := settings.SummaryModel
+	if summaryModel == "" {
+		summaryModel = target
+	}
+
+	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+	notes, errMsg := h.summarizeLongContextChunks(cliCtx, c, summaryModel, doc.question, chunks, settings.Concurrency)
+	if errMsg != nil {
+		cliCancel(errMsg.Error)
+		return nil, errMsg
+	}
+	cliCancel()
+
+	messages := make([]json.RawMessage, 0, len(doc.system)+1)
+	for _, message := range doc.system {
+		messages = append(messages, json.RawMessage(message.Raw))
+	}
+	user, _ := json.Marshal(map[string]string{"role": "user", "content": buildLongContextReducePrompt(notes, doc.question)})
+	messages = append(messages, user)
+	if out, errSet = sjson.SetBytes(out, "messages", messages); errSet != nil {
+		return nil, &interfaces.ErrorMessage{StatusCode: http.StatusInternalServerError, Error: errSet}
+	}
+	c.Header(LongContextChunksHeader, strconv.Itoa(len(chunks)))
+	return out, nil
+}
+
+// summarizeLongContextChunks condenses every chunk on model, at most concurrency at a time, and
+// returns the notes in chunk order. The first failure aborts the remaining chunks.
+func (h *OpenAIAPIHandler) summarizeLongContextChunks(ctx context.Context, c *gin.Context, model, question string, chunks []string, concurrency int) ([]string, *interfaces.ErrorMessage) {
+	runCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	alt := h.GetAlt(c)
+	notes := make([]string, len(chunks))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var once sync.Once
+	var failure *interfaces.ErrorMessage
+	for i, chunk := range chunks {
+		wg.Add(1)
+		go func(i int, chunk string) {
+			defer wg.Done()
+			select {
+			case sem <- struct{}{}:
+			case <-runCtx.Done():
+				return
+			}
+			defer func() { <-sem }()
+			request, _ := json.Marshal(map[string]any{
+				"model": model,
+				"messages": []map[string]string{
+					{"role": "system", "content": longContextMapPrompt},
+					{"role": "user", "content": fmt.Sprintf("Question:\n%s\n\nExcerpt %d of %d:\n%s", question, i+1, len(chunks), chunk)},
+				},
+			})
+			resp, _, errMsg := h.ExecuteWithAuthManager(runCtx, h.HandlerType(), model, request, alt)
+			if errMsg != nil {
+				once.Do(func() {
+					failure = errMsg
+					cancel()
+				})
+				return
+			}
+			notes[i] = strings.TrimSpace(gjson.GetBytes(resp, "choices.0.message.content").String())
+		}(i, chunk)
+	}
+	wg.Wait()
+	if failure != nil {
+		return nil, failure
+	}
+	return notes, nil
+}
+
+// buildLongContextReducePrompt combines the chunk notes and the question into the final prompt.
+func buildLongContextReducePrompt(notes []string, question string) string {
+	var prompt strings.Builder
+	prompt.WriteString("The conversation was too long to send in full. Below are notes extracted from each part of it, in order, followed by the final message to respond to.\n\n")
+	for i, note := range notes {
+		if note == "" || note == "NONE" {
+			continue
+		}
+		fmt.Fprintf(&prompt, "Notes from part %d of %d:\n%s\n\n", i+1, len(notes), note)
+	}
+	prompt.WriteString("Final message:\n")
+	prompt.WriteString(question)
+	return prompt.String()
+}
@@ -0,0 +1,101 @@
+package openai
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestLongContextTarget(t *testing.T) {
+	if target, ok := longContextTarget([]byte(`{"model":"gpt-5-longctx"}`)); !ok || target != "gpt-5" {
+		t.Fatalf("longContextTarget() = %q, %v; want gpt-5, true", target, ok)
+	}
+	for _, body := range []string{`{"model":"gpt-5"}`, `{"model":"-longctx"}`} {
+		if _, ok := longContextTarget([]byte(body)); ok {
+			t.Fatalf("longContextTarget(%s) matched", body)
+		}
+	}
+}
+
+func TestLongContextSettingsDefaults(t *testing.T) {
+	h := NewOpenAIAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, nil))
+	if _, enabled := h.longContextSettings(); enabled {
+		t.Fatal("long context enabled without config")
+	}
+
+	h = NewOpenAIAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{LongContext: sdkconfig.LongContextConfig{Enabled: true, MaxChunks: 3}}, nil))
+	settings, enabled := h.longContextSettings()
+	if !enabled || settings.ChunkTokens != defaultLongContextChunkTokens || settings.MaxChunks != 3 || settings.Concurrency != defaultLongContextConcurrency {
+		t.Fatalf("settings = %+v, enabled = %v; want defaults with max-chunks 3", settings, enabled)
+	}
+}
+
+func TestSplitLongContextDocument(t *testing.T) {
+	body := []byte(`{"messages":[
+		{"role":"system","content":"be brief"},
+		{"role":"user","content":"first document"},
+		{"role":"assistant","content":[{"type":"text","text":"noted"}]},
+		{"role":"user","content":"what changed?"}
+	]}`)
+
+	doc := splitLongContextDocument(body, 100)
+	if len(doc.system) != 1 || doc.question != "what changed?" {
+		t.Fatalf("doc = %+v, want one system message and the last user message as question", doc)
+	}
+	if doc.text != "[user]\nfirst document\n\n[assistant]\nnoted" {
+		t.Fatalf("doc.text = %q", doc.text)
+	}
+
+	long := strings.Repeat("x", longContextQuestionChars+10) + "?"
+	doc = splitLongContextDocument([]byte(`{"messages":[{"role":"user","content":"`+long+`"}]}`), 100)
+	if !strings.Contains(doc.text, long) || len(doc.question) != longContextQuestionChars || !strings.HasSuffix(doc.question, "?") {
+		t.Fatalf("oversized question: text %d bytes, question %d bytes", len(doc.text), len(doc.question))
+	}
+}
+
+func TestSplitLongContextChunks(t *testing.T) {
+	text := strings.Repeat("a", 60) + "\n" + strings.Repeat("b", 60) + "\n" + strings.Repeat("c", 30)
+	chunks := splitLongContextChunks(text, 100)
+	if len(chunks) != 2 || !strings.HasSuffix(chunks[0], "\n") || strings.Join(chunks, "") != text {
+		t.Fatalf("chunks = %q, want a newline break preserving the text", chunks)
+	}
+
+	runes := strings.Repeat("é", 10)
+	for _, chunk := range splitLongContextChunks(runes, 5) {
+		if !strings.HasPrefix(chunk, "é") {
+			t.Fatalf("chunk %q splits a multi-byte rune", chunk)
+		}
+	}
+}
+
+func TestReduceLongContextPassesShortRequestsThrough(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	h := NewOpenAIAPIHandler(handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, nil))
+
+	body := []byte(`{"model":"gpt-5-longctx","messages":[{"role":"user","content":"hi"}]}`)
+	out, errMsg := h.reduceLongContext(c, body, "gpt-5", sdkconfig.LongContextConfig{ChunkTokens: 100, MaxChunks: 4, Concurrency: 1})
+	if errMsg != nil {
+		t.Fatalf("reduceLongContext() error = %v", errMsg.Error)
+	}
+	if gjson.GetBytes(out, "model").String() != "gpt-5" || gjson.GetBytes(out, "messages.0.content").String() != "hi" {
+		t.Fatalf("out = %s, want model rewritten and messages kept", out)
+	}
+
+	big := []byte(`{"model":"gpt-5-longctx","messages":[{"role":"user","content":"` + strings.Repeat("z", 5000) + `"}]}`)
+	if _, errMsg = h.reduceLongContext(c, big, "gpt-5", sdkconfig.LongContextConfig{ChunkTokens: 100, MaxChunks: 4, Concurrency: 1}); errMsg == nil || errMsg.StatusCode != 413 {
+		t.Fatalf("reduceLongContext() = %v, want 413 when chunks exceed max-chunks", errMsg)
+	}
+}
+
+func TestBuildLongContextReducePromptSkipsEmptyNotes(t *testing.T) {
+	prompt := buildLongContextReducePrompt([]string{"fact one", "NONE", ""}, "question?")
+	if !strings.Contains(prompt, "Notes from part 1 of 3:\nfact one") || strings.Contains(prompt, "part 2") || !strings.HasSuffix(prompt, "Final message:\nquestion?") {
+		t.Fatalf("prompt = %q", prompt)
+	}
+}
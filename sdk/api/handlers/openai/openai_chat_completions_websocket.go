@@ -0,0 +1,185 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	responsesconverter "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/openai/openai/responses"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// ChatCompletionsWebsocket handles the /v1/chat/completions/ws endpoint.
+// It is a transport bridge for clients behind intermediaries that buffer SSE: each text
+// message from the client is a Chat Completions request, and the response is delivered as
+// one text message per chunk carrying exactly the JSON payload the SSE endpoint would emit
+// in its "data:" lines, followed by a "[DONE]" message. Requests on one connection are
+// served sequentially.
+func (h *OpenAIAPIHandler) ChatCompletionsWebsocket(c *gin.Context) {
+	conn, err := responsesWebsocketUpgrader.Upgrade(c.Writer, c.Request, websocketUpgradeHeaders(c.Request))
+	if err != nil {
+		return
+	}
+	clientIP := websocketClientAddress(c)
+	log.Infof("chat completions websocket: client connected remote=%s", clientIP)
+	defer func() {
+		if errClose := conn.Close(); errClose != nil {
+			log.Warnf("chat completions websocket: close connection error: %v", errClose)
+		}
+	}()
+
+	var secretDLPDone func()
+	defer func() {
+		if secretDLPDone != nil {
+			secretDLPDone()
+		}
+	}()
+
+	for {
+		msgType, payload, errReadMessage := conn.ReadMessage()
+		if errReadMessage != nil {
+			if websocket.IsCloseError(errReadMessage, websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseNoStatusReceived) {
+				log.Infof("chat completions websocket: client disconnected remote=%s", clientIP)
+			}
+			return
+		}
+		if msgType != websocket.TextMessage && msgType != websocket.BinaryMessage {
+			continue
+		}
+		if h.SecretDLP != nil {
+			redactedPayload, secretDLPSession, errDLP := h.SecretDLP.RedactGinPayload(c, payload)
+			if errDLP != nil {
+				errMsg := &interfaces.ErrorMessage{StatusCode: http.StatusUnprocessableEntity, Error: errDLP}
+				h.LoggingAPIResponseError(context.WithValue(context.Background(), "gin", c), errMsg)
+				if errWrite := writeChatCompletionsWebsocketError(conn, errMsg); errWrite != nil {
+					return
+				}
+				continue
+			}
+			if secretDLPSession != nil && secretDLPDone == nil {
+				secretDLPDone = h.SecretDLP.BeginRequest()
+			}
+			payload = redactedPayload
+		}
+
+		requestJSON, errMsg := normalizeChatCompletionsWebsocketRequest(payload)
+		if errMsg != nil {
+			if errWrite := writeChatCompletionsWebsocketError(conn, errMsg); errWrite != nil {
+				return
+			}
+			continue
+		}
+
+		modelName := gjson.GetBytes(requestJSON, "model").String()
+		cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
+		dataChan, _, errChan := h.ExecuteStreamWithAuthManager(cliCtx, h.HandlerType(), modelName, requestJSON, h.GetAlt(c))
+		if errForward := h.forwardChatCompletionsWebsocket(c, conn, cliCancel, dataChan, errChan); errForward != nil {
+			log.Warnf("chat completions websocket: forward failed remote=%s error=%v", clientIP, errForward)
+			return
+		}
+	}
+}
+
+// normalizeChatCompletionsWebsocketRequest validates a websocket request message and forces
+// streaming, since the websocket transport always delivers chunked responses.
+func normalizeChatCompletionsWebsocketRequest(payload []byte) ([]byte, *interfaces.ErrorMessage) {
+	trimmed := bytes.TrimSpace(payload)
+	if len(trimmed) == 0 || !gjson.ValidBytes(trimmed) || !gjson.ParseBytes(trimmed).IsObject() {
+		return nil, &interfaces.ErrorMessage{
+			StatusCode: http.StatusBadRequest,
+			Error:      fmt.Errorf("invalid request: websocket message must be a JSON object"),
+		}
+	}
+	requestJSON := bytes.Clone(trimmed)
+	if shouldTreatAsResponsesFormat(requestJSON) {
+		modelName := gjson.GetBytes(requestJSON, "model").String()
+		requestJSON = responsesconverter.ConvertOpenAIResponsesRequestToOpenAIChatCompletions(modelName, requestJSON, true)
+	}
+	if strings.TrimSpace(gjson.GetBytes(requestJSON, "model").String()) == "" {
+		return nil, &interfaces.ErrorMessage{
+			StatusCode: http.StatusBadRequest,
+			Error:      fmt.Errorf("invalid request: model is required"),
+		}
+	}
+	updated, errSet := sjson.SetBytes(requestJSON, "stream", true)
+	if errSet != nil {
+		return nil, &interfaces.ErrorMessage{StatusCode: http.StatusBadRequest, Error: errSet}
+	}
+	return updated, nil
+}
+
+// forwardChatCompletionsWebsocket writes one streamed response to the websocket. It returns a
+// non-nil error only when the connection is no longer usable.
+func (h *OpenAIAPIHandler) forwardChatCompletionsWebsocket(
+	c *gin.Context,
+	conn *websocket.Conn,
+	cancel handlers.APIHandlerCancelFunc,
+	data <-chan []byte,
+	errs <-chan *interfaces.ErrorMessage,
+) error {
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			cancel(c.Request.Context().Err())
+			return c.Request.Context().Err()
+		case errMsg, ok := <-errs:
+			if !ok {
+				errs = nil
+				continue
+			}
+			if errMsg == nil {
+				continue
+			}
+			h.LoggingAPIResponseError(context.WithValue(context.Background(), "gin", c), errMsg)
+			markAPIResponseTimestamp(c)
+			errWrite := writeChatCompletionsWebsocketError(conn, errMsg)
+			cancel(errMsg.Error)
+			return errWrite
+		case chunk, ok := <-data:
+			if !ok {
+				if errMsg, okPendingErr := handlers.PendingStreamError(errs); okPendingErr && errMsg != nil {
+					h.LoggingAPIResponseError(context.WithValue(context.Background(), "gin", c), errMsg)
+					markAPIResponseTimestamp(c)
+					errWrite := writeChatCompletionsWebsocketError(conn, errMsg)
+					cancel(errMsg.Error)
+					return errWrite
+				}
+				errWrite := conn.WriteMessage(websocket.TextMessage, []byte(wsDoneMarker))
+				cancel(nil)
+				return errWrite
+			}
+			for _, payload := range websocketJSONPayloadsFromChunk(chunk) {
+				markAPIResponseTimestamp(c)
+				if errWrite := conn.WriteMessage(websocket.TextMessage, payload); errWrite != nil {
+					cancel(errWrite)
+					return errWrite
+				}
+			}
+		}
+	}
+}
+
+// writeChatCompletionsWebsocketError sends the same error body the SSE endpoint emits for
+// terminal stream errors. The connection stays open for further requests.
+func writeChatCompletionsWebsocketError(conn *websocket.Conn, errMsg *interfaces.ErrorMessage) error {
+	status := http.StatusInternalServerError
+	errText := http.StatusText(status)
+	if errMsg != nil {
+		if errMsg.StatusCode > 0 {
+			status = errMsg.StatusCode
+			errText = http.StatusText(status)
+		}
+		if errMsg.Error != nil && strings.TrimSpace(errMsg.Error.Error()) != "" {
+			errText = errMsg.Error.Error()
+		}
+	}
+	return conn.WriteMessage(websocket.TextMessage, handlers.BuildErrorResponseBody(status, errText))
+}
@@ -0,0 +1,102 @@
+package openai
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/tidwall/gjson"
+)
+
+func TestNormalizeChatCompletionsWebsocketRequestForcesStream(t *testing.T) {
+	requestJSON, errMsg := normalizeChatCompletionsWebsocketRequest([]byte(`{"model":"gpt-5","messages":[{"role":"user","content":"hi"}],"stream":false}`))
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %v", errMsg.Error)
+	}
+	if !gjson.GetBytes(requestJSON, "stream").Bool() {
+		t.Fatalf("stream = false, want true; payload=%s", requestJSON)
+	}
+	if got := gjson.GetBytes(requestJSON, "model").String(); got != "gpt-5" {
+		t.Fatalf("model = %q, want gpt-5", got)
+	}
+}
+
+func TestNormalizeChatCompletionsWebsocketRequestRejectsInvalidPayload(t *testing.T) {
+	for _, payload := range []string{``, `not json`, `[]`, `{"messages":[]}`} {
+		_, errMsg := normalizeChatCompletionsWebsocketRequest([]byte(payload))
+		if errMsg == nil {
+			t.Fatalf("expected error for payload %q", payload)
+		}
+		if errMsg.StatusCode != http.StatusBadRequest {
+			t.Fatalf("status = %d, want %d for payload %q", errMsg.StatusCode, http.StatusBadRequest, payload)
+		}
+	}
+}
+
+func TestForwardChatCompletionsWebsocketWritesChunkPayloads(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	serverErrCh := make(chan error, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := responsesWebsocketUpgrader.Upgrade(w, r, nil)
+		if err != nil {
+			serverErrCh <- err
+			return
+		}
+		defer func() {
+			_ = conn.Close()
+		}()
+
+		ctx, _ := gin.CreateTestContext(httptest.NewRecorder())
+		ctx.Request = r
+
+		data := make(chan []byte, 2)
+		errCh := make(chan *interfaces.ErrorMessage)
+		data <- []byte("data: {\"id\":\"chatcmpl-1\",\"choices\":[{\"delta\":{\"content\":\"he\"}}]}\n\n")
+		data <- []byte("data: {\"id\":\"chatcmpl-1\",\"choices\":[{\"delta\":{\"content\":\"llo\"}}]}\n\n")
+		close(data)
+		close(errCh)
+
+		serverErrCh <- (*OpenAIAPIHandler)(nil).forwardChatCompletionsWebsocket(ctx, conn, func(...interface{}) {}, data, errCh)
+	}))
+	defer server.Close()
+
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+	conn, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("dial websocket: %v", err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	var content string
+	for i := 0; i < 2; i++ {
+		_, payload, errReadMessage := conn.ReadMessage()
+		if errReadMessage != nil {
+			t.Fatalf("read websocket message: %v", errReadMessage)
+		}
+		if strings.HasPrefix(string(payload), "data:") {
+			t.Fatalf("payload kept SSE framing: %s", payload)
+		}
+		content += gjson.GetBytes(payload, "choices.0.delta.content").String()
+	}
+	if content != "hello" {
+		t.Fatalf("content = %q, want hello", content)
+	}
+	_, payload, errReadMessage := conn.ReadMessage()
+	if errReadMessage != nil {
+		t.Fatalf("read websocket done message: %v", errReadMessage)
+	}
+	if string(payload) != wsDoneMarker {
+		t.Fatalf("final payload = %s, want %s", payload, wsDoneMarker)
+	}
+
+	if errServer := <-serverErrCh; errServer != nil {
+		t.Fatalf("server error: %v", errServer)
+	}
+}
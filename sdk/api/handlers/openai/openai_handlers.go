@@ -87,6 +87,18 @@ func (h *OpenAIAPIHandler) Models() []map[string]any {
 	return modelRegistry.GetAvailableModels("openai")
 }
 
+// scopedModels returns the models the client's API key may use.
+func (h *OpenAIAPIHandler) scopedModels(c *gin.Context) []map[string]any {
+	models := h.Models()
+	scoped := models[:0]
+	for _, model := range models {
+		if id, _ := model["id"].(string); h.APIKeyAllowsModel(c, id) {
+			scoped = append(scoped, model)
+		}
+	}
+	return scoped
+}
+
 // OpenAIModels handles the /v1/models endpoint.
 // It returns a list of available AI models with their capabilities
 // and specifications in OpenAI-compatible format.
@@ -96,13 +108,20 @@ func (h *OpenAIAPIHandler) OpenAIModels(c *gin.Context) {
 		return
 	}
 
-	// Get all available models
-	allModels := h.Models()
-
-	c.JSON(http.StatusOK, gin.H{
-		"object": "list",
-		"data":   allModels,
-	})
+	data, digest, errJSON := registry.GetGlobalRegistry().GetAvailableModelsJSON(h.HandlerType())
+	if errJSON != nil {
+		c.JSON(http.StatusOK, gin.H{
+			"object": "list",
+			"data":   h.scopedModels(c),
+		})
+		return
+	}
+	data, digest = h.ScopeModelListing(c, data, digest)
+	body := make([]byte, 0, len(data)+32)
+	body = append(body, `{"object":"list","data":`...)
+	body = append(body, data...)
+	body = append(body, '}')
+	handlers.WriteModelListing(c, body, "openai-"+digest)
 }
 
 // ChatCompletions handles the /v1/chat/completions endpoint.
@@ -124,6 +143,17 @@ func (h *OpenAIAPIHandler) ChatCompletions(c *gin.Context) {
 		return
 	}
 
+	// Render server-side prompt templates before anything inspects the messages.
+	if rawJSON, err = h.applyPromptTemplate(rawJSON); err != nil {
+		c.JSON(http.StatusBadRequest, handlers.ErrorResponse{
+			Error: handlers.ErrorDetail{
+				Message: fmt.Sprintf("Invalid request: %v", err),
+				Type:    "invalid_request_error",
+			},
+		})
+		return
+	}
+
 	// Check if the client requested a streaming response.
 	streamResult := gjson.GetBytes(rawJSON, "stream")
 	stream := streamResult.Type == gjson.True
@@ -136,6 +166,19 @@ func (h *OpenAIAPIHandler) ChatCompletions(c *gin.Context) {
 		stream = gjson.GetBytes(rawJSON, "stream").Bool()
 	}
 
+	// "<model>-longctx" condenses conversations too long for the model before answering.
+	if target, ok := longContextTarget(rawJSON); ok {
+		if settings, enabled := h.longContextSettings(); enabled {
+			reduced, errMsg := h.reduceLongContext(c, rawJSON, target, settings)
+			if errMsg != nil {
+				h.WriteErrorResponse(c, errMsg)
+				return
+			}
+			rawJSON = reduced
+		}
+	}
+	rawJSON = h.CompressConversation(c, handlers.CompressionFormatOpenAI, rawJSON)
+
 	if stream {
 		h.handleStreamingResponse(c, rawJSON)
 	} else {
@@ -454,6 +497,15 @@ func (h *OpenAIAPIHandler) handleNonStreamingResponse(c *gin.Context, rawJSON []
 		return
 	}
 	handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
+	if limit := h.autoContinueLimit(); limit > 0 {
+		resp = h.continueNonStreaming(c, cliCtx, modelName, rawJSON, resp, limit)
+	}
+	if processors := h.outputProcessorsFor(c, modelName); len(processors) > 0 {
+		resp = applyOutputProcessors(resp, processors)
+	}
+	if settings := h.jsonModeSettingsFor(rawJSON); settings.validate {
+		resp = h.enforceJSONMode(c, cliCtx, modelName, rawJSON, resp, settings.repair)
+	}
 	_, _ = c.Writer.Write(resp)
 	cliCancel()
 }
@@ -481,6 +533,11 @@ func (h *OpenAIAPIHandler) handleStreamingResponse(c *gin.Context, rawJSON []byt
 	modelName := gjson.GetBytes(rawJSON, "model").String()
 	cliCtx, cliCancel := h.GetContextWithCancel(h, c, context.Background())
 	dataChan, upstreamHeaders, errChan := h.ExecuteStreamWithAuthManager(cliCtx, h.HandlerType(), modelName, rawJSON, h.GetAlt(c))
+	if limit := h.autoContinueLimit(); limit > 0 {
+		dataChan, errChan = h.continueStreaming(c, cliCtx, modelName, rawJSON, dataChan, errChan, limit)
+	}
+	dataChan = postProcessStream(cliCtx, dataChan, h.outputProcessorsFor(c, modelName))
+	jsonMode := h.newJSONModeStream(rawJSON)
 
 	setSSEHeaders := func() {
 		c.Header("Content-Type", "text/event-stream")
@@ -490,6 +547,7 @@ func (h *OpenAIAPIHandler) handleStreamingResponse(c *gin.Context, rawJSON []byt
 		if cfg := h.CurrentConfig(); cfg != nil && cfg.Streaming.DisableProxyBuffering {
 			c.Header("X-Accel-Buffering", "no") // Disable proxy buffering for SSE
 		}
+		jsonMode.declareTrailer(c)
 	}
 
 	// Peek at the first chunk to determine success or failure before setting headers
@@ -540,11 +598,12 @@ func (h *OpenAIAPIHandler) handleStreamingResponse(c *gin.Context, rawJSON []byt
 			setSSEHeaders()
 			handlers.WriteUpstreamHeaders(c.Writer.Header(), upstreamHeaders)
 
+			jsonMode.observe(chunk)
 			_ = writeOpenAISSEData(c.Writer, chunk)
 			flusher.Flush()
 
 			// Continue streaming the rest
-			h.handleStreamResult(c, flusher, func(err error) { cliCancel(err) }, dataChan, errChan)
+			h.handleStreamResult(c, flusher, func(err error) { cliCancel(err) }, dataChan, errChan, jsonMode)
 			return
 		}
 	}
@@ -696,14 +755,15 @@ func (h *OpenAIAPIHandler) handleCompletionsStreamingResponse(c *gin.Context, ra
 			h.handleStreamResult(c, flusher, func(err error) {
 				stop()
 				cliCancel(err)
-			}, convertedChan, errChan)
+			}, convertedChan, errChan, nil)
 			return
 		}
 	}
 }
-func (h *OpenAIAPIHandler) handleStreamResult(c *gin.Context, flusher http.Flusher, cancel func(error), data <-chan []byte, errs <-chan *interfaces.ErrorMessage) {
+func (h *OpenAIAPIHandler) handleStreamResult(c *gin.Context, flusher http.Flusher, cancel func(error), data <-chan []byte, errs <-chan *interfaces.ErrorMessage, jsonMode *jsonModeStream) {
 	h.ForwardStream(c, flusher, cancel, data, errs, handlers.StreamForwardOptions{
 		WriteChunk: func(chunk []byte) {
+			jsonMode.observe(chunk)
 			_ = writeOpenAISSEData(c.Writer, chunk)
 		},
 		WriteTerminalError: func(errMsg *interfaces.ErrorMessage) {
@@ -723,6 +783,7 @@ func (h *OpenAIAPIHandler) handleStreamResult(c *gin.Context, flusher http.Flush
 		},
 		WriteDone: func() {
 			_, _ = fmt.Fprint(c.Writer, "data: [DONE]\n\n")
+			jsonMode.finish(c)
 		},
 	})
 }
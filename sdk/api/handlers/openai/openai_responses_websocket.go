@@ -476,6 +476,7 @@ func (h *OpenAIResponsesAPIHandler) ResponsesWebsocket(c *gin.Context) {
 		dataChan, _, errChan := h.ExecuteStreamWithAuthManager(cliCtx, h.HandlerType(), modelName, requestJSON, "")
 
 		completedOutput, completedResponseID, completedPendingToolCallIDs, forwardErrMsg, errForward := h.forwardResponsesWebsocket(c, conn, cliCancel, dataChan, errChan, wsTimelineLog, passthroughSessionID)
+		recordResponsesWebsocketTranscript(cliCtx, passthroughSessionID, modelName, lastAttemptedAuthID, payload, completedOutput, completedResponseID, forwardErrMsg, errForward)
 		if errForward != nil {
 			wsTerminateErr = errForward
 			log.Warnf("responses websocket: forward failed id=%s error=%v", passthroughSessionID, errForward)
@@ -513,6 +514,39 @@ func (h *OpenAIResponsesAPIHandler) ResponsesWebsocket(c *gin.Context) {
 	}
 }
 
+// recordResponsesWebsocketTranscript appends one websocket turn to the session transcript when
+// session transcripts are enabled.
+func recordResponsesWebsocketTranscript(ctx context.Context, sessionID, modelName, authID string, request, output []byte, responseID string, errMsg *interfaces.ErrorMessage, errForward error) {
+	if !requestlogging.SessionTranscriptsEnabled() {
+		return
+	}
+	entry := requestlogging.TranscriptEntry{
+		SessionID: sessionID,
+		RequestID: requestlogging.GetRequestID(ctx),
+		Model:     modelName,
+		AuthID:    authID,
+		Request:   bytes.Clone(request),
+	}
+	if len(output) > 0 || responseID != "" {
+		response, errMarshal := json.Marshal(struct {
+			ID     string          `json:"id,omitempty"`
+			Output json.RawMessage `json:"output,omitempty"`
+		}{ID: responseID, Output: output})
+		if errMarshal == nil {
+			entry.Response = response
+		}
+	}
+	switch {
+	case errMsg != nil && errMsg.Error != nil:
+		entry.Error = errMsg.Error.Error()
+	case errForward != nil:
+		entry.Error = errForward.Error()
+	}
+	if errAppend := requestlogging.AppendSessionTranscript(entry); errAppend != nil {
+		log.Warnf("responses websocket: record transcript failed id=%s error=%v", sessionID, errAppend)
+	}
+}
+
 func websocketClientAddress(c *gin.Context) string {
 	if c == nil || c.Request == nil {
 		return ""
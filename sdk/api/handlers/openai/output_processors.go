@@ -0,0 +1,307 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// Output processor names accepted in output-processors rules.
+const (
+	outputProcessorStripCodeFences      = "strip-code-fences"
+	outputProcessorNormalizeLineEndings = "normalize-line-endings"
+	outputProcessorTrailingNewline      = "trailing-newline"
+)
+
+// textProcessor transforms assistant text incrementally. push receives each streamed fragment
+// and returns the text that can be emitted now; finish returns any held-back remainder.
+type textProcessor interface {
+	push(text string) string
+	finish() string
+}
+
+// outputPipeline chains text processors in configuration order.
+type outputPipeline struct {
+	processors []textProcessor
+}
+
+func newOutputPipeline(names []string) *outputPipeline {
+	p := &outputPipeline{}
+	for _, name := range names {
+		switch name {
+		case outputProcessorStripCodeFences:
+			p.processors = append(p.processors, &codeFenceStripper{})
+		case outputProcessorNormalizeLineEndings:
+			p.processors = append(p.processors, &lineEndingNormalizer{})
+		case outputProcessorTrailingNewline:
+			p.processors = append(p.processors, &trailingNewlineEnforcer{})
+		}
+	}
+	return p
+}
+
+func (p *outputPipeline) push(text string) string {
+	for _, proc := range p.processors {
+		text = proc.push(text)
+	}
+	return text
+}
+
+func (p *outputPipeline) finish() string {
+	out := ""
+	for _, proc := range p.processors {
+		out = proc.push(out) + proc.finish()
+	}
+	return out
+}
+
+// outputProcessorsFor resolves the processor names of every rule matching the client API key
+// and requested model.
+func (h *OpenAIAPIHandler) outputProcessorsFor(c *gin.Context, modelName string) []string {
+	cfg := h.CurrentConfig()
+	if cfg == nil || len(cfg.OutputProcessors) == 0 {
+		return nil
+	}
+	apiKey := ""
+	if c != nil {
+		apiKey = c.GetString("userApiKey")
+	}
+	var names []string
+	for _, rule := range cfg.OutputProcessors {
+		if !outputRuleMatches(rule, apiKey, modelName) {
+			continue
+		}
+		for _, name := range rule.Processors {
+			name = strings.ToLower(strings.TrimSpace(name))
+			switch name {
+			case outputProcessorStripCodeFences, outputProcessorNormalizeLineEndings, outputProcessorTrailingNewline:
+				names = append(names, name)
+			default:
+				log.Debugf("output processors: ignoring unknown processor %q", name)
+			}
+		}
+	}
+	return names
+}
+
+func outputRuleMatches(rule internalconfig.OutputProcessorRule, apiKey, modelName string) bool {
+	if len(rule.APIKeys) > 0 {
+		matched := false
+		for _, key := range rule.APIKeys {
+			if strings.TrimSpace(key) == apiKey {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if len(rule.Models) == 0 {
+		return true
+	}
+	for _, pattern := range rule.Models {
+		if wildcardMatch(pattern, modelName) {
+			return true
+		}
+	}
+	return false
+}
+
+// wildcardMatch reports whether value matches pattern case-insensitively, where "*" matches
+// any run of characters.
+func wildcardMatch(pattern, value string) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	value = strings.ToLower(strings.TrimSpace(value))
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == value
+	}
+	if !strings.HasPrefix(value, parts[0]) {
+		return false
+	}
+	value = value[len(parts[0]):]
+	for _, part := range parts[1 : len(parts)-1] {
+		idx := strings.Index(value, part)
+		if idx < 0 {
+			return false
+		}
+		value = value[idx+len(part):]
+	}
+	return strings.HasSuffix(value, parts[len(parts)-1])
+}
+
+// applyOutputProcessors rewrites the message content of every choice in a non-streaming response.
+func applyOutputProcessors(resp []byte, names []string) []byte {
+	for i, choice := range gjson.GetBytes(resp, "choices").Array() {
+		content := choice.Get("message.content")
+		if content.Type != gjson.String {
+			continue
+		}
+		pipeline := newOutputPipeline(names)
+		processed := pipeline.push(content.String()) + pipeline.finish()
+		if updated, errSet := sjson.SetBytes(resp, fmt.Sprintf("choices.%d.message.content", i), processed); errSet == nil {
+			resp = updated
+		}
+	}
+	return resp
+}
+
+// postProcessStream rewrites delta content of streamed chunks, flushing held-back text into the
+// chunk that carries each choice's finish_reason.
+func postProcessStream(ctx context.Context, data <-chan []byte, names []string) <-chan []byte {
+	if data == nil || len(names) == 0 {
+		return data
+	}
+	out := make(chan []byte)
+	go func() {
+		defer close(out)
+		pipelines := make(map[int64]*outputPipeline)
+		for chunk := range data {
+			chunk = processStreamChunk(chunk, names, pipelines)
+			select {
+			case <-ctx.Done():
+				return
+			case out <- chunk:
+			}
+		}
+	}()
+	return out
+}
+
+func processStreamChunk(chunk []byte, names []string, pipelines map[int64]*outputPipeline) []byte {
+	payload := sseChunkPayload(chunk)
+	choices := gjson.GetBytes(payload, "choices")
+	if !choices.IsArray() {
+		return chunk
+	}
+	changed := false
+	for i, choice := range choices.Array() {
+		index := choice.Get("index").Int()
+		pipeline := pipelines[index]
+		if pipeline == nil {
+			pipeline = newOutputPipeline(names)
+			pipelines[index] = pipeline
+		}
+		content := choice.Get("delta.content")
+		text := ""
+		if content.Type == gjson.String {
+			text = pipeline.push(content.String())
+		}
+		if finish := choice.Get("finish_reason"); finish.Type == gjson.String && finish.String() != "" {
+			text += pipeline.finish()
+		}
+		if content.Type != gjson.String && text == "" {
+			continue
+		}
+		if updated, errSet := sjson.SetBytes(payload, fmt.Sprintf("choices.%d.delta.content", i), text); errSet == nil {
+			payload = updated
+			changed = true
+		}
+	}
+	if !changed {
+		return chunk
+	}
+	return payload
+}
+
+// codeFenceStripper removes a markdown code fence wrapping the whole output.
+type codeFenceStripper struct {
+	head    strings.Builder
+	decided bool
+	fenced  bool
+	tail    string
+}
+
+func (p *codeFenceStripper) push(text string) string {
+	if !p.decided {
+		p.head.WriteString(text)
+		buffered := p.head.String()
+		trimmed := strings.TrimLeft(buffered, " \t\r\n")
+		switch {
+		case len(trimmed) < 3 && strings.HasPrefix("```", trimmed):
+			return ""
+		case strings.HasPrefix(trimmed, "```"):
+			newline := strings.Index(trimmed, "\n")
+			if newline < 0 {
+				return ""
+			}
+			p.decided, p.fenced = true, true
+			text = trimmed[newline+1:]
+		default:
+			p.decided = true
+			return buffered
+		}
+	}
+	if !p.fenced {
+		return text
+	}
+	// Hold back a trailing line that may turn out to be the closing fence.
+	combined := p.tail + text
+	if idx := strings.LastIndex(combined, "\n"); idx >= 0 && strings.HasPrefix("```", strings.TrimRight(combined[idx+1:], " \t\r")) {
+		p.tail = combined[idx:]
+		return combined[:idx]
+	}
+	p.tail = ""
+	return combined
+}
+
+func (p *codeFenceStripper) finish() string {
+	if !p.decided {
+		return p.head.String()
+	}
+	tail := p.tail
+	p.tail = ""
+	if strings.TrimSpace(tail) == "```" {
+		return ""
+	}
+	return tail
+}
+
+// lineEndingNormalizer converts CRLF and lone CR line endings to LF, including CRLF pairs
+// split across fragments.
+type lineEndingNormalizer struct {
+	pendingCR bool
+}
+
+func (p *lineEndingNormalizer) push(text string) string {
+	if text == "" {
+		return ""
+	}
+	if p.pendingCR && strings.HasPrefix(text, "\n") {
+		text = text[1:]
+	}
+	p.pendingCR = strings.HasSuffix(text, "\r")
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.ReplaceAll(text, "\r", "\n")
+}
+
+func (p *lineEndingNormalizer) finish() string { return "" }
+
+// trailingNewlineEnforcer appends a newline when non-empty output does not end with one.
+type trailingNewlineEnforcer struct {
+	last  byte
+	wrote bool
+}
+
+func (p *trailingNewlineEnforcer) push(text string) string {
+	if text != "" {
+		p.last = text[len(text)-1]
+		p.wrote = true
+	}
+	return text
+}
+
+func (p *trailingNewlineEnforcer) finish() string {
+	if p.wrote && p.last != '\n' {
+		p.last = '\n'
+		return "\n"
+	}
+	return ""
+}
@@ -0,0 +1,108 @@
+package openai
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+func runPipeline(names []string, fragments ...string) string {
+	pipeline := newOutputPipeline(names)
+	var out strings.Builder
+	for _, fragment := range fragments {
+		out.WriteString(pipeline.push(fragment))
+	}
+	out.WriteString(pipeline.finish())
+	return out.String()
+}
+
+func TestOutputPipeline(t *testing.T) {
+	cases := []struct {
+		name      string
+		names     []string
+		fragments []string
+		want      string
+	}{
+		{
+			name:      "strip fences across fragments",
+			names:     []string{outputProcessorStripCodeFences},
+			fragments: []string{"``", "`go\nfunc main() {}", "\n`", "``"},
+			want:      "func main() {}",
+		},
+		{
+			name:      "unfenced output untouched",
+			names:     []string{outputProcessorStripCodeFences},
+			fragments: []string{"plain ", "text\n```not a fence"},
+			want:      "plain text\n```not a fence",
+		},
+		{
+			name:      "normalize split crlf",
+			names:     []string{outputProcessorNormalizeLineEndings},
+			fragments: []string{"a\r", "\nb\rc"},
+			want:      "a\nb\nc",
+		},
+		{
+			name:      "strip then trailing newline",
+			names:     []string{outputProcessorStripCodeFences, outputProcessorTrailingNewline},
+			fragments: []string{"```\nx = 1\n```"},
+			want:      "x = 1\n",
+		},
+		{
+			name:      "trailing newline on empty output",
+			names:     []string{outputProcessorTrailingNewline},
+			fragments: []string{""},
+			want:      "",
+		},
+	}
+	for _, tc := range cases {
+		if got := runPipeline(tc.names, tc.fragments...); got != tc.want {
+			t.Fatalf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestOutputProcessorsFor(t *testing.T) {
+	base := handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{OutputProcessors: []sdkconfig.OutputProcessorRule{
+		{Models: []string{"qwen*-coder*"}, Processors: []string{"strip-code-fences", "bogus"}},
+		{APIKeys: []string{"key-a"}, Processors: []string{"Trailing-Newline"}},
+	}}, nil)
+	h := NewOpenAIAPIHandler(base)
+
+	if got := h.outputProcessorsFor(nil, "Qwen3-Coder-480B"); len(got) != 1 || got[0] != outputProcessorStripCodeFences {
+		t.Fatalf("processors = %v, want [strip-code-fences]", got)
+	}
+	if got := h.outputProcessorsFor(nil, "gpt-5"); len(got) != 0 {
+		t.Fatalf("processors = %v, want none", got)
+	}
+}
+
+func TestPostProcessStreamFlushesOnFinish(t *testing.T) {
+	data := make(chan []byte, 3)
+	for _, fragment := range []string{"```py\nprint(1)", "\n```"} {
+		payload, _ := sjson.SetBytes([]byte(`{"choices":[{"index":0,"delta":{}}]}`), "choices.0.delta.content", fragment)
+		data <- append([]byte("data: "), payload...)
+	}
+	data <- []byte(`data: {"choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}`)
+	close(data)
+
+	var content strings.Builder
+	for chunk := range postProcessStream(context.Background(), data, []string{outputProcessorStripCodeFences, outputProcessorTrailingNewline}) {
+		content.WriteString(gjson.GetBytes(sseChunkPayload(chunk), "choices.0.delta.content").String())
+	}
+	if got := content.String(); got != "print(1)\n" {
+		t.Fatalf("streamed content = %q, want %q", got, "print(1)\n")
+	}
+}
+
+func TestApplyOutputProcessors(t *testing.T) {
+	resp := []byte(`{"choices":[{"message":{"role":"assistant","content":"a\r\nb"}}]}`)
+	out := applyOutputProcessors(resp, []string{outputProcessorNormalizeLineEndings, outputProcessorTrailingNewline})
+	if got := gjson.GetBytes(out, "choices.0.message.content").String(); got != "a\nb\n" {
+		t.Fatalf("content = %q, want %q", got, "a\nb\n")
+	}
+}
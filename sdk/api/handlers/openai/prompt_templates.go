@@ -0,0 +1,57 @@
+package openai
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/prompttemplate"
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+// applyPromptTemplate renders the prompt template named by the request's "template" field with
+// its "variables" and returns the request with the rendered messages placed before any messages
+// the client sent. Requests without a template are returned unchanged.
+func (h *OpenAIAPIHandler) applyPromptTemplate(rawJSON []byte) ([]byte, error) {
+	name := gjson.GetBytes(rawJSON, "template")
+	if !name.Exists() {
+		return rawJSON, nil
+	}
+	if name.Type != gjson.String || name.String() == "" {
+		return nil, errors.New("template must be a non-empty string")
+	}
+	var variables map[string]any
+	if raw := gjson.GetBytes(rawJSON, "variables"); raw.Exists() {
+		if !raw.IsObject() {
+			return nil, errors.New("variables must be an object")
+		}
+		if errUnmarshal := json.Unmarshal([]byte(raw.Raw), &variables); errUnmarshal != nil {
+			return nil, fmt.Errorf("variables: %w", errUnmarshal)
+		}
+	}
+	rendered, errRender := prompttemplate.Render(h.CurrentConfig(), name.String(), variables)
+	if errRender != nil {
+		return nil, errRender
+	}
+
+	messages := make([]any, 0, len(rendered.Messages))
+	for _, message := range rendered.Messages {
+		messages = append(messages, message)
+	}
+	for _, message := range gjson.GetBytes(rawJSON, "messages").Array() {
+		messages = append(messages, json.RawMessage(message.Raw))
+	}
+	out, errSet := sjson.SetBytes(rawJSON, "messages", messages)
+	if errSet != nil {
+		return nil, fmt.Errorf("set rendered messages: %w", errSet)
+	}
+	if rendered.Model != "" && gjson.GetBytes(out, "model").String() == "" {
+		if out, errSet = sjson.SetBytes(out, "model", rendered.Model); errSet != nil {
+			return nil, fmt.Errorf("set template model: %w", errSet)
+		}
+	}
+	out, _ = sjson.DeleteBytes(out, "template")
+	out, _ = sjson.DeleteBytes(out, "variables")
+	return out, nil
+}
@@ -0,0 +1,57 @@
+package openai
+
+import (
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/api/handlers"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+func TestApplyPromptTemplate(t *testing.T) {
+	base := handlers.NewBaseAPIHandlers(&sdkconfig.SDKConfig{PromptTemplates: sdkconfig.PromptTemplatesConfig{
+		Templates: []sdkconfig.PromptTemplate{{
+			Name:     "summarize",
+			Model:    "gpt-5",
+			Messages: []sdkconfig.PromptTemplateMessage{{Role: "system", Content: "Summarize in {{.words}} words."}},
+		}},
+	}}, nil)
+	h := NewOpenAIAPIHandler(base)
+
+	out, err := h.applyPromptTemplate([]byte(`{"template":"summarize","variables":{"words":20},"messages":[{"role":"user","content":"text"}],"stream":true}`))
+	if err != nil {
+		t.Fatalf("applyPromptTemplate() error = %v", err)
+	}
+	if gjson.GetBytes(out, "template").Exists() || gjson.GetBytes(out, "variables").Exists() {
+		t.Fatalf("template fields not removed: %s", out)
+	}
+	if got := gjson.GetBytes(out, "model").String(); got != "gpt-5" {
+		t.Fatalf("model = %q, want template default", got)
+	}
+	messages := gjson.GetBytes(out, "messages").Array()
+	if len(messages) != 2 || messages[0].Get("content").String() != "Summarize in 20 words." || messages[1].Get("content").String() != "text" {
+		t.Fatalf("messages = %s", gjson.GetBytes(out, "messages").Raw)
+	}
+	if !gjson.GetBytes(out, "stream").Bool() {
+		t.Fatal("other request fields must be kept")
+	}
+
+	out, err = h.applyPromptTemplate([]byte(`{"model":"claude-sonnet-4","template":"summarize","variables":{"words":5}}`))
+	if err != nil || gjson.GetBytes(out, "model").String() != "claude-sonnet-4" {
+		t.Fatalf("request model must win: %s, %v", out, err)
+	}
+
+	plain := []byte(`{"model":"gpt-5","messages":[]}`)
+	if out, err = h.applyPromptTemplate(plain); err != nil || string(out) != string(plain) {
+		t.Fatalf("request without template changed: %s, %v", out, err)
+	}
+	for _, body := range []string{
+		`{"template":"missing"}`,
+		`{"template":"summarize"}`,
+		`{"template":"summarize","variables":[1]}`,
+	} {
+		if _, err = h.applyPromptTemplate([]byte(body)); err == nil {
+			t.Fatalf("applyPromptTemplate(%s) expected error", body)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+// ProfileHeader selects a named profile for a single request, overriding active-profile.
+const ProfileHeader = "X-CPA-Profile"
+
+// requestProfileName returns the profile requested through ProfileHeader, if any.
+func requestProfileName(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil || ginCtx.Request == nil {
+		return ""
+	}
+	return strings.TrimSpace(ginCtx.GetHeader(ProfileHeader))
+}
+
+// resolveRequestProfile returns the profile governing a request: the one named by the
+// X-CPA-Profile header, otherwise the configured active-profile. A nil profile means the
+// request is unrestricted.
+func resolveRequestProfile(ctx context.Context, cfg *config.SDKConfig) (*config.ProfileConfig, *interfaces.ErrorMessage) {
+	if name := requestProfileName(ctx); name != "" {
+		profile, ok := cfg.FindProfile(name)
+		if !ok {
+			return nil, &interfaces.ErrorMessage{
+				StatusCode: http.StatusBadRequest,
+				Error:      fmt.Errorf("unknown profile %q", name),
+			}
+		}
+		return profile, nil
+	}
+	if cfg == nil || strings.TrimSpace(cfg.ActiveProfile) == "" {
+		return nil, nil
+	}
+	profile, ok := cfg.FindProfile(cfg.ActiveProfile)
+	if !ok {
+		// A stale active-profile must not take the proxy down; serve the request unrestricted.
+		return nil, nil
+	}
+	return profile, nil
+}
+
+// applyRequestProfile resolves the request profile and applies its model mappings, then resolves
+// a virtual model to the candidate serving rawJSON.
+func (h *BaseAPIHandler) applyRequestProfile(ctx context.Context, modelName string, rawJSON []byte) (*config.ProfileConfig, string, *interfaces.ErrorMessage) {
+	profile, errMsg := resolveRequestProfile(ctx, h.CurrentConfig())
+	if errMsg != nil {
+		return nil, modelName, errMsg
+	}
+	if profile != nil {
+		modelName = profile.MapModel(modelName)
+	}
+	modelName, errMsg = h.resolveVirtualModel(modelName, rawJSON)
+	if errMsg != nil {
+		return nil, modelName, errMsg
+	}
+	return profile, modelName, nil
+}
+
+// restrictProvidersToProfile drops providers the profile does not enable.
+func restrictProvidersToProfile(profile *config.ProfileConfig, providers []string, modelName string) ([]string, *interfaces.ErrorMessage) {
+	if profile == nil || len(profile.Providers) == 0 {
+		return providers, nil
+	}
+	allowed := make([]string, 0, len(providers))
+	for _, provider := range providers {
+		if profile.AllowsProvider(provider) {
+			allowed = append(allowed, provider)
+		}
+	}
+	if len(allowed) == 0 {
+		return nil, &interfaces.ErrorMessage{
+			StatusCode: http.StatusForbidden,
+			Error:      fmt.Errorf("model %s is not available in profile %q", modelName, profile.Name),
+		}
+	}
+	return allowed, nil
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+func newProfileTestContext(t *testing.T, profileHeader string) context.Context {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	if profileHeader != "" {
+		c.Request.Header.Set(ProfileHeader, profileHeader)
+	}
+	return context.WithValue(context.Background(), "gin", c)
+}
+
+func profileTestConfig() *sdkconfig.SDKConfig {
+	return &sdkconfig.SDKConfig{
+		ActiveProfile: "work",
+		Profiles: []sdkconfig.ProfileConfig{
+			{Name: "work", Providers: []string{"claude"}},
+			{
+				Name:          "offline",
+				Providers:     []string{"ollama"},
+				ModelMappings: []sdkconfig.ProfileModelMapping{{From: "gpt-5", To: "qwen3:32b"}},
+			},
+		},
+	}
+}
+
+func TestResolveRequestProfile(t *testing.T) {
+	cfg := profileTestConfig()
+
+	profile, errMsg := resolveRequestProfile(newProfileTestContext(t, ""), cfg)
+	if errMsg != nil || profile == nil || profile.Name != "work" {
+		t.Fatalf("expected active profile work, got %+v err=%v", profile, errMsg)
+	}
+
+	profile, errMsg = resolveRequestProfile(newProfileTestContext(t, "OFFLINE"), cfg)
+	if errMsg != nil || profile == nil || profile.Name != "offline" {
+		t.Fatalf("expected header profile offline, got %+v err=%v", profile, errMsg)
+	}
+
+	if _, errMsg = resolveRequestProfile(newProfileTestContext(t, "missing"), cfg); errMsg == nil || errMsg.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unknown header profile, got %v", errMsg)
+	}
+
+	cfg.ActiveProfile = "deleted"
+	if profile, errMsg = resolveRequestProfile(newProfileTestContext(t, ""), cfg); errMsg != nil || profile != nil {
+		t.Fatalf("expected stale active profile to be ignored, got %+v err=%v", profile, errMsg)
+	}
+}
+
+func TestApplyRequestProfileMapsModel(t *testing.T) {
+	h := NewBaseAPIHandlers(profileTestConfig(), nil)
+
+	profile, model, errMsg := h.applyRequestProfile(newProfileTestContext(t, "offline"), "GPT-5", nil)
+	if errMsg != nil {
+		t.Fatalf("unexpected error: %v", errMsg.Error)
+	}
+	if profile == nil || model != "qwen3:32b" {
+		t.Fatalf("model = %q, want qwen3:32b", model)
+	}
+}
+
+func TestRestrictProvidersToProfile(t *testing.T) {
+	profile := &sdkconfig.ProfileConfig{Name: "work", Providers: []string{"claude"}}
+
+	providers, errMsg := restrictProvidersToProfile(profile, []string{"codex", "claude"}, "claude-sonnet-4")
+	if errMsg != nil || len(providers) != 1 || providers[0] != "claude" {
+		t.Fatalf("providers = %v err=%v, want [claude]", providers, errMsg)
+	}
+
+	if _, errMsg = restrictProvidersToProfile(profile, []string{"codex"}, "gpt-5"); errMsg == nil || errMsg.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403 when no provider is enabled, got %v", errMsg)
+	}
+
+	all, errMsg := restrictProvidersToProfile(nil, []string{"codex"}, "gpt-5")
+	if errMsg != nil || len(all) != 1 {
+		t.Fatalf("expected nil profile to allow all providers, got %v err=%v", all, errMsg)
+	}
+}
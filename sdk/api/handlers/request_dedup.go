@@ -0,0 +1,289 @@
+package handlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// DeduplicatedHeader marks responses that were served from another identical in-flight request.
+const DeduplicatedHeader = "X-CPA-Deduplicated"
+
+// Buffered stream chunks are bounded so a long-running stream cannot grow without limit.
+// Once exceeded the entry stops accepting new joiners and drops the oldest chunks every
+// existing subscriber has been sent.
+const maxRequestDedupBufferBytes = 8 << 20
+
+var errDedupLeaderCanceled = errors.New("deduplicated request was canceled by the original client")
+
+// requestDeduplicator tracks in-flight requests keyed by a hash of the inbound API key,
+// endpoint, and payload so identical retries can share the first request's upstream call.
+type requestDeduplicator struct {
+	mu      sync.Mutex
+	entries map[string]*dedupEntry
+}
+
+type dedupEntry struct {
+	chunkBroadcast
+	// overflow is set once the buffer exceeded maxRequestDedupBufferBytes; the stream can then no
+	// longer be replayed from the start.
+	overflow bool
+	body     []byte
+	expires  time.Time
+}
+
+func newDedupEntry() *dedupEntry {
+	entry := &dedupEntry{}
+	entry.maxBytes = maxRequestDedupBufferBytes
+	return entry
+}
+
+func newRequestDeduplicator() *requestDeduplicator {
+	return &requestDeduplicator{entries: make(map[string]*dedupEntry)}
+}
+
+func (h *BaseAPIHandler) requestDeduplicator() *requestDeduplicator {
+	h.dedupOnce.Do(func() {
+		h.dedup = newRequestDeduplicator()
+	})
+	return h.dedup
+}
+
+// RequestDedupWindow reports whether request deduplication is enabled and how long completed
+// responses stay joinable after they finish.
+func RequestDedupWindow(cfg *config.SDKConfig) (time.Duration, bool) {
+	if cfg == nil || !cfg.RequestDedup.Enabled {
+		return 0, false
+	}
+	if cfg.RequestDedup.WindowSeconds <= 0 {
+		return 0, true
+	}
+	return time.Duration(cfg.RequestDedup.WindowSeconds) * time.Second, true
+}
+
+// requestDedupKey returns the deduplication key for a client request, or "" when the request
+// is not eligible. Only requests that arrived over HTTP are eligible; internal callers without
+// a gin context always execute directly.
+func requestDedupKey(ctx context.Context, entryProtocol, exitProtocol, modelName, alt string, rawJSON []byte, stream bool) string {
+	if ctx == nil || len(rawJSON) == 0 {
+		return ""
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil || ginCtx.Request == nil {
+		return ""
+	}
+	if pinnedAuthIDFromContext(ctx) != "" || executionSessionIDFromContext(ctx) != "" {
+		return ""
+	}
+	apiKey := ""
+	if raw, exists := ginCtx.Get("userApiKey"); exists {
+		if value, okString := raw.(string); okString {
+			apiKey = value
+		}
+	}
+	path := strings.TrimSpace(ginCtx.FullPath())
+	if path == "" && ginCtx.Request.URL != nil {
+		path = ginCtx.Request.URL.Path
+	}
+	mode := "unary"
+	if stream {
+		mode = "stream"
+	}
+	hasher := sha256.New()
+	profile := strings.TrimSpace(ginCtx.GetHeader(ProfileHeader))
+	for _, part := range []string{apiKey, path, profile, entryProtocol, exitProtocol, modelName, alt, mode} {
+		hasher.Write([]byte(part))
+		hasher.Write([]byte{0})
+	}
+	hasher.Write(rawJSON)
+	return hex.EncodeToString(hasher.Sum(nil))
+}
+
+// acquire returns the entry for key and whether the caller became its leader.
+// Expired completed entries are replaced so a stale response is never reused.
+func (d *requestDeduplicator) acquire(key string) (*dedupEntry, bool) {
+	now := time.Now()
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for k, entry := range d.entries {
+		if entry.expired(now) {
+			delete(d.entries, k)
+		}
+	}
+	if entry, ok := d.entries[key]; ok && entry.joinable() {
+		return entry, false
+	}
+	entry := newDedupEntry()
+	d.entries[key] = entry
+	return entry, true
+}
+
+// release marks the entry complete and either drops it or keeps it for the configured window.
+func (d *requestDeduplicator) release(key string, entry *dedupEntry, window time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	entry.mu.Lock()
+	keep := window > 0 && entry.errMsg == nil && !entry.overflow
+	if keep {
+		entry.expires = time.Now().Add(window)
+	}
+	entry.mu.Unlock()
+	if !keep && d.entries[key] == entry {
+		delete(d.entries, key)
+	}
+}
+
+func (e *dedupEntry) expired(now time.Time) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.done && !e.expires.IsZero() && now.After(e.expires)
+}
+
+func (e *dedupEntry) joinable() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.overflow {
+		return false
+	}
+	if e.done && (e.errMsg != nil || e.expires.IsZero()) {
+		return false
+	}
+	return true
+}
+
+func (e *dedupEntry) appendChunk(chunk []byte) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.size+len(chunk) > e.maxBytes {
+		e.overflow = true
+	}
+	e.appendLocked(chunk)
+}
+
+func (e *dedupEntry) finish(body []byte, errMsg *interfaces.ErrorMessage) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.body = cloneBytes(body)
+	e.finishLocked(errMsg)
+}
+
+// wait blocks until a non-streaming entry completes and returns a copy of its result.
+func (e *dedupEntry) wait(ctx context.Context) ([]byte, http.Header, *interfaces.ErrorMessage) {
+	for {
+		e.mu.Lock()
+		if e.done {
+			body, headers, errMsg := cloneBytes(e.body), dedupFollowerHeaders(e.headers), e.errMsg
+			e.mu.Unlock()
+			return body, headers, errMsg
+		}
+		updated := e.updatedLocked()
+		e.mu.Unlock()
+		select {
+		case <-ctx.Done():
+			return nil, nil, &interfaces.ErrorMessage{StatusCode: http.StatusRequestTimeout, Error: ctx.Err()}
+		case <-updated:
+		}
+	}
+}
+
+// subscribe replays buffered chunks from the start and then follows the live stream. It reports
+// false when the stream can no longer be replayed from the start because its buffer overflowed.
+func (e *dedupEntry) subscribe(ctx context.Context) (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage, bool) {
+	e.mu.Lock()
+	id, ok := e.attachLocked(0)
+	e.mu.Unlock()
+	if !ok {
+		return nil, nil, nil, false
+	}
+	headers := dedupFollowerHeaders(nil)
+	dataChan, errChan := e.follow(ctx, id, 0, headers, nil)
+	return dataChan, headers, errChan, true
+}
+
+func dedupFollowerHeaders(headers http.Header) http.Header {
+	out := cloneHeader(headers)
+	if out == nil {
+		out = make(http.Header)
+	}
+	out.Set(DeduplicatedHeader, "true")
+	return out
+}
+
+// executeDeduplicated runs execute once per identical in-flight request and hands the result
+// to every duplicate that arrives before it completes (or within the configured window).
+func (h *BaseAPIHandler) executeDeduplicated(ctx context.Context, key string, execute func() ([]byte, http.Header, *interfaces.ErrorMessage)) ([]byte, http.Header, *interfaces.ErrorMessage) {
+	window, enabled := RequestDedupWindow(h.CurrentConfig())
+	if !enabled || key == "" {
+		return execute()
+	}
+	dedup := h.requestDeduplicator()
+	entry, leader := dedup.acquire(key)
+	if !leader {
+		log.Debugf("request dedup: attaching duplicate request to in-flight response")
+		return entry.wait(ctx)
+	}
+	body, headers, errMsg := execute()
+	entry.setHeaders(headers)
+	entry.finish(body, errMsg)
+	dedup.release(key, entry, window)
+	return body, headers, errMsg
+}
+
+// executeStreamDeduplicated is the streaming counterpart of executeDeduplicated. The leader's
+// upstream stream is tee'd into a shared buffer that duplicates replay from the beginning.
+// Upstream work stays bound to the leader's request; if that client disconnects, duplicates
+// receive a terminal error instead of a silently truncated stream.
+func (h *BaseAPIHandler) executeStreamDeduplicated(ctx context.Context, key string, execute func() (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage)) (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage) {
+	window, enabled := RequestDedupWindow(h.CurrentConfig())
+	if !enabled || key == "" {
+		return execute()
+	}
+	dedup := h.requestDeduplicator()
+	entry, leader := dedup.acquire(key)
+	if !leader {
+		if dataChan, headers, errChan, ok := entry.subscribe(ctx); ok {
+			log.Debugf("request dedup: attaching duplicate stream to in-flight response")
+			return dataChan, headers, errChan
+		}
+		return execute()
+	}
+	upstreamData, upstreamHeaders, upstreamErrs := execute()
+	// Subscribe before buffering starts so the leader's stream is never trimmed away.
+	dataChan, _, errChan, _ := entry.subscribe(ctx)
+	go func() {
+		var errMsg *interfaces.ErrorMessage
+		if upstreamData != nil {
+			headersCaptured := false
+			for chunk := range upstreamData {
+				if !headersCaptured {
+					// Stream headers are committed once the first payload is emitted.
+					entry.setHeaders(upstreamHeaders)
+					headersCaptured = true
+				}
+				entry.appendChunk(chunk)
+			}
+		}
+		if upstreamErrs != nil {
+			if pending, ok := <-upstreamErrs; ok {
+				errMsg = pending
+			}
+		}
+		if errMsg == nil && ctx != nil && ctx.Err() != nil {
+			errMsg = &interfaces.ErrorMessage{StatusCode: http.StatusBadGateway, Error: errDedupLeaderCanceled}
+		}
+		entry.finish(nil, errMsg)
+		dedup.release(key, entry, window)
+	}()
+	// The leader keeps the live header map so bootstrap retries can still update it before commit.
+	return dataChan, upstreamHeaders, errChan
+}
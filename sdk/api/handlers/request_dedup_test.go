@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+func newDedupTestContext(t *testing.T, apiKey string) context.Context {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	if apiKey != "" {
+		c.Set("userApiKey", apiKey)
+	}
+	return context.WithValue(context.Background(), "gin", c)
+}
+
+func TestRequestDedupKey(t *testing.T) {
+	payload := []byte(`{"model":"gpt-5","messages":[]}`)
+	ctxA := newDedupTestContext(t, "key-a")
+	ctxB := newDedupTestContext(t, "key-b")
+
+	keyA := requestDedupKey(ctxA, "openai", "openai", "gpt-5", "", payload, false)
+	if keyA == "" {
+		t.Fatal("expected key for request with gin context")
+	}
+	if again := requestDedupKey(ctxA, "openai", "openai", "gpt-5", "", payload, false); again != keyA {
+		t.Fatalf("expected stable key, got %q and %q", keyA, again)
+	}
+	if other := requestDedupKey(ctxB, "openai", "openai", "gpt-5", "", payload, false); other == keyA {
+		t.Fatal("expected different API keys to produce different dedup keys")
+	}
+	if streamKey := requestDedupKey(ctxA, "openai", "openai", "gpt-5", "", payload, true); streamKey == keyA {
+		t.Fatal("expected stream and non-stream requests to produce different dedup keys")
+	}
+	if got := requestDedupKey(context.Background(), "openai", "openai", "gpt-5", "", payload, false); got != "" {
+		t.Fatalf("expected empty key without gin context, got %q", got)
+	}
+}
+
+func TestExecuteDeduplicated_SharesInFlightResponse(t *testing.T) {
+	h := NewBaseAPIHandlers(&sdkconfig.SDKConfig{RequestDedup: sdkconfig.RequestDedupConfig{Enabled: true}}, nil)
+
+	var calls atomic.Int32
+	release := make(chan struct{})
+	execute := func() ([]byte, http.Header, *interfaces.ErrorMessage) {
+		calls.Add(1)
+		<-release
+		return []byte("ok"), http.Header{"X-Upstream": {"1"}}, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([][]byte, 2)
+	headers := make([]http.Header, 2)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		results[0], headers[0], _ = h.executeDeduplicated(context.Background(), "same", execute)
+	}()
+	// Wait for the leader to register before the duplicate arrives.
+	deadline := time.Now().Add(time.Second)
+	for calls.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		results[1], headers[1], _ = h.executeDeduplicated(context.Background(), "same", execute)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("expected 1 upstream call, got %d", got)
+	}
+	for i, body := range results {
+		if string(body) != "ok" {
+			t.Fatalf("result %d = %q, want %q", i, body, "ok")
+		}
+	}
+	if headers[1].Get(DeduplicatedHeader) != "true" {
+		t.Fatalf("expected duplicate to carry %s header, got %v", DeduplicatedHeader, headers[1])
+	}
+	if headers[0].Get(DeduplicatedHeader) != "" {
+		t.Fatalf("expected leader response without %s header", DeduplicatedHeader)
+	}
+}
+
+func TestExecuteDeduplicated_WindowReusesCompletedResponse(t *testing.T) {
+	h := NewBaseAPIHandlers(&sdkconfig.SDKConfig{RequestDedup: sdkconfig.RequestDedupConfig{Enabled: true, WindowSeconds: 60}}, nil)
+
+	calls := 0
+	execute := func() ([]byte, http.Header, *interfaces.ErrorMessage) {
+		calls++
+		return []byte("ok"), nil, nil
+	}
+	h.executeDeduplicated(context.Background(), "same", execute)
+	body, _, _ := h.executeDeduplicated(context.Background(), "same", execute)
+
+	if calls != 1 {
+		t.Fatalf("expected completed response to be reused within window, got %d calls", calls)
+	}
+	if string(body) != "ok" {
+		t.Fatalf("body = %q, want %q", body, "ok")
+	}
+}
+
+func TestExecuteDeduplicated_DisabledExecutesEveryRequest(t *testing.T) {
+	h := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, nil)
+
+	calls := 0
+	execute := func() ([]byte, http.Header, *interfaces.ErrorMessage) {
+		calls++
+		return []byte("ok"), nil, nil
+	}
+	h.executeDeduplicated(context.Background(), "same", execute)
+	h.executeDeduplicated(context.Background(), "same", execute)
+
+	if calls != 2 {
+		t.Fatalf("expected 2 upstream calls when disabled, got %d", calls)
+	}
+}
+
+func TestDedupEntrySubscribe_ReplaysBufferedChunks(t *testing.T) {
+	entry := newDedupEntry()
+	entry.setHeaders(http.Header{"X-Upstream": {"1"}})
+	entry.appendChunk([]byte("a"))
+	entry.appendChunk([]byte("b"))
+
+	dataChan, headers, errChan, ok := entry.subscribe(context.Background())
+	if !ok {
+		t.Fatal("expected buffered stream to be replayable")
+	}
+	go func() {
+		entry.appendChunk([]byte("c"))
+		entry.finish(nil, nil)
+	}()
+
+	var got string
+	for chunk := range dataChan {
+		got += string(chunk)
+	}
+	if got != "abc" {
+		t.Fatalf("replayed stream = %q, want %q", got, "abc")
+	}
+	if errMsg, ok := <-errChan; ok && errMsg != nil {
+		t.Fatalf("unexpected error: %v", errMsg.Error)
+	}
+	if headers.Get("X-Upstream") != "1" || headers.Get(DeduplicatedHeader) != "true" {
+		t.Fatalf("unexpected follower headers: %v", headers)
+	}
+}
+
+func TestDedupEntryAppendChunk_TrimsSentChunksAfterOverflow(t *testing.T) {
+	entry := newDedupEntry()
+	dataChan, _, _, ok := entry.subscribe(context.Background())
+	if !ok {
+		t.Fatal("expected empty stream to be replayable")
+	}
+
+	chunk := make([]byte, maxRequestDedupBufferBytes/4)
+	for range 8 {
+		entry.appendChunk(chunk)
+		<-dataChan
+	}
+	// The subscriber records its position when it next looks for chunks.
+	entry.appendChunk([]byte("tail"))
+	if got := string(<-dataChan); got != "tail" {
+		t.Fatalf("chunk after overflow = %q, want %q", got, "tail")
+	}
+	entry.finish(nil, nil)
+	for range dataChan {
+	}
+
+	entry.mu.Lock()
+	size, base := entry.size, entry.base
+	entry.mu.Unlock()
+	if size > maxRequestDedupBufferBytes {
+		t.Fatalf("buffered %d bytes after overflow, want at most %d", size, maxRequestDedupBufferBytes)
+	}
+	if base == 0 {
+		t.Fatal("expected sent chunks to be dropped after overflow")
+	}
+	if _, _, _, ok = entry.subscribe(context.Background()); ok {
+		t.Fatal("expected trimmed stream to refuse new subscribers")
+	}
+}
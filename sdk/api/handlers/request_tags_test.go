@@ -0,0 +1,35 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	coreexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+func TestSetRequestTagsMetadata(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ginCtx.Request = httptest.NewRequest(http.MethodPost, "/v1/responses", nil)
+	ginCtx.Request.Header.Set(RequestTagsHeader, "team=infra,job=nightly")
+	ctx := context.WithValue(context.Background(), "gin", ginCtx)
+
+	meta := map[string]any{}
+	setRequestTagsMetadata(ctx, meta, "openai-response", []byte(`{"metadata":{"team":"ml","ticket":"T-1","count":3}}`))
+	tags, ok := meta[coreexecutor.RequestTagsMetadataKey].(map[string]string)
+	if !ok {
+		t.Fatalf("metadata = %v, want request tags", meta)
+	}
+	if len(tags) != 3 || tags["team"] != "infra" || tags["job"] != "nightly" || tags["ticket"] != "T-1" {
+		t.Fatalf("tags = %v, want header tags over string metadata values", tags)
+	}
+
+	meta = map[string]any{}
+	setRequestTagsMetadata(context.Background(), meta, "openai", []byte(`{"metadata":{"team":"ml"}}`))
+	if _, exists := meta[coreexecutor.RequestTagsMetadataKey]; exists {
+		t.Fatal("chat completion metadata must not become tags")
+	}
+}
@@ -0,0 +1,213 @@
+package handlers
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"encoding/json"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// ResponseCacheHeader reports whether a cacheable response was served from the response cache
+// ("hit") or executed upstream ("miss").
+const ResponseCacheHeader = "X-CPA-Cache"
+
+const (
+	defaultResponseCacheTTL     = time.Hour
+	defaultResponseCacheMaxSize = 64
+)
+
+// responseCacheSettings is the resolved cache configuration for one request.
+type responseCacheSettings struct {
+	ttl      time.Duration
+	maxBytes int
+	// lookup is false when the client asked to revalidate with Cache-Control: no-cache.
+	lookup bool
+}
+
+// responseCacheFor resolves the cache settings of a request, reporting false when the request
+// must bypass the cache.
+func responseCacheFor(ctx context.Context, cfg *config.SDKConfig, modelName string) (responseCacheSettings, bool) {
+	if cfg == nil || !cfg.Cache.Enabled {
+		return responseCacheSettings{}, false
+	}
+	settings := responseCacheSettings{
+		ttl:      parseResponseCacheTTL(cfg.Cache.TTL, defaultResponseCacheTTL),
+		maxBytes: defaultResponseCacheMaxSize << 20,
+		lookup:   true,
+	}
+	if cfg.Cache.MaxSizeMB > 0 {
+		settings.maxBytes = cfg.Cache.MaxSizeMB << 20
+	}
+	for _, override := range cfg.Cache.Models {
+		pattern := strings.TrimSpace(override.Model)
+		if pattern == "" {
+			continue
+		}
+		if matched, _ := path.Match(pattern, modelName); matched {
+			settings.ttl = parseResponseCacheTTL(override.TTL, settings.ttl)
+			break
+		}
+	}
+	if settings.ttl <= 0 {
+		return responseCacheSettings{}, false
+	}
+	if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil && ginCtx.Request != nil {
+		for _, directive := range strings.Split(strings.ToLower(ginCtx.GetHeader("Cache-Control")), ",") {
+			switch strings.TrimSpace(directive) {
+			case "no-store":
+				return responseCacheSettings{}, false
+			case "no-cache":
+				settings.lookup = false
+			}
+		}
+	}
+	return settings, true
+}
+
+func parseResponseCacheTTL(raw string, fallback time.Duration) time.Duration {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return fallback
+	}
+	if raw == "0" {
+		return 0
+	}
+	ttl, errParse := time.ParseDuration(raw)
+	if errParse != nil {
+		log.Warnf("response cache: invalid ttl %q, using %s", raw, fallback)
+		return fallback
+	}
+	return ttl
+}
+
+// normalizeCachePayload re-encodes a JSON payload with sorted object keys and no insignificant
+// whitespace, so requests differing only in formatting share a cache entry.
+func normalizeCachePayload(rawJSON []byte) []byte {
+	decoder := json.NewDecoder(bytes.NewReader(rawJSON))
+	decoder.UseNumber()
+	var payload any
+	if errDecode := decoder.Decode(&payload); errDecode != nil {
+		return rawJSON
+	}
+	normalized, errMarshal := json.Marshal(payload)
+	if errMarshal != nil {
+		return rawJSON
+	}
+	return normalized
+}
+
+// responseCache is an LRU of completed responses bounded by the total size of their bodies.
+type responseCache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List
+	entries map[string]*list.Element
+	now     func() time.Time
+}
+
+type responseCacheEntry struct {
+	key       string
+	body      []byte
+	headers   http.Header
+	expiresAt time.Time
+}
+
+func newResponseCache() *responseCache {
+	return &responseCache{order: list.New(), entries: make(map[string]*list.Element), now: time.Now}
+}
+
+func (h *BaseAPIHandler) responseCache() *responseCache {
+	h.cacheOnce.Do(func() {
+		h.respCache = newResponseCache()
+	})
+	return h.respCache
+}
+
+func (c *responseCache) get(key string) ([]byte, http.Header, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, nil, false
+	}
+	entry := elem.Value.(*responseCacheEntry)
+	if !c.now().Before(entry.expiresAt) {
+		c.remove(elem)
+		return nil, nil, false
+	}
+	c.order.MoveToFront(elem)
+	return bytes.Clone(entry.body), cloneHeader(entry.headers), true
+}
+
+// set stores a response and evicts the least recently used entries beyond maxBytes. Bodies
+// larger than maxBytes are not cached.
+func (c *responseCache) set(key string, body []byte, headers http.Header, ttl time.Duration, maxBytes int) {
+	if len(body) > maxBytes {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		c.remove(elem)
+	}
+	entry := &responseCacheEntry{key: key, body: bytes.Clone(body), headers: cloneHeader(headers), expiresAt: c.now().Add(ttl)}
+	c.entries[key] = c.order.PushFront(entry)
+	c.size += len(entry.body)
+	for c.size > maxBytes {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *responseCache) remove(elem *list.Element) {
+	entry := elem.Value.(*responseCacheEntry)
+	c.order.Remove(elem)
+	delete(c.entries, entry.key)
+	c.size -= len(entry.body)
+}
+
+// executeCached serves a non-streaming request from the response cache when possible and
+// stores successful responses of execute. Requests without a cache key execute directly.
+func (h *BaseAPIHandler) executeCached(ctx context.Context, key, modelName string, execute func() ([]byte, http.Header, *interfaces.ErrorMessage)) ([]byte, http.Header, *interfaces.ErrorMessage) {
+	if key == "" {
+		return execute()
+	}
+	settings, ok := responseCacheFor(ctx, h.CurrentConfig(), modelName)
+	if !ok {
+		return execute()
+	}
+	cache := h.responseCache()
+	if settings.lookup {
+		if body, headers, hit := cache.get(key); hit {
+			if headers == nil {
+				headers = make(http.Header)
+			}
+			headers.Set(ResponseCacheHeader, "hit")
+			return body, headers, nil
+		}
+	}
+	body, headers, errMsg := execute()
+	if errMsg != nil {
+		return body, headers, errMsg
+	}
+	stored := cloneHeader(headers)
+	if stored != nil {
+		stored.Del(DeduplicatedHeader)
+	}
+	cache.set(key, body, stored, settings.ttl, settings.maxBytes)
+	headers = cloneHeader(headers)
+	if headers == nil {
+		headers = make(http.Header)
+	}
+	headers.Set(ResponseCacheHeader, "miss")
+	return body, headers, nil
+}
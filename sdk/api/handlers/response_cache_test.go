@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+func newCacheTestContext(t *testing.T, cacheControl string) context.Context {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	if cacheControl != "" {
+		c.Request.Header.Set("Cache-Control", cacheControl)
+	}
+	c.Set("userApiKey", "key-a")
+	return context.WithValue(context.Background(), "gin", c)
+}
+
+func TestExecuteCached(t *testing.T) {
+	h := NewBaseAPIHandlers(&sdkconfig.SDKConfig{Cache: sdkconfig.ResponseCacheConfig{
+		Enabled: true,
+		Models:  []sdkconfig.ResponseCacheModelTTL{{Model: "*-preview", TTL: "0"}},
+	}}, nil)
+	calls := 0
+	execute := func() ([]byte, http.Header, *interfaces.ErrorMessage) {
+		calls++
+		return []byte("ok"), http.Header{"X-Upstream": {"1"}}, nil
+	}
+	run := func(cacheControl, model string) http.Header {
+		ctx := newCacheTestContext(t, cacheControl)
+		key := requestDedupKey(ctx, "openai", "openai", model, "", normalizeCachePayload([]byte(`{"model":"`+model+`"}`)), false)
+		body, headers, errMsg := h.executeCached(ctx, key, model, execute)
+		if errMsg != nil || string(body) != "ok" {
+			t.Fatalf("executeCached() = %q, %v", body, errMsg)
+		}
+		return headers
+	}
+
+	if got := run("", "gpt-5").Get(ResponseCacheHeader); got != "miss" || calls != 1 {
+		t.Fatalf("first request: header %q, calls %d; want miss, 1", got, calls)
+	}
+	headers := run("", "gpt-5")
+	if headers.Get(ResponseCacheHeader) != "hit" || headers.Get("X-Upstream") != "1" || calls != 1 {
+		t.Fatalf("repeat request: headers %v, calls %d; want cached hit", headers, calls)
+	}
+	if got := run("no-cache", "gpt-5").Get(ResponseCacheHeader); got != "miss" || calls != 2 {
+		t.Fatalf("no-cache request: header %q, calls %d; want miss, 2", got, calls)
+	}
+	if got := run("no-store", "gpt-5").Get(ResponseCacheHeader); got != "" || calls != 3 {
+		t.Fatalf("no-store request: header %q, calls %d; want uncached, 3", got, calls)
+	}
+	run("", "gpt-5-preview")
+	if got := run("", "gpt-5-preview").Get(ResponseCacheHeader); got != "" || calls != 5 {
+		t.Fatalf("disabled model: header %q, calls %d; want uncached, 5", got, calls)
+	}
+}
+
+func TestResponseCacheEvictsBySize(t *testing.T) {
+	cache := newResponseCache()
+	body := []byte(strings.Repeat("x", 40))
+	cache.set("a", body, nil, time.Hour, 100)
+	cache.set("b", body, nil, time.Hour, 100)
+	if _, _, ok := cache.get("a"); !ok {
+		t.Fatal("expected a to be cached")
+	}
+	cache.set("c", body, nil, time.Hour, 100)
+	if _, _, ok := cache.get("b"); ok {
+		t.Fatal("expected least recently used entry b to be evicted")
+	}
+	if _, _, ok := cache.get("a"); !ok {
+		t.Fatal("expected recently used entry a to survive")
+	}
+
+	cache.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if _, _, ok := cache.get("c"); ok {
+		t.Fatal("expected expired entry to be dropped")
+	}
+}
+
+func TestNormalizeCachePayload(t *testing.T) {
+	a := normalizeCachePayload([]byte(`{"model":"gpt-5", "temperature":0.10,"messages":[]}`))
+	b := normalizeCachePayload([]byte("{\n  \"messages\": [],\n  \"temperature\": 0.10,\n  \"model\": \"gpt-5\"\n}"))
+	if string(a) != string(b) {
+		t.Fatalf("normalized payloads differ: %s vs %s", a, b)
+	}
+}
@@ -0,0 +1,323 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// LastEventIDHeader carries the id of the last stream event a reconnecting client received.
+const LastEventIDHeader = "Last-Event-ID"
+
+const (
+	defaultStreamReplayBufferMB = 4
+	defaultStreamReplayWindow   = 5 * time.Minute
+)
+
+var (
+	errStreamReplayUnknown   = errors.New("no resumable stream for this Idempotency-Key")
+	errStreamReplayEvicted   = errors.New("the requested stream position is no longer buffered; retry with a new Idempotency-Key")
+	errStreamReplayEventID   = errors.New("invalid Last-Event-ID: expected a non-negative integer")
+	errStreamReplayAbandoned = errors.New("stream was not resumed within the replay window")
+)
+
+// StreamReplaySettings reports whether resumable streams are enabled, how long a stream stays
+// resumable without a connected client and how many bytes of events one stream buffers.
+func StreamReplaySettings(cfg *config.SDKConfig) (time.Duration, int, bool) {
+	if cfg == nil || !cfg.StreamReplay.Enabled {
+		return 0, 0, false
+	}
+	window := defaultStreamReplayWindow
+	if cfg.StreamReplay.WindowSeconds > 0 {
+		window = time.Duration(cfg.StreamReplay.WindowSeconds) * time.Second
+	}
+	maxBytes := defaultStreamReplayBufferMB << 20
+	if cfg.StreamReplay.MaxBufferMB > 0 {
+		maxBytes = cfg.StreamReplay.MaxBufferMB << 20
+	}
+	return window, maxBytes, true
+}
+
+// streamReplayKey returns the replay session key of a streaming request, or "" when the request
+// carries no Idempotency-Key or is not an SSE stream. Sessions are scoped to the client API key
+// and endpoint so a key cannot be used to read another client's stream.
+func streamReplayKey(ctx context.Context, alt string) (string, *gin.Context) {
+	if ctx == nil || alt != "" {
+		return "", nil
+	}
+	ginCtx, ok := ctx.Value("gin").(*gin.Context)
+	if !ok || ginCtx == nil || ginCtx.Request == nil {
+		return "", nil
+	}
+	idempotencyKey := strings.TrimSpace(ginCtx.GetHeader("Idempotency-Key"))
+	if idempotencyKey == "" {
+		return "", nil
+	}
+	path := strings.TrimSpace(ginCtx.FullPath())
+	if path == "" && ginCtx.Request.URL != nil {
+		path = ginCtx.Request.URL.Path
+	}
+	hasher := sha256.New()
+	for _, part := range []string{ginCtx.GetString("userApiKey"), path, idempotencyKey} {
+		hasher.Write([]byte(part))
+		hasher.Write([]byte{0})
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), ginCtx
+}
+
+// streamReplayStore holds the resumable streams of one handler keyed by streamReplayKey.
+type streamReplayStore struct {
+	mu       sync.Mutex
+	sessions map[string]*streamReplaySession
+}
+
+// streamReplaySession buffers the events of one upstream stream. Event ids are the indexes of
+// the buffered chunks, and the upstream is canceled once no client has been connected for window.
+type streamReplaySession struct {
+	chunkBroadcast
+	window  time.Duration
+	expires time.Time
+	idle    *time.Timer
+	cancel  context.CancelFunc
+}
+
+func newStreamReplaySession(window time.Duration, maxBytes int) *streamReplaySession {
+	session := &streamReplaySession{window: window}
+	session.maxBytes = maxBytes
+	session.lastDetachedLocked = session.startIdleLocked
+	return session
+}
+
+func (h *BaseAPIHandler) streamReplayStore() *streamReplayStore {
+	h.replayOnce.Do(func() {
+		h.replay = &streamReplayStore{sessions: make(map[string]*streamReplaySession)}
+	})
+	return h.replay
+}
+
+// acquire returns the live session for key, or creates one when create is set. It reports
+// whether the caller created the session and must start its upstream.
+func (s *streamReplayStore) acquire(key string, create bool, window time.Duration, maxBytes int) (*streamReplaySession, bool) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, session := range s.sessions {
+		if session.expired(now) {
+			delete(s.sessions, k)
+		}
+	}
+	if session, ok := s.sessions[key]; ok {
+		return session, false
+	}
+	if !create {
+		return nil, false
+	}
+	session := newStreamReplaySession(window, maxBytes)
+	s.sessions[key] = session
+	return session, true
+}
+
+// release keeps a finished session resumable for its window, or drops it right away when the
+// upstream failed before emitting anything so the client can retry with the same key.
+func (s *streamReplayStore) release(key string, session *streamReplaySession) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	session.mu.Lock()
+	drop := session.errMsg != nil && session.base+len(session.chunks) == 0
+	session.expires = time.Now().Add(session.window)
+	session.mu.Unlock()
+	if drop && s.sessions[key] == session {
+		delete(s.sessions, key)
+	}
+}
+
+func (e *streamReplaySession) expired(now time.Time) bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.done && now.After(e.expires)
+}
+
+func (e *streamReplaySession) appendChunk(chunk []byte) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.appendLocked(chunk)
+}
+
+func (e *streamReplaySession) finish(errMsg *interfaces.ErrorMessage) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.stopIdleLocked()
+	e.finishLocked(errMsg)
+}
+
+// startIdleLocked starts the idle timer once the last client is gone while the upstream still
+// runs; callers must hold e.mu.
+func (e *streamReplaySession) startIdleLocked() {
+	if e.done || e.cancel == nil {
+		return
+	}
+	e.stopIdleLocked()
+	cancel := e.cancel
+	e.idle = time.AfterFunc(e.window, func() {
+		log.Debugf("stream replay: canceling upstream stream nobody resumed")
+		cancel()
+	})
+}
+
+func (e *streamReplaySession) stopIdleLocked() {
+	if e.idle != nil {
+		e.idle.Stop()
+		e.idle = nil
+	}
+}
+
+// subscribe replays the buffered chunks from index start and then follows the live stream. It
+// reports false when start is no longer buffered. The client's SSE events are tagged with ids
+// through ginCtx's response writer.
+func (e *streamReplaySession) subscribe(ctx context.Context, ginCtx *gin.Context, start int) (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage, bool) {
+	e.mu.Lock()
+	id, ok := e.attachLocked(start)
+	if !ok {
+		e.mu.Unlock()
+		return nil, nil, nil, false
+	}
+	e.stopIdleLocked()
+	headers := cloneHeader(e.headers)
+	e.mu.Unlock()
+	if headers == nil {
+		headers = make(http.Header)
+	}
+
+	writer := &streamReplayWriter{ResponseWriter: ginCtx.Writer, marked: start - 1, boundary: true}
+	writer.received.Store(int64(start - 1))
+	ginCtx.Writer = writer
+
+	// Once the stream ended every chunk was handed over; the writer tags the last one before the end.
+	dataChan, errChan := e.follow(ctx, id, start, headers, func(next int) { writer.received.Store(int64(next)) })
+	return dataChan, headers, errChan, true
+}
+
+// executeStreamReplayable runs a streaming request whose upstream outlives its client so the
+// client can reconnect and resume. A request with a known key resumes after its Last-Event-ID,
+// or from the start without one; otherwise execute starts the upstream on a context detached from
+// the client. The returned bool reports a resumed stream.
+func (h *BaseAPIHandler) executeStreamReplayable(ctx context.Context, ginCtx *gin.Context, key string, execute func(context.Context) (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage)) (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage, bool) {
+	window, maxBytes, _ := StreamReplaySettings(h.CurrentConfig())
+	start := 0
+	lastEventID := strings.TrimSpace(ginCtx.GetHeader(LastEventIDHeader))
+	if lastEventID != "" {
+		id, errParse := strconv.Atoi(lastEventID)
+		if errParse != nil || id < 0 {
+			return nil, nil, streamReplayError(http.StatusBadRequest, errStreamReplayEventID), true
+		}
+		start = id + 1
+	}
+	session, created := h.streamReplayStore().acquire(key, lastEventID == "", window, maxBytes)
+	if session == nil {
+		return nil, nil, streamReplayError(http.StatusConflict, errStreamReplayUnknown), true
+	}
+	if !created {
+		dataChan, headers, errChan, ok := session.subscribe(ctx, ginCtx, start)
+		if !ok {
+			return nil, nil, streamReplayError(http.StatusConflict, errStreamReplayEvicted), true
+		}
+		log.Debugf("stream replay: resuming stream at event %d", start)
+		return dataChan, headers, errChan, true
+	}
+
+	// The upstream outlives this request: run it on a copy of the gin context whose request
+	// context is never canceled.
+	detached := ginCtx.Copy()
+	detached.Request = ginCtx.Request.Clone(context.WithoutCancel(ginCtx.Request.Context()))
+	runCtx, cancel := context.WithCancel(context.WithValue(context.WithoutCancel(ctx), "gin", detached))
+	session.mu.Lock()
+	session.cancel = cancel
+	session.mu.Unlock()
+
+	upstreamData, upstreamHeaders, upstreamErrs := execute(runCtx)
+	// Subscribe before buffering starts so the first chunks are never dropped for this client.
+	dataChan, _, errChan, _ := session.subscribe(ctx, ginCtx, 0)
+	store := h.streamReplayStore()
+	go func() {
+		defer cancel()
+		var errMsg *interfaces.ErrorMessage
+		if upstreamData != nil {
+			headersCaptured := false
+			for chunk := range upstreamData {
+				if !headersCaptured {
+					session.setHeaders(upstreamHeaders)
+					headersCaptured = true
+				}
+				session.appendChunk(chunk)
+			}
+		}
+		if upstreamErrs != nil {
+			if pending, ok := <-upstreamErrs; ok {
+				errMsg = pending
+			}
+		}
+		if errMsg == nil && runCtx.Err() != nil {
+			errMsg = &interfaces.ErrorMessage{StatusCode: http.StatusGatewayTimeout, Error: errStreamReplayAbandoned}
+		}
+		session.finish(errMsg)
+		store.release(key, session)
+	}()
+	// The first client keeps the live header map so bootstrap retries can still update it.
+	return dataChan, upstreamHeaders, errChan, false
+}
+
+func streamReplayError(status int, err error) <-chan *interfaces.ErrorMessage {
+	errChan := make(chan *interfaces.ErrorMessage, 1)
+	errChan <- &interfaces.ErrorMessage{StatusCode: status, Error: err}
+	close(errChan)
+	return errChan
+}
+
+// streamReplayWriter tags a resumable SSE response with event ids. Handlers write a chunk only
+// after receiving it and receive the next one only after writing it, so once chunk n was handed
+// over every chunk before it is on the wire; an "id: n-1" line is written at the next event
+// boundary. Ids therefore trail by at most one chunk, which a resuming client receives again.
+type streamReplayWriter struct {
+	gin.ResponseWriter
+	received atomic.Int64
+	marked   int
+	boundary bool
+}
+
+func (w *streamReplayWriter) Write(p []byte) (int, error) {
+	w.markDelivered()
+	n, err := w.ResponseWriter.Write(p)
+	if len(p) > 0 {
+		w.boundary = bytes.HasSuffix(p, []byte("\n\n"))
+	}
+	return n, err
+}
+
+func (w *streamReplayWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *streamReplayWriter) markDelivered() {
+	delivered := int(w.received.Load()) - 1
+	if !w.boundary || delivered <= w.marked {
+		return
+	}
+	if !strings.HasPrefix(w.ResponseWriter.Header().Get("Content-Type"), "text/event-stream") {
+		return
+	}
+	w.marked = delivered
+	_, _ = w.ResponseWriter.Write([]byte("id: " + strconv.Itoa(delivered) + "\n\n"))
+}
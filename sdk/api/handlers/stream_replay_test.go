@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+func newReplayTestContext(t *testing.T, lastEventID string) (context.Context, context.CancelFunc, *gin.Context, *httptest.ResponseRecorder) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	c.Request.Header.Set("Idempotency-Key", "turn-1")
+	if lastEventID != "" {
+		c.Request.Header.Set(LastEventIDHeader, lastEventID)
+	}
+	c.Set("userApiKey", "key-a")
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), "gin", c))
+	return ctx, cancel, c, recorder
+}
+
+func TestExecuteStreamReplayable_ResumesAfterDisconnect(t *testing.T) {
+	h := NewBaseAPIHandlers(&sdkconfig.SDKConfig{StreamReplay: sdkconfig.StreamReplayConfig{Enabled: true}}, nil)
+	upstream := make(chan []byte)
+	execute := func(runCtx context.Context) (<-chan []byte, http.Header, <-chan *interfaces.ErrorMessage) {
+		errChan := make(chan *interfaces.ErrorMessage)
+		close(errChan)
+		return upstream, http.Header{"X-Upstream": {"1"}}, errChan
+	}
+
+	ctx, cancel, c, _ := newReplayTestContext(t, "")
+	key, _ := streamReplayKey(ctx, "")
+	dataChan, _, _, resumed := h.executeStreamReplayable(ctx, c, key, execute)
+	if resumed {
+		t.Fatal("first request reported as resumed")
+	}
+	upstream <- []byte("a")
+	if got := string(<-dataChan); got != "a" {
+		t.Fatalf("first chunk = %q", got)
+	}
+	// The client drops; the upstream keeps running into the buffer.
+	cancel()
+	upstream <- []byte("b")
+	upstream <- []byte("c")
+	close(upstream)
+
+	ctx, cancel, c, _ = newReplayTestContext(t, "0")
+	defer cancel()
+	dataChan, headers, errChan, resumed := h.executeStreamReplayable(ctx, c, key, execute)
+	if !resumed {
+		t.Fatal("reconnect was not resumed")
+	}
+	var got []string
+	for chunk := range dataChan {
+		got = append(got, string(chunk))
+	}
+	if strings.Join(got, ",") != "b,c" {
+		t.Fatalf("resumed chunks = %v, want b,c", got)
+	}
+	if errMsg := <-errChan; errMsg != nil {
+		t.Fatalf("resumed stream error = %v", errMsg.Error)
+	}
+	if headers.Get("X-Upstream") != "1" {
+		t.Fatalf("resumed headers = %v", headers)
+	}
+}
+
+func TestExecuteStreamReplayable_RejectsUnknownOrEvicted(t *testing.T) {
+	h := NewBaseAPIHandlers(&sdkconfig.SDKConfig{StreamReplay: sdkconfig.StreamReplayConfig{Enabled: true}}, nil)
+	ctx, cancel, c, _ := newReplayTestContext(t, "3")
+	defer cancel()
+	key, _ := streamReplayKey(ctx, "")
+	_, _, errChan, _ := h.executeStreamReplayable(ctx, c, key, nil)
+	if errMsg := <-errChan; errMsg == nil || errMsg.StatusCode != http.StatusConflict {
+		t.Fatalf("unknown key error = %+v, want 409", errMsg)
+	}
+
+	session := newStreamReplaySession(0, 2)
+	for _, chunk := range []string{"aa", "bb", "cc"} {
+		session.appendChunk([]byte(chunk))
+	}
+	if _, _, _, ok := session.subscribe(ctx, c, 0); ok {
+		t.Fatal("subscribe succeeded from an evicted position")
+	}
+	if _, _, _, ok := session.subscribe(ctx, c, 2); !ok {
+		t.Fatal("subscribe failed from a buffered position")
+	}
+}
+
+func TestStreamReplayWriterTagsDeliveredEvents(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	recorder := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(recorder)
+	c.Header("Content-Type", "text/event-stream")
+	writer := &streamReplayWriter{ResponseWriter: c.Writer, marked: -1, boundary: true}
+
+	writer.received.Store(0)
+	_, _ = writer.Write([]byte("data: a\n\n"))
+	writer.received.Store(1)
+	_, _ = writer.Write([]byte("data: b\n\n"))
+	writer.received.Store(2)
+	_, _ = writer.Write([]byte("data: [DONE]\n\n"))
+
+	want := "data: a\n\nid: 0\n\ndata: b\n\nid: 1\n\ndata: [DONE]\n\n"
+	if got := recorder.Body.String(); got != want {
+		t.Fatalf("body = %q, want %q", got, want)
+	}
+}
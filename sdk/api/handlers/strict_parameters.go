@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	. "github.com/router-for-me/CLIProxyAPI/v7/internal/constant"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
+	translatorcommon "github.com/router-for-me/CLIProxyAPI/v7/internal/translator/common"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	"github.com/tidwall/gjson"
+)
+
+// StrictParametersHeader turns strict parameter validation on ("true") or off ("false") for a
+// single request, overriding strict-parameters.
+const StrictParametersHeader = "X-CPA-Strict-Parameters"
+
+// strictStructuralParameters are chat completion fields every model accepts. Streaming is
+// handled by the proxy itself and reasoning_effort by the thinking layer for every provider, so
+// neither depends on the sparse supported_parameters hints in the model registry.
+var strictStructuralParameters = []string{"model", "messages", "user", "stream", "stream_options", "reasoning_effort"}
+
+// strictImpliedParameters lists fields accepted whenever the keyed parameter is supported.
+var strictImpliedParameters = map[string][]string{
+	"max_tokens": {"max_completion_tokens"},
+	"tools":      {"tool_choice", "parallel_tool_calls"},
+}
+
+// strictBuiltinToolDroppingProviders translate Responses requests to the Claude or Gemini API,
+// which have no equivalent of some built-in tools.
+var strictBuiltinToolDroppingProviders = map[string]struct{}{
+	Claude: {}, Gemini: {}, GeminiCLI: {}, Antigravity: {}, "vertex": {}, "aistudio": {},
+}
+
+// strictParametersEnabled reports whether unsupported parameters must be rejected for this request.
+func strictParametersEnabled(ctx context.Context, cfg *config.SDKConfig) bool {
+	if ctx != nil {
+		if ginCtx, ok := ctx.Value("gin").(*gin.Context); ok && ginCtx != nil && ginCtx.Request != nil {
+			if raw := strings.TrimSpace(ginCtx.GetHeader(StrictParametersHeader)); raw != "" {
+				if enabled, errParse := strconv.ParseBool(raw); errParse == nil {
+					return enabled
+				}
+			}
+		}
+	}
+	return cfg != nil && cfg.StrictParameters
+}
+
+// validateStrictParameters rejects an OpenAI chat completion carrying top-level fields that the
+// model does not list in its supported parameters, instead of letting translation drop them.
+// Since any candidate provider may serve the request, only parameters every provider lists are
+// accepted. Validation is skipped unless every candidate provider publishes a parameter list for
+// the model.
+func (h *BaseAPIHandler) validateStrictParameters(ctx context.Context, entryProtocol string, providers []string, modelName string, rawJSON []byte) *interfaces.ErrorMessage {
+	if entryProtocol != OpenAI || len(providers) == 0 || !strictParametersEnabled(ctx, h.CurrentConfig()) {
+		return nil
+	}
+	modelKey := strings.TrimSpace(modelName)
+	if parsed := thinking.ParseSuffix(modelKey); parsed.ModelName != "" {
+		modelKey = parsed.ModelName
+	}
+	var supported map[string]struct{}
+	for _, provider := range providers {
+		info := registry.LookupModelInfo(modelKey, provider)
+		if info == nil || len(info.SupportedParameters) == 0 {
+			return nil
+		}
+		params := make(map[string]struct{}, len(info.SupportedParameters))
+		for _, param := range info.SupportedParameters {
+			params[param] = struct{}{}
+			for _, implied := range strictImpliedParameters[param] {
+				params[implied] = struct{}{}
+			}
+		}
+		if supported == nil {
+			supported = params
+			continue
+		}
+		for param := range supported {
+			if _, ok := params[param]; !ok {
+				delete(supported, param)
+			}
+		}
+	}
+	advertised := make([]string, 0, len(supported))
+	for param := range supported {
+		advertised = append(advertised, param)
+	}
+	sort.Strings(advertised)
+	for _, param := range strictStructuralParameters {
+		supported[param] = struct{}{}
+	}
+
+	var unsupported []string
+	gjson.ParseBytes(rawJSON).ForEach(func(key, _ gjson.Result) bool {
+		if _, ok := supported[key.String()]; !ok {
+			unsupported = append(unsupported, key.String())
+		}
+		return true
+	})
+	if len(unsupported) == 0 {
+		return nil
+	}
+	sort.Strings(unsupported)
+	return &interfaces.ErrorMessage{
+		StatusCode: http.StatusBadRequest,
+		Error: fmt.Errorf("model %s does not support request parameter(s): %s (supported: %s)",
+			modelKey, strings.Join(unsupported, ", "), strings.Join(advertised, ", ")),
+	}
+}
+
+// validateStrictBuiltinTools rejects a Responses request declaring built-in tools, such as
+// file_search, that a candidate provider would drop during translation. Without strict
+// parameters the translators drop them with a logged warning.
+func (h *BaseAPIHandler) validateStrictBuiltinTools(ctx context.Context, entryProtocol string, providers []string, rawJSON []byte) *interfaces.ErrorMessage {
+	if entryProtocol != OpenaiResponse || !strictParametersEnabled(ctx, h.CurrentConfig()) {
+		return nil
+	}
+	tools := translatorcommon.UnsupportedResponsesBuiltinTools(rawJSON)
+	if len(tools) == 0 {
+		return nil
+	}
+	for _, provider := range providers {
+		if _, drops := strictBuiltinToolDroppingProviders[strings.ToLower(strings.TrimSpace(provider))]; drops {
+			return &interfaces.ErrorMessage{
+				StatusCode: http.StatusBadRequest,
+				Error:      fmt.Errorf("provider %s does not support built-in tool(s): %s", provider, strings.Join(tools, ", ")),
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+func TestValidateStrictParameters(t *testing.T) {
+	const clientID = "test-strict-parameters"
+	registry.GetGlobalRegistry().RegisterClient(clientID, "strict-test", []*registry.ModelInfo{
+		{ID: "strict-model", SupportedParameters: []string{"temperature", "max_tokens", "stream", "tools"}},
+		{ID: "open-model"},
+	})
+	defer registry.GetGlobalRegistry().UnregisterClient(clientID)
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{StrictParameters: true}, coreauth.NewManager(nil, nil, nil))
+	providers := []string{"strict-test"}
+	ctx := context.Background()
+
+	errMsg := handler.validateStrictParameters(ctx, "openai", providers, "strict-model",
+		[]byte(`{"model":"strict-model","messages":[],"temperature":0.2,"top_k":5,"logprobs":true}`))
+	if errMsg == nil || errMsg.StatusCode != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unsupported parameters, got %+v", errMsg)
+	}
+	if msg := errMsg.Error.Error(); !strings.Contains(msg, "logprobs, top_k") {
+		t.Fatalf("error %q does not list the unsupported parameters", msg)
+	}
+
+	implied := []byte(`{"model":"strict-model","messages":[],"max_completion_tokens":10,"stream":true,"stream_options":{},"tools":[],"tool_choice":"auto"}`)
+	if errMsg = handler.validateStrictParameters(ctx, "openai", providers, "strict-model", implied); errMsg != nil {
+		t.Fatalf("implied parameters rejected: %v", errMsg.Error)
+	}
+
+	unsupported := []byte(`{"model":"strict-model","top_k":5}`)
+	if errMsg = handler.validateStrictParameters(ctx, "claude", providers, "strict-model", unsupported); errMsg != nil {
+		t.Fatalf("non-OpenAI entry protocol must not be validated: %v", errMsg.Error)
+	}
+	if errMsg = handler.validateStrictParameters(ctx, "openai", providers, "open-model", unsupported); errMsg != nil {
+		t.Fatalf("model without a parameter list must not be validated: %v", errMsg.Error)
+	}
+}
+
+func TestValidateStrictParametersWithRegistryModels(t *testing.T) {
+	var codexModel *registry.ModelInfo
+	for _, model := range registry.GetCodexFreeModels() {
+		if model.ID == "gpt-5.4-mini" {
+			codexModel = model
+		}
+	}
+	if codexModel == nil {
+		t.Fatal("expected gpt-5.4-mini in the codex-free model definitions")
+	}
+	const codexClient, otherClient = "test-strict-codex", "test-strict-other"
+	registry.GetGlobalRegistry().RegisterClient(codexClient, "strict-codex", []*registry.ModelInfo{codexModel})
+	defer registry.GetGlobalRegistry().UnregisterClient(codexClient)
+	registry.GetGlobalRegistry().RegisterClient(otherClient, "strict-other", []*registry.ModelInfo{
+		{ID: codexModel.ID, SupportedParameters: []string{"temperature", "max_tokens", "stream", "tools"}},
+	})
+	defer registry.GetGlobalRegistry().UnregisterClient(otherClient)
+
+	handler := NewBaseAPIHandlers(&sdkconfig.SDKConfig{StrictParameters: true}, coreauth.NewManager(nil, nil, nil))
+	ctx := context.Background()
+
+	streaming := []byte(`{"model":"gpt-5.4-mini","messages":[],"stream":true,"stream_options":{"include_usage":true},"reasoning_effort":"low","tools":[],"tool_choice":"auto"}`)
+	if errMsg := handler.validateStrictParameters(ctx, "openai", []string{"strict-codex"}, codexModel.ID, streaming); errMsg != nil {
+		t.Fatalf("streaming request rejected for the codex model: %v", errMsg.Error)
+	}
+
+	// temperature is listed by only one of the two providers, so either may drop it.
+	withTemperature := []byte(`{"model":"gpt-5.4-mini","messages":[],"temperature":0.2,"tools":[]}`)
+	errMsg := handler.validateStrictParameters(ctx, "openai", []string{"strict-other", "strict-codex"}, codexModel.ID, withTemperature)
+	if errMsg == nil || !strings.Contains(errMsg.Error.Error(), "parameter(s): temperature") {
+		t.Fatalf("expected temperature to be rejected across providers, got %+v", errMsg)
+	}
+	if errMsg = handler.validateStrictParameters(ctx, "openai", []string{"strict-other"}, codexModel.ID, withTemperature); errMsg != nil {
+		t.Fatalf("temperature rejected for a provider that lists it: %v", errMsg.Error)
+	}
+}
+
+func TestStrictParametersHeaderOverridesConfig(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ginCtx, _ := gin.CreateTestContext(httptest.NewRecorder())
+	ginCtx.Request = httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	ctx := context.WithValue(context.Background(), "gin", ginCtx)
+
+	if strictParametersEnabled(ctx, &sdkconfig.SDKConfig{}) {
+		t.Fatal("strict parameters must be off by default")
+	}
+	ginCtx.Request.Header.Set(StrictParametersHeader, "true")
+	if !strictParametersEnabled(ctx, &sdkconfig.SDKConfig{}) {
+		t.Fatal("header must enable strict parameters")
+	}
+	ginCtx.Request.Header.Set(StrictParametersHeader, "false")
+	if strictParametersEnabled(ctx, &sdkconfig.SDKConfig{StrictParameters: true}) {
+		t.Fatal("header must disable strict parameters")
+	}
+}
+
+func TestValidateStrictBuiltinTools(t *testing.T) {
+	fileSearch := []byte(`{"model":"claude-sonnet-4","input":"find it","tools":[{"type":"file_search","vector_store_ids":["vs_1"]},{"type":"web_search"}]}`)
+	strict := NewBaseAPIHandlers(&sdkconfig.SDKConfig{StrictParameters: true}, coreauth.NewManager(nil, nil, nil))
+	ctx := context.Background()
+
+	errMsg := strict.validateStrictBuiltinTools(ctx, "openai-response", []string{"codex", "claude"}, fileSearch)
+	if errMsg == nil || errMsg.StatusCode != http.StatusBadRequest || !strings.Contains(errMsg.Error.Error(), "file_search") {
+		t.Fatalf("expected 400 naming file_search, got %+v", errMsg)
+	}
+	if errMsg = strict.validateStrictBuiltinTools(ctx, "openai-response", []string{"codex"}, fileSearch); errMsg != nil {
+		t.Fatalf("provider keeping built-in tools rejected: %v", errMsg.Error)
+	}
+	lenient := NewBaseAPIHandlers(&sdkconfig.SDKConfig{}, coreauth.NewManager(nil, nil, nil))
+	if errMsg = lenient.validateStrictBuiltinTools(ctx, "openai-response", []string{"gemini"}, fileSearch); errMsg != nil {
+		t.Fatalf("built-in tools rejected without strict parameters: %v", errMsg.Error)
+	}
+}
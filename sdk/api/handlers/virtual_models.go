@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/interfaces"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	log "github.com/sirupsen/logrus"
+	"github.com/tidwall/gjson"
+)
+
+// resolveVirtualModel returns the model a request for modelName is routed to. Names other than
+// virtual models are returned unchanged; a virtual model resolves to its first candidate whose
+// condition rawJSON meets and whose model some provider currently serves.
+func (h *BaseAPIHandler) resolveVirtualModel(modelName string, rawJSON []byte) (string, *interfaces.ErrorMessage) {
+	cfg := h.CurrentConfig()
+	virtual, ok := cfg.FindVirtualModel(modelName)
+	if !ok {
+		return modelName, nil
+	}
+	for _, candidate := range virtual.Candidates {
+		model := strings.TrimSpace(candidate.Model)
+		if model == "" || !virtualCandidateMatches(candidate, rawJSON) {
+			continue
+		}
+		// Candidates are concrete models; a virtual model naming another one is skipped.
+		if _, nested := cfg.FindVirtualModel(model); nested {
+			continue
+		}
+		if _, _, _, errMsg := h.getRequestDetailsWithOptions(model, false); errMsg != nil {
+			continue
+		}
+		log.Debugf("virtual model %s resolved to %s", virtual.Name, model)
+		return model, nil
+	}
+	return "", &interfaces.ErrorMessage{
+		StatusCode: http.StatusServiceUnavailable,
+		Error:      fmt.Errorf("no candidate of virtual model %s is available for this request", virtual.Name),
+	}
+}
+
+// virtualCandidateMatches reports whether rawJSON meets the condition of candidate.
+func virtualCandidateMatches(candidate config.VirtualModelCandidate, rawJSON []byte) bool {
+	switch strings.ToLower(strings.TrimSpace(candidate.When)) {
+	case "", "else", "always":
+		return true
+	case "tools":
+		return requestDeclaresTools(rawJSON)
+	case "vision":
+		return requestHasImages(rawJSON)
+	default:
+		return false
+	}
+}
+
+// requestDeclaresTools reports whether an OpenAI, Claude or Gemini request declares tools.
+func requestDeclaresTools(rawJSON []byte) bool {
+	return len(gjson.GetBytes(rawJSON, "tools").Array()) > 0 || len(gjson.GetBytes(rawJSON, "functions").Array()) > 0
+}
+
+// requestHasImages reports whether an OpenAI chat or responses, Claude or Gemini request carries
+// an image.
+func requestHasImages(rawJSON []byte) bool {
+	for _, path := range []string{"messages", "input", "contents"} {
+		for _, message := range gjson.GetBytes(rawJSON, path).Array() {
+			for _, part := range message.Get("content").Array() {
+				switch part.Get("type").String() {
+				case "image_url", "input_image", "image":
+					return true
+				}
+			}
+			for _, part := range message.Get("parts").Array() {
+				for _, key := range []string{"inlineData.mimeType", "inline_data.mime_type", "fileData.mimeType", "file_data.mime_type"} {
+					if strings.HasPrefix(part.Get(key).String(), "image/") {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	sdkconfig "github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+func TestResolveVirtualModel(t *testing.T) {
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient("virtual-model-test-claude", "claude", []*registry.ModelInfo{{ID: "virtual-test-claude"}})
+	t.Cleanup(func() { reg.UnregisterClient("virtual-model-test-claude") })
+
+	h := NewBaseAPIHandlers(&sdkconfig.SDKConfig{
+		VirtualModels: []sdkconfig.VirtualModel{
+			{
+				Name: "smart-default",
+				Candidates: []sdkconfig.VirtualModelCandidate{
+					{When: "tools", Model: "virtual-test-claude"},
+					{When: "vision", Model: "virtual-test-unserved"},
+					{When: "else", Model: "copilot-gpt-5.1"},
+				},
+			},
+			{
+				Name:       "tools-only",
+				Candidates: []sdkconfig.VirtualModelCandidate{{When: "tools", Model: "virtual-test-claude"}},
+			},
+		},
+	}, nil)
+
+	cases := []struct {
+		name  string
+		model string
+		body  string
+		want  string
+	}{
+		{name: "tools", model: "Smart-Default", body: `{"tools":[{"type":"function","function":{"name":"f"}}]}`, want: "virtual-test-claude"},
+		{name: "unserved vision falls through", model: "smart-default", body: `{"messages":[{"role":"user","content":[{"type":"image_url","image_url":{"url":"data:image/png;base64,AA=="}}]}]}`, want: "copilot-gpt-5.1"},
+		{name: "else", model: "smart-default", body: `{"messages":[{"role":"user","content":"hi"}]}`, want: "copilot-gpt-5.1"},
+		{name: "not virtual", model: "gpt-5", body: `{}`, want: "gpt-5"},
+	}
+	for _, tc := range cases {
+		got, errMsg := h.resolveVirtualModel(tc.model, []byte(tc.body))
+		if errMsg != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, errMsg.Error)
+		}
+		if got != tc.want {
+			t.Fatalf("%s: model = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+
+	if _, errMsg := h.resolveVirtualModel("tools-only", []byte(`{"messages":[]}`)); errMsg == nil || errMsg.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503 without a matching candidate, got %v", errMsg)
+	}
+}
+
+func TestRequestHasImages(t *testing.T) {
+	cases := map[string]bool{
+		`{"messages":[{"role":"user","content":[{"type":"image","source":{"type":"base64"}}]}]}`:         true,
+		`{"input":[{"role":"user","content":[{"type":"input_image","image_url":"https://x/y.png"}]}]}`:   true,
+		`{"contents":[{"role":"user","parts":[{"inlineData":{"mimeType":"image/png","data":"AA=="}}]}]}`: true,
+		`{"contents":[{"role":"user","parts":[{"inlineData":{"mimeType":"audio/wav","data":"AA=="}}]}]}`: false,
+		`{"messages":[{"role":"user","content":"describe an image"}]}`:                                   false,
+	}
+	for body, want := range cases {
+		if got := requestHasImages([]byte(body)); got != want {
+			t.Fatalf("requestHasImages(%s) = %t, want %t", body, got, want)
+		}
+	}
+}
@@ -93,6 +93,10 @@ func (s *FileTokenStore) Save(ctx context.Context, auth *cliproxyauth.Auth) (str
 		}
 	}
 
+	if auth.Storage == nil && auth.Metadata == nil {
+		return "", fmt.Errorf("auth filestore: nothing to persist for %s", auth.ID)
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -100,42 +104,64 @@ func (s *FileTokenStore) Save(ctx context.Context, auth *cliproxyauth.Auth) (str
 		return "", fmt.Errorf("auth filestore: create dir failed: %w", err)
 	}
 
+	// Serialize writers across processes; s.mu only covers this process.
+	unlock, errLock := acquireAuthFileLock(path)
+	if errLock != nil {
+		return "", errLock
+	}
+	defer unlock()
+
+	existing, errRead := os.ReadFile(path)
+	if errRead != nil && !os.IsNotExist(errRead) {
+		return "", fmt.Errorf("auth filestore: read existing failed: %w", errRead)
+	}
+	var stored map[string]any
+	if len(existing) > 0 {
+		_ = json.Unmarshal(existing, &stored)
+	}
+
+	if auth.Metadata == nil {
+		auth.Metadata = make(map[string]any)
+	}
+	auth.Metadata["disabled"] = auth.Disabled
+
+	// Compare-and-swap on the revision: a file written by someone else after this record was
+	// loaded is only overwritten when it does not carry a fresher token.
+	storedRevision := cliproxyauth.MetadataRevision(stored)
+	if storedRevision > cliproxyauth.MetadataRevision(auth.Metadata) && cliproxyauth.PreferStoredMetadata(stored, incomingAuthMetadata(auth)) {
+		return "", fmt.Errorf("auth filestore: %s: %w", path, cliproxyauth.ErrStoreConflict)
+	}
+	auth.Metadata[cliproxyauth.MetadataRevisionKey] = storedRevision
+
 	// metadataSetter is a private interface for TokenStorage implementations that support metadata injection.
 	type metadataSetter interface {
 		SetMetadata(map[string]any)
 	}
 
-	switch {
-	case auth.Storage != nil:
-		if auth.Metadata == nil {
-			auth.Metadata = make(map[string]any)
-		}
-		auth.Metadata["disabled"] = auth.Disabled
+	if auth.Storage != nil {
+		auth.Metadata[cliproxyauth.MetadataRevisionKey] = storedRevision + 1
 		if setter, ok := auth.Storage.(metadataSetter); ok {
 			setter.SetMetadata(auth.Metadata)
 		}
 		if err = auth.Storage.SaveTokenToFile(path); err != nil {
 			return "", err
 		}
-	case auth.Metadata != nil:
-		auth.Metadata["disabled"] = auth.Disabled
+	} else {
 		raw, errMarshal := json.Marshal(auth.Metadata)
 		if errMarshal != nil {
 			return "", fmt.Errorf("auth filestore: marshal metadata failed: %w", errMarshal)
 		}
-		if existing, errRead := os.ReadFile(path); errRead == nil {
-			if jsonEqual(existing, raw) {
-				return path, nil
-			}
-		} else if !os.IsNotExist(errRead) {
-			return "", fmt.Errorf("auth filestore: read existing failed: %w", errRead)
+		if len(existing) > 0 && jsonEqual(existing, raw) {
+			return path, nil
+		}
+		auth.Metadata[cliproxyauth.MetadataRevisionKey] = storedRevision + 1
+		if raw, errMarshal = json.Marshal(auth.Metadata); errMarshal != nil {
+			return "", fmt.Errorf("auth filestore: marshal metadata failed: %w", errMarshal)
 		}
 		// Use atomic write to prevent race conditions with file watcher
 		if errWrite := util.AtomicWriteFile(path, raw, 0o600); errWrite != nil {
 			return "", fmt.Errorf("auth filestore: write file failed: %w", errWrite)
 		}
-	default:
-		return "", fmt.Errorf("auth filestore: nothing to persist for %s", auth.ID)
 	}
 
 	if auth.Attributes == nil {
@@ -152,6 +178,39 @@ func (s *FileTokenStore) Save(ctx context.Context, auth *cliproxyauth.Auth) (str
 	return path, nil
 }
 
+// acquireAuthFileLock takes an exclusive advisory lock on a hidden sidecar file next to path.
+// The auth file itself is replaced by atomic renames, so it cannot carry the lock.
+func acquireAuthFileLock(path string) (func(), error) {
+	lockPath := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".lock")
+	f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("auth filestore: open lock file failed: %w", err)
+	}
+	if errLock := lockFile(f); errLock != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("auth filestore: lock %s failed: %w", path, errLock)
+	}
+	return func() {
+		_ = unlockFile(f)
+		_ = f.Close()
+	}, nil
+}
+
+// incomingAuthMetadata returns the fields a Save of auth would write, with metadata overriding
+// token storage fields the same way TokenStorage implementations merge them.
+func incomingAuthMetadata(auth *cliproxyauth.Auth) map[string]any {
+	out := make(map[string]any)
+	if auth.Storage != nil {
+		if raw, errMarshal := json.Marshal(auth.Storage); errMarshal == nil {
+			_ = json.Unmarshal(raw, &out)
+		}
+	}
+	for k, v := range auth.Metadata {
+		out[k] = v
+	}
+	return out
+}
+
 // List enumerates all auth JSON files under the configured directory.
 func (s *FileTokenStore) List(ctx context.Context) ([]*cliproxyauth.Auth, error) {
 	dir := s.baseDirSnapshot()
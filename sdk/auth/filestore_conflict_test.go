@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	cliproxyauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+func readAuthFileMetadata(t *testing.T, path string) map[string]any {
+	t.Helper()
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read auth file: %v", err)
+	}
+	meta := make(map[string]any)
+	if err = json.Unmarshal(raw, &meta); err != nil {
+		t.Fatalf("unmarshal auth file: %v", err)
+	}
+	return meta
+}
+
+func TestFileTokenStore_Save_BumpsRevision(t *testing.T) {
+	baseDir := t.TempDir()
+	store := NewFileTokenStore()
+	store.SetBaseDir(baseDir)
+
+	auth := &cliproxyauth.Auth{
+		ID:       "rev.json",
+		FileName: "rev.json",
+		Metadata: map[string]any{"type": "claude", "expired": "2026-01-01T00:00:00Z"},
+	}
+	path, err := store.Save(context.Background(), auth)
+	if err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	if got := cliproxyauth.MetadataRevision(readAuthFileMetadata(t, path)); got != 1 {
+		t.Fatalf("revision = %d, want 1", got)
+	}
+
+	// Saving identical content must not bump the revision.
+	if _, err = store.Save(context.Background(), auth); err != nil {
+		t.Fatalf("second Save() error: %v", err)
+	}
+	if got := cliproxyauth.MetadataRevision(readAuthFileMetadata(t, path)); got != 1 {
+		t.Fatalf("revision after no-op save = %d, want 1", got)
+	}
+
+	auth.Metadata["expired"] = "2026-01-02T00:00:00Z"
+	if _, err = store.Save(context.Background(), auth); err != nil {
+		t.Fatalf("third Save() error: %v", err)
+	}
+	if got := cliproxyauth.MetadataRevision(readAuthFileMetadata(t, path)); got != 2 {
+		t.Fatalf("revision = %d, want 2", got)
+	}
+}
+
+func TestFileTokenStore_Save_RejectsStaleOverwrite(t *testing.T) {
+	baseDir := t.TempDir()
+	path := filepath.Join(baseDir, "shared.json")
+	// Another process already refreshed the token and bumped the revision.
+	if err := os.WriteFile(path, []byte(`{"type":"claude","expired":"2026-01-02T00:00:00Z","revision":3}`), 0o600); err != nil {
+		t.Fatalf("seed auth file: %v", err)
+	}
+	store := NewFileTokenStore()
+	store.SetBaseDir(baseDir)
+
+	stale := &cliproxyauth.Auth{
+		ID:       "shared.json",
+		FileName: "shared.json",
+		Metadata: map[string]any{"type": "claude", "expired": "2026-01-01T00:00:00Z", "revision": float64(2)},
+	}
+	if _, err := store.Save(context.Background(), stale); !errors.Is(err, cliproxyauth.ErrStoreConflict) {
+		t.Fatalf("Save() error = %v, want ErrStoreConflict", err)
+	}
+	meta := readAuthFileMetadata(t, path)
+	if meta["expired"] != "2026-01-02T00:00:00Z" {
+		t.Fatalf("stored token was overwritten: %v", meta)
+	}
+
+	// A stale revision carrying a fresher token still wins.
+	fresher := &cliproxyauth.Auth{
+		ID:       "shared.json",
+		FileName: "shared.json",
+		Metadata: map[string]any{"type": "claude", "expired": "2026-01-03T00:00:00Z", "revision": float64(2)},
+	}
+	if _, err := store.Save(context.Background(), fresher); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	meta = readAuthFileMetadata(t, path)
+	if meta["expired"] != "2026-01-03T00:00:00Z" || cliproxyauth.MetadataRevision(meta) != 4 {
+		t.Fatalf("metadata = %v, want fresher token at revision 4", meta)
+	}
+}
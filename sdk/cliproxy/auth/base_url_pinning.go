@@ -0,0 +1,54 @@
+package auth
+
+import "strings"
+
+// BaseURLPinner chooses the endpoint a credential with regional base URLs should use, e.g. the
+// fastest healthy one by probe latency.
+type BaseURLPinner interface {
+	PinBaseURL(authID string, candidates []string) string
+}
+
+// SetBaseURLPinner installs pinner; nil removes it.
+func (m *Manager) SetBaseURLPinner(pinner BaseURLPinner) {
+	if m == nil {
+		return
+	}
+	if pinner == nil {
+		m.baseURLPinner.Store(nil)
+		return
+	}
+	m.baseURLPinner.Store(&pinner)
+}
+
+// applyPinnedBaseURL returns auth with its base_url attribute replaced by the pinned endpoint
+// when the credential lists regional_base_urls. The configured base_url is the first candidate.
+func (m *Manager) applyPinnedBaseURL(auth *Auth) *Auth {
+	if m == nil || auth == nil {
+		return auth
+	}
+	regional := strings.TrimSpace(auth.Attributes["regional_base_urls"])
+	if regional == "" {
+		return auth
+	}
+	pinner := m.baseURLPinner.Load()
+	if pinner == nil {
+		return auth
+	}
+	base := strings.TrimRight(strings.TrimSpace(auth.Attributes["base_url"]), "/")
+	candidates := make([]string, 0, 4)
+	if base != "" {
+		candidates = append(candidates, base)
+	}
+	for _, url := range strings.Split(regional, ",") {
+		if url = strings.TrimSpace(url); url != "" && url != base {
+			candidates = append(candidates, url)
+		}
+	}
+	pinned := (*pinner).PinBaseURL(auth.ID, candidates)
+	if pinned == "" || pinned == base {
+		return auth
+	}
+	out := auth.Clone()
+	out.Attributes["base_url"] = pinned
+	return out
+}
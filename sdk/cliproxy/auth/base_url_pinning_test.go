@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"slices"
+	"testing"
+)
+
+type fixedPinner struct {
+	pinned     string
+	candidates []string
+}
+
+func (p *fixedPinner) PinBaseURL(_ string, candidates []string) string {
+	p.candidates = candidates
+	return p.pinned
+}
+
+func TestApplyPinnedBaseURL(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	auth := &Auth{ID: "gemini-key", Attributes: map[string]string{
+		"base_url":           "https://generativelanguage.googleapis.com/",
+		"regional_base_urls": "https://eu.example.com, https://us.example.com",
+	}}
+	if got := m.applyPinnedBaseURL(auth); got != auth {
+		t.Fatal("without a pinner the auth must be returned unchanged")
+	}
+
+	pinner := &fixedPinner{pinned: "https://eu.example.com"}
+	m.SetBaseURLPinner(pinner)
+	got := m.applyPinnedBaseURL(auth)
+	if got == auth || got.Attributes["base_url"] != "https://eu.example.com" {
+		t.Fatalf("base_url = %q, want the pinned endpoint on a copy", got.Attributes["base_url"])
+	}
+	if auth.Attributes["base_url"] != "https://generativelanguage.googleapis.com/" {
+		t.Fatal("the shared auth must not be modified")
+	}
+	want := []string{"https://generativelanguage.googleapis.com", "https://eu.example.com", "https://us.example.com"}
+	if !slices.Equal(pinner.candidates, want) {
+		t.Fatalf("candidates = %v, want %v", pinner.candidates, want)
+	}
+
+	plain := &Auth{ID: "claude-key", Attributes: map[string]string{"base_url": "https://api.anthropic.com"}}
+	if got := m.applyPinnedBaseURL(plain); got != plain {
+		t.Fatal("auths without regional base URLs must not be pinned")
+	}
+}
@@ -12,6 +12,8 @@ const (
 	AuthSourceMemory      = "memory"
 	AuthSourceObjectStore = "objectstore"
 	AuthSourcePostgres    = "postgres"
+	AuthSourceVault       = "vault"
+	AuthSourceAWSSecrets  = "aws-secrets-manager"
 
 	AttributeAPIKey        = "api_key"
 	AttributeAuthKind      = "auth_kind"
@@ -98,6 +100,10 @@ func normalizeAuthSourceKind(source string) string {
 		return AuthSourceObjectStore
 	case AuthSourcePostgres, "postgresql", "database", "db":
 		return AuthSourcePostgres
+	case AuthSourceVault:
+		return AuthSourceVault
+	case AuthSourceAWSSecrets, "secretsmanager", "aws-secretsmanager":
+		return AuthSourceAWSSecrets
 	default:
 		return ""
 	}
@@ -0,0 +1,206 @@
+package auth
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+// maxConcurrentRequestsKey is the attribute or metadata key capping the in-flight requests of
+// one credential.
+const maxConcurrentRequestsKey = "max_concurrent_requests"
+
+// authConcurrency counts in-flight requests per credential. The zero value is ready to use.
+type authConcurrency struct {
+	mu       sync.Mutex
+	inFlight map[string]int
+	// released is closed and replaced whenever a slot frees up, waking waiters.
+	released chan struct{}
+}
+
+func (c *authConcurrency) tryAcquire(authID string, limit int) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.inFlight[authID] >= limit {
+		return false
+	}
+	if c.inFlight == nil {
+		c.inFlight = make(map[string]int)
+	}
+	c.inFlight[authID]++
+	return true
+}
+
+func (c *authConcurrency) release(authID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.inFlight[authID] <= 1 {
+		delete(c.inFlight, authID)
+	} else {
+		c.inFlight[authID]--
+	}
+	if c.released != nil {
+		close(c.released)
+		c.released = nil
+	}
+}
+
+// changed returns a channel closed by the next release.
+func (c *authConcurrency) changed() <-chan struct{} {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.released == nil {
+		c.released = make(chan struct{})
+	}
+	return c.released
+}
+
+// InFlight returns the number of requests currently running on authID.
+func (m *Manager) InFlight(authID string) int {
+	if m == nil {
+		return 0
+	}
+	m.concurrency.mu.Lock()
+	defer m.concurrency.mu.Unlock()
+	return m.concurrency.inFlight[authID]
+}
+
+// authMaxConcurrentRequests returns the max_concurrent_requests cap of auth from its attributes
+// or metadata, or 0 when it is unlimited.
+func authMaxConcurrentRequests(auth *Auth) int {
+	if auth == nil {
+		return 0
+	}
+	if raw := strings.TrimSpace(auth.Attributes[maxConcurrentRequestsKey]); raw != "" {
+		if limit, errParse := strconv.Atoi(raw); errParse == nil && limit > 0 {
+			return limit
+		}
+		return 0
+	}
+	switch v := auth.Metadata[maxConcurrentRequestsKey].(type) {
+	case float64:
+		return max(int(v), 0)
+	case int:
+		return max(v, 0)
+	case string:
+		if limit, errParse := strconv.Atoi(strings.TrimSpace(v)); errParse == nil && limit > 0 {
+			return limit
+		}
+	}
+	return 0
+}
+
+// concurrencyWait returns how long a request may wait for a credential slot when every
+// candidate is at its cap; 0 fails over immediately.
+func (m *Manager) concurrencyWait() time.Duration {
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil {
+		return 0
+	}
+	raw := strings.TrimSpace(cfg.Routing.ConcurrencyWait)
+	if raw == "" {
+		return 0
+	}
+	wait, errParse := time.ParseDuration(raw)
+	if errParse != nil || wait < 0 {
+		return 0
+	}
+	return wait
+}
+
+// pickNextMixedWithinLimits picks like pickNextMixed but skips credentials that are at their
+// max_concurrent_requests cap or that routing keeps out of the pick (see excludeUnroutedAuths).
+// When every remaining candidate is at its cap it waits up to routing.concurrency-wait for a
+// slot, then fails with a retryable 429. The returned release frees the slot and the lease that
+// keeps the picked executor's sessions open while it serves the request.
+func (m *Manager) pickNextMixedWithinLimits(ctx context.Context, providers []string, model string, opts cliproxyexecutor.Options, tried map[string]struct{}) (*Auth, ProviderExecutor, string, func(), error) {
+	var deadline time.Time
+	var full map[string]struct{}
+	exclude := tried
+	for {
+		wake := m.concurrency.changed()
+		auth, executor, provider, errPick := m.pickNextMixed(ctx, providers, model, opts, m.excludeUnroutedAuths(providers, model, opts, exclude))
+		if errPick != nil {
+			if errResidency := m.residencyError(ctx, providers, model, opts); errResidency != nil {
+				return nil, nil, "", nil, errResidency
+			}
+			if len(full) == 0 {
+				return nil, nil, "", nil, errPick
+			}
+			if deadline.IsZero() {
+				deadline = time.Now().Add(m.concurrencyWait())
+			}
+			remaining := time.Until(deadline)
+			if remaining <= 0 {
+				return nil, nil, "", nil, &Error{Code: "auth_concurrency_limit", Message: "every available credential is at its concurrency limit", Retryable: true, HTTPStatus: http.StatusTooManyRequests}
+			}
+			timer := time.NewTimer(remaining)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, nil, "", nil, ctx.Err()
+			case <-timer.C:
+			case <-wake:
+				timer.Stop()
+			}
+			full, exclude = nil, tried
+			continue
+		}
+		limit := authMaxConcurrentRequests(auth)
+		if limit <= 0 {
+			return auth, executor, provider, m.executorLeases.acquire(executor), nil
+		}
+		if m.concurrency.tryAcquire(auth.ID, limit) {
+			authID := auth.ID
+			lease := m.executorLeases.acquire(executor)
+			var once sync.Once
+			return auth, executor, provider, func() {
+				once.Do(func() {
+					m.concurrency.release(authID)
+					if lease != nil {
+						lease()
+					}
+				})
+			}, nil
+		}
+		if full == nil {
+			full = make(map[string]struct{})
+			exclude = maps.Clone(tried)
+			if exclude == nil {
+				exclude = make(map[string]struct{})
+			}
+		}
+		full[auth.ID] = struct{}{}
+		exclude[auth.ID] = struct{}{}
+	}
+}
+
+// releaseOnStreamEnd returns result with release called once its chunks are drained.
+func releaseOnStreamEnd(result *cliproxyexecutor.StreamResult, release func()) *cliproxyexecutor.StreamResult {
+	if release == nil {
+		return result
+	}
+	if result == nil || result.Chunks == nil {
+		release()
+		return result
+	}
+	out := make(chan cliproxyexecutor.StreamChunk)
+	in := result.Chunks
+	go func() {
+		defer close(out)
+		defer release()
+		for chunk := range in {
+			out <- chunk
+		}
+	}()
+	wrapped := *result
+	wrapped.Chunks = out
+	return &wrapped
+}
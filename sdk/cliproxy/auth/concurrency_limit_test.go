@@ -0,0 +1,102 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+func newConcurrencyTestManager(t *testing.T, wait string) *Manager {
+	t.Helper()
+	manager := NewManager(nil, &FillFirstSelector{}, nil)
+	manager.SetConfig(&internalconfig.Config{Routing: internalconfig.RoutingConfig{ConcurrencyWait: wait}})
+	manager.executors["claude"] = schedulerTestExecutor{}
+	for _, auth := range []*Auth{
+		{ID: "claude-a", Provider: "claude", Attributes: map[string]string{maxConcurrentRequestsKey: "1"}},
+		{ID: "claude-b", Provider: "claude", Metadata: map[string]any{maxConcurrentRequestsKey: float64(1)}},
+	} {
+		if _, errRegister := manager.Register(context.Background(), auth); errRegister != nil {
+			t.Fatalf("Register(%s) error = %v", auth.ID, errRegister)
+		}
+	}
+	return manager
+}
+
+func TestPickNextMixedWithinLimits_SkipsCredentialsAtTheirCap(t *testing.T) {
+	manager := newConcurrencyTestManager(t, "")
+	providers := []string{"claude"}
+	ctx := context.Background()
+
+	first, _, _, releaseFirst, errPick := manager.pickNextMixedWithinLimits(ctx, providers, "", cliproxyexecutor.Options{}, nil)
+	if errPick != nil || first.ID != "claude-a" || releaseFirst == nil {
+		t.Fatalf("first pick = %v, %v; want claude-a with a release", first, errPick)
+	}
+	second, _, _, releaseSecond, errPick := manager.pickNextMixedWithinLimits(ctx, providers, "", cliproxyexecutor.Options{}, nil)
+	if errPick != nil || second.ID != "claude-b" {
+		t.Fatalf("second pick = %v, %v; want claude-b", second, errPick)
+	}
+
+	_, _, _, _, errPick = manager.pickNextMixedWithinLimits(ctx, providers, "", cliproxyexecutor.Options{}, nil)
+	var authErr *Error
+	if !errors.As(errPick, &authErr) || authErr.HTTPStatus != http.StatusTooManyRequests || !authErr.Retryable {
+		t.Fatalf("pick with every credential full error = %v, want a retryable 429", errPick)
+	}
+
+	releaseFirst()
+	releaseFirst()
+	if got := manager.InFlight("claude-a"); got != 0 {
+		t.Fatalf("InFlight(claude-a) after double release = %d, want 0", got)
+	}
+	third, _, _, releaseThird, errPick := manager.pickNextMixedWithinLimits(ctx, providers, "", cliproxyexecutor.Options{}, nil)
+	if errPick != nil || third.ID != "claude-a" {
+		t.Fatalf("pick after release = %v, %v; want claude-a", third, errPick)
+	}
+	releaseSecond()
+	releaseThird()
+}
+
+func TestPickNextMixedWithinLimits_WaitsForAFreeSlot(t *testing.T) {
+	manager := newConcurrencyTestManager(t, "5s")
+	providers := []string{"claude"}
+	ctx := context.Background()
+
+	_, _, _, releaseA, _ := manager.pickNextMixedWithinLimits(ctx, providers, "", cliproxyexecutor.Options{}, nil)
+	_, _, _, releaseB, _ := manager.pickNextMixedWithinLimits(ctx, providers, "", cliproxyexecutor.Options{}, nil)
+	time.AfterFunc(20*time.Millisecond, releaseB)
+
+	got, _, _, release, errPick := manager.pickNextMixedWithinLimits(ctx, providers, "", cliproxyexecutor.Options{}, nil)
+	if errPick != nil || got.ID != "claude-b" {
+		t.Fatalf("waiting pick = %v, %v; want claude-b once released", got, errPick)
+	}
+	release()
+
+	waitCtx, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	_, _, _, releaseHeld, _ := manager.pickNextMixedWithinLimits(ctx, providers, "", cliproxyexecutor.Options{}, nil)
+	if _, _, _, _, errPick = manager.pickNextMixedWithinLimits(waitCtx, providers, "", cliproxyexecutor.Options{}, nil); !errors.Is(errPick, context.DeadlineExceeded) {
+		t.Fatalf("waiting pick with expiring context error = %v, want deadline exceeded", errPick)
+	}
+	releaseA()
+	releaseHeld()
+}
+
+func TestReleaseOnStreamEnd(t *testing.T) {
+	released := make(chan struct{})
+	chunks := make(chan cliproxyexecutor.StreamChunk, 1)
+	chunks <- cliproxyexecutor.StreamChunk{Payload: []byte("data")}
+	close(chunks)
+
+	result := releaseOnStreamEnd(&cliproxyexecutor.StreamResult{Chunks: chunks}, func() { close(released) })
+	for range result.Chunks {
+	}
+	select {
+	case <-released:
+	case <-time.After(time.Second):
+		t.Fatal("slot was not released when the stream ended")
+	}
+}
@@ -50,6 +50,12 @@ type ProviderExecutor interface {
 	HttpRequest(ctx context.Context, auth *Auth, req *http.Request) (*http.Response, error)
 }
 
+// EmbeddingsExecutor is implemented by executors whose provider serves embeddings. The request
+// payload is an OpenAI embeddings request and the response payload an OpenAI embeddings response.
+type EmbeddingsExecutor interface {
+	ExecuteEmbeddings(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error)
+}
+
 // RequestAuthPreparer lets an executor update missing auth metadata immediately
 // before a request. Manager serializes and persists returned updates.
 type RequestAuthPreparer interface {
@@ -259,6 +265,10 @@ type Manager struct {
 	mu            sync.RWMutex
 	auths         map[string]*Auth
 	scheduler     *authScheduler
+	// recentErrors keeps the latest failures per auth for the management API.
+	recentErrors recentErrorLog
+	// health tracks last outcomes and retry hints per auth; see AuthHealth.
+	health authHealthTracker
 	// pluginScheduler runs outside m.mu before falling back to native selection.
 	pluginScheduler PluginScheduler
 	// homeRuntimeAuths caches auths returned by Home so websocket sessions can
@@ -282,6 +292,35 @@ type Manager struct {
 	// modelPoolOffsets tracks per-auth alias pool rotation state.
 	modelPoolOffsets map[string]int
 
+	// preflight holds the most recent startup credential validation report.
+	preflight atomic.Pointer[PreflightReport]
+
+	// headerSessions backs the {session_uuid} header template variable.
+	headerSessions headerSessionIDs
+
+	// sessionProviders backs routing.provider-affinity.
+	sessionProviders sessionProviders
+
+	// outageChecker reports providers with declared incidents; see SetProviderOutageChecker.
+	outageChecker atomic.Pointer[ProviderOutageChecker]
+	// baseURLPinner picks among regional base URLs; see SetBaseURLPinner.
+	baseURLPinner atomic.Pointer[BaseURLPinner]
+	// registeredObserver is told about newly registered auths; see SetAuthRegisteredObserver.
+	registeredObserver atomic.Pointer[AuthRegisteredObserver]
+	// standbyEngaged records per provider whether standby credentials are in rotation.
+	standbyEngaged sync.Map
+	// regionFailovers maps failed over regions to when they rejoin their failover group.
+	regionFailovers sync.Map
+	// regionCurrent records the current region of each failover group and model.
+	regionCurrent sync.Map
+	// concurrency counts in-flight requests for max_concurrent_requests caps.
+	concurrency authConcurrency
+	// executorLeases counts in-flight requests per executor instance; see RegisterExecutor.
+	executorLeases executorLeases
+	// selectionPolicies maps providers to custom selection policies; see SetSelectionPolicy.
+	selectionPolicies   atomic.Pointer[map[string]SelectionPolicy]
+	selectionPoliciesMu sync.Mutex
+
 	// runtimeConfig stores the latest application config for request-time decisions.
 	// It is initialized in NewManager; never Load() before first Store().
 	runtimeConfig atomic.Value
@@ -2264,12 +2303,12 @@ func (m *Manager) RegisterExecutor(executor ProviderExecutor) {
 	m.executors[provider] = executor
 	m.mu.Unlock()
 
+	m.executorLeases.reinstate(executor)
 	if replaced == nil || replaced == executor {
 		return
 	}
-	if closer, ok := replaced.(ExecutionSessionCloser); ok && closer != nil {
-		closer.CloseExecutionSession(CloseAllExecutionSessionsID)
-	}
+	// Requests already running on the replaced executor finish there before its sessions close.
+	m.executorLeases.retire(replaced)
 }
 
 // UnregisterExecutor removes the executor associated with the provider key.
@@ -2310,6 +2349,7 @@ func (m *Manager) Register(ctx context.Context, auth *Auth) (*Auth, error) {
 	m.queueRefreshReschedule(auth.ID)
 	_ = m.persist(ctx, auth)
 	m.hook.OnAuthRegistered(ctx, auth.Clone())
+	m.notifyAuthRegistered(ctx, auth.Clone())
 	if clearedCooldown {
 		m.persistCooldownStates(ctx)
 	}
@@ -2405,6 +2445,7 @@ func (m *Manager) Remove(ctx context.Context, id string) {
 	}
 	m.queueRefreshUnschedule(id)
 	m.invalidateSessionAffinity(id)
+	m.health.remove(id)
 
 	if provider != "" {
 		if exec, ok := m.Executor(provider); ok && exec != nil {
@@ -2461,7 +2502,11 @@ func (m *Manager) Load(ctx context.Context) error {
 // Execute performs a non-streaming execution using the configured selector and executor.
 // It supports multiple providers for the same model and round-robins the starting provider per model.
 func (m *Manager) Execute(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
-	normalized := m.normalizeProviders(providers)
+	normalized, errReadOnly := m.excludeReadOnlyProviders(m.normalizeProviders(providers))
+	if errReadOnly != nil {
+		return cliproxyexecutor.Response{}, errReadOnly
+	}
+	normalized = m.avoidProviderOutages(ctx, normalized)
 	if len(normalized) == 0 {
 		return cliproxyexecutor.Response{}, &Error{Code: "provider_not_found", Message: "no provider supplied"}
 	}
@@ -2499,7 +2544,47 @@ func (m *Manager) Execute(ctx context.Context, providers []string, req cliproxye
 
 // It supports multiple providers for the same model and round-robins the starting provider per model.
 func (m *Manager) ExecuteCount(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
-	normalized := m.normalizeProviders(providers)
+	return m.executeUnary(ctx, m.normalizeProviders(providers), req, opts, countTokensCall)
+}
+
+// ExecuteEmbeddings performs an OpenAI embeddings request on the providers whose executors
+// implement EmbeddingsExecutor, with the same credential selection and retries as Execute.
+func (m *Manager) ExecuteEmbeddings(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	normalized, errReadOnly := m.excludeReadOnlyProviders(m.normalizeProviders(providers))
+	if errReadOnly != nil {
+		return cliproxyexecutor.Response{}, errReadOnly
+	}
+	supported := make([]string, 0, len(normalized))
+	for _, provider := range normalized {
+		if _, ok := m.executorFor(provider).(EmbeddingsExecutor); ok {
+			supported = append(supported, provider)
+		}
+	}
+	if len(normalized) > 0 && len(supported) == 0 {
+		return cliproxyexecutor.Response{}, &Error{Code: "embeddings_not_supported", Message: "no provider of this model supports embeddings", HTTPStatus: http.StatusBadRequest}
+	}
+	return m.executeUnary(ctx, supported, req, opts, embeddingsCall)
+}
+
+// unaryCall performs one non-streaming executor call other than Execute.
+type unaryCall func(ctx context.Context, executor ProviderExecutor, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error)
+
+func countTokensCall(ctx context.Context, executor ProviderExecutor, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return executor.CountTokens(ctx, auth, req, opts)
+}
+
+func embeddingsCall(ctx context.Context, executor ProviderExecutor, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	embedder, ok := executor.(EmbeddingsExecutor)
+	if !ok {
+		return cliproxyexecutor.Response{}, &Error{Code: "embeddings_not_supported", Message: "provider does not support embeddings", HTTPStatus: http.StatusBadRequest}
+	}
+	return embedder.ExecuteEmbeddings(ctx, auth, req, opts)
+}
+
+// executeUnary runs call on the credentials of providers, retrying across credentials and after
+// cooldowns like Execute.
+func (m *Manager) executeUnary(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, call unaryCall) (cliproxyexecutor.Response, error) {
+	normalized := m.avoidProviderOutages(ctx, providers)
 	if len(normalized) == 0 {
 		return cliproxyexecutor.Response{}, &Error{Code: "provider_not_found", Message: "no provider supplied"}
 	}
@@ -2509,7 +2594,7 @@ func (m *Manager) ExecuteCount(ctx context.Context, providers []string, req clip
 	var lastErr error
 	retryModel := authSelectionModelFromOptions(opts, req.Model)
 	for attempt := 0; ; attempt++ {
-		resp, errExec := m.executeCountMixedOnce(ctx, normalized, req, opts, maxRetryCredentials)
+		resp, errExec := m.executeUnaryMixedOnce(ctx, normalized, req, opts, maxRetryCredentials, call)
 		if errExec == nil {
 			return resp, nil
 		}
@@ -2531,7 +2616,11 @@ func (m *Manager) ExecuteCount(ctx context.Context, providers []string, req clip
 // ExecuteStream performs a streaming execution using the configured selector and executor.
 // It supports multiple providers for the same model and round-robins the starting provider per model.
 func (m *Manager) ExecuteStream(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (*cliproxyexecutor.StreamResult, error) {
-	normalized := m.normalizeProviders(providers)
+	normalized, errReadOnly := m.excludeReadOnlyProviders(m.normalizeProviders(providers))
+	if errReadOnly != nil {
+		return nil, errReadOnly
+	}
+	normalized = m.avoidProviderOutages(ctx, normalized)
 	if len(normalized) == 0 {
 		return nil, &Error{Code: "provider_not_found", Message: "no provider supplied"}
 	}
@@ -2691,7 +2780,18 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 	tried := make(map[string]struct{})
 	attempted := make(map[string]struct{})
 	var lastErr error
+	// release frees the concurrency slot and executor lease of the credential being tried.
+	var release func()
+	defer func() {
+		if release != nil {
+			release()
+		}
+	}()
 	for {
+		if release != nil {
+			release()
+			release = nil
+		}
 		if !homeMode && maxRetryCredentials > 0 && len(attempted) >= maxRetryCredentials {
 			if lastErr != nil {
 				return cliproxyexecutor.Response{}, lastErr
@@ -2702,13 +2802,14 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 		if homeMode {
 			pickOpts = withHomeAuthCount(opts, homeAuthCount)
 		}
-		auth, executor, provider, errPick := m.pickNextMixed(ctx, providers, routeModel, pickOpts, tried)
+		auth, executor, provider, releaseSlot, errPick := m.pickNextMixedWithinLimits(ctx, providers, routeModel, pickOpts, tried)
 		if errPick != nil {
 			if shouldReturnLastErrorOnPickFailure(homeMode, lastErr, errPick) {
 				return cliproxyexecutor.Response{}, lastErr
 			}
 			return cliproxyexecutor.Response{}, errPick
 		}
+		release = releaseSlot
 
 		entry := logEntryWithRequestID(ctx)
 		debugLogAuthSelection(entry, auth, provider, routeModel)
@@ -2738,6 +2839,8 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 			lastErr = errPrepare
 			continue
 		}
+		auth = m.applyHeaderTemplates(auth, opts)
+		auth = m.applyPinnedBaseURL(auth)
 		var authErr error
 		didRefreshOnUnauthorized := false
 		for _, upstreamModel := range models {
@@ -2793,6 +2896,7 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 				continue
 			}
 			m.MarkResult(execCtx, result)
+			m.recordSessionProvider(ctx, routeModel, opts, provider)
 			rewriteForceMappedResponse(&resp, aliasResult)
 			return resp, nil
 		}
@@ -2809,7 +2913,7 @@ func (m *Manager) executeMixedOnce(ctx context.Context, providers []string, req
 	}
 }
 
-func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, maxRetryCredentials int) (cliproxyexecutor.Response, error) {
+func (m *Manager) executeUnaryMixedOnce(ctx context.Context, providers []string, req cliproxyexecutor.Request, opts cliproxyexecutor.Options, maxRetryCredentials int, call unaryCall) (cliproxyexecutor.Response, error) {
 	if len(providers) == 0 {
 		return cliproxyexecutor.Response{}, &Error{Code: "provider_not_found", Message: "no provider supplied"}
 	}
@@ -2821,7 +2925,18 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 	tried := make(map[string]struct{})
 	attempted := make(map[string]struct{})
 	var lastErr error
+	// release frees the concurrency slot and executor lease of the credential being tried.
+	var release func()
+	defer func() {
+		if release != nil {
+			release()
+		}
+	}()
 	for {
+		if release != nil {
+			release()
+			release = nil
+		}
 		if !homeMode && maxRetryCredentials > 0 && len(attempted) >= maxRetryCredentials {
 			if lastErr != nil {
 				return cliproxyexecutor.Response{}, lastErr
@@ -2832,13 +2947,14 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 		if homeMode {
 			pickOpts = withHomeAuthCount(opts, homeAuthCount)
 		}
-		auth, executor, provider, errPick := m.pickNextMixed(ctx, providers, routeModel, pickOpts, tried)
+		auth, executor, provider, releaseSlot, errPick := m.pickNextMixedWithinLimits(ctx, providers, routeModel, pickOpts, tried)
 		if errPick != nil {
 			if shouldReturnLastErrorOnPickFailure(homeMode, lastErr, errPick) {
 				return cliproxyexecutor.Response{}, lastErr
 			}
 			return cliproxyexecutor.Response{}, errPick
 		}
+		release = releaseSlot
 
 		entry := logEntryWithRequestID(ctx)
 		debugLogAuthSelection(entry, auth, provider, routeModel)
@@ -2868,6 +2984,8 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 			lastErr = errPrepare
 			continue
 		}
+		auth = m.applyHeaderTemplates(auth, opts)
+		auth = m.applyPinnedBaseURL(auth)
 		var authErr error
 		didRefreshOnUnauthorized := false
 		for _, upstreamModel := range models {
@@ -2883,7 +3001,7 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 			if errIntercept != nil {
 				return cliproxyexecutor.Response{}, errIntercept
 			}
-			resp, errExec := executor.CountTokens(execCtx, auth, execReq, execOpts)
+			resp, errExec := call(execCtx, executor, auth, execReq, execOpts)
 			if errExec != nil {
 				if errCtx := execCtx.Err(); errCtx != nil {
 					return cliproxyexecutor.Response{}, errCtx
@@ -2891,7 +3009,7 @@ func (m *Manager) executeCountMixedOnce(ctx context.Context, providers []string,
 				if refreshed, okRefresh := m.tryRefreshAfterUnauthorized(execCtx, auth, errExec, didRefreshOnUnauthorized); okRefresh {
 					auth = refreshed
 					didRefreshOnUnauthorized = true
-					resp, errExec = executor.CountTokens(execCtx, auth, execReq, execOpts)
+					resp, errExec = call(execCtx, executor, auth, execReq, execOpts)
 					if errExec != nil {
 						if errCtx := execCtx.Err(); errCtx != nil {
 							return cliproxyexecutor.Response{}, errCtx
@@ -2944,7 +3062,18 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 	tried := make(map[string]struct{})
 	attempted := make(map[string]struct{})
 	var lastErr error
+	// release frees the concurrency slot and executor lease of the credential being tried.
+	var release func()
+	defer func() {
+		if release != nil {
+			release()
+		}
+	}()
 	for {
+		if release != nil {
+			release()
+			release = nil
+		}
 		if !homeMode && maxRetryCredentials > 0 && len(attempted) >= maxRetryCredentials {
 			if lastErr != nil {
 				return nil, lastErr
@@ -2955,13 +3084,14 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 		if homeMode {
 			pickOpts = withHomeAuthCount(opts, homeAuthCount)
 		}
-		auth, executor, provider, errPick := m.pickNextMixed(ctx, providers, routeModel, pickOpts, tried)
+		auth, executor, provider, releaseSlot, errPick := m.pickNextMixedWithinLimits(ctx, providers, routeModel, pickOpts, tried)
 		if errPick != nil {
 			if shouldReturnLastErrorOnPickFailure(homeMode, lastErr, errPick) {
 				return nil, lastErr
 			}
 			return nil, errPick
 		}
+		release = releaseSlot
 
 		entry := logEntryWithRequestID(ctx)
 		debugLogAuthSelection(entry, auth, provider, routeModel)
@@ -2989,6 +3119,8 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 			lastErr = errPrepare
 			continue
 		}
+		auth = m.applyHeaderTemplates(auth, opts)
+		auth = m.applyPinnedBaseURL(auth)
 		execReq := sanitizeDownstreamWebsocketFallbackRequest(execCtx, auth, req)
 		streamExecutionModel := ""
 		if restoreExecutionModel {
@@ -3011,6 +3143,9 @@ func (m *Manager) executeStreamMixedOnce(ctx context.Context, providers []string
 			}
 			continue
 		}
+		m.recordSessionProvider(ctx, routeModel, opts, provider)
+		streamResult = releaseOnStreamEnd(streamResult, release)
+		release = nil
 		return streamResult, nil
 	}
 }
@@ -3201,6 +3336,9 @@ func contextWithRequestedModelAlias(ctx context.Context, opts cliproxyexecutor.O
 	if serviceTier != "" {
 		ctx = coreusage.WithServiceTier(ctx, serviceTier)
 	}
+	if tags, ok := opts.Metadata[cliproxyexecutor.RequestTagsMetadataKey].(map[string]string); ok {
+		ctx = coreusage.WithRequestTags(ctx, tags)
+	}
 	return ctx
 }
 
@@ -3862,6 +4000,9 @@ func (m *Manager) MarkResult(ctx context.Context, result Result) {
 			cooldownRecordsBefore = m.cooldownStateRecordsForAuthLocked(auth, now)
 		}
 		auth.recordRecentRequest(now, result.Success)
+		m.recordRecentError(result, auth, now)
+		m.health.record(result, now)
+		m.noteRegionResult(auth, result, now)
 		if result.Success {
 			auth.Success++
 		} else {
@@ -4883,6 +5024,9 @@ func (m *Manager) pickNextLegacy(ctx context.Context, provider, model string, op
 		return nil, nil, errPick
 	}
 	if !handled {
+		selected = m.pickViaSelectionPolicy(ctx, []string{provider}, model, opts, available)
+	}
+	if !handled && selected == nil {
 		selected, errPick = selector.Pick(ctx, provider, selectionArgForSelector(selector, model), opts, available)
 		if errPick != nil {
 			return nil, nil, errPick
@@ -4909,7 +5053,7 @@ func (m *Manager) pickNext(ctx context.Context, provider, model string, opts cli
 		return auth, exec, err
 	}
 
-	if m.hasPluginScheduler() || !m.useSchedulerFastPath() {
+	if m.hasPluginScheduler() || !m.useSchedulerFastPath() || m.hasSelectionPolicy(provider) {
 		return m.pickNextLegacy(ctx, provider, model, opts, tried)
 	}
 	if strings.TrimSpace(model) != "" {
@@ -5043,6 +5187,9 @@ func (m *Manager) pickNextMixedLegacy(ctx context.Context, providers []string, m
 		return nil, nil, "", errPick
 	}
 	if !handled {
+		selected = m.pickViaSelectionPolicy(ctx, providers, model, opts, available)
+	}
+	if !handled && selected == nil {
 		selected, errPick = selector.Pick(ctx, "mixed", selectionArgForSelector(selector, model), opts, available)
 		if errPick != nil {
 			return nil, nil, "", errPick
@@ -5073,7 +5220,13 @@ func (m *Manager) pickNextMixed(ctx context.Context, providers []string, model s
 		return m.pickNextViaHome(ctx, model, opts, tried)
 	}
 
-	if m.hasPluginScheduler() || !m.useSchedulerFastPath() {
+	if preferred := m.preferredSessionProvider(providers, model, opts); preferred != "" {
+		if auth, executor, providerKey, errPick := m.pickNextMixed(ctx, []string{preferred}, model, opts, tried); errPick == nil {
+			return auth, executor, providerKey, nil
+		}
+	}
+
+	if m.hasPluginScheduler() || !m.useSchedulerFastPath() || m.hasSelectionPolicy(providers...) {
 		return m.pickNextMixedLegacy(ctx, providers, model, opts, tried)
 	}
 
@@ -102,3 +102,33 @@ func TestManagerExecutorReturnsRegisteredExecutor(t *testing.T) {
 		t.Fatal("expected unknown provider lookup to fail")
 	}
 }
+
+func TestManagerRegisterExecutorDefersCloseUntilInFlightRequestsFinish(t *testing.T) {
+	t.Parallel()
+
+	manager := NewManager(nil, &FillFirstSelector{}, nil)
+	replaced := &replaceAwareExecutor{id: "codex"}
+	current := &replaceAwareExecutor{id: "codex"}
+	manager.RegisterExecutor(replaced)
+	if _, errRegister := manager.Register(context.Background(), &Auth{ID: "codex-a", Provider: "codex"}); errRegister != nil {
+		t.Fatalf("Register() error = %v", errRegister)
+	}
+
+	_, picked, _, release, errPick := manager.pickNextMixedWithinLimits(context.Background(), []string{"codex"}, "", cliproxyexecutor.Options{}, nil)
+	if errPick != nil || picked != replaced || release == nil {
+		t.Fatalf("pick = %v, %v; want the first executor with a release", picked, errPick)
+	}
+	manager.RegisterExecutor(current)
+	if closed := replaced.ClosedSessionIDs(); len(closed) != 0 {
+		t.Fatalf("replaced executor closed %v while a request was in flight", closed)
+	}
+
+	release()
+	release()
+	if closed := replaced.ClosedSessionIDs(); len(closed) != 1 || closed[0] != CloseAllExecutionSessionsID {
+		t.Fatalf("replaced executor close calls = %v, want one %q", closed, CloseAllExecutionSessionsID)
+	}
+	if len(current.ClosedSessionIDs()) != 0 {
+		t.Fatalf("expected current executor to stay open")
+	}
+}
@@ -0,0 +1,167 @@
+package auth
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+// costTierKey is the attribute or metadata key naming the billing tier of a credential.
+const costTierKey = "tier"
+
+// costTier orders credentials by what a request costs on them, cheapest first.
+type costTier int
+
+const (
+	costTierFree costTier = iota
+	costTierSubscription
+	costTierPaid
+)
+
+func (t costTier) String() string {
+	switch t {
+	case costTierFree:
+		return "free"
+	case costTierPaid:
+		return "paid"
+	default:
+		return "subscription"
+	}
+}
+
+// parseCostTier maps a configured tier name to its costTier.
+func parseCostTier(raw string) (costTier, bool) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "free":
+		return costTierFree, true
+	case "subscription", "sub":
+		return costTierSubscription, true
+	case "paid", "api", "paid-api":
+		return costTierPaid, true
+	}
+	return costTierSubscription, false
+}
+
+// authCostTier returns the billing tier of auth. An explicit tier attribute or metadata value
+// wins; otherwise free Codex plans are free, API keys are paid and OAuth logins are
+// subscriptions.
+func authCostTier(auth *Auth) costTier {
+	if auth == nil {
+		return costTierSubscription
+	}
+	if tier, ok := parseCostTier(auth.Attributes[costTierKey]); ok {
+		return tier
+	}
+	if raw, ok := auth.Metadata[costTierKey].(string); ok {
+		if tier, okTier := parseCostTier(raw); okTier {
+			return tier
+		}
+	}
+	if isFreeCodexAuth(auth) {
+		return costTierFree
+	}
+	if strings.TrimSpace(auth.Attributes["api_key"]) != "" {
+		return costTierPaid
+	}
+	return costTierSubscription
+}
+
+// preferCheaperTiers reports whether routing.prefer-cheaper-tiers is enabled.
+func (m *Manager) preferCheaperTiers() bool {
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	return cfg != nil && cfg.Routing.PreferCheaperTiers
+}
+
+// costlierTierAuths returns, per provider in pool, the credentials of tiers costlier than the
+// cheapest tier with an available credential, so cheaper tiers are exhausted first. Credentials
+// in skip are ignored.
+func costlierTierAuths(pool []routingPoolEntry, skip map[string]struct{}) map[string]struct{} {
+	cheapest := make(map[string]costTier)
+	for _, entry := range pool {
+		if _, skipped := skip[entry.id]; skipped || !entry.available {
+			continue
+		}
+		if tier, ok := cheapest[entry.provider]; !ok || entry.tier < tier {
+			cheapest[entry.provider] = entry.tier
+		}
+	}
+	excluded := make(map[string]struct{})
+	for _, entry := range pool {
+		if tier, ok := cheapest[entry.provider]; ok && entry.tier > tier {
+			excluded[entry.id] = struct{}{}
+		}
+	}
+	return excluded
+}
+
+// TierUsage summarizes the credentials of one billing tier of a provider.
+type TierUsage struct {
+	Provider string `json:"provider"`
+	Tier     string `json:"tier"`
+	Auths    int    `json:"auths"`
+	// Available counts the credentials that are neither disabled nor cooling down.
+	Available int `json:"available"`
+	// QuotaExceeded counts the credentials whose last error was a quota error.
+	QuotaExceeded int `json:"quota_exceeded"`
+	// QuotaRecoverAt is the earliest time a quota-exhausted credential of the tier recovers.
+	QuotaRecoverAt *time.Time `json:"quota_recover_at,omitempty"`
+	// Requests and Failures cover the last hour.
+	Requests int64 `json:"requests"`
+	Failures int64 `json:"failures"`
+}
+
+// TierUsage returns per provider and billing tier credential counts, quota state and recent
+// traffic, optionally filtered by provider, ordered by provider and tier.
+func (m *Manager) TierUsage(provider string) []TierUsage {
+	if m == nil {
+		return nil
+	}
+	provider = strings.TrimSpace(provider)
+	now := time.Now()
+	type tierKey struct {
+		provider string
+		tier     costTier
+	}
+	usage := make(map[tierKey]*TierUsage)
+	var keys []tierKey
+	for _, auth := range m.List() {
+		if provider != "" && !strings.EqualFold(auth.Provider, provider) {
+			continue
+		}
+		key := tierKey{provider: auth.Provider, tier: authCostTier(auth)}
+		entry := usage[key]
+		if entry == nil {
+			entry = &TierUsage{Provider: key.provider, Tier: key.tier.String()}
+			usage[key] = entry
+			keys = append(keys, key)
+		}
+		entry.Auths++
+		if blocked, _, _ := isAuthBlockedForModel(auth, "", now); !blocked {
+			entry.Available++
+		}
+		if auth.Quota.Exceeded {
+			entry.QuotaExceeded++
+			if recoverAt := auth.Quota.NextRecoverAt; recoverAt.After(now) && (entry.QuotaRecoverAt == nil || recoverAt.Before(*entry.QuotaRecoverAt)) {
+				entry.QuotaRecoverAt = timePtrIfSet(recoverAt)
+			}
+		}
+		buckets := auth.RecentRequestsSnapshot(now)
+		for _, bucket := range buckets[max(len(buckets)-healthErrorRateBuckets, 0):] {
+			entry.Requests += bucket.Success + bucket.Failed
+			entry.Failures += bucket.Failed
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].provider != keys[j].provider {
+			return keys[i].provider < keys[j].provider
+		}
+		return keys[i].tier < keys[j].tier
+	})
+	out := make([]TierUsage, 0, len(keys))
+	for _, key := range keys {
+		out = append(out, *usage[key])
+	}
+	return out
+}
@@ -0,0 +1,49 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+func TestPickNextMixedWithinLimits_PrefersCheaperTiers(t *testing.T) {
+	manager := NewManager(nil, &FillFirstSelector{}, nil)
+	manager.SetConfig(&internalconfig.Config{Routing: internalconfig.RoutingConfig{PreferCheaperTiers: true}})
+	manager.executors["codex"] = schedulerTestExecutor{}
+	for _, auth := range []*Auth{
+		// Costlier tiers sort first, so fill-first would pick them whenever they are in the pool.
+		{ID: "codex-0-key", Provider: "codex", Attributes: map[string]string{"api_key": "sk-test"}},
+		{ID: "codex-1-plus", Provider: "codex", Attributes: map[string]string{"plan_type": "plus"}},
+		{ID: "codex-2-free", Provider: "codex", Attributes: map[string]string{"plan_type": "free"}},
+		{ID: "codex-3-free", Provider: "codex", Metadata: map[string]any{costTierKey: "free"}},
+	} {
+		if _, errRegister := manager.Register(context.Background(), auth); errRegister != nil {
+			t.Fatalf("Register(%s) error = %v", auth.ID, errRegister)
+		}
+	}
+	pick := func(tried map[string]struct{}) string {
+		t.Helper()
+		auth, _, _, _, errPick := manager.pickNextMixedWithinLimits(context.Background(), []string{"codex"}, "", cliproxyexecutor.Options{}, tried)
+		if errPick != nil {
+			t.Fatalf("pick error = %v", errPick)
+		}
+		return auth.ID
+	}
+
+	if got := pick(nil); got != "codex-2-free" {
+		t.Fatalf("pick = %s, want the free tier", got)
+	}
+	if got := pick(map[string]struct{}{"codex-2-free": {}, "codex-3-free": {}}); got != "codex-1-plus" {
+		t.Fatalf("pick with the free tier exhausted = %s, want the subscription tier", got)
+	}
+	if got := pick(map[string]struct{}{"codex-1-plus": {}, "codex-2-free": {}, "codex-3-free": {}}); got != "codex-0-key" {
+		t.Fatalf("pick with cheaper tiers exhausted = %s, want the paid tier", got)
+	}
+
+	usage := manager.TierUsage("codex")
+	if len(usage) != 3 || usage[0].Tier != "free" || usage[0].Auths != 2 || usage[1].Tier != "subscription" || usage[2].Tier != "paid" {
+		t.Fatalf("tier usage = %+v", usage)
+	}
+}
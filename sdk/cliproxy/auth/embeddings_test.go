@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+type embeddingsTestExecutor struct {
+	schedulerProviderTestExecutor
+}
+
+func (e embeddingsTestExecutor) ExecuteEmbeddings(ctx context.Context, auth *Auth, req cliproxyexecutor.Request, opts cliproxyexecutor.Options) (cliproxyexecutor.Response, error) {
+	return cliproxyexecutor.Response{Payload: []byte(`{"object":"list","auth":"` + auth.ID + `"}`)}, nil
+}
+
+func TestManager_ExecuteEmbeddingsRoutesToEmbeddingProviders(t *testing.T) {
+	manager := NewManager(nil, &RoundRobinSelector{}, nil)
+	manager.RegisterExecutor(schedulerProviderTestExecutor{provider: "claude"})
+	manager.RegisterExecutor(embeddingsTestExecutor{schedulerProviderTestExecutor{provider: "gemini"}})
+	for _, auth := range []*Auth{{ID: "claude-a", Provider: "claude"}, {ID: "gemini-a", Provider: "gemini"}} {
+		if _, errRegister := manager.Register(context.Background(), auth); errRegister != nil {
+			t.Fatalf("Register(%s) error = %v", auth.ID, errRegister)
+		}
+	}
+	ctx := context.Background()
+
+	resp, errExec := manager.ExecuteEmbeddings(ctx, []string{"claude", "gemini"}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{})
+	if errExec != nil {
+		t.Fatalf("ExecuteEmbeddings() error = %v", errExec)
+	}
+	if got := string(resp.Payload); got != `{"object":"list","auth":"gemini-a"}` {
+		t.Fatalf("ExecuteEmbeddings() payload = %s, want the gemini credential", got)
+	}
+
+	_, errExec = manager.ExecuteEmbeddings(ctx, []string{"claude"}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{})
+	var authErr *Error
+	if !errors.As(errExec, &authErr) || authErr.Code != "embeddings_not_supported" || authErr.HTTPStatus != http.StatusBadRequest {
+		t.Fatalf("ExecuteEmbeddings() without an embeddings provider error = %v, want embeddings_not_supported 400", errExec)
+	}
+}
+
+func TestManager_ExecuteEmbeddingsRefusesReadOnlyProviders(t *testing.T) {
+	manager := NewManager(nil, &RoundRobinSelector{}, nil)
+	manager.SetConfig(&internalconfig.Config{ReadOnlyProviders: []string{"gemini"}})
+	manager.RegisterExecutor(embeddingsTestExecutor{schedulerProviderTestExecutor{provider: "gemini"}})
+	manager.RegisterExecutor(embeddingsTestExecutor{schedulerProviderTestExecutor{provider: "openai-compatibility"}})
+	for _, auth := range []*Auth{{ID: "gemini-a", Provider: "gemini"}, {ID: "compat-a", Provider: "openai-compatibility"}} {
+		if _, errRegister := manager.Register(context.Background(), auth); errRegister != nil {
+			t.Fatalf("Register(%s) error = %v", auth.ID, errRegister)
+		}
+	}
+	ctx := context.Background()
+
+	_, errExec := manager.ExecuteEmbeddings(ctx, []string{"gemini"}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{})
+	var authErr *Error
+	if !errors.As(errExec, &authErr) || authErr.Code != "provider_read_only" || authErr.HTTPStatus != http.StatusForbidden {
+		t.Fatalf("ExecuteEmbeddings() on a read-only provider error = %v, want provider_read_only 403", errExec)
+	}
+
+	resp, errExec := manager.ExecuteEmbeddings(ctx, []string{"gemini", "openai-compatibility"}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{})
+	if errExec != nil {
+		t.Fatalf("ExecuteEmbeddings() with another provider available error = %v", errExec)
+	}
+	if got := string(resp.Payload); got != `{"object":"list","auth":"compat-a"}` {
+		t.Fatalf("ExecuteEmbeddings() payload = %s, want the non read-only credential", got)
+	}
+}
@@ -0,0 +1,88 @@
+package auth
+
+import (
+	"reflect"
+	"sync"
+)
+
+// executorLeases counts in-flight requests per executor instance so a replaced executor keeps
+// its execution sessions until the requests it already serves have finished. The zero value is
+// ready to use.
+type executorLeases struct {
+	mu       sync.Mutex
+	inFlight map[ProviderExecutor]int
+	// retired holds replaced executors whose sessions close when their last lease is released.
+	retired map[ProviderExecutor]struct{}
+}
+
+// leasable reports whether exec can key the lease maps; executors with non-comparable dynamic
+// types are never leased and are closed as soon as they are replaced.
+func leasable(exec ProviderExecutor) bool {
+	return exec != nil && reflect.TypeOf(exec).Comparable()
+}
+
+// acquire records one in-flight request on exec and returns the func that ends it. It returns
+// nil when exec cannot be leased.
+func (l *executorLeases) acquire(exec ProviderExecutor) func() {
+	if !leasable(exec) {
+		return nil
+	}
+	l.mu.Lock()
+	if l.inFlight == nil {
+		l.inFlight = make(map[ProviderExecutor]int)
+	}
+	l.inFlight[exec]++
+	l.mu.Unlock()
+	var once sync.Once
+	return func() { once.Do(func() { l.release(exec) }) }
+}
+
+func (l *executorLeases) release(exec ProviderExecutor) {
+	l.mu.Lock()
+	if l.inFlight[exec] > 1 {
+		l.inFlight[exec]--
+		l.mu.Unlock()
+		return
+	}
+	delete(l.inFlight, exec)
+	_, retired := l.retired[exec]
+	delete(l.retired, exec)
+	l.mu.Unlock()
+	if retired {
+		closeExecutorSessions(exec)
+	}
+}
+
+// retire closes the sessions of a replaced executor, deferring the close until its in-flight
+// requests finish.
+func (l *executorLeases) retire(exec ProviderExecutor) {
+	if leasable(exec) {
+		l.mu.Lock()
+		if l.inFlight[exec] > 0 {
+			if l.retired == nil {
+				l.retired = make(map[ProviderExecutor]struct{})
+			}
+			l.retired[exec] = struct{}{}
+			l.mu.Unlock()
+			return
+		}
+		l.mu.Unlock()
+	}
+	closeExecutorSessions(exec)
+}
+
+// reinstate cancels a pending retirement of exec after it is registered again.
+func (l *executorLeases) reinstate(exec ProviderExecutor) {
+	if !leasable(exec) {
+		return
+	}
+	l.mu.Lock()
+	delete(l.retired, exec)
+	l.mu.Unlock()
+}
+
+func closeExecutorSessions(exec ProviderExecutor) {
+	if closer, ok := exec.(ExecutionSessionCloser); ok && closer != nil {
+		closer.CloseExecutionSession(CloseAllExecutionSessionsID)
+	}
+}
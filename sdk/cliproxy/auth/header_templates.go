@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/buildinfo"
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+// headerTemplateWildcard applies a header template to every provider.
+const headerTemplateWildcard = "*"
+
+// maxHeaderSessionIDs bounds the session UUID table; it is reset when full.
+const maxHeaderSessionIDs = 10000
+
+// headerSessionIDs hands out one random UUID per session key.
+type headerSessionIDs struct {
+	mu  sync.Mutex
+	ids map[string]string
+}
+
+func (h *headerSessionIDs) get(key string) string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if id, ok := h.ids[key]; ok {
+		return id
+	}
+	if h.ids == nil || len(h.ids) >= maxHeaderSessionIDs {
+		h.ids = make(map[string]string)
+	}
+	id := uuid.NewString()
+	h.ids[key] = id
+	return id
+}
+
+// applyHeaderTemplates returns auth with the configured header templates for its provider rendered
+// into "header:" attributes, so every executor applies them through ApplyCustomHeadersFromAttrs.
+// Headers the auth already defines are left untouched. auth is returned as-is when nothing applies.
+func (m *Manager) applyHeaderTemplates(auth *Auth, opts cliproxyexecutor.Options) *Auth {
+	if m == nil || auth == nil {
+		return auth
+	}
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || len(cfg.HeaderTemplates) == 0 {
+		return auth
+	}
+	provider := strings.ToLower(strings.TrimSpace(auth.Provider))
+	templates := mergedHeaderTemplates(cfg.HeaderTemplates, provider)
+	if len(templates) == 0 {
+		return auth
+	}
+
+	existing := make(map[string]bool, len(auth.Attributes))
+	for key := range auth.Attributes {
+		if name, ok := strings.CutPrefix(key, "header:"); ok {
+			existing[http.CanonicalHeaderKey(strings.TrimSpace(name))] = true
+		}
+	}
+	var replacer *strings.Replacer
+	var out *Auth
+	for name, value := range templates {
+		if existing[name] {
+			continue
+		}
+		if replacer == nil {
+			replacer = m.headerTemplateReplacer(auth, provider, opts)
+			out = auth.Clone()
+			if out.Attributes == nil {
+				out.Attributes = make(map[string]string, len(templates))
+			}
+		}
+		out.Attributes["header:"+name] = replacer.Replace(value)
+	}
+	if out == nil {
+		return auth
+	}
+	return out
+}
+
+// mergedHeaderTemplates combines wildcard and provider templates; provider entries win.
+func mergedHeaderTemplates(all map[string]map[string]string, provider string) map[string]string {
+	merged := make(map[string]string)
+	for key, headers := range all {
+		if strings.TrimSpace(key) != headerTemplateWildcard {
+			continue
+		}
+		addHeaderTemplates(merged, headers)
+	}
+	for key, headers := range all {
+		if provider == "" || strings.ToLower(strings.TrimSpace(key)) != provider {
+			continue
+		}
+		addHeaderTemplates(merged, headers)
+	}
+	return merged
+}
+
+func addHeaderTemplates(dst, headers map[string]string) {
+	for name, value := range headers {
+		name = strings.TrimSpace(name)
+		if name == "" || strings.TrimSpace(value) == "" {
+			continue
+		}
+		dst[http.CanonicalHeaderKey(name)] = value
+	}
+}
+
+func (m *Manager) headerTemplateReplacer(auth *Auth, provider string, opts cliproxyexecutor.Options) *strings.Replacer {
+	sessionKey := "auth:" + auth.ID
+	if raw, ok := opts.Metadata[cliproxyexecutor.ExecutionSessionMetadataKey].(string); ok && strings.TrimSpace(raw) != "" {
+		sessionKey = "session:" + strings.TrimSpace(raw) + "|" + auth.ID
+	}
+	label := strings.TrimSpace(auth.Label)
+	if label == "" {
+		label = auth.ID
+	}
+	return strings.NewReplacer(
+		"{auth_id}", auth.ID,
+		"{auth_label}", label,
+		"{provider}", provider,
+		"{version}", buildinfo.Version,
+		"{session_uuid}", m.headerSessions.get(sessionKey),
+		"{uuid}", uuid.NewString(),
+	)
+}
@@ -0,0 +1,56 @@
+package auth
+
+import (
+	"testing"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+func TestApplyHeaderTemplates(t *testing.T) {
+	manager := NewManager(nil, nil, nil)
+	manager.SetConfig(&internalconfig.Config{HeaderTemplates: map[string]map[string]string{
+		"*":       {"x-client": "{auth_label}", "user-agent": "generic"},
+		"Copilot": {"User-Agent": "proxy/{provider}", "X-Session": "{session_uuid}", "X-Fixed": "keep"},
+	}})
+	auth := &Auth{ID: "copilot-1", Provider: "copilot", Label: "work", Attributes: map[string]string{"header:x-fixed": "from-auth"}}
+
+	out := manager.applyHeaderTemplates(auth, cliproxyexecutor.Options{})
+	if out == auth {
+		t.Fatal("expected a clone with rendered headers")
+	}
+	if got := out.Attributes["header:User-Agent"]; got != "proxy/copilot" {
+		t.Fatalf("User-Agent = %q, want provider template to win over wildcard", got)
+	}
+	if got := out.Attributes["header:X-Client"]; got != "work" {
+		t.Fatalf("X-Client = %q, want auth label", got)
+	}
+	if _, ok := out.Attributes["header:X-Fixed"]; ok {
+		t.Fatal("auth header attribute should take precedence over template")
+	}
+	if _, ok := auth.Attributes["header:User-Agent"]; ok {
+		t.Fatal("original auth must not be modified")
+	}
+
+	again := manager.applyHeaderTemplates(auth, cliproxyexecutor.Options{})
+	if again.Attributes["header:X-Session"] != out.Attributes["header:X-Session"] {
+		t.Fatal("session uuid should be stable per auth without an execution session")
+	}
+	session := manager.applyHeaderTemplates(auth, cliproxyexecutor.Options{Metadata: map[string]any{
+		cliproxyexecutor.ExecutionSessionMetadataKey: "s-1",
+	}})
+	if session.Attributes["header:X-Session"] == out.Attributes["header:X-Session"] {
+		t.Fatal("execution sessions should get their own uuid")
+	}
+}
+
+func TestApplyHeaderTemplatesNoMatch(t *testing.T) {
+	manager := NewManager(nil, nil, nil)
+	manager.SetConfig(&internalconfig.Config{HeaderTemplates: map[string]map[string]string{
+		"claude": {"User-Agent": "x"},
+	}})
+	auth := &Auth{ID: "gemini-1", Provider: "gemini"}
+	if out := manager.applyHeaderTemplates(auth, cliproxyexecutor.Options{}); out != auth {
+		t.Fatal("expected auth unchanged when no template applies")
+	}
+}
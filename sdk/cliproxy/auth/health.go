@@ -0,0 +1,204 @@
+package auth
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// QuotaSnapshotMetadataKey is the metadata key under which executors store provider reported
+// quota details, e.g. the Copilot premium request counts.
+const QuotaSnapshotMetadataKey = "quota_snapshot"
+
+// healthErrorRateBuckets is how many recent request buckets make up the rolling error rate
+// window (one hour).
+const healthErrorRateBuckets = 6
+
+// AuthHealth summarizes the recent behaviour of one credential for diagnostics.
+type AuthHealth struct {
+	AuthID        string `json:"auth_id"`
+	AuthIndex     string `json:"auth_index,omitempty"`
+	Provider      string `json:"provider"`
+	Label         string `json:"label,omitempty"`
+	Tier          string `json:"tier"`
+	Status        Status `json:"status"`
+	StatusMessage string `json:"status_message,omitempty"`
+	Disabled      bool   `json:"disabled"`
+	Unavailable   bool   `json:"unavailable"`
+
+	LastSuccessAt *time.Time `json:"last_success_at,omitempty"`
+	LastFailureAt *time.Time `json:"last_failure_at,omitempty"`
+	LastError     *Error     `json:"last_error,omitempty"`
+
+	// Requests, Failures and ErrorRate cover the last hour.
+	Requests  int64   `json:"requests"`
+	Failures  int64   `json:"failures"`
+	ErrorRate float64 `json:"error_rate"`
+
+	// CooldownUntil is when the credential becomes selectable again; ModelCooldowns lists the
+	// models that are cooling down on their own.
+	CooldownUntil  *time.Time           `json:"cooldown_until,omitempty"`
+	ModelCooldowns map[string]time.Time `json:"model_cooldowns,omitempty"`
+	// RetryAfterUntil is when the latest provider supplied retry hint expires.
+	RetryAfterUntil *time.Time `json:"retry_after_until,omitempty"`
+
+	Quota         QuotaState `json:"quota"`
+	QuotaSnapshot any        `json:"quota_snapshot,omitempty"`
+
+	TokenExpiresAt *time.Time `json:"token_expires_at,omitempty"`
+	NextRefreshAt  *time.Time `json:"next_refresh_at,omitempty"`
+}
+
+// authHealthEntry holds the outcome timestamps the auth itself does not keep.
+type authHealthEntry struct {
+	lastSuccess     time.Time
+	lastFailure     time.Time
+	retryAfterUntil time.Time
+}
+
+// authHealthTracker records per-auth outcomes reported through MarkResult.
+type authHealthTracker struct {
+	mu      sync.Mutex
+	entries map[string]*authHealthEntry
+}
+
+func (t *authHealthTracker) record(result Result, now time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.entries == nil {
+		t.entries = make(map[string]*authHealthEntry)
+	}
+	entry := t.entries[result.AuthID]
+	if entry == nil {
+		entry = &authHealthEntry{}
+		t.entries[result.AuthID] = entry
+	}
+	if result.Success {
+		entry.lastSuccess = now
+		return
+	}
+	entry.lastFailure = now
+	if result.RetryAfter != nil && *result.RetryAfter > 0 {
+		entry.retryAfterUntil = now.Add(*result.RetryAfter)
+	}
+}
+
+func (t *authHealthTracker) get(authID string) authHealthEntry {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if entry := t.entries[authID]; entry != nil {
+		return *entry
+	}
+	return authHealthEntry{}
+}
+
+func (t *authHealthTracker) remove(authID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.entries, authID)
+}
+
+// AuthHealth returns the health of every registered auth, optionally filtered by provider,
+// ordered by provider and auth ID.
+func (m *Manager) AuthHealth(provider string) []AuthHealth {
+	if m == nil {
+		return nil
+	}
+	provider = strings.TrimSpace(provider)
+	now := time.Now()
+	out := make([]AuthHealth, 0)
+	for _, auth := range m.List() {
+		if provider != "" && !strings.EqualFold(auth.Provider, provider) {
+			continue
+		}
+		out = append(out, buildAuthHealth(auth, m.health.get(auth.ID), now))
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Provider != out[j].Provider {
+			return out[i].Provider < out[j].Provider
+		}
+		return out[i].AuthID < out[j].AuthID
+	})
+	return out
+}
+
+func buildAuthHealth(auth *Auth, entry authHealthEntry, now time.Time) AuthHealth {
+	health := AuthHealth{
+		AuthID:         auth.ID,
+		AuthIndex:      auth.Index,
+		Provider:       auth.Provider,
+		Label:          auth.Label,
+		Tier:           authCostTier(auth).String(),
+		Status:         auth.Status,
+		StatusMessage:  auth.StatusMessage,
+		Disabled:       auth.Disabled,
+		Unavailable:    auth.Unavailable,
+		LastSuccessAt:  timePtrIfSet(entry.lastSuccess),
+		LastFailureAt:  timePtrIfSet(entry.lastFailure),
+		LastError:      cloneError(auth.LastError),
+		Quota:          auth.Quota,
+		QuotaSnapshot:  auth.Metadata[QuotaSnapshotMetadataKey],
+		NextRefreshAt:  timePtrIfSet(auth.NextRefreshAfter),
+		ModelCooldowns: make(map[string]time.Time),
+	}
+	buckets := auth.RecentRequestsSnapshot(now)
+	for _, bucket := range buckets[max(len(buckets)-healthErrorRateBuckets, 0):] {
+		health.Requests += bucket.Success + bucket.Failed
+		health.Failures += bucket.Failed
+	}
+	if health.Requests > 0 {
+		health.ErrorRate = float64(health.Failures) / float64(health.Requests)
+	}
+	if entry.retryAfterUntil.After(now) {
+		health.RetryAfterUntil = timePtrIfSet(entry.retryAfterUntil)
+	}
+	cooldown := auth.NextRetryAfter
+	if auth.Quota.Exceeded && auth.Quota.NextRecoverAt.After(cooldown) {
+		cooldown = auth.Quota.NextRecoverAt
+	}
+	if cooldown.After(now) {
+		health.CooldownUntil = timePtrIfSet(cooldown)
+	}
+	for model, state := range auth.ModelStates {
+		if state != nil && state.Unavailable && state.NextRetryAfter.After(now) {
+			health.ModelCooldowns[model] = state.NextRetryAfter
+		}
+	}
+	if len(health.ModelCooldowns) == 0 {
+		health.ModelCooldowns = nil
+	}
+	if expiry, ok := auth.ExpirationTime(); ok {
+		health.TokenExpiresAt = timePtrIfSet(expiry)
+	}
+	return health
+}
+
+// QuotaCooldownActive reports whether any enabled credential, or one of its models, is cooling
+// down after a quota or rate-limit error, i.e. whether upstream capacity is currently reduced.
+func (m *Manager) QuotaCooldownActive() bool {
+	if m == nil {
+		return false
+	}
+	now := time.Now()
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, auth := range m.auths {
+		if auth == nil || auth.Disabled {
+			continue
+		}
+		if quotaCoolingDown(auth.Quota, now) {
+			return true
+		}
+		for _, state := range auth.ModelStates {
+			if state != nil && quotaCoolingDown(state.Quota, now) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func quotaCoolingDown(quota QuotaState, now time.Time) bool {
+	return quota.Exceeded && quota.NextRecoverAt.After(now)
+}
@@ -0,0 +1,80 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestManagerAuthHealth(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	ctx := WithSkipPersist(context.Background())
+	expiry := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+	for _, auth := range []*Auth{
+		{ID: "copilot-a", Provider: "copilot", Metadata: map[string]any{
+			"expired":                expiry.Format(time.RFC3339),
+			QuotaSnapshotMetadataKey: map[string]any{"premium_interactions": map[string]any{"remaining": 12}},
+		}},
+		{ID: "claude-a", Provider: "claude"},
+	} {
+		if _, err := m.Register(ctx, auth); err != nil {
+			t.Fatalf("Register(%s): %v", auth.ID, err)
+		}
+	}
+
+	retryAfter := 30 * time.Second
+	m.MarkResult(ctx, Result{AuthID: "copilot-a", Provider: "copilot", Success: true})
+	m.MarkResult(ctx, Result{AuthID: "copilot-a", Provider: "copilot", Success: true})
+	m.MarkResult(ctx, Result{AuthID: "copilot-a", Provider: "copilot", RetryAfter: &retryAfter, Error: &Error{HTTPStatus: http.StatusTooManyRequests, Message: "slow down"}})
+	m.MarkResult(ctx, Result{AuthID: "copilot-a", Provider: "copilot", Success: true})
+
+	health := m.AuthHealth("")
+	if len(health) != 2 || health[0].AuthID != "claude-a" || health[1].AuthID != "copilot-a" {
+		t.Fatalf("health order = %+v", health)
+	}
+	if idle := health[0]; idle.LastSuccessAt != nil || idle.Requests != 0 || idle.ErrorRate != 0 {
+		t.Fatalf("idle auth health = %+v", idle)
+	}
+	got := health[1]
+	if got.LastSuccessAt == nil || got.LastFailureAt == nil {
+		t.Fatalf("last outcomes not recorded: %+v", got)
+	}
+	if got.Requests != 4 || got.Failures != 1 || got.ErrorRate != 0.25 {
+		t.Fatalf("requests = %d, failures = %d, error rate = %v; want 4, 1, 0.25", got.Requests, got.Failures, got.ErrorRate)
+	}
+	if got.RetryAfterUntil == nil || time.Until(*got.RetryAfterUntil) <= 0 {
+		t.Fatalf("retry_after_until = %v, want a future time", got.RetryAfterUntil)
+	}
+	if got.TokenExpiresAt == nil || !got.TokenExpiresAt.Equal(expiry) {
+		t.Fatalf("token_expires_at = %v, want %v", got.TokenExpiresAt, expiry)
+	}
+	if got.QuotaSnapshot == nil {
+		t.Fatal("quota snapshot missing")
+	}
+
+	if filtered := m.AuthHealth("CLAUDE"); len(filtered) != 1 || filtered[0].AuthID != "claude-a" {
+		t.Fatalf("provider filter = %+v", filtered)
+	}
+	m.Remove(ctx, "copilot-a")
+	if entry := m.health.get("copilot-a"); !entry.lastSuccess.IsZero() {
+		t.Fatal("health entry kept after Remove")
+	}
+}
+
+func TestManagerQuotaCooldownActive(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	ctx := WithSkipPersist(context.Background())
+	if _, err := m.Register(ctx, &Auth{ID: "claude-a", Provider: "claude"}); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if m.QuotaCooldownActive() {
+		t.Fatal("cooldown reported before any quota error")
+	}
+
+	retryAfter := time.Minute
+	m.MarkResult(ctx, Result{AuthID: "claude-a", Provider: "claude", Model: "claude-sonnet-4-5", RetryAfter: &retryAfter, Error: &Error{HTTPStatus: http.StatusTooManyRequests, Message: "rate limited"}})
+	if !m.QuotaCooldownActive() {
+		t.Fatal("cooldown not reported after a 429")
+	}
+}
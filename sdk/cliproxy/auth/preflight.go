@@ -0,0 +1,166 @@
+package auth
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	log "github.com/sirupsen/logrus"
+)
+
+// Preflight result states.
+const (
+	PreflightOK          = "ok"
+	PreflightRefreshed   = "refreshed"
+	PreflightExpired     = "expired"
+	PreflightNoModels    = "no_models"
+	PreflightUnavailable = "unavailable"
+	PreflightDisabled    = "disabled"
+)
+
+// defaultPreflightConcurrency bounds parallel refreshes so large auth directories do not
+// hammer provider token endpoints at boot.
+const defaultPreflightConcurrency = 8
+
+// PreflightResult is the validation outcome for a single credential.
+type PreflightResult struct {
+	ID        string    `json:"id"`
+	Index     string    `json:"auth_index,omitempty"`
+	Provider  string    `json:"provider"`
+	Label     string    `json:"label,omitempty"`
+	FileName  string    `json:"file_name,omitempty"`
+	Status    string    `json:"status"`
+	Message   string    `json:"message,omitempty"`
+	ExpiresAt time.Time `json:"expires_at,omitzero"`
+	Models    int       `json:"models"`
+}
+
+// PreflightReport summarizes a validation pass over every credential.
+type PreflightReport struct {
+	StartedAt  time.Time         `json:"started_at"`
+	FinishedAt time.Time         `json:"finished_at"`
+	Total      int               `json:"total"`
+	Healthy    int               `json:"healthy"`
+	Problems   int               `json:"problems"`
+	Disabled   int               `json:"disabled"`
+	Results    []PreflightResult `json:"results"`
+}
+
+// LatestPreflight returns the most recent preflight report, or nil when none has run.
+func (m *Manager) LatestPreflight() *PreflightReport {
+	if m == nil {
+		return nil
+	}
+	return m.preflight.Load()
+}
+
+// Preflight validates every registered credential in parallel: expired tokens are refreshed
+// through their executor, and credentials without any registered models are flagged.
+// It performs no model requests, so it is cheap enough to run on every boot.
+func (m *Manager) Preflight(ctx context.Context) *PreflightReport {
+	if m == nil {
+		return nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	report := &PreflightReport{StartedAt: time.Now()}
+	auths := m.List()
+	sort.Slice(auths, func(i, j int) bool { return auths[i].ID < auths[j].ID })
+	report.Results = make([]PreflightResult, len(auths))
+
+	sem := make(chan struct{}, defaultPreflightConcurrency)
+	var wg sync.WaitGroup
+	for i, auth := range auths {
+		wg.Add(1)
+		go func(i int, auth *Auth) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			report.Results[i] = m.preflightAuth(ctx, auth)
+		}(i, auth)
+	}
+	wg.Wait()
+
+	report.FinishedAt = time.Now()
+	report.Total = len(report.Results)
+	for _, result := range report.Results {
+		switch result.Status {
+		case PreflightOK, PreflightRefreshed:
+			report.Healthy++
+		case PreflightDisabled:
+			report.Disabled++
+		default:
+			report.Problems++
+		}
+	}
+	m.preflight.Store(report)
+	return report
+}
+
+func (m *Manager) preflightAuth(ctx context.Context, auth *Auth) PreflightResult {
+	result := PreflightResult{
+		ID:       auth.ID,
+		Index:    auth.EnsureIndex(),
+		Provider: auth.Provider,
+		Label:    auth.Label,
+		FileName: auth.FileName,
+		Status:   PreflightOK,
+	}
+	if auth.Disabled || auth.Status == StatusDisabled {
+		result.Status = PreflightDisabled
+		return result
+	}
+
+	if expiresAt, ok := auth.ExpirationTime(); ok {
+		result.ExpiresAt = expiresAt
+		if !expiresAt.After(time.Now()) {
+			refreshed, errRefresh := m.refreshAuthForRequest(ctx, auth.ID, "")
+			if errRefresh != nil {
+				result.Status = PreflightExpired
+				result.Message = errRefresh.Error()
+				return result
+			}
+			result.Status = PreflightRefreshed
+			if next, okNext := refreshed.ExpirationTime(); okNext {
+				result.ExpiresAt = next
+			}
+		}
+	}
+
+	result.Models = len(registry.GetGlobalRegistry().GetModelsForClient(auth.ID))
+	if result.Models == 0 {
+		result.Status = PreflightNoModels
+		result.Message = "no models registered; the model list could not be fetched or every model is excluded"
+		return result
+	}
+
+	if auth.Unavailable || auth.Status == StatusError {
+		result.Status = PreflightUnavailable
+		result.Message = strings.TrimSpace(auth.StatusMessage)
+	}
+	return result
+}
+
+// LogSummary writes a one-line summary plus one warning per problem credential.
+func (r *PreflightReport) LogSummary() {
+	if r == nil {
+		return
+	}
+	log.Infof("auth preflight: %d credential(s) checked in %s: %d healthy, %d with problems, %d disabled",
+		r.Total, r.FinishedAt.Sub(r.StartedAt).Round(time.Millisecond), r.Healthy, r.Problems, r.Disabled)
+	for _, result := range r.Results {
+		switch result.Status {
+		case PreflightOK, PreflightRefreshed, PreflightDisabled:
+			continue
+		}
+		name := result.FileName
+		if name == "" {
+			name = result.Label
+		}
+		log.Warnf("auth preflight: %s (%s, auth_index=%s) %s: %s", name, result.Provider, result.Index, result.Status, result.Message)
+	}
+}
@@ -0,0 +1,59 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+)
+
+func TestManagerPreflightClassifiesAuths(t *testing.T) {
+	manager := NewManager(nil, nil, nil)
+	reg := registry.GetGlobalRegistry()
+	reg.RegisterClient("preflight-ok", "claude", []*registry.ModelInfo{{ID: "claude-sonnet-4"}})
+	reg.RegisterClient("preflight-expired", "claude", []*registry.ModelInfo{{ID: "claude-sonnet-4"}})
+	t.Cleanup(func() {
+		reg.UnregisterClient("preflight-ok")
+		reg.UnregisterClient("preflight-expired")
+	})
+
+	auths := []*Auth{
+		{ID: "preflight-ok", Provider: "claude"},
+		{ID: "preflight-no-models", Provider: "claude"},
+		{ID: "preflight-disabled", Provider: "claude", Disabled: true, Status: StatusDisabled},
+		{
+			ID:       "preflight-expired",
+			Provider: "claude",
+			Metadata: map[string]any{"expired": time.Now().Add(-time.Hour).Format(time.RFC3339)},
+		},
+	}
+	for _, auth := range auths {
+		if _, errRegister := manager.Register(context.Background(), auth); errRegister != nil {
+			t.Fatalf("register %s: %v", auth.ID, errRegister)
+		}
+	}
+
+	if manager.LatestPreflight() != nil {
+		t.Fatal("expected no report before the first preflight")
+	}
+	report := manager.Preflight(context.Background())
+	if report == nil || manager.LatestPreflight() != report {
+		t.Fatal("expected preflight report to be stored")
+	}
+
+	want := map[string]string{
+		"preflight-ok":        PreflightOK,
+		"preflight-no-models": PreflightNoModels,
+		"preflight-disabled":  PreflightDisabled,
+		"preflight-expired":   PreflightExpired,
+	}
+	for _, result := range report.Results {
+		if result.Status != want[result.ID] {
+			t.Fatalf("%s: status = %q, want %q (message=%q)", result.ID, result.Status, want[result.ID], result.Message)
+		}
+	}
+	if report.Total != 4 || report.Healthy != 1 || report.Problems != 2 || report.Disabled != 1 {
+		t.Fatalf("summary = total %d healthy %d problems %d disabled %d", report.Total, report.Healthy, report.Problems, report.Disabled)
+	}
+}
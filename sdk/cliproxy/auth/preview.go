@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/thinking"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+// PreviewAuth reports which auth would most likely serve model, trying providers in order.
+// It applies the same availability and priority rules as the built-in selectors but does not
+// advance round-robin cursors or session affinity, so the actual pick may rotate to a peer of
+// the returned auth with the same priority. Pinned auth, forced provider, free-auth and
+// data-residency metadata in opts are honored as in selection.
+func (m *Manager) PreviewAuth(providers []string, model string, opts cliproxyexecutor.Options) (*Auth, string, error) {
+	if m == nil {
+		return nil, "", &Error{Code: "auth_not_found", Message: "no auth manager"}
+	}
+	modelKey := strings.TrimSpace(model)
+	if parsed := thinking.ParseSuffix(modelKey); parsed.ModelName != "" {
+		modelKey = strings.TrimSpace(parsed.ModelName)
+	}
+	registryRef := registry.GetGlobalRegistry()
+	pinnedAuthID := pinnedAuthIDFromMetadata(opts.Metadata)
+	disallowFreeAuth := disallowFreeAuthFromMetadata(opts.Metadata)
+	requiredResidency := requiredResidencyFromMetadata(opts.Metadata)
+	forcedProvider, _ := opts.Metadata["forced_provider"].(bool)
+	now := time.Now()
+
+	var lastErr error
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, provider := range providers {
+		provider = strings.ToLower(strings.TrimSpace(provider))
+		if provider == "" {
+			continue
+		}
+		candidates := make([]*Auth, 0)
+		for _, candidate := range m.auths {
+			if candidate == nil || executorKeyFromAuth(candidate) != provider || candidate.Disabled {
+				continue
+			}
+			if pinnedAuthID != "" && candidate.ID != pinnedAuthID {
+				continue
+			}
+			if disallowFreeAuth && isFreeCodexAuth(candidate) {
+				continue
+			}
+			if !residencySatisfies(authResidency(candidate), requiredResidency) {
+				continue
+			}
+			if !forcedProvider && modelKey != "" && !m.authSupportsRouteModel(registryRef, candidate, model) {
+				continue
+			}
+			candidates = append(candidates, candidate)
+		}
+		available, errAvailable := getAvailableAuths(candidates, provider, modelKey, now)
+		if errAvailable != nil {
+			lastErr = errAvailable
+			continue
+		}
+		return available[0].Clone(), provider, nil
+	}
+	if lastErr == nil {
+		lastErr = &Error{Code: "auth_not_found", Message: "no auth candidates"}
+	}
+	return nil, "", lastErr
+}
@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+func TestManager_PreviewAuthSkipsUnavailableProvidersAndPrefersPriority(t *testing.T) {
+	manager := NewManager(nil, nil, nil)
+	ctx := context.Background()
+
+	for _, auth := range []*Auth{
+		{ID: "gemini-disabled", Provider: "gemini", Disabled: true},
+		{ID: "claude-low", Provider: "claude", Status: StatusActive},
+		{ID: "claude-high", Provider: "claude", Status: StatusActive, Attributes: map[string]string{"priority": "5"}},
+	} {
+		if _, err := manager.Register(ctx, auth); err != nil {
+			t.Fatalf("register %s: %v", auth.ID, err)
+		}
+	}
+
+	auth, provider, err := manager.PreviewAuth([]string{"gemini", "claude"}, "", cliproxyexecutor.Options{})
+	if err != nil {
+		t.Fatalf("PreviewAuth() error = %v", err)
+	}
+	if provider != "claude" || auth == nil || auth.ID != "claude-high" {
+		t.Fatalf("PreviewAuth() = %v, %q; want claude-high, claude", auth, provider)
+	}
+
+	// Previewing must not rotate the selection.
+	again, _, _ := manager.PreviewAuth([]string{"claude"}, "", cliproxyexecutor.Options{})
+	if again == nil || again.ID != "claude-high" {
+		t.Fatalf("second PreviewAuth() = %v, want claude-high", again)
+	}
+
+	pinned, _, _ := manager.PreviewAuth([]string{"claude"}, "", cliproxyexecutor.Options{Metadata: map[string]any{cliproxyexecutor.PinnedAuthMetadataKey: "claude-low"}})
+	if pinned == nil || pinned.ID != "claude-low" {
+		t.Fatalf("pinned PreviewAuth() = %v, want claude-low", pinned)
+	}
+
+	if _, _, err := manager.PreviewAuth([]string{"gemini"}, "", cliproxyexecutor.Options{}); err == nil {
+		t.Fatal("expected error when no auth is available")
+	}
+}
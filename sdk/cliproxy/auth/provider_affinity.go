@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+// maxSessionProviders bounds the session provider table. When it is full, expired entries are
+// dropped first, then the entries closest to expiry.
+const maxSessionProviders = 10000
+
+// sessionProviders records which provider last served each session.
+type sessionProviders struct {
+	mu      sync.Mutex
+	entries map[string]sessionProviderEntry
+}
+
+type sessionProviderEntry struct {
+	provider  string
+	expiresAt time.Time
+}
+
+func (s *sessionProviders) get(key string, now time.Time) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[key]
+	if !ok {
+		return ""
+	}
+	if now.After(entry.expiresAt) {
+		delete(s.entries, key)
+		return ""
+	}
+	return entry.provider
+}
+
+func (s *sessionProviders) set(key, provider string, expiresAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.entries == nil {
+		s.entries = make(map[string]sessionProviderEntry)
+	}
+	if _, exists := s.entries[key]; !exists && len(s.entries) >= maxSessionProviders {
+		now := time.Now()
+		for entryKey, entry := range s.entries {
+			if now.After(entry.expiresAt) {
+				delete(s.entries, entryKey)
+			}
+		}
+		for len(s.entries) >= maxSessionProviders {
+			oldestKey := ""
+			var oldest time.Time
+			for entryKey, entry := range s.entries {
+				if oldestKey == "" || entry.expiresAt.Before(oldest) {
+					oldestKey, oldest = entryKey, entry.expiresAt
+				}
+			}
+			delete(s.entries, oldestKey)
+		}
+	}
+	s.entries[key] = sessionProviderEntry{provider: provider, expiresAt: expiresAt}
+}
+
+// providerAffinityKeys returns the session keys of a request for provider affinity, or empty keys
+// when provider affinity is disabled or the request carries no session. The fallback key lets a
+// second turn find the provider recorded for the first one, as in SessionAffinitySelector.
+func (m *Manager) providerAffinityKeys(model string, opts cliproxyexecutor.Options) (primary, fallback string, ttl time.Duration) {
+	if m == nil {
+		return "", "", 0
+	}
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || !cfg.Routing.ProviderAffinity {
+		return "", "", 0
+	}
+	ttl = time.Hour
+	if raw := strings.TrimSpace(cfg.Routing.SessionAffinityTTL); raw != "" {
+		if parsed, errParse := time.ParseDuration(raw); errParse == nil && parsed > 0 {
+			ttl = parsed
+		}
+	}
+	var primaryID, fallbackID string
+	if raw, ok := opts.Metadata[cliproxyexecutor.ExecutionSessionMetadataKey].(string); ok && strings.TrimSpace(raw) != "" {
+		primaryID = "execution:" + strings.TrimSpace(raw)
+	} else {
+		primaryID, fallbackID = extractSessionIDs(opts.Headers, opts.OriginalRequest, opts.Metadata)
+	}
+	if primaryID == "" {
+		return "", "", 0
+	}
+	primary = primaryID + "::" + model
+	if fallbackID != "" && fallbackID != primaryID {
+		fallback = fallbackID + "::" + model
+	}
+	return primary, fallback, ttl
+}
+
+// preferredSessionProvider returns the provider that last served the request's session when it is
+// one of several candidate providers.
+func (m *Manager) preferredSessionProvider(providers []string, model string, opts cliproxyexecutor.Options) string {
+	if len(providers) < 2 {
+		return ""
+	}
+	primary, fallback, _ := m.providerAffinityKeys(model, opts)
+	if primary == "" {
+		return ""
+	}
+	now := time.Now()
+	provider := m.sessionProviders.get(primary, now)
+	if provider == "" && fallback != "" {
+		provider = m.sessionProviders.get(fallback, now)
+	}
+	if provider == "" {
+		return ""
+	}
+	for _, candidate := range providers {
+		if strings.EqualFold(strings.TrimSpace(candidate), provider) {
+			return provider
+		}
+	}
+	return ""
+}
+
+// recordSessionProvider remembers that provider served the request's session.
+func (m *Manager) recordSessionProvider(ctx context.Context, model string, opts cliproxyexecutor.Options, provider string) {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	if provider == "" {
+		return
+	}
+	primary, _, ttl := m.providerAffinityKeys(model, opts)
+	if primary == "" {
+		return
+	}
+	if previous := m.sessionProviders.get(primary, time.Now()); previous != "" && previous != provider {
+		logEntryWithRequestID(ctx).Debugf("provider-affinity: session moved from %s to %s | model=%s", previous, provider, model)
+	}
+	m.sessionProviders.set(primary, provider, time.Now().Add(ttl))
+}
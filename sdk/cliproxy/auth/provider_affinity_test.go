@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+func TestManager_PickNextMixed_ProviderAffinityKeepsSessionOnLastProvider(t *testing.T) {
+	t.Parallel()
+
+	manager := NewManager(nil, &RoundRobinSelector{}, nil)
+	manager.SetConfig(&internalconfig.Config{Routing: internalconfig.RoutingConfig{ProviderAffinity: true}})
+	manager.executors["gemini"] = schedulerTestExecutor{}
+	manager.executors["claude"] = schedulerTestExecutor{}
+	for _, auth := range []*Auth{{ID: "gemini-a", Provider: "gemini"}, {ID: "gemini-b", Provider: "gemini"}, {ID: "claude-a", Provider: "claude"}} {
+		if _, errRegister := manager.Register(context.Background(), auth); errRegister != nil {
+			t.Fatalf("Register(%s) error = %v", auth.ID, errRegister)
+		}
+	}
+
+	providers := []string{"gemini", "claude"}
+	opts := cliproxyexecutor.Options{Headers: http.Header{"X-Session-Id": {"session-1"}}}
+	manager.recordSessionProvider(context.Background(), "", opts, "claude")
+
+	for index := 0; index < 3; index++ {
+		got, _, provider, errPick := manager.pickNextMixed(context.Background(), providers, "", opts, map[string]struct{}{})
+		if errPick != nil {
+			t.Fatalf("pickNextMixed() #%d error = %v", index, errPick)
+		}
+		if provider != "claude" || got.ID != "claude-a" {
+			t.Fatalf("pickNextMixed() #%d = %s/%s, want claude/claude-a", index, provider, got.ID)
+		}
+	}
+
+	got, _, provider, errPick := manager.pickNextMixed(context.Background(), providers, "", opts, map[string]struct{}{"claude-a": {}})
+	if errPick != nil {
+		t.Fatalf("pickNextMixed() with claude exhausted error = %v", errPick)
+	}
+	if provider != "gemini" {
+		t.Fatalf("pickNextMixed() with claude exhausted = %s/%s, want a gemini auth", provider, got.ID)
+	}
+
+	other := cliproxyexecutor.Options{Headers: http.Header{"X-Session-Id": {"session-2"}}}
+	if preferred := manager.preferredSessionProvider(providers, "", other); preferred != "" {
+		t.Fatalf("preferredSessionProvider() for a new session = %q, want none", preferred)
+	}
+}
+
+func TestManager_ProviderAffinityDisabledByDefault(t *testing.T) {
+	t.Parallel()
+
+	manager := NewManager(nil, &RoundRobinSelector{}, nil)
+	opts := cliproxyexecutor.Options{Headers: http.Header{"X-Session-Id": {"session-1"}}}
+	manager.recordSessionProvider(context.Background(), "", opts, "claude")
+	if preferred := manager.preferredSessionProvider([]string{"gemini", "claude"}, "", opts); preferred != "" {
+		t.Fatalf("preferredSessionProvider() = %q, want none without routing.provider-affinity", preferred)
+	}
+}
+
+func TestSessionProviders_EvictsExpiredThenOldestWhenFull(t *testing.T) {
+	t.Parallel()
+
+	var table sessionProviders
+	now := time.Now()
+	table.set("expired", "gemini", now.Add(-time.Minute))
+	for i := 1; i < maxSessionProviders; i++ {
+		table.set("session-"+strconv.Itoa(i), "claude", now.Add(time.Hour+time.Duration(i)*time.Second))
+	}
+
+	table.set("new-1", "codex", now.Add(2*time.Hour))
+	if len(table.entries) != maxSessionProviders {
+		t.Fatalf("entries = %d, want %d", len(table.entries), maxSessionProviders)
+	}
+	if _, ok := table.entries["expired"]; ok {
+		t.Fatal("expired entry kept while the table was full")
+	}
+	if got := table.get("session-1", now); got != "claude" {
+		t.Fatalf("session-1 provider = %q, want claude after evicting only the expired entry", got)
+	}
+
+	table.set("new-2", "codex", now.Add(2*time.Hour))
+	if _, ok := table.entries["session-1"]; ok {
+		t.Fatal("entry closest to expiry kept while the table was full")
+	}
+	for _, key := range []string{"session-2", "new-1", "new-2"} {
+		if table.get(key, now) == "" {
+			t.Fatalf("%s evicted, want only the oldest entry evicted", key)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package auth
+
+import (
+	"context"
+	"strings"
+)
+
+// ProviderOutageChecker reports whether a provider has a declared incident severe enough for
+// routing to avoid it, e.g. from the provider's status page.
+type ProviderOutageChecker interface {
+	ProviderOutage(provider string) bool
+}
+
+// SetProviderOutageChecker installs checker; nil removes it.
+func (m *Manager) SetProviderOutageChecker(checker ProviderOutageChecker) {
+	if m == nil {
+		return
+	}
+	if checker == nil {
+		m.outageChecker.Store(nil)
+		return
+	}
+	m.outageChecker.Store(&checker)
+}
+
+// avoidProviderOutages drops providers with a declared outage from a multi-provider request.
+// When every candidate is affected the list is returned unchanged so the request still runs.
+func (m *Manager) avoidProviderOutages(ctx context.Context, providers []string) []string {
+	if m == nil || len(providers) < 2 {
+		return providers
+	}
+	checker := m.outageChecker.Load()
+	if checker == nil {
+		return providers
+	}
+	healthy := make([]string, 0, len(providers))
+	var avoided []string
+	for _, provider := range providers {
+		if (*checker).ProviderOutage(provider) {
+			avoided = append(avoided, provider)
+			continue
+		}
+		healthy = append(healthy, provider)
+	}
+	if len(avoided) == 0 || len(healthy) == 0 {
+		return providers
+	}
+	logEntryWithRequestID(ctx).Debugf("provider-status: avoiding providers with declared outages: %s", strings.Join(avoided, ", "))
+	return healthy
+}
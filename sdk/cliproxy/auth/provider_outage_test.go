@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"context"
+	"slices"
+	"testing"
+)
+
+type outageSet map[string]bool
+
+func (s outageSet) ProviderOutage(provider string) bool { return s[provider] }
+
+func TestAvoidProviderOutages(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	providers := []string{"claude", "codex"}
+	if got := m.avoidProviderOutages(context.Background(), providers); !slices.Equal(got, providers) {
+		t.Fatalf("without a checker got %v", got)
+	}
+
+	m.SetProviderOutageChecker(outageSet{"claude": true})
+	if got := m.avoidProviderOutages(context.Background(), providers); !slices.Equal(got, []string{"codex"}) {
+		t.Fatalf("got %v, want [codex]", got)
+	}
+	if got := m.avoidProviderOutages(context.Background(), []string{"claude"}); !slices.Equal(got, []string{"claude"}) {
+		t.Fatalf("a single provider must be kept, got %v", got)
+	}
+
+	m.SetProviderOutageChecker(outageSet{"claude": true, "codex": true})
+	if got := m.avoidProviderOutages(context.Background(), providers); !slices.Equal(got, providers) {
+		t.Fatalf("all providers affected must keep the list, got %v", got)
+	}
+}
@@ -0,0 +1,47 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+// excludeReadOnlyProviders drops providers listed in read-only-providers from a generation
+// request. Token counting and model listing are not affected. When every provider is read-only
+// the request is refused with a 403.
+func (m *Manager) excludeReadOnlyProviders(providers []string) ([]string, error) {
+	if m == nil || len(providers) == 0 {
+		return providers, nil
+	}
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || len(cfg.ReadOnlyProviders) == 0 {
+		return providers, nil
+	}
+	readOnly := make(map[string]struct{}, len(cfg.ReadOnlyProviders))
+	for _, provider := range cfg.ReadOnlyProviders {
+		if provider = strings.ToLower(strings.TrimSpace(provider)); provider != "" {
+			readOnly[provider] = struct{}{}
+		}
+	}
+	allowed := make([]string, 0, len(providers))
+	var refused []string
+	for _, provider := range providers {
+		if _, ok := readOnly[strings.ToLower(provider)]; ok {
+			refused = append(refused, provider)
+			continue
+		}
+		allowed = append(allowed, provider)
+	}
+	if len(refused) == 0 {
+		return providers, nil
+	}
+	if len(allowed) == 0 {
+		return nil, &Error{
+			Code:       "provider_read_only",
+			Message:    "provider " + strings.Join(refused, ", ") + " is in read-only mode; only token counting and model listing are served",
+			HTTPStatus: http.StatusForbidden,
+		}
+	}
+	return allowed, nil
+}
@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"testing"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+func TestManager_ReadOnlyProvidersRefuseGeneration(t *testing.T) {
+	manager := NewManager(nil, &RoundRobinSelector{}, nil)
+	manager.SetConfig(&internalconfig.Config{ReadOnlyProviders: []string{" Claude "}})
+	manager.executors["claude"] = schedulerTestExecutor{}
+	manager.executors["gemini"] = schedulerTestExecutor{}
+	for _, auth := range []*Auth{{ID: "claude-a", Provider: "claude"}, {ID: "gemini-a", Provider: "gemini"}} {
+		if _, errRegister := manager.Register(context.Background(), auth); errRegister != nil {
+			t.Fatalf("Register(%s) error = %v", auth.ID, errRegister)
+		}
+	}
+	ctx := context.Background()
+
+	_, errExec := manager.Execute(ctx, []string{"claude"}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{})
+	var authErr *Error
+	if !errors.As(errExec, &authErr) || authErr.HTTPStatus != http.StatusForbidden || authErr.Code != "provider_read_only" {
+		t.Fatalf("Execute() on a read-only provider error = %v, want provider_read_only 403", errExec)
+	}
+	if _, errStream := manager.ExecuteStream(ctx, []string{"claude"}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{}); !errors.As(errStream, &authErr) || authErr.HTTPStatus != http.StatusForbidden {
+		t.Fatalf("ExecuteStream() on a read-only provider error = %v, want 403", errStream)
+	}
+	if _, errCount := manager.ExecuteCount(ctx, []string{"claude"}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{}); errCount != nil {
+		t.Fatalf("ExecuteCount() on a read-only provider error = %v, want it served", errCount)
+	}
+
+	allowed, errReadOnly := manager.excludeReadOnlyProviders([]string{"claude", "gemini"})
+	if errReadOnly != nil || !slices.Equal(allowed, []string{"gemini"}) {
+		t.Fatalf("excludeReadOnlyProviders() = %v, %v; want [gemini]", allowed, errReadOnly)
+	}
+	if _, errExec = manager.Execute(ctx, []string{"claude", "gemini"}, cliproxyexecutor.Request{}, cliproxyexecutor.Options{}); errExec != nil {
+		t.Fatalf("Execute() with another provider available error = %v", errExec)
+	}
+}
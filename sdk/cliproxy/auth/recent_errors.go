@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	// recentErrorsPerAuth is how many failures are retained for each auth.
+	recentErrorsPerAuth = 20
+	// recentErrorMessageLimit bounds the summarized error body kept per entry.
+	recentErrorMessageLimit = 512
+)
+
+// RecentError is a summarized upstream failure kept in memory for diagnostics.
+type RecentError struct {
+	Timestamp  time.Time `json:"timestamp"`
+	Provider   string    `json:"provider"`
+	AuthID     string    `json:"auth_id"`
+	AuthLabel  string    `json:"auth_label,omitempty"`
+	Model      string    `json:"model,omitempty"`
+	HTTPStatus int       `json:"http_status,omitempty"`
+	Code       string    `json:"code,omitempty"`
+	Message    string    `json:"message"`
+}
+
+// recentErrorRing is a fixed-size ring of the latest failures of one auth.
+type recentErrorRing struct {
+	entries [recentErrorsPerAuth]RecentError
+	next    int
+	size    int
+}
+
+func (r *recentErrorRing) add(entry RecentError) {
+	r.entries[r.next] = entry
+	r.next = (r.next + 1) % len(r.entries)
+	if r.size < len(r.entries) {
+		r.size++
+	}
+}
+
+func (r *recentErrorRing) appendTo(dst []RecentError) []RecentError {
+	start := (r.next - r.size + len(r.entries)) % len(r.entries)
+	for i := 0; i < r.size; i++ {
+		dst = append(dst, r.entries[(start+i)%len(r.entries)])
+	}
+	return dst
+}
+
+// recentErrorLog keeps the latest failures per auth, independently of request logging.
+type recentErrorLog struct {
+	mu    sync.Mutex
+	rings map[string]*recentErrorRing
+}
+
+func (l *recentErrorLog) record(entry RecentError) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.rings == nil {
+		l.rings = make(map[string]*recentErrorRing)
+	}
+	ring := l.rings[entry.AuthID]
+	if ring == nil {
+		ring = &recentErrorRing{}
+		l.rings[entry.AuthID] = ring
+	}
+	ring.add(entry)
+}
+
+// snapshot returns matching failures, newest first. Empty filters match everything.
+func (l *recentErrorLog) snapshot(provider, authID string, limit int) []RecentError {
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	authID = strings.TrimSpace(authID)
+	l.mu.Lock()
+	out := make([]RecentError, 0)
+	for id, ring := range l.rings {
+		if authID != "" && id != authID {
+			continue
+		}
+		for _, entry := range ring.appendTo(nil) {
+			if provider != "" && !strings.EqualFold(entry.Provider, provider) {
+				continue
+			}
+			out = append(out, entry)
+		}
+	}
+	l.mu.Unlock()
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Timestamp.After(out[j].Timestamp) })
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+// summarizeErrorMessage collapses whitespace and truncates an upstream error body.
+func summarizeErrorMessage(message string) string {
+	message = strings.Join(strings.Fields(message), " ")
+	if len(message) <= recentErrorMessageLimit {
+		return message
+	}
+	cut := recentErrorMessageLimit
+	for cut > 0 && !utf8.RuneStart(message[cut]) {
+		cut--
+	}
+	return message[:cut] + "..."
+}
+
+// recordRecentError stores a failed result of auth.
+func (m *Manager) recordRecentError(result Result, auth *Auth, now time.Time) {
+	if m == nil || result.Success || auth == nil {
+		return
+	}
+	provider := strings.TrimSpace(result.Provider)
+	if provider == "" {
+		provider = strings.TrimSpace(auth.Provider)
+	}
+	entry := RecentError{
+		Timestamp: now,
+		Provider:  provider,
+		AuthID:    auth.ID,
+		AuthLabel: auth.Label,
+		Model:     result.Model,
+	}
+	if result.Error != nil {
+		entry.HTTPStatus = result.Error.HTTPStatus
+		entry.Code = result.Error.Code
+		entry.Message = summarizeErrorMessage(result.Error.Message)
+	}
+	m.recentErrors.record(entry)
+}
+
+// RecentErrors returns the most recent upstream failures, newest first, optionally filtered by
+// provider and auth ID. limit <= 0 returns every retained entry.
+func (m *Manager) RecentErrors(provider, authID string, limit int) []RecentError {
+	if m == nil {
+		return nil
+	}
+	return m.recentErrors.snapshot(provider, authID, limit)
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestManagerMarkResultRecordsRecentErrors(t *testing.T) {
+	mgr := NewManager(nil, nil, nil)
+	for _, auth := range []*Auth{
+		{ID: "copilot-1", Provider: "copilot", Label: "work", Attributes: map[string]string{"runtime_only": "true"}},
+		{ID: "grok-1", Provider: "grok", Attributes: map[string]string{"runtime_only": "true"}},
+	} {
+		if _, err := mgr.Register(WithSkipPersist(context.Background()), auth); err != nil {
+			t.Fatalf("Register returned error: %v", err)
+		}
+	}
+
+	mgr.MarkResult(context.Background(), Result{AuthID: "copilot-1", Model: "gpt-5", Success: true})
+	mgr.MarkResult(context.Background(), Result{AuthID: "copilot-1", Model: "gpt-5", Error: &Error{HTTPStatus: 401, Message: "bad\n  token"}})
+	mgr.MarkResult(context.Background(), Result{AuthID: "grok-1", Provider: "grok", Error: &Error{HTTPStatus: 500, Message: "boom"}})
+
+	copilot := mgr.RecentErrors("Copilot", "", 0)
+	if len(copilot) != 1 {
+		t.Fatalf("copilot errors = %+v, want 1", copilot)
+	}
+	got := copilot[0]
+	if got.Provider != "copilot" || got.AuthLabel != "work" || got.HTTPStatus != 401 || got.Message != "bad token" {
+		t.Fatalf("copilot error = %+v", got)
+	}
+	if all := mgr.RecentErrors("", "", 0); len(all) != 2 || all[0].AuthID != "grok-1" {
+		t.Fatalf("all errors = %+v, want newest first", all)
+	}
+	if byAuth := mgr.RecentErrors("", "grok-1", 0); len(byAuth) != 1 {
+		t.Fatalf("grok errors = %+v", byAuth)
+	}
+}
+
+func TestRecentErrorRingKeepsLatest(t *testing.T) {
+	var log recentErrorLog
+	for i := 0; i < recentErrorsPerAuth+5; i++ {
+		log.record(RecentError{AuthID: "a", HTTPStatus: i})
+	}
+	entries := log.snapshot("", "a", 0)
+	if len(entries) != recentErrorsPerAuth {
+		t.Fatalf("entries = %d, want %d", len(entries), recentErrorsPerAuth)
+	}
+	if limited := log.snapshot("", "", 3); len(limited) != 3 {
+		t.Fatalf("limited entries = %d, want 3", len(limited))
+	}
+	seen := make(map[int]bool)
+	for _, entry := range entries {
+		seen[entry.HTTPStatus] = true
+	}
+	if seen[0] || !seen[recentErrorsPerAuth+4] {
+		t.Fatalf("ring kept wrong entries: %v", seen)
+	}
+}
+
+func TestSummarizeErrorMessageTruncates(t *testing.T) {
+	got := summarizeErrorMessage(strings.Repeat("é", recentErrorMessageLimit))
+	if len(got) > recentErrorMessageLimit+3 || !strings.HasSuffix(got, "...") {
+		t.Fatalf("summary length = %d", len(got))
+	}
+}
@@ -0,0 +1,179 @@
+package auth
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/metrics"
+	log "github.com/sirupsen/logrus"
+)
+
+// regionKey is the attribute or metadata key naming the region a credential is served from.
+const regionKey = "region"
+
+// defaultRegionFailoverCooldown is how long a region is skipped after a 5xx or timeout when
+// routing.region-failover-cooldown is unset or invalid.
+const defaultRegionFailoverCooldown = time.Minute
+
+// authRegion returns the lowercased region of auth, or "" when it is untagged. Vertex service
+// accounts without a region fall back to their location.
+func authRegion(auth *Auth) string {
+	if auth == nil {
+		return ""
+	}
+	if region := strings.TrimSpace(auth.Attributes[regionKey]); region != "" {
+		return strings.ToLower(region)
+	}
+	if raw, ok := auth.Metadata[regionKey].(string); ok && strings.TrimSpace(raw) != "" {
+		return strings.ToLower(strings.TrimSpace(raw))
+	}
+	if strings.EqualFold(auth.Provider, "vertex") {
+		if raw, ok := auth.Metadata["location"].(string); ok {
+			return strings.ToLower(strings.TrimSpace(raw))
+		}
+	}
+	return ""
+}
+
+// regionFailoverConfig returns the configured failover groups and how long a region is skipped
+// after a 5xx or timeout.
+func (m *Manager) regionFailoverConfig() ([]internalconfig.RegionFailoverGroup, time.Duration) {
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || len(cfg.Routing.RegionFailover) == 0 {
+		return nil, 0
+	}
+	cooldown := defaultRegionFailoverCooldown
+	if raw := strings.TrimSpace(cfg.Routing.RegionFailoverCooldown); raw != "" {
+		if parsed, errParse := time.ParseDuration(raw); errParse == nil && parsed > 0 {
+			cooldown = parsed
+		}
+	}
+	return cfg.Routing.RegionFailover, cooldown
+}
+
+func regionGroupName(group internalconfig.RegionFailoverGroup) string {
+	if name := strings.TrimSpace(group.Name); name != "" {
+		return name
+	}
+	if len(group.Regions) > 0 {
+		return normalizeRegion(group.Regions[0])
+	}
+	return ""
+}
+
+func normalizeRegion(region string) string {
+	return strings.ToLower(strings.TrimSpace(region))
+}
+
+// outOfRegionAuths returns the pool credentials of failover group regions other than the group's
+// current region: the first region of the group with a credential in pool that is not failed
+// over after a 5xx or timeout. While every region of a group is failed over, all of its
+// credentials stay in the pool.
+func (m *Manager) outOfRegionAuths(pool []routingPoolEntry, model string) map[string]struct{} {
+	groups, _ := m.regionFailoverConfig()
+	if len(groups) == 0 {
+		return nil
+	}
+	byRegion := make(map[string][]string)
+	for _, entry := range pool {
+		if entry.region != "" {
+			byRegion[entry.region] = append(byRegion[entry.region], entry.id)
+		}
+	}
+	now := time.Now()
+	excluded := make(map[string]struct{})
+	for _, group := range groups {
+		var primary, current string
+		for _, raw := range group.Regions {
+			region := normalizeRegion(raw)
+			if len(byRegion[region]) == 0 {
+				continue
+			}
+			if primary == "" {
+				primary = region
+			}
+			if !m.regionFailedOver(region, now) {
+				current = region
+				break
+			}
+		}
+		if current == "" {
+			continue
+		}
+		m.noteRegionFailover(regionGroupName(group), model, primary, current)
+		for _, raw := range group.Regions {
+			if region := normalizeRegion(raw); region != current {
+				for _, id := range byRegion[region] {
+					excluded[id] = struct{}{}
+				}
+			}
+		}
+	}
+	return excluded
+}
+
+// regionFailedOver reports whether region is skipped after a recent 5xx or timeout.
+func (m *Manager) regionFailedOver(region string, now time.Time) bool {
+	value, ok := m.regionFailovers.Load(region)
+	if !ok {
+		return false
+	}
+	until, _ := value.(time.Time)
+	return now.Before(until)
+}
+
+// noteRegionResult fails over away from the region of auth when result is a 5xx or timeout
+// and the region belongs to a failover group.
+func (m *Manager) noteRegionResult(auth *Auth, result Result, now time.Time) {
+	if result.Success || !isRegionFailoverError(result.Error) {
+		return
+	}
+	groups, cooldown := m.regionFailoverConfig()
+	region := authRegion(auth)
+	if len(groups) == 0 || region == "" {
+		return
+	}
+	for _, group := range groups {
+		for _, raw := range group.Regions {
+			if normalizeRegion(raw) == region {
+				m.regionFailovers.Store(region, now.Add(cooldown))
+				return
+			}
+		}
+	}
+}
+
+// isRegionFailoverError reports whether err is a server error or timeout, the failures that move
+// a failover group to its next region.
+func isRegionFailoverError(err *Error) bool {
+	if err == nil {
+		return false
+	}
+	switch status := statusCodeFromResult(err); {
+	case status >= http.StatusInternalServerError, status == http.StatusRequestTimeout:
+		return true
+	case status == 0:
+		lower := strings.ToLower(err.Message)
+		return strings.Contains(lower, "timeout") || strings.Contains(lower, "deadline exceeded")
+	}
+	return false
+}
+
+// noteRegionFailover logs and counts when the current region of a group changes for model.
+func (m *Manager) noteRegionFailover(group, model, primary, current string) {
+	previous := primary
+	if value, loaded := m.regionCurrent.Swap(group+"\x00"+model, current); loaded {
+		previous, _ = value.(string)
+	}
+	if previous == current {
+		return
+	}
+	metrics.RecordRegionFailover(group, previous, current)
+	if current == primary {
+		log.Infof("region failover group %s returned to primary region %s for model %s", group, current, model)
+		return
+	}
+	log.Warnf("region failover group %s failed over from %s to %s for model %s", group, previous, current, model)
+}
@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+func TestPickNextMixedWithinLimits_RegionFailover(t *testing.T) {
+	manager := NewManager(nil, &FillFirstSelector{}, nil)
+	manager.SetConfig(&internalconfig.Config{Routing: internalconfig.RoutingConfig{
+		RegionFailover: []internalconfig.RegionFailoverGroup{{Name: "vertex", Regions: []string{"us-central1", "us-east4"}}},
+	}})
+	manager.executors["vertex"] = schedulerTestExecutor{}
+	for _, auth := range []*Auth{
+		// Fill-first prefers the lowest ID, so the secondary region would win unfiltered.
+		{ID: "vertex-0-east", Provider: "vertex", Attributes: map[string]string{regionKey: "us-east4"}},
+		{ID: "vertex-1-central", Provider: "vertex", Metadata: map[string]any{"location": "us-central1"}},
+	} {
+		if _, errRegister := manager.Register(context.Background(), auth); errRegister != nil {
+			t.Fatalf("Register(%s) error = %v", auth.ID, errRegister)
+		}
+	}
+	pick := func() string {
+		t.Helper()
+		auth, _, _, _, errPick := manager.pickNextMixedWithinLimits(context.Background(), []string{"vertex"}, "", cliproxyexecutor.Options{}, nil)
+		if errPick != nil {
+			t.Fatalf("pick error = %v", errPick)
+		}
+		return auth.ID
+	}
+
+	if got := pick(); got != "vertex-1-central" {
+		t.Fatalf("pick = %s, want the primary region", got)
+	}
+
+	// Quota errors do not fail over.
+	manager.noteRegionResult(manager.auths["vertex-1-central"], Result{Error: &Error{HTTPStatus: http.StatusTooManyRequests}}, time.Now())
+	if got := pick(); got != "vertex-1-central" {
+		t.Fatalf("pick after 429 = %s, want the primary region", got)
+	}
+
+	manager.noteRegionResult(manager.auths["vertex-1-central"], Result{Error: &Error{Message: "context deadline exceeded"}}, time.Now())
+	if got := pick(); got != "vertex-0-east" {
+		t.Fatalf("pick after timeout = %s, want the secondary region", got)
+	}
+
+	manager.noteRegionResult(manager.auths["vertex-0-east"], Result{Error: &Error{HTTPStatus: http.StatusBadGateway}}, time.Now())
+	if got := pick(); got != "vertex-0-east" {
+		t.Fatalf("pick with every region failed over = %s, want fill-first over the whole group", got)
+	}
+
+	manager.regionFailovers.Store("us-central1", time.Now().Add(-time.Second))
+	if got := pick(); got != "vertex-1-central" {
+		t.Fatalf("pick after the cooldown = %s, want the primary region back", got)
+	}
+}
@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// AuthRegisteredObserver is notified after a new auth joins the manager, e.g. to prefetch its
+// models and credentials before the first request is routed to it.
+type AuthRegisteredObserver func(ctx context.Context, auth *Auth)
+
+// SetAuthRegisteredObserver installs observer; nil removes it.
+func (m *Manager) SetAuthRegisteredObserver(observer AuthRegisteredObserver) {
+	if m == nil {
+		return
+	}
+	if observer == nil {
+		m.registeredObserver.Store(nil)
+		return
+	}
+	m.registeredObserver.Store(&observer)
+}
+
+func (m *Manager) notifyAuthRegistered(ctx context.Context, auth *Auth) {
+	if m == nil || auth == nil || auth.Disabled || auth.Status == StatusDisabled {
+		return
+	}
+	if observer := m.registeredObserver.Load(); observer != nil {
+		(*observer)(ctx, auth)
+	}
+}
+
+// WarmAuth refreshes the credentials of the auth with id when they are due, so the refresh does
+// not run on the first request. It reports whether a refresh succeeded.
+func (m *Manager) WarmAuth(ctx context.Context, id string) (bool, error) {
+	auth, ok := m.GetByID(strings.TrimSpace(id))
+	if !ok || auth == nil || auth.Disabled {
+		return false, nil
+	}
+	if !m.shouldRefresh(auth, time.Now()) {
+		return false, nil
+	}
+	if _, err := m.refreshAuthForRequest(ctx, auth.ID, ""); err != nil {
+		return false, err
+	}
+	return true, nil
+}
@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+type dueRefreshEvaluator struct{ due bool }
+
+func (e dueRefreshEvaluator) ShouldRefresh(time.Time, *Auth) bool { return e.due }
+
+func TestManagerNotifiesAuthRegisteredObserverForNewAuthsOnly(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	var notified []string
+	m.SetAuthRegisteredObserver(func(_ context.Context, auth *Auth) {
+		notified = append(notified, auth.ID)
+	})
+
+	ctx := WithSkipPersist(context.Background())
+	if _, err := m.Register(ctx, &Auth{ID: "a", Provider: "test"}); err != nil {
+		t.Fatalf("register a: %v", err)
+	}
+	if _, err := m.Register(ctx, &Auth{ID: "b", Provider: "test", Disabled: true}); err != nil {
+		t.Fatalf("register b: %v", err)
+	}
+	if _, err := m.Update(ctx, &Auth{ID: "a", Provider: "test", Label: "renamed"}); err != nil {
+		t.Fatalf("update a: %v", err)
+	}
+	m.SetAuthRegisteredObserver(nil)
+	if _, err := m.Register(ctx, &Auth{ID: "c", Provider: "test"}); err != nil {
+		t.Fatalf("register c: %v", err)
+	}
+
+	if len(notified) != 1 || notified[0] != "a" {
+		t.Fatalf("notified = %v, want [a]", notified)
+	}
+}
+
+func TestManagerWarmAuthRefreshesOnlyWhenDue(t *testing.T) {
+	m := NewManager(nil, nil, nil)
+	executor := &unauthorizedRefreshExecutor{id: "test-warm"}
+	m.RegisterExecutor(executor)
+	ctx := WithSkipPersist(context.Background())
+	if _, err := m.Register(ctx, &Auth{ID: "fresh", Provider: "test-warm", Runtime: dueRefreshEvaluator{}}); err != nil {
+		t.Fatalf("register fresh: %v", err)
+	}
+	if _, err := m.Register(ctx, &Auth{ID: "stale", Provider: "test-warm", Runtime: dueRefreshEvaluator{due: true}}); err != nil {
+		t.Fatalf("register stale: %v", err)
+	}
+
+	if refreshed, err := m.WarmAuth(ctx, "fresh"); err != nil || refreshed {
+		t.Fatalf("WarmAuth(fresh) = %v, %v; want no refresh", refreshed, err)
+	}
+	if refreshed, err := m.WarmAuth(ctx, "stale"); err != nil || !refreshed {
+		t.Fatalf("WarmAuth(stale) = %v, %v; want refresh", refreshed, err)
+	}
+	if executor.refreshCalls != 1 {
+		t.Fatalf("refresh calls = %d, want 1", executor.refreshCalls)
+	}
+	if got, _ := m.GetByID("stale"); authAccessToken(got) != "refreshed-access-token" {
+		t.Fatalf("stale auth token = %q, want refreshed", authAccessToken(got))
+	}
+	if refreshed, err := m.WarmAuth(ctx, "missing"); err != nil || refreshed {
+		t.Fatalf("WarmAuth(missing) = %v, %v; want no-op", refreshed, err)
+	}
+}
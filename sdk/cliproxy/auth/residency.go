@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+// residencyKey is the attribute or metadata key naming the data-residency region of a credential.
+const residencyKey = "residency"
+
+// authResidency returns the lowercased data-residency region of auth, or "" when it is untagged.
+func authResidency(auth *Auth) string {
+	if auth == nil {
+		return ""
+	}
+	if region := strings.TrimSpace(auth.Attributes[residencyKey]); region != "" {
+		return strings.ToLower(region)
+	}
+	if raw, ok := auth.Metadata[residencyKey].(string); ok {
+		return strings.ToLower(strings.TrimSpace(raw))
+	}
+	return ""
+}
+
+// residencySatisfies reports whether a credential in region may serve a request that must stay
+// in required. A region admits its sub-regions, so "eu" admits "eu-west"; untagged credentials
+// never satisfy a requirement.
+func residencySatisfies(region, required string) bool {
+	if required == "" {
+		return true
+	}
+	return region == required || strings.HasPrefix(region, required+"-")
+}
+
+// requiredResidencyFromMetadata returns the data-residency region a request must stay in.
+func requiredResidencyFromMetadata(meta map[string]any) string {
+	if len(meta) == 0 {
+		return ""
+	}
+	raw, _ := meta[cliproxyexecutor.RequiredResidencyMetadataKey].(string)
+	return strings.ToLower(strings.TrimSpace(raw))
+}
+
+// nonResidentAuths returns the pool credentials outside required.
+func nonResidentAuths(pool []routingPoolEntry, required string) map[string]struct{} {
+	if required == "" {
+		return nil
+	}
+	var out map[string]struct{}
+	for _, entry := range pool {
+		if residencySatisfies(entry.residency, required) {
+			continue
+		}
+		if out == nil {
+			out = make(map[string]struct{})
+		}
+		out[entry.id] = struct{}{}
+	}
+	return out
+}
+
+// residencyError explains a failed pick when it failed because no credential of providers that
+// serves model is in the data-residency region the request requires. It returns nil when the
+// request has no requirement or a compliant credential exists.
+func (m *Manager) residencyError(ctx context.Context, providers []string, model string, opts cliproxyexecutor.Options) error {
+	required := requiredResidencyFromMetadata(opts.Metadata)
+	if required == "" || m == nil {
+		return nil
+	}
+	pinned := pinnedAuthIDFromMetadata(opts.Metadata)
+	for _, entry := range m.collectRoutingPool(providers, model, nil) {
+		if pinned != "" && entry.id != pinned {
+			continue
+		}
+		if residencySatisfies(entry.residency, required) {
+			return nil
+		}
+	}
+	logEntryWithRequestID(ctx).Warnf("data residency: rejected request for model %s: no credential of %s is in region %q", model, strings.Join(providers, ","), required)
+	return &Error{
+		Code:       "residency_not_satisfied",
+		Message:    fmt.Sprintf("no credential in data-residency region %q can serve model %s", required, model),
+		HTTPStatus: http.StatusForbidden,
+	}
+}
@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+func TestPickNextMixedWithinLimits_HonorsResidency(t *testing.T) {
+	manager := NewManager(nil, &FillFirstSelector{}, nil)
+	manager.executors["codex"] = schedulerTestExecutor{}
+	for _, auth := range []*Auth{
+		// Fill-first prefers the lowest ID, so the US and untagged credentials would win unfiltered.
+		{ID: "codex-0-us", Provider: "codex", Attributes: map[string]string{residencyKey: "us"}},
+		{ID: "codex-1-untagged", Provider: "codex"},
+		{ID: "codex-2-eu-west", Provider: "codex", Metadata: map[string]any{residencyKey: "EU-West"}},
+	} {
+		if _, errRegister := manager.Register(context.Background(), auth); errRegister != nil {
+			t.Fatalf("Register(%s) error = %v", auth.ID, errRegister)
+		}
+	}
+	pick := func(meta map[string]any) (string, error) {
+		t.Helper()
+		auth, _, _, _, errPick := manager.pickNextMixedWithinLimits(context.Background(), []string{"codex"}, "", cliproxyexecutor.Options{Metadata: meta}, nil)
+		if errPick != nil {
+			return "", errPick
+		}
+		return auth.ID, nil
+	}
+
+	if got, errPick := pick(nil); errPick != nil || got != "codex-0-us" {
+		t.Fatalf("unrestricted pick = %s, %v; want codex-0-us", got, errPick)
+	}
+	if got, errPick := pick(map[string]any{cliproxyexecutor.RequiredResidencyMetadataKey: "eu"}); errPick != nil || got != "codex-2-eu-west" {
+		t.Fatalf("eu pick = %s, %v; want codex-2-eu-west", got, errPick)
+	}
+
+	// A pinned credential outside the region is rejected rather than used.
+	_, errPick := pick(map[string]any{
+		cliproxyexecutor.RequiredResidencyMetadataKey: "eu",
+		cliproxyexecutor.PinnedAuthMetadataKey:        "codex-0-us",
+	})
+	var authErr *Error
+	if !errors.As(errPick, &authErr) || authErr.HTTPStatus != http.StatusForbidden {
+		t.Fatalf("pinned non-resident pick error = %v, want a 403", errPick)
+	}
+
+	_, errPick = pick(map[string]any{cliproxyexecutor.RequiredResidencyMetadataKey: "apac"})
+	if !errors.As(errPick, &authErr) || authErr.Code != "residency_not_satisfied" {
+		t.Fatalf("apac pick error = %v, want residency_not_satisfied", errPick)
+	}
+}
+
+func TestResidencySatisfies(t *testing.T) {
+	tests := []struct {
+		region, required string
+		want             bool
+	}{
+		{"", "", true},
+		{"us", "", true},
+		{"eu", "eu", true},
+		{"eu-west", "eu", true},
+		{"europe", "eu", false},
+		{"eu", "eu-west", false},
+		{"", "eu", false},
+	}
+	for _, tt := range tests {
+		if got := residencySatisfies(tt.region, tt.required); got != tt.want {
+			t.Errorf("residencySatisfies(%q, %q) = %v, want %v", tt.region, tt.required, got, tt.want)
+		}
+	}
+}
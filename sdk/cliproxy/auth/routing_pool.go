@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	log "github.com/sirupsen/logrus"
+)
+
+// routingPoolEntry is one enabled credential of a pick that serves the requested model.
+type routingPoolEntry struct {
+	id       string
+	provider string
+	standby  bool
+	tier     costTier
+	// residency is the data-residency region of the credential.
+	residency string
+	// region is the region the credential is served from, for region failover groups.
+	region string
+	// available reports whether the credential can take the request now: it was not tried
+	// yet and is not cooling down for the model.
+	available bool
+}
+
+// collectRoutingPool lists the enabled credentials of providers that serve model.
+func (m *Manager) collectRoutingPool(providers []string, model string, tried map[string]struct{}) []routingPoolEntry {
+	providerSet := make(map[string]struct{}, len(providers))
+	for _, provider := range providers {
+		if p := strings.TrimSpace(strings.ToLower(provider)); p != "" {
+			providerSet[p] = struct{}{}
+		}
+	}
+	now := time.Now()
+	registryRef := registry.GetGlobalRegistry()
+	var pool []routingPoolEntry
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	for _, candidate := range m.auths {
+		if candidate == nil || candidate.Disabled {
+			continue
+		}
+		providerKey := executorKeyFromAuth(candidate)
+		if _, ok := providerSet[providerKey]; !ok {
+			continue
+		}
+		if strings.TrimSpace(model) != "" && !m.authSupportsRouteModel(registryRef, candidate, model) {
+			continue
+		}
+		entry := routingPoolEntry{
+			id:        candidate.ID,
+			provider:  providerKey,
+			standby:   authStandby(candidate),
+			tier:      authCostTier(candidate),
+			residency: authResidency(candidate),
+			region:    authRegion(candidate),
+		}
+		if _, used := tried[candidate.ID]; !used {
+			blocked, _, _ := isAuthBlockedForModel(candidate, model, now)
+			entry.available = !blocked
+		}
+		pool = append(pool, entry)
+	}
+	return pool
+}
+
+// excludeUnroutedAuths returns tried extended with the credentials routing keeps out of this
+// pick: credentials outside the data-residency region the request requires, credentials of
+// regions a failover group is not currently using, idle standby credentials and, with
+// routing.prefer-cheaper-tiers, credentials of costlier tiers than the cheapest one still
+// available. Apart from residency the pinned credential is never excluded,
+// and tried itself is never modified.
+func (m *Manager) excludeUnroutedAuths(providers []string, model string, opts cliproxyexecutor.Options, tried map[string]struct{}) map[string]struct{} {
+	if m == nil {
+		return tried
+	}
+	pool := m.collectRoutingPool(providers, model, tried)
+	required := requiredResidencyFromMetadata(opts.Metadata)
+	nonResident := nonResidentAuths(pool, required)
+	if len(nonResident) > 0 {
+		pool = slices.DeleteFunc(pool, func(entry routingPoolEntry) bool {
+			_, skip := nonResident[entry.id]
+			return skip
+		})
+		log.Debugf("data residency: %d credential(s) for model %s excluded outside region %q", len(nonResident), model, required)
+	}
+	outOfRegion := m.outOfRegionAuths(pool, model)
+	if len(outOfRegion) > 0 {
+		pool = slices.DeleteFunc(pool, func(entry routingPoolEntry) bool {
+			_, skip := outOfRegion[entry.id]
+			return skip
+		})
+	}
+	excluded := m.idleStandbyAuths(pool)
+	maps.Copy(excluded, outOfRegion)
+	if m.preferCheaperTiers() {
+		for id := range costlierTierAuths(pool, excluded) {
+			excluded[id] = struct{}{}
+		}
+	}
+	delete(excluded, pinnedAuthIDFromMetadata(opts.Metadata))
+	maps.Copy(excluded, nonResident)
+	if len(excluded) == 0 {
+		return tried
+	}
+	out := maps.Clone(tried)
+	if out == nil {
+		out = make(map[string]struct{}, len(excluded))
+	}
+	maps.Copy(out, excluded)
+	return out
+}
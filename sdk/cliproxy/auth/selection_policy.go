@@ -0,0 +1,207 @@
+package auth
+
+import (
+	"context"
+	"maps"
+	"strings"
+	"sync"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+// AnySelectionPolicyProvider registers a SelectionPolicy for every provider without its own.
+const AnySelectionPolicyProvider = "*"
+
+// SelectionCandidate is one available credential offered to a SelectionPolicy, with the
+// health, quota and latency figures the manager tracks for it.
+type SelectionCandidate struct {
+	// Auth is a copy of the credential.
+	Auth *Auth
+	// Provider is the executor key of the credential.
+	Provider string
+	// Priority is the configured priority; higher is preferred by the built-in selectors.
+	Priority int
+	// InFlight is the number of requests currently running on the credential.
+	InFlight int
+	// Success and Failed count the results recorded for the credential.
+	Success int64
+	Failed  int64
+	// QuotaExceeded and QuotaRecoverAt report the last quota error of the credential.
+	QuotaExceeded  bool
+	QuotaRecoverAt time.Time
+	// Latency and TTFT are moving averages of the completed requests, 0 until the first one.
+	Latency time.Duration
+	TTFT    time.Duration
+}
+
+// SelectionRequest describes one credential pick.
+type SelectionRequest struct {
+	// Provider is the provider whose policy is asked; candidates of a request routed across
+	// several providers may belong to other providers.
+	Provider string
+	// Model is the requested model.
+	Model string
+	// Options are the execution options of the request.
+	Options cliproxyexecutor.Options
+	// Candidates are the credentials available for the request.
+	Candidates []SelectionCandidate
+}
+
+// SelectionPolicy orders the candidates of a pick by preference and returns their IDs. The
+// first returned ID among the candidates is used; returning none defers to the built-in
+// selector. Policies are called concurrently and must not block.
+type SelectionPolicy func(ctx context.Context, req SelectionRequest) []string
+
+// SetSelectionPolicy replaces the built-in credential selection for provider with policy, or
+// restores it when policy is nil. AnySelectionPolicyProvider applies to every provider without
+// its own policy. Requests routed across several providers use the policy of the first of
+// them that has one.
+func (m *Manager) SetSelectionPolicy(provider string, policy SelectionPolicy) {
+	if m == nil {
+		return
+	}
+	provider = strings.ToLower(strings.TrimSpace(provider))
+	if provider == "" {
+		return
+	}
+	m.selectionPoliciesMu.Lock()
+	defer m.selectionPoliciesMu.Unlock()
+	next := make(map[string]SelectionPolicy)
+	if current := m.selectionPolicies.Load(); current != nil {
+		maps.Copy(next, *current)
+	}
+	if policy == nil {
+		delete(next, provider)
+	} else {
+		next[provider] = policy
+		registerSelectionLatencyPlugin()
+	}
+	m.selectionPolicies.Store(&next)
+}
+
+// selectionPolicyFor returns the policy of the first of providers that has one, falling back to
+// the AnySelectionPolicyProvider policy.
+func (m *Manager) selectionPolicyFor(providers ...string) (SelectionPolicy, string) {
+	if m == nil {
+		return nil, ""
+	}
+	policies := m.selectionPolicies.Load()
+	if policies == nil || len(*policies) == 0 {
+		return nil, ""
+	}
+	for _, provider := range providers {
+		provider = strings.ToLower(strings.TrimSpace(provider))
+		if policy := (*policies)[provider]; policy != nil {
+			return policy, provider
+		}
+	}
+	return (*policies)[AnySelectionPolicyProvider], AnySelectionPolicyProvider
+}
+
+func (m *Manager) hasSelectionPolicy(providers ...string) bool {
+	policy, _ := m.selectionPolicyFor(providers...)
+	return policy != nil
+}
+
+// pickViaSelectionPolicy asks the selection policy of providers to choose among candidates. It
+// returns nil when no policy applies or the policy named no candidate.
+func (m *Manager) pickViaSelectionPolicy(ctx context.Context, providers []string, model string, opts cliproxyexecutor.Options, candidates []*Auth) *Auth {
+	policy, provider := m.selectionPolicyFor(providers...)
+	if policy == nil || len(candidates) == 0 {
+		return nil
+	}
+	byID := make(map[string]*Auth, len(candidates))
+	req := SelectionRequest{Provider: provider, Model: model, Options: opts, Candidates: make([]SelectionCandidate, 0, len(candidates))}
+	for _, auth := range candidates {
+		if auth == nil {
+			continue
+		}
+		byID[auth.ID] = auth
+		latency, ttft := selectionLatencies.get(auth.ID)
+		req.Candidates = append(req.Candidates, SelectionCandidate{
+			Auth:           auth.Clone(),
+			Provider:       executorKeyFromAuth(auth),
+			Priority:       authPriority(auth),
+			InFlight:       m.InFlight(auth.ID),
+			Success:        auth.Success,
+			Failed:         auth.Failed,
+			QuotaExceeded:  auth.Quota.Exceeded,
+			QuotaRecoverAt: auth.Quota.NextRecoverAt,
+			Latency:        latency,
+			TTFT:           ttft,
+		})
+	}
+	for _, id := range policy(ctx, req) {
+		if selected := byID[id]; selected != nil {
+			return selected
+		}
+	}
+	return nil
+}
+
+const (
+	// selectionLatencyWeight is the weight of the newest request in the latency averages.
+	selectionLatencyWeight = 0.2
+	// maxSelectionLatencyEntries bounds the credentials whose latency is tracked.
+	maxSelectionLatencyEntries = 4096
+)
+
+type latencyAverages struct {
+	latency, ttft time.Duration
+}
+
+// selectionLatencyTracker keeps moving latency averages per credential for selection policies.
+type selectionLatencyTracker struct {
+	mu      sync.Mutex
+	entries map[string]*latencyAverages
+}
+
+var (
+	selectionLatencies           = &selectionLatencyTracker{entries: make(map[string]*latencyAverages)}
+	selectionLatencyRegistration sync.Once
+)
+
+// registerSelectionLatencyPlugin starts tracking latencies once the first policy is set.
+func registerSelectionLatencyPlugin() {
+	selectionLatencyRegistration.Do(func() {
+		coreusage.RegisterNamedPlugin("auth-selection-latency", selectionLatencies)
+	})
+}
+
+func (t *selectionLatencyTracker) HandleUsage(_ context.Context, record coreusage.Record) {
+	if record.AuthID == "" || record.Failed || record.Latency <= 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	entry, ok := t.entries[record.AuthID]
+	if !ok {
+		if len(t.entries) >= maxSelectionLatencyEntries {
+			return
+		}
+		t.entries[record.AuthID] = &latencyAverages{latency: record.Latency, ttft: record.TTFT}
+		return
+	}
+	entry.latency = movingAverage(entry.latency, record.Latency)
+	if record.TTFT > 0 {
+		entry.ttft = movingAverage(entry.ttft, record.TTFT)
+	}
+}
+
+func (t *selectionLatencyTracker) get(authID string) (time.Duration, time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if entry, ok := t.entries[authID]; ok {
+		return entry.latency, entry.ttft
+	}
+	return 0, 0
+}
+
+func movingAverage(average, sample time.Duration) time.Duration {
+	if average <= 0 {
+		return sample
+	}
+	return time.Duration(float64(average)*(1-selectionLatencyWeight) + float64(sample)*selectionLatencyWeight)
+}
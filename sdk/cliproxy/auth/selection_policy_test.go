@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	coreusage "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/usage"
+)
+
+func TestManager_SelectionPolicyOrdersCandidates(t *testing.T) {
+	manager := NewManager(nil, &RoundRobinSelector{}, nil)
+	manager.executors["claude"] = schedulerTestExecutor{}
+	manager.executors["gemini"] = schedulerTestExecutor{}
+	for _, auth := range []*Auth{{ID: "claude-a", Provider: "claude"}, {ID: "claude-b", Provider: "claude"}, {ID: "gemini-a", Provider: "gemini"}} {
+		if _, errRegister := manager.Register(context.Background(), auth); errRegister != nil {
+			t.Fatalf("Register(%s) error = %v", auth.ID, errRegister)
+		}
+	}
+	selectionLatencies.HandleUsage(context.Background(), coreusage.Record{AuthID: "claude-b", Latency: 40 * time.Millisecond})
+
+	var seen SelectionRequest
+	manager.SetSelectionPolicy("Claude", func(_ context.Context, req SelectionRequest) []string {
+		seen = req
+		return []string{"unknown", "claude-b", "claude-a"}
+	})
+	for index := 0; index < 3; index++ {
+		got, _, provider, errPick := manager.pickNextMixed(context.Background(), []string{"claude"}, "", cliproxyexecutor.Options{}, nil)
+		if errPick != nil {
+			t.Fatalf("pickNextMixed() #%d error = %v", index, errPick)
+		}
+		if provider != "claude" || got.ID != "claude-b" {
+			t.Fatalf("pickNextMixed() #%d = %s/%s, want claude/claude-b", index, provider, got.ID)
+		}
+	}
+	if seen.Provider != "claude" || len(seen.Candidates) != 2 {
+		t.Fatalf("policy request = %+v, want the two claude candidates", seen)
+	}
+	for _, candidate := range seen.Candidates {
+		if candidate.Auth.ID == "claude-b" && candidate.Latency != 40*time.Millisecond {
+			t.Fatalf("claude-b latency = %v, want 40ms", candidate.Latency)
+		}
+	}
+
+	// Without a policy for gemini the built-in selector still applies.
+	if got, _, _, errPick := manager.pickNextMixed(context.Background(), []string{"gemini"}, "", cliproxyexecutor.Options{}, nil); errPick != nil || got.ID != "gemini-a" {
+		t.Fatalf("gemini pick = %v, %v; want gemini-a", got, errPick)
+	}
+
+	// A policy naming no candidate defers to the built-in selector.
+	manager.SetSelectionPolicy(AnySelectionPolicyProvider, func(context.Context, SelectionRequest) []string { return nil })
+	manager.SetSelectionPolicy("claude", nil)
+	picked := make(map[string]bool)
+	for index := 0; index < 2; index++ {
+		got, _, _, errPick := manager.pickNextMixed(context.Background(), []string{"claude"}, "", cliproxyexecutor.Options{}, nil)
+		if errPick != nil {
+			t.Fatalf("fallback pick #%d error = %v", index, errPick)
+		}
+		picked[got.ID] = true
+	}
+	if len(picked) != 2 {
+		t.Fatalf("fallback picks = %v, want round-robin over both claude auths", picked)
+	}
+}
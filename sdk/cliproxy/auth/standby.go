@@ -0,0 +1,87 @@
+package auth
+
+import (
+	"strconv"
+	"strings"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// standbyKey is the attribute or metadata key marking a credential as warm standby.
+const standbyKey = "standby"
+
+// authStandby reports whether auth is a standby credential, kept out of rotation while its
+// provider has enough active credentials.
+func authStandby(auth *Auth) bool {
+	if auth == nil {
+		return false
+	}
+	if raw := strings.TrimSpace(auth.Attributes[standbyKey]); raw != "" {
+		parsed, errParse := strconv.ParseBool(raw)
+		return errParse == nil && parsed
+	}
+	switch v := auth.Metadata[standbyKey].(type) {
+	case bool:
+		return v
+	case string:
+		parsed, errParse := strconv.ParseBool(strings.TrimSpace(v))
+		return errParse == nil && parsed
+	}
+	return false
+}
+
+// standbyThreshold returns how many available active credentials a provider needs for its
+// standby credentials to stay out of rotation.
+func (m *Manager) standbyThreshold() int {
+	cfg, _ := m.runtimeConfig.Load().(*internalconfig.Config)
+	if cfg == nil || cfg.Routing.StandbyThreshold <= 0 {
+		return 1
+	}
+	return cfg.Routing.StandbyThreshold
+}
+
+// idleStandbyAuths returns the standby credentials of every provider in pool that still has at
+// least standbyThreshold available active credentials. Providers below the threshold keep their
+// standby credentials in rotation until they recover.
+func (m *Manager) idleStandbyAuths(pool []routingPoolEntry) map[string]struct{} {
+	activeReady := make(map[string]int)
+	standby := make(map[string][]string)
+	for _, entry := range pool {
+		switch {
+		case entry.standby:
+			standby[entry.provider] = append(standby[entry.provider], entry.id)
+		case entry.available:
+			activeReady[entry.provider]++
+		}
+	}
+	excluded := make(map[string]struct{})
+	threshold := m.standbyThreshold()
+	for provider, ids := range standby {
+		engaged := activeReady[provider] < threshold
+		m.noteStandbyState(provider, engaged, activeReady[provider])
+		if engaged {
+			continue
+		}
+		for _, id := range ids {
+			excluded[id] = struct{}{}
+		}
+	}
+	return excluded
+}
+
+// noteStandbyState logs when a provider's standby credentials join or leave the pool.
+func (m *Manager) noteStandbyState(provider string, engaged bool, activeReady int) {
+	wasEngaged := false
+	if previous, loaded := m.standbyEngaged.Swap(provider, engaged); loaded {
+		wasEngaged, _ = previous.(bool)
+	}
+	if wasEngaged == engaged {
+		return
+	}
+	if engaged {
+		log.Infof("standby credentials for %s activated: %d active credentials available", provider, activeReady)
+		return
+	}
+	log.Infof("standby credentials for %s returned to standby: %d active credentials available", provider, activeReady)
+}
@@ -0,0 +1,54 @@
+package auth
+
+import (
+	"context"
+	"testing"
+
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+)
+
+func TestPickNextMixedWithinLimits_EngagesStandbyBelowThreshold(t *testing.T) {
+	manager := NewManager(nil, &FillFirstSelector{}, nil)
+	manager.SetConfig(&internalconfig.Config{Routing: internalconfig.RoutingConfig{StandbyThreshold: 2}})
+	manager.executors["claude"] = schedulerTestExecutor{}
+	for _, auth := range []*Auth{
+		// Standby IDs sort first, so fill-first would pick them whenever they are in the pool.
+		{ID: "claude-0-standby", Provider: "claude", Attributes: map[string]string{standbyKey: "true"}},
+		{ID: "claude-1-standby", Provider: "claude", Metadata: map[string]any{standbyKey: true}},
+		{ID: "claude-a", Provider: "claude"},
+		{ID: "claude-b", Provider: "claude"},
+	} {
+		if _, errRegister := manager.Register(context.Background(), auth); errRegister != nil {
+			t.Fatalf("Register(%s) error = %v", auth.ID, errRegister)
+		}
+	}
+	pick := func(tried map[string]struct{}) string {
+		t.Helper()
+		auth, _, _, _, errPick := manager.pickNextMixedWithinLimits(context.Background(), []string{"claude"}, "", cliproxyexecutor.Options{}, tried)
+		if errPick != nil {
+			t.Fatalf("pick error = %v", errPick)
+		}
+		return auth.ID
+	}
+
+	if got := pick(nil); got != "claude-a" {
+		t.Fatalf("pick with a full active pool = %s, want claude-a", got)
+	}
+	tried := map[string]struct{}{"claude-a": {}}
+	if got := pick(tried); got != "claude-0-standby" {
+		t.Fatalf("pick below the threshold = %s, want claude-0-standby", got)
+	}
+	if len(tried) != 1 {
+		t.Fatalf("tried was modified: %v", tried)
+	}
+	if got := pick(nil); got != "claude-a" {
+		t.Fatalf("pick after the pool recovered = %s, want claude-a", got)
+	}
+
+	pinned := cliproxyexecutor.Options{Metadata: map[string]any{cliproxyexecutor.PinnedAuthMetadataKey: "claude-1-standby"}}
+	auth, _, _, _, errPick := manager.pickNextMixedWithinLimits(context.Background(), []string{"claude"}, "", pinned, nil)
+	if errPick != nil || auth.ID != "claude-1-standby" {
+		t.Fatalf("pinned standby pick = %v, %v; want claude-1-standby", auth, errPick)
+	}
+}
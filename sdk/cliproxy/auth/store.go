@@ -1,6 +1,10 @@
 package auth
 
-import "context"
+import (
+	"context"
+	"errors"
+	"math"
+)
 
 // Store abstracts persistence of Auth state across restarts.
 type Store interface {
@@ -11,3 +15,51 @@ type Store interface {
 	// Delete removes the auth record identified by id.
 	Delete(ctx context.Context, id string) error
 }
+
+// TokenStore is the pluggable persistence backend for credential tokens. The file store keeps
+// tokens as JSON files under the auth directory; the Git, Postgres, object storage, Vault and
+// AWS Secrets Manager stores in internal/store persist them remotely. Token refreshes reach the
+// selected backend through Manager persistence, so any Store implementation qualifies.
+type TokenStore = Store
+
+// ErrStoreConflict is returned by Store.Save when the backend already holds a newer revision of
+// the record with a fresher token, typically written by another process refreshing the same
+// credential. The stored copy is kept and reaches the manager through the normal reload path.
+var ErrStoreConflict = errors.New("auth record was updated concurrently by another writer")
+
+// MetadataRevisionKey is the metadata key holding the monotonically increasing write revision of
+// a persisted auth record. Token storages flatten metadata into their JSON, so every auth file
+// carries it.
+const MetadataRevisionKey = "revision"
+
+// MetadataRevision returns the write revision recorded in metadata, or 0 when absent.
+func MetadataRevision(metadata map[string]any) int64 {
+	if metadata == nil {
+		return 0
+	}
+	switch v := metadata[MetadataRevisionKey].(type) {
+	case int64:
+		return v
+	case int:
+		return int64(v)
+	case float64:
+		if v > 0 && v < math.MaxInt64 {
+			return int64(v)
+		}
+	}
+	return 0
+}
+
+// PreferStoredMetadata reports whether a stored record must win over an incoming write after a
+// concurrent modification was detected: the stored copy wins when its token expires later.
+func PreferStoredMetadata(stored, incoming map[string]any) bool {
+	storedExpiry, okStored := expirationFromMap(stored)
+	if !okStored {
+		return false
+	}
+	incomingExpiry, okIncoming := expirationFromMap(incoming)
+	if !okIncoming {
+		return true
+	}
+	return storedExpiry.After(incomingExpiry)
+}
@@ -0,0 +1,74 @@
+package cliproxy
+
+import (
+	"context"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+// authPrefetchTimeout bounds the background credential refresh and model fetch of a new auth.
+const authPrefetchTimeout = 30 * time.Second
+
+// startAuthPrefetch prefetches every auth registered from now on, whether it arrives through
+// the watcher or the management API. Auths loaded at startup are already covered by the
+// initial model registration.
+func (s *Service) startAuthPrefetch() {
+	if s == nil || s.coreManager == nil {
+		return
+	}
+	s.coreManager.SetAuthRegisteredObserver(func(_ context.Context, auth *coreauth.Auth) {
+		s.prefetchAuth(auth)
+	})
+}
+
+func (s *Service) shutdownAuthPrefetch() {
+	if s == nil || s.coreManager == nil {
+		return
+	}
+	s.coreManager.SetAuthRegisteredObserver(nil)
+}
+
+// prefetchAuth warms a newly registered auth in the background so the first request routed
+// to it does not pay for the credential refresh or the upstream model list fetch.
+func (s *Service) prefetchAuth(auth *coreauth.Auth) {
+	if s == nil || s.coreManager == nil || auth == nil || auth.ID == "" {
+		return
+	}
+	id := auth.ID
+	if _, running := s.authPrefetches.LoadOrStore(id, struct{}{}); running {
+		return
+	}
+	go func() {
+		defer s.authPrefetches.Delete(id)
+		ctx, cancel := context.WithTimeout(context.Background(), authPrefetchTimeout)
+		defer cancel()
+		s.runAuthPrefetch(ctx, id)
+	}()
+}
+
+func (s *Service) runAuthPrefetch(ctx context.Context, id string) {
+	start := time.Now()
+	// Auths registered through the management API have not been bound to an executor yet.
+	if current, ok := s.latestAuthForModelRegistration(id); ok {
+		s.ensureExecutorsForAuth(current)
+	}
+	refreshed, errWarm := s.coreManager.WarmAuth(ctx, id)
+	if errWarm != nil {
+		log.Debugf("auth prefetch: credential refresh for %s failed: %v", id, errWarm)
+	}
+	// Registration that ran with stale credentials may have fetched no models; retry it with
+	// the refreshed token. Otherwise only fill in auths nothing registered models for yet.
+	if !refreshed && len(registry.GetGlobalRegistry().GetModelsForClient(id)) > 0 {
+		return
+	}
+	latest, ok := s.latestAuthForModelRegistration(id)
+	if !ok || latest.Disabled {
+		return
+	}
+	if s.refreshModelRegistrationForAuth(latest) {
+		log.Debugf("auth prefetch: %s ready with %d models in %s", id, len(registry.GetGlobalRegistry().GetModelsForClient(id)), time.Since(start).Round(time.Millisecond))
+	}
+}
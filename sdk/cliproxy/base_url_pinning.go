@@ -0,0 +1,20 @@
+package cliproxy
+
+import (
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/baseurlpin"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func (s *Service) applyBaseURLPinningConfig(cfg *config.Config) {
+	if s == nil || cfg == nil {
+		return
+	}
+	baseurlpin.Default.Apply(cfg.BaseURLPinning)
+	if s.coreManager != nil {
+		s.coreManager.SetBaseURLPinner(baseurlpin.Default)
+	}
+}
+
+func (s *Service) shutdownBaseURLPinning() {
+	baseurlpin.Default.Stop()
+}
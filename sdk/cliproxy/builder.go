@@ -56,6 +56,9 @@ type Builder struct {
 	// postAuthHook is called after auth record creation and before persistence.
 	postAuthHook coreauth.PostAuthHook
 
+	// selectionPolicies replace the built-in credential selection per provider.
+	selectionPolicies map[string]coreauth.SelectionPolicy
+
 	// serverOptions contains additional server configuration options.
 	serverOptions []api.ServerOption
 }
@@ -179,6 +182,19 @@ func (b *Builder) WithPostAuthHook(hook coreauth.PostAuthHook) *Builder {
 	return b
 }
 
+// WithSelectionPolicy replaces the built-in credential selection for provider with policy.
+// Use coreauth.AnySelectionPolicyProvider to apply it to every provider without its own policy.
+func (b *Builder) WithSelectionPolicy(provider string, policy coreauth.SelectionPolicy) *Builder {
+	if policy == nil {
+		return b
+	}
+	if b.selectionPolicies == nil {
+		b.selectionPolicies = make(map[string]coreauth.SelectionPolicy)
+	}
+	b.selectionPolicies[provider] = policy
+	return b
+}
+
 // Build validates inputs, applies defaults, and returns a ready-to-run service.
 func (b *Builder) Build() (*Service, error) {
 	if b.cfg == nil {
@@ -269,6 +285,9 @@ func (b *Builder) Build() (*Service, error) {
 	if pluginHost != nil {
 		coreManager.SetPluginScheduler(pluginHost)
 	}
+	for provider, policy := range b.selectionPolicies {
+		coreManager.SetSelectionPolicy(provider, policy)
+	}
 
 	service := &Service{
 		cfg:            b.cfg,
@@ -23,6 +23,9 @@ const RequestPathMetadataKey = "request_path"
 // DisallowFreeAuthMetadataKey instructs auth selection to skip known free-tier credentials.
 const DisallowFreeAuthMetadataKey = "disallow_free_auth"
 
+// RequiredResidencyMetadataKey restricts auth selection to credentials of a data-residency region.
+const RequiredResidencyMetadataKey = "required_residency"
+
 // AuthSelectionModelMetadataKey overrides the model used only for auth selection.
 const AuthSelectionModelMetadataKey = "auth_selection_model"
 
@@ -32,6 +35,9 @@ const ReasoningEffortMetadataKey = "reasoning_effort"
 // ServiceTierMetadataKey stores the client-requested service tier for usage logs.
 const ServiceTierMetadataKey = "service_tier"
 
+// RequestTagsMetadataKey stores the client-supplied request tags (map[string]string) for usage logs.
+const RequestTagsMetadataKey = "request_tags"
+
 // ManagedProviderTransportMetadataKey forces the backend transport used by managed
 // providers. Supported values are provider-specific but include "anthropic" and
 // "openai" for Claude Messages and OpenAI Chat Completions compatible transports.
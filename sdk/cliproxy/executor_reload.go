@@ -0,0 +1,141 @@
+package cliproxy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/constant"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// bindExecutor registers the executor built by build under providerKey. When reuseUnchanged is
+// set and the bound executor was built from an identical config section, the existing instance
+// is kept so config reloads do not drop its connections and sessions. Replaced executors keep
+// serving their in-flight requests; the manager releases their sessions once those finish.
+func (s *Service) bindExecutor(providerKey string, reuseUnchanged bool, build func() coreauth.ProviderExecutor) {
+	providerKey = strings.ToLower(strings.TrimSpace(providerKey))
+	s.cfgMu.RLock()
+	fingerprint := executorConfigFingerprint(s.cfg, providerKey)
+	s.cfgMu.RUnlock()
+	if reuseUnchanged {
+		if existing, ok := s.coreManager.Executor(providerKey); ok && existing != nil {
+			if previous, okPrevious := s.executorFingerprints.Load(providerKey); okPrevious && previous == fingerprint {
+				return
+			}
+			log.Debugf("config for %s executor changed, swapping instance", providerKey)
+		}
+	}
+	exec := build()
+	if exec == nil {
+		return
+	}
+	s.coreManager.RegisterExecutor(exec)
+	s.executorFingerprints.Store(providerKey, fingerprint)
+}
+
+// executorConfigFingerprint hashes the configuration an executor for providerKey is built from:
+// the provider's own section plus every setting not owned by another provider or by routing,
+// auth and service plumbing that executors never read.
+func executorConfigFingerprint(cfg *config.Config, providerKey string) string {
+	if cfg == nil {
+		return ""
+	}
+	shared := *cfg
+	shared.APIKeys = nil
+	shared.APIKeyModels = nil
+	shared.APIKeyMetadata = nil
+	shared.ManagedProviders = nil
+	shared.Home = config.HomeConfig{}
+	shared.RemoteManagement = config.RemoteManagement{}
+	shared.AuthDir = ""
+	shared.TokenStore = config.TokenStoreConfig{}
+	shared.Pprof = config.PprofConfig{}
+	shared.Metrics = config.MetricsConfig{}
+	shared.Warmup = config.WarmupConfig{}
+	shared.ScheduledJobs = nil
+	shared.UsageSinks = nil
+	shared.UsageReport = config.UsageReportConfig{}
+	shared.ProviderStatus = config.ProviderStatusConfig{}
+	shared.InboundRateLimit = config.InboundRateLimitConfig{}
+	shared.RequestRetry = 0
+	shared.MaxRetryCredentials = 0
+	shared.MaxRetryInterval = 0
+	shared.QuotaExceeded = config.QuotaExceeded{}
+	shared.Routing = config.RoutingConfig{}
+	shared.OAuthModelAlias = nil
+	shared.OAuthExcludedModels = nil
+	shared.GeminiKey = nil
+	shared.InteractionsKey = nil
+	shared.VertexCompatAPIKey = nil
+	shared.ClaudeKey = nil
+	shared.ClaudeHeaderDefaults = config.ClaudeHeaderDefaults{}
+	shared.DisableClaudeCloakMode = false
+	shared.CodexKey = nil
+	shared.Codex = config.CodexConfig{}
+	shared.CodexHeaderDefaults = config.CodexHeaderDefaults{}
+	shared.CopilotKey = nil
+	shared.GrokKey = nil
+	shared.Grok = config.GrokConfig{}
+	shared.CursorKey = nil
+	shared.KiroKey = nil
+	shared.KiroPreferredEndpoint = ""
+	shared.Chutes = config.ChutesConfig{}
+	shared.OpenAICompatibility = nil
+	shared.AntigravitySignatureCacheEnabled = nil
+	shared.AntigravitySignatureBypassStrict = nil
+
+	sections := []any{shared, executorConfigSection(cfg, providerKey)}
+	sum := sha256.New()
+	for _, section := range sections {
+		raw, errMarshal := yaml.Marshal(section)
+		if errMarshal != nil {
+			// An unhashable config always counts as changed.
+			return ""
+		}
+		sum.Write(raw)
+		sum.Write([]byte{0})
+	}
+	return hex.EncodeToString(sum.Sum(nil))
+}
+
+// executorConfigSection returns the provider-owned config read by the executor for providerKey.
+func executorConfigSection(cfg *config.Config, providerKey string) any {
+	switch providerKey {
+	case constant.Gemini:
+		return cfg.GeminiKey
+	case constant.GeminiInteractions:
+		return cfg.InteractionsKey
+	case "vertex":
+		return cfg.VertexCompatAPIKey
+	case "claude":
+		return []any{cfg.ClaudeKey, cfg.ClaudeHeaderDefaults, cfg.DisableClaudeCloakMode}
+	case "codex":
+		return []any{cfg.CodexKey, cfg.Codex, cfg.CodexHeaderDefaults}
+	case "xai":
+		return []any{cfg.GrokKey, cfg.Grok}
+	case "cursor":
+		return cfg.CursorKey
+	case "chutes":
+		return cfg.Chutes
+	case "antigravity":
+		return []any{cfg.AntigravitySignatureCacheEnabled, cfg.AntigravitySignatureBypassStrict}
+	}
+	for _, managed := range config.NormalizeManagedProviders(cfg.ManagedProviders) {
+		if config.ManagedProviderName(managed) == providerKey {
+			return managed
+		}
+	}
+	for _, compat := range cfg.OpenAICompatibility {
+		if strings.EqualFold(strings.TrimSpace(compat.Name), providerKey) {
+			return compat
+		}
+	}
+	// Unknown providers may read any section, so the whole config is theirs.
+	return []any{cfg.GeminiKey, cfg.InteractionsKey, cfg.VertexCompatAPIKey, cfg.ClaudeKey, cfg.CodexKey,
+		cfg.CopilotKey, cfg.GrokKey, cfg.Grok, cfg.CursorKey, cfg.KiroKey, cfg.KiroPreferredEndpoint,
+		cfg.Chutes, cfg.OpenAICompatibility, cfg.ManagedProviders}
+}
@@ -0,0 +1,56 @@
+package cliproxy
+
+import (
+	"testing"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+)
+
+func TestRegisterExecutorsForAuthsWithReuse_SwapsOnlyChangedProviders(t *testing.T) {
+	service := &Service{
+		cfg: &config.Config{
+			ClaudeKey: []config.ClaudeKey{{APIKey: "claude-a"}},
+			CodexKey:  []config.CodexKey{{APIKey: "codex-a"}},
+		},
+		coreManager: coreauth.NewManager(nil, nil, nil),
+	}
+	auths := []*coreauth.Auth{
+		{ID: "claude-auth", Provider: "claude", Status: coreauth.StatusActive},
+		{ID: "codex-auth", Provider: "codex", Status: coreauth.StatusActive},
+	}
+	service.registerExecutorsForAuths(auths, true)
+	claudeBefore, _ := service.coreManager.Executor("claude")
+	codexBefore, _ := service.coreManager.Executor("codex")
+
+	service.cfg = &config.Config{
+		ClaudeKey: []config.ClaudeKey{{APIKey: "claude-a"}},
+		CodexKey:  []config.CodexKey{{APIKey: "codex-b"}},
+	}
+	service.registerExecutorsForAuthsWithReuse(auths, true, true)
+	claudeAfter, _ := service.coreManager.Executor("claude")
+	codexAfter, _ := service.coreManager.Executor("codex")
+
+	if claudeAfter != claudeBefore {
+		t.Fatal("expected claude executor to survive a reload that left its section unchanged")
+	}
+	if codexAfter == codexBefore {
+		t.Fatal("expected codex executor to be swapped after its section changed")
+	}
+}
+
+func TestExecutorConfigFingerprint_IgnoresOtherProviders(t *testing.T) {
+	base := &config.Config{ClaudeKey: []config.ClaudeKey{{APIKey: "claude-a"}}}
+	otherChanged := &config.Config{
+		ClaudeKey: []config.ClaudeKey{{APIKey: "claude-a"}},
+		CodexKey:  []config.CodexKey{{APIKey: "codex-a"}},
+	}
+	ownChanged := &config.Config{ClaudeKey: []config.ClaudeKey{{APIKey: "claude-b"}}}
+
+	if executorConfigFingerprint(base, "claude") != executorConfigFingerprint(otherChanged, "claude") {
+		t.Fatal("expected claude fingerprint to ignore codex keys")
+	}
+	if executorConfigFingerprint(base, "claude") == executorConfigFingerprint(ownChanged, "claude") {
+		t.Fatal("expected claude fingerprint to change with claude keys")
+	}
+}
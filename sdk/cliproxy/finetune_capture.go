@@ -0,0 +1,17 @@
+package cliproxy
+
+import (
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/finetunecapture"
+)
+
+func (s *Service) applyFinetuneCaptureConfig(cfg *config.Config) {
+	if s == nil || cfg == nil {
+		return
+	}
+	finetunecapture.Apply(cfg.FinetuneCapture)
+}
+
+func (s *Service) shutdownFinetuneCapture() {
+	finetunecapture.Apply(config.FinetuneCaptureConfig{})
+}
@@ -0,0 +1,40 @@
+package cliproxy
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+	"github.com/router-for-me/CLIProxyAPI/v7/sdk/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// logModelCollisions logs the model ID collision report after model registration, warning about
+// every model shadowed by a routing prefix. Unchanged reports are not logged again.
+func (s *Service) logModelCollisions() {
+	s.cfgMu.RLock()
+	cfg := s.cfg
+	s.cfgMu.RUnlock()
+	var sdkCfg *config.SDKConfig
+	if cfg != nil {
+		sdkCfg = &cfg.SDKConfig
+	}
+
+	report := registry.GetGlobalRegistry().CollisionReport(config.ModelRoutingPrefixes(sdkCfg))
+	warnings := report.Warnings()
+	fingerprint := strconv.Itoa(report.Shared) + "\n" + strings.Join(warnings, "\n")
+
+	s.collisionMu.Lock()
+	changed := fingerprint != s.collisionFingerprint
+	s.collisionFingerprint = fingerprint
+	s.collisionMu.Unlock()
+	if !changed {
+		return
+	}
+
+	log.Infof("model registry: %d model IDs, %d shared by several providers, %d shadowed by routing prefixes",
+		len(report.Models), report.Shared, report.Shadowed)
+	for _, warning := range warnings {
+		log.Warn(warning)
+	}
+}
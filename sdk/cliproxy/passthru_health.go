@@ -0,0 +1,328 @@
+package cliproxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/runtime/executor/helps"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// passthruHealthTick is how often the checker looks for routes due for a probe.
+	passthruHealthTick            = 5 * time.Second
+	passthruHealthDefaultInterval = 30 * time.Second
+	passthruHealthDefaultTimeout  = 5 * time.Second
+	passthruHealthMinInterval     = 5 * time.Second
+	// passthruHealthStatusPrefix marks model states set by the checker, so recovery only
+	// clears blocks it placed itself.
+	passthruHealthStatusPrefix = "health check failed"
+)
+
+// passthruHealthChecker probes passthru routes that configure a health check and takes
+// failing routes out of rotation, so requests fail over to the next route sharing the
+// routing name.
+type passthruHealthChecker struct {
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	states map[string]*passthruHealthState
+}
+
+// passthruHealthState tracks the probes of one passthru auth.
+type passthruHealthState struct {
+	nextProbe time.Time
+	failures  int
+	probing   bool
+}
+
+type passthruHealthSettings struct {
+	path      string
+	interval  time.Duration
+	timeout   time.Duration
+	threshold int
+}
+
+// passthruHealthEnabled reports whether any passthru route in cfg configures a health check.
+func passthruHealthEnabled(cfg *config.Config) bool {
+	if cfg == nil {
+		return false
+	}
+	for _, route := range cfg.Passthru {
+		if route.HealthCheck != nil && strings.TrimSpace(route.HealthCheck.Path) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// passthruHealthSettingsFromAuth parses the health_check attribute written by the synthesizer.
+func passthruHealthSettingsFromAuth(auth *coreauth.Auth) (passthruHealthSettings, bool) {
+	if auth == nil || auth.Attributes == nil {
+		return passthruHealthSettings{}, false
+	}
+	raw := strings.TrimSpace(auth.Attributes["health_check"])
+	if raw == "" {
+		return passthruHealthSettings{}, false
+	}
+	var check config.PassthruHealthCheck
+	if errUnmarshal := json.Unmarshal([]byte(raw), &check); errUnmarshal != nil {
+		log.Warnf("passthru health: invalid health check for auth %s: %v", auth.ID, errUnmarshal)
+		return passthruHealthSettings{}, false
+	}
+	settings := passthruHealthSettings{
+		path:      strings.TrimSpace(check.Path),
+		interval:  passthruHealthDuration(check.Interval, passthruHealthDefaultInterval),
+		timeout:   passthruHealthDuration(check.Timeout, passthruHealthDefaultTimeout),
+		threshold: max(check.FailureThreshold, 1),
+	}
+	settings.interval = max(settings.interval, passthruHealthMinInterval)
+	return settings, settings.path != ""
+}
+
+func passthruHealthDuration(raw string, fallback time.Duration) time.Duration {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return fallback
+	}
+	parsed, errParse := time.ParseDuration(raw)
+	if errParse != nil || parsed <= 0 {
+		log.Warnf("passthru health: invalid duration %q; using %s", raw, fallback)
+		return fallback
+	}
+	return parsed
+}
+
+func (s *Service) applyPassthruHealthConfig(cfg *config.Config) {
+	if s == nil || cfg == nil || s.coreManager == nil {
+		return
+	}
+	if s.passthruHealth == nil {
+		s.passthruHealth = &passthruHealthChecker{}
+	}
+	s.passthruHealth.apply(passthruHealthEnabled(cfg), s.coreManager, s.probePassthruRoute)
+}
+
+func (s *Service) shutdownPassthruHealth() {
+	if s == nil || s.passthruHealth == nil {
+		return
+	}
+	s.passthruHealth.apply(false, nil, nil)
+}
+
+// apply starts the probe loop when enabled and stops it otherwise.
+func (c *passthruHealthChecker) apply(enabled bool, manager *coreauth.Manager, probe func(context.Context, *coreauth.Auth, passthruHealthSettings) error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	running := c.cancel != nil
+	if running == enabled {
+		return
+	}
+	if running {
+		c.cancel()
+		c.cancel = nil
+		c.states = nil
+		return
+	}
+	if manager == nil || probe == nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	c.cancel = cancel
+	c.states = make(map[string]*passthruHealthState)
+	go c.run(ctx, manager, probe)
+}
+
+func (c *passthruHealthChecker) run(ctx context.Context, manager *coreauth.Manager, probe func(context.Context, *coreauth.Auth, passthruHealthSettings) error) {
+	ticker := time.NewTicker(passthruHealthTick)
+	defer ticker.Stop()
+	for {
+		c.probeDue(ctx, manager, probe, time.Now())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// probeDue starts a probe for every health-checked passthru auth whose interval elapsed.
+func (c *passthruHealthChecker) probeDue(ctx context.Context, manager *coreauth.Manager, probe func(context.Context, *coreauth.Auth, passthruHealthSettings) error, now time.Time) {
+	seen := make(map[string]bool)
+	for _, auth := range manager.List() {
+		settings, ok := passthruHealthSettingsFromAuth(auth)
+		if !ok || auth.Disabled {
+			continue
+		}
+		seen[auth.ID] = true
+		c.mu.Lock()
+		if c.states == nil {
+			c.mu.Unlock()
+			return
+		}
+		state := c.states[auth.ID]
+		if state == nil {
+			state = &passthruHealthState{}
+			c.states[auth.ID] = state
+		}
+		due := !state.probing && !now.Before(state.nextProbe)
+		if due {
+			state.probing = true
+			state.nextProbe = now.Add(settings.interval)
+		}
+		c.mu.Unlock()
+		if due {
+			go c.probeAuth(ctx, manager, probe, auth, settings)
+		}
+	}
+	c.mu.Lock()
+	for id := range c.states {
+		if !seen[id] {
+			delete(c.states, id)
+		}
+	}
+	c.mu.Unlock()
+}
+
+func (c *passthruHealthChecker) probeAuth(ctx context.Context, manager *coreauth.Manager, probe func(context.Context, *coreauth.Auth, passthruHealthSettings) error, auth *coreauth.Auth, settings passthruHealthSettings) {
+	errProbe := probe(ctx, auth, settings)
+	if ctx.Err() != nil {
+		return
+	}
+
+	c.mu.Lock()
+	state := c.states[auth.ID]
+	if state == nil {
+		c.mu.Unlock()
+		return
+	}
+	state.probing = false
+	if errProbe == nil {
+		state.failures = 0
+	} else {
+		state.failures++
+	}
+	failures := state.failures
+	c.mu.Unlock()
+
+	label := strings.TrimSpace(auth.Attributes["base_url"])
+	if errProbe == nil {
+		if markPassthruRouteHealth(ctx, manager, auth.ID, "", time.Time{}) {
+			log.Infof("passthru health: %s recovered", label)
+		}
+		return
+	}
+	log.Debugf("passthru health: %s probe failed (%d/%d): %v", label, failures, settings.threshold, errProbe)
+	if failures < settings.threshold {
+		return
+	}
+	// Block the route until shortly after the next probe, so a stalled checker never
+	// keeps it out of rotation for long.
+	until := time.Now().Add(2*settings.interval + settings.timeout)
+	if markPassthruRouteHealth(ctx, manager, auth.ID, errProbe.Error(), until) {
+		log.Warnf("passthru health: %s marked unhealthy: %v", label, errProbe)
+	}
+}
+
+// markPassthruRouteHealth blocks the routing model of a passthru auth until until, or clears
+// a block placed by the checker when reason is empty. It reports whether the route changed
+// between healthy and unhealthy.
+func markPassthruRouteHealth(ctx context.Context, manager *coreauth.Manager, authID, reason string, until time.Time) bool {
+	current, ok := manager.GetByID(authID)
+	if !ok || current == nil || current.Attributes == nil {
+		return false
+	}
+	model := strings.TrimSpace(current.Attributes["passthru_routing_name"])
+	if model == "" {
+		model = strings.TrimSpace(current.Attributes["passthru_model"])
+	}
+	if model == "" {
+		return false
+	}
+	state := current.ModelStates[model]
+	blocked := state != nil && state.Unavailable && strings.HasPrefix(state.StatusMessage, passthruHealthStatusPrefix)
+	if reason == "" && !blocked {
+		return false
+	}
+
+	updated := current.Clone()
+	if updated.ModelStates == nil {
+		updated.ModelStates = make(map[string]*coreauth.ModelState)
+	}
+	next := &coreauth.ModelState{}
+	if state != nil {
+		copied := *state
+		next = &copied
+	}
+	now := time.Now()
+	next.UpdatedAt = now
+	if reason == "" {
+		next.Status = coreauth.StatusActive
+		next.StatusMessage = ""
+		next.Unavailable = false
+		next.NextRetryAfter = time.Time{}
+	} else {
+		next.Status = coreauth.StatusError
+		next.StatusMessage = passthruHealthStatusPrefix + ": " + reason
+		next.Unavailable = true
+		next.NextRetryAfter = until
+	}
+	updated.ModelStates[model] = next
+	updated.UpdatedAt = now
+	if _, errUpdate := manager.Update(coreauth.WithSkipPersist(ctx), updated); errUpdate != nil {
+		log.Warnf("passthru health: failed to update auth %s: %v", authID, errUpdate)
+		return false
+	}
+	return reason == "" || !blocked
+}
+
+// probePassthruRoute sends the configured health check request to a passthru upstream.
+func (s *Service) probePassthruRoute(ctx context.Context, auth *coreauth.Auth, settings passthruHealthSettings) error {
+	base := strings.TrimRight(strings.TrimSpace(auth.Attributes["base_url"]), "/")
+	if base == "" {
+		return fmt.Errorf("missing base url")
+	}
+	url := base + "/" + strings.TrimLeft(settings.path, "/")
+
+	probeCtx, cancel := context.WithTimeout(ctx, settings.timeout)
+	defer cancel()
+	req, errReq := http.NewRequestWithContext(probeCtx, http.MethodGet, url, nil)
+	if errReq != nil {
+		return errReq
+	}
+	if apiKey := strings.TrimSpace(auth.Attributes["api_key"]); apiKey != "" {
+		if strings.EqualFold(auth.Provider, "claude") {
+			req.Header.Set("x-api-key", apiKey)
+		} else {
+			req.Header.Set("Authorization", "Bearer "+apiKey)
+		}
+	}
+	util.ApplyCustomHeadersFromAttrs(req, auth.Attributes)
+
+	s.cfgMu.RLock()
+	cfg := s.cfg
+	s.cfgMu.RUnlock()
+	client := helps.NewProxyAwareHTTPClient(probeCtx, cfg, auth, settings.timeout)
+	resp, errDo := client.Do(req)
+	if errDo != nil {
+		return errDo
+	}
+	defer func() {
+		if errClose := resp.Body.Close(); errClose != nil {
+			log.Debugf("passthru health: close response body error: %v", errClose)
+		}
+	}()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("GET %s returned status %d", settings.path, resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package cliproxy
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+)
+
+func TestPassthruHealthSettingsFromAuth(t *testing.T) {
+	auth := &coreauth.Auth{ID: "route", Attributes: map[string]string{
+		"health_check": `{"path":"/health","interval":"1s","failure-threshold":0}`,
+	}}
+	settings, ok := passthruHealthSettingsFromAuth(auth)
+	if !ok {
+		t.Fatal("expected health check settings")
+	}
+	if settings.path != "/health" || settings.interval != passthruHealthMinInterval ||
+		settings.timeout != passthruHealthDefaultTimeout || settings.threshold != 1 {
+		t.Fatalf("settings = %+v, want clamped interval, default timeout and threshold 1", settings)
+	}
+
+	if _, ok := passthruHealthSettingsFromAuth(&coreauth.Auth{Attributes: map[string]string{}}); ok {
+		t.Fatal("expected no settings without a health_check attribute")
+	}
+}
+
+func TestPassthruHealthBlocksAndRestoresRoute(t *testing.T) {
+	ctx := context.Background()
+	mgr := coreauth.NewManager(nil, nil, nil)
+	auth := &coreauth.Auth{
+		ID:       "vllm-local",
+		Provider: "openai-compatibility",
+		Attributes: map[string]string{
+			"passthru":              "true",
+			"passthru_routing_name": "qwen",
+			"base_url":              "http://127.0.0.1:8000/v1",
+			"health_check":          `{"path":"/health","failure-threshold":2}`,
+		},
+	}
+	if _, err := mgr.Register(ctx, auth); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	settings, _ := passthruHealthSettingsFromAuth(auth)
+
+	healthy := false
+	probe := func(context.Context, *coreauth.Auth, passthruHealthSettings) error {
+		if healthy {
+			return nil
+		}
+		return errors.New("connection refused")
+	}
+	checker := &passthruHealthChecker{states: map[string]*passthruHealthState{auth.ID: {}}}
+	blocked := func() bool {
+		current, _ := mgr.GetByID(auth.ID)
+		state := current.ModelStates["qwen"]
+		return state != nil && state.Unavailable && strings.HasPrefix(state.StatusMessage, passthruHealthStatusPrefix)
+	}
+
+	checker.probeAuth(ctx, mgr, probe, auth, settings)
+	if blocked() {
+		t.Fatal("route blocked before reaching the failure threshold")
+	}
+	checker.probeAuth(ctx, mgr, probe, auth, settings)
+	if !blocked() {
+		t.Fatal("route not blocked after reaching the failure threshold")
+	}
+	current, _ := mgr.GetByID(auth.ID)
+	if until := current.ModelStates["qwen"].NextRetryAfter; !until.After(time.Now()) {
+		t.Fatalf("NextRetryAfter = %s, want a future time", until)
+	}
+
+	healthy = true
+	checker.probeAuth(ctx, mgr, probe, auth, settings)
+	if blocked() {
+		t.Fatal("route still blocked after a successful probe")
+	}
+}
@@ -0,0 +1,20 @@
+package cliproxy
+
+import (
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/providerstatus"
+)
+
+func (s *Service) applyProviderStatusConfig(cfg *config.Config) {
+	if s == nil || cfg == nil {
+		return
+	}
+	providerstatus.Default.Apply(cfg.ProviderStatus)
+	if s.coreManager != nil {
+		s.coreManager.SetProviderOutageChecker(providerstatus.Default)
+	}
+}
+
+func (s *Service) shutdownProviderStatus() {
+	providerstatus.Default.Stop()
+}
@@ -0,0 +1,86 @@
+package cliproxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/scheduledjobs"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/trafficshaper"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	coreauth "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/auth"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+)
+
+// scheduledJobBackend routes scheduled prompts through the core auth manager.
+type scheduledJobBackend struct {
+	manager *coreauth.Manager
+}
+
+func (s *Service) applyScheduledJobsConfig(cfg *config.Config) {
+	if s == nil || cfg == nil || s.coreManager == nil {
+		return
+	}
+	if s.scheduledJobs == nil {
+		s.scheduledJobs = &scheduledjobs.Scheduler{}
+	}
+	s.scheduledJobs.ApplyShaping(trafficshaper.SettingsFromConfig(cfg.KeepAliveShaping))
+	s.scheduledJobs.Apply(scheduledjobs.JobsFromConfig(cfg), scheduledJobBackend{manager: s.coreManager})
+}
+
+func (s *Service) shutdownScheduledJobs() {
+	if s == nil || s.scheduledJobs == nil {
+		return
+	}
+	s.scheduledJobs.Stop()
+}
+
+func (b scheduledJobBackend) Execute(ctx context.Context, model string, payload []byte, headers http.Header, authID string) ([]byte, error) {
+	providers := util.GetProviderName(model)
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("model %s has no registered provider", model)
+	}
+	meta := map[string]any{cliproxyexecutor.RequestedModelMetadataKey: model}
+	if authID != "" {
+		meta[cliproxyexecutor.PinnedAuthMetadataKey] = authID
+	}
+	source := sdktranslator.FromString("openai")
+	resp, errExec := b.manager.Execute(ctx, providers, cliproxyexecutor.Request{
+		Model:   model,
+		Payload: payload,
+	}, cliproxyexecutor.Options{
+		Headers:         headers.Clone(),
+		OriginalRequest: payload,
+		SourceFormat:    source,
+		ResponseFormat:  source,
+		Metadata:        meta,
+	})
+	if errExec != nil {
+		return nil, errExec
+	}
+	return resp.Payload, nil
+}
+
+func (b scheduledJobBackend) AuthIDs(provider, model string) []string {
+	providers := map[string]bool{}
+	if provider != "" {
+		providers[provider] = true
+	} else {
+		for _, name := range util.GetProviderName(model) {
+			providers[strings.ToLower(name)] = true
+		}
+	}
+	var ids []string
+	for _, auth := range b.manager.List() {
+		if auth == nil || auth.Disabled || !providers[strings.ToLower(auth.Provider)] {
+			continue
+		}
+		ids = append(ids, auth.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
@@ -25,6 +25,7 @@ import (
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/redisqueue"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/runtime/executor"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/scheduledjobs"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/watcher"
 	"github.com/router-for-me/CLIProxyAPI/v7/internal/watcher/diff"
@@ -55,6 +56,11 @@ type Service struct {
 	// configPath is the path to the configuration file.
 	configPath string
 
+	// collisionMu guards collisionFingerprint.
+	collisionMu sync.Mutex
+	// collisionFingerprint identifies the last logged model collision report.
+	collisionFingerprint string
+
 	// tokenProvider handles loading token-based clients.
 	tokenProvider TokenClientProvider
 
@@ -79,6 +85,22 @@ type Service struct {
 	// pprofServer manages the optional pprof HTTP debug server.
 	pprofServer *pprofServer
 
+	// modelWarmer sends configured warm-up requests to cold-starting providers.
+	modelWarmer *modelWarmer
+
+	// authPrefetches tracks auth IDs with a background prefetch in flight.
+	authPrefetches sync.Map
+
+	// executorFingerprints maps provider keys to the config fingerprint their bound executor was
+	// built from (see bindExecutor).
+	executorFingerprints sync.Map
+
+	// passthruHealth probes passthru routes that configure a health check.
+	passthruHealth *passthruHealthChecker
+
+	// scheduledJobs runs the scheduled-jobs config section.
+	scheduledJobs *scheduledjobs.Scheduler
+
 	// serverErr channel for server startup/shutdown errors.
 	serverErr chan error
 
@@ -145,7 +167,9 @@ type executorRegistrationOptions struct {
 	includeBaseline   bool
 	includePlugins    bool
 	forceReplaceAuths bool
-	auths             []*coreauth.Auth
+	// reuseUnchanged keeps forcibly replaced executors whose config section did not change.
+	reuseUnchanged bool
+	auths          []*coreauth.Auth
 }
 
 var registerPluginExecutors = func(host *pluginhost.Host, manager *coreauth.Manager) {
@@ -164,6 +188,16 @@ func (s *Service) RegisterUsagePlugin(plugin usage.Plugin) {
 	usage.RegisterPlugin(plugin)
 }
 
+// RegisterUsageSink registers or replaces a named sink that receives one structured
+// usage.Event per upstream request, e.g. a Kafka or BigQuery forwarder.
+//
+// Parameters:
+//   - name: The sink name; registering the same name again replaces the sink
+//   - sink: The usage sink to register
+func (s *Service) RegisterUsageSink(name string, sink usage.Sink) {
+	usage.RegisterSink(name, sink)
+}
+
 func (s *Service) registerPluginAuthParser() {
 	var parser PluginAuthParser
 	if s != nil && s.pluginHost != nil {
@@ -284,6 +318,7 @@ func (s *Service) runModelRegistrationTasks(ctx context.Context, tasks []modelRe
 	compatCache := s.newOpenAICompatibilityRegistrationCache()
 	s.runModelRegistrationTaskPhase(ctx, configAPIKeyTasks, compatCache)
 	s.runModelRegistrationTaskPhase(ctx, otherTasks, compatCache)
+	s.logModelCollisions()
 }
 
 func (s *Service) runModelRegistrationTaskPhase(ctx context.Context, tasks []modelRegistrationTask, compatCache *openAICompatibilityRegistrationCache) {
@@ -996,7 +1031,7 @@ func (s *Service) registerAvailableExecutors(ctx context.Context, opts executorR
 		s.registerExecutorsForAuths(baselineExecutorAuths(), true)
 	}
 	if len(opts.auths) > 0 {
-		s.registerExecutorsForAuths(opts.auths, opts.forceReplaceAuths)
+		s.registerExecutorsForAuthsWithReuse(opts.auths, opts.forceReplaceAuths, opts.reuseUnchanged)
 	}
 	if opts.includePlugins && s.pluginHost != nil {
 		registerPluginExecutors(s.pluginHost, s.coreManager)
@@ -1031,6 +1066,10 @@ func baselineExecutorAuths() []*coreauth.Auth {
 }
 
 func (s *Service) registerExecutorsForAuths(auths []*coreauth.Auth, forceReplace bool) {
+	s.registerExecutorsForAuthsWithReuse(auths, forceReplace, false)
+}
+
+func (s *Service) registerExecutorsForAuthsWithReuse(auths []*coreauth.Auth, forceReplace, reuseUnchanged bool) {
 	reboundCodex := false
 	for _, auth := range auths {
 		if auth != nil && strings.EqualFold(strings.TrimSpace(auth.Provider), "codex") {
@@ -1039,11 +1078,15 @@ func (s *Service) registerExecutorsForAuths(auths []*coreauth.Auth, forceReplace
 			}
 			reboundCodex = true
 		}
-		s.registerExecutorForAuth(auth, forceReplace)
+		s.registerExecutorForAuthWithReuse(auth, forceReplace, reuseUnchanged)
 	}
 }
 
 func (s *Service) registerExecutorForAuth(a *coreauth.Auth, forceReplace bool) {
+	s.registerExecutorForAuthWithReuse(a, forceReplace, false)
+}
+
+func (s *Service) registerExecutorForAuthWithReuse(a *coreauth.Auth, forceReplace, reuseUnchanged bool) {
 	if s == nil || s.coreManager == nil || a == nil {
 		return
 	}
@@ -1057,7 +1100,7 @@ func (s *Service) registerExecutorForAuth(a *coreauth.Auth, forceReplace bool) {
 				}
 			}
 		}
-		s.coreManager.RegisterExecutor(executor.NewCodexAutoExecutor(s.cfg))
+		s.bindExecutor("codex", reuseUnchanged, func() coreauth.ProviderExecutor { return executor.NewCodexAutoExecutor(s.cfg) })
 		return
 	}
 	// Skip disabled auth entries when (re)binding executors.
@@ -1080,37 +1123,41 @@ func (s *Service) registerExecutorForAuth(a *coreauth.Auth, forceReplace bool) {
 				}
 			}
 		}
-		s.coreManager.RegisterExecutor(executor.NewOpenAICompatExecutor(compatProviderKey, s.cfg))
+		s.bindExecutor(compatProviderKey, reuseUnchanged, func() coreauth.ProviderExecutor {
+			return executor.NewOpenAICompatExecutor(compatProviderKey, s.cfg)
+		})
 		return
 	}
 	providerKey := strings.ToLower(strings.TrimSpace(a.Provider))
 	switch providerKey {
 	case constant.Gemini:
-		s.coreManager.RegisterExecutor(executor.NewGeminiExecutor(s.cfg))
+		s.bindExecutor(providerKey, reuseUnchanged, func() coreauth.ProviderExecutor { return executor.NewGeminiExecutor(s.cfg) })
 	case constant.GeminiInteractions:
-		s.coreManager.RegisterExecutor(executor.NewGeminiInteractionsExecutor(s.cfg))
+		s.bindExecutor(providerKey, reuseUnchanged, func() coreauth.ProviderExecutor { return executor.NewGeminiInteractionsExecutor(s.cfg) })
 	case "vertex":
-		s.coreManager.RegisterExecutor(executor.NewGeminiVertexExecutor(s.cfg))
+		s.bindExecutor(providerKey, reuseUnchanged, func() coreauth.ProviderExecutor { return executor.NewGeminiVertexExecutor(s.cfg) })
 	case "aistudio":
 		if s.wsGateway != nil {
 			s.coreManager.RegisterExecutor(executor.NewAIStudioExecutor(s.cfg, a.ID, s.wsGateway))
 		}
 		return
 	case "antigravity":
-		s.coreManager.RegisterExecutor(executor.NewAntigravityExecutor(s.cfg))
+		s.bindExecutor(providerKey, reuseUnchanged, func() coreauth.ProviderExecutor { return executor.NewAntigravityExecutor(s.cfg) })
 	case "claude":
-		s.coreManager.RegisterExecutor(executor.NewClaudeExecutor(s.cfg))
+		s.bindExecutor(providerKey, reuseUnchanged, func() coreauth.ProviderExecutor { return executor.NewClaudeExecutor(s.cfg) })
 	case "kimi":
-		s.coreManager.RegisterExecutor(executor.NewKimiExecutor(s.cfg))
+		s.bindExecutor(providerKey, reuseUnchanged, func() coreauth.ProviderExecutor { return executor.NewKimiExecutor(s.cfg) })
 	case "chutes":
-		s.coreManager.RegisterExecutor(executor.NewChutesExecutor(s.cfg))
+		s.bindExecutor(providerKey, reuseUnchanged, func() coreauth.ProviderExecutor { return executor.NewChutesExecutor(s.cfg) })
 	case "xai":
-		s.coreManager.RegisterExecutor(executor.NewXAIAutoExecutor(s.cfg))
+		s.bindExecutor(providerKey, reuseUnchanged, func() coreauth.ProviderExecutor { return executor.NewXAIAutoExecutor(s.cfg) })
 	case "cursor":
-		s.coreManager.RegisterExecutor(executor.NewCursorExecutor(s.cfg))
+		s.bindExecutor(providerKey, reuseUnchanged, func() coreauth.ProviderExecutor { return executor.NewCursorExecutor(s.cfg) })
 	default:
 		if s.isManagedProvider(providerKey) {
-			s.coreManager.RegisterExecutor(executor.NewManagedProviderExecutor(providerKey, s.cfg))
+			s.bindExecutor(providerKey, reuseUnchanged, func() coreauth.ProviderExecutor {
+				return executor.NewManagedProviderExecutor(providerKey, s.cfg)
+			})
 			return
 		}
 		if providerKey == "" {
@@ -1129,7 +1176,9 @@ func (s *Service) registerExecutorForAuth(a *coreauth.Auth, forceReplace bool) {
 				}
 			}
 		}
-		s.coreManager.RegisterExecutor(executor.NewOpenAICompatExecutor(providerKey, s.cfg))
+		s.bindExecutor(providerKey, reuseUnchanged, func() coreauth.ProviderExecutor {
+			return executor.NewOpenAICompatExecutor(providerKey, s.cfg)
+		})
 	}
 }
 
@@ -1352,6 +1401,14 @@ func (s *Service) applyConfigUpdateWithAuthSynthesis(newCfg *config.Config, synt
 	s.applyRetryConfig(newCfg)
 	s.configureCooldownStateStore(newCfg)
 	s.applyPprofConfig(newCfg)
+	s.applyWarmupConfig(newCfg)
+	s.applyPassthruHealthConfig(newCfg)
+	s.applyScheduledJobsConfig(newCfg)
+	s.applyUsageSinksConfig(newCfg)
+	s.applyProviderStatusConfig(newCfg)
+	s.applyBaseURLPinningConfig(newCfg)
+	s.applyFinetuneCaptureConfig(newCfg)
+	s.applyVirtualModelsConfig(newCfg)
 	if s.server != nil {
 		s.server.UpdateClients(newCfg)
 	}
@@ -1371,6 +1428,7 @@ func (s *Service) applyConfigUpdateWithAuthSynthesis(newCfg *config.Config, synt
 	s.registerAvailableExecutors(context.Background(), executorRegistrationOptions{
 		includeBaseline:   newCfg.Home.Enabled,
 		forceReplaceAuths: true,
+		reuseUnchanged:    true,
 		auths:             auths,
 	})
 	if synthesizeConfigAuths {
@@ -1806,6 +1864,7 @@ func (s *Service) Run(ctx context.Context) error {
 	}
 
 	s.registerModelRefreshCallback()
+	s.startAuthPrefetch()
 
 	// Prefer core auth manager auto refresh if available.
 	if s.coreManager != nil && !homeEnabled {
@@ -1814,8 +1873,19 @@ func (s *Service) Run(ctx context.Context) error {
 		log.Infof("core auth auto-refresh started (interval=%s)", interval)
 		s.startChutesModelAutoRefresh(context.Background(), interval)
 		s.startManagedProviderModelAutoRefresh(context.Background(), interval)
+		go s.runStartupPreflight(ctx)
 	}
 
+	executor.SetOpenAICompatCapabilitiesHook(s.refreshOpenAICompatCapabilities)
+	s.applyWarmupConfig(s.cfg)
+	s.applyPassthruHealthConfig(s.cfg)
+	s.applyScheduledJobsConfig(s.cfg)
+	s.applyUsageSinksConfig(s.cfg)
+	s.applyProviderStatusConfig(s.cfg)
+	s.applyBaseURLPinningConfig(s.cfg)
+	s.applyFinetuneCaptureConfig(s.cfg)
+	s.applyVirtualModelsConfig(s.cfg)
+
 	select {
 	case <-ctx.Done():
 		log.Debug("service context cancelled, shutting down...")
@@ -1825,6 +1895,22 @@ func (s *Service) Run(ctx context.Context) error {
 	}
 }
 
+// startupPreflightDelay lets initial model registration finish before credentials are checked.
+const startupPreflightDelay = 5 * time.Second
+
+// runStartupPreflight validates every credential once after boot so dead tokens surface in
+// the log and the management report instead of on the first unlucky request.
+func (s *Service) runStartupPreflight(ctx context.Context) {
+	timer := time.NewTimer(startupPreflightDelay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return
+	case <-timer.C:
+	}
+	s.coreManager.Preflight(ctx).LogSummary()
+}
+
 // Shutdown gracefully stops background workers and the HTTP server.
 // It ensures all resources are properly cleaned up and connections are closed.
 // The shutdown is idempotent and can be called multiple times safely.
@@ -1871,6 +1957,14 @@ func (s *Service) Shutdown(ctx context.Context) error {
 			s.managedProviderRefreshCancel()
 			s.managedProviderRefreshCancel = nil
 		}
+		s.shutdownWarmup()
+		s.shutdownAuthPrefetch()
+		s.shutdownPassthruHealth()
+		s.shutdownScheduledJobs()
+		s.shutdownUsageSinks()
+		s.shutdownProviderStatus()
+		s.shutdownBaseURLPinning()
+		s.shutdownFinetuneCapture()
 		if s.coreManager != nil {
 			s.coreManager.StopAutoRefresh()
 		}
@@ -2102,7 +2196,7 @@ func (s *Service) registerModelsForAuthWithCache(ctx context.Context, a *coreaut
 	var models []*ModelInfo
 	switch provider {
 	case constant.Gemini:
-		models = registry.GetGeminiModels()
+		models = registry.WithGeminiBuiltins(registry.GetGeminiModels())
 		if entry := s.resolveConfigGeminiKey(a); entry != nil {
 			if len(entry.Models) > 0 {
 				models = buildGeminiConfigModels(entry)
@@ -2358,6 +2452,20 @@ func (s *Service) registerModelsForAuthWithCache(ctx context.Context, a *coreaut
 // Re-registration is deliberate: registry cooldown/suspension state is treated
 // as part of the previous registration snapshot and is cleared when the auth is
 // rebound to the refreshed model catalog.
+// refreshOpenAICompatCapabilities registers the models of every auth of the named compatibility
+// provider again, so capabilities detected on first use reach the registry.
+func (s *Service) refreshOpenAICompatCapabilities(provider string) {
+	if s == nil || s.coreManager == nil {
+		return
+	}
+	for _, a := range s.coreManager.List() {
+		if a == nil || a.Attributes == nil || !strings.EqualFold(strings.TrimSpace(a.Attributes["compat_name"]), provider) {
+			continue
+		}
+		s.refreshModelRegistrationForAuth(a)
+	}
+}
+
 func (s *Service) refreshModelRegistrationForAuth(current *coreauth.Auth) bool {
 	return s.refreshModelRegistrationForAuthWithCache(current, nil)
 }
@@ -2805,6 +2913,10 @@ func buildOpenAICompatibilityConfigModels(compat *config.OpenAICompatibility) []
 		return nil
 	}
 	now := time.Now().Unix()
+	var detectedParams []string
+	if caps, ok := executor.OpenAICompatCapabilitiesFor(compat.Name); ok {
+		detectedParams = caps.SupportedParameters()
+	}
 	models := make([]*ModelInfo, 0, len(compat.Models))
 	for i := range compat.Models {
 		model := compat.Models[i]
@@ -2834,6 +2946,7 @@ func buildOpenAICompatibilityConfigModels(compat *config.OpenAICompatibility) []
 			DisplayName:               modelID,
 			UserDefined:               false,
 			Thinking:                  thinking,
+			SupportedParameters:       append([]string(nil), detectedParams...),
 			SupportedInputModalities:  inputModalities,
 			SupportedOutputModalities: outputModalities,
 		})
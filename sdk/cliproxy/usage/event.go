@@ -0,0 +1,146 @@
+package usage
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Event is the structured form of a usage record handed to sinks. Missing names are reported
+// as "unknown" and the request-scoped fields fall back to the values stored in the context, so
+// sinks receive the same shape for every upstream request.
+type Event struct {
+	Timestamp       time.Time         `json:"timestamp"`
+	RequestID       string            `json:"request_id,omitempty"`
+	Provider        string            `json:"provider"`
+	ExecutorType    string            `json:"executor_type"`
+	Model           string            `json:"model"`
+	Alias           string            `json:"alias"`
+	AuthID          string            `json:"auth_id,omitempty"`
+	AuthIndex       string            `json:"auth_index,omitempty"`
+	AuthType        string            `json:"auth_type"`
+	Source          string            `json:"source,omitempty"`
+	ReasoningEffort string            `json:"reasoning_effort,omitempty"`
+	ServiceTier     string            `json:"service_tier"`
+	Tags            map[string]string `json:"tags,omitempty"`
+	LatencyMs       int64             `json:"latency_ms"`
+	TTFTMs          int64             `json:"ttft_ms"`
+	Failed          bool              `json:"failed"`
+	StatusCode      int               `json:"status_code,omitempty"`
+	Tokens          EventTokens       `json:"tokens"`
+}
+
+// EventTokens is the token breakdown of an Event.
+type EventTokens struct {
+	Input         int64 `json:"input"`
+	Output        int64 `json:"output"`
+	Reasoning     int64 `json:"reasoning"`
+	Cached        int64 `json:"cached"`
+	CacheRead     int64 `json:"cache_read"`
+	CacheCreation int64 `json:"cache_creation"`
+	Total         int64 `json:"total"`
+	// Estimated mirrors Detail.Estimated: the counts were approximated by the proxy.
+	Estimated bool `json:"estimated,omitempty"`
+}
+
+// NewEvent builds the Event for record. API keys are deliberately left out.
+func NewEvent(ctx context.Context, record Record) Event {
+	timestamp := record.RequestedAt
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	model := orUnknown(record.Model)
+	alias := strings.TrimSpace(record.Alias)
+	if alias == "" {
+		alias = model
+	}
+	reasoningEffort := strings.TrimSpace(record.ReasoningEffort)
+	if reasoningEffort == "" {
+		reasoningEffort = ReasoningEffortFromContext(ctx)
+	}
+	serviceTier := strings.TrimSpace(record.ServiceTier)
+	if serviceTier == "" {
+		serviceTier = ServiceTierFromContext(ctx)
+	}
+	tags := record.Tags
+	if len(tags) == 0 {
+		tags = RequestTagsFromContext(ctx)
+	}
+	tokens := EventTokens{
+		Input:         record.Detail.InputTokens,
+		Output:        record.Detail.OutputTokens,
+		Reasoning:     record.Detail.ReasoningTokens,
+		Cached:        record.Detail.CachedTokens,
+		CacheRead:     record.Detail.CacheReadTokens,
+		CacheCreation: record.Detail.CacheCreationTokens,
+		Total:         record.Detail.TotalTokens,
+		Estimated:     record.Detail.Estimated,
+	}
+	if tokens.Total == 0 {
+		tokens.Total = tokens.Input + tokens.Output + tokens.Reasoning
+	}
+	return Event{
+		Timestamp:       timestamp,
+		RequestID:       strings.TrimSpace(record.RequestID),
+		Provider:        orUnknown(record.Provider),
+		ExecutorType:    orUnknown(record.ExecutorType),
+		Model:           model,
+		Alias:           alias,
+		AuthID:          record.AuthID,
+		AuthIndex:       record.AuthIndex,
+		AuthType:        orUnknown(record.AuthType),
+		Source:          record.Source,
+		ReasoningEffort: reasoningEffort,
+		ServiceTier:     serviceTier,
+		Tags:            tags,
+		LatencyMs:       record.Latency.Milliseconds(),
+		TTFTMs:          record.TTFT.Milliseconds(),
+		Failed:          record.Failed,
+		StatusCode:      record.Fail.StatusCode,
+		Tokens:          tokens,
+	}
+}
+
+func orUnknown(value string) string {
+	if value = strings.TrimSpace(value); value == "" {
+		return "unknown"
+	}
+	return value
+}
+
+// Sink receives one structured Event per upstream request, e.g. to forward usage to StatsD,
+// Kafka, BigQuery or a webhook. Sinks run on the usage dispatcher goroutine and should hand
+// slow work off instead of blocking it.
+type Sink interface {
+	HandleEvent(ctx context.Context, event Event)
+}
+
+// SinkFunc adapts a function to a Sink.
+type SinkFunc func(ctx context.Context, event Event)
+
+// HandleEvent calls f(ctx, event).
+func (f SinkFunc) HandleEvent(ctx context.Context, event Event) { f(ctx, event) }
+
+// SinkPlugin adapts a Sink to a Plugin so it can be registered on a Manager.
+func SinkPlugin(sink Sink) Plugin {
+	if sink == nil {
+		return nil
+	}
+	return sinkPlugin{sink: sink}
+}
+
+type sinkPlugin struct {
+	sink Sink
+}
+
+func (p sinkPlugin) HandleUsage(ctx context.Context, record Record) {
+	p.sink.HandleEvent(ctx, NewEvent(ctx, record))
+}
+
+// RegisterSink registers or replaces a named sink on the default manager.
+func RegisterSink(name string, sink Sink) {
+	if sink == nil {
+		return
+	}
+	RegisterNamedPlugin(name, SinkPlugin(sink))
+}
@@ -0,0 +1,49 @@
+package usage
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNewEventFillsDefaults(t *testing.T) {
+	ctx := WithServiceTier(context.Background(), "flex")
+	event := NewEvent(ctx, Record{
+		Provider:  "claude",
+		Model:     "claude-sonnet",
+		APIKey:    "secret",
+		RequestID: "req-1",
+		Latency:   1500 * time.Millisecond,
+		Detail:    Detail{InputTokens: 10, OutputTokens: 5},
+	})
+	if event.Alias != "claude-sonnet" || event.ExecutorType != "unknown" || event.AuthType != "unknown" {
+		t.Fatalf("unexpected names: %+v", event)
+	}
+	if event.ServiceTier != "flex" || event.RequestID != "req-1" || event.LatencyMs != 1500 {
+		t.Fatalf("unexpected request fields: %+v", event)
+	}
+	if event.Tokens.Total != 15 {
+		t.Fatalf("total tokens = %d, want 15", event.Tokens.Total)
+	}
+	if event.Timestamp.IsZero() {
+		t.Fatal("expected timestamp to default to now")
+	}
+}
+
+func TestManagerUnregisterNamedKeepsOtherPlugins(t *testing.T) {
+	m := NewManager(1)
+	var got []string
+	plugin := func(name string) Plugin {
+		return SinkPlugin(SinkFunc(func(context.Context, Event) { got = append(got, name) }))
+	}
+	m.RegisterNamed("a", plugin("a"))
+	m.RegisterNamed("b", plugin("b"))
+	m.RegisterNamed("c", plugin("c"))
+	m.UnregisterNamed("a")
+	m.RegisterNamed("c", plugin("c2"))
+
+	m.dispatch(queueItem{ctx: context.Background()})
+	if len(got) != 2 || got[0] != "b" || got[1] != "c2" {
+		t.Fatalf("delivered to %v, want [b c2]", got)
+	}
+}
@@ -25,10 +25,15 @@ type Record struct {
 	AuthIndex    string
 	AuthType     string
 	Source       string
+	// RequestID is the proxy request ID the record belongs to, when known.
+	RequestID string
 	// ReasoningEffort stores the translated upstream thinking level for request event logs.
 	ReasoningEffort string
 	// ServiceTier stores the client-requested service tier for request event logs.
 	ServiceTier string
+	// Tags are the client-supplied request tags (X-CLIProxy-Tags header or Responses metadata),
+	// e.g. {"team": "infra"}, for chargeback reporting.
+	Tags        map[string]string
 	RequestedAt time.Time
 	Latency     time.Duration
 	TTFT        time.Duration
@@ -260,6 +265,30 @@ func (m *Manager) RegisterNamed(name string, plugin Plugin) {
 	m.pluginsMu.Unlock()
 }
 
+// UnregisterNamed removes the plugin registered under name, if any.
+func (m *Manager) UnregisterNamed(name string) {
+	if m == nil {
+		return
+	}
+	name = strings.TrimSpace(name)
+	m.pluginsMu.Lock()
+	defer m.pluginsMu.Unlock()
+	index, exists := m.named[name]
+	if !exists {
+		return
+	}
+	delete(m.named, name)
+	if index < 0 || index >= len(m.plugins) {
+		return
+	}
+	m.plugins = append(m.plugins[:index], m.plugins[index+1:]...)
+	for other, otherIndex := range m.named {
+		if otherIndex > index {
+			m.named[other] = otherIndex - 1
+		}
+	}
+}
+
 // Publish enqueues a usage record for processing. If no plugin is registered
 // the record will be discarded downstream.
 func (m *Manager) Publish(ctx context.Context, record Record) {
@@ -343,6 +372,9 @@ func RegisterPlugin(plugin Plugin) { DefaultManager().Register(plugin) }
 // RegisterNamedPlugin registers or replaces a named plugin on the default manager.
 func RegisterNamedPlugin(name string, plugin Plugin) { DefaultManager().RegisterNamed(name, plugin) }
 
+// UnregisterNamedPlugin removes a named plugin from the default manager.
+func UnregisterNamedPlugin(name string) { DefaultManager().UnregisterNamed(name) }
+
 // PublishRecord publishes a record using the default manager.
 func PublishRecord(ctx context.Context, record Record) { DefaultManager().Publish(ctx, record) }
 
@@ -0,0 +1,69 @@
+package usage
+
+import (
+	"context"
+	"maps"
+	"strings"
+)
+
+const (
+	// MaxTags bounds how many tags are kept per request.
+	MaxTags        = 16
+	maxTagKeyLen   = 64
+	maxTagValueLen = 256
+)
+
+type requestTagsContextKey struct{}
+
+// ParseTags parses a comma-separated list of key=value pairs such as "team=infra,job=nightly".
+// Keys are lower-cased; entries without a key are skipped, and later entries override earlier
+// ones. It returns nil when no tag is found.
+func ParseTags(raw string) map[string]string {
+	var tags map[string]string
+	for _, entry := range strings.Split(raw, ",") {
+		key, value, _ := strings.Cut(entry, "=")
+		tags = AddTag(tags, key, value)
+	}
+	return tags
+}
+
+// AddTag normalises key and value and stores them in tags, allocating the map when needed. Empty
+// keys, oversized entries and new keys beyond MaxTags are dropped.
+func AddTag(tags map[string]string, key, value string) map[string]string {
+	key = strings.ToLower(strings.TrimSpace(key))
+	value = strings.TrimSpace(value)
+	if key == "" || len(key) > maxTagKeyLen || len(value) > maxTagValueLen {
+		return tags
+	}
+	if _, exists := tags[key]; !exists && len(tags) >= MaxTags {
+		return tags
+	}
+	if tags == nil {
+		tags = make(map[string]string)
+	}
+	tags[key] = value
+	return tags
+}
+
+// WithRequestTags stores the client-supplied request tags for usage sinks.
+func WithRequestTags(ctx context.Context, tags map[string]string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if len(tags) == 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, requestTagsContextKey{}, maps.Clone(tags))
+}
+
+// RequestTagsFromContext returns a copy of the request tags stored in ctx, or nil.
+func RequestTagsFromContext(ctx context.Context) map[string]string {
+	if ctx == nil {
+		return nil
+	}
+	tags, _ := ctx.Value(requestTagsContextKey{}).(map[string]string)
+	if len(tags) == 0 {
+		return nil
+	}
+	return maps.Clone(tags)
+}
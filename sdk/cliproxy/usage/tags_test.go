@@ -0,0 +1,55 @@
+package usage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	tags := ParseTags(" Team=infra, job = nightly ,=orphan,flag,team=platform," + strings.Repeat("k", 65) + "=long")
+	want := map[string]string{"team": "platform", "job": "nightly", "flag": ""}
+	if len(tags) != len(want) {
+		t.Fatalf("ParseTags() = %v, want %v", tags, want)
+	}
+	for key, value := range want {
+		if tags[key] != value {
+			t.Fatalf("tag %s = %q, want %q", key, tags[key], value)
+		}
+	}
+	if ParseTags("") != nil {
+		t.Fatal("empty header must yield nil tags")
+	}
+}
+
+func TestAddTagCapsCount(t *testing.T) {
+	var tags map[string]string
+	for i := range MaxTags + 4 {
+		tags = AddTag(tags, fmt.Sprintf("k%d", i), "v")
+	}
+	if len(tags) != MaxTags {
+		t.Fatalf("len(tags) = %d, want %d", len(tags), MaxTags)
+	}
+	if tags = AddTag(tags, "k0", "updated"); tags["k0"] != "updated" {
+		t.Fatal("existing keys must still be updatable at the cap")
+	}
+}
+
+func TestRequestTagsContextIsolation(t *testing.T) {
+	source := map[string]string{"team": "infra"}
+	ctx := WithRequestTags(context.Background(), source)
+	source["team"] = "changed"
+
+	got := RequestTagsFromContext(ctx)
+	if got["team"] != "infra" {
+		t.Fatalf("tags = %v, want a copy taken at WithRequestTags", got)
+	}
+	got["team"] = "mutated"
+	if RequestTagsFromContext(ctx)["team"] != "infra" {
+		t.Fatal("RequestTagsFromContext must return a copy")
+	}
+	if RequestTagsFromContext(context.Background()) != nil {
+		t.Fatal("expected nil tags without WithRequestTags")
+	}
+}
@@ -0,0 +1,23 @@
+package cliproxy
+
+import (
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/telemetry"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/usagereport"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/usagesinks"
+)
+
+func (s *Service) applyUsageSinksConfig(cfg *config.Config) {
+	if s == nil || cfg == nil {
+		return
+	}
+	usagesinks.Apply(cfg.UsageSinks)
+	usagereport.Apply(cfg)
+	telemetry.Apply(cfg.Telemetry)
+}
+
+func (s *Service) shutdownUsageSinks() {
+	usagesinks.Apply(nil)
+	usagereport.Stop()
+	telemetry.Stop()
+}
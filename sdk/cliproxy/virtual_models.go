@@ -0,0 +1,43 @@
+package cliproxy
+
+import (
+	"strings"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+)
+
+const (
+	// virtualModelsClientID registers the configured virtual models in the model registry so
+	// they are listed next to provider models.
+	virtualModelsClientID = "virtual-models"
+	virtualModelsProvider = "virtual"
+)
+
+// applyVirtualModelsConfig lists the configured virtual models. Requests for them are resolved to
+// a candidate model by the API handlers before provider selection.
+func (s *Service) applyVirtualModelsConfig(cfg *config.Config) {
+	if s == nil || cfg == nil {
+		return
+	}
+	created := time.Now().Unix()
+	models := make([]*ModelInfo, 0, len(cfg.VirtualModels))
+	for _, virtual := range cfg.VirtualModels {
+		name := strings.TrimSpace(virtual.Name)
+		if name == "" || len(virtual.Candidates) == 0 {
+			continue
+		}
+		models = append(models, &ModelInfo{
+			ID:          name,
+			Object:      "model",
+			Created:     created,
+			OwnedBy:     "cliproxy",
+			Type:        virtualModelsProvider,
+			DisplayName: name,
+			Description: strings.TrimSpace(virtual.Description),
+		})
+	}
+	// Registering no models removes previously listed virtual models.
+	registry.GetGlobalRegistry().RegisterClient(virtualModelsClientID, virtualModelsProvider, models)
+}
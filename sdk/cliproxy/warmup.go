@@ -0,0 +1,176 @@
+package cliproxy
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/util"
+	cliproxyexecutor "github.com/router-for-me/CLIProxyAPI/v7/sdk/cliproxy/executor"
+	sdktranslator "github.com/router-for-me/CLIProxyAPI/v7/sdk/translator"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// warmupStartupDelay gives auth loading and model registration time to settle.
+	warmupStartupDelay = 15 * time.Second
+	warmupMinInterval  = time.Minute
+)
+
+// modelWarmer periodically sends minimal requests to configured models so providers with
+// cold starts are ready before the first real request.
+type modelWarmer struct {
+	mu       sync.Mutex
+	cancel   context.CancelFunc
+	settings warmupSettings
+}
+
+type warmupSettings struct {
+	enabled  bool
+	models   []string
+	interval time.Duration
+}
+
+func (w warmupSettings) equal(other warmupSettings) bool {
+	if w.enabled != other.enabled || w.interval != other.interval || len(w.models) != len(other.models) {
+		return false
+	}
+	for i := range w.models {
+		if w.models[i] != other.models[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// warmupSettingsFromConfig normalizes the warmup section of cfg.
+func warmupSettingsFromConfig(cfg *config.Config) warmupSettings {
+	if cfg == nil || !cfg.Warmup.Enabled {
+		return warmupSettings{}
+	}
+	models := make([]string, 0, len(cfg.Warmup.Models))
+	seen := make(map[string]bool, len(cfg.Warmup.Models))
+	for _, model := range cfg.Warmup.Models {
+		trimmed := strings.TrimSpace(model)
+		if trimmed == "" || seen[trimmed] {
+			continue
+		}
+		seen[trimmed] = true
+		models = append(models, trimmed)
+	}
+	if len(models) == 0 {
+		return warmupSettings{}
+	}
+	var interval time.Duration
+	if raw := strings.TrimSpace(cfg.Warmup.Interval); raw != "" {
+		parsed, errParse := time.ParseDuration(raw)
+		if errParse != nil || parsed <= 0 {
+			log.Warnf("warmup: invalid interval %q; warming up at startup only", raw)
+		} else {
+			interval = max(parsed, warmupMinInterval)
+		}
+	}
+	return warmupSettings{enabled: true, models: models, interval: interval}
+}
+
+func (s *Service) applyWarmupConfig(cfg *config.Config) {
+	if s == nil || cfg == nil || s.coreManager == nil {
+		return
+	}
+	if s.modelWarmer == nil {
+		s.modelWarmer = &modelWarmer{}
+	}
+	s.modelWarmer.apply(warmupSettingsFromConfig(cfg), s.warmupModel)
+}
+
+func (s *Service) shutdownWarmup() {
+	if s == nil || s.modelWarmer == nil {
+		return
+	}
+	s.modelWarmer.apply(warmupSettings{}, nil)
+}
+
+// apply restarts the warm-up loop when the settings changed.
+func (w *modelWarmer) apply(settings warmupSettings, warm func(context.Context, string) error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.cancel != nil && w.settings.equal(settings) {
+		return
+	}
+	if w.cancel != nil {
+		w.cancel()
+		w.cancel = nil
+	}
+	w.settings = settings
+	if !settings.enabled || warm == nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	w.cancel = cancel
+	go runWarmupLoop(ctx, settings, warm)
+}
+
+func runWarmupLoop(ctx context.Context, settings warmupSettings, warm func(context.Context, string) error) {
+	timer := time.NewTimer(warmupStartupDelay)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+		warmModels(ctx, settings.models, warm)
+		if settings.interval <= 0 {
+			return
+		}
+		timer.Reset(settings.interval)
+	}
+}
+
+func warmModels(ctx context.Context, models []string, warm func(context.Context, string) error) {
+	for _, model := range models {
+		if ctx.Err() != nil {
+			return
+		}
+		start := time.Now()
+		if errWarm := warm(ctx, model); errWarm != nil {
+			log.Warnf("warmup: model %s failed after %s: %v", model, time.Since(start).Round(time.Millisecond), errWarm)
+			continue
+		}
+		log.Debugf("warmup: model %s ready in %s", model, time.Since(start).Round(time.Millisecond))
+	}
+}
+
+// warmupModel sends a one-token chat completion for model through the auth manager.
+func (s *Service) warmupModel(ctx context.Context, model string) error {
+	providers := util.GetProviderName(model)
+	if len(providers) == 0 {
+		log.Debugf("warmup: model %s has no registered provider yet; skipping", model)
+		return nil
+	}
+	payload, errMarshal := json.Marshal(map[string]any{
+		"model":      model,
+		"messages":   []map[string]string{{"role": "user", "content": "ping"}},
+		"max_tokens": 1,
+		"stream":     false,
+	})
+	if errMarshal != nil {
+		return errMarshal
+	}
+	source := sdktranslator.FromString("openai")
+	_, errExec := s.coreManager.Execute(ctx, providers, cliproxyexecutor.Request{
+		Model:   model,
+		Payload: payload,
+	}, cliproxyexecutor.Options{
+		OriginalRequest: payload,
+		SourceFormat:    source,
+		ResponseFormat:  source,
+		Metadata: map[string]any{
+			cliproxyexecutor.RequestedModelMetadataKey: model,
+		},
+	})
+	return errExec
+}
@@ -0,0 +1,73 @@
+package cliproxy
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+)
+
+func TestWarmupSettingsFromConfig(t *testing.T) {
+	cfg := &config.Config{Warmup: config.WarmupConfig{
+		Enabled:  true,
+		Models:   []string{" llama3.1:8b ", "", "llama3.1:8b", "deepseek-ai/DeepSeek-V3"},
+		Interval: "10s",
+	}}
+	settings := warmupSettingsFromConfig(cfg)
+	if !settings.enabled {
+		t.Fatal("expected warmup to be enabled")
+	}
+	if len(settings.models) != 2 || settings.models[0] != "llama3.1:8b" || settings.models[1] != "deepseek-ai/DeepSeek-V3" {
+		t.Fatalf("models = %v, want deduplicated trimmed list", settings.models)
+	}
+	if settings.interval != warmupMinInterval {
+		t.Fatalf("interval = %s, want minimum %s", settings.interval, warmupMinInterval)
+	}
+
+	cfg.Warmup.Interval = "bogus"
+	if got := warmupSettingsFromConfig(cfg).interval; got != 0 {
+		t.Fatalf("interval = %s, want 0 for invalid value", got)
+	}
+
+	cfg.Warmup.Models = nil
+	if warmupSettingsFromConfig(cfg).enabled {
+		t.Fatal("expected warmup without models to be disabled")
+	}
+}
+
+func TestWarmModelsContinuesAfterFailure(t *testing.T) {
+	var warmed []string
+	warm := func(_ context.Context, model string) error {
+		warmed = append(warmed, model)
+		if model == "a" {
+			return errors.New("cold start failed")
+		}
+		return nil
+	}
+	warmModels(context.Background(), []string{"a", "b"}, warm)
+	if len(warmed) != 2 {
+		t.Fatalf("warmed = %v, want both models attempted", warmed)
+	}
+}
+
+func TestModelWarmerApplyRestartsOnlyOnChange(t *testing.T) {
+	w := &modelWarmer{}
+	warm := func(context.Context, string) error { return nil }
+	settings := warmupSettings{enabled: true, models: []string{"a"}, interval: time.Minute}
+
+	w.apply(settings, warm)
+	first := w.cancel
+	if first == nil {
+		t.Fatal("expected warm-up loop to start")
+	}
+	w.apply(warmupSettings{enabled: true, models: []string{"a"}, interval: time.Minute}, warm)
+	if w.cancel == nil {
+		t.Fatal("expected warm-up loop to keep running for identical settings")
+	}
+	w.apply(warmupSettings{}, nil)
+	if w.cancel != nil {
+		t.Fatal("expected warm-up loop to stop when disabled")
+	}
+}
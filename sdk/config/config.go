@@ -4,13 +4,42 @@
 // embed CLIProxyAPI without importing internal packages.
 package config
 
-import internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+import (
+	internalconfig "github.com/router-for-me/CLIProxyAPI/v7/internal/config"
+	"github.com/router-for-me/CLIProxyAPI/v7/internal/registry"
+)
 
 type SDKConfig = internalconfig.SDKConfig
 
 type Config = internalconfig.Config
 
 type StreamingConfig = internalconfig.StreamingConfig
+type ResponseHeadersConfig = internalconfig.ResponseHeadersConfig
+type ResponseHeaderRules = internalconfig.ResponseHeaderRules
+type ModelPricing = internalconfig.ModelPricing
+type RequestDedupConfig = internalconfig.RequestDedupConfig
+type StreamReplayConfig = internalconfig.StreamReplayConfig
+type ProfileConfig = internalconfig.ProfileConfig
+type ProfileModelMapping = internalconfig.ProfileModelMapping
+type VirtualModel = internalconfig.VirtualModel
+type VirtualModelCandidate = internalconfig.VirtualModelCandidate
+type JSONModeConfig = internalconfig.JSONModeConfig
+type AutoContinueConfig = internalconfig.AutoContinueConfig
+type LongContextConfig = internalconfig.LongContextConfig
+type ConversationCompressionConfig = internalconfig.ConversationCompressionConfig
+type MessageBatchesConfig = internalconfig.MessageBatchesConfig
+type ResponseCacheConfig = internalconfig.ResponseCacheConfig
+type ResponseCacheModelTTL = internalconfig.ResponseCacheModelTTL
+type EnsembleConfig = internalconfig.EnsembleConfig
+type PromptTemplatesConfig = internalconfig.PromptTemplatesConfig
+type PromptTemplate = internalconfig.PromptTemplate
+type PromptTemplateMessage = internalconfig.PromptTemplateMessage
+type OutputProcessorRule = internalconfig.OutputProcessorRule
+type APIKeyModelScope = internalconfig.APIKeyModelScope
+type APIKeyPriority = internalconfig.APIKeyPriority
+type APIKeyResidency = internalconfig.APIKeyResidency
+type APIKeyMetadata = internalconfig.APIKeyMetadata
+type AdmissionConfig = internalconfig.AdmissionConfig
 type TLSConfig = internalconfig.TLSConfig
 type RemoteManagement = internalconfig.RemoteManagement
 type OAuthModelAlias = internalconfig.OAuthModelAlias
@@ -32,11 +61,16 @@ type VertexCompatModel = internalconfig.VertexCompatModel
 type OpenAICompatibility = internalconfig.OpenAICompatibility
 type OpenAICompatibilityAPIKey = internalconfig.OpenAICompatibilityAPIKey
 type OpenAICompatibilityModel = internalconfig.OpenAICompatibilityModel
+type OpenAICompatibilityQuirks = internalconfig.OpenAICompatibilityQuirks
 
 type TLS = internalconfig.TLSConfig
 
 const (
 	DefaultPanelGitHubRepository = internalconfig.DefaultPanelGitHubRepository
+
+	PriorityHigh   = internalconfig.PriorityHigh
+	PriorityNormal = internalconfig.PriorityNormal
+	PriorityBatch  = internalconfig.PriorityBatch
 )
 
 func LoadConfig(configFile string) (*Config, error) { return internalconfig.LoadConfig(configFile) }
@@ -82,3 +116,7 @@ func FindManagedProviderByPrefix(cfg *SDKConfig, model string) (ManagedProviderC
 func FindManagedProviderByProtocolPrefix(cfg *SDKConfig, model string) (ManagedProviderConfig, string, string, bool) {
 	return internalconfig.FindManagedProviderByProtocolPrefix(cfg, model)
 }
+
+func ModelRoutingPrefixes(cfg *SDKConfig) []registry.RoutingPrefix {
+	return internalconfig.ModelRoutingPrefixes(cfg)
+}
@@ -1276,6 +1276,8 @@ type UsageRecord struct {
 	ReasoningEffort string
 	// ServiceTier records the requested or reported service tier.
 	ServiceTier string
+	// Tags are the client-supplied request tags, e.g. {"team": "infra"}.
+	Tags map[string]string
 	// RequestedAt is the time the request was received.
 	RequestedAt time.Time
 	// Latency is the total request latency.
@@ -15,6 +15,8 @@ type Registry struct {
 	requests  map[Format]map[Format]RequestTransform
 	responses map[Format]map[Format]ResponseTransform
 	hooks     PluginHooks
+	// system caches translated system prompts shared by repeated requests.
+	system *systemPromptCache
 }
 
 // NewRegistry constructs an empty translator registry.
@@ -22,6 +24,7 @@ func NewRegistry() *Registry {
 	return &Registry{
 		requests:  make(map[Format]map[Format]RequestTransform),
 		responses: make(map[Format]map[Format]ResponseTransform),
+		system:    newSystemPromptCache(),
 	}
 }
 
@@ -51,6 +54,13 @@ func (r *Registry) SetPluginHooks(hooks PluginHooks) {
 	r.hooks = hooks
 }
 
+// HasPluginHooks reports whether translator plugin hooks are installed on this registry.
+func (r *Registry) HasPluginHooks() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.hooks != nil
+}
+
 // TranslateRequest converts a payload between schemas, returning the original payload
 // if no translator is registered. When falling back to the original payload, the
 // "model" field is still updated to match the resolved model name so that
@@ -66,7 +76,7 @@ func (r *Registry) TranslateRequest(from, to Format, model string, rawJSON []byt
 
 	body := rawJSON
 	if fn != nil {
-		body = fn(model, body, stream)
+		body = r.system.translate(fn, from, to, model, body, stream)
 	} else {
 		if model != "" && gjson.GetBytes(body, "model").String() != model {
 			if updated, err := sjson.SetBytes(body, "model", model); err != nil {
@@ -233,6 +243,11 @@ func SetPluginHooks(hooks PluginHooks) {
 	defaultRegistry.SetPluginHooks(hooks)
 }
 
+// HasPluginHooks reports whether plugin hooks are installed on the default registry.
+func HasPluginHooks() bool {
+	return defaultRegistry.HasPluginHooks()
+}
+
 // TranslateRequest is a helper on the default registry.
 func TranslateRequest(from, to Format, model string, rawJSON []byte, stream bool) []byte {
 	return defaultRegistry.TranslateRequest(from, to, model, rawJSON, stream)
@@ -0,0 +1,207 @@
+package translator
+
+import (
+	"bytes"
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"sync"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+const (
+	// systemCacheMinBytes is the smallest system prompt worth caching; shorter prompts translate
+	// faster than they hash.
+	systemCacheMinBytes = 1024
+	systemCacheEntries  = 256
+	systemCacheMaxBytes = 16 << 20
+
+	systemPlaceholderPrefix = "cliproxy-system-fragment:"
+)
+
+// systemCacheKey identifies one translated system prompt.
+type systemCacheKey struct {
+	from, to Format
+	model    string
+	stream   bool
+	hash     string
+}
+
+// systemCacheEntry is the translated form of a system prompt. template is what the target
+// fragment looks like when the prompt is replaced by its placeholder; a request may reuse
+// translated only when its placeholder translation yields the same template. Entries without a
+// path record prompts whose translation is not self-contained, so they are never spliced.
+type systemCacheEntry struct {
+	key        systemCacheKey
+	path       string
+	template   string
+	translated string
+}
+
+func (e *systemCacheEntry) size() int {
+	return len(e.template) + len(e.translated) + len(e.key.model) + len(e.key.hash)
+}
+
+// systemPromptCache is a bounded LRU of translated system prompts. Agent workloads resend the
+// same large system prompt on every turn; with the cache the translator only sees a short
+// placeholder and the stored translation is spliced back into its output.
+type systemPromptCache struct {
+	mu      sync.Mutex
+	entries map[systemCacheKey]*list.Element
+	order   *list.List
+	bytes   int
+}
+
+func newSystemPromptCache() *systemPromptCache {
+	return &systemPromptCache{entries: make(map[systemCacheKey]*list.Element), order: list.New()}
+}
+
+func (c *systemPromptCache) get(key systemCacheKey) (*systemCacheEntry, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*systemCacheEntry), true
+}
+
+func (c *systemPromptCache) put(entry *systemCacheEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if elem, ok := c.entries[entry.key]; ok {
+		c.bytes -= elem.Value.(*systemCacheEntry).size()
+		c.order.Remove(elem)
+	}
+	c.entries[entry.key] = c.order.PushFront(entry)
+	c.bytes += entry.size()
+	for c.order.Len() > systemCacheEntries || c.bytes > systemCacheMaxBytes {
+		oldest := c.order.Back()
+		if oldest == nil {
+			break
+		}
+		evicted := oldest.Value.(*systemCacheEntry)
+		c.order.Remove(oldest)
+		delete(c.entries, evicted.key)
+		c.bytes -= evicted.size()
+	}
+}
+
+// translate runs fn, reusing the cached translation of a large system prompt when possible.
+// The first request carrying a prompt is translated twice, with and without the prompt, to
+// prove that the prompt maps onto a single target fragment; later requests only translate the
+// remainder of the payload.
+func (c *systemPromptCache) translate(fn RequestTransform, from, to Format, model string, rawJSON []byte, stream bool) []byte {
+	if c == nil {
+		return fn(model, rawJSON, stream)
+	}
+	targetPaths := targetSystemPaths(to)
+	sourcePath := sourceSystemPath(from, rawJSON)
+	if len(targetPaths) == 0 || sourcePath == "" {
+		return fn(model, rawJSON, stream)
+	}
+	prompt := gjson.GetBytes(rawJSON, sourcePath)
+	if !prompt.Exists() || len(prompt.Raw) < systemCacheMinBytes {
+		return fn(model, rawJSON, stream)
+	}
+	sum := sha256.Sum256([]byte(prompt.Raw))
+	key := systemCacheKey{from: from, to: to, model: model, stream: stream, hash: hex.EncodeToString(sum[:16])}
+	placeholder := systemPlaceholderPrefix + key.hash
+	stripped, errStrip := sjson.SetBytes(bytes.Clone(rawJSON), sourcePath, placeholder)
+	if errStrip != nil {
+		return fn(model, rawJSON, stream)
+	}
+
+	if entry, ok := c.get(key); ok {
+		if entry.path == "" {
+			return fn(model, rawJSON, stream)
+		}
+		out := fn(model, stripped, stream)
+		if gjson.GetBytes(out, entry.path).Raw == entry.template {
+			spliced, errSplice := sjson.SetRawBytes(out, entry.path, []byte(entry.translated))
+			if errSplice == nil && !bytes.Contains(spliced, []byte(placeholder)) {
+				return spliced
+			}
+		}
+		return fn(model, rawJSON, stream)
+	}
+
+	// Translators may rewrite their input in place, so the placeholder run gets its own copy.
+	out := fn(model, bytes.Clone(stripped), stream)
+	full := fn(model, rawJSON, stream)
+	entry := &systemCacheEntry{key: key}
+	for _, path := range targetPaths {
+		fragment := gjson.GetBytes(out, path)
+		if !fragment.Exists() || !strings.Contains(fragment.Raw, placeholder) {
+			continue
+		}
+		translated := gjson.GetBytes(full, path)
+		if !translated.Exists() {
+			break
+		}
+		spliced, errSplice := sjson.SetRawBytes(out, path, []byte(translated.Raw))
+		if errSplice == nil && bytes.Equal(spliced, full) {
+			entry.path = path
+			entry.template = fragment.Raw
+			entry.translated = translated.Raw
+		}
+		break
+	}
+	c.put(entry)
+	return full
+}
+
+// sourceSystemPath returns where the system prompt lives in a request of the given format.
+func sourceSystemPath(format Format, rawJSON []byte) string {
+	switch format {
+	case FormatClaude:
+		return "system"
+	case FormatGemini:
+		if gjson.GetBytes(rawJSON, "systemInstruction").Exists() {
+			return "systemInstruction"
+		}
+		return "system_instruction"
+	case Format("gemini-cli"), FormatAntigravity:
+		if gjson.GetBytes(rawJSON, "request.systemInstruction").Exists() {
+			return "request.systemInstruction"
+		}
+		return "request.system_instruction"
+	case FormatOpenAI:
+		if isSystemRole(gjson.GetBytes(rawJSON, "messages.0.role").String()) {
+			return "messages.0.content"
+		}
+	case FormatOpenAIResponse, FormatCodex:
+		if gjson.GetBytes(rawJSON, "instructions").Exists() {
+			return "instructions"
+		}
+		if isSystemRole(gjson.GetBytes(rawJSON, "input.0.role").String()) {
+			return "input.0.content"
+		}
+	}
+	return ""
+}
+
+// targetSystemPaths lists where translators of the given format may place the system prompt.
+func targetSystemPaths(format Format) []string {
+	switch format {
+	case FormatClaude:
+		return []string{"system"}
+	case FormatGemini:
+		return []string{"systemInstruction", "system_instruction"}
+	case Format("gemini-cli"), FormatAntigravity:
+		return []string{"request.systemInstruction", "request.system_instruction"}
+	case FormatOpenAI:
+		return []string{"messages.0"}
+	case FormatOpenAIResponse, FormatCodex:
+		return []string{"instructions", "input.0"}
+	}
+	return nil
+}
+
+func isSystemRole(role string) bool {
+	return role == "system" || role == "developer"
+}
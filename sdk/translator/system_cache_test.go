@@ -0,0 +1,87 @@
+package translator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+	"github.com/tidwall/sjson"
+)
+
+func largeClaudeRequest(system, user string) []byte {
+	body, _ := sjson.SetBytes([]byte(`{"messages":[{"role":"user","content":""}]}`), "system", system)
+	body, _ = sjson.SetBytes(body, "messages.0.content", user)
+	return body
+}
+
+func TestRegistryTranslateRequestReusesTranslatedSystemPrompt(t *testing.T) {
+	registry := NewRegistry()
+	var seen []string
+	registry.Register(FormatClaude, FormatOpenAI, func(model string, rawJSON []byte, stream bool) []byte {
+		system := gjson.GetBytes(rawJSON, "system").String()
+		seen = append(seen, system)
+		out := []byte(`{"messages":[]}`)
+		out, _ = sjson.SetBytes(out, "messages.-1", map[string]string{"role": "system", "content": "Instructions: " + system})
+		out, _ = sjson.SetBytes(out, "messages.-1", map[string]string{"role": "user", "content": gjson.GetBytes(rawJSON, "messages.0.content").String()})
+		return out
+	}, ResponseTransform{})
+
+	system := strings.Repeat("be precise. ", 200)
+	first := registry.TranslateRequest(FormatClaude, FormatOpenAI, "m", largeClaudeRequest(system, "one"), false)
+	second := registry.TranslateRequest(FormatClaude, FormatOpenAI, "m", largeClaudeRequest(system, "two"), false)
+
+	if got := gjson.GetBytes(second, "messages.0.content").String(); got != "Instructions: "+system {
+		t.Fatalf("second system prompt = %.40q..., want the translated prompt", got)
+	}
+	if got := gjson.GetBytes(second, "messages.1.content").String(); got != "two" {
+		t.Fatalf("second user message = %q, want %q", got, "two")
+	}
+	if gjson.GetBytes(first, "messages.0.content").String() != "Instructions: "+system {
+		t.Fatalf("first translation lost the system prompt: %s", first)
+	}
+	// One full and one placeholder translation fill the cache, then the hit only sees the placeholder.
+	if len(seen) != 3 || seen[0] == system || seen[1] != system || seen[2] == system {
+		t.Fatalf("translator saw %d payloads, want placeholder, full, placeholder", len(seen))
+	}
+}
+
+func TestRegistryTranslateRequestSkipsSystemPromptSpreadAcrossPayload(t *testing.T) {
+	registry := NewRegistry()
+	calls := 0
+	registry.Register(FormatClaude, FormatOpenAI, func(model string, rawJSON []byte, stream bool) []byte {
+		calls++
+		system := gjson.GetBytes(rawJSON, "system").String()
+		out, _ := sjson.SetBytes([]byte(`{}`), "messages.0", map[string]string{"role": "system", "content": system})
+		// The prompt also influences a field outside the system message.
+		out, _ = sjson.SetBytes(out, "metadata.prompt_length", len(system))
+		return out
+	}, ResponseTransform{})
+
+	system := strings.Repeat("be precise. ", 200)
+	for i := 0; i < 3; i++ {
+		out := registry.TranslateRequest(FormatClaude, FormatOpenAI, "m", largeClaudeRequest(system, "hi"), false)
+		if got := gjson.GetBytes(out, "metadata.prompt_length").Int(); got != int64(len(system)) {
+			t.Fatalf("prompt_length = %d, want %d", got, len(system))
+		}
+	}
+	// The first request proves the prompt is not cacheable; later ones translate once each.
+	if calls != 4 {
+		t.Fatalf("translator calls = %d, want 4", calls)
+	}
+}
+
+func TestSystemPromptCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	cache := newSystemPromptCache()
+	for i := 0; i <= systemCacheEntries; i++ {
+		cache.put(&systemCacheEntry{key: systemCacheKey{hash: strings.Repeat("x", i)}})
+	}
+	if _, ok := cache.get(systemCacheKey{hash: ""}); ok {
+		t.Fatal("oldest entry survived eviction")
+	}
+	if _, ok := cache.get(systemCacheKey{hash: strings.Repeat("x", systemCacheEntries)}); !ok {
+		t.Fatal("newest entry was evicted")
+	}
+	if cache.order.Len() != systemCacheEntries {
+		t.Fatalf("cache holds %d entries, want %d", cache.order.Len(), systemCacheEntries)
+	}
+}